@@ -2,7 +2,10 @@ package api
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 // Agent encapsulates an API client which talks to Nomad's
@@ -135,6 +138,16 @@ func (a *Agent) ForceLeave(node string) error {
 	return err
 }
 
+// Reload makes the agent reload its configuration files, as done when it
+// receives a SIGHUP, and reports the settings that were changed.
+func (a *Agent) Reload() (*AgentReloadResult, error) {
+	var resp AgentReloadResult
+	if _, err := a.client.write("/v1/agent/reload", nil, &resp, nil); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // Servers is used to query the list of servers on a client node.
 func (a *Agent) Servers() ([]string, error) {
 	var resp []string
@@ -157,6 +170,46 @@ func (a *Agent) SetServers(addrs []string) error {
 	return err
 }
 
+// Metrics is used to query the metrics retained in memory by the agent over
+// the last intervals.
+func (a *Agent) Metrics() ([]*AgentMetricsInterval, error) {
+	var resp []*AgentMetricsInterval
+	_, err := a.client.query("/v1/agent/metrics", &resp, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Logs is used to query the most recent logs of the agent.
+func (a *Agent) Logs() ([]string, error) {
+	var resp []string
+	_, err := a.client.query("/v1/agent/logs", &resp, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Profile is used to capture a runtime profile of the agent in the pprof
+// format. The "cpu" profile and the "trace" are collected over the given number
+// of seconds, while debug sets the format of the other profiles, such as the
+// "goroutine" dump. The agent must have enable_debug set.
+func (a *Agent) Profile(profile string, seconds, debug int) ([]byte, error) {
+	q := &QueryOptions{Params: map[string]string{
+		"debug": strconv.Itoa(debug),
+	}}
+	if seconds > 0 {
+		q.Params["seconds"] = strconv.Itoa(seconds)
+	}
+	body, err := a.client.rawQuery("/v1/agent/pprof/"+profile, q)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
 // joinResponse is used to decode the response we get while
 // sending a member join request.
 type joinResponse struct {
@@ -179,6 +232,40 @@ type AgentMember struct {
 	DelegateCur uint8
 }
 
+// AgentReloadResult reports the settings changed by reloading the
+// configuration of an agent
+type AgentReloadResult struct {
+	// Reloaded lists the changed settings which were applied
+	Reloaded []string
+
+	// RestartRequired lists the changed settings which only take effect once
+	// the agent is restarted
+	RestartRequired []string
+
+	// Errors lists the failures to apply changed settings
+	Errors []string
+}
+
+// AgentMetricsInterval holds the metrics of an agent aggregated during an
+// interval
+type AgentMetricsInterval struct {
+	Interval time.Time
+	Gauges   map[string]float32
+	Points   map[string][]float32
+	Counters map[string]AgentMetricsSample
+	Samples  map[string]AgentMetricsSample
+}
+
+// AgentMetricsSample is the rolled up view of the values of a metric
+type AgentMetricsSample struct {
+	Count       int
+	Sum         float64
+	SumSq       float64
+	Min         float64
+	Max         float64
+	LastUpdated time.Time
+}
+
 // AgentMembersNameSort implements sort.Interface for []*AgentMembersNameSort
 // based on the Name, DC and Region
 type AgentMembersNameSort []*AgentMember
@@ -3,6 +3,7 @@ package api
 import (
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/nomad/testutil"
@@ -111,6 +112,80 @@ func TestAgent_Members(t *testing.T) {
 	}
 }
 
+func TestAgent_Metrics(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Agent()
+
+	if _, err := a.Metrics(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestAgent_Logs(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Agent()
+
+	// The startup of the agent is logged
+	logs, err := a.Logs()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(logs) == 0 {
+		t.Fatalf("expected logs")
+	}
+}
+
+func TestAgent_Profile(t *testing.T) {
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.EnableDebug = true
+	})
+	defer s.Stop()
+	a := c.Agent()
+
+	out, err := a.Profile("goroutine", 0, 2)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(string(out), "goroutine ") {
+		t.Fatalf("bad: %s", out)
+	}
+
+	out, err = a.Profile("cpu", 1, 0)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(out) == 0 {
+		t.Fatalf("empty profile")
+	}
+}
+
+func TestAgent_Profile_Disabled(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Agent()
+
+	if _, err := a.Profile("goroutine", 0, 0); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestAgent_Reload(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Agent()
+
+	// Reloading the unchanged configuration reports no change
+	result, err := a.Reload()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(result.Reloaded) != 0 || len(result.RestartRequired) != 0 || len(result.Errors) != 0 {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
 func TestAgent_ForceLeave(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
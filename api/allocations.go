@@ -1,11 +1,11 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"sort"
 	"time"
-
-	"github.com/hashicorp/go-cleanhttp"
 )
 
 // Allocations is used to query the alloc-related endpoints.
@@ -33,6 +33,28 @@ func (a *Allocations) PrefixList(prefix string) ([]*AllocationListStub, *QueryMe
 	return a.List(&QueryOptions{Prefix: prefix})
 }
 
+// AllocationListFilter filters the allocations listed by the servers. Empty
+// fields don't filter.
+type AllocationListFilter struct {
+	JobID         string
+	NodeID        string
+	TaskGroup     string
+	ClientStatus  string
+	DesiredStatus string
+}
+
+// ListFiltered returns the allocations passing the filter, filtered by the
+// servers.
+func (a *Allocations) ListFiltered(filter *AllocationListFilter, q *QueryOptions) ([]*AllocationListStub, *QueryMeta, error) {
+	return a.List(withParams(q, map[string]string{
+		"job":            filter.JobID,
+		"node":           filter.NodeID,
+		"task_group":     filter.TaskGroup,
+		"client_status":  filter.ClientStatus,
+		"desired_status": filter.DesiredStatus,
+	}))
+}
+
 // Info is used to retrieve a single allocation.
 func (a *Allocations) Info(allocID string, q *QueryOptions) (*Allocation, *QueryMeta, error) {
 	var resp Allocation
@@ -48,13 +70,14 @@ func (a *Allocations) Stats(alloc *Allocation, q *QueryOptions) (*AllocResourceU
 	if err != nil {
 		return nil, err
 	}
-	if node.HTTPAddr == "" {
+	addr, err := a.client.resolveNodeAddr(node, node.HTTPAddr)
+	if err != nil {
+		return nil, err
+	}
+	if addr == "" {
 		return nil, fmt.Errorf("http addr of the node where alloc %q is running is not advertised", alloc.ID)
 	}
-	client, err := NewClient(&Config{
-		Address:    fmt.Sprintf("http://%s", node.HTTPAddr),
-		HttpClient: cleanhttp.DefaultClient(),
-	})
+	client, err := a.client.nodeClient(addr)
 	if err != nil {
 		return nil, err
 	}
@@ -63,11 +86,101 @@ func (a *Allocations) Stats(alloc *Allocation, q *QueryOptions) (*AllocResourceU
 	return &resp, err
 }
 
+// Events returns the events of the tasks of an allocation ordered by time.
+func (a *Allocations) Events(allocID string, q *QueryOptions) ([]*AllocTaskEvent, *QueryMeta, error) {
+	var resp []*AllocTaskEvent
+	qm, err := a.client.query("/v1/allocation/"+allocID+"/events", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// StreamEvents streams the events of the tasks of an allocation, starting
+// with the events already recorded. The returned channel is closed once the
+// allocation terminates on its client or when cancel is closed.
+func (a *Allocations) StreamEvents(allocID string, cancel <-chan struct{}, q *QueryOptions) (<-chan *AllocTaskEvent, error) {
+	if q == nil {
+		q = &QueryOptions{}
+	}
+	if q.Params == nil {
+		q.Params = make(map[string]string)
+	}
+	q.Params["follow"] = "true"
+
+	r, err := a.client.rawQuery("/v1/allocation/"+allocID+"/events", q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Close the body when cancelled to interrupt the decoding
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-cancel:
+			r.Close()
+		case <-done:
+		}
+	}()
+
+	events := make(chan *AllocTaskEvent, 10)
+	go func() {
+		defer r.Close()
+		defer close(done)
+		defer close(events)
+
+		// The decoder skips the empty heartbeat lines
+		dec := json.NewDecoder(r)
+		for {
+			var event AllocTaskEvent
+			if err := dec.Decode(&event); err != nil {
+				return
+			}
+
+			select {
+			case events <- &event:
+			case <-cancel:
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// Restart restarts the given task of an allocation in place, or all of its
+// tasks if taskName is empty. The allocation is not rescheduled.
+func (a *Allocations) Restart(alloc *Allocation, taskName string, q *WriteOptions) error {
+	node, _, err := a.client.Nodes().Info(alloc.NodeID, nil)
+	if err != nil {
+		return err
+	}
+	addr, err := a.client.resolveNodeAddr(node, node.HTTPAddr)
+	if err != nil {
+		return err
+	}
+	if addr == "" {
+		return fmt.Errorf("http addr of the node where alloc %q is running is not advertised", alloc.ID)
+	}
+	client, err := a.client.nodeClient(addr)
+	if err != nil {
+		return err
+	}
+
+	endpoint := "/v1/client/allocation/" + alloc.ID + "/restart"
+	if taskName != "" {
+		endpoint += "?task=" + url.QueryEscape(taskName)
+	}
+	_, err = client.write(endpoint, nil, nil, q)
+	return err
+}
+
 // Allocation is used for serialization of allocations.
 type Allocation struct {
 	ID                 string
 	EvalID             string
 	Name               string
+	Namespace          string
 	NodeID             string
 	JobID              string
 	Job                *Job
@@ -81,9 +194,37 @@ type Allocation struct {
 	ClientStatus       string
 	ClientDescription  string
 	TaskStates         map[string]*TaskState
+	DeploymentID       string
+	DeploymentStatus   *AllocDeploymentStatus
+	RescheduleTracker  *RescheduleTracker
 	CreateIndex        uint64
 	ModifyIndex        uint64
 	CreateTime         int64
+
+	PreemptedAllocations  []string
+	PreemptedByAllocation string
+}
+
+// RescheduleTracker tracks the attempts to reschedule the failed allocations
+// that the allocation replaces.
+type RescheduleTracker struct {
+	Events []*RescheduleEvent
+}
+
+// RescheduleEvent records the rescheduling of a failed allocation.
+type RescheduleEvent struct {
+	RescheduleTime int64
+	PrevAllocID    string
+	PrevNodeID     string
+	Delay          time.Duration
+	SetupFailure   bool
+}
+
+// AllocDeploymentStatus captures the health of an allocation placed by a
+// deployment. Healthy is nil until the health has been determined.
+type AllocDeploymentStatus struct {
+	Healthy *bool
+	Canary  bool
 }
 
 // AllocationMetric is used to deserialize allocation metrics.
@@ -115,6 +256,7 @@ type AllocationListStub struct {
 	ClientStatus       string
 	ClientDescription  string
 	TaskStates         map[string]*TaskState
+	DeploymentStatus   *AllocDeploymentStatus
 	CreateIndex        uint64
 	ModifyIndex        uint64
 	CreateTime         int64
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"sort"
 	"testing"
@@ -115,3 +118,86 @@ func TestAllocations_CreateIndexSort(t *testing.T) {
 		t.Fatalf("\n\n%#v\n\n%#v", allocs, expect)
 	}
 }
+
+func TestAllocations_ListFiltered(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		query = req.URL.Query()
+		resp.Header().Set("X-Nomad-Index", "1")
+		resp.Header().Set("X-Nomad-LastContact", "0")
+		resp.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	q := &QueryOptions{Params: map[string]string{"foo": "bar"}}
+	filter := &AllocationListFilter{JobID: "example", ClientStatus: "running"}
+	if _, _, err := c.Allocations().ListFiltered(filter, q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The set filters are sent along the params of the options
+	expected := url.Values{
+		"job":           []string{"example"},
+		"client_status": []string{"running"},
+		"foo":           []string{"bar"},
+	}
+	if !reflect.DeepEqual(query, expected) {
+		t.Fatalf("bad query: %v", query)
+	}
+
+	// The options passed in are left untouched
+	if len(q.Params) != 1 {
+		t.Fatalf("options modified: %v", q.Params)
+	}
+}
+
+func TestAllocations_List_Pagination(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		query = req.URL.Query()
+		resp.Header().Set("X-Nomad-Index", "1")
+		resp.Header().Set("X-Nomad-LastContact", "0")
+		resp.Header().Set("X-Nomad-NextToken", "bbbb")
+		resp.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	_, qm, err := c.Allocations().List(&QueryOptions{PerPage: 10, NextToken: "aaaa"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if query.Get("per_page") != "10" || query.Get("next_token") != "aaaa" {
+		t.Fatalf("bad query: %v", query)
+	}
+	if qm.NextToken != "bbbb" {
+		t.Fatalf("bad next token: %q", qm.NextToken)
+	}
+}
+
+func TestAllocations_Events_Unknown(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Allocations()
+
+	// Querying the events of an unknown allocation fails
+	if _, _, err := a.Events("8c9b3ac8-5ce5-4d6b-a1e1-9b2c5d4cf8a1", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := a.StreamEvents("8c9b3ac8-5ce5-4d6b-a1e1-9b2c5d4cf8a1", nil, nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -3,9 +3,13 @@ package api
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -14,6 +18,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-rootcerts"
 )
 
 // QueryOptions are used to parameterize a query
@@ -22,6 +27,10 @@ type QueryOptions struct {
 	// by the Config
 	Region string
 
+	// Namespace is the namespace to query. Overrides the namespace provided
+	// by the Config
+	Namespace string
+
 	// AllowStale allows any Nomad server (non-leader) to service
 	// a read. This allows for lower latency and higher throughput
 	AllowStale bool
@@ -39,6 +48,19 @@ type QueryOptions struct {
 
 	// Set HTTP parameters on the query.
 	Params map[string]string
+
+	// Timeout bounds each attempt of the request, including reading its
+	// response. Overrides the timeout of the Config.
+	Timeout time.Duration
+
+	// PerPage is the maximum number of objects returned by the job,
+	// allocation, evaluation and node lists. All of them are returned if
+	// zero.
+	PerPage int32
+
+	// NextToken resumes a paginated list from the NextToken of the QueryMeta
+	// of the previous page.
+	NextToken string
 }
 
 // WriteOptions are used to parameterize a write
@@ -46,6 +68,14 @@ type WriteOptions struct {
 	// Providing a datacenter overwrites the region provided
 	// by the Config
 	Region string
+
+	// Namespace is the namespace to write to. Overrides the namespace
+	// provided by the Config
+	Namespace string
+
+	// Timeout bounds the request, including reading its response. Overrides
+	// the timeout of the Config.
+	Timeout time.Duration
 }
 
 // QueryMeta is used to return meta data about a query
@@ -63,6 +93,10 @@ type QueryMeta struct {
 
 	// How long did the request take
 	RequestTime time.Duration
+
+	// NextToken is the token to list the next page of a paginated list. It
+	// is empty on the last page.
+	NextToken string
 }
 
 // WriteMeta is used to return meta data about a write
@@ -92,8 +126,13 @@ type Config struct {
 	// Region to use. If not provided, the default agent region is used.
 	Region string
 
+	// Namespace to use. If not provided, the default namespace is used.
+	Namespace string
+
 	// HttpClient is the client to use. Default will be
-	// used if not provided.
+	// used if not provided. Its Transport may be any http.RoundTripper,
+	// unless TLS or a unix socket address is configured which require an
+	// *http.Transport.
 	HttpClient *http.Client
 
 	// HttpAuth is the auth info to use for http access.
@@ -102,8 +141,86 @@ type Config struct {
 	// WaitTime limits how long a Watch will block. If not provided,
 	// the agent default values will be used.
 	WaitTime time.Duration
+
+	// NodeAddressResolver resolves the addresses advertised by nodes when
+	// making requests directly to a node, such as to the AllocFS and stats
+	// endpoints. If not provided, the advertised address is dialed.
+	NodeAddressResolver NodeAddressResolver
+
+	// TLSConfig provides the various TLS related configurations for the
+	// HTTP client.
+	TLSConfig *TLSConfig
+
+	// RetryMax is the number of times the GET requests are retried after a
+	// connection error or a retryable response, a 5xx or 429 status.
+	// Requests modifying the cluster state are never retried. Zero disables
+	// the retries.
+	RetryMax int
+
+	// RetryWaitMin and RetryWaitMax bound the exponential backoff between
+	// the retries. They default to 100ms and 5s.
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// Timeout bounds each attempt of the requests, including reading their
+	// responses, so it must be longer than the WaitTime of blocking queries
+	// and the streams followed. Zero means no timeout.
+	Timeout time.Duration
+}
+
+const (
+	// defaultRetryWaitMin and defaultRetryWaitMax are the default bounds of
+	// the backoff between the retries of a request
+	defaultRetryWaitMin = 100 * time.Millisecond
+	defaultRetryWaitMax = 5 * time.Second
+)
+
+// TLSConfig contains the parameters needed to configure TLS on the HTTP client
+// used to communicate with Nomad.
+type TLSConfig struct {
+	// CACert is the path to a PEM-encoded CA cert file to use to verify the
+	// Nomad server SSL certificate.
+	CACert string
+
+	// CAPath is the path to a directory of PEM-encoded CA cert files to verify
+	// the Nomad server SSL certificate.
+	CAPath string
+
+	// ClientCert is the path to the certificate for Nomad communication
+	ClientCert string
+
+	// ClientKey is the path to the private key for Nomad communication
+	ClientKey string
+
+	// TLSServerName, if set, is used to set the SNI host when connecting via
+	// TLS.
+	TLSServerName string
+
+	// Insecure enables or disables SSL verification
+	Insecure bool
+}
+
+// Copy returns a copy of the TLS configuration.
+func (t *TLSConfig) Copy() *TLSConfig {
+	if t == nil {
+		return nil
+	}
+
+	nt := new(TLSConfig)
+	*nt = *t
+	return nt
 }
 
+// NodeAddressResolver returns the address to dial to reach the given address
+// advertised by a node, for example to route it through a bastion or to
+// rewrite it to an internal DNS name. The returned address may include a
+// scheme, otherwise http is used.
+type NodeAddressResolver func(node *Node, addr string) (string, error)
+
+// unixSocketScheme is the scheme of the addresses of agents listening on a
+// unix domain socket, such as unix:///var/run/nomad.sock
+const unixSocketScheme = "unix"
+
 // DefaultConfig returns a default configuration for the client
 func DefaultConfig() *Config {
 	config := &Config{
@@ -128,9 +245,122 @@ func DefaultConfig() *Config {
 			Password: password,
 		}
 	}
+
+	// Read TLS specific env vars
+	if v := os.Getenv("NOMAD_CACERT"); v != "" {
+		config.TLSConfig = ensureTLSConfig(config.TLSConfig)
+		config.TLSConfig.CACert = v
+	}
+	if v := os.Getenv("NOMAD_CAPATH"); v != "" {
+		config.TLSConfig = ensureTLSConfig(config.TLSConfig)
+		config.TLSConfig.CAPath = v
+	}
+	if v := os.Getenv("NOMAD_CLIENT_CERT"); v != "" {
+		config.TLSConfig = ensureTLSConfig(config.TLSConfig)
+		config.TLSConfig.ClientCert = v
+	}
+	if v := os.Getenv("NOMAD_CLIENT_KEY"); v != "" {
+		config.TLSConfig = ensureTLSConfig(config.TLSConfig)
+		config.TLSConfig.ClientKey = v
+	}
+	if v := os.Getenv("NOMAD_TLS_SERVER_NAME"); v != "" {
+		config.TLSConfig = ensureTLSConfig(config.TLSConfig)
+		config.TLSConfig.TLSServerName = v
+	}
+	if v := os.Getenv("NOMAD_SKIP_VERIFY"); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			insecure = false
+		}
+		config.TLSConfig = ensureTLSConfig(config.TLSConfig)
+		config.TLSConfig.Insecure = insecure
+	}
 	return config
 }
 
+// ensureTLSConfig returns the given TLS configuration, or a new one if nil.
+func ensureTLSConfig(t *TLSConfig) *TLSConfig {
+	if t == nil {
+		return &TLSConfig{}
+	}
+	return t
+}
+
+// ConfigureTLS applies the TLS configuration to the transport of the HTTP
+// client. It is a no-op if no TLS configuration is set.
+func (c *Config) ConfigureTLS() error {
+	if c.TLSConfig == nil {
+		return nil
+	}
+	if c.HttpClient == nil {
+		return fmt.Errorf("config HTTP Client must be set")
+	}
+	transport, ok := c.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("config HTTP Client transport must be an *http.Transport")
+	}
+
+	var clientCert tls.Certificate
+	foundClientCert := false
+	if c.TLSConfig.ClientCert != "" || c.TLSConfig.ClientKey != "" {
+		if c.TLSConfig.ClientCert == "" || c.TLSConfig.ClientKey == "" {
+			return fmt.Errorf("Both client cert and client key must be provided")
+		}
+		var err error
+		clientCert, err = tls.LoadX509KeyPair(c.TLSConfig.ClientCert, c.TLSConfig.ClientKey)
+		if err != nil {
+			return err
+		}
+		foundClientCert = true
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	clientTLSConfig := transport.TLSClientConfig
+	rootConfig := &rootcerts.Config{
+		CAFile: c.TLSConfig.CACert,
+		CAPath: c.TLSConfig.CAPath,
+	}
+	if err := rootcerts.ConfigureTLS(clientTLSConfig, rootConfig); err != nil {
+		return err
+	}
+
+	clientTLSConfig.InsecureSkipVerify = c.TLSConfig.Insecure
+	if foundClientCert {
+		clientTLSConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	if c.TLSConfig.TLSServerName != "" {
+		clientTLSConfig.ServerName = c.TLSConfig.TLSServerName
+	}
+	return nil
+}
+
+// unixSocketPath returns the path of the unix domain socket named by the
+// address, if it names one
+func unixSocketPath(address string) (string, bool) {
+	u, err := url.Parse(address)
+	if err != nil || u.Scheme != unixSocketScheme {
+		return "", false
+	}
+	return u.Host + u.Path, true
+}
+
+// configureUnixSocket makes the transport of the HTTP client dial the unix
+// domain socket at the path for all the requests.
+func (c *Config) configureUnixSocket(path string) error {
+	transport, ok := c.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("config HTTP Client transport must be an *http.Transport")
+	}
+
+	transport.Proxy = nil
+	transport.Dial = func(_, _ string) (net.Conn, error) {
+		return net.Dial("unix", path)
+	}
+	return nil
+}
+
 // Client provides a client to the Nomad API
 type Client struct {
 	config Config
@@ -151,25 +381,111 @@ func NewClient(config *Config) (*Client, error) {
 		config.HttpClient = defConfig.HttpClient
 	}
 
+	// Configure the TLS configurations
+	if err := config.ConfigureTLS(); err != nil {
+		return nil, err
+	}
+
+	// Dial the unix domain socket of the agent if the address names one
+	if path, ok := unixSocketPath(config.Address); ok {
+		if err := config.configureUnixSocket(path); err != nil {
+			return nil, err
+		}
+	}
+
 	client := &Client{
 		config: *config,
 	}
 	return client, nil
 }
 
+// resolveNodeAddr returns the URL to dial to reach the given address
+// advertised by the node, resolved by the NodeAddressResolver if one is
+// configured. It returns an empty string if the node can't be reached.
+func (c *Client) resolveNodeAddr(node *Node, addr string) (string, error) {
+	if resolver := c.config.NodeAddressResolver; resolver != nil {
+		resolved, err := resolver(node, addr)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve address of node %q: %v", node.ID, err)
+		}
+		addr = resolved
+	}
+
+	if addr == "" {
+		return "", nil
+	}
+	if !strings.Contains(addr, "://") {
+		scheme := "http://"
+		if node.TLSEnabled {
+			scheme = "https://"
+		}
+		addr = scheme + addr
+	}
+	return addr, nil
+}
+
+// nodeClient returns a client dialing the given node address, presenting the
+// TLS configuration of the client to nodes serving HTTPS.
+func (c *Client) nodeClient(addr string) (*Client, error) {
+	config := &Config{
+		Address:    addr,
+		Region:     c.config.Region,
+		HttpClient: cleanhttp.DefaultClient(),
+		TLSConfig:  c.config.TLSConfig.Copy(),
+
+		RetryMax:     c.config.RetryMax,
+		RetryWaitMin: c.config.RetryWaitMin,
+		RetryWaitMax: c.config.RetryWaitMax,
+		Timeout:      c.config.Timeout,
+	}
+
+	// Nodes present certificates valid for client.<region>.nomad
+	if config.TLSConfig != nil && strings.HasPrefix(addr, "https://") {
+		region := config.Region
+		if region == "" {
+			region = "global"
+		}
+		config.TLSConfig.TLSServerName = "client." + region + ".nomad"
+	}
+	return NewClient(config)
+}
+
+// GetNodeClient returns a client dialing the HTTP address advertised by the
+// given node, to query its agent directly.
+func (c *Client) GetNodeClient(nodeID string, q *QueryOptions) (*Client, error) {
+	node, _, err := c.Nodes().Info(nodeID, q)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := c.resolveNodeAddr(node, node.HTTPAddr)
+	if err != nil {
+		return nil, err
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("http addr of the node %q is not advertised", nodeID)
+	}
+	return c.nodeClient(addr)
+}
+
 // SetRegion sets the region to forward API requests to.
 func (c *Client) SetRegion(region string) {
 	c.config.Region = region
 }
 
+// SetNamespace sets the namespace of the API requests.
+func (c *Client) SetNamespace(namespace string) {
+	c.config.Namespace = namespace
+}
+
 // request is used to help build up a request
 type request struct {
-	config *Config
-	method string
-	url    *url.URL
-	params url.Values
-	body   io.Reader
-	obj    interface{}
+	config  *Config
+	method  string
+	url     *url.URL
+	params  url.Values
+	body    io.Reader
+	obj     interface{}
+	timeout time.Duration
 }
 
 // setQueryOptions is used to annotate the request with
@@ -181,6 +497,9 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.Region != "" {
 		r.params.Set("region", q.Region)
 	}
+	if q.Namespace != "" {
+		r.params.Set("namespace", q.Namespace)
+	}
 	if q.AllowStale {
 		r.params.Set("stale", "")
 	}
@@ -196,6 +515,36 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	for k, v := range q.Params {
 		r.params.Set(k, v)
 	}
+	if q.Timeout != 0 {
+		r.timeout = q.Timeout
+	}
+	if q.PerPage != 0 {
+		r.params.Set("per_page", strconv.FormatInt(int64(q.PerPage), 10))
+	}
+	if q.NextToken != "" {
+		r.params.Set("next_token", q.NextToken)
+	}
+}
+
+// withParams returns a copy of the query options with the non-empty params
+// added to them
+func withParams(q *QueryOptions, params map[string]string) *QueryOptions {
+	out := &QueryOptions{}
+	if q != nil {
+		*out = *q
+	}
+	out.Params = make(map[string]string, len(out.Params)+len(params))
+	if q != nil {
+		for k, v := range q.Params {
+			out.Params[k] = v
+		}
+	}
+	for k, v := range params {
+		if v != "" {
+			out.Params[k] = v
+		}
+	}
+	return out
 }
 
 // durToMsec converts a duration to a millisecond specified string
@@ -212,6 +561,18 @@ func (r *request) setWriteOptions(q *WriteOptions) {
 	if q.Region != "" {
 		r.params.Set("region", q.Region)
 	}
+	if q.Namespace != "" {
+		r.params.Set("namespace", q.Namespace)
+	}
+	if q.Timeout != 0 {
+		r.timeout = q.Timeout
+	}
+}
+
+// retryable returns whether the request can be sent again. Only the GET
+// requests without a body are, as they don't modify the cluster state.
+func (r *request) retryable() bool {
+	return r.method == "GET" && r.body == nil && r.obj == nil
 }
 
 // toHTTP converts the request to an HTTP request
@@ -254,20 +615,31 @@ func (r *request) toHTTP() (*http.Request, error) {
 func (c *Client) newRequest(method, path string) *request {
 	base, _ := url.Parse(c.config.Address)
 	u, _ := url.Parse(path)
+
+	// Requests to a unix domain socket are plain HTTP, the transport dials
+	// the socket whatever the host
+	scheme, host := base.Scheme, base.Host
+	if scheme == unixSocketScheme {
+		scheme, host = "http", "localhost"
+	}
 	r := &request{
 		config: &c.config,
 		method: method,
 		url: &url.URL{
-			Scheme: base.Scheme,
+			Scheme: scheme,
 			User:   base.User,
-			Host:   base.Host,
+			Host:   host,
 			Path:   u.Path,
 		},
-		params: make(map[string][]string),
+		params:  make(map[string][]string),
+		timeout: c.config.Timeout,
 	}
 	if c.config.Region != "" {
 		r.params.Set("region", c.config.Region)
 	}
+	if c.config.Namespace != "" {
+		r.params.Set("namespace", c.config.Namespace)
+	}
 	if c.config.WaitTime != 0 {
 		r.params.Set("wait", durToMsec(r.config.WaitTime))
 	}
@@ -302,15 +674,38 @@ func (m *multiCloser) Read(p []byte) (int, error) {
 	return m.reader.Read(p)
 }
 
-// doRequest runs a request with our client
+// cancelCloser wraps the body of a response to release the context bounding
+// its request once closed.
+type cancelCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// doRequest runs a request with our client, retrying it as configured
 func (c *Client) doRequest(r *request) (time.Duration, *http.Response, error) {
-	req, err := r.toHTTP()
+	retryable := r.retryable()
+	diff, resp, err := c.sendRequest(r)
+	for attempt := 0; retryable && attempt < c.config.RetryMax; attempt++ {
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			break
+		}
+
+		// Discard the failed response to reuse its connection
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		time.Sleep(c.retryBackoff(attempt))
+		diff, resp, err = c.sendRequest(r)
+	}
 	if err != nil {
-		return 0, nil, err
+		return diff, resp, err
 	}
-	start := time.Now()
-	resp, err := c.config.HttpClient.Do(req)
-	diff := time.Now().Sub(start)
 
 	// If the response is compressed, we swap the body's reader.
 	if resp != nil && resp.Header != nil {
@@ -337,6 +732,57 @@ func (c *Client) doRequest(r *request) (time.Duration, *http.Response, error) {
 	return diff, resp, err
 }
 
+// sendRequest makes a single attempt of the request, bounded by its timeout
+func (c *Client) sendRequest(r *request) (time.Duration, *http.Response, error) {
+	req, err := r.toHTTP()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var cancel context.CancelFunc
+	if r.timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(context.Background(), r.timeout)
+		req = req.WithContext(ctx)
+	}
+
+	start := time.Now()
+	resp, err := c.config.HttpClient.Do(req)
+	diff := time.Now().Sub(start)
+
+	// The timeout bounds reading the response as well, so the context is
+	// only released once its body is closed
+	if cancel != nil {
+		if err != nil {
+			cancel()
+		} else {
+			resp.Body = &cancelCloser{ReadCloser: resp.Body, cancel: cancel}
+		}
+	}
+	return diff, resp, err
+}
+
+// retryBackoff returns the duration to wait before the given retry attempt,
+// doubling from RetryWaitMin up to RetryWaitMax
+func (c *Client) retryBackoff(attempt int) time.Duration {
+	min, max := c.config.RetryWaitMin, c.config.RetryWaitMax
+	if min <= 0 {
+		min = defaultRetryWaitMin
+	}
+	if max <= 0 {
+		max = defaultRetryWaitMax
+	}
+
+	wait := min
+	for i := 0; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
 // rawQuery makes a GET request to the specified endpoint but returns just the
 // response body.
 func (c *Client) rawQuery(endpoint string, q *QueryOptions) (io.ReadCloser, error) {
@@ -350,6 +796,20 @@ func (c *Client) rawQuery(endpoint string, q *QueryOptions) (io.ReadCloser, erro
 	return resp.Body, nil
 }
 
+// rawWrite makes a PUT request to the specified endpoint but returns just the
+// response body.
+func (c *Client) rawWrite(endpoint string, in interface{}, q *WriteOptions) (io.ReadCloser, error) {
+	r := c.newRequest("PUT", endpoint)
+	r.setWriteOptions(q)
+	r.obj = in
+	_, resp, err := requireOK(c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
 // Query is used to do a GET request against an endpoint
 // and deserialize the response into an interface using
 // standard Nomad conventions.
@@ -442,6 +902,9 @@ func parseQueryMeta(resp *http.Response, q *QueryMeta) error {
 	default:
 		q.KnownLeader = false
 	}
+
+	// Parse the X-Nomad-NextToken
+	q.NextToken = header.Get("X-Nomad-NextToken")
 	return nil
 }
 
@@ -474,7 +937,8 @@ func encodeBody(obj interface{}) (io.Reader, error) {
 	return buf, nil
 }
 
-// requireOK is used to wrap doRequest and check for a 200
+// requireOK is used to wrap doRequest and check for a 200. Other status codes
+// are returned as an UnexpectedResponseError.
 func requireOK(d time.Duration, resp *http.Response, e error) (time.Duration, *http.Response, error) {
 	if e != nil {
 		if resp != nil {
@@ -486,7 +950,7 @@ func requireOK(d time.Duration, resp *http.Response, e error) (time.Duration, *h
 		var buf bytes.Buffer
 		io.Copy(&buf, resp.Body)
 		resp.Body.Close()
-		return d, nil, fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, buf.Bytes())
+		return d, nil, &UnexpectedResponseError{StatusCode: resp.StatusCode, Body: buf.String()}
 	}
 	return d, resp, nil
 }
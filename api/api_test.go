@@ -2,9 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -114,6 +119,57 @@ func TestDefaultConfig_env(t *testing.T) {
 	}
 }
 
+func TestDefaultConfig_TLSEnv(t *testing.T) {
+	os.Setenv("NOMAD_CACERT", "ca.pem")
+	defer os.Setenv("NOMAD_CACERT", "")
+	os.Setenv("NOMAD_CLIENT_CERT", "client.pem")
+	defer os.Setenv("NOMAD_CLIENT_CERT", "")
+	os.Setenv("NOMAD_CLIENT_KEY", "client-key.pem")
+	defer os.Setenv("NOMAD_CLIENT_KEY", "")
+	os.Setenv("NOMAD_SKIP_VERIFY", "true")
+	defer os.Setenv("NOMAD_SKIP_VERIFY", "")
+
+	config := DefaultConfig()
+	expected := &TLSConfig{
+		CACert:     "ca.pem",
+		ClientCert: "client.pem",
+		ClientKey:  "client-key.pem",
+		Insecure:   true,
+	}
+	if !reflect.DeepEqual(config.TLSConfig, expected) {
+		t.Fatalf("bad: %#v", config.TLSConfig)
+	}
+}
+
+func TestConfig_ConfigureTLS(t *testing.T) {
+	const fixtures = "../helper/tlsutil/test-fixtures/"
+	config := DefaultConfig()
+	config.TLSConfig = &TLSConfig{
+		CACert:        fixtures + "ca.pem",
+		ClientCert:    fixtures + "client.pem",
+		ClientKey:     fixtures + "client-key.pem",
+		TLSServerName: "server.global.nomad",
+	}
+	if err := config.ConfigureTLS(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tlsConfig := config.HttpClient.Transport.(*http.Transport).TLSClientConfig
+	if tlsConfig.RootCAs == nil || len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("bad: %#v", tlsConfig)
+	}
+	if tlsConfig.ServerName != "server.global.nomad" || tlsConfig.InsecureSkipVerify {
+		t.Fatalf("bad: %#v", tlsConfig)
+	}
+
+	// Both the client certificate and key must be set
+	config = DefaultConfig()
+	config.TLSConfig = &TLSConfig{ClientCert: fixtures + "client.pem"}
+	if err := config.ConfigureTLS(); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
 func TestSetQueryOptions(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -235,3 +291,200 @@ func TestQueryString(t *testing.T) {
 		t.Fatalf("bad uri: %q", uri)
 	}
 }
+
+func TestResolveNodeAddr(t *testing.T) {
+	node := &Node{ID: "foo", HTTPAddr: "10.0.0.1:4646"}
+
+	c, err := NewClient(DefaultConfig())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The advertised address is used without a resolver
+	addr, err := c.resolveNodeAddr(node, node.HTTPAddr)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if addr != "http://10.0.0.1:4646" {
+		t.Fatalf("bad addr: %q", addr)
+	}
+
+	// The resolver rewrites the address and may set the scheme
+	var seen *Node
+	conf := DefaultConfig()
+	conf.NodeAddressResolver = func(n *Node, a string) (string, error) {
+		seen = n
+		return "https://" + n.ID + ".internal:4646", nil
+	}
+	if c, err = NewClient(conf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	addr, err = c.resolveNodeAddr(node, node.HTTPAddr)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if addr != "https://foo.internal:4646" {
+		t.Fatalf("bad addr: %q", addr)
+	}
+	if seen != node {
+		t.Fatalf("resolver not called with the node")
+	}
+
+	// Resolver errors are returned
+	conf.NodeAddressResolver = func(*Node, string) (string, error) {
+		return "", fmt.Errorf("no route")
+	}
+	if c, err = NewClient(conf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err = c.resolveNodeAddr(node, node.HTTPAddr); err == nil || !strings.Contains(err.Error(), "no route") {
+		t.Fatalf("expected resolver error, got %v", err)
+	}
+
+	// Nodes serving TLS are dialed over https
+	node.TLSEnabled = true
+	c, err = NewClient(DefaultConfig())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	addr, err = c.resolveNodeAddr(node, node.HTTPAddr)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if addr != "https://10.0.0.1:4646" {
+		t.Fatalf("bad addr: %q", addr)
+	}
+}
+
+func TestClient_UnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "nomad.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer ln.Close()
+	go http.Serve(ln, http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/status/leader" {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		resp.Write([]byte(`"127.0.0.1:4647"`))
+	}))
+
+	conf := DefaultConfig()
+	conf.Address = "unix://" + socket
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	leader, err := c.Status().Leader()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if leader != "127.0.0.1:4647" {
+		t.Fatalf("bad leader: %q", leader)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		attempts++
+		if attempts < 3 {
+			resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp.Write([]byte(`"127.0.0.1:4647"`))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.RetryMax = 2
+	conf.RetryWaitMin = time.Millisecond
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The GET requests are retried until they succeed
+	leader, err := c.Status().Leader()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if leader != "127.0.0.1:4647" || attempts != 3 {
+		t.Fatalf("bad: %q after %d attempts", leader, attempts)
+	}
+
+	// The writes are not retried
+	attempts = 0
+	if err := c.System().GarbageCollect(); err == nil {
+		t.Fatalf("expected error")
+	}
+	if attempts != 1 {
+		t.Fatalf("write attempted %d times", attempts)
+	}
+}
+
+func TestClient_RetryBackoff(t *testing.T) {
+	c := &Client{config: Config{
+		RetryWaitMin: 10 * time.Millisecond,
+		RetryWaitMax: 50 * time.Millisecond,
+	}}
+
+	expected := []time.Duration{10, 20, 40, 50, 50}
+	for attempt, wait := range expected {
+		if backoff := c.retryBackoff(attempt); backoff != wait*time.Millisecond {
+			t.Fatalf("attempt %d: expected %v, got %v", attempt, wait*time.Millisecond, backoff)
+		}
+	}
+
+	// The bounds default when unset
+	c = &Client{}
+	if backoff := c.retryBackoff(10); backoff != defaultRetryWaitMax {
+		t.Fatalf("bad: %v", backoff)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		select {
+		case <-unblock:
+		case <-time.After(5 * time.Second):
+		}
+		resp.Write([]byte(`"127.0.0.1:4647"`))
+	}))
+	defer srv.Close()
+	defer close(unblock)
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.Timeout = 50 * time.Millisecond
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := c.Status().Leader(); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("request not bounded by the timeout: %v", elapsed)
+	}
+
+	// The options override the timeout of the config
+	r := c.newRequest("GET", "/v1/status/leader")
+	r.setQueryOptions(&QueryOptions{Timeout: time.Minute})
+	if r.timeout != time.Minute {
+		t.Fatalf("bad timeout: %v", r.timeout)
+	}
+}
@@ -0,0 +1,560 @@
+// Package apitest provides an in-memory fake of the Nomad HTTP API so that
+// programs built on the api package can be unit tested without running a
+// Nomad agent.
+//
+// The fake implements the common job, allocation and node endpoints as well
+// as the client file system endpoints, which serve canned files and log
+// frames registered on the server.
+package apitest
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// Server is an in-memory fake of the Nomad HTTP API. It acts both as the
+// servers and as the single client node all allocations are placed on.
+type Server struct {
+	// HTTPAddr is the address the fake is listening on
+	HTTPAddr string
+
+	// NodeID is the ID of the fake client node
+	NodeID string
+
+	server *httptest.Server
+
+	index  uint64
+	jobs   map[string]*api.Job
+	allocs map[string]*api.Allocation
+	files  map[string]map[string][]byte
+	logs   map[string][]*api.StreamFrame
+	lock   sync.Mutex
+}
+
+// NewServer starts a new fake server. Stop must be called once the server is
+// no longer used.
+func NewServer() *Server {
+	s := &Server{
+		NodeID: generateUUID(),
+		index:  1,
+		jobs:   make(map[string]*api.Job),
+		allocs: make(map[string]*api.Allocation),
+		files:  make(map[string]map[string][]byte),
+		logs:   make(map[string][]*api.StreamFrame),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/jobs", s.wrap(s.jobsRequest))
+	mux.HandleFunc("/v1/job/", s.wrap(s.jobSpecificRequest))
+	mux.HandleFunc("/v1/allocations", s.wrap(s.allocsRequest))
+	mux.HandleFunc("/v1/allocation/", s.wrap(s.allocSpecificRequest))
+	mux.HandleFunc("/v1/node/", s.wrap(s.nodeSpecificRequest))
+	mux.HandleFunc("/v1/client/fs/", s.fsRequest)
+
+	s.server = httptest.NewServer(mux)
+	s.HTTPAddr = strings.TrimPrefix(s.server.URL, "http://")
+	return s
+}
+
+// Stop shuts the fake server down.
+func (s *Server) Stop() {
+	s.server.Close()
+}
+
+// Client returns an API client configured to talk to the fake server.
+func (s *Server) Client() (*api.Client, error) {
+	conf := api.DefaultConfig()
+	conf.Address = s.server.URL
+	return api.NewClient(conf)
+}
+
+// AddAlloc stores the allocation. If the allocation has no NodeID it is
+// placed on the fake client node.
+func (s *Server) AddAlloc(alloc *api.Allocation) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if alloc.ID == "" {
+		alloc.ID = generateUUID()
+	}
+	if alloc.NodeID == "" {
+		alloc.NodeID = s.NodeID
+	}
+	if alloc.Job == nil {
+		alloc.Job = s.jobs[alloc.JobID]
+	}
+	index := s.nextIndex()
+	if alloc.CreateIndex == 0 {
+		alloc.CreateIndex = index
+	}
+	alloc.ModifyIndex = index
+	s.allocs[alloc.ID] = alloc
+}
+
+// AddFile stores the contents of a file at the given path of the allocation
+// directory. Parent directories are implied.
+func (s *Server) AddFile(allocID, filePath string, contents []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	files, ok := s.files[allocID]
+	if !ok {
+		files = make(map[string][]byte)
+		s.files[allocID] = files
+	}
+	files[cleanPath(filePath)] = contents
+}
+
+// AddLogFrames appends frames to the logs of the given task and log type,
+// either "stdout" or "stderr", of an allocation.
+func (s *Server) AddLogFrames(allocID, task, logType string, frames ...*api.StreamFrame) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := logKey(allocID, task, logType)
+	s.logs[key] = append(s.logs[key], frames...)
+}
+
+// Job returns the registered job with the given ID or nil.
+func (s *Server) Job(jobID string) *api.Job {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.jobs[jobID]
+}
+
+// codedError is an error carrying the HTTP status code to respond with
+type codedError struct {
+	code int
+	msg  string
+}
+
+func (e *codedError) Error() string {
+	return e.msg
+}
+
+// wrap turns a handler returning an object into an http.HandlerFunc that
+// writes the object as JSON. The handler is called with the lock held.
+func (s *Server) wrap(handler func(req *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		s.lock.Lock()
+		obj, err := handler(req)
+		index := s.index
+		s.lock.Unlock()
+
+		if err != nil {
+			code := 500
+			if cerr, ok := err.(*codedError); ok {
+				code = cerr.code
+			}
+			resp.WriteHeader(code)
+			resp.Write([]byte(err.Error()))
+			return
+		}
+
+		writeJSON(resp, index, obj)
+	}
+}
+
+func (s *Server) jobsRequest(req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		prefix := req.URL.Query().Get("prefix")
+		stubs := make([]*api.JobListStub, 0, len(s.jobs))
+		for _, job := range s.jobs {
+			if strings.HasPrefix(job.ID, prefix) {
+				stubs = append(stubs, jobStub(job))
+			}
+		}
+		return stubs, nil
+	case "PUT", "POST":
+		var args api.RegisterJobRequest
+		if err := json.NewDecoder(req.Body).Decode(&args); err != nil {
+			return nil, &codedError{400, err.Error()}
+		}
+		if args.Job == nil || args.Job.ID == "" {
+			return nil, &codedError{400, "Job ID must be specified"}
+		}
+		return s.registerJob(&args)
+	default:
+		return nil, &codedError{405, "Invalid method"}
+	}
+}
+
+func (s *Server) registerJob(args *api.RegisterJobRequest) (interface{}, error) {
+	job := args.Job
+	existing := s.jobs[job.ID]
+	if args.EnforceIndex {
+		var current uint64
+		if existing != nil {
+			current = existing.JobModifyIndex
+		}
+		if current != args.JobModifyIndex {
+			return nil, &codedError{500, fmt.Sprintf("%s %d: job exists with conflicting job modify index: %d",
+				api.RegisterEnforceIndexErrPrefix, args.JobModifyIndex, current)}
+		}
+	}
+
+	index := s.nextIndex()
+	job.CreateIndex = index
+	if existing != nil {
+		job.CreateIndex = existing.CreateIndex
+	}
+	job.ModifyIndex = index
+	job.JobModifyIndex = index
+	if job.Status == "" {
+		job.Status = "pending"
+	}
+	s.jobs[job.ID] = job
+	return map[string]interface{}{
+		"EvalID":          generateUUID(),
+		"EvalCreateIndex": index,
+		"JobModifyIndex":  index,
+	}, nil
+}
+
+func (s *Server) jobSpecificRequest(req *http.Request) (interface{}, error) {
+	jobID := strings.TrimPrefix(req.URL.Path, "/v1/job/")
+	if strings.HasSuffix(jobID, "/allocations") {
+		jobID = strings.TrimSuffix(jobID, "/allocations")
+		if _, ok := s.jobs[jobID]; !ok {
+			return nil, &codedError{404, "job not found"}
+		}
+		stubs := make([]*api.AllocationListStub, 0)
+		for _, alloc := range s.allocs {
+			if alloc.JobID == jobID {
+				stubs = append(stubs, allocStub(alloc))
+			}
+		}
+		return stubs, nil
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, &codedError{404, "job not found"}
+	}
+
+	switch req.Method {
+	case "GET":
+		return job, nil
+	case "DELETE":
+		delete(s.jobs, jobID)
+		index := s.nextIndex()
+		return map[string]interface{}{
+			"EvalID":          generateUUID(),
+			"EvalCreateIndex": index,
+			"JobModifyIndex":  index,
+		}, nil
+	default:
+		return nil, &codedError{405, "Invalid method"}
+	}
+}
+
+func (s *Server) allocsRequest(req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, &codedError{405, "Invalid method"}
+	}
+
+	prefix := req.URL.Query().Get("prefix")
+	stubs := make([]*api.AllocationListStub, 0, len(s.allocs))
+	for _, alloc := range s.allocs {
+		if strings.HasPrefix(alloc.ID, prefix) {
+			stubs = append(stubs, allocStub(alloc))
+		}
+	}
+	return stubs, nil
+}
+
+func (s *Server) allocSpecificRequest(req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, &codedError{405, "Invalid method"}
+	}
+
+	allocID := strings.TrimPrefix(req.URL.Path, "/v1/allocation/")
+	alloc, ok := s.allocs[allocID]
+	if !ok {
+		return nil, &codedError{404, "alloc not found"}
+	}
+	return alloc, nil
+}
+
+func (s *Server) nodeSpecificRequest(req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, &codedError{405, "Invalid method"}
+	}
+
+	nodeID := strings.TrimPrefix(req.URL.Path, "/v1/node/")
+	if nodeID != s.NodeID {
+		return nil, &codedError{404, "node not found"}
+	}
+	return &api.Node{
+		ID:         s.NodeID,
+		Datacenter: "dc1",
+		Name:       "apitest",
+		HTTPAddr:   s.HTTPAddr,
+		Status:     "ready",
+	}, nil
+}
+
+// fsRequest serves the client file system endpoints from the canned files and
+// log frames. Streams end once the available data has been sent.
+func (s *Server) fsRequest(resp http.ResponseWriter, req *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v1/client/fs/"), "/", 2)
+	if len(parts) != 2 {
+		http.Error(resp, "alloc id not found", 400)
+		return
+	}
+	endpoint, allocID := parts[0], parts[1]
+	q := req.URL.Query()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.allocs[allocID]; !ok {
+		http.Error(resp, "unknown allocation ID", 404)
+		return
+	}
+
+	switch endpoint {
+	case "ls":
+		entries, ok := s.list(allocID, q.Get("path"))
+		if !ok {
+			http.Error(resp, "no such file or directory", 404)
+			return
+		}
+		writeJSON(resp, s.index, entries)
+	case "stat":
+		info, ok := s.stat(allocID, q.Get("path"))
+		if !ok {
+			http.Error(resp, "no such file or directory", 404)
+			return
+		}
+		writeJSON(resp, s.index, info)
+	case "stat-batch":
+		var paths []string
+		if err := json.NewDecoder(req.Body).Decode(&paths); err != nil {
+			http.Error(resp, err.Error(), 400)
+			return
+		}
+		results := make([]*api.AllocFileStat, len(paths))
+		for i, p := range paths {
+			results[i] = &api.AllocFileStat{Path: p}
+			if info, ok := s.stat(allocID, p); ok {
+				results[i].FileInfo = info
+			} else {
+				results[i].Error = "no such file or directory"
+			}
+		}
+		writeJSON(resp, s.index, results)
+	case "cat", "readat":
+		data, ok := s.files[allocID][cleanPath(q.Get("path"))]
+		if !ok {
+			http.Error(resp, "no such file", 404)
+			return
+		}
+		if endpoint == "readat" {
+			offset, _ := strconv.ParseInt(q.Get("offset"), 10, 64)
+			limit, _ := strconv.ParseInt(q.Get("limit"), 10, 64)
+			data = slice(data, offset, limit)
+		}
+		resp.Write(data)
+	case "stream":
+		filePath := cleanPath(q.Get("path"))
+		data, ok := s.files[allocID][filePath]
+		if !ok {
+			http.Error(resp, "no such file", 404)
+			return
+		}
+		offset, _ := strconv.ParseInt(q.Get("offset"), 10, 64)
+		if q.Get("origin") == api.OriginEnd {
+			offset = int64(len(data)) - offset
+		}
+		if offset < 0 {
+			offset = 0
+		}
+		frame := &api.StreamFrame{
+			Offset: int64(len(data)),
+			Data:   slice(data, offset, -1),
+			File:   filePath,
+		}
+		enc := json.NewEncoder(resp)
+		if len(frame.Data) != 0 {
+			enc.Encode(frame)
+		}
+	case "logs":
+		frames, ok := s.logs[logKey(allocID, q.Get("task"), q.Get("type"))]
+		if !ok {
+			http.Error(resp, "no such log", 404)
+			return
+		}
+		enc := json.NewEncoder(resp)
+		for _, frame := range frames {
+			enc.Encode(frame)
+		}
+	default:
+		http.Error(resp, "invalid endpoint", 404)
+	}
+}
+
+// list returns the entries directly below the given directory of an
+// allocation and whether the directory exists.
+func (s *Server) list(allocID, dir string) ([]*api.AllocFileInfo, bool) {
+	dir = cleanPath(dir)
+	seen := make(map[string]*api.AllocFileInfo)
+	for filePath, data := range s.files[allocID] {
+		rel := filePath
+		if dir != "/" {
+			if !strings.HasPrefix(filePath, dir+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(filePath, dir)
+		}
+
+		rel = strings.TrimPrefix(rel, "/")
+		name := strings.SplitN(rel, "/", 2)[0]
+		if name == rel {
+			seen[name] = fileInfo(name, data)
+		} else if _, ok := seen[name]; !ok {
+			seen[name] = dirInfo(name)
+		}
+	}
+	if len(seen) == 0 && dir != "/" {
+		return nil, false
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]*api.AllocFileInfo, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, seen[name])
+	}
+	return entries, true
+}
+
+// stat returns the file info of a path of an allocation and whether it
+// exists.
+func (s *Server) stat(allocID, filePath string) (*api.AllocFileInfo, bool) {
+	filePath = cleanPath(filePath)
+	if data, ok := s.files[allocID][filePath]; ok {
+		return fileInfo(path.Base(filePath), data), true
+	}
+	if _, ok := s.list(allocID, filePath); ok {
+		return dirInfo(path.Base(filePath)), true
+	}
+	return nil, false
+}
+
+// writeJSON writes the object as JSON along with the query meta headers.
+func writeJSON(resp http.ResponseWriter, index uint64, obj interface{}) {
+	resp.Header().Set("X-Nomad-Index", strconv.FormatUint(index, 10))
+	resp.Header().Set("X-Nomad-LastContact", "0")
+	resp.Header().Set("X-Nomad-KnownLeader", "true")
+	resp.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(resp).Encode(obj)
+}
+
+// nextIndex increments and returns the modify index. The lock must be held.
+func (s *Server) nextIndex() uint64 {
+	s.index++
+	return s.index
+}
+
+func jobStub(job *api.Job) *api.JobListStub {
+	return &api.JobListStub{
+		ID:                job.ID,
+		ParentID:          job.ParentID,
+		Name:              job.Name,
+		Type:              job.Type,
+		Priority:          job.Priority,
+		Status:            job.Status,
+		StatusDescription: job.StatusDescription,
+		CreateIndex:       job.CreateIndex,
+		ModifyIndex:       job.ModifyIndex,
+		JobModifyIndex:    job.JobModifyIndex,
+	}
+}
+
+func allocStub(alloc *api.Allocation) *api.AllocationListStub {
+	return &api.AllocationListStub{
+		ID:                 alloc.ID,
+		EvalID:             alloc.EvalID,
+		Name:               alloc.Name,
+		NodeID:             alloc.NodeID,
+		JobID:              alloc.JobID,
+		TaskGroup:          alloc.TaskGroup,
+		DesiredStatus:      alloc.DesiredStatus,
+		DesiredDescription: alloc.DesiredDescription,
+		ClientStatus:       alloc.ClientStatus,
+		ClientDescription:  alloc.ClientDescription,
+		TaskStates:         alloc.TaskStates,
+		CreateIndex:        alloc.CreateIndex,
+		ModifyIndex:        alloc.ModifyIndex,
+		CreateTime:         alloc.CreateTime,
+	}
+}
+
+func fileInfo(name string, data []byte) *api.AllocFileInfo {
+	return &api.AllocFileInfo{
+		Name:     name,
+		Size:     int64(len(data)),
+		FileMode: "-rw-r--r--",
+		ModTime:  time.Now(),
+	}
+}
+
+func dirInfo(name string) *api.AllocFileInfo {
+	return &api.AllocFileInfo{
+		Name:     name,
+		IsDir:    true,
+		FileMode: "drwxr-xr-x",
+		ModTime:  time.Now(),
+	}
+}
+
+// slice returns the data starting at offset limited to limit bytes. A
+// negative limit returns all remaining data.
+func slice(data []byte, offset, limit int64) []byte {
+	if offset > int64(len(data)) {
+		return nil
+	}
+	data = data[offset:]
+	if limit >= 0 && limit < int64(len(data)) {
+		data = data[:limit]
+	}
+	return data
+}
+
+func cleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+func logKey(allocID, task, logType string) string {
+	return allocID + "/" + task + "/" + logType
+}
+
+// generateUUID is used to generate a random UUID
+func generateUUID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Errorf("failed to read random bytes: %v", err))
+	}
+
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%12x",
+		buf[0:4],
+		buf[4:6],
+		buf[6:8],
+		buf[8:10],
+		buf[10:16])
+}
@@ -0,0 +1,134 @@
+package apitest
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+func testServer(t *testing.T) (*Server, *api.Client) {
+	s := NewServer()
+	c, err := s.Client()
+	if err != nil {
+		s.Stop()
+		t.Fatalf("err: %v", err)
+	}
+	return s, c
+}
+
+func TestServer_Jobs(t *testing.T) {
+	s, c := testServer(t)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	job := &api.Job{ID: "example", Name: "example", Type: "service", Priority: 50}
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s.Job("example") == nil {
+		t.Fatalf("job not registered")
+	}
+
+	// Enforcing a stale index fails
+	if _, _, err := jobs.EnforceRegister(job, 1, nil); err == nil {
+		t.Fatalf("expected enforce index error")
+	}
+
+	list, qm, err := jobs.PrefixList("ex")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != "example" || qm.LastIndex == 0 {
+		t.Fatalf("bad: %#v %#v", list, qm)
+	}
+
+	info, _, err := jobs.Info("example", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.Name != "example" || info.JobModifyIndex == 0 {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	s.AddAlloc(&api.Allocation{JobID: "example", TaskGroup: "web", ClientStatus: "running"})
+	allocs, _, err := jobs.Allocations("example", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(allocs) != 1 || allocs[0].NodeID != s.NodeID {
+		t.Fatalf("bad: %#v", allocs)
+	}
+
+	if _, _, err := jobs.Deregister("example", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := jobs.Info("example", nil); err == nil {
+		t.Fatalf("expected job to be deregistered")
+	}
+}
+
+func TestServer_AllocFS(t *testing.T) {
+	s, c := testServer(t)
+	defer s.Stop()
+
+	alloc := &api.Allocation{JobID: "example", TaskGroup: "web"}
+	s.AddAlloc(alloc)
+	s.AddFile(alloc.ID, "alloc/logs/web.stdout.0", []byte("hello"))
+	s.AddFile(alloc.ID, "web/local/config", []byte("world"))
+	s.AddLogFrames(alloc.ID, "web", "stdout", &api.StreamFrame{Data: []byte("foo\n")}, &api.StreamFrame{Data: []byte("bar\n")})
+
+	fs := c.AllocFS()
+	entries, _, err := fs.List(alloc, "/", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "alloc" || !entries[0].IsDir || entries[1].Name != "web" {
+		t.Fatalf("bad: %#v", entries)
+	}
+
+	info, _, err := fs.Stat(alloc, "web/local/config", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.IsDir || info.Size != 5 {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	stats, _, err := fs.StatBatch(alloc, []string{"web/local/config", "web/missing"}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(stats) != 2 || stats[0].FileInfo == nil || stats[0].FileInfo.Size != 5 ||
+		stats[1].FileInfo != nil || stats[1].Error == "" {
+		t.Fatalf("bad: %#v", stats)
+	}
+
+	r, err := fs.Cat(alloc, "web/local/config", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "world" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	frames, err := fs.Stream(alloc, "alloc/logs/web.stdout.0", api.OriginEnd, 3, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data, err = ioutil.ReadAll(api.NewFrameReader(frames, nil))
+	if err != nil || string(data) != "llo" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	frames, err = fs.Logs(alloc, false, "web", "stdout", api.OriginStart, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data, err = ioutil.ReadAll(api.NewFrameReader(frames, nil))
+	if err != nil || string(data) != "foo\nbar\n" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+}
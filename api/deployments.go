@@ -0,0 +1,137 @@
+package api
+
+import (
+	"sort"
+)
+
+// Deployments is used to query the deployments endpoints.
+type Deployments struct {
+	client *Client
+}
+
+// Deployments returns a new handle on the deployments.
+func (c *Client) Deployments() *Deployments {
+	return &Deployments{client: c}
+}
+
+// List is used to dump all of the deployments.
+func (d *Deployments) List(q *QueryOptions) ([]*Deployment, *QueryMeta, error) {
+	var resp []*Deployment
+	qm, err := d.client.query("/v1/deployments", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(DeploymentIndexSort(resp))
+	return resp, qm, nil
+}
+
+func (d *Deployments) PrefixList(prefix string) ([]*Deployment, *QueryMeta, error) {
+	return d.List(&QueryOptions{Prefix: prefix})
+}
+
+// Info is used to query a single deployment by its ID.
+func (d *Deployments) Info(deploymentID string, q *QueryOptions) (*Deployment, *QueryMeta, error) {
+	var resp Deployment
+	qm, err := d.client.query("/v1/deployment/"+deploymentID, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Allocations is used to retrieve the allocations placed by a deployment.
+func (d *Deployments) Allocations(deploymentID string, q *QueryOptions) ([]*AllocationListStub, *QueryMeta, error) {
+	var resp []*AllocationListStub
+	qm, err := d.client.query("/v1/deployment/"+deploymentID+"/allocations", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(AllocIndexSort(resp))
+	return resp, qm, nil
+}
+
+// Fail is used to fail a running deployment. If the deployment auto reverts,
+// the job is reverted to its latest stable version.
+func (d *Deployments) Fail(deploymentID string, q *WriteOptions) (*DeploymentUpdateResponse, *WriteMeta, error) {
+	var resp DeploymentUpdateResponse
+	wm, err := d.client.write("/v1/deployment/"+deploymentID+"/fail", nil, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Promote is used to promote the canaries of a running deployment and to mark
+// its allocations whose health is not yet known as healthy.
+func (d *Deployments) Promote(deploymentID string, q *WriteOptions) (*DeploymentUpdateResponse, *WriteMeta, error) {
+	return d.PromoteGroups(deploymentID, nil, q)
+}
+
+// PromoteGroups is used to promote the canaries of the given task groups of a
+// running deployment and to mark their allocations whose health is not yet
+// known as healthy. All the task groups are promoted if none is given.
+func (d *Deployments) PromoteGroups(deploymentID string, groups []string, q *WriteOptions) (*DeploymentUpdateResponse, *WriteMeta, error) {
+	var resp DeploymentUpdateResponse
+	req := &DeploymentPromoteRequest{Groups: groups}
+	wm, err := d.client.write("/v1/deployment/"+deploymentID+"/promote", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Deployment is used to serialize a deployment.
+type Deployment struct {
+	ID                string
+	JobID             string
+	Namespace         string
+	JobVersion        uint64
+	JobModifyIndex    uint64
+	TaskGroups        map[string]*DeploymentState
+	Status            string
+	StatusDescription string
+	CreateIndex       uint64
+	ModifyIndex       uint64
+}
+
+// DeploymentState tracks the progress of a deployment for a task group.
+type DeploymentState struct {
+	AutoRevert      bool
+	DesiredTotal    int
+	PlacedAllocs    int
+	HealthyAllocs   int
+	UnhealthyAllocs int
+	DesiredCanaries int
+	PlacedCanaries  int
+	Promoted        bool
+}
+
+// DeploymentPromoteRequest is used to promote the canaries of a deployment.
+type DeploymentPromoteRequest struct {
+	Groups []string
+}
+
+// DeploymentUpdateResponse is used to respond to a change of a deployment.
+type DeploymentUpdateResponse struct {
+	DeploymentModifyIndex uint64
+	RevertedJobVersion    *uint64
+	EvalID                string
+	EvalCreateIndex       uint64
+	WriteMeta
+}
+
+// DeploymentIndexSort is a wrapper to sort deployments by CreateIndex. We
+// reverse the test so that we get the highest index first.
+type DeploymentIndexSort []*Deployment
+
+func (d DeploymentIndexSort) Len() int {
+	return len(d)
+}
+
+func (d DeploymentIndexSort) Less(i, j int) bool {
+	return d[i].CreateIndex > d[j].CreateIndex
+}
+
+func (d DeploymentIndexSort) Swap(i, j int) {
+	d[i], d[j] = d[j], d[i]
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestDeployments_List(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	d := c.Deployments()
+
+	// Listing when nothing exists returns empty
+	result, qm, err := d.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if qm.LastIndex != 0 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	if n := len(result); n != 0 {
+		t.Fatalf("expected 0 deployments, got: %d", n)
+	}
+
+	// Register a service job with a health gated update. Its evaluation
+	// creates a deployment.
+	job := testJob()
+	job.Type = "service"
+	job.Update = &UpdateStrategy{
+		MaxParallel: 1,
+		HealthCheck: "task_states",
+	}
+	if _, _, err := c.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var deployment *Deployment
+	testutil.WaitForResult(func() (bool, error) {
+		result, qm, err = d.List(nil)
+		if err != nil {
+			return false, err
+		}
+		if len(result) != 1 {
+			return false, nil
+		}
+		deployment = result[0]
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("expected a deployment: %v", err)
+	})
+	assertQueryMeta(t, qm)
+	if deployment.JobID != job.ID || deployment.Status != "running" {
+		t.Fatalf("bad: %#v", deployment)
+	}
+
+	// Query it by prefix and ID
+	result, _, err = d.PrefixList(deployment.ID[:4])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(result) != 1 || result[0].ID != deployment.ID {
+		t.Fatalf("bad: %#v", result)
+	}
+	info, qm, err := d.Info(deployment.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if info.ID != deployment.ID || info.TaskGroups["group1"] == nil {
+		t.Fatalf("bad: %#v", info)
+	}
+	if _, _, err := d.Allocations(deployment.ID, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Fail the deployment
+	resp, wm, err := d.Fail(deployment.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if resp.RevertedJobVersion != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	info, _, err = d.Info(deployment.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if info.Status != "failed" {
+		t.Fatalf("bad: %#v", info)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// UnexpectedResponseError is returned when the agent answers a request with
+// a status code other than 200. Use its methods, or the IsNotFound,
+// IsPermissionDenied, IsServerError and IsRetryable functions, to branch on
+// the kind of error.
+type UnexpectedResponseError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Body is the body of the response, usually the error message of the
+	// agent
+	Body string
+}
+
+func (e *UnexpectedResponseError) Error() string {
+	return fmt.Sprintf("Unexpected response code: %d (%s)", e.StatusCode, e.Body)
+}
+
+// IsNotFound returns whether the requested object doesn't exist.
+func (e *UnexpectedResponseError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsPermissionDenied returns whether the request was refused for lack of
+// permissions.
+func (e *UnexpectedResponseError) IsPermissionDenied() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsServerError returns whether the agent failed to handle the request.
+func (e *UnexpectedResponseError) IsServerError() bool {
+	return e.StatusCode >= 500
+}
+
+// Retryable returns whether sending the same request again may succeed.
+func (e *UnexpectedResponseError) Retryable() bool {
+	return retryableStatus(e.StatusCode)
+}
+
+// retryableStatus returns whether a request answered with the status code may
+// succeed if sent again
+func retryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests
+}
+
+// IsNotFound returns whether the error is a response for an object that
+// doesn't exist.
+func IsNotFound(err error) bool {
+	e, ok := err.(*UnexpectedResponseError)
+	return ok && e.IsNotFound()
+}
+
+// IsPermissionDenied returns whether the error is a response refusing the
+// request for lack of permissions.
+func IsPermissionDenied(err error) bool {
+	e, ok := err.(*UnexpectedResponseError)
+	return ok && e.IsPermissionDenied()
+}
+
+// IsServerError returns whether the error is a response of an agent failing
+// to handle the request.
+func IsServerError(err error) bool {
+	e, ok := err.(*UnexpectedResponseError)
+	return ok && e.IsServerError()
+}
+
+// IsRetryable returns whether sending the same request again may succeed
+// after the error, which is the case of the retryable responses and of the
+// errors reaching the agent.
+func IsRetryable(err error) bool {
+	switch e := err.(type) {
+	case *UnexpectedResponseError:
+		return e.Retryable()
+	case *url.Error:
+		return true
+	}
+	return false
+}
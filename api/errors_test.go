@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestUnexpectedResponseError(t *testing.T) {
+	cases := []struct {
+		code       int
+		notFound   bool
+		permission bool
+		server     bool
+		retryable  bool
+	}{
+		{400, false, false, false, false},
+		{403, false, true, false, false},
+		{404, true, false, false, false},
+		{429, false, false, false, true},
+		{500, false, false, true, true},
+		{503, false, false, true, true},
+	}
+	for _, c := range cases {
+		err := &UnexpectedResponseError{StatusCode: c.code, Body: "boom"}
+		if IsNotFound(err) != c.notFound || IsPermissionDenied(err) != c.permission ||
+			IsServerError(err) != c.server || IsRetryable(err) != c.retryable {
+			t.Fatalf("bad kinds of error for code %d", c.code)
+		}
+	}
+
+	err := &UnexpectedResponseError{StatusCode: 404, Body: "job not found"}
+	if msg := err.Error(); msg != "Unexpected response code: 404 (job not found)" {
+		t.Fatalf("bad message: %q", msg)
+	}
+
+	// Errors reaching the agent are retryable, other errors are of no kind
+	if !IsRetryable(&url.Error{Op: "Get", URL: "http://127.0.0.1:4646", Err: fmt.Errorf("refused")}) {
+		t.Fatalf("connection error not retryable")
+	}
+	other := fmt.Errorf("boom")
+	if IsNotFound(other) || IsPermissionDenied(other) || IsServerError(other) || IsRetryable(other) {
+		t.Fatalf("untyped error has a kind")
+	}
+}
+
+func TestUnexpectedResponseError_Request(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	_, _, err := c.Jobs().Info("nope", nil)
+	e, ok := err.(*UnexpectedResponseError)
+	if !ok {
+		t.Fatalf("expected an UnexpectedResponseError, got: %#v", err)
+	}
+	if !e.IsNotFound() || e.Body == "" {
+		t.Fatalf("bad: %#v", e)
+	}
+}
@@ -26,6 +26,34 @@ func (e *Evaluations) List(q *QueryOptions) ([]*Evaluation, *QueryMeta, error) {
 	return resp, qm, nil
 }
 
+// EvaluationListFilter filters the evaluations listed by the servers. Empty
+// fields don't filter.
+type EvaluationListFilter struct {
+	JobID  string
+	Status string
+}
+
+// ListFiltered returns the evaluations passing the filter, filtered by the
+// servers.
+func (e *Evaluations) ListFiltered(filter *EvaluationListFilter, q *QueryOptions) ([]*Evaluation, *QueryMeta, error) {
+	return e.List(withParams(q, map[string]string{
+		"job":    filter.JobID,
+		"status": filter.Status,
+	}))
+}
+
+// Blocked is used to list the evaluations blocked until resources become
+// available to place their remaining allocations.
+func (e *Evaluations) Blocked(q *QueryOptions) ([]*Evaluation, *QueryMeta, error) {
+	var resp []*Evaluation
+	qm, err := e.client.query("/v1/evaluations/blocked", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(EvalIndexSort(resp))
+	return resp, qm, nil
+}
+
 func (e *Evaluations) PrefixList(prefix string) ([]*Evaluation, *QueryMeta, error) {
 	return e.List(&QueryOptions{Prefix: prefix})
 }
@@ -40,6 +68,17 @@ func (e *Evaluations) Info(evalID string, q *QueryOptions) (*Evaluation, *QueryM
 	return &resp, qm, nil
 }
 
+// Delete is used to delete an evaluation, such as a broken evaluation stuck
+// in the pending state. Evaluations being processed by a scheduler can not be
+// deleted.
+func (e *Evaluations) Delete(evalID string, q *WriteOptions) (*WriteMeta, error) {
+	wm, err := e.client.delete("/v1/evaluation/"+evalID, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
 // Allocations is used to retrieve a set of allocations given
 // an evaluation ID.
 func (e *Evaluations) Allocations(evalID string, q *QueryOptions) ([]*AllocationListStub, *QueryMeta, error) {
@@ -54,23 +93,27 @@ func (e *Evaluations) Allocations(evalID string, q *QueryOptions) ([]*Allocation
 
 // Evaluation is used to serialize an evaluation.
 type Evaluation struct {
-	ID                string
-	Priority          int
-	Type              string
-	TriggeredBy       string
-	JobID             string
-	JobModifyIndex    uint64
-	NodeID            string
-	NodeModifyIndex   uint64
-	Status            string
-	StatusDescription string
-	Wait              time.Duration
-	NextEval          string
-	PreviousEval      string
-	BlockedEval       string
-	FailedTGAllocs    map[string]*AllocationMetric
-	CreateIndex       uint64
-	ModifyIndex       uint64
+	ID                   string
+	Priority             int
+	Type                 string
+	TriggeredBy          string
+	JobID                string
+	Namespace            string
+	JobModifyIndex       uint64
+	NodeID               string
+	NodeModifyIndex      uint64
+	Status               string
+	StatusDescription    string
+	Wait                 time.Duration
+	NextEval             string
+	PreviousEval         string
+	BlockedEval          string
+	FailedTGAllocs       map[string]*AllocationMetric
+	ClassEligibility     map[string]bool
+	EscapedComputedClass bool
+	DeliveryAttempts     int
+	CreateIndex          uint64
+	ModifyIndex          uint64
 }
 
 // EvalIndexSort is a wrapper to sort evaluations by CreateIndex.
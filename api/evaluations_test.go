@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strings"
 	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
 )
 
 func TestEvaluations_List(t *testing.T) {
@@ -48,6 +50,51 @@ func TestEvaluations_List(t *testing.T) {
 	}
 }
 
+func TestEvaluations_ListFiltered_Delete(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	e := c.Evaluations()
+
+	// Register a job. This will create an evaluation.
+	job := testJob()
+	evalID, wm, err := c.Jobs().Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Wait for the evaluation to complete
+	filter := &EvaluationListFilter{JobID: job.ID, Status: "complete"}
+	testutil.WaitForResult(func() (bool, error) {
+		result, _, err := e.ListFiltered(filter, nil)
+		if err != nil {
+			return false, err
+		}
+		return len(result) == 1 && result[0].ID == evalID, nil
+	}, func(err error) {
+		t.Fatalf("eval not complete: %v", err)
+	})
+
+	// Delete the evaluation
+	wm, err = e.Delete(evalID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Deleting it again fails
+	if _, err := e.Delete(evalID, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error: %v", err)
+	}
+	result, _, err := e.ListFiltered(filter, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(result) != 0 {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
 func TestEvaluations_PrefixList(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	// The topics of the events
+	EventTopicJob        = "Job"
+	EventTopicAllocation = "Allocation"
+	EventTopicNode       = "Node"
+	EventTopicEvaluation = "Evaluation"
+)
+
+// Event is a change of the state of the cluster. Only the object of the topic
+// of the event is set, as it was after the change.
+type Event struct {
+	Topic      string
+	Type       string
+	Key        string
+	Namespace  string
+	Index      uint64
+	Job        *Job
+	Allocation *Allocation
+	Node       *Node
+	Evaluation *Evaluation
+}
+
+// EventStream is used to stream the events of the cluster.
+type EventStream struct {
+	client *Client
+}
+
+// EventStream returns a new handle on the event stream.
+func (c *Client) EventStream() *EventStream {
+	return &EventStream{client: c}
+}
+
+// Stream streams the events of the cluster.
+// The parameters are:
+// * topics: The topics of the events to stream. All the topics are streamed
+//           if empty.
+// * cancel: A channel that when closed, streaming will end.
+//
+// The events following the WaitIndex of the QueryOptions are streamed. If it
+// is unset, the events retained by the servers are streamed first.
+//
+// The return value is a channel that will emit the events as they are read.
+// It is closed when the stream ends.
+func (e *EventStream) Stream(topics []string, cancel <-chan struct{},
+	q *QueryOptions) (<-chan *Event, error) {
+
+	if q == nil {
+		q = &QueryOptions{}
+	}
+	if q.Params == nil {
+		q.Params = make(map[string]string)
+	}
+	if len(topics) != 0 {
+		q.Params["topic"] = strings.Join(topics, ",")
+	}
+
+	r, err := e.client.rawQuery("/v1/event/stream", q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Close the body when cancelled to interrupt the decoding
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-cancel:
+			r.Close()
+		case <-done:
+		}
+	}()
+
+	// Create the output channel
+	events := make(chan *Event, 10)
+
+	go func() {
+		// Close the body
+		defer r.Close()
+		defer close(done)
+		defer close(events)
+
+		// Create a decoder, which skips the empty heartbeat lines
+		dec := json.NewDecoder(r)
+
+		for {
+			// Decode the next event
+			var event Event
+			if err := dec.Decode(&event); err != nil {
+				return
+			}
+
+			select {
+			case events <- &event:
+			case <-cancel:
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventStream_Stream(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	cancel := make(chan struct{})
+	defer close(cancel)
+	events, err := c.EventStream().Stream([]string{EventTopicJob}, cancel, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Register a job, which publishes an event
+	job := testJob()
+	if _, _, err := c.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	select {
+	case event := <-events:
+		if event == nil {
+			t.Fatalf("stream closed")
+		}
+		if event.Topic != EventTopicJob || event.Type != "JobRegistered" || event.Key != job.ID {
+			t.Fatalf("bad: %#v", event)
+		}
+		if event.Job == nil || event.Job.ID != job.ID || event.Index == 0 {
+			t.Fatalf("bad: %#v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for the event")
+	}
+}
+
+func TestEventStream_InvalidTopic(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	if _, err := c.EventStream().Stream([]string{"foo"}, nil, nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
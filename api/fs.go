@@ -2,8 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"strconv"
 	"sync"
 	"time"
@@ -15,8 +17,16 @@ const (
 	// and end of a file.
 	OriginStart = "start"
 	OriginEnd   = "end"
+
+	// heartbeatMisses is the number of consecutive heartbeats that may be
+	// missed before a stream is considered dead.
+	heartbeatMisses = 3
 )
 
+// ErrHeartbeatTimeout is returned by a FrameReader when the stream stops
+// sending heartbeats.
+var ErrHeartbeatTimeout = errors.New("timed out waiting for stream heartbeat")
+
 // AllocFileInfo holds information about a file inside the AllocDir
 type AllocFileInfo struct {
 	Name     string
@@ -26,17 +36,33 @@ type AllocFileInfo struct {
 	ModTime  time.Time
 }
 
-// StreamFrame is used to frame data of a file when streaming
+// AllocFileStat is the result of stat-ing a single path of a batch. Either
+// FileInfo or Error is set.
+type AllocFileStat struct {
+	Path     string
+	FileInfo *AllocFileInfo
+	Error    string
+}
+
+// StreamFrame is used to frame data of a file when streaming. The first frame
+// of a stream is a handshake carrying the rate at which heartbeat frames will
+// be sent.
 type StreamFrame struct {
-	Offset    int64  `json:",omitempty"`
-	Data      []byte `json:",omitempty"`
-	File      string `json:",omitempty"`
-	FileEvent string `json:",omitempty"`
+	Offset        int64         `json:",omitempty"`
+	Data          []byte        `json:",omitempty"`
+	File          string        `json:",omitempty"`
+	FileEvent     string        `json:",omitempty"`
+	HeartbeatRate time.Duration `json:",omitempty"`
 }
 
 // IsHeartbeat returns if the frame is a heartbeat frame
 func (s *StreamFrame) IsHeartbeat() bool {
-	return len(s.Data) == 0 && s.FileEvent == "" && s.File == "" && s.Offset == 0
+	return len(s.Data) == 0 && s.FileEvent == "" && s.File == "" && s.Offset == 0 && s.HeartbeatRate == 0
+}
+
+// IsHandshake returns if the frame is the handshake frame opening a stream
+func (s *StreamFrame) IsHandshake() bool {
+	return s.HeartbeatRate != 0
 }
 
 // AllocFS is used to introspect an allocation directory on a Nomad client
@@ -52,17 +78,17 @@ func (c *Client) AllocFS() *AllocFS {
 // getNodeClient returns a Client that will dial the node. If the QueryOptions
 // is set, the function will ensure that it is initalized and that the Params
 // field is valid.
-func (a *AllocFS) getNodeClient(nodeHTTPAddr, allocID string, q **QueryOptions) (*Client, error) {
-	if nodeHTTPAddr == "" {
+func (a *AllocFS) getNodeClient(node *Node, allocID string, q **QueryOptions) (*Client, error) {
+	addr, err := a.client.resolveNodeAddr(node, streamAddr(node))
+	if err != nil {
+		return nil, err
+	}
+	if addr == "" {
 		return nil, fmt.Errorf("http addr of the node where alloc %q is running is not advertised", allocID)
 	}
 
 	// Get an API client for the node
-	nodeClientConfig := &Config{
-		Address: fmt.Sprintf("http://%s", nodeHTTPAddr),
-		Region:  a.client.config.Region,
-	}
-	nodeClient, err := NewClient(nodeClientConfig)
+	nodeClient, err := a.client.nodeClient(addr)
 	if err != nil {
 		return nil, err
 	}
@@ -81,13 +107,22 @@ func (a *AllocFS) getNodeClient(nodeHTTPAddr, allocID string, q **QueryOptions)
 	return nodeClient, nil
 }
 
+// streamAddr returns the address of the node's file system endpoints,
+// preferring the address the node advertises for streaming traffic.
+func streamAddr(node *Node) string {
+	if node.StreamAddr != "" {
+		return node.StreamAddr
+	}
+	return node.HTTPAddr
+}
+
 // List is used to list the files at a given path of an allocation directory
 func (a *AllocFS) List(alloc *Allocation, path string, q *QueryOptions) ([]*AllocFileInfo, *QueryMeta, error) {
 	node, _, err := a.client.Nodes().Info(alloc.NodeID, &QueryOptions{})
 	if err != nil {
 		return nil, nil, err
 	}
-	nodeClient, err := a.getNodeClient(node.HTTPAddr, alloc.ID, &q)
+	nodeClient, err := a.getNodeClient(node, alloc.ID, &q)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -108,7 +143,7 @@ func (a *AllocFS) Stat(alloc *Allocation, path string, q *QueryOptions) (*AllocF
 	if err != nil {
 		return nil, nil, err
 	}
-	nodeClient, err := a.getNodeClient(node.HTTPAddr, alloc.ID, &q)
+	nodeClient, err := a.getNodeClient(node, alloc.ID, &q)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -122,6 +157,70 @@ func (a *AllocFS) Stat(alloc *Allocation, path string, q *QueryOptions) (*AllocF
 	return &resp, qm, nil
 }
 
+// StatBatch is used to stat many paths of an allocation directory in a single
+// request. The results are returned in the order of the paths and a path that
+// can not be stat-ed has its Error set.
+func (a *AllocFS) StatBatch(alloc *Allocation, paths []string, q *WriteOptions) ([]*AllocFileStat, *WriteMeta, error) {
+	node, _, err := a.client.Nodes().Info(alloc.NodeID, &QueryOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	nodeClient, err := a.getNodeClient(node, alloc.ID, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp []*AllocFileStat
+	wm, err := nodeClient.write(fmt.Sprintf("/v1/client/fs/stat-batch/%s", alloc.ID), paths, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, wm, nil
+}
+
+// Checksums is used to retrieve the SHA256 checksums of the files at or below
+// a given path of an allocation directory. The checksums are keyed by the
+// slash separated path of each file relative to the given path.
+func (a *AllocFS) Checksums(alloc *Allocation, path string, q *QueryOptions) (map[string]string, *QueryMeta, error) {
+	node, _, err := a.client.Nodes().Info(alloc.NodeID, &QueryOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	nodeClient, err := a.getNodeClient(node, alloc.ID, &q)
+	if err != nil {
+		return nil, nil, err
+	}
+	q.Params["path"] = path
+
+	var resp map[string]string
+	qm, err := nodeClient.query(fmt.Sprintf("/v1/client/fs/checksum/%s", alloc.ID), &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// Tar is used to stream a tar archive of the given files of an allocation
+// directory. The files are relative to the given path, as returned by
+// Checksums, and are archived under those names.
+func (a *AllocFS) Tar(alloc *Allocation, path string, files []string, q *WriteOptions) (io.ReadCloser, error) {
+	node, _, err := a.client.Nodes().Info(alloc.NodeID, &QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodeClient, err := a.getNodeClient(node, alloc.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("/v1/client/fs/tar/%s?path=%s", alloc.ID, url.QueryEscape(path))
+	r, err := nodeClient.rawWrite(endpoint, files, q)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
 // ReadAt is used to read bytes at a given offset until limit at the given path
 // in an allocation directory. If limit is <= 0, there is no limit.
 func (a *AllocFS) ReadAt(alloc *Allocation, path string, offset int64, limit int64, q *QueryOptions) (io.ReadCloser, error) {
@@ -130,7 +229,7 @@ func (a *AllocFS) ReadAt(alloc *Allocation, path string, offset int64, limit int
 		return nil, err
 	}
 
-	nodeClient, err := a.getNodeClient(node.HTTPAddr, alloc.ID, &q)
+	nodeClient, err := a.getNodeClient(node, alloc.ID, &q)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +252,7 @@ func (a *AllocFS) Cat(alloc *Allocation, path string, q *QueryOptions) (io.ReadC
 		return nil, err
 	}
 
-	nodeClient, err := a.getNodeClient(node.HTTPAddr, alloc.ID, &q)
+	nodeClient, err := a.getNodeClient(node, alloc.ID, &q)
 	if err != nil {
 		return nil, err
 	}
@@ -173,7 +272,11 @@ func (a *AllocFS) Cat(alloc *Allocation, path string, q *QueryOptions) (io.ReadC
 // * origin: Either "start" or "end" and defines from where the offset is applied.
 // * cancel: A channel that when closed, streaming will end.
 //
-// The return value is a channel that will emit StreamFrames as they are read.
+// The heartbeat rate may be requested by setting the "heartbeat" parameter of
+// the QueryOptions to a duration.
+//
+// The return value is a channel that will emit StreamFrames as they are read,
+// including the handshake and heartbeat frames.
 func (a *AllocFS) Stream(alloc *Allocation, path, origin string, offset int64,
 	cancel <-chan struct{}, q *QueryOptions) (<-chan *StreamFrame, error) {
 
@@ -182,7 +285,7 @@ func (a *AllocFS) Stream(alloc *Allocation, path, origin string, offset int64,
 		return nil, err
 	}
 
-	nodeClient, err := a.getNodeClient(node.HTTPAddr, alloc.ID, &q)
+	nodeClient, err := a.getNodeClient(node, alloc.ID, &q)
 	if err != nil {
 		return nil, err
 	}
@@ -220,11 +323,6 @@ func (a *AllocFS) Stream(alloc *Allocation, path, origin string, offset int64,
 				return
 			}
 
-			// Discard heartbeat frames
-			if frame.IsHeartbeat() {
-				continue
-			}
-
 			frames <- &frame
 		}
 	}()
@@ -232,6 +330,31 @@ func (a *AllocFS) Stream(alloc *Allocation, path, origin string, offset int64,
 	return frames, nil
 }
 
+// StreamLatest streams the most recently created file in a directory whose
+// name matches a pattern, switching to newer matching files as they are
+// created. A frame with a "file switched" FileEvent is emitted on each switch.
+// The parameters are:
+// * dir: path to the directory to follow.
+// * pattern: A file name pattern as accepted by filepath.Match.
+// * offset: The offset to start streaming data at in the first file.
+// * origin: Either "start" or "end" and defines from where the offset is applied.
+// * cancel: A channel that when closed, streaming will end.
+//
+// The return value is a channel that will emit StreamFrames as they are read.
+func (a *AllocFS) StreamLatest(alloc *Allocation, dir, pattern, origin string, offset int64,
+	cancel <-chan struct{}, q *QueryOptions) (<-chan *StreamFrame, error) {
+
+	if q == nil {
+		q = &QueryOptions{}
+	}
+	if q.Params == nil {
+		q.Params = make(map[string]string)
+	}
+	q.Params["follow_latest"] = "true"
+	q.Params["pattern"] = pattern
+	return a.Stream(alloc, dir, origin, offset, cancel, q)
+}
+
 // Logs streams the content of a tasks logs blocking on EOF.
 // The parameters are:
 // * allocation: the allocation to stream from.
@@ -242,7 +365,11 @@ func (a *AllocFS) Stream(alloc *Allocation, path, origin string, offset int64,
 // * offset: The offset to start streaming data at.
 // * cancel: A channel that when closed, streaming will end.
 //
-// The return value is a channel that will emit StreamFrames as they are read.
+// The heartbeat rate may be requested by setting the "heartbeat" parameter of
+// the QueryOptions to a duration.
+//
+// The return value is a channel that will emit StreamFrames as they are read,
+// including the handshake and heartbeat frames.
 func (a *AllocFS) Logs(alloc *Allocation, follow bool, task, logType, origin string,
 	offset int64, cancel <-chan struct{}, q *QueryOptions) (<-chan *StreamFrame, error) {
 
@@ -251,7 +378,7 @@ func (a *AllocFS) Logs(alloc *Allocation, follow bool, task, logType, origin str
 		return nil, err
 	}
 
-	nodeClient, err := a.getNodeClient(node.HTTPAddr, alloc.ID, &q)
+	nodeClient, err := a.getNodeClient(node, alloc.ID, &q)
 	if err != nil {
 		return nil, err
 	}
@@ -291,11 +418,6 @@ func (a *AllocFS) Logs(alloc *Allocation, follow bool, task, logType, origin str
 				return
 			}
 
-			// Discard heartbeat frames
-			if frame.IsHeartbeat() {
-				continue
-			}
-
 			frames <- &frame
 		}
 	}()
@@ -303,7 +425,9 @@ func (a *AllocFS) Logs(alloc *Allocation, follow bool, task, logType, origin str
 	return frames, nil
 }
 
-// FrameReader is used to convert a stream of frames into a read closer.
+// FrameReader is used to convert a stream of frames into a read closer. Once
+// the stream's handshake has been read, a Read returns ErrHeartbeatTimeout if
+// several heartbeats are missed.
 type FrameReader struct {
 	frames   <-chan *StreamFrame
 	cancelCh chan struct{}
@@ -313,6 +437,9 @@ type FrameReader struct {
 
 	unblockTime time.Duration
 
+	// heartbeatRate is the rate negotiated in the stream's handshake
+	heartbeatRate time.Duration
+
 	frame       *StreamFrame
 	frameOffset int
 
@@ -349,10 +476,15 @@ func (f *FrameReader) Read(p []byte) (n int, err error) {
 		return 0, io.EOF
 	}
 
-	if f.frame == nil {
-		var unblock <-chan time.Time
-		if f.unblockTime.Nanoseconds() > 0 {
-			unblock = time.After(f.unblockTime)
+	var unblock <-chan time.Time
+	if f.unblockTime.Nanoseconds() > 0 {
+		unblock = time.After(f.unblockTime)
+	}
+
+	for f.frame == nil {
+		var timeout <-chan time.Time
+		if f.heartbeatRate > 0 {
+			timeout = time.After(heartbeatMisses * f.heartbeatRate)
 		}
 
 		select {
@@ -360,10 +492,21 @@ func (f *FrameReader) Read(p []byte) (n int, err error) {
 			if !ok {
 				return 0, io.EOF
 			}
+
+			// Handshake and heartbeat frames only prove liveness
+			if frame.IsHandshake() {
+				f.heartbeatRate = frame.HeartbeatRate
+				continue
+			}
+			if frame.IsHeartbeat() {
+				continue
+			}
 			f.frame = frame
 
 			// Store the total offset into the file
 			f.byteOffset = int(f.frame.Offset)
+		case <-timeout:
+			return 0, ErrHeartbeatTimeout
 		case <-unblock:
 			return 0, nil
 		case <-f.cancelCh:
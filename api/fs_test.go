@@ -110,3 +110,50 @@ func TestFS_FrameReader_Unblock(t *testing.T) {
 	case <-time.After(300 * time.Millisecond):
 	}
 }
+
+func TestFS_FrameReader_HeartbeatTimeout(t *testing.T) {
+	framesCh := make(chan *StreamFrame, 3)
+	cancelCh := make(chan struct{})
+
+	r := NewFrameReader(framesCh, cancelCh)
+
+	// Negotiate a fast heartbeat and send a heartbeat and some data
+	framesCh <- &StreamFrame{HeartbeatRate: 10 * time.Millisecond}
+	framesCh <- &StreamFrame{}
+	framesCh <- &StreamFrame{File: "foo", Data: []byte("hello")}
+
+	p := make([]byte, 5)
+	n, err := r.Read(p)
+	if err != nil || string(p[:n]) != "hello" {
+		t.Fatalf("got %q, %v", p[:n], err)
+	}
+
+	// No more heartbeats arrive so the read times out
+	resultCh := make(chan error)
+	go func() {
+		_, err := r.Read(p)
+		resultCh <- err
+	}()
+
+	select {
+	case err := <-resultCh:
+		if err != ErrHeartbeatTimeout {
+			t.Fatalf("got %v; want %v", err, ErrHeartbeatTimeout)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("read should have timed out")
+	}
+}
+
+func TestFS_StreamAddr(t *testing.T) {
+	node := &Node{HTTPAddr: "127.0.0.1:4646"}
+	if addr := streamAddr(node); addr != node.HTTPAddr {
+		t.Fatalf("bad: %v", addr)
+	}
+
+	// The advertised streaming address is preferred
+	node.StreamAddr = "10.0.0.1:8080"
+	if addr := streamAddr(node); addr != node.StreamAddr {
+		t.Fatalf("bad: %v", addr)
+	}
+}
@@ -3,6 +3,9 @@ package api
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -61,6 +64,67 @@ func (j *Jobs) EnforceRegister(job *Job, modifyIndex uint64, q *WriteOptions) (s
 	return resp.EvalID, wm, nil
 }
 
+// JobRegionRegisterResult is the result of registering a job in one of the
+// regions passed to RegisterRegions.
+type JobRegionRegisterResult struct {
+	Region    string
+	EvalID    string
+	WriteMeta *WriteMeta
+	Error     error
+}
+
+// RegisterRegions registers the same job in each of the given regions, setting
+// its region for each of them. The regions are validated first so a typo
+// doesn't leave the job registered in only some of them. The results are
+// returned in the order of the regions, along with an error summarizing the
+// regions that failed.
+func (j *Jobs) RegisterRegions(job *Job, regions []string, q *WriteOptions) ([]*JobRegionRegisterResult, error) {
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("at least one region must be given")
+	}
+	if err := j.client.Regions().Validate(regions...); err != nil {
+		return nil, err
+	}
+
+	results := make([]*JobRegionRegisterResult, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		// Each region gets its own copy of the job and of the options
+		regionJob := *job
+		regionJob.Region = region
+		var opts WriteOptions
+		if q != nil {
+			opts = *q
+		}
+		opts.Region = region
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			evalID, wm, err := j.Register(&regionJob, &opts)
+			results[i] = &JobRegionRegisterResult{
+				Region:    regionJob.Region,
+				EvalID:    evalID,
+				WriteMeta: wm,
+				Error:     err,
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, result := range results {
+		if result.Error != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", result.Region, result.Error))
+		}
+	}
+	if len(failed) != 0 {
+		return results, fmt.Errorf("failed to register job in %d of %d regions: %s",
+			len(failed), len(regions), strings.Join(failed, "; "))
+	}
+	return results, nil
+}
+
 // List is used to list all of the existing jobs.
 func (j *Jobs) List(q *QueryOptions) ([]*JobListStub, *QueryMeta, error) {
 	var resp []*JobListStub
@@ -141,6 +205,28 @@ func (j *Jobs) PeriodicForce(jobID string, q *WriteOptions) (string, *WriteMeta,
 	return resp.EvalID, wm, nil
 }
 
+// PeriodicForceMissed spawns the instance of the periodic job for its earliest
+// skipped launch and returns the eval ID
+func (j *Jobs) PeriodicForceMissed(jobID string, q *WriteOptions) (string, *WriteMeta, error) {
+	var resp periodicForceResponse
+	wm, err := j.client.write("/v1/job/"+jobID+"/periodic/force?missed=true", nil, &resp, q)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.EvalID, wm, nil
+}
+
+// PeriodicLaunch is used to retrieve the last launch and the skipped launches
+// of a periodic job
+func (j *Jobs) PeriodicLaunch(jobID string, q *QueryOptions) (*PeriodicLaunch, *QueryMeta, error) {
+	var resp PeriodicLaunch
+	qm, err := j.client.query("/v1/job/"+jobID+"/periodic/launch", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
 func (j *Jobs) Plan(job *Job, diff bool, q *WriteOptions) (*JobPlanResponse, *WriteMeta, error) {
 	if job == nil {
 		return nil, nil, fmt.Errorf("must pass non-nil job")
@@ -159,6 +245,106 @@ func (j *Jobs) Plan(job *Job, diff bool, q *WriteOptions) (*JobPlanResponse, *Wr
 	return &resp, wm, nil
 }
 
+// Diff is used to compare the given job against the registered version of the
+// job without evaluating it. Contextual includes unchanged fields in the diff.
+func (j *Jobs) Diff(job *Job, contextual bool, q *WriteOptions) (*JobDiffResponse, *WriteMeta, error) {
+	if job == nil {
+		return nil, nil, fmt.Errorf("must pass non-nil job")
+	}
+
+	var resp JobDiffResponse
+	req := &JobDiffRequest{
+		Job:        job,
+		Contextual: contextual,
+	}
+	wm, err := j.client.write("/v1/job/"+job.ID+"/diff", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &resp, wm, nil
+}
+
+// UpdatePriority is used to change the priority of an existing job without
+// resubmitting it. The job's queued evaluations are updated as well.
+func (j *Jobs) UpdatePriority(jobID string, priority int, q *WriteOptions) (*JobPriorityResponse, *WriteMeta, error) {
+	var resp JobPriorityResponse
+	req := &JobPriorityRequest{
+		JobID:    jobID,
+		Priority: priority,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/priority", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Versions is used to retrieve the tracked versions of a job, ordered from
+// the most recent version.
+func (j *Jobs) Versions(jobID string, q *QueryOptions) ([]*Job, *QueryMeta, error) {
+	var resp []*Job
+	qm, err := j.client.query("/v1/job/"+jobID+"/versions", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// VersionDiff is used to compare two tracked versions of a job. If nil, to
+// defaults to the most recent version and from to the version preceding to.
+func (j *Jobs) VersionDiff(jobID string, from, to *uint64, q *QueryOptions) (*JobDiff, *QueryMeta, error) {
+	params := make(map[string]string)
+	if from != nil {
+		params["from"] = strconv.FormatUint(*from, 10)
+	}
+	if to != nil {
+		params["to"] = strconv.FormatUint(*to, 10)
+	}
+
+	var resp JobDiff
+	qm, err := j.client.query("/v1/job/"+jobID+"/diff", &resp, withParams(q, params))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Revert is used to revert a job to a prior version. If enforcePriorVersion
+// is set, the revert only succeeds if the job is currently at that version.
+// It returns the ID of the evaluation, along with any errors encountered.
+func (j *Jobs) Revert(jobID string, version uint64, enforcePriorVersion *uint64,
+	q *WriteOptions) (string, *WriteMeta, error) {
+	var resp registerJobResponse
+	req := &JobRevertRequest{
+		JobID:               jobID,
+		JobVersion:          version,
+		EnforcePriorVersion: enforcePriorVersion,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/revert", req, &resp, q)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.EvalID, wm, nil
+}
+
+// Dispatch is used to dispatch an instance of the given parameterized job
+// with the passed meta data and payload.
+func (j *Jobs) Dispatch(jobID string, meta map[string]string,
+	payload []byte, q *WriteOptions) (*JobDispatchResponse, *WriteMeta, error) {
+	var resp JobDispatchResponse
+	req := &JobDispatchRequest{
+		JobID:   jobID,
+		Meta:    meta,
+		Payload: payload,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/dispatch", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 func (j *Jobs) Summary(jobID string, q *QueryOptions) (*JobSummary, *QueryMeta, error) {
 	var resp JobSummary
 	qm, err := j.client.query("/v1/job/"+jobID+"/summary", &resp, q)
@@ -175,8 +361,13 @@ type periodicForceResponse struct {
 
 // UpdateStrategy is for serializing update strategy for a job.
 type UpdateStrategy struct {
-	Stagger     time.Duration
-	MaxParallel int
+	Stagger         time.Duration
+	MaxParallel     int
+	HealthCheck     string
+	MinHealthyTime  time.Duration
+	HealthyDeadline time.Duration
+	AutoRevert      bool
+	Canary          int
 }
 
 // PeriodicConfig is for serializing periodic config for a job.
@@ -187,12 +378,37 @@ type PeriodicConfig struct {
 	ProhibitOverlap bool
 }
 
+// PeriodicLaunch is the launch state of a periodic job
+type PeriodicLaunch struct {
+	ID      string
+	Launch  time.Time
+	Skipped []time.Time
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// FailoverConfig is for serializing the failover config of a job.
+type FailoverConfig struct {
+	Region string
+	After  time.Duration
+}
+
+// ParameterizedJobConfig is for serializing the parameters a job can be
+// dispatched with.
+type ParameterizedJobConfig struct {
+	Payload      string
+	MetaRequired []string
+	MetaOptional []string
+}
+
 // Job is used to serialize a job.
 type Job struct {
 	Region            string
 	ID                string
 	ParentID          string
 	Name              string
+	Namespace         string
 	Type              string
 	Priority          int
 	AllAtOnce         bool
@@ -200,11 +416,19 @@ type Job struct {
 	Constraints       []*Constraint
 	TaskGroups        []*TaskGroup
 	Update            *UpdateStrategy
+	StopStrategy      string
 	Periodic          *PeriodicConfig
+	Failover          *FailoverConfig
+	FailoverFrom      string
+	FailoverTo        string
+	ParameterizedJob  *ParameterizedJobConfig
+	Payload           []byte
 	Meta              map[string]string
 	VaultToken        string
 	Status            string
 	StatusDescription string
+	Version           uint64
+	Stable            bool
 	CreateIndex       uint64
 	ModifyIndex       uint64
 	JobModifyIndex    uint64
@@ -351,6 +575,45 @@ type JobPlanResponse struct {
 	NextPeriodicLaunch time.Time
 }
 
+type JobDiffRequest struct {
+	Job        *Job
+	Contextual bool
+}
+
+type JobDiffResponse struct {
+	JobModifyIndex uint64
+	Diff           *JobDiff
+}
+
+type JobPriorityRequest struct {
+	JobID    string
+	Priority int
+}
+
+type JobPriorityResponse struct {
+	JobModifyIndex uint64
+	UpdatedEvals   int
+}
+
+type JobRevertRequest struct {
+	JobID               string
+	JobVersion          uint64
+	EnforcePriorVersion *uint64 `json:",omitempty"`
+}
+
+type JobDispatchRequest struct {
+	JobID   string
+	Payload []byte
+	Meta    map[string]string
+}
+
+type JobDispatchResponse struct {
+	DispatchedJobID string
+	EvalID          string
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+}
+
 type JobDiff struct {
 	Type       string
 	ID         string
@@ -392,6 +655,7 @@ type ObjectDiff struct {
 
 type PlanAnnotations struct {
 	DesiredTGUpdates map[string]*DesiredUpdates
+	PreemptedAllocs  []*AllocationListStub
 }
 
 type DesiredUpdates struct {
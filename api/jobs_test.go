@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/testutil"
 )
@@ -289,6 +290,50 @@ func TestJobs_Evaluations(t *testing.T) {
 	}
 }
 
+func TestJobs_Evaluations_Blocking(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	job := testJob()
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	evals, qm, err := jobs.Evaluations("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+
+	// Force an evaluation while blocking on the evaluations of the job
+	errCh := make(chan error, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _, err := jobs.ForceEvaluate("job1", nil)
+		errCh <- err
+	}()
+
+	// The query only returns once the evaluations changed
+	index, known := qm.LastIndex, len(evals)
+	for i := 0; len(evals) == known; i++ {
+		if i == 10 {
+			t.Fatalf("forced evaluation not returned: %#v", evals)
+		}
+		q := &QueryOptions{WaitIndex: index, WaitTime: 5 * time.Second}
+		evals, qm, err = jobs.Evaluations("job1", q)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if qm.LastIndex <= index {
+			t.Fatalf("returned without a change: %d <= %d", qm.LastIndex, index)
+		}
+		index = qm.LastIndex
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
 func TestJobs_Deregister(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -368,6 +413,39 @@ func TestJobs_ForceEvaluate(t *testing.T) {
 	t.Fatalf("evaluation %q missing", evalID)
 }
 
+func TestJobs_PeriodicLaunch(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Querying a non-existent job fails
+	_, _, err := jobs.PeriodicLaunch("job1", nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %#v", err)
+	}
+
+	// Create a new job
+	job := testPeriodicJob()
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	launch, qm, err := jobs.PeriodicLaunch(job.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if launch.ID != job.ID || launch.Launch.IsZero() || len(launch.Skipped) != 0 {
+		t.Fatalf("bad: %#v", launch)
+	}
+
+	// Forcing a missed launch fails as no launch was skipped
+	_, _, err = jobs.PeriodicForceMissed(job.ID, nil)
+	if err == nil || !strings.Contains(err.Error(), "no skipped launches") {
+		t.Fatalf("expected no skipped launches error, got: %#v", err)
+	}
+}
+
 func TestJobs_PeriodicForce(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -490,6 +568,182 @@ func TestJobs_Plan(t *testing.T) {
 	}
 }
 
+func TestJobs_UpdatePriority(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Updating a non-existent job fails
+	_, _, err := jobs.UpdatePriority("job1", 80, nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+
+	// Create a job and register it
+	job := testJob()
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, wm, err := jobs.UpdatePriority(job.ID, 80, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if resp.JobModifyIndex == 0 {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	out, _, err := jobs.Info(job.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.Priority != 80 {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestJobs_VersionsRevert(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Register a job twice to create two versions
+	job := testJob()
+	priority := job.Priority
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	job.Priority = priority + 10
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	versions, qm, err := jobs.Versions(job.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 0 {
+		t.Fatalf("bad: %#v", versions)
+	}
+	if versions[0].Priority != priority+10 || versions[1].Priority != priority {
+		t.Fatalf("bad priorities: %d %d", versions[0].Priority, versions[1].Priority)
+	}
+
+	// Compare the two versions
+	diff, qm, err := jobs.VersionDiff(job.ID, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if diff.Type != "Edited" || len(diff.Fields) != 1 || diff.Fields[0].Name != "Priority" {
+		t.Fatalf("bad: %#v", diff)
+	}
+
+	// Reverting while enforcing the wrong prior version fails
+	prior := uint64(0)
+	_, _, err = jobs.Revert(job.ID, 0, &prior, nil)
+	if err == nil || !strings.Contains(err.Error(), "enforcing version") {
+		t.Fatalf("expected enforce version error, got: %v", err)
+	}
+
+	// Revert to the first version
+	prior = 1
+	evalID, wm, err := jobs.Revert(job.ID, 0, &prior, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if evalID == "" {
+		t.Fatalf("missing eval ID")
+	}
+
+	out, _, err := jobs.Info(job.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.Version != 2 || out.Priority != priority {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestJobs_Dispatch(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Dispatching a job that isn't parameterized fails
+	job := testJob()
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	_, _, err := jobs.Dispatch(job.ID, nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "not a parameterized job") {
+		t.Fatalf("expected parameterized job error, got: %v", err)
+	}
+
+	// Register a parameterized job requiring meta
+	job = testJob()
+	job.ID = "job2"
+	job.ParameterizedJob = &ParameterizedJobConfig{
+		MetaRequired: []string{"foo"},
+	}
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, wm, err := jobs.Dispatch(job.ID, map[string]string{"foo": "bar"}, []byte("hello"), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	if resp.EvalID == "" || !strings.HasPrefix(resp.DispatchedJobID, "job2/dispatch-") {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Check the dispatched job carries the meta data and payload
+	out, _, err := jobs.Info(resp.DispatchedJobID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.ParentID != job.ID || out.Meta["foo"] != "bar" || string(out.Payload) != "hello" {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestJobs_Diff(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Create a job and register it
+	job := testJob()
+	_, wm, err := jobs.Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Check that passing a nil job fails
+	if _, _, err := jobs.Diff(nil, false, nil); err == nil {
+		t.Fatalf("expect an error when job isn't provided")
+	}
+
+	// Make a diff request against the registered job
+	diffResp, _, err := jobs.Diff(job, true, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diffResp.JobModifyIndex == 0 {
+		t.Fatalf("bad JobModifyIndex value: %#v", diffResp)
+	}
+	if diffResp.Diff == nil || diffResp.Diff.Type != "None" {
+		t.Fatalf("bad diff: %#v", diffResp.Diff)
+	}
+}
+
 func TestJobs_JobSummary(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -627,3 +881,60 @@ func TestJobs_Sort(t *testing.T) {
 		t.Fatalf("\n\n%#v\n\n%#v", jobs, expect)
 	}
 }
+
+func TestJobs_RegisterRegions(t *testing.T) {
+	c1, s1 := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.Region = "regionA"
+	})
+	defer s1.Stop()
+
+	c2, s2 := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.Region = "regionB"
+	})
+	defer s2.Stop()
+
+	// Join the servers
+	if _, err := c2.Agent().Join(s1.SerfAddr); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	testutil.WaitForResult(func() (bool, error) {
+		return c1.Regions().Validate("regionA", "regionB") == nil, nil
+	}, func(err error) {
+		t.Fatalf("regions not joined")
+	})
+
+	// Unknown regions fail before registering anywhere
+	job := testJob()
+	if _, err := c1.Jobs().RegisterRegions(job, []string{"regionA", "regionC"}, nil); err == nil {
+		t.Fatalf("expected unknown region error")
+	}
+	if jobs, _, err := c1.Jobs().List(nil); err != nil || len(jobs) != 0 {
+		t.Fatalf("job registered: %v %v", jobs, err)
+	}
+
+	// The job is registered in each region
+	results, err := c1.Jobs().RegisterRegions(job, []string{"regionA", "regionB"}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("bad: %#v", results)
+	}
+	for i, region := range []string{"regionA", "regionB"} {
+		if results[i].Region != region || results[i].EvalID == "" || results[i].Error != nil {
+			t.Fatalf("bad result: %#v", results[i])
+		}
+		out, _, err := c1.Jobs().Info(job.ID, &QueryOptions{Region: region})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.Region != region {
+			t.Fatalf("bad region: %q", out.Region)
+		}
+	}
+
+	// The job passed in is left untouched
+	if job.Region != "region1" {
+		t.Fatalf("job modified: %q", job.Region)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"sort"
+)
+
+// Namespaces is used to query the namespaces endpoints.
+type Namespaces struct {
+	client *Client
+}
+
+// Namespaces returns a new handle on the namespaces.
+func (c *Client) Namespaces() *Namespaces {
+	return &Namespaces{client: c}
+}
+
+// List is used to dump all of the namespaces.
+func (n *Namespaces) List(q *QueryOptions) ([]*Namespace, *QueryMeta, error) {
+	var resp []*Namespace
+	qm, err := n.client.query("/v1/namespaces", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(NamespaceNameSort(resp))
+	return resp, qm, nil
+}
+
+// PrefixList is used to list the namespaces whose name starts with the
+// prefix.
+func (n *Namespaces) PrefixList(prefix string) ([]*Namespace, *QueryMeta, error) {
+	return n.List(&QueryOptions{Prefix: prefix})
+}
+
+// Info is used to query a single namespace by its name.
+func (n *Namespaces) Info(name string, q *QueryOptions) (*Namespace, *QueryMeta, error) {
+	var resp Namespace
+	qm, err := n.client.query("/v1/namespace/"+name, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Register is used to create or update a namespace.
+func (n *Namespaces) Register(namespace *Namespace, q *WriteOptions) (*WriteMeta, error) {
+	wm, err := n.client.write("/v1/namespace/"+namespace.Name, namespace, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Delete is used to delete a namespace that no longer has any jobs.
+func (n *Namespaces) Delete(name string, q *WriteOptions) (*WriteMeta, error) {
+	wm, err := n.client.delete("/v1/namespace/"+name, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Namespace is used to serialize a namespace.
+type Namespace struct {
+	Name        string
+	Description string
+	Quota       string
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// NamespaceNameSort is a wrapper to sort namespaces by name.
+type NamespaceNameSort []*Namespace
+
+func (n NamespaceNameSort) Len() int {
+	return len(n)
+}
+
+func (n NamespaceNameSort) Less(i, j int) bool {
+	return n[i].Name < n[j].Name
+}
+
+func (n NamespaceNameSort) Swap(i, j int) {
+	n[i], n[j] = n[j], n[i]
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestNamespaces_Register_Info_Delete(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	n := c.Namespaces()
+
+	// Register a namespace
+	namespace := &Namespace{
+		Name:        "engineering",
+		Description: "engineering team",
+	}
+	wm, err := n.Register(namespace, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Query it by prefix and name
+	result, qm, err := n.PrefixList("eng")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(result) != 1 || result[0].Name != namespace.Name {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	out, qm, err := n.Info(namespace.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if out.Description != namespace.Description {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Delete the namespace
+	wm, err = n.Delete(namespace.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	if _, _, err := n.Info(namespace.Name, nil); err == nil {
+		t.Fatalf("expected the namespace to be deleted")
+	}
+}
+
+func TestNamespaces_JobIsolation(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Registering a job in an unknown namespace fails
+	job := testJob()
+	wo := &WriteOptions{Namespace: "engineering"}
+	if _, _, err := jobs.Register(job, wo); err == nil {
+		t.Fatalf("expected an error registering a job in an unknown namespace")
+	}
+
+	if _, err := c.Namespaces().Register(&Namespace{Name: "engineering"}, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, _, err := jobs.Register(job, wo); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The job is only listed in its namespace
+	result, _, err := jobs.List(&QueryOptions{Namespace: "engineering"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(result) != 1 || result[0].ID != job.ID {
+		t.Fatalf("bad: %#v", result)
+	}
+	result, _, err = jobs.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(result) != 0 {
+		t.Fatalf("expected no jobs in the default namespace, got: %#v", result)
+	}
+
+	// A namespace with jobs can not be deleted
+	if _, err := c.Namespaces().Delete("engineering", nil); err == nil {
+		t.Fatalf("expected an error deleting a namespace with jobs")
+	}
+}
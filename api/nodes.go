@@ -1,11 +1,10 @@
 package api
 
 import (
-	"fmt"
+	"net/url"
 	"sort"
 	"strconv"
-
-	"github.com/hashicorp/go-cleanhttp"
+	"time"
 )
 
 // Nodes is used to query node-related API endpoints
@@ -33,6 +32,25 @@ func (n *Nodes) PrefixList(prefix string) ([]*NodeListStub, *QueryMeta, error) {
 	return n.List(&QueryOptions{Prefix: prefix})
 }
 
+// NodeListFilter filters the nodes listed by the servers. Empty fields don't
+// filter.
+type NodeListFilter struct {
+	Status     string
+	Datacenter string
+	NodeClass  string
+	NamePrefix string
+}
+
+// ListFiltered returns the nodes passing the filter, filtered by the servers.
+func (n *Nodes) ListFiltered(filter *NodeListFilter, q *QueryOptions) ([]*NodeListStub, *QueryMeta, error) {
+	return n.List(withParams(q, map[string]string{
+		"status":      filter.Status,
+		"datacenter":  filter.Datacenter,
+		"class":       filter.NodeClass,
+		"name_prefix": filter.NamePrefix,
+	}))
+}
+
 // Info is used to query a specific node by its ID.
 func (n *Nodes) Info(nodeID string, q *QueryOptions) (*Node, *QueryMeta, error) {
 	var resp Node
@@ -45,8 +63,24 @@ func (n *Nodes) Info(nodeID string, q *QueryOptions) (*Node, *QueryMeta, error)
 
 // ToggleDrain is used to toggle drain mode on/off for a given node.
 func (n *Nodes) ToggleDrain(nodeID string, drain bool, q *WriteOptions) (*WriteMeta, error) {
-	drainArg := strconv.FormatBool(drain)
-	wm, err := n.client.write("/v1/node/"+nodeID+"/drain?enable="+drainArg, nil, nil, q)
+	return n.UpdateDrain(nodeID, drain, nil, q)
+}
+
+// UpdateDrain is used to toggle drain mode on/off for a given node. The
+// strategy controls how the allocations of the node are migrated when
+// enabling drain mode.
+func (n *Nodes) UpdateDrain(nodeID string, drain bool, strategy *DrainStrategy, q *WriteOptions) (*WriteMeta, error) {
+	v := url.Values{}
+	v.Set("enable", strconv.FormatBool(drain))
+	if strategy != nil {
+		if strategy.Deadline != 0 {
+			v.Set("deadline", strategy.Deadline.String())
+		}
+		if strategy.IgnoreSystemJobs {
+			v.Set("ignore_system", "true")
+		}
+	}
+	wm, err := n.client.write("/v1/node/"+nodeID+"/drain?"+v.Encode(), nil, nil, q)
 	if err != nil {
 		return nil, err
 	}
@@ -75,17 +109,7 @@ func (n *Nodes) ForceEvaluate(nodeID string, q *WriteOptions) (string, *WriteMet
 }
 
 func (n *Nodes) Stats(nodeID string, q *QueryOptions) (*HostStats, error) {
-	node, _, err := n.client.Nodes().Info(nodeID, q)
-	if err != nil {
-		return nil, err
-	}
-	if node.HTTPAddr == "" {
-		return nil, fmt.Errorf("http addr of the node %q is running is not advertised", nodeID)
-	}
-	client, err := NewClient(&Config{
-		Address:    fmt.Sprintf("http://%s", node.HTTPAddr),
-		HttpClient: cleanhttp.DefaultClient(),
-	})
+	client, err := n.client.GetNodeClient(nodeID, q)
 	if err != nil {
 		return nil, err
 	}
@@ -102,20 +126,40 @@ type Node struct {
 	Datacenter        string
 	Name              string
 	HTTPAddr          string
+	TLSEnabled        bool
+	StreamAddr        string
 	Attributes        map[string]string
 	Resources         *Resources
 	Reserved          *Resources
+	MinDynamicPort    int
+	MaxDynamicPort    int
 	Links             map[string]string
 	Meta              map[string]string
 	NodeClass         string
 	Drain             bool
+	DrainStrategy     *DrainStrategy
 	Status            string
 	StatusDescription string
 	StatusUpdatedAt   int64
+	HostVolumes       map[string]*HostVolumeInfo
 	CreateIndex       uint64
 	ModifyIndex       uint64
 }
 
+// DrainStrategy controls how the allocations of a draining node are migrated.
+// A negative deadline migrates all the allocations immediately.
+type DrainStrategy struct {
+	Deadline         time.Duration
+	IgnoreSystemJobs bool
+	ForceDeadline    time.Time
+}
+
+// HostVolumeInfo is a directory of a node that can be mounted into tasks.
+type HostVolumeInfo struct {
+	Path     string
+	ReadOnly bool
+}
+
 // HostStats represents resource usage stats of the host running a Nomad client
 type HostStats struct {
 	Memory           *HostMemoryStats
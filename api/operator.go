@@ -0,0 +1,250 @@
+package api
+
+import (
+	"strconv"
+	"time"
+)
+
+// Operator is used to perform cluster-wide operations on the servers.
+type Operator struct {
+	client *Client
+}
+
+// Operator returns a handle on the operator endpoints.
+func (c *Client) Operator() *Operator {
+	return &Operator{client: c}
+}
+
+// KeyringResponse is the result of an operation on the gossip keyring of the
+// servers.
+type KeyringResponse struct {
+	// Messages maps the name of the servers to the error they reported
+	Messages map[string]string
+
+	// Keys maps the installed keys to the number of servers having them
+	Keys map[string]int
+
+	// NumNodes is the number of servers in the gossip pool
+	NumNodes int
+}
+
+// KeyringRequest is used to install, use or remove a gossip encryption key
+type KeyringRequest struct {
+	Key string
+}
+
+// KeyringList lists the gossip encryption keys installed on the servers.
+func (op *Operator) KeyringList(q *QueryOptions) (*KeyringResponse, error) {
+	var resp KeyringResponse
+	if _, err := op.client.query("/v1/operator/keyring/list", &resp, q); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// KeyringInstall installs a new gossip encryption key on all the servers.
+func (op *Operator) KeyringInstall(key string, q *WriteOptions) (*KeyringResponse, error) {
+	return op.keyringWrite("install", key, q)
+}
+
+// KeyringUse changes the primary gossip encryption key of all the servers.
+// The key must already be installed.
+func (op *Operator) KeyringUse(key string, q *WriteOptions) (*KeyringResponse, error) {
+	return op.keyringWrite("use", key, q)
+}
+
+// KeyringRemove removes a gossip encryption key from all the servers. The
+// primary key can't be removed.
+func (op *Operator) KeyringRemove(key string, q *WriteOptions) (*KeyringResponse, error) {
+	return op.keyringWrite("remove", key, q)
+}
+
+func (op *Operator) keyringWrite(action, key string, q *WriteOptions) (*KeyringResponse, error) {
+	var resp KeyringResponse
+	req := &KeyringRequest{Key: key}
+	if _, err := op.client.write("/v1/operator/keyring/"+action, req, &resp, q); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RaftServer has information about a server in the Raft configuration.
+type RaftServer struct {
+	// Node is the name of the server, as known by the gossip pool. It is
+	// empty if the server isn't a known member of the region.
+	Node string
+
+	// Address is the IP:port of the server, used as its identity by Raft
+	Address string
+
+	// Leader is true if this server is the current cluster leader.
+	Leader bool
+
+	// Voter is true if this server has a vote in the cluster.
+	Voter bool
+}
+
+// RaftConfiguration is returned when querying for the current Raft
+// configuration.
+type RaftConfiguration struct {
+	// Servers has the list of servers in the Raft configuration.
+	Servers []*RaftServer
+
+	// Index has the Raft index of this configuration.
+	Index uint64
+}
+
+// RaftGetConfiguration is used to query the current Raft peer set.
+func (op *Operator) RaftGetConfiguration(q *QueryOptions) (*RaftConfiguration, error) {
+	var resp RaftConfiguration
+	if _, err := op.client.query("/v1/operator/raft/configuration", &resp, q); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RaftRemovePeerByAddress is used to kick a stale peer (one that is in the Raft
+// quorum but no longer known to Serf) by address in the form of "IP:port".
+func (op *Operator) RaftRemovePeerByAddress(address string, q *WriteOptions) error {
+	r := op.client.newRequest("DELETE", "/v1/operator/raft/peer")
+	r.setWriteOptions(q)
+	r.params.Set("address", address)
+
+	_, resp, err := requireOK(op.client.doRequest(r))
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+	return nil
+}
+
+// AutopilotConfiguration is the cluster wide configuration of Autopilot,
+// which manages the Raft peers of the servers.
+type AutopilotConfiguration struct {
+	// CleanupDeadServers controls whether to remove dead servers from the Raft
+	// peer set once a replacement joins the cluster.
+	CleanupDeadServers bool
+
+	// LastContactThreshold is the limit on the amount of time a server can go
+	// without leader contact before being considered unhealthy.
+	LastContactThreshold time.Duration
+
+	// MaxTrailingLogs is the amount of entries in the Raft log that a server
+	// can be behind before being considered unhealthy.
+	MaxTrailingLogs uint64
+
+	// ServerStabilizationTime is the minimum amount of time a new server must
+	// be healthy before it is added to the Raft peer set.
+	ServerStabilizationTime time.Duration
+
+	// CreateIndex holds the index corresponding the creation of this
+	// configuration.
+	CreateIndex uint64
+
+	// ModifyIndex will be set to the index of the last update when retrieving
+	// the configuration, and is used for check-and-set updates.
+	ModifyIndex uint64
+}
+
+// ServerHealth is the health of a server as determined by Autopilot.
+type ServerHealth struct {
+	// Name is the name of the server.
+	Name string
+
+	// Address is the IP:port of the server.
+	Address string
+
+	// Version is the Nomad version of the server.
+	Version string
+
+	// Leader is true if this server is the current cluster leader.
+	Leader bool
+
+	// SerfStatus is the status of the server in the gossip pool.
+	SerfStatus string
+
+	// LastContact is the time since the last contact of the server with the
+	// leader.
+	LastContact time.Duration
+
+	// LastTerm is the term of the last log entry of the server.
+	LastTerm uint64
+
+	// LastIndex is the index of the last log entry of the server.
+	LastIndex uint64
+
+	// Healthy is whether the server is healthy according to the Autopilot
+	// configuration.
+	Healthy bool
+
+	// Voter is whether the server is a Raft peer with a vote in the cluster.
+	Voter bool
+
+	// StableSince is the time since the server has been healthy.
+	StableSince time.Time
+}
+
+// OperatorHealthReply is the health of the servers of the region.
+type OperatorHealthReply struct {
+	// Healthy is true if all the voting servers are healthy.
+	Healthy bool
+
+	// FailureTolerance is the number of healthy voting servers that could be
+	// lost without the cluster losing its quorum.
+	FailureTolerance int
+
+	// Servers is the health of each server.
+	Servers []ServerHealth
+}
+
+// AutopilotGetConfiguration is used to query the current Autopilot
+// configuration.
+func (op *Operator) AutopilotGetConfiguration(q *QueryOptions) (*AutopilotConfiguration, error) {
+	var resp AutopilotConfiguration
+	if _, err := op.client.query("/v1/operator/autopilot/configuration", &resp, q); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AutopilotSetConfiguration is used to set the current Autopilot
+// configuration.
+func (op *Operator) AutopilotSetConfiguration(conf *AutopilotConfiguration, q *WriteOptions) error {
+	if _, err := op.client.write("/v1/operator/autopilot/configuration", conf, nil, q); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AutopilotCASConfiguration is used to perform a check-and-set update on the
+// Autopilot configuration. The ModifyIndex value is respected. Returns true
+// on success or false on failure.
+func (op *Operator) AutopilotCASConfiguration(conf *AutopilotConfiguration, q *WriteOptions) (bool, error) {
+	r := op.client.newRequest("PUT", "/v1/operator/autopilot/configuration")
+	r.setWriteOptions(q)
+	r.params.Set("cas", strconv.FormatUint(conf.ModifyIndex, 10))
+	r.obj = conf
+
+	_, resp, err := requireOK(op.client.doRequest(r))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var out bool
+	if err := decodeBody(resp, &out); err != nil {
+		return false, err
+	}
+	return out, nil
+}
+
+// AutopilotServerHealth is used to query the health of the servers, as
+// determined by Autopilot on the leader.
+func (op *Operator) AutopilotServerHealth(q *QueryOptions) (*OperatorHealthReply, error) {
+	var resp OperatorHealthReply
+	if _, err := op.client.query("/v1/operator/autopilot/health", &resp, q); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestOperator_Keyring(t *testing.T) {
+	const (
+		key1 = "pUqJrVyVRj5jsiYEkM/tFQ=="
+		key2 = "kZyFABeAmc64UMTrm9XuKA=="
+	)
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.Server.EncryptKey = key1
+	})
+	defer s.Stop()
+	operator := c.Operator()
+
+	// The initial key is installed
+	resp, err := operator.KeyringList(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Keys) != 1 || resp.Keys[key1] != 1 {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Install a new key and make it the primary key
+	if _, err := operator.KeyringInstall(key2, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := operator.KeyringUse(key2, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The primary key can't be removed
+	if _, err := operator.KeyringRemove(key2, nil); err == nil {
+		t.Fatalf("expected err")
+	}
+
+	// Remove the initial key
+	if _, err := operator.KeyringRemove(key1, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp, err = operator.KeyringList(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Keys) != 1 || resp.Keys[key2] != 1 {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestOperator_RaftGetConfiguration(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	operator := c.Operator()
+	var out *RaftConfiguration
+	testutil.WaitForResult(func() (bool, error) {
+		var err error
+		out, err = operator.RaftGetConfiguration(nil)
+		return err == nil, err
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	if len(out.Servers) != 1 ||
+		!out.Servers[0].Leader ||
+		!out.Servers[0].Voter {
+		t.Fatalf("bad: %v", out)
+	}
+}
+
+func TestOperator_RaftRemovePeerByAddress(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	// If we get this error, it proves we sent the address all the way
+	// through.
+	operator := c.Operator()
+	err := operator.RaftRemovePeerByAddress("nope", nil)
+	if err == nil || !strings.Contains(err.Error(),
+		"address \"nope\" was not found in the Raft configuration") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestOperator_AutopilotGetSetConfiguration(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	operator := c.Operator()
+	var config *AutopilotConfiguration
+	testutil.WaitForResult(func() (bool, error) {
+		var err error
+		config, err = operator.AutopilotGetConfiguration(nil)
+		return err == nil, err
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	if !config.CleanupDeadServers {
+		t.Fatalf("bad: %v", config)
+	}
+
+	// Change a config setting
+	newConf := &AutopilotConfiguration{CleanupDeadServers: false}
+	if err := operator.AutopilotSetConfiguration(newConf, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	config, err := operator.AutopilotGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.CleanupDeadServers {
+		t.Fatalf("bad: %v", config)
+	}
+}
+
+func TestOperator_AutopilotCASConfiguration(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	operator := c.Operator()
+	var config *AutopilotConfiguration
+	testutil.WaitForResult(func() (bool, error) {
+		var err error
+		config, err = operator.AutopilotGetConfiguration(nil)
+		return err == nil, err
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Pass an invalid ModifyIndex
+	{
+		newConf := &AutopilotConfiguration{
+			CleanupDeadServers: false,
+			ModifyIndex:        config.ModifyIndex - 1,
+		}
+		resp, err := operator.AutopilotCASConfiguration(newConf, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp {
+			t.Fatalf("bad: %v", resp)
+		}
+	}
+
+	// Pass a valid ModifyIndex
+	{
+		newConf := &AutopilotConfiguration{
+			CleanupDeadServers: false,
+			ModifyIndex:        config.ModifyIndex,
+		}
+		resp, err := operator.AutopilotCASConfiguration(newConf, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !resp {
+			t.Fatalf("bad: %v", resp)
+		}
+	}
+}
+
+func TestOperator_AutopilotServerHealth(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	operator := c.Operator()
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := operator.AutopilotServerHealth(nil)
+		if err != nil {
+			return false, err
+		}
+		if !out.Healthy || len(out.Servers) != 1 || !out.Servers[0].Healthy {
+			return false, fmt.Errorf("bad: %v", out)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
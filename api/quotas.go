@@ -0,0 +1,109 @@
+package api
+
+import (
+	"sort"
+)
+
+// Quotas is used to query the quotas endpoints.
+type Quotas struct {
+	client *Client
+}
+
+// Quotas returns a new handle on the quotas.
+func (c *Client) Quotas() *Quotas {
+	return &Quotas{client: c}
+}
+
+// List is used to dump all of the quota specifications.
+func (q *Quotas) List(qo *QueryOptions) ([]*QuotaSpec, *QueryMeta, error) {
+	var resp []*QuotaSpec
+	qm, err := q.client.query("/v1/quotas", &resp, qo)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(QuotaSpecNameSort(resp))
+	return resp, qm, nil
+}
+
+// PrefixList is used to list the quota specifications whose name starts
+// with the prefix.
+func (q *Quotas) PrefixList(prefix string) ([]*QuotaSpec, *QueryMeta, error) {
+	return q.List(&QueryOptions{Prefix: prefix})
+}
+
+// Info is used to query a single quota specification by its name.
+func (q *Quotas) Info(name string, qo *QueryOptions) (*QuotaSpec, *QueryMeta, error) {
+	var resp QuotaSpec
+	qm, err := q.client.query("/v1/quota/"+name, &resp, qo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Usage is used to query the resources used by the jobs using a quota.
+func (q *Quotas) Usage(name string, qo *QueryOptions) (*QuotaUsage, *QueryMeta, error) {
+	var resp QuotaUsage
+	qm, err := q.client.query("/v1/quota/"+name+"/usage", &resp, qo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Register is used to create or update a quota specification.
+func (q *Quotas) Register(spec *QuotaSpec, qo *WriteOptions) (*WriteMeta, error) {
+	wm, err := q.client.write("/v1/quota/"+spec.Name, spec, nil, qo)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Delete is used to delete a quota specification that is no longer used by
+// any job.
+func (q *Quotas) Delete(name string, qo *WriteOptions) (*WriteMeta, error) {
+	wm, err := q.client.delete("/v1/quota/"+name, nil, qo)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// QuotaSpec is used to serialize a quota specification.
+type QuotaSpec struct {
+	Name        string
+	Description string
+	Limit       *QuotaLimit
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// QuotaLimit is the resources limited by a quota. A zero value leaves the
+// resource unlimited.
+type QuotaLimit struct {
+	CPU      int
+	MemoryMB int `mapstructure:"memory"`
+	DiskMB   int `mapstructure:"disk"`
+}
+
+// QuotaUsage is the resources used by the jobs using a quota.
+type QuotaUsage struct {
+	Name string
+	Used *Resources
+}
+
+// QuotaSpecNameSort is a wrapper to sort quota specifications by name.
+type QuotaSpecNameSort []*QuotaSpec
+
+func (q QuotaSpecNameSort) Len() int {
+	return len(q)
+}
+
+func (q QuotaSpecNameSort) Less(i, j int) bool {
+	return q[i].Name < q[j].Name
+}
+
+func (q QuotaSpecNameSort) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestQuotas_Register_Info_Delete(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	q := c.Quotas()
+
+	// Listing when nothing exists returns empty
+	result, qm, err := q.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if qm.LastIndex != 0 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	if n := len(result); n != 0 {
+		t.Fatalf("expected 0 quotas, got: %d", n)
+	}
+
+	// Register a quota
+	spec := &QuotaSpec{
+		Name:        "engineering",
+		Description: "engineering team",
+		Limit: &QuotaLimit{
+			CPU:      1000,
+			MemoryMB: 2048,
+		},
+	}
+	wm, err := q.Register(spec, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Query it by prefix and name
+	result, qm, err = q.PrefixList("eng")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(result) != 1 || result[0].Name != spec.Name {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	out, qm, err := q.Info(spec.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if out.Description != spec.Description || out.Limit.MemoryMB != 2048 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Nothing uses the quota yet
+	usage, qm, err := q.Usage(spec.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if usage.Name != spec.Name || usage.Used == nil || usage.Used.CPU != 0 {
+		t.Fatalf("bad: %#v", usage)
+	}
+
+	// Delete the quota
+	wm, err = q.Delete(spec.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	if _, _, err := q.Info(spec.Name, nil); err == nil {
+		t.Fatalf("expected the quota to be deleted")
+	}
+}
+
+func TestQuotas_NamespaceQuota(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	// Registering a namespace with an unknown quota fails
+	ns := &Namespace{Name: "engineering", Quota: "engineering"}
+	if _, err := c.Namespaces().Register(ns, nil); err == nil {
+		t.Fatalf("expected an error registering a namespace with an unknown quota")
+	}
+
+	spec := &QuotaSpec{
+		Name:  "engineering",
+		Limit: &QuotaLimit{CPU: 1000},
+	}
+	if _, err := c.Quotas().Register(spec, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := c.Namespaces().Register(ns, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// A quota used by a namespace can not be deleted
+	if _, err := c.Quotas().Delete(spec.Name, nil); err == nil {
+		t.Fatalf("expected an error deleting a quota in use")
+	}
+}
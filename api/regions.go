@@ -1,6 +1,10 @@
 package api
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // Regions is used to query the regions in the cluster.
 type Regions struct {
@@ -21,3 +25,25 @@ func (r *Regions) List() ([]string, error) {
 	sort.Strings(resp)
 	return resp, nil
 }
+
+// Validate returns an error if any of the given regions is unknown to the
+// cluster, as requests forwarded to them would fail.
+func (r *Regions) Validate(regions ...string) error {
+	known, err := r.List()
+	if err != nil {
+		return err
+	}
+
+	var unknown []string
+	for _, region := range regions {
+		i := sort.SearchStrings(known, region)
+		if i == len(known) || known[i] != region {
+			unknown = append(unknown, region)
+		}
+	}
+	if len(unknown) != 0 {
+		return fmt.Errorf("unknown regions %s, known regions are %s",
+			strings.Join(unknown, ", "), strings.Join(known, ", "))
+	}
+	return nil
+}
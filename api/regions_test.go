@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/nomad/testutil"
@@ -40,3 +41,19 @@ func TestRegionsList(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	})
 }
+
+func TestRegions_Validate(t *testing.T) {
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.Region = "regionA"
+	})
+	defer s.Stop()
+
+	if err := c.Regions().Validate("regionA"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err := c.Regions().Validate("regionA", "regionB")
+	if err == nil || !strings.Contains(err.Error(), "unknown regions regionB") {
+		t.Fatalf("expected unknown region error, got: %v", err)
+	}
+}
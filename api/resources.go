@@ -8,6 +8,20 @@ type Resources struct {
 	DiskMB   int
 	IOPS     int
 	Networks []*NetworkResource
+
+	GPU        int
+	GPUDevices []int
+
+	CPUHardLimit bool
+}
+
+// ResourceOverride overrides the resources of a task on the nodes of a
+// node class.
+type ResourceOverride struct {
+	NodeClass string
+	CPU       int
+	MemoryMB  int
+	IOPS      int
 }
 
 type Port struct {
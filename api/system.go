@@ -15,3 +15,11 @@ func (s *System) GarbageCollect() error {
 	_, err := s.client.write("/v1/system/gc", &req, nil, nil)
 	return err
 }
+
+// ReconcileSummaries recomputes the summaries of all the jobs from their
+// allocations.
+func (s *System) ReconcileSummaries() error {
+	var req struct{}
+	_, err := s.client.write("/v1/system/reconcile/summaries", &req, nil, nil)
+	return err
+}
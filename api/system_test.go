@@ -12,3 +12,12 @@ func TestSystem_GarbageCollect(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestSystem_ReconcileSummaries(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	e := c.System()
+	if err := e.ReconcileSummaries(); err != nil {
+		t.Fatal(err)
+	}
+}
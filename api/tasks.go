@@ -57,6 +57,24 @@ type RestartPolicy struct {
 	Mode     string
 }
 
+// ReschedulePolicy defines how the Nomad scheduler replaces the failed
+// allocations of a taskgroup
+type ReschedulePolicy struct {
+	Attempts      int
+	Interval      time.Duration
+	Delay         time.Duration
+	DelayFunction string        `mapstructure:"delay_function"`
+	MaxDelay      time.Duration `mapstructure:"max_delay"`
+	Unlimited     bool
+}
+
+// MigrateStrategy throttles the migration of the allocations of a taskgroup
+// off draining nodes
+type MigrateStrategy struct {
+	MaxParallel    int           `mapstructure:"max_parallel"`
+	HealthDeadline time.Duration `mapstructure:"health_deadline"`
+}
+
 // The ServiceCheck data model represents the consul health check that
 // Nomad registers for a Task
 type ServiceCheck struct {
@@ -70,16 +88,25 @@ type ServiceCheck struct {
 	PortLabel     string `mapstructure:"port"`
 	Interval      time.Duration
 	Timeout       time.Duration
-	InitialStatus string `mapstructure:"initial_status"`
+	InitialStatus string        `mapstructure:"initial_status"`
+	CheckRestart  *CheckRestart `mapstructure:"check_restart"`
+}
+
+// CheckRestart configures the restart of a task whose service check keeps
+// failing.
+type CheckRestart struct {
+	Limit int
+	Grace time.Duration
 }
 
 // The Service model represents a Consul service definition
 type Service struct {
-	Id        string
-	Name      string
-	Tags      []string
-	PortLabel string `mapstructure:"port"`
-	Checks    []ServiceCheck
+	Id         string
+	Name       string
+	Tags       []string
+	PortLabel  string `mapstructure:"port"`
+	Checks     []ServiceCheck
+	CanaryTags []string `mapstructure:"canary_tags"`
 }
 
 // EphemeralDisk is an ephemeral disk object
@@ -90,13 +117,16 @@ type EphemeralDisk struct {
 
 // TaskGroup is the unit of scheduling.
 type TaskGroup struct {
-	Name          string
-	Count         int
-	Constraints   []*Constraint
-	Tasks         []*Task
-	RestartPolicy *RestartPolicy
-	EphemeralDisk *EphemeralDisk
-	Meta          map[string]string
+	Name             string
+	Count            int
+	Constraints      []*Constraint
+	Tasks            []*Task
+	RestartPolicy    *RestartPolicy
+	ReschedulePolicy *ReschedulePolicy
+	Migrate          *MigrateStrategy
+	EphemeralDisk    *EphemeralDisk
+	Meta             map[string]string
+	Volumes          map[string]*VolumeRequest
 }
 
 // NewTaskGroup creates a new TaskGroup.
@@ -128,6 +158,15 @@ func (g *TaskGroup) AddTask(t *Task) *TaskGroup {
 	return g
 }
 
+// AddVolume adds a volume request to the task group
+func (g *TaskGroup) AddVolume(volume *VolumeRequest) *TaskGroup {
+	if g.Volumes == nil {
+		g.Volumes = make(map[string]*VolumeRequest)
+	}
+	g.Volumes[volume.Name] = volume
+	return g
+}
+
 // RequireDisk adds a ephemeral disk to the task group
 func (g *TaskGroup) RequireDisk(disk *EphemeralDisk) *TaskGroup {
 	g.EphemeralDisk = disk
@@ -163,6 +202,7 @@ type LogShuttleConfig struct {
 	BatchSize     int
 	BackBuff      int
 	MaxLineLength int
+	MaxLineSplits int
 	KinesisShards int
 }
 
@@ -182,6 +222,47 @@ type Task struct {
 	LogConfig       *LogConfig
 	Artifacts       []*TaskArtifact
 	Vault           *Vault
+	DispatchPayload *DispatchPayloadConfig
+	Lifecycle       *TaskLifecycleConfig
+	Templates       []*Template
+	EnvFromConsul   *EnvFromConsulConfig
+	VolumeMounts    []*VolumeMount
+
+	ResourceOverrides []*ResourceOverride
+}
+
+// VolumeRequest is a volume requested by a task group.
+type VolumeRequest struct {
+	Name     string
+	Type     string
+	Source   string
+	ReadOnly bool
+}
+
+// VolumeMount mounts a volume of the task group into a task.
+type VolumeMount struct {
+	Volume      string
+	Destination string
+	ReadOnly    bool
+}
+
+// DispatchPayloadConfig configures how a task gets its input from a job
+// dispatch.
+type DispatchPayloadConfig struct {
+	File string
+}
+
+// TaskLifecycleConfig configures when a task is run relative to the main tasks
+// of its task group.
+type TaskLifecycleConfig struct {
+	Hook    string
+	Sidecar bool
+}
+
+// EnvFromConsulConfig configures the environment variables of a task read
+// from Consul's key/value store.
+type EnvFromConsulConfig struct {
+	Prefix string
 }
 
 // TaskArtifact is used to download artifacts before running a task.
@@ -191,6 +272,17 @@ type TaskArtifact struct {
 	RelativeDest  string
 }
 
+// Template is a template rendered into the task's directory from the data of
+// Consul.
+type Template struct {
+	SourcePath   string        `mapstructure:"source"`
+	DestPath     string        `mapstructure:"destination"`
+	EmbeddedTmpl string        `mapstructure:"data"`
+	ChangeMode   string        `mapstructure:"change_mode"`
+	ChangeSignal string        `mapstructure:"change_signal"`
+	Splay        time.Duration `mapstructure:"splay"`
+}
+
 type Vault struct {
 	Policies []string
 }
@@ -222,12 +314,25 @@ func (t *Task) SetMeta(key, val string) *Task {
 	return t
 }
 
+// AddVolumeMount is used to mount a volume of the task group into the task.
+func (t *Task) AddVolumeMount(mount *VolumeMount) *Task {
+	t.VolumeMounts = append(t.VolumeMounts, mount)
+	return t
+}
+
 // Require is used to add resource requirements to a task.
 func (t *Task) Require(r *Resources) *Task {
 	t.Resources = r
 	return t
 }
 
+// OverrideResources is used to override the resource requirements of a task
+// on the nodes of a node class.
+func (t *Task) OverrideResources(o *ResourceOverride) *Task {
+	t.ResourceOverrides = append(t.ResourceOverrides, o)
+	return t
+}
+
 // Constraint adds a new constraints to a single task.
 func (t *Task) Constrain(c *Constraint) *Task {
 	t.Constraints = append(t.Constraints, c)
@@ -243,8 +348,9 @@ func (t *Task) SetLogConfig(l *LogConfig) *Task {
 // TaskState tracks the current state of a task and events that caused state
 // transitions.
 type TaskState struct {
-	State  string
-	Events []*TaskEvent
+	State       string
+	Events      []*TaskEvent
+	FailedSetup bool
 }
 
 const (
@@ -259,22 +365,47 @@ const (
 	TaskNotRestarting          = "Not Restarting"
 	TaskDownloadingArtifacts   = "Downloading Artifacts"
 	TaskArtifactDownloadFailed = "Failed Artifact Download"
-	TaskDiskExceeded           = "Disk Exceeded"
+	TaskDiskExceeded           = "Disk Resources Exceeded"
+	TaskSiblingFailed          = "Sibling task failed"
+	TaskRestartSignal          = "Restart Signaled"
+	TaskSignaling              = "Signaling"
+	TaskTemplateRenderFailed   = "Failed Template Rendering"
+	TaskEnvFromConsulFailed    = "Failed Consul Environment"
+	TaskHealthChanged          = "Health Changed"
+	TaskDriverMessage          = "Driver"
+	TaskOOMKilled              = "OOM Killed"
 )
 
 // TaskEvent is an event that effects the state of a task and contains meta-data
 // appropriate to the events type.
 type TaskEvent struct {
-	Type            string
-	Time            int64
-	RestartReason   string
-	DriverError     string
-	ExitCode        int
-	Signal          int
-	Message         string
-	KillTimeout     time.Duration
-	KillError       string
-	StartDelay      int64
-	DownloadError   string
-	ValidationError string
+	Type               string
+	Time               int64
+	DisplayMessage     string
+	RestartReason      string
+	DriverError        string
+	ExitCode           int
+	Signal             int
+	Message            string
+	OOMKilled          bool
+	KillTimeout        time.Duration
+	KillError          string
+	StartDelay         int64
+	DownloadError      string
+	ValidationError    string
+	DiskLimit          int64
+	DiskSize           int64
+	FailedSibling      string
+	TemplateError      string
+	EnvFromConsulError string
+	HealthStatus       string
+	TaskSignalReason   string
+	TaskSignal         string
+	DriverMessage      string
+}
+
+// AllocTaskEvent is an event of a task of an allocation.
+type AllocTaskEvent struct {
+	Task  string
+	Event *TaskEvent
 }
@@ -185,6 +185,28 @@ func TestTask_Require(t *testing.T) {
 	}
 }
 
+func TestTask_OverrideResources(t *testing.T) {
+	task := NewTask("task1", "exec")
+
+	// Override the resources on a node class
+	override := &ResourceOverride{
+		NodeClass: "large",
+		MemoryMB:  1024,
+	}
+	out := task.OverrideResources(override)
+	if n := len(task.ResourceOverrides); n != 1 {
+		t.Fatalf("expected 1 resource override, got: %d", n)
+	}
+	if !reflect.DeepEqual(task.ResourceOverrides[0], override) {
+		t.Fatalf("expect: %#v, got: %#v", override, task.ResourceOverrides[0])
+	}
+
+	// Check that we returned the task
+	if out != task {
+		t.Fatalf("expect: %#v, got: %#v", task, out)
+	}
+}
+
 func TestTask_Constrain(t *testing.T) {
 	task := NewTask("task1", "exec")
 
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// checkRestartInterval is the interval at which the Consul checks that
+	// restart their task when failing are queried.
+	checkRestartInterval = 1 * time.Second
+)
+
+// TaskCheckStatusesFunc returns the status of the Consul checks registered
+// for the services of a task by check name.
+type TaskCheckStatusesFunc func(allocID, task string) (map[string]string, error)
+
+// SetTaskCheckStatuses sets the function used to query the Consul checks of
+// the tasks when restarting the tasks whose checks keep failing.
+func (r *AllocRunner) SetTaskCheckStatuses(fn TaskCheckStatusesFunc) {
+	r.taskCheckStatuses = fn
+}
+
+// hasCheckRestarts returns whether any check of the tasks restarts its task
+// when failing.
+func hasCheckRestarts(tasks []*structs.Task) bool {
+	for _, task := range tasks {
+		if len(checkRestarts(task)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRestarts returns the checks of the task that restart it when failing.
+func checkRestarts(task *structs.Task) []*structs.ServiceCheck {
+	var checks []*structs.ServiceCheck
+	for _, service := range task.Services {
+		for _, check := range service.Checks {
+			if check.CheckRestart != nil && check.CheckRestart.Limit > 0 {
+				checks = append(checks, check)
+			}
+		}
+	}
+	return checks
+}
+
+// watchCheckRestarts restarts the tasks of the allocation once one of their
+// checks has failed for its check_restart limit of consecutive runs. Failures
+// are ignored during the grace period following the start of the task. The
+// restarts count against the restart policy, which applies its delay.
+func (r *AllocRunner) watchCheckRestarts() {
+	ticker := time.NewTicker(checkRestartInterval)
+	defer ticker.Stop()
+
+	// failingSince is the time since when the checks of each task have been
+	// failing, by task and check name
+	failingSince := make(map[string]map[string]time.Time)
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.destroyCh:
+			return
+		}
+
+		if r.allocTerminal() {
+			return
+		}
+
+		// Use the latest job as the checks can be updated in place
+		alloc := r.Alloc()
+		tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+		if tg == nil {
+			return
+		}
+
+		now := time.Now()
+		for _, task := range tg.Tasks {
+			checks := checkRestarts(task)
+			if len(checks) == 0 || !r.taskPastGrace(task.Name, checks, now) {
+				delete(failingSince, task.Name)
+				continue
+			}
+
+			if _, ok := failingSince[task.Name]; !ok {
+				failingSince[task.Name] = make(map[string]time.Time)
+			}
+			if failed := r.failedCheck(alloc.ID, task.Name, checks, failingSince[task.Name], now); failed != "" {
+				delete(failingSince, task.Name)
+				r.restartFailedTask(alloc.ID, task.Name, failed)
+			}
+		}
+	}
+}
+
+// taskPastGrace returns whether the task is running and past the grace period
+// of all the given checks as of now.
+func (r *AllocRunner) taskPastGrace(task string, checks []*structs.ServiceCheck, now time.Time) bool {
+	var grace time.Duration
+	for _, check := range checks {
+		if check.CheckRestart.Grace > grace {
+			grace = check.CheckRestart.Grace
+		}
+	}
+
+	r.taskStatusLock.RLock()
+	defer r.taskStatusLock.RUnlock()
+	state, ok := r.taskStates[task]
+	if !ok || state.State != structs.TaskStateRunning {
+		return false
+	}
+
+	// A task signaled to restart is past its grace period again only once it
+	// has been started since
+	var started time.Time
+	for _, event := range state.Events {
+		switch event.Type {
+		case structs.TaskStarted:
+			started = time.Unix(0, event.Time)
+		case structs.TaskRestartSignal:
+			started = time.Time{}
+		}
+	}
+	return !started.IsZero() && now.Sub(started) >= grace
+}
+
+// failedCheck updates since when the given checks of the task have been
+// critical, by check name, and returns the name of one that reached its limit
+// of consecutive failures, or an empty string if none did.
+func (r *AllocRunner) failedCheck(allocID, task string, checks []*structs.ServiceCheck,
+	failingSince map[string]time.Time, now time.Time) string {
+
+	if r.taskCheckStatuses == nil {
+		return ""
+	}
+	statuses, err := r.taskCheckStatuses(allocID, task)
+	if err != nil {
+		r.logger.Printf("[WARN] client: failed to query checks of task '%s' in alloc '%s': %v",
+			task, allocID, err)
+		return ""
+	}
+
+	failed := ""
+	for _, check := range checks {
+		if statuses[check.Name] != consulapi.HealthCritical {
+			delete(failingSince, check.Name)
+			continue
+		}
+
+		since, ok := failingSince[check.Name]
+		if !ok {
+			since = now
+			failingSince[check.Name] = now
+		}
+
+		// The check has failed limit times once it failed for limit - 1
+		// intervals after its first failure.
+		limit := time.Duration(check.CheckRestart.Limit-1) * check.Interval
+		if failed == "" && now.Sub(since) >= limit {
+			failed = check.Name
+		}
+	}
+	return failed
+}
+
+// restartFailedTask restarts the task due to its failed check.
+func (r *AllocRunner) restartFailedTask(allocID, task, check string) {
+	r.taskLock.RLock()
+	tr, ok := r.tasks[task]
+	r.taskLock.RUnlock()
+	if !ok {
+		return
+	}
+
+	reason := fmt.Sprintf("check %q is unhealthy", check)
+	r.logger.Printf("[INFO] client: restarting task '%s' in alloc '%s': %s", task, allocID, reason)
+	if err := tr.RestartFailed(reason); err != nil {
+		r.logger.Printf("[WARN] client: failed to restart task '%s' in alloc '%s': %v", task, allocID, err)
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestAllocRunner_CheckRestart(t *testing.T) {
+	upd, ar := testAllocRunner(true)
+
+	// Restart the task once before the restart policy is exceeded
+	tg := ar.alloc.Job.TaskGroups[0]
+	*tg.RestartPolicy = structs.RestartPolicy{
+		Attempts: 1,
+		Interval: 10 * time.Minute,
+		Delay:    10 * time.Millisecond,
+		Mode:     structs.RestartPolicyModeFail,
+	}
+	task := tg.Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{"run_for": "10s"}
+	check := task.Services[0].Checks[0]
+	check.CheckRestart = &structs.CheckRestart{Limit: 1}
+
+	// The check is always critical
+	ar.SetTaskCheckStatuses(func(allocID, taskName string) (map[string]string, error) {
+		return map[string]string{check.Name: consulapi.HealthCritical}, nil
+	})
+	go ar.Run()
+	defer ar.Destroy()
+
+	var last *structs.Allocation
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, fmt.Errorf("No updates")
+		}
+		last = upd.Allocs[upd.Count-1]
+		if last.ClientStatus != structs.AllocClientStatusFailed {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusFailed)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The task was signaled to restart twice, the second restart exceeding
+	// the restart policy
+	var signals int
+	events := last.TaskStates[task.Name].Events
+	for _, e := range events {
+		if e.Type == structs.TaskRestartSignal {
+			signals++
+		}
+	}
+	if signals != 2 {
+		t.Fatalf("got %d restart signals; want 2: %#v", signals, events)
+	}
+	if e := events[len(events)-1]; e.Type != structs.TaskNotRestarting {
+		t.Fatalf("last event is %q; want %q", e.Type, structs.TaskNotRestarting)
+	}
+}
+
+func TestAllocRunner_CheckRestart_Grace(t *testing.T) {
+	_, ar := testAllocRunner(false)
+	now := time.Now()
+	checks := []*structs.ServiceCheck{
+		{Name: "a", CheckRestart: &structs.CheckRestart{Limit: 1, Grace: 10 * time.Second}},
+		{Name: "b", CheckRestart: &structs.CheckRestart{Limit: 1, Grace: 30 * time.Second}},
+	}
+
+	cases := []struct {
+		events []*structs.TaskEvent
+		past   bool
+	}{
+		// Within the longest grace period
+		{
+			events: []*structs.TaskEvent{
+				{Type: structs.TaskStarted, Time: now.Add(-20 * time.Second).UnixNano()},
+			},
+			past: false,
+		},
+		// Past the grace periods
+		{
+			events: []*structs.TaskEvent{
+				{Type: structs.TaskStarted, Time: now.Add(-time.Minute).UnixNano()},
+			},
+			past: true,
+		},
+		// Signaled to restart but not started since
+		{
+			events: []*structs.TaskEvent{
+				{Type: structs.TaskStarted, Time: now.Add(-time.Minute).UnixNano()},
+				{Type: structs.TaskRestartSignal, Time: now.Add(-time.Second).UnixNano()},
+			},
+			past: false,
+		},
+	}
+
+	for i, c := range cases {
+		ar.taskStates["web"] = &structs.TaskState{State: structs.TaskStateRunning, Events: c.events}
+		if past := ar.taskPastGrace("web", checks, now); past != c.past {
+			t.Fatalf("case %d: got %v; want %v", i, past, c.past)
+		}
+	}
+}
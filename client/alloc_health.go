@@ -0,0 +1,182 @@
+package client
+
+import (
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// allocHealthInterval is the interval at which the health of an
+	// allocation placed by a deployment is checked.
+	allocHealthInterval = 1 * time.Second
+)
+
+// TaskChecksFunc returns the number of Consul checks registered for the
+// services of a task and how many of them are passing.
+type TaskChecksFunc func(allocID, task string) (passing, total int, err error)
+
+// SetTaskChecks sets the function used to query the Consul checks of the
+// tasks when determining the health of the allocation.
+func (r *AllocRunner) SetTaskChecks(fn TaskChecksFunc) {
+	r.taskChecks = fn
+}
+
+// shouldWatchHealth returns whether the health of the allocation has to be
+// determined for the deployment that placed it.
+func (r *AllocRunner) shouldWatchHealth() bool {
+	r.allocLock.Lock()
+	defer r.allocLock.Unlock()
+
+	if r.allocHealth != nil || r.alloc.DeploymentID == "" || r.alloc.DeploymentStatus.HasHealth() {
+		return false
+	}
+
+	update := r.alloc.Job.Update
+	return update.HealthGated() && update.HealthCheck != structs.UpdateHealthCheckManual
+}
+
+// watchHealth watches the tasks of the allocation until they are healthy,
+// one of them fails or the healthy deadline of the update strategy passes.
+// The health is then set on the allocation and synced to the servers.
+func (r *AllocRunner) watchHealth(tg *structs.TaskGroup) {
+	alloc := r.Alloc()
+	update := alloc.Job.Update
+
+	deadline := time.NewTimer(update.Deadline())
+	defer deadline.Stop()
+	ticker := time.NewTicker(allocHealthInterval)
+	defer ticker.Stop()
+
+	// checksSince is the time since when the Consul checks have been passing
+	var checksSince time.Time
+	for {
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			if !r.allocTerminal() {
+				r.logger.Printf("[DEBUG] client: alloc '%s' not healthy within %v", alloc.ID, update.Deadline())
+				r.setHealth(false)
+			}
+			return
+		case <-r.destroyCh:
+			return
+		}
+
+		// A stopped allocation is no longer part of the rollout
+		if r.allocTerminal() {
+			return
+		}
+
+		now := time.Now()
+		r.taskStatusLock.RLock()
+		healthy, failed := tasksHealthy(tg.Tasks, r.taskStates, update.MinHealthyTime, now)
+		r.taskStatusLock.RUnlock()
+
+		if failed {
+			r.logger.Printf("[DEBUG] client: alloc '%s' unhealthy due to a failed task", alloc.ID)
+			r.setHealth(false)
+			return
+		}
+		if !healthy {
+			checksSince = time.Time{}
+			continue
+		}
+
+		if update.HealthCheck == structs.UpdateHealthCheckChecks {
+			if !r.checksPassing(alloc.ID, tg.Tasks) {
+				checksSince = time.Time{}
+				continue
+			}
+			if checksSince.IsZero() {
+				checksSince = now
+			}
+			if now.Sub(checksSince) < update.MinHealthyTime {
+				continue
+			}
+		}
+
+		r.setHealth(true)
+		return
+	}
+}
+
+// checksPassing returns whether all the Consul checks defined by the tasks
+// are registered and passing.
+func (r *AllocRunner) checksPassing(allocID string, tasks []*structs.Task) bool {
+	for _, task := range tasks {
+		expected := 0
+		for _, service := range task.Services {
+			expected += len(service.Checks)
+		}
+		if expected == 0 {
+			continue
+		}
+		if r.taskChecks == nil {
+			return false
+		}
+
+		passing, total, err := r.taskChecks(allocID, task.Name)
+		if err != nil {
+			r.logger.Printf("[WARN] client: failed to query checks of task '%s' in alloc '%s': %v",
+				task.Name, allocID, err)
+			return false
+		}
+		if total < expected || passing != total {
+			return false
+		}
+	}
+	return true
+}
+
+// tasksHealthy returns whether all the tasks have been running for at least
+// minHealthyTime as of now and whether any of them has failed.
+func tasksHealthy(tasks []*structs.Task, states map[string]*structs.TaskState,
+	minHealthyTime time.Duration, now time.Time) (bool, bool) {
+
+	healthy := true
+	for _, task := range tasks {
+		state, ok := states[task.Name]
+		if !ok {
+			healthy = false
+			continue
+		}
+		if state.Failed() {
+			return false, true
+		}
+		if state.State != structs.TaskStateRunning {
+			healthy = false
+			continue
+		}
+
+		// The task must not have been restarted within minHealthyTime
+		var started time.Time
+		for _, event := range state.Events {
+			if event.Type == structs.TaskStarted {
+				started = time.Unix(0, event.Time)
+			}
+		}
+		if started.IsZero() || now.Sub(started) < minHealthyTime {
+			healthy = false
+		}
+	}
+	return healthy, false
+}
+
+// allocTerminal returns whether the allocation has been stopped
+func (r *AllocRunner) allocTerminal() bool {
+	r.allocLock.Lock()
+	defer r.allocLock.Unlock()
+	return r.alloc.TerminalStatus()
+}
+
+// setHealth sets the health of the allocation and syncs it
+func (r *AllocRunner) setHealth(healthy bool) {
+	r.allocLock.Lock()
+	r.allocHealth = &healthy
+	r.allocLock.Unlock()
+	select {
+	case r.dirtyCh <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestTasksHealthy(t *testing.T) {
+	now := time.Now()
+	tasks := []*structs.Task{{Name: "web"}, {Name: "sidecar"}}
+	running := func(started time.Time) *structs.TaskState {
+		return &structs.TaskState{
+			State:  structs.TaskStateRunning,
+			Events: []*structs.TaskEvent{{Type: structs.TaskStarted, Time: started.UnixNano()}},
+		}
+	}
+
+	cases := []struct {
+		states  map[string]*structs.TaskState
+		healthy bool
+		failed  bool
+	}{
+		// A task has not started
+		{
+			states:  map[string]*structs.TaskState{"web": running(now.Add(-time.Minute))},
+			healthy: false,
+		},
+		// A task has not been running for the minimum healthy time
+		{
+			states: map[string]*structs.TaskState{
+				"web":     running(now.Add(-time.Minute)),
+				"sidecar": running(now.Add(-time.Second)),
+			},
+			healthy: false,
+		},
+		// All tasks are healthy
+		{
+			states: map[string]*structs.TaskState{
+				"web":     running(now.Add(-time.Minute)),
+				"sidecar": running(now.Add(-20 * time.Second)),
+			},
+			healthy: true,
+		},
+		// A task failed
+		{
+			states: map[string]*structs.TaskState{
+				"web": running(now.Add(-time.Minute)),
+				"sidecar": {
+					State:  structs.TaskStateDead,
+					Events: []*structs.TaskEvent{structs.NewTaskEvent(structs.TaskNotRestarting)},
+				},
+			},
+			failed: true,
+		},
+	}
+
+	for i, c := range cases {
+		healthy, failed := tasksHealthy(tasks, c.states, 10*time.Second, now)
+		if healthy != c.healthy || failed != c.failed {
+			t.Fatalf("case %d: got healthy %v failed %v; want %v %v", i, healthy, failed, c.healthy, c.failed)
+		}
+	}
+}
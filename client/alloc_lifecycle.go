@@ -0,0 +1,159 @@
+package client
+
+import (
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// taskLifecycle groups the tasks of a task group by when they are run.
+type taskLifecycle struct {
+	// prestart tasks run to completion before the main tasks are started.
+	prestart []*structs.Task
+
+	// sidecars are started along with the prestart tasks and keep running
+	// until the main tasks have exited.
+	sidecars []*structs.Task
+
+	// main are the tasks without a lifecycle.
+	main []*structs.Task
+
+	// poststop tasks are started once the main tasks have exited.
+	poststop []*structs.Task
+}
+
+// newTaskLifecycle groups the tasks by their lifecycle hook.
+func newTaskLifecycle(tasks []*structs.Task) *taskLifecycle {
+	l := new(taskLifecycle)
+	for _, task := range tasks {
+		switch {
+		case task.Lifecycle == nil:
+			l.main = append(l.main, task)
+		case task.Lifecycle.Hook == structs.TaskLifecycleHookPoststop:
+			l.poststop = append(l.poststop, task)
+		case task.Lifecycle.Sidecar:
+			l.sidecars = append(l.sidecars, task)
+		default:
+			l.prestart = append(l.prestart, task)
+		}
+	}
+	return l
+}
+
+// receiveTasks marks the tasks that weren't restored as received and returns
+// them by name. All tasks are marked up front such that the allocation isn't
+// considered complete while some of its tasks wait for their lifecycle.
+func (r *AllocRunner) receiveTasks(tasks []*structs.Task) map[string]*structs.Task {
+	pending := make(map[string]*structs.Task, len(tasks))
+	for _, task := range tasks {
+		if _, ok := r.restored[task.Name]; ok {
+			continue
+		}
+		r.setTaskState(task.Name, structs.TaskStatePending, structs.NewTaskEvent(structs.TaskReceived))
+		pending[task.Name] = task
+	}
+	return pending
+}
+
+// runTaskLifecycle starts the pending tasks ordered by their lifecycle,
+// removing the started tasks from pending. It closes doneCh once all tasks
+// were started, a prestart task failed or stopCh was closed.
+func (r *AllocRunner) runTaskLifecycle(l *taskLifecycle, pending map[string]*structs.Task,
+	stopCh <-chan struct{}, doneCh chan<- struct{}) {
+	defer close(doneCh)
+
+	// Run the prestart tasks to completion
+	if !r.startTasks(append(l.sidecars, l.prestart...), pending, stopCh) ||
+		!r.waitTasksDead(l.prestart, stopCh) {
+		return
+	}
+
+	// Don't start the remaining tasks if a prestart task failed. Its
+	// siblings that are already running are killed when it fails.
+	if failed := r.failedTask(l.prestart); failed != "" {
+		r.logger.Printf("[DEBUG] client: prestart task %q failed, not starting remaining tasks of alloc %q", failed, r.alloc.ID)
+		for name := range pending {
+			r.setTaskState(name, structs.TaskStateDead,
+				structs.NewTaskEvent(structs.TaskSiblingFailed).SetFailedSibling(failed))
+			delete(pending, name)
+		}
+		return
+	}
+
+	// Run the main tasks and stop the sidecars once they have exited
+	if !r.startTasks(l.main, pending, stopCh) || !r.waitTasksDead(l.main, stopCh) {
+		return
+	}
+	r.taskLock.RLock()
+	for _, task := range l.sidecars {
+		if tr, ok := r.tasks[task.Name]; ok {
+			tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+		}
+	}
+	r.taskLock.RUnlock()
+
+	r.startTasks(l.poststop, pending, stopCh)
+}
+
+// startTasks starts the task runners of the given tasks that are pending. It
+// returns false without starting any task if stopCh is closed.
+func (r *AllocRunner) startTasks(tasks []*structs.Task, pending map[string]*structs.Task,
+	stopCh <-chan struct{}) bool {
+	select {
+	case <-stopCh:
+		return false
+	default:
+	}
+
+	alloc := r.Alloc()
+	r.taskLock.Lock()
+	defer r.taskLock.Unlock()
+	for _, task := range tasks {
+		if _, ok := pending[task.Name]; !ok {
+			continue
+		}
+		delete(pending, task.Name)
+
+		tr := NewTaskRunner(r.logger, r.config, r.stateDB, r.setTaskState, r.ctx, alloc, task.Copy())
+		r.tasks[task.Name] = tr
+		go tr.Run()
+	}
+	return true
+}
+
+// waitTasksDead blocks until all of the given tasks are dead. It returns false
+// if stopCh is closed first.
+func (r *AllocRunner) waitTasksDead(tasks []*structs.Task, stopCh <-chan struct{}) bool {
+	for !r.tasksDead(tasks) {
+		select {
+		case <-r.taskStateCh:
+		case <-stopCh:
+			return false
+		}
+	}
+	return true
+}
+
+// tasksDead returns whether all of the given tasks are dead.
+func (r *AllocRunner) tasksDead(tasks []*structs.Task) bool {
+	r.taskStatusLock.RLock()
+	defer r.taskStatusLock.RUnlock()
+	for _, task := range tasks {
+		state, ok := r.taskStates[task.Name]
+		if !ok || state.State != structs.TaskStateDead {
+			return false
+		}
+	}
+	return true
+}
+
+// failedTask returns the name of one of the given tasks that failed, or an
+// empty string if none did.
+func (r *AllocRunner) failedTask(tasks []*structs.Task) string {
+	r.taskStatusLock.RLock()
+	defer r.taskStatusLock.RUnlock()
+	for _, task := range tasks {
+		if state, ok := r.taskStates[task.Name]; ok && state.Failed() {
+			return task.Name
+		}
+	}
+	return ""
+}
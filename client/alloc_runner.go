@@ -3,11 +3,13 @@ package client
 import (
 	"fmt"
 	"log"
-	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/boltdb/bolt"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
@@ -34,6 +36,10 @@ const (
 // AllocStateUpdater is used to update the status of an allocation
 type AllocStateUpdater func(alloc *structs.Allocation)
 
+// AllocRootFunc returns the root under which the directory of an allocation
+// asking for diskMB of ephemeral disk is created.
+type AllocRootFunc func(diskMB int) (string, error)
+
 type AllocStatsReporter interface {
 	LatestAllocStats(taskFilter string) (*cstructs.AllocResourceUsage, error)
 }
@@ -41,6 +47,7 @@ type AllocStatsReporter interface {
 // AllocRunner is used to wrap an allocation and provide the execution context.
 type AllocRunner struct {
 	config  *config.Config
+	stateDB *bolt.DB
 	updater AllocStateUpdater
 	logger  *log.Logger
 
@@ -49,8 +56,26 @@ type AllocRunner struct {
 	allocClientDescription string
 	allocLock              sync.Mutex
 
+	// allocHealth is the health of the allocation determined for the
+	// deployment that placed it. It is nil as long as it is not known.
+	allocHealth *bool
+
+	// taskChecks is used to query the Consul checks of the tasks
+	taskChecks TaskChecksFunc
+
+	// taskCheckStatuses is used to query the Consul checks of the tasks
+	// that restart them when failing
+	taskCheckStatuses TaskCheckStatusesFunc
+
+	// allocRoot chooses the root of the allocation directory. The alloc dir
+	// of the config is used if it is not set.
+	allocRoot AllocRootFunc
+
 	dirtyCh chan struct{}
 
+	// taskStateCh is signaled whenever the state of a task changes
+	taskStateCh chan struct{}
+
 	ctx        *driver.ExecContext
 	ctxLock    sync.Mutex
 	tasks      map[string]*TaskRunner
@@ -77,41 +102,62 @@ type allocRunnerState struct {
 	Alloc                  *structs.Allocation
 	AllocClientStatus      string
 	AllocClientDescription string
+	AllocHealth            *bool
 	Context                *driver.ExecContext
 }
 
 // NewAllocRunner is used to create a new allocation context
-func NewAllocRunner(logger *log.Logger, config *config.Config, updater AllocStateUpdater,
-	alloc *structs.Allocation) *AllocRunner {
+func NewAllocRunner(logger *log.Logger, config *config.Config, stateDB *bolt.DB,
+	updater AllocStateUpdater, alloc *structs.Allocation) *AllocRunner {
 	ar := &AllocRunner{
-		config:     config,
-		updater:    updater,
-		logger:     logger,
-		alloc:      alloc,
-		dirtyCh:    make(chan struct{}, 1),
-		tasks:      make(map[string]*TaskRunner),
-		taskStates: copyTaskStates(alloc.TaskStates),
-		restored:   make(map[string]struct{}),
-		updateCh:   make(chan *structs.Allocation, 64),
-		destroyCh:  make(chan struct{}),
-		waitCh:     make(chan struct{}),
+		config:      config,
+		stateDB:     stateDB,
+		updater:     updater,
+		logger:      logger,
+		alloc:       alloc,
+		dirtyCh:     make(chan struct{}, 1),
+		taskStateCh: make(chan struct{}, 1),
+		tasks:       make(map[string]*TaskRunner),
+		taskStates:  copyTaskStates(alloc.TaskStates),
+		restored:    make(map[string]struct{}),
+		updateCh:    make(chan *structs.Allocation, 64),
+		destroyCh:   make(chan struct{}),
+		waitCh:      make(chan struct{}),
 	}
 	return ar
 }
 
-// stateFilePath returns the path to our state file
-func (r *AllocRunner) stateFilePath() string {
+// allocID returns the ID of the allocation.
+func (r *AllocRunner) allocID() string {
 	r.allocLock.Lock()
 	defer r.allocLock.Unlock()
-	path := filepath.Join(r.config.StateDir, "alloc", r.alloc.ID, "state.json")
-	return path
+	return r.alloc.ID
 }
 
 // RestoreState is used to restore the state of the alloc runner
 func (r *AllocRunner) RestoreState() error {
 	// Load the snapshot
 	var snap allocRunnerState
-	if err := restoreState(r.stateFilePath(), &snap); err != nil {
+	var taskStates map[string]struct{}
+	err := r.stateDB.View(func(tx *bolt.Tx) error {
+		bkt, err := getAllocationBucket(tx, r.allocID())
+		if err != nil || bkt == nil {
+			return err
+		}
+		if err := getObject(bkt, allocRunnerStateKey, &snap); err != nil {
+			return err
+		}
+
+		taskStates = make(map[string]struct{})
+		if tasks := bkt.Bucket(tasksBucket); tasks != nil {
+			tasks.ForEach(func(k, v []byte) error {
+				taskStates[string(k)] = struct{}{}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
@@ -120,6 +166,7 @@ func (r *AllocRunner) RestoreState() error {
 	r.ctx = snap.Context
 	r.allocClientStatus = snap.AllocClientStatus
 	r.allocClientDescription = snap.AllocClientDescription
+	r.allocHealth = snap.AllocHealth
 	r.taskStates = snap.Alloc.TaskStates
 
 	var snapshotErrors multierror.Error
@@ -136,12 +183,20 @@ func (r *AllocRunner) RestoreState() error {
 	// Restore the task runners
 	var mErr multierror.Error
 	for name, state := range r.taskStates {
-		// Mark the task as restored.
-		r.restored[name] = struct{}{}
-
 		task := &structs.Task{Name: name}
-		tr := NewTaskRunner(r.logger, r.config, r.setTaskState, r.ctx, r.Alloc(),
+		tr := NewTaskRunner(r.logger, r.config, r.stateDB, r.setTaskState, r.ctx, r.Alloc(),
 			task)
+
+		// Tasks that never started, such as those waiting for their
+		// lifecycle, have no state to restore and are started by Run.
+		if state.State == structs.TaskStatePending {
+			if _, ok := taskStates[name]; !ok {
+				continue
+			}
+		}
+
+		// Mark the task as restored.
+		r.restored[name] = struct{}{}
 		r.tasks[name] = tr
 
 		// Skip tasks in terminal states.
@@ -160,6 +215,12 @@ func (r *AllocRunner) RestoreState() error {
 	return mErr.ErrorOrNil()
 }
 
+// SetAllocRoot sets the function choosing the root under which the
+// allocation directory is created.
+func (r *AllocRunner) SetAllocRoot(fn AllocRootFunc) {
+	r.allocRoot = fn
+}
+
 // SaveState is used to snapshot the state of the alloc runner
 // if the fullSync is marked as false only the state of the Alloc Runner
 // is snapshotted. If fullSync is marked as true, we snapshot
@@ -190,6 +251,7 @@ func (r *AllocRunner) saveAllocRunnerState() error {
 	r.allocLock.Lock()
 	allocClientStatus := r.allocClientStatus
 	allocClientDescription := r.allocClientDescription
+	allocHealth := r.allocHealth
 	r.allocLock.Unlock()
 
 	r.ctxLock.Lock()
@@ -202,8 +264,15 @@ func (r *AllocRunner) saveAllocRunnerState() error {
 		Context:                ctx,
 		AllocClientStatus:      allocClientStatus,
 		AllocClientDescription: allocClientDescription,
+		AllocHealth:            allocHealth,
 	}
-	return persistState(r.stateFilePath(), &snap)
+	return r.stateDB.Update(func(tx *bolt.Tx) error {
+		bkt, err := getAllocationBucket(tx, alloc.ID)
+		if err != nil {
+			return err
+		}
+		return putObject(bkt, allocRunnerStateKey, &snap)
+	})
 }
 
 func (r *AllocRunner) saveTaskRunnerState(tr *TaskRunner) error {
@@ -216,7 +285,9 @@ func (r *AllocRunner) saveTaskRunnerState(tr *TaskRunner) error {
 
 // DestroyState is used to cleanup after ourselves
 func (r *AllocRunner) DestroyState() error {
-	return os.RemoveAll(filepath.Dir(r.stateFilePath()))
+	return r.stateDB.Update(func(tx *bolt.Tx) error {
+		return deleteAllocationBucket(tx, r.allocID())
+	})
 }
 
 // DestroyContext is used to destroy the context
@@ -238,6 +309,15 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 	r.allocLock.Lock()
 	alloc := r.alloc.Copy()
 
+	// The health of the allocation has been determined for its deployment
+	if r.allocHealth != nil {
+		healthy := *r.allocHealth
+		alloc.DeploymentStatus = &structs.AllocDeploymentStatus{
+			Healthy: &healthy,
+			Canary:  alloc.DeploymentStatus.IsCanary(),
+		}
+	}
+
 	// The status has explicitly been set.
 	if r.allocClientStatus != "" || r.allocClientDescription != "" {
 		alloc.ClientStatus = r.allocClientStatus
@@ -255,9 +335,10 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 
 	// Scan the task states to determine the status of the alloc
 	var pending, running, dead, failed bool
+	var oomKilled []string
 	r.taskStatusLock.RLock()
 	alloc.TaskStates = copyTaskStates(r.taskStates)
-	for _, state := range r.taskStates {
+	for name, state := range r.taskStates {
 		switch state.State {
 		case structs.TaskStateRunning:
 			running = true
@@ -266,6 +347,9 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 		case structs.TaskStateDead:
 			if state.Failed() {
 				failed = true
+				if state.OOMKilled() {
+					oomKilled = append(oomKilled, name)
+				}
 			} else {
 				dead = true
 			}
@@ -276,6 +360,13 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 	// Determine the alloc status
 	if failed {
 		alloc.ClientStatus = structs.AllocClientStatusFailed
+
+		// Distinguish tasks killed for running out of memory from crashes
+		if len(oomKilled) != 0 {
+			sort.Strings(oomKilled)
+			alloc.ClientDescription = fmt.Sprintf("task(s) %s killed for exceeding their memory limit",
+				strings.Join(oomKilled, ", "))
+		}
 	} else if running {
 		alloc.ClientStatus = structs.AllocClientStatusRunning
 	} else if pending {
@@ -329,10 +420,28 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 		r.taskStates[taskName] = taskState
 	}
 
-	// Set the tasks state.
-	taskState.State = state
+	// Set the tasks state. Informational events, such as the messages of
+	// the drivers, leave it unchanged.
+	if state != "" {
+		taskState.State = state
+	}
+	setDisplayMessage(event)
 	r.appendTaskEvent(taskState, event)
 
+	select {
+	case r.taskStateCh <- struct{}{}:
+	default:
+	}
+
+	// Track whether the task last failed while being set up or while running
+	// so that the failure of the task can be attributed.
+	switch {
+	case event.SetupFailure():
+		taskState.FailedSetup = true
+	case event.Type == structs.TaskStarted:
+		taskState.FailedSetup = false
+	}
+
 	// If the task failed, we should kill all the other tasks in the task group.
 	if state == structs.TaskStateDead && taskState.Failed() {
 		var destroyingTasks []string
@@ -387,7 +496,17 @@ func (r *AllocRunner) Run() {
 	// Create the execution context
 	r.ctxLock.Lock()
 	if r.ctx == nil {
-		allocDir := allocdir.NewAllocDir(filepath.Join(r.config.AllocDir, r.alloc.ID), r.Alloc().Resources.DiskMB)
+		diskMB := r.Alloc().Resources.DiskMB
+		root := r.config.AllocDir
+		if r.allocRoot != nil {
+			if selected, err := r.allocRoot(diskMB); err != nil {
+				r.logger.Printf("[WARN] client: failed to select alloc dir root for alloc %q, using %q: %v", r.alloc.ID, root, err)
+			} else {
+				root = selected
+			}
+		}
+		allocDir := allocdir.NewAllocDir(filepath.Join(root, r.alloc.ID), diskMB)
+		allocDir.TmpSize = r.config.TmpDirSize
 		if err := allocDir.Build(tg.Tasks); err != nil {
 			r.logger.Printf("[WARN] client: failed to build task directories: %v", err)
 			r.setStatus(structs.AllocClientStatusFailed, fmt.Sprintf("failed to build task dirs for '%s'", alloc.TaskGroup))
@@ -396,6 +515,7 @@ func (r *AllocRunner) Run() {
 		}
 		r.ctx = driver.NewExecContext(allocDir, r.alloc.ID)
 	}
+	r.ctx.Volumes = tg.Volumes
 	r.ctxLock.Unlock()
 
 	// Check if the allocation is in a terminal status. In this case, we don't
@@ -408,25 +528,26 @@ func (r *AllocRunner) Run() {
 		return
 	}
 
-	// Start the task runners
+	// Start the task runners, ordered by the lifecycle of the tasks
 	r.logger.Printf("[DEBUG] client: starting task runners for alloc '%s'", r.alloc.ID)
-	r.taskLock.Lock()
-	for _, task := range tg.Tasks {
-		if _, ok := r.restored[task.Name]; ok {
-			continue
-		}
-
-		tr := NewTaskRunner(r.logger, r.config, r.setTaskState, r.ctx, r.Alloc(),
-			task.Copy())
-		r.tasks[task.Name] = tr
-		tr.MarkReceived()
-		go tr.Run()
-	}
-	r.taskLock.Unlock()
+	pending := r.receiveTasks(tg.Tasks)
+	lifecycleStopCh := make(chan struct{})
+	lifecycleDoneCh := make(chan struct{})
+	go r.runTaskLifecycle(newTaskLifecycle(tg.Tasks), pending, lifecycleStopCh, lifecycleDoneCh)
 
 	// Start watching the shared allocation directory for disk usage
 	go r.ctx.AllocDir.StartDiskWatcher()
 
+	// Determine the health of the allocation for its deployment
+	if r.shouldWatchHealth() {
+		go r.watchHealth(tg)
+	}
+
+	// Restart the tasks whose checks keep failing
+	if hasCheckRestarts(tg.Tasks) {
+		go r.watchCheckRestarts()
+	}
+
 	watchdog := time.NewTicker(watchdogInterval)
 	defer watchdog.Stop()
 
@@ -467,6 +588,13 @@ OUTER:
 		}
 	}
 
+	// Stop starting tasks and mark the tasks that never started as dead
+	close(lifecycleStopCh)
+	<-lifecycleDoneCh
+	for name := range pending {
+		r.setTaskState(name, structs.TaskStateDead, taskDestroyEvent)
+	}
+
 	// Destroy each sub-task
 	runners := r.getTaskRunners()
 	for _, tr := range runners {
@@ -545,6 +673,28 @@ func (r *AllocRunner) getTaskRunners() []*TaskRunner {
 	return runners
 }
 
+// Restart restarts the given task of the allocation, or all of its tasks if
+// taskName is empty, recording reason in the task events.
+func (r *AllocRunner) Restart(taskName, reason string) error {
+	if taskName != "" {
+		r.taskLock.RLock()
+		tr, ok := r.tasks[taskName]
+		r.taskLock.RUnlock()
+		if !ok {
+			return fmt.Errorf("allocation %q has no task %q", r.alloc.ID, taskName)
+		}
+		return tr.Restart(reason)
+	}
+
+	var mErr multierror.Error
+	for _, tr := range r.getTaskRunners() {
+		if err := tr.Restart(reason); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
 // LatestAllocStats returns the latest allocation stats. If the optional taskFilter is set
 // the allocation stats will only include the given task.
 func (r *AllocRunner) LatestAllocStats(taskFilter string) (*cstructs.AllocResourceUsage, error) {
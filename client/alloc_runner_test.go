@@ -3,10 +3,13 @@ package client
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/boltdb/bolt"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
@@ -35,10 +38,35 @@ func testAllocRunnerFromAlloc(alloc *structs.Allocation, restarts bool) (*MockAl
 		*alloc.Job.LookupTaskGroup(alloc.TaskGroup).RestartPolicy = structs.RestartPolicy{Attempts: 0}
 		alloc.Job.Type = structs.JobTypeBatch
 	}
-	ar := NewAllocRunner(logger, conf, upd.Update, alloc)
+	ar := NewAllocRunner(logger, conf, testStateDB(), upd.Update, alloc)
 	return upd, ar
 }
 
+// testStateDB returns a state database in a new temporary directory.
+func testStateDB() *bolt.DB {
+	dir, err := ioutil.TempDir("", "NomadClientState")
+	if err != nil {
+		panic(err)
+	}
+	db, err := bolt.Open(filepath.Join(dir, stateDBFile), 0600, nil)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// testAllocStateExists returns whether the state of the alloc runner is
+// saved in its state database.
+func testAllocStateExists(ar *AllocRunner) bool {
+	exists := false
+	ar.stateDB.View(func(tx *bolt.Tx) error {
+		bkt, err := getAllocationBucket(tx, ar.allocID())
+		exists = err == nil && bkt != nil
+		return nil
+	})
+	return exists
+}
+
 func testAllocRunner(restarts bool) (*MockAllocStateUpdater, *AllocRunner) {
 	return testAllocRunnerFromAlloc(mock.Alloc(), restarts)
 }
@@ -66,6 +94,63 @@ func TestAllocRunner_SimpleRun(t *testing.T) {
 // TestAllocRuner_RetryArtifact ensures that if one task in a task group is
 // retrying fetching an artifact, other tasks in the the group should be able
 // to proceed.
+func TestAllocRunner_OOMKilledDescription(t *testing.T) {
+	_, ar := testAllocRunner(false)
+	ar.setTaskState("web", structs.TaskStateRunning, structs.NewTaskEvent(structs.TaskStarted))
+	ar.setTaskState("web", structs.TaskStateDead, structs.NewTaskEvent(structs.TaskTerminated).SetExitCode(137).SetOOMKilled(true))
+	ar.setTaskState("web", structs.TaskStateDead, structs.NewTaskEvent(structs.TaskOOMKilled))
+	ar.setTaskState("web", structs.TaskStateDead, structs.NewTaskEvent(structs.TaskNotRestarting))
+
+	alloc := ar.Alloc()
+	if alloc.ClientStatus != structs.AllocClientStatusFailed {
+		t.Fatalf("bad status: %v", alloc.ClientStatus)
+	}
+	expected := "task(s) web killed for exceeding their memory limit"
+	if alloc.ClientDescription != expected {
+		t.Fatalf("bad description: %q", alloc.ClientDescription)
+	}
+}
+
+func TestAllocRunner_AllocRoot(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	upd, ar := testAllocRunner(false)
+
+	root, err := ioutil.TempDir("", "AllocRoot")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	var asked int
+	ar.SetAllocRoot(func(diskMB int) (string, error) {
+		asked = diskMB
+		return root, nil
+	})
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, fmt.Errorf("No updates")
+		}
+		last := upd.Allocs[upd.Count-1]
+		if last.ClientStatus != structs.AllocClientStatusComplete {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusComplete)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	if asked != ar.Alloc().Resources.DiskMB {
+		t.Fatalf("asked for %d MB; want %d", asked, ar.Alloc().Resources.DiskMB)
+	}
+	expected := filepath.Join(root, ar.Alloc().ID)
+	if dir := ar.ctx.AllocDir.AllocDir; dir != expected {
+		t.Fatalf("alloc dir is %q; want %q", dir, expected)
+	}
+}
+
 func TestAllocRunner_RetryArtifact(t *testing.T) {
 	ctestutil.ExecCompatible(t)
 
@@ -157,8 +242,8 @@ func TestAllocRunner_TerminalUpdate_Destroy(t *testing.T) {
 		}
 
 		// Check the state still exists
-		if _, err := os.Stat(ar.stateFilePath()); err != nil {
-			return false, fmt.Errorf("state file destroyed: %v", err)
+		if !testAllocStateExists(ar) {
+			return false, fmt.Errorf("state destroyed")
 		}
 
 		// Check the alloc directory still exists
@@ -186,10 +271,8 @@ func TestAllocRunner_TerminalUpdate_Destroy(t *testing.T) {
 		}
 
 		// Check the state was cleaned
-		if _, err := os.Stat(ar.stateFilePath()); err == nil {
-			return false, fmt.Errorf("state file still exists: %v", ar.stateFilePath())
-		} else if !os.IsNotExist(err) {
-			return false, fmt.Errorf("stat err: %v", err)
+		if testAllocStateExists(ar) {
+			return false, fmt.Errorf("state still exists")
 		}
 
 		// Check the alloc directory was cleaned
@@ -264,8 +347,8 @@ func TestAllocRunner_DiskExceeded_Destroy(t *testing.T) {
 		}
 
 		// Check the state still exists
-		if _, err := os.Stat(ar.stateFilePath()); err != nil {
-			return false, fmt.Errorf("state file destroyed: %v", err)
+		if !testAllocStateExists(ar) {
+			return false, fmt.Errorf("state destroyed")
 		}
 
 		// Check the alloc directory still exists
@@ -293,10 +376,8 @@ func TestAllocRunner_DiskExceeded_Destroy(t *testing.T) {
 		}
 
 		// Check the state was cleaned
-		if _, err := os.Stat(ar.stateFilePath()); err == nil {
-			return false, fmt.Errorf("state file still exists: %v", ar.stateFilePath())
-		} else if !os.IsNotExist(err) {
-			return false, fmt.Errorf("stat err: %v", err)
+		if testAllocStateExists(ar) {
+			return false, fmt.Errorf("state still exists")
 		}
 
 		// Check the alloc directory was cleaned
@@ -340,10 +421,8 @@ func TestAllocRunner_Destroy(t *testing.T) {
 		}
 
 		// Check the state was cleaned
-		if _, err := os.Stat(ar.stateFilePath()); err == nil {
-			return false, fmt.Errorf("state file still exists: %v", ar.stateFilePath())
-		} else if !os.IsNotExist(err) {
-			return false, fmt.Errorf("stat err: %v", err)
+		if testAllocStateExists(ar) {
+			return false, fmt.Errorf("state still exists")
 		}
 
 		// Check the alloc directory was cleaned
@@ -412,7 +491,7 @@ func TestAllocRunner_SaveRestoreState(t *testing.T) {
 	}
 
 	// Create a new alloc runner
-	ar2 := NewAllocRunner(ar.logger, ar.config, upd.Update,
+	ar2 := NewAllocRunner(ar.logger, ar.config, ar.stateDB, upd.Update,
 		&structs.Allocation{ID: ar.alloc.ID})
 	err = ar2.RestoreState()
 	if err != nil {
@@ -485,7 +564,7 @@ func TestAllocRunner_SaveRestoreState_TerminalAlloc(t *testing.T) {
 	ar.destroy = true
 
 	// Create a new alloc runner
-	ar2 := NewAllocRunner(ar.logger, ar.config, upd.Update,
+	ar2 := NewAllocRunner(ar.logger, ar.config, ar.stateDB, upd.Update,
 		&structs.Allocation{ID: ar.alloc.ID})
 	ar2.logger = prefixedTestLogger("ar2: ")
 	err = ar2.RestoreState()
@@ -497,8 +576,8 @@ func TestAllocRunner_SaveRestoreState_TerminalAlloc(t *testing.T) {
 
 	testutil.WaitForResult(func() (bool, error) {
 		// Check the state still exists
-		if _, err := os.Stat(ar.stateFilePath()); err != nil {
-			return false, fmt.Errorf("state file destroyed: %v", err)
+		if !testAllocStateExists(ar) {
+			return false, fmt.Errorf("state destroyed")
 		}
 
 		// Check the alloc directory still exists
@@ -527,10 +606,8 @@ func TestAllocRunner_SaveRestoreState_TerminalAlloc(t *testing.T) {
 		}
 
 		// Check the state was cleaned
-		if _, err := os.Stat(ar.stateFilePath()); err == nil {
-			return false, fmt.Errorf("state file still exists: %v", ar.stateFilePath())
-		} else if !os.IsNotExist(err) {
-			return false, fmt.Errorf("stat err: %v", err)
+		if testAllocStateExists(ar) {
+			return false, fmt.Errorf("state still exists")
 		}
 
 		// Check the alloc directory was cleaned
@@ -589,9 +666,80 @@ func TestAllocRunner_TaskFailed_KillTG(t *testing.T) {
 		if !state2.Failed() {
 			return false, fmt.Errorf("task2 should have failed")
 		}
+		if !state2.FailedSetup {
+			return false, fmt.Errorf("task2 should have failed during setup")
+		}
+		if state1.FailedSetup {
+			return false, fmt.Errorf("task1 should not have failed during setup")
+		}
+
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestAllocRunner_TaskLifecycle(t *testing.T) {
+	upd, ar := testAllocRunner(false)
 
+	// Run a prestart task, a sidecar and a poststop task around the main task
+	main := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	main.Driver = "mock_driver"
+	main.Config = map[string]interface{}{"run_for": "500ms"}
+
+	newTask := func(name, hook string, sidecar bool, runFor string) {
+		task := main.Copy()
+		task.Name = name
+		task.Config = map[string]interface{}{"run_for": runFor}
+		task.Lifecycle = &structs.TaskLifecycleConfig{Hook: hook, Sidecar: sidecar}
+		ar.alloc.Job.TaskGroups[0].Tasks = append(ar.alloc.Job.TaskGroups[0].Tasks, task)
+		ar.alloc.TaskResources[task.Name] = task.Resources
+	}
+	newTask("init", structs.TaskLifecycleHookPrestart, false, "100ms")
+	newTask("sidecar", structs.TaskLifecycleHookPrestart, true, "10s")
+	newTask("cleanup", structs.TaskLifecycleHookPoststop, false, "100ms")
+	go ar.Run()
+	defer ar.Destroy()
+
+	var last *structs.Allocation
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, fmt.Errorf("No updates")
+		}
+		last = upd.Allocs[upd.Count-1]
+		if last.ClientStatus != structs.AllocClientStatusComplete {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusComplete)
+		}
+		if state := last.TaskStates["cleanup"]; state == nil || state.State != structs.TaskStateDead {
+			return false, fmt.Errorf("cleanup task hasn't run")
+		}
 		return true, nil
 	}, func(err error) {
 		t.Fatalf("err: %v", err)
 	})
+
+	eventTime := func(task, eventType string) int64 {
+		for _, e := range last.TaskStates[task].Events {
+			if e.Type == eventType {
+				return e.Time
+			}
+		}
+		t.Fatalf("task %q has no %q event: %#v", task, eventType, last.TaskStates[task].Events)
+		return 0
+	}
+
+	// The main task starts after the prestart task exited and before the
+	// poststop task is started
+	if eventTime("init", structs.TaskTerminated) > eventTime(main.Name, structs.TaskStarted) {
+		t.Fatalf("main task started before the prestart task exited")
+	}
+	if eventTime(main.Name, structs.TaskTerminated) > eventTime("cleanup", structs.TaskStarted) {
+		t.Fatalf("poststop task started before the main task exited")
+	}
+
+	// The sidecar is killed once the main task has exited
+	if eventTime("sidecar", structs.TaskStarted) > eventTime(main.Name, structs.TaskStarted) {
+		t.Fatalf("sidecar started after the main task")
+	}
+	eventTime("sidecar", structs.TaskKilled)
 }
@@ -1,6 +1,9 @@
 package allocdir
 
 import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -8,6 +11,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -50,8 +54,12 @@ var (
 	// directory
 	TaskSecrets = "secrets"
 
+	// TaskTmp is the name of the scratch directory inside each task
+	// directory. It is size limited and emptied when the task restarts.
+	TaskTmp = "tmp"
+
 	// TaskDirs is the set of directories created in each tasks directory.
-	TaskDirs = []string{"tmp"}
+	TaskDirs = []string{TaskTmp}
 )
 
 type AllocDir struct {
@@ -92,6 +100,11 @@ type AllocDir struct {
 	// MaxSize represents the total amount of megabytes that the shared allocation
 	// directory is allowed to consume.
 	MaxSize int
+
+	// TmpSize is the size in megabytes of the tmpfs backing each task's tmp
+	// directory. Zero leaves the directory on disk. It is only enforced on
+	// platforms supporting tmpfs.
+	TmpSize int
 }
 
 // AllocFileInfo holds information about a file inside the AllocDir
@@ -108,6 +121,8 @@ type AllocDirFS interface {
 	List(path string) ([]*AllocFileInfo, error)
 	Stat(path string) (*AllocFileInfo, error)
 	ReadAt(path string, offset int64) (io.ReadCloser, error)
+	Checksums(path string) (map[string]string, error)
+	Tar(path string, files []string, w io.Writer) error
 	BlockUntilExists(path string, t *tomb.Tomb) chan error
 	ChangeEvents(path string, curOffset int64, t *tomb.Tomb) (*watch.FileChanges, error)
 }
@@ -158,6 +173,14 @@ func (d *AllocDir) UnmountAll() error {
 			}
 		}
 
+		taskTmp := filepath.Join(dir, TaskTmp)
+		if d.pathExists(taskTmp) {
+			if err := d.unmountTmpDir(taskTmp); err != nil {
+				mErr.Errors = append(mErr.Errors,
+					fmt.Errorf("failed to unmount the tmp dir %q: %v", taskTmp, err))
+			}
+		}
+
 		taskSecret := filepath.Join(dir, TaskSecrets)
 		if d.pathExists(taskSecret) {
 			if err := d.removeSecretDir(taskSecret); err != nil {
@@ -236,6 +259,16 @@ func (d *AllocDir) Build(tasks []*structs.Task) error {
 			}
 		}
 
+		// Limit the size of the tmp directory
+		tmp := filepath.Join(taskDir, TaskTmp)
+		if err := d.mountTmpDir(tmp); err != nil {
+			return err
+		}
+
+		if err := d.dropDirPermissions(tmp); err != nil {
+			return err
+		}
+
 		// Create the secret directory
 		secret := filepath.Join(taskDir, TaskSecrets)
 		if err := d.createSecretDir(secret); err != nil {
@@ -250,6 +283,28 @@ func (d *AllocDir) Build(tasks []*structs.Task) error {
 	return nil
 }
 
+// CleanTaskTmp removes the contents of the tmp directory of the given task.
+func (d *AllocDir) CleanTaskTmp(task string) error {
+	taskdir, ok := d.TaskDirs[task]
+	if !ok {
+		return fmt.Errorf("Task directory doesn't exist for task %v", task)
+	}
+
+	tmp := filepath.Join(taskdir, TaskTmp)
+	entries, err := ioutil.ReadDir(tmp)
+	if err != nil {
+		return fmt.Errorf("Couldn't read directory %v: %v", tmp, err)
+	}
+
+	var mErr multierror.Error
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(tmp, entry.Name())); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
 // Embed takes a mapping of absolute directory or file paths on the host to
 // their intended, relative location within the task directory. Embed attempts
 // hardlink and then defaults to copying. If the path exists on the host and
@@ -366,22 +421,51 @@ func (d *AllocDir) LogDir() string {
 	return filepath.Join(d.AllocDir, SharedAllocName, LogDirName)
 }
 
-// List returns the list of files at a path relative to the alloc dir
+// secretsPath returns whether the absolute path is a task's secrets directory
+// or inside one. The secrets directories aren't exposed through the file
+// operations of the alloc dir.
+func (d *AllocDir) secretsPath(path string) bool {
+	for _, dir := range d.TaskDirs {
+		rel, err := filepath.Rel(filepath.Join(dir, TaskSecrets), path)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretsPathError returns the error of accessing a path inside a task's
+// secrets directory.
+func secretsPathError(path string) error {
+	return fmt.Errorf("access to secrets directory prohibited: %q", path)
+}
+
+// List returns the list of files at a path relative to the alloc dir. The
+// tasks' secrets directories are omitted.
 func (d *AllocDir) List(path string) ([]*AllocFileInfo, error) {
 	p := filepath.Join(d.AllocDir, path)
+	if d.secretsPath(p) {
+		return []*AllocFileInfo{}, secretsPathError(path)
+	}
 	finfos, err := ioutil.ReadDir(p)
 	if err != nil {
 		return []*AllocFileInfo{}, err
 	}
-	files := make([]*AllocFileInfo, len(finfos))
-	for idx, info := range finfos {
-		files[idx] = &AllocFileInfo{
+	files := make([]*AllocFileInfo, 0, len(finfos))
+	for _, info := range finfos {
+		if d.secretsPath(filepath.Join(p, info.Name())) {
+			continue
+		}
+		files = append(files, &AllocFileInfo{
 			Name:     info.Name(),
 			IsDir:    info.IsDir(),
 			Size:     info.Size(),
 			FileMode: info.Mode().String(),
 			ModTime:  info.ModTime(),
-		}
+		})
 	}
 	return files, err
 }
@@ -389,6 +473,9 @@ func (d *AllocDir) List(path string) ([]*AllocFileInfo, error) {
 // Stat returns information about the file at a path relative to the alloc dir
 func (d *AllocDir) Stat(path string) (*AllocFileInfo, error) {
 	p := filepath.Join(d.AllocDir, path)
+	if d.secretsPath(p) {
+		return nil, secretsPathError(path)
+	}
 	info, err := os.Stat(p)
 	if err != nil {
 		return nil, err
@@ -406,6 +493,9 @@ func (d *AllocDir) Stat(path string) (*AllocFileInfo, error) {
 // ReadAt returns a reader for a file at the path relative to the alloc dir
 func (d *AllocDir) ReadAt(path string, offset int64) (io.ReadCloser, error) {
 	p := filepath.Join(d.AllocDir, path)
+	if d.secretsPath(p) {
+		return nil, secretsPathError(path)
+	}
 	f, err := os.Open(p)
 	if err != nil {
 		return nil, err
@@ -416,13 +506,135 @@ func (d *AllocDir) ReadAt(path string, offset int64) (io.ReadCloser, error) {
 	return f, nil
 }
 
+// Checksums returns the hex encoded SHA256 checksum of each regular file at or
+// below the path relative to the alloc dir. The checksums are keyed by the
+// slash separated path of the file relative to the passed path. Files in the
+// tasks' secrets directories are skipped.
+func (d *AllocDir) Checksums(path string) (map[string]string, error) {
+	root := filepath.Join(d.AllocDir, path)
+	if d.secretsPath(root) {
+		return nil, secretsPathError(path)
+	}
+	sums := make(map[string]string)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && d.secretsPath(p) {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			rel = info.Name()
+		}
+
+		sum, err := fileChecksum(p)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// fileChecksum returns the hex encoded SHA256 checksum of the file at path.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Tar writes a tar archive of the passed files to w. The files are slash
+// separated paths relative to the path relative to the alloc dir, as returned
+// by Checksums, and are archived under those names.
+func (d *AllocDir) Tar(path string, files []string, w io.Writer) error {
+	root := filepath.Join(d.AllocDir, path)
+	tw := tar.NewWriter(w)
+	for _, file := range files {
+		name := filepath.Clean(filepath.FromSlash(file))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("file %q is outside of path %q", file, path)
+		}
+
+		// A file path is the root itself when a single file is archived
+		p := filepath.Join(root, name)
+		if info, err := os.Stat(root); err == nil && info.Mode().IsRegular() && name == info.Name() {
+			p = root
+		}
+		if d.secretsPath(p) {
+			return secretsPathError(file)
+		}
+
+		if err := tarFile(tw, p, filepath.ToSlash(name)); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// tarFile writes the regular file at path to the tar writer under name.
+func tarFile(tw *tar.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("file %q is not a regular file", name)
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	// Only copy the size captured in the header in case the file is still
+	// being written to
+	_, err = io.Copy(tw, io.LimitReader(f, hdr.Size))
+	return err
+}
+
 // BlockUntilExists blocks until the passed file relative the allocation
 // directory exists. The block can be cancelled with the passed tomb.
 func (d *AllocDir) BlockUntilExists(path string, t *tomb.Tomb) chan error {
 	// Get the path relative to the alloc directory
 	p := filepath.Join(d.AllocDir, path)
-	watcher := getFileWatcher(p)
 	returnCh := make(chan error, 1)
+	if d.secretsPath(p) {
+		returnCh <- secretsPathError(path)
+		close(returnCh)
+		return returnCh
+	}
+
+	watcher := getFileWatcher(p)
 	go func() {
 		returnCh <- watcher.BlockUntilExists(t)
 		close(returnCh)
@@ -436,6 +648,9 @@ func (d *AllocDir) BlockUntilExists(path string, t *tomb.Tomb) chan error {
 func (d *AllocDir) ChangeEvents(path string, curOffset int64, t *tomb.Tomb) (*watch.FileChanges, error) {
 	// Get the path relative to the alloc directory
 	p := filepath.Join(d.AllocDir, path)
+	if d.secretsPath(p) {
+		return nil, secretsPathError(path)
+	}
 	watcher := getFileWatcher(p)
 	return watcher.ChangeEvents(t, curOffset)
 }
@@ -482,6 +697,15 @@ func (d *AllocDir) GetSize() int64 {
 	return d.size
 }
 
+// DiskUsage walks the allocation directory and returns the disk space it
+// consumes. An allocation directory which has been destroyed has no usage.
+func (d *AllocDir) DiskUsage() (int64, error) {
+	if err := d.syncDiskUsage(); err != nil {
+		return 0, err
+	}
+	return d.GetSize(), nil
+}
+
 // setSize sets the size of the shared allocation directory.
 func (d *AllocDir) setSize(size int64) {
 	d.sizeLock.Lock()
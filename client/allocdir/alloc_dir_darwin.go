@@ -25,6 +25,16 @@ func (d *AllocDir) removeSecretDir(dir string) error {
 	return os.RemoveAll(dir)
 }
 
+// mountTmpDir is a no-op since the size of the tmp dir can't be limited.
+func (d *AllocDir) mountTmpDir(dir string) error {
+	return nil
+}
+
+// unmountTmpDir is a no-op since mountTmpDir doesn't mount anything.
+func (d *AllocDir) unmountTmpDir(dir string) error {
+	return nil
+}
+
 // MountSpecialDirs mounts the dev and proc file system on the chroot of the
 // task. It's a no-op on darwin.
 func (d *AllocDir) MountSpecialDirs(taskDir string) error {
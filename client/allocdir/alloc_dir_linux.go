@@ -60,6 +60,33 @@ func (d *AllocDir) removeSecretDir(dir string) error {
 	return os.RemoveAll(dir)
 }
 
+// mountTmpDir mounts a tmpfs limited to the tmp size at the given path so that
+// scratch data can't exhaust the disk. Nothing is mounted if no tmp size is
+// set.
+func (d *AllocDir) mountTmpDir(dir string) error {
+	// Only mount the tmpfs if enabled and we are root
+	if d.TmpSize == 0 || unix.Geteuid() != 0 {
+		return nil
+	}
+
+	options := fmt.Sprintf("size=%dm", d.TmpSize)
+	err := syscall.Mount("tmpfs", dir, "tmpfs", 0, options)
+	return os.NewSyscallError("mount", err)
+}
+
+// unmountTmpDir unmounts the tmpfs mounted by mountTmpDir
+func (d *AllocDir) unmountTmpDir(dir string) error {
+	if unix.Geteuid() != 0 {
+		return nil
+	}
+
+	// The directory of tasks started by older clients isn't a mount point.
+	if err := syscall.Unmount(dir, 0); err != nil && err != syscall.EINVAL {
+		return os.NewSyscallError("unmount", err)
+	}
+	return nil
+}
+
 // MountSpecialDirs mounts the dev and proc file system from the host to the
 // chroot
 func (d *AllocDir) MountSpecialDirs(taskDir string) error {
@@ -1,11 +1,15 @@
 package allocdir
 
 import (
+	"archive/tar"
+	"bytes"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/nomad/client/testutil"
@@ -96,6 +100,58 @@ func TestAllocDir_LogDir(t *testing.T) {
 	}
 }
 
+func TestAllocDir_CleanTaskTmp(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	d := NewAllocDir(tmp, structs.DefaultResources().DiskMB)
+	defer d.Destroy()
+	tasks := []*structs.Task{t1, t2}
+	if err := d.Build(tasks); err != nil {
+		t.Fatalf("Build(%v) failed: %v", tasks, err)
+	}
+
+	// Write scratch data to the tmp dirs of both tasks
+	for _, task := range tasks {
+		taskTmp := filepath.Join(d.TaskDirs[task.Name], TaskTmp)
+		if err := os.MkdirAll(filepath.Join(taskTmp, "nested"), 0777); err != nil {
+			t.Fatalf("Couldn't create nested dir: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(taskTmp, "scratch"), []byte("foo"), 0666); err != nil {
+			t.Fatalf("Couldn't write scratch file: %v", err)
+		}
+	}
+
+	if err := d.CleanTaskTmp(t1.Name); err != nil {
+		t.Fatalf("CleanTaskTmp(%v) failed: %v", t1.Name, err)
+	}
+
+	// The tmp dir of the cleaned task should be empty but still exist
+	entries, err := ioutil.ReadDir(filepath.Join(d.TaskDirs[t1.Name], TaskTmp))
+	if err != nil {
+		t.Fatalf("Couldn't read tmp dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("tmp dir not emptied: %v", entries)
+	}
+
+	// The other task must be untouched
+	entries, err = ioutil.ReadDir(filepath.Join(d.TaskDirs[t2.Name], TaskTmp))
+	if err != nil {
+		t.Fatalf("Couldn't read tmp dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("tmp dir of other task modified: %v", entries)
+	}
+
+	if err := d.CleanTaskTmp("missing"); err == nil {
+		t.Fatalf("expected error for missing task")
+	}
+}
+
 func TestAllocDir_EmbedNonExistent(t *testing.T) {
 	tmp, err := ioutil.TempDir("", "AllocDir")
 	if err != nil {
@@ -226,3 +282,133 @@ func TestAllocDir_MountSharedAlloc(t *testing.T) {
 		}
 	}
 }
+
+func TestAllocDir_ChecksumsTar(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	d := NewAllocDir(tmp, structs.DefaultResources().DiskMB)
+	defer d.Destroy()
+
+	results := filepath.Join(tmp, "results")
+	if err := os.MkdirAll(filepath.Join(results, "sub"), 0777); err != nil {
+		t.Fatalf("Couldn't create dir: %v", err)
+	}
+	files := map[string]string{
+		"a.txt":     "foo",
+		"sub/b.txt": "bar",
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(results, name), []byte(contents), 0666); err != nil {
+			t.Fatalf("Couldn't write file: %v", err)
+		}
+	}
+
+	sums, err := d.Checksums("results")
+	if err != nil {
+		t.Fatalf("Checksums() failed: %v", err)
+	}
+	expected := map[string]string{
+		"a.txt":     "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		"sub/b.txt": "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9",
+	}
+	if !reflect.DeepEqual(sums, expected) {
+		t.Fatalf("Checksums() returned %v; want %v", sums, expected)
+	}
+
+	// A single file is keyed by its name
+	sums, err = d.Checksums("results/a.txt")
+	if err != nil {
+		t.Fatalf("Checksums() failed: %v", err)
+	}
+	if len(sums) != 1 || sums["a.txt"] != expected["a.txt"] {
+		t.Fatalf("Checksums() returned %v", sums)
+	}
+
+	var buf bytes.Buffer
+	if err := d.Tar("results", []string{"sub/b.txt"}, &buf); err != nil {
+		t.Fatalf("Tar() failed: %v", err)
+	}
+	tr := tar.NewReader(&buf)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatalf("reading tar failed: %v", err)
+	}
+	if hdr.Name != "sub/b.txt" {
+		t.Fatalf("bad name: %q", hdr.Name)
+	}
+	contents, err := ioutil.ReadAll(tr)
+	if err != nil || string(contents) != "bar" {
+		t.Fatalf("bad contents %q: %v", contents, err)
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Fatalf("expected a single file, got: %v", err)
+	}
+
+	// Files outside of the path are rejected
+	if err := d.Tar("results", []string{"../results/a.txt"}, ioutil.Discard); err == nil {
+		t.Fatalf("expected error archiving file outside of path")
+	}
+}
+
+func TestAllocDir_SecretsHidden(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	d := NewAllocDir(tmp, structs.DefaultResources().DiskMB)
+	defer d.Destroy()
+
+	tasks := []*structs.Task{t1}
+	if err := d.Build(tasks); err != nil {
+		t.Fatalf("Build(%v) failed: %v", tasks, err)
+	}
+
+	secret := filepath.Join(t1.Name, TaskSecrets, "token")
+	if err := ioutil.WriteFile(filepath.Join(tmp, secret), []byte("foo"), 0666); err != nil {
+		t.Fatalf("Couldn't write file: %v", err)
+	}
+
+	// The secrets directory isn't listed
+	files, err := d.List(t1.Name)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	for _, f := range files {
+		if f.Name == TaskSecrets {
+			t.Fatalf("List() returned the secrets directory")
+		}
+	}
+
+	// The secrets can't be accessed
+	for _, path := range []string{filepath.Join(t1.Name, TaskSecrets), secret} {
+		if _, err := d.List(path); err == nil {
+			t.Fatalf("List(%q) succeeded", path)
+		}
+		if _, err := d.Stat(path); err == nil {
+			t.Fatalf("Stat(%q) succeeded", path)
+		}
+		if _, err := d.ReadAt(path, 0); err == nil {
+			t.Fatalf("ReadAt(%q) succeeded", path)
+		}
+	}
+	if err := d.Tar(t1.Name, []string{filepath.ToSlash(filepath.Join(TaskSecrets, "token"))}, ioutil.Discard); err == nil {
+		t.Fatalf("Tar() archived a secret")
+	}
+
+	// The secrets aren't checksummed
+	sums, err := d.Checksums(t1.Name)
+	if err != nil {
+		t.Fatalf("Checksums() failed: %v", err)
+	}
+	for name := range sums {
+		if strings.HasPrefix(name, TaskSecrets+"/") {
+			t.Fatalf("Checksums() returned secret %q", name)
+		}
+	}
+}
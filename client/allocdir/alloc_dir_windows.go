@@ -39,6 +39,16 @@ func (d *AllocDir) removeSecretDir(dir string) error {
 	return os.RemoveAll(dir)
 }
 
+// mountTmpDir is a no-op since the size of the tmp dir can't be limited.
+func (d *AllocDir) mountTmpDir(dir string) error {
+	return nil
+}
+
+// unmountTmpDir is a no-op since mountTmpDir doesn't mount anything.
+func (d *AllocDir) unmountTmpDir(dir string) error {
+	return nil
+}
+
 // The windows version does nothing currently.
 func (d *AllocDir) dropDirPermissions(path string) error {
 	return nil
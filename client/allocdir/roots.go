@@ -0,0 +1,121 @@
+package allocdir
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+const (
+	// RootPolicyMostFree places allocation directories on the root with the
+	// most free space.
+	RootPolicyMostFree = "most-free"
+
+	// RootPolicyRoundRobin places allocation directories on the roots in
+	// turn, skipping the roots without enough free space.
+	RootPolicyRoundRobin = "round-robin"
+)
+
+// ValidRootPolicy returns whether the placement policy of the allocation
+// directory roots is known.
+func ValidRootPolicy(policy string) bool {
+	switch policy {
+	case "", RootPolicyMostFree, RootPolicyRoundRobin:
+		return true
+	default:
+		return false
+	}
+}
+
+// RootSelector chooses the root directory under which the directory of an
+// allocation is created when the client has several alloc dir roots.
+type RootSelector struct {
+	roots  []string
+	policy string
+
+	// freeBytes returns the free space of the filesystem backing a root
+	freeBytes func(root string) (uint64, error)
+
+	next int
+	l    sync.Mutex
+}
+
+// NewRootSelector returns a selector placing allocation directories on the
+// roots following the policy. The most-free policy is used if none is given.
+func NewRootSelector(roots []string, policy string) (*RootSelector, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no alloc dir roots given")
+	}
+	if !ValidRootPolicy(policy) {
+		return nil, fmt.Errorf("unknown alloc dir policy %q", policy)
+	}
+	if policy == "" {
+		policy = RootPolicyMostFree
+	}
+	return &RootSelector{
+		roots:     roots,
+		policy:    policy,
+		freeBytes: diskFreeBytes,
+	}, nil
+}
+
+// Roots returns the roots the selector places allocation directories on.
+func (s *RootSelector) Roots() []string {
+	return s.roots
+}
+
+// Policy returns the placement policy of the selector.
+func (s *RootSelector) Policy() string {
+	return s.policy
+}
+
+// Select returns the root on which to create the directory of an allocation
+// asking for diskMB of ephemeral disk. Roots whose free space cannot be read
+// are skipped. If no root has enough free space, the one with the most free
+// space is returned.
+func (s *RootSelector) Select(diskMB int) (string, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if len(s.roots) == 1 {
+		return s.roots[0], nil
+	}
+
+	ask := uint64(diskMB) * 1024 * 1024
+	free := make([]uint64, len(s.roots))
+	best := -1
+	for i, root := range s.roots {
+		bytes, err := s.freeBytes(root)
+		if err != nil {
+			continue
+		}
+		free[i] = bytes
+		if best == -1 || bytes > free[best] {
+			best = i
+		}
+	}
+	if best == -1 {
+		return "", fmt.Errorf("failed to read the free space of the alloc dir roots")
+	}
+
+	if s.policy == RootPolicyRoundRobin {
+		for i := 0; i < len(s.roots); i++ {
+			idx := (s.next + i) % len(s.roots)
+			if free[idx] > 0 && free[idx] >= ask {
+				s.next = idx + 1
+				return s.roots[idx], nil
+			}
+		}
+	}
+	return s.roots[best], nil
+}
+
+// diskFreeBytes returns the free space of the filesystem backing the path.
+func diskFreeBytes(path string) (uint64, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return 0, err
+	}
+	return usage.Free, nil
+}
@@ -0,0 +1,132 @@
+package allocdir
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// testRootSelector returns a selector over the roots whose free space in
+// megabytes is read from the map.
+func testRootSelector(t *testing.T, policy string, free map[string]uint64, roots ...string) *RootSelector {
+	s, err := NewRootSelector(roots, policy)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s.freeBytes = func(root string) (uint64, error) {
+		mb, ok := free[root]
+		if !ok {
+			return 0, fmt.Errorf("unknown root %q", root)
+		}
+		return mb * 1024 * 1024, nil
+	}
+	return s
+}
+
+func TestNewRootSelector_Invalid(t *testing.T) {
+	if _, err := NewRootSelector(nil, ""); err == nil {
+		t.Fatalf("expected error without roots")
+	}
+	if _, err := NewRootSelector([]string{"/a"}, "random"); err == nil {
+		t.Fatalf("expected error for unknown policy")
+	}
+
+	s, err := NewRootSelector([]string{"/a"}, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s.policy != RootPolicyMostFree {
+		t.Fatalf("bad policy: %q", s.policy)
+	}
+}
+
+func TestRootSelector_MostFree(t *testing.T) {
+	free := map[string]uint64{"/a": 100, "/b": 300, "/c": 200}
+	s := testRootSelector(t, RootPolicyMostFree, free, "/a", "/b", "/c")
+
+	for i := 0; i < 3; i++ {
+		root, err := s.Select(10)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if root != "/b" {
+			t.Fatalf("bad root: %q", root)
+		}
+	}
+
+	// Roots whose usage can't be read are skipped
+	delete(free, "/b")
+	root, err := s.Select(10)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if root != "/c" {
+		t.Fatalf("bad root: %q", root)
+	}
+
+	for k := range free {
+		delete(free, k)
+	}
+	if _, err := s.Select(10); err == nil {
+		t.Fatalf("expected error without readable roots")
+	}
+}
+
+func TestRootSelector_RoundRobin(t *testing.T) {
+	free := map[string]uint64{"/a": 100, "/b": 50, "/c": 200}
+	s := testRootSelector(t, RootPolicyRoundRobin, free, "/a", "/b", "/c")
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		root, err := s.Select(10)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		got = append(got, root)
+	}
+	expected := []string{"/a", "/b", "/c", "/a"}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("bad order: %v", got)
+		}
+	}
+
+	// Roots without enough free space are skipped
+	root, err := s.Select(75)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if root != "/c" {
+		t.Fatalf("bad root: %q", root)
+	}
+
+	// The root with the most free space is used if none fits the ask
+	root, err = s.Select(500)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if root != "/c" {
+		t.Fatalf("bad root: %q", root)
+	}
+}
+
+func TestRootSelector_Select_Disk(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDirRoot")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s, err := NewRootSelector([]string{tmp, tmp}, RootPolicyMostFree)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	root, err := s.Select(1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if root != tmp {
+		t.Fatalf("bad root: %q", root)
+	}
+}
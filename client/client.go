@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/boltdb/bolt"
 	consulapi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/go-multierror"
@@ -23,8 +24,10 @@ import (
 	"github.com/hashicorp/nomad/client/fingerprint"
 	"github.com/hashicorp/nomad/client/rpcproxy"
 	"github.com/hashicorp/nomad/client/stats"
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/vaultclient"
 	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/helper/tlsutil"
 	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/structs"
 	vaultapi "github.com/hashicorp/vault/api"
@@ -103,6 +106,12 @@ type Client struct {
 	configCopy *config.Config
 	configLock sync.RWMutex
 
+	// allocRoots chooses the alloc dir root of new allocations
+	allocRoots *allocdir.RootSelector
+
+	// stateDB persists the state of the alloc and task runners
+	stateDB *bolt.DB
+
 	logger *log.Logger
 
 	rpcProxy *rpcproxy.RPCProxy
@@ -156,12 +165,22 @@ type Client struct {
 
 // NewClient is used to create a new client from the given configuration
 func NewClient(cfg *config.Config, consulSyncer *consul.Syncer, logger *log.Logger) (*Client, error) {
+	// Configure TLS
+	var tlsWrap tlsutil.RegionWrapper
+	if cfg.TLSConfig != nil && cfg.TLSConfig.EnableRPC {
+		tw, err := tlsutil.NewTLSConfiguration(cfg.TLSConfig).OutgoingTLSWrapper()
+		if err != nil {
+			return nil, err
+		}
+		tlsWrap = tw
+	}
+
 	// Create the client
 	c := &Client{
 		config:             cfg,
 		consulSyncer:       consulSyncer,
 		start:              time.Now(),
-		connPool:           nomad.NewPool(cfg.LogOutput, clientRPCCache, clientMaxStreams, nil),
+		connPool:           nomad.NewPool(cfg.LogOutput, clientRPCCache, clientMaxStreams, tlsWrap),
 		logger:             logger,
 		hostStatsCollector: stats.NewHostStatsCollector(),
 		allocs:             make(map[string]*AllocRunner),
@@ -238,6 +257,11 @@ func NewClient(cfg *config.Config, consulSyncer *consul.Syncer, logger *log.Logg
 	// Start collecting stats
 	go c.collectHostStats()
 
+	// Garbage collect terminal allocations under disk pressure
+	if c.config.GCInterval > 0 {
+		go c.gcDiskPressure()
+	}
+
 	// Start the RPCProxy maintenance task.  This task periodically
 	// shuffles the list of Nomad Server Endpoints this Client will use
 	// when communicating with Nomad Servers via RPC.  This is done in
@@ -275,6 +299,14 @@ func (c *Client) init() error {
 	}
 	c.logger.Printf("[INFO] client: using state directory %v", c.config.StateDir)
 
+	// Open the state database, failing rather than blocking if another agent
+	// holds it
+	db, err := bolt.Open(filepath.Join(c.config.StateDir, stateDBFile), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("failed to open state database: %v", err)
+	}
+	c.stateDB = db
+
 	// Ensure the alloc dir exists if we have one
 	if c.config.AllocDir != "" {
 		if err := os.MkdirAll(c.config.AllocDir, 0755); err != nil {
@@ -289,7 +321,25 @@ func (c *Client) init() error {
 		c.config.AllocDir = p
 	}
 
-	c.logger.Printf("[INFO] client: using alloc directory %v", c.config.AllocDir)
+	// Ensure the additional alloc dir roots exist
+	for _, root := range c.config.AllocDirs {
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return fmt.Errorf("failed creating alloc dir root: %s", err)
+		}
+	}
+
+	roots := c.config.AllocDirRoots()
+	allocRoots, err := allocdir.NewRootSelector(roots, c.config.AllocDirPolicy)
+	if err != nil {
+		return err
+	}
+	c.allocRoots = allocRoots
+
+	if len(roots) > 1 {
+		c.logger.Printf("[INFO] client: using alloc directories %v (policy %q)", roots, allocRoots.Policy())
+	} else {
+		c.logger.Printf("[INFO] client: using alloc directory %v", c.config.AllocDir)
+	}
 	return nil
 }
 
@@ -352,7 +402,15 @@ func (c *Client) Shutdown() error {
 	c.shutdown = true
 	close(c.shutdownCh)
 	c.connPool.Shutdown()
-	return c.saveState()
+
+	var mErr multierror.Error
+	if err := c.saveState(); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	}
+	if err := c.stateDB.Close(); err != nil {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("failed to close state database: %v", err))
+	}
+	return mErr.ErrorOrNil()
 }
 
 // RPC is used to forward an RPC call to a nomad server, or fail if no servers
@@ -443,6 +501,18 @@ func (c *Client) GetAllocFS(allocID string) (allocdir.AllocDirFS, error) {
 	return ar.ctx.AllocDir, nil
 }
 
+// RestartAllocation restarts the given task of an allocation, or all of its
+// tasks if taskName is empty, without rescheduling the allocation.
+func (c *Client) RestartAllocation(allocID, taskName, reason string) error {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown allocation ID %q", allocID)
+	}
+	return ar.Restart(taskName, reason)
+}
+
 // AddPrimaryServerToRPCProxy adds serverAddr to the RPC Proxy's primary
 // server list.
 func (c *Client) AddPrimaryServerToRPCProxy(serverAddr string) *rpcproxy.ServerEndpoint {
@@ -455,22 +525,28 @@ func (c *Client) restoreState() error {
 		return nil
 	}
 
-	// Scan the directory
-	list, err := ioutil.ReadDir(filepath.Join(c.config.StateDir, "alloc"))
-	if err != nil && os.IsNotExist(err) {
-		return nil
-	} else if err != nil {
-		return fmt.Errorf("failed to list alloc state: %v", err)
+	// Move the state written by earlier versions into the state database
+	if err := c.migrateLegacyState(); err != nil {
+		return fmt.Errorf("failed to migrate legacy state: %v", err)
 	}
 
+	// List the allocations with a saved state
+	var ids []string
+	c.stateDB.View(func(tx *bolt.Tx) error {
+		ids = allocationIDs(tx)
+		return nil
+	})
+
 	// Load each alloc back
 	var mErr multierror.Error
-	for _, entry := range list {
-		id := entry.Name()
+	for _, id := range ids {
 		alloc := &structs.Allocation{ID: id}
 		c.configLock.RLock()
-		ar := NewAllocRunner(c.logger, c.configCopy, c.updateAllocStatus, alloc)
+		ar := NewAllocRunner(c.logger, c.configCopy, c.stateDB, c.updateAllocStatus, alloc)
 		c.configLock.RUnlock()
+		ar.SetTaskChecks(c.taskChecks)
+		ar.SetTaskCheckStatuses(c.taskCheckStatuses)
+		ar.SetAllocRoot(c.allocRoots.Select)
 		c.allocLock.Lock()
 		c.allocs[id] = ar
 		c.allocLock.Unlock()
@@ -577,6 +653,9 @@ func (c *Client) setupNode() error {
 
 	node.ID = id
 	node.SecretID = secretID
+	if c.config.TLSConfig != nil {
+		node.TLSEnabled = c.config.TLSConfig.EnableHTTP
+	}
 	if node.Attributes == nil {
 		node.Attributes = make(map[string]string)
 	}
@@ -592,6 +671,19 @@ func (c *Client) setupNode() error {
 	if node.Reserved == nil {
 		node.Reserved = &structs.Resources{}
 	}
+
+	// Expose the host volumes that exist
+	for name, volume := range c.config.HostVolumes {
+		info, err := os.Stat(volume.Path)
+		if err != nil {
+			return fmt.Errorf("host volume %q: %v", name, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("host volume %q: %s is not a directory", name, volume.Path)
+		}
+	}
+	node.HostVolumes = structs.CopyMapStringClientHostVolumeConfig(c.config.HostVolumes)
+
 	if node.Datacenter == "" {
 		node.Datacenter = "dc1"
 	}
@@ -717,7 +809,7 @@ func (c *Client) setupDrivers() error {
 
 	var avail []string
 	var skipped []string
-	driverCtx := driver.NewDriverContext("", c.config, c.config.Node, c.logger, nil)
+	driverCtx := driver.NewDriverContext("", c.config, c.config.Node, c.logger, nil, nil)
 	for name := range driver.BuiltinDrivers {
 		// Skip fingerprinting drivers that are not in the whitelist if it is
 		// enabled.
@@ -738,6 +830,7 @@ func (c *Client) setupDrivers() error {
 		}
 		if applies {
 			avail = append(avail, name)
+			c.setDriverJobLists(name)
 		}
 
 		p, period := d.Periodic()
@@ -756,6 +849,26 @@ func (c *Client) setupDrivers() error {
 	return nil
 }
 
+// setDriverJobLists advertises the jobs allowed to use the driver by copying
+// its configured job whitelist and blacklist into the node attributes, such
+// that the schedulers avoid placing other jobs on the node.
+func (c *Client) setDriverJobLists(name string) {
+	c.configLock.Lock()
+	defer c.configLock.Unlock()
+	attrs := []string{
+		structs.NodeDriverJobWhitelistAttr,
+		structs.NodeDriverJobBlacklistAttr,
+		structs.NodeDriverNamespaceWhitelistAttr,
+		structs.NodeDriverNamespaceBlacklistAttr,
+	}
+	for _, attr := range attrs {
+		key := fmt.Sprintf(attr, name)
+		if list, ok := c.config.Options[key]; ok {
+			c.config.Node.Attributes[key] = list
+		}
+	}
+}
+
 // retryIntv calculates a retry interval value given the base
 func (c *Client) retryIntv(base time.Duration) time.Duration {
 	if c.config.DevMode {
@@ -972,12 +1085,31 @@ func (c *Client) updateAllocStatus(alloc *structs.Allocation) {
 	stripped.TaskStates = alloc.TaskStates
 	stripped.ClientStatus = alloc.ClientStatus
 	stripped.ClientDescription = alloc.ClientDescription
+	stripped.DeploymentStatus = alloc.DeploymentStatus
 	select {
 	case c.allocUpdates <- stripped:
 	case <-c.shutdownCh:
 	}
 }
 
+// taskChecks returns the number of Consul checks registered for the services
+// of a task and how many of them are passing.
+func (c *Client) taskChecks(allocID, task string) (int, int, error) {
+	if c.consulSyncer == nil {
+		return 0, 0, fmt.Errorf("consul syncer not available")
+	}
+	return c.consulSyncer.ChecksPassing(consul.NewExecutorDomain(allocID, task))
+}
+
+// taskCheckStatuses returns the status of the Consul checks registered for
+// the services of a task by check name.
+func (c *Client) taskCheckStatuses(allocID, task string) (map[string]string, error) {
+	if c.consulSyncer == nil {
+		return nil, fmt.Errorf("consul syncer not available")
+	}
+	return c.consulSyncer.CheckStatuses(consul.NewExecutorDomain(allocID, task))
+}
+
 // allocSync is a long lived function that batches allocation updates to the
 // server.
 func (c *Client) allocSync() {
@@ -1284,8 +1416,11 @@ func (c *Client) updateAlloc(exist, update *structs.Allocation) error {
 // addAlloc is invoked when we should add an allocation
 func (c *Client) addAlloc(alloc *structs.Allocation) error {
 	c.configLock.RLock()
-	ar := NewAllocRunner(c.logger, c.configCopy, c.updateAllocStatus, alloc)
+	ar := NewAllocRunner(c.logger, c.configCopy, c.stateDB, c.updateAllocStatus, alloc)
 	c.configLock.RUnlock()
+	ar.SetTaskChecks(c.taskChecks)
+	ar.SetTaskCheckStatuses(c.taskCheckStatuses)
+	ar.SetAllocRoot(c.allocRoots.Select)
 	go ar.Run()
 
 	// Store the alloc runner.
@@ -1579,6 +1714,12 @@ func (c *Client) collectHostStats() {
 	for {
 		select {
 		case <-next.C:
+			// Publish the allocation metrics aggregated over the node if the
+			// operator has opted in
+			if c.config.PublishAllocationMetrics && c.config.AggregateAllocationMetrics {
+				c.emitAllocStats()
+			}
+
 			ru, err := c.hostStatsCollector.Collect()
 			next.Reset(c.config.StatsCollectionInterval)
 			if err != nil {
@@ -1626,6 +1767,47 @@ func (c *Client) emitStats(hStats *stats.HostStats) {
 	}
 }
 
+// emitAllocStats pushes the resource usage of the tasks running on the node,
+// aggregated over the node, to remote metrics collection sinks
+func (c *Client) emitAllocStats() {
+	ru := &cstructs.ResourceUsage{
+		MemoryStats: &cstructs.MemoryStats{},
+		CpuStats:    &cstructs.CpuStats{},
+	}
+	var tasks int
+	var restarts uint64
+	for _, ar := range c.getAllocRunners() {
+		for _, tr := range ar.getTaskRunners() {
+			restarts += tr.Restarts()
+
+			usage := tr.LatestResourceUsage()
+			if usage == nil || usage.ResourceUsage == nil {
+				continue
+			}
+			tasks++
+			if usage.ResourceUsage.MemoryStats != nil {
+				ru.MemoryStats.Add(usage.ResourceUsage.MemoryStats)
+			}
+			if usage.ResourceUsage.CpuStats != nil {
+				ru.CpuStats.Add(usage.ResourceUsage.CpuStats)
+			}
+		}
+	}
+
+	nodeID := c.Node().ID
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "tasks"}, float32(tasks))
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "restarts"}, float32(restarts))
+
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "memory", "rss"}, float32(ru.MemoryStats.RSS))
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "memory", "cache"}, float32(ru.MemoryStats.Cache))
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "memory", "swap"}, float32(ru.MemoryStats.Swap))
+
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "cpu", "total_percent"}, float32(ru.CpuStats.Percent))
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "cpu", "system"}, float32(ru.CpuStats.SystemMode))
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "cpu", "user"}, float32(ru.CpuStats.UserMode))
+	metrics.SetGauge([]string{"client", "allocs", nodeID, "cpu", "total_ticks"}, float32(ru.CpuStats.TotalTicks))
+}
+
 // RPCProxy returns the Client's RPCProxy instance
 func (c *Client) RPCProxy() *rpcproxy.RPCProxy {
 	return c.rpcProxy
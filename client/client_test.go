@@ -12,7 +12,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/command/agent/consul"
 	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/mock"
@@ -279,6 +281,33 @@ func TestClient_Drivers_OutOfWhitelist(t *testing.T) {
 	}
 }
 
+func TestClient_Drivers_JobLists(t *testing.T) {
+	c := testClient(t, func(c *config.Config) {
+		if c.Options == nil {
+			c.Options = make(map[string]string)
+		}
+
+		c.Options["driver.mock_driver.job_whitelist"] = "infra,ops"
+		c.Options["driver.mock_driver.namespace_blacklist"] = "web"
+		c.Options["driver.exec.job_blacklist"] = "web"
+	})
+	defer c.Shutdown()
+
+	node := c.Node()
+	if v := node.Attributes["driver.mock_driver.job_whitelist"]; v != "infra,ops" {
+		t.Fatalf("bad: %q", v)
+	}
+	if _, ok := node.Attributes["driver.mock_driver.job_blacklist"]; ok {
+		t.Fatalf("unexpected blacklist: %#v", node.Attributes)
+	}
+	if v := node.Attributes["driver.mock_driver.namespace_blacklist"]; v != "web" {
+		t.Fatalf("bad: %q", v)
+	}
+	if node.Attributes["driver.exec"] != "" && node.Attributes["driver.exec.job_blacklist"] != "web" {
+		t.Fatalf("missing exec job blacklist: %#v", node.Attributes)
+	}
+}
+
 func TestClient_Register(t *testing.T) {
 	s1, _ := testServer(t, nil)
 	defer s1.Shutdown()
@@ -710,3 +739,56 @@ func TestClient_BlockedAllocations(t *testing.T) {
 	c1.allocLock.Unlock()
 
 }
+
+func TestClient_EmitAllocStats(t *testing.T) {
+	c := testClient(t, nil)
+	defer c.Shutdown()
+
+	// Capture the metrics in memory
+	sink := metrics.NewInmemSink(10*time.Second, time.Minute)
+	conf := metrics.DefaultConfig("nomad")
+	conf.EnableHostname = false
+	conf.EnableRuntimeMetrics = false
+	metrics.NewGlobal(conf, sink)
+
+	// Two running tasks, only one of which has restarted
+	newTaskRunner := func(restarts uint64, rss uint64, percent float64) *TaskRunner {
+		return &TaskRunner{
+			restarts: restarts,
+			running:  true,
+			resourceUsage: &cstructs.TaskResourceUsage{
+				ResourceUsage: &cstructs.ResourceUsage{
+					MemoryStats: &cstructs.MemoryStats{RSS: rss},
+					CpuStats:    &cstructs.CpuStats{Percent: percent},
+				},
+			},
+		}
+	}
+	c.allocLock.Lock()
+	c.allocs["foo"] = &AllocRunner{tasks: map[string]*TaskRunner{
+		"web":   newTaskRunner(2, 100, 10),
+		"cache": newTaskRunner(0, 50, 5),
+	}}
+	c.allocLock.Unlock()
+
+	c.emitAllocStats()
+
+	// Remove the fake alloc runner before the client shuts down
+	c.allocLock.Lock()
+	delete(c.allocs, "foo")
+	c.allocLock.Unlock()
+
+	prefix := "nomad.client.allocs." + c.Node().ID + "."
+	expected := map[string]float32{
+		"tasks":             2,
+		"restarts":          2,
+		"memory.rss":        150,
+		"cpu.total_percent": 15,
+	}
+	gauges := sink.Data()[0].Gauges
+	for key, value := range expected {
+		if actual, ok := gauges[prefix+key]; !ok || actual != value {
+			t.Fatalf("bad %s: %v %v", key, actual, ok)
+		}
+	}
+}
@@ -59,6 +59,14 @@ type Config struct {
 	// AllocDir is where we store data for allocations
 	AllocDir string
 
+	// AllocDirs are additional roots, such as the mount points of other
+	// disks, on which the directories of allocations can be created
+	AllocDirs []string
+
+	// AllocDirPolicy is how the root of the directory of an allocation is
+	// chosen when several roots are configured
+	AllocDirPolicy string
+
 	// LogOutput is the destination for logs
 	LogOutput io.Writer
 
@@ -68,6 +76,10 @@ type Config struct {
 	// Network interface to be used in network fingerprinting
 	NetworkInterface string
 
+	// NetworkCIDR restricts the addresses advertised for the tasks to those
+	// within the CIDR, possibly on several interfaces.
+	NetworkCIDR string
+
 	// Network speed is the default speed of network interfaces if they can not
 	// be determined dynamically.
 	NetworkSpeed int
@@ -77,6 +89,12 @@ type Config struct {
 	// used.
 	MaxKillTimeout time.Duration
 
+	// TmpDirSize is the size in megabytes of the tmpfs mounted as the tmp
+	// directory of each task when running as root on Linux, so that scratch
+	// data can't exhaust the disk. The tmpfs is backed by memory. Zero, the
+	// default, leaves the tmp directory on the disk of the alloc dir.
+	TmpDirSize int
+
 	// Servers is a list of known server addresses. These are as "host:port"
 	Servers []string
 
@@ -103,6 +121,10 @@ type Config struct {
 	// task's chroot.
 	ChrootEnv map[string]string
 
+	// HostVolumes are the directories of the host that can be mounted into
+	// tasks, by name.
+	HostVolumes map[string]*structs.ClientHostVolumeConfig
+
 	// Options provides arbitrary key-value configuration for nomad internals,
 	// like fingerprinters and drivers. The format is:
 	//
@@ -121,6 +143,9 @@ type Config struct {
 	// VaultConfig is this Agent's Vault configuration
 	VaultConfig *config.VaultConfig
 
+	// TLSConfig holds various TLS related configurations
+	TLSConfig *config.TLSConfig
+
 	// StatsCollectionInterval is the interval at which the Nomad client
 	// collects resource usage stats
 	StatsCollectionInterval time.Duration
@@ -132,6 +157,20 @@ type Config struct {
 	// PublishAllocationMetrics determines whether nomad is going to publish
 	// allocation metrics to remote Telemetry sinks
 	PublishAllocationMetrics bool
+
+	// AggregateAllocationMetrics determines whether the allocation metrics
+	// are only published as aggregates over the node rather than per task,
+	// limiting the cardinality of the metrics
+	AggregateAllocationMetrics bool
+
+	// GCInterval is the interval at which the client checks the disk usage
+	// of the allocation directory for pressure. Zero disables the check.
+	GCInterval time.Duration
+
+	// GCDiskUsageThreshold is the disk usage percentage of the filesystem
+	// backing the allocation directory above which terminal allocations are
+	// garbage collected.
+	GCDiskUsageThreshold float64
 }
 
 func (c *Config) Copy() *Config {
@@ -139,21 +178,45 @@ func (c *Config) Copy() *Config {
 	*nc = *c
 	nc.Node = nc.Node.Copy()
 	nc.Servers = structs.CopySliceString(nc.Servers)
+	nc.AllocDirs = structs.CopySliceString(nc.AllocDirs)
 	nc.Options = structs.CopyMapStringString(nc.Options)
 	nc.GloballyReservedPorts = structs.CopySliceInt(c.GloballyReservedPorts)
+	nc.HostVolumes = structs.CopyMapStringClientHostVolumeConfig(c.HostVolumes)
 	nc.ConsulConfig = c.ConsulConfig.Copy()
 	nc.VaultConfig = c.VaultConfig.Copy()
+	nc.TLSConfig = c.TLSConfig.Copy()
 	return nc
 }
 
+// AllocDirRoots returns the roots on which the directories of allocations
+// can be created, starting with AllocDir and without duplicates.
+func (c *Config) AllocDirRoots() []string {
+	roots := make([]string, 0, 1+len(c.AllocDirs))
+	seen := make(map[string]struct{}, 1+len(c.AllocDirs))
+	for _, root := range append([]string{c.AllocDir}, c.AllocDirs...) {
+		if root == "" {
+			continue
+		}
+		if _, ok := seen[root]; ok {
+			continue
+		}
+		seen[root] = struct{}{}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
 		VaultConfig:             config.DefaultVaultConfig(),
 		ConsulConfig:            config.DefaultConsulConfig(),
+		TLSConfig:               &config.TLSConfig{},
 		LogOutput:               os.Stderr,
 		Region:                  "global",
 		StatsCollectionInterval: 1 * time.Second,
+		GCInterval:              1 * time.Minute,
+		GCDiskUsageThreshold:    80,
 	}
 }
 
@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestConfigRead(t *testing.T) {
 	config := Config{}
@@ -34,3 +37,15 @@ func TestConfigReadDefault(t *testing.T) {
 		t.Errorf("Expected %s, found %s", expected, actual)
 	}
 }
+
+func TestConfigAllocDirRoots(t *testing.T) {
+	config := Config{
+		AllocDir:  "/a",
+		AllocDirs: []string{"/b", "/a", "", "/c", "/b"},
+	}
+
+	expected := []string{"/a", "/b", "/c"}
+	if actual := config.AllocDirRoots(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, found %v", expected, actual)
+	}
+}
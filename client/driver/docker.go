@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	docker "github.com/fsouza/go-dockerclient"
@@ -61,6 +62,11 @@ const (
 	// dockerTimeout is the length of time a request can be outstanding before
 	// it is timed out.
 	dockerTimeout = 1 * time.Minute
+
+	// dockerVolumesConfigOption is the key for enabling the use of custom
+	// bind volumes to arbitrary host paths.
+	dockerVolumesConfigOption  = "docker.volumes.enabled"
+	dockerVolumesConfigDefault = false
 )
 
 type DockerDriver struct {
@@ -88,6 +94,10 @@ type DockerDriverConfig struct {
 	Privileged       bool                `mapstructure:"privileged"`         // Flag to run the container in privileged mode
 	DNSServers       []string            `mapstructure:"dns_servers"`        // DNS Server for containers
 	DNSSearchDomains []string            `mapstructure:"dns_search_domains"` // DNS Search domains for containers
+	DNSOptions       []string            `mapstructure:"dns_options"`        // DNS resolver options for containers
+	IPv4Address      string              `mapstructure:"ipv4_address"`       // Static IPv4 address of the container on a user defined network
+	IPv6Address      string              `mapstructure:"ipv6_address"`       // Static IPv6 address of the container on a user defined network
+	NetworkAliases   []string            `mapstructure:"network_aliases"`    // Aliases of the container on a user defined network
 	Hostname         string              `mapstructure:"hostname"`           // Hostname for containers
 	LabelsRaw        []map[string]string `mapstructure:"labels"`             //
 	Labels           map[string]string   `mapstructure:"-"`                  // Labels to set when the container starts up
@@ -100,6 +110,11 @@ type DockerDriverConfig struct {
 	AttachStderr     bool                `mapstructure:"attach_stderr"`      // Attach to STDERR
 	ShmSize          int64               `mapstructure:"shm_size"`           // Size of /dev/shm of the container in bytes
 	WorkDir          string              `mapstructure:"work_dir"`           // Working directory inside the container
+	Volumes          []string            `mapstructure:"volumes"`            // Host-Volumes to mount in, syntax: /path/to/host/directory:/destination/path/in/container
+	VolumeDriver     string              `mapstructure:"volume_driver"`      // Docker volume driver used for the container's volumes
+
+	RestartUnhealthyAfterRaw string        `mapstructure:"restart_unhealthy_after"` //
+	RestartUnhealthyAfter    time.Duration `mapstructure:"-"`                       // Period after which a container unhealthy according to its HEALTHCHECK is restarted
 }
 
 // Validate validates a docker driver config
@@ -111,9 +126,47 @@ func (c *DockerDriverConfig) Validate() error {
 	c.PortMap = mapMergeStrInt(c.PortMapRaw...)
 	c.Labels = mapMergeStrStr(c.LabelsRaw...)
 
+	// Addresses and aliases can only be set on user defined networks
+	if c.IPv4Address != "" || c.IPv6Address != "" || len(c.NetworkAliases) != 0 {
+		if !isUserDefinedNetwork(c.NetworkMode) {
+			return fmt.Errorf("ipv4_address, ipv6_address and network_aliases require a user defined network_mode")
+		}
+	}
+	if c.IPv4Address != "" {
+		if ip := net.ParseIP(c.IPv4Address); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("invalid ipv4_address %q", c.IPv4Address)
+		}
+	}
+	if c.IPv6Address != "" {
+		if ip := net.ParseIP(c.IPv6Address); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid ipv6_address %q", c.IPv6Address)
+		}
+	}
+
+	if c.RestartUnhealthyAfterRaw != "" {
+		after, err := time.ParseDuration(c.RestartUnhealthyAfterRaw)
+		if err != nil {
+			return fmt.Errorf("invalid restart_unhealthy_after %q: %v", c.RestartUnhealthyAfterRaw, err)
+		}
+		if after <= 0 {
+			return fmt.Errorf("restart_unhealthy_after must be positive")
+		}
+		c.RestartUnhealthyAfter = after
+	}
+
 	return nil
 }
 
+// isUserDefinedNetwork returns whether the network mode is a user defined
+// network rather than one of the modes built into docker.
+func isUserDefinedNetwork(mode string) bool {
+	switch mode {
+	case "", "default", "bridge", "host", "none", "nat":
+		return false
+	}
+	return !strings.HasPrefix(mode, "container:")
+}
+
 // NewDockerDriverConfig returns a docker driver config by parsing the HCL
 // config
 func NewDockerDriverConfig(task *structs.Task) (*DockerDriverConfig, error) {
@@ -133,12 +186,13 @@ func NewDockerDriverConfig(task *structs.Task) (*DockerDriverConfig, error) {
 }
 
 type dockerPID struct {
-	Version        string
-	ImageID        string
-	ContainerID    string
-	KillTimeout    time.Duration
-	MaxKillTimeout time.Duration
-	PluginConfig   *PluginReattachConfig
+	Version               string
+	ImageID               string
+	ContainerID           string
+	KillTimeout           time.Duration
+	MaxKillTimeout        time.Duration
+	RestartUnhealthyAfter time.Duration
+	PluginConfig          *PluginReattachConfig
 }
 
 type DockerHandle struct {
@@ -158,6 +212,11 @@ type DockerHandle struct {
 	resourceUsage     *cstructs.TaskResourceUsage
 	waitCh            chan *dstructs.WaitResult
 	doneCh            chan bool
+
+	// restartUnhealthyAfter is the period after which an unhealthy container
+	// is restarted, if set, and healthCh receives the changes of its health
+	restartUnhealthyAfter time.Duration
+	healthCh              chan *dstructs.HealthUpdate
 }
 
 func NewDockerDriver(ctx *DriverContext) Driver {
@@ -206,6 +265,21 @@ func (d *DockerDriver) Validate(config map[string]interface{}) error {
 			"dns_search_domains": &fields.FieldSchema{
 				Type: fields.TypeArray,
 			},
+			"dns_options": &fields.FieldSchema{
+				Type: fields.TypeArray,
+			},
+			"ipv4_address": &fields.FieldSchema{
+				Type: fields.TypeString,
+			},
+			"ipv6_address": &fields.FieldSchema{
+				Type: fields.TypeString,
+			},
+			"network_aliases": &fields.FieldSchema{
+				Type: fields.TypeArray,
+			},
+			"restart_unhealthy_after": &fields.FieldSchema{
+				Type: fields.TypeString,
+			},
 			"hostname": &fields.FieldSchema{
 				Type: fields.TypeString,
 			},
@@ -239,6 +313,12 @@ func (d *DockerDriver) Validate(config map[string]interface{}) error {
 			"work_dir": &fields.FieldSchema{
 				Type: fields.TypeString,
 			},
+			"volumes": &fields.FieldSchema{
+				Type: fields.TypeArray,
+			},
+			"volume_driver": &fields.FieldSchema{
+				Type: fields.TypeString,
+			},
 		},
 	}
 
@@ -337,6 +417,12 @@ func (d *DockerDriver) Fingerprint(cfg *config.Config, node *structs.Node) (bool
 		node.Attributes["docker.privileged.enabled"] = "1"
 	}
 
+	if d.config.ReadBoolDefault(dockerVolumesConfigOption, dockerVolumesConfigDefault) {
+		node.Attributes[dockerVolumesConfigOption] = "1"
+	} else {
+		delete(node.Attributes, dockerVolumesConfigOption)
+	}
+
 	// This is the first operation taken on the client so we'll try to
 	// establish a connection to the Docker daemon. If this fails it means
 	// Docker isn't available so we'll simply disable the docker driver.
@@ -354,7 +440,8 @@ func (d *DockerDriver) Fingerprint(cfg *config.Config, node *structs.Node) (bool
 	return true, nil
 }
 
-func (d *DockerDriver) containerBinds(alloc *allocdir.AllocDir, task *structs.Task) ([]string, error) {
+func (d *DockerDriver) containerBinds(driverConfig *DockerDriverConfig, alloc *allocdir.AllocDir,
+	task *structs.Task) ([]string, error) {
 	shared := alloc.SharedDir
 	local, ok := alloc.TaskDirs[task.Name]
 	if !ok {
@@ -363,15 +450,103 @@ func (d *DockerDriver) containerBinds(alloc *allocdir.AllocDir, task *structs.Ta
 
 	allocDirBind := fmt.Sprintf("%s:%s", shared, allocdir.SharedAllocContainerPath)
 	taskLocalBind := fmt.Sprintf("%s:%s", local, allocdir.TaskLocalContainerPath)
+	secretsBind := fmt.Sprintf("%s:%s", filepath.Join(local, allocdir.TaskSecrets), allocdir.TaskSecretsContainerPath)
 
 	if selinuxLabel := d.config.Read("docker.volumes.selinuxlabel"); selinuxLabel != "" {
 		allocDirBind = fmt.Sprintf("%s:%s", allocDirBind, selinuxLabel)
 		taskLocalBind = fmt.Sprintf("%s:%s", taskLocalBind, selinuxLabel)
+		secretsBind = fmt.Sprintf("%s:%s", secretsBind, selinuxLabel)
 	}
-	return []string{
+	binds := []string{
 		allocDirBind,
 		taskLocalBind,
-	}, nil
+		secretsBind,
+	}
+
+	volumesEnabled := d.config.ReadBoolDefault(dockerVolumesConfigOption, dockerVolumesConfigDefault)
+	for _, userbind := range driverConfig.Volumes {
+		parts := strings.Split(userbind, ":")
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid docker volume: %q", userbind)
+		}
+
+		switch {
+		case driverConfig.VolumeDriver != "":
+			// Named volumes of a volume driver can expose anything to the
+			// container
+			if !volumesEnabled {
+				return nil, fmt.Errorf("%s is false; cannot use volume driver %q", dockerVolumesConfigOption, driverConfig.VolumeDriver)
+			}
+		case filepath.IsAbs(parts[0]):
+			if !volumesEnabled {
+				return nil, fmt.Errorf("%s is false; cannot use host path %q", dockerVolumesConfigOption, parts[0])
+			}
+		default:
+			// Relative paths are relative to the task directory and can't
+			// escape it
+			src := filepath.Join(local, parts[0])
+			if rel, err := filepath.Rel(local, src); err != nil || strings.HasPrefix(rel, "..") {
+				return nil, fmt.Errorf("docker volume %q escapes the task directory", userbind)
+			}
+			parts[0] = src
+		}
+		binds = append(binds, strings.Join(parts, ":"))
+	}
+	return binds, nil
+}
+
+// hostVolumeBinds returns the binds mounting the client's host volumes of the
+// task's volume mounts.
+func (d *DockerDriver) hostVolumeBinds(ctx *ExecContext, task *structs.Task) ([]string, error) {
+	var binds []string
+	for _, mount := range task.VolumeMounts {
+		req, ok := ctx.Volumes[mount.Volume]
+		if !ok {
+			return nil, fmt.Errorf("volume %q isn't requested by the task group", mount.Volume)
+		}
+		if req.Type != structs.VolumeTypeHost {
+			continue
+		}
+		volume, ok := d.config.HostVolumes[req.Source]
+		if !ok {
+			return nil, fmt.Errorf("host volume %q doesn't exist on the client", req.Source)
+		}
+
+		bind := fmt.Sprintf("%s:%s", volume.Path, mount.Destination)
+		if volume.ReadOnly || req.ReadOnly || mount.ReadOnly {
+			bind += ":ro"
+		}
+		binds = append(binds, bind)
+	}
+	return binds, nil
+}
+
+// dockerGPUDevices returns the devices of the GPUs and the NVIDIA driver's
+// control devices found on the host.
+func dockerGPUDevices(gpus []int) []docker.Device {
+	if len(gpus) == 0 {
+		return nil
+	}
+
+	var paths []string
+	for _, path := range dstructs.NvidiaControlDevices {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	for _, gpu := range gpus {
+		paths = append(paths, dstructs.NvidiaDevicePath(gpu))
+	}
+
+	devices := make([]docker.Device, len(paths))
+	for i, path := range paths {
+		devices[i] = docker.Device{
+			PathOnHost:        path,
+			PathInContainer:   path,
+			CgroupPermissions: "rwm",
+		}
+	}
+	return devices
 }
 
 // createContainer initializes a struct needed to call docker.client.CreateContainer()
@@ -385,15 +560,21 @@ func (d *DockerDriver) createContainer(ctx *ExecContext, task *structs.Task,
 		return c, fmt.Errorf("task.Resources is empty")
 	}
 
-	binds, err := d.containerBinds(ctx.AllocDir, task)
+	binds, err := d.containerBinds(driverConfig, ctx.AllocDir, task)
+	if err != nil {
+		return c, err
+	}
+	volumeBinds, err := d.hostVolumeBinds(ctx, task)
 	if err != nil {
 		return c, err
 	}
+	binds = append(binds, volumeBinds...)
 
 	// Set environment variables.
 	d.taskEnv.SetAllocDir(allocdir.SharedAllocContainerPath)
 	d.taskEnv.SetTaskLocalDir(allocdir.TaskLocalContainerPath)
-	d.taskEnv.SetTaskLocalDir(allocdir.TaskSecretsContainerPath)
+	d.taskEnv.SetSecretDir(allocdir.TaskSecretsContainerPath)
+	d.taskEnv.SetTmpDir(filepath.Join(allocdir.TaskLocalContainerPath, allocdir.TaskTmp))
 
 	config := &docker.Config{
 		Image:        driverConfig.ImageName,
@@ -426,7 +607,9 @@ func (d *DockerDriver) createContainer(ctx *ExecContext, task *structs.Task,
 		// Binds are used to mount a host volume into the container. We mount a
 		// local directory for storage and a shared alloc directory that can be
 		// used to share data between different tasks in the same task group.
-		Binds: binds,
+		// The task's volumes are also mounted.
+		Binds:        binds,
+		VolumeDriver: driverConfig.VolumeDriver,
 		LogConfig: docker.LogConfig{
 			Type: "syslog",
 			Config: map[string]string{
@@ -435,8 +618,24 @@ func (d *DockerDriver) createContainer(ctx *ExecContext, task *structs.Task,
 		},
 	}
 
+	// Expose the GPUs assigned to the task
+	hostConfig.Devices = dockerGPUDevices(task.Resources.GPUDevices)
+
+	// Bound the CPU usage of the task rather than only weighting it
+	if task.Resources.CPUHardLimit {
+		quota, err := shelpers.CPUQuota(task.Resources.CPU)
+		if err != nil {
+			return c, fmt.Errorf("failed to compute the CPU quota: %v", err)
+		}
+		hostConfig.CPUPeriod = shelpers.CPUPeriod
+		hostConfig.CPUQuota = quota
+	}
+
 	d.logger.Printf("[DEBUG] driver.docker: using %d bytes memory for %s", hostConfig.Memory, task.Name)
 	d.logger.Printf("[DEBUG] driver.docker: using %d cpu shares for %s", hostConfig.CPUShares, task.Name)
+	if hostConfig.CPUQuota != 0 {
+		d.logger.Printf("[DEBUG] driver.docker: using a cpu quota of %d/%dus for %s", hostConfig.CPUQuota, hostConfig.CPUPeriod, task.Name)
+	}
 	d.logger.Printf("[DEBUG] driver.docker: binding directories %#v for %s", hostConfig.Binds, task.Name)
 
 	//  set privileged mode
@@ -465,6 +664,9 @@ func (d *DockerDriver) createContainer(ctx *ExecContext, task *structs.Task,
 		hostConfig.DNSSearch = append(hostConfig.DNSSearch, domain)
 	}
 
+	// set DNS options
+	hostConfig.DNSOptions = driverConfig.DNSOptions
+
 	hostConfig.IpcMode = driverConfig.IpcMode
 	hostConfig.PidMode = driverConfig.PidMode
 	hostConfig.UTSMode = driverConfig.UTSMode
@@ -476,6 +678,27 @@ func (d *DockerDriver) createContainer(ctx *ExecContext, task *structs.Task,
 		hostConfig.NetworkMode = defaultNetworkMode
 	}
 
+	// Configure the container's endpoint on a user defined network
+	var networkingConfig *docker.NetworkingConfig
+	if driverConfig.IPv4Address != "" || driverConfig.IPv6Address != "" || len(driverConfig.NetworkAliases) != 0 {
+		endpoint := &docker.EndpointConfig{
+			Aliases: driverConfig.NetworkAliases,
+		}
+		if driverConfig.IPv4Address != "" || driverConfig.IPv6Address != "" {
+			endpoint.IPAMConfig = &docker.EndpointIPAMConfig{
+				IPv4Address: driverConfig.IPv4Address,
+				IPv6Address: driverConfig.IPv6Address,
+			}
+		}
+		networkingConfig = &docker.NetworkingConfig{
+			EndpointsConfig: map[string]*docker.EndpointConfig{
+				hostConfig.NetworkMode: endpoint,
+			},
+		}
+		d.logger.Printf("[DEBUG] driver.docker: using network %s with address %q %q and aliases %v for %s",
+			hostConfig.NetworkMode, driverConfig.IPv4Address, driverConfig.IPv6Address, driverConfig.NetworkAliases, task.Name)
+	}
+
 	// Setup port mapping and exposed ports
 	if len(task.Resources.Networks) == 0 {
 		d.logger.Println("[DEBUG] driver.docker: No network interfaces are available")
@@ -568,9 +791,10 @@ func (d *DockerDriver) createContainer(ctx *ExecContext, task *structs.Task,
 	d.logger.Printf("[DEBUG] driver.docker: setting container name to: %s", containerName)
 
 	return docker.CreateContainerOptions{
-		Name:       containerName,
-		Config:     config,
-		HostConfig: hostConfig,
+		Name:             containerName,
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
 	}, nil
 }
 
@@ -630,39 +854,13 @@ func (d *DockerDriver) pullImage(driverConfig *DockerDriverConfig, client *docke
 		Tag:        tag,
 	}
 
-	authOptions := docker.AuthConfiguration{}
-	if len(driverConfig.Auth) != 0 {
-		authOptions = docker.AuthConfiguration{
-			Username:      driverConfig.Auth[0].Username,
-			Password:      driverConfig.Auth[0].Password,
-			Email:         driverConfig.Auth[0].Email,
-			ServerAddress: driverConfig.Auth[0].ServerAddress,
-		}
-	}
-
-	if authConfigFile := d.config.Read("docker.auth.config"); authConfigFile != "" {
-		if f, err := os.Open(authConfigFile); err == nil {
-			defer f.Close()
-			var authConfigurations *docker.AuthConfigurations
-			if authConfigurations, err = docker.NewAuthConfigurations(f); err != nil {
-				return fmt.Errorf("Failed to create docker auth object: %v", err)
-			}
-
-			authConfigurationKey := ""
-			if driverConfig.SSL {
-				authConfigurationKey += "https://"
-			}
-
-			authConfigurationKey += strings.Split(driverConfig.ImageName, "/")[0]
-			if authConfiguration, ok := authConfigurations.Configs[authConfigurationKey]; ok {
-				authOptions = authConfiguration
-			}
-		} else {
-			return fmt.Errorf("Failed to open auth config file: %v, error: %v", authConfigFile, err)
-		}
+	authOptions, err := d.authOptions(driverConfig, repo)
+	if err != nil {
+		return err
 	}
 
-	err := client.PullImage(pullOptions, authOptions)
+	d.EmitEvent("Downloading image %s:%s", repo, tag)
+	err = client.PullImage(pullOptions, authOptions)
 	if err != nil {
 		d.logger.Printf("[ERR] driver.docker: failed pulling container %s:%s: %s", repo, tag, err)
 		return d.recoverablePullError(err, driverConfig.ImageName)
@@ -677,6 +875,7 @@ func (d *DockerDriver) loadImage(driverConfig *DockerDriverConfig, client *docke
 	for _, image := range driverConfig.LoadImages {
 		archive := filepath.Join(taskDir, allocdir.TaskLocal, image)
 		d.logger.Printf("[DEBUG] driver.docker: loading image from: %v", archive)
+		d.EmitEvent("Loading image %s", image)
 		f, err := os.Open(archive)
 		if err != nil {
 			errors.Errors = append(errors.Errors, fmt.Errorf("unable to open image archive: %v", err))
@@ -836,11 +1035,15 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle
 		maxKillTimeout: maxKill,
 		doneCh:         make(chan bool),
 		waitCh:         make(chan *dstructs.WaitResult, 1),
+
+		restartUnhealthyAfter: driverConfig.RestartUnhealthyAfter,
+		healthCh:              make(chan *dstructs.HealthUpdate, 8),
 	}
 	if err := exec.SyncServices(consulContext(d.config, container.ID)); err != nil {
 		d.logger.Printf("[ERR] driver.docker: error registering services with consul for task: %q: %v", task.Name, err)
 	}
 	go h.collectStats()
+	go h.watchHealth()
 	go h.run()
 	return h, nil
 }
@@ -912,12 +1115,16 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 		maxKillTimeout: pid.MaxKillTimeout,
 		doneCh:         make(chan bool),
 		waitCh:         make(chan *dstructs.WaitResult, 1),
+
+		restartUnhealthyAfter: pid.RestartUnhealthyAfter,
+		healthCh:              make(chan *dstructs.HealthUpdate, 8),
 	}
 	if err := exec.SyncServices(consulContext(d.config, pid.ContainerID)); err != nil {
 		h.logger.Printf("[ERR] driver.docker: error registering services with consul: %v", err)
 	}
 
 	go h.collectStats()
+	go h.watchHealth()
 	go h.run()
 	return h, nil
 }
@@ -925,12 +1132,13 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 func (h *DockerHandle) ID() string {
 	// Return a handle to the PID
 	pid := dockerPID{
-		Version:        h.version,
-		ImageID:        h.imageID,
-		ContainerID:    h.containerID,
-		KillTimeout:    h.killTimeout,
-		MaxKillTimeout: h.maxKillTimeout,
-		PluginConfig:   NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
+		Version:               h.version,
+		ImageID:               h.imageID,
+		ContainerID:           h.containerID,
+		KillTimeout:           h.killTimeout,
+		MaxKillTimeout:        h.maxKillTimeout,
+		RestartUnhealthyAfter: h.restartUnhealthyAfter,
+		PluginConfig:          NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
 	}
 	data, err := json.Marshal(pid)
 	if err != nil {
@@ -958,6 +1166,20 @@ func (h *DockerHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+// Signal sends the signal to the main process of the container
+func (h *DockerHandle) Signal(s os.Signal) error {
+	sysSig, ok := s.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("Failed to determine signal number")
+	}
+
+	opts := docker.KillContainerOptions{
+		ID:     h.containerID,
+		Signal: docker.Signal(sysSig),
+	}
+	return h.client.KillContainer(opts)
+}
+
 // Kill is used to terminate the task. This uses `docker stop -t killTimeout`
 func (h *DockerHandle) Kill() error {
 	// Stop the container
@@ -999,8 +1221,19 @@ func (h *DockerHandle) run() {
 		err = fmt.Errorf("Docker container exited with non-zero exit code: %d", exitCode)
 	}
 
+	// Check whether the container was killed for running out of memory
+	// before it is removed
+	oomKilled := false
+	if container, ierr := h.client.InspectContainer(h.containerID); ierr != nil {
+		h.logger.Printf("[DEBUG] driver.docker: failed to inspect exited container %s: %v", h.containerID, ierr)
+	} else {
+		oomKilled = container.State.OOMKilled
+	}
+
 	close(h.doneCh)
-	h.waitCh <- dstructs.NewWaitResult(exitCode, 0, err)
+	result := dstructs.NewWaitResult(exitCode, 0, err)
+	result.OOMKilled = oomKilled
+	h.waitCh <- result
 	close(h.waitCh)
 
 	// Remove services
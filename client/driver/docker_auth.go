@@ -0,0 +1,205 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// dockerHubRegistry is the registry of images whose name doesn't include
+	// one and dockerHubServer is its server address in docker config files.
+	dockerHubRegistry = "index.docker.io"
+	dockerHubServer   = "https://index.docker.io/v1/"
+
+	// dockerCredentialHelperPrefix is the prefix of the executables of the
+	// docker credential helpers.
+	dockerCredentialHelperPrefix = "docker-credential-"
+)
+
+// dockerConfigFile is a docker config file holding the registries' credentials
+// or the credential helpers storing them.
+type dockerConfigFile struct {
+	Auths       map[string]dockerConfigAuth `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+// dockerConfigAuth is the encoded credentials of a registry.
+type dockerConfigAuth struct {
+	Auth  string `json:"auth"`
+	Email string `json:"email"`
+}
+
+// dockerCredentials is the output of a docker credential helper.
+type dockerCredentials struct {
+	Username string `json:"Username"`
+	Secret   string `json:"Secret"`
+}
+
+// parseDockerConfigFile parses a docker config file, either in the
+// config.json or the legacy .dockercfg format.
+func parseDockerConfigFile(data []byte) (*dockerConfigFile, error) {
+	var config dockerConfigFile
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if len(config.Auths) != 0 || config.CredsStore != "" || len(config.CredHelpers) != 0 {
+		return &config, nil
+	}
+
+	// The legacy format maps the registries to their credentials
+	if err := json.Unmarshal(data, &config.Auths); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// dockerRegistry returns the registry hosting the repository.
+func dockerRegistry(repo string) string {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) == 1 {
+		return dockerHubRegistry
+	}
+	if strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost" {
+		return parts[0]
+	}
+	return dockerHubRegistry
+}
+
+// dockerRegistryServer returns the server address of the registry as stored
+// by docker.
+func dockerRegistryServer(registry string) string {
+	if registry == dockerHubRegistry {
+		return dockerHubServer
+	}
+	return registry
+}
+
+// normalizeDockerRegistry strips the scheme and path of a registry's server
+// address.
+func normalizeDockerRegistry(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	server = strings.SplitN(server, "/", 2)[0]
+	if server == "docker.io" || server == "registry-1.docker.io" {
+		return dockerHubRegistry
+	}
+	return server
+}
+
+// authFromConfigFile returns the credentials of the registry from the docker
+// config file, or nil if it doesn't have any.
+func authFromConfigFile(file, registry string) (*docker.AuthConfiguration, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open auth config file: %v, error: %v", file, err)
+	}
+	config, err := parseDockerConfigFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse auth config file: %v, error: %v", file, err)
+	}
+
+	// Credential helpers configured for the registry take precedence
+	for server, helper := range config.CredHelpers {
+		if normalizeDockerRegistry(server) == registry {
+			return authFromHelper(helper, registry)
+		}
+	}
+
+	for server, auth := range config.Auths {
+		if normalizeDockerRegistry(server) != registry || auth.Auth == "" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode credentials of %q: %v", server, err)
+		}
+		userpass := strings.SplitN(string(data), ":", 2)
+		if len(userpass) != 2 {
+			return nil, fmt.Errorf("Failed to decode credentials of %q", server)
+		}
+		return &docker.AuthConfiguration{
+			Username:      userpass[0],
+			Password:      userpass[1],
+			Email:         auth.Email,
+			ServerAddress: server,
+		}, nil
+	}
+
+	if config.CredsStore != "" {
+		return authFromHelper(config.CredsStore, registry)
+	}
+	return nil, nil
+}
+
+// authFromHelper returns the credentials of the registry from the docker
+// credential helper, or nil if it doesn't have any.
+func authFromHelper(helper, registry string) (*docker.AuthConfiguration, error) {
+	server := dockerRegistryServer(registry)
+	cmd := exec.Command(dockerCredentialHelperPrefix+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Helpers report missing credentials on stdout
+		out := stdout.String() + stderr.String()
+		if strings.Contains(out, "credentials not found") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("docker credential helper %q failed: %v: %s", helper, err, strings.TrimSpace(out))
+	}
+
+	var creds dockerCredentials
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials of docker credential helper %q: %v", helper, err)
+	}
+	return &docker.AuthConfiguration{
+		Username:      creds.Username,
+		Password:      creds.Secret,
+		ServerAddress: server,
+	}, nil
+}
+
+// authOptions returns the credentials used to pull the repository: the task's
+// auth, otherwise the ones found in the client's docker config file or
+// credential helper.
+func (d *DockerDriver) authOptions(driverConfig *DockerDriverConfig, repo string) (docker.AuthConfiguration, error) {
+	if len(driverConfig.Auth) != 0 {
+		return docker.AuthConfiguration{
+			Username:      driverConfig.Auth[0].Username,
+			Password:      driverConfig.Auth[0].Password,
+			Email:         driverConfig.Auth[0].Email,
+			ServerAddress: driverConfig.Auth[0].ServerAddress,
+		}, nil
+	}
+
+	registry := dockerRegistry(repo)
+	if file := d.config.Read("docker.auth.config"); file != "" {
+		auth, err := authFromConfigFile(file, registry)
+		if err != nil {
+			return docker.AuthConfiguration{}, err
+		}
+		if auth != nil {
+			return *auth, nil
+		}
+	}
+
+	if helper := d.config.Read("docker.auth.helper"); helper != "" {
+		auth, err := authFromHelper(helper, registry)
+		if err != nil {
+			return docker.AuthConfiguration{}, err
+		}
+		if auth != nil {
+			return *auth, nil
+		}
+	}
+	return docker.AuthConfiguration{}, nil
+}
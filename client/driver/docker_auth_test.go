@@ -0,0 +1,101 @@
+package driver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDockerRegistry(t *testing.T) {
+	cases := map[string]string{
+		"redis":                          dockerHubRegistry,
+		"library/redis":                  dockerHubRegistry,
+		"quay.io/coreos/etcd":            "quay.io",
+		"localhost/foo":                  "localhost",
+		"registry.example.com:5000/team": "registry.example.com:5000",
+	}
+	for repo, expected := range cases {
+		if got := dockerRegistry(repo); got != expected {
+			t.Fatalf("repo %q: got registry %q; want %q", repo, got, expected)
+		}
+	}
+}
+
+func TestAuthFromConfigFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper script requires a unix shell")
+	}
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A credential helper only knowing the credentials of quay.io
+	helper := `#!/bin/sh
+read server
+if [ "$server" = "quay.io" ]; then
+  echo '{"ServerURL": "quay.io", "Username": "helper", "Secret": "secret"}'
+  exit 0
+fi
+echo "credentials not found in native keychain"
+exit 1
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, dockerCredentialHelperPrefix+"test"), []byte(helper), 0755); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	write := func(name, config string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return path
+	}
+	legacy := write("dockercfg", fmt.Sprintf(`{"https://index.docker.io/v1/": {"auth": %q}}`, encoded))
+	config := write("config.json", fmt.Sprintf(`{
+  "auths": {"https://index.docker.io/v1/": {"auth": %q}, "quay.io": {}},
+  "credHelpers": {"gcr.io": "test"},
+  "credsStore": "test"
+}`, encoded))
+
+	cases := []struct {
+		file     string
+		registry string
+		username string
+		password string
+	}{
+		{legacy, dockerHubRegistry, "user", "pass"},
+		{legacy, "quay.io", "", ""},
+		{config, dockerHubRegistry, "user", "pass"},
+		{config, "quay.io", "helper", "secret"},
+		{config, "gcr.io", "", ""},
+	}
+	for i, c := range cases {
+		auth, err := authFromConfigFile(c.file, c.registry)
+		if err != nil {
+			t.Fatalf("case %d: err: %v", i, err)
+		}
+		if c.username == "" {
+			if auth != nil {
+				t.Fatalf("case %d: expected no credentials; got %#v", i, auth)
+			}
+			continue
+		}
+		if auth == nil || auth.Username != c.username || auth.Password != c.password {
+			t.Fatalf("case %d: got %#v; want %s:%s", i, auth, c.username, c.password)
+		}
+	}
+
+	// A failing credential helper is an error
+	if _, err := authFromHelper("missing", "quay.io"); err == nil {
+		t.Fatalf("expected an error for a missing credential helper")
+	}
+}
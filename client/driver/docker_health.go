@@ -0,0 +1,111 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+)
+
+const (
+	// dockerHealthStatusPrefix is the prefix of the status of the events
+	// reporting the health of a container's HEALTHCHECK.
+	dockerHealthStatusPrefix = "health_status: "
+
+	// dockerHealthUnhealthy is the health status of a container whose
+	// HEALTHCHECK keeps failing.
+	dockerHealthUnhealthy = "unhealthy"
+)
+
+// containerHealthStatus returns the health status of the container reported
+// by the event and whether the event reports it.
+func containerHealthStatus(event *docker.APIEvents, containerID string) (string, bool) {
+	// Events of newer APIs use the action and actor fields
+	id, status := event.ID, event.Status
+	if event.Actor.ID != "" {
+		id = event.Actor.ID
+	}
+	if event.Action != "" {
+		status = event.Action
+	}
+	if id != containerID || !strings.HasPrefix(status, dockerHealthStatusPrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(status, dockerHealthStatusPrefix)), true
+}
+
+// HealthCh returns the channel on which the changes of the container's
+// HEALTHCHECK status are sent.
+func (h *DockerHandle) HealthCh() <-chan *dstructs.HealthUpdate {
+	return h.healthCh
+}
+
+// watchHealth watches the docker events reporting the health of the container
+// until it exits.
+func (h *DockerHandle) watchHealth() {
+	events := make(chan *docker.APIEvents, 16)
+	if err := h.client.AddEventListener(events); err != nil {
+		h.logger.Printf("[ERR] driver.docker: failed to watch health of container %s: %v", h.containerID, err)
+		return
+	}
+	defer h.client.RemoveEventListener(events)
+	h.watchHealthEvents(events)
+}
+
+// watchHealthEvents sends the changes of the container's health reported by
+// the events. Once the container has been unhealthy for restartUnhealthyAfter,
+// if set, it asks for the task to be restarted.
+func (h *DockerHandle) watchHealthEvents(events <-chan *docker.APIEvents) {
+	var timer *time.Timer
+	var unhealthyCh <-chan time.Time
+	stopTimer := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, unhealthyCh = nil, nil
+		}
+	}
+	defer stopTimer()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			status, ok := containerHealthStatus(event, h.containerID)
+			if !ok {
+				continue
+			}
+			h.logger.Printf("[DEBUG] driver.docker: container %s is %s", h.containerID, status)
+			h.sendHealth(&dstructs.HealthUpdate{Status: status})
+
+			if status != dockerHealthUnhealthy {
+				stopTimer()
+			} else if timer == nil && h.restartUnhealthyAfter > 0 {
+				timer = time.NewTimer(h.restartUnhealthyAfter)
+				unhealthyCh = timer.C
+			}
+		case <-unhealthyCh:
+			timer, unhealthyCh = nil, nil
+			h.sendHealth(&dstructs.HealthUpdate{
+				Status:        dockerHealthUnhealthy,
+				RestartReason: fmt.Sprintf("container unhealthy for %v", h.restartUnhealthyAfter),
+			})
+		case <-h.doneCh:
+			return
+		}
+	}
+}
+
+// sendHealth sends the health update without blocking the events of the
+// docker client, dropping it if the previous ones haven't been received.
+func (h *DockerHandle) sendHealth(update *dstructs.HealthUpdate) {
+	select {
+	case h.healthCh <- update:
+	default:
+		h.logger.Printf("[WARN] driver.docker: dropping health update of container %s: %s",
+			h.containerID, update.Status)
+	}
+}
@@ -0,0 +1,91 @@
+package driver
+
+import (
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+)
+
+func TestDockerDriver_ContainerHealthStatus(t *testing.T) {
+	cases := []struct {
+		event  *docker.APIEvents
+		status string
+		ok     bool
+	}{
+		{
+			event:  &docker.APIEvents{Action: "health_status: healthy", Actor: docker.APIActor{ID: "abc"}},
+			status: "healthy",
+			ok:     true,
+		},
+		{
+			event:  &docker.APIEvents{Status: "health_status: unhealthy", ID: "abc"},
+			status: "unhealthy",
+			ok:     true,
+		},
+		{
+			event: &docker.APIEvents{Action: "health_status: healthy", Actor: docker.APIActor{ID: "def"}},
+		},
+		{
+			event: &docker.APIEvents{Action: "die", Actor: docker.APIActor{ID: "abc"}},
+		},
+	}
+
+	for i, c := range cases {
+		status, ok := containerHealthStatus(c.event, "abc")
+		if status != c.status || ok != c.ok {
+			t.Fatalf("case %d: got %q %v; want %q %v", i, status, ok, c.status, c.ok)
+		}
+	}
+}
+
+func TestDockerDriver_WatchHealthEvents(t *testing.T) {
+	h := &DockerHandle{
+		logger:                testLogger(),
+		containerID:           "abc",
+		doneCh:                make(chan bool),
+		restartUnhealthyAfter: 100 * time.Millisecond,
+		healthCh:              make(chan *dstructs.HealthUpdate, 8),
+	}
+	events := make(chan *docker.APIEvents)
+	go h.watchHealthEvents(events)
+	defer close(h.doneCh)
+
+	health := func(status string) *docker.APIEvents {
+		return &docker.APIEvents{Action: "health_status: " + status, Actor: docker.APIActor{ID: "abc"}}
+	}
+	next := func() *dstructs.HealthUpdate {
+		select {
+		case update := <-h.healthCh:
+			return update
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for health update")
+		}
+		return nil
+	}
+
+	// Recovering before the period doesn't restart the container
+	events <- health("unhealthy")
+	events <- health("healthy")
+	if u := next(); u.Status != "unhealthy" || u.RestartReason != "" {
+		t.Fatalf("bad update: %#v", u)
+	}
+	if u := next(); u.Status != "healthy" || u.RestartReason != "" {
+		t.Fatalf("bad update: %#v", u)
+	}
+	select {
+	case u := <-h.healthCh:
+		t.Fatalf("unexpected update: %#v", u)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Staying unhealthy for the period restarts the container
+	events <- health("unhealthy")
+	if u := next(); u.Status != "unhealthy" || u.RestartReason != "" {
+		t.Fatalf("bad update: %#v", u)
+	}
+	if u := next(); u.Status != "unhealthy" || u.RestartReason == "" {
+		t.Fatalf("expected a restart: %#v", u)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/env"
 	"github.com/hashicorp/nomad/client/testutil"
+	shelpers "github.com/hashicorp/nomad/helper/stats"
 	"github.com/hashicorp/nomad/nomad/structs"
 	tu "github.com/hashicorp/nomad/testutil"
 )
@@ -608,6 +609,208 @@ func TestDockerWorkDir(t *testing.T) {
 	}
 }
 
+func TestDockerDriver_VolumesBinds(t *testing.T) {
+	task, _, _ := dockerTask()
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	driver := NewDockerDriver(driverCtx).(*DockerDriver)
+	taskDir := execCtx.AllocDir.TaskDirs[task.Name]
+
+	binds := func(volumes []string, volumeDriver string) ([]string, error) {
+		driverConfig := &DockerDriverConfig{Volumes: volumes, VolumeDriver: volumeDriver}
+		all, err := driver.containerBinds(driverConfig, execCtx.AllocDir, task)
+		if err != nil {
+			return nil, err
+		}
+		// Skip the alloc, local and secrets directories
+		return all[3:], nil
+	}
+
+	// Paths relative to the task directory are always allowed
+	got, err := binds([]string{"data:/data:ro"}, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if want := []string{filepath.Join(taskDir, "data") + ":/data:ro"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if _, err := binds([]string{"../../etc:/etc"}, ""); err == nil {
+		t.Fatalf("expected an error for a volume escaping the task directory")
+	}
+	if _, err := binds([]string{"/data"}, ""); err == nil {
+		t.Fatalf("expected an error for a volume without destination")
+	}
+
+	// Host paths and volume drivers require volumes to be enabled
+	if _, err := binds([]string{"/etc:/etc"}, ""); err == nil {
+		t.Fatalf("expected an error for a host path with volumes disabled")
+	}
+	if _, err := binds([]string{"data:/data"}, "local"); err == nil {
+		t.Fatalf("expected an error for a volume driver with volumes disabled")
+	}
+
+	driverCtx.config.Options = map[string]string{dockerVolumesConfigOption: "true"}
+	if got, err = binds([]string{"/etc:/etc:ro"}, ""); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if want := []string{"/etc:/etc:ro"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got, err = binds([]string{"data:/data"}, "local"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if want := []string{"data:/data"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+func TestDockerDriver_HostVolumeBinds(t *testing.T) {
+	task, _, _ := dockerTask()
+	task.VolumeMounts = []*structs.VolumeMount{
+		{Volume: "data", Destination: "/srv/data"},
+		{Volume: "certs", Destination: "/etc/certs"},
+	}
+
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	driverCtx.config.HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"shared_data": {Name: "shared_data", Path: "/mnt/data"},
+		"certs":       {Name: "certs", Path: "/etc/ssl/certs", ReadOnly: true},
+	}
+	execCtx.Volumes = map[string]*structs.VolumeRequest{
+		"data":  {Name: "data", Type: structs.VolumeTypeHost, Source: "shared_data"},
+		"certs": {Name: "certs", Type: structs.VolumeTypeHost, Source: "certs"},
+	}
+	driver := NewDockerDriver(driverCtx).(*DockerDriver)
+
+	binds, err := driver.hostVolumeBinds(execCtx, task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []string{"/mnt/data:/srv/data", "/etc/ssl/certs:/etc/certs:ro"}
+	if !reflect.DeepEqual(binds, expected) {
+		t.Fatalf("got binds %v; want %v", binds, expected)
+	}
+
+	// Missing host volumes are an error
+	execCtx.Volumes["data"].Source = "missing"
+	if _, err := driver.hostVolumeBinds(execCtx, task); err == nil {
+		t.Fatalf("expected an error for a missing host volume")
+	}
+}
+
+func TestDockerDriver_GPUDevices(t *testing.T) {
+	task, _, _ := dockerTask()
+	task.Resources.GPU = 2
+	task.Resources.GPUDevices = []int{1, 3}
+
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	driver := NewDockerDriver(driverCtx).(*DockerDriver)
+
+	driverConfig, err := NewDockerDriverConfig(task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	opts, err := driver.createContainer(execCtx, task, driverConfig, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var gpus []string
+	for _, device := range opts.HostConfig.Devices {
+		if device.PathOnHost != device.PathInContainer {
+			t.Fatalf("bad device: %#v", device)
+		}
+		if device.PathOnHost == "/dev/nvidia1" || device.PathOnHost == "/dev/nvidia3" {
+			gpus = append(gpus, device.PathOnHost)
+		}
+	}
+	if !reflect.DeepEqual(gpus, []string{"/dev/nvidia1", "/dev/nvidia3"}) {
+		t.Fatalf("got devices %#v", opts.HostConfig.Devices)
+	}
+}
+
+func TestDockerDriver_CPUHardLimit(t *testing.T) {
+	task, _, _ := dockerTask()
+	task.Resources.CPUHardLimit = true
+
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	driver := NewDockerDriver(driverCtx).(*DockerDriver)
+
+	driverConfig, err := NewDockerDriverConfig(task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	opts, err := driver.createContainer(execCtx, task, driverConfig, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected, err := shelpers.CPUQuota(task.Resources.CPU)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if opts.HostConfig.CPUPeriod != shelpers.CPUPeriod || opts.HostConfig.CPUQuota != expected {
+		t.Fatalf("got quota %d/%d; want %d/%d", opts.HostConfig.CPUQuota, opts.HostConfig.CPUPeriod,
+			expected, shelpers.CPUPeriod)
+	}
+}
+
+func TestDockerDriver_UserNetwork(t *testing.T) {
+	task, _, _ := dockerTask()
+	task.Config["network_mode"] = "backend"
+	task.Config["ipv4_address"] = "10.10.0.5"
+	task.Config["network_aliases"] = []string{"redis"}
+	task.Config["dns_options"] = []string{"ndots:2"}
+
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	driver := NewDockerDriver(driverCtx).(*DockerDriver)
+
+	driverConfig, err := NewDockerDriverConfig(task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	opts, err := driver.createContainer(execCtx, task, driverConfig, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if opts.HostConfig.NetworkMode != "backend" {
+		t.Fatalf("got network mode %q; want backend", opts.HostConfig.NetworkMode)
+	}
+	if !reflect.DeepEqual(opts.HostConfig.DNSOptions, []string{"ndots:2"}) {
+		t.Fatalf("got dns options %v", opts.HostConfig.DNSOptions)
+	}
+	if opts.NetworkingConfig == nil {
+		t.Fatalf("missing networking config")
+	}
+	endpoint := opts.NetworkingConfig.EndpointsConfig["backend"]
+	if endpoint == nil || endpoint.IPAMConfig == nil || endpoint.IPAMConfig.IPv4Address != "10.10.0.5" {
+		t.Fatalf("bad endpoint config: %#v", endpoint)
+	}
+	if !reflect.DeepEqual(endpoint.Aliases, []string{"redis"}) {
+		t.Fatalf("got aliases %v", endpoint.Aliases)
+	}
+
+	// Addresses require a user defined network
+	for _, mode := range []string{"", "bridge", "host", "container:foo"} {
+		task.Config["network_mode"] = mode
+		if _, err := NewDockerDriverConfig(task); err == nil {
+			t.Fatalf("expected an error for an address with network mode %q", mode)
+		}
+	}
+
+	// Addresses must be valid
+	task.Config["network_mode"] = "backend"
+	task.Config["ipv4_address"] = "fd00::5"
+	if _, err := NewDockerDriverConfig(task); err == nil {
+		t.Fatalf("expected an error for an invalid ipv4 address")
+	}
+}
+
 func inSlice(needle string, haystack []string) bool {
 	for _, h := range haystack {
 		if h == needle {
@@ -3,6 +3,7 @@ package driver
 import (
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 
 	"github.com/hashicorp/nomad/client/allocdir"
@@ -60,15 +61,20 @@ type Driver interface {
 	Validate(map[string]interface{}) error
 }
 
+// LogEventFn records an informational message of a driver in the events of
+// the task.
+type LogEventFn func(message string, args ...interface{})
+
 // DriverContext is a means to inject dependencies such as loggers, configs, and
 // node attributes into a Driver without having to change the Driver interface
 // each time we do it. Used in conjection with Factory, above.
 type DriverContext struct {
-	taskName string
-	config   *config.Config
-	logger   *log.Logger
-	node     *structs.Node
-	taskEnv  *env.TaskEnvironment
+	taskName  string
+	config    *config.Config
+	logger    *log.Logger
+	node      *structs.Node
+	taskEnv   *env.TaskEnvironment
+	emitEvent LogEventFn
 }
 
 // NewEmptyDriverContext returns a DriverContext with all fields set to their
@@ -88,13 +94,22 @@ func NewEmptyDriverContext() *DriverContext {
 // private to the driver. If we want to change this later we can gorename all of
 // the fields in DriverContext.
 func NewDriverContext(taskName string, config *config.Config, node *structs.Node,
-	logger *log.Logger, taskEnv *env.TaskEnvironment) *DriverContext {
+	logger *log.Logger, taskEnv *env.TaskEnvironment, eventEmitter LogEventFn) *DriverContext {
 	return &DriverContext{
-		taskName: taskName,
-		config:   config,
-		node:     node,
-		logger:   logger,
-		taskEnv:  taskEnv,
+		taskName:  taskName,
+		config:    config,
+		node:      node,
+		logger:    logger,
+		taskEnv:   taskEnv,
+		emitEvent: eventEmitter,
+	}
+}
+
+// EmitEvent records an informational message in the events of the task. It
+// is a no-op for contexts not created for a task.
+func (d *DriverContext) EmitEvent(message string, args ...interface{}) {
+	if d.emitEvent != nil {
+		d.emitEvent(message, args...)
 	}
 }
 
@@ -114,10 +129,21 @@ type DriverHandle interface {
 	// Kill is used to stop the task
 	Kill() error
 
+	// Signal is used to send a signal to the task
+	Signal(s os.Signal) error
+
 	// Stats returns aggregated stats of the driver
 	Stats() (*cstructs.TaskResourceUsage, error)
 }
 
+// HealthHandle is implemented by the handles of drivers reporting the health
+// of their tasks.
+type HealthHandle interface {
+	// HealthCh returns a channel on which the changes of the task's health
+	// are sent
+	HealthCh() <-chan *dstructs.HealthUpdate
+}
+
 // ExecContext is shared between drivers within an allocation
 type ExecContext struct {
 	// AllocDir contains information about the alloc directory structure.
@@ -125,6 +151,10 @@ type ExecContext struct {
 
 	// Alloc ID
 	AllocID string
+
+	// Volumes are the volumes requested by the task group that its tasks
+	// mount.
+	Volumes map[string]*structs.VolumeRequest
 }
 
 // NewExecContext is used to create a new execution context
@@ -154,11 +184,13 @@ func GetTaskEnv(allocDir *allocdir.AllocDir, node *structs.Node,
 
 		env.SetTaskLocalDir(filepath.Join(taskdir, allocdir.TaskLocal))
 		env.SetSecretDir(filepath.Join(taskdir, allocdir.TaskSecrets))
+		env.SetTmpDir(filepath.Join(taskdir, allocdir.TaskTmp))
 	}
 
 	if task.Resources != nil {
 		env.SetMemLimit(task.Resources.MemoryMB).
 			SetCpuLimit(task.Resources.CPU).
+			SetGPUDevices(task.Resources.GPUDevices).
 			SetNetworks(task.Resources.Networks)
 	}
 
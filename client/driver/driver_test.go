@@ -89,7 +89,7 @@ func testDriverContexts(task *structs.Task) (*DriverContext, *ExecContext) {
 		return nil, nil
 	}
 
-	driverCtx := NewDriverContext(task.Name, cfg, cfg.Node, testLogger(), taskEnv)
+	driverCtx := NewDriverContext(task.Name, cfg, cfg.Node, testLogger(), taskEnv, nil)
 	return driverCtx, execCtx
 }
 
@@ -2,6 +2,7 @@ package env
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -25,12 +26,20 @@ const (
 	// directory where it can store sensitive data.
 	SecretDir = "NOMAD_SECRET_DIR"
 
+	// TmpDir is the environment variable with the path to the tasks scratch
+	// directory. Its contents are removed when the task is restarted.
+	TmpDir = "NOMAD_TMP"
+
 	// MemLimit is the environment variable with the tasks memory limit in MBs.
 	MemLimit = "NOMAD_MEMORY_LIMIT"
 
 	// CpuLimit is the environment variable with the tasks CPU limit in MHz.
 	CpuLimit = "NOMAD_CPU_LIMIT"
 
+	// GPUDevices is the environment variable with the comma separated indexes
+	// of the GPUs assigned to the task.
+	GPUDevices = "NOMAD_GPU_DEVICES"
+
 	// AllocID is the environment variable for passing the allocation ID.
 	AllocID = "NOMAD_ALLOC_ID"
 
@@ -85,8 +94,10 @@ type TaskEnvironment struct {
 	AllocDir        string
 	TaskDir         string
 	SecretDir       string
+	TmpDir          string
 	CpuLimit        int
 	MemLimit        int
+	GPUDevices      []int
 	TaskName        string
 	AllocIndex      int
 	AllocId         string
@@ -149,7 +160,7 @@ func (t *TaskEnvironment) Build() *TaskEnvironment {
 				value = forwardedPort
 			}
 			t.FullEnv[fmt.Sprintf("%s%s", PortPrefix, label)] = fmt.Sprintf("%d", value)
-			IPPort := fmt.Sprintf("%s:%d", network.IP, value)
+			IPPort := net.JoinHostPort(network.IP, strconv.Itoa(value))
 			t.FullEnv[fmt.Sprintf("%s%s", AddrPrefix, label)] = IPPort
 		}
 	}
@@ -164,6 +175,9 @@ func (t *TaskEnvironment) Build() *TaskEnvironment {
 	if t.SecretDir != "" {
 		t.FullEnv[SecretDir] = t.SecretDir
 	}
+	if t.TmpDir != "" {
+		t.FullEnv[TmpDir] = t.TmpDir
+	}
 
 	// Build the resource limits
 	if t.MemLimit != 0 {
@@ -172,6 +186,13 @@ func (t *TaskEnvironment) Build() *TaskEnvironment {
 	if t.CpuLimit != 0 {
 		t.FullEnv[CpuLimit] = strconv.Itoa(t.CpuLimit)
 	}
+	if len(t.GPUDevices) != 0 {
+		devices := make([]string, len(t.GPUDevices))
+		for i, device := range t.GPUDevices {
+			devices[i] = strconv.Itoa(device)
+		}
+		t.FullEnv[GPUDevices] = strings.Join(devices, ",")
+	}
 
 	// Build the tasks ids
 	if t.AllocId != "" {
@@ -276,6 +297,16 @@ func (t *TaskEnvironment) ClearSecretDir() *TaskEnvironment {
 	return t
 }
 
+func (t *TaskEnvironment) SetTmpDir(dir string) *TaskEnvironment {
+	t.TmpDir = dir
+	return t
+}
+
+func (t *TaskEnvironment) ClearTmpDir() *TaskEnvironment {
+	t.TmpDir = ""
+	return t
+}
+
 func (t *TaskEnvironment) SetMemLimit(limit int) *TaskEnvironment {
 	t.MemLimit = limit
 	return t
@@ -296,6 +327,16 @@ func (t *TaskEnvironment) ClearCpuLimit() *TaskEnvironment {
 	return t
 }
 
+func (t *TaskEnvironment) SetGPUDevices(devices []int) *TaskEnvironment {
+	t.GPUDevices = devices
+	return t
+}
+
+func (t *TaskEnvironment) ClearGPUDevices() *TaskEnvironment {
+	t.GPUDevices = nil
+	return t
+}
+
 func (t *TaskEnvironment) SetNetworks(networks []*structs.NetworkResource) *TaskEnvironment {
 	t.Networks = networks
 	return t
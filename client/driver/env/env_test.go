@@ -163,6 +163,25 @@ func TestEnvironment_AsList(t *testing.T) {
 	}
 }
 
+func TestEnvironment_IPv6Addr(t *testing.T) {
+	n := mock.Node()
+	env := NewTaskEnvironment(n, false).
+		SetNetworks([]*structs.NetworkResource{
+			{
+				IP:           "2001:db8::10",
+				DynamicPorts: []structs.Port{{Label: "http", Value: 8080}},
+			},
+		}).Build()
+
+	vars := env.EnvMap()
+	if addr := vars["NOMAD_ADDR_http"]; addr != "[2001:db8::10]:8080" {
+		t.Fatalf("bad address: %q", addr)
+	}
+	if ip := vars["NOMAD_IP_http"]; ip != "2001:db8::10" {
+		t.Fatalf("bad ip: %q", ip)
+	}
+}
+
 func TestEnvironment_ClearEnvvars(t *testing.T) {
 	n := mock.Node()
 	env := NewTaskEnvironment(n, false).
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -26,6 +27,10 @@ const (
 	// The key populated in Node Attributes to indicate the presence of the Exec
 	// driver
 	execDriverAttr = "driver.exec"
+
+	// execTaskChrootEnvOption is the client option allowing tasks to embed
+	// additional host paths in their chroot
+	execTaskChrootEnvOption = "exec.task_chroot_env.enabled"
 )
 
 // ExecDriver fork/execs tasks using as many of the underlying OS's isolation
@@ -35,8 +40,13 @@ type ExecDriver struct {
 }
 
 type ExecDriverConfig struct {
-	Command string   `mapstructure:"command"`
-	Args    []string `mapstructure:"args"`
+	Command      string              `mapstructure:"command"`
+	Args         []string            `mapstructure:"args"`
+	ChrootEnvRaw []map[string]string `mapstructure:"chroot_env"`
+	ChrootEnv    map[string]string   `mapstructure:"-"`
+	NetworkMode  string              `mapstructure:"network_mode"`
+	PortMapRaw   []map[string]int    `mapstructure:"port_map"`
+	PortMap      map[string]int      `mapstructure:"-"`
 }
 
 // execHandle is returned from Start/Open as a handle to the PID
@@ -71,6 +81,15 @@ func (d *ExecDriver) Validate(config map[string]interface{}) error {
 			"args": &fields.FieldSchema{
 				Type: fields.TypeArray,
 			},
+			"chroot_env": &fields.FieldSchema{
+				Type: fields.TypeArray,
+			},
+			"network_mode": &fields.FieldSchema{
+				Type: fields.TypeString,
+			},
+			"port_map": &fields.FieldSchema{
+				Type: fields.TypeArray,
+			},
 		},
 	}
 
@@ -97,6 +116,25 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, err
 	}
 
+	// Embedding host paths in the chroot must be allowed by the operator
+	driverConfig.ChrootEnv = mapMergeStrStr(driverConfig.ChrootEnvRaw...)
+	if len(driverConfig.ChrootEnv) > 0 && !d.config.ReadBoolDefault(execTaskChrootEnvOption, false) {
+		return nil, fmt.Errorf("chroot_env is not allowed on this client; set %q to enable it", execTaskChrootEnvOption)
+	}
+
+	// Ports can only be remapped in the network namespace of the task
+	driverConfig.PortMap = mapMergeStrInt(driverConfig.PortMapRaw...)
+	switch driverConfig.NetworkMode {
+	case "", executor.NetworkModeHost:
+		if len(driverConfig.PortMap) > 0 {
+			return nil, fmt.Errorf("port_map requires the %q network mode", executor.NetworkModeBridge)
+		}
+	case executor.NetworkModeBridge:
+		d.taskEnv.SetPortMap(driverConfig.PortMap)
+	default:
+		return nil, fmt.Errorf("unknown network_mode %q", driverConfig.NetworkMode)
+	}
+
 	// Set the host environment variables.
 	filter := strings.Split(d.config.ReadDefault("env.blacklist", config.DefaultEnvBlacklist), ",")
 	d.taskEnv.AppendHostEnvvars(filter)
@@ -121,12 +159,13 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, err
 	}
 	executorCtx := &executor.ExecutorContext{
-		TaskEnv:   d.taskEnv,
-		Driver:    "exec",
-		AllocDir:  ctx.AllocDir,
-		AllocID:   ctx.AllocID,
-		ChrootEnv: d.config.ChrootEnv,
-		Task:      task,
+		TaskEnv:       d.taskEnv,
+		Driver:        "exec",
+		AllocDir:      ctx.AllocDir,
+		AllocID:       ctx.AllocID,
+		ChrootEnv:     d.config.ChrootEnv,
+		Task:          task,
+		TaskChrootEnv: driverConfig.ChrootEnv,
 	}
 
 	ps, err := exec.LaunchCmd(&executor.ExecCommand{
@@ -135,6 +174,8 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		FSIsolation:    true,
 		ResourceLimits: true,
 		User:           getExecutorUser(task),
+		NetworkMode:    driverConfig.NetworkMode,
+		PortMap:        driverConfig.PortMap,
 	}, executorCtx)
 	if err != nil {
 		pluginClient.Kill()
@@ -258,6 +299,10 @@ func (h *execHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+func (h *execHandle) Signal(s os.Signal) error {
+	return h.executor.Signal(s)
+}
+
 func (h *execHandle) Kill() error {
 	if err := h.executor.ShutDown(); err != nil {
 		if h.pluginClient.Exited() {
@@ -305,7 +350,9 @@ func (h *execHandle) run() {
 			h.logger.Printf("[ERR] driver.exec: unmounting dev,proc and alloc dirs failed: %v", e)
 		}
 	}
-	h.waitCh <- dstructs.NewWaitResult(ps.ExitCode, ps.Signal, err)
+	result := dstructs.NewWaitResult(ps.ExitCode, ps.Signal, err)
+	result.OOMKilled = ps.OOMKilled
+	h.waitCh <- result
 	close(h.waitCh)
 	// Remove services
 	if err := h.executor.DeregisterServices(); err != nil {
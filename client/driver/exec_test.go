@@ -323,3 +323,124 @@ func TestExecDriverUser(t *testing.T) {
 		t.Fatalf("Expecting '%v' in '%v'", msg, err)
 	}
 }
+
+func TestExecDriver_ChrootEnv(t *testing.T) {
+	ctestutils.ExecCompatible(t)
+
+	// Create a host directory to embed in the chroot
+	hostDir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(hostDir)
+	if err := os.Chmod(hostDir, 0755); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := []byte("win")
+	if err := ioutil.WriteFile(filepath.Join(hostDir, "input.txt"), exp, 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	file := "output.txt"
+	task := &structs.Task{
+		Name: "sleep",
+		Config: map[string]interface{}{
+			"command": "/bin/bash",
+			"args": []string{
+				"-c",
+				fmt.Sprintf(`cat /opt/custom/input.txt > ${%s}/%s`, env.AllocDir, file),
+			},
+			"chroot_env": []map[string]string{
+				{hostDir: "/opt/custom"},
+			},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: basicResources,
+	}
+
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	d := NewExecDriver(driverCtx)
+
+	// The client doesn't allow tasks to customize their chroot by default
+	if handle, err := d.Start(execCtx, task); err == nil {
+		handle.Kill()
+		t.Fatalf("expected chroot_env to be rejected")
+	}
+
+	driverCtx.config.Options = map[string]string{execTaskChrootEnvOption: "true"}
+	handle, err := d.Start(execCtx, task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	select {
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
+		}
+	case <-time.After(time.Duration(testutil.TestMultiplier()*5) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	// Check that the task read the embedded host file
+	act, err := ioutil.ReadFile(filepath.Join(execCtx.AllocDir.SharedDir, file))
+	if err != nil {
+		t.Fatalf("Couldn't read expected output: %v", err)
+	}
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("Command outputted %v; want %v", act, exp)
+	}
+}
+
+func TestExecDriver_NetworkMode(t *testing.T) {
+	ctestutils.ExecCompatible(t)
+	cases := []struct {
+		config map[string]interface{}
+		err    string
+	}{
+		{
+			config: map[string]interface{}{"network_mode": "overlay"},
+			err:    `unknown network_mode "overlay"`,
+		},
+		{
+			config: map[string]interface{}{
+				"port_map": []map[string]int{{"http": 8080}},
+			},
+			err: `port_map requires the "bridge" network mode`,
+		},
+	}
+
+	for _, c := range cases {
+		task := &structs.Task{
+			Name: "sleep",
+			Config: map[string]interface{}{
+				"command": "/bin/sleep",
+				"args":    []string{"1"},
+			},
+			LogConfig: &structs.LogConfig{
+				MaxFiles:      10,
+				MaxFileSizeMB: 10,
+			},
+			Resources: basicResources,
+		}
+		for k, v := range c.config {
+			task.Config[k] = v
+		}
+
+		driverCtx, execCtx := testDriverContexts(task)
+		d := NewExecDriver(driverCtx)
+		handle, err := d.Start(execCtx, task)
+		execCtx.AllocDir.Destroy()
+		if err == nil {
+			handle.Kill()
+			t.Fatalf("expected %v to be rejected", c.config)
+		}
+		if !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("got %q; want %q", err, c.err)
+		}
+	}
+}
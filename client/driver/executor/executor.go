@@ -37,6 +37,14 @@ const (
 	// tree for finding out the pids that the executor and it's child processes
 	// have forked
 	pidScanInterval = 5 * time.Second
+
+	// NetworkModeHost runs the command in the network namespace of the host
+	NetworkModeHost = "host"
+
+	// NetworkModeBridge runs the command in its own network namespace
+	// attached to a bridge managed by Nomad, with the ports of the task
+	// forwarded from the host
+	NetworkModeBridge = "bridge"
 )
 
 var (
@@ -53,6 +61,7 @@ type Executor interface {
 	Wait() (*ProcessState, error)
 	ShutDown() error
 	Exit() error
+	Signal(sig os.Signal) error
 	UpdateLogConfig(logConfig *structs.LogConfig) error
 	UpdateTask(task *structs.Task) error
 	SyncServices(ctx *ConsulContext) error
@@ -106,6 +115,10 @@ type ExecutorContext struct {
 	// task's chroot.
 	ChrootEnv map[string]string
 
+	// TaskChrootEnv is a mapping of additional directories on the host OS to
+	// embed inside the task's chroot, overriding ChrootEnv.
+	TaskChrootEnv map[string]string
+
 	// Driver is the name of the driver that invoked the executor
 	Driver string
 
@@ -136,6 +149,15 @@ type ExecCommand struct {
 	// ResourceLimits determines whether resource limits are enforced by the
 	// executor.
 	ResourceLimits bool
+
+	// NetworkMode is either NetworkModeHost or NetworkModeBridge. An empty
+	// mode runs the command in the network of the host.
+	NetworkMode string
+
+	// PortMap maps the labels of the ports of the task to the ports the
+	// command listens on in bridge mode. Unmapped ports are forwarded to the
+	// same port.
+	PortMap map[string]int
 }
 
 // ProcessState holds information about the state of a user process.
@@ -143,6 +165,7 @@ type ProcessState struct {
 	Pid             int
 	ExitCode        int
 	Signal          int
+	OOMKilled       bool
 	IsolationConfig *dstructs.IsolationConfig
 	Time            time.Time
 }
@@ -299,8 +322,15 @@ func (e *UniversalExecutor) LaunchCmd(command *ExecCommand, ctx *ExecutorContext
 	e.cmd.Args = append([]string{e.cmd.Path}, ctx.TaskEnv.ParseAndReplace(command.Args)...)
 	e.cmd.Env = ctx.TaskEnv.EnvList()
 
-	// Start the process
-	if err := e.cmd.Start(); err != nil {
+	// Setup the network namespace of the task and start the process in it.
+	// This is done last so that the network isn't leaked by earlier failures.
+	if err := e.configureNetwork(); err != nil {
+		return nil, err
+	}
+	if err := e.startCmd(); err != nil {
+		if nerr := e.removeNetwork(); nerr != nil {
+			e.logger.Printf("[ERR] executor: failed to remove network: %v", nerr)
+		}
 		return nil, err
 	}
 	go e.collectPids()
@@ -420,7 +450,14 @@ func (e *UniversalExecutor) wait() {
 		e.logger.Printf("[DEBUG] executor: unexpected Wait() error type: %v", err)
 	}
 
-	e.exitState = &ProcessState{Pid: 0, ExitCode: exitCode, Signal: signal, IsolationConfig: ic, Time: time.Now()}
+	e.exitState = &ProcessState{
+		Pid:             0,
+		ExitCode:        exitCode,
+		Signal:          signal,
+		OOMKilled:       e.resConCtx.oomKilled(),
+		IsolationConfig: ic,
+		Time:            time.Now(),
+	}
 }
 
 var (
@@ -480,6 +517,10 @@ func (e *UniversalExecutor) Exit() error {
 			merr.Errors = append(merr.Errors, err)
 		}
 	}
+
+	if err := e.removeNetwork(); err != nil {
+		merr.Errors = append(merr.Errors, err)
+	}
 	return merr.ErrorOrNil()
 }
 
@@ -504,6 +545,17 @@ func (e *UniversalExecutor) ShutDown() error {
 	return nil
 }
 
+// Signal sends the given signal to the process of the user command
+func (e *UniversalExecutor) Signal(sig os.Signal) error {
+	if e.cmd.Process == nil {
+		return fmt.Errorf("executor.signal error: no process found")
+	}
+	if err := e.cmd.Process.Signal(sig); err != nil && err.Error() != finishedErr {
+		return fmt.Errorf("executor.signal error: %v", err)
+	}
+	return nil
+}
+
 // SyncServices syncs the services of the task that the executor is running with
 // Consul
 func (e *UniversalExecutor) SyncServices(ctx *ConsulContext) error {
@@ -3,6 +3,7 @@
 package executor
 
 import (
+	"fmt"
 	"os"
 
 	cstructs "github.com/hashicorp/nomad/client/structs"
@@ -29,6 +30,21 @@ func (e *UniversalExecutor) configureIsolation() error {
 	return nil
 }
 
+func (e *UniversalExecutor) configureNetwork() error {
+	if e.command.NetworkMode == NetworkModeBridge {
+		return fmt.Errorf("bridge networking is only supported on Linux")
+	}
+	return nil
+}
+
+func (e *UniversalExecutor) startCmd() error {
+	return e.cmd.Start()
+}
+
+func (e *UniversalExecutor) removeNetwork() error {
+	return nil
+}
+
 func (e *UniversalExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
 	pidStats, err := e.pidStats()
 	if err != nil {
@@ -18,8 +18,10 @@ import (
 	"github.com/opencontainers/runc/libcontainer/system"
 
 	"github.com/hashicorp/nomad/client/allocdir"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/stats"
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	shelpers "github.com/hashicorp/nomad/helper/stats"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -88,6 +90,11 @@ func (e *UniversalExecutor) applyLimits(pid int) error {
 		}
 		return err
 	}
+
+	// Watch the memory cgroup so that OOM kills can be told apart from crashes
+	if err := e.resConCtx.watchOOM(); err != nil {
+		e.logger.Printf("[WARN] executor: failed to watch for OOM kills: %v", err)
+	}
 	return nil
 }
 
@@ -102,6 +109,9 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 	// TODO: verify this is needed for things like network access
 	e.resConCtx.groups.Resources.AllowAllDevices = true
 
+	// Deny access to the GPUs not assigned to the task
+	e.resConCtx.groups.Resources.DeniedDevices = deniedGPUDevices(resources.GPUDevices)
+
 	if resources.MemoryMB > 0 {
 		// Total amount of memory allowed to consume
 		e.resConCtx.groups.Resources.Memory = int64(resources.MemoryMB * 1024 * 1024)
@@ -116,6 +126,16 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 	// Set the relative CPU shares for this cgroup.
 	e.resConCtx.groups.Resources.CpuShares = int64(resources.CPU)
 
+	// Bound the CPU usage of the task rather than only weighting it
+	if resources.CPUHardLimit {
+		quota, err := shelpers.CPUQuota(resources.CPU)
+		if err != nil {
+			return fmt.Errorf("failed to compute the CPU quota: %v", err)
+		}
+		e.resConCtx.groups.Resources.CpuPeriod = shelpers.CPUPeriod
+		e.resConCtx.groups.Resources.CpuQuota = quota
+	}
+
 	if resources.IOPS != 0 {
 		// Validate it is in an acceptable range.
 		if resources.IOPS < 10 || resources.IOPS > 1000 {
@@ -128,6 +148,36 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 	return nil
 }
 
+// deniedGPUDevices returns the devices of the host's NVIDIA GPUs that aren't
+// assigned to the task.
+func deniedGPUDevices(assigned []int) []*cgroupConfig.Device {
+	paths, err := filepath.Glob("/dev/nvidia[0-9]*")
+	if err != nil {
+		return nil
+	}
+
+	var denied []*cgroupConfig.Device
+OUTER:
+	for _, path := range paths {
+		index, err := strconv.Atoi(strings.TrimPrefix(path, "/dev/nvidia"))
+		if err != nil {
+			continue
+		}
+		for _, gpu := range assigned {
+			if gpu == index {
+				continue OUTER
+			}
+		}
+		denied = append(denied, &cgroupConfig.Device{
+			Type:        'c',
+			Major:       dstructs.NvidiaDeviceMajor,
+			Minor:       int64(index),
+			Permissions: "rwm",
+		})
+	}
+	return denied
+}
+
 // Stats reports the resource utilization of the cgroup. If there is no resource
 // isolation we aggregate the resource utilization of all the pids launched by
 // the executor.
@@ -231,6 +281,16 @@ func (e *UniversalExecutor) configureChroot() error {
 	if len(e.ctx.ChrootEnv) > 0 {
 		chroot = e.ctx.ChrootEnv
 	}
+	if len(e.ctx.TaskChrootEnv) > 0 {
+		merged := make(map[string]string, len(chroot)+len(e.ctx.TaskChrootEnv))
+		for k, v := range chroot {
+			merged[k] = v
+		}
+		for k, v := range e.ctx.TaskChrootEnv {
+			merged[k] = v
+		}
+		chroot = merged
+	}
 
 	if err := allocDir.Embed(e.ctx.Task.Name, chroot); err != nil {
 		return err
@@ -109,3 +109,29 @@ ld.so.conf.d/`
 		t.Fatalf("Command output incorrectly: want %v; got %v", expected, act)
 	}
 }
+
+func TestReadOOMKillCount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "OOMControl")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := map[string]uint64{
+		"oom_kill_disable 0\nunder_oom 0\noom_kill 2\n": 2,
+		"oom_kill_disable 0\nunder_oom 0\n":             0,
+	}
+	path := filepath.Join(dir, "memory.oom_control")
+	for content, expected := range cases {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		kills, err := readOOMKillCount(path)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if kills != expected {
+			t.Fatalf("got %d kills; want %d for %q", kills, expected, content)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package executor
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+const (
+	// bridgeName is the name of the bridge the network namespaces of the
+	// tasks are attached to
+	bridgeName = "nomad"
+
+	// bridgeSubnet is the subnet from which the addresses of the tasks are
+	// allocated. The first address of the subnet is the one of the bridge.
+	bridgeSubnet = "172.26.64.0/20"
+
+	// bridgeLeaseDir is the directory holding one file per address of the
+	// bridge subnet allocated to a task
+	bridgeLeaseDir = "/var/run/nomad/bridge"
+
+	// netnsDir is the directory in which `ip netns` mounts the named network
+	// namespaces
+	netnsDir = "/var/run/netns"
+)
+
+// portMapping forwards a port of the host to a port of the task.
+type portMapping struct {
+	HostPort int
+	TaskPort int
+}
+
+// bridgeNetworkID returns the identifier of the network of a task, from
+// which the names of its namespace, veth pair and iptables chain are derived.
+// It is kept short since interface names are limited to 15 characters.
+func bridgeNetworkID(allocID, task string) string {
+	sum := sha1.Sum([]byte(allocID + "/" + task))
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+func netnsName(id string) string    { return "nomad-" + id }
+func hostVethName(id string) string { return "nv" + id }
+func peerVethName(id string) string { return "np" + id }
+func natChainName(id string) string { return "NOMAD-" + id }
+
+// bridgeGateway returns the address of the bridge in the subnet.
+func bridgeGateway(subnet *net.IPNet) net.IP {
+	gw := make(net.IP, len(subnet.IP))
+	copy(gw, subnet.IP)
+	gw[len(gw)-1]++
+	return gw
+}
+
+// configureNetwork creates the network namespace of the task if it asked for
+// bridge networking and forwards its ports from the host.
+func (e *UniversalExecutor) configureNetwork() error {
+	if e.command.NetworkMode != NetworkModeBridge {
+		return nil
+	}
+
+	var hostIP string
+	var ports []portMapping
+	if networks := e.ctx.Task.Resources.Networks; len(networks) > 0 {
+		hostIP = networks[0].IP
+		for label, port := range networks[0].MapLabelToValues(nil) {
+			mapping := portMapping{HostPort: port, TaskPort: port}
+			if mapped, ok := e.command.PortMap[label]; ok {
+				mapping.TaskPort = mapped
+			}
+			ports = append(ports, mapping)
+		}
+	}
+
+	id := bridgeNetworkID(e.ctx.AllocID, e.ctx.Task.Name)
+	taskIP, err := setupBridgeNetwork(id, hostIP, ports)
+	if err != nil {
+		return fmt.Errorf("failed to setup bridge network: %v", err)
+	}
+	e.resConCtx.netID = id
+	e.logger.Printf("[DEBUG] executor: task attached to bridge %s with address %s", bridgeName, taskIP)
+	return nil
+}
+
+// startCmd starts the user process, in the network namespace of the task if
+// it has one.
+func (e *UniversalExecutor) startCmd() error {
+	if e.resConCtx.netID == "" {
+		return e.cmd.Start()
+	}
+	return inNetNS(filepath.Join(netnsDir, netnsName(e.resConCtx.netID)), e.cmd.Start)
+}
+
+// removeNetwork tears down the network namespace of the task, if any.
+func (e *UniversalExecutor) removeNetwork() error {
+	if e.resConCtx.netID == "" {
+		return nil
+	}
+	return teardownBridgeNetwork(e.resConCtx.netID)
+}
+
+// inNetNS runs fn on an OS thread switched to the network namespace at path.
+// Processes forked by fn inherit the namespace.
+func inNetNS(path string, fn func() error) error {
+	runtime.LockOSThread()
+
+	hostNS, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", syscall.Gettid()))
+	if err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	defer hostNS.Close()
+	taskNS, err := os.Open(path)
+	if err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	defer taskNS.Close()
+
+	if err := system.Setns(taskNS.Fd(), syscall.CLONE_NEWNET); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("failed to enter network namespace %s: %v", path, err)
+	}
+	fnErr := fn()
+
+	// The thread is left locked if it can't be switched back so that it is
+	// discarded instead of being reused by other goroutines
+	if err := system.Setns(hostNS.Fd(), syscall.CLONE_NEWNET); err != nil {
+		return fmt.Errorf("failed to leave network namespace %s: %v", path, err)
+	}
+	runtime.UnlockOSThread()
+	return fnErr
+}
+
+// setupBridgeNetwork creates the network namespace of the task, attaches it
+// to the bridge and forwards the ports of the host IP to the task. It returns
+// the address of the task on the bridge.
+func setupBridgeNetwork(id, hostIP string, ports []portMapping) (ip net.IP, err error) {
+	_, subnet, err := net.ParseCIDR(bridgeSubnet)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureBridge(subnet); err != nil {
+		return nil, err
+	}
+
+	ip, err = allocateBridgeIP(bridgeLeaseDir, id, subnet)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			teardownBridgeNetwork(id)
+		}
+	}()
+
+	ns, host, peer := netnsName(id), hostVethName(id), peerVethName(id)
+	ones, _ := subnet.Mask.Size()
+	cmds := [][]string{
+		{"ip", "netns", "add", ns},
+		{"ip", "link", "add", host, "type", "veth", "peer", "name", peer},
+		{"ip", "link", "set", host, "master", bridgeName},
+		{"ip", "link", "set", host, "up"},
+		{"ip", "link", "set", peer, "netns", ns},
+		{"ip", "netns", "exec", ns, "ip", "link", "set", peer, "name", "eth0"},
+		{"ip", "netns", "exec", ns, "ip", "addr", "add", fmt.Sprintf("%s/%d", ip, ones), "dev", "eth0"},
+		{"ip", "netns", "exec", ns, "ip", "link", "set", "eth0", "up"},
+		{"ip", "netns", "exec", ns, "ip", "link", "set", "lo", "up"},
+		{"ip", "netns", "exec", ns, "ip", "route", "add", "default", "via", bridgeGateway(subnet).String()},
+	}
+	if hostIP != "" && len(ports) > 0 {
+		chain := natChainName(id)
+		cmds = append(cmds, []string{"iptables", "-t", "nat", "-N", chain})
+		for _, rule := range portMappingRules(chain, hostIP, ip, ports) {
+			cmds = append(cmds, append([]string{"iptables", "-t", "nat", "-A"}, rule...))
+		}
+		for _, rule := range chainJumpRules(chain) {
+			cmds = append(cmds, append([]string{"iptables", "-t", "nat", "-A"}, rule...))
+		}
+	}
+
+	for _, cmd := range cmds {
+		if err := run(cmd...); err != nil {
+			return nil, err
+		}
+	}
+	return ip, nil
+}
+
+// teardownBridgeNetwork removes the port forwarding rules, network namespace
+// and address of the task. Parts that don't exist are skipped so that it can
+// be called on partially created networks.
+func teardownBridgeNetwork(id string) error {
+	var merr multierror.Error
+
+	chain := natChainName(id)
+	if run("iptables", "-t", "nat", "-n", "-L", chain) == nil {
+		for _, rule := range chainJumpRules(chain) {
+			if run(append([]string{"iptables", "-t", "nat", "-C"}, rule...)...) != nil {
+				continue
+			}
+			if err := run(append([]string{"iptables", "-t", "nat", "-D"}, rule...)...); err != nil {
+				merr.Errors = append(merr.Errors, err)
+			}
+		}
+		if err := run("iptables", "-t", "nat", "-F", chain); err != nil {
+			merr.Errors = append(merr.Errors, err)
+		}
+		if err := run("iptables", "-t", "nat", "-X", chain); err != nil {
+			merr.Errors = append(merr.Errors, err)
+		}
+	}
+
+	// Deleting the namespace destroys the peer, and with it the host end
+	if _, err := os.Stat(filepath.Join(netnsDir, netnsName(id))); err == nil {
+		if err := run("ip", "netns", "del", netnsName(id)); err != nil {
+			merr.Errors = append(merr.Errors, err)
+		}
+	}
+	if run("ip", "link", "show", hostVethName(id)) == nil {
+		if err := run("ip", "link", "del", hostVethName(id)); err != nil {
+			merr.Errors = append(merr.Errors, err)
+		}
+	}
+
+	if err := releaseBridgeIP(bridgeLeaseDir, id); err != nil {
+		merr.Errors = append(merr.Errors, err)
+	}
+	return merr.ErrorOrNil()
+}
+
+// ensureBridge creates the bridge if it doesn't exist and allows the traffic
+// of the tasks to be forwarded and masqueraded.
+func ensureBridge(subnet *net.IPNet) error {
+	if run("ip", "link", "show", bridgeName) != nil {
+		ones, _ := subnet.Mask.Size()
+		cmds := [][]string{
+			{"ip", "link", "add", bridgeName, "type", "bridge"},
+			{"ip", "addr", "add", fmt.Sprintf("%s/%d", bridgeGateway(subnet), ones), "dev", bridgeName},
+			{"ip", "link", "set", bridgeName, "up"},
+		}
+		for _, cmd := range cmds {
+			if err := run(cmd...); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := ioutil.WriteFile("/proc/sys/net/ipv4/ip_forward", []byte("1"), 0644); err != nil {
+		return fmt.Errorf("failed to enable IP forwarding: %v", err)
+	}
+
+	rules := [][]string{
+		{"-t", "nat", "POSTROUTING", "-s", subnet.String(), "!", "-o", bridgeName, "-j", "MASQUERADE"},
+		{"-t", "filter", "FORWARD", "-i", bridgeName, "-j", "ACCEPT"},
+		{"-t", "filter", "FORWARD", "-o", bridgeName, "-j", "ACCEPT"},
+	}
+	for _, rule := range rules {
+		table, spec := rule[:2], rule[2:]
+		if run(append(append([]string{"iptables"}, table...), append([]string{"-C"}, spec...)...)...) == nil {
+			continue
+		}
+		if err := run(append(append([]string{"iptables"}, table...), append([]string{"-A"}, spec...)...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// portMappingRules returns the rules of the chain of the task translating the
+// ports of the host IP to the ports of the task.
+func portMappingRules(chain, hostIP string, taskIP net.IP, ports []portMapping) [][]string {
+	var rules [][]string
+	for _, port := range ports {
+		dest := net.JoinHostPort(taskIP.String(), strconv.Itoa(port.TaskPort))
+		for _, proto := range []string{"tcp", "udp"} {
+			rules = append(rules, []string{
+				chain, "-d", hostIP, "-p", proto, "--dport", strconv.Itoa(port.HostPort),
+				"-j", "DNAT", "--to-destination", dest,
+			})
+		}
+	}
+	return rules
+}
+
+// chainJumpRules returns the rules sending the traffic addressed to the host,
+// from other hosts and from the host itself, to the chain of the task.
+func chainJumpRules(chain string) [][]string {
+	return [][]string{
+		{"PREROUTING", "-m", "addrtype", "--dst-type", "LOCAL", "-j", chain},
+		{"OUTPUT", "-m", "addrtype", "--dst-type", "LOCAL", "-j", chain},
+	}
+}
+
+// allocateBridgeIP leases a free address of the subnet to the task. A lease is
+// a file named after the address in dir containing the id of the task, which
+// is created exclusively so that concurrent executors never share an address.
+func allocateBridgeIP(dir, id string, subnet *net.IPNet) (net.IP, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	gw := bridgeGateway(subnet)
+	ip := make(net.IP, len(gw))
+	copy(ip, gw)
+	for {
+		incIP(ip)
+		if !subnet.Contains(ip) {
+			return nil, fmt.Errorf("no free address in %s", subnet)
+		}
+		if isBroadcast(ip, subnet) {
+			continue
+		}
+
+		f, err := os.OpenFile(filepath.Join(dir, ip.String()), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		_, err = f.WriteString(id)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, err
+		}
+		return ip, nil
+	}
+}
+
+// releaseBridgeIP removes the leases of the task.
+func releaseBridgeIP(dir, id string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		owner, err := ioutil.ReadFile(path)
+		if err != nil || string(owner) != id {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// incIP increments the address in place.
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
+
+// isBroadcast returns whether the address is the last one of the subnet.
+func isBroadcast(ip net.IP, subnet *net.IPNet) bool {
+	for i := range ip {
+		if ip[i]|subnet.Mask[i] != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// run runs the command, returning its output with the error if it fails.
+func run(args ...string) error {
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestAllocateBridgeIP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "BridgeLeases")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The subnet has a single address left besides the one of the bridge
+	_, subnet, _ := net.ParseCIDR("10.1.0.0/30")
+	ip1, err := allocateBridgeIP(dir, "a", subnet)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ip1.String() != "10.1.0.2" {
+		t.Fatalf("bad ip: %v", ip1)
+	}
+	if _, err := allocateBridgeIP(dir, "b", subnet); err == nil {
+		t.Fatalf("expected the subnet to be exhausted")
+	}
+
+	// Released addresses are reused
+	if err := releaseBridgeIP(dir, "a"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ip2, err := allocateBridgeIP(dir, "b", subnet)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ip2.Equal(ip1) {
+		t.Fatalf("got %v; want %v", ip2, ip1)
+	}
+}
+
+func TestPortMappingRules(t *testing.T) {
+	ports := []portMapping{{HostPort: 23000, TaskPort: 8080}}
+	rules := portMappingRules("NOMAD-abc", "10.0.0.5", net.ParseIP("172.26.64.2"), ports)
+	expected := [][]string{
+		{"NOMAD-abc", "-d", "10.0.0.5", "-p", "tcp", "--dport", "23000", "-j", "DNAT", "--to-destination", "172.26.64.2:8080"},
+		{"NOMAD-abc", "-d", "10.0.0.5", "-p", "udp", "--dport", "23000", "-j", "DNAT", "--to-destination", "172.26.64.2:8080"},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("got %v; want %v", rules, expected)
+	}
+}
+
+func TestBridgeNetworkID(t *testing.T) {
+	id := bridgeNetworkID("2b3c4d5e-alloc", "web")
+	if id != bridgeNetworkID("2b3c4d5e-alloc", "web") {
+		t.Fatalf("id isn't stable")
+	}
+	if id == bridgeNetworkID("2b3c4d5e-alloc", "db") {
+		t.Fatalf("tasks share an id")
+	}
+
+	// Interface names are limited to 15 characters
+	if name := hostVethName(id); len(name) > 15 {
+		t.Fatalf("interface name too long: %q", name)
+	}
+}
@@ -1,8 +1,17 @@
 package executor
 
 import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 
 	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	cgroupConfig "github.com/opencontainers/runc/libcontainer/configs"
@@ -14,6 +23,13 @@ type resourceContainerContext struct {
 	groups  *cgroupConfig.Cgroup
 	cgPaths map[string]string
 	cgLock  sync.Mutex
+
+	// oomEvents is set to 1 once the kernel notified an OOM condition in the
+	// memory cgroup of the task
+	oomEvents int32
+
+	// netID identifies the bridge network of the task, if it has one
+	netID string
 }
 
 // clientCleanup remoevs this host's Cgroup from the Nomad Client's context
@@ -21,6 +37,9 @@ func clientCleanup(ic *dstructs.IsolationConfig, pid int) error {
 	if err := DestroyCgroup(ic.Cgroup, ic.CgroupPaths, pid); err != nil {
 		return err
 	}
+	if ic.NetworkID != "" {
+		return teardownBridgeNetwork(ic.NetworkID)
+	}
 	return nil
 }
 
@@ -38,5 +57,94 @@ func (rc *resourceContainerContext) getIsolationConfig() *dstructs.IsolationConf
 	return &dstructs.IsolationConfig{
 		Cgroup:      rc.groups,
 		CgroupPaths: rc.cgPaths,
+		NetworkID:   rc.netID,
+	}
+}
+
+// watchOOM registers for the OOM notifications of the memory cgroup of the
+// task, so that the executor can report whether the task was killed for
+// exceeding its memory limit.
+func (rc *resourceContainerContext) watchOOM() error {
+	rc.cgLock.Lock()
+	dir, ok := rc.cgPaths["memory"]
+	rc.cgLock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	oomControl, err := os.Open(filepath.Join(dir, "memory.oom_control"))
+	if err != nil {
+		return err
+	}
+	fd, _, errno := syscall.RawSyscall(syscall.SYS_EVENTFD2, 0, syscall.O_CLOEXEC, 0)
+	if errno != 0 {
+		oomControl.Close()
+		return fmt.Errorf("failed to create eventfd: %v", errno)
+	}
+	eventfd := os.NewFile(fd, "eventfd")
+
+	data := fmt.Sprintf("%d %d", eventfd.Fd(), oomControl.Fd())
+	if err := ioutil.WriteFile(filepath.Join(dir, "cgroup.event_control"), []byte(data), 0700); err != nil {
+		eventfd.Close()
+		oomControl.Close()
+		return fmt.Errorf("failed to register for OOM notifications: %v", err)
+	}
+
+	go func() {
+		defer eventfd.Close()
+		defer oomControl.Close()
+		buf := make([]byte, 8)
+		for {
+			if _, err := eventfd.Read(buf); err != nil {
+				return
+			}
+			// The eventfd is also signaled when the cgroup is removed
+			if _, err := os.Lstat(filepath.Join(dir, "memory.oom_control")); os.IsNotExist(err) {
+				return
+			}
+			if binary.LittleEndian.Uint64(buf) > 0 {
+				atomic.StoreInt32(&rc.oomEvents, 1)
+			}
+		}
+	}()
+	return nil
+}
+
+// oomKilled returns whether a process of the task was killed by the kernel
+// for exceeding the memory limit of the task.
+func (rc *resourceContainerContext) oomKilled() bool {
+	if atomic.LoadInt32(&rc.oomEvents) == 1 {
+		return true
+	}
+
+	rc.cgLock.Lock()
+	dir, ok := rc.cgPaths["memory"]
+	rc.cgLock.Unlock()
+	if !ok {
+		return false
+	}
+
+	// Recent kernels count the OOM kills of the cgroup
+	kills, err := readOOMKillCount(filepath.Join(dir, "memory.oom_control"))
+	return err == nil && kills > 0
+}
+
+// readOOMKillCount returns the number of processes killed by the OOM killer
+// from the memory.oom_control file of a memory cgroup. Zero is returned on
+// kernels that don't report the count.
+func readOOMKillCount(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			return strconv.ParseUint(fields[1], 10, 64)
+		}
 	}
+	return 0, scanner.Err()
 }
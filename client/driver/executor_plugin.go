@@ -2,8 +2,11 @@ package driver
 
 import (
 	"encoding/gob"
+	"fmt"
 	"log"
 	"net/rpc"
+	"os"
+	"syscall"
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad/client/driver/executor"
@@ -36,6 +39,12 @@ type LaunchSyslogServerArgs struct {
 	Ctx *executor.ExecutorContext
 }
 
+// SignalArgs wraps the signal to send to the user command for the purposes of
+// RPC
+type SignalArgs struct {
+	Signal int
+}
+
 // SyncServicesArgs wraps the consul context for the purposes of RPC
 type SyncServicesArgs struct {
 	Ctx *executor.ConsulContext
@@ -67,6 +76,14 @@ func (e *ExecutorRPC) Exit() error {
 	return e.client.Call("Plugin.Exit", new(interface{}), new(interface{}))
 }
 
+func (e *ExecutorRPC) Signal(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+	return e.client.Call("Plugin.Signal", SignalArgs{Signal: int(s)}, new(interface{}))
+}
+
 func (e *ExecutorRPC) UpdateLogConfig(logConfig *structs.LogConfig) error {
 	return e.client.Call("Plugin.UpdateLogConfig", logConfig, new(interface{}))
 }
@@ -132,6 +149,10 @@ func (e *ExecutorRPCServer) Exit(args interface{}, resp *interface{}) error {
 	return e.Impl.Exit()
 }
 
+func (e *ExecutorRPCServer) Signal(args SignalArgs, resp *interface{}) error {
+	return e.Impl.Signal(syscall.Signal(args.Signal))
+}
+
 func (e *ExecutorRPCServer) UpdateLogConfig(args *structs.LogConfig, resp *interface{}) error {
 	return e.Impl.UpdateLogConfig(args)
 }
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -31,6 +33,11 @@ const (
 	// The key populated in Node Attributes to indicate presence of the Java
 	// driver
 	javaDriverAttr = "driver.java"
+
+	// javaHeapPercent is the percentage of the task's memory used as the
+	// heap size of the JVM unless set by the task's JVM options. The rest is
+	// left to the JVM's non-heap memory.
+	javaHeapPercent = 75
 )
 
 // JavaDriver is a simple driver to execute applications packaged in Jars.
@@ -139,23 +146,101 @@ func (d *JavaDriver) Fingerprint(cfg *config.Config, node *structs.Node) (bool,
 		return false, nil
 	}
 
-	// Assume 'java -version' returns 3 lines:
-	//    java version "1.6.0_36"
-	//    OpenJDK Runtime Environment (IcedTea6 1.13.8) (6b36-1.13.8-0ubuntu1~12.04)
-	//    OpenJDK 64-Bit Server VM (build 23.25-b01, mixed mode)
-	// Each line is terminated by \n
-	info := strings.Split(infoString, "\n")
-	versionString := info[0]
-	versionString = strings.TrimPrefix(versionString, "java version ")
-	versionString = strings.Trim(versionString, "\"")
+	versionString, runtimeString, vmString, ok := parseJavaVersionOutput(infoString)
+	if !ok {
+		if currentlyEnabled {
+			d.logger.Printf("[WARN] driver.java: unexpected Java version information %q, aborting", infoString)
+		}
+		delete(node.Attributes, javaDriverAttr)
+		return false, nil
+	}
+
 	node.Attributes[javaDriverAttr] = "1"
 	node.Attributes["driver.java.version"] = versionString
-	node.Attributes["driver.java.runtime"] = info[1]
-	node.Attributes["driver.java.vm"] = info[2]
+	node.Attributes["driver.java.runtime"] = runtimeString
+	node.Attributes["driver.java.vm"] = vmString
+	if major := javaMajorVersion(versionString); major != "" {
+		node.Attributes["driver.java.version.major"] = major
+	} else {
+		delete(node.Attributes, "driver.java.version.major")
+	}
 
 	return true, nil
 }
 
+// parseJavaVersionOutput parses the output of 'java -version' and returns the
+// version, runtime and VM of Java. It expects 3 lines such as:
+//
+//	java version "1.6.0_36"
+//	OpenJDK Runtime Environment (IcedTea6 1.13.8) (6b36-1.13.8-0ubuntu1~12.04)
+//	OpenJDK 64-Bit Server VM (build 23.25-b01, mixed mode)
+//
+// where the first line may also start with "openjdk version".
+func parseJavaVersionOutput(out string) (string, string, string, bool) {
+	info := strings.Split(strings.TrimSpace(out), "\n")
+	if len(info) < 3 {
+		return "", "", "", false
+	}
+
+	// The version is quoted on the first line
+	parts := strings.Split(info[0], "\"")
+	if len(parts) < 3 || parts[1] == "" {
+		return "", "", "", false
+	}
+	return parts[1], strings.TrimSpace(info[1]), strings.TrimSpace(info[2]), true
+}
+
+// javaMajorVersion returns the major version of Java given its full version,
+// such as 8 for "1.8.0_91" or 9 for "9.0.1", or an empty string if it can't be
+// determined.
+func javaMajorVersion(version string) string {
+	parts := strings.FieldsFunc(version, func(r rune) bool {
+		return r == '.' || r == '_' || r == '-' || r == '+'
+	})
+	if len(parts) > 1 && parts[0] == "1" {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	if _, err := strconv.Atoi(parts[0]); err != nil {
+		return ""
+	}
+	return parts[0]
+}
+
+// javaHeapOptions returns the JVM options sizing the heap from the task's
+// memory, unless already set by the given JVM options.
+func javaHeapOptions(jvmOpts []string, memoryMB int) []string {
+	if memoryMB <= 0 {
+		return nil
+	}
+
+	hasOpt := func(prefixes ...string) bool {
+		for _, opt := range jvmOpts {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(opt, prefix) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	heap := memoryMB * javaHeapPercent / 100
+	if heap < 1 {
+		heap = 1
+	}
+	var opts []string
+	if !hasOpt("-Xmx", "-XX:MaxHeapSize=") {
+		opts = append(opts, fmt.Sprintf("-Xmx%dm", heap))
+	}
+	if !hasOpt("-Xms", "-XX:InitialHeapSize=") {
+		opts = append(opts, fmt.Sprintf("-Xms%dm", heap))
+	}
+	return opts
+}
+
 func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, error) {
 	var driverConfig JavaDriverConfig
 	if err := mapstructure.WeakDecode(task.Config, &driverConfig); err != nil {
@@ -175,7 +260,12 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, fmt.Errorf("jar_path must be specified")
 	}
 
+	// Size the heap from the task's memory unless set by the JVM options
 	args := []string{}
+	if task.Resources != nil {
+		args = append(args, javaHeapOptions(driverConfig.JvmOpts, task.Resources.MemoryMB)...)
+	}
+
 	// Look for jvm options
 	if len(driverConfig.JvmOpts) != 0 {
 		d.logger.Printf("[DEBUG] driver.java: found JVM options: %s", driverConfig.JvmOpts)
@@ -358,6 +448,10 @@ func (h *javaHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+func (h *javaHandle) Signal(s os.Signal) error {
+	return h.executor.Signal(s)
+}
+
 func (h *javaHandle) Kill() error {
 	if err := h.executor.ShutDown(); err != nil {
 		if h.pluginClient.Exited() {
@@ -403,7 +497,7 @@ func (h *javaHandle) run() {
 			h.logger.Printf("[ERR] driver.java: unmounting dev,proc and alloc dirs failed: %v", e)
 		}
 	}
-	h.waitCh <- &dstructs.WaitResult{ExitCode: ps.ExitCode, Signal: ps.Signal, Err: err}
+	h.waitCh <- &dstructs.WaitResult{ExitCode: ps.ExitCode, Signal: ps.Signal, OOMKilled: ps.OOMKilled, Err: err}
 	close(h.waitCh)
 
 	// Remove services
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -268,3 +269,80 @@ func TestJavaDriverUser(t *testing.T) {
 		t.Fatalf("Expecting '%v' in '%v'", msg, err)
 	}
 }
+
+func TestJavaDriver_ParseVersionOutput(t *testing.T) {
+	cases := []struct {
+		out                  string
+		version, runtime, vm string
+		major                string
+		ok                   bool
+	}{
+		{
+			out: `java version "1.6.0_36"
+OpenJDK Runtime Environment (IcedTea6 1.13.8) (6b36-1.13.8-0ubuntu1~12.04)
+OpenJDK 64-Bit Server VM (build 23.25-b01, mixed mode)
+`,
+			version: "1.6.0_36",
+			runtime: "OpenJDK Runtime Environment (IcedTea6 1.13.8) (6b36-1.13.8-0ubuntu1~12.04)",
+			vm:      "OpenJDK 64-Bit Server VM (build 23.25-b01, mixed mode)",
+			major:   "6",
+			ok:      true,
+		},
+		{
+			out: `openjdk version "9-internal"
+OpenJDK Runtime Environment (build 9-internal+0-2016-04-14-195246.buildd.src)
+OpenJDK 64-Bit Server VM (build 9-internal+0-2016-04-14-195246.buildd.src, mixed mode)`,
+			version: "9-internal",
+			runtime: "OpenJDK Runtime Environment (build 9-internal+0-2016-04-14-195246.buildd.src)",
+			vm:      "OpenJDK 64-Bit Server VM (build 9-internal+0-2016-04-14-195246.buildd.src, mixed mode)",
+			major:   "9",
+			ok:      true,
+		},
+		{
+			out: "java: command not found",
+		},
+	}
+
+	for i, c := range cases {
+		version, runtime, vm, ok := parseJavaVersionOutput(c.out)
+		if ok != c.ok || version != c.version || runtime != c.runtime || vm != c.vm {
+			t.Fatalf("case %d: got %q %q %q %v", i, version, runtime, vm, ok)
+		}
+		if ok {
+			if major := javaMajorVersion(version); major != c.major {
+				t.Fatalf("case %d: got major version %q; want %q", i, major, c.major)
+			}
+		}
+	}
+}
+
+func TestJavaDriver_HeapOptions(t *testing.T) {
+	cases := []struct {
+		jvmOpts  []string
+		memoryMB int
+		expected []string
+	}{
+		{
+			memoryMB: 256,
+			expected: []string{"-Xmx192m", "-Xms192m"},
+		},
+		{
+			jvmOpts:  []string{"-Xmx64m"},
+			memoryMB: 256,
+			expected: []string{"-Xms192m"},
+		},
+		{
+			jvmOpts:  []string{"-XX:InitialHeapSize=32m", "-Xmx64m"},
+			memoryMB: 256,
+		},
+		{
+			memoryMB: 0,
+		},
+	}
+
+	for i, c := range cases {
+		if got := javaHeapOptions(c.jvmOpts, c.memoryMB); !reflect.DeepEqual(got, c.expected) {
+			t.Fatalf("case %d: got %v; want %v", i, got, c.expected)
+		}
+	}
+}
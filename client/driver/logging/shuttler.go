@@ -1,17 +1,41 @@
 package logging
 
 import (
+	"bytes"
 	"io"
 	"log"
+	"sync"
+	"unicode/utf8"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/heroku/log-shuttle"
 )
 
+const (
+	// LineContinuationMarker is appended to every chunk of a split line but
+	// the last and prepended to every chunk but the first, so consumers can
+	// tell that consecutive lines belong together.
+	LineContinuationMarker = "..."
+)
+
 type Shuttler struct {
 	logShuttler *shuttle.Shuttle
 	pr          io.Reader
 	pw          io.Writer
+
+	// maxLineLength is the maximum length of a line handed to log-shuttle,
+	// including continuation markers. maxLineSplits caps the number of
+	// chunks a single line is split into; zero means unlimited.
+	maxLineLength int
+	maxLineSplits int
+
+	// buf holds the partial line that has not yet been terminated by a
+	// newline. truncating is set once the partial line has exceeded
+	// maxLineSplits and the rest of it is being discarded.
+	buf        []byte
+	truncating bool
+	bufLock    sync.Mutex
 }
 
 func NewShuttler(config *structs.LogShuttleConfig, logger *log.Logger) (*Shuttler, error) {
@@ -30,17 +54,138 @@ func NewShuttler(config *structs.LogShuttleConfig, logger *log.Logger) (*Shuttle
 	// TODO:
 	//go LogFmtMetricsEmitter(s.MetricsRegistry, sConfig.StatsSource, sConfig.StatsInterval, logger)
 
-	return &Shuttler{logShuttler: s, pr: pr, pw: pw}, nil
+	return &Shuttler{
+		logShuttler:   s,
+		pr:            pr,
+		pw:            pw,
+		maxLineLength: sConfig.MaxLineLength,
+		maxLineSplits: config.MaxLineSplits,
+	}, nil
 }
 
+// Write buffers p until complete lines are available and forwards them to
+// log-shuttle. Lines longer than the configured MaxLineLength are split into
+// several lines joined by continuation markers rather than being truncated by
+// the log sink.
 func (s *Shuttler) Write(p []byte) (n int, err error) {
-	return s.pw.Write(p)
+	s.bufLock.Lock()
+	defer s.bufLock.Unlock()
+
+	n = len(p)
+	for len(p) > 0 {
+		idx := bytes.IndexByte(p, '\n')
+		if idx == -1 {
+			s.bufferPartial(p)
+			return n, nil
+		}
+
+		s.bufferPartial(p[:idx])
+		if err := s.flushLine(); err != nil {
+			return n, err
+		}
+		p = p[idx+1:]
+	}
+	return n, nil
+}
+
+// bufferPartial appends a fragment of a not yet terminated line to the
+// buffer, discarding it if the line is already known to be truncated.
+func (s *Shuttler) bufferPartial(p []byte) {
+	if s.truncating {
+		return
+	}
+	s.buf = append(s.buf, p...)
+
+	// Bound the memory held for a single line when the number of splits is
+	// limited, since anything beyond the limit will be discarded anyway.
+	if s.maxLineSplits > 0 && s.maxLineLength > 0 {
+		limit := s.maxLineSplits * s.maxLineLength
+		if len(s.buf) > limit {
+			s.buf = s.buf[:limit]
+			s.truncating = true
+		}
+	}
+}
+
+// flushLine splits the buffered line and writes the resulting chunks to
+// log-shuttle.
+func (s *Shuttler) flushLine() error {
+	chunks, truncated := splitLine(s.buf, s.maxLineLength, s.maxLineSplits)
+	if len(chunks) > 1 {
+		metrics.IncrCounter([]string{"client", "log_shuttle", "lines_split"}, 1)
+	}
+	if truncated || s.truncating {
+		metrics.IncrCounter([]string{"client", "log_shuttle", "lines_truncated"}, 1)
+	}
+	s.buf = s.buf[:0]
+	s.truncating = false
+
+	for _, c := range chunks {
+		if _, err := s.pw.Write(append(c, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *Shuttler) Shutdown() {
+	s.bufLock.Lock()
+	if len(s.buf) > 0 {
+		s.flushLine()
+	}
+	s.bufLock.Unlock()
+
 	s.logShuttler.Land()
 }
 
+// splitLine splits line into chunks no longer than maxLength, including the
+// continuation markers. Chunk boundaries never fall inside a UTF-8 encoded
+// rune. If maxSplits is positive, at most maxSplits chunks are returned and
+// truncated reports whether the remainder of the line was dropped.
+func splitLine(line []byte, maxLength, maxSplits int) (chunks [][]byte, truncated bool) {
+	if maxLength <= 0 || len(line) <= maxLength {
+		return [][]byte{line}, false
+	}
+
+	// If the maximum length can't accommodate the markers on both sides of
+	// a chunk, split without them.
+	marker := []byte(LineContinuationMarker)
+	if maxLength <= 2*len(marker) {
+		marker = nil
+	}
+
+	for start := 0; start < len(line); {
+		if maxSplits > 0 && len(chunks) == maxSplits {
+			return chunks, true
+		}
+
+		var chunk []byte
+		if start != 0 {
+			chunk = append(chunk, marker...)
+		}
+
+		// The last chunk only needs room for the leading marker.
+		end := start + maxLength - len(chunk)
+		if end < len(line) {
+			end -= len(marker)
+			for end > start+1 && !utf8.RuneStart(line[end]) {
+				end--
+			}
+		} else {
+			end = len(line)
+		}
+
+		chunk = append(chunk, line[start:end]...)
+		if end != len(line) {
+			chunk = append(chunk, marker...)
+		}
+		chunks = append(chunks, chunk)
+		start = end
+	}
+
+	return chunks, false
+}
+
 func getShuttleConfig(config *structs.LogShuttleConfig) shuttle.Config {
 	sConfig := shuttle.NewConfig()
 	sConfig.InputFormat = shuttle.InputFormatRaw
@@ -62,7 +207,9 @@ func getShuttleConfig(config *structs.LogShuttleConfig) shuttle.Config {
 	sConfig.NumOutlets = config.NumOutlets
 	sConfig.BatchSize = config.BatchSize
 	sConfig.BackBuff = config.BackBuff
-	sConfig.MaxLineLength = config.MaxLineLength
+	if config.MaxLineLength > 0 {
+		sConfig.MaxLineLength = config.MaxLineLength
+	}
 	sConfig.KinesisShards = config.KinesisShards
 	return sConfig
 }
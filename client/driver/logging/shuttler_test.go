@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitLine_Short(t *testing.T) {
+	line := []byte("hello world")
+	chunks, truncated := splitLine(line, 100, 0)
+	if truncated {
+		t.Fatalf("unexpected truncation")
+	}
+	if len(chunks) != 1 || !bytes.Equal(chunks[0], line) {
+		t.Fatalf("bad chunks: %q", chunks)
+	}
+}
+
+func TestSplitLine_Split(t *testing.T) {
+	line := []byte(strings.Repeat("a", 25))
+	chunks, truncated := splitLine(line, 10, 0)
+	if truncated {
+		t.Fatalf("unexpected truncation")
+	}
+
+	var joined []byte
+	for i, c := range chunks {
+		if len(c) > 10 {
+			t.Fatalf("chunk %d too long: %q", i, c)
+		}
+		if i != 0 {
+			if !bytes.HasPrefix(c, []byte(LineContinuationMarker)) {
+				t.Fatalf("chunk %d missing leading marker: %q", i, c)
+			}
+			c = c[len(LineContinuationMarker):]
+		}
+		if i != len(chunks)-1 {
+			if !bytes.HasSuffix(c, []byte(LineContinuationMarker)) {
+				t.Fatalf("chunk %d missing trailing marker: %q", i, c)
+			}
+			c = c[:len(c)-len(LineContinuationMarker)]
+		}
+		joined = append(joined, c...)
+	}
+
+	if !bytes.Equal(joined, line) {
+		t.Fatalf("got %q; want %q", joined, line)
+	}
+}
+
+func TestSplitLine_Runes(t *testing.T) {
+	line := []byte(strings.Repeat("é", 20))
+	chunks, _ := splitLine(line, 11, 0)
+	for i, c := range chunks {
+		if !utf8.Valid(c) {
+			t.Fatalf("chunk %d split a rune: %q", i, c)
+		}
+	}
+}
+
+func TestSplitLine_Truncate(t *testing.T) {
+	line := []byte(strings.Repeat("a", 100))
+	chunks, truncated := splitLine(line, 10, 3)
+	if !truncated {
+		t.Fatalf("expected truncation")
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks; want 3", len(chunks))
+	}
+}
@@ -5,6 +5,7 @@ package driver
 import (
 	"errors"
 	"log"
+	"os"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -43,6 +44,10 @@ type MockDriverConfig struct {
 
 	// ExitErrMsg is the error message that the task returns while exiting
 	ExitErrMsg string `mapstructure:"exit_err_msg"`
+
+	// UnhealthyAfter is the duration after which the MockDriver reports the
+	// task unhealthy and asks for it to be restarted
+	UnhealthyAfter time.Duration `mapstructure:"unhealthy_after"`
 }
 
 // MockDriver is a driver which is used for testing purposes
@@ -72,15 +77,17 @@ func (m *MockDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 	}
 
 	h := mockDriverHandle{
-		taskName:    task.Name,
-		runFor:      driverConfig.RunFor,
-		killAfter:   driverConfig.KillAfter,
-		killTimeout: task.KillTimeout,
-		exitCode:    driverConfig.ExitCode,
-		exitSignal:  driverConfig.ExitSignal,
-		logger:      m.logger,
-		doneCh:      make(chan struct{}),
-		waitCh:      make(chan *dstructs.WaitResult, 1),
+		taskName:       task.Name,
+		runFor:         driverConfig.RunFor,
+		killAfter:      driverConfig.KillAfter,
+		killTimeout:    task.KillTimeout,
+		exitCode:       driverConfig.ExitCode,
+		exitSignal:     driverConfig.ExitSignal,
+		unhealthyAfter: driverConfig.UnhealthyAfter,
+		logger:         m.logger,
+		doneCh:         make(chan struct{}),
+		waitCh:         make(chan *dstructs.WaitResult, 1),
+		healthCh:       make(chan *dstructs.HealthUpdate, 2),
 	}
 	if driverConfig.ExitErrMsg != "" {
 		h.exitErr = errors.New(driverConfig.ExitErrMsg)
@@ -121,6 +128,9 @@ type mockDriverHandle struct {
 	logger      *log.Logger
 	waitCh      chan *dstructs.WaitResult
 	doneCh      chan struct{}
+
+	unhealthyAfter time.Duration
+	healthCh       chan *dstructs.HealthUpdate
 }
 
 // TODO Implement when we need it.
@@ -138,6 +148,17 @@ func (h *mockDriverHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+// HealthCh returns the channel on which the mock task reports being unhealthy
+func (h *mockDriverHandle) HealthCh() <-chan *dstructs.HealthUpdate {
+	return h.healthCh
+}
+
+// Signal logs the signal sent to a mock task
+func (h *mockDriverHandle) Signal(s os.Signal) error {
+	h.logger.Printf("[DEBUG] driver.mock: signaling task %q with %v", h.taskName, s)
+	return nil
+}
+
 // Kill kills a mock task
 func (h *mockDriverHandle) Kill() error {
 	h.logger.Printf("[DEBUG] driver.mock: killing task %q after kill timeout: %v", h.taskName, h.killTimeout)
@@ -162,10 +183,19 @@ func (h *mockDriverHandle) Stats() (*cstructs.TaskResourceUsage, error) {
 func (h *mockDriverHandle) run() {
 	timer := time.NewTimer(h.runFor)
 	defer timer.Stop()
+	var unhealthyCh <-chan time.Time
+	if h.unhealthyAfter > 0 {
+		unhealthy := time.NewTimer(h.unhealthyAfter)
+		defer unhealthy.Stop()
+		unhealthyCh = unhealthy.C
+	}
 	for {
 		select {
 		case <-timer.C:
 			close(h.doneCh)
+		case <-unhealthyCh:
+			h.healthCh <- &dstructs.HealthUpdate{Status: "unhealthy"}
+			h.healthCh <- &dstructs.HealthUpdate{Status: "unhealthy", RestartReason: "mock task unhealthy"}
 		case <-h.doneCh:
 			h.logger.Printf("[DEBUG] driver.mock: finished running task %q", h.taskName)
 			h.waitCh <- dstructs.NewWaitResult(h.exitCode, h.exitSignal, h.exitErr)
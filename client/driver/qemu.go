@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/hashicorp/go-plugin"
@@ -43,10 +45,11 @@ type QemuDriver struct {
 }
 
 type QemuDriverConfig struct {
-	ImagePath   string           `mapstructure:"image_path"`
-	Accelerator string           `mapstructure:"accelerator"`
-	PortMap     []map[string]int `mapstructure:"port_map"` // A map of host port labels and to guest ports.
-	Args        []string         `mapstructure:"args"`     // extra arguments to qemu executable
+	ImagePath        string           `mapstructure:"image_path"`
+	Accelerator      string           `mapstructure:"accelerator"`
+	PortMap          []map[string]int `mapstructure:"port_map"`          // A map of host port labels and to guest ports.
+	Args             []string         `mapstructure:"args"`              // extra arguments to qemu executable
+	GracefulShutdown bool             `mapstructure:"graceful_shutdown"` // Power the VM down through ACPI before stopping it
 }
 
 // qemuHandle is returned from Start/Open as a handle to the PID
@@ -61,6 +64,10 @@ type qemuHandle struct {
 	version        string
 	waitCh         chan *dstructs.WaitResult
 	doneCh         chan struct{}
+
+	// monitorPath is the path of the QMP monitor socket of the VM, if any
+	monitorPath      string
+	gracefulShutdown bool
 }
 
 // NewQemuDriver is used to create a new exec driver
@@ -86,6 +93,9 @@ func (d *QemuDriver) Validate(config map[string]interface{}) error {
 			"args": &fields.FieldSchema{
 				Type: fields.TypeArray,
 			},
+			"graceful_shutdown": &fields.FieldSchema{
+				Type: fields.TypeBool,
+			},
 		},
 	}
 
@@ -176,6 +186,24 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		"-nographic",
 	}
 
+	// Expose the QMP monitor in the task directory so that the VM can be
+	// powered down gracefully. Unix sockets aren't supported on Windows and
+	// their path length is limited.
+	monitorPath := ""
+	if runtime.GOOS != "windows" {
+		path := filepath.Join(taskDir, qemuMonitorSocket)
+		if len(path) < qemuMonitorPathMax {
+			monitorPath = path
+			args = append(args, "-qmp", fmt.Sprintf("unix:%s,server,nowait", monitorPath))
+		} else {
+			d.logger.Printf("[WARN] driver.qemu: monitor socket path %q exceeds %d characters, not exposing monitor",
+				path, qemuMonitorPathMax)
+		}
+	}
+	if driverConfig.GracefulShutdown && monitorPath == "" {
+		return nil, fmt.Errorf("graceful_shutdown requires the qemu monitor which is unavailable on this client")
+	}
+
 	// Add pass through arguments to qemu executable. A user can specify
 	// these arguments in driver task configuration. These arguments are
 	// passed directly to the qemu driver as command line options.
@@ -271,6 +299,9 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		logger:         d.logger,
 		doneCh:         make(chan struct{}),
 		waitCh:         make(chan *dstructs.WaitResult, 1),
+
+		monitorPath:      monitorPath,
+		gracefulShutdown: driverConfig.GracefulShutdown,
 	}
 
 	if err := h.executor.SyncServices(consulContext(d.config, "")); err != nil {
@@ -287,6 +318,9 @@ type qemuId struct {
 	UserPid        int
 	PluginConfig   *PluginReattachConfig
 	AllocDir       *allocdir.AllocDir
+
+	MonitorPath      string
+	GracefulShutdown bool
 }
 
 func (d *QemuDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
@@ -322,6 +356,9 @@ func (d *QemuDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, erro
 		version:        id.Version,
 		doneCh:         make(chan struct{}),
 		waitCh:         make(chan *dstructs.WaitResult, 1),
+
+		monitorPath:      id.MonitorPath,
+		gracefulShutdown: id.GracefulShutdown,
 	}
 	if err := h.executor.SyncServices(consulContext(d.config, "")); err != nil {
 		h.logger.Printf("[ERR] driver.qemu: error registering services: %v", err)
@@ -338,6 +375,9 @@ func (h *qemuHandle) ID() string {
 		PluginConfig:   NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
 		UserPid:        h.userPid,
 		AllocDir:       h.allocDir,
+
+		MonitorPath:      h.monitorPath,
+		GracefulShutdown: h.gracefulShutdown,
 	}
 
 	data, err := json.Marshal(id)
@@ -360,9 +400,31 @@ func (h *qemuHandle) Update(task *structs.Task) error {
 	return nil
 }
 
-// TODO: allow a 'shutdown_command' that can be executed over a ssh connection
-// to the VM
+// Signal powers the VM down through ACPI when sent an interrupt or terminate
+// signal and the monitor is available, and otherwise signals qemu.
+func (h *qemuHandle) Signal(s os.Signal) error {
+	if h.monitorPath != "" && (s == os.Interrupt || s == syscall.SIGTERM) {
+		return sendQemuCommand(h.monitorPath, "system_powerdown")
+	}
+	return h.executor.Signal(s)
+}
+
 func (h *qemuHandle) Kill() error {
+	// Ask the guest to power down and give it the kill timeout to do so
+	// before stopping qemu
+	if h.gracefulShutdown && h.monitorPath != "" {
+		if err := sendQemuCommand(h.monitorPath, "system_powerdown"); err != nil {
+			h.logger.Printf("[WARN] driver.qemu: failed to power down VM gracefully: %v", err)
+		} else {
+			select {
+			case <-h.doneCh:
+				return nil
+			case <-time.After(h.killTimeout):
+				h.logger.Printf("[WARN] driver.qemu: VM didn't power down within %v, stopping it", h.killTimeout)
+			}
+		}
+	}
+
 	if err := h.executor.ShutDown(); err != nil {
 		if h.pluginClient.Exited() {
 			return nil
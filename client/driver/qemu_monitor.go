@@ -0,0 +1,83 @@
+package driver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// qemuMonitorSocket is the name of the QMP monitor socket of the VM in the
+	// task directory.
+	qemuMonitorSocket = "qemu-monitor.sock"
+
+	// qemuMonitorPathMax is the maximum length of a unix socket path.
+	qemuMonitorPathMax = 108
+
+	// qemuMonitorTimeout is the timeout of a command sent to the monitor.
+	qemuMonitorTimeout = 5 * time.Second
+)
+
+// qemuMonitorMessage is a message read from the QMP monitor: the greeting, a
+// command's reply or an asynchronous event.
+type qemuMonitorMessage struct {
+	QMP    json.RawMessage `json:"QMP"`
+	Return json.RawMessage `json:"return"`
+	Error  *struct {
+		Class string `json:"class"`
+		Desc  string `json:"desc"`
+	} `json:"error"`
+	Event string `json:"event"`
+}
+
+// sendQemuCommand connects to the QMP monitor at path and executes the
+// command, returning an error if it failed.
+func sendQemuCommand(path, command string) error {
+	conn, err := net.DialTimeout("unix", path, qemuMonitorTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to monitor: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(qemuMonitorTimeout))
+
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	enc := json.NewEncoder(conn)
+
+	// Read the greeting then leave the capabilities negotiation mode to be
+	// able to execute commands
+	var greeting qemuMonitorMessage
+	if err := dec.Decode(&greeting); err != nil {
+		return fmt.Errorf("failed to read monitor greeting: %v", err)
+	}
+	if greeting.QMP == nil {
+		return fmt.Errorf("unexpected monitor greeting")
+	}
+	for _, cmd := range []string{"qmp_capabilities", command} {
+		if err := enc.Encode(map[string]string{"execute": cmd}); err != nil {
+			return fmt.Errorf("failed to send %q to monitor: %v", cmd, err)
+		}
+		if err := readQemuReply(dec); err != nil {
+			return fmt.Errorf("monitor command %q failed: %v", cmd, err)
+		}
+	}
+	return nil
+}
+
+// readQemuReply reads the reply of the last command sent to the monitor,
+// skipping events.
+func readQemuReply(dec *json.Decoder) error {
+	for {
+		var msg qemuMonitorMessage
+		if err := dec.Decode(&msg); err != nil {
+			return err
+		}
+		switch {
+		case msg.Error != nil:
+			return fmt.Errorf("%s: %s", msg.Error.Class, msg.Error.Desc)
+		case msg.Return != nil:
+			return nil
+		}
+	}
+}
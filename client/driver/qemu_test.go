@@ -1,8 +1,13 @@
 package driver
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -142,3 +147,64 @@ func TestQemuDriverUser(t *testing.T) {
 		t.Fatalf("Expecting '%v' in '%v'", msg, err)
 	}
 }
+
+func TestQemuDriver_Monitor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, qemuMonitorSocket)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	// A fake monitor replying to the capabilities negotiation, sending an
+	// event before replying to the powerdown and failing other commands
+	cmds := make(chan string, 2)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		dec := json.NewDecoder(conn)
+		fmt.Fprintln(conn, `{"QMP": {"version": {}, "capabilities": []}}`)
+		for {
+			var req map[string]string
+			if err := dec.Decode(&req); err != nil {
+				return
+			}
+			cmds <- req["execute"]
+			switch req["execute"] {
+			case "qmp_capabilities":
+				fmt.Fprintln(conn, `{"return": {}}`)
+			case "system_powerdown":
+				fmt.Fprintln(conn, `{"event": "POWERDOWN", "timestamp": {}}`)
+				fmt.Fprintln(conn, `{"return": {}}`)
+			default:
+				fmt.Fprintln(conn, `{"error": {"class": "CommandNotFound", "desc": "unknown"}}`)
+			}
+		}
+	}()
+
+	if err := sendQemuCommand(path, "system_powerdown"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, want := range []string{"qmp_capabilities", "system_powerdown"} {
+		if got := <-cmds; got != want {
+			t.Fatalf("got command %q; want %q", got, want)
+		}
+	}
+
+	// Connecting to a missing monitor fails
+	if err := sendQemuCommand(filepath.Join(dir, "missing.sock"), "system_powerdown"); err == nil {
+		t.Fatalf("expected an error connecting to a missing monitor")
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -26,6 +28,10 @@ const (
 	// The option that enables this driver in the Config.Options map.
 	rawExecConfigOption = "driver.raw_exec.enable"
 
+	// The option that places the tasks of this driver in cgroups enforcing
+	// their resources on Linux.
+	rawExecCgroupsConfigOption = "driver.raw_exec.cgroups.enable"
+
 	// The key populated in Node Attributes to indicate presence of the Raw Exec
 	// driver
 	rawExecDriverAttr = "driver.raw_exec"
@@ -41,16 +47,17 @@ type RawExecDriver struct {
 
 // rawExecHandle is returned from Start/Open as a handle to the PID
 type rawExecHandle struct {
-	version        string
-	pluginClient   *plugin.Client
-	userPid        int
-	executor       executor.Executor
-	killTimeout    time.Duration
-	maxKillTimeout time.Duration
-	allocDir       *allocdir.AllocDir
-	logger         *log.Logger
-	waitCh         chan *dstructs.WaitResult
-	doneCh         chan struct{}
+	version         string
+	pluginClient    *plugin.Client
+	userPid         int
+	executor        executor.Executor
+	isolationConfig *dstructs.IsolationConfig
+	killTimeout     time.Duration
+	maxKillTimeout  time.Duration
+	allocDir        *allocdir.AllocDir
+	logger          *log.Logger
+	waitCh          chan *dstructs.WaitResult
+	doneCh          chan struct{}
 }
 
 // NewRawExecDriver is used to create a new raw exec driver
@@ -93,6 +100,9 @@ func (d *RawExecDriver) Fingerprint(cfg *config.Config, node *structs.Node) (boo
 			d.logger.Printf("[WARN] driver.raw_exec: raw exec is enabled. Only enable if needed")
 		}
 		node.Attributes[rawExecDriverAttr] = "1"
+		if cfg.ReadBoolDefault(rawExecCgroupsConfigOption, false) && runtime.GOOS != "linux" {
+			d.logger.Printf("[WARN] driver.raw_exec: %s is only supported on linux", rawExecCgroupsConfigOption)
+		}
 		return true, nil
 	}
 
@@ -143,10 +153,14 @@ func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandl
 		Task:     task,
 	}
 
+	// Enforce the task's resources through cgroups if enabled, still without
+	// chrooting the task
+	resourceLimits := runtime.GOOS == "linux" && d.config.ReadBoolDefault(rawExecCgroupsConfigOption, false)
 	ps, err := exec.LaunchCmd(&executor.ExecCommand{
-		Cmd:  command,
-		Args: driverConfig.Args,
-		User: task.User,
+		Cmd:            command,
+		Args:           driverConfig.Args,
+		User:           task.User,
+		ResourceLimits: resourceLimits,
 	}, executorCtx)
 	if err != nil {
 		pluginClient.Kill()
@@ -157,16 +171,17 @@ func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandl
 	// Return a driver handle
 	maxKill := d.DriverContext.config.MaxKillTimeout
 	h := &rawExecHandle{
-		pluginClient:   pluginClient,
-		executor:       exec,
-		userPid:        ps.Pid,
-		killTimeout:    GetKillTimeout(task.KillTimeout, maxKill),
-		maxKillTimeout: maxKill,
-		allocDir:       ctx.AllocDir,
-		version:        d.config.Version,
-		logger:         d.logger,
-		doneCh:         make(chan struct{}),
-		waitCh:         make(chan *dstructs.WaitResult, 1),
+		pluginClient:    pluginClient,
+		executor:        exec,
+		userPid:         ps.Pid,
+		isolationConfig: ps.IsolationConfig,
+		killTimeout:     GetKillTimeout(task.KillTimeout, maxKill),
+		maxKillTimeout:  maxKill,
+		allocDir:        ctx.AllocDir,
+		version:         d.config.Version,
+		logger:          d.logger,
+		doneCh:          make(chan struct{}),
+		waitCh:          make(chan *dstructs.WaitResult, 1),
 	}
 	if err := h.executor.SyncServices(consulContext(d.config, "")); err != nil {
 		h.logger.Printf("[ERR] driver.raw_exec: error registering services with consul for task: %q: %v", task.Name, err)
@@ -176,12 +191,13 @@ func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandl
 }
 
 type rawExecId struct {
-	Version        string
-	KillTimeout    time.Duration
-	MaxKillTimeout time.Duration
-	UserPid        int
-	PluginConfig   *PluginReattachConfig
-	AllocDir       *allocdir.AllocDir
+	Version         string
+	KillTimeout     time.Duration
+	MaxKillTimeout  time.Duration
+	UserPid         int
+	PluginConfig    *PluginReattachConfig
+	IsolationConfig *dstructs.IsolationConfig
+	AllocDir        *allocdir.AllocDir
 }
 
 func (d *RawExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
@@ -199,6 +215,12 @@ func (d *RawExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, e
 		if e := destroyPlugin(id.PluginConfig.Pid, id.UserPid); e != nil {
 			d.logger.Printf("[ERR] driver.raw_exec: error destroying plugin and userpid: %v", e)
 		}
+		if id.IsolationConfig != nil {
+			ePid := pluginConfig.Reattach.Pid
+			if e := executor.ClientCleanup(id.IsolationConfig, ePid); e != nil {
+				d.logger.Printf("[ERR] driver.raw_exec: destroying resource container failed: %v", e)
+			}
+		}
 		return nil, fmt.Errorf("error connecting to plugin: %v", err)
 	}
 
@@ -207,16 +229,17 @@ func (d *RawExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, e
 
 	// Return a driver handle
 	h := &rawExecHandle{
-		pluginClient:   pluginClient,
-		executor:       exec,
-		userPid:        id.UserPid,
-		logger:         d.logger,
-		killTimeout:    id.KillTimeout,
-		maxKillTimeout: id.MaxKillTimeout,
-		allocDir:       id.AllocDir,
-		version:        id.Version,
-		doneCh:         make(chan struct{}),
-		waitCh:         make(chan *dstructs.WaitResult, 1),
+		pluginClient:    pluginClient,
+		executor:        exec,
+		userPid:         id.UserPid,
+		isolationConfig: id.IsolationConfig,
+		logger:          d.logger,
+		killTimeout:     id.KillTimeout,
+		maxKillTimeout:  id.MaxKillTimeout,
+		allocDir:        id.AllocDir,
+		version:         id.Version,
+		doneCh:          make(chan struct{}),
+		waitCh:          make(chan *dstructs.WaitResult, 1),
 	}
 	if err := h.executor.SyncServices(consulContext(d.config, "")); err != nil {
 		h.logger.Printf("[ERR] driver.raw_exec: error registering services with consul: %v", err)
@@ -227,12 +250,13 @@ func (d *RawExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, e
 
 func (h *rawExecHandle) ID() string {
 	id := rawExecId{
-		Version:        h.version,
-		KillTimeout:    h.killTimeout,
-		MaxKillTimeout: h.maxKillTimeout,
-		PluginConfig:   NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
-		UserPid:        h.userPid,
-		AllocDir:       h.allocDir,
+		Version:         h.version,
+		KillTimeout:     h.killTimeout,
+		MaxKillTimeout:  h.maxKillTimeout,
+		PluginConfig:    NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
+		IsolationConfig: h.isolationConfig,
+		UserPid:         h.userPid,
+		AllocDir:        h.allocDir,
 	}
 
 	data, err := json.Marshal(id)
@@ -255,6 +279,10 @@ func (h *rawExecHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+func (h *rawExecHandle) Signal(s os.Signal) error {
+	return h.executor.Signal(s)
+}
+
 func (h *rawExecHandle) Kill() error {
 	if err := h.executor.ShutDown(); err != nil {
 		if h.pluginClient.Exited() {
@@ -289,6 +317,12 @@ func (h *rawExecHandle) run() {
 		if e := killProcess(h.userPid); e != nil {
 			h.logger.Printf("[ERR] driver.raw_exec: error killing user process: %v", e)
 		}
+		if h.isolationConfig != nil {
+			ePid := h.pluginClient.ReattachConfig().Pid
+			if e := executor.ClientCleanup(h.isolationConfig, ePid); e != nil {
+				h.logger.Printf("[ERR] driver.raw_exec: destroying resource container failed: %v", e)
+			}
+		}
 		if e := h.allocDir.UnmountAll(); e != nil {
 			h.logger.Printf("[ERR] driver.raw_exec: unmounting dev,proc and alloc dirs failed: %v", e)
 		}
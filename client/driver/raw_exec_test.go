@@ -9,11 +9,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/env"
 	"github.com/hashicorp/nomad/helper/testtask"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
+
+	ctestutils "github.com/hashicorp/nomad/client/testutil"
 )
 
 func TestRawExecDriver_Fingerprint(t *testing.T) {
@@ -277,3 +280,53 @@ func TestRawExecDriverUser(t *testing.T) {
 		t.Fatalf("Expecting '%v' in '%v'", msg, err)
 	}
 }
+
+func TestRawExecDriver_Cgroups(t *testing.T) {
+	ctestutils.ExecCompatible(t)
+	task := &structs.Task{
+		Name: "sleep",
+		Config: map[string]interface{}{
+			"command": "/bin/sh",
+			"args":    []string{"-c", "cat /proc/self/cgroup > ${NOMAD_TASK_DIR}/cgroup"},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: basicResources,
+	}
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	driverCtx.config.Options = map[string]string{rawExecCgroupsConfigOption: "true"}
+	d := NewRawExecDriver(driverCtx)
+
+	handle, err := d.Start(execCtx, task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if handle == nil {
+		t.Fatalf("missing handle")
+	}
+	if handle.(*rawExecHandle).isolationConfig == nil {
+		t.Fatalf("missing isolation config")
+	}
+
+	select {
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
+		}
+	case <-time.After(time.Duration(testutil.TestMultiplier()*5) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	// The task ran in a cgroup created for it
+	taskDir := execCtx.AllocDir.TaskDirs[task.Name]
+	data, err := ioutil.ReadFile(filepath.Join(taskDir, allocdir.TaskLocal, "cgroup"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(string(data), "/nomad/") {
+		t.Fatalf("task not in a nomad cgroup: %s", data)
+	}
+}
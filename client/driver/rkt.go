@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
@@ -60,6 +61,9 @@ type RktDriverConfig struct {
 	DNSServers       []string `mapstructure:"dns_servers"`        // DNS Server for containers
 	DNSSearchDomains []string `mapstructure:"dns_search_domains"` // DNS Search domains for containers
 	Debug            bool     `mapstructure:"debug"`              // Enable debug option for rkt command
+
+	PortMapRaw []map[string]string `mapstructure:"port_map"` //
+	PortMap    map[string]string   `mapstructure:"-"`        // A map of host port labels and the names of the ports exposed by the image
 }
 
 // rktHandle is returned from Start/Open as a handle to the PID
@@ -117,6 +121,9 @@ func (d *RktDriver) Validate(config map[string]interface{}) error {
 			"debug": &fields.FieldSchema{
 				Type: fields.TypeBool,
 			},
+			"port_map": &fields.FieldSchema{
+				Type: fields.TypeArray,
+			},
 		},
 	}
 
@@ -212,6 +219,28 @@ func (d *RktDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, e
 	cmdArgs = append(cmdArgs, "run")
 	cmdArgs = append(cmdArgs, fmt.Sprintf("--volume=%s,kind=host,source=%s", task.Name, ctx.AllocDir.SharedDir))
 	cmdArgs = append(cmdArgs, fmt.Sprintf("--mount=volume=%s,target=%s", task.Name, ctx.AllocDir.SharedDir))
+
+	// Mount the task's local directory
+	localDir := filepath.Join(taskDir, allocdir.TaskLocal)
+	cmdArgs = append(cmdArgs, fmt.Sprintf("--volume=%s-local,kind=host,source=%s", task.Name, localDir))
+	cmdArgs = append(cmdArgs, fmt.Sprintf("--mount=volume=%s-local,target=%s", task.Name, localDir))
+
+	// Forward the task's dynamic and reserved ports to the image's ports
+	driverConfig.PortMap = mapMergeStrStr(driverConfig.PortMapRaw...)
+	if len(driverConfig.PortMap) > 0 {
+		if len(task.Resources.Networks) == 0 {
+			return nil, fmt.Errorf("Trying to map ports but no network interface is available")
+		}
+		ports := task.Resources.Networks[0].MapLabelToValues(nil)
+		for label, name := range driverConfig.PortMap {
+			port, ok := ports[label]
+			if !ok {
+				return nil, fmt.Errorf("port %q in port_map not found in the task's network resources", label)
+			}
+			cmdArgs = append(cmdArgs, fmt.Sprintf("--port=%s:%d", name, port))
+		}
+	}
+
 	cmdArgs = append(cmdArgs, img)
 	if insecure == true {
 		cmdArgs = append(cmdArgs, "--insecure-options=all")
@@ -397,6 +426,10 @@ func (h *rktHandle) Update(task *structs.Task) error {
 
 // Kill is used to terminate the task. We send an Interrupt
 // and then provide a 5 second grace period before doing a Kill.
+func (h *rktHandle) Signal(s os.Signal) error {
+	return h.executor.Signal(s)
+}
+
 func (h *rktHandle) Kill() error {
 	h.executor.ShutDown()
 	select {
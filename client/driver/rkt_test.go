@@ -375,6 +375,7 @@ func TestRktTaskValidate(t *testing.T) {
 			"args":               []string{"--version"},
 			"dns_servers":        []string{"8.8.8.8", "8.8.4.4"},
 			"dns_search_domains": []string{"example.com", "example.org", "example.net"},
+			"port_map":           []map[string]string{{"main": "client"}},
 		},
 		Resources: basicResources,
 	}
@@ -386,3 +387,84 @@ func TestRktTaskValidate(t *testing.T) {
 		t.Fatalf("Validation error in TaskConfig : '%v'", err)
 	}
 }
+
+func TestRktDriver_PortsMapping(t *testing.T) {
+	if os.Getenv("NOMAD_TEST_RKT") == "" {
+		t.Skip("skipping rkt tests")
+	}
+
+	ctestutils.RktCompatible(t)
+	task := &structs.Task{
+		Name: "etcd",
+		Config: map[string]interface{}{
+			"image": "docker://redis:latest",
+			"port_map": []map[string]string{
+				map[string]string{
+					"main": "6379-tcp",
+				},
+			},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: &structs.Resources{
+			MemoryMB: 256,
+			CPU:      512,
+			Networks: []*structs.NetworkResource{
+				&structs.NetworkResource{
+					IP:            "127.0.0.1",
+					ReservedPorts: []structs.Port{{Label: "main", Value: 8080}},
+				},
+			},
+		},
+	}
+
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	d := NewRktDriver(driverCtx)
+
+	handle, err := d.Start(execCtx, task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if handle == nil {
+		t.Fatalf("missing handle")
+	}
+	defer handle.Kill()
+
+	select {
+	case res := <-handle.WaitCh():
+		t.Fatalf("task exited: %v", res)
+	case <-time.After(time.Duration(testutil.TestMultiplier()*3) * time.Second):
+	}
+}
+
+func TestRktDriver_PortsMapping_Missing(t *testing.T) {
+	ctestutils.RktCompatible(t)
+	task := &structs.Task{
+		Name: "etcd",
+		Config: map[string]interface{}{
+			"image": "docker://redis:latest",
+			"port_map": []map[string]string{
+				map[string]string{
+					"undefined": "6379-tcp",
+				},
+			},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: basicResources,
+	}
+
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	d := NewRktDriver(driverCtx)
+
+	if handle, err := d.Start(execCtx, task); err == nil {
+		handle.Kill()
+		t.Fatalf("expected error mapping an undefined port")
+	}
+}
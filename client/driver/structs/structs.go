@@ -11,13 +11,26 @@ const (
 
 	// CheckBufSize is the size of the check output result
 	CheckBufSize = 4 * 1024
+
+	// NvidiaDeviceMajor is the major number of the NVIDIA GPU devices
+	NvidiaDeviceMajor = 195
 )
 
+// NvidiaControlDevices are the devices of the NVIDIA driver that are needed to
+// use any of the GPUs.
+var NvidiaControlDevices = []string{"/dev/nvidiactl", "/dev/nvidia-uvm", "/dev/nvidia-uvm-tools"}
+
+// NvidiaDevicePath returns the path of the device of the NVIDIA GPU.
+func NvidiaDevicePath(index int) string {
+	return fmt.Sprintf("/dev/nvidia%d", index)
+}
+
 // WaitResult stores the result of a Wait operation.
 type WaitResult struct {
-	ExitCode int
-	Signal   int
-	Err      error
+	ExitCode  int
+	Signal    int
+	OOMKilled bool
+	Err       error
 }
 
 func NewWaitResult(code, signal int, err error) *WaitResult {
@@ -33,10 +46,24 @@ func (r *WaitResult) Successful() bool {
 }
 
 func (r *WaitResult) String() string {
+	if r.OOMKilled {
+		return fmt.Sprintf("Wait returned exit code %v, signal %v, and error %v after an OOM kill",
+			r.ExitCode, r.Signal, r.Err)
+	}
 	return fmt.Sprintf("Wait returned exit code %v, signal %v, and error %v",
 		r.ExitCode, r.Signal, r.Err)
 }
 
+// HealthUpdate is a change of the health of a task reported by its driver.
+type HealthUpdate struct {
+	// Status is the health status of the task, such as healthy or unhealthy
+	Status string
+
+	// RestartReason is set when the task should be restarted as it has been
+	// unhealthy for too long
+	RestartReason string
+}
+
 // RecoverableError wraps an error and marks whether it is recoverable and could
 // be retried or it is fatal.
 type RecoverableError struct {
@@ -7,4 +7,7 @@ import cgroupConfig "github.com/opencontainers/runc/libcontainer/configs"
 type IsolationConfig struct {
 	Cgroup      *cgroupConfig.Cgroup
 	CgroupPaths map[string]string
+
+	// NetworkID identifies the bridge network of the task, if it has one
+	NetworkID string
 }
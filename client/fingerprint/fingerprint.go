@@ -21,10 +21,12 @@ func init() {
 	builtinFingerprintMap["cpu"] = NewCPUFingerprint
 	builtinFingerprintMap["env_aws"] = NewEnvAWSFingerprint
 	builtinFingerprintMap["env_gce"] = NewEnvGCEFingerprint
+	builtinFingerprintMap["gpu"] = NewGPUFingerprint
 	builtinFingerprintMap["host"] = NewHostFingerprint
 	builtinFingerprintMap["memory"] = NewMemoryFingerprint
 	builtinFingerprintMap["network"] = NewNetworkFingerprint
 	builtinFingerprintMap["nomad"] = NewNomadFingerprint
+	builtinFingerprintMap["script"] = NewScriptFingerprint
 	builtinFingerprintMap["storage"] = NewStorageFingerprint
 	builtinFingerprintMap["vault"] = NewVaultFingerprint
 
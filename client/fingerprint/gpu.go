@@ -0,0 +1,112 @@
+package fingerprint
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	client "github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// nvidiaSmi is the utility queried for the NVIDIA GPUs of the node.
+	nvidiaSmi = "nvidia-smi"
+)
+
+// gpuDevice is a GPU of the node as reported by nvidia-smi.
+type gpuDevice struct {
+	Index    int
+	Model    string
+	MemoryMB int
+}
+
+// GPUFingerprint is used to fingerprint the NVIDIA GPUs of the node.
+type GPUFingerprint struct {
+	StaticFingerprinter
+	logger *log.Logger
+}
+
+// NewGPUFingerprint is used to create a GPU fingerprint
+func NewGPUFingerprint(logger *log.Logger) Fingerprint {
+	f := &GPUFingerprint{
+		logger: logger,
+	}
+	return f
+}
+
+func (f *GPUFingerprint) Fingerprint(cfg *client.Config, node *structs.Node) (bool, error) {
+	path, err := exec.LookPath(nvidiaSmi)
+	if err != nil {
+		// No NVIDIA driver is installed
+		return false, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, "--query-gpu=index,name,memory.total", "--format=csv,noheader,nounits")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		f.logger.Printf("[WARN] fingerprint.gpu: failed to query GPUs: %v: %s", err, strings.TrimSpace(stderr.String()))
+		return false, nil
+	}
+
+	devices, err := parseNvidiaSmiOutput(stdout.String())
+	if err != nil {
+		return false, err
+	}
+	if len(devices) == 0 {
+		return false, nil
+	}
+
+	if node.Resources == nil {
+		node.Resources = &structs.Resources{}
+	}
+	node.Resources.GPU = len(devices)
+	node.Resources.GPUDevices = make([]int, 0, len(devices))
+
+	node.Attributes["gpu.count"] = strconv.Itoa(len(devices))
+	node.Attributes["gpu.model"] = devices[0].Model
+	node.Attributes["gpu.memory_mb"] = strconv.Itoa(devices[0].MemoryMB)
+	for _, device := range devices {
+		node.Resources.GPUDevices = append(node.Resources.GPUDevices, device.Index)
+		node.Attributes[fmt.Sprintf("gpu.%d.model", device.Index)] = device.Model
+		node.Attributes[fmt.Sprintf("gpu.%d.memory_mb", device.Index)] = strconv.Itoa(device.MemoryMB)
+	}
+
+	return true, nil
+}
+
+// parseNvidiaSmiOutput parses the GPUs listed by nvidia-smi as lines of comma
+// separated index, name and total memory in MB.
+func parseNvidiaSmiOutput(output string) ([]*gpuDevice, error) {
+	var devices []*gpuDevice
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected %s output: %q", nvidiaSmi, line)
+		}
+
+		index, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GPU index %q: %v", fields[0], err)
+		}
+		memory, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse memory of GPU %d: %v", index, err)
+		}
+		devices = append(devices, &gpuDevice{
+			Index:    index,
+			Model:    strings.TrimSpace(fields[1]),
+			MemoryMB: memory,
+		})
+	}
+	return devices, nil
+}
@@ -0,0 +1,81 @@
+package fingerprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestGPUFingerprint(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake nvidia-smi requires a unix shell")
+	}
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := `#!/bin/sh
+echo "0, Tesla K80, 11439"
+echo "1, Tesla K80, 11439"
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, nvidiaSmi), []byte(script), 0755); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	f := NewGPUFingerprint(testLogger())
+	node := &structs.Node{
+		Attributes: make(map[string]string),
+	}
+	ok, err := f.Fingerprint(&config.Config{}, node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("should apply")
+	}
+
+	assertNodeAttributeEquals(t, node, "gpu.count", "2")
+	assertNodeAttributeEquals(t, node, "gpu.model", "Tesla K80")
+	assertNodeAttributeEquals(t, node, "gpu.memory_mb", "11439")
+	assertNodeAttributeEquals(t, node, "gpu.1.model", "Tesla K80")
+
+	if node.Resources == nil {
+		t.Fatalf("Node Resources was nil")
+	}
+	if node.Resources.GPU != 2 {
+		t.Fatalf("got %d GPUs; want 2", node.Resources.GPU)
+	}
+	if !reflect.DeepEqual(node.Resources.GPUDevices, []int{0, 1}) {
+		t.Fatalf("got GPU devices %v", node.Resources.GPUDevices)
+	}
+}
+
+func TestGPUFingerprint_ParseOutput(t *testing.T) {
+	devices, err := parseNvidiaSmiOutput("0, GeForce GTX 1080, 8113\n\n3, Tesla P100-PCIE-16GB, 16276\n")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []*gpuDevice{
+		{Index: 0, Model: "GeForce GTX 1080", MemoryMB: 8113},
+		{Index: 3, Model: "Tesla P100-PCIE-16GB", MemoryMB: 16276},
+	}
+	if !reflect.DeepEqual(devices, expected) {
+		t.Fatalf("got %#v; want %#v", devices, expected)
+	}
+
+	for _, output := range []string{"0, Tesla K80", "a, Tesla K80, 11439", "0, Tesla K80, [Not Supported]"} {
+		if _, err := parseNvidiaSmiOutput(output); err == nil {
+			t.Fatalf("expected an error parsing %q", output)
+		}
+	}
+}
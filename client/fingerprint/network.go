@@ -50,60 +50,86 @@ func NewNetworkFingerprint(logger *log.Logger) Fingerprint {
 }
 
 func (f *NetworkFingerprint) Fingerprint(cfg *config.Config, node *structs.Node) (bool, error) {
-	// newNetwork is populated and addded to the Nodes resources
-	newNetwork := &structs.NetworkResource{}
-	var ip string
+	// The addresses to advertise can be restricted to a CIDR
+	var cidr *net.IPNet
+	if cfg.NetworkCIDR != "" {
+		var err error
+		if _, cidr, err = net.ParseCIDR(cfg.NetworkCIDR); err != nil {
+			return false, fmt.Errorf("Invalid network CIDR %q: %v", cfg.NetworkCIDR, err)
+		}
+	}
 
-	intf, err := f.findInterface(cfg.NetworkInterface)
+	intfs, err := f.findInterfaces(cfg.NetworkInterface, cidr)
 	switch {
 	case err != nil:
 		return false, fmt.Errorf("Error while detecting network interface during fingerprinting: %v", err)
-	case intf == nil:
+	case len(intfs) == 0:
 		// No interface could be found
 		return false, nil
 	}
 
-	if ip, err = f.ipAddress(intf); err != nil {
-		return false, fmt.Errorf("Unable to find IP address of interface: %s, err: %v", intf.Name, err)
+	if node.Resources == nil {
+		node.Resources = &structs.Resources{}
 	}
 
-	newNetwork.Device = intf.Name
-	node.Attributes["unique.network.ip-address"] = ip
-	newNetwork.IP = ip
-	newNetwork.CIDR = newNetwork.IP + "/32"
-
-	f.logger.Printf("[DEBUG] fingerprint.network: Detected interface %v with IP %v during fingerprinting", intf.Name, ip)
+	// Add a network for each address to advertise, with the speed of the
+	// interface it belongs to
+	for i := range intfs {
+		intf := &intfs[i]
+		ips, err := f.ipAddresses(intf, cidr)
+		if err != nil {
+			return false, fmt.Errorf("Unable to find IP address of interface: %s, err: %v", intf.Name, err)
+		}
 
-	if throughput := f.linkSpeed(intf.Name); throughput > 0 {
-		newNetwork.MBits = throughput
-		f.logger.Printf("[DEBUG] fingerprint.network: link speed for %v set to %v", intf.Name, newNetwork.MBits)
-	} else {
-		f.logger.Printf("[DEBUG] fingerprint.network: Unable to read link speed; setting to default %v", cfg.NetworkSpeed)
-		newNetwork.MBits = cfg.NetworkSpeed
-	}
+		mbits := f.linkSpeed(intf.Name)
+		if mbits > 0 {
+			f.logger.Printf("[DEBUG] fingerprint.network: link speed for %v set to %v", intf.Name, mbits)
+		} else {
+			f.logger.Printf("[DEBUG] fingerprint.network: Unable to read link speed of %v; setting to default %v", intf.Name, cfg.NetworkSpeed)
+			mbits = cfg.NetworkSpeed
+		}
 
-	if node.Resources == nil {
-		node.Resources = &structs.Resources{}
+		for _, ip := range ips {
+			f.logger.Printf("[DEBUG] fingerprint.network: Detected interface %v with IP %v during fingerprinting", intf.Name, ip)
+			node.Resources.Networks = append(node.Resources.Networks, &structs.NetworkResource{
+				Device: intf.Name,
+				IP:     ip.String(),
+				CIDR:   hostCIDR(ip),
+				MBits:  mbits,
+			})
+		}
 	}
 
-	node.Resources.Networks = append(node.Resources.Networks, newNetwork)
+	node.Attributes["unique.network.ip-address"] = node.Resources.Networks[0].IP
 
 	// return true, because we have a network connection
 	return true, nil
 }
 
-// Gets the ipv4 addr for a network interface
-func (f *NetworkFingerprint) ipAddress(intf *net.Interface) (string, error) {
+// hostCIDR returns the CIDR notation of the single address.
+func hostCIDR(ip net.IP) string {
+	if ip.To4() != nil {
+		return ip.String() + "/32"
+	}
+	return ip.String() + "/128"
+}
+
+// ipAddresses returns the addresses of the interface to advertise. If a CIDR
+// is given, these are the addresses of the interface within it. Otherwise the
+// first IPv4 address is used, falling back to the first global IPv6 address.
+func (f *NetworkFingerprint) ipAddresses(intf *net.Interface, cidr *net.IPNet) ([]net.IP, error) {
 	var addrs []net.Addr
 	var err error
 
 	if addrs, err = f.interfaceDetector.Addrs(intf); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if len(addrs) == 0 {
-		return "", errors.New(fmt.Sprintf("Interface %s has no IP address", intf.Name))
+		return nil, errors.New(fmt.Sprintf("Interface %s has no IP address", intf.Name))
 	}
+
+	var ipv4, ipv6, matching []net.IP
 	for _, addr := range addrs {
 		var ip net.IP
 		switch v := (addr).(type) {
@@ -112,13 +138,32 @@ func (f *NetworkFingerprint) ipAddress(intf *net.Interface) (string, error) {
 		case *net.IPAddr:
 			ip = v.IP
 		}
-		if ip.To4() != nil {
-			return ip.String(), nil
+		switch {
+		case ip == nil:
+			continue
+		case cidr != nil:
+			if cidr.Contains(ip) {
+				matching = append(matching, ip)
+			}
+		case ip.To4() != nil:
+			ipv4 = append(ipv4, ip)
+		case ip.IsGlobalUnicast():
+			ipv6 = append(ipv6, ip)
 		}
 	}
 
-	return "", fmt.Errorf("Couldn't parse IP address for interface %s", intf.Name)
+	switch {
+	case cidr != nil && len(matching) != 0:
+		return matching, nil
+	case cidr != nil:
+		return nil, fmt.Errorf("Interface %s has no IP address in %s", intf.Name, cidr)
+	case len(ipv4) != 0:
+		return ipv4[:1], nil
+	case len(ipv6) != 0:
+		return ipv6[:1], nil
+	}
 
+	return nil, fmt.Errorf("Couldn't parse IP address for interface %s", intf.Name)
 }
 
 // Checks if the device is marked UP by the operator
@@ -126,9 +171,10 @@ func (f *NetworkFingerprint) isDeviceEnabled(intf *net.Interface) bool {
 	return intf.Flags&net.FlagUp != 0
 }
 
-// Checks if the device has any IP address configured
-func (f *NetworkFingerprint) deviceHasIpAddress(intf *net.Interface) bool {
-	_, err := f.ipAddress(intf)
+// Checks if the device has any IP address configured, within the CIDR if
+// one is given
+func (f *NetworkFingerprint) deviceHasIpAddress(intf *net.Interface, cidr *net.IPNet) bool {
+	_, err := f.ipAddresses(intf, cidr)
 	return err == nil
 }
 
@@ -136,16 +182,22 @@ func (n *NetworkFingerprint) isDeviceLoopBackOrPointToPoint(intf *net.Interface)
 	return intf.Flags&(net.FlagLoopback|net.FlagPointToPoint) != 0
 }
 
-// Returns the interface with the name passed by user
-// If the name is blank then it iterates through all the devices
-// and finds one which is routable and marked as UP
+// Returns the interfaces whose addresses are advertised
+// If a name is passed by the user, only the interface with that name
+// is returned. If a CIDR is passed, all the devices marked as UP with
+// an address within it are returned. Otherwise it iterates through all
+// the devices and finds one which is routable and marked as UP
 // It excludes PPP and lo devices unless they are specifically asked
-func (f *NetworkFingerprint) findInterface(deviceName string) (*net.Interface, error) {
+func (f *NetworkFingerprint) findInterfaces(deviceName string, cidr *net.IPNet) ([]net.Interface, error) {
 	var interfaces []net.Interface
 	var err error
 
 	if deviceName != "" {
-		return f.interfaceDetector.InterfaceByName(deviceName)
+		intf, err := f.interfaceDetector.InterfaceByName(deviceName)
+		if err != nil || intf == nil {
+			return nil, err
+		}
+		return []net.Interface{*intf}, nil
 	}
 
 	var intfs []net.Interface
@@ -155,13 +207,20 @@ func (f *NetworkFingerprint) findInterface(deviceName string) (*net.Interface, e
 	}
 
 	for _, intf := range intfs {
-		if f.isDeviceEnabled(&intf) && !f.isDeviceLoopBackOrPointToPoint(&intf) && f.deviceHasIpAddress(&intf) {
-			interfaces = append(interfaces, intf)
+		if !f.isDeviceEnabled(&intf) || !f.deviceHasIpAddress(&intf, cidr) {
+			continue
 		}
+		if cidr == nil && f.isDeviceLoopBackOrPointToPoint(&intf) {
+			continue
+		}
+		interfaces = append(interfaces, intf)
 	}
 
 	if len(interfaces) == 0 {
 		return nil, nil
 	}
-	return &interfaces[0], nil
+	if cidr == nil {
+		return interfaces[:1], nil
+	}
+	return interfaces, nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/nomad/client/config"
@@ -142,6 +143,45 @@ func (n *NetworkInterfaceDetectorMultipleInterfaces) Addrs(intf *net.Interface)
 	return nil, fmt.Errorf("Can't find addresses for device: %v", intf.Name)
 }
 
+// A fake network detector which simulates interfaces with IPv6 addresses
+type NetworkInterfaceDetectorIPv6 struct {
+}
+
+func (n *NetworkInterfaceDetectorIPv6) Interfaces() ([]net.Interface, error) {
+	return []net.Interface{lo, eth0, eth2}, nil
+}
+
+func (n *NetworkInterfaceDetectorIPv6) InterfaceByName(name string) (*net.Interface, error) {
+	switch name {
+	case "lo":
+		return &lo, nil
+	case "eth0":
+		return &eth0, nil
+	case "eth2":
+		return &eth2, nil
+	}
+	return nil, fmt.Errorf("No device with name %v found", name)
+}
+
+func (n *NetworkInterfaceDetectorIPv6) Addrs(intf *net.Interface) ([]net.Addr, error) {
+	hostAddr := func(cidr string) net.Addr {
+		ip, ipnet, _ := net.ParseCIDR(cidr)
+		ipnet.IP = ip
+		return ipnet
+	}
+
+	switch intf.Name {
+	case "lo":
+		return []net.Addr{hostAddr("127.0.0.1/8"), hostAddr("::1/128")}, nil
+	case "eth0":
+		// IPv6 only, with a link-local address listed first
+		return []net.Addr{hostAddr("fe80::1/64"), hostAddr("2001:db8::10/64")}, nil
+	case "eth2":
+		return []net.Addr{hostAddr("10.0.0.5/8"), hostAddr("2001:db8:1::5/64")}, nil
+	}
+	return nil, fmt.Errorf("Can't find addresses for device: %v", intf.Name)
+}
+
 func TestNetworkFingerprint_basic(t *testing.T) {
 	if v := os.Getenv(skipOnlineTestsEnvVar); v != "" {
 		t.Skipf("Environment variable %+q not empty, skipping test", skipOnlineTestsEnvVar)
@@ -308,3 +348,89 @@ func TestNetworkFingerPrint_excludelo_down_interfaces(t *testing.T) {
 		t.Fatal("Expected Network Resource to have a non-zero bandwith")
 	}
 }
+
+func TestNetworkFingerPrint_ipv6(t *testing.T) {
+	f := &NetworkFingerprint{logger: testLogger(), interfaceDetector: &NetworkInterfaceDetectorIPv6{}}
+	node := &structs.Node{
+		Attributes: make(map[string]string),
+	}
+	cfg := &config.Config{NetworkSpeed: 100}
+
+	ok, err := f.Fingerprint(cfg, node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("should apply")
+	}
+
+	// The global address of the first interface is used
+	if len(node.Resources.Networks) != 1 {
+		t.Fatalf("bad networks: %#v", node.Resources.Networks)
+	}
+	net := node.Resources.Networks[0]
+	if net.Device != "eth0" || net.IP != "2001:db8::10" || net.CIDR != "2001:db8::10/128" {
+		t.Fatalf("bad network: %#v", net)
+	}
+	if ip := node.Attributes["unique.network.ip-address"]; ip != "2001:db8::10" {
+		t.Fatalf("bad ip-address attribute: %q", ip)
+	}
+}
+
+func TestNetworkFingerPrint_cidr(t *testing.T) {
+	cases := []struct {
+		cidr     string
+		expected []string
+	}{
+		{
+			cidr:     "2001:db8::/32",
+			expected: []string{"eth0 2001:db8::10/128", "eth2 2001:db8:1::5/128"},
+		},
+		{
+			cidr:     "10.0.0.0/8",
+			expected: []string{"eth2 10.0.0.5/32"},
+		},
+		{
+			// Loopback devices are used when explicitly selected
+			cidr:     "127.0.0.0/8",
+			expected: []string{"lo 127.0.0.1/32"},
+		},
+	}
+
+	for _, c := range cases {
+		f := &NetworkFingerprint{logger: testLogger(), interfaceDetector: &NetworkInterfaceDetectorIPv6{}}
+		node := &structs.Node{
+			Attributes: make(map[string]string),
+		}
+		cfg := &config.Config{NetworkSpeed: 100, NetworkCIDR: c.cidr}
+
+		ok, err := f.Fingerprint(cfg, node)
+		if err != nil {
+			t.Fatalf("%s: err: %v", c.cidr, err)
+		}
+		if !ok {
+			t.Fatalf("%s: should apply", c.cidr)
+		}
+
+		var actual []string
+		for _, n := range node.Resources.Networks {
+			if n.MBits != 100 {
+				t.Fatalf("%s: bad bandwidth: %#v", c.cidr, n)
+			}
+			actual = append(actual, fmt.Sprintf("%s %s", n.Device, n.CIDR))
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Fatalf("%s: got networks %v; want %v", c.cidr, actual, c.expected)
+		}
+	}
+
+	// No interface has an address in the CIDR
+	f := &NetworkFingerprint{logger: testLogger(), interfaceDetector: &NetworkInterfaceDetectorIPv6{}}
+	node := &structs.Node{
+		Attributes: make(map[string]string),
+	}
+	cfg := &config.Config{NetworkSpeed: 100, NetworkCIDR: "192.168.0.0/16"}
+	if ok, err := f.Fingerprint(cfg, node); ok || err != nil {
+		t.Fatalf("expected no network: %v %v", ok, err)
+	}
+}
@@ -0,0 +1,189 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	client "github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// scriptDirOption is the client option setting the directory of the
+	// fingerprint scripts.
+	scriptDirOption = "fingerprint.script_dir"
+
+	// scriptFingerprintPeriod is the interval at which the scripts are run.
+	scriptFingerprintPeriod = 1 * time.Minute
+
+	// scriptTimeout is the time a script is given to output its fingerprint.
+	scriptTimeout = 10 * time.Second
+)
+
+// scriptOutput is the fingerprint output by a script.
+type scriptOutput struct {
+	Attributes map[string]string `json:"attributes"`
+	Links      map[string]string `json:"links"`
+}
+
+// ScriptFingerprint is used to fingerprint the node with the executables of
+// the client's script directory. Each script outputs a JSON object whose
+// attributes and links are added to the node.
+type ScriptFingerprint struct {
+	logger *log.Logger
+
+	// outputs is the last successful output of each script by path
+	outputs map[string]*scriptOutput
+}
+
+// NewScriptFingerprint is used to create a script fingerprint
+func NewScriptFingerprint(logger *log.Logger) Fingerprint {
+	return &ScriptFingerprint{
+		logger:  logger,
+		outputs: make(map[string]*scriptOutput),
+	}
+}
+
+func (f *ScriptFingerprint) Fingerprint(config *client.Config, node *structs.Node) (bool, error) {
+	// Guard against uninitialized Links
+	if node.Links == nil {
+		node.Links = map[string]string{}
+	}
+
+	// Remember what the scripts previously set so that what they no longer
+	// output can be removed
+	prev := f.merged()
+
+	dir := config.Read(scriptDirOption)
+	scripts, err := scriptPaths(dir)
+	if err != nil {
+		f.logger.Printf("[WARN] fingerprint.script: failed to list scripts in %q: %v", dir, err)
+	}
+
+	// Forget the scripts that were removed, and keep the last output of the
+	// scripts that fail
+	current := make(map[string]*scriptOutput, len(scripts))
+	for _, script := range scripts {
+		out, err := runScript(script)
+		if err != nil {
+			f.logger.Printf("[WARN] fingerprint.script: script %q failed: %v", script, err)
+			if last, ok := f.outputs[script]; ok {
+				current[script] = last
+			}
+			continue
+		}
+		current[script] = out
+	}
+	f.outputs = current
+
+	merged := f.merged()
+	for k := range prev.Attributes {
+		if _, ok := merged.Attributes[k]; !ok {
+			delete(node.Attributes, k)
+		}
+	}
+	for k := range prev.Links {
+		if _, ok := merged.Links[k]; !ok {
+			delete(node.Links, k)
+		}
+	}
+	for k, v := range merged.Attributes {
+		node.Attributes[k] = v
+	}
+	for k, v := range merged.Links {
+		node.Links[k] = v
+	}
+
+	return len(merged.Attributes) != 0 || len(merged.Links) != 0, nil
+}
+
+func (f *ScriptFingerprint) Periodic() (bool, time.Duration) {
+	return true, scriptFingerprintPeriod
+}
+
+// merged returns the attributes and links output by all the scripts. Scripts
+// are merged in lexical order, later ones overriding earlier ones.
+func (f *ScriptFingerprint) merged() *scriptOutput {
+	merged := &scriptOutput{
+		Attributes: make(map[string]string),
+		Links:      make(map[string]string),
+	}
+	paths := make([]string, 0, len(f.outputs))
+	for path := range f.outputs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for k, v := range f.outputs[path].Attributes {
+			merged.Attributes[k] = v
+		}
+		for k, v := range f.outputs[path].Links {
+			merged.Links[k] = v
+		}
+	}
+	return merged
+}
+
+// scriptPaths returns the paths of the executables in the directory, skipping
+// hidden files, in lexical order.
+func scriptPaths(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, info.Name()))
+	}
+	return paths, nil
+}
+
+// runScript runs the script and parses its output.
+func runScript(path string) (*scriptOutput, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cmd.Wait()
+	}()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	case <-time.After(scriptTimeout):
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("timed out after %v", scriptTimeout)
+	}
+
+	var out scriptOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse output: %v", err)
+	}
+	return &out, nil
+}
@@ -0,0 +1,94 @@
+package fingerprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestScriptFingerprint(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fingerprint scripts require a unix shell")
+	}
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string, mode os.FileMode) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), mode); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	write("gpu", `#!/bin/sh
+echo '{"attributes": {"gpu.count": "2", "gpu.model": "k80"}, "links": {"inventory": "rack-1"}}'
+`, 0755)
+	write("dongle", `#!/bin/sh
+echo '{"attributes": {"license.dongle": "1"}}'
+`, 0755)
+	write("readme", "not a script", 0644)
+	write(".hidden", "#!/bin/sh\nexit 1\n", 0755)
+
+	cfg := &config.Config{Options: map[string]string{scriptDirOption: dir}}
+	node := &structs.Node{Attributes: map[string]string{"cpu.arch": "amd64"}}
+	fp := NewScriptFingerprint(testLogger())
+
+	ok, err := fp.Fingerprint(cfg, node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("should apply")
+	}
+	assertNodeAttributeEquals(t, node, "gpu.count", "2")
+	assertNodeAttributeEquals(t, node, "gpu.model", "k80")
+	assertNodeAttributeEquals(t, node, "license.dongle", "1")
+	assertNodeAttributeEquals(t, node, "cpu.arch", "amd64")
+	assertNodeLinksContains(t, node, "inventory")
+
+	// A failing script keeps its previous attributes, a removed script's
+	// attributes and a no longer output attribute are removed
+	write("gpu", "#!/bin/sh\nexit 1\n", 0755)
+	if err := os.Remove(filepath.Join(dir, "dongle")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := fp.Fingerprint(cfg, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertNodeAttributeEquals(t, node, "gpu.count", "2")
+	if _, ok := node.Attributes["license.dongle"]; ok {
+		t.Fatalf("attribute of removed script not removed: %v", node.Attributes)
+	}
+
+	write("gpu", `#!/bin/sh
+echo '{"attributes": {"gpu.count": "1"}}'
+`, 0755)
+	if _, err := fp.Fingerprint(cfg, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertNodeAttributeEquals(t, node, "gpu.count", "1")
+	if _, ok := node.Attributes["gpu.model"]; ok {
+		t.Fatalf("attribute no longer output not removed: %v", node.Attributes)
+	}
+	if _, ok := node.Links["inventory"]; ok {
+		t.Fatalf("link no longer output not removed: %v", node.Links)
+	}
+	assertNodeAttributeEquals(t, node, "cpu.arch", "amd64")
+}
+
+func TestScriptFingerprint_NoDir(t *testing.T) {
+	node := &structs.Node{Attributes: make(map[string]string)}
+	ok, err := NewScriptFingerprint(testLogger()).Fingerprint(&config.Config{}, node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ok {
+		t.Fatalf("should not apply without scripts")
+	}
+}
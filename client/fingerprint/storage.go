@@ -35,21 +35,41 @@ func (f *StorageFingerprint) Fingerprint(cfg *config.Config, node *structs.Node)
 	}
 
 	// Guard against unset AllocDir
-	storageDir := cfg.AllocDir
-	if storageDir == "" {
-		var err error
-		storageDir, err = os.Getwd()
+	roots := cfg.AllocDirRoots()
+	if len(roots) == 0 {
+		cwd, err := os.Getwd()
 		if err != nil {
 			return false, fmt.Errorf("unable to get CWD from filesystem: %s", err)
 		}
+		roots = []string{cwd}
 	}
 
-	volume, total, free, err := f.diskFree(storageDir)
-	if err != nil {
-		return false, fmt.Errorf("failed to determine disk space for %s: %v", storageDir, err)
+	// The directory of an allocation lives on a single root, so the capacity
+	// of the node is the one of the root with the most free space. Summing
+	// the roots would advertise space no single allocation can use.
+	var total, free uint64
+	for i, root := range roots {
+		volume, rootTotal, rootFree, err := f.diskFree(root)
+		if err != nil {
+			return false, fmt.Errorf("failed to determine disk space for %s: %v", root, err)
+		}
+
+		if i == 0 {
+			node.Attributes["unique.storage.volume"] = volume
+		}
+		if len(roots) > 1 {
+			prefix := fmt.Sprintf("unique.storage.root.%d.", i)
+			node.Attributes[prefix+"path"] = root
+			node.Attributes[prefix+"volume"] = volume
+			node.Attributes[prefix+"bytestotal"] = strconv.FormatUint(rootTotal, 10)
+			node.Attributes[prefix+"bytesfree"] = strconv.FormatUint(rootFree, 10)
+		}
+
+		if i == 0 || rootFree > free {
+			total, free = rootTotal, rootFree
+		}
 	}
 
-	node.Attributes["unique.storage.volume"] = volume
 	node.Attributes["unique.storage.bytestotal"] = strconv.FormatUint(total, 10)
 	node.Attributes["unique.storage.bytesfree"] = strconv.FormatUint(free, 10)
 
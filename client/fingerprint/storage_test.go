@@ -1,9 +1,13 @@
 package fingerprint
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
 	"strconv"
 	"testing"
 
+	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -39,3 +43,70 @@ func TestStorageFingerprint(t *testing.T) {
 		t.Errorf("Expected node.Resources.DiskMB to be non-zero")
 	}
 }
+
+func TestStorageFingerprint_AllocDirs(t *testing.T) {
+	fp := NewStorageFingerprint(testLogger())
+	node := &structs.Node{
+		Attributes: make(map[string]string),
+	}
+
+	single, err := ioutil.TempDir("", "StorageFingerprint")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(single)
+
+	cfg := &config.Config{AllocDir: single}
+	ok, err := fp.Fingerprint(cfg, node)
+	if err != nil || !ok {
+		t.Fatalf("failed to fingerprint: %v", err)
+	}
+	if _, ok := node.Attributes["unique.storage.root.0.path"]; ok {
+		t.Fatalf("unexpected root attributes with a single alloc dir: %v", node.Attributes)
+	}
+	expected := node.Attributes["unique.storage.bytestotal"]
+
+	// Roots on the same volume are counted once
+	other, err := ioutil.TempDir("", "StorageFingerprint")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(other)
+
+	cfg.AllocDirs = []string{other}
+	ok, err = fp.Fingerprint(cfg, node)
+	if err != nil || !ok {
+		t.Fatalf("failed to fingerprint: %v", err)
+	}
+
+	for i, root := range []string{single, other} {
+		prefix := fmt.Sprintf("unique.storage.root.%d.", i)
+		if path := node.Attributes[prefix+"path"]; path != root {
+			t.Fatalf("bad %spath: %q", prefix, path)
+		}
+		assertNodeAttributeContains(t, node, prefix+"volume")
+		assertNodeAttributeContains(t, node, prefix+"bytestotal")
+		assertNodeAttributeContains(t, node, prefix+"bytesfree")
+	}
+	if actual := node.Attributes["unique.storage.bytestotal"]; actual != expected {
+		t.Fatalf("unique.storage.bytestotal is %s; want %s", actual, expected)
+	}
+
+	// The advertised disk is the one of the root with the most free space
+	var largest uint64
+	for i := range cfg.AllocDirRoots() {
+		free, err := strconv.ParseUint(node.Attributes[fmt.Sprintf("unique.storage.root.%d.bytesfree", i)], 10, 64)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if free > largest {
+			largest = free
+		}
+	}
+	if actual := node.Attributes["unique.storage.bytesfree"]; actual != strconv.FormatUint(largest, 10) {
+		t.Fatalf("unique.storage.bytesfree is %s; want %d", actual, largest)
+	}
+	if node.Resources.DiskMB != int(largest/bytesPerMegabyte) {
+		t.Fatalf("DiskMB is %d; want %d", node.Resources.DiskMB, largest/bytesPerMegabyte)
+	}
+}
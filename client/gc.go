@@ -0,0 +1,123 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/shirou/gopsutil/disk"
+)
+
+// gcCandidate is a terminal allocation whose directory can be collected
+type gcCandidate struct {
+	ar    *AllocRunner
+	alloc string
+	size  int64
+}
+
+// gcCandidates sorts candidates by decreasing disk usage
+type gcCandidates []*gcCandidate
+
+func (g gcCandidates) Len() int           { return len(g) }
+func (g gcCandidates) Less(i, j int) bool { return g[i].size > g[j].size }
+func (g gcCandidates) Swap(i, j int)      { g[i], g[j] = g[j], g[i] }
+
+// gcDiskPressure periodically checks the disk usage of the filesystem backing
+// the allocation directory and garbage collects terminal allocations when it
+// exceeds the configured threshold.
+func (c *Client) gcDiskPressure() {
+	next := time.NewTimer(c.config.GCInterval)
+	defer next.Stop()
+	for {
+		select {
+		case <-next.C:
+			collected, err := c.gcTerminalAllocs(c.diskPressure)
+			if err != nil {
+				c.logger.Printf("[WARN] client: failed to garbage collect allocations: %v", err)
+			}
+			if collected != 0 {
+				c.logger.Printf("[WARN] client: garbage collected %d terminal allocation(s) due to disk pressure", collected)
+			}
+			next.Reset(c.config.GCInterval)
+		case <-c.shutdownCh:
+			return
+		}
+	}
+}
+
+// diskPressure returns whether the disk usage of the filesystem backing any
+// of the alloc dir roots exceeds the configured threshold.
+func (c *Client) diskPressure() (bool, error) {
+	for _, root := range c.config.AllocDirRoots() {
+		usage, err := disk.Usage(root)
+		if err != nil {
+			return false, fmt.Errorf("failed to read disk usage of %q: %v", root, err)
+		}
+		if usage.UsedPercent > c.config.GCDiskUsageThreshold {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// gcTerminalAllocs destroys the directories of terminal allocations, largest
+// first, for as long as pressure reports that the disk is under pressure. The
+// allocation runners are kept until the servers remove the allocations. It
+// returns the number of collected allocations.
+func (c *Client) gcTerminalAllocs(pressure func() (bool, error)) (int, error) {
+	underPressure, err := pressure()
+	if err != nil || !underPressure {
+		return 0, err
+	}
+
+	var candidates gcCandidates
+	for _, ar := range c.getAllocRunners() {
+		alloc := ar.Alloc()
+		if !alloc.Terminated() {
+			continue
+		}
+
+		ar.ctxLock.Lock()
+		ctx := ar.ctx
+		ar.ctxLock.Unlock()
+		if ctx == nil {
+			continue
+		}
+
+		size, err := ctx.AllocDir.DiskUsage()
+		if err != nil {
+			c.logger.Printf("[WARN] client: failed to compute disk usage of alloc %q: %v", alloc.ID, err)
+			continue
+		}
+
+		// Allocations which have already been collected have no usage
+		if size == 0 {
+			continue
+		}
+		candidates = append(candidates, &gcCandidate{ar: ar, alloc: alloc.ID, size: size})
+	}
+	sort.Sort(candidates)
+
+	collected := 0
+	for _, cand := range candidates {
+		if collected != 0 {
+			if underPressure, err = pressure(); err != nil {
+				return collected, err
+			}
+			if !underPressure {
+				break
+			}
+		}
+
+		c.logger.Printf("[INFO] client: garbage collecting terminal alloc %q using %d bytes due to disk pressure",
+			cand.alloc, cand.size)
+		if err := cand.ar.DestroyContext(); err != nil {
+			c.logger.Printf("[ERR] client: failed to destroy context for alloc %q: %v", cand.alloc, err)
+			continue
+		}
+		metrics.IncrCounter([]string{"client", "gc", "disk_pressure"}, 1)
+		collected++
+	}
+	return collected, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestClient_GCTerminalAllocs(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "nomadtest-gc")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	c := &Client{
+		logger: testLogger(),
+		allocs: make(map[string]*AllocRunner),
+	}
+
+	// Create a running alloc and two terminal allocs of different sizes
+	statuses := []string{
+		structs.AllocClientStatusRunning,
+		structs.AllocClientStatusComplete,
+		structs.AllocClientStatusFailed,
+	}
+	sizes := []int{300, 100, 200}
+	dirs := make([]string, len(statuses))
+	for i, status := range statuses {
+		alloc := mock.Alloc()
+		alloc.ClientStatus = status
+		_, ar := testAllocRunnerFromAlloc(alloc, false)
+
+		dirs[i] = filepath.Join(tmp, alloc.ID)
+		if err := os.MkdirAll(dirs[i], 0777); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dirs[i], "data"), make([]byte, sizes[i]), 0666); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		ar.ctx = driver.NewExecContext(allocdir.NewAllocDir(dirs[i], 10), alloc.ID)
+		c.allocs[alloc.ID] = ar
+	}
+
+	// No allocs are collected without pressure
+	noPressure := func() (bool, error) { return false, nil }
+	if n, err := c.gcTerminalAllocs(noPressure); err != nil || n != 0 {
+		t.Fatalf("got %d, %v; want 0 collected", n, err)
+	}
+
+	// Relieve the pressure after the first collection
+	calls := 0
+	pressure := func() (bool, error) {
+		calls++
+		return calls == 1, nil
+	}
+	if n, err := c.gcTerminalAllocs(pressure); err != nil || n != 1 {
+		t.Fatalf("got %d, %v; want 1 collected", n, err)
+	}
+
+	// Only the largest terminal alloc should have been collected
+	for i, dir := range dirs {
+		_, err := os.Stat(dir)
+		if i == 2 && !os.IsNotExist(err) {
+			t.Fatalf("alloc dir %q not collected: %v", dir, err)
+		} else if i != 2 && err != nil {
+			t.Fatalf("alloc dir %q collected: %v", dir, err)
+		}
+	}
+
+	// The remaining terminal alloc is collected under sustained pressure
+	alwaysPressure := func() (bool, error) { return true, nil }
+	if n, err := c.gcTerminalAllocs(alwaysPressure); err != nil || n != 1 {
+		t.Fatalf("got %d, %v; want 1 collected", n, err)
+	}
+	if _, err := os.Stat(dirs[0]); err != nil {
+		t.Fatalf("running alloc dir collected: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	gg "github.com/hashicorp/go-getter"
@@ -18,11 +19,11 @@ var (
 	lock    sync.Mutex
 
 	// supported is the set of download schemes supported by Nomad
-	supported = []string{"http", "https", "s3"}
+	supported = []string{"http", "https", "s3", "git"}
 )
 
 // getClient returns a client that is suitable for Nomad downloading artifacts.
-func getClient(src, dst string) *gg.Client {
+func getClient(src, dst string, mode gg.ClientMode) *gg.Client {
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -39,7 +40,7 @@ func getClient(src, dst string) *gg.Client {
 	return &gg.Client{
 		Src:     src,
 		Dst:     dst,
-		Mode:    gg.ClientModeAny,
+		Mode:    mode,
 		Getters: getters,
 	}
 }
@@ -47,7 +48,15 @@ func getClient(src, dst string) *gg.Client {
 // getGetterUrl returns the go-getter URL to download the artifact.
 func getGetterUrl(taskEnv *env.TaskEnvironment, artifact *structs.TaskArtifact) (string, error) {
 	taskEnv.Build()
-	u, err := url.Parse(taskEnv.ReplaceEnv(artifact.GetterSource))
+	source := taskEnv.ReplaceEnv(artifact.GetterSource)
+
+	// Split off a forced getter, such as "git::", which isn't part of the URL
+	forced := ""
+	if idx := strings.Index(source, "::"); idx > 0 && !strings.Contains(source[:idx], "/") {
+		forced, source = source[:idx+2], source[idx+2:]
+	}
+
+	u, err := url.Parse(source)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse source URL %q: %v", artifact.GetterSource, err)
 	}
@@ -58,7 +67,18 @@ func getGetterUrl(taskEnv *env.TaskEnvironment, artifact *structs.TaskArtifact)
 		q.Add(k, taskEnv.ReplaceEnv(v))
 	}
 	u.RawQuery = q.Encode()
-	return u.String(), nil
+	return forced + u.String(), nil
+}
+
+// getMode returns the client mode to download the URL with. Repositories are
+// always downloaded as directories while other sources are downloaded as a
+// file unless they are archives.
+func getMode(url, pwd string) gg.ClientMode {
+	src, err := gg.Detect(url, pwd, gg.Detectors)
+	if err == nil && strings.HasPrefix(src, "git::") {
+		return gg.ClientModeDir
+	}
+	return gg.ClientModeAny
 }
 
 // GetArtifact downloads an artifact into the specified task directory.
@@ -70,7 +90,7 @@ func GetArtifact(taskEnv *env.TaskEnvironment, artifact *structs.TaskArtifact, t
 
 	// Download the artifact
 	dest := filepath.Join(taskDir, artifact.RelativeDest)
-	if err := getClient(url, dest).Get(); err != nil {
+	if err := getClient(url, dest, getMode(url, taskDir)).Get(); err != nil {
 		return fmt.Errorf("GET error: %v", err)
 	}
 
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -102,6 +103,26 @@ func TestGetGetterUrl_Interprolation(t *testing.T) {
 	}
 }
 
+func TestGetGetterUrl_ForcedGetter(t *testing.T) {
+	artifact := &structs.TaskArtifact{
+		GetterSource: "git::https://example.com/repo.git",
+		GetterOptions: map[string]string{
+			"ref": "v1.0",
+		},
+	}
+
+	taskEnv := env.NewTaskEnvironment(mock.Node(), false)
+	act, err := getGetterUrl(taskEnv, artifact)
+	if err != nil {
+		t.Fatalf("getGetterUrl() failed: %v", err)
+	}
+
+	exp := "git::https://example.com/repo.git?ref=v1.0"
+	if act != exp {
+		t.Fatalf("getGetterUrl() returned %q; want %q", act, exp)
+	}
+}
+
 func TestGetArtifact_InvalidChecksum(t *testing.T) {
 	// Create the test server hosting the file to download
 	ts := httptest.NewServer(http.FileServer(http.Dir(filepath.Dir("./test-fixtures/"))))
@@ -204,3 +225,48 @@ func TestGetArtifact_Archive(t *testing.T) {
 	}
 	checkContents(taskDir, expected, t)
 }
+
+func TestGetArtifact_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	// Create a repository to clone
+	repo, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("failed to make temp directory: %v", err)
+	}
+	defer os.RemoveAll(repo)
+
+	createContents(repo, map[string]string{"my.config": "hello world\n"}, t)
+	for _, args := range [][]string{
+		{"init"},
+		{"add", "my.config"},
+		{"-c", "user.name=nomad", "-c", "user.email=nomad@example.com", "commit", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	// Create a temp directory to clone into
+	taskDir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("failed to make temp directory: %v", err)
+	}
+	defer os.RemoveAll(taskDir)
+
+	artifact := &structs.TaskArtifact{
+		GetterSource: "git::file://" + filepath.ToSlash(repo),
+		RelativeDest: "local/repo",
+	}
+
+	taskEnv := env.NewTaskEnvironment(mock.Node(), false)
+	if err := GetArtifact(taskEnv, artifact, taskDir); err != nil {
+		t.Fatalf("GetArtifact failed: %v", err)
+	}
+
+	checkContents(filepath.Join(taskDir, "local", "repo"), map[string]string{"my.config": "hello world\n"}, t)
+}
@@ -17,7 +17,7 @@ const (
 	ReasonNoRestartsAllowed   = "Policy allows no restarts"
 	ReasonUnrecoverableErrror = "Error was unrecoverable"
 	ReasonWithinPolicy        = "Restart within policy"
-	ReasonDelay               = "Exceeded allowed attempts, applying a delay"
+	ReasonRestartTriggered    = "Restart triggered by user"
 )
 
 func newRestartTracker(policy *structs.RestartPolicy, jobType string) *RestartTracker {
@@ -34,15 +34,18 @@ func newRestartTracker(policy *structs.RestartPolicy, jobType string) *RestartTr
 }
 
 type RestartTracker struct {
-	waitRes   *cstructs.WaitResult
-	startErr  error
-	count     int       // Current number of attempts.
-	onSuccess bool      // Whether to restart on successful exit code.
-	startTime time.Time // When the interval began
-	reason    string    // The reason for the last state
-	policy    *structs.RestartPolicy
-	rand      *rand.Rand
-	lock      sync.Mutex
+	waitRes          *cstructs.WaitResult
+	startErr         error
+	restartTriggered bool      // Whether the task has been signaled to be restarted
+	failure          bool      // Whether the triggered restart is due to a failure
+	count            int       // Current number of attempts.
+	setupCount       int       // Current number of attempts after setup failures.
+	onSuccess        bool      // Whether to restart on successful exit code.
+	startTime        time.Time // When the interval began
+	reason           string    // The reason for the last state
+	policy           *structs.RestartPolicy
+	rand             *rand.Rand
+	lock             sync.Mutex
 }
 
 // SetPolicy updates the policy used to determine restarts.
@@ -69,6 +72,17 @@ func (r *RestartTracker) SetWaitResult(res *cstructs.WaitResult) *RestartTracker
 	return r
 }
 
+// SetRestartTriggered is used to mark that the task has been signaled to be
+// restarted. A triggered restart only counts against the restart policy if it
+// is due to a failure of the task, such as its checks failing.
+func (r *RestartTracker) SetRestartTriggered(failure bool) *RestartTracker {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.restartTriggered = true
+	r.failure = failure
+	return r
+}
+
 // GetReason returns a human-readable description for the last state returned by
 // GetState.
 func (r *RestartTracker) GetReason() string {
@@ -91,6 +105,17 @@ func (r *RestartTracker) GetState() (string, time.Duration) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
+	// Hot path if a restart was triggered
+	failure := r.failure
+	if r.restartTriggered {
+		r.restartTriggered = false
+		r.failure = false
+		if !failure {
+			r.reason = ReasonRestartTriggered
+			return structs.TaskRestarting, r.jitter()
+		}
+	}
+
 	// Hot path if no attempts are expected
 	if r.policy.Attempts == 0 {
 		r.reason = ReasonNoRestartsAllowed
@@ -101,13 +126,21 @@ func (r *RestartTracker) GetState() (string, time.Duration) {
 		return structs.TaskNotRestarting, 0
 	}
 
-	r.count++
+	// Failures to set up the task are counted apart from the failures of the
+	// started task, so that failures of the infrastructure do not use up the
+	// attempts to restart a crashing application and vice versa.
+	if r.startErr != nil {
+		r.setupCount++
+	} else {
+		r.count++
+	}
 
 	// Check if we have entered a new interval.
 	end := r.startTime.Add(r.policy.Interval)
 	now := time.Now()
 	if now.After(end) {
 		r.count = 0
+		r.setupCount = 0
 		r.startTime = now
 	}
 
@@ -115,6 +148,8 @@ func (r *RestartTracker) GetState() (string, time.Duration) {
 		return r.handleStartError()
 	} else if r.waitRes != nil {
 		return r.handleWaitResult()
+	} else if failure {
+		return r.handleFailure()
 	} else {
 		return "", 0
 	}
@@ -131,16 +166,8 @@ func (r *RestartTracker) handleStartError() (string, time.Duration) {
 		return structs.TaskNotRestarting, 0
 	}
 
-	if r.count > r.policy.Attempts {
-		if r.policy.Mode == structs.RestartPolicyModeFail {
-			r.reason = fmt.Sprintf(
-				`Exceeded allowed attempts %d in interval %v and mode is "fail"`,
-				r.policy.Attempts, r.policy.Interval)
-			return structs.TaskNotRestarting, 0
-		} else {
-			r.reason = ReasonDelay
-			return structs.TaskRestarting, r.getDelay()
-		}
+	if r.setupCount > r.policy.Attempts {
+		return r.handleAttemptsExceeded()
 	}
 
 	r.reason = ReasonWithinPolicy
@@ -158,21 +185,36 @@ func (r *RestartTracker) handleWaitResult() (string, time.Duration) {
 	}
 
 	if r.count > r.policy.Attempts {
-		if r.policy.Mode == structs.RestartPolicyModeFail {
-			r.reason = fmt.Sprintf(
-				`Exceeded allowed attempts %d in interval %v and mode is "fail"`,
-				r.policy.Attempts, r.policy.Interval)
-			return structs.TaskNotRestarting, 0
-		} else {
-			r.reason = ReasonDelay
-			return structs.TaskRestarting, r.getDelay()
-		}
+		return r.handleAttemptsExceeded()
+	}
+
+	r.reason = ReasonWithinPolicy
+	return structs.TaskRestarting, r.jitter()
+}
+
+// handleFailure returns the new state and potential wait duration for
+// restarting the task after a restart was triggered due to a failure.
+func (r *RestartTracker) handleFailure() (string, time.Duration) {
+	if r.count > r.policy.Attempts {
+		return r.handleAttemptsExceeded()
 	}
 
 	r.reason = ReasonWithinPolicy
 	return structs.TaskRestarting, r.jitter()
 }
 
+// handleAttemptsExceeded returns the new state and potential wait duration
+// once the task has exceeded the allowed attempts within the interval. In
+// delay mode the task is restarted once the next interval begins.
+func (r *RestartTracker) handleAttemptsExceeded() (string, time.Duration) {
+	r.reason = fmt.Sprintf(`Exceeded allowed attempts %d in interval %v and mode is %q`,
+		r.policy.Attempts, r.policy.Interval, r.policy.Mode)
+	if r.policy.Mode == structs.RestartPolicyModeFail {
+		return structs.TaskNotRestarting, 0
+	}
+	return structs.TaskRestarting, r.getDelay()
+}
+
 // getDelay returns the delay time to enter the next interval.
 func (r *RestartTracker) getDelay() time.Duration {
 	end := r.startTime.Add(r.policy.Interval)
@@ -52,6 +52,10 @@ func TestClient_RestartTracker_ModeDelay(t *testing.T) {
 		if !(when > p.Delay && when <= p.Interval) {
 			t.Fatalf("NextRestart() returned %v; want > %v and <= %v", when, p.Delay, p.Interval)
 		}
+		expected := `Exceeded allowed attempts 3 in interval 2m0s and mode is "delay"`
+		if reason := rt.GetReason(); reason != expected {
+			t.Fatalf("GetReason() returned %q; want %q", reason, expected)
+		}
 	}
 }
 
@@ -73,6 +77,10 @@ func TestClient_RestartTracker_ModeFail(t *testing.T) {
 	if state, _ := rt.SetWaitResult(testWaitResult(127)).GetState(); state != structs.TaskNotRestarting {
 		t.Fatalf("NextRestart() returned %v; want %v", state, structs.TaskNotRestarting)
 	}
+	expected := `Exceeded allowed attempts 3 in interval 2m0s and mode is "fail"`
+	if reason := rt.GetReason(); reason != expected {
+		t.Fatalf("GetReason() returned %q; want %q", reason, expected)
+	}
 }
 
 func TestClient_RestartTracker_NoRestartOnSuccess(t *testing.T) {
@@ -139,3 +147,79 @@ func TestClient_RestartTracker_StartError_Recoverable_Delay(t *testing.T) {
 		t.Fatalf("NextRestart() returned %v; want > %v and <= %v", when, p.Delay, p.Interval)
 	}
 }
+
+func TestClient_RestartTracker_StartError_SeparateAttempts(t *testing.T) {
+	t.Parallel()
+	p := testPolicy(true, structs.RestartPolicyModeFail)
+	rt := newRestartTracker(p, structs.JobTypeSystem)
+	recErr := cstructs.NewRecoverableError(fmt.Errorf("foo"), true)
+
+	// Use up the attempts with failures of the started task
+	for i := 0; i < p.Attempts; i++ {
+		if state, _ := rt.SetWaitResult(testWaitResult(1)).GetState(); state != structs.TaskRestarting {
+			t.Fatalf("NextRestart() returned %v, want %v", state, structs.TaskRestarting)
+		}
+		rt.SetWaitResult(nil)
+	}
+
+	// Setup failures have attempts of their own
+	for i := 0; i < p.Attempts; i++ {
+		if state, _ := rt.SetStartError(recErr).GetState(); state != structs.TaskRestarting {
+			t.Fatalf("NextRestart() returned %v, want %v", state, structs.TaskRestarting)
+		}
+	}
+	if state, _ := rt.SetStartError(recErr).GetState(); state != structs.TaskNotRestarting {
+		t.Fatalf("NextRestart() returned %v; want %v", state, structs.TaskNotRestarting)
+	}
+}
+
+func TestClient_RestartTracker_RestartTriggered(t *testing.T) {
+	t.Parallel()
+	p := testPolicy(true, structs.RestartPolicyModeFail)
+	p.Attempts = 0
+	rt := newRestartTracker(p, structs.JobTypeService)
+
+	// Triggered restarts are allowed even if the policy allows no restarts
+	for i := 0; i < 3; i++ {
+		state, when := rt.SetRestartTriggered(false).GetState()
+		if state != structs.TaskRestarting {
+			t.Fatalf("NextRestart() returned %v, want %v", state, structs.TaskRestarting)
+		}
+		if !withinJitter(p.Delay, when) {
+			t.Fatalf("NextRestart() returned %v; want %v+jitter", when, p.Delay)
+		}
+		if reason := rt.GetReason(); reason != ReasonRestartTriggered {
+			t.Fatalf("GetReason() returned %q; want %q", reason, ReasonRestartTriggered)
+		}
+	}
+
+	// The trigger is cleared once consumed
+	if state, _ := rt.SetWaitResult(testWaitResult(1)).GetState(); state != structs.TaskNotRestarting {
+		t.Fatalf("NextRestart() returned %v, want %v", state, structs.TaskNotRestarting)
+	}
+}
+
+func TestClient_RestartTracker_RestartTriggered_Failure(t *testing.T) {
+	t.Parallel()
+	p := testPolicy(true, structs.RestartPolicyModeFail)
+	p.Attempts = 2
+	rt := newRestartTracker(p, structs.JobTypeService)
+
+	// Restarts due to failures count against the policy
+	for i := 0; i < p.Attempts; i++ {
+		state, when := rt.SetRestartTriggered(true).GetState()
+		if state != structs.TaskRestarting {
+			t.Fatalf("NextRestart() returned %v, want %v", state, structs.TaskRestarting)
+		}
+		if !withinJitter(p.Delay, when) {
+			t.Fatalf("NextRestart() returned %v; want %v+jitter", when, p.Delay)
+		}
+		if reason := rt.GetReason(); reason != ReasonWithinPolicy {
+			t.Fatalf("GetReason() returned %q; want %q", reason, ReasonWithinPolicy)
+		}
+	}
+
+	if state, _ := rt.SetRestartTriggered(true).GetState(); state != structs.TaskNotRestarting {
+		t.Fatalf("NextRestart() returned %v, want %v", state, structs.TaskNotRestarting)
+	}
+}
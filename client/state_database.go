@@ -0,0 +1,216 @@
+package client
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+/*
+The client persists the state of its alloc and task runners in a bolt database
+in the state directory, so that a restarted agent reattaches to the tasks it
+was running. The database has the following layout:
+
+allocations/
+|--> <alloc-id>/
+   |--> alloc_runner -> allocRunnerState
+   |--> tasks/
+      |--> <task-name> -> taskRunnerState
+*/
+
+const (
+	// stateDBFile is the name of the state database in the state directory
+	stateDBFile = "state.db"
+
+	// legacyStateDir is the directory of the state directory in which
+	// earlier versions persisted the state of each runner in its own file
+	legacyStateDir = "alloc"
+)
+
+var (
+	allocationsBucket = []byte("allocations")
+	tasksBucket       = []byte("tasks")
+
+	allocRunnerStateKey = []byte("alloc_runner")
+)
+
+// putObject stores the JSON encoding of the object under the key.
+func putObject(bkt *bolt.Bucket, key []byte, obj interface{}) error {
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %v", err)
+	}
+	if err := bkt.Put(key, buf); err != nil {
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+	return nil
+}
+
+// getObject decodes the object stored under the key. The object is left
+// untouched if the key doesn't exist.
+func getObject(bkt *bolt.Bucket, key []byte, obj interface{}) error {
+	buf := bkt.Get(key)
+	if buf == nil {
+		return nil
+	}
+	if err := json.Unmarshal(buf, obj); err != nil {
+		return fmt.Errorf("failed to decode state: %v", err)
+	}
+	return nil
+}
+
+// getAllocationBucket returns the bucket of the allocation, creating it in
+// writable transactions. It returns nil in read-only transactions if the
+// bucket doesn't exist.
+func getAllocationBucket(tx *bolt.Tx, allocID string) (*bolt.Bucket, error) {
+	if !tx.Writable() {
+		allocs := tx.Bucket(allocationsBucket)
+		if allocs == nil {
+			return nil, nil
+		}
+		return allocs.Bucket([]byte(allocID)), nil
+	}
+
+	allocs, err := tx.CreateBucketIfNotExists(allocationsBucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create allocations bucket: %v", err)
+	}
+	alloc, err := allocs.CreateBucketIfNotExists([]byte(allocID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create allocation bucket: %v", err)
+	}
+	return alloc, nil
+}
+
+// getTasksBucket returns the bucket holding the state of the tasks of the
+// allocation, with the same semantics as getAllocationBucket.
+func getTasksBucket(tx *bolt.Tx, allocID string) (*bolt.Bucket, error) {
+	alloc, err := getAllocationBucket(tx, allocID)
+	if err != nil || alloc == nil {
+		return nil, err
+	}
+	if !tx.Writable() {
+		return alloc.Bucket(tasksBucket), nil
+	}
+
+	tasks, err := alloc.CreateBucketIfNotExists(tasksBucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tasks bucket: %v", err)
+	}
+	return tasks, nil
+}
+
+// deleteAllocationBucket deletes the state of the allocation and its tasks.
+func deleteAllocationBucket(tx *bolt.Tx, allocID string) error {
+	allocs := tx.Bucket(allocationsBucket)
+	if allocs == nil || allocs.Bucket([]byte(allocID)) == nil {
+		return nil
+	}
+	return allocs.DeleteBucket([]byte(allocID))
+}
+
+// allocationIDs returns the IDs of the allocations having a saved state.
+func allocationIDs(tx *bolt.Tx) []string {
+	allocs := tx.Bucket(allocationsBucket)
+	if allocs == nil {
+		return nil
+	}
+
+	var ids []string
+	allocs.ForEach(func(k, v []byte) error {
+		// Only nested buckets have a nil value
+		if v == nil {
+			ids = append(ids, string(k))
+		}
+		return nil
+	})
+	return ids
+}
+
+// migrateLegacyState moves the state files written by earlier versions into
+// the state database and removes them, so that upgraded clients reattach to
+// the tasks started before the upgrade.
+func (c *Client) migrateLegacyState() error {
+	legacyDir := filepath.Join(c.config.StateDir, legacyStateDir)
+	list, err := ioutil.ReadDir(legacyDir)
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to list legacy alloc state: %v", err)
+	}
+
+	err = c.stateDB.Update(func(tx *bolt.Tx) error {
+		for _, entry := range list {
+			if !entry.IsDir() {
+				continue
+			}
+			if err := migrateLegacyAlloc(tx, filepath.Join(legacyDir, entry.Name()), entry.Name()); err != nil {
+				return fmt.Errorf("failed to migrate state of alloc %s: %v", entry.Name(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.logger.Printf("[INFO] client: migrated the state of %d allocation(s) to %s", len(list), stateDBFile)
+	return os.RemoveAll(legacyDir)
+}
+
+// migrateLegacyAlloc copies the legacy state files of the alloc runner and
+// its task runners into the bucket of the allocation.
+func migrateLegacyAlloc(tx *bolt.Tx, dir, allocID string) error {
+	buf, err := ioutil.ReadFile(filepath.Join(dir, "state.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var snap struct {
+		Alloc *structs.Allocation
+	}
+	if err := json.Unmarshal(buf, &snap); err != nil {
+		return fmt.Errorf("failed to decode state: %v", err)
+	}
+
+	alloc, err := getAllocationBucket(tx, allocID)
+	if err != nil {
+		return err
+	}
+	if err := alloc.Put(allocRunnerStateKey, buf); err != nil {
+		return err
+	}
+	if snap.Alloc == nil {
+		return nil
+	}
+
+	tasks, err := getTasksBucket(tx, allocID)
+	if err != nil {
+		return err
+	}
+	for name := range snap.Alloc.TaskStates {
+		hash := md5.Sum([]byte(name))
+		path := filepath.Join(dir, "task-"+hex.EncodeToString(hash[:]), "state.json")
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := tasks.Put([]byte(name), buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
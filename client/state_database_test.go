@@ -0,0 +1,176 @@
+package client
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestStateDatabase_Objects(t *testing.T) {
+	db := testStateDB()
+	defer db.Close()
+
+	alloc := mock.Alloc()
+	err := db.Update(func(tx *bolt.Tx) error {
+		bkt, err := getAllocationBucket(tx, alloc.ID)
+		if err != nil {
+			return err
+		}
+		if err := putObject(bkt, allocRunnerStateKey, &allocRunnerState{Alloc: alloc}); err != nil {
+			return err
+		}
+
+		tasks, err := getTasksBucket(tx, alloc.ID)
+		if err != nil {
+			return err
+		}
+		return putObject(tasks, []byte("web"), &taskRunnerState{HandleID: "handle"})
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		if ids := allocationIDs(tx); !reflect.DeepEqual(ids, []string{alloc.ID}) {
+			t.Fatalf("bad alloc ids: %v", ids)
+		}
+
+		// Buckets aren't created by read-only transactions
+		if bkt, err := getAllocationBucket(tx, "unknown"); err != nil || bkt != nil {
+			t.Fatalf("expected no bucket: %v %v", bkt, err)
+		}
+
+		bkt, err := getAllocationBucket(tx, alloc.ID)
+		if err != nil {
+			return err
+		}
+		var snap allocRunnerState
+		if err := getObject(bkt, allocRunnerStateKey, &snap); err != nil {
+			return err
+		}
+		if snap.Alloc == nil || snap.Alloc.ID != alloc.ID {
+			t.Fatalf("bad alloc runner state: %#v", snap)
+		}
+
+		tasks, err := getTasksBucket(tx, alloc.ID)
+		if err != nil {
+			return err
+		}
+		var task taskRunnerState
+		if err := getObject(tasks, []byte("web"), &task); err != nil {
+			return err
+		}
+		if task.HandleID != "handle" {
+			t.Fatalf("bad task runner state: %#v", task)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return deleteAllocationBucket(tx, alloc.ID)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	db.View(func(tx *bolt.Tx) error {
+		if ids := allocationIDs(tx); len(ids) != 0 {
+			t.Fatalf("bad alloc ids: %v", ids)
+		}
+		return nil
+	})
+}
+
+func TestClient_MigrateLegacyState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "NomadClientState")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Write the state files of earlier versions
+	alloc1, alloc2 := mock.Alloc(), mock.Alloc()
+	alloc1.TaskStates = map[string]*structs.TaskState{
+		"web":     &structs.TaskState{State: structs.TaskStateRunning},
+		"sidecar": &structs.TaskState{State: structs.TaskStatePending},
+	}
+	for _, alloc := range []*structs.Allocation{alloc1, alloc2} {
+		path := filepath.Join(dir, legacyStateDir, alloc.ID, "state.json")
+		if err := persistState(path, &allocRunnerState{Alloc: alloc}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	hash := md5.Sum([]byte("web"))
+	path := filepath.Join(dir, legacyStateDir, alloc1.ID, "task-"+hex.EncodeToString(hash[:]), "state.json")
+	if err := persistState(path, &taskRunnerState{HandleID: "handle"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, stateDBFile), 0600, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer db.Close()
+
+	c := &Client{
+		config:  &config.Config{StateDir: dir},
+		logger:  testLogger(),
+		stateDB: db,
+	}
+	if err := c.migrateLegacyState(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, legacyStateDir)); !os.IsNotExist(err) {
+		t.Fatalf("legacy state not removed: %v", err)
+	}
+
+	db.View(func(tx *bolt.Tx) error {
+		ids := allocationIDs(tx)
+		sort.Strings(ids)
+		expected := []string{alloc1.ID, alloc2.ID}
+		sort.Strings(expected)
+		if !reflect.DeepEqual(ids, expected) {
+			t.Fatalf("bad alloc ids: %v", ids)
+		}
+
+		bkt, _ := getAllocationBucket(tx, alloc1.ID)
+		var snap allocRunnerState
+		if err := getObject(bkt, allocRunnerStateKey, &snap); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if snap.Alloc == nil || len(snap.Alloc.TaskStates) != 2 {
+			t.Fatalf("bad alloc runner state: %#v", snap)
+		}
+
+		tasks, _ := getTasksBucket(tx, alloc1.ID)
+		var task taskRunnerState
+		if err := getObject(tasks, []byte("web"), &task); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if task.HandleID != "handle" {
+			t.Fatalf("bad task runner state: %#v", task)
+		}
+		if tasks.Get([]byte("sidecar")) != nil {
+			t.Fatalf("unexpected state for task without state file")
+		}
+		return nil
+	})
+
+	// Migrating again is a no-op
+	if err := c.migrateLegacyState(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// consulEnvKV is the subset of the Consul KV API used to read the environment
+// variables of a task.
+type consulEnvKV interface {
+	List(prefix string, q *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error)
+}
+
+// consulEnv returns the environment variables set by the keys under the
+// prefix. Each variable is named after its key relative to the prefix, with
+// the separators of nested keys replaced by underscores.
+func consulEnv(kv consulEnvKV, prefix string) (map[string]string, error) {
+	pairs, _, err := kv.List(prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys under %q: %v", prefix, err)
+	}
+
+	env := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		// Skip the prefix itself and folders
+		name := strings.TrimPrefix(pair.Key, prefix)
+		name = strings.Trim(name, "/")
+		if name == "" || strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+		env[strings.Replace(name, "/", "_", -1)] = string(pair.Value)
+	}
+	return env, nil
+}
+
+// setEnvFromConsul reads the task's environment variables from Consul and
+// adds them to the task environment. Variables set by the task's env take
+// precedence over the ones read from Consul.
+func (r *TaskRunner) setEnvFromConsul() error {
+	client, err := r.consulClient()
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("consul isn't configured")
+	}
+
+	env, err := consulEnv(client.KV(), r.task.EnvFromConsul.Prefix)
+	if err != nil {
+		return err
+	}
+	for k, v := range r.task.Env {
+		env[k] = v
+	}
+	r.taskEnv.SetEnvvars(env).Build()
+	return nil
+}
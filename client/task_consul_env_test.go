@@ -0,0 +1,84 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// mockConsulEnvKV is a Consul KV backed by a list of pairs
+type mockConsulEnvKV []*consulapi.KVPair
+
+func (kv mockConsulEnvKV) List(prefix string, q *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error) {
+	var pairs consulapi.KVPairs
+	for _, pair := range kv {
+		if strings.HasPrefix(pair.Key, prefix) {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil, nil
+}
+
+func TestConsulEnv(t *testing.T) {
+	kv := mockConsulEnvKV{
+		{Key: "config/web/", Value: nil},
+		{Key: "config/web/LOG_LEVEL", Value: []byte("debug")},
+		{Key: "config/web/db/", Value: nil},
+		{Key: "config/web/db/HOST", Value: []byte("db.service.consul")},
+		{Key: "config/api/LOG_LEVEL", Value: []byte("info")},
+	}
+
+	env, err := consulEnv(kv, "config/web/")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := map[string]string{
+		"LOG_LEVEL": "debug",
+		"db_HOST":   "db.service.consul",
+	}
+	if !reflect.DeepEqual(env, expected) {
+		t.Fatalf("got %v; want %v", env, expected)
+	}
+}
+
+func TestTaskRunner_EnvFromConsul_Failed(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{"run_for": "10s"}
+	task.EnvFromConsul = &structs.EnvFromConsulConfig{Prefix: "config/web/"}
+
+	upd, tr := testTaskRunnerFromAlloc(false, alloc)
+
+	// Point the task runner at an unreachable Consul
+	tr.config.ConsulConfig.Addr = "127.0.0.1:1"
+	tr.MarkReceived()
+	go tr.Run()
+	defer tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+	defer tr.ctx.AllocDir.Destroy()
+
+	select {
+	case <-tr.WaitCh():
+	case <-time.After(time.Duration(testutil.TestMultiplier()*15) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	if upd.state != structs.TaskStateDead {
+		t.Fatalf("got state %v; want %v", upd.state, structs.TaskStateDead)
+	}
+	var failed *structs.TaskEvent
+	for _, e := range upd.events {
+		if e.Type == structs.TaskEnvFromConsulFailed {
+			failed = e
+		}
+	}
+	if failed == nil || failed.EnvFromConsulError == "" {
+		t.Fatalf("no Consul environment failure in events: %#v", upd.events)
+	}
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// setDisplayMessage sets the human readable description of the task event
+// from its type and fields, unless it is already set.
+func setDisplayMessage(e *structs.TaskEvent) {
+	if e.DisplayMessage != "" {
+		return
+	}
+
+	var desc string
+	switch e.Type {
+	case structs.TaskStarted:
+		desc = "Task started by client"
+	case structs.TaskReceived:
+		desc = "Task received by client"
+	case structs.TaskFailedValidation:
+		desc = orDefault(e.ValidationError, "Validation of task failed")
+	case structs.TaskDriverFailure:
+		desc = orDefault(e.DriverError, "Failed to start task")
+	case structs.TaskDownloadingArtifacts:
+		desc = "Client is downloading artifacts"
+	case structs.TaskArtifactDownloadFailed:
+		desc = orDefault(e.DownloadError, "Failed to download artifacts")
+	case structs.TaskKilling:
+		if e.KillTimeout != 0 {
+			desc = fmt.Sprintf("Sent interrupt. Waiting %v before force killing", e.KillTimeout)
+		} else {
+			desc = "Sent interrupt"
+		}
+	case structs.TaskKilled:
+		desc = orDefault(e.KillError, "Task successfully killed")
+	case structs.TaskTerminated:
+		parts := []string{fmt.Sprintf("Exit Code: %d", e.ExitCode)}
+		if e.Signal != 0 {
+			parts = append(parts, fmt.Sprintf("Signal: %d", e.Signal))
+		}
+		if e.OOMKilled {
+			parts = append(parts, "OOM Killed")
+		}
+		if e.Message != "" {
+			parts = append(parts, fmt.Sprintf("Exit Message: %q", e.Message))
+		}
+		desc = strings.Join(parts, ", ")
+	case structs.TaskRestarting:
+		in := fmt.Sprintf("Task restarting in %v", time.Duration(e.StartDelay))
+		if e.RestartReason != "" && e.RestartReason != ReasonWithinPolicy {
+			desc = fmt.Sprintf("%s - %s", e.RestartReason, in)
+		} else {
+			desc = in
+		}
+	case structs.TaskNotRestarting:
+		desc = orDefault(e.RestartReason, "Task exceeded restart policy")
+	case structs.TaskRestartSignal:
+		desc = orDefault(e.RestartReason, "Task signaled to restart")
+	case structs.TaskSignaling:
+		switch {
+		case e.TaskSignal == "" && e.TaskSignalReason == "":
+			desc = "Task being sent a signal"
+		case e.TaskSignal == "":
+			desc = e.TaskSignalReason
+		case e.TaskSignalReason == "":
+			desc = fmt.Sprintf("Task being sent signal %v", e.TaskSignal)
+		default:
+			desc = fmt.Sprintf("Task being sent signal %v: %v", e.TaskSignal, e.TaskSignalReason)
+		}
+	case structs.TaskDiskExceeded:
+		desc = fmt.Sprintf("Disk usage of %d bytes exceeded the limit of %d bytes", e.DiskSize, e.DiskLimit)
+	case structs.TaskSiblingFailed:
+		if e.FailedSibling != "" {
+			desc = fmt.Sprintf("Task's sibling %q failed", e.FailedSibling)
+		} else {
+			desc = "Task's sibling failed"
+		}
+	case structs.TaskTemplateRenderFailed:
+		desc = orDefault(e.TemplateError, "Failed to render templates")
+	case structs.TaskEnvFromConsulFailed:
+		desc = orDefault(e.EnvFromConsulError, "Failed to read environment from Consul")
+	case structs.TaskHealthChanged:
+		if e.HealthStatus != "" {
+			desc = fmt.Sprintf("Task is %s", e.HealthStatus)
+		} else {
+			desc = "Task health changed"
+		}
+	case structs.TaskDriverMessage:
+		desc = e.DriverMessage
+	case structs.TaskOOMKilled:
+		desc = "Task was killed for exceeding its memory limit"
+	}
+	e.DisplayMessage = desc
+}
+
+// orDefault returns the value if it is set and the default otherwise.
+func orDefault(value, def string) string {
+	if value != "" {
+		return value
+	}
+	return def
+}
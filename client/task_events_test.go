@@ -0,0 +1,76 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestSetDisplayMessage(t *testing.T) {
+	cases := []struct {
+		event    *structs.TaskEvent
+		expected string
+	}{
+		{
+			event:    structs.NewTaskEvent(structs.TaskReceived),
+			expected: "Task received by client",
+		},
+		{
+			event:    structs.NewTaskEvent(structs.TaskDriverFailure).SetDriverError(fmt.Errorf("no image")),
+			expected: "no image",
+		},
+		{
+			event:    structs.NewTaskEvent(structs.TaskTerminated).SetExitCode(137).SetSignal(9).SetOOMKilled(true),
+			expected: "Exit Code: 137, Signal: 9, OOM Killed",
+		},
+		{
+			event:    structs.NewTaskEvent(structs.TaskRestarting).SetRestartDelay(5 * time.Second).SetRestartReason(ReasonWithinPolicy),
+			expected: "Task restarting in 5s",
+		},
+		{
+			event:    structs.NewTaskEvent(structs.TaskSiblingFailed).SetFailedSibling("web"),
+			expected: `Task's sibling "web" failed`,
+		},
+		{
+			event:    structs.NewTaskEvent(structs.TaskDriverMessage).SetDriverMessage("Downloading image redis:3.2"),
+			expected: "Downloading image redis:3.2",
+		},
+		{
+			event:    structs.NewTaskEvent(structs.TaskOOMKilled),
+			expected: "Task was killed for exceeding its memory limit",
+		},
+		{
+			event:    &structs.TaskEvent{Type: structs.TaskStarted, DisplayMessage: "set"},
+			expected: "set",
+		},
+	}
+
+	for _, c := range cases {
+		setDisplayMessage(c.event)
+		if c.event.DisplayMessage != c.expected {
+			t.Fatalf("%s: got %q; want %q", c.event.Type, c.event.DisplayMessage, c.expected)
+		}
+	}
+}
+
+func TestAllocRunner_DriverEvent(t *testing.T) {
+	_, ar := testAllocRunner(false)
+	ar.setTaskState("web", structs.TaskStateRunning, structs.NewTaskEvent(structs.TaskStarted))
+
+	// Informational events don't change the state of the task
+	event := structs.NewTaskEvent(structs.TaskDriverMessage).SetDriverMessage("Downloading image")
+	ar.setTaskState("web", "", event)
+
+	state := ar.taskStates["web"]
+	if state.State != structs.TaskStateRunning {
+		t.Fatalf("bad state: %v", state.State)
+	}
+	if len(state.Events) != 2 {
+		t.Fatalf("bad events: %v", state.Events)
+	}
+	if last := state.Events[1]; last.DisplayMessage != "Downloading image" {
+		t.Fatalf("bad display message: %q", last.DisplayMessage)
+	}
+}
@@ -1,21 +1,25 @@
 package client
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/boltdb/bolt"
+	consulapi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver"
 	"github.com/hashicorp/nomad/client/getter"
+	"github.com/hashicorp/nomad/helper/signals"
 	"github.com/hashicorp/nomad/nomad/structs"
 
 	"github.com/hashicorp/nomad/client/driver/env"
@@ -40,6 +44,7 @@ const (
 // TaskRunner is used to wrap a task within an allocation and provide the execution context.
 type TaskRunner struct {
 	config         *config.Config
+	stateDB        *bolt.DB
 	updater        TaskStateUpdater
 	logger         *log.Logger
 	ctx            *driver.ExecContext
@@ -64,6 +69,17 @@ type TaskRunner struct {
 	// downloaded
 	artifactsDownloaded bool
 
+	// templates renders the task's templates. It is created when they are
+	// first rendered.
+	templates *taskTemplateManager
+
+	// restarts counts the restarts of the task since the task runner was
+	// created. It is accessed atomically.
+	restarts uint64
+
+	// restartCh is used to signal that the task should be restarted
+	restartCh chan *taskRestart
+
 	destroy      bool
 	destroyCh    chan struct{}
 	destroyLock  sync.Mutex
@@ -86,7 +102,7 @@ type taskRunnerState struct {
 type TaskStateUpdater func(taskName, state string, event *structs.TaskEvent)
 
 // NewTaskRunner is used to create a new task context
-func NewTaskRunner(logger *log.Logger, config *config.Config,
+func NewTaskRunner(logger *log.Logger, config *config.Config, stateDB *bolt.DB,
 	updater TaskStateUpdater, ctx *driver.ExecContext,
 	alloc *structs.Allocation, task *structs.Task) *TaskRunner {
 
@@ -103,6 +119,7 @@ func NewTaskRunner(logger *log.Logger, config *config.Config,
 
 	tc := &TaskRunner{
 		config:         config,
+		stateDB:        stateDB,
 		updater:        updater,
 		logger:         logger,
 		restartTracker: restartTracker,
@@ -110,6 +127,7 @@ func NewTaskRunner(logger *log.Logger, config *config.Config,
 		alloc:          alloc,
 		task:           task,
 		updateCh:       make(chan *structs.Allocation, 64),
+		restartCh:      make(chan *taskRestart, 1),
 		destroyCh:      make(chan struct{}),
 		waitCh:         make(chan struct{}),
 	}
@@ -127,24 +145,18 @@ func (r *TaskRunner) WaitCh() <-chan struct{} {
 	return r.waitCh
 }
 
-// stateFilePath returns the path to our state file
-func (r *TaskRunner) stateFilePath() string {
-	// Get the MD5 of the task name
-	hashVal := md5.Sum([]byte(r.task.Name))
-	hashHex := hex.EncodeToString(hashVal[:])
-	dirName := fmt.Sprintf("task-%s", hashHex)
-
-	// Generate the path
-	path := filepath.Join(r.config.StateDir, "alloc", r.alloc.ID,
-		dirName, "state.json")
-	return path
-}
-
 // RestoreState is used to restore our state
 func (r *TaskRunner) RestoreState() error {
 	// Load the snapshot
 	var snap taskRunnerState
-	if err := restoreState(r.stateFilePath(), &snap); err != nil {
+	err := r.stateDB.View(func(tx *bolt.Tx) error {
+		bkt, err := getTasksBucket(tx, r.alloc.ID)
+		if err != nil || bkt == nil {
+			return err
+		}
+		return getObject(bkt, []byte(r.task.Name), &snap)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -202,12 +214,24 @@ func (r *TaskRunner) SaveState() error {
 		snap.HandleID = r.handle.ID()
 	}
 	r.handleLock.Unlock()
-	return persistState(r.stateFilePath(), &snap)
+	return r.stateDB.Update(func(tx *bolt.Tx) error {
+		bkt, err := getTasksBucket(tx, r.alloc.ID)
+		if err != nil {
+			return err
+		}
+		return putObject(bkt, []byte(r.task.Name), &snap)
+	})
 }
 
 // DestroyState is used to cleanup after ourselves
 func (r *TaskRunner) DestroyState() error {
-	return os.RemoveAll(r.stateFilePath())
+	return r.stateDB.Update(func(tx *bolt.Tx) error {
+		bkt, err := getTasksBucket(tx, r.alloc.ID)
+		if err != nil {
+			return err
+		}
+		return bkt.Delete([]byte(r.task.Name))
+	})
 }
 
 // setState is used to update the state of the task runner
@@ -238,7 +262,7 @@ func (r *TaskRunner) createDriver() (driver.Driver, error) {
 		return nil, fmt.Errorf("task environment not made for task %q in allocation %q", r.task.Name, r.alloc.ID)
 	}
 
-	driverCtx := driver.NewDriverContext(r.task.Name, r.config, r.config.Node, r.logger, r.taskEnv)
+	driverCtx := driver.NewDriverContext(r.task.Name, r.config, r.config.Node, r.logger, r.taskEnv, r.emitDriverEvent)
 	driver, err := driver.NewDriver(r.task.Driver, driverCtx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create driver '%s' for alloc %s: %v",
@@ -247,6 +271,13 @@ func (r *TaskRunner) createDriver() (driver.Driver, error) {
 	return driver, err
 }
 
+// emitDriverEvent records an informational message of the driver in the task
+// events, leaving the state of the task unchanged.
+func (r *TaskRunner) emitDriverEvent(message string, args ...interface{}) {
+	event := structs.NewTaskEvent(structs.TaskDriverMessage).SetDriverMessage(fmt.Sprintf(message, args...))
+	r.updater(r.task.Name, "", event)
+}
+
 // Run is a long running routine used to manage the task
 func (r *TaskRunner) Run() {
 	defer close(r.waitCh)
@@ -285,6 +316,11 @@ func (r *TaskRunner) validateTask() error {
 		}
 	}
 
+	// Validate the job is allowed to use the driver
+	if node := r.config.Node; node != nil && !node.DriverJobAllowed(r.task.Driver, r.alloc.Job) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("job %q is not allowed to use driver %q", r.alloc.Job.ID, r.task.Driver))
+	}
+
 	// Validate the artifacts
 	for i, artifact := range r.task.Artifacts {
 		// Verify the artifact doesn't escape the task directory.
@@ -297,18 +333,135 @@ func (r *TaskRunner) validateTask() error {
 		}
 	}
 
+	// Validate the signals of the templates are supported
+	for i, tmpl := range r.task.Templates {
+		if tmpl.ChangeMode != structs.TemplateChangeModeSignal {
+			continue
+		}
+		if _, err := signals.Parse(tmpl.ChangeSignal); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("template (%d) failed validation: %v", i, err))
+		}
+	}
+
 	if len(mErr.Errors) == 1 {
 		return mErr.Errors[0]
 	}
 	return mErr.ErrorOrNil()
 }
 
+// canaryTask returns the task to hand to the driver. While the allocation is
+// a canary, its services are registered with their canary tags if set, such
+// that traffic is only cut over once the deployment is promoted.
+func canaryTask(task *structs.Task, alloc *structs.Allocation) *structs.Task {
+	if !alloc.DeploymentStatus.IsCanary() {
+		return task
+	}
+
+	var canary *structs.Task
+	for i, service := range task.Services {
+		if len(service.CanaryTags) == 0 {
+			continue
+		}
+		if canary == nil {
+			canary = task.Copy()
+		}
+		canary.Services[i].Tags = canary.Services[i].CanaryTags
+	}
+	if canary == nil {
+		return task
+	}
+	return canary
+}
+
+// writePayload writes the payload of a dispatched job into the task's local
+// directory at the file configured by the task's dispatch payload.
+func (r *TaskRunner) writePayload() error {
+	taskDir, ok := r.ctx.AllocDir.TaskDirs[r.task.Name]
+	if !ok {
+		return fmt.Errorf("task directory couldn't be found")
+	}
+
+	path := filepath.Join(taskDir, allocdir.TaskLocal, r.task.DispatchPayload.File)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, r.alloc.Job.Payload, 0666)
+}
+
+// consulClient returns a Consul client for the agent's Consul configuration
+// or nil if Consul isn't configured.
+func (r *TaskRunner) consulClient() (*consulapi.Client, error) {
+	if r.config.ConsulConfig == nil {
+		return nil, nil
+	}
+	apiConf, err := r.config.ConsulConfig.ApiConfig()
+	if err != nil {
+		return nil, err
+	}
+	return consulapi.NewClient(apiConf)
+}
+
+// renderTemplates renders the task's templates into its directory. The
+// templates are watched for changes once they are first rendered.
+func (r *TaskRunner) renderTemplates() error {
+	if r.templates == nil {
+		taskDir, ok := r.ctx.AllocDir.TaskDirs[r.task.Name]
+		if !ok {
+			return fmt.Errorf("task directory couldn't be found")
+		}
+
+		var kv templateKV
+		client, err := r.consulClient()
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			kv = client.KV()
+		}
+
+		r.templates = newTaskTemplateManager(r.task.Templates, taskDir, r.taskEnv.EnvMap(), kv)
+		if _, err := r.templates.Render(); err != nil {
+			return err
+		}
+		go r.watchTemplates(r.templates)
+		return nil
+	}
+
+	_, err := r.templates.Render()
+	return err
+}
+
 func (r *TaskRunner) run() {
 	// Predeclare things so we can jump to the RESTART
 	var handleEmpty bool
 	var stopCollection chan struct{}
+	var healthCh <-chan *dstructs.HealthUpdate
 
 	for {
+		// Write the payload of a dispatched job
+		if r.task.DispatchPayload != nil {
+			if err := r.writePayload(); err != nil {
+				r.setState(structs.TaskStateDead, structs.NewTaskEvent(structs.TaskDriverFailure).SetDriverError(err))
+				r.logger.Printf("[ERR] client: failed to write payload for alloc %q task %q: %v", r.alloc.ID, r.task.Name, err)
+				r.restartTracker.SetStartError(err)
+				goto RESTART
+			}
+		}
+
+		// Read the task's environment variables from Consul each time it is
+		// started
+		r.handleLock.Lock()
+		handleEmpty = r.handle == nil
+		r.handleLock.Unlock()
+		if handleEmpty && r.task.EnvFromConsul != nil {
+			if err := r.setEnvFromConsul(); err != nil {
+				r.setState(structs.TaskStatePending,
+					structs.NewTaskEvent(structs.TaskEnvFromConsulFailed).SetEnvFromConsulError(err))
+				r.restartTracker.SetStartError(dstructs.NewRecoverableError(err, true))
+				goto RESTART
+			}
+		}
+
 		// Download the task's artifacts
 		if !r.artifactsDownloaded && len(r.task.Artifacts) > 0 {
 			r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskDownloadingArtifacts))
@@ -333,6 +486,16 @@ func (r *TaskRunner) run() {
 			r.artifactsDownloaded = true
 		}
 
+		// Render the task's templates
+		if len(r.task.Templates) > 0 {
+			if err := r.renderTemplates(); err != nil {
+				r.setState(structs.TaskStatePending,
+					structs.NewTaskEvent(structs.TaskTemplateRenderFailed).SetTemplateError(err))
+				r.restartTracker.SetStartError(dstructs.NewRecoverableError(err, true))
+				goto RESTART
+			}
+		}
+
 		// Start the task if not yet started or it is being forced. This logic
 		// is necessary because in the case of a restore the handle already
 		// exists.
@@ -360,6 +523,12 @@ func (r *TaskRunner) run() {
 			go r.collectResourceUsageStats(stopCollection)
 		}
 
+		// Watch the health of the task if reported by the driver
+		healthCh = nil
+		if h, ok := r.handle.(driver.HealthHandle); ok {
+			healthCh = h.HealthCh()
+		}
+
 		// Wait for updates
 	WAIT:
 		for {
@@ -379,6 +548,9 @@ func (r *TaskRunner) run() {
 				// Log whether the task was successful or not.
 				r.restartTracker.SetWaitResult(waitRes)
 				r.setState(structs.TaskStateDead, r.waitErrorToEvent(waitRes))
+				if waitRes.OOMKilled {
+					r.setState(structs.TaskStateDead, structs.NewTaskEvent(structs.TaskOOMKilled))
+				}
 				if !waitRes.Successful() {
 					r.logger.Printf("[INFO] client: task %q for alloc %q failed: %v", r.task.Name, r.alloc.ID, waitRes)
 				} else {
@@ -386,10 +558,46 @@ func (r *TaskRunner) run() {
 				}
 
 				break WAIT
+			case health := <-healthCh:
+				if health.RestartReason != "" {
+					r.logger.Printf("[INFO] client: restarting unhealthy task %q for alloc %q: %v",
+						r.task.Name, r.alloc.ID, health.RestartReason)
+					if err := r.RestartFailed(health.RestartReason); err != nil {
+						r.logger.Printf("[WARN] client: failed to restart task %q for alloc %q: %v", r.task.Name, r.alloc.ID, err)
+					}
+					continue
+				}
+				r.setState(structs.TaskStateRunning,
+					structs.NewTaskEvent(structs.TaskHealthChanged).SetHealthStatus(health.Status))
 			case update := <-r.updateCh:
 				if err := r.handleUpdate(update); err != nil {
 					r.logger.Printf("[ERR] client: update to task %q failed: %v", r.task.Name, err)
 				}
+			case restart := <-r.restartCh:
+				r.logger.Printf("[DEBUG] client: restarting task %q for alloc %q: %v", r.task.Name, r.alloc.ID, restart.event.RestartReason)
+				r.setState(structs.TaskStateRunning, restart.event)
+
+				// Kill the task, leaving it running if that fails.
+				if killed, err := r.handleDestroy(); !killed {
+					r.logger.Printf("[ERR] client: failed to kill task %q for restart: %v", r.task.Name, err)
+					continue
+				}
+
+				// Wait for the task to exit
+				<-r.handle.WaitCh()
+
+				r.runningLock.Lock()
+				r.running = false
+				r.runningLock.Unlock()
+
+				// Stop collection of the task's resource usage
+				close(stopCollection)
+
+				// Let the restart tracker apply the restart delay, which gives
+				// the driver time to clean up. Only restarts due to failures
+				// count against the restart policy.
+				r.restartTracker.SetRestartTriggered(restart.failure)
+				break WAIT
 			case <-r.destroyCh:
 				// Mark that we received the kill event
 				timeout := driver.GetKillTimeout(r.task.KillTimeout, r.config.MaxKillTimeout)
@@ -437,6 +645,7 @@ func (r *TaskRunner) run() {
 			return
 		case structs.TaskRestarting:
 			r.logger.Printf("[INFO] client: Restarting task %q for alloc %q in %v", r.task.Name, r.alloc.ID, when)
+			atomic.AddUint64(&r.restarts, 1)
 			r.setState(structs.TaskStatePending,
 				structs.NewTaskEvent(structs.TaskRestarting).
 					SetRestartDelay(when).
@@ -467,6 +676,11 @@ func (r *TaskRunner) run() {
 		r.handle = nil
 		stopCollection = nil
 		r.handleLock.Unlock()
+
+		// Empty the tmp dir so scratch data doesn't survive the restart.
+		if err := r.ctx.AllocDir.CleanTaskTmp(r.task.Name); err != nil {
+			r.logger.Printf("[WARN] client: failed to clean tmp dir of task %q for alloc %q: %v", r.task.Name, r.alloc.ID, err)
+		}
 	}
 }
 
@@ -480,7 +694,7 @@ func (r *TaskRunner) startTask() error {
 	}
 
 	// Start the job
-	handle, err := driver.Start(r.ctx, r.task)
+	handle, err := driver.Start(r.ctx, canaryTask(r.task, r.alloc))
 	if err != nil {
 		return fmt.Errorf("failed to start task '%s' for alloc '%s': %v",
 			r.task.Name, r.alloc.ID, err)
@@ -542,6 +756,12 @@ func (r *TaskRunner) LatestResourceUsage() *cstructs.TaskResourceUsage {
 	return r.resourceUsage
 }
 
+// Restarts returns the number of restarts of the task since the task runner
+// was created
+func (r *TaskRunner) Restarts() uint64 {
+	return atomic.LoadUint64(&r.restarts)
+}
+
 // handleUpdate takes an updated allocation and updates internal state to
 // reflect the new config for the task.
 func (r *TaskRunner) handleUpdate(update *structs.Allocation) error {
@@ -569,7 +789,7 @@ func (r *TaskRunner) handleUpdate(update *structs.Allocation) error {
 	var mErr multierror.Error
 	r.handleLock.Lock()
 	if r.handle != nil {
-		if err := r.handle.Update(updatedTask); err != nil {
+		if err := r.handle.Update(canaryTask(updatedTask, update)); err != nil {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("updating task resources failed: %v", err))
 		}
 	}
@@ -616,6 +836,7 @@ func (r *TaskRunner) waitErrorToEvent(res *dstructs.WaitResult) *structs.TaskEve
 	return structs.NewTaskEvent(structs.TaskTerminated).
 		SetExitCode(res.ExitCode).
 		SetSignal(res.Signal).
+		SetOOMKilled(res.OOMKilled).
 		SetExitMessage(res.Err)
 }
 
@@ -629,6 +850,71 @@ func (r *TaskRunner) Update(update *structs.Allocation) {
 	}
 }
 
+// Signal is used to send a signal to a running task. The reason is recorded
+// in the task's events.
+func (r *TaskRunner) Signal(reason string, s os.Signal) error {
+	r.runningLock.Lock()
+	running := r.running
+	r.runningLock.Unlock()
+	if !running {
+		return fmt.Errorf("task %q is not running", r.task.Name)
+	}
+
+	r.handleLock.Lock()
+	handle := r.handle
+	r.handleLock.Unlock()
+	if handle == nil {
+		return fmt.Errorf("task %q has no handle", r.task.Name)
+	}
+
+	r.logger.Printf("[DEBUG] client: signaling task %q for alloc %q with %v: %v", r.task.Name, r.alloc.ID, s, reason)
+	r.setState(structs.TaskStateRunning,
+		structs.NewTaskEvent(structs.TaskSignaling).SetTaskSignal(s).SetTaskSignalReason(reason))
+	return handle.Signal(s)
+}
+
+// taskRestart is a request to restart a running task.
+type taskRestart struct {
+	// event is recorded in the task's events when it is restarted
+	event *structs.TaskEvent
+
+	// failure marks that the restart counts against the restart policy
+	failure bool
+}
+
+// Restart is used to restart a running task. The reason is recorded in the
+// task's events. Restarting a task that is not running returns an error.
+func (r *TaskRunner) Restart(reason string) error {
+	return r.restart(reason, false)
+}
+
+// RestartFailed is used to restart a running task that is failing while
+// still running, such as when its checks fail. Unlike Restart, the restart
+// counts against the restart policy of the task.
+func (r *TaskRunner) RestartFailed(reason string) error {
+	return r.restart(reason, true)
+}
+
+func (r *TaskRunner) restart(reason string, failure bool) error {
+	r.runningLock.Lock()
+	running := r.running
+	r.runningLock.Unlock()
+	if !running {
+		return fmt.Errorf("task %q is not running", r.task.Name)
+	}
+
+	restart := &taskRestart{
+		event:   structs.NewTaskEvent(structs.TaskRestartSignal).SetRestartReason(reason),
+		failure: failure,
+	}
+	select {
+	case r.restartCh <- restart:
+	default:
+		// A restart is already pending
+	}
+	return nil
+}
+
 // Destroy is used to indicate that the task context should be destroyed. The
 // event parameter provides a context for the destroy.
 func (r *TaskRunner) Destroy(event *structs.TaskEvent) {
@@ -646,6 +932,11 @@ func (r *TaskRunner) Destroy(event *structs.TaskEvent) {
 // emitStats emits resource usage stats of tasks to remote metrics collector
 // sinks
 func (r *TaskRunner) emitStats(ru *cstructs.TaskResourceUsage) {
+	// The client publishes the aggregate of the tasks of the node instead
+	if r.config.AggregateAllocationMetrics {
+		return
+	}
+
 	if ru.ResourceUsage.MemoryStats != nil && r.config.PublishAllocationMetrics {
 		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "memory", "rss"}, float32(ru.ResourceUsage.MemoryStats.RSS))
 		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "memory", "cache"}, float32(ru.ResourceUsage.MemoryStats.Cache))
@@ -663,4 +954,8 @@ func (r *TaskRunner) emitStats(ru *cstructs.TaskResourceUsage) {
 		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "cpu", "throttled_periods"}, float32(ru.ResourceUsage.CpuStats.ThrottledPeriods))
 		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "cpu", "total_ticks"}, float32(ru.ResourceUsage.CpuStats.TotalTicks))
 	}
+
+	if r.config.PublishAllocationMetrics {
+		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "restarts"}, float32(r.Restarts()))
+	}
 }
@@ -2,11 +2,14 @@ package client
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -59,7 +62,7 @@ func testTaskRunnerFromAlloc(restarts bool, alloc *structs.Allocation) (*MockTas
 	allocDir.Build([]*structs.Task{task})
 
 	ctx := driver.NewExecContext(allocDir, alloc.ID)
-	tr := NewTaskRunner(logger, conf, upd.Update, ctx, alloc, task)
+	tr := NewTaskRunner(logger, conf, testStateDB(), upd.Update, ctx, alloc, task)
 	if !restarts {
 		tr.restartTracker = noRestartsTracker()
 	}
@@ -163,6 +166,104 @@ func TestTaskRunner_Destroy(t *testing.T) {
 	}
 }
 
+func TestTaskRunner_Restart(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	upd, tr := testTaskRunner(true)
+	tr.MarkReceived()
+	defer tr.ctx.AllocDir.Destroy()
+
+	// Change command to ensure we run for a bit
+	tr.task.Config["command"] = "/bin/sleep"
+	tr.task.Config["args"] = []string{"1000"}
+	go tr.Run()
+	defer tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+
+	testutil.WaitForResult(func() (bool, error) {
+		if l := len(upd.events); l != 2 {
+			return false, fmt.Errorf("Expect two events; got %v", l)
+		}
+		if upd.events[1].Type != structs.TaskStarted {
+			return false, fmt.Errorf("Second Event was %v; want %v", upd.events[1].Type, structs.TaskStarted)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Shorten the restart delay
+	tr.restartTracker.policy.Delay = 1 * time.Second
+
+	if err := tr.Restart("test"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		if l := len(upd.events); l != 5 {
+			return false, fmt.Errorf("Expect five events; got %v", l)
+		}
+		if upd.events[2].Type != structs.TaskRestartSignal {
+			return false, fmt.Errorf("Third Event was %v; want %v", upd.events[2].Type, structs.TaskRestartSignal)
+		}
+		if upd.events[3].Type != structs.TaskRestarting {
+			return false, fmt.Errorf("Fourth Event was %v; want %v", upd.events[3].Type, structs.TaskRestarting)
+		}
+		if upd.events[4].Type != structs.TaskStarted {
+			return false, fmt.Errorf("Fifth Event was %v; want %v", upd.events[4].Type, structs.TaskStarted)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	if n := tr.Restarts(); n != 1 {
+		t.Fatalf("Restarts %d; want 1", n)
+	}
+
+	if upd.state != structs.TaskStateRunning {
+		t.Fatalf("TaskState %v; want %v", upd.state, structs.TaskStateRunning)
+	}
+
+	// The restart shouldn't count against the restart policy
+	if tr.restartTracker.count != 0 {
+		t.Fatalf("restart counted against policy: %d", tr.restartTracker.count)
+	}
+}
+
+func TestTaskRunner_HealthRestart(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"run_for":         "10s",
+		"unhealthy_after": "100ms",
+	}
+
+	upd, tr := testTaskRunnerFromAlloc(false, alloc)
+	tr.MarkReceived()
+	go tr.Run()
+	defer tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+	defer tr.ctx.AllocDir.Destroy()
+
+	// The driver's health is recorded and the unhealthy task restarted
+	testutil.WaitForResult(func() (bool, error) {
+		var health, restart bool
+		for _, e := range upd.events {
+			switch e.Type {
+			case structs.TaskHealthChanged:
+				health = e.HealthStatus == "unhealthy"
+			case structs.TaskRestartSignal:
+				restart = e.RestartReason == "mock task unhealthy"
+			}
+		}
+		if !health || !restart {
+			return false, fmt.Errorf("missing health or restart events: %#v", upd.events)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
 func TestTaskRunner_Update(t *testing.T) {
 	ctestutil.ExecCompatible(t)
 	_, tr := testTaskRunner(false)
@@ -234,7 +335,7 @@ func TestTaskRunner_SaveRestoreState(t *testing.T) {
 	}
 
 	// Create a new task runner
-	tr2 := NewTaskRunner(tr.logger, tr.config, upd.Update,
+	tr2 := NewTaskRunner(tr.logger, tr.config, tr.stateDB, upd.Update,
 		tr.ctx, tr.alloc, &structs.Task{Name: tr.task.Name})
 	if err := tr2.RestoreState(); err != nil {
 		t.Fatalf("err: %v", err)
@@ -409,3 +510,77 @@ func TestTaskRunner_Validate_UserEnforcement(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestTaskRunner_Validate_DriverJobLists(t *testing.T) {
+	_, tr := testTaskRunner(false)
+	defer tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+	defer tr.ctx.AllocDir.Destroy()
+
+	// The job isn't whitelisted for the driver
+	tr.config.Node = mock.Node()
+	tr.task.Driver = "mock_driver"
+	tr.config.Node.Attributes["driver.mock_driver.job_whitelist"] = "infra"
+	if err := tr.validateTask(); err == nil || !strings.Contains(err.Error(), "not allowed to use driver") {
+		t.Fatalf("expected driver error: %v", err)
+	}
+
+	// The job is whitelisted for the driver
+	tr.config.Node.Attributes["driver.mock_driver.job_whitelist"] = "infra," + tr.alloc.Job.ID
+	if err := tr.validateTask(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The job is blacklisted for the driver
+	tr.config.Node.Attributes["driver.mock_driver.job_blacklist"] = tr.alloc.Job.ID
+	if err := tr.validateTask(); err == nil {
+		t.Fatalf("expected driver error")
+	}
+}
+
+func TestTaskRunner_CanaryTask(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Services[0].CanaryTags = []string{"canary"}
+
+	// Allocations that aren't canaries register their tags
+	if out := canaryTask(task, alloc); out != task {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	out := canaryTask(task, alloc)
+	if !reflect.DeepEqual(out.Services[0].Tags, []string{"canary"}) {
+		t.Fatalf("bad: %#v", out.Services[0])
+	}
+	if reflect.DeepEqual(task.Services[0].Tags, []string{"canary"}) {
+		t.Fatalf("task modified: %#v", task.Services[0])
+	}
+	if !reflect.DeepEqual(out.Services[1].Tags, task.Services[1].Tags) {
+		t.Fatalf("bad: %#v", out.Services[1])
+	}
+}
+
+func TestTaskRunner_WritePayload(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.DispatchPayload = &structs.DispatchPayloadConfig{
+		File: "input/payload.json",
+	}
+	alloc.Job.Payload = []byte("{\"foo\": \"bar\"}")
+
+	_, tr := testTaskRunnerFromAlloc(false, alloc)
+	defer tr.ctx.AllocDir.Destroy()
+
+	if err := tr.writePayload(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	taskDir := tr.ctx.AllocDir.TaskDirs[task.Name]
+	data, err := ioutil.ReadFile(filepath.Join(taskDir, allocdir.TaskLocal, "input", "payload.json"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(data, alloc.Job.Payload) {
+		t.Fatalf("bad payload: %q", data)
+	}
+}
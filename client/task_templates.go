@@ -0,0 +1,255 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/helper/signals"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// templateWatchInterval is the interval at which the templates of a
+	// running task are re-rendered to detect changes of their data.
+	templateWatchInterval = 5 * time.Second
+)
+
+// templateKV is the subset of the Consul KV API used to render templates.
+type templateKV interface {
+	Get(key string, q *consulapi.QueryOptions) (*consulapi.KVPair, *consulapi.QueryMeta, error)
+}
+
+// taskTemplateManager renders the templates of a task into its directory and
+// tracks their rendered contents to detect changes.
+type taskTemplateManager struct {
+	templates []*structs.Template
+	taskDir   string
+	env       map[string]string
+	kv        templateKV
+
+	// rendered is the last rendered content of each template by destination
+	rendered map[string]string
+	lock     sync.Mutex
+}
+
+// newTaskTemplateManager returns a manager rendering the templates into the
+// task directory, reading keys from the given Consul KV and environment
+// variables from env.
+func newTaskTemplateManager(templates []*structs.Template, taskDir string,
+	env map[string]string, kv templateKV) *taskTemplateManager {
+	return &taskTemplateManager{
+		templates: templates,
+		taskDir:   taskDir,
+		env:       env,
+		kv:        kv,
+		rendered:  make(map[string]string, len(templates)),
+	}
+}
+
+// Render renders all templates and writes those whose content changed. It
+// returns the templates that changed since they were previously rendered.
+func (m *taskTemplateManager) Render() ([]*structs.Template, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Render all templates before writing any of them such that the task
+	// never sees a partial update
+	contents := make([]string, len(m.templates))
+	for i, tmpl := range m.templates {
+		content, err := m.render(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template %q: %v", tmpl.DestPath, err)
+		}
+		contents[i] = content
+	}
+
+	var changed []*structs.Template
+	for i, tmpl := range m.templates {
+		prev, ok := m.rendered[tmpl.DestPath]
+		if ok && prev == contents[i] {
+			continue
+		}
+
+		dest := filepath.Join(m.taskDir, tmpl.DestPath)
+		if err := os.MkdirAll(filepath.Dir(dest), 0777); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(dest, []byte(contents[i]), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write template %q: %v", tmpl.DestPath, err)
+		}
+		m.rendered[tmpl.DestPath] = contents[i]
+		if ok {
+			changed = append(changed, tmpl)
+		}
+	}
+	return changed, nil
+}
+
+// render returns the rendered content of the template.
+func (m *taskTemplateManager) render(tmpl *structs.Template) (string, error) {
+	text := tmpl.EmbeddedTmpl
+	if tmpl.SourcePath != "" {
+		b, err := ioutil.ReadFile(filepath.Join(m.taskDir, tmpl.SourcePath))
+		if err != nil {
+			return "", err
+		}
+		text = string(b)
+	}
+
+	t, err := template.New(tmpl.DestPath).Funcs(m.funcs()).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, nil); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// funcs returns the functions available to the templates.
+func (m *taskTemplateManager) funcs() template.FuncMap {
+	return template.FuncMap{
+		"env": func(name string) string {
+			return m.env[name]
+		},
+		"key": func(key string) (string, error) {
+			value, ok, err := m.key(key)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				return "", fmt.Errorf("key %q not found", key)
+			}
+			return value, nil
+		},
+		"keyOrDefault": func(key, def string) (string, error) {
+			value, ok, err := m.key(key)
+			if err != nil || !ok {
+				return def, err
+			}
+			return value, nil
+		},
+	}
+}
+
+// key returns the value of the Consul key and whether it exists.
+func (m *taskTemplateManager) key(key string) (string, bool, error) {
+	if m.kv == nil {
+		return "", false, fmt.Errorf("consul not available to read key %q", key)
+	}
+	pair, _, err := m.kv.Get(key, nil)
+	if err != nil {
+		return "", false, err
+	}
+	if pair == nil {
+		return "", false, nil
+	}
+	return string(pair.Value), true, nil
+}
+
+// templateChanges returns how the task has to react to the changed templates:
+// whether it has to be restarted, otherwise the signals it has to be sent,
+// and the longest splay of the templates.
+func templateChanges(changed []*structs.Template) (bool, []string, time.Duration) {
+	restart := false
+	var sigs []string
+	seen := make(map[string]struct{})
+	var splay time.Duration
+	for _, tmpl := range changed {
+		if tmpl.Splay > splay {
+			splay = tmpl.Splay
+		}
+
+		switch tmpl.ChangeMode {
+		case structs.TemplateChangeModeRestart:
+			restart = true
+		case structs.TemplateChangeModeSignal:
+			if _, ok := seen[tmpl.ChangeSignal]; !ok {
+				seen[tmpl.ChangeSignal] = struct{}{}
+				sigs = append(sigs, tmpl.ChangeSignal)
+			}
+		}
+	}
+	if restart {
+		sigs = nil
+	}
+	return restart, sigs, splay
+}
+
+// watchTemplates re-renders the templates of the task while it is running and
+// restarts or signals the task according to the change mode of the templates
+// that changed.
+func (r *TaskRunner) watchTemplates(m *taskTemplateManager) {
+	ticker := time.NewTicker(templateWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.waitCh:
+			return
+		case <-r.destroyCh:
+			return
+		}
+
+		// Templates are rendered before a stopped task is started again
+		r.runningLock.Lock()
+		running := r.running
+		r.runningLock.Unlock()
+		if !running {
+			continue
+		}
+
+		changed, err := m.Render()
+		if err != nil {
+			r.logger.Printf("[WARN] client: failed to re-render templates of task %q for alloc %q: %v",
+				r.task.Name, r.alloc.ID, err)
+			continue
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		restart, sigs, splay := templateChanges(changed)
+		if !restart && len(sigs) == 0 {
+			continue
+		}
+
+		// Spread the reactions of the tasks rendering the same data
+		if splay > 0 {
+			select {
+			case <-time.After(time.Duration(rand.Int63n(int64(splay)))):
+			case <-r.destroyCh:
+				return
+			}
+		}
+
+		if restart {
+			if err := r.Restart("template with change_mode restart re-rendered"); err != nil {
+				r.logger.Printf("[WARN] client: failed to restart task %q for alloc %q: %v",
+					r.task.Name, r.alloc.ID, err)
+			}
+			continue
+		}
+		for _, name := range sigs {
+			sig, err := signals.Parse(name)
+			if err != nil {
+				r.logger.Printf("[ERR] client: task %q for alloc %q: %v", r.task.Name, r.alloc.ID, err)
+				continue
+			}
+			if err := r.Signal("template re-rendered", sig); err != nil {
+				r.logger.Printf("[WARN] client: failed to signal task %q for alloc %q: %v",
+					r.task.Name, r.alloc.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// mockTemplateKV is a Consul KV backed by a map
+type mockTemplateKV map[string]string
+
+func (kv mockTemplateKV) Get(key string, q *consulapi.QueryOptions) (*consulapi.KVPair, *consulapi.QueryMeta, error) {
+	value, ok := kv[key]
+	if !ok {
+		return nil, nil, nil
+	}
+	return &consulapi.KVPair{Key: key, Value: []byte(value)}, nil, nil
+}
+
+func TestTaskTemplateManager_Render(t *testing.T) {
+	taskDir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(taskDir)
+
+	// A template read from the task directory
+	if err := ioutil.WriteFile(filepath.Join(taskDir, "source.tmpl"), []byte(`port={{ key "port" }}`), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	embedded := &structs.Template{
+		EmbeddedTmpl: `host={{ key "host" }} region={{ keyOrDefault "region" "global" }} task={{ env "NOMAD_TASK_NAME" }}`,
+		DestPath:     "local/embedded.conf",
+		ChangeMode:   structs.TemplateChangeModeRestart,
+	}
+	source := &structs.Template{
+		SourcePath: "source.tmpl",
+		DestPath:   "local/source.conf",
+		ChangeMode: structs.TemplateChangeModeNoop,
+	}
+	kv := mockTemplateKV{"host": "foo", "port": "8080"}
+	env := map[string]string{"NOMAD_TASK_NAME": "web"}
+	m := newTaskTemplateManager([]*structs.Template{embedded, source}, taskDir, env, kv)
+
+	read := func(dest string) string {
+		data, err := ioutil.ReadFile(filepath.Join(taskDir, dest))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return string(data)
+	}
+
+	// The first render writes all templates without reporting changes
+	changed, err := m.Render()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("got changes on first render: %v", changed)
+	}
+	if got, want := read(embedded.DestPath), "host=foo region=global task=web"; got != want {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+	if got, want := read(source.DestPath), "port=8080"; got != want {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+
+	// Unchanged data doesn't change the templates
+	if changed, err = m.Render(); err != nil || len(changed) != 0 {
+		t.Fatalf("got changes %v, err %v", changed, err)
+	}
+
+	// Only the template whose data changed is reported
+	kv["region"] = "east"
+	changed, err = m.Render()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(changed, []*structs.Template{embedded}) {
+		t.Fatalf("got changes %v; want %v", changed, embedded)
+	}
+	if got, want := read(embedded.DestPath), "host=foo region=east task=web"; got != want {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+
+	// A missing key fails rendering
+	delete(kv, "port")
+	if _, err := m.Render(); err == nil {
+		t.Fatalf("expected an error rendering a missing key")
+	}
+}
+
+func TestTemplateChanges(t *testing.T) {
+	signal := func(sig string, splay time.Duration) *structs.Template {
+		return &structs.Template{ChangeMode: structs.TemplateChangeModeSignal, ChangeSignal: sig, Splay: splay}
+	}
+	restart := &structs.Template{ChangeMode: structs.TemplateChangeModeRestart, Splay: time.Second}
+	noop := &structs.Template{ChangeMode: structs.TemplateChangeModeNoop, Splay: time.Minute}
+
+	cases := []struct {
+		changed []*structs.Template
+		restart bool
+		sigs    []string
+		splay   time.Duration
+	}{
+		{
+			changed: []*structs.Template{noop},
+			splay:   time.Minute,
+		},
+		{
+			changed: []*structs.Template{signal("SIGHUP", 0), signal("SIGUSR1", 2*time.Second), signal("SIGHUP", 0)},
+			sigs:    []string{"SIGHUP", "SIGUSR1"},
+			splay:   2 * time.Second,
+		},
+		{
+			changed: []*structs.Template{signal("SIGHUP", 0), restart},
+			restart: true,
+			splay:   time.Second,
+		},
+	}
+
+	for i, c := range cases {
+		restart, sigs, splay := templateChanges(c.changed)
+		if restart != c.restart || !reflect.DeepEqual(sigs, c.sigs) || splay != c.splay {
+			t.Fatalf("case %d: got %v %v %v; want %v %v %v", i, restart, sigs, splay, c.restart, c.sigs, c.splay)
+		}
+	}
+}
+
+func TestTaskRunner_Template_Render(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{"run_for": "10s"}
+	task.Templates = []*structs.Template{
+		{
+			EmbeddedTmpl: `task={{ env "NOMAD_TASK_NAME" }}`,
+			DestPath:     "local/task.conf",
+			ChangeMode:   structs.TemplateChangeModeRestart,
+		},
+	}
+
+	upd, tr := testTaskRunnerFromAlloc(false, alloc)
+	tr.MarkReceived()
+	go tr.Run()
+	defer tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+	defer tr.ctx.AllocDir.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.state != structs.TaskStateRunning {
+			return false, fmt.Errorf("task not running: %v", upd.state)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The template was rendered before the task was started
+	taskDir := tr.ctx.AllocDir.TaskDirs[task.Name]
+	data, err := ioutil.ReadFile(filepath.Join(taskDir, "local", "task.conf"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got, want := string(data), "task="+task.Name; got != want {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+}
+
+func TestTaskRunner_Template_RenderFailed(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{"run_for": "10s"}
+	task.Templates = []*structs.Template{
+		{
+			EmbeddedTmpl: `{{ invalid }}`,
+			DestPath:     "local/task.conf",
+			ChangeMode:   structs.TemplateChangeModeRestart,
+		},
+	}
+
+	upd, tr := testTaskRunnerFromAlloc(false, alloc)
+	tr.MarkReceived()
+	go tr.Run()
+	defer tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+	defer tr.ctx.AllocDir.Destroy()
+
+	select {
+	case <-tr.WaitCh():
+	case <-time.After(time.Duration(testutil.TestMultiplier()*15) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	if upd.state != structs.TaskStateDead {
+		t.Fatalf("got state %v; want %v", upd.state, structs.TaskStateDead)
+	}
+	var failed *structs.TaskEvent
+	for _, e := range upd.events {
+		if e.Type == structs.TaskTemplateRenderFailed {
+			failed = e
+		}
+	}
+	if failed == nil || failed.TemplateError == "" {
+		t.Fatalf("no template rendering failure in events: %#v", upd.events)
+	}
+}
+
+func TestTaskRunner_Validate_TemplateSignal(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Templates = []*structs.Template{
+		{
+			EmbeddedTmpl: "foo",
+			DestPath:     "local/foo",
+			ChangeMode:   structs.TemplateChangeModeSignal,
+			ChangeSignal: "SIGFOO",
+		},
+	}
+
+	_, tr := testTaskRunnerFromAlloc(false, alloc)
+	defer tr.ctx.AllocDir.Destroy()
+
+	if err := tr.validateTask(); err == nil {
+		t.Fatalf("expected an error for an unknown signal")
+	}
+}
@@ -13,11 +13,14 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/nomad/client"
 	clientconfig "github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/helper/autojoin"
 	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
 )
 
 const (
@@ -29,6 +32,10 @@ const (
 	serverRpcCheckTimeout   = 3 * time.Second
 	serverSerfCheckInterval = 10 * time.Second
 	serverSerfCheckTimeout  = 3 * time.Second
+
+	// clientDiscoverInterval is the interval at which a client retries to
+	// discover its servers with cloud auto-discovery.
+	clientDiscoverInterval = 30 * time.Second
 )
 
 // Agent is a long running daemon that is used to run both
@@ -52,6 +59,15 @@ type Agent struct {
 	serverRPCAddr  string
 	serverSerfAddr string
 
+	// inmemSink and logWriter retain the recent metrics and logs of the
+	// agent for the debugging endpoints
+	inmemSink *metrics.InmemSink
+	logWriter *logWriter
+
+	// reloadCh is used to request the agent command to reload the
+	// configuration. It is nil when the agent is not run by the command.
+	reloadCh chan chan reloadReply
+
 	shutdown     bool
 	shutdownCh   chan struct{}
 	shutdownLock sync.Mutex
@@ -132,6 +148,12 @@ func (a *Agent) serverConfig() (*nomad.Config, error) {
 	if len(a.config.Server.EnabledSchedulers) != 0 {
 		conf.EnabledSchedulers = a.config.Server.EnabledSchedulers
 	}
+	if a.config.Server.Preemption != nil {
+		conf.PreemptionConfig = *a.config.Server.Preemption
+	}
+	if a.config.Server.Autopilot != nil {
+		conf.AutopilotConfig = *a.config.Server.Autopilot
+	}
 
 	// Set up the advertise addrs
 	if addr := a.config.AdvertiseAddrs.Serf; addr != "" {
@@ -174,8 +196,8 @@ func (a *Agent) serverConfig() (*nomad.Config, error) {
 	// Resolve the Server's HTTP Address
 	if a.config.AdvertiseAddrs.HTTP != "" {
 		a.serverHTTPAddr = a.config.AdvertiseAddrs.HTTP
-	} else if a.config.Addresses.HTTP != "" {
-		a.serverHTTPAddr = net.JoinHostPort(a.config.Addresses.HTTP, strconv.Itoa(a.config.Ports.HTTP))
+	} else if addr := a.config.httpBindAddr(); addr != "" {
+		a.serverHTTPAddr = net.JoinHostPort(addr, strconv.Itoa(a.config.Ports.HTTP))
 	} else if a.config.BindAddr != "" {
 		a.serverHTTPAddr = net.JoinHostPort(a.config.BindAddr, strconv.Itoa(a.config.Ports.HTTP))
 	} else {
@@ -239,9 +261,15 @@ func (a *Agent) serverConfig() (*nomad.Config, error) {
 		return nil, fmt.Errorf("server_service_name must be set when auto_advertise is enabled")
 	}
 
-	// Add the Consul and Vault configs
+	// Set up the gossip encryption
+	if err := a.setupKeyring(conf); err != nil {
+		return nil, err
+	}
+
+	// Add the Consul, Vault and TLS configs
 	conf.ConsulConfig = a.config.Consul
 	conf.VaultConfig = a.config.Vault
+	conf.TLSConfig = a.config.TLSConfig
 
 	return conf, nil
 }
@@ -272,11 +300,24 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	if a.config.Client.AllocDir != "" {
 		conf.AllocDir = a.config.Client.AllocDir
 	}
-	conf.Servers = a.config.Client.Servers
+	conf.AllocDirs = a.config.Client.AllocDirs
+	conf.AllocDirPolicy = a.config.Client.AllocDirPolicy
+	for _, server := range a.config.Client.Servers {
+		if !autojoin.IsConfig(server) {
+			conf.Servers = append(conf.Servers, server)
+		}
+	}
 	if a.config.Client.NetworkInterface != "" {
 		conf.NetworkInterface = a.config.Client.NetworkInterface
 	}
+	conf.NetworkCIDR = a.config.Client.NetworkCIDR
 	conf.ChrootEnv = a.config.Client.ChrootEnv
+	if len(a.config.Client.HostVolumes) != 0 {
+		conf.HostVolumes = make(map[string]*structs.ClientHostVolumeConfig, len(a.config.Client.HostVolumes))
+		for _, volume := range a.config.Client.HostVolumes {
+			conf.HostVolumes[volume.Name] = volume
+		}
+	}
 	conf.Options = a.config.Client.Options
 	// Logging deprecation messages about consul related configuration in client
 	// options
@@ -303,6 +344,17 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 		}
 		conf.MaxKillTimeout = dur
 	}
+	conf.TmpDirSize = a.config.Client.TmpDirSize
+	if a.config.Client.GCInterval != "" {
+		dur, err := time.ParseDuration(a.config.Client.GCInterval)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing GC interval: %s", err)
+		}
+		conf.GCInterval = dur
+	}
+	if a.config.Client.GCDiskUsageThreshold != 0 {
+		conf.GCDiskUsageThreshold = a.config.Client.GCDiskUsageThreshold
+	}
 	conf.ClientMaxPort = uint(a.config.Client.ClientMaxPort)
 	conf.ClientMinPort = uint(a.config.Client.ClientMinPort)
 
@@ -312,12 +364,14 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	conf.Node.Name = a.config.NodeName
 	conf.Node.Meta = a.config.Client.Meta
 	conf.Node.NodeClass = a.config.Client.NodeClass
+	conf.Node.MinDynamicPort = a.config.Client.MinDynamicPort
+	conf.Node.MaxDynamicPort = a.config.Client.MaxDynamicPort
 
 	// Resolve the Client's HTTP address
 	if a.config.AdvertiseAddrs.HTTP != "" {
 		a.clientHTTPAddr = a.config.AdvertiseAddrs.HTTP
-	} else if a.config.Addresses.HTTP != "" {
-		a.clientHTTPAddr = net.JoinHostPort(a.config.Addresses.HTTP, strconv.Itoa(a.config.Ports.HTTP))
+	} else if addr := a.config.httpBindAddr(); addr != "" {
+		a.clientHTTPAddr = net.JoinHostPort(addr, strconv.Itoa(a.config.Ports.HTTP))
 	} else if a.config.BindAddr != "" {
 		a.clientHTTPAddr = net.JoinHostPort(a.config.BindAddr, strconv.Itoa(a.config.Ports.HTTP))
 	} else {
@@ -332,6 +386,15 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	conf.Node.HTTPAddr = httpAddr
 	a.clientHTTPAddr = httpAddr
 
+	// Resolve the address advertised for streaming file system traffic
+	if a.config.AdvertiseAddrs.Stream != "" {
+		addr, err := net.ResolveTCPAddr("tcp", a.config.AdvertiseAddrs.Stream)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving stream advertise address %+q: %v", a.config.AdvertiseAddrs.Stream, err)
+		}
+		conf.Node.StreamAddr = net.JoinHostPort(addr.IP.String(), strconv.Itoa(addr.Port))
+	}
+
 	// Reserve resources on the node.
 	r := conf.Node.Reserved
 	if r == nil {
@@ -353,9 +416,11 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 
 	conf.ConsulConfig = a.config.Consul
 	conf.VaultConfig = a.config.Vault
+	conf.TLSConfig = a.config.TLSConfig
 	conf.StatsCollectionInterval = a.config.Telemetry.collectionInterval
 	conf.PublishNodeMetrics = a.config.Telemetry.PublishNodeMetrics
 	conf.PublishAllocationMetrics = a.config.Telemetry.PublishAllocationMetrics
+	conf.AggregateAllocationMetrics = a.config.Telemetry.AggregateAllocationMetrics
 	return conf, nil
 }
 
@@ -389,7 +454,7 @@ func (a *Agent) setupServer() error {
 					Name:     "Nomad Server HTTP Check",
 					Type:     "http",
 					Path:     "/v1/status/peers",
-					Protocol: "http", // TODO TLS
+					Protocol: a.httpProtocol(),
 					Interval: serverHttpCheckInterval,
 					Timeout:  serverHttpCheckTimeout,
 				},
@@ -457,6 +522,17 @@ func (a *Agent) setupClient() error {
 	}
 	a.client = client
 
+	// Discover the servers configured with cloud auto-discovery
+	var discover []string
+	for _, server := range a.config.Client.Servers {
+		if autojoin.IsConfig(server) {
+			discover = append(discover, server)
+		}
+	}
+	if len(discover) != 0 {
+		go a.discoverServers(discover)
+	}
+
 	// Create the Nomad Client  services for Consul
 	if a.config.Consul.AutoAdvertise {
 		httpServ := &structs.Service{
@@ -468,7 +544,7 @@ func (a *Agent) setupClient() error {
 					Name:     "Nomad Client HTTP Check",
 					Type:     "http",
 					Path:     "/v1/agent/servers",
-					Protocol: "http", // TODO TLS
+					Protocol: a.httpProtocol(),
 					Interval: clientHttpCheckInterval,
 					Timeout:  clientHttpCheckTimeout,
 				},
@@ -482,6 +558,29 @@ func (a *Agent) setupClient() error {
 	return nil
 }
 
+// discoverServers adds the servers found with the cloud auto-discovery
+// configurations to the servers of the client, retrying until some are found.
+// The client then learns the other servers through its heartbeats.
+func (a *Agent) discoverServers(configs []string) {
+	for {
+		addrs := autojoin.Resolve(configs, a.logger)
+		for _, addr := range addrs {
+			a.client.AddPrimaryServerToRPCProxy(addr)
+		}
+		if len(addrs) != 0 {
+			a.logger.Printf("[INFO] agent: discovered %d servers", len(addrs))
+			return
+		}
+
+		a.logger.Printf("[WARN] agent: no servers discovered, retrying in %v", clientDiscoverInterval)
+		select {
+		case <-time.After(clientDiscoverInterval):
+		case <-a.shutdownCh:
+			return
+		}
+	}
+}
+
 // reservePortsForClient reserves a range of ports for the client to use when
 // it creates various plugins for log collection, executors, drivers, etc
 func (a *Agent) reservePortsForClient(conf *clientconfig.Config) error {
@@ -618,6 +717,43 @@ func (a *Agent) Server() *nomad.Server {
 	return a.server
 }
 
+// httpProtocol returns the protocol of the HTTP API of the agent.
+func (a *Agent) httpProtocol() string {
+	if a.config.TLSConfig != nil && a.config.TLSConfig.EnableHTTP {
+		return "https"
+	}
+	return "http"
+}
+
+// ReloadTLS reloads the certificate and key presented by the agent from the
+// files of the given TLS configuration. Enabling or disabling TLS and
+// changing the certificate authority require a restart of the agent.
+func (a *Agent) ReloadTLS(newConfig *config.TLSConfig) error {
+	current := a.config.TLSConfig
+	if current == nil || newConfig == nil {
+		return nil
+	}
+
+	if current.EnableHTTP != newConfig.EnableHTTP || current.EnableRPC != newConfig.EnableRPC ||
+		current.CAFile != newConfig.CAFile {
+		a.logger.Printf("[WARN] agent: enabling or disabling TLS and changing the CA file require a restart")
+	}
+	if !current.EnableHTTP && !current.EnableRPC {
+		return nil
+	}
+	if newConfig.CertFile == "" || newConfig.KeyFile == "" {
+		return fmt.Errorf("cert_file and key_file must be set to reload the TLS certificate")
+	}
+
+	if _, err := current.GetKeyLoader().LoadKeyPair(newConfig.CertFile, newConfig.KeyFile); err != nil {
+		return err
+	}
+	current.CertFile = newConfig.CertFile
+	current.KeyFile = newConfig.KeyFile
+	a.logger.Printf("[INFO] agent: reloaded TLS certificate %q", newConfig.CertFile)
+	return nil
+}
+
 // Stats is used to return statistics for debugging and insight
 // for various sub-systems
 func (a *Agent) Stats() map[string]map[string]string {
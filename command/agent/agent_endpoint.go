@@ -1,9 +1,17 @@
 package agent
 
 import (
+	"bytes"
+	"fmt"
 	"net"
 	"net/http"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/serf/serf"
 )
 
@@ -119,6 +127,16 @@ func (s *HTTPServer) AgentForceLeaveRequest(resp http.ResponseWriter, req *http.
 	return nil, err
 }
 
+// AgentReloadRequest is used to reload the configuration of the agent, as done
+// when it receives a SIGHUP. It reports the changed settings that were
+// reloaded and those requiring a restart of the agent.
+func (s *HTTPServer) AgentReloadRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	return s.agent.Reload()
+}
+
 // AgentServersRequest is used to query the list of servers used by the Nomad
 // Client for RPCs.  This endpoint can also be used to update the list of
 // servers for a given agent.
@@ -166,6 +184,141 @@ func (s *HTTPServer) updateServers(resp http.ResponseWriter, req *http.Request)
 	return nil, nil
 }
 
+// AgentMetricsRequest is used to query the metrics retained in memory by the
+// agent over the last intervals.
+func (s *HTTPServer) AgentMetricsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	sink := s.agent.inmemSink
+	if sink == nil {
+		return nil, CodedError(501, "agent does not retain metrics")
+	}
+
+	data := sink.Data()
+	out := make([]*metricsInterval, len(data))
+	for i, intv := range data {
+		out[i] = newMetricsInterval(intv)
+	}
+	return out, nil
+}
+
+// AgentLogsRequest is used to query the most recent logs of the agent.
+func (s *HTTPServer) AgentLogsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	if s.agent.logWriter == nil {
+		return nil, CodedError(501, "agent does not retain logs")
+	}
+	return s.agent.logWriter.Logs(), nil
+}
+
+// AgentPprofRequest is used to capture a runtime profile of the agent. The
+// "cpu" profile and the execution "trace" are collected over the requested
+// number of seconds, while the other profiles are a snapshot, such as the
+// "goroutine" dump when debug=2 is set. Profiling requires enable_debug.
+func (s *HTTPServer) AgentPprofRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	if !s.agent.config.EnableDebug {
+		return nil, CodedError(403, "profiling requires enable_debug on the agent")
+	}
+
+	profile := strings.TrimPrefix(req.URL.Path, "/v1/agent/pprof/")
+	if profile == "" {
+		return nil, CodedError(400, "missing profile")
+	}
+
+	query := req.URL.Query()
+	seconds, debug := 1, 0
+	if v := query.Get("seconds"); v != "" {
+		var err error
+		if seconds, err = strconv.Atoi(v); err != nil || seconds <= 0 {
+			return nil, CodedError(400, fmt.Sprintf("invalid seconds %q", v))
+		}
+	}
+	if v := query.Get("debug"); v != "" {
+		var err error
+		if debug, err = strconv.Atoi(v); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("invalid debug %q", v))
+		}
+	}
+
+	var buf bytes.Buffer
+	switch profile {
+	case "cpu":
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return nil, err
+		}
+		s.waitProfile(seconds)
+		pprof.StopCPUProfile()
+	case "trace":
+		if err := trace.Start(&buf); err != nil {
+			return nil, err
+		}
+		s.waitProfile(seconds)
+		trace.Stop()
+	default:
+		p := pprof.Lookup(profile)
+		if p == nil {
+			return nil, CodedError(404, fmt.Sprintf("unknown profile %q", profile))
+		}
+		if err := p.WriteTo(&buf, debug); err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Header().Set("Content-Type", "application/octet-stream")
+	resp.Write(buf.Bytes())
+	return nil, nil
+}
+
+// waitProfile waits for the given number of seconds of profiling, ending early
+// if the agent shuts down.
+func (s *HTTPServer) waitProfile(seconds int) {
+	select {
+	case <-time.After(time.Duration(seconds) * time.Second):
+	case <-s.agent.shutdownCh:
+	}
+}
+
+// metricsInterval is a copy of the metrics aggregated during an interval
+type metricsInterval struct {
+	Interval time.Time
+	Gauges   map[string]float32
+	Points   map[string][]float32
+	Counters map[string]metrics.AggregateSample
+	Samples  map[string]metrics.AggregateSample
+}
+
+func newMetricsInterval(intv *metrics.IntervalMetrics) *metricsInterval {
+	intv.RLock()
+	defer intv.RUnlock()
+
+	out := &metricsInterval{
+		Interval: intv.Interval,
+		Gauges:   make(map[string]float32, len(intv.Gauges)),
+		Points:   make(map[string][]float32, len(intv.Points)),
+		Counters: make(map[string]metrics.AggregateSample, len(intv.Counters)),
+		Samples:  make(map[string]metrics.AggregateSample, len(intv.Samples)),
+	}
+	for k, v := range intv.Gauges {
+		out.Gauges[k] = v
+	}
+	for k, v := range intv.Points {
+		out.Points[k] = append([]float32(nil), v...)
+	}
+	for k, v := range intv.Counters {
+		out.Counters[k] = *v
+	}
+	for k, v := range intv.Samples {
+		out.Samples[k] = *v
+	}
+	return out
+}
+
 type agentSelf struct {
 	Config *Config                      `json:"config"`
 	Member Member                       `json:"member,omitempty"`
@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
 )
 
 func TestHTTP_AgentSelf(t *testing.T) {
@@ -105,6 +108,44 @@ func TestHTTP_AgentForceLeave(t *testing.T) {
 	})
 }
 
+func TestHTTP_AgentReload(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Reloading fails when the agent is not run by the command
+		req, err := http.NewRequest("PUT", "/v1/agent/reload", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err := s.Server.AgentReloadRequest(httptest.NewRecorder(), req); err == nil {
+			t.Fatalf("expected reload error")
+		}
+
+		// Serve the reload like the command
+		s.Agent.reloadCh = make(chan chan reloadReply)
+		go func() {
+			replyCh := <-s.Agent.reloadCh
+			replyCh <- reloadReply{result: &ReloadResult{Reloaded: []string{"log_level"}}}
+		}()
+
+		obj, err := s.Server.AgentReloadRequest(httptest.NewRecorder(), req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		result := obj.(*ReloadResult)
+		if len(result.Reloaded) != 1 || result.Reloaded[0] != "log_level" {
+			t.Fatalf("bad: %#v", result)
+		}
+
+		// Reloading requires a write
+		req, err = http.NewRequest("GET", "/v1/agent/reload", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err := s.Server.AgentReloadRequest(httptest.NewRecorder(), req); err == nil {
+			t.Fatalf("expected invalid method error")
+		}
+	})
+}
+
 func TestHTTP_AgentSetServers(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Establish a baseline number of servers
@@ -183,3 +224,113 @@ func TestHTTP_AgentSetServers(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_AgentMetrics(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/agent/metrics", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Without a sink the metrics are not retained
+		respW := httptest.NewRecorder()
+		_, err = s.Server.AgentMetricsRequest(respW, req)
+		if coded, ok := err.(HTTPCodedError); !ok || coded.Code() != 501 {
+			t.Fatalf("bad error: %v", err)
+		}
+
+		sink := metrics.NewInmemSink(10*time.Second, time.Minute)
+		sink.SetGauge([]string{"foo"}, 42)
+		s.Agent.inmemSink = sink
+
+		respW = httptest.NewRecorder()
+		obj, err := s.Server.AgentMetricsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out := obj.([]*metricsInterval)
+		if len(out) != 1 || out[0].Gauges["foo"] != 42 {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_AgentLogs(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		s.Agent.logWriter = NewLogWriter(10)
+		s.Agent.logWriter.Write([]byte("foo"))
+
+		req, err := http.NewRequest("GET", "/v1/agent/logs", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		obj, err := s.Server.AgentLogsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if logs := obj.([]string); len(logs) != 1 || logs[0] != "foo" {
+			t.Fatalf("bad: %#v", logs)
+		}
+	})
+}
+
+func TestHTTP_AgentPprof(t *testing.T) {
+	httpTest(t, func(c *Config) {
+		c.EnableDebug = true
+	}, func(s *TestServer) {
+		for _, path := range []string{"goroutine?debug=2", "heap", "cpu?seconds=1"} {
+			req, err := http.NewRequest("GET", "/v1/agent/pprof/"+path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+
+			if _, err := s.Server.AgentPprofRequest(respW, req); err != nil {
+				t.Fatalf("%s: err: %v", path, err)
+			}
+			if respW.Body.Len() == 0 {
+				t.Fatalf("%s: empty profile", path)
+			}
+		}
+
+		// The goroutine dump is readable
+		req, err := http.NewRequest("GET", "/v1/agent/pprof/goroutine?debug=2", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.AgentPprofRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !strings.Contains(respW.Body.String(), "goroutine ") {
+			t.Fatalf("bad: %s", respW.Body.String())
+		}
+
+		// Unknown profiles are rejected
+		req, err = http.NewRequest("GET", "/v1/agent/pprof/foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.AgentPprofRequest(httptest.NewRecorder(), req)
+		if coded, ok := err.(HTTPCodedError); !ok || coded.Code() != 404 {
+			t.Fatalf("bad error: %v", err)
+		}
+	})
+}
+
+func TestHTTP_AgentPprof_Disabled(t *testing.T) {
+	httpTest(t, func(c *Config) {
+		c.EnableDebug = false
+	}, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/agent/pprof/goroutine", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.AgentPprofRequest(httptest.NewRecorder(), req)
+		if coded, ok := err.(HTTPCodedError); !ok || coded.Code() != 403 {
+			t.Fatalf("bad error: %v", err)
+		}
+	})
+}
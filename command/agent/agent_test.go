@@ -230,6 +230,17 @@ func TestAgent_ServerConfig(t *testing.T) {
 		t.Fatalf("expect 127.0.0.3:4648, got: %s", addr)
 	}
 
+	// A unix socket HTTP address advertises the bind addr
+	conf.Addresses.HTTP = "unix:///var/run/nomad.sock"
+	out, err = a.serverConfig()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if addr := a.serverHTTPAddr; addr != "127.0.0.3:4646" {
+		t.Fatalf("expect 127.0.0.3:4646, got: %s", addr)
+	}
+	conf.Addresses.HTTP = ""
+
 	// Properly handles the bootstrap flags
 	conf.Server.BootstrapExpect = 1
 	out, err = a.serverConfig()
@@ -287,4 +298,39 @@ func TestAgent_ClientConfig(t *testing.T) {
 	if c.Node.HTTPAddr != expectedHttpAddr {
 		t.Fatalf("Expected http addr: %v, got: %v", expectedHttpAddr, c.Node.HTTPAddr)
 	}
+	if c.Node.StreamAddr != "" {
+		t.Fatalf("Expected no stream addr, got: %v", c.Node.StreamAddr)
+	}
+
+	// Advertise a distinct address for streaming
+	conf.AdvertiseAddrs.Stream = "10.0.0.1:8080"
+	c, err = a.clientConfig()
+	if err != nil {
+		t.Fatalf("got err: %v", err)
+	}
+
+	expectedStreamAddr := "10.0.0.1:8080"
+	if c.Node.StreamAddr != expectedStreamAddr {
+		t.Fatalf("Expected stream addr: %v, got: %v", expectedStreamAddr, c.Node.StreamAddr)
+	}
+	if c.Node.HTTPAddr != expectedHttpAddr {
+		t.Fatalf("Expected http addr: %v, got: %v", expectedHttpAddr, c.Node.HTTPAddr)
+	}
+
+	// An unresolvable stream address fails
+	conf.AdvertiseAddrs.Stream = "10.0.0.1"
+	if _, err := a.clientConfig(); err == nil {
+		t.Fatalf("expected error")
+	}
+	conf.AdvertiseAddrs.Stream = ""
+
+	// The auto-discovery configurations aren't static servers
+	conf.Client.Servers = []string{"10.0.0.2:4647", "provider=aws tag_key=role tag_value=server"}
+	c, err = a.clientConfig()
+	if err != nil {
+		t.Fatalf("got err: %v", err)
+	}
+	if len(c.Servers) != 1 || c.Servers[0] != "10.0.0.2:4647" {
+		t.Fatalf("bad servers: %v", c.Servers)
+	}
 }
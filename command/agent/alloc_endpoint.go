@@ -1,14 +1,22 @@
 package agent
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
 )
 
 const (
 	allocNotFoundErr = "allocation not found"
+
+	// allocRestartReason is recorded in the task events of tasks restarted
+	// through the API.
+	allocRestartReason = "Restart requested by user"
 )
 
 func (s *HTTPServer) AllocsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -21,6 +29,14 @@ func (s *HTTPServer) AllocsRequest(resp http.ResponseWriter, req *http.Request)
 		return nil, nil
 	}
 
+	// Parse the filters
+	query := req.URL.Query()
+	args.JobID = query.Get("job")
+	args.NodeID = query.Get("node")
+	args.TaskGroup = query.Get("task_group")
+	args.ClientStatus = query.Get("client_status")
+	args.DesiredStatus = query.Get("desired_status")
+
 	var out structs.AllocListResponse
 	if err := s.agent.RPC("Alloc.List", &args, &out); err != nil {
 		return nil, err
@@ -35,6 +51,9 @@ func (s *HTTPServer) AllocsRequest(resp http.ResponseWriter, req *http.Request)
 
 func (s *HTTPServer) AllocSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	allocID := strings.TrimPrefix(req.URL.Path, "/v1/allocation/")
+	if strings.HasSuffix(allocID, "/events") {
+		return s.allocEvents(resp, req, strings.TrimSuffix(allocID, "/events"))
+	}
 	if req.Method != "GET" {
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
@@ -58,6 +77,98 @@ func (s *HTTPServer) AllocSpecificRequest(resp http.ResponseWriter, req *http.Re
 	return out.Alloc, nil
 }
 
+// allocEvents returns the events of the tasks of an allocation ordered by
+// time. The parameters are:
+// * follow: If true, the events are streamed as newline delimited JSON objects
+//           until the allocation terminates on its client.
+// * wait: The heartbeat rate when following, an empty line being written when
+//         no event is recorded during this time.
+func (s *HTTPServer) allocEvents(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.AllocSpecificRequest{
+		AllocID: allocID,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	follow := false
+	if followStr := req.URL.Query().Get("follow"); followStr != "" {
+		var err error
+		if follow, err = strconv.ParseBool(followStr); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Failed to parse follow field to boolean: %v", err))
+		}
+	}
+
+	var out structs.SingleAllocResponse
+	if err := s.agent.RPC("Alloc.GetAlloc", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Alloc == nil {
+		return nil, CodedError(404, "alloc not found")
+	}
+
+	if !follow {
+		events := out.Alloc.TaskEvents()
+		if events == nil {
+			events = make([]*structs.AllocTaskEvent, 0)
+		}
+		return events, nil
+	}
+
+	// Stream the events recorded after the last one of each task
+	if args.MaxQueryTime == 0 {
+		args.MaxQueryTime = eventStreamHeartbeatRate
+	}
+	output := ioutils.NewWriteFlusher(resp)
+	enc := codec.NewEncoder(output, jsonHandle)
+	last := make(map[string]int64)
+	for {
+		written := false
+		for _, event := range out.Alloc.TaskEvents() {
+			if event.Event.Time <= last[event.Task] {
+				continue
+			}
+			last[event.Task] = event.Event.Time
+
+			if err := enc.Encode(event); err != nil {
+				return nil, nil
+			}
+			if _, err := output.Write([]byte("\n")); err != nil {
+				return nil, nil
+			}
+			written = true
+		}
+
+		// No more events are recorded once the allocation terminated
+		if out.Alloc.Terminated() {
+			return nil, nil
+		}
+
+		// Heartbeat when no event was recorded
+		if !written {
+			if _, err := output.Write([]byte("\n")); err != nil {
+				return nil, nil
+			}
+		}
+
+		args.MinQueryIndex = out.Index
+		out = structs.SingleAllocResponse{}
+		if err := s.agent.RPC("Alloc.GetAlloc", &args, &out); err != nil {
+			s.logger.Printf("[ERR] http: alloc events stream failed: %v", err)
+			return nil, nil
+		}
+		if out.Alloc == nil {
+			return nil, nil
+		}
+	}
+}
+
 func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if s.agent.client == nil {
 		return nil, clientNotRunning
@@ -68,11 +179,21 @@ func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Requ
 	// tokenize the suffix of the path to get the alloc id and find the action
 	// invoked on the alloc id
 	tokens := strings.Split(reqSuffix, "/")
-	if len(tokens) == 1 || tokens[1] != "stats" {
+	if len(tokens) != 2 {
 		return nil, CodedError(404, allocNotFoundErr)
 	}
 	allocID := tokens[0]
+	switch tokens[1] {
+	case "stats":
+		return s.allocStats(allocID, resp, req)
+	case "restart":
+		return s.allocRestart(allocID, resp, req)
+	}
 
+	return nil, CodedError(404, allocNotFoundErr)
+}
+
+func (s *HTTPServer) allocStats(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Get the stats reporter
 	clientStats := s.agent.client.StatsReporter()
 	aStats, err := clientStats.GetAllocStats(allocID)
@@ -83,3 +204,15 @@ func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Requ
 	task := req.URL.Query().Get("task")
 	return aStats.LatestAllocStats(task)
 }
+
+func (s *HTTPServer) allocRestart(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	task := req.URL.Query().Get("task")
+	if err := s.agent.client.RestartAllocation(allocID, task, allocRestartReason); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
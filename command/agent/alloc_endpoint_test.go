@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -56,6 +58,42 @@ func TestHTTP_AllocsList(t *testing.T) {
 	})
 }
 
+func TestHTTP_AllocsList_Filter(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		alloc1 := mock.Alloc()
+		alloc2 := mock.Alloc()
+		alloc2.ClientStatus = structs.AllocClientStatusRunning
+		state.UpsertJobSummary(998, mock.JobSummary(alloc1.JobID))
+		state.UpsertJobSummary(999, mock.JobSummary(alloc2.JobID))
+		err := state.UpsertAllocs(1000,
+			[]*structs.Allocation{alloc1, alloc2})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		for _, query := range []string{"job=" + alloc2.JobID, "client_status=running"} {
+			req, err := http.NewRequest("GET", "/v1/allocations?"+query, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+
+			obj, err := s.Server.AllocsRequest(respW, req)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			// Only the second alloc passes the filters
+			n := obj.([]*structs.AllocListStub)
+			if len(n) != 1 || n[0].ID != alloc2.ID {
+				t.Fatalf("%s: bad: %#v", query, n)
+			}
+		}
+	})
+}
+
 func TestHTTP_AllocsPrefixList(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Directly manipulate the state
@@ -161,6 +199,128 @@ func TestHTTP_AllocQuery(t *testing.T) {
 	})
 }
 
+func TestHTTP_AllocEvents(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		alloc := mock.Alloc()
+		alloc.TaskStates = map[string]*structs.TaskState{
+			"web": &structs.TaskState{
+				State: structs.TaskStateRunning,
+				Events: []*structs.TaskEvent{
+					{Type: structs.TaskReceived, Time: 10},
+					{Type: structs.TaskStarted, Time: 30},
+				},
+			},
+			"sidecar": &structs.TaskState{
+				State:  structs.TaskStatePending,
+				Events: []*structs.TaskEvent{{Type: structs.TaskReceived, Time: 20}},
+			},
+		}
+		if err := state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID)); err != nil {
+			t.Fatal(err)
+		}
+		if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/allocation/"+alloc.ID+"/events", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		obj, err := s.Server.AllocSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the events are ordered by time
+		events := obj.([]*structs.AllocTaskEvent)
+		if len(events) != 3 {
+			t.Fatalf("bad: %#v", events)
+		}
+		if events[0].Task != "web" || events[1].Task != "sidecar" || events[2].Event.Type != structs.TaskStarted {
+			t.Fatalf("bad order: %#v", events)
+		}
+
+		// Unknown allocations aren't found
+		req, err = http.NewRequest("GET", "/v1/allocation/"+structs.GenerateUUID()+"/events", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.AllocSpecificRequest(httptest.NewRecorder(), req)
+		if coded, ok := err.(HTTPCodedError); !ok || coded.Code() != 404 {
+			t.Fatalf("bad error: %v", err)
+		}
+	})
+}
+
+func TestHTTP_AllocEvents_Follow(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		state := s.Agent.server.State()
+		alloc := mock.Alloc()
+		alloc.TaskStates = map[string]*structs.TaskState{
+			"web": &structs.TaskState{
+				State:  structs.TaskStatePending,
+				Events: []*structs.TaskEvent{{Type: structs.TaskReceived, Time: 10}},
+			},
+		}
+		if err := state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID)); err != nil {
+			t.Fatal(err)
+		}
+		if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		url := fmt.Sprintf("http://%s/v1/allocation/%s/events?follow=true", s.Server.addr, alloc.ID)
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatalf("bad status: %d", resp.StatusCode)
+		}
+
+		dec := json.NewDecoder(resp.Body)
+		var event structs.AllocTaskEvent
+		if err := dec.Decode(&event); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if event.Task != "web" || event.Event.Type != structs.TaskReceived {
+			t.Fatalf("bad: %#v", event)
+		}
+
+		// New events are streamed until the allocation terminates
+		update := alloc.Copy()
+		update.ClientStatus = structs.AllocClientStatusComplete
+		update.TaskStates["web"].State = structs.TaskStateDead
+		update.TaskStates["web"].Events = append(update.TaskStates["web"].Events,
+			&structs.TaskEvent{Type: structs.TaskStarted, Time: 20},
+			&structs.TaskEvent{Type: structs.TaskTerminated, Time: 30})
+		if err := state.UpdateAllocsFromClient(1001, []*structs.Allocation{update}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var types []string
+		for {
+			var event structs.AllocTaskEvent
+			if err := dec.Decode(&event); err != nil {
+				break
+			}
+			types = append(types, event.Event.Type)
+		}
+		if strings.Join(types, ",") != "Started,Terminated" {
+			t.Fatalf("bad events: %v", types)
+		}
+	})
+}
+
 func TestHTTP_AllocStats(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Make the HTTP request
@@ -177,3 +337,31 @@ func TestHTTP_AllocStats(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_AllocRestart(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Restarting requires a PUT or POST
+		req, err := http.NewRequest("GET", "/v1/client/allocation/123/restart", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), ErrInvalidMethod) {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Restarting an unknown allocation fails
+		req, err = http.NewRequest("PUT", "/v1/client/allocation/123/restart", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), "unknown allocation") {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -21,11 +22,14 @@ import (
 	"github.com/hashicorp/go-checkpoint"
 	"github.com/hashicorp/go-syslog"
 	"github.com/hashicorp/logutils"
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/helper/autojoin"
 	"github.com/hashicorp/nomad/helper/flag-slice"
 	"github.com/hashicorp/nomad/helper/gated-writer"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/scada-client/scada"
 	"github.com/mitchellh/cli"
+	"github.com/mitchellh/copystructure"
 )
 
 // gracefulTimeout controls how long we wait before forcefully terminating
@@ -47,8 +51,22 @@ type Command struct {
 	httpServer     *HTTPServer
 	logFilter      *logutils.LevelFilter
 	logOutput      io.Writer
+	logWriter      *logWriter
+	inmemSink      *metrics.InmemSink
 	retryJoinErrCh chan struct{}
 
+	// loadedConfig is a copy of the configuration last read from the files
+	// and flags, before the agent completes it, to find the settings changed
+	// on reload
+	loadedConfig *Config
+
+	// metricsSinks are the external sinks the metrics are emitted to, which
+	// are replaced when the telemetry configuration is reloaded
+	metricsSinks metrics.FanoutSink
+
+	// reloadCh serves the configuration reloads requested through the agent
+	reloadCh chan chan reloadReply
+
 	scadaProvider *scada.Provider
 	scadaHttp     *HTTPServer
 }
@@ -83,6 +101,7 @@ func (c *Command) readConfig() *Config {
 	flags.Var((*sliceflag.StringFlag)(&cmdConfig.Server.RetryJoin), "retry-join", "")
 	flags.IntVar(&cmdConfig.Server.RetryMaxAttempts, "retry-max", 0, "")
 	flags.StringVar(&cmdConfig.Server.RetryInterval, "retry-interval", "", "")
+	flags.StringVar(&cmdConfig.Server.EncryptKey, "encrypt", "", "")
 
 	// Client-only options
 	flags.StringVar(&cmdConfig.Client.StateDir, "state-dir", "", "")
@@ -219,6 +238,27 @@ func (c *Command) readConfig() *Config {
 			return nil
 		}
 	}
+	for _, dir := range config.Client.AllocDirs {
+		if !filepath.IsAbs(dir) {
+			c.Ui.Error(fmt.Sprintf("alloc_dirs must be given as absolute paths: got %v", dir))
+			return nil
+		}
+	}
+	if !allocdir.ValidRootPolicy(config.Client.AllocDirPolicy) {
+		c.Ui.Error(fmt.Sprintf("Invalid alloc_dir_policy %q: must be %q or %q",
+			config.Client.AllocDirPolicy, allocdir.RootPolicyMostFree, allocdir.RootPolicyRoundRobin))
+		return nil
+	}
+	if cidr := config.Client.NetworkCIDR; cidr != "" {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid network_cidr %q: %v", cidr, err))
+			return nil
+		}
+	}
+	if err := config.Client.validateDynamicPorts(); err != nil {
+		c.Ui.Error(err.Error())
+		return nil
+	}
 
 	// Ensure that we have the directories we neet to run.
 	if config.Server.Enabled && config.DataDir == "" {
@@ -244,6 +284,20 @@ func (c *Command) readConfig() *Config {
 		c.Ui.Error("WARNING: Bootstrap mode enabled! Potentially unsafe operation.")
 	}
 
+	// Check the cloud auto-discovery configurations
+	joinAddrs := make([]string, 0, len(config.Server.RetryJoin)+len(config.Client.Servers))
+	joinAddrs = append(joinAddrs, config.Server.RetryJoin...)
+	joinAddrs = append(joinAddrs, config.Client.Servers...)
+	for _, addr := range joinAddrs {
+		if !autojoin.IsConfig(addr) {
+			continue
+		}
+		if err := autojoin.Validate(addr); err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid auto-discovery configuration: %v", err))
+			return nil
+		}
+	}
+
 	return config
 }
 
@@ -285,6 +339,7 @@ func (c *Command) setupLoggers(config *Config) (*gatedwriter.Writer, *logWriter,
 	} else {
 		logOutput = io.MultiWriter(c.logFilter, logWriter)
 	}
+	c.logWriter = logWriter
 	c.logOutput = logOutput
 	log.SetOutput(logOutput)
 	return logGate, logWriter, logOutput
@@ -299,6 +354,12 @@ func (c *Command) setupAgent(config *Config, logOutput io.Writer) error {
 		return err
 	}
 	c.agent = agent
+	agent.inmemSink = c.inmemSink
+	agent.logWriter = c.logWriter
+
+	// Serve the reloads requested through the HTTP API
+	c.reloadCh = make(chan chan reloadReply)
+	agent.reloadCh = c.reloadCh
 
 	// Enable the SCADA integration
 	if err := c.setupSCADA(config); err != nil {
@@ -380,6 +441,7 @@ func (c *Command) Run(args []string) int {
 	if config == nil {
 		return 1
 	}
+	c.loadedConfig = copyConfig(config)
 
 	// Setup the log outputs
 	logGate, _, logOutput := c.setupLoggers(config)
@@ -486,14 +548,18 @@ WAIT:
 		sig = os.Interrupt
 	case <-c.retryJoinErrCh:
 		return 1
+	case replyCh := <-c.reloadCh:
+		conf, result, err := c.handleReload(config)
+		*config = *conf
+		replyCh <- reloadReply{result: result, err: err}
+		goto WAIT
 	}
 	c.Ui.Output(fmt.Sprintf("Caught signal: %v", sig))
 
 	// Check if this is a SIGHUP
 	if sig == syscall.SIGHUP {
-		if conf := c.handleReload(config); conf != nil {
-			*config = *conf
-		}
+		conf, _, _ := c.handleReload(config)
+		*config = *conf
 		goto WAIT
 	}
 
@@ -532,14 +598,20 @@ WAIT:
 	}
 }
 
-// handleReload is invoked when we should reload our configs, e.g. SIGHUP
-func (c *Command) handleReload(config *Config) *Config {
+// handleReload is invoked when we should reload our configs, e.g. SIGHUP. It
+// returns the configuration to run with along with the settings which were
+// changed. An error is returned if the configuration could not be read, in
+// which case the current configuration is kept.
+func (c *Command) handleReload(config *Config) (*Config, *ReloadResult, error) {
 	c.Ui.Output("Reloading configuration...")
 	newConf := c.readConfig()
 	if newConf == nil {
 		c.Ui.Error(fmt.Sprintf("Failed to reload configs"))
-		return config
+		return config, nil, fmt.Errorf("failed to read the configuration")
 	}
+	loaded := copyConfig(newConf)
+	result := newReloadResult(c.loadedConfig, loaded)
+	c.loadedConfig = loaded
 
 	// Change the log level
 	minLevel := logutils.LogLevel(strings.ToUpper(newConf.LogLevel))
@@ -549,11 +621,45 @@ func (c *Command) handleReload(config *Config) *Config {
 		c.Ui.Error(fmt.Sprintf(
 			"Invalid log level: %s. Valid log levels are: %v",
 			minLevel, c.logFilter.Levels))
+		result.fail(fmt.Errorf("invalid log level %q", newConf.LogLevel), "log_level")
 
 		// Keep the current log level
 		newConf.LogLevel = config.LogLevel
 	}
-	return newConf
+
+	// Reload the TLS certificate. The current TLS configuration is kept as
+	// the listeners and connection pools serve its key loader.
+	if c.agent != nil {
+		if err := c.agent.ReloadTLS(newConf.TLSConfig); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to reload the TLS certificate: %v", err))
+			result.fail(err, "tls.cert_file", "tls.key_file")
+		}
+	}
+	newConf.TLSConfig = config.TLSConfig
+
+	// Replace the metrics sinks if their configuration changed
+	if result.reloaded("telemetry.") {
+		if err := c.setupMetricsSinks(newConf); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to reload the telemetry sinks: %v", err))
+			result.fail(err, "telemetry.")
+			newConf.Telemetry = config.Telemetry
+		}
+	}
+
+	if len(result.RestartRequired) > 0 {
+		c.Ui.Warn(fmt.Sprintf("Changing %s requires a restart of the agent",
+			strings.Join(result.RestartRequired, ", ")))
+	}
+	return newConf, result, nil
+}
+
+// copyConfig returns a deep copy of the configuration.
+func copyConfig(config *Config) *Config {
+	copied, err := copystructure.Copy(config)
+	if err != nil {
+		panic(fmt.Sprintf("failed to copy the configuration: %v", err))
+	}
+	return copied.(*Config)
 }
 
 // setupTelemetry is used ot setup the telemetry sub-systems
@@ -564,7 +670,14 @@ func (c *Command) setupTelemetry(config *Config) error {
 	*/
 	inm := metrics.NewInmemSink(10*time.Second, time.Minute)
 	metrics.DefaultInmemSignal(inm)
+	c.inmemSink = inm
+	return c.setupMetricsSinks(config)
+}
 
+// setupMetricsSinks emits the metrics to the sinks of the telemetry
+// configuration along with the in-memory sink, replacing the sinks previously
+// set up.
+func (c *Command) setupMetricsSinks(config *Config) error {
 	var telConfig *Telemetry
 	if config.Telemetry == nil {
 		telConfig = &Telemetry{}
@@ -631,13 +744,25 @@ func (c *Command) setupTelemetry(config *Config) error {
 		fanout = append(fanout, sink)
 	}
 
+	// Stop the previous sinks, flushing their pending metrics
+	for _, sink := range c.metricsSinks {
+		switch s := sink.(type) {
+		case *metrics.StatsiteSink:
+			s.Shutdown()
+		case *metrics.StatsdSink:
+			s.Shutdown()
+		case *circonus.CirconusSink:
+			s.Flush()
+		}
+	}
+	c.metricsSinks = fanout
+
 	// Initialize the global sink
 	if len(fanout) > 0 {
-		fanout = append(fanout, inm)
-		metrics.NewGlobal(metricsConf, fanout)
+		metrics.NewGlobal(metricsConf, append(fanout, c.inmemSink))
 	} else {
 		metricsConf.EnableHostname = false
-		metrics.NewGlobal(metricsConf, inm)
+		metrics.NewGlobal(metricsConf, c.inmemSink)
 	}
 	return nil
 }
@@ -715,7 +840,15 @@ func (c *Command) retryJoin(config *Config) {
 
 	attempt := 0
 	for {
-		n, err := c.agent.server.Join(config.Server.RetryJoin)
+		// Discover the addresses on each attempt as the instances change
+		var n int
+		var err error
+		addrs := autojoin.Resolve(config.Server.RetryJoin, logger)
+		if len(addrs) == 0 {
+			err = fmt.Errorf("no addresses to join")
+		} else {
+			n, err = c.agent.server.Join(addrs)
+		}
 		if err == nil {
 			logger.Printf("[INFO] agent: Join completed. Synced with %d initial agents", n)
 			return
@@ -825,6 +958,11 @@ Server Options:
   -rejoin
     Ignore a previous leave and attempts to rejoin the cluster.
 
+  -encrypt=<key>
+    Provides the gossip encryption key. The key must be 16, 24 or 32 bytes
+    and base64 encoded. It is ignored once a keyring has been persisted in
+    the data dir.
+
 Client Options:
 
   -client
@@ -3,10 +3,12 @@ package agent
 import (
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/helper/autojoin"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/cli"
 )
@@ -47,6 +49,10 @@ func TestCommand_Args(t *testing.T) {
 			[]string{"-client", "-alloc-dir="},
 			"Must specify both the state and alloc dir if data-dir is omitted.",
 		},
+		{
+			[]string{"-server", "-data-dir=" + tmpDir, "-retry-join=provider=foo"},
+			"Invalid auto-discovery configuration",
+		},
 	}
 	for _, tc := range tcases {
 		// Make a new command. We pre-emptively close the shutdownCh
@@ -126,3 +132,67 @@ func TestRetryJoin(t *testing.T) {
 		t.Fatalf(err.Error())
 	})
 }
+
+// staticProvider is an auto-discovery provider returning fixed addresses.
+type staticProvider []string
+
+func (p staticProvider) Addrs(args map[string]string, logger *log.Logger) ([]string, error) {
+	return p, nil
+}
+
+func TestRetryJoin_AutoDiscovery(t *testing.T) {
+	dir, agent := makeAgent(t, nil)
+	defer os.RemoveAll(dir)
+	defer agent.Shutdown()
+
+	tmpDir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	serfAddr := fmt.Sprintf(
+		"%s:%d",
+		agent.config.BindAddr,
+		agent.config.Ports.Serf)
+	autojoin.Providers["static"] = staticProvider{serfAddr}
+	defer delete(autojoin.Providers, "static")
+
+	doneCh := make(chan struct{})
+	shutdownCh := make(chan struct{})
+
+	defer func() {
+		close(shutdownCh)
+		<-doneCh
+	}()
+
+	cmd := &Command{
+		ShutdownCh: shutdownCh,
+		Ui:         new(cli.MockUi),
+	}
+
+	args := []string{
+		"-server",
+		"-data-dir", tmpDir,
+		"-node", fmt.Sprintf(`"Node %d"`, getPort()),
+		"-retry-join", "provider=static",
+		"-retry-interval", "1s",
+	}
+
+	go func() {
+		if code := cmd.Run(args); code != 0 {
+			t.Logf("bad: %d", code)
+		}
+		close(doneCh)
+	}()
+
+	testutil.WaitForResult(func() (bool, error) {
+		mem := agent.server.Members()
+		if len(mem) != 2 {
+			return false, fmt.Errorf("bad :%#v", mem)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
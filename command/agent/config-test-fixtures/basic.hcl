@@ -18,11 +18,14 @@ addresses {
 advertise {
 	rpc = "127.0.0.3"
 	serf = "127.0.0.4"
+	stream = "127.0.0.5"
 }
 client {
 	enabled = true
 	state_dir = "/tmp/client-state"
 	alloc_dir = "/tmp/alloc"
+	alloc_dirs = ["/tmp/alloc-disk1", "/tmp/alloc-disk2"]
+	alloc_dir_policy = "round-robin"
 	servers = ["a.b.c:80", "127.0.0.1:1234"]
 	node_class = "linux-medium-64bit"
 	meta {
@@ -38,6 +41,7 @@ client {
 		"/opt/myapp/bin" = "/bin"
 	}
 	network_interface = "eth0"
+	network_cidr = "10.0.0.0/8"
 	network_speed = 100
 	reserved {
 		cpu = 10
@@ -46,9 +50,18 @@ client {
 		iops = 10
 		reserved_ports = "1,100,10-12"
 	}
+	host_volume "shared_data" {
+		path = "/srv/data"
+		read_only = true
+	}
 	client_min_port = 1000
 	client_max_port = 2000
+	min_dynamic_port = 25000
+	max_dynamic_port = 30000
     max_kill_timeout = "10s"
+    tmp_dir_size = 128
+    gc_interval = "2m"
+    gc_disk_usage_threshold = 90
     stats {
         data_points = 35
         collection_interval = "5s"
@@ -68,6 +81,14 @@ server {
 	retry_max = 3
 	retry_interval = "15s"
 	rejoin_after_leave = true
+	encrypt = "abc"
+	preemption {
+		service_scheduler_enabled = true
+	}
+	autopilot {
+		cleanup_dead_servers = false
+		server_stabilization_time = "30s"
+	}
 }
 telemetry {
 	statsite_address = "127.0.0.1:1234"
@@ -76,6 +97,7 @@ telemetry {
     collection_interval = "3s"
     publish_allocation_metrics = true
     publish_node_metrics = true
+    aggregate_allocation_metrics = true
 }
 leave_on_interrupt = true
 leave_on_terminate = true
@@ -92,6 +114,22 @@ atlas {
 http_api_response_headers {
 	Access-Control-Allow-Origin = "*"
 }
+cors {
+	allowed_origins = ["https://ui.example.com", "http://localhost:4200"]
+	allowed_methods = ["GET", "PUT"]
+}
+http_server {
+	read_timeout = "30s"
+	write_timeout = "1m"
+	idle_timeout = "5m"
+	max_conns_per_client = 100
+	disable_compression = true
+}
+unix_sockets {
+	user = "1000"
+	group = "1000"
+	mode = "0700"
+}
 consul {
     server_service_name = "nomad"
     client_service_name = "nomad-client"
@@ -120,3 +158,12 @@ vault {
     tls_server_name = "foobar"
     tls_skip_verify = true
 }
+tls {
+    http = true
+    rpc = true
+    verify_server_hostname = true
+    verify_https_client = true
+    ca_file = "foo"
+    cert_file = "bar"
+    key_file = "pipe"
+}
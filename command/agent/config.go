@@ -14,6 +14,7 @@ import (
 
 	client "github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/nomad"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 )
 
@@ -92,6 +93,10 @@ type Config struct {
 	// parameters necessary to derive tokens.
 	Vault *config.VaultConfig `mapstructure:"vault"`
 
+	// TLSConfig provides TLS related configuration for the Nomad server and
+	// client
+	TLSConfig *config.TLSConfig `mapstructure:"tls"`
+
 	// NomadConfig is used to override the default config.
 	// This is largly used for testing purposes.
 	NomadConfig *nomad.Config `mapstructure:"-" json:"-"`
@@ -114,6 +119,18 @@ type Config struct {
 	// HTTPAPIResponseHeaders allows users to configure the Nomad http agent to
 	// set arbritrary headers on API responses
 	HTTPAPIResponseHeaders map[string]string `mapstructure:"http_api_response_headers"`
+
+	// CORS configures the Cross-Origin Resource Sharing support of the HTTP
+	// API, allowing browsers to call it from other origins
+	CORS *CORSConfig `mapstructure:"cors"`
+
+	// HTTPServer tunes the timeouts, connection limits and compression of
+	// the HTTP API server
+	HTTPServer *HTTPServerConfig `mapstructure:"http_server"`
+
+	// UnixSockets sets the ownership and mode of the unix domain socket the
+	// HTTP API listens on when addresses.http names one
+	UnixSockets *UnixSocketConfig `mapstructure:"unix_sockets"`
 }
 
 // AtlasConfig is used to enable an parameterize the Atlas integration
@@ -134,6 +151,55 @@ type AtlasConfig struct {
 	Endpoint string `mapstructure:"endpoint"`
 }
 
+// CORSConfig configures the Cross-Origin Resource Sharing (CORS) support of the
+// HTTP API
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to call the HTTP API, "*"
+	// allowing all the origins. CORS is disabled if empty.
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+
+	// AllowedMethods are the methods allowed in cross-origin requests.
+	// Defaults to GET, PUT, POST and DELETE.
+	AllowedMethods []string `mapstructure:"allowed_methods"`
+}
+
+// HTTPServerConfig tunes the HTTP server of the agent. The zero value keeps
+// connections open for as long as the clients do.
+type HTTPServerConfig struct {
+	// ReadTimeout is the maximum duration to read a request, including its
+	// body. Zero means no timeout.
+	ReadTimeout time.Duration `mapstructure:"read_timeout"`
+
+	// WriteTimeout is the maximum duration to write a response, counted from
+	// the end of the request. It bounds the streaming endpoints as well.
+	// Zero means no timeout.
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	// IdleTimeout is the maximum duration a keep-alive connection waits for
+	// the next request. Zero falls back to the ReadTimeout.
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+
+	// MaxConnsPerClient limits the concurrent connections of each client IP.
+	// Zero means no limit.
+	MaxConnsPerClient int `mapstructure:"max_conns_per_client"`
+
+	// DisableCompression disables the gzip compression of the responses.
+	DisableCompression bool `mapstructure:"disable_compression"`
+}
+
+// UnixSocketConfig sets the permissions of the unix domain sockets the agent
+// listens on. Unset values keep the ones of the agent process.
+type UnixSocketConfig struct {
+	// User is the numeric ID of the user owning the socket
+	User string `mapstructure:"user"`
+
+	// Group is the numeric ID of the group owning the socket
+	Group string `mapstructure:"group"`
+
+	// Mode is the octal file mode of the socket, such as "0700"
+	Mode string `mapstructure:"mode"`
+}
+
 // ClientConfig is configuration specific to the client mode
 type ClientConfig struct {
 	// Enabled controls if we are a client
@@ -145,6 +211,14 @@ type ClientConfig struct {
 	// AllocDir is the directory for storing allocation data
 	AllocDir string `mapstructure:"alloc_dir"`
 
+	// AllocDirs are additional directories, such as the mount points of
+	// other disks, on which allocation data can be stored
+	AllocDirs []string `mapstructure:"alloc_dirs"`
+
+	// AllocDirPolicy is how the directory storing the data of an allocation
+	// is chosen when several are configured
+	AllocDirPolicy string `mapstructure:"alloc_dir_policy"`
+
 	// Servers is a list of known server addresses. These are as "host:port"
 	Servers []string `mapstructure:"servers"`
 
@@ -167,12 +241,27 @@ type ClientConfig struct {
 	// Interface to use for network fingerprinting
 	NetworkInterface string `mapstructure:"network_interface"`
 
+	// CIDR of the addresses to use for task port mapping
+	NetworkCIDR string `mapstructure:"network_cidr"`
+
 	// The network link speed to use if it can not be determined dynamically.
 	NetworkSpeed int `mapstructure:"network_speed"`
 
 	// MaxKillTimeout allows capping the user-specifiable KillTimeout.
 	MaxKillTimeout string `mapstructure:"max_kill_timeout"`
 
+	// TmpDirSize is the size in megabytes of the tmpfs mounted as the tmp
+	// directory of each task. Zero disables the tmpfs.
+	TmpDirSize int `mapstructure:"tmp_dir_size"`
+
+	// GCInterval is how often the disk usage of the allocation directory is
+	// checked for pressure.
+	GCInterval string `mapstructure:"gc_interval"`
+
+	// GCDiskUsageThreshold is the disk usage percentage above which terminal
+	// allocations are garbage collected.
+	GCDiskUsageThreshold float64 `mapstructure:"gc_disk_usage_threshold"`
+
 	// ClientMaxPort is the upper range of the ports that the client uses for
 	// communicating with plugin subsystems
 	ClientMaxPort int `mapstructure:"client_max_port"`
@@ -181,10 +270,19 @@ type ClientConfig struct {
 	// communicating with plugin subsystems
 	ClientMinPort int `mapstructure:"client_min_port"`
 
+	// MinDynamicPort and MaxDynamicPort are the inclusive range of the ports
+	// the scheduler assigns as dynamic ports on the node.
+	MinDynamicPort int `mapstructure:"min_dynamic_port"`
+	MaxDynamicPort int `mapstructure:"max_dynamic_port"`
+
 	// Reserved is used to reserve resources from being used by Nomad. This can
 	// be used to target a certain utilization or to prevent Nomad from using a
 	// particular set of ports.
 	Reserved *Resources `mapstructure:"reserved"`
+
+	// HostVolumes are the directories of the host that can be mounted into
+	// tasks requesting them.
+	HostVolumes []*structs.ClientHostVolumeConfig `mapstructure:"host_volume"`
 }
 
 // ServerConfig is configuration specific to the server mode
@@ -244,17 +342,31 @@ type ServerConfig struct {
 	// the cluster until an explicit join is received. If this is set to
 	// true, we ignore the leave, and rejoin the cluster on start.
 	RejoinAfterLeave bool `mapstructure:"rejoin_after_leave"`
+
+	// Preemption controls for which scheduler types the allocations of lower
+	// priority jobs are preempted to place higher priority jobs.
+	Preemption *structs.PreemptionConfig `mapstructure:"preemption"`
+
+	// Autopilot is the configuration of Autopilot, which manages the Raft
+	// peers of the servers. It is applied when the cluster has none yet.
+	Autopilot *structs.AutopilotConfig `mapstructure:"autopilot"`
+
+	// EncryptKey is the base64 encoded secret key used to encrypt the gossip
+	// between the servers. It is only used to initialize the keyring, which
+	// is persisted in the data dir once the key is rotated.
+	EncryptKey string `mapstructure:"encrypt" json:"-"`
 }
 
 // Telemetry is the telemetry configuration for the server
 type Telemetry struct {
-	StatsiteAddr             string        `mapstructure:"statsite_address"`
-	StatsdAddr               string        `mapstructure:"statsd_address"`
-	DisableHostname          bool          `mapstructure:"disable_hostname"`
-	CollectionInterval       string        `mapstructure:"collection_interval"`
-	collectionInterval       time.Duration `mapstructure:"-"`
-	PublishAllocationMetrics bool          `mapstructure:"publish_allocation_metrics"`
-	PublishNodeMetrics       bool          `mapstructure:"publish_node_metrics"`
+	StatsiteAddr               string        `mapstructure:"statsite_address"`
+	StatsdAddr                 string        `mapstructure:"statsd_address"`
+	DisableHostname            bool          `mapstructure:"disable_hostname"`
+	CollectionInterval         string        `mapstructure:"collection_interval"`
+	collectionInterval         time.Duration `mapstructure:"-"`
+	PublishAllocationMetrics   bool          `mapstructure:"publish_allocation_metrics"`
+	PublishNodeMetrics         bool          `mapstructure:"publish_node_metrics"`
+	AggregateAllocationMetrics bool          `mapstructure:"aggregate_allocation_metrics"`
 
 	// Circonus: see https://github.com/circonus-labs/circonus-gometrics
 	// for more details on the various configuration options.
@@ -344,6 +456,11 @@ type AdvertiseAddrs struct {
 	HTTP string `mapstructure:"http"`
 	RPC  string `mapstructure:"rpc"`
 	Serf string `mapstructure:"serf"`
+
+	// Stream is the address a client advertises for its file system and log
+	// streaming endpoints, such as a reverse proxy in front of its HTTP
+	// interface. It defaults to the HTTP address.
+	Stream string `mapstructure:"stream"`
 }
 
 type Resources struct {
@@ -355,6 +472,23 @@ type Resources struct {
 	ParsedReservedPorts []int  `mapstructure:"-"`
 }
 
+// validateDynamicPorts returns an error if the dynamic port range of the
+// client is invalid. The default bounds are used for the unset ones.
+func (c *ClientConfig) validateDynamicPorts() error {
+	if c.MinDynamicPort < 0 || c.MinDynamicPort > 65535 {
+		return fmt.Errorf("Invalid min_dynamic_port %d: must be between 1 and 65535", c.MinDynamicPort)
+	}
+	if c.MaxDynamicPort < 0 || c.MaxDynamicPort > 65535 {
+		return fmt.Errorf("Invalid max_dynamic_port %d: must be between 1 and 65535", c.MaxDynamicPort)
+	}
+
+	node := &structs.Node{MinDynamicPort: c.MinDynamicPort, MaxDynamicPort: c.MaxDynamicPort}
+	if min, max := node.DynamicPortRange(); min > max {
+		return fmt.Errorf("Invalid dynamic port range: min_dynamic_port %d is greater than max_dynamic_port %d", min, max)
+	}
+	return nil
+}
+
 // ParseReserved expands the ReservedPorts string into a slice of port numbers.
 // The supported syntax is comma seperated integers or ranges seperated by
 // hyphens. For example, "80,120-150,160"
@@ -453,13 +587,17 @@ func DefaultConfig() *Config {
 		Atlas:          &AtlasConfig{},
 		Consul:         config.DefaultConsulConfig(),
 		Vault:          config.DefaultVaultConfig(),
+		TLSConfig:      &config.TLSConfig{},
+		HTTPServer:     &HTTPServerConfig{},
 		Client: &ClientConfig{
-			Enabled:        false,
-			NetworkSpeed:   100,
-			MaxKillTimeout: "30s",
-			ClientMinPort:  14000,
-			ClientMaxPort:  14512,
-			Reserved:       &Resources{},
+			Enabled:              false,
+			NetworkSpeed:         100,
+			MaxKillTimeout:       "30s",
+			ClientMinPort:        14000,
+			ClientMaxPort:        14512,
+			Reserved:             &Resources{},
+			GCInterval:           "1m",
+			GCDiskUsageThreshold: 80,
 		},
 		Server: &ServerConfig{
 			Enabled:          false,
@@ -501,6 +639,16 @@ func (c *Config) Listener(proto, addr string, port int) (net.Listener, error) {
 	return net.Listen(proto, fmt.Sprintf("%s:%d", addr, port))
 }
 
+// httpBindAddr returns the address the HTTP API listens on over TCP. When
+// addresses.http names a unix domain socket, the HTTP API listens on it in
+// addition to the bind address.
+func (c *Config) httpBindAddr() string {
+	if _, ok := unixSocketPath(c.Addresses.HTTP); ok {
+		return ""
+	}
+	return c.Addresses.HTTP
+}
+
 // Merge merges two configurations.
 func (c *Config) Merge(b *Config) *Config {
 	result := *c
@@ -617,6 +765,38 @@ func (c *Config) Merge(b *Config) *Config {
 		result.Vault = result.Vault.Merge(b.Vault)
 	}
 
+	// Apply the TLS Config
+	if result.TLSConfig == nil && b.TLSConfig != nil {
+		tlsConfig := *b.TLSConfig
+		result.TLSConfig = &tlsConfig
+	} else if b.TLSConfig != nil {
+		result.TLSConfig = result.TLSConfig.Merge(b.TLSConfig)
+	}
+
+	// Apply the CORS config
+	if result.CORS == nil && b.CORS != nil {
+		corsConfig := *b.CORS
+		result.CORS = &corsConfig
+	} else if b.CORS != nil {
+		result.CORS = result.CORS.Merge(b.CORS)
+	}
+
+	// Apply the HTTP server config
+	if result.HTTPServer == nil && b.HTTPServer != nil {
+		httpServer := *b.HTTPServer
+		result.HTTPServer = &httpServer
+	} else if b.HTTPServer != nil {
+		result.HTTPServer = result.HTTPServer.Merge(b.HTTPServer)
+	}
+
+	// Apply the unix sockets config
+	if result.UnixSockets == nil && b.UnixSockets != nil {
+		unixSockets := *b.UnixSockets
+		result.UnixSockets = &unixSockets
+	} else if b.UnixSockets != nil {
+		result.UnixSockets = result.UnixSockets.Merge(b.UnixSockets)
+	}
+
 	// Merge config files lists
 	result.Files = append(result.Files, b.Files...)
 
@@ -666,6 +846,17 @@ func (a *ServerConfig) Merge(b *ServerConfig) *ServerConfig {
 	if b.RejoinAfterLeave {
 		result.RejoinAfterLeave = true
 	}
+	if b.Preemption != nil {
+		preemption := *b.Preemption
+		result.Preemption = &preemption
+	}
+	if b.Autopilot != nil {
+		autopilot := *b.Autopilot
+		result.Autopilot = &autopilot
+	}
+	if b.EncryptKey != "" {
+		result.EncryptKey = b.EncryptKey
+	}
 
 	// Add the schedulers
 	result.EnabledSchedulers = append(result.EnabledSchedulers, b.EnabledSchedulers...)
@@ -696,24 +887,45 @@ func (a *ClientConfig) Merge(b *ClientConfig) *ClientConfig {
 	if b.AllocDir != "" {
 		result.AllocDir = b.AllocDir
 	}
+	if b.AllocDirPolicy != "" {
+		result.AllocDirPolicy = b.AllocDirPolicy
+	}
 	if b.NodeClass != "" {
 		result.NodeClass = b.NodeClass
 	}
 	if b.NetworkInterface != "" {
 		result.NetworkInterface = b.NetworkInterface
 	}
+	if b.NetworkCIDR != "" {
+		result.NetworkCIDR = b.NetworkCIDR
+	}
 	if b.NetworkSpeed != 0 {
 		result.NetworkSpeed = b.NetworkSpeed
 	}
 	if b.MaxKillTimeout != "" {
 		result.MaxKillTimeout = b.MaxKillTimeout
 	}
+	if b.TmpDirSize != 0 {
+		result.TmpDirSize = b.TmpDirSize
+	}
+	if b.GCInterval != "" {
+		result.GCInterval = b.GCInterval
+	}
+	if b.GCDiskUsageThreshold != 0 {
+		result.GCDiskUsageThreshold = b.GCDiskUsageThreshold
+	}
 	if b.ClientMaxPort != 0 {
 		result.ClientMaxPort = b.ClientMaxPort
 	}
 	if b.ClientMinPort != 0 {
 		result.ClientMinPort = b.ClientMinPort
 	}
+	if b.MinDynamicPort != 0 {
+		result.MinDynamicPort = b.MinDynamicPort
+	}
+	if b.MaxDynamicPort != 0 {
+		result.MaxDynamicPort = b.MaxDynamicPort
+	}
 	if b.Reserved != nil {
 		result.Reserved = result.Reserved.Merge(b.Reserved)
 	}
@@ -721,6 +933,12 @@ func (a *ClientConfig) Merge(b *ClientConfig) *ClientConfig {
 	// Add the servers
 	result.Servers = append(result.Servers, b.Servers...)
 
+	// Add the alloc dirs
+	result.AllocDirs = append(result.AllocDirs, b.AllocDirs...)
+
+	// Add the host volumes, later ones overriding earlier ones of the same name
+	result.HostVolumes = append(result.HostVolumes, b.HostVolumes...)
+
 	// Add the options map values
 	if result.Options == nil {
 		result.Options = make(map[string]string)
@@ -773,6 +991,9 @@ func (a *Telemetry) Merge(b *Telemetry) *Telemetry {
 	if b.PublishAllocationMetrics {
 		result.PublishAllocationMetrics = true
 	}
+	if b.AggregateAllocationMetrics {
+		result.AggregateAllocationMetrics = true
+	}
 	if b.CirconusAPIToken != "" {
 		result.CirconusAPIToken = b.CirconusAPIToken
 	}
@@ -854,6 +1075,9 @@ func (a *AdvertiseAddrs) Merge(b *AdvertiseAddrs) *AdvertiseAddrs {
 	if b.HTTP != "" {
 		result.HTTP = b.HTTP
 	}
+	if b.Stream != "" {
+		result.Stream = b.Stream
+	}
 	return &result
 }
 
@@ -876,6 +1100,57 @@ func (a *AtlasConfig) Merge(b *AtlasConfig) *AtlasConfig {
 	return &result
 }
 
+// Merge is used to merge two CORS configs together
+func (c *CORSConfig) Merge(b *CORSConfig) *CORSConfig {
+	result := *c
+
+	if len(b.AllowedOrigins) != 0 {
+		result.AllowedOrigins = b.AllowedOrigins
+	}
+	if len(b.AllowedMethods) != 0 {
+		result.AllowedMethods = b.AllowedMethods
+	}
+	return &result
+}
+
+// Merge is used to merge two HTTP server configs together
+func (h *HTTPServerConfig) Merge(b *HTTPServerConfig) *HTTPServerConfig {
+	result := *h
+
+	if b.ReadTimeout != 0 {
+		result.ReadTimeout = b.ReadTimeout
+	}
+	if b.WriteTimeout != 0 {
+		result.WriteTimeout = b.WriteTimeout
+	}
+	if b.IdleTimeout != 0 {
+		result.IdleTimeout = b.IdleTimeout
+	}
+	if b.MaxConnsPerClient != 0 {
+		result.MaxConnsPerClient = b.MaxConnsPerClient
+	}
+	if b.DisableCompression {
+		result.DisableCompression = true
+	}
+	return &result
+}
+
+// Merge is used to merge two unix socket configs together
+func (u *UnixSocketConfig) Merge(b *UnixSocketConfig) *UnixSocketConfig {
+	result := *u
+
+	if b.User != "" {
+		result.User = b.User
+	}
+	if b.Group != "" {
+		result.Group = b.Group
+	}
+	if b.Mode != "" {
+		result.Mode = b.Mode
+	}
+	return &result
+}
+
 func (r *Resources) Merge(b *Resources) *Resources {
 	result := *r
 	if b.CPU != 0 {
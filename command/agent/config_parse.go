@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/mitchellh/mapstructure"
 )
@@ -94,7 +95,11 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 		"atlas",
 		"consul",
 		"vault",
+		"tls",
 		"http_api_response_headers",
+		"cors",
+		"http_server",
+		"unix_sockets",
 	}
 	if err := checkHCLKeys(list, valid); err != nil {
 		return multierror.Prefix(err, "config:")
@@ -115,7 +120,11 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 	delete(m, "atlas")
 	delete(m, "consul")
 	delete(m, "vault")
+	delete(m, "tls")
 	delete(m, "http_api_response_headers")
+	delete(m, "cors")
+	delete(m, "http_server")
+	delete(m, "unix_sockets")
 
 	// Decode the rest
 	if err := mapstructure.WeakDecode(m, result); err != nil {
@@ -185,6 +194,34 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse the TLS config
+	if o := list.Filter("tls"); len(o.Items) > 0 {
+		if err := parseTLSConfig(&result.TLSConfig, o); err != nil {
+			return multierror.Prefix(err, "tls ->")
+		}
+	}
+
+	// Parse the CORS config
+	if o := list.Filter("cors"); len(o.Items) > 0 {
+		if err := parseCORS(&result.CORS, o); err != nil {
+			return multierror.Prefix(err, "cors ->")
+		}
+	}
+
+	// Parse the HTTP server config
+	if o := list.Filter("http_server"); len(o.Items) > 0 {
+		if err := parseHTTPServer(&result.HTTPServer, o); err != nil {
+			return multierror.Prefix(err, "http_server ->")
+		}
+	}
+
+	// Parse the unix sockets config
+	if o := list.Filter("unix_sockets"); len(o.Items) > 0 {
+		if err := parseUnixSockets(&result.UnixSockets, o); err != nil {
+			return multierror.Prefix(err, "unix_sockets ->")
+		}
+	}
+
 	// Parse out http_api_response_headers fields. These are in HCL as a list so
 	// we need to iterate over them and merge them.
 	if headersO := list.Filter("http_api_response_headers"); len(headersO.Items) > 0 {
@@ -216,6 +253,7 @@ func parsePorts(result **Ports, list *ast.ObjectList) error {
 		"http",
 		"rpc",
 		"serf",
+		"stream",
 	}
 	if err := checkHCLKeys(listVal, valid); err != nil {
 		return err
@@ -280,6 +318,7 @@ func parseAdvertise(result **AdvertiseAddrs, list *ast.ObjectList) error {
 		"http",
 		"rpc",
 		"serf",
+		"stream",
 	}
 	if err := checkHCLKeys(listVal, valid); err != nil {
 		return err
@@ -321,17 +360,26 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 		"state_dir",
 		"alloc_dir",
 		"servers",
+		"alloc_dirs",
+		"alloc_dir_policy",
 		"node_class",
 		"options",
 		"meta",
 		"chroot_env",
 		"network_interface",
+		"network_cidr",
 		"network_speed",
 		"max_kill_timeout",
+		"tmp_dir_size",
+		"gc_interval",
+		"gc_disk_usage_threshold",
 		"client_max_port",
 		"client_min_port",
+		"min_dynamic_port",
+		"max_dynamic_port",
 		"reserved",
 		"stats",
+		"host_volume",
 	}
 	if err := checkHCLKeys(listVal, valid); err != nil {
 		return err
@@ -347,6 +395,7 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 	delete(m, "chroot_env")
 	delete(m, "reserved")
 	delete(m, "stats")
+	delete(m, "host_volume")
 
 	var config ClientConfig
 	if err := mapstructure.WeakDecode(m, &config); err != nil {
@@ -402,6 +451,13 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse host volumes
+	if o := listVal.Filter("host_volume"); len(o.Items) > 0 {
+		if err := parseHostVolumes(&config.HostVolumes, o); err != nil {
+			return multierror.Prefix(err, "host_volume ->")
+		}
+	}
+
 	*result = &config
 	return nil
 }
@@ -452,6 +508,34 @@ func parseReserved(result **Resources, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseHostVolumes(result *[]*structs.ClientHostVolumeConfig, list *ast.ObjectList) error {
+	for _, item := range list.Children().Items {
+		n := item.Keys[0].Token.Value().(string)
+
+		// Check for invalid keys
+		valid := []string{
+			"path",
+			"read_only",
+		}
+		if err := checkHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		var volume structs.ClientHostVolumeConfig
+		if err := mapstructure.WeakDecode(m, &volume); err != nil {
+			return err
+		}
+		volume.Name = n
+		*result = append(*result, &volume)
+	}
+	return nil
+}
+
 func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -484,6 +568,9 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		"retry_max",
 		"retry_interval",
 		"rejoin_after_leave",
+		"preemption",
+		"encrypt",
+		"autopilot",
 	}
 	if err := checkHCLKeys(listVal, valid); err != nil {
 		return err
@@ -494,15 +581,125 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		return err
 	}
 
+	delete(m, "preemption")
+	delete(m, "autopilot")
+
 	var config ServerConfig
 	if err := mapstructure.WeakDecode(m, &config); err != nil {
 		return err
 	}
 
+	// Parse the preemption config
+	if o := listVal.Filter("preemption"); len(o.Items) > 0 {
+		if err := parsePreemption(&config.Preemption, o); err != nil {
+			return multierror.Prefix(err, "preemption ->")
+		}
+	}
+
+	// Parse the autopilot config
+	if o := listVal.Filter("autopilot"); len(o.Items) > 0 {
+		if err := parseAutopilot(&config.Autopilot, o); err != nil {
+			return multierror.Prefix(err, "autopilot ->")
+		}
+	}
+
 	*result = &config
 	return nil
 }
 
+func parsePreemption(result **structs.PreemptionConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'preemption' block allowed")
+	}
+
+	// Get our preemption object
+	obj := list.Items[0]
+
+	// Value should be an object
+	var listVal *ast.ObjectList
+	if ot, ok := obj.Val.(*ast.ObjectType); ok {
+		listVal = ot.List
+	} else {
+		return fmt.Errorf("preemption value: should be an object")
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"system_scheduler_enabled",
+		"service_scheduler_enabled",
+		"batch_scheduler_enabled",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	// Unset scheduler types keep their default
+	preemption := structs.DefaultPreemptionConfig()
+	if err := mapstructure.WeakDecode(m, &preemption); err != nil {
+		return err
+	}
+
+	*result = &preemption
+	return nil
+}
+
+func parseAutopilot(result **structs.AutopilotConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'autopilot' block allowed")
+	}
+
+	// Get our autopilot object
+	obj := list.Items[0]
+
+	// Value should be an object
+	var listVal *ast.ObjectList
+	if ot, ok := obj.Val.(*ast.ObjectType); ok {
+		listVal = ot.List
+	} else {
+		return fmt.Errorf("autopilot value: should be an object")
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"cleanup_dead_servers",
+		"last_contact_threshold",
+		"max_trailing_logs",
+		"server_stabilization_time",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	// Unset options keep their default
+	autopilot := structs.DefaultAutopilotConfig()
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &autopilot,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*result = &autopilot
+	return nil
+}
+
 func parseTelemetry(result **Telemetry, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -520,6 +717,7 @@ func parseTelemetry(result **Telemetry, list *ast.ObjectList) error {
 		"collection_interval",
 		"publish_allocation_metrics",
 		"publish_node_metrics",
+		"aggregate_allocation_metrics",
 		"circonus_api_token",
 		"circonus_api_app",
 		"circonus_api_url",
@@ -589,6 +787,115 @@ func parseAtlas(result **AtlasConfig, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseCORS(result **CORSConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'cors' block allowed")
+	}
+
+	// Get our CORS object
+	listVal := list.Items[0].Val
+
+	// Check for invalid keys
+	valid := []string{
+		"allowed_origins",
+		"allowed_methods",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var cors CORSConfig
+	if err := mapstructure.WeakDecode(m, &cors); err != nil {
+		return err
+	}
+	*result = &cors
+	return nil
+}
+
+func parseHTTPServer(result **HTTPServerConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'http_server' block allowed")
+	}
+
+	// Get our HTTP server object
+	listVal := list.Items[0].Val
+
+	// Check for invalid keys
+	valid := []string{
+		"read_timeout",
+		"write_timeout",
+		"idle_timeout",
+		"max_conns_per_client",
+		"disable_compression",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var httpServer HTTPServerConfig
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &httpServer,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+	if httpServer.MaxConnsPerClient < 0 {
+		return fmt.Errorf("max_conns_per_client must not be negative")
+	}
+
+	*result = &httpServer
+	return nil
+}
+
+func parseUnixSockets(result **UnixSocketConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'unix_sockets' block allowed")
+	}
+
+	// Get our unix sockets object
+	listVal := list.Items[0].Val
+
+	// Check for invalid keys
+	valid := []string{
+		"user",
+		"group",
+		"mode",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var unixSockets UnixSocketConfig
+	if err := mapstructure.WeakDecode(m, &unixSockets); err != nil {
+		return err
+	}
+	*result = &unixSockets
+	return nil
+}
+
 func parseConsulConfig(result **config.ConsulConfig, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -692,6 +999,43 @@ func parseVaultConfig(result **config.VaultConfig, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseTLSConfig(result **config.TLSConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'tls' block allowed")
+	}
+
+	// Get the TLS object
+	listVal := list.Items[0].Val
+
+	valid := []string{
+		"http",
+		"rpc",
+		"verify_server_hostname",
+		"verify_https_client",
+		"ca_file",
+		"cert_file",
+		"key_file",
+	}
+
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var tlsConfig config.TLSConfig
+	if err := mapstructure.WeakDecode(m, &tlsConfig); err != nil {
+		return err
+	}
+
+	*result = &tlsConfig
+	return nil
+}
+
 func checkHCLKeys(node ast.Node, valid []string) error {
 	var list *ast.ObjectList
 	switch n := node.(type) {
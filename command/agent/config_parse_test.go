@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 )
 
@@ -36,15 +37,18 @@ func TestConfig_Parse(t *testing.T) {
 					Serf: "127.0.0.3",
 				},
 				AdvertiseAddrs: &AdvertiseAddrs{
-					RPC:  "127.0.0.3",
-					Serf: "127.0.0.4",
+					RPC:    "127.0.0.3",
+					Serf:   "127.0.0.4",
+					Stream: "127.0.0.5",
 				},
 				Client: &ClientConfig{
-					Enabled:   true,
-					StateDir:  "/tmp/client-state",
-					AllocDir:  "/tmp/alloc",
-					Servers:   []string{"a.b.c:80", "127.0.0.1:1234"},
-					NodeClass: "linux-medium-64bit",
+					Enabled:        true,
+					StateDir:       "/tmp/client-state",
+					AllocDir:       "/tmp/alloc",
+					AllocDirs:      []string{"/tmp/alloc-disk1", "/tmp/alloc-disk2"},
+					AllocDirPolicy: "round-robin",
+					Servers:        []string{"a.b.c:80", "127.0.0.1:1234"},
+					NodeClass:      "linux-medium-64bit",
 					Meta: map[string]string{
 						"foo": "bar",
 						"baz": "zip",
@@ -57,11 +61,17 @@ func TestConfig_Parse(t *testing.T) {
 						"/opt/myapp/etc": "/etc",
 						"/opt/myapp/bin": "/bin",
 					},
-					NetworkInterface: "eth0",
-					NetworkSpeed:     100,
-					MaxKillTimeout:   "10s",
-					ClientMinPort:    1000,
-					ClientMaxPort:    2000,
+					NetworkInterface:     "eth0",
+					NetworkCIDR:          "10.0.0.0/8",
+					NetworkSpeed:         100,
+					MaxKillTimeout:       "10s",
+					TmpDirSize:           128,
+					GCInterval:           "2m",
+					GCDiskUsageThreshold: 90,
+					ClientMinPort:        1000,
+					ClientMaxPort:        2000,
+					MinDynamicPort:       25000,
+					MaxDynamicPort:       30000,
 					Reserved: &Resources{
 						CPU:                 10,
 						MemoryMB:            10,
@@ -70,6 +80,13 @@ func TestConfig_Parse(t *testing.T) {
 						ReservedPorts:       "1,100,10-12",
 						ParsedReservedPorts: []int{1, 10, 11, 12, 100},
 					},
+					HostVolumes: []*structs.ClientHostVolumeConfig{
+						&structs.ClientHostVolumeConfig{
+							Name:     "shared_data",
+							Path:     "/srv/data",
+							ReadOnly: true,
+						},
+					},
 				},
 				Server: &ServerConfig{
 					Enabled:           true,
@@ -85,15 +102,27 @@ func TestConfig_Parse(t *testing.T) {
 					RetryInterval:     "15s",
 					RejoinAfterLeave:  true,
 					RetryMaxAttempts:  3,
+					EncryptKey:        "abc",
+					Preemption: &structs.PreemptionConfig{
+						SystemSchedulerEnabled:  true,
+						ServiceSchedulerEnabled: true,
+					},
+					Autopilot: &structs.AutopilotConfig{
+						CleanupDeadServers:      false,
+						LastContactThreshold:    200 * time.Millisecond,
+						MaxTrailingLogs:         250,
+						ServerStabilizationTime: 30 * time.Second,
+					},
 				},
 				Telemetry: &Telemetry{
-					StatsiteAddr:             "127.0.0.1:1234",
-					StatsdAddr:               "127.0.0.1:2345",
-					DisableHostname:          true,
-					CollectionInterval:       "3s",
-					collectionInterval:       3 * time.Second,
-					PublishAllocationMetrics: true,
-					PublishNodeMetrics:       true,
+					StatsiteAddr:               "127.0.0.1:1234",
+					StatsdAddr:                 "127.0.0.1:2345",
+					DisableHostname:            true,
+					CollectionInterval:         "3s",
+					collectionInterval:         3 * time.Second,
+					PublishAllocationMetrics:   true,
+					PublishNodeMetrics:         true,
+					AggregateAllocationMetrics: true,
 				},
 				LeaveOnInt:                true,
 				LeaveOnTerm:               true,
@@ -107,6 +136,22 @@ func TestConfig_Parse(t *testing.T) {
 					Join:           true,
 					Endpoint:       "127.0.0.1:1234",
 				},
+				CORS: &CORSConfig{
+					AllowedOrigins: []string{"https://ui.example.com", "http://localhost:4200"},
+					AllowedMethods: []string{"GET", "PUT"},
+				},
+				HTTPServer: &HTTPServerConfig{
+					ReadTimeout:        30 * time.Second,
+					WriteTimeout:       time.Minute,
+					IdleTimeout:        5 * time.Minute,
+					MaxConnsPerClient:  100,
+					DisableCompression: true,
+				},
+				UnixSockets: &UnixSocketConfig{
+					User:  "1000",
+					Group: "1000",
+					Mode:  "0700",
+				},
 				Consul: &config.ConsulConfig{
 					ServerServiceName: "nomad",
 					ClientServiceName: "nomad-client",
@@ -135,6 +180,15 @@ func TestConfig_Parse(t *testing.T) {
 					TaskTokenTTL:         "1s",
 					Token:                "12345",
 				},
+				TLSConfig: &config.TLSConfig{
+					EnableHTTP:           true,
+					EnableRPC:            true,
+					VerifyServerHostname: true,
+					VerifyHTTPSClient:    true,
+					CAFile:               "foo",
+					CertFile:             "bar",
+					KeyFile:              "pipe",
+				},
 				HTTPAPIResponseHeaders: map[string]string{
 					"Access-Control-Allow-Origin": "*",
 				},
@@ -44,10 +44,11 @@ func TestConfig_Merge(t *testing.T) {
 			CirconusBrokerSelectTag:            "dc:dc1",
 		},
 		Client: &ClientConfig{
-			Enabled:   false,
-			StateDir:  "/tmp/state1",
-			AllocDir:  "/tmp/alloc1",
-			NodeClass: "class1",
+			Enabled:        false,
+			StateDir:       "/tmp/state1",
+			AllocDir:       "/tmp/alloc1",
+			AllocDirPolicy: "most-free",
+			NodeClass:      "class1",
 			Options: map[string]string{
 				"foo": "bar",
 			},
@@ -95,6 +96,15 @@ func TestConfig_Merge(t *testing.T) {
 		HTTPAPIResponseHeaders: map[string]string{
 			"Access-Control-Allow-Origin": "*",
 		},
+		CORS: &CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+		HTTPServer: &HTTPServerConfig{
+			ReadTimeout: 10 * time.Second,
+		},
+		UnixSockets: &UnixSocketConfig{
+			Mode: "0770",
+		},
 		Vault: &config.VaultConfig{
 			Token:                "1",
 			AllowUnauthenticated: false,
@@ -107,6 +117,15 @@ func TestConfig_Merge(t *testing.T) {
 			TLSSkipVerify:        false,
 			TLSServerName:        "1",
 		},
+		TLSConfig: &config.TLSConfig{
+			EnableHTTP:           false,
+			EnableRPC:            false,
+			VerifyServerHostname: false,
+			VerifyHTTPSClient:    false,
+			CAFile:               "1",
+			CertFile:             "1",
+			KeyFile:              "1",
+		},
 		Consul: &config.ConsulConfig{
 			ServerServiceName: "1",
 			ClientServiceName: "1",
@@ -145,6 +164,7 @@ func TestConfig_Merge(t *testing.T) {
 			DisableHostname:                    true,
 			PublishNodeMetrics:                 true,
 			PublishAllocationMetrics:           true,
+			AggregateAllocationMetrics:         true,
 			CirconusAPIToken:                   "1",
 			CirconusAPIApp:                     "nomad",
 			CirconusAPIURL:                     "https://api.circonus.com/v2",
@@ -158,11 +178,13 @@ func TestConfig_Merge(t *testing.T) {
 			CirconusBrokerSelectTag:            "dc:dc2",
 		},
 		Client: &ClientConfig{
-			Enabled:   true,
-			StateDir:  "/tmp/state2",
-			AllocDir:  "/tmp/alloc2",
-			NodeClass: "class2",
-			Servers:   []string{"server2"},
+			Enabled:        true,
+			StateDir:       "/tmp/state2",
+			AllocDir:       "/tmp/alloc2",
+			AllocDirs:      []string{"/tmp/alloc3"},
+			AllocDirPolicy: "round-robin",
+			NodeClass:      "class2",
+			Servers:        []string{"server2"},
 			Meta: map[string]string{
 				"baz": "zip",
 			},
@@ -170,11 +192,16 @@ func TestConfig_Merge(t *testing.T) {
 				"foo": "bar",
 				"baz": "zip",
 			},
-			ChrootEnv:      map[string]string{},
-			ClientMaxPort:  20000,
-			ClientMinPort:  22000,
-			NetworkSpeed:   105,
-			MaxKillTimeout: "50s",
+			ChrootEnv:            map[string]string{},
+			ClientMaxPort:        20000,
+			ClientMinPort:        22000,
+			MinDynamicPort:       25000,
+			MaxDynamicPort:       30000,
+			NetworkCIDR:          "10.0.0.0/8",
+			NetworkSpeed:         105,
+			MaxKillTimeout:       "50s",
+			GCInterval:           "5m",
+			GCDiskUsageThreshold: 95,
 			Reserved: &Resources{
 				CPU:                 15,
 				MemoryMB:            15,
@@ -194,10 +221,18 @@ func TestConfig_Merge(t *testing.T) {
 			NodeGCThreshold:   "12h",
 			HeartbeatGrace:    "2m",
 			RejoinAfterLeave:  true,
+			EncryptKey:        "abc",
 			StartJoin:         []string{"1.1.1.1"},
 			RetryJoin:         []string{"1.1.1.1"},
 			RetryInterval:     "10s",
 			retryInterval:     time.Second * 10,
+			Preemption: &structs.PreemptionConfig{
+				BatchSchedulerEnabled: true,
+			},
+			Autopilot: &structs.AutopilotConfig{
+				CleanupDeadServers:   true,
+				LastContactThreshold: time.Second,
+			},
 		},
 		Ports: &Ports{
 			HTTP: 20000,
@@ -210,8 +245,9 @@ func TestConfig_Merge(t *testing.T) {
 			Serf: "127.0.0.2",
 		},
 		AdvertiseAddrs: &AdvertiseAddrs{
-			RPC:  "127.0.0.2",
-			Serf: "127.0.0.2",
+			RPC:    "127.0.0.2",
+			Serf:   "127.0.0.2",
+			Stream: "127.0.0.2",
 		},
 		Atlas: &AtlasConfig{
 			Infrastructure: "hashicorp/test2",
@@ -223,6 +259,22 @@ func TestConfig_Merge(t *testing.T) {
 			"Access-Control-Allow-Origin":  "*",
 			"Access-Control-Allow-Methods": "GET, POST, OPTIONS",
 		},
+		CORS: &CORSConfig{
+			AllowedOrigins: []string{"https://ui.example.com"},
+			AllowedMethods: []string{"GET"},
+		},
+		HTTPServer: &HTTPServerConfig{
+			ReadTimeout:        20 * time.Second,
+			WriteTimeout:       time.Minute,
+			IdleTimeout:        5 * time.Minute,
+			MaxConnsPerClient:  50,
+			DisableCompression: true,
+		},
+		UnixSockets: &UnixSocketConfig{
+			User:  "1000",
+			Group: "1000",
+			Mode:  "0700",
+		},
 		Vault: &config.VaultConfig{
 			Token:                "2",
 			AllowUnauthenticated: true,
@@ -235,6 +287,15 @@ func TestConfig_Merge(t *testing.T) {
 			TLSSkipVerify:        true,
 			TLSServerName:        "2",
 		},
+		TLSConfig: &config.TLSConfig{
+			EnableHTTP:           true,
+			EnableRPC:            true,
+			VerifyServerHostname: true,
+			VerifyHTTPSClient:    true,
+			CAFile:               "2",
+			CertFile:             "2",
+			KeyFile:              "2",
+		},
 		Consul: &config.ConsulConfig{
 			ServerServiceName: "2",
 			ClientServiceName: "2",
@@ -533,3 +594,25 @@ func TestResources_ParseReserved(t *testing.T) {
 
 	}
 }
+
+func TestClientConfig_ValidateDynamicPorts(t *testing.T) {
+	cases := []struct {
+		Min, Max int
+		Err      bool
+	}{
+		{0, 0, false},
+		{25000, 30000, false},
+		{25000, 25000, false},
+		{30000, 25000, true},
+		{0, 1000, true},
+		{-1, 0, true},
+		{0, 70000, true},
+	}
+
+	for i, tc := range cases {
+		c := &ClientConfig{MinDynamicPort: tc.Min, MaxDynamicPort: tc.Max}
+		if err := c.validateDynamicPorts(); (err != nil) != tc.Err {
+			t.Fatalf("test case %d: %v", i, err)
+		}
+	}
+}
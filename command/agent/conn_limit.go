@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// connLimiter limits the number of concurrent HTTP connections of each client
+// IP. It is installed as the ConnState hook of the HTTP server and closes the
+// new connections of the clients already at the limit.
+type connLimiter struct {
+	max    int
+	logger *log.Logger
+
+	// conns tracks the accepted connections and their client IP, counts the
+	// accepted connections of each client IP
+	conns  map[net.Conn]string
+	counts map[string]int
+	l      sync.Mutex
+}
+
+// newConnLimiter returns a limiter of max connections per client IP
+func newConnLimiter(max int, logger *log.Logger) *connLimiter {
+	return &connLimiter{
+		max:    max,
+		logger: logger,
+		conns:  make(map[net.Conn]string),
+		counts: make(map[string]int),
+	}
+}
+
+// connState tracks the connections through the state changes reported by the
+// HTTP server
+func (c *connLimiter) connState(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		if !c.accept(conn) {
+			c.logger.Printf("[WARN] http: Too many connections from %v, closing connection", conn.RemoteAddr())
+			conn.Close()
+		}
+	case http.StateHijacked, http.StateClosed:
+		c.release(conn)
+	}
+}
+
+// accept tracks the connection if its client IP is under the limit. The
+// connections of the unix domain socket are not limited.
+func (c *connLimiter) accept(conn net.Conn) bool {
+	if conn.RemoteAddr().Network() == "unix" {
+		return true
+	}
+	ip := connClientIP(conn)
+
+	c.l.Lock()
+	defer c.l.Unlock()
+	if c.counts[ip] >= c.max {
+		return false
+	}
+	c.conns[conn] = ip
+	c.counts[ip]++
+	return true
+}
+
+// release stops tracking the connection
+func (c *connLimiter) release(conn net.Conn) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	ip, ok := c.conns[conn]
+	if !ok {
+		return
+	}
+	delete(c.conns, conn)
+	if c.counts[ip]--; c.counts[ip] == 0 {
+		delete(c.counts, ip)
+	}
+}
+
+// connClientIP returns the IP of the remote end of the connection
+func connClientIP(conn net.Conn) string {
+	addr := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
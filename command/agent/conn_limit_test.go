@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// testConn is a connection of a given remote address tracking its closing
+type testConn struct {
+	net.Conn
+	addr   net.Addr
+	closed bool
+}
+
+func (c *testConn) RemoteAddr() net.Addr { return c.addr }
+func (c *testConn) Close() error         { c.closed = true; return nil }
+
+func newTestConn(addr string) *testConn {
+	tcpAddr, _ := net.ResolveTCPAddr("tcp", addr)
+	return &testConn{addr: tcpAddr}
+}
+
+func TestConnLimiter(t *testing.T) {
+	l := newConnLimiter(2, log.New(os.Stderr, "", log.LstdFlags))
+
+	a1 := newTestConn("10.0.0.1:1000")
+	a2 := newTestConn("10.0.0.1:1001")
+	a3 := newTestConn("10.0.0.1:1002")
+	b1 := newTestConn("10.0.0.2:1000")
+	for _, c := range []*testConn{a1, a2, a3, b1} {
+		l.connState(c, http.StateNew)
+	}
+
+	// The third connection of the same IP is closed
+	if a1.closed || a2.closed || b1.closed {
+		t.Fatalf("connections under the limit closed")
+	}
+	if !a3.closed {
+		t.Fatalf("connection over the limit not closed")
+	}
+
+	// Closing the rejected connection doesn't release a slot
+	l.connState(a3, http.StateClosed)
+	if n := l.counts["10.0.0.1"]; n != 2 {
+		t.Fatalf("bad: %d", n)
+	}
+
+	// Closing an accepted connection releases its slot
+	l.connState(a1, http.StateClosed)
+	a4 := newTestConn("10.0.0.1:1003")
+	l.connState(a4, http.StateNew)
+	if a4.closed {
+		t.Fatalf("connection under the limit closed")
+	}
+
+	for _, c := range []*testConn{a2, a4, b1} {
+		l.connState(c, http.StateClosed)
+	}
+	if len(l.conns) != 0 || len(l.counts) != 0 {
+		t.Fatalf("connections still tracked: %v %v", l.conns, l.counts)
+	}
+}
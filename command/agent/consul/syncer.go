@@ -1001,6 +1001,51 @@ func (c *Syncer) RemovePeriodicHandler(name string) {
 	delete(c.periodicCallbacks, name)
 }
 
+// ChecksPassing returns the number of Consul checks registered for the
+// services of the given domain and how many of them are passing. The checks
+// may have been registered by another Syncer, such as the one of an executor.
+func (c *Syncer) ChecksPassing(domain ServiceDomain) (int, int, error) {
+	checks, err := c.client.Agent().Checks()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	prefix := fmt.Sprintf("%s-%s-", nomadServicePrefix, domain)
+	var passing, total int
+	for _, check := range checks {
+		if !strings.HasPrefix(check.ServiceID, prefix) {
+			continue
+		}
+		total++
+		if check.Status == consul.HealthPassing {
+			passing++
+		}
+	}
+	return passing, total, nil
+}
+
+// CheckStatuses returns the status of the Consul checks registered for the
+// services of the given domain by check name. If several checks share a name,
+// the status of a critical one is returned.
+func (c *Syncer) CheckStatuses(domain ServiceDomain) (map[string]string, error) {
+	checks, err := c.client.Agent().Checks()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s-%s-", nomadServicePrefix, domain)
+	statuses := make(map[string]string)
+	for _, check := range checks {
+		if !strings.HasPrefix(check.ServiceID, prefix) {
+			continue
+		}
+		if statuses[check.Name] != consul.HealthCritical {
+			statuses[check.Name] = check.Status
+		}
+	}
+	return statuses, nil
+}
+
 // ConsulClient returns the Consul client used by the Syncer.
 func (c *Syncer) ConsulClient() *consul.Client {
 	return c.client
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// corsExposedHeaders are the response headers of the HTTP API exposed to
+	// the cross-origin callers
+	corsExposedHeaders = "X-Nomad-Index, X-Nomad-KnownLeader, X-Nomad-LastContact"
+
+	// corsMaxAge is the number of seconds browsers may cache the result of a
+	// preflight request
+	corsMaxAge = "3600"
+)
+
+// defaultCORSMethods are the methods allowed in cross-origin requests when none
+// are configured
+var defaultCORSMethods = []string{"GET", "PUT", "POST", "DELETE"}
+
+// corsHandler wraps an HTTP handler to set the CORS headers on the responses
+// to the allowed origins and to answer their preflight requests.
+type corsHandler struct {
+	handler    http.Handler
+	allOrigins bool
+	origins    map[string]struct{}
+	methods    map[string]struct{}
+	allowed    string
+}
+
+// newCORSHandler wraps the handler with the CORS support of the given
+// configuration. The handler is returned as is if no origin is allowed.
+func newCORSHandler(config *CORSConfig, handler http.Handler) http.Handler {
+	if config == nil || len(config.AllowedOrigins) == 0 {
+		return handler
+	}
+
+	c := &corsHandler{
+		handler: handler,
+		origins: make(map[string]struct{}, len(config.AllowedOrigins)),
+		methods: make(map[string]struct{}),
+	}
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			c.allOrigins = true
+		}
+		c.origins[origin] = struct{}{}
+	}
+
+	methods := config.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	allowed := make([]string, 0, len(methods))
+	for _, method := range methods {
+		method = strings.ToUpper(method)
+		c.methods[method] = struct{}{}
+		allowed = append(allowed, method)
+	}
+	c.allowed = strings.Join(allowed, ", ")
+	return c
+}
+
+func (c *corsHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		c.handler.ServeHTTP(resp, req)
+		return
+	}
+	resp.Header().Add("Vary", "Origin")
+
+	_, allowed := c.origins[origin]
+	allowed = allowed || c.allOrigins
+
+	// Answer the preflight requests without reaching the API
+	if req.Method == "OPTIONS" && req.Header.Get("Access-Control-Request-Method") != "" {
+		if allowed {
+			c.setAllowOrigin(resp, origin)
+			resp.Header().Set("Access-Control-Allow-Methods", c.allowed)
+			if headers := req.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				resp.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			resp.Header().Set("Access-Control-Max-Age", corsMaxAge)
+		}
+		resp.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if _, ok := c.methods[req.Method]; allowed && ok {
+		c.setAllowOrigin(resp, origin)
+		resp.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+	}
+	c.handler.ServeHTTP(resp, req)
+}
+
+// setAllowOrigin allows the origin to read the response
+func (c *corsHandler) setAllowOrigin(resp http.ResponseWriter, origin string) {
+	if c.allOrigins {
+		resp.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		resp.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+}
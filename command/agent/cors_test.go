@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testCORSHandler(config *CORSConfig) http.Handler {
+	next := http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+	})
+	return newCORSHandler(config, next)
+}
+
+func TestCORSHandler_Disabled(t *testing.T) {
+	h := testCORSHandler(&CORSConfig{})
+
+	req, _ := http.NewRequest("GET", "/v1/jobs", nil)
+	req.Header.Set("Origin", "https://ui.example.com")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if v := resp.Header().Get("Access-Control-Allow-Origin"); v != "" {
+		t.Fatalf("bad: %q", v)
+	}
+}
+
+func TestCORSHandler_AllowedOrigin(t *testing.T) {
+	h := testCORSHandler(&CORSConfig{
+		AllowedOrigins: []string{"https://ui.example.com"},
+	})
+
+	req, _ := http.NewRequest("GET", "/v1/jobs", nil)
+	req.Header.Set("Origin", "https://ui.example.com")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("bad: %d", resp.Code)
+	}
+	if v := resp.Header().Get("Access-Control-Allow-Origin"); v != "https://ui.example.com" {
+		t.Fatalf("bad: %q", v)
+	}
+	if v := resp.Header().Get("Access-Control-Expose-Headers"); v != corsExposedHeaders {
+		t.Fatalf("bad: %q", v)
+	}
+	if v := resp.Header().Get("Vary"); v != "Origin" {
+		t.Fatalf("bad: %q", v)
+	}
+
+	// Other origins and methods are not allowed
+	req.Header.Set("Origin", "https://other.example.com")
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	if v := resp.Header().Get("Access-Control-Allow-Origin"); v != "" {
+		t.Fatalf("bad: %q", v)
+	}
+
+	req, _ = http.NewRequest("PATCH", "/v1/jobs", nil)
+	req.Header.Set("Origin", "https://ui.example.com")
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	if v := resp.Header().Get("Access-Control-Allow-Origin"); v != "" {
+		t.Fatalf("bad: %q", v)
+	}
+}
+
+func TestCORSHandler_AllOrigins(t *testing.T) {
+	h := testCORSHandler(&CORSConfig{
+		AllowedOrigins: []string{"*"},
+	})
+
+	req, _ := http.NewRequest("GET", "/v1/jobs", nil)
+	req.Header.Set("Origin", "https://ui.example.com")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if v := resp.Header().Get("Access-Control-Allow-Origin"); v != "*" {
+		t.Fatalf("bad: %q", v)
+	}
+}
+
+func TestCORSHandler_Preflight(t *testing.T) {
+	h := testCORSHandler(&CORSConfig{
+		AllowedOrigins: []string{"https://ui.example.com"},
+		AllowedMethods: []string{"get", "put"},
+	})
+
+	req, _ := http.NewRequest("OPTIONS", "/v1/jobs", nil)
+	req.Header.Set("Origin", "https://ui.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNoContent {
+		t.Fatalf("bad: %d", resp.Code)
+	}
+	if v := resp.Header().Get("Access-Control-Allow-Origin"); v != "https://ui.example.com" {
+		t.Fatalf("bad: %q", v)
+	}
+	if v := resp.Header().Get("Access-Control-Allow-Methods"); v != "GET, PUT" {
+		t.Fatalf("bad: %q", v)
+	}
+	if v := resp.Header().Get("Access-Control-Allow-Headers"); v != "Content-Type" {
+		t.Fatalf("bad: %q", v)
+	}
+
+	// Preflight requests of other origins are not allowed
+	req.Header.Set("Origin", "https://other.example.com")
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNoContent {
+		t.Fatalf("bad: %d", resp.Code)
+	}
+	if v := resp.Header().Get("Access-Control-Allow-Origin"); v != "" {
+		t.Fatalf("bad: %q", v)
+	}
+}
@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_DeploymentsList(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d1 := structs.NewDeployment(mock.Job())
+		d1.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 1}
+		d2 := structs.NewDeployment(mock.Job())
+		d2.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 1}
+		if err := state.UpsertDeployment(999, d1); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(1000, d2); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployments", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		if respW.HeaderMap.Get("X-Nomad-LastContact") == "" {
+			t.Fatalf("missing last contact")
+		}
+
+		// Check the deployments
+		n := obj.([]*structs.Deployment)
+		if len(n) != 2 {
+			t.Fatalf("bad: %#v", n)
+		}
+	})
+}
+
+func TestHTTP_DeploymentQuery(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d := structs.NewDeployment(mock.Job())
+		d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 1}
+		if err := state.UpsertDeployment(1000, d); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployment/"+d.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the deployment
+		out := obj.(*structs.Deployment)
+		if out.ID != d.ID {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// Query its allocations
+		req, err = http.NewRequest("GET", "/v1/deployment/"+d.ID+"/allocations", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if allocs := obj.([]*structs.AllocListStub); len(allocs) != 0 {
+			t.Fatalf("bad: %#v", allocs)
+		}
+
+		// A missing deployment is not found
+		req, err = http.NewRequest("GET", "/v1/deployment/"+structs.GenerateUUID(), nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		_, err = s.Server.DeploymentSpecificRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), "deployment not found") {
+			t.Fatalf("expected not found error, got: %v", err)
+		}
+	})
+}
+
+func TestHTTP_DeploymentFail(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d := structs.NewDeployment(mock.Job())
+		d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 1}
+		if err := state.UpsertDeployment(1000, d); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Only writes are allowed
+		req, err := http.NewRequest("GET", "/v1/deployment/"+d.ID+"/fail", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.DeploymentSpecificRequest(respW, req); err == nil {
+			t.Fatalf("expected method error")
+		}
+
+		// Make the HTTP request
+		req, err = http.NewRequest("PUT", "/v1/deployment/"+d.ID+"/fail", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		resp := obj.(structs.DeploymentUpdateResponse)
+		if resp.DeploymentModifyIndex == 0 || resp.RevertedJobVersion != nil {
+			t.Fatalf("bad: %#v", resp)
+		}
+
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.Status != structs.DeploymentStatusFailed {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
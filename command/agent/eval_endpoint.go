@@ -17,6 +17,11 @@ func (s *HTTPServer) EvalsRequest(resp http.ResponseWriter, req *http.Request) (
 		return nil, nil
 	}
 
+	// Parse the filters
+	query := req.URL.Query()
+	args.JobID = query.Get("job")
+	args.Status = query.Get("status")
+
 	var out structs.EvalListResponse
 	if err := s.agent.RPC("Eval.List", &args, &out); err != nil {
 		return nil, err
@@ -29,14 +34,42 @@ func (s *HTTPServer) EvalsRequest(resp http.ResponseWriter, req *http.Request) (
 	return out.Evaluations, nil
 }
 
+func (s *HTTPServer) EvalsBlockedRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.EvalListRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.EvalListResponse
+	if err := s.agent.RPC("Eval.Blocked", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Evaluations == nil {
+		out.Evaluations = make([]*structs.Evaluation, 0)
+	}
+	return out.Evaluations, nil
+}
+
 func (s *HTTPServer) EvalSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	path := strings.TrimPrefix(req.URL.Path, "/v1/evaluation/")
-	switch {
-	case strings.HasSuffix(path, "/allocations"):
+	if strings.HasSuffix(path, "/allocations") {
 		evalID := strings.TrimSuffix(path, "/allocations")
 		return s.evalAllocations(resp, req, evalID)
-	default:
+	}
+
+	switch req.Method {
+	case "GET":
 		return s.evalQuery(resp, req, path)
+	case "DELETE":
+		return s.evalDelete(resp, req, path)
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
 	}
 }
 
@@ -65,10 +98,6 @@ func (s *HTTPServer) evalAllocations(resp http.ResponseWriter, req *http.Request
 }
 
 func (s *HTTPServer) evalQuery(resp http.ResponseWriter, req *http.Request, evalID string) (interface{}, error) {
-	if req.Method != "GET" {
-		return nil, CodedError(405, ErrInvalidMethod)
-	}
-
 	args := structs.EvalSpecificRequest{
 		EvalID: evalID,
 	}
@@ -87,3 +116,18 @@ func (s *HTTPServer) evalQuery(resp http.ResponseWriter, req *http.Request, eval
 	}
 	return out.Eval, nil
 }
+
+func (s *HTTPServer) evalDelete(resp http.ResponseWriter, req *http.Request, evalID string) (interface{}, error) {
+	args := structs.EvalDeleteRequest{
+		Evals: []string{evalID},
+	}
+	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
+
+	var out structs.GenericResponse
+	if err := s.agent.RPC("Eval.Delete", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return nil, nil
+}
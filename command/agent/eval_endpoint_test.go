@@ -53,6 +53,108 @@ func TestHTTP_EvalList(t *testing.T) {
 	})
 }
 
+func TestHTTP_EvalsBlocked(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		eval1 := mock.Eval()
+		eval1.Status = structs.EvalStatusBlocked
+		eval2 := mock.Eval()
+		err := state.UpsertEvals(1000,
+			[]*structs.Evaluation{eval1, eval2})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/evaluations/blocked", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.EvalsBlockedRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the eval
+		e := obj.([]*structs.Evaluation)
+		if len(e) != 1 || e[0].ID != eval1.ID {
+			t.Fatalf("bad: %#v", e)
+		}
+	})
+}
+
+func TestHTTP_EvalList_Pagination(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		eval1 := mock.Eval()
+		eval1.ID = "aaaaaaaa-3350-4b4b-d185-0e1992ed43e9"
+		eval2 := mock.Eval()
+		eval2.ID = "bbbbbbbb-3350-4b4b-d185-0e1992ed43e9"
+		err := state.UpsertEvals(1000,
+			[]*structs.Evaluation{eval1, eval2})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// The first page returns the token of the second
+		req, err := http.NewRequest("GET", "/v1/evaluations?per_page=1", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.EvalsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if e := obj.([]*structs.Evaluation); len(e) != 1 || e[0].ID != eval1.ID {
+			t.Fatalf("bad: %#v", e)
+		}
+		if token := respW.HeaderMap.Get("X-Nomad-NextToken"); token != eval2.ID {
+			t.Fatalf("bad next token: %q", token)
+		}
+
+		// The last page has no next token
+		req, err = http.NewRequest("GET", "/v1/evaluations?per_page=1&next_token="+eval2.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.EvalsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if e := obj.([]*structs.Evaluation); len(e) != 1 || e[0].ID != eval2.ID {
+			t.Fatalf("bad: %#v", e)
+		}
+		if token := respW.HeaderMap.Get("X-Nomad-NextToken"); token != "" {
+			t.Fatalf("bad next token: %q", token)
+		}
+
+		// Invalid page sizes are rejected
+		req, err = http.NewRequest("GET", "/v1/evaluations?per_page=-1", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.EvalsRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.Code != 400 {
+			t.Fatalf("bad code: %d", respW.Code)
+		}
+	})
+}
+
 func TestHTTP_EvalPrefixList(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Directly manipulate the state
@@ -193,3 +295,76 @@ func TestHTTP_EvalQuery(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_EvalList_Filter(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		eval1 := mock.Eval()
+		eval2 := mock.Eval()
+		eval2.JobID = eval1.JobID
+		eval2.Status = structs.EvalStatusComplete
+		eval3 := mock.Eval()
+		err := state.UpsertEvals(1000, []*structs.Evaluation{eval1, eval2, eval3})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/evaluations?job="+eval1.JobID+"&status=complete", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.EvalsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the eval
+		e := obj.([]*structs.Evaluation)
+		if len(e) != 1 || e[0].ID != eval2.ID {
+			t.Fatalf("bad: %#v", e)
+		}
+	})
+}
+
+func TestHTTP_EvalDelete(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		eval := mock.Eval()
+		err := state.UpsertEvals(1000, []*structs.Evaluation{eval})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("DELETE", "/v1/evaluation/"+eval.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		if _, err := s.Server.EvalSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the eval is gone
+		out, err := state.EvalByID(eval.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out != nil {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// eventStreamHeartbeatRate is the default rate at which an empty line is
+	// written when no event is published, to detect closed connections.
+	eventStreamHeartbeatRate = 30 * time.Second
+)
+
+// EventStream streams the events of the cluster as newline delimited JSON
+// objects. The parameters are:
+// * topic: The topic of the events to stream, can be repeated or be a comma
+//          separated list. All the topics are streamed by default.
+// * index: The events following this index are streamed. The events retained
+//          by the servers are streamed first if unset.
+// * wait: The heartbeat rate, an empty line being written when no event is
+//         published during this time.
+func (s *HTTPServer) EventStream(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.EventListRequest{}
+	for _, topics := range req.URL.Query()["topic"] {
+		args.Topics = append(args.Topics, strings.Split(topics, ",")...)
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+	if err := args.Validate(); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.MaxQueryTime == 0 {
+		args.MaxQueryTime = eventStreamHeartbeatRate
+	}
+
+	// The first query is done before writing to the response so that errors
+	// are reported with the status code
+	var out structs.EventListResponse
+	if err := s.agent.RPC("Event.List", &args, &out); err != nil {
+		return nil, err
+	}
+	setMeta(resp, &out.QueryMeta)
+
+	// Create an output that gets flushed on every write
+	output := ioutils.NewWriteFlusher(resp)
+	enc := codec.NewEncoder(output, jsonHandle)
+
+	for {
+		for _, event := range out.Events {
+			if err := enc.Encode(event); err != nil {
+				return nil, nil
+			}
+			if _, err := output.Write([]byte("\n")); err != nil {
+				return nil, nil
+			}
+		}
+
+		// Heartbeat when no event was published
+		if len(out.Events) == 0 {
+			if _, err := output.Write([]byte("\n")); err != nil {
+				return nil, nil
+			}
+		}
+
+		args.MinQueryIndex = out.Index
+		out = structs.EventListResponse{}
+		if err := s.agent.RPC("Event.List", &args, &out); err != nil {
+			s.logger.Printf("[ERR] http: event stream failed: %v", err)
+			return nil, nil
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_EventStream(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Publish events directly in the state
+		state := s.Agent.server.State()
+		state.PublishEvents(1000, []*structs.Event{
+			{Topic: structs.EventTopicNode, Type: structs.EventTypeNodeDeregistered, Key: "foo"},
+		})
+		state.PublishEvents(1001, []*structs.Event{
+			{Topic: structs.EventTopicNode, Type: structs.EventTypeNodeDeregistered, Key: "bar"},
+		})
+
+		// Stream the events following the first one
+		url := fmt.Sprintf("http://%s/v1/event/stream?topic=Node&index=1000", s.Server.addr)
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatalf("bad status: %d", resp.StatusCode)
+		}
+		if resp.Header.Get("X-Nomad-Index") != "1001" {
+			t.Fatalf("bad index: %q", resp.Header.Get("X-Nomad-Index"))
+		}
+
+		dec := json.NewDecoder(resp.Body)
+		var event structs.Event
+		if err := dec.Decode(&event); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if event.Key != "bar" || event.Index != 1001 {
+			t.Fatalf("bad: %#v", event)
+		}
+
+		// New events are streamed
+		state.PublishEvents(1002, []*structs.Event{
+			{Topic: structs.EventTopicNode, Type: structs.EventTypeNodeDeregistered, Key: "baz"},
+		})
+		if err := dec.Decode(&event); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if event.Key != "baz" || event.Index != 1002 {
+			t.Fatalf("bad: %#v", event)
+		}
+	})
+}
+
+func TestHTTP_EventStream_InvalidTopic(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/event/stream?topic=Job,foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.EventStream(respW, req)
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+		if coded, ok := err.(HTTPCodedError); !ok || coded.Code() != 400 {
+			t.Fatalf("bad error: %v", err)
+		}
+	})
+}
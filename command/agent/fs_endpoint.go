@@ -27,10 +27,12 @@ import (
 var (
 	allocIDNotPresentErr  = fmt.Errorf("must provide a valid alloc id")
 	fileNameNotPresentErr = fmt.Errorf("must provide a file name")
+	pathsNotPresentErr    = fmt.Errorf("must provide a list of paths")
 	taskNotPresentErr     = fmt.Errorf("must provide task name")
 	logTypeNotPresentErr  = fmt.Errorf("must provide log type (stdout/stderr)")
 	clientNotRunning      = fmt.Errorf("node is not running a Nomad Client")
 	invalidOrigin         = fmt.Errorf("origin must be start or end")
+	invalidPattern        = fmt.Errorf("pattern is not a valid file name pattern")
 )
 
 const (
@@ -41,6 +43,11 @@ const (
 	// a closed connection without sending any additional data
 	streamHeartbeatRate = 1 * time.Second
 
+	// streamMinHeartbeatRate and streamMaxHeartbeatRate bound the heartbeat
+	// rate a client may request when streaming.
+	streamMinHeartbeatRate = 100 * time.Millisecond
+	streamMaxHeartbeatRate = 1 * time.Minute
+
 	// streamBatchWindow is the window in which file content is batched before
 	// being flushed if the frame size has not been hit.
 	streamBatchWindow = 200 * time.Millisecond
@@ -51,10 +58,11 @@ const (
 	// directory listing.
 	nextLogCheckRate = 100 * time.Millisecond
 
-	// deleteEvent and truncateEvent are the file events that can be sent in a
-	// StreamFrame
+	// deleteEvent, truncateEvent and switchEvent are the file events that can
+	// be sent in a StreamFrame
 	deleteEvent   = "file deleted"
 	truncateEvent = "file truncated"
+	switchEvent   = "file switched"
 
 	// OriginStart and OriginEnd are the available parameters for the origin
 	// argument when streaming a file. They respectively offset from the start
@@ -74,6 +82,12 @@ func (s *HTTPServer) FsRequest(resp http.ResponseWriter, req *http.Request) (int
 		return s.DirectoryListRequest(resp, req)
 	case strings.HasPrefix(path, "stat/"):
 		return s.FileStatRequest(resp, req)
+	case strings.HasPrefix(path, "stat-batch/"):
+		return s.FileStatBatchRequest(resp, req)
+	case strings.HasPrefix(path, "checksum/"):
+		return s.FileChecksumRequest(resp, req)
+	case strings.HasPrefix(path, "tar/"):
+		return s.FileTarRequest(resp, req)
 	case strings.HasPrefix(path, "readat/"):
 		return s.FileReadAtRequest(resp, req)
 	case strings.HasPrefix(path, "cat/"):
@@ -118,6 +132,111 @@ func (s *HTTPServer) FileStatRequest(resp http.ResponseWriter, req *http.Request
 	return fs.Stat(path)
 }
 
+// AllocFileStat is the result of stat-ing a single path of a batch
+type AllocFileStat struct {
+	// Path is the path that was stat-ed
+	Path string
+
+	// FileInfo is the file info of the path if it could be stat-ed
+	FileInfo *allocdir.AllocFileInfo `json:",omitempty"`
+
+	// Error is the error stat-ing the path
+	Error string `json:",omitempty"`
+}
+
+// FileStatBatchRequest stats each path of the JSON list of paths in the
+// request body, returning the file info or error for each in order.
+func (s *HTTPServer) FileStatBatchRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var allocID string
+	if allocID = strings.TrimPrefix(req.URL.Path, "/v1/client/fs/stat-batch/"); allocID == "" {
+		return nil, allocIDNotPresentErr
+	}
+
+	var paths []string
+	if err := decodeBody(req, &paths); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if len(paths) == 0 {
+		return nil, CodedError(400, pathsNotPresentErr.Error())
+	}
+
+	fs, err := s.agent.client.GetAllocFS(allocID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*AllocFileStat, len(paths))
+	for i, path := range paths {
+		out[i] = &AllocFileStat{Path: path}
+		if path == "" {
+			out[i].Error = fileNameNotPresentErr.Error()
+			continue
+		}
+
+		info, err := fs.Stat(path)
+		if err != nil {
+			out[i].Error = err.Error()
+			continue
+		}
+		out[i].FileInfo = info
+	}
+	return out, nil
+}
+
+// FileChecksumRequest returns the SHA256 checksums of the regular files at or
+// below the requested path, keyed by their path relative to it.
+func (s *HTTPServer) FileChecksumRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var allocID, path string
+	if allocID = strings.TrimPrefix(req.URL.Path, "/v1/client/fs/checksum/"); allocID == "" {
+		return nil, allocIDNotPresentErr
+	}
+	if path = req.URL.Query().Get("path"); path == "" {
+		path = "/"
+	}
+	fs, err := s.agent.client.GetAllocFS(allocID)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Checksums(path)
+}
+
+// FileTarRequest streams a tar archive of the JSON list of files in the
+// request body. The files are relative to the requested path, as returned by
+// the checksum endpoint.
+func (s *HTTPServer) FileTarRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var allocID, path string
+	if allocID = strings.TrimPrefix(req.URL.Path, "/v1/client/fs/tar/"); allocID == "" {
+		return nil, allocIDNotPresentErr
+	}
+	if path = req.URL.Query().Get("path"); path == "" {
+		path = "/"
+	}
+
+	var files []string
+	if err := decodeBody(req, &files); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if len(files) == 0 {
+		return nil, CodedError(400, pathsNotPresentErr.Error())
+	}
+
+	fs, err := s.agent.client.GetAllocFS(allocID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header().Set("Content-Type", "application/x-tar")
+	return nil, fs.Tar(path, files, resp)
+}
+
 func (s *HTTPServer) FileReadAtRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	var allocID, path string
 	var offset, limit int64
@@ -218,11 +337,20 @@ type StreamFrame struct {
 	// FileEvent is the last file event that occurred that could cause the
 	// streams position to change or end
 	FileEvent string `json:",omitempty"`
+
+	// HeartbeatRate is only set on the first frame of a stream and is the
+	// negotiated rate at which heartbeat frames are sent.
+	HeartbeatRate time.Duration `json:",omitempty"`
 }
 
 // IsHeartbeat returns if the frame is a heartbeat frame
 func (s *StreamFrame) IsHeartbeat() bool {
-	return s.Offset == 0 && len(s.Data) == 0 && s.File == "" && s.FileEvent == ""
+	return s.Offset == 0 && len(s.Data) == 0 && s.File == "" && s.FileEvent == "" && s.HeartbeatRate == 0
+}
+
+// IsHandshake returns if the frame is the handshake frame opening a stream
+func (s *StreamFrame) IsHandshake() bool {
+	return s.HeartbeatRate != 0
 }
 
 // StreamFramer is used to buffer and send frames as well as heartbeat.
@@ -230,6 +358,7 @@ type StreamFramer struct {
 	out        io.WriteCloser
 	enc        *codec.Encoder
 	frameSize  int
+	hRate      time.Duration
 	heartbeat  *time.Ticker
 	flusher    *time.Ticker
 	shutdownCh chan struct{}
@@ -264,6 +393,7 @@ func NewStreamFramer(out io.WriteCloser, heartbeatRate, batchWindow time.Duratio
 		out:        out,
 		enc:        enc,
 		frameSize:  frameSize,
+		hRate:      heartbeatRate,
 		heartbeat:  heartbeat,
 		flusher:    flusher,
 		outbound:   make(chan *StreamFrame),
@@ -321,6 +451,11 @@ func (s *StreamFramer) run() {
 		s.l.Unlock()
 	}()
 
+	// Send the handshake so the receiver knows how often to expect heartbeats
+	if err = s.enc.Encode(&StreamFrame{HeartbeatRate: s.hRate}); err != nil {
+		return
+	}
+
 	// Start a heartbeat/flusher go-routine. This is done seprately to avoid blocking
 	// the outbound channel.
 	go func() {
@@ -488,12 +623,40 @@ func (s *StreamFramer) Send(file, fileEvent string, data []byte, offset int64) e
 	return nil
 }
 
+// parseHeartbeatRate parses the heartbeat rate requested by a client streaming
+// a file, returning the default rate if none is requested. The requested rate
+// is clamped to the supported bounds.
+func parseHeartbeatRate(rate string) (time.Duration, error) {
+	if rate == "" {
+		return streamHeartbeatRate, nil
+	}
+
+	d, err := time.ParseDuration(rate)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse heartbeat rate: %v", err)
+	}
+
+	switch {
+	case d < streamMinHeartbeatRate:
+		return streamMinHeartbeatRate, nil
+	case d > streamMaxHeartbeatRate:
+		return streamMaxHeartbeatRate, nil
+	default:
+		return d, nil
+	}
+}
+
 // Stream streams the content of a file blocking on EOF.
 // The parameters are:
 // * path: path to file to stream.
 // * offset: The offset to start streaming data at, defaults to zero.
 // * origin: Either "start" or "end" and defines from where the offset is
 //           applied. Defaults to "start".
+// * follow_latest: If true, path is a directory and the most recently created
+//                  file matching pattern is streamed, switching to newer
+//                  matching files as they are created.
+// * pattern: The file name pattern used with follow_latest. Defaults to "*".
+// * heartbeat: The requested heartbeat rate as a duration.
 func (s *HTTPServer) Stream(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	var allocID, path string
 	var err error
@@ -526,6 +689,26 @@ func (s *HTTPServer) Stream(resp http.ResponseWriter, req *http.Request) (interf
 		return nil, invalidOrigin
 	}
 
+	var followLatest bool
+	if followStr := q.Get("follow_latest"); followStr != "" {
+		if followLatest, err = strconv.ParseBool(followStr); err != nil {
+			return nil, fmt.Errorf("Failed to parse follow_latest field to boolean: %v", err)
+		}
+	}
+
+	pattern := q.Get("pattern")
+	if pattern == "" {
+		pattern = "*"
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, invalidPattern
+	}
+
+	hRate, err := parseHeartbeatRate(q.Get("heartbeat"))
+	if err != nil {
+		return nil, err
+	}
+
 	fs, err := s.agent.client.GetAllocFS(allocID)
 	if err != nil {
 		return nil, err
@@ -535,6 +718,28 @@ func (s *HTTPServer) Stream(resp http.ResponseWriter, req *http.Request) (interf
 	if err != nil {
 		return nil, err
 	}
+
+	if followLatest {
+		if !fileInfo.IsDir {
+			return nil, fmt.Errorf("file %q is not a directory", path)
+		}
+
+		// Create an output that gets flushed on every write
+		output := ioutils.NewWriteFlusher(resp)
+
+		// Create the framer
+		framer := NewStreamFramer(output, hRate, streamBatchWindow, streamFrameSize)
+		framer.Run()
+		defer framer.Destroy()
+
+		err = s.streamLatest(offset, origin, path, pattern, fs, framer)
+		if err != nil && err != syscall.EPIPE {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
 	if fileInfo.IsDir {
 		return nil, fmt.Errorf("file %q is a directory", path)
 	}
@@ -549,7 +754,7 @@ func (s *HTTPServer) Stream(resp http.ResponseWriter, req *http.Request) (interf
 	output := ioutils.NewWriteFlusher(resp)
 
 	// Create the framer
-	framer := NewStreamFramer(output, streamHeartbeatRate, streamBatchWindow, streamFrameSize)
+	framer := NewStreamFramer(output, hRate, streamBatchWindow, streamFrameSize)
 	framer.Run()
 	defer framer.Destroy()
 
@@ -684,6 +889,151 @@ OUTER:
 	return nil
 }
 
+// streamLatest streams the most recently created file in dir whose name matches
+// pattern. When a newer matching file is created, streaming switches to it and
+// a switchEvent is sent. The offset and origin only apply to the first file
+// streamed. If the connection is broken an EPIPE error is returned.
+func (s *HTTPServer) streamLatest(offset int64, origin, dir, pattern string,
+	fs allocdir.AllocDirFS, framer *StreamFramer) error {
+
+	// Create a tomb to cancel watch events
+	t := tomb.Tomb{}
+	defer func() {
+		t.Kill(nil)
+		t.Done()
+	}()
+
+	// seen tracks the files that existed when the current file was chosen so
+	// that we only ever switch to newly created files.
+	seen := make(map[string]struct{})
+	first := true
+	for {
+		entries, err := fs.List(dir)
+		if err != nil {
+			return fmt.Errorf("failed to list entries: %v", err)
+		}
+
+		latest := findLatest(entries, pattern, seen)
+		if latest == nil {
+			if first {
+				return fmt.Errorf("no file matching %q found in %q", pattern, dir)
+			}
+
+			// The file we were switching to has disappeared, so wait for
+			// another one to be created.
+			select {
+			case <-time.After(nextLogCheckRate):
+				continue
+			case <-framer.ExitCh():
+				return nil
+			}
+		}
+
+		p := filepath.Join(dir, latest.Name)
+		for _, entry := range entries {
+			seen[entry.Name] = struct{}{}
+		}
+
+		var openOffset int64
+		if first {
+			openOffset = offset
+			if origin == OriginEnd {
+				openOffset = latest.Size - offset
+				if openOffset < 0 {
+					openOffset = 0
+				}
+			}
+			first = false
+		} else if err := framer.Send(p, switchEvent, nil, 0); err != nil {
+			return err
+		}
+
+		eofCancelCh := blockUntilNewerFile(fs, &t, dir, pattern, seen)
+		err = s.stream(openOffset, p, fs, framer, eofCancelCh)
+		if err != nil {
+			// Check if the file got deleted from under us
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return err
+		}
+
+		// Check if the stream ended because the connection was closed
+		select {
+		case <-framer.ExitCh():
+			return nil
+		default:
+		}
+	}
+}
+
+// findLatest returns the most recently modified file in entries whose name
+// matches pattern and that isn't in the exclude set, or nil if there is none.
+func findLatest(entries []*allocdir.AllocFileInfo, pattern string,
+	exclude map[string]struct{}) *allocdir.AllocFileInfo {
+
+	var latest *allocdir.AllocFileInfo
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		if _, ok := exclude[entry.Name]; ok {
+			continue
+		}
+		if match, _ := filepath.Match(pattern, entry.Name); !match {
+			continue
+		}
+
+		// Prefer the newest file, breaking ties by name so that numbered
+		// files pick the highest index.
+		if latest == nil || entry.ModTime.After(latest.ModTime) ||
+			(entry.ModTime.Equal(latest.ModTime) && entry.Name > latest.Name) {
+			latest = entry
+		}
+	}
+	return latest
+}
+
+// blockUntilNewerFile returns a channel that will have data sent when a file
+// matching pattern that isn't in the seen set is created in dir.
+func blockUntilNewerFile(fs allocdir.AllocDirFS, t *tomb.Tomb, dir, pattern string,
+	seen map[string]struct{}) chan error {
+
+	// Copy the seen set since the caller keeps modifying it
+	exclude := make(map[string]struct{}, len(seen))
+	for name := range seen {
+		exclude[name] = struct{}{}
+	}
+
+	next := make(chan error, 1)
+	go func() {
+		ticker := time.NewTicker(nextLogCheckRate)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.Dying():
+				return
+			case <-ticker.C:
+				entries, err := fs.List(dir)
+				if err != nil {
+					next <- fmt.Errorf("failed to list entries: %v", err)
+					close(next)
+					return
+				}
+
+				if findLatest(entries, pattern, exclude) != nil {
+					next <- nil
+					close(next)
+					return
+				}
+			}
+		}
+	}()
+
+	return next
+}
+
 // Logs streams the content of a log blocking on EOF. The parameters are:
 // * task: task name to stream logs for.
 // * type: stdout/stderr to stream.
@@ -691,6 +1041,7 @@ OUTER:
 // * offset: The offset to start streaming data at, defaults to zero.
 // * origin: Either "start" or "end" and defines from where the offset is
 //           applied. Defaults to "start".
+// * heartbeat: The requested heartbeat rate as a duration.
 func (s *HTTPServer) Logs(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	var allocID, task, logType string
 	var follow bool
@@ -735,6 +1086,11 @@ func (s *HTTPServer) Logs(resp http.ResponseWriter, req *http.Request) (interfac
 		return nil, invalidOrigin
 	}
 
+	hRate, err := parseHeartbeatRate(q.Get("heartbeat"))
+	if err != nil {
+		return nil, err
+	}
+
 	fs, err := s.agent.client.GetAllocFS(allocID)
 	if err != nil {
 		return nil, err
@@ -743,15 +1099,15 @@ func (s *HTTPServer) Logs(resp http.ResponseWriter, req *http.Request) (interfac
 	// Create an output that gets flushed on every write
 	output := ioutils.NewWriteFlusher(resp)
 
-	return nil, s.logs(follow, offset, origin, task, logType, fs, output)
+	return nil, s.logs(follow, offset, origin, task, logType, hRate, fs, output)
 }
 
 func (s *HTTPServer) logs(follow bool, offset int64,
-	origin, task, logType string,
+	origin, task, logType string, hRate time.Duration,
 	fs allocdir.AllocDirFS, output io.WriteCloser) error {
 
 	// Create the framer
-	framer := NewStreamFramer(output, streamHeartbeatRate, streamBatchWindow, streamFrameSize)
+	framer := NewStreamFramer(output, hRate, streamBatchWindow, streamFrameSize)
 	framer.Run()
 	defer framer.Destroy()
 
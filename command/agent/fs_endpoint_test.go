@@ -63,6 +63,95 @@ func TestAllocDirFS_Stat_MissingParams(t *testing.T) {
 	})
 }
 
+func TestAllocDirFS_StatBatch_MissingParams(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/fs/stat-batch/foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.FileStatBatchRequest(respW, req)
+		if err == nil || err.Error() != ErrInvalidMethod {
+			t.Fatalf("expected err: %v, actual: %v", ErrInvalidMethod, err)
+		}
+
+		req, err = http.NewRequest("PUT", "/v1/client/fs/stat-batch/", encodeReq([]string{"foo"}))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		_, err = s.Server.FileStatBatchRequest(respW, req)
+		if err != allocIDNotPresentErr {
+			t.Fatalf("expected err: %v, actual: %v", allocIDNotPresentErr, err)
+		}
+
+		req, err = http.NewRequest("PUT", "/v1/client/fs/stat-batch/foo", encodeReq([]string{}))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		_, err = s.Server.FileStatBatchRequest(respW, req)
+		if err == nil || err.Error() != pathsNotPresentErr.Error() {
+			t.Fatalf("expected err: %v, actual: %v", pathsNotPresentErr, err)
+		}
+	})
+}
+
+func TestAllocDirFS_Checksum_MissingParams(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/fs/checksum/", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.FileChecksumRequest(respW, req)
+		if err != allocIDNotPresentErr {
+			t.Fatalf("expected err: %v, actual: %v", allocIDNotPresentErr, err)
+		}
+	})
+}
+
+func TestAllocDirFS_Tar_MissingParams(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/fs/tar/foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.FileTarRequest(respW, req)
+		if err == nil || err.Error() != ErrInvalidMethod {
+			t.Fatalf("expected err: %v, actual: %v", ErrInvalidMethod, err)
+		}
+
+		req, err = http.NewRequest("PUT", "/v1/client/fs/tar/", encodeReq([]string{"foo"}))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		_, err = s.Server.FileTarRequest(respW, req)
+		if err != allocIDNotPresentErr {
+			t.Fatalf("expected err: %v, actual: %v", allocIDNotPresentErr, err)
+		}
+
+		req, err = http.NewRequest("PUT", "/v1/client/fs/tar/foo", encodeReq([]string{}))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		_, err = s.Server.FileTarRequest(respW, req)
+		if err == nil || err.Error() != pathsNotPresentErr.Error() {
+			t.Fatalf("expected err: %v, actual: %v", pathsNotPresentErr, err)
+		}
+	})
+}
+
 func TestAllocDirFS_ReadAt_MissingParams(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		req, err := http.NewRequest("GET", "/v1/client/fs/readat/", nil)
@@ -257,6 +346,50 @@ func TestStreamFramer_Batch(t *testing.T) {
 	}
 }
 
+func TestStreamFramer_Handshake(t *testing.T) {
+	// Create the stream framer
+	r, w := io.Pipe()
+	hRate, bWindow := 100*time.Millisecond, 100*time.Millisecond
+	sf := NewStreamFramer(w, hRate, bWindow, 100)
+	sf.Run()
+	defer sf.Destroy()
+	defer r.Close()
+
+	// The first frame carries the heartbeat rate
+	dec := codec.NewDecoder(r, jsonHandle)
+	var frame StreamFrame
+	if err := dec.Decode(&frame); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if !frame.IsHandshake() || frame.IsHeartbeat() || frame.HeartbeatRate != hRate {
+		t.Fatalf("bad handshake: %#v", frame)
+	}
+}
+
+func TestParseHeartbeatRate(t *testing.T) {
+	cases := []struct {
+		rate     string
+		expected time.Duration
+		err      bool
+	}{
+		{"", streamHeartbeatRate, false},
+		{"5s", 5 * time.Second, false},
+		{"1ms", streamMinHeartbeatRate, false},
+		{"1h", streamMaxHeartbeatRate, false},
+		{"foo", 0, true},
+	}
+
+	for _, c := range cases {
+		d, err := parseHeartbeatRate(c.rate)
+		if (err != nil) != c.err {
+			t.Fatalf("%q: unexpected error: %v", c.rate, err)
+		}
+		if d != c.expected {
+			t.Fatalf("%q: got %v; want %v", c.rate, d, c.expected)
+		}
+	}
+}
+
 func TestStreamFramer_Heartbeat(t *testing.T) {
 	// Create the stream framer
 	r, w := io.Pipe()
@@ -742,6 +875,124 @@ func TestHTTP_Stream_Delete(t *testing.T) {
 	})
 }
 
+func TestHTTP_Stream_FollowLatest(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Get a temp alloc dir and create a directory to follow
+		ad := tempAllocDir(t)
+		defer os.RemoveAll(ad.AllocDir)
+
+		dir := "output"
+		if err := os.MkdirAll(filepath.Join(ad.AllocDir, dir), 0777); err != nil {
+			t.Fatalf("Failed to make dir: %v", err)
+		}
+
+		writeToFile := func(name string, data []byte) {
+			p := filepath.Join(ad.AllocDir, dir, name)
+			if err := ioutil.WriteFile(p, data, 0777); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+
+		// Create an older file, a non-matching file and the current file
+		expected := []byte("helloworld")
+		writeToFile("current.0", []byte("old"))
+		writeToFile("other", []byte("nope"))
+		time.Sleep(10 * time.Millisecond)
+		writeToFile("current.1", expected[:5])
+
+		// Create a decoder
+		r, w := io.Pipe()
+		wrappedW := &WriteCloseChecker{WriteCloser: w}
+		defer r.Close()
+		defer w.Close()
+		dec := codec.NewDecoder(r, jsonHandle)
+
+		// Start the reader
+		var received []byte
+		var switched bool
+		resultCh := make(chan struct{})
+		errCh := make(chan error, 2)
+		go func() {
+			for {
+				var frame StreamFrame
+				if err := dec.Decode(&frame); err != nil {
+					if err != io.EOF {
+						errCh <- fmt.Errorf("failed to decode: %v", err)
+					}
+					return
+				}
+
+				if frame.IsHeartbeat() {
+					continue
+				}
+
+				if frame.FileEvent == switchEvent {
+					switched = true
+				}
+
+				received = append(received, frame.Data...)
+				if reflect.DeepEqual(received, expected) {
+					close(resultCh)
+					return
+				}
+			}
+		}()
+
+		framer := NewStreamFramer(wrappedW, streamHeartbeatRate, streamBatchWindow, streamFrameSize)
+		framer.Run()
+		defer framer.Destroy()
+
+		// Start streaming
+		go func() {
+			if err := s.Server.streamLatest(0, OriginStart, dir, "current.*", ad, framer); err != nil {
+				errCh <- fmt.Errorf("streamLatest() failed: %v", err)
+			}
+		}()
+
+		// Sleep a little before rotating to check that the switch is detected
+		time.Sleep(1 * time.Duration(testutil.TestMultiplier()) * time.Second)
+		writeToFile("current.2", expected[5:])
+
+		select {
+		case <-resultCh:
+		case err := <-errCh:
+			t.Fatalf("%v", err)
+		case <-time.After(10 * time.Duration(testutil.TestMultiplier()) * streamBatchWindow):
+			t.Fatalf("did not receive data: got %q", string(received))
+		}
+
+		if !switched {
+			t.Fatalf("did not receive switch event")
+		}
+	})
+}
+
+func TestLogs_findLatest(t *testing.T) {
+	now := time.Now()
+	entries := []*allocdir.AllocFileInfo{
+		{Name: "app.log.0", ModTime: now.Add(-2 * time.Minute)},
+		{Name: "app.log.1", ModTime: now},
+		{Name: "app.log.2", ModTime: now},
+		{Name: "other.log", ModTime: now.Add(time.Minute)},
+		{Name: "app.log.dir", ModTime: now.Add(time.Minute), IsDir: true},
+	}
+
+	latest := findLatest(entries, "app.log.*", nil)
+	if latest == nil || latest.Name != "app.log.2" {
+		t.Fatalf("bad: %#v", latest)
+	}
+
+	exclude := map[string]struct{}{"app.log.2": struct{}{}}
+	latest = findLatest(entries, "app.log.*", exclude)
+	if latest == nil || latest.Name != "app.log.1" {
+		t.Fatalf("bad: %#v", latest)
+	}
+
+	if latest = findLatest(entries, "missing*", nil); latest != nil {
+		t.Fatalf("bad: %#v", latest)
+	}
+}
+
 func TestHTTP_Logs_NoFollow(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Get a temp alloc dir and create the log dir
@@ -803,7 +1054,7 @@ func TestHTTP_Logs_NoFollow(t *testing.T) {
 
 		// Start streaming logs
 		go func() {
-			if err := s.Server.logs(false, 0, OriginStart, task, logType, ad, wrappedW); err != nil {
+			if err := s.Server.logs(false, 0, OriginStart, task, logType, streamHeartbeatRate, ad, wrappedW); err != nil {
 				t.Fatalf("logs() failed: %v", err)
 			}
 		}()
@@ -892,7 +1143,7 @@ func TestHTTP_Logs_Follow(t *testing.T) {
 
 		// Start streaming logs
 		go func() {
-			if err := s.Server.logs(true, 0, OriginStart, task, logType, ad, wrappedW); err != nil {
+			if err := s.Server.logs(true, 0, OriginStart, task, logType, streamHeartbeatRate, ad, wrappedW); err != nil {
 				t.Fatalf("logs() failed: %v", err)
 			}
 		}()
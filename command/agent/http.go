@@ -2,6 +2,7 @@ package agent
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,9 +11,11 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/NYTimes/gziphandler"
+	"github.com/hashicorp/nomad/helper/tlsutil"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/ugorji/go/codec"
 )
@@ -33,8 +36,43 @@ var (
 	// structs. The pretty handle will add indents for easier human consumption.
 	jsonHandle       = &codec.JsonHandle{}
 	jsonHandlePretty = &codec.JsonHandle{Indent: 4}
+
+	// httpMiddlewares are the registered middlewares wrapping the HTTP API
+	httpMiddlewares     []HTTPMiddleware
+	httpMiddlewaresLock sync.RWMutex
 )
 
+// HTTPMiddleware wraps the handler serving the HTTP API of the agent. It
+// allows embedders of the agent to add authentication, metrics or request
+// shaping without modifying the built-in handlers.
+type HTTPMiddleware func(next http.Handler) http.Handler
+
+// RegisterHTTPMiddleware registers a middleware wrapping the HTTP API of the
+// agents started afterwards and is meant to be called from an init function.
+// Middlewares run in the order they are registered, the first registered
+// being the outermost. They all run inside the response compression and
+// before the request is routed to the built-in handlers, so they see
+// uncompressed responses and may answer a request without passing it on.
+func RegisterHTTPMiddleware(m HTTPMiddleware) {
+	if m == nil {
+		panic("agent: RegisterHTTPMiddleware middleware is nil")
+	}
+
+	httpMiddlewaresLock.Lock()
+	defer httpMiddlewaresLock.Unlock()
+	httpMiddlewares = append(httpMiddlewares, m)
+}
+
+// applyHTTPMiddlewares wraps the handler with the registered middlewares.
+func applyHTTPMiddlewares(h http.Handler) http.Handler {
+	httpMiddlewaresLock.RLock()
+	defer httpMiddlewaresLock.RUnlock()
+	for i := len(httpMiddlewares) - 1; i >= 0; i-- {
+		h = httpMiddlewares[i](h)
+	}
+	return h
+}
+
 // HTTPServer is used to wrap an Agent and expose it over an HTTP interface
 type HTTPServer struct {
 	agent    *Agent
@@ -42,15 +80,47 @@ type HTTPServer struct {
 	listener net.Listener
 	logger   *log.Logger
 	addr     string
+
+	// unixListener is the listener of the unix domain socket the HTTP API
+	// additionally listens on, if configured
+	unixListener net.Listener
 }
 
 // NewHTTPServer starts new HTTP server over the agent
 func NewHTTPServer(agent *Agent, config *Config, logOutput io.Writer) (*HTTPServer, error) {
 	// Start the listener
-	ln, err := config.Listener("tcp", config.Addresses.HTTP, config.Ports.HTTP)
+	ln, err := config.Listener("tcp", config.httpBindAddr(), config.Ports.HTTP)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start HTTP listener: %v", err)
 	}
+	addr := ln.Addr().String()
+
+	// If TLS is enabled, wrap the listener with a TLS listener
+	if config.TLSConfig != nil && config.TLSConfig.EnableHTTP {
+		tlsConf := &tlsutil.Config{
+			VerifyIncoming: config.TLSConfig.VerifyHTTPSClient,
+			CAFile:         config.TLSConfig.CAFile,
+			CertFile:       config.TLSConfig.CertFile,
+			KeyFile:        config.TLSConfig.KeyFile,
+			KeyLoader:      config.TLSConfig.GetKeyLoader(),
+		}
+		tlsConfig, err := tlsConf.IncomingTLSConfig()
+		if err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("failed to setup TLS for the HTTP API: %v", err)
+		}
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	// Additionally listen on the unix domain socket if one is configured
+	var unixLn net.Listener
+	if path, ok := unixSocketPath(config.Addresses.HTTP); ok {
+		unixLn, err = listenUnixSocket(path, config.UnixSockets)
+		if err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("failed to start HTTP unix socket listener: %v", err)
+		}
+	}
 
 	// Create the mux
 	mux := http.NewServeMux()
@@ -61,15 +131,46 @@ func NewHTTPServer(agent *Agent, config *Config, logOutput io.Writer) (*HTTPServ
 		mux:      mux,
 		listener: ln,
 		logger:   agent.logger,
-		addr:     ln.Addr().String(),
+		addr:     addr,
+
+		unixListener: unixLn,
 	}
 	srv.registerHandlers(config.EnableDebug)
 
+	// Answer the CORS preflight requests before they reach the middlewares
+	handler := newCORSHandler(config.CORS, applyHTTPMiddlewares(mux))
+
 	// Start the server
-	go http.Serve(ln, gziphandler.GzipHandler(mux))
+	server := newHTTPAPIServer(config.HTTPServer, handler, agent.logger)
+	go server.Serve(ln)
+	if unixLn != nil {
+		go server.Serve(unixLn)
+	}
 	return srv, nil
 }
 
+// newHTTPAPIServer returns the HTTP server of the handler tuned with the given
+// configuration
+func newHTTPAPIServer(config *HTTPServerConfig, handler http.Handler, logger *log.Logger) *http.Server {
+	if config == nil {
+		config = &HTTPServerConfig{}
+	}
+
+	if !config.DisableCompression {
+		handler = gziphandler.GzipHandler(handler)
+	}
+	server := &http.Server{
+		Handler:      handler,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+	}
+	if config.MaxConnsPerClient > 0 {
+		server.ConnState = newConnLimiter(config.MaxConnsPerClient, logger).connState
+	}
+	return server
+}
+
 // newScadaHttp creates a new HTTP server wrapping the SCADA
 // listener such that HTTP calls can be sent from the brokers.
 func newScadaHttp(agent *Agent, list net.Listener) *HTTPServer {
@@ -87,7 +188,7 @@ func newScadaHttp(agent *Agent, list net.Listener) *HTTPServer {
 	srv.registerHandlers(false) // Never allow debug for SCADA
 
 	// Start the server
-	go http.Serve(list, gziphandler.GzipHandler(mux))
+	go http.Serve(list, gziphandler.GzipHandler(applyHTTPMiddlewares(mux)))
 	return srv
 }
 
@@ -96,6 +197,9 @@ func (s *HTTPServer) Shutdown() {
 	if s != nil {
 		s.logger.Printf("[DEBUG] http: Shutting down http server")
 		s.listener.Close()
+		if s.unixListener != nil {
+			s.unixListener.Close()
+		}
 	}
 }
 
@@ -111,8 +215,18 @@ func (s *HTTPServer) registerHandlers(enableDebug bool) {
 	s.mux.HandleFunc("/v1/allocation/", s.wrap(s.AllocSpecificRequest))
 
 	s.mux.HandleFunc("/v1/evaluations", s.wrap(s.EvalsRequest))
+	s.mux.HandleFunc("/v1/evaluations/blocked", s.wrap(s.EvalsBlockedRequest))
 	s.mux.HandleFunc("/v1/evaluation/", s.wrap(s.EvalSpecificRequest))
 
+	s.mux.HandleFunc("/v1/deployments", s.wrap(s.DeploymentsRequest))
+	s.mux.HandleFunc("/v1/deployment/", s.wrap(s.DeploymentSpecificRequest))
+
+	s.mux.HandleFunc("/v1/quotas", s.wrap(s.QuotasRequest))
+	s.mux.HandleFunc("/v1/quota/", s.wrap(s.QuotaSpecificRequest))
+
+	s.mux.HandleFunc("/v1/namespaces", s.wrap(s.NamespacesRequest))
+	s.mux.HandleFunc("/v1/namespace/", s.wrap(s.NamespaceSpecificRequest))
+
 	s.mux.HandleFunc("/v1/client/fs/", s.wrap(s.FsRequest))
 	s.mux.HandleFunc("/v1/client/stats", s.wrap(s.ClientStatsRequest))
 	s.mux.HandleFunc("/v1/client/allocation/", s.wrap(s.ClientAllocRequest))
@@ -121,7 +235,19 @@ func (s *HTTPServer) registerHandlers(enableDebug bool) {
 	s.mux.HandleFunc("/v1/agent/join", s.wrap(s.AgentJoinRequest))
 	s.mux.HandleFunc("/v1/agent/members", s.wrap(s.AgentMembersRequest))
 	s.mux.HandleFunc("/v1/agent/force-leave", s.wrap(s.AgentForceLeaveRequest))
+	s.mux.HandleFunc("/v1/agent/reload", s.wrap(s.AgentReloadRequest))
 	s.mux.HandleFunc("/v1/agent/servers", s.wrap(s.AgentServersRequest))
+	s.mux.HandleFunc("/v1/agent/metrics", s.wrap(s.AgentMetricsRequest))
+	s.mux.HandleFunc("/v1/agent/logs", s.wrap(s.AgentLogsRequest))
+	s.mux.HandleFunc("/v1/agent/pprof/", s.wrap(s.AgentPprofRequest))
+
+	s.mux.HandleFunc("/v1/operator/keyring/", s.wrap(s.OperatorKeyringRequest))
+	s.mux.HandleFunc("/v1/operator/raft/configuration", s.wrap(s.OperatorRaftConfiguration))
+	s.mux.HandleFunc("/v1/operator/raft/peer", s.wrap(s.OperatorRaftPeer))
+	s.mux.HandleFunc("/v1/operator/autopilot/configuration", s.wrap(s.OperatorAutopilotConfiguration))
+	s.mux.HandleFunc("/v1/operator/autopilot/health", s.wrap(s.OperatorServerHealth))
+
+	s.mux.HandleFunc("/v1/event/stream", s.wrap(s.EventStream))
 
 	s.mux.HandleFunc("/v1/regions", s.wrap(s.RegionListRequest))
 
@@ -136,6 +262,7 @@ func (s *HTTPServer) registerHandlers(enableDebug bool) {
 		s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 		s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 		s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	}
 }
 
@@ -248,6 +375,9 @@ func setMeta(resp http.ResponseWriter, m *structs.QueryMeta) {
 	setIndex(resp, m.Index)
 	setLastContact(resp, m.LastContact)
 	setKnownLeader(resp, m.KnownLeader)
+	if m.NextToken != "" {
+		resp.Header().Set("X-Nomad-NextToken", m.NextToken)
+	}
 }
 
 // setHeaders is used to set canonical response header fields
@@ -298,6 +428,13 @@ func parsePrefix(req *http.Request, b *structs.QueryOptions) {
 	}
 }
 
+// parseNamespace is used to parse the ?namespace query param
+func parseNamespace(req *http.Request, n *string) {
+	if namespace := req.URL.Query().Get("namespace"); namespace != "" {
+		*n = namespace
+	}
+}
+
 // parseRegion is used to parse the ?region query param
 func (s *HTTPServer) parseRegion(req *http.Request, r *string) {
 	if other := req.URL.Query().Get("region"); other != "" {
@@ -307,10 +444,31 @@ func (s *HTTPServer) parseRegion(req *http.Request, r *string) {
 	}
 }
 
+// parsePagination is used to parse the ?per_page and ?next_token query params
+// Returns true on error
+func parsePagination(resp http.ResponseWriter, req *http.Request, b *structs.QueryOptions) bool {
+	query := req.URL.Query()
+	if perPage := query.Get("per_page"); perPage != "" {
+		n, err := strconv.ParseInt(perPage, 10, 32)
+		if err != nil || n < 0 {
+			resp.WriteHeader(400)
+			resp.Write([]byte("Invalid per_page"))
+			return true
+		}
+		b.PerPage = int32(n)
+	}
+	b.NextToken = query.Get("next_token")
+	return false
+}
+
 // parse is a convenience method for endpoints that need to parse multiple flags
 func (s *HTTPServer) parse(resp http.ResponseWriter, req *http.Request, r *string, b *structs.QueryOptions) bool {
 	s.parseRegion(req, r)
 	parseConsistency(req, b)
 	parsePrefix(req, b)
+	parseNamespace(req, &b.Namespace)
+	if parsePagination(resp, req, b) {
+		return true
+	}
 	return parseWait(resp, req, b)
 }
@@ -2,19 +2,25 @@ package agent
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/testutil"
 )
 
@@ -192,6 +198,99 @@ func TestContentTypeIsJSON(t *testing.T) {
 	}
 }
 
+func TestHTTP_DebugEndpoints(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		s := makeHTTPServer(t, func(c *Config) {
+			c.EnableDebug = enabled
+		})
+
+		for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/cmdline", "/debug/pprof/trace?seconds=1"} {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			resp := httptest.NewRecorder()
+			s.Server.mux.ServeHTTP(resp, req)
+
+			// The endpoints are only served when debugging is enabled
+			if enabled && resp.Code != 200 {
+				t.Fatalf("%s: bad status: %d", path, resp.Code)
+			}
+			if !enabled && resp.Code != 404 {
+				t.Fatalf("%s: bad status: %d", path, resp.Code)
+			}
+		}
+		s.Cleanup()
+	}
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	// Restore the registered middlewares once done
+	httpMiddlewaresLock.Lock()
+	old := httpMiddlewares
+	httpMiddlewares = nil
+	httpMiddlewaresLock.Unlock()
+	defer func() {
+		httpMiddlewaresLock.Lock()
+		httpMiddlewares = old
+		httpMiddlewaresLock.Unlock()
+	}()
+
+	var order []string
+	RegisterHTTPMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			order = append(order, "first")
+			if req.Header.Get("X-Auth") != "secret" {
+				resp.WriteHeader(403)
+				return
+			}
+			next.ServeHTTP(resp, req)
+		})
+	})
+	RegisterHTTPMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			order = append(order, "second")
+			resp.Header().Set("X-Middleware", "second")
+			next.ServeHTTP(resp, req)
+		})
+	})
+
+	s := makeHTTPServer(t, nil)
+	defer s.Cleanup()
+
+	// Requests rejected by a middleware never reach the next ones
+	url := fmt.Sprintf("http://%s/v1/agent/self", s.Server.addr)
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 403 {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+	if len(order) != 1 || order[0] != "first" {
+		t.Fatalf("bad order: %v", order)
+	}
+
+	order = nil
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("X-Auth", "secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Middleware") != "second" {
+		t.Fatalf("missing middleware header: %v", resp.Header)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("bad order: %v", order)
+	}
+}
+
 func TestPrettyPrint(t *testing.T) {
 	testPrettyPrint("pretty=1", true, t)
 }
@@ -394,3 +493,155 @@ func encodeReq(obj interface{}) io.ReadCloser {
 	enc.Encode(obj)
 	return ioutil.NopCloser(buf)
 }
+
+func TestHTTP_VerifyHTTPSClient(t *testing.T) {
+	const (
+		cafile  = "../../helper/tlsutil/test-fixtures/ca.pem"
+		foocert = "../../helper/tlsutil/test-fixtures/server.pem"
+		fookey  = "../../helper/tlsutil/test-fixtures/server-key.pem"
+		barcert = "../../helper/tlsutil/test-fixtures/client.pem"
+		barkey  = "../../helper/tlsutil/test-fixtures/client-key.pem"
+		bazcert = "../../helper/tlsutil/test-fixtures/server2.pem"
+		bazkey  = "../../helper/tlsutil/test-fixtures/server2-key.pem"
+	)
+	s := makeHTTPServer(t, func(c *Config) {
+		c.Client.Enabled = false
+		c.TLSConfig = &config.TLSConfig{
+			EnableHTTP:        true,
+			VerifyHTTPSClient: true,
+			CAFile:            cafile,
+			CertFile:          foocert,
+			KeyFile:           fookey,
+		}
+	})
+	defer s.Cleanup()
+
+	caData, err := ioutil.ReadFile(cafile)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caData)
+	url := fmt.Sprintf("https://%s/v1/agent/self", s.Server.addr)
+
+	// Requests without a client certificate are rejected
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+	if _, err := client.Get(url); err == nil {
+		t.Fatalf("expected err")
+	}
+
+	// Requests presenting a client certificate signed by the CA are served
+	clientCert, err := tls.LoadX509KeyPair(barcert, barkey)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      pool,
+				Certificates: []tls.Certificate{clientCert},
+			},
+		},
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("bad status: %d", resp.StatusCode)
+	}
+	before := resp.TLS.PeerCertificates[0]
+
+	// Reloading the certificate is used by new connections
+	if err := s.Agent.ReloadTLS(&config.TLSConfig{
+		EnableHTTP: true,
+		CAFile:     cafile,
+		CertFile:   bazcert,
+		KeyFile:    bazkey,
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	client.Transport.(*http.Transport).CloseIdleConnections()
+	resp, err = client.Get(url)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS.PeerCertificates[0].Equal(before) {
+		t.Fatalf("expected the reloaded certificate")
+	}
+	if s.Agent.config.TLSConfig.CertFile != bazcert {
+		t.Fatalf("bad: %#v", s.Agent.config.TLSConfig)
+	}
+}
+
+func TestHTTPAPIServer_Compression(t *testing.T) {
+	handler := http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Write([]byte("hello world"))
+	})
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+
+	for _, disabled := range []bool{false, true} {
+		server := newHTTPAPIServer(&HTTPServerConfig{
+			ReadTimeout:        time.Second,
+			DisableCompression: disabled,
+		}, handler, logger)
+		if server.ReadTimeout != time.Second {
+			t.Fatalf("bad: %v", server.ReadTimeout)
+		}
+
+		req, err := http.NewRequest("GET", "/v1/jobs", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp := httptest.NewRecorder()
+		server.Handler.ServeHTTP(resp, req)
+
+		// Responses are only compressed when compression is enabled
+		encoding := resp.Header().Get("Content-Encoding")
+		if !disabled && encoding != "gzip" {
+			t.Fatalf("bad encoding: %q", encoding)
+		}
+		if disabled && encoding != "" {
+			t.Fatalf("bad encoding: %q", encoding)
+		}
+	}
+}
+
+func TestHTTP_UnixSocket(t *testing.T) {
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "nomad.sock")
+
+	s := makeHTTPServer(t, func(c *Config) {
+		c.Addresses.HTTP = "unix://" + socket
+	})
+	defer s.Cleanup()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Dial: func(_, _ string) (net.Conn, error) {
+				return net.Dial("unix", socket)
+			},
+		},
+	}
+	resp, err := client.Get("http://localhost/v1/agent/self")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("bad status: %d", resp.StatusCode)
+	}
+
+	// The HTTP API still listens on the bind address
+	if host, _, _ := net.SplitHostPort(s.Server.addr); host != s.Agent.config.BindAddr {
+		t.Fatalf("bad addr: %q", s.Server.addr)
+	}
+}
@@ -1,7 +1,9 @@
 package agent
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -51,12 +53,30 @@ func (s *HTTPServer) JobSpecificRequest(resp http.ResponseWriter, req *http.Requ
 	case strings.HasSuffix(path, "/periodic/force"):
 		jobName := strings.TrimSuffix(path, "/periodic/force")
 		return s.periodicForceRequest(resp, req, jobName)
+	case strings.HasSuffix(path, "/periodic/launch"):
+		jobName := strings.TrimSuffix(path, "/periodic/launch")
+		return s.periodicLaunchRequest(resp, req, jobName)
 	case strings.HasSuffix(path, "/plan"):
 		jobName := strings.TrimSuffix(path, "/plan")
 		return s.jobPlan(resp, req, jobName)
+	case strings.HasSuffix(path, "/diff"):
+		jobName := strings.TrimSuffix(path, "/diff")
+		return s.jobDiff(resp, req, jobName)
 	case strings.HasSuffix(path, "/summary"):
 		jobName := strings.TrimSuffix(path, "/summary")
 		return s.jobSummaryRequest(resp, req, jobName)
+	case strings.HasSuffix(path, "/priority"):
+		jobName := strings.TrimSuffix(path, "/priority")
+		return s.jobPriorityRequest(resp, req, jobName)
+	case strings.HasSuffix(path, "/versions"):
+		jobName := strings.TrimSuffix(path, "/versions")
+		return s.jobVersions(resp, req, jobName)
+	case strings.HasSuffix(path, "/revert"):
+		jobName := strings.TrimSuffix(path, "/revert")
+		return s.jobRevert(resp, req, jobName)
+	case strings.HasSuffix(path, "/dispatch"):
+		jobName := strings.TrimSuffix(path, "/dispatch")
+		return s.jobDispatchRequest(resp, req, jobName)
 	default:
 		return s.jobCRUD(resp, req, path)
 	}
@@ -71,6 +91,7 @@ func (s *HTTPServer) jobForceEvaluate(resp http.ResponseWriter, req *http.Reques
 		JobID: jobName,
 	}
 	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
 
 	var out structs.JobRegisterResponse
 	if err := s.agent.RPC("Job.Evaluate", &args, &out); err != nil {
@@ -97,6 +118,7 @@ func (s *HTTPServer) jobPlan(resp http.ResponseWriter, req *http.Request,
 		return nil, CodedError(400, "Job ID does not match")
 	}
 	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
 
 	var out structs.JobPlanResponse
 	if err := s.agent.RPC("Job.Plan", &args, &out); err != nil {
@@ -106,6 +128,177 @@ func (s *HTTPServer) jobPlan(resp http.ResponseWriter, req *http.Request,
 	return out, nil
 }
 
+func (s *HTTPServer) jobDiff(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method == "GET" {
+		return s.jobVersionDiff(resp, req, jobName)
+	}
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.JobDiffRequest
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.Job == nil {
+		return nil, CodedError(400, "Job must be specified")
+	}
+	if jobName != "" && args.Job.ID != jobName {
+		return nil, CodedError(400, "Job ID does not match")
+	}
+	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
+
+	var out structs.JobDiffResponse
+	if err := s.agent.RPC("Job.Diff", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
+func (s *HTTPServer) jobPriorityRequest(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.JobPriorityRequest
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.JobID != "" && args.JobID != jobName {
+		return nil, CodedError(400, "Job ID does not match")
+	}
+	args.JobID = jobName
+	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
+
+	var out structs.JobPriorityResponse
+	if err := s.agent.RPC("Job.UpdatePriority", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
+func (s *HTTPServer) jobVersions(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.JobVersionsRequest{
+		JobID: jobName,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.JobVersionsResponse
+	if err := s.agent.RPC("Job.GetJobVersions", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if len(out.Versions) == 0 {
+		return nil, CodedError(404, "job versions not found")
+	}
+	return out.Versions, nil
+}
+
+// jobVersionDiff compares the tracked versions of the job given by the from
+// and to query parameters.
+func (s *HTTPServer) jobVersionDiff(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	args := structs.JobVersionDiffRequest{
+		JobID: jobName,
+	}
+	for param, version := range map[string]**uint64{"from": &args.From, "to": &args.To} {
+		if value := req.URL.Query().Get(param); value != "" {
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, CodedError(400, fmt.Sprintf("Failed to parse %s version %q: %v", param, value, err))
+			}
+			*version = &v
+		}
+	}
+	if value := req.URL.Query().Get("contextual"); value != "" {
+		contextual, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Failed to parse contextual %q: %v", value, err))
+		}
+		args.Contextual = contextual
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.JobVersionDiffResponse
+	if err := s.agent.RPC("Job.GetJobVersionDiff", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Diff == nil {
+		return nil, CodedError(404, "job versions not found")
+	}
+	return out.Diff, nil
+}
+
+func (s *HTTPServer) jobRevert(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.JobRevertRequest
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.JobID != "" && args.JobID != jobName {
+		return nil, CodedError(400, "Job ID does not match")
+	}
+	args.JobID = jobName
+	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
+
+	var out structs.JobRegisterResponse
+	if err := s.agent.RPC("Job.Revert", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
+func (s *HTTPServer) jobDispatchRequest(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.JobDispatchRequest{}
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.JobID != "" && args.JobID != jobName {
+		return nil, CodedError(400, "Job ID does not match")
+	}
+	if args.JobID == "" {
+		args.JobID = jobName
+	}
+	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
+
+	var out structs.JobDispatchResponse
+	if err := s.agent.RPC("Job.Dispatch", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
 func (s *HTTPServer) periodicForceRequest(resp http.ResponseWriter, req *http.Request,
 	jobName string) (interface{}, error) {
 	if req.Method != "PUT" && req.Method != "POST" {
@@ -115,7 +308,15 @@ func (s *HTTPServer) periodicForceRequest(resp http.ResponseWriter, req *http.Re
 	args := structs.PeriodicForceRequest{
 		JobID: jobName,
 	}
+	if missedRaw := req.URL.Query().Get("missed"); missedRaw != "" {
+		missed, err := strconv.ParseBool(missedRaw)
+		if err != nil {
+			return nil, CodedError(400, "invalid missed value")
+		}
+		args.Missed = missed
+	}
 	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
 
 	var out structs.PeriodicForceResponse
 	if err := s.agent.RPC("Periodic.Force", &args, &out); err != nil {
@@ -125,6 +326,31 @@ func (s *HTTPServer) periodicForceRequest(resp http.ResponseWriter, req *http.Re
 	return out, nil
 }
 
+func (s *HTTPServer) periodicLaunchRequest(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.PeriodicLaunchRequest{
+		JobID: jobName,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.PeriodicLaunchResponse
+	if err := s.agent.RPC("Periodic.Launch", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Launch == nil {
+		return nil, CodedError(404, "periodic launch not found")
+	}
+	return out.Launch, nil
+}
+
 func (s *HTTPServer) jobAllocations(resp http.ResponseWriter, req *http.Request,
 	jobName string) (interface{}, error) {
 	if req.Method != "GET" {
@@ -154,8 +380,9 @@ func (s *HTTPServer) jobEvaluations(resp http.ResponseWriter, req *http.Request,
 	if req.Method != "GET" {
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
-	args := structs.JobSpecificRequest{
-		JobID: jobName,
+	args := structs.JobEvaluationsRequest{
+		JobID:  jobName,
+		Status: req.URL.Query().Get("status"),
 	}
 	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
 		return nil, nil
@@ -221,6 +448,7 @@ func (s *HTTPServer) jobUpdate(resp http.ResponseWriter, req *http.Request,
 		return nil, CodedError(400, "Job ID does not match")
 	}
 	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
 
 	var out structs.JobRegisterResponse
 	if err := s.agent.RPC("Job.Register", &args, &out); err != nil {
@@ -236,6 +464,7 @@ func (s *HTTPServer) jobDelete(resp http.ResponseWriter, req *http.Request,
 		JobID: jobName,
 	}
 	s.parseRegion(req, &args.Region)
+	parseNamespace(req, &args.Namespace)
 
 	var out structs.JobDeregisterResponse
 	if err := s.agent.RPC("Job.Deregister", &args, &out); err != nil {
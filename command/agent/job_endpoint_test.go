@@ -3,6 +3,7 @@ package agent
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/nomad/nomad/mock"
@@ -486,6 +487,332 @@ func TestHTTP_PeriodicForce(t *testing.T) {
 	})
 }
 
+func TestHTTP_PeriodicLaunch(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create and register a periodic job.
+		job := mock.PeriodicJob()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Forcing a missed launch fails as no launch was skipped
+		req, err := http.NewRequest("POST", "/v1/job/"+job.ID+"/periodic/force?missed=true", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		_, err = s.Server.JobSpecificRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), "no skipped launches") {
+			t.Fatalf("expected no skipped launches error, got: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err = http.NewRequest("GET", "/v1/job/"+job.ID+"/periodic/launch", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the response
+		launch := obj.(*structs.PeriodicLaunch)
+		if launch.ID != job.ID || launch.Launch.IsZero() || len(launch.Skipped) != 0 {
+			t.Fatalf("bad: %#v", launch)
+		}
+	})
+}
+
+func TestHTTP_JobPriority(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job
+		job := mock.Job()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		buf := encodeReq(structs.JobPriorityRequest{Priority: 80})
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/priority", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the response
+		update := obj.(structs.JobPriorityResponse)
+		if update.JobModifyIndex <= resp.JobModifyIndex {
+			t.Fatalf("bad: %#v", update)
+		}
+
+		// Check the job
+		getReq := structs.JobSpecificRequest{
+			JobID:        job.ID,
+			QueryOptions: structs.QueryOptions{Region: "global"},
+		}
+		var getResp structs.SingleJobResponse
+		if err := s.Agent.RPC("Job.GetJob", &getReq, &getResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if getResp.Job.Priority != 80 {
+			t.Fatalf("bad: %#v", getResp.Job)
+		}
+	})
+}
+
+func TestHTTP_JobVersions(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job
+		job := mock.Job()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		job2 := mock.Job()
+		job2.ID = job.ID
+		job2.Priority = 100
+		args2 := structs.JobRegisterRequest{
+			Job:          job2,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp2 structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args2, &resp2); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/job/"+job.ID+"/versions", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		versions := obj.([]*structs.Job)
+		if len(versions) != 2 {
+			t.Fatalf("got %d versions; want 2", len(versions))
+		}
+		if v := versions[0]; v.Version != 1 || v.Priority != 100 {
+			t.Fatalf("bad %v", v)
+		}
+		if v := versions[1]; v.Version != 0 {
+			t.Fatalf("bad %v", v)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		if respW.HeaderMap.Get("X-Nomad-LastContact") == "" {
+			t.Fatalf("missing last contact")
+		}
+	})
+}
+
+func TestHTTP_JobVersionDiff(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Register the job twice
+		job := mock.Job()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		job.Priority = 100
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/job/"+job.ID+"/diff?from=0&to=1", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		diff := obj.(*structs.JobDiff)
+		if diff.Type != structs.DiffTypeEdited || len(diff.Fields) != 1 || diff.Fields[0].Name != "Priority" {
+			t.Fatalf("bad: %#v", diff)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Malformed versions are rejected
+		req, err = http.NewRequest("GET", "/v1/job/"+job.ID+"/diff?from=foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.JobSpecificRequest(respW, req); err == nil || !strings.Contains(err.Error(), "Failed to parse from version") {
+			t.Fatalf("expected a parse error, got: %v", err)
+		}
+	})
+}
+
+func TestHTTP_JobRevert(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job and register it twice
+		job := mock.Job()
+		regReq := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var regResp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &regReq, &regResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		job2 := job.Copy()
+		job2.Priority = 100
+		regReq.Job = job2
+		if err := s.Agent.RPC("Job.Register", &regReq, &regResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		args := structs.JobRevertRequest{
+			JobVersion: 0,
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/revert", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		revertResp := obj.(structs.JobRegisterResponse)
+		if revertResp.EvalID == "" {
+			t.Fatalf("bad: %v", revertResp)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the job was reverted
+		getReq := structs.JobSpecificRequest{
+			JobID:        job.ID,
+			QueryOptions: structs.QueryOptions{Region: "global"},
+		}
+		var getResp structs.SingleJobResponse
+		if err := s.Agent.RPC("Job.GetJob", &getReq, &getResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out := getResp.Job; out.Version != 2 || out.Priority != job.Priority {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_JobDispatch(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the parameterized job
+		job := mock.Job()
+		job.Type = "batch"
+		job.ParameterizedJob = &structs.ParameterizedJobConfig{}
+
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		args2 := structs.JobDispatchRequest{
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args2)
+
+		// Make the HTTP request
+		req2, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/dispatch", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req2)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		dispatch := obj.(structs.JobDispatchResponse)
+		if dispatch.EvalID == "" {
+			t.Fatalf("bad: %v", dispatch)
+		}
+
+		if dispatch.DispatchedJobID == "" {
+			t.Fatalf("bad: %v", dispatch)
+		}
+	})
+}
+
 func TestHTTP_JobPlan(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Create the job
@@ -521,3 +848,35 @@ func TestHTTP_JobPlan(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_JobDiff(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job
+		job := mock.Job()
+		args := structs.JobDiffRequest{
+			Job:          job,
+			Contextual:   true,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/diff", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		diff := obj.(structs.JobDiffResponse)
+		if diff.Diff == nil || diff.Diff.Type != structs.DiffTypeAdded {
+			t.Fatalf("bad: %v", diff)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/hashicorp/nomad/nomad"
+	"github.com/hashicorp/serf/serf"
+)
+
+const (
+	// serfKeyring is the name of the file in the server data dir that
+	// persists the gossip keyring.
+	serfKeyring = "serf.keyring"
+)
+
+// setupKeyring configures the gossip encryption of the server. The keyring is
+// persisted in the data dir so that rotated keys survive restarts, and the
+// encrypt key of the configuration only initializes it.
+func (a *Agent) setupKeyring(conf *nomad.Config) error {
+	key := a.config.Server.EncryptKey
+	if conf.DevMode || conf.DataDir == "" {
+		if key == "" {
+			return nil
+		}
+		k, err := decodeKey(key)
+		if err != nil {
+			return err
+		}
+		conf.SerfConfig.MemberlistConfig.SecretKey = k
+		return nil
+	}
+
+	path := filepath.Join(conf.DataDir, serfKeyring)
+	if key != "" {
+		if err := initKeyring(path, key); err != nil {
+			return fmt.Errorf("failed to initialize keyring: %v", err)
+		}
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	conf.SerfConfig.KeyringFile = path
+	if err := loadKeyringFile(conf.SerfConfig); err != nil {
+		return fmt.Errorf("failed to load keyring: %v", err)
+	}
+	return nil
+}
+
+// initKeyring writes the keyring file with the given key as its only key,
+// unless the keyring file already exists.
+func initKeyring(path, key string) error {
+	if _, err := decodeKey(key); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	keys := []string{key}
+	keyringBytes, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, keyringBytes, 0600)
+}
+
+// loadKeyringFile loads the gossip keyring from the keyring file of the given
+// Serf configuration. The first key of the file is the primary key.
+func loadKeyringFile(c *serf.Config) error {
+	if c.KeyringFile == "" {
+		return nil
+	}
+
+	if _, err := os.Stat(c.KeyringFile); err != nil {
+		return err
+	}
+
+	keyringData, err := ioutil.ReadFile(c.KeyringFile)
+	if err != nil {
+		return err
+	}
+
+	var keysEncoded []string
+	if err := json.Unmarshal(keyringData, &keysEncoded); err != nil {
+		return fmt.Errorf("failed to parse keyring file %q: %v", c.KeyringFile, err)
+	}
+	if len(keysEncoded) == 0 {
+		return fmt.Errorf("keyring file %q contains no keys", c.KeyringFile)
+	}
+
+	keys := make([][]byte, 0, len(keysEncoded))
+	for _, encoded := range keysEncoded {
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return fmt.Errorf("keyring file %q: %v", c.KeyringFile, err)
+		}
+		keys = append(keys, key)
+	}
+
+	keyring, err := memberlist.NewKeyring(keys, keys[0])
+	if err != nil {
+		return err
+	}
+	c.MemberlistConfig.Keyring = keyring
+	return nil
+}
+
+// decodeKey decodes and validates a base64 encoded gossip encryption key.
+func decodeKey(key string) ([]byte, error) {
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %v", err)
+	}
+	switch len(k) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("invalid encryption key: must be 16, 24 or 32 bytes")
+	}
+	return k, nil
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad"
+)
+
+func TestAgent_LoadKeyrings(t *testing.T) {
+	const (
+		key1 = "pUqJrVyVRj5jsiYEkM/tFQ=="
+		key2 = "kZyFABeAmc64UMTrm9XuKA=="
+	)
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server", serfKeyring)
+
+	// The encrypt key initializes the keyring file
+	if err := initKeyring(path, key1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Contains(content, []byte(key1)) {
+		t.Fatalf("bad: %s", content)
+	}
+
+	// An existing keyring file is not overwritten
+	if err := initKeyring(path, key2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	newContent, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(content, newContent) {
+		t.Fatalf("keyring file was overwritten: %s", newContent)
+	}
+
+	// Invalid keys are rejected
+	if err := initKeyring(filepath.Join(dir, "other"), "bad"); err == nil {
+		t.Fatalf("expected err")
+	}
+
+	// The keyring file is loaded into the server configuration
+	a := &Agent{config: DefaultConfig()}
+	a.config.Server.EncryptKey = key2
+	conf := nomad.DefaultConfig()
+	conf.DataDir = filepath.Dir(path)
+	if err := a.setupKeyring(conf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf.SerfConfig.KeyringFile != path {
+		t.Fatalf("bad keyring file: %q", conf.SerfConfig.KeyringFile)
+	}
+	keyring := conf.SerfConfig.MemberlistConfig.Keyring
+	if keyring == nil || len(keyring.GetKeys()) != 1 {
+		t.Fatalf("bad keyring: %#v", keyring)
+	}
+	if key, _ := decodeKey(key1); !bytes.Equal(keyring.GetPrimaryKey(), key) {
+		t.Fatalf("bad primary key")
+	}
+
+	// In dev mode the encrypt key is used directly
+	conf = nomad.DefaultConfig()
+	conf.DevMode = true
+	if err := a.setupKeyring(conf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if key, _ := decodeKey(key2); !bytes.Equal(conf.SerfConfig.MemberlistConfig.SecretKey, key) {
+		t.Fatalf("bad secret key")
+	}
+}
@@ -61,6 +61,20 @@ func (l *logWriter) DeregisterHandler(lh LogHandler) {
 	delete(l.handlers, lh)
 }
 
+// Logs returns the buffered logs, from the oldest to the most recent
+func (l *logWriter) Logs() []string {
+	l.Lock()
+	defer l.Unlock()
+
+	logs := make([]string, 0, len(l.logs))
+	for i := 0; i < len(l.logs); i++ {
+		if line := l.logs[(l.index+i)%len(l.logs)]; line != "" {
+			logs = append(logs, line)
+		}
+	}
+	return logs
+}
+
 // Write is used to accumulate new logs
 func (l *logWriter) Write(p []byte) (n int, err error) {
 	l.Lock()
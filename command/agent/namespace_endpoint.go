@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func (s *HTTPServer) NamespacesRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.NamespaceListRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.NamespaceListResponse
+	if err := s.agent.RPC("Namespace.List", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Namespaces == nil {
+		out.Namespaces = make([]*structs.Namespace, 0)
+	}
+	return out.Namespaces, nil
+}
+
+func (s *HTTPServer) NamespaceSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	name := strings.TrimPrefix(req.URL.Path, "/v1/namespace/")
+	switch req.Method {
+	case "GET":
+		return s.namespaceQuery(resp, req, name)
+	case "PUT", "POST":
+		return s.namespaceUpsert(resp, req, name)
+	case "DELETE":
+		return s.namespaceDelete(resp, req, name)
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+func (s *HTTPServer) namespaceQuery(resp http.ResponseWriter, req *http.Request,
+	name string) (interface{}, error) {
+	args := structs.NamespaceSpecificRequest{
+		Name: name,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.SingleNamespaceResponse
+	if err := s.agent.RPC("Namespace.GetNamespace", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Namespace == nil {
+		return nil, CodedError(404, "namespace not found")
+	}
+	return out.Namespace, nil
+}
+
+func (s *HTTPServer) namespaceUpsert(resp http.ResponseWriter, req *http.Request,
+	name string) (interface{}, error) {
+	var namespace structs.Namespace
+	if err := decodeBody(req, &namespace); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if namespace.Name == "" {
+		namespace.Name = name
+	}
+	if namespace.Name != name {
+		return nil, CodedError(400, "Namespace name does not match")
+	}
+
+	args := structs.NamespaceUpsertRequest{
+		Namespaces: []*structs.Namespace{&namespace},
+	}
+	s.parseRegion(req, &args.Region)
+
+	var out structs.GenericResponse
+	if err := s.agent.RPC("Namespace.UpsertNamespaces", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return nil, nil
+}
+
+func (s *HTTPServer) namespaceDelete(resp http.ResponseWriter, req *http.Request,
+	name string) (interface{}, error) {
+	args := structs.NamespaceDeleteRequest{
+		Namespaces: []string{name},
+	}
+	s.parseRegion(req, &args.Region)
+
+	var out structs.GenericResponse
+	if err := s.agent.RPC("Namespace.DeleteNamespaces", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return nil, nil
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_NamespaceCRUD(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the namespace
+		namespace := &structs.Namespace{
+			Description: "engineering jobs",
+		}
+		buf := encodeReq(namespace)
+		req, err := http.NewRequest("PUT", "/v1/namespace/engineering", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.NamespaceSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Query the namespace
+		req, err = http.NewRequest("GET", "/v1/namespace/engineering", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err := s.Server.NamespaceSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		out := obj.(*structs.Namespace)
+		if out.Name != "engineering" || out.Description != "engineering jobs" {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// List the namespaces by prefix
+		req, err = http.NewRequest("GET", "/v1/namespaces?prefix=eng", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.NamespacesRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if l := obj.([]*structs.Namespace); len(l) != 1 || l[0].Name != "engineering" {
+			t.Fatalf("bad: %#v", l)
+		}
+
+		// Delete the namespace
+		req, err = http.NewRequest("DELETE", "/v1/namespace/engineering", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.NamespaceSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// It is no longer found
+		req, err = http.NewRequest("GET", "/v1/namespace/engineering", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		_, err = s.Server.NamespaceSpecificRequest(respW, req)
+		if err == nil || err.Error() != "namespace not found" {
+			t.Fatalf("expected namespace not found, got: %v", err)
+		}
+	})
+}
+
+func TestHTTP_JobsList_Namespace(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create a namespace with a job
+		ns := structs.NamespaceUpsertRequest{
+			Namespaces:   []*structs.Namespace{{Name: "engineering"}},
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.GenericResponse
+		if err := s.Agent.RPC("Namespace.UpsertNamespaces", &ns, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		job := mock.Job()
+		job.Namespace = ""
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args)
+		req, err := http.NewRequest("PUT", "/v1/jobs?namespace=engineering", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.JobsRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// The job is only listed in its namespace
+		for namespace, expected := range map[string]int{"engineering": 1, "": 0} {
+			req, err := http.NewRequest("GET", "/v1/jobs?namespace="+namespace, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+			obj, err := s.Server.JobsRequest(respW, req)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if l := obj.([]*structs.JobListStub); len(l) != expected {
+				t.Fatalf("namespace %q: bad: %#v", namespace, l)
+			}
+		}
+	})
+}
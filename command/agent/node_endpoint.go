@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -18,6 +19,13 @@ func (s *HTTPServer) NodesRequest(resp http.ResponseWriter, req *http.Request) (
 		return nil, nil
 	}
 
+	// Parse the filters
+	query := req.URL.Query()
+	args.Status = query.Get("status")
+	args.Datacenter = query.Get("datacenter")
+	args.NodeClass = query.Get("class")
+	args.NamePrefix = query.Get("name_prefix")
+
 	var out structs.NodeListResponse
 	if err := s.agent.RPC("Node.List", &args, &out); err != nil {
 		return nil, err
@@ -105,9 +113,23 @@ func (s *HTTPServer) nodeToggleDrain(resp http.ResponseWriter, req *http.Request
 		return nil, CodedError(400, "invalid enable value")
 	}
 
+	// Get the drain strategy
+	strategy := &structs.DrainStrategy{}
+	if deadlineRaw := req.URL.Query().Get("deadline"); deadlineRaw != "" {
+		if strategy.Deadline, err = time.ParseDuration(deadlineRaw); err != nil {
+			return nil, CodedError(400, "invalid deadline value")
+		}
+	}
+	if ignoreRaw := req.URL.Query().Get("ignore_system"); ignoreRaw != "" {
+		if strategy.IgnoreSystemJobs, err = strconv.ParseBool(ignoreRaw); err != nil {
+			return nil, CodedError(400, "invalid ignore_system value")
+		}
+	}
+
 	args := structs.NodeUpdateDrainRequest{
-		NodeID: nodeID,
-		Drain:  enable,
+		NodeID:        nodeID,
+		Drain:         enable,
+		DrainStrategy: strategy,
 	}
 	s.parseRegion(req, &args.Region)
 
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -56,6 +57,39 @@ func TestHTTP_NodesList(t *testing.T) {
 	})
 }
 
+func TestHTTP_NodesList_Filter(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create a node in its own datacenter
+		node := mock.Node()
+		node.Datacenter = "dc-filter"
+		args := structs.NodeRegisterRequest{
+			Node:         node,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.NodeUpdateResponse
+		if err := s.Agent.RPC("Node.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		req, err := http.NewRequest("GET", "/v1/nodes?datacenter=dc-filter&status=ready", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		obj, err := s.Server.NodesRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Only the node of the datacenter is listed
+		n := obj.([]*structs.NodeListStub)
+		if len(n) != 1 || n[0].ID != node.ID {
+			t.Fatalf("bad: %#v", n)
+		}
+	})
+}
+
 func TestHTTP_NodesPrefixList(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		ids := []string{
@@ -246,7 +280,7 @@ func TestHTTP_NodeDrain(t *testing.T) {
 		}
 
 		// Make the HTTP request
-		req, err := http.NewRequest("POST", "/v1/node/"+node.ID+"/drain?enable=1", nil)
+		req, err := http.NewRequest("POST", "/v1/node/"+node.ID+"/drain?enable=1&deadline=1h&ignore_system=true", nil)
 		if err != nil {
 			t.Fatalf("err: %v", err)
 		}
@@ -268,6 +302,18 @@ func TestHTTP_NodeDrain(t *testing.T) {
 		if len(upd.EvalIDs) == 0 {
 			t.Fatalf("bad: %v", upd)
 		}
+
+		// Check the drain strategy
+		out, err := state.NodeByID(node.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !out.Drain || out.DrainStrategy == nil {
+			t.Fatalf("bad: %#v", out)
+		}
+		if out.DrainStrategy.Deadline != time.Hour || !out.DrainStrategy.IgnoreSystemJobs {
+			t.Fatalf("bad: %#v", out.DrainStrategy)
+		}
 	})
 }
 
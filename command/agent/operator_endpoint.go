@@ -0,0 +1,212 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/serf/serf"
+)
+
+// OperatorRaftConfiguration is used to inspect the current Raft configuration.
+// This supports the stale query mode in case the cluster doesn't have a leader.
+func (s *HTTPServer) OperatorRaftConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.GenericRequest
+	if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.RaftConfigurationResponse
+	if err := s.agent.RPC("Operator.RaftGetConfiguration", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// OperatorRaftPeer supports actions on Raft peers. Currently we only support
+// removing peers by address.
+func (s *HTTPServer) OperatorRaftPeer(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "DELETE" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.RaftPeerByAddressRequest
+	s.parseRegion(req, &args.Region)
+
+	params := req.URL.Query()
+	if _, ok := params["address"]; ok {
+		args.Address = params.Get("address")
+	} else {
+		return nil, CodedError(400, "Must specify ?address with IP:port of peer to remove")
+	}
+
+	var reply struct{}
+	if err := s.agent.RPC("Operator.RaftRemovePeerByAddress", &args, &reply); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// OperatorAutopilotConfiguration is used to inspect and update the Autopilot
+// configuration. Updates support check-and-set with the ?cas parameter.
+func (s *HTTPServer) OperatorAutopilotConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		var args structs.GenericRequest
+		if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+			return nil, nil
+		}
+
+		var reply structs.AutopilotConfig
+		if err := s.agent.RPC("Operator.AutopilotGetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+		return reply, nil
+
+	case "PUT", "POST":
+		var args structs.AutopilotSetConfigRequest
+		s.parseRegion(req, &args.Region)
+		if err := decodeBody(req, &args.Config); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Error parsing autopilot config: %v", err))
+		}
+
+		// Check for a check-and-set index
+		params := req.URL.Query()
+		if _, ok := params["cas"]; ok {
+			casVal, err := strconv.ParseUint(params.Get("cas"), 10, 64)
+			if err != nil {
+				return nil, CodedError(400, fmt.Sprintf("Error parsing cas value: %v", err))
+			}
+			args.Config.ModifyIndex = casVal
+			args.CAS = true
+		}
+
+		var reply bool
+		if err := s.agent.RPC("Operator.AutopilotSetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		// Only use the reply if this was a check-and-set
+		if !args.CAS {
+			return true, nil
+		}
+		return reply, nil
+
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+// OperatorServerHealth is used to get the health of the servers, as
+// determined by Autopilot on the leader.
+func (s *HTTPServer) OperatorServerHealth(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.GenericRequest
+	if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.OperatorHealthReply
+	if err := s.agent.RPC("Operator.ServerHealth", &args, &reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// KeyringRequest is used to install, use or remove a gossip encryption key
+type KeyringRequest struct {
+	Key string
+}
+
+// KeyringResponse is the result of an operation on the gossip keyring of the
+// servers.
+type KeyringResponse struct {
+	// Messages maps the name of the servers to the error they reported
+	Messages map[string]string
+
+	// Keys maps the installed keys to the number of servers having them
+	Keys map[string]int
+
+	// NumNodes is the number of servers in the gossip pool
+	NumNodes int
+}
+
+// OperatorKeyringRequest is used to list the gossip encryption keys of the
+// servers and to install, use or remove a key on all the servers.
+func (s *HTTPServer) OperatorKeyringRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	srv := s.agent.Server()
+	if srv == nil {
+		return nil, CodedError(501, ErrInvalidMethod)
+	}
+	if !srv.Encrypted() {
+		return nil, CodedError(400, "gossip encryption is not enabled")
+	}
+
+	op := strings.TrimPrefix(req.URL.Path, "/v1/operator/keyring/")
+	if op == "list" {
+		if req.Method != "GET" {
+			return nil, CodedError(405, ErrInvalidMethod)
+		}
+		return keyringResponse(srv.KeyManager().ListKeys())
+	}
+
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	var args KeyringRequest
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.Key == "" {
+		return nil, CodedError(400, "missing key")
+	}
+
+	km := srv.KeyManager()
+	switch op {
+	case "install":
+		return keyringResponse(km.InstallKey(args.Key))
+	case "use":
+		return keyringResponse(km.UseKey(args.Key))
+	case "remove":
+		return keyringResponse(km.RemoveKey(args.Key))
+	default:
+		return nil, CodedError(404, ErrInvalidMethod)
+	}
+}
+
+// keyringResponse converts the response of the Serf key manager, returning
+// the messages of the servers that failed the operation with the error.
+func keyringResponse(resp *serf.KeyResponse, err error) (interface{}, error) {
+	if err != nil {
+		if resp == nil || len(resp.Messages) == 0 {
+			return nil, err
+		}
+		nodes := make([]string, 0, len(resp.Messages))
+		for node := range resp.Messages {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		msgs := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", node, resp.Messages[node]))
+		}
+		return nil, fmt.Errorf("%v: %s", err, strings.Join(msgs, ", "))
+	}
+
+	return &KeyringResponse{
+		Messages: resp.Messages,
+		Keys:     resp.Keys,
+		NumNodes: resp.NumNodes,
+	}, nil
+}
@@ -0,0 +1,213 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestHTTP_OperatorKeyring(t *testing.T) {
+	const (
+		key1 = "pUqJrVyVRj5jsiYEkM/tFQ=="
+		key2 = "kZyFABeAmc64UMTrm9XuKA=="
+	)
+	httpTest(t, func(c *Config) {
+		c.Server.EncryptKey = key1
+	}, func(s *TestServer) {
+		// Install a new key
+		args := KeyringRequest{Key: key2}
+		req, err := http.NewRequest("PUT", "/v1/operator/keyring/install", encodeReq(args))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.OperatorKeyringRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// List the keys
+		req, err = http.NewRequest("GET", "/v1/operator/keyring/list", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err := s.Server.OperatorKeyringRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out := obj.(*KeyringResponse)
+		if len(out.Keys) != 2 || out.Keys[key1] != 1 || out.Keys[key2] != 1 {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// Unknown operations are rejected
+		req, err = http.NewRequest("PUT", "/v1/operator/keyring/foo", encodeReq(args))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.OperatorKeyringRequest(respW, req); err == nil {
+			t.Fatalf("expected err")
+		}
+	})
+}
+
+func TestHTTP_OperatorKeyring_NotEncrypted(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/operator/keyring/list", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		_, err = s.Server.OperatorKeyringRequest(respW, req)
+		if code, ok := err.(HTTPCodedError); !ok || code.Code() != 400 {
+			t.Fatalf("expected 400 err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_OperatorRaftConfiguration(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		body := bytes.NewBuffer(nil)
+		req, err := http.NewRequest("GET", "/v1/operator/raft/configuration", body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		resp := httptest.NewRecorder()
+		obj, err := s.Server.OperatorRaftConfiguration(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Code != 200 {
+			t.Fatalf("bad code: %d", resp.Code)
+		}
+		out, ok := obj.(structs.RaftConfigurationResponse)
+		if !ok {
+			t.Fatalf("unexpected: %T", obj)
+		}
+		if len(out.Servers) != 1 ||
+			!out.Servers[0].Leader ||
+			!out.Servers[0].Voter {
+			t.Fatalf("bad: %v", out)
+		}
+	})
+}
+
+func TestHTTP_OperatorRaftPeer(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		body := bytes.NewBuffer(nil)
+		req, err := http.NewRequest("DELETE", "/v1/operator/raft/peer?address=nope", body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// If we get this error, it proves we sent the address all the
+		// way through.
+		resp := httptest.NewRecorder()
+		_, err = s.Server.OperatorRaftPeer(resp, req)
+		if err == nil || !strings.Contains(err.Error(),
+			"address \"nope\" was not found in the Raft configuration") {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_OperatorAutopilotConfiguration(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		body := bytes.NewBuffer([]byte(`{"CleanupDeadServers": false}`))
+		req, err := http.NewRequest("PUT", "/v1/operator/autopilot/configuration", body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		resp := httptest.NewRecorder()
+		if _, err = s.Server.OperatorAutopilotConfiguration(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Code != 200 {
+			t.Fatalf("bad code: %d", resp.Code)
+		}
+
+		req, err = http.NewRequest("GET", "/v1/operator/autopilot/configuration", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		resp = httptest.NewRecorder()
+		obj, err := s.Server.OperatorAutopilotConfiguration(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out, ok := obj.(structs.AutopilotConfig)
+		if !ok {
+			t.Fatalf("unexpected: %T", obj)
+		}
+		if out.CleanupDeadServers {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// A check-and-set with a stale index fails
+		body = bytes.NewBuffer([]byte(`{"CleanupDeadServers": true}`))
+		url := fmt.Sprintf("/v1/operator/autopilot/configuration?cas=%d", out.ModifyIndex-1)
+		req, err = http.NewRequest("PUT", url, body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		resp = httptest.NewRecorder()
+		obj, err = s.Server.OperatorAutopilotConfiguration(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if res := obj.(bool); res {
+			t.Fatalf("should not have updated")
+		}
+
+		// A check-and-set with the current index succeeds
+		body = bytes.NewBuffer([]byte(`{"CleanupDeadServers": true}`))
+		url = fmt.Sprintf("/v1/operator/autopilot/configuration?cas=%d", out.ModifyIndex)
+		req, err = http.NewRequest("PUT", url, body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		resp = httptest.NewRecorder()
+		obj, err = s.Server.OperatorAutopilotConfiguration(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if res := obj.(bool); !res {
+			t.Fatalf("should have updated")
+		}
+	})
+}
+
+func TestHTTP_OperatorServerHealth(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		testutil.WaitForResult(func() (bool, error) {
+			req, err := http.NewRequest("GET", "/v1/operator/autopilot/health", nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			resp := httptest.NewRecorder()
+			obj, err := s.Server.OperatorServerHealth(resp, req)
+			if err != nil {
+				return false, err
+			}
+			out, ok := obj.(structs.OperatorHealthReply)
+			if !ok {
+				t.Fatalf("unexpected: %T", obj)
+			}
+			if !out.Healthy || len(out.Servers) != 1 || !out.Servers[0].Leader {
+				return false, fmt.Errorf("bad: %#v", out)
+			}
+			return true, nil
+		}, func(err error) {
+			t.Fatalf("err: %v", err)
+		})
+	})
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func (s *HTTPServer) QuotasRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.QuotaSpecListRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.QuotaSpecListResponse
+	if err := s.agent.RPC("Quota.List", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Quotas == nil {
+		out.Quotas = make([]*structs.QuotaSpec, 0)
+	}
+	return out.Quotas, nil
+}
+
+func (s *HTTPServer) QuotaSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/v1/quota/")
+	switch {
+	case strings.HasSuffix(path, "/usage"):
+		name := strings.TrimSuffix(path, "/usage")
+		return s.quotaUsage(resp, req, name)
+	default:
+		return s.quotaCRUD(resp, req, path)
+	}
+}
+
+func (s *HTTPServer) quotaCRUD(resp http.ResponseWriter, req *http.Request,
+	name string) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		return s.quotaQuery(resp, req, name)
+	case "PUT", "POST":
+		return s.quotaUpsert(resp, req, name)
+	case "DELETE":
+		return s.quotaDelete(resp, req, name)
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+func (s *HTTPServer) quotaQuery(resp http.ResponseWriter, req *http.Request,
+	name string) (interface{}, error) {
+	args := structs.QuotaSpecSpecificRequest{
+		Name: name,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.SingleQuotaSpecResponse
+	if err := s.agent.RPC("Quota.GetQuotaSpec", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Quota == nil {
+		return nil, CodedError(404, "quota not found")
+	}
+	return out.Quota, nil
+}
+
+func (s *HTTPServer) quotaUsage(resp http.ResponseWriter, req *http.Request,
+	name string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.QuotaSpecSpecificRequest{
+		Name: name,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.QuotaUsageResponse
+	if err := s.agent.RPC("Quota.GetQuotaUsage", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Usage == nil {
+		return nil, CodedError(404, "quota not found")
+	}
+	return out.Usage, nil
+}
+
+func (s *HTTPServer) quotaUpsert(resp http.ResponseWriter, req *http.Request,
+	name string) (interface{}, error) {
+	var quota structs.QuotaSpec
+	if err := decodeBody(req, &quota); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if quota.Name == "" {
+		quota.Name = name
+	}
+	if quota.Name != name {
+		return nil, CodedError(400, "Quota name does not match")
+	}
+
+	args := structs.QuotaSpecUpsertRequest{
+		Quota: &quota,
+	}
+	s.parseRegion(req, &args.Region)
+
+	var out structs.GenericResponse
+	if err := s.agent.RPC("Quota.Upsert", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return nil, nil
+}
+
+func (s *HTTPServer) quotaDelete(resp http.ResponseWriter, req *http.Request,
+	name string) (interface{}, error) {
+	args := structs.QuotaSpecDeleteRequest{
+		Name: name,
+	}
+	s.parseRegion(req, &args.Region)
+
+	var out structs.GenericResponse
+	if err := s.agent.RPC("Quota.Delete", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return nil, nil
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_QuotaCRUD(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the quota
+		quota := &structs.QuotaSpec{
+			Limit: &structs.QuotaLimit{CPU: 1000, MemoryMB: 512},
+		}
+		buf := encodeReq(quota)
+		req, err := http.NewRequest("PUT", "/v1/quota/engineering", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.QuotaSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Query the quota
+		req, err = http.NewRequest("GET", "/v1/quota/engineering", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err := s.Server.QuotaSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		out := obj.(*structs.QuotaSpec)
+		if out.Name != "engineering" || out.Limit.MemoryMB != 512 {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// List the quotas
+		req, err = http.NewRequest("GET", "/v1/quotas", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.QuotasRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if l := obj.([]*structs.QuotaSpec); len(l) != 1 {
+			t.Fatalf("bad: %#v", l)
+		}
+
+		// Query its usage
+		req, err = http.NewRequest("GET", "/v1/quota/engineering/usage", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.QuotaSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if usage := obj.(*structs.QuotaUsage); usage.Name != "engineering" || usage.Used == nil {
+			t.Fatalf("bad: %#v", usage)
+		}
+
+		// Delete the quota
+		req, err = http.NewRequest("DELETE", "/v1/quota/engineering", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.QuotaSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// It is no longer found
+		req, err = http.NewRequest("GET", "/v1/quota/engineering", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		_, err = s.Server.QuotaSpecificRequest(respW, req)
+		if err == nil || err.Error() != "quota not found" {
+			t.Fatalf("expected quota not found, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// reloadableFields are the configuration settings applied when the agent
+// reloads its configuration. Changing any other setting requires a restart of
+// the agent.
+var reloadableFields = map[string]struct{}{
+	"log_level":                                        struct{}{},
+	"tls.cert_file":                                    struct{}{},
+	"tls.key_file":                                     struct{}{},
+	"telemetry.statsite_address":                       struct{}{},
+	"telemetry.statsd_address":                         struct{}{},
+	"telemetry.disable_hostname":                       struct{}{},
+	"telemetry.circonus_api_token":                     struct{}{},
+	"telemetry.circonus_api_app":                       struct{}{},
+	"telemetry.circonus_api_url":                       struct{}{},
+	"telemetry.circonus_submission_interval":           struct{}{},
+	"telemetry.circonus_submission_url":                struct{}{},
+	"telemetry.circonus_check_id":                      struct{}{},
+	"telemetry.circonus_check_force_metric_activation": struct{}{},
+	"telemetry.circonus_check_instance_id":             struct{}{},
+	"telemetry.circonus_check_search_tag":              struct{}{},
+	"telemetry.circonus_broker_id":                     struct{}{},
+	"telemetry.circonus_broker_select_tag":             struct{}{},
+}
+
+// reloadedBlocks are the configuration blocks holding reloadable settings,
+// whose changes are reported per setting rather than for the whole block.
+var reloadedBlocks = map[string]struct{}{
+	"tls":       struct{}{},
+	"telemetry": struct{}{},
+}
+
+// ReloadResult reports the outcome of reloading the configuration of the
+// agent.
+type ReloadResult struct {
+	// Reloaded lists the changed settings which were applied
+	Reloaded []string
+
+	// RestartRequired lists the changed settings which only take effect once
+	// the agent is restarted
+	RestartRequired []string
+
+	// Errors lists the failures to apply changed settings, which keep their
+	// current value
+	Errors []string
+}
+
+// reloadReply carries the outcome of a reload requested through the agent.
+type reloadReply struct {
+	result *ReloadResult
+	err    error
+}
+
+// Reload asks the agent command to reload the configuration files, as done
+// when the agent receives a SIGHUP.
+func (a *Agent) Reload() (*ReloadResult, error) {
+	if a.reloadCh == nil {
+		return nil, fmt.Errorf("agent does not support reloading its configuration")
+	}
+
+	replyCh := make(chan reloadReply, 1)
+	select {
+	case a.reloadCh <- replyCh:
+	case <-a.shutdownCh:
+		return nil, fmt.Errorf("agent is shutting down")
+	}
+
+	select {
+	case reply := <-replyCh:
+		return reply.result, reply.err
+	case <-a.shutdownCh:
+		return nil, fmt.Errorf("agent is shutting down")
+	}
+}
+
+// newReloadResult classifies the settings changed between the old and new
+// configurations into the reloadable ones and the ones requiring a restart.
+func newReloadResult(old, new *Config) *ReloadResult {
+	result := &ReloadResult{
+		Reloaded:        make([]string, 0),
+		RestartRequired: make([]string, 0),
+		Errors:          make([]string, 0),
+	}
+	for _, field := range changedFields(old, new) {
+		if _, ok := reloadableFields[field]; ok {
+			result.Reloaded = append(result.Reloaded, field)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, field)
+		}
+	}
+	return result
+}
+
+// reloaded returns whether a changed setting starting with the prefix was
+// reloaded.
+func (r *ReloadResult) reloaded(prefix string) bool {
+	for _, field := range r.Reloaded {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fail records the error failing to apply the reloaded settings starting
+// with one of the prefixes.
+func (r *ReloadResult) fail(err error, prefixes ...string) {
+	reloaded := r.Reloaded[:0]
+	var failed []string
+	for _, field := range r.Reloaded {
+		matched := false
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(field, prefix) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			failed = append(failed, field)
+		} else {
+			reloaded = append(reloaded, field)
+		}
+	}
+	r.Reloaded = reloaded
+
+	if len(failed) == 0 {
+		failed = prefixes
+	}
+	r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", strings.Join(failed, ", "), err))
+}
+
+// changedFields returns the sorted names of the top-level settings and blocks
+// differing between the configurations. The settings of the reloaded blocks
+// are compared individually.
+func changedFields(old, new *Config) []string {
+	var changed []string
+	walkConfigFields("", reflect.ValueOf(old), reflect.ValueOf(new), func(name string, o, n reflect.Value) {
+		if _, ok := reloadedBlocks[name]; ok {
+			walkConfigFields(name+".", o, n, func(name string, o, n reflect.Value) {
+				if !reflect.DeepEqual(o.Interface(), n.Interface()) {
+					changed = append(changed, name)
+				}
+			})
+			return
+		}
+		if !reflect.DeepEqual(o.Interface(), n.Interface()) {
+			changed = append(changed, name)
+		}
+	})
+	sort.Strings(changed)
+	return changed
+}
+
+// walkConfigFields invokes the callback with the name and values of each field
+// of the two structs that is set from the configuration files. Nil pointers
+// are compared as the zero value of their struct.
+func walkConfigFields(prefix string, old, new reflect.Value, cb func(string, reflect.Value, reflect.Value)) {
+	o, n := indirectConfig(old, new), indirectConfig(new, old)
+	if o.Kind() != reflect.Struct {
+		return
+	}
+
+	t := o.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if field.PkgPath != "" || name == "" || name == "-" {
+			continue
+		}
+		cb(prefix+name, o.Field(i), n.Field(i))
+	}
+}
+
+// indirectConfig dereferences the pointer v, using the zero value of the
+// struct pointed to by other when v is nil.
+func indirectConfig(v, other reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Ptr {
+		return v
+	}
+	if v.IsNil() {
+		return reflect.Zero(other.Type().Elem())
+	}
+	return v.Elem()
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+func TestReload_NewReloadResult(t *testing.T) {
+	old := &Config{
+		LogLevel:  "INFO",
+		BindAddr:  "127.0.0.1",
+		Ports:     &Ports{HTTP: 4646},
+		TLSConfig: &config.TLSConfig{CertFile: "a.pem", KeyFile: "a-key.pem", CAFile: "ca.pem"},
+		Telemetry: &Telemetry{StatsdAddr: "127.0.0.1:8125"},
+		Revision:  "abc",
+	}
+	new := &Config{
+		LogLevel:  "DEBUG",
+		BindAddr:  "127.0.0.1",
+		Ports:     &Ports{HTTP: 5656},
+		TLSConfig: &config.TLSConfig{CertFile: "b.pem", KeyFile: "a-key.pem", CAFile: "ca2.pem"},
+		Telemetry: &Telemetry{StatsdAddr: "127.0.0.1:8126", PublishNodeMetrics: true},
+		Vault:     &config.VaultConfig{Addr: "https://vault:8200"},
+		Revision:  "def",
+	}
+
+	result := newReloadResult(old, new)
+	expReloaded := []string{"log_level", "telemetry.statsd_address", "tls.cert_file"}
+	if !reflect.DeepEqual(result.Reloaded, expReloaded) {
+		t.Fatalf("got reloaded %v; want %v", result.Reloaded, expReloaded)
+	}
+	expRestart := []string{"ports", "telemetry.publish_node_metrics", "tls.ca_file", "vault"}
+	if !reflect.DeepEqual(result.RestartRequired, expRestart) {
+		t.Fatalf("got restart required %v; want %v", result.RestartRequired, expRestart)
+	}
+
+	// Unchanged configurations report nothing
+	result = newReloadResult(old, old)
+	if len(result.Reloaded) != 0 || len(result.RestartRequired) != 0 || len(result.Errors) != 0 {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
+func TestReload_ReloadResultFail(t *testing.T) {
+	result := &ReloadResult{
+		Reloaded: []string{"log_level", "telemetry.statsd_address", "telemetry.statsite_address"},
+	}
+	if !result.reloaded("telemetry.") {
+		t.Fatalf("telemetry should be reloaded")
+	}
+
+	result.fail(fmt.Errorf("no sink"), "telemetry.")
+	if !reflect.DeepEqual(result.Reloaded, []string{"log_level"}) {
+		t.Fatalf("bad: %#v", result.Reloaded)
+	}
+	if result.reloaded("telemetry.") {
+		t.Fatalf("telemetry should not be reloaded")
+	}
+
+	// Failures of unchanged settings are reported with the given names
+	result.fail(fmt.Errorf("bad cert"), "tls.cert_file", "tls.key_file")
+	expErrors := []string{
+		"telemetry.statsd_address, telemetry.statsite_address: no sink",
+		"tls.cert_file, tls.key_file: bad cert",
+	}
+	if !reflect.DeepEqual(result.Errors, expErrors) {
+		t.Fatalf("got errors %v; want %v", result.Errors, expErrors)
+	}
+}
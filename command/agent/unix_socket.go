@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// unixSocketPrefix prefixes the addresses naming a unix domain socket, such
+// as unix:///var/run/nomad.sock
+const unixSocketPrefix = "unix://"
+
+// unixSocketPath returns the path of the unix domain socket named by the
+// address, if it names one
+func unixSocketPath(addr string) (string, bool) {
+	if !strings.HasPrefix(addr, unixSocketPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(addr, unixSocketPrefix), true
+}
+
+// listenUnixSocket listens on the unix domain socket at the path and applies
+// the configured ownership and mode to it
+func listenUnixSocket(path string, perms *UnixSocketConfig) (net.Listener, error) {
+	// Remove the socket left over by an agent that didn't shut down cleanly
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %q: %v", path, err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := setFilePermissions(path, perms); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set permissions of socket %q: %v", path, err)
+	}
+	return ln, nil
+}
+
+// setFilePermissions applies the ownership and mode of the config to the file
+// at the path. Unset values are left untouched.
+func setFilePermissions(path string, perms *UnixSocketConfig) error {
+	if perms == nil {
+		return nil
+	}
+
+	uid, gid := -1, -1
+	var err error
+	if perms.User != "" {
+		if uid, err = strconv.Atoi(perms.User); err != nil {
+			return fmt.Errorf("invalid user ID %q: %v", perms.User, err)
+		}
+	}
+	if perms.Group != "" {
+		if gid, err = strconv.Atoi(perms.Group); err != nil {
+			return fmt.Errorf("invalid group ID %q: %v", perms.Group, err)
+		}
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(path, uid, gid); err != nil {
+			return err
+		}
+	}
+
+	if perms.Mode != "" {
+		mode, err := strconv.ParseUint(perms.Mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid mode %q: %v", perms.Mode, err)
+		}
+		if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
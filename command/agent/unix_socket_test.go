@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnixSocketPath(t *testing.T) {
+	if path, ok := unixSocketPath("unix:///var/run/nomad.sock"); !ok || path != "/var/run/nomad.sock" {
+		t.Fatalf("bad: %q %v", path, ok)
+	}
+	if _, ok := unixSocketPath("127.0.0.1"); ok {
+		t.Fatalf("tcp address parsed as a unix socket")
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nomad.sock")
+
+	// Leave a stale socket behind
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := listenUnixSocket(path, &UnixSocketConfig{Mode: "0700"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer ln.Close()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if mode := fi.Mode().Perm(); mode != 0700 {
+		t.Fatalf("bad mode: %v", mode)
+	}
+}
+
+func TestSetFilePermissions_Invalid(t *testing.T) {
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		perms *UnixSocketConfig
+		err   string
+	}{
+		{&UnixSocketConfig{User: "nomad"}, "invalid user ID"},
+		{&UnixSocketConfig{Group: "nomad"}, "invalid group ID"},
+		{&UnixSocketConfig{Mode: "999"}, "invalid mode"},
+	}
+	for _, c := range cases {
+		err := setFilePermissions(dir, c.perms)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("expected %q error, got: %v", c.err, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AllocRestartCommand struct {
+	Meta
+}
+
+func (c *AllocRestartCommand) Help() string {
+	helpText := `
+Usage: nomad alloc-restart [options] <allocation> [<task>]
+
+  Restart the tasks of an existing allocation in place. If a task name is
+  given, only that task is restarted, otherwise all running tasks of the
+  allocation are restarted. The allocation is not rescheduled and restarts
+  triggered by this command do not count against the task group's restart
+  policy.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Alloc Restart Options:
+
+  -verbose
+    Show full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocRestartCommand) Synopsis() string {
+	return "Restart the tasks of an allocation in place"
+}
+
+func (c *AllocRestartCommand) Run(args []string) int {
+	var verbose bool
+
+	flags := c.Meta.FlagSet("alloc-restart", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got an allocation ID and optionally a task name
+	args = flags.Args()
+	if l := len(args); l < 1 || l > 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	allocID := args[0]
+	task := ""
+	if len(args) == 2 {
+		task = args[1]
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Query the allocation info
+	if len(allocID) == 1 {
+		c.Ui.Error(fmt.Sprintf("Identifier must contain at least two characters."))
+		return 1
+	}
+	if len(allocID)%2 == 1 {
+		// Identifiers must be of even length, so we strip off the last byte
+		// to provide a consistent user experience.
+		allocID = allocID[:len(allocID)-1]
+	}
+
+	allocs, _, err := client.Allocations().PrefixList(allocID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocation: %v", err))
+		return 1
+	}
+	if len(allocs) == 0 {
+		c.Ui.Error(fmt.Sprintf("No allocation(s) with prefix or id %q found", allocID))
+		return 1
+	}
+	if len(allocs) > 1 {
+		// Format the allocs
+		out := make([]string, len(allocs)+1)
+		out[0] = "ID|Eval ID|Job ID|Task Group|Desired Status|Client Status"
+		for i, alloc := range allocs {
+			out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s|%s",
+				limit(alloc.ID, length),
+				limit(alloc.EvalID, length),
+				alloc.JobID,
+				alloc.TaskGroup,
+				alloc.DesiredStatus,
+				alloc.ClientStatus,
+			)
+		}
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple allocations\n\n%s", formatList(out)))
+		return 0
+	}
+
+	// Prefix lookup matched a single allocation
+	alloc, _, err := client.Allocations().Info(allocs[0].ID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocation: %s", err))
+		return 1
+	}
+
+	// Validate the task name before contacting the client
+	if task != "" {
+		if _, ok := alloc.TaskStates[task]; !ok {
+			c.Ui.Error(fmt.Sprintf("Allocation %q has no task %q", limit(alloc.ID, length), task))
+			return 1
+		}
+	}
+
+	if err := client.Allocations().Restart(alloc, task, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error restarting allocation: %s", err))
+		return 1
+	}
+
+	if task != "" {
+		c.Ui.Output(fmt.Sprintf("Restarting task %q of allocation %q", task, limit(alloc.ID, length)))
+	} else {
+		c.Ui.Output(fmt.Sprintf("Restarting allocation %q", limit(alloc.ID, length)))
+	}
+	return 0
+}
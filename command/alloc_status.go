@@ -3,9 +3,12 @@ package command
 import (
 	"fmt"
 	"math"
+	"os"
+	"os/signal"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -41,6 +44,13 @@ Alloc Status Options:
   -stats
     Display detailed resource usage statistics.
 
+  -events
+    Display the events of all the tasks of the allocation ordered by time.
+
+  -follow
+    Used with -events, stream the new events until the allocation terminates
+    or the command is interrupted.
+
   -verbose
     Show full information.
 
@@ -59,7 +69,7 @@ func (c *AllocStatusCommand) Synopsis() string {
 }
 
 func (c *AllocStatusCommand) Run(args []string) int {
-	var short, displayStats, verbose, json bool
+	var short, displayStats, verbose, json, events, follow bool
 	var tmpl string
 
 	flags := c.Meta.FlagSet("alloc-status", FlagSetClient)
@@ -67,6 +77,8 @@ func (c *AllocStatusCommand) Run(args []string) int {
 	flags.BoolVar(&short, "short", false, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
 	flags.BoolVar(&displayStats, "stats", false, "")
+	flags.BoolVar(&events, "events", false, "")
+	flags.BoolVar(&follow, "follow", false, "")
 	flags.BoolVar(&json, "json", false, "")
 	flags.StringVar(&tmpl, "t", "", "")
 
@@ -74,6 +86,11 @@ func (c *AllocStatusCommand) Run(args []string) int {
 		return 1
 	}
 
+	if follow && !events {
+		c.Ui.Error("The -follow flag requires the -events flag")
+		return 1
+	}
+
 	// Check that we got exactly one allocation ID
 	args = flags.Args()
 
@@ -204,6 +221,10 @@ func (c *AllocStatusCommand) Run(args []string) int {
 		return 0
 	}
 
+	if events {
+		return c.outputEvents(client, alloc, follow)
+	}
+
 	// Format the allocation data
 	basic := []string{
 		fmt.Sprintf("ID|%s", limit(alloc.ID, length)),
@@ -215,6 +236,19 @@ func (c *AllocStatusCommand) Run(args []string) int {
 		fmt.Sprintf("Created At|%s", formatUnixNanoTime(alloc.CreateTime)),
 	}
 
+	// Explain why the allocation failed, such as its tasks running out of memory
+	if alloc.ClientDescription != "" {
+		basic = append(basic, fmt.Sprintf("Client Description|%s", alloc.ClientDescription))
+	}
+
+	// Show the failed allocation it was rescheduled to replace
+	if tracker := alloc.RescheduleTracker; tracker != nil && len(tracker.Events) != 0 {
+		last := tracker.Events[len(tracker.Events)-1]
+		basic = append(basic,
+			fmt.Sprintf("Rescheduled From|%s", limit(last.PrevAllocID, length)),
+			fmt.Sprintf("Reschedule Delay|%s", last.Delay))
+	}
+
 	if verbose {
 		basic = append(basic,
 			fmt.Sprintf("Evaluated Nodes|%d", alloc.Metrics.NodesEvaluated),
@@ -247,12 +281,68 @@ func (c *AllocStatusCommand) Run(args []string) int {
 	return 0
 }
 
+// outputEvents prints the events of all the tasks of the allocation ordered by
+// time. If follow is set, the new events are printed as they are recorded.
+func (c *AllocStatusCommand) outputEvents(client *api.Client, alloc *api.Allocation, follow bool) int {
+	if !follow {
+		events, _, err := client.Allocations().Events(alloc.ID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying allocation events: %s", err))
+			return 1
+		}
+
+		out := make([]string, len(events)+1)
+		out[0] = "Time|Task|Type|Description"
+		for i, e := range events {
+			out[i+1] = formatAllocTaskEvent(e, "|")
+		}
+		c.Ui.Output(formatList(out))
+		return 0
+	}
+
+	cancel := make(chan struct{})
+	events, err := client.Allocations().StreamEvents(alloc.ID, cancel, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error streaming allocation events: %s", err))
+		return 1
+	}
+
+	// End the streaming when interrupted
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+	go func() {
+		<-signalCh
+		close(cancel)
+	}()
+
+	for e := range events {
+		c.Ui.Output(formatAllocTaskEvent(e, "  "))
+	}
+	return 0
+}
+
+// formatAllocTaskEvent formats the time, task, type and description of an
+// event of a task with the separator.
+func formatAllocTaskEvent(e *api.AllocTaskEvent, sep string) string {
+	return strings.Join([]string{
+		formatUnixNanoTime(e.Event.Time),
+		e.Task,
+		e.Event.Type,
+		taskEventDescription(e.Event),
+	}, sep)
+}
+
 // outputTaskDetails prints task details for each task in the allocation,
 // optionally printing verbose statistics if displayStats is set
 func (c *AllocStatusCommand) outputTaskDetails(alloc *api.Allocation, stats *api.AllocResourceUsage, displayStats bool) {
 	for task := range c.sortedTaskStateIterator(alloc.TaskStates) {
 		state := alloc.TaskStates[task]
-		c.Ui.Output(c.Colorize().Color(fmt.Sprintf("\n[bold]Task %q is %q[reset]", task, state.State)))
+		header := fmt.Sprintf("\n[bold]Task %q is %q", task, state.State)
+		if state.FailedSetup && state.State == "dead" {
+			header += " (failed during setup)"
+		}
+		c.Ui.Output(c.Colorize().Color(header + "[reset]"))
 		c.outputTaskResources(alloc, task, stats, displayStats)
 		c.Ui.Output("")
 		c.outputTaskStatus(state)
@@ -270,76 +360,141 @@ func (c *AllocStatusCommand) outputTaskStatus(state *api.TaskState) {
 	for i, event := range state.Events {
 		formatedTime := formatUnixNanoTime(event.Time)
 
-		// Build up the description based on the event type.
-		var desc string
-		switch event.Type {
-		case api.TaskStarted:
-			desc = "Task started by client"
-		case api.TaskReceived:
-			desc = "Task received by client"
-		case api.TaskFailedValidation:
-			if event.ValidationError != "" {
-				desc = event.ValidationError
-			} else {
-				desc = "Validation of task failed"
-			}
-		case api.TaskDriverFailure:
-			if event.DriverError != "" {
-				desc = event.DriverError
-			} else {
-				desc = "Failed to start task"
-			}
-		case api.TaskDownloadingArtifacts:
-			desc = "Client is downloading artifacts"
-		case api.TaskArtifactDownloadFailed:
-			if event.DownloadError != "" {
-				desc = event.DownloadError
-			} else {
-				desc = "Failed to download artifacts"
-			}
-		case api.TaskKilling:
-			if event.KillTimeout != 0 {
-				desc = fmt.Sprintf("Sent interrupt. Waiting %v before force killing", event.KillTimeout)
-			} else {
-				desc = "Sent interrupt"
-			}
-		case api.TaskKilled:
-			if event.KillError != "" {
-				desc = event.KillError
-			} else {
-				desc = "Task successfully killed"
-			}
-		case api.TaskTerminated:
-			var parts []string
-			parts = append(parts, fmt.Sprintf("Exit Code: %d", event.ExitCode))
+		// Reverse order so we are sorted by time
+		events[size-i] = fmt.Sprintf("%s|%s|%s", formatedTime, event.Type, taskEventDescription(event))
+	}
+	c.Ui.Output(formatList(events))
+}
 
-			if event.Signal != 0 {
-				parts = append(parts, fmt.Sprintf("Signal: %d", event.Signal))
-			}
+// buildDisplayMessage returns the description of a task event recorded by a
+// client which doesn't describe its events.
+func buildDisplayMessage(event *api.TaskEvent) string {
+	var desc string
+	switch event.Type {
+	case api.TaskStarted:
+		desc = "Task started by client"
+	case api.TaskReceived:
+		desc = "Task received by client"
+	case api.TaskFailedValidation:
+		if event.ValidationError != "" {
+			desc = event.ValidationError
+		} else {
+			desc = "Validation of task failed"
+		}
+	case api.TaskDriverFailure:
+		if event.DriverError != "" {
+			desc = event.DriverError
+		} else {
+			desc = "Failed to start task"
+		}
+	case api.TaskDownloadingArtifacts:
+		desc = "Client is downloading artifacts"
+	case api.TaskArtifactDownloadFailed:
+		if event.DownloadError != "" {
+			desc = event.DownloadError
+		} else {
+			desc = "Failed to download artifacts"
+		}
+	case api.TaskKilling:
+		if event.KillTimeout != 0 {
+			desc = fmt.Sprintf("Sent interrupt. Waiting %v before force killing", event.KillTimeout)
+		} else {
+			desc = "Sent interrupt"
+		}
+	case api.TaskKilled:
+		if event.KillError != "" {
+			desc = event.KillError
+		} else {
+			desc = "Task successfully killed"
+		}
+	case api.TaskTerminated:
+		var parts []string
+		parts = append(parts, fmt.Sprintf("Exit Code: %d", event.ExitCode))
 
-			if event.Message != "" {
-				parts = append(parts, fmt.Sprintf("Exit Message: %q", event.Message))
-			}
-			desc = strings.Join(parts, ", ")
-		case api.TaskRestarting:
-			in := fmt.Sprintf("Task restarting in %v", time.Duration(event.StartDelay))
-			if event.RestartReason != "" && event.RestartReason != client.ReasonWithinPolicy {
-				desc = fmt.Sprintf("%s - %s", event.RestartReason, in)
-			} else {
-				desc = in
-			}
-		case api.TaskNotRestarting:
-			if event.RestartReason != "" {
-				desc = event.RestartReason
-			} else {
-				desc = "Task exceeded restart policy"
-			}
+		if event.Signal != 0 {
+			parts = append(parts, fmt.Sprintf("Signal: %d", event.Signal))
 		}
 
-		// Reverse order so we are sorted by time
-		events[size-i] = fmt.Sprintf("%s|%s|%s", formatedTime, event.Type, desc)
+		if event.OOMKilled {
+			parts = append(parts, "OOM Killed")
+		}
+
+		if event.Message != "" {
+			parts = append(parts, fmt.Sprintf("Exit Message: %q", event.Message))
+		}
+		desc = strings.Join(parts, ", ")
+	case api.TaskRestarting:
+		in := fmt.Sprintf("Task restarting in %v", time.Duration(event.StartDelay))
+		if event.RestartReason != "" && event.RestartReason != client.ReasonWithinPolicy {
+			desc = fmt.Sprintf("%s - %s", event.RestartReason, in)
+		} else {
+			desc = in
+		}
+	case api.TaskNotRestarting:
+		if event.RestartReason != "" {
+			desc = event.RestartReason
+		} else {
+			desc = "Task exceeded restart policy"
+		}
+	case api.TaskRestartSignal:
+		if event.RestartReason != "" {
+			desc = event.RestartReason
+		} else {
+			desc = "Task signaled to restart"
+		}
+	case api.TaskSignaling:
+		sig := event.TaskSignal
+		reason := event.TaskSignalReason
+
+		if sig == "" && reason == "" {
+			desc = "Task being sent a signal"
+		} else if sig == "" {
+			desc = reason
+		} else if reason == "" {
+			desc = fmt.Sprintf("Task being sent signal %v", sig)
+		} else {
+			desc = fmt.Sprintf("Task being sent signal %v: %v", sig, reason)
+		}
+	case api.TaskTemplateRenderFailed:
+		if event.TemplateError != "" {
+			desc = event.TemplateError
+		} else {
+			desc = "Failed to render templates"
+		}
+	case api.TaskEnvFromConsulFailed:
+		if event.EnvFromConsulError != "" {
+			desc = event.EnvFromConsulError
+		} else {
+			desc = "Failed to read environment from Consul"
+		}
+	case api.TaskHealthChanged:
+		if event.HealthStatus != "" {
+			desc = fmt.Sprintf("Task is %s", event.HealthStatus)
+		} else {
+			desc = "Task health changed"
+		}
+	case api.TaskDiskExceeded:
+		desc = fmt.Sprintf("Disk usage of %d bytes exceeded the limit of %d bytes", event.DiskSize, event.DiskLimit)
+	case api.TaskSiblingFailed:
+		if event.FailedSibling != "" {
+			desc = fmt.Sprintf("Task's sibling %q failed", event.FailedSibling)
+		} else {
+			desc = "Task's sibling failed"
+		}
+	case api.TaskDriverMessage:
+		desc = event.DriverMessage
+	case api.TaskOOMKilled:
+		desc = "Task was killed for exceeding its memory limit"
 	}
-	c.Ui.Output(formatList(events))
+	return desc
+}
+
+// taskEventDescription returns the description of a task event.
+func taskEventDescription(event *api.TaskEvent) string {
+	if event.DisplayMessage != "" {
+		return event.DisplayMessage
+	}
+	return buildDisplayMessage(event)
 }
 
 // outputTaskResources prints the task resources for the passed task and if
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/cli"
@@ -73,6 +74,33 @@ func TestAllocStatusCommand_Fails(t *testing.T) {
 	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Both -json and -t are not allowed") {
 		t.Fatalf("expected getting formatter error, got: %s", out)
 	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on following without the events
+	if code := cmd.Run([]string{"-address=" + url, "-follow", "foobar"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "requires the -events flag") {
+		t.Fatalf("expected flag error, got: %s", out)
+	}
+}
+
+func TestBuildDisplayMessage(t *testing.T) {
+	event := &api.TaskEvent{Type: api.TaskTerminated, ExitCode: 137, OOMKilled: true}
+	if desc := taskEventDescription(event); desc != "Exit Code: 137, OOM Killed" {
+		t.Fatalf("bad description: %q", desc)
+	}
+
+	event = &api.TaskEvent{Type: api.TaskDriverMessage, DriverMessage: "Downloading image"}
+	if desc := taskEventDescription(event); desc != "Downloading image" {
+		t.Fatalf("bad description: %q", desc)
+	}
+
+	// The description recorded by the client is used when set
+	event.DisplayMessage = "recorded"
+	if desc := taskEventDescription(event); desc != "recorded" {
+		t.Fatalf("bad description: %q", desc)
+	}
 }
 
 func TestAllocStatusCommand_Run(t *testing.T) {
@@ -28,6 +28,33 @@ func DataFormat(format, tmpl string) (DataFormatter, error) {
 	return nil, fmt.Errorf("Unsupported format is specified.")
 }
 
+// formatOutput formats data as selected by the -json and -t flags. The
+// returned bool is false if neither flag was set and the command should use
+// its default output.
+func formatOutput(json bool, tmpl string, data interface{}) (string, bool, error) {
+	var format string
+	if json && len(tmpl) > 0 {
+		return "", true, fmt.Errorf("Both -json and -t are not allowed")
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	} else {
+		return "", false, nil
+	}
+
+	f, err := DataFormat(format, tmpl)
+	if err != nil {
+		return "", true, fmt.Errorf("Error getting formatter: %s", err)
+	}
+
+	out, err := f.TransformData(data)
+	if err != nil {
+		return "", true, fmt.Errorf("Error formatting the data: %s", err)
+	}
+	return out, true, nil
+}
+
 type JSONFormat struct {
 }
 
@@ -62,3 +62,31 @@ func TestInvalidJSONTemplate(t *testing.T) {
 		t.Fatalf("expected not specified template error, got: %s", err.Error())
 	}
 }
+
+func TestFormatOutput(t *testing.T) {
+	// No format flags
+	if _, ok, err := formatOutput(false, "", tData); ok || err != nil {
+		t.Fatalf("expected default output, got: %v %v", ok, err)
+	}
+
+	out, ok, err := formatOutput(true, "", tData)
+	if !ok || err != nil {
+		t.Fatalf("expected formatted output, got: %v %v", ok, err)
+	}
+	if out != expectJSON {
+		t.Fatalf("expected output: %s, actual: %s", expectJSON, out)
+	}
+
+	out, ok, err = formatOutput(false, "{{.Region}}", tData)
+	if !ok || err != nil {
+		t.Fatalf("expected formatted output, got: %v %v", ok, err)
+	}
+	if out != "global" {
+		t.Fatalf("expected output: global, actual: %s", out)
+	}
+
+	// Both flags
+	if _, _, err := formatOutput(true, "{{.Region}}", tData); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+type DeploymentCommand struct {
+	Meta
+}
+
+func (c *DeploymentCommand) Help() string {
+	helpText := `
+Usage: nomad deployment <subcommand> [options]
+
+  This command groups subcommands for interacting with deployments. A
+  deployment rolls out a new version of a job whose update stanza sets a
+  health check, replacing allocations in batches once the previous ones are
+  healthy.
+
+  Display the status of a deployment:
+
+      $ nomad deployment status <deployment>
+
+  Mark the pending allocations of a deployment as healthy:
+
+      $ nomad deployment promote <deployment>
+
+  Fail a deployment:
+
+      $ nomad deployment fail <deployment>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentCommand) Synopsis() string {
+	return "Interact with deployments"
+}
+
+func (c *DeploymentCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// lookupDeployment returns the deployment matching the given ID prefix. If
+// the prefix matches multiple deployments, they are returned instead.
+func lookupDeployment(client *api.Client, prefix string) (*api.Deployment, []*api.Deployment, error) {
+	if len(prefix) == 1 {
+		return nil, nil, fmt.Errorf("Identifier must contain at least two characters.")
+	}
+	if len(prefix)%2 == 1 {
+		// Identifiers must be of even length, so we strip off the last byte
+		// to provide a consistent user experience.
+		prefix = prefix[:len(prefix)-1]
+	}
+
+	deployments, _, err := client.Deployments().PrefixList(prefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error querying deployment: %v", err)
+	}
+	switch len(deployments) {
+	case 0:
+		return nil, nil, fmt.Errorf("No deployment(s) with prefix or id %q found", prefix)
+	case 1:
+		return deployments[0], nil, nil
+	default:
+		return nil, deployments, nil
+	}
+}
+
+// formatDeploymentList formats a list of deployments
+func formatDeploymentList(deployments []*api.Deployment, length int) string {
+	out := make([]string, len(deployments)+1)
+	out[0] = "ID|Job ID|Job Version|Status|Description"
+	for i, d := range deployments {
+		out[i+1] = fmt.Sprintf("%s|%s|%d|%s|%s",
+			limit(d.ID, length),
+			d.JobID,
+			d.JobVersion,
+			d.Status,
+			d.StatusDescription)
+	}
+	return formatList(out)
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type DeploymentFailCommand struct {
+	Meta
+}
+
+func (c *DeploymentFailCommand) Help() string {
+	helpText := `
+Usage: nomad deployment fail [options] <deployment>
+
+  Fail is used to mark a running deployment as failed, stopping the rollout of
+  the job. If the update stanza of the job enables auto_revert, the job is
+  reverted to its latest stable version and the resulting evaluation is
+  monitored.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Fail Options:
+
+  -detach
+    Return immediately instead of entering monitor mode. The ID of the
+    evaluation created by a revert is printed to the screen.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentFailCommand) Synopsis() string {
+	return "Manually fail a deployment"
+}
+
+func (c *DeploymentFailCommand) Run(args []string) int {
+	var detach, verbose bool
+
+	flags := c.Meta.FlagSet("deployment fail", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	deployment, matches, err := lookupDeployment(client, args[0])
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if deployment == nil {
+		c.Ui.Error(fmt.Sprintf("Prefix matched multiple deployments\n\n%s",
+			formatDeploymentList(matches, length)))
+		return 1
+	}
+
+	resp, _, err := client.Deployments().Fail(deployment.ID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error failing deployment: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Deployment %q failed", limit(deployment.ID, length)))
+	if resp.RevertedJobVersion == nil {
+		return 0
+	}
+
+	c.Ui.Output(fmt.Sprintf("Job %q reverted to version %d", deployment.JobID, *resp.RevertedJobVersion))
+	if resp.EvalID == "" {
+		return 0
+	}
+	if detach {
+		c.Ui.Output("Evaluation ID: " + resp.EvalID)
+		return 0
+	}
+
+	c.Ui.Output("")
+	mon := newMonitor(c.Ui, client, length)
+	return mon.monitor(resp.EvalID, false)
+}
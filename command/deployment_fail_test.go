@@ -0,0 +1,43 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestDeploymentFailCommand_Implements(t *testing.T) {
+	var _ cli.Command = &DeploymentFailCommand{}
+}
+
+func TestDeploymentFailCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &DeploymentFailCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a too short identifier
+	if code := cmd.Run([]string{"-address=nope", "1"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "at least two characters") {
+		t.Fatalf("expected identifier length error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "12"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying deployment") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
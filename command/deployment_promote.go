@@ -0,0 +1,114 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/helper/flag-slice"
+)
+
+type DeploymentPromoteCommand struct {
+	Meta
+}
+
+func (c *DeploymentPromoteCommand) Help() string {
+	helpText := `
+Usage: nomad deployment promote [options] <deployment>
+
+  Promote is used to mark the running allocations of a deployment whose health
+  is not yet known as healthy, allowing the deployment to proceed. It is
+  required to progress deployments of jobs whose update stanza sets the
+  "manual" health check or places canaries. Promoting the canaries of a
+  deployment continues the rollout and the resulting evaluation is monitored.
+  The canaries of only some task groups can be promoted with the group flag,
+  the rollout of the other task groups waits for them to be promoted.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Promote Options:
+
+  -detach
+    Return immediately instead of entering monitor mode. The ID of the
+    evaluation created by promoting the canaries is printed to the screen.
+
+  -group <task group>
+    Promote only the given task group. The flag can be provided more than once
+    to promote multiple task groups. All the task groups are promoted if none
+    is given.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentPromoteCommand) Synopsis() string {
+	return "Promote the canaries and pending allocations of a deployment"
+}
+
+func (c *DeploymentPromoteCommand) Run(args []string) int {
+	var detach, verbose bool
+	var groups sliceflag.StringFlag
+
+	flags := c.Meta.FlagSet("deployment promote", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.Var(&groups, "group", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	deployment, matches, err := lookupDeployment(client, args[0])
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if deployment == nil {
+		c.Ui.Error(fmt.Sprintf("Prefix matched multiple deployments\n\n%s",
+			formatDeploymentList(matches, length)))
+		return 1
+	}
+
+	resp, _, err := client.Deployments().PromoteGroups(deployment.ID, groups, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error promoting deployment: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Deployment %q promoted", limit(deployment.ID, length)))
+	if resp.EvalID == "" {
+		return 0
+	}
+	if detach {
+		c.Ui.Output("Evaluation ID: " + resp.EvalID)
+		return 0
+	}
+
+	c.Ui.Output("")
+	mon := newMonitor(c.Ui, client, length)
+	return mon.monitor(resp.EvalID, false)
+}
@@ -0,0 +1,154 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type DeploymentStatusCommand struct {
+	Meta
+}
+
+func (c *DeploymentStatusCommand) Help() string {
+	helpText := `
+Usage: nomad deployment status [options] [deployment]
+
+  Display the status of deployments. If no deployment ID is given, a list of
+  all the deployments is displayed. Otherwise the progress of the given
+  deployment is displayed for each task group.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Status Options:
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentStatusCommand) Synopsis() string {
+	return "Display the status of deployments"
+}
+
+func (c *DeploymentStatusCommand) Run(args []string) int {
+	var verbose bool
+
+	flags := c.Meta.FlagSet("deployment status", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got at most one argument
+	args = flags.Args()
+	if l := len(args); l > 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Invoked without arguments, so list all the deployments
+	if len(args) == 0 {
+		deployments, _, err := client.Deployments().List(nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying deployments: %v", err))
+			return 1
+		}
+		if len(deployments) == 0 {
+			c.Ui.Output("No deployments found")
+			return 0
+		}
+		c.Ui.Output(formatDeploymentList(deployments, length))
+		return 0
+	}
+
+	deployment, matches, err := lookupDeployment(client, args[0])
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if deployment == nil {
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple deployments\n\n%s",
+			formatDeploymentList(matches, length)))
+		return 0
+	}
+
+	// Format the deployment data
+	basic := []string{
+		fmt.Sprintf("ID|%s", limit(deployment.ID, length)),
+		fmt.Sprintf("Job ID|%s", deployment.JobID),
+		fmt.Sprintf("Job Version|%d", deployment.JobVersion),
+		fmt.Sprintf("Status|%s", deployment.Status),
+		fmt.Sprintf("Description|%s", deployment.StatusDescription),
+	}
+	c.Ui.Output(formatKV(basic))
+
+	if len(deployment.TaskGroups) == 0 {
+		return 0
+	}
+
+	groups := make([]string, 0, len(deployment.TaskGroups))
+	for name := range deployment.TaskGroups {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+
+	// Only display the canaries if the deployment places any
+	canaries := false
+	for _, state := range deployment.TaskGroups {
+		if state.DesiredCanaries > 0 {
+			canaries = true
+			break
+		}
+	}
+
+	out := make([]string, 0, len(groups)+1)
+	if canaries {
+		out = append(out, "Task Group|Auto Revert|Promoted|Desired|Canaries|Placed|Healthy|Unhealthy")
+	} else {
+		out = append(out, "Task Group|Auto Revert|Desired|Placed|Healthy|Unhealthy")
+	}
+	for _, name := range groups {
+		state := deployment.TaskGroups[name]
+		if canaries {
+			out = append(out, fmt.Sprintf("%s|%t|%t|%d|%d|%d|%d|%d",
+				name,
+				state.AutoRevert,
+				state.Promoted,
+				state.DesiredTotal,
+				state.DesiredCanaries,
+				state.PlacedAllocs,
+				state.HealthyAllocs,
+				state.UnhealthyAllocs))
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s|%t|%d|%d|%d|%d",
+			name,
+			state.AutoRevert,
+			state.DesiredTotal,
+			state.PlacedAllocs,
+			state.HealthyAllocs,
+			state.UnhealthyAllocs))
+	}
+	c.Ui.Output(c.Colorize().Color("\n[bold]Deployed[reset]"))
+	c.Ui.Output(formatList(out))
+	return 0
+}
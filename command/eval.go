@@ -0,0 +1,40 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type EvalCommand struct {
+	Meta
+}
+
+func (c *EvalCommand) Help() string {
+	helpText := `
+Usage: nomad eval <subcommand> [options]
+
+  This command groups subcommands for interacting with evaluations. They help
+  operators inspect the evaluations of the jobs and clear broken evaluations,
+  such as evaluations stuck in the pending state.
+
+  List the pending evaluations of a job:
+
+      $ nomad eval list -job <job> -status pending
+
+  Delete an evaluation:
+
+      $ nomad eval delete <evaluation-id>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *EvalCommand) Synopsis() string {
+	return "Interact with evaluations"
+}
+
+func (c *EvalCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
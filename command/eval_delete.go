@@ -0,0 +1,85 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type EvalDeleteCommand struct {
+	Meta
+}
+
+func (c *EvalDeleteCommand) Help() string {
+	helpText := `
+Usage: nomad eval delete [options] <evaluation-id>...
+
+  Delete is used to delete evaluations, such as broken evaluations stuck in the
+  pending state. Evaluations being processed by a scheduler can not be deleted.
+  Each evaluation may be given by its ID or a unique prefix of it.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *EvalDeleteCommand) Synopsis() string {
+	return "Delete evaluations"
+}
+
+func (c *EvalDeleteCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("eval delete", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got at least one evaluation ID
+	args = flags.Args()
+	if len(args) == 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	for _, evalID := range args {
+		if len(evalID) == 1 {
+			c.Ui.Error("Identifier must contain at least two characters.")
+			return 1
+		}
+		if len(evalID)%2 == 1 {
+			// Identifiers must be of even length, so we strip off the last byte
+			// to provide a consistent user experience.
+			evalID = evalID[:len(evalID)-1]
+		}
+
+		evals, _, err := client.Evaluations().PrefixList(evalID)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying evaluation: %s", err))
+			return 1
+		}
+		switch len(evals) {
+		case 0:
+			c.Ui.Error(fmt.Sprintf("No evaluation(s) with prefix or id %q found", evalID))
+			return 1
+		case 1:
+		default:
+			c.Ui.Error(fmt.Sprintf("Prefix %q matched multiple evaluations", evalID))
+			return 1
+		}
+
+		if _, err := client.Evaluations().Delete(evals[0].ID, nil); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error deleting evaluation: %s", err))
+			return 1
+		}
+		c.Ui.Output(fmt.Sprintf("Successfully deleted evaluation %q", evals[0].ID))
+	}
+	return 0
+}
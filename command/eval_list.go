@@ -0,0 +1,144 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type EvalListCommand struct {
+	Meta
+}
+
+func (c *EvalListCommand) Help() string {
+	helpText := `
+Usage: nomad eval list [options]
+
+  List is used to list the evaluations, optionally filtered by job and status.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Eval List Options:
+
+  -job <job-id>
+    Only list the evaluations of the given job.
+
+  -status <status>
+    Only list the evaluations with the given status, such as "pending",
+    "blocked", "complete", "failed" or "canceled".
+
+  -verbose
+    Show full information.
+
+  -json
+    Output the evaluations in their JSON format.
+
+  -t
+    Format and display the evaluations using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *EvalListCommand) Synopsis() string {
+	return "List evaluations"
+}
+
+func (c *EvalListCommand) Run(args []string) int {
+	var verbose, json bool
+	var jobID, status, tmpl string
+
+	flags := c.Meta.FlagSet("eval list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&jobID, "job", "", "")
+	flags.StringVar(&status, "status", "", "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if l := len(args); l != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Determine the output format
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	filter := &api.EvaluationListFilter{
+		JobID:  jobID,
+		Status: status,
+	}
+	evals, _, err := client.Evaluations().ListFiltered(filter, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying evaluations: %s", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		out, err := f.TransformData(evals)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(evals) == 0 {
+		c.Ui.Output("No evaluations found")
+		return 0
+	}
+
+	// Format the evals
+	out := make([]string, len(evals)+1)
+	out[0] = "ID|Priority|Triggered By|Job ID|Status|Placement Failures"
+	for i, eval := range evals {
+		failures, _ := evalFailureStatus(eval)
+		out[i+1] = fmt.Sprintf("%s|%d|%s|%s|%s|%s",
+			limit(eval.ID, length),
+			eval.Priority,
+			eval.TriggeredBy,
+			eval.JobID,
+			eval.Status,
+			failures,
+		)
+	}
+	c.Ui.Output(formatList(out))
+	return 0
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestEvalListCommand_Implements(t *testing.T) {
+	var _ cli.Command = &EvalListCommand{}
+}
+
+func TestEvalListCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &EvalListCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on both -json and -t options
+	if code := cmd.Run([]string{"-json", "-t", "{{.ID}}"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Both -json and -t are not allowed") {
+		t.Fatalf("expected format error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying evaluations") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestEvalListCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &EvalListCommand{Meta: Meta{Ui: ui}}
+
+	// No evaluations
+	if code := cmd.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "No evaluations found") {
+		t.Fatalf("expected no evaluations, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Register a job to create an evaluation
+	job := testJob("job1_sfx")
+	evalID, _, err := client.Jobs().Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if code := cmd.Run([]string{"-address=" + url, "-job", "job1_sfx", "-verbose"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, evalID) {
+		t.Fatalf("expected eval %q, got: %s", evalID, out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Filtering on another job lists nothing
+	if code := cmd.Run([]string{"-address=" + url, "-job", "job2_sfx"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "No evaluations found") {
+		t.Fatalf("expected no evaluations, got: %s", out)
+	}
+}
@@ -20,12 +20,19 @@ Usage: nomad eval-status [options] <evaluation-id>
   current status of an evaluation as well as determine the reason an evaluation
   did not place all allocations.
 
+  If the -blocked flag is given, the evaluations blocked until resources become
+  available are listed along with the reasons they could not place their
+  allocations.
+
 General Options:
 
   ` + generalOptionsUsage() + `
 
 Eval Status Options:
 
+  -blocked
+    List the blocked evaluations and their placement failures.
+
   -monitor
     Monitor an outstanding evaluation
 
@@ -47,11 +54,12 @@ func (c *EvalStatusCommand) Synopsis() string {
 }
 
 func (c *EvalStatusCommand) Run(args []string) int {
-	var monitor, verbose, json bool
+	var blocked, monitor, verbose, json bool
 	var tmpl string
 
 	flags := c.Meta.FlagSet("eval-status", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&blocked, "blocked", false, "")
 	flags.BoolVar(&monitor, "monitor", false, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
 	flags.BoolVar(&json, "json", false, "")
@@ -71,6 +79,21 @@ func (c *EvalStatusCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// List the blocked evaluations
+	if blocked {
+		if len(args) != 0 {
+			c.Ui.Error(c.Help())
+			return 1
+		}
+		return c.blockedEvals(client, json, tmpl, length)
+	}
+
 	// If args not specified but output format is specified, format and output the evaluations data list
 	if len(args) == 0 {
 		var format string
@@ -116,12 +139,6 @@ func (c *EvalStatusCommand) Run(args []string) int {
 
 	evalID := args[0]
 
-	// Truncate the id unless full length is requested
-	length := shortId
-	if verbose {
-		length = fullId
-	}
-
 	// Query the allocation info
 	if len(evalID) == 1 {
 		c.Ui.Error(fmt.Sprintf("Identifier must contain at least two characters."))
@@ -249,6 +266,97 @@ func (c *EvalStatusCommand) Run(args []string) int {
 	return 0
 }
 
+// blockedEvals outputs the blocked evaluations along with the placement
+// failures of their task groups.
+func (c *EvalStatusCommand) blockedEvals(client *api.Client, json bool, tmpl string, length int) int {
+	evals, _, err := client.Evaluations().Blocked(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying blocked evaluations: %v", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		out, err := f.TransformData(evals)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(evals) == 0 {
+		c.Ui.Output("No blocked evaluations")
+		return 0
+	}
+
+	// Format the evals
+	out := make([]string, len(evals)+1)
+	out[0] = "ID|Job ID|Priority|Type|Triggered By|Ineligible Classes"
+	for i, eval := range evals {
+		out[i+1] = fmt.Sprintf("%s|%s|%d|%s|%s|%s",
+			limit(eval.ID, length),
+			eval.JobID,
+			eval.Priority,
+			eval.Type,
+			eval.TriggeredBy,
+			formatIneligibleClasses(eval),
+		)
+	}
+	c.Ui.Output(formatList(out))
+
+	for _, eval := range evals {
+		if len(eval.FailedTGAllocs) == 0 {
+			continue
+		}
+
+		c.Ui.Output(c.Colorize().Color(fmt.Sprintf("\n[bold]Evaluation %q Failed Placements[reset]",
+			limit(eval.ID, length))))
+		sorted := sortedTaskGroupFromMetrics(eval.FailedTGAllocs)
+		for _, tg := range sorted {
+			metrics := eval.FailedTGAllocs[tg]
+
+			noun := "allocation"
+			if metrics.CoalescedFailures > 0 {
+				noun += "s"
+			}
+			c.Ui.Output(fmt.Sprintf("Task Group %q (failed to place %d %s):", tg, metrics.CoalescedFailures+1, noun))
+			c.Ui.Output(formatAllocMetrics(metrics, false, "  "))
+		}
+	}
+
+	return 0
+}
+
+// formatIneligibleClasses returns the computed node classes the evaluation is
+// known to be ineligible for.
+func formatIneligibleClasses(eval *api.Evaluation) string {
+	var classes []string
+	for class, eligible := range eval.ClassEligibility {
+		if !eligible {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+	return strings.Join(classes, ",")
+}
+
 func sortedTaskGroupFromMetrics(groups map[string]*api.AllocationMetric) []string {
 	tgs := make([]string, 0, len(groups))
 	for tg, _ := range groups {
@@ -260,7 +368,8 @@ func sortedTaskGroupFromMetrics(groups map[string]*api.AllocationMetric) []strin
 
 func getTriggerDetails(eval *api.Evaluation) (noun, subject string) {
 	switch eval.TriggeredBy {
-	case "job-register", "job-deregister", "periodic-job", "rolling-update":
+	case "job-register", "job-deregister", "periodic-job", "rolling-update",
+		"deployment-watcher":
 		return "Job ID", eval.JobID
 	case "node-update":
 		return "Node ID", eval.NodeID
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -52,5 +53,41 @@ func TestEvalStatusCommand_Fails(t *testing.T) {
 	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Both -json and -t are not allowed") {
 		t.Fatalf("expected getting formatter error, got: %s", out)
 	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an evaluation ID along with -blocked
+	if code := cmd.Run([]string{"-address=" + url, "-blocked", "12345678"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+}
+
+func TestEvalStatusCommand_Blocked(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &EvalStatusCommand{Meta: Meta{Ui: ui}}
+
+	if code := cmd.Run([]string{"-address=" + url, "-blocked"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "No blocked evaluations") {
+		t.Fatalf("expected no blocked evaluations, got: %s", out)
+	}
+}
 
+func TestFormatIneligibleClasses(t *testing.T) {
+	eval := &api.Evaluation{
+		ClassEligibility: map[string]bool{
+			"v1:2": false,
+			"v1:3": true,
+			"v1:1": false,
+		},
+	}
+	if out := formatIneligibleClasses(eval); out != "v1:1,v1:2" {
+		t.Fatalf("bad: %q", out)
+	}
 }
@@ -1,11 +1,17 @@
 package command
 
 import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -55,6 +61,13 @@ FS Specific Options:
   -stat
     Show file stat information instead of displaying the file, or listing the directory.
 
+  -json
+    Output the file stat information or directory listing in its JSON format.
+
+  -t
+    Format and display the file stat information or directory listing using
+    a Go template.
+
   -f
     Causes the output to not stop when the end of the file is reached, but rather to
     wait for additional output.
@@ -69,6 +82,11 @@ FS Specific Options:
 
   -c
     Sets the tail location in number of bytes relative to the end of the file.
+
+  -sync <dir>
+    Downloads the files at or below the path into the local directory. Only
+    the files whose checksums differ from the local copies are transferred,
+    as a single tar stream, so repeated syncs only pull changed files.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -78,8 +96,9 @@ func (f *FSCommand) Synopsis() string {
 }
 
 func (f *FSCommand) Run(args []string) int {
-	var verbose, machine, job, stat, tail, follow bool
+	var verbose, machine, job, stat, tail, follow, json bool
 	var numLines, numBytes int64
+	var tmpl, syncDir string
 
 	flags := f.Meta.FlagSet("fs", FlagSetClient)
 	flags.Usage = func() { f.Ui.Output(f.Help()) }
@@ -91,6 +110,9 @@ func (f *FSCommand) Run(args []string) int {
 	flags.BoolVar(&tail, "tail", false, "")
 	flags.Int64Var(&numLines, "n", -1, "")
 	flags.Int64Var(&numBytes, "c", -1, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+	flags.StringVar(&syncDir, "sync", "", "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -181,6 +203,11 @@ func (f *FSCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Sync the path into the local directory and exit
+	if syncDir != "" {
+		return f.syncPath(client, alloc, path, syncDir)
+	}
+
 	// Get file stat info
 	file, _, err := client.AllocFS().Stat(alloc, path, nil)
 	if err != nil {
@@ -190,6 +217,15 @@ func (f *FSCommand) Run(args []string) int {
 
 	// If we want file stats, print those and exit.
 	if stat {
+		// If output format is specified, format and output the data
+		if out, ok, err := formatOutput(json, tmpl, file); err != nil {
+			f.Ui.Error(err.Error())
+			return 1
+		} else if ok {
+			f.Ui.Output(out)
+			return 0
+		}
+
 		// Display the file information
 		out := make([]string, 2)
 		out[0] = "Mode|Size|Modified Time|Name"
@@ -219,6 +255,16 @@ func (f *FSCommand) Run(args []string) int {
 			f.Ui.Error(fmt.Sprintf("Error listing alloc dir: %s", err))
 			return 1
 		}
+
+		// If output format is specified, format and output the data
+		if out, ok, err := formatOutput(json, tmpl, files); err != nil {
+			f.Ui.Error(err.Error())
+			return 1
+		} else if ok {
+			f.Ui.Output(out)
+			return 0
+		}
+
 		// Display the file information in a tabular format
 		out := make([]string, len(files)+1)
 		out[0] = "Mode|Size|Modified Time|Name"
@@ -244,6 +290,12 @@ func (f *FSCommand) Run(args []string) int {
 		return 0
 	}
 
+	// The contents of a file can't be formatted
+	if json || len(tmpl) > 0 {
+		f.Ui.Error("-json and -t can only be used with -stat or a directory")
+		return 1
+	}
+
 	// We have a file, output it.
 	var r io.ReadCloser
 	var readErr error
@@ -304,7 +356,10 @@ func (f *FSCommand) Run(args []string) int {
 		return 1
 	}
 
-	io.Copy(os.Stdout, r)
+	if _, err := io.Copy(os.Stdout, r); err != nil {
+		f.Ui.Error(fmt.Sprintf("Error reading file: %v", err))
+		return 1
+	}
 	return 0
 }
 
@@ -342,6 +397,109 @@ func (f *FSCommand) followFile(client *api.Client, alloc *api.Allocation,
 	return r, nil
 }
 
+// syncPath downloads the files at or below path of the allocation directory
+// into the local directory, skipping the files whose local copy has the same
+// checksum.
+func (f *FSCommand) syncPath(client *api.Client, alloc *api.Allocation, path, dir string) int {
+	remote, _, err := client.AllocFS().Checksums(alloc, path, nil)
+	if err != nil {
+		f.Ui.Error(fmt.Sprintf("Error computing checksums: %v", err))
+		return 1
+	}
+
+	var changed []string
+	for name, sum := range remote {
+		if local, err := localChecksum(filepath.Join(dir, filepath.FromSlash(name))); err == nil && local == sum {
+			continue
+		}
+		changed = append(changed, name)
+	}
+	if len(changed) == 0 {
+		f.Ui.Output(fmt.Sprintf("All %d files are up to date", len(remote)))
+		return 0
+	}
+	sort.Strings(changed)
+
+	r, err := client.AllocFS().Tar(alloc, path, changed, nil)
+	if err != nil {
+		f.Ui.Error(fmt.Sprintf("Error downloading files: %v", err))
+		return 1
+	}
+	defer r.Close()
+
+	n, err := extractTar(r, dir)
+	if err != nil {
+		f.Ui.Error(fmt.Sprintf("Error extracting files: %v", err))
+		return 1
+	}
+	f.Ui.Output(fmt.Sprintf("Synced %d of %d files to %q", n, len(remote), dir))
+	return 0
+}
+
+// localChecksum returns the hex encoded SHA256 checksum of the local file.
+func localChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// extractTar writes the regular files of the tar stream into the directory,
+// returning the number of files written. Each file is written to a temporary
+// file first so that an interrupted sync never leaves a partial file behind.
+func extractTar(r io.Reader, dir string) (int, error) {
+	n := 0
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return n, fmt.Errorf("file %q is outside of %q", hdr.Name, dir)
+		}
+		dest := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return n, err
+		}
+
+		tmp, err := ioutil.TempFile(filepath.Dir(dest), ".nomad-sync")
+		if err != nil {
+			return n, err
+		}
+		_, err = io.Copy(tmp, tr)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), hdr.FileInfo().Mode().Perm())
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), dest)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return n, err
+		}
+		n++
+	}
+}
+
 // Get Random Allocation ID from a known jobID. Prefer to use a running allocation,
 // but use a dead allocation if no running allocations are found
 func getRandomJobAlloc(client *api.Client, jobID string) (string, error) {
@@ -1,6 +1,11 @@
 package command
 
 import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -81,3 +86,59 @@ func TestFSCommand_Fails(t *testing.T) {
 	}
 
 }
+
+func TestFSCommand_ExtractTar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad-fs")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTar := func(files map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for name, contents := range files {
+			hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))}
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if _, err := tw.Write([]byte(contents)); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return &buf
+	}
+
+	n, err := extractTar(writeTar(map[string]string{"sub/out.txt": "foo"}), dir)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 file, got %d", n)
+	}
+	path := filepath.Join(dir, "sub", "out.txt")
+	contents, err := ioutil.ReadFile(path)
+	if err != nil || string(contents) != "foo" {
+		t.Fatalf("bad contents %q: %v", contents, err)
+	}
+
+	// The extracted file matches the checksum of its contents
+	sum, err := localChecksum(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sum != "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae" {
+		t.Fatalf("bad checksum: %s", sum)
+	}
+
+	// Files escaping the directory are rejected
+	if _, err := extractTar(writeTar(map[string]string{"../escape.txt": "bar"}), dir); err == nil {
+		t.Fatalf("expected error extracting file outside of dir")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file escaped the directory: %v", err)
+	}
+}
@@ -2,15 +2,19 @@ package command
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	gg "github.com/hashicorp/go-getter"
 	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper/flag-slice"
 	"github.com/hashicorp/nomad/jobspec"
 	"github.com/hashicorp/nomad/nomad/structs"
 
@@ -43,6 +47,64 @@ func formatListWithSpaces(in []string) string {
 	return columnize.Format(in, columnConf)
 }
 
+// parseColumns splits the comma separated value of a -columns flag into the
+// selected field names.
+func parseColumns(in string) []string {
+	var columns []string
+	for _, c := range strings.Split(in, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// formatColumns formats the given fields of each element of items, which
+// must be a slice of structs or pointers to structs, into a list using
+// formatList. The fields listed in uuids, which hold UUIDs, are truncated to
+// length.
+func formatColumns(items interface{}, columns, uuids []string, length int) (string, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return "", fmt.Errorf("cannot format columns of %T", items)
+	}
+
+	// Validate the columns against the element type so that unknown columns
+	// are reported even if there are no items.
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return "", fmt.Errorf("cannot format columns of %T", items)
+	}
+	for _, c := range columns {
+		if _, ok := elem.FieldByName(c); !ok {
+			return "", fmt.Errorf("Unknown column %q", c)
+		}
+	}
+
+	truncate := make(map[string]struct{}, len(uuids))
+	for _, c := range uuids {
+		truncate[c] = struct{}{}
+	}
+
+	out := make([]string, v.Len()+1)
+	out[0] = strings.Join(columns, "|")
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.Indirect(v.Index(i))
+		fields := make([]string, len(columns))
+		for j, c := range columns {
+			fields[j] = fmt.Sprintf("%v", item.FieldByName(c).Interface())
+			if _, ok := truncate[c]; ok {
+				fields[j] = limit(fields[j], length)
+			}
+		}
+		out[i+1] = strings.Join(fields, "|")
+	}
+	return formatList(out), nil
+}
+
 // Limits the length of the string.
 func limit(s string, length int) string {
 	if len(s) < length {
@@ -230,12 +292,107 @@ READ:
 }
 
 type JobGetter struct {
+	// vars and varFiles hold the -var and -var-file flags whose variables are
+	// interpolated into the jobfile
+	vars     sliceflag.StringFlag
+	varFiles sliceflag.StringFlag
+
 	// The fields below can be overwritten for tests
 	testStdin io.Reader
 }
 
+// VarFlags adds the -var and -var-file flags to the flag set
+func (j *JobGetter) VarFlags(flags *flag.FlagSet) {
+	flags.Var(&j.vars, "var", "")
+	flags.Var(&j.varFiles, "var-file", "")
+}
+
+// jobVarsUsage returns the help text of the flags added by VarFlags
+func jobVarsUsage() string {
+	helpText := `
+  -var 'name=value'
+    Sets a variable referenced in the job file as ${var.name}. Can be
+    specified multiple times.
+
+  -var-file=<path>
+    Sets the variables assigned in an HCL file, one per line such as
+    image_tag = "3.2". Can be specified multiple times. Variables set with
+    -var take precedence.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// jobVars returns the variables set by the -var-file and -var flags. Later
+// files override earlier ones and -var flags override all files.
+func (j *JobGetter) jobVars() (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, path := range j.varFiles {
+		fileVars, err := jobspec.ParseVarFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading variable file: %v", err)
+		}
+		for name, value := range fileVars {
+			vars[name] = value
+		}
+	}
+
+	for _, v := range j.vars {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid variable %q, must be of the form name=value", v)
+		}
+		vars[parts[0]] = parts[1]
+	}
+	return vars, nil
+}
+
 // StructJob returns the Job struct from jobfile.
 func (j *JobGetter) StructJob(jpath string) (*structs.Job, error) {
+	jobfile, err := j.readJobFile(jpath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the JobFile
+	jobStruct, err := jobspec.Parse(bytes.NewReader(jobfile))
+	if err != nil {
+		fmt.Errorf("Error parsing job file from %s: %v", jpath, err)
+		return nil, err
+	}
+
+	return jobStruct, nil
+}
+
+// StructJobPositions returns the Job struct from jobfile along with the
+// positions of the job, task groups and tasks in it.
+func (j *JobGetter) StructJobPositions(jpath string) (*structs.Job, *jobspec.Positions, error) {
+	jobfile, err := j.readJobFile(jpath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jobStruct, err := jobspec.Parse(bytes.NewReader(jobfile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pos, err := jobspec.ParsePositions(bytes.NewReader(jobfile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return jobStruct, pos, nil
+}
+
+// readJobFile returns the contents of the jobfile, reading it from stdin if
+// the path is "-" or downloading it otherwise. Variables are interpolated into
+// the returned contents.
+func (j *JobGetter) readJobFile(jpath string) ([]byte, error) {
+	vars, err := j.jobVars()
+	if err != nil {
+		return nil, err
+	}
+
 	var jobfile io.Reader
 	switch jpath {
 	case "-":
@@ -283,12 +440,9 @@ func (j *JobGetter) StructJob(jpath string) (*structs.Job, error) {
 		}
 	}
 
-	// Parse the JobFile
-	jobStruct, err := jobspec.Parse(jobfile)
+	contents, err := ioutil.ReadAll(jobfile)
 	if err != nil {
-		fmt.Errorf("Error parsing job file from %s: %v", jpath, err)
 		return nil, err
 	}
-
-	return jobStruct, nil
+	return jobspec.Interpolate(contents, vars)
 }
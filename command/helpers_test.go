@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -38,6 +39,53 @@ func TestHelpers_FormatList(t *testing.T) {
 	}
 }
 
+func TestHelpers_FormatColumns(t *testing.T) {
+	allocs := []*api.AllocationListStub{
+		{
+			ID:           "2f5b6a7c-1a2b-3c4d-5e6f-7a8b9c0d1e2f",
+			JobID:        "example",
+			ClientStatus: "running",
+		},
+	}
+
+	columns := parseColumns("ID, JobID,,ClientStatus")
+	if !reflect.DeepEqual(columns, []string{"ID", "JobID", "ClientStatus"}) {
+		t.Fatalf("bad columns: %v", columns)
+	}
+
+	out, err := formatColumns(allocs, columns, allocUUIDColumns, shortId)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expect := "ID        JobID    ClientStatus\n2f5b6a7c  example  running"
+	if out != expect {
+		t.Fatalf("expect: %q, got: %q", expect, out)
+	}
+
+	// Job IDs are never truncated, unlike the UUIDs of the other columns
+	jobs := []*api.JobListStub{
+		{
+			ID:       "a-very-long-job-name-exceeding-the-short-id",
+			ParentID: "a-very-long-parent-job-name",
+			Status:   "running",
+		},
+	}
+	out, err = formatColumns(jobs, []string{"ID", "ParentID", "Status"}, nil, shortId)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expect = "ID                                           ParentID                     Status\n" +
+		"a-very-long-job-name-exceeding-the-short-id  a-very-long-parent-job-name  running"
+	if out != expect {
+		t.Fatalf("expect: %q, got: %q", expect, out)
+	}
+
+	// Unknown columns are an error even without items
+	if _, err := formatColumns([]*api.AllocationListStub{}, []string{"Foo"}, nil, shortId); err == nil {
+		t.Fatalf("expected unknown column error")
+	}
+}
+
 func TestHelpers_NodeID(t *testing.T) {
 	srv, _, _ := testServer(t, nil)
 	defer srv.Stop()
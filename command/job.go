@@ -0,0 +1,44 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type JobCommand struct {
+	Meta
+}
+
+func (c *JobCommand) Help() string {
+	helpText := `
+Usage: nomad job <subcommand> [options]
+
+  This command groups subcommands for interacting with jobs. Users can
+  dispatch instances of parameterized jobs and inspect or revert to the
+  tracked versions of a job.
+
+  Dispatch an instance of a parameterized job:
+
+      $ nomad job dispatch -meta input=foo <parameterized job>
+
+  Display the tracked versions of a job:
+
+      $ nomad job history <job>
+
+  Revert a job to a prior version:
+
+      $ nomad job revert <job> <version>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobCommand) Synopsis() string {
+	return "Interact with jobs"
+}
+
+func (c *JobCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
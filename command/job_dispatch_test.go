@@ -0,0 +1,52 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestJobDispatchCommand_Implements(t *testing.T) {
+	var _ cli.Command = &JobDispatchCommand{}
+}
+
+func TestJobDispatchCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &JobDispatchCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails when specified file does not exist
+	if code := cmd.Run([]string{"foo", "/unicorns/leprechauns"}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error reading input data") {
+		t.Fatalf("expect error reading input data, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on malformed meta
+	if code := cmd.Run([]string{"-meta", "foo", "foo"}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error parsing meta value") {
+		t.Fatalf("expect error parsing meta, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Failed to dispatch job") {
+		t.Fatalf("expected failed dispatch error, got: %s", out)
+	}
+}
@@ -0,0 +1,148 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type JobHistoryCommand struct {
+	Meta
+}
+
+func (c *JobHistoryCommand) Help() string {
+	helpText := `
+Usage: nomad job history [options] <job>
+
+  History is used to display the known versions of a particular job. The
+  tracked versions are listed from the most recent. A prior version can be
+  restored with the "nomad job revert" command.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+History Options:
+
+  -p
+    Display the difference between each version of the job and the version
+    preceding it.
+
+  -version <job version>
+    Display only the given version of the job.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobHistoryCommand) Synopsis() string {
+	return "Display all tracked versions of a job"
+}
+
+func (c *JobHistoryCommand) Run(args []string) int {
+	var versionStr string
+	var diff bool
+
+	flags := c.Meta.FlagSet("job history", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&diff, "p", false, "")
+	flags.StringVar(&versionStr, "version", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	jobID := args[0]
+
+	// Parse the version to display
+	var version uint64
+	if versionStr != "" {
+		var err error
+		version, err = strconv.ParseUint(versionStr, 10, 64)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to parse job version %q: %v", versionStr, err))
+			return 1
+		}
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Retrieve the job versions
+	versions, _, err := client.Jobs().Versions(jobID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving job versions: %s", err))
+		return 1
+	}
+
+	header := []string{"Version", "Stable", "Job Modify Index", "Type", "Priority", "Task Groups"}
+	out := make([]string, 0, len(versions)+1)
+	out = append(out, strings.Join(header, "|"))
+	found := false
+	for i, job := range versions {
+		if versionStr != "" && job.Version != version {
+			continue
+		}
+
+		groups := make([]string, 0, len(job.TaskGroups))
+		for _, tg := range job.TaskGroups {
+			groups = append(groups, fmt.Sprintf("%s (%d)", tg.Name, tg.Count))
+		}
+		values := []string{
+			fmt.Sprintf("%d", job.Version),
+			fmt.Sprintf("%t", job.Stable),
+			fmt.Sprintf("%d", job.JobModifyIndex),
+			job.Type,
+			fmt.Sprintf("%d", job.Priority),
+			strings.Join(groups, ", "),
+		}
+		if !diff {
+			out = append(out, strings.Join(values, "|"))
+			found = true
+			continue
+		}
+
+		// Display the version along with its difference to the preceding
+		// version, which is the next one as they are ordered from the most
+		// recent
+		if found {
+			c.Ui.Output("")
+		}
+		found = true
+		basic := make([]string, len(header))
+		for j := range header {
+			basic[j] = fmt.Sprintf("%s|%s", header[j], values[j])
+		}
+		c.Ui.Output(formatKV(basic))
+		if i+1 == len(versions) {
+			continue
+		}
+		prev := versions[i+1].Version
+		jobDiff, _, err := client.Jobs().VersionDiff(jobID, &prev, &job.Version, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error retrieving the diff of job version %d: %s", job.Version, err))
+			return 1
+		}
+		c.Ui.Output(fmt.Sprintf("Diff to version %d:", prev))
+		c.Ui.Output(c.Colorize().Color(strings.TrimSpace(formatJobDiff(jobDiff, false))))
+	}
+
+	if !found {
+		c.Ui.Error(fmt.Sprintf("Job %q has no tracked version %d", jobID, version))
+		return 1
+	}
+
+	if !diff {
+		c.Ui.Output(formatList(out))
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestJobHistoryCommand_Implements(t *testing.T) {
+	var _ cli.Command = &JobHistoryCommand{}
+}
+
+func TestJobHistoryCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &JobHistoryCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a malformed version
+	if code := cmd.Run([]string{"-version", "foo", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Failed to parse job version") {
+		t.Fatalf("expected version parse error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error retrieving job versions") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestJobHistoryCommand_Diff(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	// Register the job twice
+	job := testJob("job1")
+	if _, _, err := client.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	job.Priority = 80
+	if _, _, err := client.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &JobHistoryCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, "-p", "job1"}); code != 0 {
+		t.Fatalf("expected exit code 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Diff to version 0") || !strings.Contains(out, "Priority") {
+		t.Fatalf("expected the diff of the versions, got: %s", out)
+	}
+	if strings.Count(out, "Diff to version") != 1 {
+		t.Fatalf("expected the first version to have no diff, got: %s", out)
+	}
+}
@@ -0,0 +1,285 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type JobLogsCommand struct {
+	Meta
+}
+
+func (l *JobLogsCommand) Help() string {
+	helpText := `
+Usage: nomad job-logs [options] <job>
+
+  Streams the stdout/stderr of every task in the running allocations of the
+  given job. Lines from the different allocations are interleaved and each line
+  is prefixed with the short allocation ID and task name it originated from.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Job Logs Specific Options:
+
+  -stderr:
+    Display stderr logs.
+
+  -verbose
+    Show full information.
+
+  -task <task>
+    Only stream the logs of the given task.
+
+  -f, -follow
+    Causes the output to not stop when the end of the logs are reached, but
+    rather to wait for additional output.
+
+  -tail
+    Show the logs contents with offsets relative to the end of the logs. If no
+    offset is given, -n is defaulted to 10.
+
+  -n
+    Sets the tail location in best-efforted number of lines relative to the end
+    of the logs.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (l *JobLogsCommand) Synopsis() string {
+	return "Streams the logs of all allocations of a job."
+}
+
+// jobLogTarget is a single task log stream of a job
+type jobLogTarget struct {
+	alloc *api.Allocation
+	task  string
+}
+
+func (l *JobLogsCommand) Run(args []string) int {
+	var verbose, tail, stderr, follow bool
+	var task string
+	var numLines int64
+
+	flags := l.Meta.FlagSet("job-logs", FlagSetClient)
+	flags.Usage = func() { l.Ui.Output(l.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&tail, "tail", false, "")
+	flags.BoolVar(&follow, "f", false, "")
+	flags.BoolVar(&follow, "follow", false, "")
+	flags.BoolVar(&stderr, "stderr", false, "")
+	flags.StringVar(&task, "task", "", "")
+	flags.Int64Var(&numLines, "n", -1, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	args = flags.Args()
+
+	if len(args) != 1 {
+		l.Ui.Error(l.Help())
+		return 1
+	}
+	jobID := args[0]
+
+	client, err := l.Meta.Client()
+	if err != nil {
+		l.Ui.Error(fmt.Sprintf("Error initializing client: %v", err))
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Check if the job exists
+	jobs, _, err := client.Jobs().PrefixList(jobID)
+	if err != nil {
+		l.Ui.Error(fmt.Sprintf("Error querying job: %s", err))
+		return 1
+	}
+	if len(jobs) == 0 {
+		l.Ui.Error(fmt.Sprintf("No job(s) with prefix or id %q found", jobID))
+		return 1
+	}
+	if len(jobs) > 1 && strings.TrimSpace(jobID) != jobs[0].ID {
+		out := make([]string, len(jobs)+1)
+		out[0] = "ID|Type|Priority|Status"
+		for i, job := range jobs {
+			out[i+1] = fmt.Sprintf("%s|%s|%d|%s",
+				job.ID,
+				job.Type,
+				job.Priority,
+				job.Status)
+		}
+		l.Ui.Output(fmt.Sprintf("Prefix matched multiple jobs\n\n%s", formatList(out)))
+		return 0
+	}
+	jobID = jobs[0].ID
+
+	allocs, _, err := client.Jobs().Allocations(jobID, nil)
+	if err != nil {
+		l.Ui.Error(fmt.Sprintf("Error querying job allocations: %s", err))
+		return 1
+	}
+
+	targets := jobLogTargets(allocs, task)
+	if len(targets) == 0 {
+		if task != "" {
+			l.Ui.Error(fmt.Sprintf("No running allocations of job %q with task %q found", jobID, task))
+		} else {
+			l.Ui.Error(fmt.Sprintf("No running allocations of job %q found", jobID))
+		}
+		return 1
+	}
+
+	logType := "stdout"
+	if stderr {
+		logType = "stderr"
+	}
+
+	origin := api.OriginStart
+	var offset int64
+	if tail {
+		if numLines == -1 {
+			numLines = defaultTailLines
+		}
+		origin = api.OriginEnd
+		offset = numLines * bytesToLines
+	} else if numLines != -1 {
+		l.Ui.Error("-n requires -tail")
+		return 1
+	}
+
+	// Open a stream per task
+	readers := make([]io.ReadCloser, 0, len(targets))
+	closeAll := func() {
+		for _, r := range readers {
+			r.Close()
+		}
+	}
+	for _, t := range targets {
+		cancel := make(chan struct{})
+		frames, err := client.AllocFS().Logs(t.alloc, follow, t.task, logType, origin, offset, cancel, nil)
+		if err != nil {
+			closeAll()
+			l.Ui.Error(fmt.Sprintf("Error streaming logs of task %q in allocation %q: %v",
+				t.task, limit(t.alloc.ID, length), err))
+			return 1
+		}
+
+		var r io.ReadCloser
+		frameReader := api.NewFrameReader(frames, cancel)
+		frameReader.SetUnblockTime(500 * time.Millisecond)
+		r = frameReader
+		if tail {
+			r = NewLineLimitReader(r, int(numLines), int(numLines*bytesToLines), 1*time.Second)
+		}
+		readers = append(readers, r)
+	}
+
+	// End the streaming on interrupt
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+	go func() {
+		<-signalCh
+		closeAll()
+	}()
+
+	// Interleave the lines of all streams
+	lines := make(chan string, 10)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		prefix := fmt.Sprintf("[%s/%s] ", limit(t.alloc.ID, length), t.task)
+		go func(r io.Reader, prefix string) {
+			defer wg.Done()
+			prefixLines(r, prefix, lines)
+		}(readers[i], prefix)
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	for line := range lines {
+		l.Ui.Output(line)
+	}
+	closeAll()
+	return 0
+}
+
+// jobLogTargets returns the tasks of the running allocations whose logs should
+// be streamed, restricted to the given task if it is set. The targets are
+// sorted by allocation ID and task name.
+func jobLogTargets(allocs []*api.AllocationListStub, task string) []*jobLogTarget {
+	running := make(map[string]*api.AllocationListStub, len(allocs))
+	ids := make([]string, 0, len(allocs))
+	for _, stub := range allocs {
+		if stub.ClientStatus == "running" {
+			running[stub.ID] = stub
+			ids = append(ids, stub.ID)
+		}
+	}
+	sort.Strings(ids)
+
+	var targets []*jobLogTarget
+	for _, id := range ids {
+		stub := running[id]
+		tasks := make([]string, 0, len(stub.TaskStates))
+		for name := range stub.TaskStates {
+			if task == "" || name == task {
+				tasks = append(tasks, name)
+			}
+		}
+		sort.Strings(tasks)
+
+		alloc := &api.Allocation{ID: stub.ID, NodeID: stub.NodeID}
+		for _, name := range tasks {
+			targets = append(targets, &jobLogTarget{alloc: alloc, task: name})
+		}
+	}
+	return targets
+}
+
+// prefixLines reads r until EOF and sends every complete line to out with the
+// given prefix. A trailing partial line is sent once r is exhausted. Reads
+// returning no data are tolerated so that readers which periodically unblock
+// can be used.
+func prefixLines(r io.Reader, prefix string, out chan<- string) {
+	var partial []byte
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		partial = append(partial, buf[:n]...)
+		for {
+			idx := bytes.IndexByte(partial, '\n')
+			if idx == -1 {
+				break
+			}
+			out <- prefix + string(partial[:idx])
+			partial = partial[idx+1:]
+		}
+
+		if err != nil {
+			if len(partial) != 0 {
+				out <- prefix + string(partial)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestJobLogsCommand_Implements(t *testing.T) {
+	var _ cli.Command = &JobLogsCommand{}
+}
+
+func TestJobLogsCommand_Fails(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &JobLogsCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying job") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on missing job
+	if code := cmd.Run([]string{"-address=" + url, "nope"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "No job(s) with prefix or id") {
+		t.Fatalf("expected not found error, got: %s", out)
+	}
+}
+
+func TestJobLogsCommand_Targets(t *testing.T) {
+	allocs := []*api.AllocationListStub{
+		{
+			ID:           "b",
+			ClientStatus: "running",
+			TaskStates:   map[string]*api.TaskState{"web": nil, "sidecar": nil},
+		},
+		{
+			ID:           "c",
+			ClientStatus: "complete",
+			TaskStates:   map[string]*api.TaskState{"web": nil},
+		},
+		{
+			ID:           "a",
+			ClientStatus: "running",
+			TaskStates:   map[string]*api.TaskState{"web": nil},
+		},
+	}
+
+	var got []string
+	for _, target := range jobLogTargets(allocs, "") {
+		got = append(got, target.alloc.ID+"/"+target.task)
+	}
+	expected := []string{"a/web", "b/sidecar", "b/web"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v; want %v", got, expected)
+	}
+
+	got = nil
+	for _, target := range jobLogTargets(allocs, "sidecar") {
+		got = append(got, target.alloc.ID+"/"+target.task)
+	}
+	expected = []string{"b/sidecar"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v; want %v", got, expected)
+	}
+}
+
+func TestJobLogsCommand_PrefixLines(t *testing.T) {
+	out := make(chan string, 10)
+	prefixLines(strings.NewReader("foo\nbar\nbaz"), "[a/web] ", out)
+	close(out)
+
+	var got []string
+	for line := range out {
+		got = append(got, line)
+	}
+	expected := []string{"[a/web] foo", "[a/web] bar", "[a/web] baz"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v; want %v", got, expected)
+	}
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestJobRevertCommand_Implements(t *testing.T) {
+	var _ cli.Command = &JobRevertCommand{}
+}
+
+func TestJobRevertCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &JobRevertCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a malformed version
+	if code := cmd.Run([]string{"foo", "bar"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Failed to parse job version") {
+		t.Fatalf("expected version parse error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foo", "1"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error reverting job") {
+		t.Fatalf("expected failed revert error, got: %s", out)
+	}
+}
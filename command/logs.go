@@ -236,7 +236,10 @@ func (l *LogsCommand) Run(args []string) int {
 	}
 
 	defer r.Close()
-	io.Copy(os.Stdout, r)
+	if _, err := io.Copy(os.Stdout, r); err != nil {
+		l.Ui.Error(fmt.Sprintf("Error reading file: %v", err))
+		return 1
+	}
 	return 0
 }
 
@@ -15,8 +15,9 @@ import (
 const (
 	// Names of environment variables used to supply various
 	// config options to the Nomad CLI.
-	EnvNomadAddress = "NOMAD_ADDR"
-	EnvNomadRegion  = "NOMAD_REGION"
+	EnvNomadAddress   = "NOMAD_ADDR"
+	EnvNomadRegion    = "NOMAD_REGION"
+	EnvNomadNamespace = "NOMAD_NAMESPACE"
 
 	// Constants for CLI identifier length
 	shortId = 8
@@ -46,6 +47,16 @@ type Meta struct {
 
 	// The region to send API requests
 	region string
+
+	// The namespace of the API requests
+	namespace string
+
+	// TLS related flags
+	caCert     string
+	caPath     string
+	clientCert string
+	clientKey  string
+	insecure   bool
 }
 
 // FlagSet returns a FlagSet with the common flags that every
@@ -60,7 +71,13 @@ func (m *Meta) FlagSet(n string, fs FlagSetFlags) *flag.FlagSet {
 	if fs&FlagSetClient != 0 {
 		f.StringVar(&m.flagAddress, "address", "", "")
 		f.StringVar(&m.region, "region", "", "")
+		f.StringVar(&m.namespace, "namespace", "", "")
 		f.BoolVar(&m.noColor, "no-color", false, "")
+		f.StringVar(&m.caCert, "ca-cert", "", "")
+		f.StringVar(&m.caPath, "ca-path", "", "")
+		f.StringVar(&m.clientCert, "client-cert", "", "")
+		f.StringVar(&m.clientKey, "client-key", "", "")
+		f.BoolVar(&m.insecure, "tls-skip-verify", false, "")
 	}
 
 	// Create an io.Writer that writes to our UI properly for errors.
@@ -95,6 +112,34 @@ func (m *Meta) Client() (*api.Client, error) {
 	if m.region != "" {
 		config.Region = m.region
 	}
+	if v := os.Getenv(EnvNomadNamespace); v != "" {
+		config.Namespace = v
+	}
+	if m.namespace != "" {
+		config.Namespace = m.namespace
+	}
+
+	// If we need custom TLS configuration, then set it
+	if m.caCert != "" || m.caPath != "" || m.clientCert != "" || m.clientKey != "" || m.insecure {
+		if config.TLSConfig == nil {
+			config.TLSConfig = &api.TLSConfig{}
+		}
+		if m.caCert != "" {
+			config.TLSConfig.CACert = m.caCert
+		}
+		if m.caPath != "" {
+			config.TLSConfig.CAPath = m.caPath
+		}
+		if m.clientCert != "" {
+			config.TLSConfig.ClientCert = m.clientCert
+		}
+		if m.clientKey != "" {
+			config.TLSConfig.ClientKey = m.clientKey
+		}
+		if m.insecure {
+			config.TLSConfig.Insecure = m.insecure
+		}
+	}
 	return api.NewClient(config)
 }
 
@@ -111,16 +156,48 @@ func generalOptionsUsage() string {
 	helpText := `
   -address=<addr>
     The address of the Nomad server.
-    Overrides the NOMAD_ADDR environment variable if set.
+    Overrides the NOMAD_ADDR environment variable if set. A unix://
+    address connects to the unix domain socket of a local agent.
     Default = http://127.0.0.1:4646
 
   -region=<region>
     The region of the Nomad servers to forward commands to.
     Overrides the NOMAD_REGION environment variable if set.
     Defaults to the Agent's local region.
-  
+
+  -namespace=<namespace>
+    The namespace of the jobs, evaluations and allocations to operate on.
+    Overrides the NOMAD_NAMESPACE environment variable if set.
+    Defaults to the "default" namespace.
+
   -no-color
     Disables colored command output.
+
+  -ca-cert=<path>
+    Path to a PEM encoded CA cert file to use to verify the
+    Nomad server SSL certificate.  Overrides the NOMAD_CACERT
+    environment variable if set.
+
+  -ca-path=<path>
+    Path to a directory of PEM encoded CA cert files to verify
+    the Nomad server SSL certificate. If both -ca-cert and
+    -ca-path are specified, -ca-cert is used. Overrides the
+    NOMAD_CAPATH environment variable if set.
+
+  -client-cert=<path>
+    Path to a PEM encoded client certificate for TLS authentication
+    to the Nomad server. Must also specify -client-key. Overrides
+    the NOMAD_CLIENT_CERT environment variable if set.
+
+  -client-key=<path>
+    Path to an unencrypted PEM encoded private key matching the
+    client certificate from -client-cert. Overrides the
+    NOMAD_CLIENT_KEY environment variable if set.
+
+  -tls-skip-verify
+    Do not verify TLS certificate. This is highly not recommended.
+    Verification will be skipped if set. Overrides the
+    NOMAD_SKIP_VERIFY environment variable if set.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -18,7 +18,7 @@ func TestMeta_FlagSet(t *testing.T) {
 		},
 		{
 			FlagSetClient,
-			[]string{"address", "no-color", "region"},
+			[]string{"address", "ca-cert", "ca-path", "client-cert", "client-key", "namespace", "no-color", "region", "tls-skip-verify"},
 		},
 	}
 
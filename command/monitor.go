@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -275,14 +276,14 @@ func (m *monitor) monitor(evalID string, allowPrefix bool) int {
 					limit(eval.ID, m.length), eval.Status))
 
 				// Print the failures per task group
-				for tg, metrics := range eval.FailedTGAllocs {
+				for _, tg := range sortedTaskGroupFromMetrics(eval.FailedTGAllocs) {
+					metrics := eval.FailedTGAllocs[tg]
 					noun := "allocation"
 					if metrics.CoalescedFailures > 0 {
 						noun += "s"
 					}
 					m.ui.Output(fmt.Sprintf("Task Group %q (failed to place %d %s):", tg, metrics.CoalescedFailures+1, noun))
-					metrics := formatAllocMetrics(metrics, false, "  ")
-					for _, line := range strings.Split(metrics, "\n") {
+					for _, line := range strings.Split(formatAllocMetrics(metrics, false, "  "), "\n") {
 						m.ui.Output(line)
 					}
 				}
@@ -345,38 +346,54 @@ func formatAllocMetrics(metrics *api.AllocationMetric, scores bool, prefix strin
 
 	// Print a helpful message if the user has asked for a DC that has no
 	// available nodes.
-	for dc, available := range metrics.NodesAvailable {
-		if available == 0 {
+	for _, dc := range sortedMetricKeys(metrics.NodesAvailable) {
+		if metrics.NodesAvailable[dc] == 0 {
 			out += fmt.Sprintf("%s* No nodes are available in datacenter %q\n", prefix, dc)
 		}
 	}
 
 	// Print filter info
-	for class, num := range metrics.ClassFiltered {
-		out += fmt.Sprintf("%s* Class %q filtered %d nodes\n", prefix, class, num)
+	for _, class := range sortedMetricKeys(metrics.ClassFiltered) {
+		out += fmt.Sprintf("%s* Class %q filtered %d nodes\n", prefix, class, metrics.ClassFiltered[class])
 	}
-	for cs, num := range metrics.ConstraintFiltered {
-		out += fmt.Sprintf("%s* Constraint %q filtered %d nodes\n", prefix, cs, num)
+	for _, cs := range sortedMetricKeys(metrics.ConstraintFiltered) {
+		out += fmt.Sprintf("%s* Constraint %q filtered %d nodes\n", prefix, cs, metrics.ConstraintFiltered[cs])
 	}
 
 	// Print exhaustion info
 	if ne := metrics.NodesExhausted; ne > 0 {
 		out += fmt.Sprintf("%s* Resources exhausted on %d nodes\n", prefix, ne)
 	}
-	for class, num := range metrics.ClassExhausted {
-		out += fmt.Sprintf("%s* Class %q exhausted on %d nodes\n", prefix, class, num)
+	for _, class := range sortedMetricKeys(metrics.ClassExhausted) {
+		out += fmt.Sprintf("%s* Class %q exhausted on %d nodes\n", prefix, class, metrics.ClassExhausted[class])
 	}
-	for dim, num := range metrics.DimensionExhausted {
-		out += fmt.Sprintf("%s* Dimension %q exhausted on %d nodes\n", prefix, dim, num)
+	for _, dim := range sortedMetricKeys(metrics.DimensionExhausted) {
+		out += fmt.Sprintf("%s* Dimension %q exhausted on %d nodes\n", prefix, dim, metrics.DimensionExhausted[dim])
 	}
 
 	// Print scores
 	if scores {
-		for name, score := range metrics.Scores {
-			out += fmt.Sprintf("%s* Score %q = %f\n", prefix, name, score)
+		names := make([]string, 0, len(metrics.Scores))
+		for name := range metrics.Scores {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			out += fmt.Sprintf("%s* Score %q = %f\n", prefix, name, metrics.Scores[name])
 		}
 	}
 
 	out = strings.TrimSuffix(out, "\n")
 	return out
 }
+
+// sortedMetricKeys returns the keys of the given placement metric counts in
+// sorted order so the failure explanations are output consistently.
+func sortedMetricKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,159 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type MonitorEventsCommand struct {
+	Meta
+}
+
+func (c *MonitorEventsCommand) Help() string {
+	helpText := `
+Usage: nomad monitor-events [options]
+
+  Stream the events of the cluster: the registration of jobs, the updates of
+  allocations, the changes of the status of nodes and the updates of
+  evaluations. The events retained by the servers are output first, followed
+  by the new events until interrupted.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Monitor Events Options:
+
+  -topic <topics>
+    Comma separated list of the topics of the events to output. The topics are
+    "Job", "Allocation", "Node" and "Evaluation". Defaults to all the topics.
+
+  -index <index>
+    Only output the events following the given Raft index.
+
+  -json
+    Output each event in its JSON format.
+
+  -t
+    Format and display each event using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *MonitorEventsCommand) Synopsis() string {
+	return "Stream the events of the cluster"
+}
+
+func (c *MonitorEventsCommand) Run(args []string) int {
+	var json bool
+	var tmpl, topic string
+	var index uint64
+
+	flags := c.Meta.FlagSet("monitor-events", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&topic, "topic", "", "")
+	flags.Uint64Var(&index, "index", 0, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	}
+
+	var topics []string
+	if topic != "" {
+		topics = strings.Split(topic, ",")
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	cancel := make(chan struct{})
+	events, err := client.EventStream().Stream(topics, cancel, &api.QueryOptions{WaitIndex: index})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error streaming events: %s", err))
+		return 1
+	}
+
+	// End the streaming when interrupted or once returning
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+	interrupted := make(chan struct{})
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go func() {
+		select {
+		case <-signalCh:
+			close(interrupted)
+		case <-doneCh:
+		}
+		close(cancel)
+	}()
+
+	for event := range events {
+		out, formatted, err := formatOutput(json, tmpl, event)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		if !formatted {
+			out = formatEvent(event)
+		}
+		c.Ui.Output(out)
+	}
+
+	select {
+	case <-interrupted:
+		return 0
+	default:
+		c.Ui.Error("Event stream closed unexpectedly")
+		return 1
+	}
+}
+
+// formatEvent formats an event as a single line
+func formatEvent(event *api.Event) string {
+	key := event.Key
+	if event.Namespace != "" {
+		key = event.Namespace + "/" + key
+	}
+
+	var details string
+	switch {
+	case event.Job != nil:
+		details = fmt.Sprintf("Version %d, %s", event.Job.Version, event.Job.Status)
+	case event.Allocation != nil:
+		details = fmt.Sprintf("%s/%s", event.Allocation.DesiredStatus, event.Allocation.ClientStatus)
+	case event.Node != nil:
+		details = fmt.Sprintf("%s, drain %v", event.Node.Status, event.Node.Drain)
+	case event.Evaluation != nil:
+		details = fmt.Sprintf("%s (%s)", event.Evaluation.Status, event.Evaluation.TriggeredBy)
+	}
+
+	out := fmt.Sprintf("%d %s %s", event.Index, event.Type, key)
+	if details != "" {
+		out += ": " + details
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestMonitorEventsCommand_Implements(t *testing.T) {
+	var _ cli.Command = &MonitorEventsCommand{}
+}
+
+func TestMonitorEventsCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+
+	// Register a job before streaming the retained events
+	job := testJob("job1")
+	if _, _, err := client.Jobs().Register(job, nil); err != nil {
+		srv.Stop()
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &MonitorEventsCommand{Meta: Meta{Ui: ui}}
+
+	// The command runs until the stream ends with the agent
+	doneCh := make(chan int)
+	go func() {
+		doneCh <- cmd.Run([]string{"-address=" + url, "-topic=Job"})
+	}()
+	// Give the command time to output the retained events
+	time.Sleep(time.Second)
+	srv.Stop()
+
+	if code := <-doneCh; code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "JobRegistered default/job1") {
+		t.Fatalf("bad output: %s", out)
+	}
+	if strings.Contains(out, "Evaluation") {
+		t.Fatalf("unexpected evaluation events: %s", out)
+	}
+	if errOut := ui.ErrorWriter.String(); !strings.Contains(errOut, "closed unexpectedly") {
+		t.Fatalf("bad error output: %s", errOut)
+	}
+}
+
+func TestMonitorEventsCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &MonitorEventsCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error streaming events") {
+		t.Fatalf("expected streaming error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails with both -json and -t
+	if code := cmd.Run([]string{"-json", "-t", "{{.Key}}"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Both -json and -t are not allowed") {
+		t.Fatalf("expected flag error, got: %s", out)
+	}
+}
@@ -282,6 +282,37 @@ func TestMonitor_MonitorWithPrefix(t *testing.T) {
 
 }
 
+func TestMonitor_FormatAllocMetrics_Sorted(t *testing.T) {
+	metrics := &api.AllocationMetric{
+		NodesEvaluated: 3,
+		NodesFiltered:  2,
+		NodesAvailable: map[string]int{
+			"dc2": 0,
+			"dc1": 0,
+		},
+		ConstraintFiltered: map[string]int{
+			"${node.class} = web":         1,
+			"${attr.kernel.name} = linux": 1,
+		},
+		NodesExhausted: 1,
+		DimensionExhausted: map[string]int{
+			"memory exhausted": 1,
+			"cpu exhausted":    1,
+		},
+	}
+
+	expected := `* No nodes are available in datacenter "dc1"
+* No nodes are available in datacenter "dc2"
+* Constraint "${attr.kernel.name} = linux" filtered 1 nodes
+* Constraint "${node.class} = web" filtered 1 nodes
+* Resources exhausted on 1 nodes
+* Dimension "cpu exhausted" exhausted on 1 nodes
+* Dimension "memory exhausted" exhausted on 1 nodes`
+	if out := formatAllocMetrics(metrics, false, ""); out != expected {
+		t.Fatalf("bad:\n%s\n\nexpected:\n%s", out, expected)
+	}
+}
+
 func TestMonitor_DumpAllocStatus(t *testing.T) {
 	ui := new(cli.MockUi)
 
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+type NamespaceCommand struct {
+	Meta
+}
+
+func (c *NamespaceCommand) Help() string {
+	helpText := `
+Usage: nomad namespace <subcommand> [options]
+
+  This command groups subcommands for interacting with namespaces. Namespaces
+  isolate the jobs of teams sharing a cluster: the jobs, evaluations and
+  allocations of a namespace are only listed and read when the namespace is
+  selected with the -namespace flag or the NOMAD_NAMESPACE environment
+  variable. The "default" namespace is used when none is selected.
+
+  Create or update a namespace:
+
+      $ nomad namespace apply -description "Engineering team" <name>
+
+  List the namespaces:
+
+      $ nomad namespace list
+
+  Display a namespace:
+
+      $ nomad namespace status <name>
+
+  Delete a namespace no longer containing any job:
+
+      $ nomad namespace delete <name>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceCommand) Synopsis() string {
+	return "Interact with namespaces"
+}
+
+func (c *NamespaceCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// formatNamespaceList formats a list of namespaces
+func formatNamespaceList(namespaces []*api.Namespace) string {
+	out := make([]string, len(namespaces)+1)
+	out[0] = "Name|Description"
+	for i, ns := range namespaces {
+		out[i+1] = fmt.Sprintf("%s|%s", ns.Name, ns.Description)
+	}
+	return formatList(out)
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type NamespaceApplyCommand struct {
+	Meta
+}
+
+func (c *NamespaceApplyCommand) Help() string {
+	helpText := `
+Usage: nomad namespace apply [options] <name>
+
+  Apply is used to create or update a namespace. Jobs can be registered in a
+  namespace once it exists.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Apply Options:
+
+  -description
+    An optional human readable description of the namespace.
+
+  -quota
+    The name of an optional quota specification limiting the resources used
+    by the jobs of the namespace. The quota must exist.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceApplyCommand) Synopsis() string {
+	return "Create or update a namespace"
+}
+
+func (c *NamespaceApplyCommand) Run(args []string) int {
+	var description, quota string
+
+	flags := c.Meta.FlagSet("namespace apply", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&description, "description", "", "")
+	flags.StringVar(&quota, "quota", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	namespace := &api.Namespace{
+		Name:        name,
+		Description: description,
+		Quota:       quota,
+	}
+	if _, err := client.Namespaces().Register(namespace, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error applying namespace: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Successfully applied namespace %q", name))
+	return 0
+}
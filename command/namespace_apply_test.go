@@ -0,0 +1,78 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestNamespaceApplyCommand_Implements(t *testing.T) {
+	var _ cli.Command = &NamespaceApplyCommand{}
+}
+
+func TestNamespaceApplyCommand_Good(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &NamespaceApplyCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, "-description=Engineering team", "engineering"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, `Successfully applied namespace "engineering"`) {
+		t.Fatalf("expected success output, got: %s", out)
+	}
+
+	// The namespace is listed
+	ui = new(cli.MockUi)
+	list := &NamespaceListCommand{Meta: Meta{Ui: ui}}
+	if code := list.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "engineering") {
+		t.Fatalf("expected namespace listed, got: %s", out)
+	}
+
+	// The status displays its description
+	ui = new(cli.MockUi)
+	status := &NamespaceStatusCommand{Meta: Meta{Ui: ui}}
+	if code := status.Run([]string{"-address=" + url, "engineering"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Engineering team") {
+		t.Fatalf("expected namespace status, got: %s", out)
+	}
+
+	// The namespace is deleted
+	ui = new(cli.MockUi)
+	del := &NamespaceDeleteCommand{Meta: Meta{Ui: ui}}
+	if code := del.Run([]string{"-address=" + url, "engineering"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, `Successfully deleted namespace "engineering"`) {
+		t.Fatalf("expected success output, got: %s", out)
+	}
+}
+
+func TestNamespaceApplyCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &NamespaceApplyCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "engineering"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error applying namespace") {
+		t.Fatalf("expected failed apply error, got: %s", out)
+	}
+}
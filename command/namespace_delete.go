@@ -0,0 +1,59 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type NamespaceDeleteCommand struct {
+	Meta
+}
+
+func (c *NamespaceDeleteCommand) Help() string {
+	helpText := `
+Usage: nomad namespace delete [options] <name>
+
+  Delete is used to delete a namespace. A namespace can only be deleted once
+  it no longer contains any job. The default namespace can not be deleted.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceDeleteCommand) Synopsis() string {
+	return "Delete a namespace"
+}
+
+func (c *NamespaceDeleteCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("namespace delete", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if _, err := client.Namespaces().Delete(name, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error deleting namespace: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Successfully deleted namespace %q", name))
+	return 0
+}
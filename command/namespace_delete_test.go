@@ -0,0 +1,34 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestNamespaceDeleteCommand_Implements(t *testing.T) {
+	var _ cli.Command = &NamespaceDeleteCommand{}
+}
+
+func TestNamespaceDeleteCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &NamespaceDeleteCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "engineering"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error deleting namespace") {
+		t.Fatalf("expected failed delete error, got: %s", out)
+	}
+}
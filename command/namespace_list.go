@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type NamespaceListCommand struct {
+	Meta
+}
+
+func (c *NamespaceListCommand) Help() string {
+	helpText := `
+Usage: nomad namespace list [options]
+
+  List is used to list the namespaces.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceListCommand) Synopsis() string {
+	return "List namespaces"
+}
+
+func (c *NamespaceListCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("namespace list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if l := len(args); l != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	namespaces, _, err := client.Namespaces().List(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying namespaces: %s", err))
+		return 1
+	}
+	if len(namespaces) == 0 {
+		c.Ui.Output("No namespaces found")
+		return 0
+	}
+
+	c.Ui.Output(formatNamespaceList(namespaces))
+	return 0
+}
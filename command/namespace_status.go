@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type NamespaceStatusCommand struct {
+	Meta
+}
+
+func (c *NamespaceStatusCommand) Help() string {
+	helpText := `
+Usage: nomad namespace status [options] <name>
+
+  Status is used to display the details of a namespace.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceStatusCommand) Synopsis() string {
+	return "Display a namespace"
+}
+
+func (c *NamespaceStatusCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("namespace status", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	namespace, _, err := client.Namespaces().Info(name, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying namespace: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("Name|%s", namespace.Name),
+		fmt.Sprintf("Description|%s", namespace.Description),
+		fmt.Sprintf("Quota|%s", namespace.Quota),
+	}
+	c.Ui.Output(formatKV(basic))
+	return 0
+}
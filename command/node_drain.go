@@ -3,6 +3,9 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
 )
 
 type NodeDrainCommand struct {
@@ -17,18 +20,34 @@ Usage: nomad node-drain [options] <node>
   that either -enable or -disable is specified, but not both.
   The -self flag is useful to drain the local node.
 
+  The allocations of a draining node are migrated as allowed by the
+  migrate strategy of their task group. Once the deadline is reached,
+  the remaining allocations are migrated at once.
+
 General Options:
 
   ` + generalOptionsUsage() + `
 
 Node Drain Options:
 
+  -deadline <duration>
+    Set the deadline after which all the allocations of the node are
+    migrated regardless of their migrate strategy. By default there is
+    no deadline.
+
   -disable
     Disable draining for the specified node.
 
   -enable
     Enable draining for the specified node.
 
+  -force
+    Migrate all the allocations of the node immediately. This is
+    equivalent to a deadline that is already reached.
+
+  -ignore-system
+    Leave the allocations of system jobs running on the node.
+
   -self
     Query the status of the local node.
 
@@ -43,12 +62,16 @@ func (c *NodeDrainCommand) Synopsis() string {
 }
 
 func (c *NodeDrainCommand) Run(args []string) int {
-	var enable, disable, self, autoYes bool
+	var enable, disable, force, ignoreSystem, self, autoYes bool
+	var deadline time.Duration
 
 	flags := c.Meta.FlagSet("node-drain", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&enable, "enable", false, "Enable drain mode")
 	flags.BoolVar(&disable, "disable", false, "Disable drain mode")
+	flags.DurationVar(&deadline, "deadline", 0, "")
+	flags.BoolVar(&force, "force", false, "")
+	flags.BoolVar(&ignoreSystem, "ignore-system", false, "")
 	flags.BoolVar(&self, "self", false, "")
 	flags.BoolVar(&autoYes, "yes", false, "Automatic yes to prompts.")
 
@@ -62,6 +85,16 @@ func (c *NodeDrainCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The drain strategy only applies when enabling drain mode
+	if disable && (deadline != 0 || force || ignoreSystem) {
+		c.Ui.Error("The -deadline, -force and -ignore-system flags require -enable")
+		return 1
+	}
+	if deadline < 0 || (force && deadline != 0) {
+		c.Ui.Error("The -deadline must be positive and can't be combined with -force")
+		return 1
+	}
+
 	// Check that we got a node ID
 	args = flags.Args()
 	if l := len(args); self && l != 0 || !self && l != 1 {
@@ -163,7 +196,17 @@ func (c *NodeDrainCommand) Run(args []string) int {
 	}
 
 	// Toggle node draining
-	if _, err := client.Nodes().ToggleDrain(node.ID, enable, nil); err != nil {
+	var strategy *api.DrainStrategy
+	if enable {
+		strategy = &api.DrainStrategy{
+			Deadline:         deadline,
+			IgnoreSystemJobs: ignoreSystem,
+		}
+		if force {
+			strategy.Deadline = -1
+		}
+	}
+	if _, err := client.Nodes().UpdateDrain(node.ID, enable, strategy, nil); err != nil {
 		c.Ui.Error(fmt.Sprintf("Error toggling drain mode: %s", err))
 		return 1
 	}
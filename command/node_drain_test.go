@@ -27,6 +27,15 @@ func TestNodeDrainCommand_Fails(t *testing.T) {
 	}
 	ui.ErrorWriter.Reset()
 
+	// Fails on a drain strategy without -enable
+	if code := cmd.Run([]string{"-address=" + url, "-disable", "-deadline=1h", "12345678-abcd-efab-cdef-123456789abc"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "require -enable") {
+		t.Fatalf("expected drain strategy error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
 	// Fails on connection failure
 	if code := cmd.Run([]string{"-address=nope", "-enable", "12345678-abcd-efab-cdef-123456789abc"}); code != 1 {
 		t.Fatalf("expected exit code 1, got: %d", code)
@@ -32,6 +32,7 @@ type NodeStatusCommand struct {
 	stats       bool
 	json        bool
 	tmpl        string
+	columns     string
 }
 
 func (c *NodeStatusCommand) Help() string {
@@ -74,6 +75,10 @@ Node Status Options:
 
   -t
     Format and display node using a Go template.
+
+  -columns <fields>
+    Comma separated list of the fields to display in the nodes list. For
+    example: "ID,Name,Status".
 `
 	return strings.TrimSpace(helpText)
 }
@@ -93,6 +98,7 @@ func (c *NodeStatusCommand) Run(args []string) int {
 	flags.BoolVar(&c.stats, "stats", false, "")
 	flags.BoolVar(&c.json, "json", false, "")
 	flags.StringVar(&c.tmpl, "t", "", "")
+	flags.StringVar(&c.columns, "columns", "", "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -159,6 +165,17 @@ func (c *NodeStatusCommand) Run(args []string) int {
 			return 0
 		}
 
+		// Format the selected columns of the nodes list
+		if columns := parseColumns(c.columns); len(columns) > 0 {
+			out, err := formatColumns(nodes, columns, []string{"ID"}, c.length)
+			if err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
+			c.Ui.Output(out)
+			return 0
+		}
+
 		// Format the nodes list
 		out := make([]string, len(nodes)+1)
 		if c.list_allocs {
@@ -283,6 +300,29 @@ func (c *NodeStatusCommand) Run(args []string) int {
 	return c.formatNode(client, node)
 }
 
+// formatDrain formats the drain mode of the node along with its deadline and
+// whether system jobs are drained.
+func formatDrain(node *api.Node) string {
+	strategy := node.DrainStrategy
+	if !node.Drain || strategy == nil {
+		return fmt.Sprintf("%v", node.Drain)
+	}
+
+	var details []string
+	switch {
+	case strategy.Deadline < 0:
+		details = append(details, "forced")
+	case strategy.Deadline > 0:
+		details = append(details, fmt.Sprintf("deadline %s", formatTime(strategy.ForceDeadline)))
+	default:
+		details = append(details, "no deadline")
+	}
+	if strategy.IgnoreSystemJobs {
+		details = append(details, "ignoring system jobs")
+	}
+	return fmt.Sprintf("true (%s)", strings.Join(details, ", "))
+}
+
 func (c *NodeStatusCommand) formatNode(client *api.Client, node *api.Node) int {
 	// Format the header output
 	basic := []string{
@@ -290,7 +330,7 @@ func (c *NodeStatusCommand) formatNode(client *api.Client, node *api.Node) int {
 		fmt.Sprintf("Name|%s", node.Name),
 		fmt.Sprintf("Class|%s", node.NodeClass),
 		fmt.Sprintf("DC|%s", node.Datacenter),
-		fmt.Sprintf("Drain|%v", node.Drain),
+		fmt.Sprintf("Drain|%s", formatDrain(node)),
 		fmt.Sprintf("Status|%s", node.Status),
 	}
 
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/cli"
 )
@@ -210,3 +211,21 @@ func TestNodeStatusCommand_Fails(t *testing.T) {
 		t.Fatalf("expected getting formatter error, got: %s", out)
 	}
 }
+
+func TestNodeStatusCommand_FormatDrain(t *testing.T) {
+	cases := []struct {
+		node     *api.Node
+		expected string
+	}{
+		{&api.Node{}, "false"},
+		{&api.Node{Drain: true}, "true"},
+		{&api.Node{Drain: true, DrainStrategy: &api.DrainStrategy{}}, "true (no deadline)"},
+		{&api.Node{Drain: true, DrainStrategy: &api.DrainStrategy{Deadline: -1, IgnoreSystemJobs: true}},
+			"true (forced, ignoring system jobs)"},
+	}
+	for _, c := range cases {
+		if out := formatDrain(c.node); out != c.expected {
+			t.Fatalf("expected %q, got %q", c.expected, out)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorCommand struct {
+	Meta
+}
+
+func (c *OperatorCommand) Help() string {
+	helpText := `
+Usage: nomad operator <subcommand> [options]
+
+  This command groups subcommands for operators to perform cluster-wide
+  operations on the Nomad servers.
+
+  Rotate the gossip encryption key of the servers:
+
+      $ nomad operator keyring -install <new key>
+      $ nomad operator keyring -use <new key>
+      $ nomad operator keyring -remove <old key>
+
+  List the peers of the Raft quorum:
+
+      $ nomad operator raft list-peers
+
+  Capture an archive of debugging data from the agents:
+
+      $ nomad operator debug -duration 5m
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorCommand) Synopsis() string {
+	return "Provides cluster-level tools for Nomad operators"
+}
+
+func (c *OperatorCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,426 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type OperatorDebugCommand struct {
+	Meta
+}
+
+func (c *OperatorDebugCommand) Help() string {
+	helpText := `
+Usage: nomad operator debug [options]
+
+  Captures the state of the cluster and of the selected agents over a time
+  window into a compressed archive, to be attached to support escalations.
+
+  For each agent, the archive holds a CPU profile spanning the window, the
+  goroutine dumps and the metrics captured at each interval, a heap profile,
+  the most recent logs and the agent configuration. The jobs, nodes and
+  allocations of the cluster are captured at the end of the window. Profiling
+  requires the agents to set enable_debug; the other data is captured
+  regardless.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Debug Options:
+
+  -duration <duration>
+    The time window over which the agents are captured. Defaults to 2m.
+
+  -interval <duration>
+    The interval between the captures of the goroutines and the metrics of
+    the agents. Defaults to 30s.
+
+  -server-address <addresses>
+    Comma separated list of the HTTP addresses of additional servers to
+    capture. The agent given by -address is always captured.
+
+  -node-id <ids>
+    Comma separated list of the IDs of the client nodes to capture, or "all"
+    to capture every node of the cluster.
+
+  -output <path>
+    The directory in which the archive is written. Defaults to the current
+    directory.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorDebugCommand) Synopsis() string {
+	return "Build an archive of debugging data from the agents"
+}
+
+// debugTarget is an agent captured by the debug command
+type debugTarget struct {
+	// name is the directory of the agent in the archive
+	name   string
+	client *api.Client
+
+	// failed tracks the captures already reported as failed, to only warn
+	// once per capture
+	failed map[string]bool
+}
+
+// debugProfile is the result of the CPU profile of an agent
+type debugProfile struct {
+	target *debugTarget
+	out    []byte
+	err    error
+}
+
+func (c *OperatorDebugCommand) Run(args []string) int {
+	var duration, interval time.Duration
+	var servers, nodes, output string
+
+	flags := c.Meta.FlagSet("operator debug", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.DurationVar(&duration, "duration", 2*time.Minute, "")
+	flags.DurationVar(&interval, "interval", 30*time.Second, "")
+	flags.StringVar(&servers, "server-address", "", "")
+	flags.StringVar(&nodes, "node-id", "", "")
+	flags.StringVar(&output, "output", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	if duration < time.Second {
+		c.Ui.Error("The duration must be at least one second")
+		return 1
+	}
+	if interval <= 0 || interval > duration {
+		c.Ui.Error("The interval must be positive and not exceed the duration")
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+	if _, err := client.Agent().Self(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying agent: %s", err))
+		return 1
+	}
+
+	targets, err := c.targets(client, servers, nodes)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// Collect the captures in a temporary directory
+	name := "nomad-debug-" + time.Now().UTC().Format("2006-01-02-150405Z")
+	tmp, err := ioutil.TempDir("", "nomad-debug")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error creating temporary directory: %s", err))
+		return 1
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, name)
+
+	c.Ui.Output(fmt.Sprintf("Capturing %d agent(s) for %s...", len(targets), duration))
+
+	// Start the CPU profiles spanning the whole window
+	profiles := make(chan *debugProfile, len(targets))
+	for _, t := range targets {
+		go func(t *debugTarget) {
+			out, err := t.client.Agent().Profile("cpu", int(duration.Seconds()), 0)
+			profiles <- &debugProfile{target: t, out: out, err: err}
+		}(t)
+	}
+
+	// Stop capturing early when interrupted
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	interrupted := false
+	deadline := time.After(duration)
+CAPTURE:
+	for i := 0; ; i++ {
+		for _, t := range targets {
+			c.captureInterval(dir, t, i)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-deadline:
+			break CAPTURE
+		case <-signalCh:
+			interrupted = true
+			break CAPTURE
+		}
+	}
+
+	if interrupted {
+		c.Ui.Warn("Interrupted, the CPU profiles are not captured")
+	} else {
+		for range targets {
+			p := <-profiles
+			if p.err != nil {
+				c.warn(p.target, "CPU profile", p.err)
+				continue
+			}
+			c.writeFile(filepath.Join(dir, p.target.name, "profile.prof"), p.out)
+		}
+	}
+
+	for _, t := range targets {
+		c.captureAgent(dir, t)
+	}
+	c.captureCluster(dir, client)
+
+	// Archive the captures
+	if output == "" {
+		output = "."
+	}
+	archive := filepath.Join(output, name+".tar.gz")
+	if err := writeDebugArchive(archive, tmp, name); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error writing archive: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Created debug archive: %s", archive))
+	return 0
+}
+
+// targets returns the agents to capture: the agent the command is addressing,
+// the additional servers and the selected client nodes.
+func (c *OperatorDebugCommand) targets(client *api.Client, servers, nodes string) ([]*debugTarget, error) {
+	targets := []*debugTarget{newDebugTarget("agent", client)}
+
+	for _, addr := range splitDebugList(servers) {
+		c.flagAddress = addr
+		serverClient, err := c.Meta.Client()
+		if err != nil {
+			return nil, fmt.Errorf("Error initializing client for server %q: %s", addr, err)
+		}
+		name := strings.NewReplacer(":", "_", "/", "_").Replace(stripScheme(addr))
+		targets = append(targets, newDebugTarget(filepath.Join("servers", name), serverClient))
+	}
+
+	nodeIDs := splitDebugList(nodes)
+	if len(nodeIDs) == 1 && nodeIDs[0] == "all" {
+		stubs, _, err := client.Nodes().List(nil)
+		if err != nil {
+			return nil, fmt.Errorf("Error querying nodes: %s", err)
+		}
+		nodeIDs = nodeIDs[:0]
+		for _, stub := range stubs {
+			nodeIDs = append(nodeIDs, stub.ID)
+		}
+	}
+	for _, nodeID := range nodeIDs {
+		nodeClient, err := client.GetNodeClient(nodeID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Error initializing client for node %q: %s", nodeID, err)
+		}
+		targets = append(targets, newDebugTarget(filepath.Join("nodes", nodeID), nodeClient))
+	}
+	return targets, nil
+}
+
+// captureInterval captures the goroutines and the metrics of an agent at the
+// given interval
+func (c *OperatorDebugCommand) captureInterval(dir string, t *debugTarget, i int) {
+	agent := t.client.Agent()
+	if out, err := agent.Profile("goroutine", 0, 2); err != nil {
+		c.warn(t, "goroutines", err)
+	} else {
+		c.writeFile(filepath.Join(dir, t.name, fmt.Sprintf("goroutine-%03d.txt", i)), out)
+	}
+
+	if metrics, err := agent.Metrics(); err != nil {
+		c.warn(t, "metrics", err)
+	} else {
+		c.writeJSON(filepath.Join(dir, t.name, fmt.Sprintf("metrics-%03d.json", i)), metrics)
+	}
+}
+
+// captureAgent captures the heap profile, the recent logs and the
+// configuration of an agent at the end of the window
+func (c *OperatorDebugCommand) captureAgent(dir string, t *debugTarget) {
+	agent := t.client.Agent()
+	if out, err := agent.Profile("heap", 0, 0); err != nil {
+		c.warn(t, "heap profile", err)
+	} else {
+		c.writeFile(filepath.Join(dir, t.name, "heap.prof"), out)
+	}
+
+	if logs, err := agent.Logs(); err != nil {
+		c.warn(t, "logs", err)
+	} else {
+		c.writeFile(filepath.Join(dir, t.name, "logs.txt"), []byte(strings.Join(logs, "\n")+"\n"))
+	}
+
+	if self, err := agent.Self(); err != nil {
+		c.warn(t, "configuration", err)
+	} else {
+		c.writeJSON(filepath.Join(dir, t.name, "self.json"), self)
+	}
+}
+
+// captureCluster captures the state of the cluster
+func (c *OperatorDebugCommand) captureCluster(dir string, client *api.Client) {
+	dir = filepath.Join(dir, "cluster")
+
+	if jobs, _, err := client.Jobs().List(nil); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to capture the jobs: %s", err))
+	} else {
+		c.writeJSON(filepath.Join(dir, "jobs.json"), jobs)
+	}
+
+	if nodes, _, err := client.Nodes().List(nil); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to capture the nodes: %s", err))
+	} else {
+		c.writeJSON(filepath.Join(dir, "nodes.json"), nodes)
+	}
+
+	if allocs, _, err := client.Allocations().List(nil); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to capture the allocations: %s", err))
+	} else {
+		c.writeJSON(filepath.Join(dir, "allocations.json"), allocs)
+	}
+
+	if members, err := client.Agent().Members(); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to capture the members: %s", err))
+	} else {
+		c.writeJSON(filepath.Join(dir, "members.json"), members)
+	}
+}
+
+// warn reports a failed capture of an agent, once per capture
+func (c *OperatorDebugCommand) warn(t *debugTarget, capture string, err error) {
+	if t.failed[capture] {
+		return
+	}
+	t.failed[capture] = true
+	c.Ui.Warn(fmt.Sprintf("Failed to capture the %s of %s: %s", capture, t.name, err))
+}
+
+func (c *OperatorDebugCommand) writeJSON(path string, obj interface{}) {
+	out, err := json.MarshalIndent(obj, "", "    ")
+	if err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to encode %s: %s", path, err))
+		return
+	}
+	c.writeFile(path, out)
+}
+
+func (c *OperatorDebugCommand) writeFile(path string, out []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to create %s: %s", filepath.Dir(path), err))
+		return
+	}
+	if err := ioutil.WriteFile(path, out, 0644); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to write %s: %s", path, err))
+	}
+}
+
+func newDebugTarget(name string, client *api.Client) *debugTarget {
+	return &debugTarget{
+		name:   name,
+		client: client,
+		failed: make(map[string]bool),
+	}
+}
+
+// splitDebugList splits a comma separated list, ignoring the empty entries
+func splitDebugList(list string) []string {
+	var out []string
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// stripScheme removes the scheme of an address
+func stripScheme(addr string) string {
+	if i := strings.Index(addr, "://"); i != -1 {
+		return addr[i+3:]
+	}
+	return addr
+}
+
+// writeDebugArchive writes the name directory of root as a gzipped tarball at
+// the given path
+func writeDebugArchive(path, root, name string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.Walk(filepath.Join(root, name), func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		src, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorDebugCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorDebugCommand{}
+}
+
+func TestOperatorDebugCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, func(c *testutil.TestServerConfig) {
+		c.EnableDebug = true
+	})
+	defer srv.Stop()
+
+	dir, err := ioutil.TempDir("", "nomad-debug-test")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ui := new(cli.MockUi)
+	cmd := &OperatorDebugCommand{Meta: Meta{Ui: ui}}
+
+	args := []string{"-address=" + url, "-duration=1s", "-interval=1s", "-output=" + dir}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit code 0, got: %d %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.ErrorWriter.String(); out != "" {
+		t.Fatalf("unexpected warnings: %s", out)
+	}
+
+	archives, err := filepath.Glob(filepath.Join(dir, "nomad-debug-*.tar.gz"))
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("bad archives: %v %v", archives, err)
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, archives[0]) {
+		t.Fatalf("bad output: %s", out)
+	}
+
+	// Collect the files of the archive
+	f, err := os.Open(archives[0])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	tr := tar.NewReader(gz)
+	files := make(map[string]bool)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		parts := strings.SplitN(header.Name, "/", 2)
+		if len(parts) == 2 && parts[1] != "" {
+			files[parts[1]] = true
+		}
+	}
+
+	for _, file := range []string{
+		"agent/profile.prof",
+		"agent/goroutine-000.txt",
+		"agent/metrics-000.json",
+		"agent/heap.prof",
+		"agent/logs.txt",
+		"agent/self.json",
+		"cluster/jobs.json",
+		"cluster/nodes.json",
+		"cluster/allocations.json",
+		"cluster/members.json",
+	} {
+		if !files[file] {
+			t.Fatalf("missing %s in %v", file, files)
+		}
+	}
+}
+
+func TestOperatorDebugCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorDebugCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an interval exceeding the duration
+	if code := cmd.Run([]string{"-duration=1s", "-interval=2s"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "interval") {
+		t.Fatalf("expected interval error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying agent") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
@@ -0,0 +1,146 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type OperatorKeyringCommand struct {
+	Meta
+}
+
+func (c *OperatorKeyringCommand) Help() string {
+	helpText := `
+Usage: nomad operator keyring [options]
+
+  Manages the gossip encryption keys of the Nomad servers. The keys are
+  installed, used and removed on all the servers of the gossip pool, which
+  allows rotating the key without restarting the servers:
+
+    1. Install the new key with -install.
+    2. Make it the primary key used to encrypt the gossip with -use.
+    3. Remove the old key with -remove.
+
+  A key must be 16, 24 or 32 bytes and base64 encoded, for example the output
+  of "openssl rand -base64 16". The keyring of the servers is persisted in
+  their data dir. Exactly one of the keyring options must be provided.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Keyring Options:
+
+  -list
+    List the keys installed on the servers and the number of servers
+    having each key.
+
+  -install=<key>
+    Install a new key on all the servers. The key is accepted to decrypt the
+    gossip but not used to encrypt it until it is made the primary key.
+
+  -use=<key>
+    Make an installed key the primary key used to encrypt the gossip.
+
+  -remove=<key>
+    Remove a key from all the servers. The primary key can not be removed.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorKeyringCommand) Synopsis() string {
+	return "Manages the gossip encryption keys of the servers"
+}
+
+func (c *OperatorKeyringCommand) Run(args []string) int {
+	var list bool
+	var installKey, useKey, removeKey string
+
+	flags := c.Meta.FlagSet("operator keyring", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&list, "list", false, "")
+	flags.StringVar(&installKey, "install", "", "")
+	flags.StringVar(&useKey, "use", "", "")
+	flags.StringVar(&removeKey, "remove", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Check that exactly one operation was requested
+	ops := 0
+	for _, set := range []bool{list, installKey != "", useKey != "", removeKey != ""} {
+		if set {
+			ops++
+		}
+	}
+	if ops != 1 {
+		c.Ui.Error("Exactly one of -list, -install, -use or -remove must be provided")
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+	operator := client.Operator()
+
+	switch {
+	case list:
+		resp, err := operator.KeyringList(nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error listing keys: %s", err))
+			return 1
+		}
+		c.Ui.Output(formatKeyringList(resp))
+	case installKey != "":
+		_, err = operator.KeyringInstall(installKey, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error installing key: %s", err))
+			return 1
+		}
+		c.Ui.Output("Successfully installed the key")
+	case useKey != "":
+		_, err = operator.KeyringUse(useKey, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error changing the primary key: %s", err))
+			return 1
+		}
+		c.Ui.Output("Successfully changed the primary key")
+	case removeKey != "":
+		_, err = operator.KeyringRemove(removeKey, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error removing key: %s", err))
+			return 1
+		}
+		c.Ui.Output("Successfully removed the key")
+	}
+	return 0
+}
+
+// formatKeyringList formats the keys installed on the servers
+func formatKeyringList(resp *api.KeyringResponse) string {
+	keys := make([]string, 0, len(resp.Keys))
+	for key := range resp.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]string, len(keys)+1)
+	out[0] = "Key|Servers"
+	for i, key := range keys {
+		out[i+1] = fmt.Sprintf("%s|%d/%d", key, resp.Keys[key], resp.NumNodes)
+	}
+	return formatList(out)
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorKeyringCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorKeyringCommand{}
+}
+
+func TestOperatorKeyringCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorKeyringCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails without exactly one operation
+	if code := cmd.Run([]string{"-list", "-install=foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Exactly one of") {
+		t.Fatalf("expected operation error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-list"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error listing keys") {
+		t.Fatalf("expected failed list error, got: %s", out)
+	}
+}
+
+func TestOperatorKeyringCommand_Format(t *testing.T) {
+	resp := &api.KeyringResponse{
+		Keys:     map[string]int{"b": 3, "a": 1},
+		NumNodes: 3,
+	}
+	out := formatKeyringList(resp)
+	if !strings.Contains(out, "a    1/3") || strings.Index(out, "a ") > strings.Index(out, "b ") {
+		t.Fatalf("bad output: %s", out)
+	}
+}
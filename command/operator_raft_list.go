@@ -0,0 +1,93 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type OperatorRaftListCommand struct {
+	Meta
+}
+
+func (c *OperatorRaftListCommand) Help() string {
+	helpText := `
+Usage: nomad operator raft list-peers [options]
+
+  Displays the current Raft peer configuration.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Peers Options:
+
+  -stale
+    The -stale argument defaults to "false" which means the leader provides
+    the result. If the cluster is in an outage state without a leader, you
+    may need to set -stale to "true" to get the configuration from a
+    non-leader server.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorRaftListCommand) Synopsis() string {
+	return "Display the current Raft peer configuration"
+}
+
+func (c *OperatorRaftListCommand) Run(args []string) int {
+	var stale bool
+
+	flags := c.Meta.FlagSet("operator raft list-peers", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&stale, "stale", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current configuration
+	q := &api.QueryOptions{
+		AllowStale: stale,
+	}
+	reply, err := client.Operator().RaftGetConfiguration(q)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error getting peers: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(formatRaftPeers(reply.Servers))
+	return 0
+}
+
+// formatRaftPeers formats the servers of the Raft configuration
+func formatRaftPeers(servers []*api.RaftServer) string {
+	out := make([]string, len(servers)+1)
+	out[0] = "Node|Address|State|Voter"
+	for i, s := range servers {
+		state := "follower"
+		if s.Leader {
+			state = "leader"
+		}
+		node := s.Node
+		if node == "" {
+			node = "(unknown)"
+		}
+		out[i+1] = fmt.Sprintf("%s|%s|%s|%v", node, s.Address, state, s.Voter)
+	}
+	return formatList(out)
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorRaftListCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorRaftListCommand{}
+}
+
+func TestOperatorRaftListCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &OperatorRaftListCommand{Meta: Meta{Ui: ui}}
+
+	// Lists the single server as the leader
+	if code := cmd.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit code 0, got: %d %s", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "leader") || !strings.Contains(out, "true") {
+		t.Fatalf("bad output: %s", out)
+	}
+}
+
+func TestOperatorRaftListCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorRaftListCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error getting peers") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
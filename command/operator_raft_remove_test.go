@@ -0,0 +1,43 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorRaftRemoveCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorRaftRemoveCommand{}
+}
+
+func TestOperatorRaftRemoveCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorRaftRemoveCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails without an address
+	if code := cmd.Run(nil); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Missing peer address") {
+		t.Fatalf("expected missing address error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-peer-address=127.0.0.1:4647"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error removing peer") {
+		t.Fatalf("expected failed remove error, got: %s", out)
+	}
+}
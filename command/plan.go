@@ -70,6 +70,8 @@ Plan Options:
 
   -verbose
     Increase diff verbosity.
+
+  ` + jobVarsUsage() + `
 `
 	return strings.TrimSpace(helpText)
 }
@@ -85,6 +87,7 @@ func (c *PlanCommand) Run(args []string) int {
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&diff, "diff", true, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
+	c.JobGetter.VarFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return 255
@@ -167,6 +170,11 @@ func getExitCode(resp *api.JobPlanResponse) int {
 		}
 	}
 
+	// Preempted allocations are destroyed
+	if len(resp.Annotations.PreemptedAllocs) != 0 {
+		return 1
+	}
+
 	return 0
 }
 
@@ -217,6 +225,15 @@ func formatDryRun(resp *api.JobPlanResponse, job *structs.Job) string {
 		out += fmt.Sprintf("[green]- Rolling update, next evaluation will be in %s.\n", rolling.Wait)
 	}
 
+	if resp.Annotations != nil && len(resp.Annotations.PreemptedAllocs) != 0 {
+		out += "[yellow]- Allocations of lower priority jobs would be preempted:\n"
+		for _, alloc := range resp.Annotations.PreemptedAllocs {
+			out += fmt.Sprintf("%sAlloc %q of job %q on node %q\n",
+				strings.Repeat(" ", 2), limit(alloc.ID, shortId), alloc.JobID, limit(alloc.NodeID, shortId))
+		}
+		out += "[reset]"
+	}
+
 	if next := resp.NextPeriodicLaunch; !next.IsZero() {
 		out += fmt.Sprintf("[green]- If submitted now, next periodic launch would be at %s (%s from now).\n",
 			formatTime(next), formatTimeDifference(time.Now().UTC(), next, time.Second))
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+type QuotaCommand struct {
+	Meta
+}
+
+func (c *QuotaCommand) Help() string {
+	helpText := `
+Usage: nomad quota <subcommand> [options]
+
+  This command groups subcommands for interacting with resource quotas. A
+  quota caps the CPU, memory and disk used by the allocations of the jobs of
+  the namespaces that reference it with the namespace's quota. Placements
+  exceeding the quota fail until resources are freed or the quota is raised.
+
+  Create or update a quota from a specification file:
+
+      $ nomad quota apply <path>
+
+  List the quotas:
+
+      $ nomad quota list
+
+  Display the limit and usage of a quota:
+
+      $ nomad quota status <name>
+
+  Delete a quota no longer used by any namespace:
+
+      $ nomad quota delete <name>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaCommand) Synopsis() string {
+	return "Interact with resource quotas"
+}
+
+func (c *QuotaCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// formatQuotaLimit formats a limit of a quota, with a zero limit displayed as
+// unlimited.
+func formatQuotaLimit(limit int) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d", limit)
+}
+
+// formatQuotaList formats a list of quota specifications
+func formatQuotaList(quotas []*api.QuotaSpec) string {
+	out := make([]string, len(quotas)+1)
+	out[0] = "Name|Description|CPU|Memory MB|Disk MB"
+	for i, q := range quotas {
+		limit := q.Limit
+		if limit == nil {
+			limit = &api.QuotaLimit{}
+		}
+		out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s",
+			q.Name,
+			q.Description,
+			formatQuotaLimit(limit.CPU),
+			formatQuotaLimit(limit.MemoryMB),
+			formatQuotaLimit(limit.DiskMB))
+	}
+	return formatList(out)
+}
@@ -0,0 +1,102 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/jobspec"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+type QuotaApplyCommand struct {
+	Meta
+}
+
+func (c *QuotaApplyCommand) Help() string {
+	helpText := `
+Usage: nomad quota apply [options] <path>
+
+  Apply is used to create or update a quota from the specification in the
+  given file. If the supplied path is "-", the specification is read from
+  stdin. The file sets the name and description of the quota and the
+  resources it limits, in HCL or JSON:
+
+      name        = "engineering"
+      description = "Engineering team"
+
+      limit {
+        cpu    = 4000
+        memory = 8192
+        disk   = 10000
+      }
+
+  A resource missing from the limit is unlimited.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaApplyCommand) Synopsis() string {
+	return "Create or update a quota"
+}
+
+func (c *QuotaApplyCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("quota apply", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Parse the quota specification
+	var spec *structs.QuotaSpec
+	var err error
+	if path := args[0]; path == "-" {
+		spec, err = jobspec.ParseQuotaSpec(os.Stdin)
+	} else {
+		spec, err = jobspec.ParseQuotaSpecFile(path)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing quota specification: %s", err))
+		return 1
+	}
+	if err := spec.Validate(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error validating quota specification: %s", err))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	quota := &api.QuotaSpec{
+		Name:        spec.Name,
+		Description: spec.Description,
+		Limit: &api.QuotaLimit{
+			CPU:      spec.Limit.CPU,
+			MemoryMB: spec.Limit.MemoryMB,
+			DiskMB:   spec.Limit.DiskMB,
+		},
+	}
+	if _, err := client.Quotas().Register(quota, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error applying quota: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Successfully applied quota %q", quota.Name))
+	return 0
+}
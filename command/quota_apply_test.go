@@ -0,0 +1,120 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestQuotaApplyCommand_Implements(t *testing.T) {
+	var _ cli.Command = &QuotaApplyCommand{}
+}
+
+func TestQuotaApplyCommand_Good(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(`
+name        = "engineering"
+description = "Engineering team"
+
+limit {
+  cpu    = 4000
+  memory = 8192
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &QuotaApplyCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, fh.Name()}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, `Successfully applied quota "engineering"`) {
+		t.Fatalf("expected success output, got: %s", out)
+	}
+
+	// The quota is listed
+	ui = new(cli.MockUi)
+	list := &QuotaListCommand{Meta: Meta{Ui: ui}}
+	if code := list.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "engineering") || !strings.Contains(out, "unlimited") {
+		t.Fatalf("expected quota listed, got: %s", out)
+	}
+
+	// The status displays its limit and usage
+	ui = new(cli.MockUi)
+	status := &QuotaStatusCommand{Meta: Meta{Ui: ui}}
+	if code := status.Run([]string{"-address=" + url, "engineering"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, "Engineering team") || !strings.Contains(out, "8192") {
+		t.Fatalf("expected quota status, got: %s", out)
+	}
+
+	// The quota is deleted
+	ui = new(cli.MockUi)
+	del := &QuotaDeleteCommand{Meta: Meta{Ui: ui}}
+	if code := del.Run([]string{"-address=" + url, "engineering"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, `Successfully deleted quota "engineering"`) {
+		t.Fatalf("expected success output, got: %s", out)
+	}
+}
+
+func TestQuotaApplyCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &QuotaApplyCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails when the specification is invalid
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	if _, err := fh.WriteString(`name = "engineering"`); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if code := cmd.Run([]string{"-address=nope", fh.Name()}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Missing quota limit") {
+		t.Fatalf("expected validation error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if _, err := fh.WriteString("\nlimit {\n  cpu = 100\n}\n"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if code := cmd.Run([]string{"-address=nope", fh.Name()}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error applying quota") {
+		t.Fatalf("expected failed apply error, got: %s", out)
+	}
+}
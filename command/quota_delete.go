@@ -0,0 +1,59 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type QuotaDeleteCommand struct {
+	Meta
+}
+
+func (c *QuotaDeleteCommand) Help() string {
+	helpText := `
+Usage: nomad quota delete [options] <name>
+
+  Delete is used to delete a quota. A quota can only be deleted once no
+  namespace references it.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaDeleteCommand) Synopsis() string {
+	return "Delete a quota"
+}
+
+func (c *QuotaDeleteCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("quota delete", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if _, err := client.Quotas().Delete(name, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error deleting quota: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Successfully deleted quota %q", name))
+	return 0
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type QuotaListCommand struct {
+	Meta
+}
+
+func (c *QuotaListCommand) Help() string {
+	helpText := `
+Usage: nomad quota list [options]
+
+  List is used to list the quotas and the resources they limit.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaListCommand) Synopsis() string {
+	return "List quotas"
+}
+
+func (c *QuotaListCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("quota list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if l := len(args); l != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	quotas, _, err := client.Quotas().List(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying quotas: %s", err))
+		return 1
+	}
+	if len(quotas) == 0 {
+		c.Ui.Output("No quotas found")
+		return 0
+	}
+
+	c.Ui.Output(formatQuotaList(quotas))
+	return 0
+}
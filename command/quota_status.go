@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type QuotaStatusCommand struct {
+	Meta
+}
+
+func (c *QuotaStatusCommand) Help() string {
+	helpText := `
+Usage: nomad quota status [options] <name>
+
+  Display the limit of a quota and the resources used by the allocations of
+  the jobs of the namespaces referencing it.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaStatusCommand) Synopsis() string {
+	return "Display the limit and usage of a quota"
+}
+
+func (c *QuotaStatusCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("quota status", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	quota, _, err := client.Quotas().Info(name, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying quota: %s", err))
+		return 1
+	}
+	usage, _, err := client.Quotas().Usage(name, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying quota usage: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("Name|%s", quota.Name),
+		fmt.Sprintf("Description|%s", quota.Description),
+	}
+	c.Ui.Output(formatKV(basic))
+
+	limit := quota.Limit
+	if limit == nil {
+		limit = &api.QuotaLimit{}
+	}
+	used := usage.Used
+	if used == nil {
+		used = &api.Resources{}
+	}
+	out := []string{
+		"Resource|Used|Limit",
+		fmt.Sprintf("CPU|%d|%s", used.CPU, formatQuotaLimit(limit.CPU)),
+		fmt.Sprintf("Memory MB|%d|%s", used.MemoryMB, formatQuotaLimit(limit.MemoryMB)),
+		fmt.Sprintf("Disk MB|%d|%s", used.DiskMB, formatQuotaLimit(limit.DiskMB)),
+	}
+	c.Ui.Output(c.Colorize().Color("\n[bold]Usage[reset]"))
+	c.Ui.Output(formatList(out))
+	return 0
+}
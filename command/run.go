@@ -87,6 +87,8 @@ Run Options:
   -output
     Output the JSON that would be submitted to the HTTP API without submitting
     the job.
+
+  ` + jobVarsUsage() + `
 `
 	return strings.TrimSpace(helpText)
 }
@@ -106,6 +108,7 @@ func (c *RunCommand) Run(args []string) int {
 	flags.BoolVar(&output, "output", false, "")
 	flags.StringVar(&checkIndexStr, "check-index", "", "")
 	flags.StringVar(&vaultToken, "vault-token", "", "")
+	c.JobGetter.VarFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -147,8 +150,9 @@ func (c *RunCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Check if the job is periodic.
+	// Check if the job is periodic or is a parameterized job
 	periodic := job.IsPeriodic()
+	paramjob := job.IsParameterized()
 
 	// Parse the Vault token
 	if vaultToken == "" {
@@ -222,14 +226,14 @@ func (c *RunCommand) Run(args []string) int {
 	}
 
 	// Check if we should enter monitor mode
-	if detach || periodic {
+	if detach || periodic || paramjob {
 		c.Ui.Output("Job registration successful")
 		if periodic {
 			now := time.Now().UTC()
 			next := job.Periodic.Next(now)
 			c.Ui.Output(fmt.Sprintf("Approximate next launch time: %s (%s from now)",
 				formatTime(next), formatTimeDifference(now, next, time.Second)))
-		} else {
+		} else if !paramjob {
 			c.Ui.Output("Evaluation ID: " + evalID)
 		}
 
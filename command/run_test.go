@@ -48,6 +48,67 @@ job "job1" {
 	}
 }
 
+func TestRunCommand_Vars(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Meta: Meta{Ui: ui}}
+
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(`
+job "job1" {
+	type = "service"
+	datacenters = [ "dc1" ]
+	group "group1" {
+		count = ${var.count}
+		task "task1" {
+			driver = "docker"
+			config {
+				image = "redis:${var.tag}"
+			}
+			resources = {
+				cpu = 1000
+				memory = 512
+			}
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	vh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(vh.Name())
+	if _, err := vh.WriteString("count = 3\ntag = \"3.0\"\n"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The -var flag overrides the variable file
+	args := []string{"-output", "-var-file", vh.Name(), "-var", "tag=3.2", fh.Name()}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit code 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, `"Count": 3,`) || !strings.Contains(out, `"image": "redis:3.2"`) {
+		t.Fatalf("Expected interpolated JSON output: %v", out)
+	}
+
+	// Fails on undefined variables
+	ui.ErrorWriter.Reset()
+	cmd = &RunCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-output", "-var", "tag=3.2", fh.Name()}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "undefined variables: count") {
+		t.Fatalf("expected undefined variable error, got: %s", out)
+	}
+}
+
 func TestRunCommand_Fails(t *testing.T) {
 	ui := new(cli.MockUi)
 	cmd := &RunCommand{Meta: Meta{Ui: ui}}
@@ -18,11 +18,16 @@ const (
 	maxFailedTGs = 5
 )
 
+// allocUUIDColumns are the columns of the allocations list holding UUIDs,
+// which are shortened unless the verbose flag is set.
+var allocUUIDColumns = []string{"ID", "EvalID", "NodeID"}
+
 type StatusCommand struct {
 	Meta
 	length  int
 	evals   bool
 	verbose bool
+	columns []string
 }
 
 func (c *StatusCommand) Help() string {
@@ -47,6 +52,17 @@ Status Options:
 
   -verbose
     Display full information.
+
+  -json
+    Output the job status in its JSON format.
+
+  -t
+    Format and display the job status using a Go template.
+
+  -columns <fields>
+    Comma separated list of the fields to display in the jobs list, or in the
+    allocations list when a single job is queried. For example:
+    "ID,NodeID,ClientStatus".
 `
 	return strings.TrimSpace(helpText)
 }
@@ -56,13 +72,17 @@ func (c *StatusCommand) Synopsis() string {
 }
 
 func (c *StatusCommand) Run(args []string) int {
-	var short bool
+	var short, json bool
+	var tmpl, columns string
 
 	flags := c.Meta.FlagSet("status", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&short, "short", false, "")
 	flags.BoolVar(&c.evals, "evals", false, "")
 	flags.BoolVar(&c.verbose, "verbose", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+	flags.StringVar(&columns, "columns", "", "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -80,6 +100,7 @@ func (c *StatusCommand) Run(args []string) int {
 	if c.verbose {
 		c.length = fullId
 	}
+	c.columns = parseColumns(columns)
 
 	// Get the HTTP client
 	client, err := c.Meta.Client()
@@ -96,12 +117,27 @@ func (c *StatusCommand) Run(args []string) int {
 			return 1
 		}
 
+		// If output format is specified, format and output the jobs list
+		if out, ok, err := formatOutput(json, tmpl, jobs); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		} else if ok {
+			c.Ui.Output(out)
+			return 0
+		}
+
 		if len(jobs) == 0 {
 			// No output if we have no jobs
 			c.Ui.Output("No running jobs")
-		} else {
-			c.Ui.Output(createStatusListOutput(jobs))
+			return 0
 		}
+
+		out, err := c.jobListOutput(jobs)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
 		return 0
 	}
 
@@ -117,7 +153,12 @@ func (c *StatusCommand) Run(args []string) int {
 		return 1
 	}
 	if len(jobs) > 1 && strings.TrimSpace(jobID) != jobs[0].ID {
-		c.Ui.Output(fmt.Sprintf("Prefix matched multiple jobs\n\n%s", createStatusListOutput(jobs)))
+		out, err := c.jobListOutput(jobs)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple jobs\n\n%s", out))
 		return 0
 	}
 	// Prefix lookup matched a single job
@@ -127,6 +168,23 @@ func (c *StatusCommand) Run(args []string) int {
 		return 1
 	}
 
+	// If output format is specified, format and output the job status
+	if json || len(tmpl) > 0 {
+		status, err := c.jobStatus(client, job)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		out, _, err := formatOutput(json, tmpl, status)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
 	// Check if it is periodic
 	sJob, err := convertApiJob(job)
 	if err != nil {
@@ -152,6 +210,16 @@ func (c *StatusCommand) Run(args []string) int {
 		basic = append(basic, fmt.Sprintf("Next Periodic Launch|%s",
 			fmt.Sprintf("%s (%s from now)",
 				formatTime(next), formatTimeDifference(now, next, time.Second))))
+
+		// Show the launches that were skipped
+		launch, _, err := client.Jobs().PeriodicLaunch(job.ID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying periodic launch: %s", err))
+			return 1
+		}
+		if skipped := formatSkippedLaunches(launch.Skipped); skipped != "" {
+			basic = append(basic, fmt.Sprintf("Skipped Launches|%s", skipped))
+		}
 	}
 
 	c.Ui.Output(formatKV(basic))
@@ -179,6 +247,40 @@ func (c *StatusCommand) Run(args []string) int {
 	return 0
 }
 
+// jobStatus is the status of a job as output by the -json and -t flags.
+type jobStatus struct {
+	Job         *api.Job
+	Summary     *api.JobSummary
+	Allocations []*api.AllocationListStub
+	Evaluations []*api.Evaluation
+}
+
+// jobStatus queries the summary, allocations and evaluations of the passed
+// job. If a request fails, an error is returned.
+func (c *StatusCommand) jobStatus(client *api.Client, job *api.Job) (*jobStatus, error) {
+	summary, _, err := client.Jobs().Summary(job.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying job summary: %s", err)
+	}
+
+	allocs, _, err := client.Jobs().Allocations(job.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying job allocations: %s", err)
+	}
+
+	evals, _, err := client.Jobs().Evaluations(job.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying job evaluations: %s", err)
+	}
+
+	return &jobStatus{
+		Job:         job,
+		Summary:     summary,
+		Allocations: allocs,
+		Evaluations: evals,
+	}, nil
+}
+
 // outputPeriodicInfo prints information about the passed periodic job. If a
 // request fails, an error is returned.
 func (c *StatusCommand) outputPeriodicInfo(client *api.Client, job *api.Job) error {
@@ -299,7 +401,13 @@ func (c *StatusCommand) outputJobInfo(client *api.Client, job *api.Job) error {
 
 	// Format the allocs
 	c.Ui.Output(c.Colorize().Color("\n[bold]Allocations[reset]"))
-	if len(jobAllocs) > 0 {
+	if len(jobAllocs) > 0 && len(c.columns) > 0 {
+		out, err := formatColumns(jobAllocs, c.columns, allocUUIDColumns, c.length)
+		if err != nil {
+			return err
+		}
+		c.Ui.Output(out)
+	} else if len(jobAllocs) > 0 {
 		allocs = make([]string, len(jobAllocs)+1)
 		allocs[0] = "ID|Eval ID|Node ID|Task Group|Desired|Status|Created At"
 		for i, alloc := range jobAllocs {
@@ -363,6 +471,14 @@ func convertApiJob(in *api.Job) (*structs.Job, error) {
 	return structJob, nil
 }
 
+// jobListOutput formats the jobs list, using the selected columns if any.
+func (c *StatusCommand) jobListOutput(jobs []*api.JobListStub) (string, error) {
+	if len(c.columns) > 0 {
+		return formatColumns(jobs, c.columns, nil, c.length)
+	}
+	return createStatusListOutput(jobs), nil
+}
+
 // list general information about a list of jobs
 func createStatusListOutput(jobs []*api.JobListStub) string {
 	out := make([]string, len(jobs)+1)
@@ -376,3 +492,16 @@ func createStatusListOutput(jobs []*api.JobListStub) string {
 	}
 	return formatList(out)
 }
+
+// formatSkippedLaunches returns a summary of the skipped launches of a
+// periodic job or an empty string if none were skipped.
+func formatSkippedLaunches(skipped []time.Time) string {
+	switch len(skipped) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("skipped 1 run (%s)", formatTime(skipped[0]))
+	default:
+		return fmt.Sprintf("skipped %d runs (earliest %s)", len(skipped), formatTime(skipped[0]))
+	}
+}
@@ -3,6 +3,7 @@ package command
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/testutil"
@@ -115,6 +116,64 @@ func TestStatusCommand_Run(t *testing.T) {
 	}
 	ui.OutputWriter.Reset()
 
+	// Query a single job in json mode
+	if code := cmd.Run([]string{"-address=" + url, "-json", "job2_sfx"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d", code)
+	}
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, `"ID": "job2_sfx"`) || !strings.Contains(out, `"Allocations":`) {
+		t.Fatalf("expected json job status, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Query a single job using a template
+	if code := cmd.Run([]string{"-address=" + url, "-t", "{{.Job.ID}}", "job2_sfx"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d", code)
+	}
+	if out = strings.TrimSpace(ui.OutputWriter.String()); out != "job2_sfx" {
+		t.Fatalf("expected job2_sfx, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// List the jobs using a template
+	if code := cmd.Run([]string{"-address=" + url, "-t", "{{range .}}{{.ID}} {{end}}"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d", code)
+	}
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, "job1_sfx") || !strings.Contains(out, "job2_sfx") {
+		t.Fatalf("expected job1_sfx and job2_sfx, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// List the jobs with selected columns
+	if code := cmd.Run([]string{"-address=" + url, "-columns", "ID,Status"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d", code)
+	}
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, "job1_sfx") || strings.Contains(out, "Priority") {
+		t.Fatalf("expected only selected columns, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Query a single job with selected allocation columns
+	if code := cmd.Run([]string{"-address=" + url, "-columns", "JobID,ClientStatus", "job2_sfx"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d", code)
+	}
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, "JobID") || strings.Contains(out, "Eval ID") {
+		t.Fatalf("expected only selected columns, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Unknown columns fail
+	if code := cmd.Run([]string{"-address=" + url, "-columns", "Foo"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Unknown column") {
+		t.Fatalf("expected unknown column error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
 	// Query jobs with prefix match
 	if code := cmd.Run([]string{"-address=" + url, "job"}); code != 0 {
 		t.Fatalf("expected exit 0, got: %d", code)
@@ -186,6 +245,24 @@ func TestStatusCommand_Fails(t *testing.T) {
 	}
 }
 
+func TestStatusCommand_FormatSkippedLaunches(t *testing.T) {
+	base := time.Unix(1000, 0).UTC()
+	cases := []struct {
+		skipped  []time.Time
+		expected string
+	}{
+		{nil, ""},
+		{[]time.Time{base}, "skipped 1 run (" + formatTime(base) + ")"},
+		{[]time.Time{base, base.Add(time.Minute), base.Add(2 * time.Minute)}, "skipped 3 runs (earliest " + formatTime(base) + ")"},
+	}
+
+	for _, c := range cases {
+		if out := formatSkippedLaunches(c.skipped); out != c.expected {
+			t.Fatalf("formatSkippedLaunches(%v) returned %q; want %q", c.skipped, out, c.expected)
+		}
+	}
+}
+
 func waitForSuccess(ui cli.Ui, client *api.Client, length int, t *testing.T, evalId string) int {
 	mon := newMonitor(ui, client, length)
 	monErr := mon.monitor(evalId, false)
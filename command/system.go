@@ -0,0 +1,35 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type SystemCommand struct {
+	Meta
+}
+
+func (c *SystemCommand) Help() string {
+	helpText := `
+Usage: nomad system <subcommand> [options]
+
+  This command groups subcommands for interacting with the system API. Users
+  can reconcile system state.
+
+  Reconcile the summaries of all registered jobs:
+
+      $ nomad system reconcile summaries
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SystemCommand) Synopsis() string {
+	return "Interact with the system API"
+}
+
+func (c *SystemCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
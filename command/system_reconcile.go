@@ -0,0 +1,35 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type SystemReconcileCommand struct {
+	Meta
+}
+
+func (c *SystemReconcileCommand) Help() string {
+	helpText := `
+Usage: nomad system reconcile <subcommand> [options]
+
+  This command groups subcommands for reconciling the system state with the
+  authoritative allocation state.
+
+  Reconcile the summaries of all registered jobs:
+
+      $ nomad system reconcile summaries
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SystemReconcileCommand) Synopsis() string {
+	return "Reconcile system state"
+}
+
+func (c *SystemReconcileCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
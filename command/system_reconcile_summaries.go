@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SystemReconcileSummariesCommand struct {
+	Meta
+}
+
+func (c *SystemReconcileSummariesCommand) Help() string {
+	helpText := `
+Usage: nomad system reconcile summaries [options]
+
+  Reconciles the summaries of all registered jobs by recomputing them from the
+  allocations of each job. This can be used to recover from summaries that have
+  drifted from the allocation state.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SystemReconcileSummariesCommand) Synopsis() string {
+	return "Reconciles the summaries of all registered jobs"
+}
+
+func (c *SystemReconcileSummariesCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("system reconcile summaries", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if args = flags.Args(); len(args) > 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if err := client.System().ReconcileSummaries(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error running system summary reconciliation: %s", err))
+		return 1
+	}
+
+	c.Ui.Output("Successfully reconciled job summaries")
+	return 0
+}
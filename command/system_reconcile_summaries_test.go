@@ -0,0 +1,49 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestSystemReconcileSummariesCommand_Implements(t *testing.T) {
+	var _ cli.Command = &SystemReconcileSummariesCommand{}
+}
+
+func TestSystemReconcileSummariesCommand_Good(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &SystemReconcileSummariesCommand{Meta: Meta{Ui: ui}}
+
+	if code := cmd.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Successfully") {
+		t.Fatalf("expected success output, got: %s", out)
+	}
+}
+
+func TestSystemReconcileSummariesCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &SystemReconcileSummariesCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error running system summary reconciliation") {
+		t.Fatalf("expected failed reconciliation error, got: %s", out)
+	}
+}
@@ -3,6 +3,10 @@ package command
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/jobspec"
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 type ValidateCommand struct {
@@ -15,11 +19,17 @@ func (c *ValidateCommand) Help() string {
 Usage: nomad validate [options] <file>
 
   Checks if a given HCL job file has a valid specification. This can be used to
-  check for any syntax errors or validation problems with a job.
+  check for any syntax errors or validation problems with a job. All validation
+  errors are reported at once, prefixed with the position of the job, group or
+  task they apply to, and a non-zero exit code is returned if any are found.
 
   If the supplied path is "-", the jobfile is read from stdin. Otherwise
   it is read from the file at the supplied path or downloaded and
   read from URL specified.
+
+Validate Options:
+
+  ` + jobVarsUsage() + `
 `
 	return strings.TrimSpace(helpText)
 }
@@ -31,6 +41,7 @@ func (c *ValidateCommand) Synopsis() string {
 func (c *ValidateCommand) Run(args []string) int {
 	flags := c.Meta.FlagSet("validate", FlagSetNone)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	c.JobGetter.VarFlags(flags)
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
@@ -43,7 +54,7 @@ func (c *ValidateCommand) Run(args []string) int {
 	}
 
 	// Get Job struct from Jobfile
-	job, err := c.JobGetter.StructJob(args[0])
+	job, pos, err := c.JobGetter.StructJobPositions(args[0])
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error getting job struct: %s", err))
 		return 1
@@ -53,8 +64,11 @@ func (c *ValidateCommand) Run(args []string) int {
 	job.Canonicalize()
 
 	// Check that the job is valid
-	if err := job.Validate(); err != nil {
-		c.Ui.Error(fmt.Sprintf("Error validating job: %s", err))
+	if errs := validationErrors(job, pos); len(errs) != 0 {
+		c.Ui.Error(fmt.Sprintf("Error validating job: %d error(s) occurred:\n", len(errs)))
+		for _, e := range errs {
+			c.Ui.Error(fmt.Sprintf("* %s", e))
+		}
 		return 1
 	}
 
@@ -62,3 +76,59 @@ func (c *ValidateCommand) Run(args []string) int {
 	c.Ui.Output("Job validation successful")
 	return 0
 }
+
+// validationErrors validates the job and returns its validation errors, each
+// prefixed with the position of the job, task group or task it applies to.
+func validationErrors(job *structs.Job, pos *jobspec.Positions) []string {
+	var errs []string
+	for _, err := range flattenErrors(job.Validate()) {
+		tg := failedTaskGroup(job, err)
+		if tg == nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", pos.Job, err))
+			continue
+		}
+
+		for _, tgErr := range flattenErrors(tg.Validate()) {
+			p := pos.Group(tg.Name)
+			if task := failedTask(tg, tgErr); task != nil {
+				p = pos.Task(tg.Name, task.Name)
+			}
+			errs = append(errs, fmt.Sprintf("%s: group %q: %s", p, tg.Name, tgErr))
+		}
+	}
+	return errs
+}
+
+// failedTaskGroup returns the task group whose validation failure is reported
+// by err or nil if err is not a task group validation failure.
+func failedTaskGroup(job *structs.Job, err error) *structs.TaskGroup {
+	for _, tg := range job.TaskGroups {
+		if strings.HasPrefix(err.Error(), fmt.Sprintf("Task group %s validation failed: ", tg.Name)) {
+			return tg
+		}
+	}
+	return nil
+}
+
+// failedTask returns the task whose validation failure is reported by err or
+// nil if err is not a task validation failure.
+func failedTask(tg *structs.TaskGroup, err error) *structs.Task {
+	for _, task := range tg.Tasks {
+		if strings.HasPrefix(err.Error(), fmt.Sprintf("Task %s validation failed: ", task.Name)) {
+			return task
+		}
+	}
+	return nil
+}
+
+// flattenErrors returns the errors wrapped by a multierror or the error
+// itself.
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if mErr, ok := err.(*multierror.Error); ok {
+		return mErr.Errors
+	}
+	return []error{err}
+}
@@ -102,6 +102,47 @@ func TestValidateCommand_Fails(t *testing.T) {
 	ui.ErrorWriter.Reset()
 }
 
+func TestValidateCommand_Positions(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &ValidateCommand{Meta: Meta{Ui: ui}}
+
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(`job "job1" {
+	type = "service"
+	group "group1" {
+		count = -1
+		task "task1" {
+			resources = {
+				cpu = 1000
+				memory = 512
+			}
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if code := cmd.Run([]string{fh.Name()}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+
+	// Errors are reported at the position of what they apply to
+	out := ui.ErrorWriter.String()
+	for _, expected := range []string{
+		"1:5: Missing job datacenters",
+		`3:8: group "group1": Task group count can't be negative`,
+		`5:8: group "group1": Task task1 validation failed`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected %q, got: %s", expected, out)
+		}
+	}
+}
+
 func TestValidateCommand_From_STDIN(t *testing.T) {
 	stdinR, stdinW, err := os.Pipe()
 	if err != nil {
@@ -25,6 +25,11 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 	}
 
 	return map[string]cli.CommandFactory{
+		"alloc-restart": func() (cli.Command, error) {
+			return &command.AllocRestartCommand{
+				Meta: meta,
+			}, nil
+		},
 		"alloc-status": func() (cli.Command, error) {
 			return &command.AllocStatusCommand{
 				Meta: meta,
@@ -54,6 +59,41 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"deployment": func() (cli.Command, error) {
+			return &command.DeploymentCommand{
+				Meta: meta,
+			}, nil
+		},
+		"deployment fail": func() (cli.Command, error) {
+			return &command.DeploymentFailCommand{
+				Meta: meta,
+			}, nil
+		},
+		"deployment promote": func() (cli.Command, error) {
+			return &command.DeploymentPromoteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"deployment status": func() (cli.Command, error) {
+			return &command.DeploymentStatusCommand{
+				Meta: meta,
+			}, nil
+		},
+		"eval": func() (cli.Command, error) {
+			return &command.EvalCommand{
+				Meta: meta,
+			}, nil
+		},
+		"eval delete": func() (cli.Command, error) {
+			return &command.EvalDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"eval list": func() (cli.Command, error) {
+			return &command.EvalListCommand{
+				Meta: meta,
+			}, nil
+		},
 		"eval-status": func() (cli.Command, error) {
 			return &command.EvalStatusCommand{
 				Meta: meta,
@@ -79,11 +119,66 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"job": func() (cli.Command, error) {
+			return &command.JobCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job dispatch": func() (cli.Command, error) {
+			return &command.JobDispatchCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job history": func() (cli.Command, error) {
+			return &command.JobHistoryCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job revert": func() (cli.Command, error) {
+			return &command.JobRevertCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job-logs": func() (cli.Command, error) {
+			return &command.JobLogsCommand{
+				Meta: meta,
+			}, nil
+		},
 		"logs": func() (cli.Command, error) {
 			return &command.LogsCommand{
 				Meta: meta,
 			}, nil
 		},
+		"monitor-events": func() (cli.Command, error) {
+			return &command.MonitorEventsCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace": func() (cli.Command, error) {
+			return &command.NamespaceCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace apply": func() (cli.Command, error) {
+			return &command.NamespaceApplyCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace delete": func() (cli.Command, error) {
+			return &command.NamespaceDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace list": func() (cli.Command, error) {
+			return &command.NamespaceListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace status": func() (cli.Command, error) {
+			return &command.NamespaceStatusCommand{
+				Meta: meta,
+			}, nil
+		},
 		"node-drain": func() (cli.Command, error) {
 			return &command.NodeDrainCommand{
 				Meta: meta,
@@ -95,12 +190,67 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"operator": func() (cli.Command, error) {
+			return &command.OperatorCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator debug": func() (cli.Command, error) {
+			return &command.OperatorDebugCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator keyring": func() (cli.Command, error) {
+			return &command.OperatorKeyringCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator raft": func() (cli.Command, error) {
+			return &command.OperatorRaftCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator raft list-peers": func() (cli.Command, error) {
+			return &command.OperatorRaftListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator raft remove-peer": func() (cli.Command, error) {
+			return &command.OperatorRaftRemoveCommand{
+				Meta: meta,
+			}, nil
+		},
 		"plan": func() (cli.Command, error) {
 			return &command.PlanCommand{
 				Meta: meta,
 			}, nil
 		},
 
+		"quota": func() (cli.Command, error) {
+			return &command.QuotaCommand{
+				Meta: meta,
+			}, nil
+		},
+		"quota apply": func() (cli.Command, error) {
+			return &command.QuotaApplyCommand{
+				Meta: meta,
+			}, nil
+		},
+		"quota delete": func() (cli.Command, error) {
+			return &command.QuotaDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"quota list": func() (cli.Command, error) {
+			return &command.QuotaListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"quota status": func() (cli.Command, error) {
+			return &command.QuotaStatusCommand{
+				Meta: meta,
+			}, nil
+		},
 		"run": func() (cli.Command, error) {
 			return &command.RunCommand{
 				Meta: meta,
@@ -136,6 +286,21 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"system": func() (cli.Command, error) {
+			return &command.SystemCommand{
+				Meta: meta,
+			}, nil
+		},
+		"system reconcile": func() (cli.Command, error) {
+			return &command.SystemReconcileCommand{
+				Meta: meta,
+			}, nil
+		},
+		"system reconcile summaries": func() (cli.Command, error) {
+			return &command.SystemReconcileSummariesCommand{
+				Meta: meta,
+			}, nil
+		},
 		"validate": func() (cli.Command, error) {
 			return &command.ValidateCommand{
 				Meta: meta,
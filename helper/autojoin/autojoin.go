@@ -0,0 +1,128 @@
+// Package autojoin discovers the addresses of the agents to join from the
+// APIs of cloud providers, so that the configuration doesn't need to hard-code
+// the addresses of the servers.
+//
+// A discovery configuration is a list of space separated key=value pairs, the
+// provider key naming the cloud provider:
+//
+//	provider=aws tag_key=nomad tag_value=server
+//	provider=gce tag_value=nomad-server
+//	provider=azure tag_name=nomad tag_value=server tenant_id=... client_id=... subscription_id=... secret_access_key=...
+package autojoin
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+// Provider discovers the private IP addresses of the instances matching the
+// arguments of a discovery configuration.
+type Provider interface {
+	// Addrs returns the addresses of the instances matching the arguments.
+	Addrs(args map[string]string, logger *log.Logger) ([]string, error)
+}
+
+// Providers are the supported cloud providers, by name.
+var Providers = map[string]Provider{
+	"aws":   &AWSProvider{},
+	"gce":   &GCEProvider{},
+	"azure": &AzureProvider{},
+}
+
+// httpClient is the client used to query the cloud provider APIs.
+var httpClient = &http.Client{
+	Timeout:   30 * time.Second,
+	Transport: cleanhttp.DefaultTransport(),
+}
+
+// IsConfig returns whether the address is a discovery configuration rather
+// than an address.
+func IsConfig(addr string) bool {
+	return strings.Contains(addr, "provider=")
+}
+
+// Parse parses a discovery configuration into its arguments.
+func Parse(config string) (map[string]string, error) {
+	args := make(map[string]string)
+	for _, field := range strings.Fields(config) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid discovery argument %q: must be key=value", field)
+		}
+		if _, ok := args[parts[0]]; ok {
+			return nil, fmt.Errorf("duplicate discovery argument %q", parts[0])
+		}
+		args[parts[0]] = parts[1]
+	}
+	return args, nil
+}
+
+// Validate checks that the discovery configuration names a known provider.
+func Validate(config string) error {
+	_, err := provider(config)
+	return err
+}
+
+// Addrs returns the addresses of the instances matching the discovery
+// configuration.
+func Addrs(config string, logger *log.Logger) ([]string, error) {
+	args, err := Parse(config)
+	if err != nil {
+		return nil, err
+	}
+	p, err := provider(config)
+	if err != nil {
+		return nil, err
+	}
+	return p.Addrs(args, logger)
+}
+
+// Resolve replaces the discovery configurations of the addresses with the
+// addresses they discover. A discovery that fails is logged and skipped so
+// that the other addresses can still be used.
+func Resolve(addrs []string, logger *log.Logger) []string {
+	resolved := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if !IsConfig(addr) {
+			resolved = append(resolved, addr)
+			continue
+		}
+
+		discovered, err := Addrs(addr, logger)
+		if err != nil {
+			logger.Printf("[ERR] autojoin: failed to discover addresses: %v", err)
+			continue
+		}
+		logger.Printf("[DEBUG] autojoin: discovered addresses: %v", discovered)
+		resolved = append(resolved, discovered...)
+	}
+	return resolved
+}
+
+// provider returns the provider named by the discovery configuration.
+func provider(config string) (Provider, error) {
+	args, err := Parse(config)
+	if err != nil {
+		return nil, err
+	}
+	name := args["provider"]
+	if name == "" {
+		return nil, fmt.Errorf("discovery configuration is missing the provider")
+	}
+	p, ok := Providers[name]
+	if !ok {
+		names := make([]string, 0, len(Providers))
+		for n := range Providers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown provider %q, must be one of %s", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
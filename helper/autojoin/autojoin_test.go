@@ -0,0 +1,72 @@
+package autojoin
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testProvider returns the configured addresses.
+type testProvider struct {
+	addrs []string
+	err   error
+}
+
+func (p *testProvider) Addrs(args map[string]string, logger *log.Logger) ([]string, error) {
+	return p.addrs, p.err
+}
+
+func testLogger() *log.Logger {
+	return log.New(os.Stderr, "", log.LstdFlags)
+}
+
+func TestParse(t *testing.T) {
+	args, err := Parse("provider=aws  tag_key=nomad tag_value=a=b")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := map[string]string{
+		"provider":  "aws",
+		"tag_key":   "nomad",
+		"tag_value": "a=b",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("bad: %#v", args)
+	}
+
+	if _, err := Parse("provider=aws tag_key"); err == nil {
+		t.Fatalf("expected an error for a missing value")
+	}
+	if _, err := Parse("provider=aws provider=gce"); err == nil {
+		t.Fatalf("expected an error for a duplicate argument")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("provider=gce tag_value=nomad"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := Validate("tag_value=nomad"); err == nil {
+		t.Fatalf("expected an error for a missing provider")
+	}
+	err := Validate("provider=foo")
+	if err == nil || !strings.Contains(err.Error(), "unknown provider") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	Providers["test"] = &testProvider{addrs: []string{"10.0.0.1", "10.0.0.2"}}
+	Providers["failing"] = &testProvider{err: fmt.Errorf("failed")}
+	defer delete(Providers, "test")
+	defer delete(Providers, "failing")
+
+	addrs := Resolve([]string{"127.0.0.1", "provider=test", "provider=failing"}, testLogger())
+	expected := []string{"127.0.0.1", "10.0.0.1", "10.0.0.2"}
+	if !reflect.DeepEqual(addrs, expected) {
+		t.Fatalf("bad: %#v", addrs)
+	}
+}
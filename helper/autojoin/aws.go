@@ -0,0 +1,174 @@
+package autojoin
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/corehandlers"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/private/signer/v4"
+)
+
+const (
+	// ec2APIVersion is the version of the EC2 query API used.
+	ec2APIVersion = "2016-09-15"
+)
+
+// AWSProvider discovers the running AWS EC2 instances having a tag. It
+// supports the following arguments:
+//
+//	region:            The AWS region. Defaults to the region of the instance.
+//	tag_key:           The key of the tag to filter on.
+//	tag_value:         The value of the tag to filter on.
+//	access_key_id:     The AWS access key to use. Defaults to the environment,
+//	                   the shared credentials file or the instance role.
+//	secret_access_key: The AWS secret access key to use.
+type AWSProvider struct {
+	// Endpoint overrides the endpoint of the EC2 API.
+	Endpoint string
+}
+
+// ec2Reservations is the part of the EC2 DescribeInstances response used to
+// discover the addresses.
+type ec2Reservations struct {
+	Reservations []struct {
+		Instances []struct {
+			InstanceID       string `xml:"instanceId"`
+			PrivateIPAddress string `xml:"privateIpAddress"`
+		} `xml:"instancesSet>item"`
+	} `xml:"reservationSet>item"`
+	NextToken string `xml:"nextToken"`
+}
+
+// ec2Errors is the error response of the EC2 API.
+type ec2Errors struct {
+	Errors []struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Errors>Error"`
+}
+
+// Addrs returns the private addresses of the matching EC2 instances.
+func (p *AWSProvider) Addrs(args map[string]string, logger *log.Logger) ([]string, error) {
+	tagKey, tagValue := args["tag_key"], args["tag_value"]
+	if tagKey == "" || tagValue == "" {
+		return nil, fmt.Errorf("aws: tag_key and tag_value are required")
+	}
+
+	config := &aws.Config{HTTPClient: httpClient}
+	if p.Endpoint != "" {
+		config.Endpoint = aws.String(p.Endpoint)
+	}
+	if id := args["access_key_id"]; id != "" {
+		config.Credentials = credentials.NewStaticCredentials(id, args["secret_access_key"], "")
+	}
+
+	region := args["region"]
+	if region == "" {
+		var err error
+		region, err = ec2metadata.New(session.New(config)).Region()
+		if err != nil {
+			return nil, fmt.Errorf("aws: failed to get the region of the instance: %v", err)
+		}
+	}
+	config.Region = aws.String(region)
+
+	c := newEC2Client(session.New(config))
+	params := url.Values{
+		"Filter.1.Name":    {"tag:" + tagKey},
+		"Filter.1.Value.1": {tagValue},
+		"Filter.2.Name":    {"instance-state-name"},
+		"Filter.2.Value.1": {"running"},
+	}
+
+	var addrs []string
+	for {
+		var resp ec2Reservations
+		op := &request.Operation{Name: "DescribeInstances", HTTPMethod: "POST", HTTPPath: "/"}
+		if err := c.NewRequest(op, params, &resp).Send(); err != nil {
+			return nil, fmt.Errorf("aws: failed to describe instances: %v", err)
+		}
+
+		for _, reservation := range resp.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.PrivateIPAddress == "" {
+					logger.Printf("[DEBUG] autojoin.aws: instance %s has no private address", instance.InstanceID)
+					continue
+				}
+				addrs = append(addrs, instance.PrivateIPAddress)
+			}
+		}
+
+		if resp.NextToken == "" {
+			break
+		}
+		params.Set("NextToken", resp.NextToken)
+	}
+	return addrs, nil
+}
+
+// newEC2Client returns a client of the EC2 query API. Only the parts of the
+// API used for the discovery are supported: the parameters are url.Values
+// and the responses are decoded with encoding/xml.
+func newEC2Client(p client.ConfigProvider) *client.Client {
+	c := p.ClientConfig("ec2")
+	ec2 := client.New(*c.Config, metadata.ClientInfo{
+		ServiceName:   "ec2",
+		SigningRegion: c.SigningRegion,
+		Endpoint:      c.Endpoint,
+		APIVersion:    ec2APIVersion,
+	}, c.Handlers)
+
+	// The parameters aren't the structs the validation expects
+	ec2.Handlers.Validate.Remove(corehandlers.ValidateParametersHandler)
+
+	ec2.Handlers.Sign.PushBack(v4.Sign)
+	ec2.Handlers.Build.PushBack(buildEC2Request)
+	ec2.Handlers.Unmarshal.PushBack(unmarshalEC2Response)
+	ec2.Handlers.UnmarshalError.PushBack(unmarshalEC2Error)
+	return ec2
+}
+
+func buildEC2Request(r *request.Request) {
+	body := url.Values{
+		"Action":  {r.Operation.Name},
+		"Version": {r.ClientInfo.APIVersion},
+	}
+	for k, v := range r.Params.(url.Values) {
+		body[k] = v
+	}
+
+	r.HTTPRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	r.SetBufferBody([]byte(body.Encode()))
+}
+
+func unmarshalEC2Response(r *request.Request) {
+	defer r.HTTPResponse.Body.Close()
+	if err := xml.NewDecoder(r.HTTPResponse.Body).Decode(r.Data); err != nil {
+		r.Error = awserr.New("SerializationError", "failed decoding EC2 response", err)
+	}
+}
+
+func unmarshalEC2Error(r *request.Request) {
+	defer r.HTTPResponse.Body.Close()
+
+	var resp ec2Errors
+	if err := xml.NewDecoder(r.HTTPResponse.Body).Decode(&resp); err != nil || len(resp.Errors) == 0 {
+		r.Error = awserr.New("SerializationError",
+			"failed decoding EC2 error response: status "+strconv.Itoa(r.HTTPResponse.StatusCode), err)
+		return
+	}
+	r.Error = awserr.NewRequestFailure(
+		awserr.New(resp.Errors[0].Code, resp.Errors[0].Message, nil),
+		r.HTTPResponse.StatusCode, "")
+}
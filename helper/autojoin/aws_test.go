@@ -0,0 +1,91 @@
+package autojoin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAWSProvider_Addrs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if r.Form.Get("Action") != "DescribeInstances" || r.Form.Get("Filter.1.Name") != "tag:role" ||
+			r.Form.Get("Filter.1.Value.1") != "nomad-server" {
+			t.Fatalf("bad request: %v", r.Form)
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKID/") {
+			t.Fatalf("request not signed: %v", r.Header)
+		}
+
+		// Return the instances over two pages
+		if r.Form.Get("NextToken") == "" {
+			w.Write([]byte(`<DescribeInstancesResponse>
+  <reservationSet>
+    <item>
+      <instancesSet>
+        <item><instanceId>i-1</instanceId><privateIpAddress>10.0.0.1</privateIpAddress></item>
+        <item><instanceId>i-2</instanceId></item>
+      </instancesSet>
+    </item>
+  </reservationSet>
+  <nextToken>page2</nextToken>
+</DescribeInstancesResponse>`))
+			return
+		}
+		w.Write([]byte(`<DescribeInstancesResponse>
+  <reservationSet>
+    <item>
+      <instancesSet>
+        <item><instanceId>i-3</instanceId><privateIpAddress>10.0.0.3</privateIpAddress></item>
+      </instancesSet>
+    </item>
+  </reservationSet>
+</DescribeInstancesResponse>`))
+	}))
+	defer ts.Close()
+
+	p := &AWSProvider{Endpoint: ts.URL}
+	args := map[string]string{
+		"region":            "us-east-1",
+		"tag_key":           "role",
+		"tag_value":         "nomad-server",
+		"access_key_id":     "AKID",
+		"secret_access_key": "SECRET",
+	}
+	addrs, err := p.Addrs(args, testLogger())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if expected := []string{"10.0.0.1", "10.0.0.3"}; !reflect.DeepEqual(addrs, expected) {
+		t.Fatalf("bad: %#v", addrs)
+	}
+}
+
+func TestAWSProvider_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+		w.Write([]byte(`<Response><Errors><Error><Code>AuthFailure</Code><Message>denied</Message></Error></Errors></Response>`))
+	}))
+	defer ts.Close()
+
+	p := &AWSProvider{Endpoint: ts.URL}
+	args := map[string]string{
+		"region":            "us-east-1",
+		"tag_key":           "role",
+		"tag_value":         "nomad-server",
+		"access_key_id":     "AKID",
+		"secret_access_key": "SECRET",
+	}
+	_, err := p.Addrs(args, testLogger())
+	if err == nil || !strings.Contains(err.Error(), "AuthFailure") {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := p.Addrs(map[string]string{"region": "us-east-1"}, testLogger()); err == nil {
+		t.Fatalf("expected an error for missing tags")
+	}
+}
@@ -0,0 +1,124 @@
+package autojoin
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// azureLoginURL is the URL of the Azure Active Directory.
+	azureLoginURL = "https://login.microsoftonline.com/"
+
+	// azureManagementURL is the URL of the Azure Resource Manager API.
+	azureManagementURL = "https://management.azure.com/"
+
+	// azureNetworkAPIVersion is the version of the network API used.
+	azureNetworkAPIVersion = "2016-09-01"
+)
+
+// AzureProvider discovers the Azure virtual machines whose network interface
+// has a tag. The API is authenticated with the credentials of a service
+// principal. It supports the following arguments:
+//
+//	tenant_id:         The ID of the tenant.
+//	client_id:         The ID of the client.
+//	subscription_id:   The ID of the subscription.
+//	secret_access_key: The secret of the client.
+//	tag_name:          The name of the tag to filter on.
+//	tag_value:         The value of the tag to filter on.
+type AzureProvider struct {
+	// LoginURL overrides the URL of the Azure Active Directory.
+	LoginURL string
+
+	// ManagementURL overrides the URL of the Resource Manager API.
+	ManagementURL string
+}
+
+// azureNetworkInterfaces is the part of the network interface list response
+// used to discover the addresses.
+type azureNetworkInterfaces struct {
+	Value []struct {
+		Name       string            `json:"name"`
+		Tags       map[string]string `json:"tags"`
+		Properties struct {
+			IPConfigurations []struct {
+				Properties struct {
+					PrivateIPAddress string `json:"privateIPAddress"`
+				} `json:"properties"`
+			} `json:"ipConfigurations"`
+		} `json:"properties"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// Addrs returns the private addresses of the network interfaces having the
+// tag.
+func (p *AzureProvider) Addrs(args map[string]string, logger *log.Logger) ([]string, error) {
+	for _, arg := range []string{"tenant_id", "client_id", "subscription_id", "secret_access_key", "tag_name", "tag_value"} {
+		if args[arg] == "" {
+			return nil, fmt.Errorf("azure: %s is required", arg)
+		}
+	}
+
+	loginURL, managementURL := p.LoginURL, p.ManagementURL
+	if loginURL == "" {
+		loginURL = azureLoginURL
+	}
+	if managementURL == "" {
+		managementURL = azureManagementURL
+	}
+
+	// Get a token for the service principal
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {args["client_id"]},
+		"client_secret": {args["secret_access_key"]},
+		"resource":      {managementURL},
+	}
+	req, err := http.NewRequest("POST", loginURL+url.QueryEscape(args["tenant_id"])+"/oauth2/token",
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("azure: failed to get an access token: %v", err)
+	}
+
+	var addrs []string
+	u := fmt.Sprintf("%ssubscriptions/%s/providers/Microsoft.Network/networkInterfaces?api-version=%s",
+		managementURL, url.QueryEscape(args["subscription_id"]), azureNetworkAPIVersion)
+	for u != "" {
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+		var resp azureNetworkInterfaces
+		if err := doJSON(req, &resp); err != nil {
+			return nil, fmt.Errorf("azure: failed to list network interfaces: %v", err)
+		}
+
+		for _, nic := range resp.Value {
+			if value, ok := nic.Tags[args["tag_name"]]; !ok || value != args["tag_value"] {
+				continue
+			}
+			configs := nic.Properties.IPConfigurations
+			if len(configs) == 0 || configs[0].Properties.PrivateIPAddress == "" {
+				logger.Printf("[DEBUG] autojoin.azure: network interface %s has no private address", nic.Name)
+				continue
+			}
+			addrs = append(addrs, configs[0].Properties.PrivateIPAddress)
+		}
+		u = resp.NextLink
+	}
+	return addrs, nil
+}
@@ -0,0 +1,73 @@
+package autojoin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAzureProvider_Addrs(t *testing.T) {
+	var ts *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/tenant/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if r.Form.Get("client_id") != "client" || r.Form.Get("client_secret") != "secret" {
+			t.Fatalf("bad request: %v", r.Form)
+		}
+		w.Write([]byte(`{"access_token": "token"}`))
+	})
+	mux.HandleFunc("/management/subscriptions/sub/providers/Microsoft.Network/networkInterfaces", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Fatalf("bad authorization: %q", r.Header.Get("Authorization"))
+		}
+
+		// Return the network interfaces over two pages
+		if r.URL.Query().Get("page") == "" {
+			w.Write([]byte(`{
+  "value": [
+    {"name": "a", "tags": {"role": "nomad"}, "properties": {"ipConfigurations": [{"properties": {"privateIPAddress": "10.0.0.1"}}]}},
+    {"name": "b", "tags": {"role": "other"}, "properties": {"ipConfigurations": [{"properties": {"privateIPAddress": "10.0.0.2"}}]}}
+  ],
+  "nextLink": "` + ts.URL + `/management/subscriptions/sub/providers/Microsoft.Network/networkInterfaces?page=2"
+}`))
+			return
+		}
+		w.Write([]byte(`{
+  "value": [
+    {"name": "c", "tags": {"role": "nomad"}, "properties": {"ipConfigurations": [{"properties": {"privateIPAddress": "10.0.0.3"}}]}}
+  ]
+}`))
+	})
+	ts = httptest.NewServer(mux)
+	defer ts.Close()
+
+	p := &AzureProvider{
+		LoginURL:      ts.URL + "/login/",
+		ManagementURL: ts.URL + "/management/",
+	}
+	args := map[string]string{
+		"tenant_id":         "tenant",
+		"client_id":         "client",
+		"subscription_id":   "sub",
+		"secret_access_key": "secret",
+		"tag_name":          "role",
+		"tag_value":         "nomad",
+	}
+	addrs, err := p.Addrs(args, testLogger())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if expected := []string{"10.0.0.1", "10.0.0.3"}; !reflect.DeepEqual(addrs, expected) {
+		t.Fatalf("bad: %#v", addrs)
+	}
+
+	delete(args, "tenant_id")
+	_, err = p.Addrs(args, testLogger())
+	if err == nil || !strings.Contains(err.Error(), "tenant_id") {
+		t.Fatalf("err: %v", err)
+	}
+}
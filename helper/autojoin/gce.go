@@ -0,0 +1,190 @@
+package autojoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// gceMetadataURL is the URL of the GCE metadata server.
+	gceMetadataURL = "http://metadata.google.internal/computeMetadata/v1/"
+
+	// gceComputeURL is the URL of the GCE compute API.
+	gceComputeURL = "https://www.googleapis.com/compute/v1/"
+)
+
+// GCEProvider discovers the running Google Compute Engine instances having a
+// network tag. The API is authenticated with the service account of the
+// instance. It supports the following arguments:
+//
+//	project_name: The name of the project. Defaults to the project of the
+//	              instance.
+//	zone_pattern: A regular expression the zone of the instances must match.
+//	              Defaults to all the zones.
+//	tag_value:    The network tag to filter on.
+type GCEProvider struct {
+	// MetadataURL overrides the URL of the metadata server.
+	MetadataURL string
+
+	// ComputeURL overrides the URL of the compute API.
+	ComputeURL string
+}
+
+// gceInstances is the part of the aggregated instance list response of the
+// compute API used to discover the addresses.
+type gceInstances struct {
+	Items map[string]struct {
+		Instances []struct {
+			Name   string
+			Status string
+			Tags   struct {
+				Items []string
+			}
+			NetworkInterfaces []struct {
+				NetworkIP string
+			}
+		}
+	}
+	NextPageToken string
+}
+
+// Addrs returns the private addresses of the matching GCE instances.
+func (p *GCEProvider) Addrs(args map[string]string, logger *log.Logger) ([]string, error) {
+	tag := args["tag_value"]
+	if tag == "" {
+		return nil, fmt.Errorf("gce: tag_value is required")
+	}
+
+	var zonePattern *regexp.Regexp
+	if pattern := args["zone_pattern"]; pattern != "" {
+		var err error
+		zonePattern, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("gce: invalid zone_pattern: %v", err)
+		}
+	}
+
+	metadataURL, computeURL := p.MetadataURL, p.ComputeURL
+	if metadataURL == "" {
+		metadataURL = gceMetadataURL
+	}
+	if computeURL == "" {
+		computeURL = gceComputeURL
+	}
+
+	project := args["project_name"]
+	if project == "" {
+		id, err := gceMetadata(metadataURL + "project/project-id")
+		if err != nil {
+			return nil, fmt.Errorf("gce: failed to get the project of the instance: %v", err)
+		}
+		project = string(id)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	body, err := gceMetadata(metadataURL + "instance/service-accounts/default/token")
+	if err != nil {
+		return nil, fmt.Errorf("gce: failed to get an access token: %v", err)
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("gce: failed to decode the access token: %v", err)
+	}
+
+	var addrs []string
+	pageToken := ""
+	for {
+		u := computeURL + "projects/" + url.QueryEscape(project) + "/aggregated/instances"
+		if pageToken != "" {
+			u += "?pageToken=" + url.QueryEscape(pageToken)
+		}
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+		var resp gceInstances
+		if err := doJSON(req, &resp); err != nil {
+			return nil, fmt.Errorf("gce: failed to list instances: %v", err)
+		}
+
+		for scope, list := range resp.Items {
+			zone := strings.TrimPrefix(scope, "zones/")
+			if zonePattern != nil && !zonePattern.MatchString(zone) {
+				continue
+			}
+			for _, instance := range list.Instances {
+				if instance.Status != "RUNNING" || !containsString(instance.Tags.Items, tag) {
+					continue
+				}
+				if len(instance.NetworkInterfaces) == 0 || instance.NetworkInterfaces[0].NetworkIP == "" {
+					logger.Printf("[DEBUG] autojoin.gce: instance %s has no private address", instance.Name)
+					continue
+				}
+				addrs = append(addrs, instance.NetworkInterfaces[0].NetworkIP)
+			}
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	return addrs, nil
+}
+
+// gceMetadata returns the value of the metadata server at the given URL.
+func gceMetadata(u string) ([]byte, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// doJSON sends the request and decodes the JSON response into out.
+func doJSON(req *http.Request, out interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
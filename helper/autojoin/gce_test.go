@@ -0,0 +1,68 @@
+package autojoin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGCEProvider_Addrs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metadata/project/project-id", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			t.Fatalf("missing metadata header")
+		}
+		w.Write([]byte("my-project"))
+	})
+	mux.HandleFunc("/metadata/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token": "secret", "token_type": "Bearer"}`))
+	})
+	mux.HandleFunc("/compute/projects/my-project/aggregated/instances", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Fatalf("bad authorization: %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{
+  "items": {
+    "zones/us-central1-a": {
+      "instances": [
+        {"name": "a", "status": "RUNNING", "tags": {"items": ["nomad"]}, "networkInterfaces": [{"networkIP": "10.0.0.1"}]},
+        {"name": "b", "status": "TERMINATED", "tags": {"items": ["nomad"]}, "networkInterfaces": [{"networkIP": "10.0.0.2"}]},
+        {"name": "c", "status": "RUNNING", "tags": {"items": ["other"]}, "networkInterfaces": [{"networkIP": "10.0.0.3"}]}
+      ]
+    },
+    "zones/europe-west1-b": {
+      "instances": [
+        {"name": "d", "status": "RUNNING", "tags": {"items": ["nomad"]}, "networkInterfaces": [{"networkIP": "10.0.0.4"}]}
+      ]
+    }
+  }
+}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	p := &GCEProvider{
+		MetadataURL: ts.URL + "/metadata/",
+		ComputeURL:  ts.URL + "/compute/",
+	}
+	addrs, err := p.Addrs(map[string]string{"tag_value": "nomad"}, testLogger())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sort.Strings(addrs)
+	if expected := []string{"10.0.0.1", "10.0.0.4"}; !reflect.DeepEqual(addrs, expected) {
+		t.Fatalf("bad: %#v", addrs)
+	}
+
+	// Filter on the zone
+	args := map[string]string{"tag_value": "nomad", "zone_pattern": "us-central1-.*"}
+	addrs, err = p.Addrs(args, testLogger())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if expected := []string{"10.0.0.1"}; !reflect.DeepEqual(addrs, expected) {
+		t.Fatalf("bad: %#v", addrs)
+	}
+}
@@ -3,32 +3,96 @@ package flatmap
 import (
 	"fmt"
 	"reflect"
+	"time"
 )
 
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Options controls how an object is flattened.
+type Options struct {
+	// Filter is the set of flattened keys that are removed from the result.
+	Filter []string
+
+	// IgnoreFields is the set of struct field names that are skipped at any
+	// depth, such as server managed fields like ModifyIndex.
+	IgnoreFields []string
+
+	// PrimitiveOnly restricts the result to the primitive fields of the
+	// top-level struct. Nested structs, slices and interfaces are skipped.
+	PrimitiveOnly bool
+
+	// PrimitiveMaps restricts maps to their entries with primitive values,
+	// including primitives held by interfaces. Entries holding maps, slices,
+	// structs or pointers are skipped.
+	PrimitiveMaps bool
+}
+
 // Flatten takes an object and returns a flat map of the object. The keys of the
 // map is the path of the field names until a primitive field is reached and the
 // value is a string representation of the terminal field.
 func Flatten(obj interface{}, filter []string, primitiveOnly bool) map[string]string {
-	flat := make(map[string]string)
+	return FlattenWithOptions(obj, &Options{
+		Filter:        filter,
+		PrimitiveOnly: primitiveOnly,
+	})
+}
+
+// FlattenWithOptions is like Flatten but allows further control over which
+// fields are included in the flat map.
+func FlattenWithOptions(obj interface{}, opts *Options) map[string]string {
 	v := reflect.ValueOf(obj)
 	if !v.IsValid() {
 		return nil
 	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	f := &flattener{
+		opts:   opts,
+		ignore: make(map[string]struct{}, len(opts.IgnoreFields)),
+		output: make(map[string]string),
+	}
+	for _, field := range opts.IgnoreFields {
+		f.ignore[field] = struct{}{}
+	}
 
-	flatten("", v, primitiveOnly, false, flat)
-	for _, f := range filter {
-		if _, ok := flat[f]; ok {
-			delete(flat, f)
+	f.flatten("", v, false)
+	for _, k := range opts.Filter {
+		if _, ok := f.output[k]; ok {
+			delete(f.output, k)
 		}
 	}
-	return flat
+	return f.output
+}
+
+// flattener holds the state of flattening a single object.
+type flattener struct {
+	opts   *Options
+	ignore map[string]struct{}
+	output map[string]string
 }
 
 // flatten recursively calls itself to create a flatmap representation of the
 // passed value. The results are stored into the output map and the keys are
 // the fields prepended with the passed prefix.
-// XXX: A current restriction is that maps only support string keys.
-func flatten(prefix string, v reflect.Value, primitiveOnly, enteredStruct bool, output map[string]string) {
+// Durations and times are formatted using their String and RFC3339 forms.
+func (f *flattener) flatten(prefix string, v reflect.Value, enteredStruct bool) {
+	primitiveOnly := f.opts.PrimitiveOnly
+	output := f.output
+
+	switch {
+	case v.IsValid() && v.Type() == durationType:
+		output[prefix] = time.Duration(v.Int()).String()
+		return
+	case v.IsValid() && v.Type() == timeType && v.CanInterface():
+		output[prefix] = v.Interface().(time.Time).Format(time.RFC3339Nano)
+		return
+	}
+
 	switch v.Kind() {
 	case reflect.Bool:
 		output[prefix] = fmt.Sprintf("%v", v.Bool())
@@ -53,18 +117,19 @@ func flatten(prefix string, v reflect.Value, primitiveOnly, enteredStruct bool,
 		if !e.IsValid() {
 			output[prefix] = "nil"
 		}
-		flatten(prefix, e, primitiveOnly, enteredStruct, output)
+		f.flatten(prefix, e, enteredStruct)
 	case reflect.Map:
 		for _, k := range v.MapKeys() {
-			if k.Kind() == reflect.Interface {
-				k = k.Elem()
-			}
-
-			if k.Kind() != reflect.String {
-				panic(fmt.Sprintf("%q: map key is not string: %s", prefix, k))
+			val := v.MapIndex(k)
+			if f.opts.PrimitiveMaps {
+				if !isPrimitive(val) {
+					continue
+				}
+				if val.Kind() == reflect.Interface {
+					val = val.Elem()
+				}
 			}
-
-			flatten(getSubKeyPrefix(prefix, k.String()), v.MapIndex(k), primitiveOnly, enteredStruct, output)
+			f.flatten(getSubKeyPrefix(prefix, mapKey(prefix, k)), val, enteredStruct)
 		}
 	case reflect.Struct:
 		if primitiveOnly && enteredStruct {
@@ -75,12 +140,16 @@ func flatten(prefix string, v reflect.Value, primitiveOnly, enteredStruct bool,
 		t := v.Type()
 		for i := 0; i < v.NumField(); i++ {
 			name := t.Field(i).Name
+			if _, ok := f.ignore[name]; ok {
+				continue
+			}
+
 			val := v.Field(i)
 			if val.Kind() == reflect.Interface && !val.IsNil() {
 				val = val.Elem()
 			}
 
-			flatten(getSubPrefix(prefix, name), val, primitiveOnly, enteredStruct, output)
+			f.flatten(getSubPrefix(prefix, name), val, enteredStruct)
 		}
 	case reflect.Interface:
 		if primitiveOnly {
@@ -92,7 +161,7 @@ func flatten(prefix string, v reflect.Value, primitiveOnly, enteredStruct bool,
 			output[prefix] = "nil"
 			return
 		}
-		flatten(prefix, e, primitiveOnly, enteredStruct, output)
+		f.flatten(prefix, e, enteredStruct)
 	case reflect.Array, reflect.Slice:
 		if primitiveOnly {
 			return
@@ -103,13 +172,58 @@ func flatten(prefix string, v reflect.Value, primitiveOnly, enteredStruct bool,
 			return
 		}
 		for i := 0; i < v.Len(); i++ {
-			flatten(fmt.Sprintf("%s[%d]", prefix, i), v.Index(i), primitiveOnly, enteredStruct, output)
+			f.flatten(fmt.Sprintf("%s[%d]", prefix, i), v.Index(i), enteredStruct)
 		}
 	default:
 		panic(fmt.Sprintf("prefix %q; unsupported type %v", prefix, v.Kind()))
 	}
 }
 
+// mapKey returns the string representation of a map key. Keys must either be
+// strings, implement fmt.Stringer or be of a primitive kind.
+func mapKey(prefix string, k reflect.Value) string {
+	if k.Kind() == reflect.Interface {
+		k = k.Elem()
+	}
+
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if k.CanInterface() {
+		if s, ok := k.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+
+	switch k.Kind() {
+	case reflect.Bool:
+		return fmt.Sprintf("%v", k.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%v", k.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprintf("%v", k.Uint())
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%v", k.Float())
+	default:
+		panic(fmt.Sprintf("%q: unsupported map key type %v", prefix, k.Type()))
+	}
+}
+
+// isPrimitive returns whether the value, after unwrapping an interface, is of a
+// primitive kind.
+func isPrimitive(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map, reflect.Array, reflect.Slice, reflect.Struct, reflect.Ptr, reflect.Interface:
+		return false
+	default:
+		return true
+	}
+}
+
 // getSubPrefix takes the current prefix and the next subfield and returns an
 // appropriate prefix.
 func getSubPrefix(curPrefix, subField string) string {
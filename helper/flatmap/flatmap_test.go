@@ -1,8 +1,10 @@
 package flatmap
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type simpleTypes struct {
@@ -230,3 +232,132 @@ func TestFlatMap(t *testing.T) {
 		}
 	}
 }
+
+type mapHolder struct {
+	id    string
+	attrs map[string]interface{}
+}
+
+func TestFlatMap_Options(t *testing.T) {
+	cases := []struct {
+		Input    interface{}
+		Options  *Options
+		Expected map[string]string
+	}{
+		{
+			Input: &linkedList{
+				value: "foo",
+				next: &linkedList{
+					value: "bar",
+					next:  nil,
+				},
+			},
+			Options: &Options{
+				IgnoreFields: []string{"value"},
+			},
+			Expected: map[string]string{
+				"next.next": "nil",
+			},
+		},
+		{
+			Input: &mapHolder{
+				id: "foo",
+				attrs: map[string]interface{}{
+					"a": "b",
+					"c": 1,
+					"d": []string{"e"},
+					"f": map[string]string{"g": "h"},
+				},
+			},
+			Options: &Options{
+				Filter:        []string{"id"},
+				PrimitiveOnly: true,
+				PrimitiveMaps: true,
+			},
+			Expected: map[string]string{
+				"attrs[a]": "b",
+				"attrs[c]": "1",
+			},
+		},
+		{
+			Input: &mapHolder{
+				id: "foo",
+				attrs: map[string]interface{}{
+					"a": "b",
+					"f": map[string]string{"g": "h"},
+				},
+			},
+			Options: &Options{
+				IgnoreFields: []string{"id"},
+			},
+			Expected: map[string]string{
+				"attrs[a]":    "b",
+				"attrs[f][g]": "h",
+			},
+		},
+	}
+
+	for i, c := range cases {
+		act := FlattenWithOptions(c.Input, c.Options)
+		if !reflect.DeepEqual(act, c.Expected) {
+			t.Fatalf("case %d: got %#v; want %#v", i+1, act, c.Expected)
+		}
+	}
+}
+
+type stringerKey struct {
+	a, b string
+}
+
+func (s stringerKey) String() string {
+	return fmt.Sprintf("%s-%s", s.a, s.b)
+}
+
+type keyedMaps struct {
+	Ints      map[int]string
+	Stringers map[stringerKey]int
+}
+
+type timeHolder struct {
+	Created time.Time
+	Timeout time.Duration
+}
+
+func TestFlatMap_KeysAndTimes(t *testing.T) {
+	created := time.Date(2016, 10, 1, 12, 30, 0, 0, time.UTC)
+	cases := []struct {
+		Input         interface{}
+		PrimitiveOnly bool
+		Expected      map[string]string
+	}{
+		{
+			Input: &keyedMaps{
+				Ints:      map[int]string{1: "a", -2: "b"},
+				Stringers: map[stringerKey]int{stringerKey{"x", "y"}: 3},
+			},
+			Expected: map[string]string{
+				"Ints[1]":        "a",
+				"Ints[-2]":       "b",
+				"Stringers[x-y]": "3",
+			},
+		},
+		{
+			Input: &timeHolder{
+				Created: created,
+				Timeout: 90 * time.Second,
+			},
+			PrimitiveOnly: true,
+			Expected: map[string]string{
+				"Created": "2016-10-01T12:30:00Z",
+				"Timeout": "1m30s",
+			},
+		},
+	}
+
+	for i, c := range cases {
+		act := Flatten(c.Input, nil, c.PrimitiveOnly)
+		if !reflect.DeepEqual(act, c.Expected) {
+			t.Fatalf("case %d: got %#v; want %#v", i+1, act, c.Expected)
+		}
+	}
+}
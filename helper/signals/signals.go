@@ -0,0 +1,19 @@
+// Package signals maps signal names, such as those configured in job files,
+// to the signals of the platform.
+package signals
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Parse returns the signal of the platform with the given name, such as
+// "SIGHUP". The name is case insensitive.
+func Parse(name string) (os.Signal, error) {
+	sig, ok := SignalLookup[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown signal %q", name)
+	}
+	return sig, nil
+}
@@ -0,0 +1,20 @@
+package signals
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	sig, err := Parse("sighup")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sig != syscall.SIGHUP {
+		t.Fatalf("got %v; want %v", sig, syscall.SIGHUP)
+	}
+
+	if _, err := Parse("SIGFOO"); err == nil {
+		t.Fatalf("expected an error for an unknown signal")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package signals
+
+import (
+	"os"
+	"syscall"
+)
+
+// SignalLookup maps the names of the signals of the platform to the signals.
+var SignalLookup = map[string]os.Signal{
+	"SIGABRT": syscall.SIGABRT,
+	"SIGALRM": syscall.SIGALRM,
+	"SIGBUS":  syscall.SIGBUS,
+	"SIGFPE":  syscall.SIGFPE,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGILL":  syscall.SIGILL,
+	"SIGINT":  syscall.SIGINT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGPIPE": syscall.SIGPIPE,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGSEGV": syscall.SIGSEGV,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGTRAP": syscall.SIGTRAP,
+}
@@ -8,6 +8,15 @@ import (
 	"github.com/shirou/gopsutil/cpu"
 )
 
+const (
+	// CPUPeriod is the CFS period, in microseconds, over which the quota of
+	// the tasks with a hard CPU limit is enforced
+	CPUPeriod = 100000
+
+	// minCPUQuota is the smallest CFS quota accepted by the kernel
+	minCPUQuota = 1000
+)
+
 var (
 	cpuMhzPerCore float64
 	cpuModelName  string
@@ -65,3 +74,20 @@ func CPUModelName() string {
 func TotalTicksAvailable() float64 {
 	return cpuTotalTicks
 }
+
+// CPUQuota returns the CFS quota, in microseconds per CPUPeriod, which bounds
+// the CPU usage of a task to the given MHz.
+func CPUQuota(mhz int) (int64, error) {
+	if err := Init(); err != nil {
+		return 0, err
+	}
+	if cpuMhzPerCore == 0 {
+		return 0, fmt.Errorf("Unable to determine the CPU frequency")
+	}
+
+	quota := int64(float64(mhz) / cpuMhzPerCore * CPUPeriod)
+	if quota < minCPUQuota {
+		quota = minCPUQuota
+	}
+	return quota, nil
+}
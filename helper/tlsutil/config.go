@@ -0,0 +1,273 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+// RegionWrapper is a function that is used to wrap a non-TLS connection and
+// returns an appropriate TLS connection or error. This takes a region as an
+// argument.
+type RegionWrapper func(region string, conn net.Conn) (net.Conn, error)
+
+// Wrapper is a variant of RegionWrapper, where the region is provided as a
+// constant value. This is usually done by currying RegionWrapper.
+type Wrapper func(conn net.Conn) (net.Conn, error)
+
+// Config used to create tls.Config
+type Config struct {
+	// VerifyIncoming is used to verify the authenticity of incoming
+	// connections. Connections must present a certificate signed by the
+	// certificate authority.
+	VerifyIncoming bool
+
+	// VerifyOutgoing is used to verify the authenticity of outgoing
+	// connections. The remote end must present a certificate signed by the
+	// certificate authority.
+	VerifyOutgoing bool
+
+	// VerifyServerHostname is used to enable hostname verification of
+	// servers. This ensures that the certificate presented is valid for
+	// server.<region>.nomad.
+	VerifyServerHostname bool
+
+	// CAFile is a path to a certificate authority file. This is used with
+	// VerifyIncoming or VerifyOutgoing to verify the TLS connection.
+	CAFile string
+
+	// CertFile is used to provide a TLS certificate that is used for serving
+	// TLS connections. Must be provided to serve TLS connections.
+	CertFile string
+
+	// KeyFile is used to provide a TLS key that is used for serving TLS
+	// connections. Must be provided to serve TLS connections.
+	KeyFile string
+
+	// KeyLoader serves the key pair loaded from CertFile and KeyFile so that
+	// it can be reloaded. A private loader is used if not provided.
+	KeyLoader *config.KeyLoader
+}
+
+// NewTLSConfiguration returns the mutual TLS configuration of the RPC
+// connections for the given agent TLS configuration.
+func NewTLSConfiguration(newConf *config.TLSConfig) *Config {
+	return &Config{
+		VerifyIncoming:       true,
+		VerifyOutgoing:       true,
+		VerifyServerHostname: newConf.VerifyServerHostname,
+		CAFile:               newConf.CAFile,
+		CertFile:             newConf.CertFile,
+		KeyFile:              newConf.KeyFile,
+		KeyLoader:            newConf.GetKeyLoader(),
+	}
+}
+
+// AppendCA opens and parses the CA file and adds the certificates to
+// the provided CertPool.
+func (c *Config) AppendCA(pool *x509.CertPool) error {
+	if c.CAFile == "" {
+		return nil
+	}
+
+	// Read the file
+	data, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return fmt.Errorf("Failed to read CA file: %v", err)
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("Failed to parse any CA certificates")
+	}
+
+	return nil
+}
+
+// LoadKeyPair is used to load the certificate and key file into the key
+// loader. It returns a nil loader if no key pair is configured.
+func (c *Config) LoadKeyPair() (*config.KeyLoader, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, nil
+	}
+	if c.KeyLoader == nil {
+		c.KeyLoader = new(config.KeyLoader)
+	}
+	if _, err := c.KeyLoader.LoadKeyPair(c.CertFile, c.KeyFile); err != nil {
+		return nil, err
+	}
+	return c.KeyLoader, nil
+}
+
+// OutgoingTLSConfig generates a TLS configuration for outgoing
+// requests. It will return a nil config if this configuration should
+// not use TLS for outgoing connections.
+func (c *Config) OutgoingTLSConfig() (*tls.Config, error) {
+	// If VerifyServerHostname is true, that implies VerifyOutgoing
+	if c.VerifyServerHostname {
+		c.VerifyOutgoing = true
+	}
+	if !c.VerifyOutgoing {
+		return nil, nil
+	}
+	// Create the tlsConfig
+	tlsConfig := &tls.Config{
+		RootCAs:            x509.NewCertPool(),
+		InsecureSkipVerify: true,
+	}
+	if c.VerifyServerHostname {
+		// ServerName is filled in dynamically based on the target region
+		tlsConfig.ServerName = "VerifyServerHostname"
+		tlsConfig.InsecureSkipVerify = false
+	}
+
+	// Ensure we have a CA if VerifyOutgoing is set
+	if c.VerifyOutgoing && c.CAFile == "" {
+		return nil, fmt.Errorf("VerifyOutgoing set, and no CA certificate provided!")
+	}
+
+	// Parse the CA cert if any
+	err := c.AppendCA(tlsConfig.RootCAs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add cert/key
+	loader, err := c.LoadKeyPair()
+	if err != nil {
+		return nil, err
+	} else if loader != nil {
+		tlsConfig.GetClientCertificate = loader.GetClientCertificate
+	}
+
+	return tlsConfig, nil
+}
+
+// OutgoingTLSWrapper returns a a RegionWrapper based on the OutgoingTLS
+// configuration. If hostname verification is on, the wrapper
+// will properly generate the dynamic server name for verification.
+func (c *Config) OutgoingTLSWrapper() (RegionWrapper, error) {
+	// Get the TLS config
+	tlsConfig, err := c.OutgoingTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if TLS is not enabled
+	if tlsConfig == nil {
+		return nil, nil
+	}
+
+	// Generate the wrapper based on hostname verification
+	if c.VerifyServerHostname {
+		wrapper := func(region string, conn net.Conn) (net.Conn, error) {
+			conf := tlsConfig.Clone()
+			conf.ServerName = "server." + region + ".nomad"
+			return WrapTLSClient(conn, conf)
+		}
+		return wrapper, nil
+	}
+	wrapper := func(region string, c net.Conn) (net.Conn, error) {
+		return WrapTLSClient(c, tlsConfig)
+	}
+	return wrapper, nil
+}
+
+// SpecificRegion is used to invoke a static region and turns a RegionWrapper
+// into a Wrapper type.
+func SpecificRegion(region string, tlsWrap RegionWrapper) Wrapper {
+	if tlsWrap == nil {
+		return nil
+	}
+	return func(conn net.Conn) (net.Conn, error) {
+		return tlsWrap(region, conn)
+	}
+}
+
+// WrapTLSClient wraps a net.Conn into a client tls connection, performing
+// any additional verification as needed.
+//
+// crypto/tls only supports either doing no certificate verification, or
+// doing full verification including of the peer's DNS name. Unless server
+// hostnames are verified, we want to validate that the certificate is signed
+// by a known CA without verifying the certificate DNS names, so it is done
+// manually.
+func WrapTLSClient(conn net.Conn, tlsConfig *tls.Config) (net.Conn, error) {
+	var err error
+	var tlsConn *tls.Conn
+
+	tlsConn = tls.Client(conn, tlsConfig)
+
+	// If crypto/tls is doing verification, there's no need to do
+	// our own.
+	if tlsConfig.InsecureSkipVerify == false {
+		return tlsConn, nil
+	}
+
+	if err = tlsConn.Handshake(); err != nil {
+		tlsConn.Close()
+		return nil, err
+	}
+
+	// The following is lightly-modified from the doFullHandshake
+	// method in crypto/tls's handshake_client.go.
+	opts := x509.VerifyOptions{
+		Roots:         tlsConfig.RootCAs,
+		DNSName:       "",
+		Intermediates: x509.NewCertPool(),
+	}
+
+	certs := tlsConn.ConnectionState().PeerCertificates
+	for i, cert := range certs {
+		if i == 0 {
+			continue
+		}
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err = certs[0].Verify(opts)
+	if err != nil {
+		tlsConn.Close()
+		return nil, err
+	}
+
+	return tlsConn, err
+}
+
+// IncomingTLSConfig generates a TLS configuration for incoming requests
+func (c *Config) IncomingTLSConfig() (*tls.Config, error) {
+	// Create the tlsConfig
+	tlsConfig := &tls.Config{
+		ClientCAs:  x509.NewCertPool(),
+		ClientAuth: tls.NoClientCert,
+	}
+
+	// Parse the CA cert if any
+	err := c.AppendCA(tlsConfig.ClientCAs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add cert/key
+	loader, err := c.LoadKeyPair()
+	if err != nil {
+		return nil, err
+	} else if loader != nil {
+		tlsConfig.GetCertificate = loader.GetCertificate
+	}
+
+	// Check if we require verification
+	if c.VerifyIncoming {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		if c.CAFile == "" {
+			return nil, fmt.Errorf("VerifyIncoming set, and no CA certificate provided!")
+		}
+		if loader == nil {
+			return nil, fmt.Errorf("VerifyIncoming set, and no Cert/Key pair provided!")
+		}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,444 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+const (
+	// The certificates are signed by the CA and valid for localhost and
+	// server.global.nomad or client.global.nomad.
+	cacert  = "./test-fixtures/ca.pem"
+	foocert = "./test-fixtures/server.pem"
+	fookey  = "./test-fixtures/server-key.pem"
+	barcert = "./test-fixtures/client.pem"
+	barkey  = "./test-fixtures/client-key.pem"
+	bazcert = "./test-fixtures/server2.pem"
+	bazkey  = "./test-fixtures/server2-key.pem"
+)
+
+func TestConfig_AppendCA_None(t *testing.T) {
+	conf := &Config{}
+	pool := x509.NewCertPool()
+	err := conf.AppendCA(pool)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(pool.Subjects()) != 0 {
+		t.Fatalf("bad: %v", pool.Subjects())
+	}
+}
+
+func TestConfig_CACertificate_Valid(t *testing.T) {
+	conf := &Config{
+		CAFile: cacert,
+	}
+	pool := x509.NewCertPool()
+	err := conf.AppendCA(pool)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(pool.Subjects()) == 0 {
+		t.Fatalf("expected cert")
+	}
+}
+
+func TestConfig_LoadKeyPair_None(t *testing.T) {
+	conf := &Config{}
+	loader, err := conf.LoadKeyPair()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if loader != nil {
+		t.Fatalf("bad: %v", loader)
+	}
+}
+
+func TestConfig_LoadKeyPair_Valid(t *testing.T) {
+	conf := &Config{
+		CertFile: foocert,
+		KeyFile:  fookey,
+	}
+	loader, err := conf.LoadKeyPair()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if loader == nil {
+		t.Fatalf("expected key loader")
+	}
+	if cert, err := loader.GetCertificate(nil); err != nil || cert == nil {
+		t.Fatalf("expected cert: %v %v", cert, err)
+	}
+}
+
+func TestConfig_LoadKeyPair_Invalid(t *testing.T) {
+	conf := &Config{
+		CertFile: foocert,
+		KeyFile:  barkey,
+	}
+	if _, err := conf.LoadKeyPair(); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestConfig_OutgoingTLS_MissingCA(t *testing.T) {
+	conf := &Config{
+		VerifyOutgoing: true,
+	}
+	tls, err := conf.OutgoingTLSConfig()
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+	if tls != nil {
+		t.Fatalf("bad: %v", tls)
+	}
+}
+
+func TestConfig_OutgoingTLS_OnlyCA(t *testing.T) {
+	conf := &Config{
+		CAFile: cacert,
+	}
+	tls, err := conf.OutgoingTLSConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if tls != nil {
+		t.Fatalf("expected no config")
+	}
+}
+
+func TestConfig_OutgoingTLS_VerifyOutgoing(t *testing.T) {
+	conf := &Config{
+		VerifyOutgoing: true,
+		CAFile:         cacert,
+	}
+	tls, err := conf.OutgoingTLSConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if tls == nil {
+		t.Fatalf("expected config")
+	}
+	if len(tls.RootCAs.Subjects()) != 1 {
+		t.Fatalf("expect root cert")
+	}
+	if !tls.InsecureSkipVerify {
+		t.Fatalf("should skip built-in verification")
+	}
+}
+
+func TestConfig_OutgoingTLS_VerifyHostname(t *testing.T) {
+	conf := &Config{
+		VerifyServerHostname: true,
+		CAFile:               cacert,
+	}
+	tls, err := conf.OutgoingTLSConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if tls == nil {
+		t.Fatalf("expected config")
+	}
+	if len(tls.RootCAs.Subjects()) != 1 {
+		t.Fatalf("expect root cert")
+	}
+	if tls.InsecureSkipVerify {
+		t.Fatalf("should not skip built-in verification")
+	}
+}
+
+func TestConfig_OutgoingTLS_WithKeyPair(t *testing.T) {
+	conf := &Config{
+		VerifyOutgoing: true,
+		CAFile:         cacert,
+		CertFile:       foocert,
+		KeyFile:        fookey,
+	}
+	tls, err := conf.OutgoingTLSConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if tls == nil {
+		t.Fatalf("expected config")
+	}
+	if tls.GetClientCertificate == nil {
+		t.Fatalf("expected client certificate")
+	}
+	cert, err := tls.GetClientCertificate(nil)
+	if err != nil || cert == nil || len(cert.Certificate) == 0 {
+		t.Fatalf("expected client cert: %v %v", cert, err)
+	}
+}
+
+func startTLSServer(config *Config) (net.Conn, chan error) {
+	errc := make(chan error, 1)
+
+	tlsConfigServer, err := config.IncomingTLSConfig()
+	if err != nil {
+		errc <- err
+		return nil, errc
+	}
+
+	client, server := net.Pipe()
+
+	go func() {
+		tlsServer := tls.Server(server, tlsConfigServer)
+		if err := tlsServer.Handshake(); err != nil {
+			errc <- err
+		}
+		close(errc)
+
+		// Because net.Pipe() is unbuffered, if both sides
+		// Close() simultaneously, we will deadlock as they
+		// both send an alert and then block. So we make the
+		// server read any data from the client until error or
+		// EOF, which will allow the client to Close(), and
+		// *then* we Close() the server.
+		io.Copy(ioutil.Discard, tlsServer)
+		tlsServer.Close()
+	}()
+	return client, errc
+}
+
+func TestConfig_outgoingWrapper_OK(t *testing.T) {
+	config := &Config{
+		CAFile:               cacert,
+		CertFile:             foocert,
+		KeyFile:              fookey,
+		VerifyServerHostname: true,
+	}
+
+	client, errc := startTLSServer(config)
+	if client == nil {
+		t.Fatalf("startTLSServer err: %v", <-errc)
+	}
+
+	wrap, err := config.OutgoingTLSWrapper()
+	if err != nil {
+		t.Fatalf("OutgoingTLSWrapper err: %v", err)
+	}
+
+	tlsClient, err := wrap("global", client)
+	if err != nil {
+		t.Fatalf("wrapTLS err: %v", err)
+	}
+	defer tlsClient.Close()
+	if err := tlsClient.(*tls.Conn).Handshake(); err != nil {
+		t.Fatalf("write err: %v", err)
+	}
+
+	err = <-errc
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+func TestConfig_outgoingWrapper_BadCert(t *testing.T) {
+	// The server certificate is not valid for the region
+	config := &Config{
+		CAFile:               cacert,
+		CertFile:             foocert,
+		KeyFile:              fookey,
+		VerifyServerHostname: true,
+	}
+
+	client, errc := startTLSServer(config)
+	if client == nil {
+		t.Fatalf("startTLSServer err: %v", <-errc)
+	}
+
+	wrap, err := config.OutgoingTLSWrapper()
+	if err != nil {
+		t.Fatalf("OutgoingTLSWrapper err: %v", err)
+	}
+
+	tlsClient, err := wrap("other", client)
+	if err != nil {
+		t.Fatalf("wrapTLS err: %v", err)
+	}
+	defer tlsClient.Close()
+	if err := tlsClient.(*tls.Conn).Handshake(); err == nil {
+		t.Fatalf("expected hostname err")
+	}
+
+	<-errc
+}
+
+func TestConfig_wrapTLS_OK(t *testing.T) {
+	config := &Config{
+		CAFile:         cacert,
+		CertFile:       foocert,
+		KeyFile:        fookey,
+		VerifyOutgoing: true,
+	}
+
+	client, errc := startTLSServer(config)
+	if client == nil {
+		t.Fatalf("startTLSServer err: %v", <-errc)
+	}
+
+	clientConfig, err := config.OutgoingTLSConfig()
+	if err != nil {
+		t.Fatalf("OutgoingTLSConfig err: %v", err)
+	}
+
+	tlsClient, err := WrapTLSClient(client, clientConfig)
+	if err != nil {
+		t.Fatalf("wrapTLS err: %v", err)
+	} else {
+		tlsClient.Close()
+	}
+	err = <-errc
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+func TestConfig_wrapTLS_BadCert(t *testing.T) {
+	serverConfig := &Config{
+		CertFile: barcert,
+		KeyFile:  barkey,
+	}
+
+	client, errc := startTLSServer(serverConfig)
+	if client == nil {
+		t.Fatalf("startTLSServer err: %v", <-errc)
+	}
+
+	// The client trusts no certificate authority
+	clientTLSConfig := &tls.Config{
+		RootCAs:            x509.NewCertPool(),
+		InsecureSkipVerify: true,
+	}
+
+	tlsClient, err := WrapTLSClient(client, clientTLSConfig)
+	if err == nil {
+		t.Fatalf("wrapTLS no err")
+	}
+	if tlsClient != nil {
+		t.Fatalf("returned a client")
+	}
+
+	err = <-errc
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+func TestConfig_IncomingTLS(t *testing.T) {
+	conf := &Config{
+		VerifyIncoming: true,
+		CAFile:         cacert,
+		CertFile:       foocert,
+		KeyFile:        fookey,
+	}
+	tlsC, err := conf.IncomingTLSConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if tlsC == nil {
+		t.Fatalf("expected config")
+	}
+	if len(tlsC.ClientCAs.Subjects()) != 1 {
+		t.Fatalf("expect client cert")
+	}
+	if tlsC.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("should not skip verification")
+	}
+	if tlsC.GetCertificate == nil {
+		t.Fatalf("expected server certificate")
+	}
+}
+
+func TestConfig_IncomingTLS_MissingCA(t *testing.T) {
+	conf := &Config{
+		VerifyIncoming: true,
+		CertFile:       foocert,
+		KeyFile:        fookey,
+	}
+	_, err := conf.IncomingTLSConfig()
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestConfig_IncomingTLS_MissingKey(t *testing.T) {
+	conf := &Config{
+		VerifyIncoming: true,
+		CAFile:         cacert,
+	}
+	_, err := conf.IncomingTLSConfig()
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestConfig_IncomingTLS_NoVerify(t *testing.T) {
+	conf := &Config{}
+	tlsC, err := conf.IncomingTLSConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if tlsC == nil {
+		t.Fatalf("expected config")
+	}
+	if len(tlsC.ClientCAs.Subjects()) != 0 {
+		t.Fatalf("do not expect client cert")
+	}
+	if tlsC.ClientAuth != tls.NoClientCert {
+		t.Fatalf("should skip verification")
+	}
+	if tlsC.GetCertificate != nil {
+		t.Fatalf("do not expect certificate")
+	}
+}
+
+func TestConfig_IncomingTLS_Reload(t *testing.T) {
+	tlsConfig := &config.TLSConfig{
+		EnableRPC: true,
+		CAFile:    cacert,
+		CertFile:  foocert,
+		KeyFile:   fookey,
+	}
+	conf := NewTLSConfiguration(tlsConfig)
+	incoming, err := conf.IncomingTLSConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	before, err := incoming.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reloading through the agent configuration updates the served cert
+	if _, err := tlsConfig.GetKeyLoader().LoadKeyPair(bazcert, bazkey); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	after, err := incoming.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if bytes.Equal(before.Certificate[0], after.Certificate[0]) {
+		t.Fatalf("expected the reloaded certificate")
+	}
+
+	// A failed reload keeps the current certificate
+	if _, err := tlsConfig.GetKeyLoader().LoadKeyPair(foocert, bazkey); err == nil {
+		t.Fatalf("expected err")
+	}
+	current, err := incoming.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(after.Certificate[0], current.Certificate[0]) {
+		t.Fatalf("expected the certificate to be kept")
+	}
+}
@@ -0,0 +1,168 @@
+package jobspec
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+)
+
+// reVariable matches a reference to a job file variable, such as
+// ${var.image_tag}, at the start of the input.
+var reVariable = regexp.MustCompile(`^\$\{var\.([a-zA-Z0-9_\-]+)\}`)
+
+// hclEscaper escapes a value for use within an HCL string.
+var hclEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// Interpolate replaces the references to variables of the form ${var.name}
+// in the job spec with their values. Within a string, the value is escaped so
+// that it can't end the string. Outside of a string, numbers and booleans are
+// inserted verbatim, so that a variable may be used as a count, and other
+// values are inserted as strings. Within a heredoc, the value is inserted
+// verbatim. References in comments are left untouched and $${var.name} is
+// replaced by the literal ${var.name}. An error listing the undefined
+// variables is returned if any are referenced.
+func Interpolate(src []byte, vars map[string]string) ([]byte, error) {
+	const (
+		inCode = iota
+		inString
+		inHeredoc
+	)
+
+	var out bytes.Buffer
+	undefined := make(map[string]struct{})
+	context, braces, anchor := inCode, 0, ""
+	for i := 0; i < len(src); {
+		rest := src[i:]
+
+		// Replace the variable references
+		if bytes.HasPrefix(rest, []byte("$$")) && reVariable.Match(rest[1:]) {
+			ref := reVariable.Find(rest[1:])
+			out.Write(ref)
+			i += len(ref) + 1
+			continue
+		}
+		if m := reVariable.FindSubmatch(rest); m != nil {
+			name := string(m[1])
+			value, ok := vars[name]
+			switch {
+			case !ok:
+				undefined[name] = struct{}{}
+				out.Write(m[0])
+			case context == inString:
+				out.WriteString(hclEscaper.Replace(value))
+			case context == inHeredoc || isHCLLiteral(value):
+				out.WriteString(value)
+			default:
+				out.WriteString(`"` + hclEscaper.Replace(value) + `"`)
+			}
+			i += len(m[0])
+			continue
+		}
+
+		n := 1
+		switch context {
+		case inCode:
+			switch {
+			case rest[0] == '#' || bytes.HasPrefix(rest, []byte("//")):
+				// Copy the comment up to the end of the line
+				if n = bytes.IndexByte(rest, '\n'); n == -1 {
+					n = len(rest)
+				}
+			case bytes.HasPrefix(rest, []byte("/*")):
+				if n = bytes.Index(rest, []byte("*/")); n == -1 {
+					n = len(rest)
+				} else {
+					n += 2
+				}
+			case bytes.HasPrefix(rest, []byte("<<")):
+				// Copy the heredoc anchor line
+				if n = bytes.IndexByte(rest, '\n'); n == -1 {
+					n = len(rest)
+				} else {
+					n++
+				}
+				anchor = strings.TrimSpace(string(rest[2:n]))
+				context = inHeredoc
+			case rest[0] == '"':
+				context, braces = inString, 0
+			}
+		case inString:
+			switch {
+			case rest[0] == '\\' && len(rest) > 1:
+				n = 2
+			case rest[0] == '$' && len(rest) > 1 && rest[1] == '{':
+				braces++
+				n = 2
+			case rest[0] == '}' && braces > 0:
+				braces--
+			case rest[0] == '"' && braces == 0:
+				context = inCode
+			}
+		case inHeredoc:
+			// Copy the anchor line ending the heredoc
+			if src[i-1] == '\n' {
+				end := bytes.IndexByte(rest, '\n')
+				if end == -1 {
+					end = len(rest)
+				}
+				if strings.TrimSuffix(string(rest[:end]), "\r") == anchor {
+					n = end
+					context = inCode
+				}
+			}
+		}
+		out.Write(rest[:n])
+		i += n
+	}
+
+	if len(undefined) != 0 {
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("undefined variables: %s", strings.Join(names, ", "))
+	}
+	return out.Bytes(), nil
+}
+
+// isHCLLiteral returns whether the value is a number or a boolean, which can
+// be used as a bare HCL value.
+func isHCLLiteral(value string) bool {
+	if value == "true" || value == "false" {
+		return true
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// ParseVarFile parses the variable assignments of the HCL file at the given
+// path. Values must be strings, numbers or booleans.
+func ParseVarFile(path string) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := hcl.Decode(&raw, string(contents)); err != nil {
+		return nil, fmt.Errorf("error parsing %q: %v", path, err)
+	}
+
+	vars := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch v := value.(type) {
+		case string, int, int64, float64, bool:
+			vars[name] = fmt.Sprintf("%v", v)
+		default:
+			return nil, fmt.Errorf("variable %q in %q must be a string, number or boolean", name, path)
+		}
+	}
+	return vars, nil
+}
@@ -0,0 +1,108 @@
+package jobspec
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl"
+)
+
+func TestInterpolate(t *testing.T) {
+	src := []byte(`count = ${var.count}
+image = "redis:${var.tag}"
+port = "${NOMAD_PORT_db}"`)
+
+	out, err := Interpolate(src, map[string]string{"count": "3", "tag": "3.2"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := `count = 3
+image = "redis:3.2"
+port = "${NOMAD_PORT_db}"`
+	if string(out) != expected {
+		t.Fatalf("got %q; want %q", out, expected)
+	}
+
+	if _, err := Interpolate(src, nil); err == nil || err.Error() != "undefined variables: count, tag" {
+		t.Fatalf("expected undefined variables error, got: %v", err)
+	}
+}
+
+func TestInterpolate_Quoting(t *testing.T) {
+	src := []byte(`# Uses ${var.undefined}
+/* and ${var.undefined} */
+count = ${var.count}
+image = ${var.image}
+args = ["${var.arg}", "$${var.arg}", "${meta.${var.key}}"]
+script = <<EOF
+echo ${var.arg}
+EOF
+// ${var.undefined}`)
+
+	vars := map[string]string{
+		"count": "3",
+		"image": `redis" count = 10 #`,
+		"arg":   `a "quoted\ value` + "\n",
+		"key":   "tag",
+	}
+	out, err := Interpolate(src, vars)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := `# Uses ${var.undefined}
+/* and ${var.undefined} */
+count = 3
+image = "redis\" count = 10 #"
+args = ["a \"quoted\\ value\n", "${var.arg}", "${meta.tag}"]
+script = <<EOF
+echo a "quoted\ value
+
+EOF
+// ${var.undefined}`
+	if string(out) != expected {
+		t.Fatalf("got %q; want %q", out, expected)
+	}
+
+	// The values can't alter the structure of the file
+	var parsed struct {
+		Count  int
+		Image  string
+		Args   []string
+		Script string
+	}
+	if err := hcl.Decode(&parsed, string(out)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if parsed.Count != 3 || parsed.Image != vars["image"] || parsed.Args[0] != vars["arg"] {
+		t.Fatalf("bad: %#v", parsed)
+	}
+}
+
+func TestParseVarFile(t *testing.T) {
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.Remove(fh.Name())
+	if _, err := fh.WriteString("tag = \"3.2\"\ncount = 3\ncanary = true\n"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	vars, err := ParseVarFile(fh.Name())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := map[string]string{"tag": "3.2", "count": "3", "canary": "true"}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Fatalf("got %#v; want %#v", vars, expected)
+	}
+
+	if _, err := fh.WriteString("list = [1, 2]\n"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := ParseVarFile(fh.Name()); err == nil {
+		t.Fatalf("expected error for list variable")
+	}
+}
@@ -101,6 +101,8 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 	delete(m, "meta")
 	delete(m, "update")
 	delete(m, "periodic")
+	delete(m, "failover")
+	delete(m, "parameterized")
 
 	// Set the ID and name to the object key
 	result.ID = obj.Keys[0].Token.Value().(string)
@@ -136,10 +138,14 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		"constraint",
 		"update",
 		"periodic",
+		"failover",
+		"parameterized",
 		"meta",
 		"task",
 		"group",
 		"vault_token",
+		"stop_strategy",
+		"namespace",
 	}
 	if err := checkHCLKeys(listVal, valid); err != nil {
 		return multierror.Prefix(err, "job:")
@@ -166,6 +172,20 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		}
 	}
 
+	// If we have a failover config, then parse that
+	if o := listVal.Filter("failover"); len(o.Items) > 0 {
+		if err := parseFailover(&result.Failover, o); err != nil {
+			return multierror.Prefix(err, "failover ->")
+		}
+	}
+
+	// If we have a parameterized definition, then parse that
+	if o := listVal.Filter("parameterized"); len(o.Items) > 0 {
+		if err := parseParameterizedJob(&result.ParameterizedJob, o); err != nil {
+			return multierror.Prefix(err, "parameterized ->")
+		}
+	}
+
 	// Parse out meta fields. These are in HCL as a list so we need
 	// to iterate over them and merge them.
 	if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -239,9 +259,12 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			"count",
 			"constraint",
 			"restart",
+			"reschedule",
+			"migrate",
 			"meta",
 			"task",
 			"ephemeral_disk",
+			"volume",
 		}
 		if err := checkHCLKeys(listVal, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
@@ -255,7 +278,10 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 		delete(m, "meta")
 		delete(m, "task")
 		delete(m, "restart")
+		delete(m, "reschedule")
+		delete(m, "migrate")
 		delete(m, "ephemeral_disk")
+		delete(m, "volume")
 
 		// Default count to 1 if not specified
 		if _, ok := m["count"]; !ok {
@@ -283,6 +309,20 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse reschedule policy
+		if o := listVal.Filter("reschedule"); len(o.Items) > 0 {
+			if err := parseReschedulePolicy(&g.ReschedulePolicy, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', reschedule ->", n))
+			}
+		}
+
+		// Parse migrate strategy
+		if o := listVal.Filter("migrate"); len(o.Items) > 0 {
+			if err := parseMigrateStrategy(&g.Migrate, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', migrate ->", n))
+			}
+		}
+
 		// Parse ephemeral disk
 		g.EphemeralDisk = structs.DefaultEphemeralDisk()
 		if o := listVal.Filter("ephemeral_disk"); len(o.Items) > 0 {
@@ -305,6 +345,13 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse volumes
+		if o := listVal.Filter("volume"); len(o.Items) > 0 {
+			if err := parseVolumes(&g.Volumes, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', volume ->", n))
+			}
+		}
+
 		// Parse tasks
 		if o := listVal.Filter("task"); len(o.Items) > 0 {
 			if err := parseTasks(result.Name, g.Name, &g.Tasks, o); err != nil {
@@ -361,6 +408,90 @@ func parseRestartPolicy(final **structs.RestartPolicy, list *ast.ObjectList) err
 	return nil
 }
 
+func parseReschedulePolicy(final **structs.ReschedulePolicy, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'reschedule' block allowed")
+	}
+
+	// Get our job object
+	obj := list.Items[0]
+
+	// Check for invalid keys
+	valid := []string{
+		"attempts",
+		"interval",
+		"delay",
+		"delay_function",
+		"max_delay",
+		"unlimited",
+	}
+	if err := checkHCLKeys(obj.Val, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, obj.Val); err != nil {
+		return err
+	}
+
+	var result structs.ReschedulePolicy
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &result,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*final = &result
+	return nil
+}
+
+func parseMigrateStrategy(final **structs.MigrateStrategy, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'migrate' block allowed")
+	}
+
+	// Get our migrate object
+	obj := list.Items[0]
+
+	// Check for invalid keys
+	valid := []string{
+		"max_parallel",
+		"health_deadline",
+	}
+	if err := checkHCLKeys(obj.Val, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, obj.Val); err != nil {
+		return err
+	}
+
+	var result structs.MigrateStrategy
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &result,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*final = &result
+	return nil
+}
+
 func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error {
 	for _, o := range list.Elem().Items {
 		// Check for invalid keys
@@ -371,6 +502,7 @@ func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error
 			"version",
 			"regexp",
 			"distinct_hosts",
+			"distinct_property",
 		}
 		if err := checkHCLKeys(o.Val, valid); err != nil {
 			return err
@@ -413,6 +545,13 @@ func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error
 			m["Operand"] = structs.ConstraintDistinctHosts
 		}
 
+		// If "distinct_property" is provided, set the operand and the
+		// property to the "LTarget"
+		if property, ok := m[structs.ConstraintDistinctProperty]; ok {
+			m["Operand"] = structs.ConstraintDistinctProperty
+			m["LTarget"] = property
+		}
+
 		// Build the constraint
 		var c structs.Constraint
 		if err := mapstructure.WeakDecode(m, &c); err != nil {
@@ -507,16 +646,22 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 			"artifact",
 			"config",
 			"constraint",
+			"dispatch_payload",
 			"driver",
 			"env",
+			"env_from_consul",
 			"exclude_nomad_env",
 			"kill_timeout",
+			"lifecycle",
 			"logs",
 			"meta",
+			"resource_override",
 			"resources",
 			"service",
+			"template",
 			"user",
 			"vault",
+			"volume_mount",
 		}
 		if err := checkHCLKeys(listVal, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
@@ -529,13 +674,19 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 		delete(m, "artifact")
 		delete(m, "config")
 		delete(m, "constraint")
+		delete(m, "dispatch_payload")
 		delete(m, "env")
+		delete(m, "env_from_consul")
 		delete(m, "exclude_nomad_env")
+		delete(m, "lifecycle")
 		delete(m, "logs")
 		delete(m, "meta")
+		delete(m, "resource_override")
 		delete(m, "resources")
+		delete(m, "template")
 		delete(m, "service")
 		delete(m, "vault")
+		delete(m, "volume_mount")
 
 		// Build the task
 		var t structs.Task
@@ -636,6 +787,13 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 			t.Resources = &r
 		}
 
+		// Parse resource overrides
+		if o := listVal.Filter("resource_override"); len(o.Items) > 0 {
+			if err := parseResourceOverrides(&t.ResourceOverrides, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', resource_override ->", n))
+			}
+		}
+
 		// If we have logs then parse that
 		logConfig := structs.DefaultLogConfig()
 		if o := listVal.Filter("logs"); len(o.Items) > 0 {
@@ -672,6 +830,20 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 			}
 		}
 
+		// Parse volume mounts
+		if o := listVal.Filter("volume_mount"); len(o.Items) > 0 {
+			if err := parseVolumeMounts(&t.VolumeMounts, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', volume_mount ->", n))
+			}
+		}
+
+		// Parse templates
+		if o := listVal.Filter("template"); len(o.Items) > 0 {
+			if err := parseTemplates(&t.Templates, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', template ->", n))
+			}
+		}
+
 		// If we have a vault block, then parse that
 		if o := listVal.Filter("vault"); len(o.Items) > 0 {
 			var v structs.Vault
@@ -682,6 +854,27 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 			t.Vault = &v
 		}
 
+		// If we have a dispatch payload block, then parse that
+		if o := listVal.Filter("dispatch_payload"); len(o.Items) > 0 {
+			if err := parseDispatchPayload(&t.DispatchPayload, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', dispatch_payload ->", n))
+			}
+		}
+
+		// If we have a lifecycle block, then parse that
+		if o := listVal.Filter("lifecycle"); len(o.Items) > 0 {
+			if err := parseLifecycle(&t.Lifecycle, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', lifecycle ->", n))
+			}
+		}
+
+		// If we have an env_from_consul block, then parse that
+		if o := listVal.Filter("env_from_consul"); len(o.Items) > 0 {
+			if err := parseEnvFromConsul(&t.EnvFromConsul, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', env_from_consul ->", n))
+			}
+		}
+
 		*result = append(*result, &t)
 	}
 
@@ -738,6 +931,150 @@ func parseArtifacts(result *[]*structs.TaskArtifact, list *ast.ObjectList) error
 	return nil
 }
 
+func parseVolumes(result *map[string]*structs.VolumeRequest, list *ast.ObjectList) error {
+	list = list.Children()
+	volumes := make(map[string]*structs.VolumeRequest, len(list.Items))
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+		if _, ok := volumes[n]; ok {
+			return fmt.Errorf("volume '%s' defined more than once", n)
+		}
+
+		// Check for invalid keys
+		valid := []string{
+			"type",
+			"source",
+			"read_only",
+		}
+		if err := checkHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		var v structs.VolumeRequest
+		if err := mapstructure.WeakDecode(m, &v); err != nil {
+			return err
+		}
+		v.Name = n
+		volumes[n] = &v
+	}
+
+	*result = volumes
+	return nil
+}
+
+func parseResourceOverrides(result *[]*structs.ResourceOverride, list *ast.ObjectList) error {
+	list = list.Children()
+	classes := make(map[string]struct{}, len(list.Items))
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+		if _, ok := classes[n]; ok {
+			return fmt.Errorf("node class '%s' overridden more than once", n)
+		}
+		classes[n] = struct{}{}
+
+		// Check for invalid keys
+		valid := []string{
+			"cpu",
+			"iops",
+			"memory",
+		}
+		if err := checkHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		var o structs.ResourceOverride
+		if err := mapstructure.WeakDecode(m, &o); err != nil {
+			return err
+		}
+		o.NodeClass = n
+		*result = append(*result, &o)
+	}
+
+	return nil
+}
+
+func parseVolumeMounts(result *[]*structs.VolumeMount, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"volume",
+			"destination",
+			"read_only",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		var vm structs.VolumeMount
+		if err := mapstructure.WeakDecode(m, &vm); err != nil {
+			return err
+		}
+
+		*result = append(*result, &vm)
+	}
+
+	return nil
+}
+
+func parseTemplates(result *[]*structs.Template, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"source",
+			"destination",
+			"data",
+			"change_mode",
+			"change_signal",
+			"splay",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		// Default to restarting the task on changes.
+		if _, ok := m["change_mode"]; !ok {
+			m["change_mode"] = structs.TemplateChangeModeRestart
+		}
+
+		var templ structs.Template
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			Result:           &templ,
+		})
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(m); err != nil {
+			return err
+		}
+
+		*result = append(*result, &templ)
+	}
+
+	return nil
+}
+
 func parseArtifactOption(result map[string]string, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -767,6 +1104,7 @@ func parseServices(jobName string, taskGroupName string, task *structs.Task, ser
 		valid := []string{
 			"name",
 			"tags",
+			"canary_tags",
 			"port",
 			"check",
 		}
@@ -830,6 +1168,7 @@ func parseChecks(service *structs.Service, checkObjs *ast.ObjectList) error {
 			"command",
 			"args",
 			"initial_status",
+			"check_restart",
 		}
 		if err := checkHCLKeys(co.Val, valid); err != nil {
 			return multierror.Prefix(err, "check ->")
@@ -840,6 +1179,7 @@ func parseChecks(service *structs.Service, checkObjs *ast.ObjectList) error {
 		if err := hcl.DecodeObject(&cm, co.Val); err != nil {
 			return err
 		}
+		delete(cm, "check_restart")
 		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
 			WeaklyTypedInput: true,
@@ -852,12 +1192,61 @@ func parseChecks(service *structs.Service, checkObjs *ast.ObjectList) error {
 			return err
 		}
 
+		// Parse the check restart
+		if ot, ok := co.Val.(*ast.ObjectType); ok {
+			if o := ot.List.Filter("check_restart"); len(o.Items) > 0 {
+				if err := parseCheckRestart(&check.CheckRestart, o); err != nil {
+					return multierror.Prefix(err, "check ->")
+				}
+			}
+		}
+
 		service.Checks[idx] = &check
 	}
 
 	return nil
 }
 
+func parseCheckRestart(result **structs.CheckRestart, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'check_restart' block allowed per check")
+	}
+
+	// Get our check restart object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"limit",
+		"grace",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return multierror.Prefix(err, "check_restart ->")
+	}
+
+	var c structs.CheckRestart
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &c,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*result = &c
+	return nil
+}
+
 func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) == 0 {
@@ -881,6 +1270,8 @@ func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 	// Check for invalid keys
 	valid := []string{
 		"cpu",
+		"cpu_hard_limit",
+		"gpu",
 		"iops",
 		"memory",
 		"network",
@@ -1004,6 +1395,11 @@ func parseUpdate(result *structs.UpdateStrategy, list *ast.ObjectList) error {
 	valid := []string{
 		"stagger",
 		"max_parallel",
+		"health_check",
+		"min_healthy_time",
+		"healthy_deadline",
+		"auto_revert",
+		"canary",
 	}
 	if err := checkHCLKeys(o.Val, valid); err != nil {
 		return err
@@ -1020,6 +1416,45 @@ func parseUpdate(result *structs.UpdateStrategy, list *ast.ObjectList) error {
 	return dec.Decode(m)
 }
 
+func parseFailover(result **structs.FailoverConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'failover' block allowed per job")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"region",
+		"after",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	var failover structs.FailoverConfig
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &failover,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+	*result = &failover
+	return nil
+}
+
 func parsePeriodic(result **structs.PeriodicConfig, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -1070,6 +1505,134 @@ func parsePeriodic(result **structs.PeriodicConfig, list *ast.ObjectList) error
 	return nil
 }
 
+func parseParameterizedJob(result **structs.ParameterizedJobConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'parameterized' block allowed per job")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"payload",
+		"meta_required",
+		"meta_optional",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	// Build the parameterized job block
+	var d structs.ParameterizedJobConfig
+	if err := mapstructure.WeakDecode(m, &d); err != nil {
+		return err
+	}
+
+	*result = &d
+	return nil
+}
+
+func parseDispatchPayload(result **structs.DispatchPayloadConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'dispatch_payload' block allowed per task")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"file",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	var d structs.DispatchPayloadConfig
+	if err := mapstructure.WeakDecode(m, &d); err != nil {
+		return err
+	}
+
+	*result = &d
+	return nil
+}
+
+func parseLifecycle(result **structs.TaskLifecycleConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'lifecycle' block allowed per task")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"hook",
+		"sidecar",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	var l structs.TaskLifecycleConfig
+	if err := mapstructure.WeakDecode(m, &l); err != nil {
+		return err
+	}
+
+	*result = &l
+	return nil
+}
+
+func parseEnvFromConsul(result **structs.EnvFromConsulConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'env_from_consul' block allowed per task")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"prefix",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	var e structs.EnvFromConsulConfig
+	if err := mapstructure.WeakDecode(m, &e); err != nil {
+		return err
+	}
+
+	*result = &e
+	return nil
+}
+
 func parseVault(result *structs.Vault, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) == 0 {
@@ -1131,7 +1694,7 @@ func checkHCLKeys(node ast.Node, valid []string) error {
 		key := item.Keys[0].Token.Value().(string)
 		if _, ok := validMap[key]; !ok {
 			result = multierror.Append(result, fmt.Errorf(
-				"invalid key: %s", key))
+				"invalid key: %s (at %s)", key, item.Pos()))
 		}
 	}
 
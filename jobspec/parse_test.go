@@ -46,6 +46,8 @@ func TestParse(t *testing.T) {
 					Stagger:     60 * time.Second,
 					MaxParallel: 2,
 				},
+				StopStrategy: structs.JobStopStrategyRolling,
+				Namespace:    "engineering",
 
 				TaskGroups: []*structs.TaskGroup{
 					&structs.TaskGroup{
@@ -88,6 +90,17 @@ func TestParse(t *testing.T) {
 							Delay:    15 * time.Second,
 							Mode:     "delay",
 						},
+						ReschedulePolicy: &structs.ReschedulePolicy{
+							Attempts:      3,
+							Interval:      time.Hour,
+							Delay:         30 * time.Second,
+							DelayFunction: "exponential",
+							MaxDelay:      10 * time.Minute,
+						},
+						Migrate: &structs.MigrateStrategy{
+							MaxParallel:    2,
+							HealthDeadline: 10 * time.Minute,
+						},
 						EphemeralDisk: &structs.EphemeralDisk{
 							Sticky: true,
 							SizeMB: 150,
@@ -107,9 +120,10 @@ func TestParse(t *testing.T) {
 								},
 								Services: []*structs.Service{
 									{
-										Name:      "binstore-storagelocker-binsl-binstore",
-										Tags:      []string{"foo", "bar"},
-										PortLabel: "http",
+										Name:       "binstore-storagelocker-binsl-binstore",
+										Tags:       []string{"foo", "bar"},
+										CanaryTags: []string{"canary"},
+										PortLabel:  "http",
 										Checks: []*structs.ServiceCheck{
 											{
 												Name:      "check-name",
@@ -161,6 +175,20 @@ func TestParse(t *testing.T) {
 								Vault: &structs.Vault{
 									Policies: []string{"foo", "bar"},
 								},
+								Templates: []*structs.Template{
+									{
+										SourcePath:   "bar",
+										DestPath:     "bar",
+										ChangeMode:   structs.TemplateChangeModeSignal,
+										ChangeSignal: "foo",
+										Splay:        10 * time.Second,
+									},
+									{
+										EmbeddedTmpl: "{{ key \"foo\" }}",
+										DestPath:     "local/foo.conf",
+										ChangeMode:   structs.TemplateChangeModeRestart,
+									},
+								},
 							},
 							&structs.Task{
 								Name:   "storagelocker",
@@ -173,6 +201,9 @@ func TestParse(t *testing.T) {
 									CPU:      500,
 									MemoryMB: 128,
 									IOPS:     30,
+									GPU:      2,
+
+									CPUHardLimit: true,
 								},
 								Constraints: []*structs.Constraint{
 									&structs.Constraint{
@@ -275,6 +306,24 @@ func TestParse(t *testing.T) {
 			false,
 		},
 
+		{
+			"distinctProperty-constraint.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Priority: 50,
+				Region:   "global",
+				Type:     "service",
+				Constraints: []*structs.Constraint{
+					&structs.Constraint{
+						Operand: structs.ConstraintDistinctProperty,
+						LTarget: "${meta.rack}",
+					},
+				},
+			},
+			false,
+		},
+
 		{
 			"periodic-cron.hcl",
 			&structs.Job{
@@ -293,6 +342,43 @@ func TestParse(t *testing.T) {
 			false,
 		},
 
+		{
+			"failover.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Priority: 50,
+				Region:   "global",
+				Type:     "service",
+				Failover: &structs.FailoverConfig{
+					Region: "eu",
+					After:  10 * time.Minute,
+				},
+			},
+			false,
+		},
+
+		{
+			"update-health.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Priority: 50,
+				Region:   "global",
+				Type:     "service",
+				Update: structs.UpdateStrategy{
+					Stagger:         30 * time.Second,
+					MaxParallel:     2,
+					HealthCheck:     structs.UpdateHealthCheckChecks,
+					MinHealthyTime:  10 * time.Second,
+					HealthyDeadline: 2 * time.Minute,
+					AutoRevert:      true,
+					Canary:          1,
+				},
+			},
+			false,
+		},
+
 		{
 			"specify-job.hcl",
 			&structs.Job{
@@ -440,6 +526,239 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"service-check-restart.hcl",
+			&structs.Job{
+				ID:       "check_restart",
+				Name:     "check_restart",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "group",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name: "task",
+								Services: []*structs.Service{
+									{
+										Name:      "check_restart-group-task",
+										Tags:      []string{"foo", "bar"},
+										PortLabel: "http",
+										Checks: []*structs.ServiceCheck{
+											{
+												Name:     "check-name",
+												Type:     "http",
+												Interval: 10 * time.Second,
+												Timeout:  2 * time.Second,
+												CheckRestart: &structs.CheckRestart{
+													Limit: 3,
+													Grace: 30 * time.Second,
+												},
+											},
+										},
+									},
+								},
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"parameterized_job.hcl",
+			&structs.Job{
+				ID:       "parameterized_job",
+				Name:     "parameterized_job",
+				Type:     "batch",
+				Priority: 50,
+				Region:   "global",
+				ParameterizedJob: &structs.ParameterizedJobConfig{
+					Payload:      "required",
+					MetaRequired: []string{"foo", "bar"},
+					MetaOptional: []string{"baz", "bam"},
+				},
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "foo",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "bar",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								DispatchPayload: &structs.DispatchPayloadConfig{
+									File: "foo/bar",
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"task-lifecycle.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "init",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								Lifecycle: &structs.TaskLifecycleConfig{
+									Hook: "prestart",
+								},
+							},
+							&structs.Task{
+								Name:      "proxy",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								Lifecycle: &structs.TaskLifecycleConfig{
+									Hook:    "prestart",
+									Sidecar: true,
+								},
+							},
+							&structs.Task{
+								Name:      "web",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"task-resource-override.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "system",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:   "shipper",
+								Driver: "docker",
+								Resources: &structs.Resources{
+									CPU:      100,
+									MemoryMB: 128,
+								},
+								ResourceOverrides: []*structs.ResourceOverride{
+									&structs.ResourceOverride{
+										NodeClass: "large",
+										MemoryMB:  1024,
+									},
+									&structs.ResourceOverride{
+										NodeClass: "medium",
+										CPU:       200,
+										MemoryMB:  512,
+									},
+								},
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"task-env-from-consul.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:   "web",
+								Driver: "docker",
+								Env: map[string]string{
+									"LOG_LEVEL": "debug",
+								},
+								EnvFromConsul: &structs.EnvFromConsulConfig{
+									Prefix: "config/web/",
+								},
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"volumes.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Volumes: map[string]*structs.VolumeRequest{
+							"data": &structs.VolumeRequest{
+								Name:     "data",
+								Type:     "host",
+								Source:   "shared_data",
+								ReadOnly: true,
+							},
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:   "web",
+								Driver: "docker",
+								VolumeMounts: []*structs.VolumeMount{
+									&structs.VolumeMount{
+										Volume:      "data",
+										Destination: "/srv/data",
+										ReadOnly:    true,
+									},
+								},
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,118 @@
+package jobspec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+// Positions records where a job, its task groups and their tasks are defined
+// in a job spec.
+type Positions struct {
+	// Job is the position of the job stanza
+	Job token.Pos
+
+	// Groups maps a task group name to its position. Tasks defined directly
+	// in the job are recorded as a group of the same name.
+	Groups map[string]token.Pos
+
+	// Tasks maps a task group name to the positions of its tasks
+	Tasks map[string]map[string]token.Pos
+}
+
+// Group returns the position of the named task group, falling back to the
+// position of the job if it is unknown.
+func (p *Positions) Group(group string) token.Pos {
+	if pos, ok := p.Groups[group]; ok {
+		return pos
+	}
+	return p.Job
+}
+
+// Task returns the position of the named task, falling back to the position
+// of its task group if it is unknown.
+func (p *Positions) Task(group, task string) token.Pos {
+	if pos, ok := p.Tasks[group][task]; ok {
+		return pos
+	}
+	return p.Group(group)
+}
+
+// ParsePositions parses the positions of the job, task groups and tasks
+// defined in the job spec from the given io.Reader.
+func ParsePositions(r io.Reader) (*Positions, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, err
+	}
+
+	root, err := hcl.Parse(buf.String())
+	if err != nil {
+		return nil, fmt.Errorf("error parsing: %s", err)
+	}
+
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("error parsing: root should be an object")
+	}
+
+	jobs := list.Filter("job").Children()
+	if len(jobs.Items) != 1 {
+		return nil, fmt.Errorf("only one 'job' block allowed")
+	}
+
+	pos := &Positions{
+		Job:    jobs.Items[0].Pos(),
+		Groups: make(map[string]token.Pos),
+		Tasks:  make(map[string]map[string]token.Pos),
+	}
+
+	job, ok := jobs.Items[0].Val.(*ast.ObjectType)
+	if !ok {
+		return pos, nil
+	}
+
+	// Tasks outside of a group are wrapped in a group of the same name
+	for _, task := range job.List.Filter("task").Children().Items {
+		name, ok := itemName(task)
+		if !ok {
+			continue
+		}
+		pos.Groups[name] = task.Pos()
+		pos.Tasks[name] = map[string]token.Pos{name: task.Pos()}
+	}
+
+	for _, group := range job.List.Filter("group").Children().Items {
+		name, ok := itemName(group)
+		if !ok {
+			continue
+		}
+		pos.Groups[name] = group.Pos()
+		pos.Tasks[name] = make(map[string]token.Pos)
+
+		obj, ok := group.Val.(*ast.ObjectType)
+		if !ok {
+			continue
+		}
+		for _, task := range obj.List.Filter("task").Children().Items {
+			if taskName, ok := itemName(task); ok {
+				pos.Tasks[name][taskName] = task.Pos()
+			}
+		}
+	}
+
+	return pos, nil
+}
+
+// itemName returns the name an object item is keyed by
+func itemName(item *ast.ObjectItem) (string, bool) {
+	if len(item.Keys) == 0 {
+		return "", false
+	}
+	name, ok := item.Keys[0].Token.Value().(string)
+	return name, ok
+}
@@ -0,0 +1,43 @@
+package jobspec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePositions(t *testing.T) {
+	spec := `job "example" {
+	task "outer" {
+		driver = "exec"
+	}
+
+	group "cache" {
+		task "redis" {
+			driver = "docker"
+		}
+	}
+}`
+
+	pos, err := ParsePositions(strings.NewReader(spec))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		actual   string
+		expected string
+	}{
+		{pos.Job.String(), "1:5"},
+		{pos.Group("outer").String(), "2:7"},
+		{pos.Task("outer", "outer").String(), "2:7"},
+		{pos.Group("cache").String(), "6:8"},
+		{pos.Task("cache", "redis").String(), "7:8"},
+		{pos.Task("cache", "unknown").String(), "6:8"},
+		{pos.Group("unknown").String(), "1:5"},
+	}
+	for i, c := range cases {
+		if c.actual != c.expected {
+			t.Fatalf("case %d: got %q; want %q", i, c.actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package jobspec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ParseQuotaSpec parses a quota specification, which sets the name and
+// description of the quota at the top level and its resources in a limit
+// block.
+func ParseQuotaSpec(r io.Reader) (*structs.QuotaSpec, error) {
+	// Copy the reader into an in-memory buffer first since HCL requires it.
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, err
+	}
+
+	// Parse the buffer
+	root, err := hcl.Parse(buf.String())
+	if err != nil {
+		return nil, fmt.Errorf("error parsing: %s", err)
+	}
+	buf.Reset()
+
+	// Top-level item should be a list
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("error parsing: root should be an object")
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"name",
+		"description",
+		"limit",
+	}
+	if err := checkHCLKeys(list, valid); err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, list); err != nil {
+		return nil, err
+	}
+	delete(m, "limit")
+
+	var quota structs.QuotaSpec
+	if err := mapstructure.WeakDecode(m, &quota); err != nil {
+		return nil, err
+	}
+
+	// Parse the limit
+	if o := list.Filter("limit"); len(o.Items) > 0 {
+		if err := parseQuotaLimit(&quota.Limit, o); err != nil {
+			return nil, multierror.Prefix(err, "limit ->")
+		}
+	}
+
+	return &quota, nil
+}
+
+// ParseQuotaSpecFile parses the quota specification in the file at the given
+// path.
+func ParseQuotaSpecFile(path string) (*structs.QuotaSpec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseQuotaSpec(f)
+}
+
+func parseQuotaLimit(result **structs.QuotaLimit, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'limit' block allowed per quota")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"cpu",
+		"memory",
+		"disk",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	var limit structs.QuotaLimit
+	if err := mapstructure.WeakDecode(m, &limit); err != nil {
+		return err
+	}
+	*result = &limit
+	return nil
+}
@@ -0,0 +1,78 @@
+package jobspec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestParseQuotaSpec(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Result   *structs.QuotaSpec
+		ErrorStr string
+	}{
+		{
+			`
+name        = "engineering"
+description = "Engineering team"
+
+limit {
+  cpu    = 4000
+  memory = 8192
+  disk   = 10000
+}
+`,
+			&structs.QuotaSpec{
+				Name:        "engineering",
+				Description: "Engineering team",
+				Limit: &structs.QuotaLimit{
+					CPU:      4000,
+					MemoryMB: 8192,
+					DiskMB:   10000,
+				},
+			},
+			"",
+		},
+		{
+			`{"name": "batch", "limit": {"memory": 2048}}`,
+			&structs.QuotaSpec{
+				Name:  "batch",
+				Limit: &structs.QuotaLimit{MemoryMB: 2048},
+			},
+			"",
+		},
+		{
+			`name = "bad"
+owner = "me"`,
+			nil,
+			"invalid key: owner",
+		},
+		{
+			`name = "bad"
+limit {
+  iops = 10
+}`,
+			nil,
+			"invalid key: iops",
+		},
+	}
+
+	for _, tc := range cases {
+		actual, err := ParseQuotaSpec(strings.NewReader(tc.Input))
+		if tc.ErrorStr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.ErrorStr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.ErrorStr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !reflect.DeepEqual(actual, tc.Result) {
+			t.Fatalf("bad: %#v", actual)
+		}
+	}
+}
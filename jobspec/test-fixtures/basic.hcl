@@ -1,10 +1,12 @@
 job "binstore-storagelocker" {
-  region      = "global"
-  type        = "service"
-  priority    = 50
-  all_at_once = true
-  datacenters = ["us2", "eu1"]
-  vault_token = "foo"
+  region        = "global"
+  type          = "service"
+  priority      = 50
+  all_at_once   = true
+  datacenters   = ["us2", "eu1"]
+  vault_token   = "foo"
+  stop_strategy = "rolling"
+  namespace     = "engineering"
 
   meta {
     foo = "bar"
@@ -42,6 +44,19 @@ job "binstore-storagelocker" {
       mode     = "delay"
     }
 
+    reschedule {
+      attempts       = 3
+      interval       = "1h"
+      delay          = "30s"
+      delay_function = "exponential"
+      max_delay      = "10m"
+    }
+
+    migrate {
+      max_parallel    = 2
+      health_deadline = "10m"
+    }
+
     ephemeral_disk {
         sticky = true
         size = 150
@@ -71,6 +86,7 @@ job "binstore-storagelocker" {
 
       service {
         tags = ["foo", "bar"]
+        canary_tags = ["canary"]
         port = "http"
 
         check {
@@ -133,6 +149,19 @@ job "binstore-storagelocker" {
       vault {
         policies = ["foo", "bar"]
       }
+
+      template {
+        source        = "bar"
+        destination   = "bar"
+        change_mode   = "signal"
+        change_signal = "foo"
+        splay         = "10s"
+      }
+
+      template {
+        data        = "{{ key \"foo\" }}"
+        destination = "local/foo.conf"
+      }
     }
 
     task "storagelocker" {
@@ -146,6 +175,9 @@ job "binstore-storagelocker" {
         cpu    = 500
         memory = 128
         iops   = 30
+        gpu    = 2
+
+        cpu_hard_limit = true
       }
 
       constraint {
@@ -0,0 +1,6 @@
+job "foo" {
+    failover {
+        region = "eu"
+        after = "10m"
+    }
+}
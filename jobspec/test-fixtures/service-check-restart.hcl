@@ -0,0 +1,26 @@
+job "check_restart" {
+
+    type = "service"
+    group "group" {
+        count = 1
+
+        task "task" {
+          service {
+            tags = ["foo", "bar"]
+            port = "http"
+
+            check {
+              name     = "check-name"
+              type     = "http"
+              interval = "10s"
+              timeout  = "2s"
+
+              check_restart {
+                limit = 3
+                grace = "30s"
+              }
+            }
+          }
+        }
+    }
+}
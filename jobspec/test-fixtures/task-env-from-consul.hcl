@@ -0,0 +1,13 @@
+job "foo" {
+    group "bar" {
+        task "web" {
+            driver = "docker"
+            env {
+                LOG_LEVEL = "debug"
+            }
+            env_from_consul {
+                prefix = "config/web/"
+            }
+        }
+    }
+}
@@ -0,0 +1,20 @@
+job "foo" {
+    group "bar" {
+        task "init" {
+            driver = "docker"
+            lifecycle {
+                hook = "prestart"
+            }
+        }
+        task "proxy" {
+            driver = "docker"
+            lifecycle {
+                hook = "prestart"
+                sidecar = true
+            }
+        }
+        task "web" {
+            driver = "docker"
+        }
+    }
+}
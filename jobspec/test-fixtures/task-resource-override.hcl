@@ -0,0 +1,19 @@
+job "foo" {
+    type = "system"
+    group "bar" {
+        task "shipper" {
+            driver = "docker"
+            resources {
+                cpu = 100
+                memory = 128
+            }
+            resource_override "large" {
+                memory = 1024
+            }
+            resource_override "medium" {
+                cpu = 200
+                memory = 512
+            }
+        }
+    }
+}
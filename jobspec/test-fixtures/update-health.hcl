@@ -0,0 +1,11 @@
+job "foo" {
+    update {
+        stagger = "30s"
+        max_parallel = 2
+        health_check = "checks"
+        min_healthy_time = "10s"
+        healthy_deadline = "2m"
+        auto_revert = true
+        canary = 1
+    }
+}
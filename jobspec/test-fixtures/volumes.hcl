@@ -0,0 +1,19 @@
+job "foo" {
+    group "bar" {
+        volume "data" {
+            type      = "host"
+            source    = "shared_data"
+            read_only = true
+        }
+
+        task "web" {
+            driver = "docker"
+
+            volume_mount {
+                volume      = "data"
+                destination = "/srv/data"
+                read_only   = true
+            }
+        }
+    }
+}
@@ -40,6 +40,9 @@ func RunCustom(args []string, commands map[string]cli.CommandFactory) int {
 		case "executor":
 		case "syslog":
 		case "fs ls", "fs cat", "fs stat":
+		case "system reconcile", "system reconcile summaries":
+		case "job dispatch", "job history", "job revert",
+			"deployment fail", "deployment promote", "deployment status":
 		case "check":
 		default:
 			commandsInclude = append(commandsInclude, k)
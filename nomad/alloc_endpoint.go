@@ -6,6 +6,7 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/watch"
 )
@@ -33,26 +34,36 @@ func (a *Alloc) List(args *structs.AllocListRequest, reply *structs.AllocListRes
 			if err != nil {
 				return err
 			}
-			var iter memdb.ResultIterator
-			if prefix := args.QueryOptions.Prefix; prefix != "" {
-				iter, err = snap.AllocsByIDPrefix(prefix)
-			} else {
-				iter, err = snap.Allocs()
+			// Narrow the allocations down with the index of a filter
+			var candidates []*structs.Allocation
+			switch {
+			case args.NodeID != "":
+				candidates, err = snap.AllocsByNode(args.NodeID)
+			case args.JobID != "":
+				candidates, err = snap.AllocsByJob(args.RequestNamespace(), args.JobID)
+			default:
+				candidates, err = allocsByPrefix(snap, args.QueryOptions.Prefix)
 			}
 			if err != nil {
 				return err
 			}
 
 			var allocs []*structs.AllocListStub
-			for {
-				raw := iter.Next()
-				if raw == nil {
-					break
+			page := newPaginator(&args.QueryOptions)
+			for _, alloc := range candidates {
+				if alloc.Namespace != args.RequestNamespace() || !args.Matches(alloc) {
+					continue
+				}
+				if !page.accept(alloc.ID) {
+					if page.done() {
+						break
+					}
+					continue
 				}
-				alloc := raw.(*structs.Allocation)
 				allocs = append(allocs, alloc.Stub())
 			}
 			reply.Allocations = allocs
+			reply.NextToken = page.next
 
 			// Use the last index that affected the jobs table
 			index, err := snap.Index("allocs")
@@ -91,6 +102,9 @@ func (a *Alloc) GetAlloc(args *structs.AllocSpecificRequest,
 			if err != nil {
 				return err
 			}
+			if out != nil && out.Namespace != args.RequestNamespace() {
+				out = nil
+			}
 
 			// Setup the output
 			reply.Alloc = out
@@ -120,30 +134,70 @@ func (a *Alloc) GetAllocs(args *structs.AllocsGetRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "alloc", "get_alloc"}, time.Now())
 
-	// Lookup the allocations
-	snap, err := a.srv.fsm.State().Snapshot()
-	if err != nil {
-		return err
+	// Watch all the allocations
+	items := watch.NewItems()
+	for _, alloc := range args.AllocIDs {
+		items.Add(watch.Item{Alloc: alloc})
 	}
 
-	allocs := make([]*structs.Allocation, len(args.AllocIDs))
-	for i, alloc := range args.AllocIDs {
-		out, err := snap.AllocByID(alloc)
-		if err != nil {
-			return err
-		}
-		if out == nil {
-			return fmt.Errorf("unknown alloc id %q", alloc)
-		}
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     items,
+		run: func() error {
+			// Lookup the allocations
+			snap, err := a.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
 
-		allocs[i] = out
-		if reply.Index < out.ModifyIndex {
-			reply.Index = out.ModifyIndex
-		}
+			allocs := make([]*structs.Allocation, len(args.AllocIDs))
+			for i, alloc := range args.AllocIDs {
+				out, err := snap.AllocByID(alloc)
+				if err != nil {
+					return err
+				}
+				if out == nil {
+					return fmt.Errorf("unknown alloc id %q", alloc)
+				}
+
+				allocs[i] = out
+				if reply.Index < out.ModifyIndex {
+					reply.Index = out.ModifyIndex
+				}
+			}
+
+			// Set the response
+			a.srv.setQueryMeta(&reply.QueryMeta)
+			reply.Allocs = allocs
+			return nil
+		}}
+
+	return a.srv.blockingRPC(&opts)
+}
+
+// allocsByPrefix returns the allocations whose ID starts with the prefix, all
+// of them if the prefix is empty
+func allocsByPrefix(snap *state.StateSnapshot, prefix string) ([]*structs.Allocation, error) {
+	var iter memdb.ResultIterator
+	var err error
+	if prefix != "" {
+		iter, err = snap.AllocsByIDPrefix(prefix)
+	} else {
+		iter, err = snap.Allocs()
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// Set the response
-	a.srv.setQueryMeta(&reply.QueryMeta)
-	reply.Allocs = allocs
-	return nil
+	var allocs []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		allocs = append(allocs, raw.(*structs.Allocation))
+	}
+	return allocs, nil
 }
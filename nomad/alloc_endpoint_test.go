@@ -2,6 +2,7 @@ package nomad
 
 import (
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -69,6 +70,63 @@ func TestAllocEndpoint_List(t *testing.T) {
 	}
 }
 
+func TestAllocEndpoint_List_Filter(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create allocations of two jobs on two nodes
+	alloc1 := mock.Alloc()
+	alloc2 := mock.Alloc()
+	alloc2.NodeID = alloc1.NodeID
+	alloc2.ClientStatus = structs.AllocClientStatusRunning
+	alloc3 := mock.Alloc()
+	alloc3.JobID = alloc1.JobID
+	alloc3.Job = alloc1.Job
+	alloc3.NodeID = structs.GenerateUUID()
+	alloc3.DesiredStatus = structs.AllocDesiredStatusStop
+	state := s1.fsm.State()
+	for _, alloc := range []*structs.Allocation{alloc1, alloc2, alloc3} {
+		if err := state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc1, alloc2, alloc3}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		args     structs.AllocListRequest
+		expected []string
+	}{
+		{"job", structs.AllocListRequest{JobID: alloc1.JobID}, []string{alloc1.ID, alloc3.ID}},
+		{"node", structs.AllocListRequest{NodeID: alloc1.NodeID}, []string{alloc1.ID, alloc2.ID}},
+		{"node and job", structs.AllocListRequest{NodeID: alloc1.NodeID, JobID: alloc1.JobID}, []string{alloc1.ID}},
+		{"client status", structs.AllocListRequest{ClientStatus: structs.AllocClientStatusRunning}, []string{alloc2.ID}},
+		{"desired status", structs.AllocListRequest{DesiredStatus: structs.AllocDesiredStatusStop}, []string{alloc3.ID}},
+		{"task group", structs.AllocListRequest{TaskGroup: "nope"}, nil},
+	}
+	for _, c := range cases {
+		c.args.Region = "global"
+		var resp structs.AllocListResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Alloc.List", &c.args, &resp); err != nil {
+			t.Fatalf("%s: err: %v", c.name, err)
+		}
+
+		var ids []string
+		for _, alloc := range resp.Allocations {
+			ids = append(ids, alloc.ID)
+		}
+		sort.Strings(ids)
+		sort.Strings(c.expected)
+		if !reflect.DeepEqual(ids, c.expected) {
+			t.Fatalf("%s: expected %v, got %v", c.name, c.expected, ids)
+		}
+	}
+}
+
 func TestAllocEndpoint_List_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -272,3 +330,62 @@ func TestAllocEndpoint_GetAllocs(t *testing.T) {
 		t.Fatalf("expect error")
 	}
 }
+
+func TestAllocEndpoint_GetAllocs_Blocking(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the allocs
+	alloc := mock.Alloc()
+	alloc2 := mock.Alloc()
+	state := s1.fsm.State()
+	state.UpsertJobSummary(98, mock.JobSummary(alloc.JobID))
+	state.UpsertJobSummary(99, mock.JobSummary(alloc2.JobID))
+	if err := state.UpsertAllocs(100, []*structs.Allocation{alloc, alloc2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// First update an unrelated alloc
+	time.AfterFunc(100*time.Millisecond, func() {
+		other := mock.Alloc()
+		state.UpsertJobSummary(199, mock.JobSummary(other.JobID))
+		if err := state.UpsertAllocs(200, []*structs.Allocation{other}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	// Update one of the watched allocs later
+	time.AfterFunc(200*time.Millisecond, func() {
+		update := alloc2.Copy()
+		update.ClientStatus = structs.AllocClientStatusRunning
+		if err := state.UpdateAllocsFromClient(300, []*structs.Allocation{update}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	// Lookup the allocs
+	get := &structs.AllocsGetRequest{
+		AllocIDs: []string{alloc.ID, alloc2.ID},
+		QueryOptions: structs.QueryOptions{
+			Region:        "global",
+			MinQueryIndex: 150,
+		},
+	}
+	var resp structs.AllocsGetResponse
+	start := time.Now()
+	if err := msgpackrpc.CallWithCodec(codec, "Alloc.GetAllocs", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("should block (returned in %s) %#v", elapsed, resp)
+	}
+	if resp.Index != 300 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 300)
+	}
+	if len(resp.Allocs) != 2 || resp.Allocs[1].ClientStatus != structs.AllocClientStatusRunning {
+		t.Fatalf("bad: %#v", resp.Allocs)
+	}
+}
@@ -0,0 +1,354 @@
+package nomad
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/serf/serf"
+)
+
+// autopilotLoop is a long lived function that runs Autopilot while leader. It
+// keeps track of the health of the servers, adds the new servers to the Raft
+// peers once they are stable and removes the dead servers from the peers.
+func (s *Server) autopilotLoop(stopCh chan struct{}) {
+	healthTicker := time.NewTicker(s.config.ServerHealthInterval)
+	defer healthTicker.Stop()
+	ticker := time.NewTicker(s.config.AutopilotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-healthTicker.C:
+			if err := s.updateClusterHealth(); err != nil {
+				s.logger.Printf("[ERR] nomad.autopilot: error updating cluster health: %v", err)
+				continue
+			}
+			if err := s.promoteStableServers(); err != nil {
+				s.logger.Printf("[ERR] nomad.autopilot: error adding stable servers: %v", err)
+			}
+		case <-ticker.C:
+			if err := s.pruneDeadServers(); err != nil {
+				s.logger.Printf("[ERR] nomad.autopilot: error removing dead servers: %v", err)
+			}
+		case <-s.autopilotRemoveDeadCh:
+			if err := s.pruneDeadServers(); err != nil {
+				s.logger.Printf("[ERR] nomad.autopilot: error removing dead servers: %v", err)
+			}
+		}
+	}
+}
+
+// initializeAutopilotConfig sets the Autopilot configuration of the cluster
+// to the one of the server if the cluster has none yet. Later changes are
+// made through the operator API.
+func (s *Server) initializeAutopilotConfig() error {
+	existing, err := s.fsm.State().AutopilotConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get autopilot config: %v", err)
+	}
+	if existing != nil {
+		return nil
+	}
+
+	req := structs.AutopilotSetConfigRequest{
+		Config:       s.config.AutopilotConfig,
+		CAS:          true,
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	if _, _, err := s.raftApply(structs.AutopilotRequestType, &req); err != nil {
+		return fmt.Errorf("failed to set autopilot config: %v", err)
+	}
+	return nil
+}
+
+// pruneDeadServers removes the failed servers from the Raft peers. Only a
+// minority of the peers is ever removed, so a failed server is kept in the
+// peers of a small cluster until its replacement joins.
+func (s *Server) pruneDeadServers() error {
+	config, err := s.fsm.State().AutopilotConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil || !config.CleanupDeadServers {
+		return nil
+	}
+
+	peers, err := s.raftPeerSet()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, member := range s.serf.Members() {
+		valid, parts := isNomadServer(member)
+		if !valid || parts.Region != s.config.Region || member.Status != serf.StatusFailed {
+			continue
+		}
+		if _, ok := peers[parts.Addr.String()]; ok {
+			failed = append(failed, member.Name)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	if len(failed) >= len(peers)/2 {
+		s.logger.Printf("[DEBUG] nomad.autopilot: not removing %d failed servers out of %d peers as it would affect the quorum",
+			len(failed), len(peers))
+		return nil
+	}
+
+	// Force the failed servers to leave, the reconciliation of the members
+	// removes them from the peers
+	for _, name := range failed {
+		s.logger.Printf("[INFO] nomad.autopilot: attempting removal of failed server %q", name)
+		if err := s.serf.RemoveFailedNode(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promoteStableServers adds to the Raft peers the servers that have been
+// healthy for the server stabilization time.
+func (s *Server) promoteStableServers() error {
+	members := make(map[string]serf.Member)
+	for _, member := range s.serf.Members() {
+		members[member.Name] = member
+	}
+
+	s.clusterHealthLock.RLock()
+	servers := s.clusterHealth.Servers
+	s.clusterHealthLock.RUnlock()
+
+	for _, server := range servers {
+		if server.Voter || !s.serverStable(server.Address) {
+			continue
+		}
+		member, ok := members[server.Name]
+		if !ok || member.Status != serf.StatusAlive {
+			continue
+		}
+		valid, parts := isNomadServer(member)
+		if !valid {
+			continue
+		}
+		if err := s.addRaftPeer(member, parts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serverStable returns whether the server with the given address can be
+// added to the Raft peers, which is the case once it has been healthy for the
+// server stabilization time.
+func (s *Server) serverStable(addr string) bool {
+	config, err := s.fsm.State().AutopilotConfig()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad.autopilot: failed to get autopilot config: %v", err)
+		return false
+	}
+	if config == nil || config.ServerStabilizationTime == 0 {
+		return true
+	}
+
+	s.clusterHealthLock.RLock()
+	defer s.clusterHealthLock.RUnlock()
+	for _, server := range s.clusterHealth.Servers {
+		if server.Address == addr {
+			return server.Healthy && time.Since(server.StableSince) >= config.ServerStabilizationTime
+		}
+	}
+	return false
+}
+
+// updateClusterHealth determines the health of the servers of the region
+// using the Raft stats of each server.
+func (s *Server) updateClusterHealth() error {
+	config, err := s.fsm.State().AutopilotConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get autopilot config: %v", err)
+	}
+	if config == nil {
+		return nil
+	}
+
+	peers, err := s.raftPeerSet()
+	if err != nil {
+		return err
+	}
+
+	leaderStats, err := s.raftStats()
+	if err != nil {
+		return err
+	}
+
+	// Keep track of the time since the servers are healthy
+	s.clusterHealthLock.RLock()
+	prev := make(map[string]structs.ServerHealth, len(s.clusterHealth.Servers))
+	for _, server := range s.clusterHealth.Servers {
+		prev[server.Address] = server
+	}
+	s.clusterHealthLock.RUnlock()
+
+	now := time.Now()
+	leader := s.raft.Leader()
+	self := fmt.Sprintf("%s.%s", s.config.NodeName, s.config.Region)
+	healthyVoters := 0
+	var health structs.OperatorHealthReply
+	for _, member := range s.serf.Members() {
+		valid, parts := isNomadServer(member)
+		if !valid || parts.Region != s.config.Region || member.Status == serf.StatusLeft {
+			continue
+		}
+
+		addr := parts.Addr.String()
+		_, voter := peers[addr]
+		server := structs.ServerHealth{
+			Name:       member.Name,
+			Address:    addr,
+			Version:    member.Tags["build"],
+			Leader:     addr == leader,
+			SerfStatus: member.Status.String(),
+			Voter:      voter,
+		}
+
+		if member.Status == serf.StatusAlive {
+			stats := leaderStats
+			if member.Name != self {
+				stats, err = s.serverRaftStats(parts)
+			}
+			if err != nil {
+				s.logger.Printf("[WARN] nomad.autopilot: failed to get raft stats of server %q: %v", member.Name, err)
+			} else {
+				server.Healthy = serverHealthy(&server, stats, leaderStats, config)
+			}
+		}
+
+		if server.Healthy {
+			if p, ok := prev[addr]; ok && p.Healthy {
+				server.StableSince = p.StableSince
+			} else {
+				server.StableSince = now
+			}
+			if voter {
+				healthyVoters++
+			}
+		}
+		health.Servers = append(health.Servers, server)
+	}
+
+	// The cluster is healthy if all the peers are healthy, which includes the
+	// peers unknown to the gossip pool
+	health.Healthy = healthyVoters == len(peers)
+	if tolerance := healthyVoters - (len(peers)/2 + 1); tolerance > 0 {
+		health.FailureTolerance = tolerance
+	}
+
+	s.clusterHealthLock.Lock()
+	s.clusterHealth = health
+	s.clusterHealthLock.Unlock()
+
+	healthy := float32(0)
+	if health.Healthy {
+		healthy = 1
+	}
+	metrics.SetGauge([]string{"nomad", "autopilot", "healthy"}, healthy)
+	metrics.SetGauge([]string{"nomad", "autopilot", "failure_tolerance"}, float32(health.FailureTolerance))
+	metrics.SetGauge([]string{"nomad", "autopilot", "healthy_voters"}, float32(healthyVoters))
+	return nil
+}
+
+// serverHealthy fills the Raft stats of the server health and returns whether
+// the server is healthy. A server that isn't a peer yet is healthy once it
+// reports its stats, while a peer must be up to date with the leader.
+func serverHealthy(server *structs.ServerHealth, stats, leaderStats *structs.RaftStats,
+	config *structs.AutopilotConfig) bool {
+	server.LastTerm = stats.LastTerm
+	server.LastIndex = stats.LastIndex
+	if !server.Voter {
+		return true
+	}
+	if server.Leader {
+		return true
+	}
+
+	if stats.LastContact == "never" {
+		return false
+	}
+	lastContact, err := time.ParseDuration(stats.LastContact)
+	if err != nil {
+		return false
+	}
+	server.LastContact = lastContact
+	if lastContact > config.LastContactThreshold {
+		return false
+	}
+
+	if stats.LastTerm != leaderStats.LastTerm {
+		return false
+	}
+	if leaderStats.LastIndex > config.MaxTrailingLogs &&
+		stats.LastIndex < leaderStats.LastIndex-config.MaxTrailingLogs {
+		return false
+	}
+	return true
+}
+
+// serverRaftStats queries the Raft stats of a server of the region, giving up
+// once the server health interval elapsed.
+func (s *Server) serverRaftStats(parts *serverParts) (*structs.RaftStats, error) {
+	type result struct {
+		stats *structs.RaftStats
+		err   error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		var stats structs.RaftStats
+		err := s.connPool.RPC(s.config.Region, parts.Addr, parts.MajorVersion, "Status.RaftStats", struct{}{}, &stats)
+		resultCh <- result{&stats, err}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.stats, r.err
+	case <-time.After(s.config.ServerHealthInterval):
+		return nil, fmt.Errorf("timed out after %v", s.config.ServerHealthInterval)
+	}
+}
+
+// raftStats returns the Raft stats of the local server.
+func (s *Server) raftStats() (*structs.RaftStats, error) {
+	stats := s.raft.Stats()
+
+	var err error
+	reply := &structs.RaftStats{LastContact: stats["last_contact"]}
+	reply.LastIndex, err = strconv.ParseUint(stats["last_log_index"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing server's last_log_index value: %s", err)
+	}
+	reply.LastTerm, err = strconv.ParseUint(stats["last_log_term"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing server's last_log_term value: %s", err)
+	}
+	return reply, nil
+}
+
+// raftPeerSet returns the set of the addresses of the Raft peers.
+func (s *Server) raftPeerSet() (map[string]struct{}, error) {
+	peers, err := s.raftPeers.Peers()
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]struct{}, len(peers))
+	for _, peer := range peers {
+		set[peer] = struct{}{}
+	}
+	return set, nil
+}
@@ -0,0 +1,102 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// waitForPeers waits for the servers to have the given number of Raft peers.
+func waitForPeers(t *testing.T, servers []*Server, expected int) {
+	for _, s := range servers {
+		testutil.WaitForResult(func() (bool, error) {
+			peers, _ := s.raftPeers.Peers()
+			return len(peers) == expected, fmt.Errorf("got %d peers; want %d", len(peers), expected)
+		}, func(err error) {
+			t.Fatalf("err: %v", err)
+		})
+	}
+}
+
+func TestAutopilot_CleanupDeadServer(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	s3 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s3.Shutdown()
+	testJoin(t, s1, s2, s3)
+	waitForPeers(t, []*Server{s1, s2, s3}, 3)
+
+	// Kill a non-leader server
+	s3.Shutdown()
+
+	// The failed server is kept until a replacement joins
+	testutil.WaitForResult(func() (bool, error) {
+		for _, m := range s1.Members() {
+			if m.Name == fmt.Sprintf("%s.%s", s3.config.NodeName, s3.config.Region) {
+				return m.Status.String() == "failed", fmt.Errorf("bad status: %v", m.Status)
+			}
+		}
+		return false, fmt.Errorf("server not found")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	if err := s1.pruneDeadServers(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	waitForPeers(t, []*Server{s1, s2}, 3)
+
+	// Add a replacement server, the failed server is then removed
+	s4 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s4.Shutdown()
+	testJoin(t, s1, s4)
+	waitForPeers(t, []*Server{s1, s2, s4}, 3)
+
+	peers, err := s1.raftPeerSet()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := peers[s3.config.RPCAddr.String()]; ok {
+		t.Fatalf("failed server not removed: %v", peers)
+	}
+	if _, ok := peers[s4.config.RPCAddr.String()]; !ok {
+		t.Fatalf("replacement server not added: %v", peers)
+	}
+}
+
+func TestAutopilot_PromoteStableServer(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.AutopilotConfig.ServerStabilizationTime = time.Second
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	testJoin(t, s1, s2)
+
+	// The new server isn't added until it is stable
+	testutil.WaitForResult(func() (bool, error) {
+		s1.clusterHealthLock.RLock()
+		defer s1.clusterHealthLock.RUnlock()
+		return len(s1.clusterHealth.Servers) == 2, fmt.Errorf("bad: %#v", s1.clusterHealth)
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	if peers, _ := s1.raftPeers.Peers(); len(peers) != 1 {
+		t.Fatalf("server added before being stable: %v", peers)
+	}
+
+	waitForPeers(t, []*Server{s1, s2}, 2)
+}
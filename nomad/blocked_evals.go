@@ -38,9 +38,9 @@ type BlockedEvals struct {
 	// unblockCh is used to buffer unblocking of evaluations.
 	capacityChangeCh chan *capacityUpdate
 
-	// jobs is the map of blocked job and is used to ensure that only one
-	// blocked eval exists for each job.
-	jobs map[string]struct{}
+	// jobs is the map of blocked job, keyed by namespaced ID, and is used to
+	// ensure that only one blocked eval exists for each job.
+	jobs map[structs.NamespacedID]struct{}
 
 	// unblockIndexes maps computed node classes to the index in which they were
 	// unblocked. This is used to check if an evaluation could have been
@@ -91,7 +91,7 @@ func NewBlockedEvals(evalBroker *EvalBroker) *BlockedEvals {
 		evalBroker:       evalBroker,
 		captured:         make(map[string]wrappedEval),
 		escaped:          make(map[string]wrappedEval),
-		jobs:             make(map[string]struct{}),
+		jobs:             make(map[structs.NamespacedID]struct{}),
 		unblockIndexes:   make(map[string]uint64),
 		capacityChangeCh: make(chan *capacityUpdate, unblockBuffer),
 		duplicateCh:      make(chan struct{}, 1),
@@ -157,7 +157,7 @@ func (b *BlockedEvals) processBlock(eval *structs.Evaluation, token string) {
 	// the list of duplicates. We omly ever want one blocked evaluation per job,
 	// otherwise we would create unnecessary work for the scheduler as multiple
 	// evals for the same job would be run, all producing the same outcome.
-	if _, existing := b.jobs[eval.JobID]; existing {
+	if _, existing := b.jobs[structs.NewNamespacedID(eval.JobID, eval.Namespace)]; existing {
 		b.duplicates = append(b.duplicates, eval)
 
 		// Unblock any waiter.
@@ -183,7 +183,7 @@ func (b *BlockedEvals) processBlock(eval *structs.Evaluation, token string) {
 
 	// Mark the job as tracked.
 	b.stats.TotalBlocked++
-	b.jobs[eval.JobID] = struct{}{}
+	b.jobs[structs.NewNamespacedID(eval.JobID, eval.Namespace)] = struct{}{}
 
 	// Wrap the evaluation, capturing its token.
 	wrapped := wrappedEval{
@@ -300,7 +300,7 @@ func (b *BlockedEvals) unblock(computedClass string, index uint64) {
 		for id, wrapped := range b.escaped {
 			unblocked[wrapped.eval] = wrapped.token
 			delete(b.escaped, id)
-			delete(b.jobs, wrapped.eval.JobID)
+			delete(b.jobs, structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace))
 		}
 	}
 
@@ -319,7 +319,7 @@ func (b *BlockedEvals) unblock(computedClass string, index uint64) {
 		// The computed node class has never been seen by the eval so we unblock
 		// it.
 		unblocked[wrapped.eval] = wrapped.token
-		delete(b.jobs, wrapped.eval.JobID)
+		delete(b.jobs, structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace))
 		delete(b.captured, id)
 	}
 
@@ -349,7 +349,7 @@ func (b *BlockedEvals) UnblockFailed() {
 		if wrapped.eval.TriggeredBy == structs.EvalTriggerMaxPlans {
 			unblocked[wrapped.eval] = wrapped.token
 			delete(b.captured, id)
-			delete(b.jobs, wrapped.eval.JobID)
+			delete(b.jobs, structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace))
 		}
 	}
 
@@ -357,7 +357,7 @@ func (b *BlockedEvals) UnblockFailed() {
 		if wrapped.eval.TriggeredBy == structs.EvalTriggerMaxPlans {
 			unblocked[wrapped.eval] = wrapped.token
 			delete(b.escaped, id)
-			delete(b.jobs, wrapped.eval.JobID)
+			delete(b.jobs, structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace))
 			b.stats.TotalEscaped -= 1
 		}
 	}
@@ -368,6 +368,67 @@ func (b *BlockedEvals) UnblockFailed() {
 	}
 }
 
+// UnblockJobs unblocks the blocked evaluations of the given jobs, such as when
+// the quota limiting the resources of the jobs was raised.
+func (b *BlockedEvals) UnblockJobs(jobs map[structs.NamespacedID]struct{}) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	// Do nothing if not enabled
+	if !b.enabled {
+		return
+	}
+
+	unblocked := make(map[*structs.Evaluation]string, len(jobs))
+	for id, wrapped := range b.captured {
+		if _, ok := jobs[structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace)]; ok {
+			unblocked[wrapped.eval] = wrapped.token
+			delete(b.captured, id)
+			delete(b.jobs, structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace))
+		}
+	}
+
+	for id, wrapped := range b.escaped {
+		if _, ok := jobs[structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace)]; ok {
+			unblocked[wrapped.eval] = wrapped.token
+			delete(b.escaped, id)
+			delete(b.jobs, structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace))
+			b.stats.TotalEscaped -= 1
+		}
+	}
+
+	if l := len(unblocked); l > 0 {
+		b.stats.TotalBlocked -= l
+		b.evalBroker.EnqueueAll(unblocked)
+	}
+}
+
+// Untrack stops tracking the given blocked evaluations, such as when they were
+// deleted.
+func (b *BlockedEvals) Untrack(evalIDs []string) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	// Do nothing if not enabled
+	if !b.enabled {
+		return
+	}
+
+	for _, id := range evalIDs {
+		if wrapped, ok := b.captured[id]; ok {
+			delete(b.captured, id)
+			delete(b.jobs, structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace))
+			b.stats.TotalBlocked -= 1
+		}
+		if wrapped, ok := b.escaped[id]; ok {
+			delete(b.escaped, id)
+			delete(b.jobs, structs.NewNamespacedID(wrapped.eval.JobID, wrapped.eval.Namespace))
+			b.stats.TotalBlocked -= 1
+			b.stats.TotalEscaped -= 1
+		}
+	}
+}
+
 // GetDuplicates returns all the duplicate evaluations and blocks until the
 // passed timeout.
 func (b *BlockedEvals) GetDuplicates(timeout time.Duration) []*structs.Evaluation {
@@ -410,7 +471,7 @@ func (b *BlockedEvals) Flush() {
 	b.stats.TotalBlocked = 0
 	b.captured = make(map[string]wrappedEval)
 	b.escaped = make(map[string]wrappedEval)
-	b.jobs = make(map[string]struct{})
+	b.jobs = make(map[structs.NamespacedID]struct{})
 	b.duplicates = nil
 	b.capacityChangeCh = make(chan *capacityUpdate, unblockBuffer)
 	b.stopCh = make(chan struct{})
@@ -74,6 +74,43 @@ func TestBlockedEvals_Block_PriorUnblocks(t *testing.T) {
 	}
 }
 
+func TestBlockedEvals_Untrack(t *testing.T) {
+	blocked, _ := testBlockedEvals(t)
+
+	// Create an escaped and a captured blocked eval
+	e := mock.Eval()
+	e.Status = structs.EvalStatusBlocked
+	e.EscapedComputedClass = true
+	blocked.Block(e)
+
+	e2 := mock.Eval()
+	e2.Status = structs.EvalStatusBlocked
+	e2.ClassEligibility = map[string]bool{"v1:123": true}
+	blocked.Block(e2)
+
+	// Untrack both evals
+	blocked.Untrack([]string{e.ID, e2.ID})
+
+	bStats := blocked.Stats()
+	if bStats.TotalBlocked != 0 || bStats.TotalEscaped != 0 {
+		t.Fatalf("bad: %#v", bStats)
+	}
+
+	// A new eval of the job can be blocked rather than being a duplicate
+	e3 := mock.Eval()
+	e3.JobID = e.JobID
+	e3.Status = structs.EvalStatusBlocked
+	blocked.Block(e3)
+
+	bStats = blocked.Stats()
+	if bStats.TotalBlocked != 1 {
+		t.Fatalf("bad: %#v", bStats)
+	}
+	if dups := blocked.GetDuplicates(10 * time.Millisecond); len(dups) != 0 {
+		t.Fatalf("bad: %#v", dups)
+	}
+}
+
 func TestBlockedEvals_GetDuplicates(t *testing.T) {
 	blocked, _ := testBlockedEvals(t)
 
@@ -484,3 +521,39 @@ func TestBlockedEvals_UnblockFailed(t *testing.T) {
 		t.Fatalf("bad: %#v", blockedStats)
 	}
 }
+
+func TestBlockedEvals_UnblockJobs(t *testing.T) {
+	blocked, broker := testBlockedEvals(t)
+
+	// Create blocked evals for two jobs
+	e := mock.Eval()
+	e.Status = structs.EvalStatusBlocked
+	e.EscapedComputedClass = true
+	blocked.Block(e)
+
+	e2 := mock.Eval()
+	e2.Status = structs.EvalStatusBlocked
+	e2.ClassEligibility = map[string]bool{"v1:123": true}
+	blocked.Block(e2)
+
+	// Unblock only the first job
+	blocked.UnblockJobs(map[structs.NamespacedID]struct{}{
+		structs.NewNamespacedID(e.JobID, e.Namespace): struct{}{},
+	})
+
+	blockedStats := blocked.Stats()
+	if blockedStats.TotalBlocked != 1 || blockedStats.TotalEscaped != 0 {
+		t.Fatalf("bad: %#v", blockedStats)
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		// Verify Unblock caused an enqueue
+		brokerStats := broker.Stats()
+		if brokerStats.TotalReady != 1 {
+			return false, fmt.Errorf("bad: %#v", brokerStats)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+}
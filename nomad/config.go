@@ -94,6 +94,10 @@ type Config struct {
 	// RequireTLS ensures that all RPC traffic is protected with TLS
 	RequireTLS bool
 
+	// TLSConfig holds the TLS configuration of the RPC connections. When RPC
+	// TLS is enabled, all RPC traffic is protected with mutual TLS.
+	TLSConfig *config.TLSConfig
+
 	// SerfConfig is the configuration for the serf cluster
 	SerfConfig *serf.Config
 
@@ -120,6 +124,23 @@ type Config struct {
 	// that the workers dequeue for processing.
 	EnabledSchedulers []string
 
+	// PreemptionConfig controls for which scheduler types the allocations of
+	// lower priority jobs are preempted to place higher priority jobs. It is
+	// applied to the cluster when the server becomes leader.
+	PreemptionConfig structs.PreemptionConfig
+
+	// AutopilotConfig is the Autopilot configuration of the cluster. It is
+	// applied when the server becomes leader if the cluster has none yet.
+	AutopilotConfig structs.AutopilotConfig
+
+	// AutopilotInterval is how often the leader removes the dead servers and
+	// adds the stable servers to the Raft peers.
+	AutopilotInterval time.Duration
+
+	// ServerHealthInterval is how often the leader checks the health of the
+	// servers.
+	ServerHealthInterval time.Duration
+
 	// ReconcileInterval controls how often we reconcile the strongly
 	// consistent store with the Serf info. This is used to handle nodes
 	// that are force removed, as well as intermittent unavailability during
@@ -238,7 +259,12 @@ func DefaultConfig() *Config {
 		FailoverHeartbeatTTL:   300 * time.Second,
 		ConsulConfig:           config.DefaultConsulConfig(),
 		VaultConfig:            config.DefaultVaultConfig(),
+		TLSConfig:              &config.TLSConfig{},
 		RPCHoldTimeout:         5 * time.Second,
+		PreemptionConfig:       structs.DefaultPreemptionConfig(),
+		AutopilotConfig:        structs.DefaultAutopilotConfig(),
+		AutopilotInterval:      10 * time.Second,
+		ServerHealthInterval:   2 * time.Second,
 	}
 
 	// Enable all known schedulers by default
@@ -88,7 +88,8 @@ func (c *CoreScheduler) jobGC(eval *structs.Evaluation) error {
 	}
 
 	// Collect the allocations, evaluations and jobs to GC
-	var gcAlloc, gcEval, gcJob []string
+	var gcAlloc, gcEval []string
+	var gcJob []*structs.Job
 
 OUTER:
 	for i := iter.Next(); i != nil; i = iter.Next() {
@@ -99,7 +100,7 @@ OUTER:
 			continue
 		}
 
-		evals, err := c.snap.EvalsByJob(job.ID)
+		evals, err := c.snap.EvalsByJob(job.Namespace, job.ID)
 		if err != nil {
 			c.srv.logger.Printf("[ERR] sched.core: failed to get evals for job %s: %v", job.ID, err)
 			continue
@@ -124,7 +125,7 @@ OUTER:
 
 		// Job is eligible for garbage collection
 		if allEvalsGC {
-			gcJob = append(gcJob, job.ID)
+			gcJob = append(gcJob, job)
 			gcAlloc = append(gcAlloc, jobAlloc...)
 			gcEval = append(gcEval, jobEval...)
 		}
@@ -145,9 +146,10 @@ OUTER:
 	// Call to the leader to deregister the jobs.
 	for _, job := range gcJob {
 		req := structs.JobDeregisterRequest{
-			JobID: job,
+			JobID: job.ID,
 			WriteRequest: structs.WriteRequest{
-				Region: c.srv.config.Region,
+				Region:    c.srv.config.Region,
+				Namespace: job.Namespace,
 			},
 		}
 		var resp structs.JobDeregisterResponse
@@ -235,7 +237,7 @@ func (c *CoreScheduler) gcEval(eval *structs.Evaluation, thresholdIndex uint64,
 		}
 
 		// Check if the job is running
-		job, err := c.snap.JobByID(eval.JobID)
+		job, err := c.snap.JobByID(eval.Namespace, eval.JobID)
 		if err != nil {
 			return false, nil, err
 		}
@@ -178,7 +178,7 @@ func TestCoreScheduler_EvalGC_Batch(t *testing.T) {
 		t.Fatalf("bad: %v", outA2)
 	}
 
-	outB, err := state.JobByID(job.ID)
+	outB, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -589,7 +589,7 @@ func TestCoreScheduler_JobGC_OutstandingEvals(t *testing.T) {
 	}
 
 	// Should still exist
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -635,7 +635,7 @@ func TestCoreScheduler_JobGC_OutstandingEvals(t *testing.T) {
 	}
 
 	// Should not still exist
-	out, err = state.JobByID(job.ID)
+	out, err = state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -724,7 +724,7 @@ func TestCoreScheduler_JobGC_OutstandingAllocs(t *testing.T) {
 	}
 
 	// Should still exist
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -770,7 +770,7 @@ func TestCoreScheduler_JobGC_OutstandingAllocs(t *testing.T) {
 	}
 
 	// Should not still exist
-	out, err = state.JobByID(job.ID)
+	out, err = state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -866,7 +866,7 @@ func TestCoreScheduler_JobGC_OneShot(t *testing.T) {
 	}
 
 	// Should still exist
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -948,7 +948,7 @@ func TestCoreScheduler_JobGC_Force(t *testing.T) {
 	}
 
 	// Shouldn't still exist
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
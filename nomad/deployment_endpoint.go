@@ -0,0 +1,294 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// Deployment endpoint is used for manipulating deployments
+type Deployment struct {
+	srv *Server
+}
+
+// List is used to list the deployments in the system
+func (d *Deployment) List(args *structs.DeploymentListRequest, reply *structs.DeploymentListResponse) error {
+	if done, err := d.srv.forward("Deployment.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "list"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "deployment"}),
+		run: func() error {
+			// Capture all the deployments
+			snap, err := d.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = snap.DeploymentsByIDPrefix(prefix)
+			} else {
+				iter, err = snap.Deployments()
+			}
+			if err != nil {
+				return err
+			}
+
+			var deployments []*structs.Deployment
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				deployment := raw.(*structs.Deployment)
+				if deployment.Namespace != args.RequestNamespace() {
+					continue
+				}
+				deployments = append(deployments, deployment)
+			}
+			reply.Deployments = deployments
+
+			// Use the last index that affected the deployment table
+			index, err := snap.Index("deployment")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// GetDeployment is used to lookup a particular deployment
+func (d *Deployment) GetDeployment(args *structs.DeploymentSpecificRequest,
+	reply *structs.SingleDeploymentResponse) error {
+	if done, err := d.srv.forward("Deployment.GetDeployment", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "get_deployment"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Deployment: args.DeploymentID}),
+		run: func() error {
+			// Lookup the deployment
+			snap, err := d.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.DeploymentByID(args.DeploymentID)
+			if err != nil {
+				return err
+			}
+			if out != nil && out.Namespace != args.RequestNamespace() {
+				out = nil
+			}
+
+			// Setup the output
+			reply.Deployment = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the deployment table
+				index, err := snap.Index("deployment")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// Allocations is used to list the allocations placed by a deployment
+func (d *Deployment) Allocations(args *structs.DeploymentSpecificRequest,
+	reply *structs.AllocListResponse) error {
+	if done, err := d.srv.forward("Deployment.Allocations", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "allocations"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "allocs"}),
+		run: func() error {
+			// Capture the allocations
+			snap, err := d.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			allocs, err := snap.AllocsByDeployment(args.DeploymentID)
+			if err != nil {
+				return err
+			}
+
+			// Convert the allocations of the namespace to stubs
+			if len(allocs) > 0 {
+				reply.Allocations = make([]*structs.AllocListStub, 0, len(allocs))
+				for _, alloc := range allocs {
+					if alloc.Namespace != args.RequestNamespace() {
+						continue
+					}
+					reply.Allocations = append(reply.Allocations, alloc.Stub())
+				}
+			}
+
+			// Use the last index that affected the allocs table
+			index, err := snap.Index("allocs")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// Fail is used to force a running deployment to fail. The job is reverted to
+// its latest stable version if the deployment auto reverts.
+func (d *Deployment) Fail(args *structs.DeploymentFailRequest, reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Fail", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "fail"}, time.Now())
+
+	deployment, err := d.activeDeployment(args.DeploymentID, args.RequestNamespace())
+	if err != nil {
+		return err
+	}
+
+	return d.srv.failDeployment(deployment, structs.DeploymentStatusDescriptionFailedByUser, reply)
+}
+
+// Promote is used to promote the canaries of a running deployment and to mark
+// its allocations whose health is not yet known as healthy, allowing the
+// deployment to proceed. Only the task groups named by the request are
+// promoted if it names any.
+func (d *Deployment) Promote(args *structs.DeploymentPromoteRequest, reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Promote", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "promote"}, time.Now())
+
+	deployment, err := d.activeDeployment(args.DeploymentID, args.RequestNamespace())
+	if err != nil {
+		return err
+	}
+
+	for _, group := range args.Groups {
+		if _, ok := deployment.TaskGroups[group]; !ok {
+			return fmt.Errorf("deployment %q has no task group %q", deployment.ID, group)
+		}
+	}
+
+	// Find the running allocations without health
+	allocs, err := d.srv.fsm.State().AllocsByDeployment(deployment.ID)
+	if err != nil {
+		return err
+	}
+	var healthy []string
+	for _, alloc := range allocs {
+		if alloc.TerminalStatus() || alloc.DeploymentStatus.HasHealth() ||
+			!args.PromotesGroup(alloc.TaskGroup) {
+			continue
+		}
+		healthy = append(healthy, alloc.ID)
+	}
+	promote := false
+	for name, state := range deployment.TaskGroups {
+		if state.RequiresPromotion() && args.PromotesGroup(name) {
+			promote = true
+		}
+	}
+	if len(healthy) == 0 && !promote {
+		return fmt.Errorf("deployment %q has no allocations to promote", deployment.ID)
+	}
+
+	if len(healthy) != 0 {
+		req := structs.DeploymentAllocHealthRequest{
+			DeploymentID:         deployment.ID,
+			HealthyAllocationIDs: healthy,
+			WriteRequest:         args.WriteRequest,
+		}
+		_, index, err := d.srv.raftApply(structs.DeploymentAllocHealthRequestType, &req)
+		if err != nil {
+			d.srv.logger.Printf("[ERR] nomad.deployment: promote failed: %v", err)
+			return err
+		}
+		reply.DeploymentModifyIndex = index
+		reply.Index = index
+	}
+
+	if !promote {
+		return nil
+	}
+
+	// Promote the canaries and continue the rollout
+	_, index, err := d.srv.raftApply(structs.DeploymentPromoteRequestType, args)
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: promote failed: %v", err)
+		return err
+	}
+	reply.DeploymentModifyIndex = index
+	reply.Index = index
+
+	job, err := d.srv.fsm.State().JobByID(deployment.Namespace, deployment.JobID)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return fmt.Errorf("job %q not found", deployment.JobID)
+	}
+	eval, evalIndex, err := d.srv.createDeploymentEval(job)
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: eval create failed: %v", err)
+		return err
+	}
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = evalIndex
+	reply.Index = evalIndex
+	return nil
+}
+
+// activeDeployment returns the deployment with the given ID or an error if it
+// doesn't exist in the namespace or is no longer running.
+func (d *Deployment) activeDeployment(id, namespace string) (*structs.Deployment, error) {
+	if id == "" {
+		return nil, fmt.Errorf("missing deployment ID")
+	}
+
+	deployment, err := d.srv.fsm.State().DeploymentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if deployment == nil || deployment.Namespace != namespace {
+		return nil, fmt.Errorf("deployment %q not found", id)
+	}
+	if !deployment.Active() {
+		return nil, fmt.Errorf("deployment %q is %s", id, deployment.Status)
+	}
+	return deployment, nil
+}
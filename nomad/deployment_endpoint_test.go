@@ -0,0 +1,463 @@
+package nomad
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestDeploymentEndpoint_List(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployment
+	deployment := structs.NewDeployment(mock.Job())
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 1}
+	state := s1.fsm.State()
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployments
+	get := &structs.DeploymentListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if len(resp.Deployments) != 1 || resp.Deployments[0].ID != deployment.ID {
+		t.Fatalf("bad: %#v", resp.Deployments)
+	}
+
+	// Lookup the deployments by prefix
+	get = &structs.DeploymentListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global", Prefix: deployment.ID[:4]},
+	}
+	var resp2 structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.Deployments) != 1 || resp2.Deployments[0].ID != deployment.ID {
+		t.Fatalf("bad: %#v", resp2.Deployments)
+	}
+}
+
+func TestDeploymentEndpoint_GetDeployment(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployment and one of its allocations
+	job := mock.Job()
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	alloc := mock.Alloc()
+	alloc.DeploymentID = deployment.ID
+	state := s1.fsm.State()
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJobSummary(1001, mock.JobSummary(alloc.JobID)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployment
+	get := &structs.DeploymentSpecificRequest{
+		DeploymentID: deployment.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleDeploymentResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1002 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1002)
+	}
+	out, err := state.DeploymentByID(deployment.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out, resp.Deployment) {
+		t.Fatalf("bad: %#v %#v", out, resp.Deployment)
+	}
+
+	// Lookup its allocations
+	var resp2 structs.AllocListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Allocations", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.Allocations) != 1 || resp2.Allocations[0].ID != alloc.ID {
+		t.Fatalf("bad: %#v", resp2.Allocations)
+	}
+}
+
+func TestDeploymentEndpoint_Namespace_Isolation(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a deployment of a job of another namespace and its allocation
+	job := mock.Job()
+	job.Namespace = "engineering"
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.Namespace = job.Namespace
+	alloc.DeploymentID = deployment.ID
+	state := s1.fsm.State()
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The deployment and its allocations are hidden from the default namespace
+	list := &structs.DeploymentListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var listResp structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", list, &listResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(listResp.Deployments) != 0 {
+		t.Fatalf("bad: %#v", listResp.Deployments)
+	}
+
+	get := &structs.DeploymentSpecificRequest{
+		DeploymentID: deployment.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var single structs.SingleDeploymentResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &single); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if single.Deployment != nil {
+		t.Fatalf("bad: %#v", single.Deployment)
+	}
+	var allocs structs.AllocListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Allocations", get, &allocs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(allocs.Allocations) != 0 {
+		t.Fatalf("bad: %#v", allocs.Allocations)
+	}
+
+	// It can't be promoted or failed from the default namespace
+	var update structs.DeploymentUpdateResponse
+	promote := &structs.DeploymentPromoteRequest{
+		DeploymentID: deployment.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", promote, &update)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected deployment not found but got: %v", err)
+	}
+	fail := &structs.DeploymentFailRequest{
+		DeploymentID: deployment.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	err = msgpackrpc.CallWithCodec(codec, "Deployment.Fail", fail, &update)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected deployment not found but got: %v", err)
+	}
+
+	// The deployment is found from the namespace of its job
+	list.Namespace = "engineering"
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", list, &listResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(listResp.Deployments) != 1 || listResp.Deployments[0].ID != deployment.ID {
+		t.Fatalf("bad: %#v", listResp.Deployments)
+	}
+	get.Namespace = "engineering"
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Allocations", get, &allocs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(allocs.Allocations) != 1 || allocs.Allocations[0].ID != alloc.ID {
+		t.Fatalf("bad: %#v", allocs.Allocations)
+	}
+}
+
+func TestDeploymentEndpoint_Promote(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a deployment of a manually health checked job
+	job := mock.Job()
+	job.Update = structs.UpdateStrategy{
+		MaxParallel: 1,
+		HealthCheck: structs.UpdateHealthCheckManual,
+	}
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	alloc := mock.Alloc()
+	alloc.DeploymentID = deployment.ID
+	state := s1.fsm.State()
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := &structs.DeploymentPromoteRequest{
+		DeploymentID: deployment.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DeploymentStatus.IsHealthy() {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Nothing is left to promote
+	err = msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "no allocations to promote") {
+		t.Fatalf("expected promote error, got: %v", err)
+	}
+}
+
+func TestDeploymentEndpoint_Promote_Canaries(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a deployment with a healthy canary
+	job := mock.Job()
+	job.Update = structs.UpdateStrategy{
+		MaxParallel: 1,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+		Canary:      1,
+	}
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2, DesiredCanaries: 1}
+	isHealthy := true
+	alloc := mock.Alloc()
+	alloc.DeploymentID = deployment.ID
+	alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &isHealthy, Canary: true}
+	state := s1.fsm.State()
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := &structs.DeploymentPromoteRequest{
+		DeploymentID: deployment.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	out, err := state.DeploymentByID(deployment.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.RequiresPromotion() {
+		t.Fatalf("bad: %#v", out.TaskGroups["web"])
+	}
+
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.JobID != job.ID || eval.TriggeredBy != structs.EvalTriggerDeploymentWatcher {
+		t.Fatalf("bad: %#v", eval)
+	}
+}
+
+func TestDeploymentEndpoint_Promote_Groups(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a deployment with canaries in two task groups
+	job := mock.Job()
+	job.Update = structs.UpdateStrategy{
+		MaxParallel: 1,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+		Canary:      1,
+	}
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2, DesiredCanaries: 1}
+	deployment.TaskGroups["db"] = &structs.DeploymentState{DesiredTotal: 2, DesiredCanaries: 1}
+	state := s1.fsm.State()
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promoting an unknown task group fails
+	req := &structs.DeploymentPromoteRequest{
+		DeploymentID: deployment.ID,
+		Groups:       []string{"cache"},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.DeploymentUpdateResponse
+	err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "no task group") {
+		t.Fatalf("expected unknown task group error, got %v", err)
+	}
+
+	// Promote only the web task group
+	req.Groups = []string{"web"}
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	out, err := state.DeploymentByID(deployment.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.TaskGroups["web"].Promoted || out.TaskGroups["db"].Promoted {
+		t.Fatalf("bad: %#v %#v", out.TaskGroups["web"], out.TaskGroups["db"])
+	}
+
+	// The web task group has nothing left to promote
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestDeploymentEndpoint_Fail_AutoRevert(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register a stable version of the job
+	job := mock.Job()
+	job.Update = structs.UpdateStrategy{
+		MaxParallel: 1,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+		AutoRevert:  true,
+	}
+	state := s1.fsm.State()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stable := structs.NewDeployment(job)
+	if err := state.UpsertDeployment(1001, stable); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	update := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID: stable.ID,
+			Status:       structs.DeploymentStatusSuccessful,
+		},
+	}
+	if err := state.UpdateDeploymentStatus(1002, update); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Register a new version that is being deployed
+	job2 := job.Copy()
+	job2.Priority = 90
+	if err := state.UpsertJob(1003, job2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	deployment := structs.NewDeployment(job2)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{AutoRevert: true, DesiredTotal: 10}
+	if err := state.UpsertDeployment(1004, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := &structs.DeploymentFailRequest{
+		DeploymentID: deployment.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Fail", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.RevertedJobVersion == nil || *resp.RevertedJobVersion != 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	out, err := state.DeploymentByID(deployment.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusFailed ||
+		out.StatusDescription != structs.DeploymentStatusDescriptionRollback(structs.DeploymentStatusDescriptionFailedByUser, 0) {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The job was reverted to the stable version
+	current, err := state.JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if current.Version != 2 || current.Priority != job.Priority {
+		t.Fatalf("bad: %#v", current)
+	}
+
+	// A failed deployment can't be failed again
+	err = msgpackrpc.CallWithCodec(codec, "Deployment.Fail", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "is failed") {
+		t.Fatalf("expected fail error, got: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+const (
+	// deploymentWatchInterval is the interval at which the running
+	// deployments are checked if no deployment or allocation changed.
+	deploymentWatchInterval = 30 * time.Second
+)
+
+// watchDeployments is a long lived function that drives the running
+// deployments while leader. A deployment whose allocations became healthy is
+// continued by an evaluation, it completes once all of its allocations are
+// healthy and fails as soon as one of them is unhealthy.
+func (s *Server) watchDeployments(stopCh chan struct{}) {
+	ticker := time.NewTicker(deploymentWatchInterval)
+	defer ticker.Stop()
+
+	// healthy tracks the healthy allocations of the running deployments as of
+	// the last evaluation created for them.
+	healthy := make(map[string]int)
+
+	items := watch.NewItems(watch.Item{Table: "deployment"}, watch.Item{Table: "allocs"})
+	notifyCh := make(chan struct{}, 1)
+	for {
+		// Watch before checking so no change is missed
+		state := s.fsm.State()
+		state.Watch(items, notifyCh)
+
+		s.checkDeployments(healthy)
+
+		select {
+		case <-stopCh:
+			state.StopWatch(items, notifyCh)
+			return
+		case <-notifyCh:
+		case <-ticker.C:
+		}
+		state.StopWatch(items, notifyCh)
+	}
+}
+
+// checkDeployments handles the progress of the running deployments. healthy
+// is updated with the number of healthy allocations of each running
+// deployment that an evaluation was created for.
+func (s *Server) checkDeployments(healthy map[string]int) {
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to snapshot state: %v", err)
+		return
+	}
+
+	iter, err := snap.Deployments()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to list deployments: %v", err)
+		return
+	}
+
+	running := make(map[string]struct{})
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		d := raw.(*structs.Deployment)
+		if !d.Active() {
+			continue
+		}
+
+		switch {
+		case d.HasUnhealthy():
+			var resp structs.DeploymentUpdateResponse
+			if err := s.failDeployment(d, structs.DeploymentStatusDescriptionFailedAllocations, &resp); err != nil {
+				s.logger.Printf("[ERR] nomad: failed to fail deployment %q: %v", d.ID, err)
+			}
+		case d.Healthy():
+			if err := s.updateDeploymentStatus(d, structs.DeploymentStatusSuccessful,
+				structs.DeploymentStatusDescriptionSuccessful); err != nil {
+				s.logger.Printf("[ERR] nomad: failed to complete deployment %q: %v", d.ID, err)
+			}
+		default:
+			running[d.ID] = struct{}{}
+			n := d.HealthyAllocs()
+			if n <= healthy[d.ID] {
+				continue
+			}
+
+			// Continue the rollout now that more allocations are healthy
+			job, err := snap.JobByID(d.Namespace, d.JobID)
+			if err != nil || job == nil {
+				s.logger.Printf("[ERR] nomad: failed to lookup job %q of deployment %q: %v", d.JobID, d.ID, err)
+				continue
+			}
+			if _, _, err := s.createDeploymentEval(job); err != nil {
+				s.logger.Printf("[ERR] nomad: failed to create evaluation for deployment %q: %v", d.ID, err)
+				continue
+			}
+			healthy[d.ID] = n
+		}
+	}
+
+	for id := range healthy {
+		if _, ok := running[id]; !ok {
+			delete(healthy, id)
+		}
+	}
+}
+
+// createDeploymentEval creates an evaluation to continue the deployment of
+// the given job. It returns the evaluation and the index it was created at.
+func (s *Server) createDeploymentEval(job *structs.Job) (*structs.Evaluation, uint64, error) {
+	eval := &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       job.Priority,
+		Type:           job.Type,
+		TriggeredBy:    structs.EvalTriggerDeploymentWatcher,
+		JobID:          job.ID,
+		Namespace:      job.Namespace,
+		JobModifyIndex: job.JobModifyIndex,
+		Status:         structs.EvalStatusPending,
+	}
+	update := &structs.EvalUpdateRequest{
+		Evals:        []*structs.Evaluation{eval},
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+
+	_, index, err := s.raftApply(structs.EvalUpdateRequestType, update)
+	if err != nil {
+		return nil, 0, err
+	}
+	return eval, index, nil
+}
+
+// updateDeploymentStatus sets the status of a deployment
+func (s *Server) updateDeploymentStatus(d *structs.Deployment, status, desc string) error {
+	req := structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            status,
+			StatusDescription: desc,
+		},
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	_, _, err := s.raftApply(structs.DeploymentStatusUpdateRequestType, &req)
+	return err
+}
+
+// failDeployment fails a running deployment with the given description. If
+// the deployment auto reverts, its job is reverted to the latest stable
+// version prior to the deployed one.
+func (s *Server) failDeployment(d *structs.Deployment, desc string, reply *structs.DeploymentUpdateResponse) error {
+	// Find the version of the job to revert to
+	var revert *structs.Job
+	if d.AutoRevert() {
+		versions, err := s.fsm.State().JobVersionsByID(d.Namespace, d.JobID)
+		if err != nil {
+			return err
+		}
+		for _, version := range versions {
+			if version.Stable && version.Version < d.JobVersion {
+				revert = version
+				break
+			}
+		}
+	}
+	if revert != nil {
+		desc = structs.DeploymentStatusDescriptionRollback(desc, revert.Version)
+	}
+
+	req := structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: desc,
+		},
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	_, index, err := s.raftApply(structs.DeploymentStatusUpdateRequestType, &req)
+	if err != nil {
+		return err
+	}
+	reply.DeploymentModifyIndex = index
+	reply.Index = index
+
+	if revert == nil {
+		return nil
+	}
+
+	// Revert the job unless it changed since the deployment started
+	jobVersion := d.JobVersion
+	revertReq := &structs.JobRevertRequest{
+		JobID:               d.JobID,
+		JobVersion:          revert.Version,
+		EnforcePriorVersion: &jobVersion,
+		WriteRequest: structs.WriteRequest{
+			Region:    s.config.Region,
+			Namespace: d.Namespace,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	if err := s.endpoints.Job.Revert(revertReq, &resp); err != nil {
+		return fmt.Errorf("failed to revert job %q to version %d: %v", d.JobID, revert.Version, err)
+	}
+
+	s.logger.Printf("[INFO] nomad: deployment %q failed, reverted job %q to version %d",
+		d.ID, d.JobID, revert.Version)
+	reverted := revert.Version
+	reply.RevertedJobVersion = &reverted
+	reply.EvalID = resp.EvalID
+	reply.EvalCreateIndex = resp.EvalCreateIndex
+	reply.Index = resp.Index
+	return nil
+}
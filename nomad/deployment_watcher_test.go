@@ -0,0 +1,143 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestServer_WatchDeployments(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a deployment with two placed allocations
+	job := mock.Job()
+	job.Update = structs.UpdateStrategy{
+		MaxParallel: 2,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+	}
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.DeploymentID = deployment.ID
+	alloc2 := mock.Alloc()
+	alloc2.Job = job
+	alloc2.JobID = job.ID
+	alloc2.DeploymentID = deployment.ID
+
+	state := s1.fsm.State()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1001, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc, alloc2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An allocation becoming healthy continues the deployment
+	healthy := true
+	update := alloc.Copy()
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+	if err := state.UpdateAllocsFromClient(1003, []*structs.Allocation{update}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		evals, err := state.EvalsByJob(job.Namespace, job.ID)
+		if err != nil {
+			return false, err
+		}
+		if len(evals) != 1 {
+			return false, fmt.Errorf("expected one eval, got %d", len(evals))
+		}
+		if evals[0].TriggeredBy != structs.EvalTriggerDeploymentWatcher {
+			return false, fmt.Errorf("bad eval: %#v", evals[0])
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The deployment completes once all its allocations are healthy
+	update2 := alloc2.Copy()
+	update2.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+	if err := state.UpdateAllocsFromClient(1004, []*structs.Allocation{update2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.DeploymentByID(deployment.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusSuccessful {
+			return false, fmt.Errorf("bad status: %q", out.Status)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	current, err := state.JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !current.Stable {
+		t.Fatalf("job not stable: %#v", current)
+	}
+}
+
+func TestServer_WatchDeployments_Unhealthy(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a deployment with a placed allocation
+	job := mock.Job()
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	alloc := mock.Alloc()
+	alloc.DeploymentID = deployment.ID
+
+	state := s1.fsm.State()
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An unhealthy allocation fails the deployment
+	unhealthy := false
+	update := alloc.Copy()
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &unhealthy}
+	if err := state.UpdateAllocsFromClient(1002, []*structs.Allocation{update}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.DeploymentByID(deployment.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusFailed ||
+			out.StatusDescription != structs.DeploymentStatusDescriptionFailedAllocations {
+			return false, fmt.Errorf("bad status: %q %q", out.Status, out.StatusDescription)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
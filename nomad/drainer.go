@@ -0,0 +1,176 @@
+package nomad
+
+import (
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+const (
+	// drainWatchInterval is the interval at which the draining nodes are
+	// checked if no node or allocation changed.
+	drainWatchInterval = 30 * time.Second
+)
+
+// watchDrains is a long lived function that drives the throttled migrations
+// off draining nodes while leader. Once the replacement of a migrated
+// allocation is running, the job is evaluated again so that its next
+// allocations are migrated.
+func (s *Server) watchDrains(stopCh chan struct{}) {
+	ticker := time.NewTicker(drainWatchInterval)
+	defer ticker.Stop()
+
+	// running tracks the running replacements of the allocations migrated
+	// off each draining node, by job, as of the last evaluation created.
+	running := make(map[string]map[structs.NamespacedID]int)
+
+	items := watch.NewItems(watch.Item{Table: "nodes"}, watch.Item{Table: "allocs"})
+	notifyCh := make(chan struct{}, 1)
+	for {
+		// Watch before checking so no change is missed
+		state := s.fsm.State()
+		state.Watch(items, notifyCh)
+
+		s.checkDrains(running)
+
+		select {
+		case <-stopCh:
+			state.StopWatch(items, notifyCh)
+			return
+		case <-notifyCh:
+		case <-ticker.C:
+		}
+		state.StopWatch(items, notifyCh)
+	}
+}
+
+// checkDrains creates an evaluation for the jobs whose allocations are being
+// migrated off a draining node once more of their replacements are running.
+// running is updated with the number of running replacements of each job
+// that an evaluation was created for.
+func (s *Server) checkDrains(running map[string]map[structs.NamespacedID]int) {
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to snapshot state: %v", err)
+		return
+	}
+
+	iter, err := snap.Nodes()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to list nodes: %v", err)
+		return
+	}
+
+	draining := make(map[string]struct{})
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		node := raw.(*structs.Node)
+		if !node.Drain || structs.ShouldDrainNode(node.Status) {
+			continue
+		}
+		draining[node.ID] = struct{}{}
+
+		counts, err := drainReplacements(snap, node.ID)
+		if err != nil {
+			s.logger.Printf("[ERR] nomad: failed to check drain of node %q: %v", node.ID, err)
+			continue
+		}
+
+		prev := running[node.ID]
+		if prev == nil {
+			prev = make(map[structs.NamespacedID]int)
+			running[node.ID] = prev
+		}
+		for jobID, n := range counts {
+			if n <= prev[jobID] {
+				continue
+			}
+
+			// Continue the migrations now that more replacements are running
+			job, err := snap.JobByID(jobID.Namespace, jobID.ID)
+			if err != nil || job == nil {
+				s.logger.Printf("[ERR] nomad: failed to lookup job %q draining off node %q: %v", jobID, node.ID, err)
+				continue
+			}
+			if err := s.createDrainEval(job); err != nil {
+				s.logger.Printf("[ERR] nomad: failed to create evaluation for job %q draining off node %q: %v", jobID, node.ID, err)
+				continue
+			}
+			prev[jobID] = n
+		}
+		for jobID := range prev {
+			if _, ok := counts[jobID]; !ok {
+				delete(prev, jobID)
+			}
+		}
+	}
+
+	for id := range running {
+		if _, ok := draining[id]; !ok {
+			delete(running, id)
+		}
+	}
+}
+
+// drainReplacements returns the number of running replacements of the
+// allocations migrated off the node, by job, for the jobs that still have
+// allocations on the node.
+func drainReplacements(snap *state.StateSnapshot, nodeID string) (map[structs.NamespacedID]int, error) {
+	allocs, err := snap.AllocsByNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	onNode := make(map[string]struct{}, len(allocs))
+	jobs := make(map[structs.NamespacedID]struct{})
+	for _, alloc := range allocs {
+		onNode[alloc.ID] = struct{}{}
+		if !alloc.TerminalStatus() && alloc.Job != nil && alloc.Job.Type != structs.JobTypeSystem {
+			jobs[structs.NewNamespacedID(alloc.JobID, alloc.Namespace)] = struct{}{}
+		}
+	}
+
+	counts := make(map[structs.NamespacedID]int, len(jobs))
+	for jobID := range jobs {
+		jobAllocs, err := snap.AllocsByJob(jobID.Namespace, jobID.ID)
+		if err != nil {
+			return nil, err
+		}
+		n := 0
+		for _, alloc := range jobAllocs {
+			if _, ok := onNode[alloc.PreviousAllocation]; ok &&
+				alloc.ClientStatus == structs.AllocClientStatusRunning {
+				n++
+			}
+		}
+		counts[jobID] = n
+	}
+	return counts, nil
+}
+
+// createDrainEval creates an evaluation to continue migrating the allocations
+// of the given job off draining nodes.
+func (s *Server) createDrainEval(job *structs.Job) error {
+	eval := &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       job.Priority,
+		Type:           job.Type,
+		TriggeredBy:    structs.EvalTriggerNodeDrain,
+		JobID:          job.ID,
+		Namespace:      job.Namespace,
+		JobModifyIndex: job.JobModifyIndex,
+		Status:         structs.EvalStatusPending,
+	}
+	update := &structs.EvalUpdateRequest{
+		Evals:        []*structs.Evaluation{eval},
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+
+	_, _, err := s.raftApply(structs.EvalUpdateRequestType, update)
+	return err
+}
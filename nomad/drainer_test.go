@@ -0,0 +1,75 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestServer_WatchDrains(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a draining node with an allocation left and one migrated
+	node := mock.Node()
+	job := mock.Job()
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	migrated := mock.Alloc()
+	migrated.Job = job
+	migrated.JobID = job.ID
+	migrated.NodeID = node.ID
+	migrated.DesiredStatus = structs.AllocDesiredStatusStop
+
+	// The replacement of the migrated allocation is pending elsewhere
+	replacement := mock.Alloc()
+	replacement.Job = job
+	replacement.JobID = job.ID
+	replacement.PreviousAllocation = migrated.ID
+	replacement.ClientStatus = structs.AllocClientStatusPending
+
+	state := s1.fsm.State()
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpdateNodeDrain(1001, node.ID, true, &structs.DrainStrategy{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJob(1002, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc, migrated, replacement}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The replacement becoming running continues the migrations
+	update := replacement.Copy()
+	update.ClientStatus = structs.AllocClientStatusRunning
+	if err := state.UpdateAllocsFromClient(1004, []*structs.Allocation{update}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		evals, err := state.EvalsByJob(job.Namespace, job.ID)
+		if err != nil {
+			return false, err
+		}
+		if len(evals) != 1 {
+			return false, fmt.Errorf("expected one eval, got %d", len(evals))
+		}
+		if evals[0].TriggeredBy != structs.EvalTriggerNodeDrain {
+			return false, fmt.Errorf("bad eval: %#v", evals[0])
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
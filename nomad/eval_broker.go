@@ -52,11 +52,13 @@ type EvalBroker struct {
 	// and is used to eventually fail an evaluation.
 	evals map[string]int
 
-	// jobEvals tracks queued evaluations by JobID to serialize them
-	jobEvals map[string]string
+	// jobEvals tracks queued evaluations by namespaced JobID to serialize
+	// them
+	jobEvals map[structs.NamespacedID]string
 
-	// blocked tracks the blocked evaluations by JobID in a priority queue
-	blocked map[string]PendingEvaluations
+	// blocked tracks the blocked evaluations by namespaced JobID in a
+	// priority queue
+	blocked map[structs.NamespacedID]PendingEvaluations
 
 	// ready tracks the ready jobs by scheduler in a priority queue
 	ready map[string]PendingEvaluations
@@ -76,6 +78,12 @@ type EvalBroker struct {
 	// timeWait has evaluations that are waiting for time to elapse
 	timeWait map[string]*time.Timer
 
+	// nacked tracks the delivery attempts of Nack'd evaluations by ID until
+	// the leader persists them. nackedCh is used to signal that an
+	// evaluation was Nack'd.
+	nacked   map[string]int
+	nackedCh chan struct{}
+
 	l sync.RWMutex
 }
 
@@ -105,13 +113,15 @@ func NewEvalBroker(timeout time.Duration, deliveryLimit int) (*EvalBroker, error
 		enabled:       false,
 		stats:         new(BrokerStats),
 		evals:         make(map[string]int),
-		jobEvals:      make(map[string]string),
-		blocked:       make(map[string]PendingEvaluations),
+		jobEvals:      make(map[structs.NamespacedID]string),
+		blocked:       make(map[structs.NamespacedID]PendingEvaluations),
 		ready:         make(map[string]PendingEvaluations),
 		unack:         make(map[string]*unackEval),
 		waiting:       make(map[string]chan struct{}),
 		requeue:       make(map[string]*structs.Evaluation),
 		timeWait:      make(map[string]*time.Timer),
+		nacked:        make(map[string]int),
+		nackedCh:      make(chan struct{}, 1),
 	}
 	b.stats.ByScheduler = make(map[string]*SchedulerStats)
 	return b, nil
@@ -182,7 +192,13 @@ func (b *EvalBroker) processEnqueue(eval *structs.Evaluation, token string) {
 		}
 		return
 	} else if b.enabled {
-		b.evals[eval.ID] = 0
+		// Resume counting from the persisted attempts such that evaluations
+		// aren't redelivered indefinitely across leader elections.
+		b.evals[eval.ID] = eval.DeliveryAttempts
+		if eval.DeliveryAttempts != 0 && eval.DeliveryAttempts >= b.deliveryLimit {
+			b.enqueueLocked(eval, failedQueue)
+			return
+		}
 	}
 
 	// Check if we need to enforce a wait
@@ -202,6 +218,11 @@ func (b *EvalBroker) processEnqueue(eval *structs.Evaluation, token string) {
 func (b *EvalBroker) enqueueWaiting(eval *structs.Evaluation) {
 	b.l.Lock()
 	defer b.l.Unlock()
+
+	// The evaluation may have been removed while its timer was firing
+	if _, ok := b.timeWait[eval.ID]; !ok {
+		return
+	}
 	delete(b.timeWait, eval.ID)
 	b.stats.TotalWaiting -= 1
 	b.enqueueLocked(eval, eval.Type)
@@ -215,13 +236,14 @@ func (b *EvalBroker) enqueueLocked(eval *structs.Evaluation, queue string) {
 	}
 
 	// Check if there is an evaluation for this JobID pending
-	pendingEval := b.jobEvals[eval.JobID]
+	jobID := structs.NewNamespacedID(eval.JobID, eval.Namespace)
+	pendingEval := b.jobEvals[jobID]
 	if pendingEval == "" {
-		b.jobEvals[eval.JobID] = eval.ID
+		b.jobEvals[jobID] = eval.ID
 	} else if pendingEval != eval.ID {
-		blocked := b.blocked[eval.JobID]
+		blocked := b.blocked[jobID]
 		heap.Push(&blocked, eval)
-		b.blocked[eval.JobID] = blocked
+		b.blocked[jobID] = blocked
 		b.stats.TotalBlocked += 1
 		return
 	}
@@ -255,6 +277,85 @@ func (b *EvalBroker) enqueueLocked(eval *structs.Evaluation, queue string) {
 	}
 }
 
+// Reprioritize replaces the queued copies of the given evaluations so that
+// they are dequeued according to their updated priority. Evaluations that are
+// not waiting in a ready or blocked queue are ignored.
+func (b *EvalBroker) Reprioritize(evals []*structs.Evaluation) {
+	b.l.Lock()
+	defer b.l.Unlock()
+	for _, eval := range evals {
+		if b.ready[eval.Type].replace(eval) {
+			continue
+		}
+		b.blocked[structs.NewNamespacedID(eval.JobID, eval.Namespace)].replace(eval)
+	}
+}
+
+// Remove drops the given evaluations from the broker so that they are never
+// dequeued, as when they are deleted. Evaluations that are outstanding are
+// left for their scheduler to Ack or Nack.
+func (b *EvalBroker) Remove(evalIDs []string) {
+	b.l.Lock()
+	defer b.l.Unlock()
+	for _, evalID := range evalIDs {
+		if _, ok := b.unack[evalID]; ok {
+			continue
+		}
+		delete(b.evals, evalID)
+		delete(b.nacked, evalID)
+
+		// Stop waiting for the evaluation's wait time to elapse
+		if timer, ok := b.timeWait[evalID]; ok {
+			timer.Stop()
+			delete(b.timeWait, evalID)
+			b.stats.TotalWaiting -= 1
+			continue
+		}
+
+		// Drop the evaluation from the ready queues, letting the next blocked
+		// evaluation of its job become ready
+		for queue, pending := range b.ready {
+			eval := pending.remove(evalID)
+			if eval == nil {
+				continue
+			}
+			b.ready[queue] = pending
+			b.stats.TotalReady -= 1
+			b.stats.ByScheduler[queue].Ready -= 1
+			jobID := structs.NewNamespacedID(eval.JobID, eval.Namespace)
+			if b.jobEvals[jobID] == evalID {
+				delete(b.jobEvals, jobID)
+				if blocked := b.blocked[jobID]; len(blocked) != 0 {
+					raw := heap.Pop(&blocked)
+					if len(blocked) > 0 {
+						b.blocked[jobID] = blocked
+					} else {
+						delete(b.blocked, jobID)
+					}
+					next := raw.(*structs.Evaluation)
+					b.stats.TotalBlocked -= 1
+					b.enqueueLocked(next, next.Type)
+				}
+			}
+			break
+		}
+
+		// Drop the evaluation from the blocked queues
+		for jobID, blocked := range b.blocked {
+			if blocked.remove(evalID) == nil {
+				continue
+			}
+			if len(blocked) > 0 {
+				b.blocked[jobID] = blocked
+			} else {
+				delete(b.blocked, jobID)
+			}
+			b.stats.TotalBlocked -= 1
+			break
+		}
+	}
+}
+
 // Dequeue is used to perform a blocking dequeue
 func (b *EvalBroker) Dequeue(schedulers []string, timeout time.Duration) (*structs.Evaluation, string, error) {
 	var timeoutTimer *time.Timer
@@ -474,7 +575,7 @@ func (b *EvalBroker) Ack(evalID, token string) error {
 	if unack.Token != token {
 		return fmt.Errorf("Token does not match for Evaluation ID")
 	}
-	jobID := unack.Eval.JobID
+	jobID := structs.NewNamespacedID(unack.Eval.JobID, unack.Eval.Namespace)
 
 	// Ensure we were able to stop the timer
 	if !unack.NackTimer.Stop() {
@@ -494,6 +595,7 @@ func (b *EvalBroker) Ack(evalID, token string) error {
 	delete(b.unack, evalID)
 	delete(b.evals, evalID)
 	delete(b.jobEvals, jobID)
+	delete(b.nacked, evalID)
 
 	// Check if there are any blocked evaluations
 	if blocked := b.blocked[jobID]; len(blocked) != 0 {
@@ -545,6 +647,13 @@ func (b *EvalBroker) Nack(evalID, token string) error {
 	bySched := b.stats.ByScheduler[unack.Eval.Type]
 	bySched.Unacked -= 1
 
+	// Track the delivery attempts so the leader can persist them
+	b.nacked[evalID] = b.evals[evalID]
+	select {
+	case b.nackedCh <- struct{}{}:
+	default:
+	}
+
 	// Check if we've hit the delivery limit, and re-enqueue
 	// in the failedQueue
 	if b.evals[evalID] >= b.deliveryLimit {
@@ -555,6 +664,37 @@ func (b *EvalBroker) Nack(evalID, token string) error {
 	return nil
 }
 
+// GetNackedDeliveries returns the delivery attempts of the evaluations Nack'd
+// since the last call, keyed by evaluation ID. It blocks until an evaluation
+// is Nack'd or the passed timeout is reached.
+func (b *EvalBroker) GetNackedDeliveries(timeout time.Duration) map[string]int {
+	var timeoutTimer *time.Timer
+	var timeoutCh <-chan time.Time
+SCAN:
+	b.l.Lock()
+	if len(b.nacked) != 0 {
+		nacked := b.nacked
+		b.nacked = make(map[string]int)
+		b.l.Unlock()
+		return nacked
+	}
+	b.l.Unlock()
+
+	// Create the timer
+	if timeoutTimer == nil && timeout != 0 {
+		timeoutTimer = time.NewTimer(timeout)
+		timeoutCh = timeoutTimer.C
+		defer timeoutTimer.Stop()
+	}
+
+	select {
+	case <-timeoutCh:
+		return nil
+	case <-b.nackedCh:
+		goto SCAN
+	}
+}
+
 // PauseNackTimeout is used to pause the Nack timeout for an eval that is making
 // progress but is in a potentially unbounded operation such as the plan queue.
 func (b *EvalBroker) PauseNackTimeout(evalID, token string) error {
@@ -617,11 +757,12 @@ func (b *EvalBroker) Flush() {
 	b.stats.TotalWaiting = 0
 	b.stats.ByScheduler = make(map[string]*SchedulerStats)
 	b.evals = make(map[string]int)
-	b.jobEvals = make(map[string]string)
-	b.blocked = make(map[string]PendingEvaluations)
+	b.jobEvals = make(map[structs.NamespacedID]string)
+	b.blocked = make(map[structs.NamespacedID]PendingEvaluations)
 	b.ready = make(map[string]PendingEvaluations)
 	b.unack = make(map[string]*unackEval)
 	b.timeWait = make(map[string]*time.Timer)
+	b.nacked = make(map[string]int)
 }
 
 // Stats is used to query the state of the broker
@@ -691,7 +832,8 @@ func (p PendingEvaluations) Len() int {
 // so that the "min" in the min-heap is the element with the
 // highest priority
 func (p PendingEvaluations) Less(i, j int) bool {
-	if p[i].JobID != p[j].JobID && p[i].Priority != p[j].Priority {
+	sameJob := p[i].JobID == p[j].JobID && p[i].Namespace == p[j].Namespace
+	if !sameJob && p[i].Priority != p[j].Priority {
 		return !(p[i].Priority < p[j].Priority)
 	}
 	return p[i].CreateIndex < p[j].CreateIndex
@@ -716,6 +858,30 @@ func (p *PendingEvaluations) Pop() interface{} {
 	return e
 }
 
+// replace swaps the evaluation with the same ID for the passed one and
+// restores the heap ordering. It returns whether the evaluation was found.
+func (p PendingEvaluations) replace(eval *structs.Evaluation) bool {
+	for i, e := range p {
+		if e.ID == eval.ID {
+			p[i] = eval
+			heap.Fix(&p, i)
+			return true
+		}
+	}
+	return false
+}
+
+// remove removes the evaluation with the given ID and restores the heap
+// ordering. It returns the removed evaluation or nil if it wasn't found.
+func (p *PendingEvaluations) remove(evalID string) *structs.Evaluation {
+	for i, e := range *p {
+		if e.ID == evalID {
+			return heap.Remove(p, i).(*structs.Evaluation)
+		}
+	}
+	return nil
+}
+
 // Peek is used to peek at the next element that would be popped
 func (p PendingEvaluations) Peek() *structs.Evaluation {
 	n := len(p)
@@ -447,6 +447,108 @@ func TestEvalBroker_Dequeue_Priority(t *testing.T) {
 	}
 }
 
+func TestEvalBroker_Dequeue_Priority_AboveHundred(t *testing.T) {
+	b := testBroker(t, 0)
+	b.SetEnabled(true)
+
+	eval1 := mock.Eval()
+	eval1.Priority = 100
+	b.Enqueue(eval1)
+
+	eval2 := mock.Eval()
+	eval2.Priority = structs.JobMaxPriority
+	b.Enqueue(eval2)
+
+	eval3 := mock.Eval()
+	eval3.Priority = 500
+	b.Enqueue(eval3)
+
+	for _, expected := range []*structs.Evaluation{eval2, eval3, eval1} {
+		out, _, _ := b.Dequeue(defaultSched, time.Second)
+		if out != expected {
+			t.Fatalf("bad: %#v", out)
+		}
+	}
+}
+
+func TestEvalBroker_Reprioritize(t *testing.T) {
+	b := testBroker(t, 0)
+	b.SetEnabled(true)
+
+	eval1 := mock.Eval()
+	eval1.Priority = 10
+	b.Enqueue(eval1)
+
+	eval2 := mock.Eval()
+	eval2.Priority = 30
+	b.Enqueue(eval2)
+
+	// Raise the priority of the first eval above the second
+	updated := eval1.Copy()
+	updated.Priority = 50
+	b.Reprioritize([]*structs.Evaluation{updated, mock.Eval()})
+
+	out1, _, _ := b.Dequeue(defaultSched, time.Second)
+	if out1 != updated {
+		t.Fatalf("bad: %#v", out1)
+	}
+
+	out2, _, _ := b.Dequeue(defaultSched, time.Second)
+	if out2 != eval2 {
+		t.Fatalf("bad: %#v", out2)
+	}
+}
+
+func TestEvalBroker_Remove(t *testing.T) {
+	b := testBroker(t, 0)
+	b.SetEnabled(true)
+
+	// Create a ready eval and two blocked evals of the same job
+	eval1 := mock.Eval()
+	eval2 := mock.Eval()
+	eval2.JobID = eval1.JobID
+	eval2.CreateIndex = eval1.CreateIndex + 1
+	eval3 := mock.Eval()
+	eval3.JobID = eval1.JobID
+	eval3.CreateIndex = eval1.CreateIndex + 2
+	b.Enqueue(eval1)
+	b.Enqueue(eval2)
+	b.Enqueue(eval3)
+
+	// Removing the ready eval makes the next blocked eval ready, and removing
+	// a blocked eval drops it
+	b.Remove([]string{eval1.ID, eval2.ID})
+
+	stats := b.Stats()
+	if stats.TotalReady != 1 || stats.TotalBlocked != 0 {
+		t.Fatalf("bad: %#v", stats)
+	}
+	if stats.ByScheduler[eval3.Type].Ready != 1 {
+		t.Fatalf("bad: %#v", stats.ByScheduler[eval3.Type])
+	}
+
+	out, _, err := b.Dequeue(defaultSched, time.Second)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != eval3 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Removing a waiting eval stops its timer
+	eval4 := mock.Eval()
+	eval4.Wait = 10 * time.Millisecond
+	b.Enqueue(eval4)
+	b.Remove([]string{eval4.ID})
+	if stats := b.Stats(); stats.TotalWaiting != 0 {
+		t.Fatalf("bad: %#v", stats)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if stats := b.Stats(); stats.TotalReady != 0 {
+		t.Fatalf("bad: %#v", stats)
+	}
+}
+
 // Ensure FIFO at fixed priority
 func TestEvalBroker_Dequeue_FIFO(t *testing.T) {
 	b := testBroker(t, 0)
@@ -759,6 +861,51 @@ func TestEvalBroker_DeliveryLimit(t *testing.T) {
 	}
 }
 
+func TestEvalBroker_DeliveryLimit_Persisted(t *testing.T) {
+	b := testBroker(t, 0)
+	b.SetEnabled(true)
+
+	// An eval that exhausted its attempts is enqueued in the failed queue
+	exhausted := mock.Eval()
+	exhausted.DeliveryAttempts = 3
+	b.Enqueue(exhausted)
+
+	// An eval with remaining attempts resumes counting from them
+	eval := mock.Eval()
+	eval.DeliveryAttempts = 2
+	b.Enqueue(eval)
+
+	stats := b.Stats()
+	if stats.ByScheduler[failedQueue].Ready != 1 || stats.ByScheduler[eval.Type].Ready != 1 {
+		t.Fatalf("bad: %#v", stats)
+	}
+
+	out, token, err := b.Dequeue(defaultSched, time.Second)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != eval {
+		t.Fatalf("bad : %#v", out)
+	}
+	if err := b.Nack(eval.ID, token); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	stats = b.Stats()
+	if stats.ByScheduler[failedQueue].Ready != 2 {
+		t.Fatalf("bad: %#v", stats)
+	}
+
+	// The delivery attempts of the Nack'd eval are tracked for the leader
+	nacked := b.GetNackedDeliveries(time.Second)
+	if len(nacked) != 1 || nacked[eval.ID] != 3 {
+		t.Fatalf("bad: %#v", nacked)
+	}
+	if nacked := b.GetNackedDeliveries(10 * time.Millisecond); nacked != nil {
+		t.Fatalf("bad: %#v", nacked)
+	}
+}
+
 func TestEvalBroker_AckAtDeliveryLimit(t *testing.T) {
 	b := testBroker(t, 0)
 	b.SetEnabled(true)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/watch"
 )
@@ -43,6 +44,9 @@ func (e *Eval) GetEval(args *structs.EvalSpecificRequest,
 			if err != nil {
 				return err
 			}
+			if out != nil && out.Namespace != args.RequestNamespace() {
+				out = nil
+			}
 
 			// Setup the output
 			reply.Eval = out
@@ -242,6 +246,58 @@ func (e *Eval) Reblock(args *structs.EvalUpdateRequest, reply *structs.GenericRe
 	return nil
 }
 
+// Blocked is used to list the evaluations blocked until resources become
+// available to place their remaining allocations
+func (e *Eval) Blocked(args *structs.EvalListRequest,
+	reply *structs.EvalListResponse) error {
+	if done, err := e.srv.forward("Eval.Blocked", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "eval", "blocked"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "evals"}),
+		run: func() error {
+			// Scan all the evaluations
+			snap, err := e.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			iter, err := snap.Evals()
+			if err != nil {
+				return err
+			}
+
+			var evals []*structs.Evaluation
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				eval := raw.(*structs.Evaluation)
+				if eval.Status == structs.EvalStatusBlocked {
+					evals = append(evals, eval)
+				}
+			}
+			reply.Evaluations = evals
+
+			// Use the last index that affected the evals table
+			index, err := snap.Index("evals")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			e.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return e.srv.blockingRPC(&opts)
+}
+
 // Reap is used to cleanup dead evaluations and allocations
 func (e *Eval) Reap(args *structs.EvalDeleteRequest,
 	reply *structs.GenericResponse) error {
@@ -261,6 +317,54 @@ func (e *Eval) Reap(args *structs.EvalDeleteRequest,
 	return nil
 }
 
+// Delete is used by operators to delete evaluations, such as broken
+// evaluations stuck in the pending state. Evaluations being processed by a
+// scheduler can not be deleted.
+func (e *Eval) Delete(args *structs.EvalDeleteRequest,
+	reply *structs.GenericResponse) error {
+	if done, err := e.srv.forward("Eval.Delete", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "eval", "delete"}, time.Now())
+
+	// Validate the arguments
+	if len(args.Evals) == 0 {
+		return fmt.Errorf("must specify at least one evaluation to delete")
+	}
+	if len(args.Allocs) != 0 {
+		return fmt.Errorf("allocations can not be deleted")
+	}
+
+	// Check the evaluations exist and are not being scheduled
+	snap, err := e.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	for _, evalID := range args.Evals {
+		eval, err := snap.EvalByID(evalID)
+		if err != nil {
+			return err
+		}
+		if eval == nil || eval.Namespace != args.RequestNamespace() {
+			return fmt.Errorf("evaluation %q not found", evalID)
+		}
+		if _, ok := e.srv.evalBroker.Outstanding(evalID); ok {
+			return fmt.Errorf("evaluation %q is being processed by a scheduler", evalID)
+		}
+	}
+
+	// Update via Raft
+	_, index, err := e.srv.raftApply(structs.EvalDeleteRequestType, args)
+	if err != nil {
+		e.srv.logger.Printf("[ERR] nomad.eval: Delete failed: %v", err)
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
 // List is used to get a list of the evaluations in the system
 func (e *Eval) List(args *structs.EvalListRequest,
 	reply *structs.EvalListResponse) error {
@@ -280,26 +384,33 @@ func (e *Eval) List(args *structs.EvalListRequest,
 			if err != nil {
 				return err
 			}
-			var iter memdb.ResultIterator
-			if prefix := args.QueryOptions.Prefix; prefix != "" {
-				iter, err = snap.EvalsByIDPrefix(prefix)
+			// Narrow the evaluations down with the job index if filtered
+			var candidates []*structs.Evaluation
+			if args.JobID != "" {
+				candidates, err = snap.EvalsByJob(args.RequestNamespace(), args.JobID)
 			} else {
-				iter, err = snap.Evals()
+				candidates, err = evalsByPrefix(snap, args.QueryOptions.Prefix)
 			}
 			if err != nil {
 				return err
 			}
 
 			var evals []*structs.Evaluation
-			for {
-				raw := iter.Next()
-				if raw == nil {
-					break
+			page := newPaginator(&args.QueryOptions)
+			for _, eval := range candidates {
+				if eval.Namespace != args.RequestNamespace() || !args.Matches(eval) {
+					continue
+				}
+				if !page.accept(eval.ID) {
+					if page.done() {
+						break
+					}
+					continue
 				}
-				eval := raw.(*structs.Evaluation)
 				evals = append(evals, eval)
 			}
 			reply.Evaluations = evals
+			reply.NextToken = page.next
 
 			// Use the last index that affected the jobs table
 			index, err := snap.Index("evals")
@@ -339,10 +450,13 @@ func (e *Eval) Allocations(args *structs.EvalSpecificRequest,
 				return err
 			}
 
-			// Convert to a stub
+			// Convert the allocations of the namespace to stubs
 			if len(allocs) > 0 {
 				reply.Allocations = make([]*structs.AllocListStub, 0, len(allocs))
 				for _, alloc := range allocs {
+					if alloc.Namespace != args.RequestNamespace() {
+						continue
+					}
 					reply.Allocations = append(reply.Allocations, alloc.Stub())
 				}
 			}
@@ -360,3 +474,28 @@ func (e *Eval) Allocations(args *structs.EvalSpecificRequest,
 		}}
 	return e.srv.blockingRPC(&opts)
 }
+
+// evalsByPrefix returns the evaluations whose ID starts with the prefix, all
+// of them if the prefix is empty
+func evalsByPrefix(snap *state.StateSnapshot, prefix string) ([]*structs.Evaluation, error) {
+	var iter memdb.ResultIterator
+	var err error
+	if prefix != "" {
+		iter, err = snap.EvalsByIDPrefix(prefix)
+	} else {
+		iter, err = snap.Evals()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var evals []*structs.Evaluation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		evals = append(evals, raw.(*structs.Evaluation))
+	}
+	return evals, nil
+}
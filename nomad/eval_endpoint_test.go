@@ -2,6 +2,8 @@ package nomad
 
 import (
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -378,6 +380,96 @@ func TestEvalEndpoint_Reap(t *testing.T) {
 	}
 }
 
+func TestEvalEndpoint_Delete(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a pending eval and one being processed by a scheduler
+	eval1 := mock.Eval()
+	eval2 := mock.Eval()
+	s1.fsm.State().UpsertEvals(1000, []*structs.Evaluation{eval1, eval2})
+	s1.evalBroker.Enqueue(eval2)
+	if _, _, err := s1.evalBroker.Dequeue(defaultSched, time.Second); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Deleting the outstanding eval fails
+	req := &structs.EvalDeleteRequest{
+		Evals:        []string{eval2.ID},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	err := msgpackrpc.CallWithCodec(codec, "Eval.Delete", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "being processed") {
+		t.Fatalf("expected outstanding error: %v", err)
+	}
+
+	// Deleting an unknown eval fails
+	req.Evals = []string{structs.GenerateUUID()}
+	err = msgpackrpc.CallWithCodec(codec, "Eval.Delete", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error: %v", err)
+	}
+
+	// Delete the pending eval
+	req.Evals = []string{eval1.ID}
+	if err := msgpackrpc.CallWithCodec(codec, "Eval.Delete", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("Bad index: %d", resp.Index)
+	}
+
+	// Ensure deleted
+	outE, err := s1.fsm.State().EvalByID(eval1.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outE != nil {
+		t.Fatalf("Bad: %#v", outE)
+	}
+}
+
+func TestEvalEndpoint_Delete_Ready(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create an eval waiting in the ready queue of the broker
+	eval := mock.Eval()
+	s1.fsm.State().UpsertEvals(1000, []*structs.Evaluation{eval})
+	s1.evalBroker.Enqueue(eval)
+
+	// Delete it
+	req := &structs.EvalDeleteRequest{
+		Evals:        []string{eval.ID},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Eval.Delete", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the deleted eval is never dequeued
+	out, _, err := s1.evalBroker.Dequeue(defaultSched, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("deleted eval dequeued: %#v", out)
+	}
+	if stats := s1.evalBroker.Stats(); stats.TotalReady != 0 {
+		t.Fatalf("bad: %#v", stats)
+	}
+}
+
 func TestEvalEndpoint_List(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -425,6 +517,137 @@ func TestEvalEndpoint_List(t *testing.T) {
 
 }
 
+func TestEvalEndpoint_List_Filter(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create evals for two jobs, one of them complete
+	eval1 := mock.Eval()
+	eval2 := mock.Eval()
+	eval2.JobID = eval1.JobID
+	eval2.Status = structs.EvalStatusComplete
+	eval3 := mock.Eval()
+	s1.fsm.State().UpsertEvals(1000, []*structs.Evaluation{eval1, eval2, eval3})
+
+	cases := []struct {
+		JobID    string
+		Status   string
+		Expected []string
+	}{
+		{eval1.JobID, "", []string{eval1.ID, eval2.ID}},
+		{eval1.JobID, structs.EvalStatusPending, []string{eval1.ID}},
+		{"", structs.EvalStatusComplete, []string{eval2.ID}},
+		{"", structs.EvalStatusBlocked, nil},
+	}
+	for _, c := range cases {
+		get := &structs.EvalListRequest{
+			JobID:        c.JobID,
+			Status:       c.Status,
+			QueryOptions: structs.QueryOptions{Region: "global"},
+		}
+		var resp structs.EvalListResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Eval.List", get, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var ids []string
+		for _, eval := range resp.Evaluations {
+			ids = append(ids, eval.ID)
+		}
+		sort.Strings(ids)
+		sort.Strings(c.Expected)
+		if !reflect.DeepEqual(ids, c.Expected) {
+			t.Fatalf("job %q status %q: got %v; want %v", c.JobID, c.Status, ids, c.Expected)
+		}
+	}
+}
+
+func TestEvalEndpoint_List_Pagination(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create five evals
+	var evals []*structs.Evaluation
+	var ids []string
+	for i := 0; i < 5; i++ {
+		eval := mock.Eval()
+		evals = append(evals, eval)
+		ids = append(ids, eval.ID)
+	}
+	sort.Strings(ids)
+	s1.fsm.State().UpsertEvals(1000, evals)
+
+	// Walk the pages of two evals
+	var seen []string
+	var pages int
+	get := &structs.EvalListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global", PerPage: 2},
+	}
+	for {
+		var resp structs.EvalListResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Eval.List", get, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(resp.Evaluations) > 2 {
+			t.Fatalf("page too long: %d", len(resp.Evaluations))
+		}
+		for _, eval := range resp.Evaluations {
+			seen = append(seen, eval.ID)
+		}
+		pages++
+
+		if resp.NextToken == "" {
+			break
+		}
+		get.NextToken = resp.NextToken
+	}
+	if pages != 3 {
+		t.Fatalf("expected 3 pages, got %d", pages)
+	}
+	if !reflect.DeepEqual(seen, ids) {
+		t.Fatalf("expected %v, got %v", ids, seen)
+	}
+}
+
+func TestEvalEndpoint_Blocked(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a blocked and a pending eval
+	eval1 := mock.Eval()
+	eval1.Status = structs.EvalStatusBlocked
+	eval1.FailedTGAllocs = map[string]*structs.AllocMetric{
+		"web": &structs.AllocMetric{DimensionExhausted: map[string]int{"memory exhausted": 1}},
+	}
+	eval2 := mock.Eval()
+	s1.fsm.State().UpsertEvals(1000, []*structs.Evaluation{eval1, eval2})
+
+	// Lookup the blocked evals
+	get := &structs.EvalListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.EvalListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Eval.Blocked", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+
+	if len(resp.Evaluations) != 1 || resp.Evaluations[0].ID != eval1.ID {
+		t.Fatalf("bad: %#v", resp.Evaluations)
+	}
+	if !reflect.DeepEqual(resp.Evaluations[0].FailedTGAllocs, eval1.FailedTGAllocs) {
+		t.Fatalf("bad: %#v", resp.Evaluations[0].FailedTGAllocs)
+	}
+}
+
 func TestEvalEndpoint_List_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
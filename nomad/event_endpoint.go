@@ -0,0 +1,74 @@
+package nomad
+
+import (
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// Event endpoint is used to follow the changes of the state of the cluster
+type Event struct {
+	srv *Server
+}
+
+// List is used to list the events following the query index. The query
+// blocks until an event following the index is published, so that the events
+// can be streamed by repeating the query with the index of the response.
+func (e *Event) List(args *structs.EventListRequest,
+	reply *structs.EventListResponse) error {
+	if done, err := e.srv.forward("Event.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "event", "list"}, time.Now())
+
+	if err := args.Validate(); err != nil {
+		return err
+	}
+	topics := make(map[string]struct{}, len(args.Topics))
+	for _, topic := range args.Topics {
+		topics[topic] = struct{}{}
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "events"}),
+		run: func() error {
+			state := e.srv.fsm.State()
+			events, index := state.Events(args.MinQueryIndex)
+
+			reply.Events = nil
+			for _, event := range events {
+				if _, ok := topics[event.Topic]; len(topics) != 0 && !ok {
+					continue
+				}
+				if event.Topic != structs.EventTopicNode && event.Namespace != args.RequestNamespace() {
+					continue
+				}
+				reply.Events = append(reply.Events, event)
+			}
+
+			// Without any retained event, use the latest index so that the
+			// following query only returns the new events
+			if index == 0 {
+				var err error
+				if index, err = state.LatestIndex(); err != nil {
+					return err
+				}
+			}
+
+			// Ensure a non-zero index so that the following query blocks
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+
+			// Set the query response
+			e.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return e.srv.blockingRPC(&opts)
+}
@@ -0,0 +1,125 @@
+package nomad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestEventEndpoint_List(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register a job through the FSM
+	job := mock.Job()
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var regResp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &regResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// List the job events
+	req := &structs.EventListRequest{
+		Topics:       []string{structs.EventTopicJob},
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.EventListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Event.List", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Events) != 1 {
+		t.Fatalf("bad: %#v", resp.Events)
+	}
+	event := resp.Events[0]
+	if event.Type != structs.EventTypeJobRegistered || event.Key != job.ID || event.Job == nil {
+		t.Fatalf("bad: %#v", event)
+	}
+	if resp.Index < event.Index {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// The evaluation of the job is another topic
+	req.Topics = []string{structs.EventTopicEvaluation}
+	if err := msgpackrpc.CallWithCodec(codec, "Event.List", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Events) != 1 || resp.Events[0].Key != regResp.EvalID {
+		t.Fatalf("bad: %#v", resp.Events)
+	}
+
+	// The events of other namespaces are filtered
+	req.Topics = nil
+	req.Namespace = "other"
+	if err := msgpackrpc.CallWithCodec(codec, "Event.List", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Events) != 0 {
+		t.Fatalf("bad: %#v", resp.Events)
+	}
+
+	// Unknown topics are rejected
+	req.Topics = []string{"foo"}
+	if err := msgpackrpc.CallWithCodec(codec, "Event.List", req, &resp); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestEventEndpoint_List_Blocking(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	state := s1.fsm.State()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Without any event, the latest index is returned
+	req := &structs.EventListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.EventListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Event.List", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	latest, err := state.LatestIndex()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.Index != latest {
+		t.Fatalf("bad index: %d %d", resp.Index, latest)
+	}
+
+	// Publishing an event triggers the watches
+	index := resp.Index + 100
+	time.AfterFunc(100*time.Millisecond, func() {
+		state.PublishEvents(index, []*structs.Event{{
+			Topic: structs.EventTopicNode,
+			Type:  structs.EventTypeNodeDeregistered,
+			Key:   "foo",
+		}})
+	})
+
+	req.MinQueryIndex = resp.Index
+	start := time.Now()
+	var resp2 structs.EventListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Event.List", req, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("should block (returned in %s) %#v", elapsed, resp2)
+	}
+	if resp2.Index != index {
+		t.Fatalf("Bad index: %d %d", resp2.Index, index)
+	}
+	if len(resp2.Events) != 1 || resp2.Events[0].Key != "foo" {
+		t.Fatalf("bad: %#v", resp2.Events)
+	}
+}
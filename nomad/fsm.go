@@ -36,6 +36,12 @@ const (
 	PeriodicLaunchSnapshot
 	JobSummarySnapshot
 	VaultAccessorSnapshot
+	JobVersionSnapshot
+	DeploymentSnapshot
+	SchedulerConfigSnapshot
+	QuotaSpecSnapshot
+	NamespaceSnapshot
+	AutopilotConfigSnapshot
 )
 
 // nomadFSM implements a finite state machine that is used
@@ -142,6 +148,28 @@ func (n *nomadFSM) Apply(log *raft.Log) interface{} {
 		return n.applyUpsertVaultAccessor(buf[1:], log.Index)
 	case structs.VaultAccessorDegisterRequestType:
 		return n.applyDeregisterVaultAccessor(buf[1:], log.Index)
+	case structs.PeriodicLaunchSkipRequestType:
+		return n.applyPeriodicLaunchSkip(buf[1:], log.Index)
+	case structs.DeploymentStatusUpdateRequestType:
+		return n.applyDeploymentStatusUpdate(buf[1:], log.Index)
+	case structs.DeploymentAllocHealthRequestType:
+		return n.applyDeploymentAllocHealth(buf[1:], log.Index)
+	case structs.DeploymentPromoteRequestType:
+		return n.applyDeploymentPromotion(buf[1:], log.Index)
+	case structs.EvalDeliveryUpdateRequestType:
+		return n.applyEvalDeliveryUpdate(buf[1:], log.Index)
+	case structs.SchedulerConfigRequestType:
+		return n.applySchedulerConfigUpdate(buf[1:], log.Index)
+	case structs.QuotaSpecUpsertRequestType:
+		return n.applyQuotaSpecUpsert(buf[1:], log.Index)
+	case structs.QuotaSpecDeleteRequestType:
+		return n.applyQuotaSpecDelete(buf[1:], log.Index)
+	case structs.NamespaceUpsertRequestType:
+		return n.applyNamespaceUpsert(buf[1:], log.Index)
+	case structs.NamespaceDeleteRequestType:
+		return n.applyNamespaceDelete(buf[1:], log.Index)
+	case structs.AutopilotRequestType:
+		return n.applyAutopilotUpdate(buf[1:], log.Index)
 	default:
 		if ignoreUnknown {
 			n.logger.Printf("[WARN] nomad.fsm: ignoring unknown message type (%d), upgrade to newer version", msgType)
@@ -163,6 +191,7 @@ func (n *nomadFSM) applyUpsertNode(buf []byte, index uint64) interface{} {
 		n.logger.Printf("[ERR] nomad.fsm: UpsertNode failed: %v", err)
 		return err
 	}
+	n.publishNodeEvent(index, structs.EventTypeNodeRegistered, req.Node.ID)
 
 	// Unblock evals for the nodes computed node class if it is in a ready
 	// state.
@@ -184,6 +213,11 @@ func (n *nomadFSM) applyDeregisterNode(buf []byte, index uint64) interface{} {
 		n.logger.Printf("[ERR] nomad.fsm: DeleteNode failed: %v", err)
 		return err
 	}
+	n.state.PublishEvents(index, []*structs.Event{{
+		Topic: structs.EventTopicNode,
+		Type:  structs.EventTypeNodeDeregistered,
+		Key:   req.NodeID,
+	}})
 	return nil
 }
 
@@ -198,6 +232,7 @@ func (n *nomadFSM) applyStatusUpdate(buf []byte, index uint64) interface{} {
 		n.logger.Printf("[ERR] nomad.fsm: UpdateNodeStatus failed: %v", err)
 		return err
 	}
+	n.publishNodeEvent(index, structs.EventTypeNodeStatusUpdated, req.NodeID)
 
 	// Unblock evals for the nodes computed node class if it is in a ready
 	// state.
@@ -221,10 +256,24 @@ func (n *nomadFSM) applyDrainUpdate(buf []byte, index uint64) interface{} {
 		panic(fmt.Errorf("failed to decode request: %v", err))
 	}
 
-	if err := n.state.UpdateNodeDrain(index, req.NodeID, req.Drain); err != nil {
+	if err := n.state.UpdateNodeDrain(index, req.NodeID, req.Drain, req.DrainStrategy); err != nil {
 		n.logger.Printf("[ERR] nomad.fsm: UpdateNodeDrain failed: %v", err)
 		return err
 	}
+	n.publishNodeEvent(index, structs.EventTypeNodeDrainUpdated, req.NodeID)
+
+	// Unblock evals for the nodes computed node class if it is in a ready
+	// state and no longer draining.
+	if !req.Drain {
+		node, err := n.state.NodeByID(req.NodeID)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: looking up node %q failed: %v", req.NodeID, err)
+			return err
+		}
+		if node != nil && node.Status == structs.NodeStatusReady {
+			n.blockedEvals.Unblock(node.ComputedClass, index)
+		}
+	}
 	return nil
 }
 
@@ -246,6 +295,15 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 		n.logger.Printf("[ERR] nomad.fsm: UpsertJob failed: %v", err)
 		return err
 	}
+	if job, err := n.state.JobByID(req.Job.Namespace, req.Job.ID); err == nil && job != nil {
+		n.state.PublishEvents(index, []*structs.Event{{
+			Topic:     structs.EventTopicJob,
+			Type:      structs.EventTypeJobRegistered,
+			Key:       job.ID,
+			Namespace: job.Namespace,
+			Job:       job,
+		}})
+	}
 
 	// We always add the job to the periodic dispatcher because there is the
 	// possibility that the periodic spec was removed and then we should stop
@@ -261,7 +319,7 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 	// job was not launched. In this case, we use the insertion time to
 	// determine if a launch was missed.
 	if req.Job.IsPeriodic() {
-		prevLaunch, err := n.state.PeriodicLaunchByID(req.Job.ID)
+		prevLaunch, err := n.state.PeriodicLaunchByID(req.Job.Namespace, req.Job.ID)
 		if err != nil {
 			n.logger.Printf("[ERR] nomad.fsm: PeriodicLaunchByID failed: %v", err)
 			return err
@@ -270,7 +328,11 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 		// Record the insertion time as a launch. We overload the launch table
 		// such that the first entry is the insertion time.
 		if prevLaunch == nil {
-			launch := &structs.PeriodicLaunch{ID: req.Job.ID, Launch: time.Now()}
+			launch := &structs.PeriodicLaunch{
+				ID:        req.Job.ID,
+				Namespace: req.Job.Namespace,
+				Launch:    time.Now(),
+			}
 			if err := n.state.UpsertPeriodicLaunch(index, launch); err != nil {
 				n.logger.Printf("[ERR] nomad.fsm: UpsertPeriodicLaunch failed: %v", err)
 				return err
@@ -281,7 +343,7 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 	// Check if the parent job is periodic and mark the launch time.
 	parentID := req.Job.ParentID
 	if parentID != "" {
-		parent, err := n.state.JobByID(parentID)
+		parent, err := n.state.JobByID(req.Job.Namespace, parentID)
 		if err != nil {
 			n.logger.Printf("[ERR] nomad.fsm: JobByID(%v) lookup for parent failed: %v", parentID, err)
 			return err
@@ -297,7 +359,26 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 				return err
 			}
 
-			launch := &structs.PeriodicLaunch{ID: parentID, Launch: t}
+			prevLaunch, err := n.state.PeriodicLaunchByID(req.Job.Namespace, parentID)
+			if err != nil {
+				n.logger.Printf("[ERR] nomad.fsm: PeriodicLaunchByID failed: %v", err)
+				return err
+			}
+
+			// Launching a skipped instance removes it from the skipped
+			// launches but never moves the last launch time backwards.
+			launch := &structs.PeriodicLaunch{
+				ID:        parentID,
+				Namespace: req.Job.Namespace,
+				Launch:    t,
+			}
+			if prevLaunch != nil {
+				launch = prevLaunch.Copy()
+				launch.RemoveSkipped(t)
+				if t.After(launch.Launch) {
+					launch.Launch = t
+				}
+			}
 			if err := n.state.UpsertPeriodicLaunch(index, launch); err != nil {
 				n.logger.Printf("[ERR] nomad.fsm: UpsertPeriodicLaunch failed: %v", err)
 				return err
@@ -315,12 +396,24 @@ func (n *nomadFSM) applyDeregisterJob(buf []byte, index uint64) interface{} {
 		panic(fmt.Errorf("failed to decode request: %v", err))
 	}
 
-	if err := n.state.DeleteJob(index, req.JobID); err != nil {
+	// Lookup the job before it is deleted to include it in the event
+	event := &structs.Event{
+		Topic:     structs.EventTopicJob,
+		Type:      structs.EventTypeJobDeregistered,
+		Key:       req.JobID,
+		Namespace: req.RequestNamespace(),
+	}
+	if job, err := n.state.JobByID(req.RequestNamespace(), req.JobID); err == nil && job != nil {
+		event.Job = job
+	}
+
+	if err := n.state.DeleteJob(index, req.RequestNamespace(), req.JobID); err != nil {
 		n.logger.Printf("[ERR] nomad.fsm: DeleteJob failed: %v", err)
 		return err
 	}
+	n.state.PublishEvents(index, []*structs.Event{event})
 
-	if err := n.periodicDispatcher.Remove(req.JobID); err != nil {
+	if err := n.periodicDispatcher.Remove(req.RequestNamespace(), req.JobID); err != nil {
 		n.logger.Printf("[ERR] nomad.fsm: periodicDispatcher.Remove failed: %v", err)
 		return err
 	}
@@ -328,7 +421,7 @@ func (n *nomadFSM) applyDeregisterJob(buf []byte, index uint64) interface{} {
 	// We always delete from the periodic launch table because it is possible that
 	// the job was updated to be non-perioidic, thus checking if it is periodic
 	// doesn't ensure we clean it up properly.
-	n.state.DeletePeriodicLaunch(index, req.JobID)
+	n.state.DeletePeriodicLaunch(index, req.RequestNamespace(), req.JobID)
 
 	return nil
 }
@@ -344,6 +437,7 @@ func (n *nomadFSM) applyUpdateEval(buf []byte, index uint64) interface{} {
 		n.logger.Printf("[ERR] nomad.fsm: UpsertEvals failed: %v", err)
 		return err
 	}
+	n.publishEvalEvents(index, req.Evals)
 
 	for _, eval := range req.Evals {
 		if eval.ShouldEnqueue() {
@@ -366,6 +460,12 @@ func (n *nomadFSM) applyDeleteEval(buf []byte, index uint64) interface{} {
 		n.logger.Printf("[ERR] nomad.fsm: DeleteEval failed: %v", err)
 		return err
 	}
+
+	// Stop tracking deleted blocked evaluations so that new ones can be
+	// blocked for their jobs, and drop the deleted evaluations queued in the
+	// broker so that they are never scheduled
+	n.blockedEvals.Untrack(req.Evals)
+	n.evalBroker.Remove(req.Evals)
 	return nil
 }
 
@@ -412,10 +512,43 @@ func (n *nomadFSM) applyAllocUpdate(buf []byte, index uint64) interface{} {
 		alloc.Resources.Add(alloc.SharedResources)
 	}
 
-	if err := n.state.UpsertAllocs(index, req.Alloc); err != nil {
-		n.logger.Printf("[ERR] nomad.fsm: UpsertAllocs failed: %v", err)
+	if err := n.state.UpsertPlanResults(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertPlanResults failed: %v", err)
 		return err
 	}
+	n.publishAllocEvents(index, req.Alloc)
+	n.publishEvalEvents(index, req.PreemptionEvals)
+
+	// Process the evaluations of the jobs whose allocations were preempted
+	for _, eval := range req.PreemptionEvals {
+		if eval.ShouldEnqueue() {
+			n.evalBroker.Enqueue(eval)
+		} else if eval.ShouldBlock() {
+			n.blockedEvals.Block(eval)
+		}
+	}
+
+	// Unblock evals for the nodes computed node class if the plan stopped
+	// allocations on it, freeing capacity.
+	freed := make(map[string]struct{})
+	for _, alloc := range req.Alloc {
+		if !alloc.TerminalStatus() {
+			continue
+		}
+		if _, ok := freed[alloc.NodeID]; ok {
+			continue
+		}
+		freed[alloc.NodeID] = struct{}{}
+
+		node, err := n.state.NodeByID(alloc.NodeID)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: looking up node %q failed: %v", alloc.NodeID, err)
+			return err
+		}
+		if node != nil {
+			n.blockedEvals.Unblock(node.ComputedClass, index)
+		}
+	}
 	return nil
 }
 
@@ -442,6 +575,7 @@ func (n *nomadFSM) applyAllocClientUpdate(buf []byte, index uint64) interface{}
 		n.logger.Printf("[ERR] nomad.fsm: UpdateAllocFromClient failed: %v", err)
 		return err
 	}
+	n.publishAllocEvents(index, req.Alloc)
 
 	// Unblock evals for the nodes computed node class if the client has
 	// finished running an allocation.
@@ -462,6 +596,62 @@ func (n *nomadFSM) applyAllocClientUpdate(buf []byte, index uint64) interface{}
 	return nil
 }
 
+// publishNodeEvent publishes an event of the given type for the node as it is
+// stored after the write at index.
+func (n *nomadFSM) publishNodeEvent(index uint64, eventType, nodeID string) {
+	node, err := n.state.NodeByID(nodeID)
+	if err != nil || node == nil {
+		return
+	}
+	n.state.PublishEvents(index, []*structs.Event{{
+		Topic: structs.EventTopicNode,
+		Type:  eventType,
+		Key:   node.ID,
+		Node:  node,
+	}})
+}
+
+// publishAllocEvents publishes an update event for each of the allocations as
+// they are stored after the write at index. The allocations of the requests
+// are partial, so they are looked up.
+func (n *nomadFSM) publishAllocEvents(index uint64, allocs []*structs.Allocation) {
+	var events []*structs.Event
+	for _, update := range allocs {
+		alloc, err := n.state.AllocByID(update.ID)
+		if err != nil || alloc == nil {
+			continue
+		}
+		events = append(events, &structs.Event{
+			Topic:      structs.EventTopicAllocation,
+			Type:       structs.EventTypeAllocationUpdated,
+			Key:        alloc.ID,
+			Namespace:  alloc.Namespace,
+			Allocation: alloc,
+		})
+	}
+	n.state.PublishEvents(index, events)
+}
+
+// publishEvalEvents publishes an event for each of the evaluations, marking
+// the completed ones.
+func (n *nomadFSM) publishEvalEvents(index uint64, evals []*structs.Evaluation) {
+	var events []*structs.Event
+	for _, eval := range evals {
+		eventType := structs.EventTypeEvaluationUpdated
+		if eval.Status == structs.EvalStatusComplete {
+			eventType = structs.EventTypeEvaluationCompleted
+		}
+		events = append(events, &structs.Event{
+			Topic:      structs.EventTopicEvaluation,
+			Type:       eventType,
+			Key:        eval.ID,
+			Namespace:  eval.Namespace,
+			Evaluation: eval,
+		})
+	}
+	n.state.PublishEvents(index, events)
+}
+
 // applyReconcileSummaries reconciles summaries for all the jobs
 func (n *nomadFSM) applyReconcileSummaries(buf []byte, index uint64) interface{} {
 	if err := n.state.ReconcileJobSummaries(index); err != nil {
@@ -503,6 +693,256 @@ func (n *nomadFSM) applyDeregisterVaultAccessor(buf []byte, index uint64) interf
 	return nil
 }
 
+// applyPeriodicLaunchSkip records skipped launches of a periodic job
+func (n *nomadFSM) applyPeriodicLaunchSkip(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "periodic_launch_skip"}, time.Now())
+	var req structs.PeriodicLaunchSkipRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	prevLaunch, err := n.state.PeriodicLaunchByID(req.RequestNamespace(), req.JobID)
+	if err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: PeriodicLaunchByID failed: %v", err)
+		return err
+	}
+
+	// The job may have been deregistered in the meantime.
+	if prevLaunch == nil {
+		return nil
+	}
+
+	launch := prevLaunch.Copy()
+	launch.AddSkipped(req.Launches...)
+	if err := n.state.UpsertPeriodicLaunch(index, launch); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertPeriodicLaunch failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// applyDeploymentStatusUpdate updates the status of a deployment
+func (n *nomadFSM) applyDeploymentStatusUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "deployment_status_update"}, time.Now())
+	var req structs.DeploymentStatusUpdateRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentStatus(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentStatus failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyDeploymentAllocHealth marks allocations of a deployment as healthy
+func (n *nomadFSM) applyDeploymentAllocHealth(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "deployment_alloc_health"}, time.Now())
+	var req structs.DeploymentAllocHealthRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentAllocHealth(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentAllocHealth failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyDeploymentPromotion promotes the canaries of a deployment
+func (n *nomadFSM) applyDeploymentPromotion(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "deployment_promotion"}, time.Now())
+	var req structs.DeploymentPromoteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentPromotion(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentPromotion failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyEvalDeliveryUpdate records the delivery attempts of evaluations
+func (n *nomadFSM) applyEvalDeliveryUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "eval_delivery_update"}, time.Now())
+	var req structs.EvalDeliveryUpdateRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateEvalDeliveries(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateEvalDeliveries failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applySchedulerConfigUpdate sets the scheduler configuration
+func (n *nomadFSM) applySchedulerConfigUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "scheduler_config"}, time.Now())
+	var req structs.SchedulerSetConfigRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.SchedulerSetConfig(index, &req.Config); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: SchedulerSetConfig failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyQuotaSpecUpsert creates or updates a quota specification. The blocked
+// evaluations of the jobs of the namespaces using the quota are retried since
+// the quota may have been raised.
+func (n *nomadFSM) applyQuotaSpecUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "upsert_quota_spec"}, time.Now())
+	var req structs.QuotaSpecUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertQuotaSpec(index, req.Quota); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertQuotaSpec failed: %v", err)
+		return err
+	}
+
+	iter, err := n.state.NamespacesByQuota(req.Quota.Name)
+	if err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: looking up namespaces of quota %q failed: %v", req.Quota.Name, err)
+		return err
+	}
+	var namespaces []string
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		namespaces = append(namespaces, raw.(*structs.Namespace).Name)
+	}
+	if err := n.unblockNamespaces(namespaces); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: unblocking jobs of quota %q failed: %v", req.Quota.Name, err)
+		return err
+	}
+	return nil
+}
+
+// unblockNamespaces retries the blocked evaluations of the jobs of the given
+// namespaces.
+func (n *nomadFSM) unblockNamespaces(namespaces []string) error {
+	jobs := make(map[structs.NamespacedID]struct{})
+	for _, namespace := range namespaces {
+		iter, err := n.state.JobsByNamespace(namespace)
+		if err != nil {
+			return err
+		}
+		for {
+			raw := iter.Next()
+			if raw == nil {
+				break
+			}
+			jobs[raw.(*structs.Job).NamespacedID()] = struct{}{}
+		}
+	}
+	if len(jobs) != 0 {
+		n.blockedEvals.UnblockJobs(jobs)
+	}
+	return nil
+}
+
+// applyQuotaSpecDelete deletes a quota specification
+func (n *nomadFSM) applyQuotaSpecDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "delete_quota_spec"}, time.Now())
+	var req structs.QuotaSpecDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteQuotaSpec(index, req.Name); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteQuotaSpec failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyNamespaceUpsert creates or updates namespaces. The blocked evaluations
+// of the jobs of the namespaces whose quota changed are retried.
+func (n *nomadFSM) applyNamespaceUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "upsert_namespaces"}, time.Now())
+	var req structs.NamespaceUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	var changed []string
+	for _, namespace := range req.Namespaces {
+		existing, err := n.state.NamespaceByName(namespace.Name)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: looking up namespace %q failed: %v", namespace.Name, err)
+			return err
+		}
+		if existing != nil && existing.Quota != namespace.Quota {
+			changed = append(changed, namespace.Name)
+		}
+	}
+
+	if err := n.state.UpsertNamespaces(index, req.Namespaces); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertNamespaces failed: %v", err)
+		return err
+	}
+
+	if err := n.unblockNamespaces(changed); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: unblocking jobs of namespaces failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyNamespaceDelete deletes namespaces
+func (n *nomadFSM) applyNamespaceDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "delete_namespaces"}, time.Now())
+	var req structs.NamespaceDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteNamespaces(index, req.Namespaces); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteNamespaces failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyAutopilotUpdate sets the Autopilot configuration. With check-and-set,
+// the result is whether the configuration was set.
+func (n *nomadFSM) applyAutopilotUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "autopilot"}, time.Now())
+	var req structs.AutopilotSetConfigRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if req.CAS {
+		act, err := n.state.AutopilotCASConfig(index, req.Config.ModifyIndex, &req.Config)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: AutopilotCASConfig failed: %v", err)
+			return err
+		}
+		return act
+	}
+
+	if err := n.state.AutopilotSetConfig(index, &req.Config); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: AutopilotSetConfig failed: %v", err)
+		return err
+	}
+	return nil
+}
+
 func (n *nomadFSM) Snapshot() (raft.FSMSnapshot, error) {
 	// Create a new snapshot
 	snap, err := n.state.Snapshot()
@@ -641,6 +1081,60 @@ func (n *nomadFSM) Restore(old io.ReadCloser) error {
 				return err
 			}
 
+		case JobVersionSnapshot:
+			version := new(structs.Job)
+			if err := dec.Decode(version); err != nil {
+				return err
+			}
+			if err := restore.JobVersionRestore(version); err != nil {
+				return err
+			}
+
+		case DeploymentSnapshot:
+			deployment := new(structs.Deployment)
+			if err := dec.Decode(deployment); err != nil {
+				return err
+			}
+			if err := restore.DeploymentRestore(deployment); err != nil {
+				return err
+			}
+
+		case SchedulerConfigSnapshot:
+			config := new(structs.SchedulerConfiguration)
+			if err := dec.Decode(config); err != nil {
+				return err
+			}
+			if err := restore.SchedulerConfigRestore(config); err != nil {
+				return err
+			}
+
+		case QuotaSpecSnapshot:
+			quota := new(structs.QuotaSpec)
+			if err := dec.Decode(quota); err != nil {
+				return err
+			}
+			if err := restore.QuotaSpecRestore(quota); err != nil {
+				return err
+			}
+
+		case NamespaceSnapshot:
+			namespace := new(structs.Namespace)
+			if err := dec.Decode(namespace); err != nil {
+				return err
+			}
+			if err := restore.NamespaceRestore(namespace); err != nil {
+				return err
+			}
+
+		case AutopilotConfigSnapshot:
+			config := new(structs.AutopilotConfig)
+			if err := dec.Decode(config); err != nil {
+				return err
+			}
+			if err := restore.AutopilotConfigRestore(config); err != nil {
+				return err
+			}
+
 		default:
 			return fmt.Errorf("Unrecognized snapshot type: %v", msgType)
 		}
@@ -707,6 +1201,7 @@ func (n *nomadFSM) reconcileQueuedAllocations(index uint64) error {
 			Type:           job.Type,
 			TriggeredBy:    structs.EvalTriggerJobRegister,
 			JobID:          job.ID,
+			Namespace:      job.Namespace,
 			JobModifyIndex: job.JobModifyIndex + 1,
 			Status:         structs.EvalStatusPending,
 			AnnotatePlan:   true,
@@ -723,7 +1218,7 @@ func (n *nomadFSM) reconcileQueuedAllocations(index uint64) error {
 		}
 
 		// Get the job summary from the fsm state store
-		summary, err := n.state.JobSummaryByID(job.ID)
+		summary, err := n.state.JobSummaryByID(job.Namespace, job.ID)
 		if err != nil {
 			return err
 		}
@@ -818,6 +1313,30 @@ func (s *nomadSnapshot) Persist(sink raft.SnapshotSink) error {
 		sink.Cancel()
 		return err
 	}
+	if err := s.persistJobVersions(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistDeployments(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistSchedulerConfig(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistQuotaSpecs(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistNamespaces(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistAutopilotConfig(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
 	return nil
 }
 
@@ -1031,7 +1550,153 @@ func (s *nomadSnapshot) persistVaultAccessors(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *nomadSnapshot) persistJobVersions(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the tracked job versions
+	versions, err := s.snap.JobVersions()
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := versions.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		job := raw.(*structs.Job)
+
+		// Write out a job version
+		sink.Write([]byte{byte(JobVersionSnapshot)})
+		if err := encoder.Encode(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistDeployments(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the deployments
+	deployments, err := s.snap.Deployments()
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := deployments.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		deployment := raw.(*structs.Deployment)
+
+		// Write out a deployment
+		sink.Write([]byte{byte(DeploymentSnapshot)})
+		if err := encoder.Encode(deployment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistSchedulerConfig(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get the scheduler configuration
+	config, err := s.snap.SchedulerConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+
+	// Write out the scheduler configuration
+	sink.Write([]byte{byte(SchedulerConfigSnapshot)})
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistQuotaSpecs(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the quota specifications
+	quotas, err := s.snap.QuotaSpecs()
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := quotas.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		quota := raw.(*structs.QuotaSpec)
+
+		// Write out a quota specification
+		sink.Write([]byte{byte(QuotaSpecSnapshot)})
+		if err := encoder.Encode(quota); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Release is a no-op, as we just need to GC the pointer
 // to the state store snapshot. There is nothing to explicitly
 // cleanup.
+func (s *nomadSnapshot) persistAutopilotConfig(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get the Autopilot configuration
+	config, err := s.snap.AutopilotConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+
+	// Write out the Autopilot configuration
+	sink.Write([]byte{byte(AutopilotConfigSnapshot)})
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (s *nomadSnapshot) Release() {}
+
+func (s *nomadSnapshot) persistNamespaces(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the namespaces
+	namespaces, err := s.snap.Namespaces()
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := namespaces.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		namespace := raw.(*structs.Namespace)
+
+		// Write out a namespace
+		sink.Write([]byte{byte(NamespaceSnapshot)})
+		if err := encoder.Encode(namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
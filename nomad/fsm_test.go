@@ -261,6 +261,57 @@ func TestFSM_UpdateNodeDrain(t *testing.T) {
 	}
 }
 
+func TestFSM_UpdateNodeDrain_Unblock(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.blockedEvals.SetEnabled(true)
+
+	node := mock.Node()
+	node.Drain = true
+	req := structs.NodeRegisterRequest{
+		Node: node,
+	}
+	buf, err := structs.Encode(structs.NodeRegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Mark an eval as blocked.
+	eval := mock.Eval()
+	eval.ClassEligibility = map[string]bool{node.ComputedClass: true}
+	fsm.blockedEvals.Block(eval)
+
+	// Disable the drain
+	req2 := structs.NodeUpdateDrainRequest{
+		NodeID: node.ID,
+		Drain:  false,
+	}
+	buf, err = structs.Encode(structs.NodeUpdateDrainRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the eval was unblocked.
+	testutil.WaitForResult(func() (bool, error) {
+		bStats := fsm.blockedEvals.Stats()
+		if bStats.TotalBlocked != 0 {
+			return false, fmt.Errorf("bad: %#v", bStats)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+}
+
 func TestFSM_RegisterJob(t *testing.T) {
 	fsm := testFSM(t)
 
@@ -279,7 +330,7 @@ func TestFSM_RegisterJob(t *testing.T) {
 	}
 
 	// Verify we are registered
-	jobOut, err := fsm.State().JobByID(req.Job.ID)
+	jobOut, err := fsm.State().JobByID(req.Job.Namespace, req.Job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -291,12 +342,12 @@ func TestFSM_RegisterJob(t *testing.T) {
 	}
 
 	// Verify it was added to the periodic runner.
-	if _, ok := fsm.periodicDispatcher.tracked[job.ID]; !ok {
+	if _, ok := fsm.periodicDispatcher.tracked[job.NamespacedID()]; !ok {
 		t.Fatal("job not added to periodic runner")
 	}
 
 	// Verify the launch time was tracked.
-	launchOut, err := fsm.State().PeriodicLaunchByID(req.Job.ID)
+	launchOut, err := fsm.State().PeriodicLaunchByID(req.Job.Namespace, req.Job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -308,6 +359,74 @@ func TestFSM_RegisterJob(t *testing.T) {
 	}
 }
 
+func TestFSM_PeriodicLaunchSkip(t *testing.T) {
+	fsm := testFSM(t)
+
+	job := mock.PeriodicJob()
+	req := structs.JobRegisterRequest{
+		Job: job,
+	}
+	buf, err := structs.Encode(structs.JobRegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp := fsm.Apply(makeLog(buf)); resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Record two skipped launches
+	skipped1 := time.Unix(1000, 0)
+	skipped2 := time.Unix(2000, 0)
+	req2 := structs.PeriodicLaunchSkipRequest{
+		JobID:    job.ID,
+		Launches: []time.Time{skipped2, skipped1},
+	}
+	buf, err = structs.Encode(structs.PeriodicLaunchSkipRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp := fsm.Apply(makeLog(buf)); resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	launchOut, err := fsm.State().PeriodicLaunchByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(launchOut.Skipped) != 2 || !launchOut.Skipped[0].Equal(skipped1) || !launchOut.Skipped[1].Equal(skipped2) {
+		t.Fatalf("bad skipped launches: %v", launchOut.Skipped)
+	}
+	lastLaunch := launchOut.Launch
+
+	// Register the derived job of the first skipped launch
+	derived := job.Copy()
+	derived.ParentID = job.ID
+	derived.ID = fsm.periodicDispatcher.derivedJobID(job, skipped1)
+	derived.Periodic = nil
+	req3 := structs.JobRegisterRequest{
+		Job: derived,
+	}
+	buf, err = structs.Encode(structs.JobRegisterRequestType, req3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp := fsm.Apply(makeLog(buf)); resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// The launch is no longer skipped and the last launch is kept
+	launchOut, err = fsm.State().PeriodicLaunchByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(launchOut.Skipped) != 1 || !launchOut.Skipped[0].Equal(skipped2) {
+		t.Fatalf("bad skipped launches: %v", launchOut.Skipped)
+	}
+	if !launchOut.Launch.Equal(lastLaunch) {
+		t.Fatalf("bad launch time: got %v; want %v", launchOut.Launch, lastLaunch)
+	}
+}
+
 func TestFSM_DeregisterJob(t *testing.T) {
 	fsm := testFSM(t)
 
@@ -339,7 +458,7 @@ func TestFSM_DeregisterJob(t *testing.T) {
 	}
 
 	// Verify we are NOT registered
-	jobOut, err := fsm.State().JobByID(req.Job.ID)
+	jobOut, err := fsm.State().JobByID(req.Job.Namespace, req.Job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -348,12 +467,12 @@ func TestFSM_DeregisterJob(t *testing.T) {
 	}
 
 	// Verify it was removed from the periodic runner.
-	if _, ok := fsm.periodicDispatcher.tracked[job.ID]; ok {
+	if _, ok := fsm.periodicDispatcher.tracked[job.NamespacedID()]; ok {
 		t.Fatal("job not removed from periodic runner")
 	}
 
 	// Verify it was removed from the periodic launch table.
-	launchOut, err := fsm.State().PeriodicLaunchByID(req.Job.ID)
+	launchOut, err := fsm.State().PeriodicLaunchByID(req.Job.Namespace, req.Job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -398,6 +517,205 @@ func TestFSM_UpdateEval(t *testing.T) {
 	}
 }
 
+func TestFSM_EvalDeliveryUpdate(t *testing.T) {
+	fsm := testFSM(t)
+
+	eval := mock.Eval()
+	fsm.State().UpsertEvals(1, []*structs.Evaluation{eval})
+
+	req := structs.EvalDeliveryUpdateRequest{
+		DeliveryAttempts: map[string]int{eval.ID: 2},
+	}
+	buf, err := structs.Encode(structs.EvalDeliveryUpdateRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out, err := fsm.State().EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.DeliveryAttempts != 2 {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestFSM_SchedulerConfig(t *testing.T) {
+	fsm := testFSM(t)
+
+	req := structs.SchedulerSetConfigRequest{
+		Config: structs.SchedulerConfiguration{
+			PreemptionConfig: structs.PreemptionConfig{ServiceSchedulerEnabled: true},
+		},
+	}
+	buf, err := structs.Encode(structs.SchedulerConfigRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the config is set
+	out, err := fsm.State().SchedulerConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || !out.PreemptionConfig.ServiceSchedulerEnabled || out.ModifyIndex != 1 {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestFSM_AutopilotConfig(t *testing.T) {
+	fsm := testFSM(t)
+
+	req := structs.AutopilotSetConfigRequest{
+		Config: structs.AutopilotConfig{
+			CleanupDeadServers:   true,
+			LastContactThreshold: 10 * time.Second,
+			MaxTrailingLogs:      300,
+		},
+	}
+	buf, err := structs.Encode(structs.AutopilotRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the config is set
+	out, err := fsm.State().AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || !out.CleanupDeadServers || out.MaxTrailingLogs != 300 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// A check-and-set with a stale index is rejected
+	req.CAS = true
+	req.Config.CleanupDeadServers = false
+	req.Config.ModifyIndex = 2
+	buf, err = structs.Encode(structs.AutopilotRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp = fsm.Apply(makeLog(buf))
+	if ok, _ := resp.(bool); ok {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out, err = fsm.State().AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.CleanupDeadServers {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestFSM_UpsertDeleteQuotaSpec(t *testing.T) {
+	fsm := testFSM(t)
+
+	req := structs.QuotaSpecUpsertRequest{
+		Quota: &structs.QuotaSpec{
+			Name:  "engineering",
+			Limit: &structs.QuotaLimit{MemoryMB: 1024},
+		},
+	}
+	buf, err := structs.Encode(structs.QuotaSpecUpsertRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the quota is created
+	out, err := fsm.State().QuotaSpecByName("engineering")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Limit.MemoryMB != 1024 || out.CreateIndex != 1 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	req2 := structs.QuotaSpecDeleteRequest{Name: "engineering"}
+	buf, err = structs.Encode(structs.QuotaSpecDeleteRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the quota is deleted
+	out, err = fsm.State().QuotaSpecByName("engineering")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestFSM_UpsertDeleteNamespaces(t *testing.T) {
+	fsm := testFSM(t)
+
+	req := structs.NamespaceUpsertRequest{
+		Namespaces: []*structs.Namespace{{Name: "engineering"}},
+	}
+	buf, err := structs.Encode(structs.NamespaceUpsertRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the namespace is created
+	out, err := fsm.State().NamespaceByName("engineering")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.CreateIndex != 1 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	req2 := structs.NamespaceDeleteRequest{Namespaces: []string{"engineering"}}
+	buf, err = structs.Encode(structs.NamespaceDeleteRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the namespace is deleted
+	out, err = fsm.State().NamespaceByName("engineering")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestFSM_UpdateEval_Blocked(t *testing.T) {
 	fsm := testFSM(t)
 	fsm.evalBroker.SetEnabled(true)
@@ -541,6 +859,86 @@ func TestFSM_UpsertAllocs(t *testing.T) {
 	}
 }
 
+func TestFSM_UpsertAllocs_PreemptionEvals(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.evalBroker.SetEnabled(true)
+
+	alloc := mock.Alloc()
+	fsm.State().UpsertJobSummary(1, mock.JobSummary(alloc.JobID))
+	eval := mock.Eval()
+	eval.TriggeredBy = structs.EvalTriggerPreemption
+	req := structs.AllocUpdateRequest{
+		Alloc:           []*structs.Allocation{alloc},
+		PreemptionEvals: []*structs.Evaluation{eval},
+	}
+	buf, err := structs.Encode(structs.AllocUpdateRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the eval is registered and enqueued
+	out, err := fsm.State().EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("not found!")
+	}
+	stats := fsm.evalBroker.Stats()
+	if stats.TotalReady != 1 {
+		t.Fatalf("bad: %#v %#v", stats, out)
+	}
+}
+
+func TestFSM_UpsertAllocs_StopUnblock(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.blockedEvals.SetEnabled(true)
+
+	node := mock.Node()
+	fsm.State().UpsertNode(1, node)
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	fsm.State().UpsertJobSummary(2, mock.JobSummary(alloc.JobID))
+	fsm.State().UpsertAllocs(3, []*structs.Allocation{alloc})
+
+	// Mark an eval as blocked.
+	eval := mock.Eval()
+	eval.ClassEligibility = map[string]bool{node.ComputedClass: true}
+	fsm.blockedEvals.Block(eval)
+
+	// Stop the allocation
+	stop := alloc.Copy()
+	stop.DesiredStatus = structs.AllocDesiredStatusStop
+	req := structs.AllocUpdateRequest{
+		Alloc: []*structs.Allocation{stop},
+	}
+	buf, err := structs.Encode(structs.AllocUpdateRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the eval was unblocked.
+	testutil.WaitForResult(func() (bool, error) {
+		bStats := fsm.blockedEvals.Stats()
+		if bStats.TotalBlocked != 0 {
+			return false, fmt.Errorf("bad: %#v", bStats)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+}
+
 func TestFSM_UpsertAllocs_SharedJob(t *testing.T) {
 	fsm := testFSM(t)
 
@@ -771,6 +1169,80 @@ func TestFSM_UpdateAllocFromClient(t *testing.T) {
 	}
 }
 
+func TestFSM_DeploymentStatusUpdate(t *testing.T) {
+	fsm := testFSM(t)
+	state := fsm.State()
+
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 1}
+	if err := state.UpsertDeployment(1001, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.DeploymentID = deployment.ID
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Mark the allocation healthy
+	req := structs.DeploymentAllocHealthRequest{
+		DeploymentID:         deployment.ID,
+		HealthyAllocationIDs: []string{alloc.ID},
+	}
+	buf, err := structs.Encode(structs.DeploymentAllocHealthRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp := fsm.Apply(makeLog(buf)); resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DeploymentStatus.IsHealthy() {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Complete the deployment
+	req2 := structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      deployment.ID,
+			Status:            structs.DeploymentStatusSuccessful,
+			StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+		},
+	}
+	buf, err = structs.Encode(structs.DeploymentStatusUpdateRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp := fsm.Apply(makeLog(buf)); resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	d, err := state.DeploymentByID(deployment.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d.Status != structs.DeploymentStatusSuccessful || !d.Healthy() {
+		t.Fatalf("bad: %#v", d)
+	}
+	jobOut, err := state.JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !jobOut.Stable {
+		t.Fatalf("bad: %#v", jobOut)
+	}
+}
+
 func TestFSM_UpsertVaultAccessor(t *testing.T) {
 	fsm := testFSM(t)
 	fsm.blockedEvals.SetEnabled(true)
@@ -919,8 +1391,8 @@ func TestFSM_SnapshotRestore_Jobs(t *testing.T) {
 	// Verify the contents
 	fsm2 := testSnapshotRestore(t, fsm)
 	state2 := fsm2.State()
-	out1, _ := state2.JobByID(job1.ID)
-	out2, _ := state2.JobByID(job2.ID)
+	out1, _ := state2.JobByID(job1.Namespace, job1.ID)
+	out2, _ := state2.JobByID(job2.Namespace, job2.ID)
 	if !reflect.DeepEqual(job1, out1) {
 		t.Fatalf("bad: \n%#v\n%#v", out1, job1)
 	}
@@ -1059,8 +1531,8 @@ func TestFSM_SnapshotRestore_PeriodicLaunches(t *testing.T) {
 	// Verify the contents
 	fsm2 := testSnapshotRestore(t, fsm)
 	state2 := fsm2.State()
-	out1, _ := state2.PeriodicLaunchByID(launch1.ID)
-	out2, _ := state2.PeriodicLaunchByID(launch2.ID)
+	out1, _ := state2.PeriodicLaunchByID(launch1.Namespace, launch1.ID)
+	out2, _ := state2.PeriodicLaunchByID(launch2.Namespace, launch2.ID)
 	if !reflect.DeepEqual(launch1, out1) {
 		t.Fatalf("bad: \n%#v\n%#v", out1, job1)
 	}
@@ -1076,17 +1548,17 @@ func TestFSM_SnapshotRestore_JobSummary(t *testing.T) {
 
 	job1 := mock.Job()
 	state.UpsertJob(1000, job1)
-	js1, _ := state.JobSummaryByID(job1.ID)
+	js1, _ := state.JobSummaryByID(job1.Namespace, job1.ID)
 
 	job2 := mock.Job()
 	state.UpsertJob(1001, job2)
-	js2, _ := state.JobSummaryByID(job2.ID)
+	js2, _ := state.JobSummaryByID(job2.Namespace, job2.ID)
 
 	// Verify the contents
 	fsm2 := testSnapshotRestore(t, fsm)
 	state2 := fsm2.State()
-	out1, _ := state2.JobSummaryByID(job1.ID)
-	out2, _ := state2.JobSummaryByID(job2.ID)
+	out1, _ := state2.JobSummaryByID(job1.Namespace, job1.ID)
+	out2, _ := state2.JobSummaryByID(job2.Namespace, job2.ID)
 	if !reflect.DeepEqual(js1, out1) {
 		t.Fatalf("bad: \n%#v\n%#v", js1, out1)
 	}
@@ -1116,6 +1588,125 @@ func TestFSM_SnapshotRestore_VaultAccessors(t *testing.T) {
 	}
 }
 
+func TestFSM_SnapshotRestore_JobVersions(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	job := mock.Job()
+	state.UpsertJob(1000, job.Copy())
+	state.UpsertJob(1001, job.Copy())
+	versions, _ := state.JobVersionsByID(job.Namespace, job.ID)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, _ := state2.JobVersionsByID(job.Namespace, job.ID)
+	if len(out) != 2 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if !reflect.DeepEqual(versions, out) {
+		t.Fatalf("bad: \n%#v\n%#v", versions, out)
+	}
+}
+
+func TestFSM_SnapshotRestore_Deployments(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	d1 := structs.NewDeployment(mock.Job())
+	state.UpsertDeployment(1000, d1)
+	d2 := structs.NewDeployment(mock.Job())
+	state.UpsertDeployment(1001, d2)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.DeploymentByID(d1.ID)
+	out2, _ := state2.DeploymentByID(d2.ID)
+	if !reflect.DeepEqual(d1, out1) {
+		t.Fatalf("bad: \n%#v\n%#v", out1, d1)
+	}
+	if !reflect.DeepEqual(d2, out2) {
+		t.Fatalf("bad: \n%#v\n%#v", out2, d2)
+	}
+}
+
+func TestFSM_SnapshotRestore_SchedulerConfig(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	config := &structs.SchedulerConfiguration{
+		PreemptionConfig: structs.PreemptionConfig{BatchSchedulerEnabled: true},
+	}
+	state.SchedulerSetConfig(1000, config)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, _ := state2.SchedulerConfig()
+	if !reflect.DeepEqual(config, out) {
+		t.Fatalf("bad: \n%#v\n%#v", out, config)
+	}
+}
+
+func TestFSM_SnapshotRestore_AutopilotConfig(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	config := &structs.AutopilotConfig{
+		CleanupDeadServers:      true,
+		LastContactThreshold:    time.Second,
+		ServerStabilizationTime: 5 * time.Second,
+	}
+	state.AutopilotSetConfig(1000, config)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, _ := state2.AutopilotConfig()
+	if !reflect.DeepEqual(config, out) {
+		t.Fatalf("bad: \n%#v\n%#v", out, config)
+	}
+}
+
+func TestFSM_SnapshotRestore_QuotaSpecs(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	quota := &structs.QuotaSpec{
+		Name:  "engineering",
+		Limit: &structs.QuotaLimit{CPU: 500},
+	}
+	state.UpsertQuotaSpec(1000, quota)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, _ := state2.QuotaSpecByName("engineering")
+	if !reflect.DeepEqual(quota, out) {
+		t.Fatalf("bad: \n%#v\n%#v", out, quota)
+	}
+}
+
+func TestFSM_SnapshotRestore_Namespaces(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	ns := &structs.Namespace{
+		Name:        "engineering",
+		Description: "Engineering team",
+	}
+	state.UpsertNamespaces(1000, []*structs.Namespace{ns})
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, _ := state2.NamespaceByName("engineering")
+	if !reflect.DeepEqual(ns, out) {
+		t.Fatalf("bad: \n%#v\n%#v", out, ns)
+	}
+}
+
 func TestFSM_SnapshotRestore_AddMissingSummary(t *testing.T) {
 	// Add some state
 	fsm := testFSM(t)
@@ -1127,7 +1718,7 @@ func TestFSM_SnapshotRestore_AddMissingSummary(t *testing.T) {
 	state.UpsertAllocs(1011, []*structs.Allocation{alloc})
 
 	// Delete the summary
-	state.DeleteJobSummary(1040, alloc.Job.ID)
+	state.DeleteJobSummary(1040, alloc.Job.Namespace, alloc.Job.ID)
 
 	// Delete the index
 	if err := state.RemoveIndex("job_summary"); err != nil {
@@ -1138,9 +1729,10 @@ func TestFSM_SnapshotRestore_AddMissingSummary(t *testing.T) {
 	state2 := fsm2.State()
 	latestIndex, _ := state.LatestIndex()
 
-	out, _ := state2.JobSummaryByID(alloc.Job.ID)
+	out, _ := state2.JobSummaryByID(alloc.Job.Namespace, alloc.Job.ID)
 	expected := structs.JobSummary{
-		JobID: alloc.Job.ID,
+		Namespace: structs.DefaultNamespace,
+		JobID:     alloc.Job.ID,
 		Summary: map[string]structs.TaskGroupSummary{
 			"web": structs.TaskGroupSummary{
 				Starting: 1,
@@ -1175,8 +1767,8 @@ func TestFSM_ReconcileSummaries(t *testing.T) {
 	state.UpsertAllocs(1011, []*structs.Allocation{alloc})
 
 	// Delete the summaries
-	state.DeleteJobSummary(1030, job1.ID)
-	state.DeleteJobSummary(1040, alloc.Job.ID)
+	state.DeleteJobSummary(1030, job1.Namespace, job1.ID)
+	state.DeleteJobSummary(1040, alloc.Job.Namespace, alloc.Job.ID)
 
 	req := structs.GenericRequest{}
 	buf, err := structs.Encode(structs.ReconcileJobSummariesRequestType, req)
@@ -1189,9 +1781,10 @@ func TestFSM_ReconcileSummaries(t *testing.T) {
 		t.Fatalf("resp: %v", resp)
 	}
 
-	out1, _ := state.JobSummaryByID(job1.ID)
+	out1, _ := state.JobSummaryByID(job1.Namespace, job1.ID)
 	expected := structs.JobSummary{
-		JobID: job1.ID,
+		Namespace: structs.DefaultNamespace,
+		JobID:     job1.ID,
 		Summary: map[string]structs.TaskGroupSummary{
 			"web": structs.TaskGroupSummary{
 				Queued: 10,
@@ -1207,9 +1800,10 @@ func TestFSM_ReconcileSummaries(t *testing.T) {
 	// This exercises the code path which adds the allocations made by the
 	// planner and the number of unplaced allocations in the reconcile summaries
 	// codepath
-	out2, _ := state.JobSummaryByID(alloc.Job.ID)
+	out2, _ := state.JobSummaryByID(alloc.Job.Namespace, alloc.Job.ID)
 	expected = structs.JobSummary{
-		JobID: alloc.Job.ID,
+		Namespace: structs.DefaultNamespace,
+		JobID:     alloc.Job.ID,
 		Summary: map[string]structs.TaskGroupSummary{
 			"web": structs.TaskGroupSummary{
 				Queued:   10,
@@ -1223,3 +1817,92 @@ func TestFSM_ReconcileSummaries(t *testing.T) {
 		t.Fatalf("expected: %#v, actual: %#v", &expected, out2)
 	}
 }
+
+func TestFSM_Events(t *testing.T) {
+	fsm := testFSM(t)
+
+	apply := func(index uint64, msgType structs.MessageType, req interface{}) {
+		buf, err := structs.Encode(msgType, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		log := makeLog(buf)
+		log.Index = index
+		if resp := fsm.Apply(log); resp != nil {
+			t.Fatalf("resp: %v", resp)
+		}
+	}
+
+	node := mock.Node()
+	apply(1, structs.NodeRegisterRequestType, structs.NodeRegisterRequest{Node: node})
+	apply(2, structs.NodeUpdateStatusRequestType, structs.NodeUpdateStatusRequest{
+		NodeID: node.ID,
+		Status: structs.NodeStatusDown,
+	})
+
+	job := mock.Job()
+	apply(3, structs.JobRegisterRequestType, structs.JobRegisterRequest{Job: job})
+
+	eval := mock.Eval()
+	eval.Status = structs.EvalStatusComplete
+	apply(4, structs.EvalUpdateRequestType, structs.EvalUpdateRequest{
+		Evals: []*structs.Evaluation{eval},
+	})
+
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	apply(5, structs.AllocUpdateRequestType, structs.AllocUpdateRequest{
+		Alloc: []*structs.Allocation{alloc},
+		Job:   alloc.Job,
+	})
+
+	update := &structs.Allocation{
+		ID:           alloc.ID,
+		NodeID:       node.ID,
+		ClientStatus: structs.AllocClientStatusRunning,
+	}
+	apply(6, structs.AllocClientUpdateRequestType, structs.AllocUpdateRequest{
+		Alloc: []*structs.Allocation{update},
+	})
+
+	apply(7, structs.JobDeregisterRequestType, structs.JobDeregisterRequest{JobID: job.ID})
+
+	events, index := fsm.State().Events(0)
+	if index != 7 {
+		t.Fatalf("bad index: %d", index)
+	}
+	expected := []struct {
+		index     uint64
+		eventType string
+		key       string
+	}{
+		{1, structs.EventTypeNodeRegistered, node.ID},
+		{2, structs.EventTypeNodeStatusUpdated, node.ID},
+		{3, structs.EventTypeJobRegistered, job.ID},
+		{4, structs.EventTypeEvaluationCompleted, eval.ID},
+		{5, structs.EventTypeAllocationUpdated, alloc.ID},
+		{6, structs.EventTypeAllocationUpdated, alloc.ID},
+		{7, structs.EventTypeJobDeregistered, job.ID},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("bad: %#v", events)
+	}
+	for i, e := range expected {
+		event := events[i]
+		if event.Index != e.index || event.Type != e.eventType || event.Key != e.key {
+			t.Fatalf("bad event %d: %#v", i, event)
+		}
+	}
+
+	// The events carry the stored objects
+	if events[1].Node == nil || events[1].Node.Status != structs.NodeStatusDown {
+		t.Fatalf("bad node: %#v", events[1].Node)
+	}
+	if events[5].Allocation == nil || events[5].Allocation.ClientStatus != structs.AllocClientStatusRunning ||
+		events[5].Allocation.JobID != alloc.JobID {
+		t.Fatalf("bad alloc: %#v", events[5].Allocation)
+	}
+	if events[6].Job == nil || events[6].Namespace != job.Namespace {
+		t.Fatalf("bad job: %#v", events[6])
+	}
+}
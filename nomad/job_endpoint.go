@@ -3,6 +3,7 @@ package nomad
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -38,6 +39,11 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return fmt.Errorf("missing job for registration")
 	}
 
+	// Jobs without a namespace are registered in the request's namespace
+	if args.Job.Namespace == "" {
+		args.Job.Namespace = args.RequestNamespace()
+	}
+
 	// Initialize the job fields (sets defaults and any necessary init work).
 	args.Job.Canonicalize()
 
@@ -46,13 +52,18 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return err
 	}
 
+	// Ensure the namespace of the job exists
+	if err := j.validateJobNamespace(args.Job); err != nil {
+		return err
+	}
+
 	if args.EnforceIndex {
 		// Lookup the job
 		snap, err := j.srv.fsm.State().Snapshot()
 		if err != nil {
 			return err
 		}
-		job, err := snap.JobByID(args.Job.ID)
+		job, err := snap.JobByID(args.Job.Namespace, args.Job.ID)
 		if err != nil {
 			return err
 		}
@@ -115,8 +126,8 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	// Populate the reply with job information
 	reply.JobModifyIndex = index
 
-	// If the job is periodic, we don't create an eval.
-	if args.Job.IsPeriodic() {
+	// If the job is periodic or parameterized, we don't create an eval.
+	if args.Job.IsPeriodic() || args.Job.IsParameterized() {
 		return nil
 	}
 
@@ -127,6 +138,7 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		Type:           args.Job.Type,
 		TriggeredBy:    structs.EvalTriggerJobRegister,
 		JobID:          args.Job.ID,
+		Namespace:      args.Job.Namespace,
 		JobModifyIndex: index,
 		Status:         structs.EvalStatusPending,
 	}
@@ -170,7 +182,7 @@ func (j *Job) Summary(args *structs.JobSummaryRequest,
 			}
 
 			// Look for job summary
-			out, err := snap.JobSummaryByID(args.JobID)
+			out, err := snap.JobSummaryByID(args.RequestNamespace(), args.JobID)
 			if err != nil {
 				return err
 			}
@@ -212,7 +224,7 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 	if err != nil {
 		return err
 	}
-	job, err := snap.JobByID(args.JobID)
+	job, err := snap.JobByID(args.RequestNamespace(), args.JobID)
 	if err != nil {
 		return err
 	}
@@ -222,6 +234,8 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 
 	if job.IsPeriodic() {
 		return fmt.Errorf("can't evaluate periodic job")
+	} else if job.IsParameterized() {
+		return fmt.Errorf("can't evaluate parameterized job")
 	}
 
 	// Create a new evaluation
@@ -231,6 +245,7 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 		Type:           job.Type,
 		TriggeredBy:    structs.EvalTriggerJobRegister,
 		JobID:          job.ID,
+		Namespace:      job.Namespace,
 		JobModifyIndex: job.ModifyIndex,
 		Status:         structs.EvalStatusPending,
 	}
@@ -271,7 +286,7 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 	if err != nil {
 		return err
 	}
-	job, err := snap.JobByID(args.JobID)
+	job, err := snap.JobByID(args.RequestNamespace(), args.JobID)
 	if err != nil {
 		return err
 	}
@@ -286,8 +301,8 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 	// Populate the reply with job information
 	reply.JobModifyIndex = index
 
-	// If the job is periodic, we don't create an eval.
-	if job != nil && job.IsPeriodic() {
+	// If the job is periodic or parameterized, we don't create an eval.
+	if job != nil && (job.IsPeriodic() || job.IsParameterized()) {
 		return nil
 	}
 
@@ -301,6 +316,7 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 		Type:           structs.JobTypeService,
 		TriggeredBy:    structs.EvalTriggerJobDeregister,
 		JobID:          args.JobID,
+		Namespace:      args.RequestNamespace(),
 		JobModifyIndex: index,
 		Status:         structs.EvalStatusPending,
 	}
@@ -343,7 +359,7 @@ func (j *Job) GetJob(args *structs.JobSpecificRequest,
 			if err != nil {
 				return err
 			}
-			out, err := snap.JobByID(args.JobID)
+			out, err := snap.JobByID(args.RequestNamespace(), args.JobID)
 			if err != nil {
 				return err
 			}
@@ -368,6 +384,182 @@ func (j *Job) GetJob(args *structs.JobSpecificRequest,
 	return j.srv.blockingRPC(&opts)
 }
 
+// GetJobVersions is used to retrieve the tracked versions of a job
+func (j *Job) GetJobVersions(args *structs.JobVersionsRequest,
+	reply *structs.JobVersionsResponse) error {
+	if done, err := j.srv.forward("Job.GetJobVersions", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "get_job_versions"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Job: args.JobID}),
+		run: func() error {
+
+			// Look for the job versions
+			snap, err := j.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.JobVersionsByID(args.RequestNamespace(), args.JobID)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Versions = out
+			if len(out) != 0 {
+				reply.Index = out[0].ModifyIndex
+			} else {
+				// Use the last index that affected the job version table
+				index, err := snap.Index("job_version")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			j.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return j.srv.blockingRPC(&opts)
+}
+
+// GetJobVersionDiff is used to compare two tracked versions of a job
+func (j *Job) GetJobVersionDiff(args *structs.JobVersionDiffRequest,
+	reply *structs.JobVersionDiffResponse) error {
+	if done, err := j.srv.forward("Job.GetJobVersionDiff", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "get_job_version_diff"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Job: args.JobID}),
+		run: func() error {
+			snap, err := j.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			versions, err := snap.JobVersionsByID(args.RequestNamespace(), args.JobID)
+			if err != nil {
+				return err
+			}
+			if len(versions) == 0 {
+				reply.Diff = nil
+				index, err := snap.Index("job_version")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+				j.srv.setQueryMeta(&reply.QueryMeta)
+				return nil
+			}
+
+			// Find the compared versions, which are ordered from the most
+			// recent one
+			to, from := 0, -1
+			if args.To != nil {
+				if to = versionIndex(versions, *args.To); to == -1 {
+					return fmt.Errorf("job %q has no tracked version %d", args.JobID, *args.To)
+				}
+			}
+			if args.From != nil {
+				if from = versionIndex(versions, *args.From); from == -1 {
+					return fmt.Errorf("job %q has no tracked version %d", args.JobID, *args.From)
+				}
+			} else if to+1 < len(versions) {
+				from = to + 1
+			}
+
+			// The first tracked version is compared against no job
+			var old *structs.Job
+			if from != -1 {
+				old = versions[from]
+			}
+			diff, err := old.Diff(versions[to], args.Contextual)
+			if err != nil {
+				return fmt.Errorf("failed to create job diff: %v", err)
+			}
+			if err := scheduler.Annotate(diff, nil); err != nil {
+				return fmt.Errorf("failed to annotate job diff: %v", err)
+			}
+
+			reply.Diff = diff
+			reply.Index = versions[0].ModifyIndex
+			j.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return j.srv.blockingRPC(&opts)
+}
+
+// versionIndex returns the index of the given version among the versions of a
+// job, or -1 if it isn't tracked.
+func versionIndex(versions []*structs.Job, version uint64) int {
+	for i, job := range versions {
+		if job.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// Revert is used to revert a job to a prior tracked version by registering
+// the definition of that version again
+func (j *Job) Revert(args *structs.JobRevertRequest, reply *structs.JobRegisterResponse) error {
+	if done, err := j.srv.forward("Job.Revert", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "revert"}, time.Now())
+
+	// Validate the arguments
+	if args.JobID == "" {
+		return fmt.Errorf("missing job ID for revert")
+	}
+
+	// Lookup the job by version
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	cur, err := snap.JobByID(args.RequestNamespace(), args.JobID)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return fmt.Errorf("job %q not found", args.JobID)
+	}
+	if args.JobVersion == cur.Version {
+		return fmt.Errorf("can't revert to current version")
+	}
+	if args.EnforcePriorVersion != nil && *args.EnforcePriorVersion != cur.Version {
+		return fmt.Errorf("current job has version %d; enforcing version %d", cur.Version, *args.EnforcePriorVersion)
+	}
+
+	jobV, err := snap.JobByIDAndVersion(args.RequestNamespace(), args.JobID, args.JobVersion)
+	if err != nil {
+		return err
+	}
+	if jobV == nil {
+		return fmt.Errorf("job %q at version %d not found", args.JobID, args.JobVersion)
+	}
+
+	// Build the register request
+	reg := &structs.JobRegisterRequest{
+		Job:          jobV.Copy(),
+		WriteRequest: args.WriteRequest,
+	}
+
+	// Register the version
+	return j.Register(reg, reply)
+}
+
 // List is used to list the jobs registered in the system
 func (j *Job) List(args *structs.JobListRequest,
 	reply *structs.JobListResponse) error {
@@ -389,28 +581,36 @@ func (j *Job) List(args *structs.JobListRequest,
 			}
 			var iter memdb.ResultIterator
 			if prefix := args.QueryOptions.Prefix; prefix != "" {
-				iter, err = snap.JobsByIDPrefix(prefix)
+				iter, err = snap.JobsByIDPrefix(args.RequestNamespace(), prefix)
 			} else {
-				iter, err = snap.Jobs()
+				iter, err = snap.JobsByNamespace(args.RequestNamespace())
 			}
 			if err != nil {
 				return err
 			}
 
 			var jobs []*structs.JobListStub
+			page := newPaginator(&args.QueryOptions)
 			for {
 				raw := iter.Next()
 				if raw == nil {
 					break
 				}
 				job := raw.(*structs.Job)
-				summary, err := snap.JobSummaryByID(job.ID)
+				if !page.accept(job.ID) {
+					if page.done() {
+						break
+					}
+					continue
+				}
+				summary, err := snap.JobSummaryByID(job.Namespace, job.ID)
 				if err != nil {
 					return fmt.Errorf("unable to look up summary for job: %v", job.ID)
 				}
 				jobs = append(jobs, job.Stub(summary))
 			}
 			reply.Jobs = jobs
+			reply.NextToken = page.next
 
 			// Use the last index that affected the jobs table
 			index, err := snap.Index("jobs")
@@ -445,12 +645,12 @@ func (j *Job) Allocations(args *structs.JobSpecificRequest,
 			if err != nil {
 				return err
 			}
-			allocs, err := snap.AllocsByJob(args.JobID)
+			allocs, err := snap.AllocsByJob(args.RequestNamespace(), args.JobID)
 			if err != nil {
 				return err
 			}
 
-			// Convert to stubs
+			// Convert the allocations to stubs
 			if len(allocs) > 0 {
 				reply.Allocations = make([]*structs.AllocListStub, 0, len(allocs))
 				for _, alloc := range allocs {
@@ -474,33 +674,49 @@ func (j *Job) Allocations(args *structs.JobSpecificRequest,
 }
 
 // Evaluations is used to list the evaluations for a job
-func (j *Job) Evaluations(args *structs.JobSpecificRequest,
+func (j *Job) Evaluations(args *structs.JobEvaluationsRequest,
 	reply *structs.JobEvaluationsResponse) error {
 	if done, err := j.srv.forward("Job.Evaluations", args, args, reply); done {
 		return err
 	}
 	defer metrics.MeasureSince([]string{"nomad", "job", "evaluations"}, time.Now())
 
-	// Capture the evaluations
-	snap, err := j.srv.fsm.State().Snapshot()
-	if err != nil {
-		return err
-	}
-	reply.Evaluations, err = snap.EvalsByJob(args.JobID)
-	if err != nil {
-		return err
-	}
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{EvalJob: args.JobID}),
+		run: func() error {
+			// Capture the evaluations
+			snap, err := j.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			evals, err := snap.EvalsByJob(args.RequestNamespace(), args.JobID)
+			if err != nil {
+				return err
+			}
+			reply.Evaluations = nil
+			for _, eval := range evals {
+				if args.Status != "" && eval.Status != args.Status {
+					continue
+				}
+				reply.Evaluations = append(reply.Evaluations, eval)
+			}
 
-	// Use the last index that affected the evals table
-	index, err := snap.Index("evals")
-	if err != nil {
-		return err
-	}
-	reply.Index = index
+			// Use the last index that affected the evals table
+			index, err := snap.Index("evals")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
 
-	// Set the query response
-	j.srv.setQueryMeta(&reply.QueryMeta)
-	return nil
+			// Set the query response
+			j.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+
+	return j.srv.blockingRPC(&opts)
 }
 
 // Plan is used to cause a dry-run evaluation of the Job and return the results
@@ -516,6 +732,11 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 		return fmt.Errorf("Job required for plan")
 	}
 
+	// Jobs without a namespace are planned in the request's namespace
+	if args.Job.Namespace == "" {
+		args.Job.Namespace = args.RequestNamespace()
+	}
+
 	// Initialize the job fields (sets defaults and any necessary init work).
 	args.Job.Canonicalize()
 
@@ -524,6 +745,11 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 		return err
 	}
 
+	// Ensure the namespace of the job exists
+	if err := j.validateJobNamespace(args.Job); err != nil {
+		return err
+	}
+
 	// Acquire a snapshot of the state
 	snap, err := j.srv.fsm.State().Snapshot()
 	if err != nil {
@@ -531,7 +757,7 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 	}
 
 	// Get the original job
-	oldJob, err := snap.JobByID(args.Job.ID)
+	oldJob, err := snap.JobByID(args.Job.Namespace, args.Job.ID)
 	if err != nil {
 		return err
 	}
@@ -553,6 +779,7 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 		Type:           args.Job.Type,
 		TriggeredBy:    structs.EvalTriggerJobRegister,
 		JobID:          args.Job.ID,
+		Namespace:      args.Job.Namespace,
 		JobModifyIndex: updatedIndex,
 		Status:         structs.EvalStatusPending,
 		AnnotatePlan:   true,
@@ -610,6 +837,308 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 	return nil
 }
 
+// Diff is used to compare a submitted job against the registered version of
+// the job. Unlike Plan, no evaluation is run so only the task changes are
+// annotated.
+func (j *Job) Diff(args *structs.JobDiffRequest, reply *structs.JobDiffResponse) error {
+	if done, err := j.srv.forward("Job.Diff", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "diff"}, time.Now())
+
+	// Validate the arguments
+	if args.Job == nil {
+		return fmt.Errorf("Job required for diff")
+	}
+
+	// Jobs without a namespace are compared in the request's namespace
+	if args.Job.Namespace == "" {
+		args.Job.Namespace = args.RequestNamespace()
+	}
+
+	// Initialize the job fields (sets defaults and any necessary init work).
+	args.Job.Canonicalize()
+
+	// Validate the job.
+	if err := validateJob(args.Job); err != nil {
+		return err
+	}
+
+	// Acquire a snapshot of the state
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	// Get the original job
+	oldJob, err := snap.JobByID(args.Job.Namespace, args.Job.ID)
+	if err != nil {
+		return err
+	}
+
+	jobDiff, err := oldJob.Diff(args.Job, args.Contextual)
+	if err != nil {
+		return fmt.Errorf("failed to create job diff: %v", err)
+	}
+	if err := scheduler.Annotate(jobDiff, nil); err != nil {
+		return fmt.Errorf("failed to annotate job diff: %v", err)
+	}
+
+	var index uint64
+	if oldJob != nil {
+		index = oldJob.JobModifyIndex
+	}
+
+	reply.Diff = jobDiff
+	reply.JobModifyIndex = index
+	reply.Index = index
+	return nil
+}
+
+// UpdatePriority is used to change the priority of a registered job without
+// resubmitting its specification. The priority of the job's queued
+// evaluations is updated as well so they are scheduled accordingly.
+func (j *Job) UpdatePriority(args *structs.JobPriorityRequest, reply *structs.JobPriorityResponse) error {
+	if done, err := j.srv.forward("Job.UpdatePriority", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "update_priority"}, time.Now())
+
+	// Validate the arguments
+	if args.JobID == "" {
+		return fmt.Errorf("missing job ID for priority update")
+	}
+	if args.Priority < structs.JobMinPriority || args.Priority > structs.JobMaxPriority {
+		return fmt.Errorf("job priority must be between [%d, %d]",
+			structs.JobMinPriority, structs.JobMaxPriority)
+	}
+
+	// Lookup the job
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	job, err := snap.JobByID(args.RequestNamespace(), args.JobID)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return fmt.Errorf("job not found")
+	}
+
+	// Commit the job with the new priority via Raft
+	updated := job.Copy()
+	updated.Priority = args.Priority
+	regReq := &structs.JobRegisterRequest{
+		Job:          updated,
+		WriteRequest: args.WriteRequest,
+	}
+	_, index, err := j.srv.raftApply(structs.JobRegisterRequestType, regReq)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Priority update failed: %v", err)
+		return err
+	}
+	reply.JobModifyIndex = index
+	reply.Index = index
+
+	// Find the queued evaluations of the job
+	evals, err := snap.EvalsByJob(args.RequestNamespace(), args.JobID)
+	if err != nil {
+		return err
+	}
+	var queued []*structs.Evaluation
+	for _, eval := range evals {
+		if eval.Status != structs.EvalStatusPending || eval.Priority == args.Priority {
+			continue
+		}
+		eval = eval.Copy()
+		eval.Priority = args.Priority
+		queued = append(queued, eval)
+	}
+	if len(queued) == 0 {
+		return nil
+	}
+
+	// Commit the updated evaluations via Raft
+	update := &structs.EvalUpdateRequest{
+		Evals:        queued,
+		WriteRequest: structs.WriteRequest{Region: args.Region},
+	}
+	_, evalIndex, err := j.srv.raftApply(structs.EvalUpdateRequestType, update)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Eval update failed: %v", err)
+		return err
+	}
+
+	// Reorder the evaluations already waiting in the broker
+	j.srv.evalBroker.Reprioritize(queued)
+
+	reply.UpdatedEvals = len(queued)
+	reply.Index = evalIndex
+	return nil
+}
+
+// Dispatch is used to dispatch a job based on a parameterized job.
+func (j *Job) Dispatch(args *structs.JobDispatchRequest, reply *structs.JobDispatchResponse) error {
+	if done, err := j.srv.forward("Job.Dispatch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "dispatch"}, time.Now())
+
+	// Lookup the parameterized job
+	if args.JobID == "" {
+		return fmt.Errorf("missing parameterized job ID")
+	}
+
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	parameterizedJob, err := snap.JobByID(args.RequestNamespace(), args.JobID)
+	if err != nil {
+		return err
+	}
+	if parameterizedJob == nil {
+		return fmt.Errorf("parameterized job not found")
+	}
+
+	if !parameterizedJob.IsParameterized() {
+		return fmt.Errorf("Specified job %q is not a parameterized job", args.JobID)
+	}
+
+	// Validate the arguments
+	if err := validateDispatchRequest(args, parameterizedJob); err != nil {
+		return err
+	}
+
+	// Derive the child job and commit it via Raft
+	dispatchJob := parameterizedJob.Copy()
+	dispatchJob.ParameterizedJob = nil
+	dispatchJob.ID = structs.DispatchedID(parameterizedJob.ID, time.Now())
+	dispatchJob.ParentID = parameterizedJob.ID
+	dispatchJob.Name = dispatchJob.ID
+	dispatchJob.Status = ""
+	dispatchJob.StatusDescription = ""
+
+	// Merge in the meta data
+	for k, v := range args.Meta {
+		if dispatchJob.Meta == nil {
+			dispatchJob.Meta = make(map[string]string, len(args.Meta))
+		}
+		dispatchJob.Meta[k] = v
+	}
+
+	// Store the payload
+	dispatchJob.Payload = args.Payload
+
+	regReq := &structs.JobRegisterRequest{
+		Job:          dispatchJob,
+		WriteRequest: args.WriteRequest,
+	}
+
+	// Commit this update via Raft
+	_, jobCreateIndex, err := j.srv.raftApply(structs.JobRegisterRequestType, regReq)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Dispatched job register failed: %v", err)
+		return err
+	}
+
+	// Create a new evaluation
+	eval := &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       dispatchJob.Priority,
+		Type:           dispatchJob.Type,
+		TriggeredBy:    structs.EvalTriggerJobRegister,
+		JobID:          dispatchJob.ID,
+		Namespace:      dispatchJob.Namespace,
+		JobModifyIndex: jobCreateIndex,
+		Status:         structs.EvalStatusPending,
+	}
+	update := &structs.EvalUpdateRequest{
+		Evals:        []*structs.Evaluation{eval},
+		WriteRequest: structs.WriteRequest{Region: args.Region},
+	}
+
+	// Commit this evaluation via Raft
+	_, evalIndex, err := j.srv.raftApply(structs.EvalUpdateRequestType, update)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Eval create failed: %v", err)
+		return err
+	}
+
+	// Setup the reply
+	reply.DispatchedJobID = dispatchJob.ID
+	reply.JobCreateIndex = jobCreateIndex
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = evalIndex
+	reply.Index = evalIndex
+	return nil
+}
+
+// validateDispatchRequest returns whether the request is valid given the
+// parameterized job's configuration.
+func validateDispatchRequest(req *structs.JobDispatchRequest, job *structs.Job) error {
+	// Check the payload constraint is met
+	hasInputData := len(req.Payload) != 0
+	if job.ParameterizedJob.Payload == structs.DispatchPayloadRequired && !hasInputData {
+		return fmt.Errorf("Payload is not provided but required by parameterized job")
+	} else if job.ParameterizedJob.Payload == structs.DispatchPayloadForbidden && hasInputData {
+		return fmt.Errorf("Payload provided but forbidden by parameterized job")
+	}
+
+	// Check the payload doesn't exceed the size limit
+	if l := len(req.Payload); l > structs.DispatchPayloadSizeLimit {
+		return fmt.Errorf("Payload exceeds maximum size; %d > %d", l, structs.DispatchPayloadSizeLimit)
+	}
+
+	// Check if the metadata is a set of only keys the parameterized job
+	// accepts
+	keys := make([]string, 0, len(req.Meta))
+	for k := range req.Meta {
+		keys = append(keys, k)
+	}
+	var allowed []string
+	allowed = append(allowed, job.ParameterizedJob.MetaRequired...)
+	allowed = append(allowed, job.ParameterizedJob.MetaOptional...)
+	if subset, diff := structs.SliceStringIsSubset(allowed, keys); !subset {
+		sort.Strings(diff)
+		return fmt.Errorf("Dispatch request included unpermitted metadata keys: %v", diff)
+	}
+
+	// Check the metadata key constraints are met
+	var missing []string
+	for _, k := range job.ParameterizedJob.MetaRequired {
+		if _, ok := req.Meta[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("Dispatch did not provide required meta keys: %v", missing)
+	}
+
+	return nil
+}
+
+// validateJobNamespace returns an error if the namespace of the job doesn't
+// exist.
+func (j *Job) validateJobNamespace(job *structs.Job) error {
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	if job.Namespace != structs.DefaultNamespace {
+		namespace, err := snap.NamespaceByName(job.Namespace)
+		if err != nil {
+			return err
+		}
+		if namespace == nil {
+			return fmt.Errorf("job namespace %q does not exist", job.Namespace)
+		}
+	}
+	return nil
+}
+
 // validateJob validates a Job and task drivers and returns an error if there is
 // a validation problem or if the Job is of a type a user is not allowed to
 // submit.
@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/scheduler"
 	"github.com/hashicorp/nomad/testutil"
 )
 
@@ -39,7 +40,7 @@ func TestJobEndpoint_Register(t *testing.T) {
 
 	// Check for the node in the FSM
 	state := s1.fsm.State()
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -116,6 +117,150 @@ func TestJobEndpoint_Register_InvalidDriverConfig(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_Namespace(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Registering a job in a missing namespace fails
+	job := mock.Job()
+	job.Namespace = ""
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global", Namespace: "engineering"},
+	}
+	var resp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected a missing namespace error but got: %v", err)
+	}
+
+	// Registering succeeds once the namespace exists
+	ns := &structs.Namespace{Name: "engineering"}
+	if err := s1.fsm.State().UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The job and its evaluation are in the namespace of the request
+	out, err := s1.fsm.State().JobByID("engineering", job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Namespace != "engineering" {
+		t.Fatalf("bad: %#v", out)
+	}
+	eval, err := s1.fsm.State().EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.Namespace != "engineering" {
+		t.Fatalf("bad: %#v", eval)
+	}
+
+	// The job ID can be reused in another namespace
+	req.Job = job.Copy()
+	req.Job.Namespace = ""
+	req.Job.Priority = 20
+	req.Namespace = ""
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = s1.fsm.State().JobByID(structs.DefaultNamespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Namespace != structs.DefaultNamespace || out.Priority != 20 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The job in the other namespace is left untouched
+	out, err = s1.fsm.State().JobByID("engineering", job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Priority == 20 || out.Version != 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestJobEndpoint_Namespace_Isolation(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a job in another namespace
+	state := s1.fsm.State()
+	job := mock.Job()
+	job.Namespace = "engineering"
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The job is not found from the default namespace
+	get := &structs.JobSpecificRequest{
+		JobID:        job.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var single structs.SingleJobResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJob", get, &single); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if single.Job != nil {
+		t.Fatalf("bad: %#v", single.Job)
+	}
+
+	list := &structs.JobListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var listResp structs.JobListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.List", list, &listResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(listResp.Jobs) != 0 {
+		t.Fatalf("bad: %#v", listResp.Jobs)
+	}
+
+	dereg := &structs.JobDeregisterRequest{
+		JobID:        job.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var deregResp structs.JobDeregisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Deregister", dereg, &deregResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := state.JobByID("engineering", job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("job of another namespace deregistered")
+	}
+
+	// The job is found from its namespace
+	get.Namespace = "engineering"
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJob", get, &single); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if single.Job == nil || single.Job.ID != job.ID {
+		t.Fatalf("bad: %#v", single.Job)
+	}
+
+	list.Namespace = "engineering"
+	if err := msgpackrpc.CallWithCodec(codec, "Job.List", list, &listResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(listResp.Jobs) != 1 || listResp.Jobs[0].ID != job.ID {
+		t.Fatalf("bad: %#v", listResp.Jobs)
+	}
+}
+
 func TestJobEndpoint_Register_Existing(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -156,7 +301,7 @@ func TestJobEndpoint_Register_Existing(t *testing.T) {
 
 	// Check for the node in the FSM
 	state := s1.fsm.State()
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -228,7 +373,7 @@ func TestJobEndpoint_Register_Periodic(t *testing.T) {
 
 	// Check for the node in the FSM
 	state := s1.fsm.State()
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -249,6 +394,49 @@ func TestJobEndpoint_Register_Periodic(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_ParameterizedJob(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request for a parameterized job.
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	job.ParameterizedJob = &structs.ParameterizedJobConfig{}
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.JobModifyIndex == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// Check for the job in the FSM
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+	if out.Status != structs.JobStatusRunning {
+		t.Fatalf("bad status: %v", out.Status)
+	}
+	if resp.EvalID != "" {
+		t.Fatalf("Register created an eval for a parameterized job")
+	}
+}
+
 func TestJobEndpoint_Register_EnforceIndex(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -293,7 +481,7 @@ func TestJobEndpoint_Register_EnforceIndex(t *testing.T) {
 
 	// Check for the node in the FSM
 	state := s1.fsm.State()
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -349,7 +537,7 @@ func TestJobEndpoint_Register_EnforceIndex(t *testing.T) {
 		t.Fatalf("bad index: %d", resp.Index)
 	}
 
-	out, err = state.JobByID(job.ID)
+	out, err = state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -418,7 +606,7 @@ func TestJobEndpoint_Register_Vault_AllowUnauthenticated(t *testing.T) {
 
 	// Check for the job in the FSM
 	state := s1.fsm.State()
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -529,7 +717,7 @@ func TestJobEndpoint_Register_Vault_Policies(t *testing.T) {
 
 	// Check for the job in the FSM
 	state := s1.fsm.State()
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -651,6 +839,83 @@ func TestJobEndpoint_Evaluate_Periodic(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_UpdatePriority(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	job := mock.Job()
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An out of range priority is rejected
+	req := &structs.JobPriorityRequest{
+		JobID:        job.ID,
+		Priority:     structs.JobMaxPriority + 1,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp2 structs.JobPriorityResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.UpdatePriority", req, &resp2); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Update the priority
+	req.Priority = 80
+	if err := msgpackrpc.CallWithCodec(codec, "Job.UpdatePriority", req, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp2.JobModifyIndex <= resp.JobModifyIndex {
+		t.Fatalf("bad index: %d", resp2.JobModifyIndex)
+	}
+	if resp2.UpdatedEvals != 1 {
+		t.Fatalf("expected 1 updated eval: %#v", resp2)
+	}
+
+	// Check the job and the queued eval were updated
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Priority != 80 || out.JobModifyIndex != resp2.JobModifyIndex {
+		t.Fatalf("bad: %#v", out)
+	}
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval.Priority != 80 {
+		t.Fatalf("bad: %#v", eval)
+	}
+
+	// The broker dequeues the updated eval
+	out2, _, err := s1.evalBroker.Dequeue(defaultSched, time.Second)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out2 == nil || out2.ID != eval.ID || out2.Priority != 80 {
+		t.Fatalf("bad: %#v", out2)
+	}
+
+	// Updating a missing job fails
+	req.JobID = "foo"
+	if err := msgpackrpc.CallWithCodec(codec, "Job.UpdatePriority", req, &resp2); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func TestJobEndpoint_Deregister(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -687,7 +952,7 @@ func TestJobEndpoint_Deregister(t *testing.T) {
 
 	// Check for the node in the FSM
 	state := s1.fsm.State()
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -818,7 +1083,7 @@ func TestJobEndpoint_Deregister_Periodic(t *testing.T) {
 
 	// Check for the node in the FSM
 	state := s1.fsm.State()
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -897,6 +1162,258 @@ func TestJobEndpoint_GetJob(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_GetJobVersions(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register the job twice
+	job := mock.Job()
+	job.Priority = 88
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job.Priority = 100
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the job versions
+	get := &structs.JobVersionsRequest{
+		JobID:        job.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var versionsResp structs.JobVersionsResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersions", get, &versionsResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if versionsResp.Index != resp.JobModifyIndex {
+		t.Fatalf("Bad index: %d %d", versionsResp.Index, resp.JobModifyIndex)
+	}
+
+	versions := versionsResp.Versions
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions; want 2", len(versions))
+	}
+	if v := versions[0]; v.Priority != 100 || v.Version != 1 {
+		t.Fatalf("bad: %+v", v)
+	}
+	if v := versions[1]; v.Priority != 88 || v.Version != 0 {
+		t.Fatalf("bad: %+v", v)
+	}
+
+	// Lookup non-existing job
+	get.JobID = "foobarbaz"
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersions", get, &versionsResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if versionsResp.Index != resp.JobModifyIndex {
+		t.Fatalf("Bad index: %d %d", versionsResp.Index, resp.JobModifyIndex)
+	}
+	if l := len(versionsResp.Versions); l != 0 {
+		t.Fatalf("unexpected versions: %d", l)
+	}
+}
+
+func TestJobEndpoint_GetJobVersionDiff(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register the job three times
+	job := mock.Job()
+	job.Priority = 88
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.JobRegisterResponse
+	for _, priority := range []int{88, 90, 100} {
+		job.Priority = priority
+		if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// The most recent version is compared against the preceding one
+	get := &structs.JobVersionDiffRequest{
+		JobID:        job.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var diffResp structs.JobVersionDiffResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersionDiff", get, &diffResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if diffResp.Index != resp.JobModifyIndex {
+		t.Fatalf("Bad index: %d %d", diffResp.Index, resp.JobModifyIndex)
+	}
+	priorityDiff := func(diff *structs.JobDiff) *structs.FieldDiff {
+		if diff == nil || diff.Type != structs.DiffTypeEdited {
+			t.Fatalf("bad: %#v", diff)
+		}
+		for _, field := range diff.Fields {
+			if field.Name == "Priority" {
+				return field
+			}
+		}
+		t.Fatalf("missing priority diff: %#v", diff.Fields)
+		return nil
+	}
+	if field := priorityDiff(diffResp.Diff); field.Old != "90" || field.New != "100" {
+		t.Fatalf("bad: %#v", field)
+	}
+
+	// Compare the given versions
+	from, to := uint64(0), uint64(1)
+	get.From, get.To = &from, &to
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersionDiff", get, &diffResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if field := priorityDiff(diffResp.Diff); field.Old != "88" || field.New != "90" {
+		t.Fatalf("bad: %#v", field)
+	}
+
+	// The first version is compared against no job
+	get.From, get.To = nil, &from
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersionDiff", get, &diffResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if diffResp.Diff == nil || diffResp.Diff.Type != structs.DiffTypeAdded {
+		t.Fatalf("bad: %#v", diffResp.Diff)
+	}
+
+	// Comparing an untracked version fails
+	missing := uint64(10)
+	get.To = &missing
+	err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersionDiff", get, &diffResp)
+	if err == nil || !strings.Contains(err.Error(), "no tracked version 10") {
+		t.Fatalf("expected a missing version error, got: %v", err)
+	}
+
+	// The versions of jobs of other namespaces aren't found
+	get.To = nil
+	get.Namespace = "engineering"
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersionDiff", get, &diffResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if diffResp.Diff != nil {
+		t.Fatalf("unexpected diff: %#v", diffResp.Diff)
+	}
+}
+
+func TestJobEndpoint_Revert(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the initial register request
+	job := mock.Job()
+	job.Priority = 100
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reregister again to get another version
+	job2 := job.Copy()
+	job2.Priority = 1
+	reg = &structs.JobRegisterRequest{
+		Job:          job2,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create revert request and enforcing it be at an incorrect version
+	prior := uint64(10)
+	revert := &structs.JobRevertRequest{
+		JobID:               job.ID,
+		JobVersion:          0,
+		EnforcePriorVersion: &prior,
+		WriteRequest:        structs.WriteRequest{Region: "global"},
+	}
+	err := msgpackrpc.CallWithCodec(codec, "Job.Revert", revert, &resp)
+	if err == nil || !strings.Contains(err.Error(), "enforcing version 10") {
+		t.Fatalf("expected enforcement error, got: %v", err)
+	}
+
+	// Create revert request to the current version
+	revert = &structs.JobRevertRequest{
+		JobID:        job.ID,
+		JobVersion:   1,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	err = msgpackrpc.CallWithCodec(codec, "Job.Revert", revert, &resp)
+	if err == nil || !strings.Contains(err.Error(), "current version") {
+		t.Fatalf("expected current version error, got: %v", err)
+	}
+
+	// Create revert request to an untracked version
+	revert.JobVersion = 5
+	err = msgpackrpc.CallWithCodec(codec, "Job.Revert", revert, &resp)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+
+	// Create revert request enforcing it be at the current version
+	prior = 1
+	revert = &structs.JobRevertRequest{
+		JobID:               job.ID,
+		JobVersion:          0,
+		EnforcePriorVersion: &prior,
+		WriteRequest:        structs.WriteRequest{Region: "global"},
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Revert", revert, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad response: %#v", resp)
+	}
+
+	// Check for the job in the FSM
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+	if out.Priority != job.Priority {
+		t.Fatalf("job not reverted: %d", out.Priority)
+	}
+	if out.Version != 2 {
+		t.Fatalf("got version %d; want 2", out.Version)
+	}
+
+	// Lookup the evaluation
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.JobModifyIndex != out.JobModifyIndex || eval.Priority != job.Priority {
+		t.Fatalf("bad eval: %#v", eval)
+	}
+}
+
 func TestJobEndpoint_GetJobSummary(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -936,7 +1453,8 @@ func TestJobEndpoint_GetJobSummary(t *testing.T) {
 	}
 
 	expectedJobSummary := structs.JobSummary{
-		JobID: job.ID,
+		Namespace: structs.DefaultNamespace,
+		JobID:     job.ID,
 		Summary: map[string]structs.TaskGroupSummary{
 			"web": structs.TaskGroupSummary{},
 		},
@@ -1016,7 +1534,7 @@ func TestJobEndpoint_GetJobSummary_Blocking(t *testing.T) {
 
 	// Job delete fires watches
 	time.AfterFunc(100*time.Millisecond, func() {
-		if err := state.DeleteJob(300, job1.ID); err != nil {
+		if err := state.DeleteJob(300, job1.Namespace, job1.ID); err != nil {
 			t.Fatalf("err: %v", err)
 		}
 	})
@@ -1090,7 +1608,7 @@ func TestJobEndpoint_GetJob_Blocking(t *testing.T) {
 
 	// Job delete fires watches
 	time.AfterFunc(100*time.Millisecond, func() {
-		if err := state.DeleteJob(300, job2.ID); err != nil {
+		if err := state.DeleteJob(300, job2.Namespace, job2.ID); err != nil {
 			t.Fatalf("err: %v", err)
 		}
 	})
@@ -1167,6 +1685,48 @@ func TestJobEndpoint_ListJobs(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_ListJobs_Pagination(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create jobs whose IDs differ in case
+	state := s1.fsm.State()
+	for i, id := range []string{"B", "a", "c"} {
+		job := mock.Job()
+		job.ID = id
+		if err := state.UpsertJob(uint64(1000+i), job); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Walk the pages of one job
+	var seen []string
+	get := &structs.JobListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global", PerPage: 1},
+	}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("pagination doesn't terminate: %v", seen)
+		}
+		var resp structs.JobListResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, job := range resp.Jobs {
+			seen = append(seen, job.ID)
+		}
+		if resp.NextToken == "" {
+			break
+		}
+		get.NextToken = resp.NextToken
+	}
+	if expected := []string{"a", "B", "c"}; !reflect.DeepEqual(seen, expected) {
+		t.Fatalf("got %v; want %v", seen, expected)
+	}
+}
+
 func TestJobEndpoint_ListJobs_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -1208,7 +1768,7 @@ func TestJobEndpoint_ListJobs_Blocking(t *testing.T) {
 
 	// Job deletion triggers watches
 	time.AfterFunc(100*time.Millisecond, func() {
-		if err := state.DeleteJob(200, job.ID); err != nil {
+		if err := state.DeleteJob(200, job.Namespace, job.ID); err != nil {
 			t.Fatalf("err: %v", err)
 		}
 	})
@@ -1333,6 +1893,7 @@ func TestJobEndpoint_Evaluations(t *testing.T) {
 	eval1 := mock.Eval()
 	eval2 := mock.Eval()
 	eval2.JobID = eval1.JobID
+	eval2.Status = structs.EvalStatusComplete
 	state := s1.fsm.State()
 	err := state.UpsertEvals(1000,
 		[]*structs.Evaluation{eval1, eval2})
@@ -1341,7 +1902,7 @@ func TestJobEndpoint_Evaluations(t *testing.T) {
 	}
 
 	// Lookup the jobs
-	get := &structs.JobSpecificRequest{
+	get := &structs.JobEvaluationsRequest{
 		JobID:        eval1.JobID,
 		QueryOptions: structs.QueryOptions{Region: "global"},
 	}
@@ -1356,6 +1917,69 @@ func TestJobEndpoint_Evaluations(t *testing.T) {
 	if len(resp2.Evaluations) != 2 {
 		t.Fatalf("bad: %#v", resp2.Evaluations)
 	}
+
+	// Lookup the complete evaluations
+	get.Status = structs.EvalStatusComplete
+	var resp3 structs.JobEvaluationsResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Evaluations", get, &resp3); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp3.Evaluations) != 1 || resp3.Evaluations[0].ID != eval2.ID {
+		t.Fatalf("bad: %#v", resp3.Evaluations)
+	}
+}
+
+func TestJobEndpoint_Evaluations_Blocking(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	eval1 := mock.Eval()
+	eval2 := mock.Eval()
+	eval2.JobID = "job1"
+	state := s1.fsm.State()
+
+	// First upsert an unrelated eval
+	time.AfterFunc(100*time.Millisecond, func() {
+		err := state.UpsertEvals(100, []*structs.Evaluation{eval1})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	// Upsert an eval for the job we are interested in later
+	time.AfterFunc(200*time.Millisecond, func() {
+		err := state.UpsertEvals(200, []*structs.Evaluation{eval2})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	// Lookup the jobs
+	get := &structs.JobEvaluationsRequest{
+		JobID: "job1",
+		QueryOptions: structs.QueryOptions{
+			Region:        "global",
+			MinQueryIndex: 50,
+		},
+	}
+	var resp structs.JobEvaluationsResponse
+	start := time.Now()
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Evaluations", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("should block (returned in %s) %#v", elapsed, resp)
+	}
+	if resp.Index != 200 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 200)
+	}
+	if len(resp.Evaluations) != 1 || resp.Evaluations[0].JobID != "job1" {
+		t.Fatalf("bad: %#v", resp.Evaluations)
+	}
 }
 
 func TestJobEndpoint_Plan_WithDiff(t *testing.T) {
@@ -1461,3 +2085,306 @@ func TestJobEndpoint_Plan_NoDiff(t *testing.T) {
 		t.Fatalf("no failed task group alloc metrics")
 	}
 }
+
+func TestJobEndpoint_Diff(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// Change the command of the task
+	job2 := job.Copy()
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+
+	// Create a diff request
+	diffReq := &structs.JobDiffRequest{
+		Job:          job2,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var diffResp structs.JobDiffResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Diff", diffReq, &diffResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check the response
+	if diffResp.JobModifyIndex == 0 {
+		t.Fatalf("bad cas: %d", diffResp.JobModifyIndex)
+	}
+	diff := diffResp.Diff
+	if diff == nil || diff.Type != structs.DiffTypeEdited {
+		t.Fatalf("bad diff: %#v", diff)
+	}
+	if len(diff.TaskGroups) != 1 || len(diff.TaskGroups[0].Tasks) != 1 {
+		t.Fatalf("bad task group diffs: %#v", diff.TaskGroups)
+	}
+	taskDiff := diff.TaskGroups[0].Tasks[0]
+	if taskDiff.Type != structs.DiffTypeEdited {
+		t.Fatalf("bad task diff: %#v", taskDiff)
+	}
+	if len(taskDiff.Annotations) != 1 || taskDiff.Annotations[0] != scheduler.AnnotationForcesDestructiveUpdate {
+		t.Fatalf("bad annotations: %v", taskDiff.Annotations)
+	}
+
+	// Diffing an unregistered job shows it as added
+	diffReq.Job = mock.Job()
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Diff", diffReq, &diffResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if diffResp.JobModifyIndex != 0 {
+		t.Fatalf("bad cas: %d", diffResp.JobModifyIndex)
+	}
+	if diffResp.Diff.Type != structs.DiffTypeAdded {
+		t.Fatalf("bad diff: %#v", diffResp.Diff)
+	}
+}
+
+func TestJobEndpoint_Dispatch(t *testing.T) {
+	// No requirements
+	d1 := mock.Job()
+	d1.Type = structs.JobTypeBatch
+	d1.ParameterizedJob = &structs.ParameterizedJobConfig{}
+
+	// Require input data
+	d2 := mock.Job()
+	d2.Type = structs.JobTypeBatch
+	d2.ParameterizedJob = &structs.ParameterizedJobConfig{
+		Payload: structs.DispatchPayloadRequired,
+	}
+
+	// Disallow input data
+	d3 := mock.Job()
+	d3.Type = structs.JobTypeBatch
+	d3.ParameterizedJob = &structs.ParameterizedJobConfig{
+		Payload: structs.DispatchPayloadForbidden,
+	}
+
+	// Require meta
+	d4 := mock.Job()
+	d4.Type = structs.JobTypeBatch
+	d4.ParameterizedJob = &structs.ParameterizedJobConfig{
+		MetaRequired: []string{"foo", "bar"},
+	}
+
+	// Optional meta
+	d5 := mock.Job()
+	d5.Type = structs.JobTypeBatch
+	d5.ParameterizedJob = &structs.ParameterizedJobConfig{
+		MetaOptional: []string{"foo", "bar"},
+	}
+
+	reqNoInputNoMeta := &structs.JobDispatchRequest{}
+	reqInputDataNoMeta := &structs.JobDispatchRequest{
+		Payload: []byte("hello world"),
+	}
+	reqNoInputDataMeta := &structs.JobDispatchRequest{
+		Meta: map[string]string{
+			"foo": "f1",
+			"bar": "f2",
+		},
+	}
+	reqInputDataMeta := &structs.JobDispatchRequest{
+		Payload: []byte("hello world"),
+		Meta: map[string]string{
+			"foo": "f1",
+			"bar": "f2",
+		},
+	}
+	reqBadMeta := &structs.JobDispatchRequest{
+		Payload: []byte("hello world"),
+		Meta: map[string]string{
+			"foo": "f1",
+			"bar": "f2",
+			"baz": "f3",
+		},
+	}
+	reqInputDataTooLarge := &structs.JobDispatchRequest{
+		Payload: make([]byte, structs.DispatchPayloadSizeLimit+100),
+	}
+
+	type testCase struct {
+		name             string
+		parameterizedJob *structs.Job
+		dispatchReq      *structs.JobDispatchRequest
+		err              bool
+		errStr           string
+	}
+	cases := []testCase{
+		{
+			name:             "optional input data w/ data",
+			parameterizedJob: d1,
+			dispatchReq:      reqInputDataNoMeta,
+			err:              false,
+		},
+		{
+			name:             "optional input data w/o data",
+			parameterizedJob: d1,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              false,
+		},
+		{
+			name:             "require input data w/ data",
+			parameterizedJob: d2,
+			dispatchReq:      reqInputDataNoMeta,
+			err:              false,
+		},
+		{
+			name:             "require input data w/o data",
+			parameterizedJob: d2,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              true,
+			errStr:           "not provided but required",
+		},
+		{
+			name:             "disallow input data w/o data",
+			parameterizedJob: d3,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              false,
+		},
+		{
+			name:             "disallow input data w/ data",
+			parameterizedJob: d3,
+			dispatchReq:      reqInputDataNoMeta,
+			err:              true,
+			errStr:           "provided but forbidden",
+		},
+		{
+			name:             "require meta w/ meta",
+			parameterizedJob: d4,
+			dispatchReq:      reqInputDataMeta,
+			err:              false,
+		},
+		{
+			name:             "require meta w/o meta",
+			parameterizedJob: d4,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              true,
+			errStr:           "did not provide required meta keys",
+		},
+		{
+			name:             "optional meta w/ meta",
+			parameterizedJob: d5,
+			dispatchReq:      reqNoInputDataMeta,
+			err:              false,
+		},
+		{
+			name:             "optional meta w/o meta",
+			parameterizedJob: d5,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              false,
+		},
+		{
+			name:             "optional meta w/ bad meta",
+			parameterizedJob: d5,
+			dispatchReq:      reqBadMeta,
+			err:              true,
+			errStr:           "unpermitted metadata keys",
+		},
+		{
+			name:             "optional input w/ too big of input",
+			parameterizedJob: d1,
+			dispatchReq:      reqInputDataTooLarge,
+			err:              true,
+			errStr:           "Payload exceeds maximum size",
+		},
+	}
+
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	for _, tc := range cases {
+		// Create the register request
+		regReq := &structs.JobRegisterRequest{
+			Job:          tc.parameterizedJob,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+
+		// Fetch the response
+		var regResp structs.JobRegisterResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Job.Register", regReq, &regResp); err != nil {
+			t.Fatalf("%s: err: %v", tc.name, err)
+		}
+
+		// Now try to dispatch
+		tc.dispatchReq.JobID = tc.parameterizedJob.ID
+		tc.dispatchReq.WriteRequest = structs.WriteRequest{Region: "global"}
+
+		var dispatchResp structs.JobDispatchResponse
+		dispatchErr := msgpackrpc.CallWithCodec(codec, "Job.Dispatch", tc.dispatchReq, &dispatchResp)
+
+		if dispatchErr == nil {
+			if tc.err {
+				t.Fatalf("%s: Expected error: %v", tc.name, dispatchErr)
+			}
+
+			// Check that we got an eval and job id back
+			if dispatchResp.EvalID == "" || dispatchResp.DispatchedJobID == "" {
+				t.Fatalf("%s: Bad response: %#v", tc.name, dispatchResp)
+			}
+
+			state := s1.fsm.State()
+			out, err := state.JobByID(structs.DefaultNamespace, dispatchResp.DispatchedJobID)
+			if err != nil {
+				t.Fatalf("%s: err: %v", tc.name, err)
+			}
+			if out == nil {
+				t.Fatalf("%s: expected job", tc.name)
+			}
+			if out.CreateIndex != dispatchResp.JobCreateIndex {
+				t.Fatalf("%s: index mis-match", tc.name)
+			}
+			if out.ParentID != tc.parameterizedJob.ID || out.IsParameterized() {
+				t.Fatalf("%s: bad dispatched job: %#v", tc.name, out)
+			}
+			if !reflect.DeepEqual(out.Payload, tc.dispatchReq.Payload) {
+				t.Fatalf("%s: bad payload: %q", tc.name, out.Payload)
+			}
+			for k, v := range tc.dispatchReq.Meta {
+				if out.Meta[k] != v {
+					t.Fatalf("%s: bad meta %q: %q", tc.name, k, out.Meta[k])
+				}
+			}
+
+			// Lookup the evaluation
+			eval, err := state.EvalByID(dispatchResp.EvalID)
+			if err != nil {
+				t.Fatalf("%s: err: %v", tc.name, err)
+			}
+			if eval == nil {
+				t.Fatalf("%s: expected eval", tc.name)
+			}
+			if eval.CreateIndex != dispatchResp.EvalCreateIndex {
+				t.Fatalf("%s: index mis-match", tc.name)
+			}
+		} else {
+			if !tc.err {
+				t.Fatalf("%s: Got unexpected error: %v", tc.name, dispatchErr)
+			} else if !strings.Contains(dispatchErr.Error(), tc.errStr) {
+				t.Fatalf("%s: Expected err to include %q; got %v", tc.name, tc.errStr, dispatchErr)
+			}
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/serf/serf"
@@ -17,6 +18,11 @@ const (
 	// unblocked to re-enter the scheduler. A failed evaluation occurs under
 	// high contention when the schedulers plan does not make progress.
 	failedEvalUnblockInterval = 1 * time.Minute
+
+	// failoverCheckInterval is the interval at which jobs with a failover
+	// config are checked for having been blocked long enough to be moved to
+	// their fallback region.
+	failoverCheckInterval = 30 * time.Second
 )
 
 // monitorLeadership is used to monitor if we acquire or lose our role
@@ -30,10 +36,12 @@ func (s *Server) monitorLeadership() {
 			if isLeader {
 				stopCh = make(chan struct{})
 				go s.leaderLoop(stopCh)
+				metrics.IncrCounter([]string{"nomad", "leader", "acquired"}, 1)
 				s.logger.Printf("[INFO] nomad: cluster leadership acquired")
 			} else if stopCh != nil {
 				close(stopCh)
 				stopCh = nil
+				metrics.IncrCounter([]string{"nomad", "leader", "lost"}, 1)
 				s.logger.Printf("[INFO] nomad: cluster leadership lost")
 			}
 		case <-s.shutdownCh:
@@ -139,6 +147,21 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 		return err
 	}
 
+	// Apply the configured scheduler configuration
+	if err := s.initializeSchedulerConfig(); err != nil {
+		return err
+	}
+
+	// Create the default namespace
+	if err := s.initializeDefaultNamespace(); err != nil {
+		return err
+	}
+
+	// Apply the configured Autopilot configuration if there is none yet
+	if err := s.initializeAutopilotConfig(); err != nil {
+		return err
+	}
+
 	// Enable the periodic dispatcher, since we are now the leader.
 	s.periodicDispatcher.SetEnabled(true)
 	s.periodicDispatcher.Start()
@@ -157,9 +180,24 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 	// Reap any duplicate blocked evaluations
 	go s.reapDupBlockedEvaluations(stopCh)
 
+	// Persist the delivery attempts of Nack'd evaluations
+	go s.persistEvalDeliveries(stopCh)
+
 	// Periodically unblock failed allocations
 	go s.periodicUnblockFailedEvals(stopCh)
 
+	// Move blocked jobs to their failover region
+	go s.failoverBlockedJobs(stopCh)
+
+	// Drive the progress of the running deployments
+	go s.watchDeployments(stopCh)
+
+	// Drive the throttled migrations off draining nodes
+	go s.watchDrains(stopCh)
+
+	// Manage the Raft peers of the servers
+	go s.autopilotLoop(stopCh)
+
 	// Setup the heartbeat timers. This is done both when starting up or when
 	// a leader fail over happens. Since the timers are maintained by the leader
 	// node, effectively this means all the timers are renewed at the time of failover.
@@ -190,12 +228,15 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 // eval tracker is maintained only by the leader, so it must be restored anytime
 // a leadership transition takes place.
 func (s *Server) restoreEvals() error {
+	defer metrics.MeasureSince([]string{"nomad", "leader", "restore_evals"}, time.Now())
+
 	// Get an iterator over every evaluation
 	iter, err := s.fsm.State().Evals()
 	if err != nil {
 		return fmt.Errorf("failed to get evaluations: %v", err)
 	}
 
+	var enqueued, exhausted, blocked int
 	for {
 		raw := iter.Next()
 		if raw == nil {
@@ -205,10 +246,72 @@ func (s *Server) restoreEvals() error {
 
 		if eval.ShouldEnqueue() {
 			s.evalBroker.Enqueue(eval)
+			enqueued++
+			if eval.DeliveryAttempts != 0 && eval.DeliveryAttempts >= s.config.EvalDeliveryLimit {
+				exhausted++
+			}
 		} else if eval.ShouldBlock() {
 			s.blockedEvals.Block(eval)
+			blocked++
 		}
 	}
+
+	// Evaluations that exhausted their delivery attempts before the
+	// leadership transition are failed rather than redelivered.
+	metrics.SetGauge([]string{"nomad", "leader", "restored_evals"}, float32(enqueued))
+	metrics.SetGauge([]string{"nomad", "leader", "restored_exhausted_evals"}, float32(exhausted))
+	metrics.SetGauge([]string{"nomad", "leader", "restored_blocked_evals"}, float32(blocked))
+	s.logger.Printf("[DEBUG] nomad: restored %d evaluations (%d exhausted delivery attempts) and %d blocked evaluations",
+		enqueued, exhausted, blocked)
+	return nil
+}
+
+// initializeSchedulerConfig sets the scheduler configuration of the cluster
+// to the one of the server if they differ.
+func (s *Server) initializeSchedulerConfig() error {
+	existing, err := s.fsm.State().SchedulerConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get scheduler config: %v", err)
+	}
+	if existing != nil && existing.PreemptionConfig == s.config.PreemptionConfig {
+		return nil
+	}
+
+	req := structs.SchedulerSetConfigRequest{
+		Config: structs.SchedulerConfiguration{
+			PreemptionConfig: s.config.PreemptionConfig,
+		},
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	if _, _, err := s.raftApply(structs.SchedulerConfigRequestType, &req); err != nil {
+		return fmt.Errorf("failed to set scheduler config: %v", err)
+	}
+	return nil
+}
+
+// initializeDefaultNamespace creates the default namespace if it doesn't
+// exist yet, such as when the cluster is bootstrapped.
+func (s *Server) initializeDefaultNamespace() error {
+	existing, err := s.fsm.State().NamespaceByName(structs.DefaultNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to get default namespace: %v", err)
+	}
+	if existing != nil {
+		return nil
+	}
+
+	req := structs.NamespaceUpsertRequest{
+		Namespaces: []*structs.Namespace{
+			&structs.Namespace{
+				Name:        structs.DefaultNamespace,
+				Description: "Default shared namespace",
+			},
+		},
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	if _, _, err := s.raftApply(structs.NamespaceUpsertRequestType, &req); err != nil {
+		return fmt.Errorf("failed to create default namespace: %v", err)
+	}
 	return nil
 }
 
@@ -282,7 +385,7 @@ func (s *Server) restorePeriodicDispatcher() error {
 		// If the periodic job has never been launched before, launch will hold
 		// the time the periodic job was added. Otherwise it has the last launch
 		// time of the periodic job.
-		launch, err := s.fsm.State().PeriodicLaunchByID(job.ID)
+		launch, err := s.fsm.State().PeriodicLaunchByID(job.Namespace, job.ID)
 		if err != nil || launch == nil {
 			return fmt.Errorf("failed to get periodic launch time: %v", err)
 		}
@@ -297,7 +400,19 @@ func (s *Server) restorePeriodicDispatcher() error {
 			continue
 		}
 
-		if _, err := s.periodicDispatcher.ForceRun(job.ID); err != nil {
+		// Only a single instance is force run, so any earlier launches that
+		// were missed while there was no leader are recorded as skipped.
+		if missed := missedLaunches(job, nextLaunch, now); len(missed) != 0 {
+			if err := s.SkipLaunches(job, missed); err != nil {
+				msg := fmt.Sprintf("recording skipped launches of periodic job %q failed: %v", job.ID, err)
+				s.logger.Printf("[ERR] nomad.periodic: %s", msg)
+				return errors.New(msg)
+			}
+			s.logger.Printf("[DEBUG] nomad.periodic: periodic job %q skipped"+
+				" %d launches during leadership establishment", job.ID, len(missed))
+		}
+
+		if _, err := s.periodicDispatcher.ForceRun(job.Namespace, job.ID); err != nil {
 			msg := fmt.Sprintf("force run of periodic job %q failed: %v", job.ID, err)
 			s.logger.Printf("[ERR] nomad.periodic: %s", msg)
 			return errors.New(msg)
@@ -309,6 +424,27 @@ func (s *Server) restorePeriodicDispatcher() error {
 	return nil
 }
 
+// missedLaunches returns the launches of the periodic job, starting at the
+// passed launch, that should have occurred before now excluding the most
+// recent one. At most structs.PeriodicMaxSkippedLaunches launches are
+// returned.
+func missedLaunches(job *structs.Job, launch, now time.Time) []time.Time {
+	var missed []time.Time
+	for {
+		next := job.Periodic.Next(launch)
+		if next.IsZero() || !next.Before(now) {
+			break
+		}
+
+		missed = append(missed, launch.UTC())
+		if len(missed) > structs.PeriodicMaxSkippedLaunches {
+			missed = missed[1:]
+		}
+		launch = next
+	}
+	return missed
+}
+
 // schedulePeriodic is used to do periodic job dispatch while we are leader
 func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 	evalGC := time.NewTicker(s.config.EvalGCInterval)
@@ -437,6 +573,31 @@ func (s *Server) reapDupBlockedEvaluations(stopCh chan struct{}) {
 	}
 }
 
+// persistEvalDeliveries is used to persist the delivery attempts of Nack'd
+// evaluations so they aren't reset by a leader election.
+func (s *Server) persistEvalDeliveries(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+			deliveries := s.evalBroker.GetNackedDeliveries(time.Second)
+			if len(deliveries) == 0 {
+				continue
+			}
+
+			// Update via Raft
+			req := structs.EvalDeliveryUpdateRequest{
+				DeliveryAttempts: deliveries,
+			}
+			if _, _, err := s.raftApply(structs.EvalDeliveryUpdateRequestType, &req); err != nil {
+				s.logger.Printf("[ERR] nomad: failed to persist eval delivery attempts: %v", err)
+				continue
+			}
+		}
+	}
+}
+
 // periodicUnblockFailedEvals periodically unblocks failed, blocked evaluations.
 func (s *Server) periodicUnblockFailedEvals(stopCh chan struct{}) {
 	ticker := time.NewTicker(failedEvalUnblockInterval)
@@ -452,6 +613,145 @@ func (s *Server) periodicUnblockFailedEvals(stopCh chan struct{}) {
 	}
 }
 
+// failoverBlockedJobs periodically moves jobs that have been blocked for
+// longer than their failover period to their fallback region.
+func (s *Server) failoverBlockedJobs(stopCh chan struct{}) {
+	ticker := time.NewTicker(failoverCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			s.failoverJobs(time.Now())
+		}
+	}
+}
+
+// failoverJobs moves the jobs that have been blocked for longer than their
+// failover period as of now. The time a job has been blocked for is derived
+// from the index its blocked evaluation was created at, using the time table,
+// so that it is not reset by leader elections. Only jobs without any
+// allocation placed are moved; a partially placed job keeps running in its
+// region.
+func (s *Server) failoverJobs(now time.Time) {
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to find blocked jobs to failover: %v", err)
+		return
+	}
+
+	blocked, err := blockedFailoverEvals(snap)
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to find blocked jobs to failover: %v", err)
+		return
+	}
+
+	tt := s.fsm.TimeTable()
+	for jobID, eval := range blocked {
+		job, err := snap.JobByID(jobID.Namespace, jobID.ID)
+		if err != nil {
+			s.logger.Printf("[ERR] nomad: failed to lookup job %q to failover: %v", jobID.ID, err)
+			continue
+		}
+		if job == nil || job.Failover == nil || job.FailedOver() {
+			continue
+		}
+
+		since := tt.NearestTime(eval.CreateIndex)
+		if now.Sub(since) < job.Failover.After {
+			continue
+		}
+
+		allocs, err := snap.AllocsByJob(jobID.Namespace, jobID.ID)
+		if err != nil {
+			s.logger.Printf("[ERR] nomad: failed to lookup allocations of job %q to failover: %v", jobID.ID, err)
+			continue
+		}
+		placed := false
+		for _, alloc := range allocs {
+			if !alloc.TerminalStatus() {
+				placed = true
+				break
+			}
+		}
+		if placed {
+			continue
+		}
+
+		if err := s.failoverJob(job); err != nil {
+			s.logger.Printf("[ERR] nomad: failed to failover job %q to region %q: %v",
+				job.ID, job.Failover.Region, err)
+			continue
+		}
+		s.logger.Printf("[INFO] nomad: job %q blocked since %v moved to region %q",
+			job.ID, since, job.Failover.Region)
+	}
+}
+
+// blockedFailoverEvals returns the most recent blocked evaluation of each job.
+func blockedFailoverEvals(snap *state.StateSnapshot) (map[structs.NamespacedID]*structs.Evaluation, error) {
+	iter, err := snap.Evals()
+	if err != nil {
+		return nil, err
+	}
+
+	evals := make(map[structs.NamespacedID]*structs.Evaluation)
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		eval := raw.(*structs.Evaluation)
+		if eval.Status != structs.EvalStatusBlocked {
+			continue
+		}
+		jobID := structs.NewNamespacedID(eval.JobID, eval.Namespace)
+		if existing, ok := evals[jobID]; ok && existing.CreateIndex > eval.CreateIndex {
+			continue
+		}
+		evals[jobID] = eval
+	}
+	return evals, nil
+}
+
+// failoverJob registers a copy of the job in its failover region, recording
+// the region it was moved from. The job is then updated in this region to
+// record the region it was moved to, which stops it from being placed here.
+func (s *Server) failoverJob(job *structs.Job) error {
+	moved := job.Copy()
+	moved.Region = job.Failover.Region
+	moved.Failover = nil
+	moved.FailoverFrom = s.config.Region
+	moved.Status = ""
+	moved.StatusDescription = ""
+
+	regReq := &structs.JobRegisterRequest{
+		Job:          moved,
+		WriteRequest: structs.WriteRequest{Region: moved.Region},
+	}
+	var regResp structs.JobRegisterResponse
+	if err := s.RPC("Job.Register", regReq, &regResp); err != nil {
+		return fmt.Errorf("failed to register job: %v", err)
+	}
+
+	home := job.Copy()
+	home.FailoverTo = job.Failover.Region
+	home.Status = ""
+	home.StatusDescription = ""
+
+	homeReq := &structs.JobRegisterRequest{
+		Job:          home,
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	var homeResp structs.JobRegisterResponse
+	if err := s.RPC("Job.Register", homeReq, &homeResp); err != nil {
+		return fmt.Errorf("failed to record the failover of the job: %v", err)
+	}
+	return nil
+}
+
 // revokeLeadership is invoked once we step down as leader.
 // This is used to cleanup any state that may be specific to a leader.
 func (s *Server) revokeLeadership() error {
@@ -470,6 +770,11 @@ func (s *Server) revokeLeadership() error {
 	// Disable the Vault client as it is only useful as a leader.
 	s.vault.SetActive(false)
 
+	// Clear the cluster health, it is only tracked by the leader
+	s.clusterHealthLock.Lock()
+	s.clusterHealth = structs.OperatorHealthReply{}
+	s.clusterHealthLock.Unlock()
+
 	// Clear the heartbeat timers on either shutdown or step down,
 	// since we are no longer responsible for TTL expirations.
 	if err := s.clearAllHeartbeatTimers(); err != nil {
@@ -561,13 +866,33 @@ func (s *Server) addRaftPeer(m serf.Member, parts *serverParts) error {
 		}
 	}
 
+	// Leave a new server to Autopilot until it is stable
+	addr := parts.Addr.String()
+	peers, err := s.raftPeerSet()
+	if err != nil {
+		return err
+	}
+	if _, ok := peers[addr]; ok {
+		return nil
+	}
+	if !s.serverStable(addr) {
+		s.logger.Printf("[DEBUG] nomad: waiting for server %v to be stable before adding it as raft peer", parts)
+		return nil
+	}
+
 	// Attempt to add as a peer
-	future := s.raft.AddPeer(parts.Addr.String())
+	future := s.raft.AddPeer(addr)
 	if err := future.Error(); err != nil && err != raft.ErrKnownPeer {
 		s.logger.Printf("[ERR] nomad: failed to add raft peer: %v", err)
 		return err
 	} else if err == nil {
 		s.logger.Printf("[INFO] nomad: added raft peer: %v", parts)
+
+		// Dead servers may be removed now that a server joined
+		select {
+		case s.autopilotRemoveDeadCh <- struct{}{}:
+		default:
+		}
 	}
 	return nil
 }
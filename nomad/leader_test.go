@@ -360,7 +360,7 @@ func TestLeader_PeriodicDispatcher_Restore_Adds(t *testing.T) {
 
 	// Check that the new leader is tracking the periodic job.
 	testutil.WaitForResult(func() (bool, error) {
-		_, tracked := leader.periodicDispatcher.tracked[periodic.ID]
+		_, tracked := leader.periodicDispatcher.tracked[periodic.NamespacedID()]
 		return tracked, nil
 	}, func(err error) {
 		t.Fatalf("periodic job not tracked")
@@ -401,12 +401,12 @@ func TestLeader_PeriodicDispatcher_Restore_NoEvals(t *testing.T) {
 	s1.restorePeriodicDispatcher()
 
 	// Ensure the job is tracked.
-	if _, tracked := s1.periodicDispatcher.tracked[job.ID]; !tracked {
+	if _, tracked := s1.periodicDispatcher.tracked[job.NamespacedID()]; !tracked {
 		t.Fatalf("periodic job not restored")
 	}
 
 	// Check that an eval was made.
-	last, err := s1.fsm.State().PeriodicLaunchByID(job.ID)
+	last, err := s1.fsm.State().PeriodicLaunchByID(job.Namespace, job.ID)
 	if err != nil || last == nil {
 		t.Fatalf("failed to get periodic launch time: %v", err)
 	}
@@ -452,12 +452,12 @@ func TestLeader_PeriodicDispatcher_Restore_Evals(t *testing.T) {
 	s1.restorePeriodicDispatcher()
 
 	// Ensure the job is tracked.
-	if _, tracked := s1.periodicDispatcher.tracked[job.ID]; !tracked {
+	if _, tracked := s1.periodicDispatcher.tracked[job.NamespacedID()]; !tracked {
 		t.Fatalf("periodic job not restored")
 	}
 
 	// Check that an eval was made.
-	last, err := s1.fsm.State().PeriodicLaunchByID(job.ID)
+	last, err := s1.fsm.State().PeriodicLaunchByID(job.Namespace, job.ID)
 	if err != nil || last == nil {
 		t.Fatalf("failed to get periodic launch time: %v", err)
 	}
@@ -466,6 +466,56 @@ func TestLeader_PeriodicDispatcher_Restore_Evals(t *testing.T) {
 	}
 }
 
+func TestLeader_PeriodicDispatcher_Restore_SkippedLaunches(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Inject a periodic job that missed three launches while there was no
+	// leader and launches once in the future.
+	now := time.Now().Round(time.Second)
+	missed1 := now.Add(-3 * time.Second)
+	missed2 := now.Add(-2 * time.Second)
+	missed3 := now.Add(-1 * time.Second)
+	future := now.Add(10 * time.Second)
+	job := testPeriodicJob(missed1, missed2, missed3, future)
+	req := structs.JobRegisterRequest{
+		Job: job,
+	}
+	_, _, err := s1.raftApply(structs.JobRegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Make the last launch precede the missed launches.
+	s1.periodicDispatcher.SetEnabled(false)
+	launch := &structs.PeriodicLaunch{ID: job.ID, Launch: now.Add(-4 * time.Second)}
+	if err := s1.fsm.State().UpsertPeriodicLaunch(1000, launch); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Restore the periodic dispatcher.
+	s1.periodicDispatcher.SetEnabled(true)
+	s1.periodicDispatcher.Start()
+	if err := s1.restorePeriodicDispatcher(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The most recent missed launch is force run and the others are skipped.
+	last, err := s1.fsm.State().PeriodicLaunchByID(job.Namespace, job.ID)
+	if err != nil || last == nil {
+		t.Fatalf("failed to get periodic launch time: %v", err)
+	}
+	if len(last.Skipped) != 2 || !last.Skipped[0].Equal(missed1) || !last.Skipped[1].Equal(missed2) {
+		t.Fatalf("bad skipped launches: got %v; want [%v %v]", last.Skipped, missed1, missed2)
+	}
+	if last.Launch.Before(missed3) {
+		t.Fatalf("restorePeriodicDispatcher did not force launch")
+	}
+}
+
 func TestLeader_PeriodicDispatch(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0
@@ -518,6 +568,76 @@ func TestLeader_ReapFailedEval(t *testing.T) {
 	})
 }
 
+func TestLeader_PersistEvalDeliveries(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+		c.EvalDeliveryLimit = 3
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a pending eval, which is enqueued
+	eval := mock.Eval()
+	req := structs.EvalUpdateRequest{
+		Evals: []*structs.Evaluation{eval},
+	}
+	if _, _, err := s1.raftApply(structs.EvalUpdateRequestType, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Dequeue and Nack
+	out, token, err := s1.evalBroker.Dequeue(defaultSched, time.Second)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.ID != eval.ID {
+		t.Fatalf("bad: %#v", out)
+	}
+	s1.evalBroker.Nack(out.ID, token)
+
+	// Wait for the delivery attempt to be persisted
+	state := s1.fsm.State()
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.EvalByID(eval.ID)
+		if err != nil {
+			return false, err
+		}
+		return out != nil && out.DeliveryAttempts == 1, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Simulate a leader election, which restores the evals into a flushed
+	// broker
+	s1.evalBroker.SetEnabled(false)
+	s1.evalBroker.SetEnabled(true)
+	if err := s1.restoreEvals(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The remaining two deliveries exhaust the delivery limit
+	for i := 0; i < 2; i++ {
+		out, token, err := s1.evalBroker.Dequeue(defaultSched, time.Second)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out == nil || out.ID != eval.ID {
+			t.Fatalf("bad: %#v", out)
+		}
+		s1.evalBroker.Nack(out.ID, token)
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.EvalByID(eval.ID)
+		if err != nil {
+			return false, err
+		}
+		return out != nil && out.Status == structs.EvalStatusFailed, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
 func TestLeader_ReapDuplicateEval(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0
@@ -572,3 +692,109 @@ func TestLeader_RestoreVaultAccessors(t *testing.T) {
 		t.Fatalf("Bad revoked accessors: %v", tvc.RevokedTokens)
 	}
 }
+
+func TestLeader_FailoverJobs(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+	})
+	defer s1.Shutdown()
+	s2 := testServer(t, func(c *Config) {
+		c.Region = "region2"
+		c.NumSchedulers = 0
+	})
+	defer s2.Shutdown()
+	testJoin(t, s1, s2)
+	testutil.WaitForLeader(t, s1.RPC)
+	testutil.WaitForLeader(t, s2.RPC)
+
+	// Insert a job that failovers to the second region, a blocked eval and
+	// a running allocation
+	state := s1.fsm.State()
+	job := mock.Job()
+	job.Failover = &structs.FailoverConfig{
+		Region: "region2",
+		After:  10 * time.Minute,
+	}
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	eval := mock.Eval()
+	eval.JobID = job.ID
+	eval.Status = structs.EvalStatusBlocked
+	if err := state.UpsertEvals(1001, []*structs.Evaluation{eval}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	state.UpsertJobSummary(1002, mock.JobSummary(alloc.JobID))
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The job is not moved before it has been blocked for the failover period
+	since := s1.fsm.TimeTable().NearestTime(eval.CreateIndex)
+	s1.failoverJobs(since.Add(job.Failover.After - time.Second))
+	if out, err := s2.fsm.State().JobByID(job.Namespace, job.ID); err != nil || out != nil {
+		t.Fatalf("job moved early: %v %v", out, err)
+	}
+
+	// The job is not moved while it has a running allocation
+	s1.failoverJobs(since.Add(job.Failover.After))
+	if out, err := s2.fsm.State().JobByID(job.Namespace, job.ID); err != nil || out != nil {
+		t.Fatalf("partially placed job moved: %v %v", out, err)
+	}
+
+	// The job is moved once nothing of it is placed
+	stopped := alloc.Copy()
+	stopped.ClientStatus = structs.AllocClientStatusFailed
+	if err := state.UpdateAllocsFromClient(1004, []*structs.Allocation{stopped}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s1.failoverJobs(since.Add(job.Failover.After))
+
+	out, err := s2.fsm.State().JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Region != "region2" || out.FailoverFrom != "global" || out.Failover != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The job is kept in its region with a record of the move
+	out, err = state.JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.FailoverTo != "region2" || !out.FailedOver() {
+		t.Fatalf("failover not recorded: %#v", out)
+	}
+}
+
+func TestLeader_InitializeSchedulerConfig(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.PreemptionConfig.BatchSchedulerEnabled = true
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := s1.fsm.State().SchedulerConfig()
+		if err != nil {
+			return false, err
+		}
+		if out == nil {
+			return false, fmt.Errorf("scheduler config not set")
+		}
+		expected := structs.PreemptionConfig{
+			SystemSchedulerEnabled: true,
+			BatchSchedulerEnabled:  true,
+		}
+		if out.PreemptionConfig != expected {
+			return false, fmt.Errorf("bad: %#v", out)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
@@ -64,6 +64,7 @@ func Job() *structs.Job {
 		Region:      "global",
 		ID:          structs.GenerateUUID(),
 		Name:        "my-job",
+		Namespace:   structs.DefaultNamespace,
 		Type:        structs.JobTypeService,
 		Priority:    50,
 		AllAtOnce:   false,
@@ -160,6 +161,7 @@ func SystemJob() *structs.Job {
 		Region:      "global",
 		ID:          structs.GenerateUUID(),
 		Name:        "my-job",
+		Namespace:   structs.DefaultNamespace,
 		Type:        structs.JobTypeSystem,
 		Priority:    100,
 		AllAtOnce:   false,
@@ -229,18 +231,20 @@ func PeriodicJob() *structs.Job {
 
 func Eval() *structs.Evaluation {
 	eval := &structs.Evaluation{
-		ID:       structs.GenerateUUID(),
-		Priority: 50,
-		Type:     structs.JobTypeService,
-		JobID:    structs.GenerateUUID(),
-		Status:   structs.EvalStatusPending,
+		ID:        structs.GenerateUUID(),
+		Priority:  50,
+		Type:      structs.JobTypeService,
+		JobID:     structs.GenerateUUID(),
+		Namespace: structs.DefaultNamespace,
+		Status:    structs.EvalStatusPending,
 	}
 	return eval
 }
 
 func JobSummary(jobID string) *structs.JobSummary {
 	js := &structs.JobSummary{
-		JobID: jobID,
+		JobID:     jobID,
+		Namespace: structs.DefaultNamespace,
 		Summary: map[string]structs.TaskGroupSummary{
 			"web": {
 				Queued:   0,
@@ -256,6 +260,7 @@ func Alloc() *structs.Allocation {
 		ID:        structs.GenerateUUID(),
 		EvalID:    structs.GenerateUUID(),
 		NodeID:    "12345678-abcd-efab-cdef-123456789abc",
+		Namespace: structs.DefaultNamespace,
 		TaskGroup: "web",
 		Resources: &structs.Resources{
 			CPU:      500,
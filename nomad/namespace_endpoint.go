@@ -0,0 +1,171 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// Namespace endpoint is used for manipulating namespaces
+type Namespace struct {
+	srv *Server
+}
+
+// List is used to list the namespaces in the system
+func (n *Namespace) List(args *structs.NamespaceListRequest, reply *structs.NamespaceListResponse) error {
+	if done, err := n.srv.forward("Namespace.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "namespace", "list"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "namespaces"}),
+		run: func() error {
+			// Capture all the namespaces
+			snap, err := n.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = snap.NamespacesByNamePrefix(prefix)
+			} else {
+				iter, err = snap.Namespaces()
+			}
+			if err != nil {
+				return err
+			}
+
+			var namespaces []*structs.Namespace
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				namespaces = append(namespaces, raw.(*structs.Namespace))
+			}
+			reply.Namespaces = namespaces
+
+			// Use the last index that affected the namespaces table
+			index, err := snap.Index("namespaces")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			n.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return n.srv.blockingRPC(&opts)
+}
+
+// GetNamespace is used to lookup a particular namespace
+func (n *Namespace) GetNamespace(args *structs.NamespaceSpecificRequest,
+	reply *structs.SingleNamespaceResponse) error {
+	if done, err := n.srv.forward("Namespace.GetNamespace", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "namespace", "get_namespace"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "namespaces"}),
+		run: func() error {
+			// Lookup the namespace
+			snap, err := n.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.NamespaceByName(args.Name)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Namespace = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the namespaces table
+				index, err := snap.Index("namespaces")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			n.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return n.srv.blockingRPC(&opts)
+}
+
+// UpsertNamespaces is used to create or update namespaces
+func (n *Namespace) UpsertNamespaces(args *structs.NamespaceUpsertRequest,
+	reply *structs.GenericResponse) error {
+	if done, err := n.srv.forward("Namespace.UpsertNamespaces", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "namespace", "upsert_namespaces"}, time.Now())
+
+	// Validate the arguments
+	if len(args.Namespaces) == 0 {
+		return fmt.Errorf("must specify at least one namespace")
+	}
+	for _, namespace := range args.Namespaces {
+		if err := namespace.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Commit this update via Raft
+	resp, index, err := n.srv.raftApply(structs.NamespaceUpsertRequestType, args)
+	if err != nil {
+		n.srv.logger.Printf("[ERR] nomad.namespace: UpsertNamespaces failed: %v", err)
+		return err
+	}
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+
+	reply.Index = index
+	return nil
+}
+
+// DeleteNamespaces is used to delete namespaces that no longer hold any job
+func (n *Namespace) DeleteNamespaces(args *structs.NamespaceDeleteRequest,
+	reply *structs.GenericResponse) error {
+	if done, err := n.srv.forward("Namespace.DeleteNamespaces", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "namespace", "delete_namespaces"}, time.Now())
+
+	// Validate the arguments
+	if len(args.Namespaces) == 0 {
+		return fmt.Errorf("must specify at least one namespace to delete")
+	}
+
+	// Commit this update via Raft
+	resp, index, err := n.srv.raftApply(structs.NamespaceDeleteRequestType, args)
+	if err != nil {
+		n.srv.logger.Printf("[ERR] nomad.namespace: DeleteNamespaces failed: %v", err)
+		return err
+	}
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+
+	reply.Index = index
+	return nil
+}
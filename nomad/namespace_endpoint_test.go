@@ -0,0 +1,146 @@
+package nomad
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestNamespaceEndpoint_Upsert_Get_List(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Invalid namespaces are rejected
+	req := &structs.NamespaceUpsertRequest{
+		Namespaces:   []*structs.Namespace{{Name: "engineering team"}},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	err := msgpackrpc.CallWithCodec(codec, "Namespace.UpsertNamespaces", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "Invalid namespace name") {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	// Create the namespace
+	req.Namespaces[0].Name = "engineering"
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.UpsertNamespaces", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// Lookup the namespace
+	get := &structs.NamespaceSpecificRequest{
+		Name:         "engineering",
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var single structs.SingleNamespaceResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.GetNamespace", get, &single); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if single.Index != resp.Index {
+		t.Fatalf("Bad index: %d %d", single.Index, resp.Index)
+	}
+	if single.Namespace == nil || single.Namespace.Name != "engineering" {
+		t.Fatalf("bad: %#v", single.Namespace)
+	}
+
+	// List the namespaces by prefix
+	list := &structs.NamespaceListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global", Prefix: "eng"},
+	}
+	var listResp structs.NamespaceListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.List", list, &listResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(listResp.Namespaces) != 1 || listResp.Namespaces[0].Name != "engineering" {
+		t.Fatalf("bad: %#v", listResp.Namespaces)
+	}
+
+	// The default namespace is created by the leader
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := s1.fsm.State().NamespaceByName(structs.DefaultNamespace)
+		return out != nil, err
+	}, func(err error) {
+		t.Fatalf("default namespace not created: %v", err)
+	})
+}
+
+func TestNamespaceEndpoint_Upsert_UnknownQuota(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the upsert request with a namespace using a missing quota
+	req := &structs.NamespaceUpsertRequest{
+		Namespaces:   []*structs.Namespace{{Name: "engineering", Quota: "engineering"}},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	err := msgpackrpc.CallWithCodec(codec, "Namespace.UpsertNamespaces", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected a missing quota error but got: %v", err)
+	}
+
+	// Upserting succeeds once the quota exists
+	quota := &structs.QuotaSpec{
+		Name:  "engineering",
+		Limit: &structs.QuotaLimit{CPU: 1000},
+	}
+	if err := s1.fsm.State().UpsertQuotaSpec(1000, quota); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.UpsertNamespaces", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestNamespaceEndpoint_Delete(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	state := s1.fsm.State()
+	ns := &structs.Namespace{Name: "engineering"}
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	job := mock.Job()
+	job.Namespace = "engineering"
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Deleting the namespace while it has jobs fails
+	req := &structs.NamespaceDeleteRequest{
+		Namespaces:   []string{"engineering"},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.DeleteNamespaces", req, &resp); err == nil {
+		t.Fatalf("expected an error deleting a namespace with jobs")
+	}
+
+	if err := state.DeleteJob(1002, job.Namespace, job.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.DeleteNamespaces", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := state.NamespaceByName("engineering")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
@@ -399,9 +399,22 @@ func (n *Node) UpdateDrain(args *structs.NodeUpdateDrainRequest,
 	// Update the timestamp to
 	node.StatusUpdatedAt = time.Now().Unix()
 
-	// Commit this update via Raft
+	// The deadline of the drain starts when drain mode is enabled
+	if args.Drain {
+		if args.DrainStrategy == nil {
+			args.DrainStrategy = &structs.DrainStrategy{}
+		}
+		if args.DrainStrategy.Deadline != 0 {
+			args.DrainStrategy.ForceDeadline = time.Now().UTC().Add(args.DrainStrategy.Deadline)
+		}
+	} else {
+		args.DrainStrategy = nil
+	}
+
+	// Commit this update via Raft. The drain strategy of a draining node is
+	// replaced by the new one.
 	var index uint64
-	if node.Drain != args.Drain {
+	if node.Drain != args.Drain || args.Drain {
 		_, index, err = n.srv.raftApply(structs.NodeUpdateDrainRequestType, args)
 		if err != nil {
 			n.srv.logger.Printf("[ERR] nomad.client: drain update failed: %v", err)
@@ -721,6 +734,9 @@ func (n *Node) batchUpdate(future *batchFuture, updates []*structs.Allocation) {
 	if err != nil {
 		n.srv.logger.Printf("[ERR] nomad.client: alloc update failed: %v", err)
 		mErr.Errors = append(mErr.Errors, err)
+	} else if err := n.createRescheduleEvals(updates); err != nil {
+		n.srv.logger.Printf("[ERR] nomad.client: reschedule eval creation failed: %v", err)
+		mErr.Errors = append(mErr.Errors, err)
 	}
 
 	// For each allocation we are updating check if we should revoke any
@@ -783,15 +799,26 @@ func (n *Node) List(args *structs.NodeListRequest,
 			}
 
 			var nodes []*structs.NodeListStub
+			page := newPaginator(&args.QueryOptions)
 			for {
 				raw := iter.Next()
 				if raw == nil {
 					break
 				}
 				node := raw.(*structs.Node)
+				if !args.Matches(node) {
+					continue
+				}
+				if !page.accept(node.ID) {
+					if page.done() {
+						break
+					}
+					continue
+				}
 				nodes = append(nodes, node.Stub())
 			}
 			reply.Nodes = nodes
+			reply.NextToken = page.next
 
 			// Use the last index that affected the jobs table
 			index, err := snap.Index("nodes")
@@ -840,14 +867,15 @@ func (n *Node) createNodeEvals(nodeID string, nodeIndex uint64) ([]string, uint6
 	// Create an eval for each JobID affected
 	var evals []*structs.Evaluation
 	var evalIDs []string
-	jobIDs := make(map[string]struct{})
+	jobIDs := make(map[structs.NamespacedID]struct{})
 
 	for _, alloc := range allocs {
 		// Deduplicate on JobID
-		if _, ok := jobIDs[alloc.JobID]; ok {
+		jobID := structs.NewNamespacedID(alloc.JobID, alloc.Namespace)
+		if _, ok := jobIDs[jobID]; ok {
 			continue
 		}
-		jobIDs[alloc.JobID] = struct{}{}
+		jobIDs[jobID] = struct{}{}
 
 		// Create a new eval
 		eval := &structs.Evaluation{
@@ -856,6 +884,7 @@ func (n *Node) createNodeEvals(nodeID string, nodeIndex uint64) ([]string, uint6
 			Type:            alloc.Job.Type,
 			TriggeredBy:     structs.EvalTriggerNodeUpdate,
 			JobID:           alloc.JobID,
+			Namespace:       alloc.Namespace,
 			NodeID:          nodeID,
 			NodeModifyIndex: nodeIndex,
 			Status:          structs.EvalStatusPending,
@@ -867,10 +896,10 @@ func (n *Node) createNodeEvals(nodeID string, nodeIndex uint64) ([]string, uint6
 	// Create an evaluation for each system job.
 	for _, job := range sysJobs {
 		// Still dedup on JobID as the node may already have the system job.
-		if _, ok := jobIDs[job.ID]; ok {
+		if _, ok := jobIDs[job.NamespacedID()]; ok {
 			continue
 		}
-		jobIDs[job.ID] = struct{}{}
+		jobIDs[job.NamespacedID()] = struct{}{}
 
 		// Create a new eval
 		eval := &structs.Evaluation{
@@ -879,6 +908,7 @@ func (n *Node) createNodeEvals(nodeID string, nodeIndex uint64) ([]string, uint6
 			Type:            job.Type,
 			TriggeredBy:     structs.EvalTriggerNodeUpdate,
 			JobID:           job.ID,
+			Namespace:       job.Namespace,
 			NodeID:          nodeID,
 			NodeModifyIndex: nodeIndex,
 			Status:          structs.EvalStatusPending,
@@ -903,6 +933,75 @@ func (n *Node) createNodeEvals(nodeID string, nodeIndex uint64) ([]string, uint6
 	return evalIDs, evalIndex, nil
 }
 
+// createRescheduleEvals creates an evaluation for each job with allocations
+// that failed on the client and whose task group has a reschedule policy, so
+// that the scheduler replaces them according to it.
+func (n *Node) createRescheduleEvals(updates []*structs.Allocation) error {
+	// Snapshot the state
+	snap, err := n.srv.fsm.State().Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to snapshot state: %v", err)
+	}
+
+	var evals []*structs.Evaluation
+	jobIDs := make(map[structs.NamespacedID]struct{})
+	for _, update := range updates {
+		if update.ClientStatus != structs.AllocClientStatusFailed {
+			continue
+		}
+
+		alloc, err := snap.AllocByID(update.ID)
+		if err != nil {
+			return err
+		}
+		if alloc == nil || alloc.DesiredStatus != structs.AllocDesiredStatusRun {
+			continue
+		}
+
+		// Deduplicate on JobID
+		jobID := structs.NewNamespacedID(alloc.JobID, alloc.Namespace)
+		if _, ok := jobIDs[jobID]; ok {
+			continue
+		}
+
+		job, err := snap.JobByID(alloc.Namespace, alloc.JobID)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			continue
+		}
+		tg := job.LookupTaskGroup(alloc.TaskGroup)
+		if tg == nil || tg.ReschedulePolicy == nil || !tg.ReschedulePolicy.Enabled() {
+			continue
+		}
+		jobIDs[jobID] = struct{}{}
+
+		evals = append(evals, &structs.Evaluation{
+			ID:             structs.GenerateUUID(),
+			Priority:       job.Priority,
+			Type:           job.Type,
+			TriggeredBy:    structs.EvalTriggerRetryFailedAlloc,
+			JobID:          job.ID,
+			Namespace:      job.Namespace,
+			JobModifyIndex: job.JobModifyIndex,
+			Status:         structs.EvalStatusPending,
+		})
+	}
+
+	// Fast-path if nothing to do
+	if len(evals) == 0 {
+		return nil
+	}
+
+	update := &structs.EvalUpdateRequest{
+		Evals:        evals,
+		WriteRequest: structs.WriteRequest{Region: n.srv.config.Region},
+	}
+	_, _, err = n.srv.raftApply(structs.EvalUpdateRequestType, update)
+	return err
+}
+
 // batchFuture is used to wait on a batch update to complete
 type batchFuture struct {
 	doneCh chan struct{}
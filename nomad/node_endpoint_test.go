@@ -613,11 +613,16 @@ func TestClientEndpoint_UpdateDrain(t *testing.T) {
 
 	// Update the status
 	dereg := &structs.NodeUpdateDrainRequest{
-		NodeID:       node.ID,
-		Drain:        true,
+		NodeID: node.ID,
+		Drain:  true,
+		DrainStrategy: &structs.DrainStrategy{
+			Deadline:         time.Hour,
+			IgnoreSystemJobs: true,
+		},
 		WriteRequest: structs.WriteRequest{Region: "global"},
 	}
 	var resp2 structs.NodeDrainUpdateResponse
+	start := time.Now()
 	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateDrain", dereg, &resp2); err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -634,6 +639,28 @@ func TestClientEndpoint_UpdateDrain(t *testing.T) {
 	if !out.Drain {
 		t.Fatalf("bad: %#v", out)
 	}
+
+	// The deadline should start when drain mode was enabled
+	strategy := out.DrainStrategy
+	if strategy == nil || !strategy.IgnoreSystemJobs {
+		t.Fatalf("bad: %#v", strategy)
+	}
+	if strategy.ForceDeadline.Before(start.Add(time.Hour)) || strategy.ForceDeadline.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("bad deadline: %v", strategy.ForceDeadline)
+	}
+
+	// Disabling drain mode should clear the strategy
+	dereg.Drain = false
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateDrain", dereg, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Drain || out.DrainStrategy != nil {
+		t.Fatalf("bad: %#v", out)
+	}
 }
 
 // This test ensures that Nomad marks client state of allocations which are in
@@ -683,11 +710,11 @@ func TestClientEndpoint_Drain_Down(t *testing.T) {
 
 	// Wait for the scheduler to create an allocation
 	testutil.WaitForResult(func() (bool, error) {
-		allocs, err := s1.fsm.state.AllocsByJob(job.ID)
+		allocs, err := s1.fsm.state.AllocsByJob(job.Namespace, job.ID)
 		if err != nil {
 			return false, err
 		}
-		allocs1, err := s1.fsm.state.AllocsByJob(job1.ID)
+		allocs1, err := s1.fsm.state.AllocsByJob(job1.Namespace, job1.ID)
 		if err != nil {
 			return false, err
 		}
@@ -719,12 +746,13 @@ func TestClientEndpoint_Drain_Down(t *testing.T) {
 
 	// Ensure that the allocation has transitioned to lost
 	testutil.WaitForResult(func() (bool, error) {
-		summary, err := s1.fsm.state.JobSummaryByID(job.ID)
+		summary, err := s1.fsm.state.JobSummaryByID(job.Namespace, job.ID)
 		if err != nil {
 			return false, err
 		}
 		expectedSummary := &structs.JobSummary{
-			JobID: job.ID,
+			Namespace: structs.DefaultNamespace,
+			JobID:     job.ID,
 			Summary: map[string]structs.TaskGroupSummary{
 				"web": structs.TaskGroupSummary{
 					Queued: 1,
@@ -738,12 +766,13 @@ func TestClientEndpoint_Drain_Down(t *testing.T) {
 			return false, fmt.Errorf("expected: %#v, actual: %#v", expectedSummary, summary)
 		}
 
-		summary1, err := s1.fsm.state.JobSummaryByID(job1.ID)
+		summary1, err := s1.fsm.state.JobSummaryByID(job1.Namespace, job1.ID)
 		if err != nil {
 			return false, err
 		}
 		expectedSummary1 := &structs.JobSummary{
-			JobID: job1.ID,
+			Namespace: structs.DefaultNamespace,
+			JobID:     job1.ID,
 			Summary: map[string]structs.TaskGroupSummary{
 				"web": structs.TaskGroupSummary{
 					Lost: 1,
@@ -1296,6 +1325,68 @@ func TestClientEndpoint_UpdateAlloc(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_UpdateAlloc_Reschedule(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	node := mock.Node()
+	reg := &structs.NodeRegisterRequest{
+		Node:         node,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.GenericResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Inject a job rescheduling its failed allocations
+	state := s1.fsm.State()
+	job := mock.Job()
+	job.TaskGroups[0].ReschedulePolicy = structs.NewReschedulePolicy(job.Type)
+	if err := state.UpsertJob(98, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	alloc.Job = job
+	alloc.JobID = job.ID
+	if err := state.UpsertAllocs(100, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Fail the alloc
+	clientAlloc := new(structs.Allocation)
+	*clientAlloc = *alloc
+	clientAlloc.ClientStatus = structs.AllocClientStatusFailed
+	update := &structs.AllocUpdateRequest{
+		Alloc:        []*structs.Allocation{clientAlloc},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp2 structs.NodeAllocsResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateAlloc", update, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure an eval was created to reschedule the alloc
+	evals, err := state.EvalsByJob(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evals) != 1 {
+		t.Fatalf("expected one eval, got: %#v", evals)
+	}
+	eval := evals[0]
+	if eval.TriggeredBy != structs.EvalTriggerRetryFailedAlloc ||
+		eval.Type != job.Type || eval.Priority != job.Priority {
+		t.Fatalf("bad: %#v", eval)
+	}
+}
+
 func TestClientEndpoint_BatchUpdate(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -1651,6 +1742,56 @@ func TestClientEndpoint_ListNodes(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_ListNodes_Filter(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create nodes of two datacenters and classes
+	node1 := mock.Node()
+	node1.Name = "web-1"
+	node2 := mock.Node()
+	node2.Name = "batch-1"
+	node2.Datacenter = "dc2"
+	node2.NodeClass = "batch"
+	node2.Status = structs.NodeStatusDown
+	state := s1.fsm.State()
+	if err := state.UpsertNode(1000, node1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertNode(1001, node2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		args     structs.NodeListRequest
+		expected []string
+	}{
+		{"status", structs.NodeListRequest{Status: structs.NodeStatusDown}, []string{node2.ID}},
+		{"datacenter", structs.NodeListRequest{Datacenter: "dc1"}, []string{node1.ID}},
+		{"class", structs.NodeListRequest{NodeClass: "batch"}, []string{node2.ID}},
+		{"name prefix", structs.NodeListRequest{NamePrefix: "web"}, []string{node1.ID}},
+		{"no match", structs.NodeListRequest{Datacenter: "dc2", NamePrefix: "web"}, nil},
+	}
+	for _, c := range cases {
+		c.args.Region = "global"
+		var resp structs.NodeListResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Node.List", &c.args, &resp); err != nil {
+			t.Fatalf("%s: err: %v", c.name, err)
+		}
+
+		var ids []string
+		for _, node := range resp.Nodes {
+			ids = append(ids, node.ID)
+		}
+		if !reflect.DeepEqual(ids, c.expected) {
+			t.Fatalf("%s: expected %v, got %v", c.name, c.expected, ids)
+		}
+	}
+}
+
 func TestClientEndpoint_ListNodes_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -1692,7 +1833,7 @@ func TestClientEndpoint_ListNodes_Blocking(t *testing.T) {
 
 	// Node drain updates trigger watches.
 	time.AfterFunc(100*time.Millisecond, func() {
-		if err := state.UpdateNodeDrain(3, node.ID, true); err != nil {
+		if err := state.UpdateNodeDrain(3, node.ID, true, nil); err != nil {
 			t.Fatalf("err: %v", err)
 		}
 	})
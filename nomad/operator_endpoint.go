@@ -0,0 +1,143 @@
+package nomad
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// Operator endpoint is used to perform low-level operator tasks for Nomad.
+type Operator struct {
+	srv *Server
+}
+
+// RaftGetConfiguration is used to retrieve the current Raft configuration.
+func (op *Operator) RaftGetConfiguration(args *structs.GenericRequest, reply *structs.RaftConfigurationResponse) error {
+	if done, err := op.srv.forward("Operator.RaftGetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	peers, err := op.srv.raftPeers.Peers()
+	if err != nil {
+		return err
+	}
+
+	// Fill out the reply, naming the peers known by the gossip pool
+	leader := op.srv.raft.Leader()
+	reply.Index = op.srv.raft.LastIndex()
+	reply.Servers = make([]*structs.RaftServer, 0, len(peers))
+
+	op.srv.peerLock.RLock()
+	defer op.srv.peerLock.RUnlock()
+	for _, peer := range peers {
+		node := ""
+		if parts, ok := op.srv.localPeers[peer]; ok {
+			node = parts.Name
+		}
+		reply.Servers = append(reply.Servers, &structs.RaftServer{
+			Node:    node,
+			Address: peer,
+			Leader:  peer == leader,
+			Voter:   true,
+		})
+	}
+	return nil
+}
+
+// RaftRemovePeerByAddress is used to kick a stale peer (one that is in the Raft
+// quorum but no longer known to Serf) by address in the form of "IP:port". The
+// reply argument is not used, but is required to fulfill the RPC interface.
+func (op *Operator) RaftRemovePeerByAddress(args *structs.RaftPeerByAddressRequest, reply *struct{}) error {
+	if done, err := op.srv.forward("Operator.RaftRemovePeerByAddress", args, args, reply); done {
+		return err
+	}
+
+	// Since this is an operation designed for humans to use, return an error
+	// if the supplied address isn't among the peers as it's likely a typo.
+	peers, err := op.srv.raftPeers.Peers()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, peer := range peers {
+		if peer == args.Address {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("address %q was not found in the Raft configuration", args.Address)
+	}
+
+	// Exclude the peer from the quorum. A server still alive in the gossip
+	// pool will be added back by the leader.
+	if err := op.srv.raft.RemovePeer(args.Address).Error(); err != nil {
+		op.srv.logger.Printf("[WARN] nomad.operator: failed to remove Raft peer %q: %v",
+			args.Address, err)
+		return err
+	}
+
+	op.srv.logger.Printf("[WARN] nomad.operator: removed Raft peer %q", args.Address)
+	return nil
+}
+
+// AutopilotGetConfiguration is used to retrieve the current Autopilot
+// configuration.
+func (op *Operator) AutopilotGetConfiguration(args *structs.GenericRequest, reply *structs.AutopilotConfig) error {
+	if done, err := op.srv.forward("Operator.AutopilotGetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	config, err := op.srv.fsm.State().AutopilotConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return fmt.Errorf("autopilot config not initialized yet")
+	}
+
+	*reply = *config
+	return nil
+}
+
+// AutopilotSetConfiguration is used to set the current Autopilot
+// configuration. The reply is whether the configuration was set, which is
+// only false when a check-and-set failed.
+func (op *Operator) AutopilotSetConfiguration(args *structs.AutopilotSetConfigRequest, reply *bool) error {
+	if done, err := op.srv.forward("Operator.AutopilotSetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	resp, _, err := op.srv.raftApply(structs.AutopilotRequestType, args)
+	if err != nil {
+		op.srv.logger.Printf("[ERR] nomad.operator: Apply failed: %v", err)
+		return err
+	}
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+
+	// Check if the return type is a bool
+	if respBool, ok := resp.(bool); ok {
+		*reply = respBool
+	} else {
+		*reply = true
+	}
+	return nil
+}
+
+// ServerHealth is used to get the health of the servers of the region, as
+// determined by Autopilot on the leader.
+func (op *Operator) ServerHealth(args *structs.GenericRequest, reply *structs.OperatorHealthReply) error {
+	// This must be sent to the leader, so we fix the args since we are
+	// re-using a structure where we don't support all the options.
+	args.AllowStale = false
+	if done, err := op.srv.forward("Operator.ServerHealth", args, args, reply); done {
+		return err
+	}
+
+	op.srv.clusterHealthLock.RLock()
+	defer op.srv.clusterHealthLock.RUnlock()
+	*reply = op.srv.clusterHealth
+	return nil
+}
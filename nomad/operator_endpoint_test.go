@@ -0,0 +1,205 @@
+package nomad
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestOperator_RaftGetConfiguration(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	arg := structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+	var reply structs.RaftConfigurationResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.RaftGetConfiguration", &arg, &reply); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if len(reply.Servers) != 1 {
+		t.Fatalf("bad: %#v", reply)
+	}
+	me := reply.Servers[0]
+	expected := &structs.RaftServer{
+		Node:    fmt.Sprintf("%s.%s", s1.config.NodeName, s1.config.Region),
+		Address: s1.config.RPCAddr.String(),
+		Leader:  true,
+		Voter:   true,
+	}
+	if *me != *expected {
+		t.Fatalf("bad: got %#v; want %#v", me, expected)
+	}
+	if reply.Index == 0 {
+		t.Fatalf("bad index: %#v", reply)
+	}
+}
+
+func TestOperator_RaftRemovePeerByAddress(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	s3 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s3.Shutdown()
+	testJoin(t, s1, s2, s3)
+	codec := rpcClient(t, s1)
+
+	// Wait for the cluster so that a missing peer doesn't break the quorum
+	testutil.WaitForResult(func() (bool, error) {
+		peers, _ := s1.raftPeers.Peers()
+		return len(peers) == 3, fmt.Errorf("bad peers: %v", peers)
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	testutil.WaitForLeader(t, s1.RPC)
+	var leader *Server
+	for _, s := range []*Server{s1, s2, s3} {
+		if s.IsLeader() {
+			leader = s
+		}
+	}
+	if leader == nil {
+		t.Fatalf("no leader")
+	}
+
+	// Try to remove a peer that's not there
+	arg := structs.RaftPeerByAddressRequest{
+		Address: "127.0.0.1:1337",
+		WriteRequest: structs.WriteRequest{
+			Region: s1.config.Region,
+		},
+	}
+	var reply struct{}
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftRemovePeerByAddress", &arg, &reply)
+	if err == nil || !strings.Contains(err.Error(), "not found in the Raft configuration") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Add it manually to Raft
+	if err := leader.raft.AddPeer(arg.Address).Error(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Make sure it's there
+	peers, err := leader.raftPeers.Peers()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(peers) != 4 {
+		t.Fatalf("bad: %v", peers)
+	}
+
+	// Remove it, now it should go through
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.RaftRemovePeerByAddress", &arg, &reply); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Make sure it's not there
+	peers, err = leader.raftPeers.Peers()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(peers) != 3 {
+		t.Fatalf("bad: %v", peers)
+	}
+}
+
+func TestOperator_AutopilotGetSetConfiguration(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.AutopilotConfig.CleanupDeadServers = false
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	arg := structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+	var reply structs.AutopilotConfig
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotGetConfiguration", &arg, &reply); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if reply.CleanupDeadServers {
+		t.Fatalf("bad: %#v", reply)
+	}
+
+	// Update the config with a stale index
+	update := structs.AutopilotSetConfigRequest{
+		Config:       reply,
+		CAS:          true,
+		WriteRequest: structs.WriteRequest{Region: s1.config.Region},
+	}
+	update.Config.CleanupDeadServers = true
+	update.Config.ModifyIndex--
+	var set bool
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotSetConfiguration", &update, &set); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if set {
+		t.Fatalf("expected the stale check-and-set to fail")
+	}
+
+	// Update the config with the current index
+	update.Config.ModifyIndex = reply.ModifyIndex
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotSetConfiguration", &update, &set); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !set {
+		t.Fatalf("expected the check-and-set to succeed")
+	}
+
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotGetConfiguration", &arg, &reply); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reply.CleanupDeadServers {
+		t.Fatalf("bad: %#v", reply)
+	}
+}
+
+func TestOperator_ServerHealth(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	arg := structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+	testutil.WaitForResult(func() (bool, error) {
+		var reply structs.OperatorHealthReply
+		if err := msgpackrpc.CallWithCodec(codec, "Operator.ServerHealth", &arg, &reply); err != nil {
+			return false, err
+		}
+		if !reply.Healthy || reply.FailureTolerance != 0 || len(reply.Servers) != 1 {
+			return false, fmt.Errorf("bad: %#v", reply)
+		}
+		server := reply.Servers[0]
+		if !server.Healthy || !server.Leader || !server.Voter || server.SerfStatus != "alive" {
+			return false, fmt.Errorf("bad: %#v", server)
+		}
+		if server.Address != s1.config.RPCAddr.String() || server.LastIndex == 0 {
+			return false, fmt.Errorf("bad: %#v", server)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
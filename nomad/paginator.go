@@ -0,0 +1,58 @@
+package nomad
+
+import (
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// paginator selects the page of a list query among the objects it lists, in
+// the increasing order of their IDs. The token of a page is the ID of its
+// first object.
+//
+// The id indexes of the state store are case insensitive, so IDs are compared
+// lowercased to follow the order in which the objects are iterated.
+type paginator struct {
+	perPage int32
+
+	// start is the lowercased token of the requested page
+	start string
+
+	// count is the number of objects accepted on the page
+	count int32
+
+	// next is the token of the next page, the ID of its first object. It is
+	// only set once the page is full and another object follows.
+	next string
+}
+
+// newPaginator returns the paginator of the page requested by the options
+func newPaginator(opts *structs.QueryOptions) *paginator {
+	return &paginator{
+		perPage: opts.PerPage,
+		start:   strings.ToLower(opts.NextToken),
+	}
+}
+
+// accept returns whether the object of the given ID is on the page. It must be
+// called in the increasing order of the IDs, for the objects passing the
+// filters of the query only.
+func (p *paginator) accept(id string) bool {
+	if strings.ToLower(id) < p.start {
+		return false
+	}
+	if p.perPage > 0 && p.count >= p.perPage {
+		if p.next == "" {
+			p.next = id
+		}
+		return false
+	}
+	p.count++
+	return true
+}
+
+// done returns whether the page is complete, so the remaining objects can be
+// skipped
+func (p *paginator) done() bool {
+	return p.next != ""
+}
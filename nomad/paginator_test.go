@@ -0,0 +1,70 @@
+package nomad
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestPaginator(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+
+	cases := []struct {
+		perPage   int32
+		nextToken string
+		page      []string
+		next      string
+	}{
+		{0, "", ids, ""},
+		{2, "", []string{"a", "b"}, "c"},
+		{2, "c", []string{"c", "d"}, "e"},
+		{2, "e", []string{"e"}, ""},
+		{5, "", ids, ""},
+		{0, "bb", []string{"c", "d", "e"}, ""},
+	}
+	for _, c := range cases {
+		p := newPaginator(&structs.QueryOptions{PerPage: c.perPage, NextToken: c.nextToken})
+
+		var page []string
+		for _, id := range ids {
+			if !p.accept(id) {
+				if p.done() {
+					break
+				}
+				continue
+			}
+			page = append(page, id)
+		}
+		if !reflect.DeepEqual(page, c.page) || p.next != c.next {
+			t.Fatalf("per page %d from %q: got %v next %q", c.perPage, c.nextToken, page, p.next)
+		}
+	}
+}
+
+func TestPaginator_MixedCase(t *testing.T) {
+	// IDs are iterated in the order of the case insensitive id indexes
+	ids := []string{"a", "B", "c"}
+
+	var seen []string
+	var token string
+	for pages := 0; pages < len(ids); pages++ {
+		p := newPaginator(&structs.QueryOptions{PerPage: 1, NextToken: token})
+		for _, id := range ids {
+			if !p.accept(id) {
+				if p.done() {
+					break
+				}
+				continue
+			}
+			seen = append(seen, id)
+		}
+		if p.next == "" {
+			break
+		}
+		token = p.next
+	}
+	if !reflect.DeepEqual(seen, ids) {
+		t.Fatalf("got %v; want %v", seen, ids)
+	}
+}
@@ -20,7 +20,7 @@ type PeriodicDispatch struct {
 	enabled    bool
 	running    bool
 
-	tracked map[string]*structs.Job
+	tracked map[structs.NamespacedID]*structs.Job
 	heap    *periodicHeap
 
 	updateCh chan struct{}
@@ -39,6 +39,9 @@ type JobEvalDispatcher interface {
 
 	// RunningChildren returns whether the passed job has any running children.
 	RunningChildren(job *structs.Job) (bool, error)
+
+	// SkipLaunches records the passed launch times of the job as skipped.
+	SkipLaunches(job *structs.Job, launches []time.Time) error
 }
 
 // DispatchJob creates an evaluation for the passed job and commits both the
@@ -58,6 +61,7 @@ func (s *Server) DispatchJob(job *structs.Job) (*structs.Evaluation, error) {
 		Type:           job.Type,
 		TriggeredBy:    structs.EvalTriggerPeriodicJob,
 		JobID:          job.ID,
+		Namespace:      job.Namespace,
 		JobModifyIndex: index,
 		Status:         structs.EvalStatusPending,
 	}
@@ -87,7 +91,7 @@ func (s *Server) RunningChildren(job *structs.Job) (bool, error) {
 	}
 
 	prefix := fmt.Sprintf("%s%s", job.ID, structs.PeriodicLaunchSuffix)
-	iter, err := state.JobsByIDPrefix(prefix)
+	iter, err := state.JobsByIDPrefix(job.Namespace, prefix)
 	if err != nil {
 		return false, err
 	}
@@ -102,7 +106,7 @@ func (s *Server) RunningChildren(job *structs.Job) (bool, error) {
 		}
 
 		// Get the childs evaluations.
-		evals, err := state.EvalsByJob(child.ID)
+		evals, err := state.EvalsByJob(child.Namespace, child.ID)
 		if err != nil {
 			return false, err
 		}
@@ -130,12 +134,24 @@ func (s *Server) RunningChildren(job *structs.Job) (bool, error) {
 	return false, nil
 }
 
+// SkipLaunches commits the skipped launches of the passed periodic job to the
+// raft log.
+func (s *Server) SkipLaunches(job *structs.Job, launches []time.Time) error {
+	req := structs.PeriodicLaunchSkipRequest{
+		JobID:        job.ID,
+		Launches:     launches,
+		WriteRequest: structs.WriteRequest{Namespace: job.Namespace},
+	}
+	_, _, err := s.raftApply(structs.PeriodicLaunchSkipRequestType, req)
+	return err
+}
+
 // NewPeriodicDispatch returns a periodic dispatcher that is used to track and
 // launch periodic jobs.
 func NewPeriodicDispatch(logger *log.Logger, dispatcher JobEvalDispatcher) *PeriodicDispatch {
 	return &PeriodicDispatch{
 		dispatcher: dispatcher,
-		tracked:    make(map[string]*structs.Job),
+		tracked:    make(map[structs.NamespacedID]*structs.Job),
 		heap:       NewPeriodicHeap(),
 		updateCh:   make(chan struct{}, 1),
 		stopCh:     make(chan struct{}),
@@ -195,10 +211,10 @@ func (p *PeriodicDispatch) Add(job *structs.Job) error {
 
 	// If we were tracking a job and it has been disabled or made non-periodic remove it.
 	disabled := !job.IsPeriodic() || !job.Periodic.Enabled
-	_, tracked := p.tracked[job.ID]
+	_, tracked := p.tracked[job.NamespacedID()]
 	if disabled {
 		if tracked {
-			p.removeLocked(job.ID)
+			p.removeLocked(job.NamespacedID())
 		}
 
 		// If the job is disabled and we aren't tracking it, do nothing.
@@ -206,7 +222,7 @@ func (p *PeriodicDispatch) Add(job *structs.Job) error {
 	}
 
 	// Add or update the job.
-	p.tracked[job.ID] = job
+	p.tracked[job.NamespacedID()] = job
 	next := job.Periodic.Next(time.Now().UTC())
 	if tracked {
 		if err := p.heap.Update(job, next); err != nil {
@@ -231,17 +247,17 @@ func (p *PeriodicDispatch) Add(job *structs.Job) error {
 	return nil
 }
 
-// Remove stops tracking the passed job. If the job is not tracked, it is a
-// no-op.
-func (p *PeriodicDispatch) Remove(jobID string) error {
+// Remove stops tracking the job with the given ID in the given namespace. If
+// the job is not tracked, it is a no-op.
+func (p *PeriodicDispatch) Remove(namespace, jobID string) error {
 	p.l.Lock()
 	defer p.l.Unlock()
-	return p.removeLocked(jobID)
+	return p.removeLocked(structs.NewNamespacedID(jobID, namespace))
 }
 
 // Remove stops tracking the passed job. If the job is not tracked, it is a
 // no-op. It assumes this is called while a lock is held.
-func (p *PeriodicDispatch) removeLocked(jobID string) error {
+func (p *PeriodicDispatch) removeLocked(jobID structs.NamespacedID) error {
 	// Do nothing if not enabled
 	if !p.enabled {
 		return nil
@@ -269,9 +285,16 @@ func (p *PeriodicDispatch) removeLocked(jobID string) error {
 	return nil
 }
 
-// ForceRun causes the periodic job to be evaluated immediately and returns the
-// subsequent eval.
-func (p *PeriodicDispatch) ForceRun(jobID string) (*structs.Evaluation, error) {
+// ForceRun causes the periodic job with the given ID in the given namespace to
+// be evaluated immediately and returns the subsequent eval.
+func (p *PeriodicDispatch) ForceRun(namespace, jobID string) (*structs.Evaluation, error) {
+	return p.ForceRunLaunch(namespace, jobID, time.Now().UTC())
+}
+
+// ForceRunLaunch causes the instance of the periodic job for the given launch
+// time to be evaluated immediately and returns the subsequent eval. It is used
+// to run launches that were skipped.
+func (p *PeriodicDispatch) ForceRunLaunch(namespace, jobID string, launch time.Time) (*structs.Evaluation, error) {
 	p.l.Lock()
 
 	// Do nothing if not enabled
@@ -280,14 +303,14 @@ func (p *PeriodicDispatch) ForceRun(jobID string) (*structs.Evaluation, error) {
 		return nil, fmt.Errorf("periodic dispatch disabled")
 	}
 
-	job, tracked := p.tracked[jobID]
+	job, tracked := p.tracked[structs.NewNamespacedID(jobID, namespace)]
 	if !tracked {
 		p.l.Unlock()
 		return nil, fmt.Errorf("can't force run non-tracked job %v", jobID)
 	}
 
 	p.l.Unlock()
-	return p.createEval(job, time.Now().UTC())
+	return p.createEval(job, launch)
 }
 
 // shouldRun returns whether the long lived run function should run.
@@ -350,6 +373,11 @@ func (p *PeriodicDispatch) dispatch(job *structs.Job, launchTime time.Time) {
 				" periodic job %q because job prohibits overlap", job.ID)
 			p.logger.Println(msg)
 			p.l.Unlock()
+
+			// Record the skip so it is visible and can be run later.
+			if err := p.dispatcher.SkipLaunches(job, []time.Time{launchTime}); err != nil {
+				p.logger.Printf("[ERR] nomad.periodic: failed to record skipped launch of periodic job %q: %v", job.ID, err)
+			}
 			return
 		}
 	}
@@ -406,7 +434,7 @@ func (p *PeriodicDispatch) deriveJob(periodicJob *structs.Job, time time.Time) (
 			p.logger.Printf("[ERR] nomad.periodic: deriving job from"+
 				" periodic job %v failed; deregistering from periodic runner: %v",
 				periodicJob.ID, r)
-			p.Remove(periodicJob.ID)
+			p.Remove(periodicJob.Namespace, periodicJob.ID)
 			derived = nil
 			err = fmt.Errorf("Failed to create a copy of the periodic job %v: %v", periodicJob.ID, r)
 		}
@@ -451,13 +479,13 @@ func (p *PeriodicDispatch) Flush() {
 	p.stopCh = make(chan struct{})
 	p.updateCh = make(chan struct{}, 1)
 	p.waitCh = make(chan struct{})
-	p.tracked = make(map[string]*structs.Job)
+	p.tracked = make(map[structs.NamespacedID]*structs.Job)
 	p.heap = NewPeriodicHeap()
 }
 
 // periodicHeap wraps a heap and gives operations other than Push/Pop.
 type periodicHeap struct {
-	index map[string]*periodicJob
+	index map[structs.NamespacedID]*periodicJob
 	heap  periodicHeapImp
 }
 
@@ -469,18 +497,18 @@ type periodicJob struct {
 
 func NewPeriodicHeap() *periodicHeap {
 	return &periodicHeap{
-		index: make(map[string]*periodicJob),
+		index: make(map[structs.NamespacedID]*periodicJob),
 		heap:  make(periodicHeapImp, 0),
 	}
 }
 
 func (p *periodicHeap) Push(job *structs.Job, next time.Time) error {
-	if _, ok := p.index[job.ID]; ok {
+	if _, ok := p.index[job.NamespacedID()]; ok {
 		return fmt.Errorf("job %v already exists", job.ID)
 	}
 
 	pJob := &periodicJob{job, next, 0}
-	p.index[job.ID] = pJob
+	p.index[job.NamespacedID()] = pJob
 	heap.Push(&p.heap, pJob)
 	return nil
 }
@@ -491,7 +519,7 @@ func (p *periodicHeap) Pop() *periodicJob {
 	}
 
 	pJob := heap.Pop(&p.heap).(*periodicJob)
-	delete(p.index, pJob.job.ID)
+	delete(p.index, pJob.job.NamespacedID())
 	return pJob
 }
 
@@ -504,12 +532,12 @@ func (p *periodicHeap) Peek() *periodicJob {
 }
 
 func (p *periodicHeap) Contains(job *structs.Job) bool {
-	_, ok := p.index[job.ID]
+	_, ok := p.index[job.NamespacedID()]
 	return ok
 }
 
 func (p *periodicHeap) Update(job *structs.Job, next time.Time) error {
-	if pJob, ok := p.index[job.ID]; ok {
+	if pJob, ok := p.index[job.NamespacedID()]; ok {
 		// Need to update the job as well because its spec can change.
 		pJob.job = job
 		pJob.next = next
@@ -521,9 +549,9 @@ func (p *periodicHeap) Update(job *structs.Job, next time.Time) error {
 }
 
 func (p *periodicHeap) Remove(job *structs.Job) error {
-	if pJob, ok := p.index[job.ID]; ok {
+	if pJob, ok := p.index[job.NamespacedID()]; ok {
 		heap.Remove(&p.heap, pJob.index)
-		delete(p.index, job.ID)
+		delete(p.index, job.NamespacedID())
 		return nil
 	}
 
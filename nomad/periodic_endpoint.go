@@ -6,6 +6,7 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
 )
 
 // Periodic endpoint is used for periodic job interactions
@@ -30,7 +31,7 @@ func (p *Periodic) Force(args *structs.PeriodicForceRequest, reply *structs.Peri
 	if err != nil {
 		return err
 	}
-	job, err := snap.JobByID(args.JobID)
+	job, err := snap.JobByID(args.RequestNamespace(), args.JobID)
 	if err != nil {
 		return err
 	}
@@ -42,8 +43,19 @@ func (p *Periodic) Force(args *structs.PeriodicForceRequest, reply *structs.Peri
 		return fmt.Errorf("can't force launch non-periodic job")
 	}
 
-	// Force run the job.
-	eval, err := p.srv.periodicDispatcher.ForceRun(job.ID)
+	// Force run the job, either now or at its earliest skipped launch.
+	launchTime := time.Now().UTC()
+	if args.Missed {
+		launch, err := snap.PeriodicLaunchByID(job.Namespace, job.ID)
+		if err != nil {
+			return err
+		}
+		if launch == nil || len(launch.Skipped) == 0 {
+			return fmt.Errorf("job %q has no skipped launches", job.ID)
+		}
+		launchTime = launch.Skipped[0]
+	}
+	eval, err := p.srv.periodicDispatcher.ForceRunLaunch(job.Namespace, job.ID, launchTime)
 	if err != nil {
 		return fmt.Errorf("force launch for job %q failed: %v", job.ID, err)
 	}
@@ -53,3 +65,49 @@ func (p *Periodic) Force(args *structs.PeriodicForceRequest, reply *structs.Peri
 	reply.Index = eval.CreateIndex
 	return nil
 }
+
+// Launch is used to retrieve the launch state of a periodic job, including
+// its skipped launches
+func (p *Periodic) Launch(args *structs.PeriodicLaunchRequest,
+	reply *structs.PeriodicLaunchResponse) error {
+	if done, err := p.srv.forward("Periodic.Launch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "periodic", "launch"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Job: args.JobID}),
+		run: func() error {
+
+			// Look for the periodic launch
+			snap, err := p.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.PeriodicLaunchByID(args.RequestNamespace(), args.JobID)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Launch = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the periodic launch table
+				index, err := snap.Index("periodic_launch")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			p.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return p.srv.blockingRPC(&opts)
+}
@@ -1,7 +1,9 @@
 package nomad
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/nomad/nomad/mock"
@@ -54,6 +56,155 @@ func TestPeriodicEndpoint_Force(t *testing.T) {
 	}
 }
 
+func TestPeriodicEndpoint_Force_Missed(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	state := s1.fsm.State()
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create and insert a periodic job.
+	job := mock.PeriodicJob()
+	if err := state.UpsertJob(100, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s1.periodicDispatcher.Add(job)
+
+	// Forcing a missed launch fails without skipped launches.
+	req := &structs.PeriodicForceRequest{
+		JobID:        job.ID,
+		Missed:       true,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.PeriodicForceResponse
+	err := msgpackrpc.CallWithCodec(codec, "Periodic.Force", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "no skipped launches") {
+		t.Fatalf("expected no skipped launches error, got: %v", err)
+	}
+
+	// Record two skipped launches.
+	now := time.Now().Round(time.Second)
+	skipped1 := now.Add(-2 * time.Hour)
+	skipped2 := now.Add(-1 * time.Hour)
+	launch := &structs.PeriodicLaunch{ID: job.ID, Launch: now}
+	launch.AddSkipped(skipped2, skipped1)
+	if err := state.UpsertPeriodicLaunch(200, launch); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Force launch the earliest skipped launch.
+	if err := msgpackrpc.CallWithCodec(codec, "Periodic.Force", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The evaluation is for the instance of the earliest skipped launch.
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil {
+		t.Fatalf("expected eval")
+	}
+	if expected := s1.periodicDispatcher.derivedJobID(job, skipped1); eval.JobID != expected {
+		t.Fatalf("bad job ID: got %q; want %q", eval.JobID, expected)
+	}
+
+	// The launch is no longer skipped and the last launch is unchanged.
+	out, err := state.PeriodicLaunchByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Skipped) != 1 || !out.Skipped[0].Equal(skipped2) {
+		t.Fatalf("bad skipped launches: %v", out.Skipped)
+	}
+	if !out.Launch.Equal(now) {
+		t.Fatalf("bad launch: got %v; want %v", out.Launch, now)
+	}
+}
+
+func TestPeriodicEndpoint_Launch(t *testing.T) {
+	s1 := testServer(t, nil)
+	state := s1.fsm.State()
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create and insert a periodic launch.
+	launch := &structs.PeriodicLaunch{ID: "foo", Launch: time.Now()}
+	launch.AddSkipped(time.Now().Add(-1 * time.Hour))
+	if err := state.UpsertPeriodicLaunch(100, launch); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the launch
+	req := &structs.PeriodicLaunchRequest{
+		JobID:        "foo",
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.PeriodicLaunchResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Periodic.Launch", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 100 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+	if resp.Launch == nil || len(resp.Launch.Skipped) != 1 {
+		t.Fatalf("bad launch: %#v", resp.Launch)
+	}
+
+	// Lookup a non-existing launch
+	req.JobID = "bar"
+	if err := msgpackrpc.CallWithCodec(codec, "Periodic.Launch", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Launch != nil {
+		t.Fatalf("unexpected launch: %#v", resp.Launch)
+	}
+}
+
+func TestPeriodicEndpoint_Launch_Namespace(t *testing.T) {
+	s1 := testServer(t, nil)
+	state := s1.fsm.State()
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a periodic job in another namespace and its launch
+	job := mock.PeriodicJob()
+	job.Namespace = "engineering"
+	if err := state.UpsertJob(100, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	launch := &structs.PeriodicLaunch{ID: job.ID, Namespace: job.Namespace, Launch: time.Now()}
+	if err := state.UpsertPeriodicLaunch(101, launch); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The launch is hidden from the default namespace
+	req := &structs.PeriodicLaunchRequest{
+		JobID:        job.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.PeriodicLaunchResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Periodic.Launch", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Launch != nil {
+		t.Fatalf("unexpected launch: %#v", resp.Launch)
+	}
+
+	// The launch is found from the namespace of its job
+	req.Namespace = "engineering"
+	if err := msgpackrpc.CallWithCodec(codec, "Periodic.Launch", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Launch == nil || resp.Launch.ID != job.ID {
+		t.Fatalf("bad launch: %#v", resp.Launch)
+	}
+}
+
 func TestPeriodicEndpoint_Force_NonPeriodic(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
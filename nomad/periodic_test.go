@@ -19,8 +19,9 @@ import (
 )
 
 type MockJobEvalDispatcher struct {
-	Jobs map[string]*structs.Job
-	lock sync.Mutex
+	Jobs    map[string]*structs.Job
+	Skipped []time.Time
+	lock    sync.Mutex
 }
 
 func NewMockJobEvalDispatcher() *MockJobEvalDispatcher {
@@ -45,6 +46,13 @@ func (m *MockJobEvalDispatcher) RunningChildren(parent *structs.Job) (bool, erro
 	return false, nil
 }
 
+func (m *MockJobEvalDispatcher) SkipLaunches(job *structs.Job, launches []time.Time) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Skipped = append(m.Skipped, launches...)
+	return nil
+}
+
 // LaunchTimes returns the launch times of child jobs in sorted order.
 func (m *MockJobEvalDispatcher) LaunchTimes(p *PeriodicDispatch, parentID string) ([]time.Time, error) {
 	m.lock.Lock()
@@ -202,7 +210,7 @@ func TestPeriodicDispatch_Add_TriggersUpdate(t *testing.T) {
 
 func TestPeriodicDispatch_Remove_Untracked(t *testing.T) {
 	p, _ := testPeriodicDispatcher()
-	if err := p.Remove("foo"); err != nil {
+	if err := p.Remove(structs.DefaultNamespace, "foo"); err != nil {
 		t.Fatalf("Remove failed %v; expected a no-op", err)
 	}
 }
@@ -220,7 +228,7 @@ func TestPeriodicDispatch_Remove_Tracked(t *testing.T) {
 		t.Fatalf("Add didn't track the job: %v", tracked)
 	}
 
-	if err := p.Remove(job.ID); err != nil {
+	if err := p.Remove(job.Namespace, job.ID); err != nil {
 		t.Fatalf("Remove failed %v", err)
 	}
 
@@ -242,7 +250,7 @@ func TestPeriodicDispatch_Remove_TriggersUpdate(t *testing.T) {
 	}
 
 	// Remove the job.
-	if err := p.Remove(job.ID); err != nil {
+	if err := p.Remove(job.Namespace, job.ID); err != nil {
 		t.Fatalf("Add failed %v", err)
 	}
 
@@ -258,7 +266,7 @@ func TestPeriodicDispatch_Remove_TriggersUpdate(t *testing.T) {
 func TestPeriodicDispatch_ForceRun_Untracked(t *testing.T) {
 	p, _ := testPeriodicDispatcher()
 
-	if _, err := p.ForceRun("foo"); err == nil {
+	if _, err := p.ForceRun(structs.DefaultNamespace, "foo"); err == nil {
 		t.Fatal("ForceRun of untracked job should fail")
 	}
 }
@@ -275,7 +283,7 @@ func TestPeriodicDispatch_ForceRun_Tracked(t *testing.T) {
 	}
 
 	// ForceRun the job
-	if _, err := p.ForceRun(job.ID); err != nil {
+	if _, err := p.ForceRun(job.Namespace, job.ID); err != nil {
 		t.Fatalf("ForceRun failed %v", err)
 	}
 
@@ -318,6 +326,39 @@ func TestPeriodicDispatch_Run_DisallowOverlaps(t *testing.T) {
 	if times[0] != launch1 {
 		t.Fatalf("periodic dispatcher created eval for time %v; want %v", times[0], launch1)
 	}
+
+	// Check that the second launch was recorded as skipped.
+	m.lock.Lock()
+	skipped := m.Skipped
+	m.lock.Unlock()
+	if len(skipped) != 1 || !skipped[0].Equal(launch2) {
+		t.Fatalf("incorrect skipped launches for job %q; got %v; want %v", job.ID, skipped, launch2)
+	}
+}
+
+func TestPeriodicDispatch_ForceRunLaunch(t *testing.T) {
+	p, m := testPeriodicDispatcher()
+
+	// Create a job that won't be evalauted for a while.
+	job := testPeriodicJob(time.Now().Add(10 * time.Second))
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	// ForceRun a launch in the past
+	launch := time.Now().Add(-time.Hour).Round(time.Second)
+	if _, err := p.ForceRunLaunch(job.Namespace, job.ID, launch); err != nil {
+		t.Fatalf("ForceRunLaunch failed %v", err)
+	}
+
+	// Check that job was launched with the given launch time.
+	launches, err := m.LaunchTimes(p, job.ID)
+	if err != nil {
+		t.Fatalf("failed to get launch times for job %q: %v", job.ID, err)
+	}
+	if len(launches) != 1 || !launches[0].Equal(launch) {
+		t.Fatalf("bad launches: got %v; want %v", launches, launch)
+	}
 }
 
 func TestPeriodicDispatch_Run_Multiple(t *testing.T) {
@@ -441,7 +482,7 @@ func TestPeriodicDispatch_Complex(t *testing.T) {
 	}
 
 	for _, job := range toDelete {
-		if err := p.Remove(job.ID); err != nil {
+		if err := p.Remove(job.Namespace, job.ID); err != nil {
 			t.Fatalf("Remove failed %v", err)
 		}
 	}
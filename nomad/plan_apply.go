@@ -127,8 +127,10 @@ func (s *Server) applyPlan(job *structs.Job, result *structs.PlanResult, snap *s
 
 	// Setup the update request
 	req := structs.AllocUpdateRequest{
-		Job:   job,
-		Alloc: make([]*structs.Allocation, 0, minUpdates),
+		Job:               job,
+		Alloc:             make([]*structs.Allocation, 0, minUpdates),
+		Deployment:        result.Deployment,
+		DeploymentUpdates: result.DeploymentUpdates,
 	}
 	for _, updateList := range result.NodeUpdate {
 		req.Alloc = append(req.Alloc, updateList...)
@@ -137,6 +139,40 @@ func (s *Server) applyPlan(job *structs.Job, result *structs.PlanResult, snap *s
 		req.Alloc = append(req.Alloc, allocList...)
 	}
 
+	// Create an evaluation for each job whose allocations were preempted so
+	// that they are rescheduled
+	preemptedJobs := make(map[structs.NamespacedID]struct{})
+	for _, updateList := range result.NodeUpdate {
+		for _, alloc := range updateList {
+			if alloc.PreemptedByAllocation == "" {
+				continue
+			}
+			jobID := structs.NewNamespacedID(alloc.JobID, alloc.Namespace)
+			if _, ok := preemptedJobs[jobID]; ok {
+				continue
+			}
+			preemptedJobs[jobID] = struct{}{}
+
+			preemptedJob, err := s.fsm.State().JobByID(alloc.Namespace, alloc.JobID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to lookup job %q: %v", alloc.JobID, err)
+			}
+			if preemptedJob == nil {
+				continue
+			}
+			req.PreemptionEvals = append(req.PreemptionEvals, &structs.Evaluation{
+				ID:             structs.GenerateUUID(),
+				Priority:       preemptedJob.Priority,
+				Type:           preemptedJob.Type,
+				TriggeredBy:    structs.EvalTriggerPreemption,
+				JobID:          preemptedJob.ID,
+				Namespace:      preemptedJob.Namespace,
+				JobModifyIndex: preemptedJob.JobModifyIndex,
+				Status:         structs.EvalStatusPending,
+			})
+		}
+	}
+
 	// Set the time the alloc was applied for the first time. This can be used
 	// to approximate the scheduling time.
 	now := time.Now().UTC().UnixNano()
@@ -155,7 +191,7 @@ func (s *Server) applyPlan(job *structs.Job, result *structs.PlanResult, snap *s
 	// Optimistically apply to our state view
 	if snap != nil {
 		nextIdx := s.raft.AppliedIndex() + 1
-		if err := snap.UpsertAllocs(nextIdx, req.Alloc); err != nil {
+		if err := snap.UpsertPlanResults(nextIdx, &req); err != nil {
 			return future, err
 		}
 	}
@@ -196,8 +232,32 @@ func evaluatePlan(pool *EvaluatePool, snap *state.StateSnapshot, plan *structs.P
 
 	// Create a result holder for the plan
 	result := &structs.PlanResult{
-		NodeUpdate:     make(map[string][]*structs.Allocation),
-		NodeAllocation: make(map[string][]*structs.Allocation),
+		NodeUpdate:        make(map[string][]*structs.Allocation),
+		NodeAllocation:    make(map[string][]*structs.Allocation),
+		Deployment:        plan.Deployment,
+		DeploymentUpdates: plan.DeploymentUpdates,
+	}
+
+	// Reject the plan if its placements exceed the quota of its namespace. The
+	// scheduler is forced to refresh its state, which accounts for the usage
+	// of the quota when placing allocations.
+	fit, err := evaluatePlanQuota(snap, plan)
+	if err != nil {
+		return nil, err
+	}
+	if !fit {
+		allocIndex, err := snap.Index("allocs")
+		if err != nil {
+			return nil, err
+		}
+		quotaIndex, err := snap.Index("quota_specs")
+		if err != nil {
+			return nil, err
+		}
+		result.Deployment = nil
+		result.DeploymentUpdates = nil
+		result.RefreshIndex = maxUint64(maxUint64(allocIndex, quotaIndex), 1)
+		return result, nil
 	}
 
 	// Collect all the nodeIDs
@@ -237,6 +297,8 @@ func evaluatePlan(pool *EvaluatePool, snap *state.StateSnapshot, plan *structs.P
 			if plan.AllAtOnce {
 				result.NodeUpdate = nil
 				result.NodeAllocation = nil
+				result.Deployment = nil
+				result.DeploymentUpdates = nil
 				return true
 			}
 
@@ -313,6 +375,49 @@ func evaluatePlan(pool *EvaluatePool, snap *state.StateSnapshot, plan *structs.P
 	return result, mErr.ErrorOrNil()
 }
 
+// evaluatePlanQuota checks that the plan doesn't increase the resources used
+// by the allocations of the jobs of the namespaces using the quota of the
+// plan's namespace beyond its limit.
+func evaluatePlanQuota(snap *state.StateSnapshot, plan *structs.Plan) (bool, error) {
+	if plan.Job == nil {
+		return true, nil
+	}
+	namespace, err := snap.NamespaceByName(plan.Job.Namespace)
+	if err != nil {
+		return false, fmt.Errorf("failed to get namespace %q: %v", plan.Job.Namespace, err)
+	}
+	if namespace == nil || namespace.Quota == "" {
+		return true, nil
+	}
+
+	quota, err := snap.QuotaSpecByName(namespace.Quota)
+	if err != nil {
+		return false, fmt.Errorf("failed to get quota %q: %v", namespace.Quota, err)
+	}
+	if quota == nil || quota.Limit == nil {
+		return true, nil
+	}
+
+	usage, err := snap.QuotaUsage(quota.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to get usage of quota %q: %v", quota.Name, err)
+	}
+	after, err := snap.QuotaUsageAfterPlan(quota.Name, plan)
+	if err != nil {
+		return false, fmt.Errorf("failed to get usage of quota %q: %v", quota.Name, err)
+	}
+
+	if fit, _ := quota.Limit.Superset(after); !fit {
+		// Plans that don't increase the usage, such as those stopping
+		// allocations after the quota was lowered, are allowed.
+		before := usage.Used
+		if after.CPU > before.CPU || after.MemoryMB > before.MemoryMB || after.DiskMB > before.DiskMB {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // evaluateNodePlan is used to evalute the plan for a single node,
 // returning if the plan is valid or if an error is encountered
 func evaluateNodePlan(snap *state.StateSnapshot, plan *structs.Plan, nodeID string) (bool, error) {
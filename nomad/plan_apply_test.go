@@ -179,6 +179,81 @@ func TestPlanApply_applyPlan(t *testing.T) {
 	}
 }
 
+func TestPlanApply_applyPlan_Preemption(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register node
+	node := mock.Node()
+	testRegisterNode(t, s1, node)
+
+	// Register a low priority allocation
+	low := mock.Alloc()
+	low.NodeID = node.ID
+	low.Job.Priority = 20
+	if err := s1.State().UpsertJob(1000, low.Job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := s1.State().UpsertAllocs(1001, []*structs.Allocation{low}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Preempt it to place a higher priority allocation
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	job := alloc.Job
+	alloc.Job = nil
+	alloc.PreemptedAllocations = []string{low.ID}
+	s1.State().UpsertJobSummary(1002, mock.JobSummary(alloc.JobID))
+	plan := &structs.Plan{
+		NodeUpdate:     make(map[string][]*structs.Allocation),
+		NodeAllocation: make(map[string][]*structs.Allocation),
+	}
+	plan.AppendPreemptedAlloc(low, alloc.ID)
+	plan.AppendAlloc(alloc)
+	result := &structs.PlanResult{
+		NodeUpdate:     plan.NodeUpdate,
+		NodeAllocation: plan.NodeAllocation,
+	}
+
+	// Snapshot the state
+	snap, err := s1.State().Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Apply the plan
+	future, err := s1.applyPlan(job, result, snap)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := planWaitFuture(future); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the preempted allocation is evicted
+	out, err := s1.fsm.State().AllocByID(low.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.DesiredStatus != structs.AllocDesiredStatusEvict || out.PreemptedByAllocation != alloc.ID {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Ensure the preempted job is evaluated
+	evals, err := s1.fsm.State().EvalsByJob(low.Namespace, low.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evals) != 1 || evals[0].TriggeredBy != structs.EvalTriggerPreemption ||
+		evals[0].Priority != low.Job.Priority {
+		t.Fatalf("bad: %#v", evals)
+	}
+}
+
 func TestPlanApply_EvalPlan_Simple(t *testing.T) {
 	state := testStateStore(t)
 	node := mock.Node()
@@ -207,6 +282,64 @@ func TestPlanApply_EvalPlan_Simple(t *testing.T) {
 	}
 }
 
+func TestPlanApply_EvalPlan_Quota(t *testing.T) {
+	state := testStateStore(t)
+	node := mock.Node()
+	state.UpsertNode(1000, node)
+	quota := &structs.QuotaSpec{
+		Name:  "engineering",
+		Limit: &structs.QuotaLimit{CPU: 600},
+	}
+	state.UpsertQuotaSpec(1001, quota)
+	state.UpsertNamespaces(1001, []*structs.Namespace{{Name: "engineering", Quota: "engineering"}})
+	job := mock.Job()
+	job.Namespace = "engineering"
+	state.UpsertJob(1002, job)
+	existing := mock.Alloc()
+	existing.Job = job
+	existing.JobID = job.ID
+	existing.NodeID = node.ID
+	state.UpsertAllocs(1003, []*structs.Allocation{existing})
+	snap, _ := state.Snapshot()
+
+	// Placing another allocation exceeds the quota
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	plan := &structs.Plan{
+		Job: job,
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID: []*structs.Allocation{alloc},
+		},
+	}
+
+	pool := NewEvaluatePool(workerPoolSize, workerPoolBufferSize)
+	defer pool.Shutdown()
+
+	result, err := evaluatePlan(pool, snap, plan)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(result.NodeAllocation) != 0 {
+		t.Fatalf("should not allow alloc")
+	}
+	if result.RefreshIndex != 1003 {
+		t.Fatalf("bad: %d", result.RefreshIndex)
+	}
+
+	// Replacing the existing allocation fits
+	plan.NodeUpdate = map[string][]*structs.Allocation{
+		node.ID: []*structs.Allocation{existing},
+	}
+	result, err = evaluatePlan(pool, snap, plan)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(result.NodeAllocation, plan.NodeAllocation) {
+		t.Fatalf("incorrect node allocations")
+	}
+}
+
 func TestPlanApply_EvalPlan_Partial(t *testing.T) {
 	state := testStateStore(t)
 	node := mock.Node()
@@ -0,0 +1,218 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// Quota endpoint is used for manipulating quota specifications
+type Quota struct {
+	srv *Server
+}
+
+// List is used to list the quota specifications in the system
+func (q *Quota) List(args *structs.QuotaSpecListRequest, reply *structs.QuotaSpecListResponse) error {
+	if done, err := q.srv.forward("Quota.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "list"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "quota_specs"}),
+		run: func() error {
+			// Capture all the quota specifications
+			snap, err := q.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = snap.QuotaSpecsByNamePrefix(prefix)
+			} else {
+				iter, err = snap.QuotaSpecs()
+			}
+			if err != nil {
+				return err
+			}
+
+			var quotas []*structs.QuotaSpec
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				quotas = append(quotas, raw.(*structs.QuotaSpec))
+			}
+			reply.Quotas = quotas
+
+			// Use the last index that affected the quota specs table
+			index, err := snap.Index("quota_specs")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			q.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return q.srv.blockingRPC(&opts)
+}
+
+// GetQuotaSpec is used to lookup a particular quota specification
+func (q *Quota) GetQuotaSpec(args *structs.QuotaSpecSpecificRequest,
+	reply *structs.SingleQuotaSpecResponse) error {
+	if done, err := q.srv.forward("Quota.GetQuotaSpec", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "get_quota_spec"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "quota_specs"}),
+		run: func() error {
+			// Lookup the quota specification
+			snap, err := q.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.QuotaSpecByName(args.Name)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Quota = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the quota specs table
+				index, err := snap.Index("quota_specs")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			q.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return q.srv.blockingRPC(&opts)
+}
+
+// GetQuotaUsage is used to lookup the resources used by the allocations of
+// the jobs using a quota
+func (q *Quota) GetQuotaUsage(args *structs.QuotaSpecSpecificRequest,
+	reply *structs.QuotaUsageResponse) error {
+	if done, err := q.srv.forward("Quota.GetQuotaUsage", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "get_quota_usage"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "quota_specs"}, watch.Item{Table: "allocs"}),
+		run: func() error {
+			// Compute the usage of the quota
+			snap, err := q.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			quota, err := snap.QuotaSpecByName(args.Name)
+			if err != nil {
+				return err
+			}
+			if quota != nil {
+				if reply.Usage, err = snap.QuotaUsage(args.Name); err != nil {
+					return err
+				}
+			} else {
+				reply.Usage = nil
+			}
+
+			// Use the last index that affected the quota specs or allocs table
+			quotaIndex, err := snap.Index("quota_specs")
+			if err != nil {
+				return err
+			}
+			allocIndex, err := snap.Index("allocs")
+			if err != nil {
+				return err
+			}
+			reply.Index = maxUint64(quotaIndex, allocIndex)
+
+			// Set the query response
+			q.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return q.srv.blockingRPC(&opts)
+}
+
+// Upsert is used to create or update a quota specification
+func (q *Quota) Upsert(args *structs.QuotaSpecUpsertRequest, reply *structs.GenericResponse) error {
+	if done, err := q.srv.forward("Quota.Upsert", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "upsert"}, time.Now())
+
+	// Validate the arguments
+	if args.Quota == nil {
+		return fmt.Errorf("missing quota specification")
+	}
+	if err := args.Quota.Validate(); err != nil {
+		return err
+	}
+
+	// Commit this update via Raft
+	resp, index, err := q.srv.raftApply(structs.QuotaSpecUpsertRequestType, args)
+	if err != nil {
+		q.srv.logger.Printf("[ERR] nomad.quota: Upsert failed: %v", err)
+		return err
+	}
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+
+	reply.Index = index
+	return nil
+}
+
+// Delete is used to delete a quota specification that is no longer used by
+// any job
+func (q *Quota) Delete(args *structs.QuotaSpecDeleteRequest, reply *structs.GenericResponse) error {
+	if done, err := q.srv.forward("Quota.Delete", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "delete"}, time.Now())
+
+	// Validate the arguments
+	if args.Name == "" {
+		return fmt.Errorf("missing quota name")
+	}
+
+	// Commit this update via Raft
+	resp, index, err := q.srv.raftApply(structs.QuotaSpecDeleteRequestType, args)
+	if err != nil {
+		q.srv.logger.Printf("[ERR] nomad.quota: Delete failed: %v", err)
+		return err
+	}
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+
+	reply.Index = index
+	return nil
+}
@@ -0,0 +1,168 @@
+package nomad
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestQuotaEndpoint_Upsert_Get_List(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Invalid specifications are rejected
+	req := &structs.QuotaSpecUpsertRequest{
+		Quota:        &structs.QuotaSpec{Name: "engineering"},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	err := msgpackrpc.CallWithCodec(codec, "Quota.Upsert", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "Missing quota limit") {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	// Create the quota
+	req.Quota.Limit = &structs.QuotaLimit{CPU: 1000, MemoryMB: 1024}
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.Upsert", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// Lookup the quota
+	get := &structs.QuotaSpecSpecificRequest{
+		Name:         "engineering",
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var single structs.SingleQuotaSpecResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.GetQuotaSpec", get, &single); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if single.Index != resp.Index {
+		t.Fatalf("Bad index: %d %d", single.Index, resp.Index)
+	}
+	if single.Quota == nil || single.Quota.Limit.MemoryMB != 1024 {
+		t.Fatalf("bad: %#v", single.Quota)
+	}
+
+	// List the quotas by prefix
+	list := &structs.QuotaSpecListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global", Prefix: "eng"},
+	}
+	var listResp structs.QuotaSpecListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.List", list, &listResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(listResp.Quotas) != 1 || listResp.Quotas[0].Name != "engineering" {
+		t.Fatalf("bad: %#v", listResp.Quotas)
+	}
+}
+
+func TestQuotaEndpoint_GetQuotaUsage(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the quota and an allocation of a job of a namespace using it
+	state := s1.fsm.State()
+	quota := &structs.QuotaSpec{
+		Name:  "engineering",
+		Limit: &structs.QuotaLimit{CPU: 1000},
+	}
+	if err := state.UpsertQuotaSpec(1000, quota); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ns := &structs.Namespace{Name: "engineering", Quota: "engineering"}
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	job := mock.Job()
+	job.Namespace = "engineering"
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.Namespace = job.Namespace
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	get := &structs.QuotaSpecSpecificRequest{
+		Name:         "engineering",
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.QuotaUsageResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.GetQuotaUsage", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1002 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1002)
+	}
+	if resp.Usage == nil || resp.Usage.Used.CPU != alloc.Resources.CPU {
+		t.Fatalf("bad: %#v", resp.Usage)
+	}
+
+	// No usage is returned for an unknown quota
+	get.Name = "unknown"
+	var resp2 structs.QuotaUsageResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.GetQuotaUsage", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp2.Usage != nil {
+		t.Fatalf("bad: %#v", resp2.Usage)
+	}
+}
+
+func TestQuotaEndpoint_Delete(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	state := s1.fsm.State()
+	quota := &structs.QuotaSpec{
+		Name:  "engineering",
+		Limit: &structs.QuotaLimit{CPU: 1000},
+	}
+	if err := state.UpsertQuotaSpec(1000, quota); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ns := &structs.Namespace{Name: "engineering", Quota: "engineering"}
+	if err := state.UpsertNamespaces(1001, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Deleting the quota while a namespace uses it fails
+	req := &structs.QuotaSpecDeleteRequest{
+		Name:         "engineering",
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.Delete", req, &resp); err == nil {
+		t.Fatalf("expected an error deleting a quota in use")
+	}
+
+	if err := state.DeleteNamespaces(1002, []string{ns.Name}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.Delete", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := state.QuotaSpecByName("engineering")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
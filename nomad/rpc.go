@@ -300,6 +300,7 @@ func (s *Server) raftApplyFuture(t structs.MessageType, msg interface{}) (raft.A
 // raftApply is used to encode a message, run it through raft, and return
 // the FSM response along with any errors
 func (s *Server) raftApply(t structs.MessageType, msg interface{}) (interface{}, uint64, error) {
+	defer metrics.MeasureSince([]string{"nomad", "raft", "apply_latency"}, time.Now())
 	future, err := s.raftApplyFuture(t, msg)
 	if err != nil {
 		return nil, 0, err
@@ -3,9 +3,12 @@ package nomad
 import (
 	"net"
 	"net/rpc"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/helper/tlsutil"
+	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/testutil"
 )
 
@@ -82,3 +85,52 @@ func TestRPC_forwardRegion(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 }
+
+func TestRPC_TLS(t *testing.T) {
+	const (
+		cafile  = "../helper/tlsutil/test-fixtures/ca.pem"
+		foocert = "../helper/tlsutil/test-fixtures/server.pem"
+		fookey  = "../helper/tlsutil/test-fixtures/server-key.pem"
+		barcert = "../helper/tlsutil/test-fixtures/client.pem"
+		barkey  = "../helper/tlsutil/test-fixtures/client-key.pem"
+	)
+	s1 := testServer(t, func(c *Config) {
+		c.TLSConfig = &config.TLSConfig{
+			EnableRPC:            true,
+			VerifyServerHostname: true,
+			CAFile:               cafile,
+			CertFile:             foocert,
+			KeyFile:              fookey,
+		}
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// A client presenting a certificate signed by the CA can make RPCs
+	tlsConf := tlsutil.NewTLSConfiguration(&config.TLSConfig{
+		EnableRPC:            true,
+		VerifyServerHostname: true,
+		CAFile:               cafile,
+		CertFile:             barcert,
+		KeyFile:              barkey,
+	})
+	tlsWrap, err := tlsConf.OutgoingTLSWrapper()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	tlsPool := NewPool(os.Stderr, time.Minute, 2, tlsWrap)
+	defer tlsPool.Shutdown()
+
+	var out struct{}
+	addr := s1.config.RPCAddr
+	if err := tlsPool.RPC(s1.config.Region, addr, 1, "Status.Ping", struct{}{}, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Plain connections are rejected
+	plainPool := NewPool(os.Stderr, time.Minute, 2, nil)
+	defer plainPool.Shutdown()
+	if err := plainPool.RPC(s1.config.Region, addr, 1, "Status.Ping", struct{}{}, &out); err == nil {
+		t.Fatalf("expected err")
+	}
+}
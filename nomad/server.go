@@ -16,11 +16,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/armon/go-metrics"
 	consulapi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
-	"github.com/hashicorp/consul/tlsutil"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/helper/tlsutil"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/raft"
@@ -113,6 +114,15 @@ type Server struct {
 	// join/leave from the region.
 	reconcileCh chan serf.Member
 
+	// clusterHealth is the health of the servers of the region as last
+	// determined by Autopilot on the leader.
+	clusterHealth     structs.OperatorHealthReply
+	clusterHealthLock sync.RWMutex
+
+	// autopilotRemoveDeadCh is used to trigger the removal of the dead servers
+	// once a new server is added to the Raft peers.
+	autopilotRemoveDeadCh chan struct{}
+
 	// eventCh is used to receive events from the serf cluster
 	eventCh chan serf.Event
 
@@ -153,15 +163,20 @@ type Server struct {
 
 // Holds the RPC endpoints
 type endpoints struct {
-	Status   *Status
-	Node     *Node
-	Job      *Job
-	Eval     *Eval
-	Plan     *Plan
-	Alloc    *Alloc
-	Region   *Region
-	Periodic *Periodic
-	System   *System
+	Status     *Status
+	Node       *Node
+	Job        *Job
+	Eval       *Eval
+	Plan       *Plan
+	Alloc      *Alloc
+	Region     *Region
+	Periodic   *Periodic
+	System     *System
+	Deployment *Deployment
+	Quota      *Quota
+	Namespace  *Namespace
+	Operator   *Operator
+	Event      *Event
 }
 
 // NewServer is used to construct a new Nomad server from the
@@ -187,11 +202,28 @@ func NewServer(config *Config, consulSyncer *consul.Syncer, logger *log.Logger)
 		return nil, err
 	}
 
+	// Configure TLS
+	var tlsWrap tlsutil.RegionWrapper
+	var incomingTLS *tls.Config
+	if config.TLSConfig.EnableRPC {
+		tlsConf := tlsutil.NewTLSConfiguration(config.TLSConfig)
+		if tlsWrap, err = tlsConf.OutgoingTLSWrapper(); err != nil {
+			return nil, err
+		}
+		if incomingTLS, err = tlsConf.IncomingTLSConfig(); err != nil {
+			return nil, err
+		}
+
+		// Plain connections are rejected once RPC is protected with TLS
+		config.RequireTLS = true
+	}
+
 	// Create the server
 	s := &Server{
 		config:       config,
 		consulSyncer: consulSyncer,
-		connPool:     NewPool(config.LogOutput, serverRPCCache, serverMaxStreams, nil),
+		connPool:     NewPool(config.LogOutput, serverRPCCache, serverMaxStreams, tlsWrap),
+		rpcTLS:       incomingTLS,
 		logger:       logger,
 		rpcServer:    rpc.NewServer(),
 		peers:        make(map[string][]*serverParts),
@@ -202,6 +234,8 @@ func NewServer(config *Config, consulSyncer *consul.Syncer, logger *log.Logger)
 		blockedEvals: blockedEvals,
 		planQueue:    planQueue,
 		shutdownCh:   make(chan struct{}),
+
+		autopilotRemoveDeadCh: make(chan struct{}, 1),
 	}
 
 	// Create the periodic dispatcher for launching periodic jobs.
@@ -215,8 +249,7 @@ func NewServer(config *Config, consulSyncer *consul.Syncer, logger *log.Logger)
 	}
 
 	// Initialize the RPC layer
-	// TODO: TLS...
-	if err := s.setupRPC(nil); err != nil {
+	if err := s.setupRPC(tlsWrap); err != nil {
 		s.Shutdown()
 		s.logger.Printf("[ERR] nomad: failed to start RPC layer: %s", err)
 		return nil, fmt.Errorf("Failed to start RPC layer: %v", err)
@@ -270,6 +303,9 @@ func NewServer(config *Config, consulSyncer *consul.Syncer, logger *log.Logger)
 	// Emit metrics
 	go s.heartbeatStats()
 
+	// Emit metrics for the Raft log
+	go s.emitRaftStats(time.Second, s.shutdownCh)
+
 	// Done
 	return s, nil
 }
@@ -576,7 +612,7 @@ func (s *Server) setupVaultClient() error {
 }
 
 // setupRPC is used to setup the RPC listener
-func (s *Server) setupRPC(tlsWrap tlsutil.DCWrapper) error {
+func (s *Server) setupRPC(tlsWrap tlsutil.RegionWrapper) error {
 	// Create endpoints
 	s.endpoints.Status = &Status{s}
 	s.endpoints.Node = &Node{srv: s}
@@ -587,6 +623,11 @@ func (s *Server) setupRPC(tlsWrap tlsutil.DCWrapper) error {
 	s.endpoints.Region = &Region{s}
 	s.endpoints.Periodic = &Periodic{s}
 	s.endpoints.System = &System{s}
+	s.endpoints.Deployment = &Deployment{s}
+	s.endpoints.Quota = &Quota{s}
+	s.endpoints.Namespace = &Namespace{s}
+	s.endpoints.Operator = &Operator{s}
+	s.endpoints.Event = &Event{s}
 
 	// Register the handlers
 	s.rpcServer.Register(s.endpoints.Status)
@@ -598,6 +639,11 @@ func (s *Server) setupRPC(tlsWrap tlsutil.DCWrapper) error {
 	s.rpcServer.Register(s.endpoints.Region)
 	s.rpcServer.Register(s.endpoints.Periodic)
 	s.rpcServer.Register(s.endpoints.System)
+	s.rpcServer.Register(s.endpoints.Deployment)
+	s.rpcServer.Register(s.endpoints.Quota)
+	s.rpcServer.Register(s.endpoints.Namespace)
+	s.rpcServer.Register(s.endpoints.Operator)
+	s.rpcServer.Register(s.endpoints.Event)
 
 	list, err := net.ListenTCP("tcp", s.config.RPCAddr)
 	if err != nil {
@@ -622,11 +668,10 @@ func (s *Server) setupRPC(tlsWrap tlsutil.DCWrapper) error {
 		return fmt.Errorf("RPC advertise address is not advertisable: %v", addr)
 	}
 
-	// Provide a DC specific wrapper. Raft replication is only
-	// ever done in the same datacenter, so we can provide it as a constant.
-	// wrapper := tlsutil.SpecificDC(s.config.Datacenter, tlsWrap)
-	// TODO: TLS...
-	s.raftLayer = NewRaftLayer(s.rpcAdvertise, nil)
+	// Provide a region specific wrapper. Raft replication is only
+	// ever done in the same region, so we can provide it as a constant.
+	wrapper := tlsutil.SpecificRegion(s.config.Region, tlsWrap)
+	s.raftLayer = NewRaftLayer(s.rpcAdvertise, wrapper)
 	return nil
 }
 
@@ -935,3 +980,42 @@ func (s *Server) Stats() map[string]map[string]string {
 	}
 	return stats
 }
+
+// emitRaftStats is used to export metrics about the Raft log of the server: its
+// commit and applied indexes, the number of entries committed during the
+// period and how far the FSM lags behind the commits.
+func (s *Server) emitRaftStats(period time.Duration, stopCh chan struct{}) {
+	var lastCommit uint64
+	for {
+		select {
+		case <-time.After(period):
+			leader := float32(0)
+			if s.IsLeader() {
+				leader = 1
+			}
+			metrics.SetGauge([]string{"nomad", "raft", "is_leader"}, leader)
+
+			stats := s.raft.Stats()
+			commit, err := strconv.ParseUint(stats["commit_index"], 10, 64)
+			if err != nil {
+				continue
+			}
+			applied, err := strconv.ParseUint(stats["applied_index"], 10, 64)
+			if err != nil {
+				continue
+			}
+			metrics.SetGauge([]string{"nomad", "raft", "commit_index"}, float32(commit))
+			metrics.SetGauge([]string{"nomad", "raft", "applied_index"}, float32(applied))
+			if commit >= applied {
+				metrics.SetGauge([]string{"nomad", "raft", "apply_lag"}, float32(commit-applied))
+			}
+			if lastCommit != 0 && commit >= lastCommit {
+				metrics.SetGauge([]string{"nomad", "raft", "commit_index_delta"}, float32(commit-lastCommit))
+			}
+			lastCommit = commit
+
+		case <-stopCh:
+			return
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/nomad/command/agent/consul"
 	"github.com/hashicorp/nomad/testutil"
 )
@@ -57,6 +58,11 @@ func testServer(t *testing.T, cb func(*Config)) *Server {
 	config.RaftConfig.ElectionTimeout = 50 * time.Millisecond
 	config.RaftTimeout = 500 * time.Millisecond
 
+	// Tighten the Autopilot timing
+	config.AutopilotConfig.ServerStabilizationTime = 100 * time.Millisecond
+	config.AutopilotInterval = 100 * time.Millisecond
+	config.ServerHealthInterval = 50 * time.Millisecond
+
 	// Disable Vault
 	config.VaultConfig.Enabled = false
 
@@ -135,3 +141,40 @@ func TestServer_Regions(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	})
 }
+
+func TestServer_EmitRaftStats(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Capture the metrics in memory
+	sink := metrics.NewInmemSink(10*time.Second, time.Minute)
+	conf := metrics.DefaultConfig("")
+	conf.EnableHostname = false
+	conf.EnableRuntimeMetrics = false
+	metrics.NewGlobal(conf, sink)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go s1.emitRaftStats(10*time.Millisecond, stopCh)
+
+	testutil.WaitForResult(func() (bool, error) {
+		data := sink.Data()
+		gauges := data[len(data)-1].Gauges
+		if gauges["nomad.raft.is_leader"] != 1 {
+			return false, fmt.Errorf("bad leader gauge: %v", gauges)
+		}
+		if gauges["nomad.raft.commit_index"] == 0 {
+			return false, fmt.Errorf("bad commit index: %v", gauges)
+		}
+		if _, ok := gauges["nomad.raft.apply_lag"]; !ok {
+			return false, fmt.Errorf("missing apply lag: %v", gauges)
+		}
+		if _, ok := gauges["nomad.raft.commit_index_delta"]; !ok {
+			return false, fmt.Errorf("missing commit delta: %v", gauges)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"sync"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+const (
+	// eventBufferSize is the number of events retained by the state store.
+	// Subscribers falling behind by more than this many events miss the
+	// oldest ones.
+	eventBufferSize = 4096
+)
+
+// eventBuffer retains the most recent events in memory. The events aren't
+// part of the snapshots and are lost on restore.
+type eventBuffer struct {
+	events []*structs.Event
+	size   int
+	l      sync.RWMutex
+}
+
+// newEventBuffer creates an event buffer retaining up to size events.
+func newEventBuffer(size int) *eventBuffer {
+	return &eventBuffer{size: size}
+}
+
+// PublishEvents records the events caused by the write at the given index and
+// notifies the watchers of the events.
+func (s *StateStore) PublishEvents(index uint64, events []*structs.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.events.l.Lock()
+	for _, event := range events {
+		event.Index = index
+	}
+	s.events.events = append(s.events.events, events...)
+	if extra := len(s.events.events) - s.events.size; extra > 0 {
+		s.events.events = s.events.events[extra:]
+	}
+	s.events.l.Unlock()
+
+	s.watch.notify(watch.NewItems(watch.Item{Table: "events"}))
+}
+
+// Events returns the retained events with an index greater than minIndex and
+// the index of the last event. The index is zero if no event was published.
+func (s *StateStore) Events(minIndex uint64) ([]*structs.Event, uint64) {
+	s.events.l.RLock()
+	defer s.events.l.RUnlock()
+
+	n := len(s.events.events)
+	if n == 0 {
+		return nil, 0
+	}
+
+	// The events are ordered by index, find the first one following minIndex
+	first := n
+	for first > 0 && s.events.events[first-1].Index > minIndex {
+		first--
+	}
+
+	var events []*structs.Event
+	if first < n {
+		events = make([]*structs.Event, n-first)
+		copy(events, s.events.events[first:])
+	}
+	return events, s.events.events[n-1].Index
+}
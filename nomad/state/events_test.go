@@ -0,0 +1,80 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+func TestStateStore_Events(t *testing.T) {
+	state := testStateStore(t)
+
+	// Nothing is returned before any event is published
+	events, index := state.Events(0)
+	if len(events) != 0 || index != 0 {
+		t.Fatalf("bad: %#v %d", events, index)
+	}
+
+	notify := setupNotifyTest(state, watch.Item{Table: "events"})
+	state.PublishEvents(1000, []*structs.Event{
+		{Topic: structs.EventTopicNode, Type: structs.EventTypeNodeRegistered, Key: "a"},
+		{Topic: structs.EventTopicNode, Type: structs.EventTypeNodeRegistered, Key: "b"},
+	})
+	notify.verify(t)
+
+	state.PublishEvents(1001, []*structs.Event{
+		{Topic: structs.EventTopicNode, Type: structs.EventTypeNodeDeregistered, Key: "a"},
+	})
+
+	// All the events follow index zero
+	events, index = state.Events(0)
+	if len(events) != 3 || index != 1001 {
+		t.Fatalf("bad: %#v %d", events, index)
+	}
+	if events[0].Index != 1000 || events[1].Index != 1000 || events[2].Index != 1001 {
+		t.Fatalf("bad indexes: %#v", events)
+	}
+
+	// Only the last event follows its predecessor
+	events, index = state.Events(1000)
+	if len(events) != 1 || events[0].Key != "a" || events[0].Type != structs.EventTypeNodeDeregistered {
+		t.Fatalf("bad: %#v", events)
+	}
+	if index != 1001 {
+		t.Fatalf("bad index: %d", index)
+	}
+
+	// Nothing follows the last event
+	events, index = state.Events(1001)
+	if len(events) != 0 || index != 1001 {
+		t.Fatalf("bad: %#v %d", events, index)
+	}
+
+	// The events are shared with the snapshots
+	snap, err := state.Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if events, _ := snap.Events(0); len(events) != 3 {
+		t.Fatalf("bad: %#v", events)
+	}
+}
+
+func TestStateStore_Events_Limit(t *testing.T) {
+	state := testStateStore(t)
+	state.events = newEventBuffer(2)
+
+	for i := uint64(1); i <= 3; i++ {
+		state.PublishEvents(i, []*structs.Event{{Topic: structs.EventTopicNode}})
+	}
+
+	// Only the most recent events are retained
+	events, index := state.Events(0)
+	if len(events) != 2 || events[0].Index != 2 || events[1].Index != 3 {
+		t.Fatalf("bad: %#v", events)
+	}
+	if index != 3 {
+		t.Fatalf("bad index: %d", index)
+	}
+}
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"github.com/hashicorp/go-memdb"
@@ -20,10 +21,16 @@ func stateStoreSchema() *memdb.DBSchema {
 		nodeTableSchema,
 		jobTableSchema,
 		jobSummarySchema,
+		jobVersionSchema,
 		periodicLaunchTableSchema,
+		deploymentTableSchema,
 		evalTableSchema,
 		allocTableSchema,
 		vaultAccessorTableSchema,
+		schedulerConfigTableSchema,
+		quotaSpecTableSchema,
+		namespaceTableSchema,
+		autopilotConfigTableSchema,
 	}
 
 	// Add each of the tables
@@ -84,14 +91,24 @@ func jobTableSchema() *memdb.TableSchema {
 		Indexes: map[string]*memdb.IndexSchema{
 			// Primary index is used for job management
 			// and simple direct lookup. ID is required to be
-			// unique.
+			// unique within a namespace.
 			"id": &memdb.IndexSchema{
 				Name:         "id",
 				AllowMissing: false,
 				Unique:       true,
-				Indexer: &memdb.StringFieldIndex{
-					Field:     "ID",
-					Lowercase: true,
+
+				// Use a compound index so the tuple of (Namespace, ID) is
+				// uniquely identifying
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+						&memdb.StringFieldIndex{
+							Field:     "ID",
+							Lowercase: true,
+						},
+					},
 				},
 			},
 			"type": &memdb.IndexSchema{
@@ -119,6 +136,14 @@ func jobTableSchema() *memdb.TableSchema {
 					Conditional: jobIsPeriodic,
 				},
 			},
+			"namespace": &memdb.IndexSchema{
+				Name:         "namespace",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Namespace",
+				},
+			},
 		},
 	}
 }
@@ -132,15 +157,83 @@ func jobSummarySchema() *memdb.TableSchema {
 				Name:         "id",
 				AllowMissing: false,
 				Unique:       true,
-				Indexer: &memdb.StringFieldIndex{
-					Field:     "JobID",
-					Lowercase: true,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+						&memdb.StringFieldIndex{
+							Field:     "JobID",
+							Lowercase: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// jobVersionSchema returns the memdb schema for the job version table which
+// keeps a historical view of job versions.
+func jobVersionSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "job_version",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+
+				// Use a compound index so the tuple of (Namespace, JobID,
+				// Version) is uniquely identifying
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+						&memdb.StringFieldIndex{
+							Field:     "ID",
+							Lowercase: true,
+						},
+						&jobVersionIndex{},
+					},
 				},
 			},
 		},
 	}
 }
 
+// jobVersionIndex is used to index a job by its version. The version is
+// encoded in big endian byte order so that versions of a job are iterated in
+// ascending order.
+type jobVersionIndex struct{}
+
+func (j *jobVersionIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	job, ok := obj.(*structs.Job)
+	if !ok {
+		return false, nil, fmt.Errorf("Unexpected type: %v", obj)
+	}
+	return true, encodeVersion(job.Version), nil
+}
+
+func (j *jobVersionIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+	version, ok := args[0].(uint64)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a uint64: %#v", args[0])
+	}
+	return encodeVersion(version), nil
+}
+
+// encodeVersion returns the big endian encoding of a job version
+func encodeVersion(version uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, version)
+	return buf
+}
+
 // jobIsGCable satisfies the ConditionalIndexFunc interface and creates an index
 // on whether a job is eligible for garbage collection.
 func jobIsGCable(obj interface{}) (bool, error) {
@@ -178,14 +271,59 @@ func periodicLaunchTableSchema() *memdb.TableSchema {
 		Indexes: map[string]*memdb.IndexSchema{
 			// Primary index is used for job management
 			// and simple direct lookup. ID is required to be
-			// unique.
+			// unique within a namespace.
 			"id": &memdb.IndexSchema{
 				Name:         "id",
 				AllowMissing: false,
 				Unique:       true,
-				Indexer: &memdb.StringFieldIndex{
-					Field:     "ID",
-					Lowercase: true,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+						&memdb.StringFieldIndex{
+							Field:     "ID",
+							Lowercase: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// deploymentTableSchema returns the MemDB schema for the deployment table.
+// This table is used to store the deployments rolling out health gated
+// updates of jobs.
+func deploymentTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "deployment",
+		Indexes: map[string]*memdb.IndexSchema{
+			// Primary index is used for direct lookup.
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "ID",
+				},
+			},
+
+			// Job index is used to lookup deployments by job
+			"job": &memdb.IndexSchema{
+				Name:         "job",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+						&memdb.StringFieldIndex{
+							Field:     "JobID",
+							Lowercase: true,
+						},
+					},
 				},
 			},
 		},
@@ -214,9 +352,16 @@ func evalTableSchema() *memdb.TableSchema {
 				Name:         "job",
 				AllowMissing: false,
 				Unique:       false,
-				Indexer: &memdb.StringFieldIndex{
-					Field:     "JobID",
-					Lowercase: true,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+						&memdb.StringFieldIndex{
+							Field:     "JobID",
+							Lowercase: true,
+						},
+					},
 				},
 			},
 		},
@@ -274,9 +419,16 @@ func allocTableSchema() *memdb.TableSchema {
 				Name:         "job",
 				AllowMissing: false,
 				Unique:       false,
-				Indexer: &memdb.StringFieldIndex{
-					Field:     "JobID",
-					Lowercase: true,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+						&memdb.StringFieldIndex{
+							Field:     "JobID",
+							Lowercase: true,
+						},
+					},
 				},
 			},
 
@@ -289,6 +441,16 @@ func allocTableSchema() *memdb.TableSchema {
 					Field: "EvalID",
 				},
 			},
+
+			// Deployment index is used to lookup allocations by deployment
+			"deployment": &memdb.IndexSchema{
+				Name:         "deployment",
+				AllowMissing: true, // Missing is allowed for allocations without a deployment
+				Unique:       false,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "DeploymentID",
+				},
+			},
 		},
 	}
 }
@@ -330,3 +492,85 @@ func vaultAccessorTableSchema() *memdb.TableSchema {
 		},
 	}
 }
+
+// schedulerConfigTableSchema returns the MemDB schema for the scheduler
+// configuration table. The table holds the single cluster wide scheduler
+// configuration.
+func schedulerConfigTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "scheduler_config",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: true,
+				Unique:       true,
+				Indexer: &memdb.ConditionalIndex{
+					Conditional: func(obj interface{}) (bool, error) { return true, nil },
+				},
+			},
+		},
+	}
+}
+
+// autopilotConfigTableSchema returns the MemDB schema for the Autopilot
+// configuration table. The table holds the single cluster wide Autopilot
+// configuration.
+func autopilotConfigTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "autopilot_config",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: true,
+				Unique:       true,
+				Indexer: &memdb.ConditionalIndex{
+					Conditional: func(obj interface{}) (bool, error) { return true, nil },
+				},
+			},
+		},
+	}
+}
+
+// quotaSpecTableSchema returns the MemDB schema for the quota specification
+// table. Quota specifications are looked up by their name.
+func quotaSpecTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "quota_specs",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Name",
+				},
+			},
+		},
+	}
+}
+
+// namespaceTableSchema returns the MemDB schema for the namespace table.
+// Namespaces are looked up by their name.
+func namespaceTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "namespaces",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Name",
+				},
+			},
+			"quota": &memdb.IndexSchema{
+				Name:         "quota",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Quota",
+				},
+			},
+		},
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strings"
 	"sync"
 
 	"github.com/hashicorp/go-memdb"
@@ -29,6 +30,7 @@ type StateStore struct {
 	logger *log.Logger
 	db     *memdb.MemDB
 	watch  *stateWatch
+	events *eventBuffer
 }
 
 // NewStateStore is used to create a new state store
@@ -44,6 +46,7 @@ func NewStateStore(logOutput io.Writer) (*StateStore, error) {
 		logger: log.New(logOutput, "", log.LstdFlags),
 		db:     db,
 		watch:  newStateWatch(),
+		events: newEventBuffer(eventBufferSize),
 	}
 	return s, nil
 }
@@ -57,6 +60,7 @@ func (s *StateStore) Snapshot() (*StateSnapshot, error) {
 			logger: s.logger,
 			db:     s.db.Snapshot(),
 			watch:  s.watch,
+			events: s.events,
 		},
 	}
 	return snap, nil
@@ -104,14 +108,14 @@ func (s *StateStore) UpsertJobSummary(index uint64, jobSummary *structs.JobSumma
 	return nil
 }
 
-// DeleteJobSummary deletes the job summary with the given ID. This is for
-// testing purposes only.
-func (s *StateStore) DeleteJobSummary(index uint64, id string) error {
+// DeleteJobSummary deletes the job summary with the given ID in the given
+// namespace. This is for testing purposes only.
+func (s *StateStore) DeleteJobSummary(index uint64, namespace, id string) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
 	// Delete the job summary
-	if _, err := txn.DeleteAll("job_summary", "id", id); err != nil {
+	if _, err := txn.DeleteAll("job_summary", "id", namespace, id); err != nil {
 		return fmt.Errorf("deleting job summary failed: %v", err)
 	}
 	if err := txn.Insert("index", &IndexEntry{"job_summary", index}); err != nil {
@@ -144,6 +148,7 @@ func (s *StateStore) UpsertNode(index uint64, node *structs.Node) error {
 		node.CreateIndex = exist.CreateIndex
 		node.ModifyIndex = index
 		node.Drain = exist.Drain // Retain the drain mode
+		node.DrainStrategy = exist.DrainStrategy
 	} else {
 		node.CreateIndex = index
 		node.ModifyIndex = index
@@ -233,8 +238,9 @@ func (s *StateStore) UpdateNodeStatus(index uint64, nodeID, status string) error
 	return nil
 }
 
-// UpdateNodeDrain is used to update the drain of a node
-func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool) error {
+// UpdateNodeDrain is used to update the drain of a node. The drain strategy
+// is only retained while the node is draining.
+func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool, strategy *structs.DrainStrategy) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
@@ -258,6 +264,10 @@ func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool) er
 
 	// Update the drain in the copy
 	copyNode.Drain = drain
+	copyNode.DrainStrategy = nil
+	if drain {
+		copyNode.DrainStrategy = strategy
+	}
 	copyNode.ModifyIndex = index
 
 	// Insert the node
@@ -321,17 +331,26 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 	watcher.Add(watch.Item{Table: "jobs"})
 	watcher.Add(watch.Item{Job: job.ID})
 
+	// Jobs registered without a namespace belong to the default namespace
+	if job.Namespace == "" {
+		job.Namespace = structs.DefaultNamespace
+	}
+
 	// Check if the job already exists
-	existing, err := txn.First("jobs", "id", job.ID)
+	existing, err := txn.First("jobs", "id", job.Namespace, job.ID)
 	if err != nil {
 		return fmt.Errorf("job lookup failed: %v", err)
 	}
 
+	// A new version of the job is only stable once it has been deployed
+	job.Stable = false
+
 	// Setup the indexes correctly
 	if existing != nil {
 		job.CreateIndex = existing.(*structs.Job).CreateIndex
 		job.ModifyIndex = index
 		job.JobModifyIndex = index
+		job.Version = existing.(*structs.Job).Version + 1
 
 		// Compute the job status
 		var err error
@@ -343,10 +362,11 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 		job.CreateIndex = index
 		job.ModifyIndex = index
 		job.JobModifyIndex = index
+		job.Version = 0
 
 		// If we are inserting the job for the first time, we don't need to
 		// calculate the jobs status as it is known.
-		if job.IsPeriodic() {
+		if job.IsPeriodic() || job.IsParameterized() {
 			job.Status = structs.JobStatusRunning
 		} else {
 			job.Status = structs.JobStatusPending
@@ -369,18 +389,24 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	// Track the version of the job
+	if err := s.upsertJobVersion(index, job, txn); err != nil {
+		return fmt.Errorf("unable to upsert job into job_version table: %v", err)
+	}
+
 	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
 }
 
-// DeleteJob is used to deregister a job
-func (s *StateStore) DeleteJob(index uint64, jobID string) error {
+// DeleteJob is used to deregister the job with the given ID in the given
+// namespace
+func (s *StateStore) DeleteJob(index uint64, namespace, jobID string) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
 	// Lookup the node
-	existing, err := txn.First("jobs", "id", jobID)
+	existing, err := txn.First("jobs", "id", namespace, jobID)
 	if err != nil {
 		return fmt.Errorf("job lookup failed: %v", err)
 	}
@@ -403,23 +429,55 @@ func (s *StateStore) DeleteJob(index uint64, jobID string) error {
 	}
 
 	// Delete the job summary
-	if _, err = txn.DeleteAll("job_summary", "id", jobID); err != nil {
+	if _, err = txn.DeleteAll("job_summary", "id", namespace, jobID); err != nil {
 		return fmt.Errorf("deleing job summary failed: %v", err)
 	}
 	if err := txn.Insert("index", &IndexEntry{"job_summary", index}); err != nil {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	// Delete the tracked versions of the job
+	versions, err := s.jobVersionsByID(txn, namespace, jobID)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		if err := txn.Delete("job_version", version); err != nil {
+			return fmt.Errorf("deleting job version failed: %v", err)
+		}
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_version", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// Delete the deployments of the job
+	deployments, err := s.deploymentsByJobID(txn, namespace, jobID)
+	if err != nil {
+		return err
+	}
+	for _, deployment := range deployments {
+		if err := txn.Delete("deployment", deployment); err != nil {
+			return fmt.Errorf("deleting deployment failed: %v", err)
+		}
+		watcher.Add(watch.Item{Deployment: deployment.ID})
+	}
+	if len(deployments) != 0 {
+		watcher.Add(watch.Item{Table: "deployment"})
+		if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
 	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
 }
 
-// JobByID is used to lookup a job by its ID
-func (s *StateStore) JobByID(id string) (*structs.Job, error) {
+// JobByID is used to lookup a job by its ID in the given namespace
+func (s *StateStore) JobByID(namespace, id string) (*structs.Job, error) {
 	txn := s.db.Txn(false)
 
-	existing, err := txn.First("jobs", "id", id)
+	existing, err := txn.First("jobs", "id", namespace, id)
 	if err != nil {
 		return nil, fmt.Errorf("job lookup failed: %v", err)
 	}
@@ -430,11 +488,150 @@ func (s *StateStore) JobByID(id string) (*structs.Job, error) {
 	return nil, nil
 }
 
-// JobsByIDPrefix is used to lookup a job by prefix
-func (s *StateStore) JobsByIDPrefix(id string) (memdb.ResultIterator, error) {
+// upsertJobVersion inserts a job into its historic version table and limits
+// the number of tracked versions to structs.JobTrackedVersions.
+func (s *StateStore) upsertJobVersion(index uint64, job *structs.Job, txn *memdb.Txn) error {
+	if err := txn.Insert("job_version", job); err != nil {
+		return fmt.Errorf("failed to insert job version: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_version", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	versions, err := s.jobVersionsByID(txn, job.Namespace, job.ID)
+	if err != nil {
+		return err
+	}
+
+	// Remove the oldest versions that are no longer tracked
+	if len(versions) <= structs.JobTrackedVersions {
+		return nil
+	}
+	for _, old := range versions[structs.JobTrackedVersions:] {
+		if err := txn.Delete("job_version", old); err != nil {
+			return fmt.Errorf("failed to delete job version: %v", err)
+		}
+	}
+	return nil
+}
+
+// JobVersionsByID returns the tracked versions of the job with the given ID in
+// the given namespace, ordered from the most recent version.
+func (s *StateStore) JobVersionsByID(namespace, id string) ([]*structs.Job, error) {
+	txn := s.db.Txn(false)
+	return s.jobVersionsByID(txn, namespace, id)
+}
+
+// jobVersionsByID is the implementation of JobVersionsByID that uses the
+// passed transaction.
+func (s *StateStore) jobVersionsByID(txn *memdb.Txn, namespace, id string) ([]*structs.Job, error) {
+	iter, err := txn.Get("job_version", "id_prefix", namespace, id)
+	if err != nil {
+		return nil, fmt.Errorf("job version lookup failed: %v", err)
+	}
+
+	var all []*structs.Job
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+
+		// The prefix lookup also returns the jobs whose ID starts with the
+		// given ID so only keep the exact matches.
+		job := raw.(*structs.Job)
+		if !strings.EqualFold(job.ID, id) {
+			continue
+		}
+
+		// Versions are iterated in ascending order so prepend them
+		all = append([]*structs.Job{job}, all...)
+	}
+	return all, nil
+}
+
+// JobByIDAndVersion returns the job with the given ID in the given namespace
+// at the given version, or nil if the version is not tracked.
+func (s *StateStore) JobByIDAndVersion(namespace, id string, version uint64) (*structs.Job, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("job_version", "id", namespace, id, version)
+	if err != nil {
+		return nil, fmt.Errorf("job version lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Job), nil
+	}
+	return nil, nil
+}
+
+// JobVersions returns an iterator over the tracked versions of all jobs
+func (s *StateStore) JobVersions() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("job_version", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// updateJobStabilityImpl marks the given version of a job as stable or not
+func (s *StateStore) updateJobStabilityImpl(index uint64, namespace, jobID string, version uint64, stable bool,
+	watcher watch.Items, txn *memdb.Txn) error {
+
+	// Update the tracked version of the job
+	existing, err := txn.First("job_version", "id", namespace, jobID, version)
+	if err != nil {
+		return fmt.Errorf("job version lookup failed: %v", err)
+	}
+	if existing == nil {
+		return nil
+	}
+	tracked := existing.(*structs.Job)
+	if tracked.Stable == stable {
+		return nil
+	}
+
+	updated := tracked.Copy()
+	updated.Stable = stable
+	updated.ModifyIndex = index
+	if err := txn.Insert("job_version", updated); err != nil {
+		return fmt.Errorf("job version insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_version", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// Update the job if the version is its current one
+	existing, err = txn.First("jobs", "id", namespace, jobID)
+	if err != nil {
+		return fmt.Errorf("job lookup failed: %v", err)
+	}
+	if existing == nil || existing.(*structs.Job).Version != version {
+		return nil
+	}
+
+	job := existing.(*structs.Job).Copy()
+	job.Stable = stable
+	job.ModifyIndex = index
+	if err := txn.Insert("jobs", job); err != nil {
+		return fmt.Errorf("job insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"jobs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	watcher.Add(watch.Item{Table: "jobs"})
+	watcher.Add(watch.Item{Job: jobID})
+	return nil
+}
+
+// JobsByIDPrefix is used to lookup the jobs of a namespace by prefix
+func (s *StateStore) JobsByIDPrefix(namespace, id string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
 
-	iter, err := txn.Get("jobs", "id_prefix", id)
+	iter, err := txn.Get("jobs", "id_prefix", namespace, id)
 	if err != nil {
 		return nil, fmt.Errorf("job lookup failed: %v", err)
 	}
@@ -490,11 +687,12 @@ func (s *StateStore) JobsByGC(gc bool) (memdb.ResultIterator, error) {
 	return iter, nil
 }
 
-// JobSummary returns a job summary object which matches a specific id.
-func (s *StateStore) JobSummaryByID(jobID string) (*structs.JobSummary, error) {
+// JobSummary returns a job summary object which matches a specific id in the
+// given namespace.
+func (s *StateStore) JobSummaryByID(namespace, jobID string) (*structs.JobSummary, error) {
 	txn := s.db.Txn(false)
 
-	existing, err := txn.First("job_summary", "id", jobID)
+	existing, err := txn.First("job_summary", "id", namespace, jobID)
 	if err != nil {
 		return nil, err
 	}
@@ -518,11 +716,12 @@ func (s *StateStore) JobSummaries() (memdb.ResultIterator, error) {
 	return iter, nil
 }
 
-// JobSummaryByPrefix is used to look up Job Summary by id prefix
-func (s *StateStore) JobSummaryByPrefix(id string) (memdb.ResultIterator, error) {
+// JobSummaryByPrefix is used to look up the Job Summaries of a namespace by id
+// prefix
+func (s *StateStore) JobSummaryByPrefix(namespace, id string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
 
-	iter, err := txn.Get("job_summary", "id_prefix", id)
+	iter, err := txn.Get("job_summary", "id_prefix", namespace, id)
 	if err != nil {
 		return nil, fmt.Errorf("eval lookup failed: %v", err)
 	}
@@ -539,8 +738,13 @@ func (s *StateStore) UpsertPeriodicLaunch(index uint64, launch *structs.Periodic
 	watcher.Add(watch.Item{Table: "periodic_launch"})
 	watcher.Add(watch.Item{Job: launch.ID})
 
+	// Launches recorded without a namespace belong to the default namespace
+	if launch.Namespace == "" {
+		launch.Namespace = structs.DefaultNamespace
+	}
+
 	// Check if the job already exists
-	existing, err := txn.First("periodic_launch", "id", launch.ID)
+	existing, err := txn.First("periodic_launch", "id", launch.Namespace, launch.ID)
 	if err != nil {
 		return fmt.Errorf("periodic launch lookup failed: %v", err)
 	}
@@ -567,13 +771,14 @@ func (s *StateStore) UpsertPeriodicLaunch(index uint64, launch *structs.Periodic
 	return nil
 }
 
-// DeletePeriodicLaunch is used to delete the periodic launch
-func (s *StateStore) DeletePeriodicLaunch(index uint64, jobID string) error {
+// DeletePeriodicLaunch is used to delete the periodic launch of the job with
+// the given ID in the given namespace
+func (s *StateStore) DeletePeriodicLaunch(index uint64, namespace, jobID string) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
 	// Lookup the launch
-	existing, err := txn.First("periodic_launch", "id", jobID)
+	existing, err := txn.First("periodic_launch", "id", namespace, jobID)
 	if err != nil {
 		return fmt.Errorf("launch lookup failed: %v", err)
 	}
@@ -599,11 +804,11 @@ func (s *StateStore) DeletePeriodicLaunch(index uint64, jobID string) error {
 }
 
 // PeriodicLaunchByID is used to lookup a periodic launch by the periodic job
-// ID.
-func (s *StateStore) PeriodicLaunchByID(id string) (*structs.PeriodicLaunch, error) {
+// ID and namespace.
+func (s *StateStore) PeriodicLaunchByID(namespace, id string) (*structs.PeriodicLaunch, error) {
 	txn := s.db.Txn(false)
 
-	existing, err := txn.First("periodic_launch", "id", id)
+	existing, err := txn.First("periodic_launch", "id", namespace, id)
 	if err != nil {
 		return nil, fmt.Errorf("periodic launch lookup failed: %v", err)
 	}
@@ -626,28 +831,14 @@ func (s *StateStore) PeriodicLaunches() (memdb.ResultIterator, error) {
 	return iter, nil
 }
 
-// UpsertEvaluation is used to upsert an evaluation
-func (s *StateStore) UpsertEvals(index uint64, evals []*structs.Evaluation) error {
+// UpsertDeployment is used to insert or update a deployment
+func (s *StateStore) UpsertDeployment(index uint64, deployment *structs.Deployment) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
 	watcher := watch.NewItems()
-	watcher.Add(watch.Item{Table: "evals"})
-
-	// Do a nested upsert
-	jobs := make(map[string]string, len(evals))
-	for _, eval := range evals {
-		watcher.Add(watch.Item{Eval: eval.ID})
-		if err := s.nestedUpsertEval(txn, index, eval); err != nil {
-			return err
-		}
-
-		jobs[eval.JobID] = ""
-	}
-
-	// Set the job's status
-	if err := s.setJobStatuses(index, watcher, txn, jobs, false); err != nil {
-		return fmt.Errorf("setting job status failed: %v", err)
+	if err := s.upsertDeploymentImpl(index, deployment, watcher, txn); err != nil {
+		return err
 	}
 
 	txn.Defer(func() { s.watch.notify(watcher) })
@@ -655,118 +846,89 @@ func (s *StateStore) UpsertEvals(index uint64, evals []*structs.Evaluation) erro
 	return nil
 }
 
-// nestedUpsertEvaluation is used to nest an evaluation upsert within a transaction
-func (s *StateStore) nestedUpsertEval(txn *memdb.Txn, index uint64, eval *structs.Evaluation) error {
-	// Lookup the evaluation
-	existing, err := txn.First("evals", "id", eval.ID)
+// upsertDeploymentImpl is the implementation of UpsertDeployment that uses
+// the passed transaction.
+func (s *StateStore) upsertDeploymentImpl(index uint64, deployment *structs.Deployment,
+	watcher watch.Items, txn *memdb.Txn) error {
+
+	watcher.Add(watch.Item{Table: "deployment"})
+	watcher.Add(watch.Item{Deployment: deployment.ID})
+
+	existing, err := txn.First("deployment", "id", deployment.ID)
 	if err != nil {
-		return fmt.Errorf("eval lookup failed: %v", err)
+		return fmt.Errorf("deployment lookup failed: %v", err)
 	}
 
-	// Update the indexes
+	// Deployments created without a namespace belong to the default namespace
+	if deployment.Namespace == "" {
+		deployment.Namespace = structs.DefaultNamespace
+	}
+
+	// Setup the indexes correctly
 	if existing != nil {
-		eval.CreateIndex = existing.(*structs.Evaluation).CreateIndex
-		eval.ModifyIndex = index
+		deployment.CreateIndex = existing.(*structs.Deployment).CreateIndex
+		deployment.ModifyIndex = index
 	} else {
-		eval.CreateIndex = index
-		eval.ModifyIndex = index
+		deployment.CreateIndex = index
+		deployment.ModifyIndex = index
 	}
 
-	// Update the job summary
-	summaryRaw, err := txn.First("job_summary", "id", eval.JobID)
-	if err != nil {
-		return fmt.Errorf("job summary lookup failed: %v", err)
+	if err := txn.Insert("deployment", deployment); err != nil {
+		return fmt.Errorf("deployment insert failed: %v", err)
 	}
-	if summaryRaw != nil {
-		js := summaryRaw.(structs.JobSummary)
-		var hasSummaryChanged bool
-		for tg, num := range eval.QueuedAllocations {
-			if summary, ok := js.Summary[tg]; ok {
-				if summary.Queued != num {
-					summary.Queued = num
-					js.Summary[tg] = summary
-					hasSummaryChanged = true
-				}
-			} else {
-				s.logger.Printf("[ERR] state_store: unable to update queued for job %q and task group %q", eval.JobID, tg)
-			}
-		}
-
-		// Insert the job summary
-		if hasSummaryChanged {
-			js.ModifyIndex = index
-			if err := txn.Insert("job_summary", js); err != nil {
-				return fmt.Errorf("job summary insert failed: %v", err)
-			}
-			if err := txn.Insert("index", &IndexEntry{"job_summary", index}); err != nil {
-				return fmt.Errorf("index update failed: %v", err)
-			}
-		}
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
 	}
 
-	// Insert the eval
-	if err := txn.Insert("evals", eval); err != nil {
-		return fmt.Errorf("eval insert failed: %v", err)
-	}
-	if err := txn.Insert("index", &IndexEntry{"evals", index}); err != nil {
-		return fmt.Errorf("index update failed: %v", err)
+	// Limit the number of deployments kept for the job
+	if existing == nil {
+		return s.pruneDeployments(deployment.Namespace, deployment.JobID, watcher, txn)
 	}
 	return nil
 }
 
-// DeleteEval is used to delete an evaluation
-func (s *StateStore) DeleteEval(index uint64, evals []string, allocs []string) error {
-	txn := s.db.Txn(true)
-	defer txn.Abort()
-	watcher := watch.NewItems()
-	watcher.Add(watch.Item{Table: "evals"})
-	watcher.Add(watch.Item{Table: "allocs"})
-
-	jobs := make(map[string]string, len(evals))
-	for _, eval := range evals {
-		existing, err := txn.First("evals", "id", eval)
-		if err != nil {
-			return fmt.Errorf("eval lookup failed: %v", err)
-		}
-		if existing == nil {
-			continue
-		}
-		if err := txn.Delete("evals", existing); err != nil {
-			return fmt.Errorf("eval delete failed: %v", err)
-		}
-		watcher.Add(watch.Item{Eval: eval})
-		jobs[existing.(*structs.Evaluation).JobID] = ""
+// pruneDeployments removes the oldest deployments of a job that are no longer
+// running once more than structs.JobTrackedDeployments are kept.
+func (s *StateStore) pruneDeployments(namespace, jobID string, watcher watch.Items, txn *memdb.Txn) error {
+	deployments, err := s.deploymentsByJobID(txn, namespace, jobID)
+	if err != nil {
+		return err
 	}
 
-	for _, alloc := range allocs {
-		existing, err := txn.First("allocs", "id", alloc)
-		if err != nil {
-			return fmt.Errorf("alloc lookup failed: %v", err)
+	for len(deployments) > structs.JobTrackedDeployments {
+		// Find the oldest deployment that is no longer running
+		oldest := -1
+		for i, deployment := range deployments {
+			if deployment.Active() {
+				continue
+			}
+			if oldest == -1 || deployment.CreateIndex < deployments[oldest].CreateIndex {
+				oldest = i
+			}
 		}
-		if existing == nil {
-			continue
+		if oldest == -1 {
+			return nil
 		}
-		if err := txn.Delete("allocs", existing); err != nil {
-			return fmt.Errorf("alloc delete failed: %v", err)
+
+		if err := txn.Delete("deployment", deployments[oldest]); err != nil {
+			return fmt.Errorf("deployment delete failed: %v", err)
 		}
-		realAlloc := existing.(*structs.Allocation)
-		watcher.Add(watch.Item{Alloc: realAlloc.ID})
-		watcher.Add(watch.Item{AllocEval: realAlloc.EvalID})
-		watcher.Add(watch.Item{AllocJob: realAlloc.JobID})
-		watcher.Add(watch.Item{AllocNode: realAlloc.NodeID})
+		watcher.Add(watch.Item{Deployment: deployments[oldest].ID})
+		deployments = append(deployments[:oldest], deployments[oldest+1:]...)
 	}
+	return nil
+}
 
-	// Update the indexes
-	if err := txn.Insert("index", &IndexEntry{"evals", index}); err != nil {
-		return fmt.Errorf("index update failed: %v", err)
-	}
-	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
-		return fmt.Errorf("index update failed: %v", err)
-	}
+// UpdateDeploymentStatus is used to update the status of a deployment. A
+// deployment that completed successfully marks its version of the job as
+// stable.
+func (s *StateStore) UpdateDeploymentStatus(index uint64, req *structs.DeploymentStatusUpdateRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
 
-	// Set the job's status
-	if err := s.setJobStatuses(index, watcher, txn, jobs, true); err != nil {
-		return fmt.Errorf("setting job status failed: %v", err)
+	watcher := watch.NewItems()
+	if err := s.updateDeploymentStatusImpl(index, req.DeploymentUpdate, watcher, txn); err != nil {
+		return err
 	}
 
 	txn.Defer(func() { s.watch.notify(watcher) })
@@ -774,224 +936,1212 @@ func (s *StateStore) DeleteEval(index uint64, evals []string, allocs []string) e
 	return nil
 }
 
-// EvalByID is used to lookup an eval by its ID
-func (s *StateStore) EvalByID(id string) (*structs.Evaluation, error) {
-	txn := s.db.Txn(false)
+// updateDeploymentStatusImpl is the implementation of UpdateDeploymentStatus
+// that uses the passed transaction.
+func (s *StateStore) updateDeploymentStatusImpl(index uint64, u *structs.DeploymentStatusUpdate,
+	watcher watch.Items, txn *memdb.Txn) error {
 
-	existing, err := txn.First("evals", "id", id)
+	existing, err := txn.First("deployment", "id", u.DeploymentID)
 	if err != nil {
-		return nil, fmt.Errorf("eval lookup failed: %v", err)
+		return fmt.Errorf("deployment lookup failed: %v", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("deployment %q not found", u.DeploymentID)
 	}
 
-	if existing != nil {
-		return existing.(*structs.Evaluation), nil
+	deployment := existing.(*structs.Deployment).Copy()
+	deployment.Status = u.Status
+	deployment.StatusDescription = u.StatusDescription
+	if err := s.upsertDeploymentImpl(index, deployment, watcher, txn); err != nil {
+		return err
 	}
-	return nil, nil
+
+	if deployment.Status == structs.DeploymentStatusSuccessful {
+		return s.updateJobStabilityImpl(index, deployment.Namespace, deployment.JobID, deployment.JobVersion, true, watcher, txn)
+	}
+	return nil
 }
 
-// EvalsByIDPrefix is used to lookup evaluations by prefix
-func (s *StateStore) EvalsByIDPrefix(id string) (memdb.ResultIterator, error) {
-	txn := s.db.Txn(false)
+// UpdateDeploymentPromotion promotes the canaries of the task groups of a
+// deployment named by the request, or of all of them if it names none,
+// allowing their rollout to proceed. The promoted canaries are no longer
+// marked as canaries, so that their clients cut over to them.
+func (s *StateStore) UpdateDeploymentPromotion(index uint64, req *structs.DeploymentPromoteRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
 
-	iter, err := txn.Get("evals", "id_prefix", id)
+	existing, err := txn.First("deployment", "id", req.DeploymentID)
 	if err != nil {
-		return nil, fmt.Errorf("eval lookup failed: %v", err)
+		return fmt.Errorf("deployment lookup failed: %v", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("deployment %q not found", req.DeploymentID)
+	}
+
+	deployment := existing.(*structs.Deployment).Copy()
+	for name, state := range deployment.TaskGroups {
+		if state.DesiredCanaries > 0 && req.PromotesGroup(name) {
+			state.Promoted = true
+		}
+	}
+	if deployment.StatusDescription == structs.DeploymentStatusDescriptionNeedsPromotion &&
+		!deployment.RequiresPromotion() {
+		deployment.StatusDescription = structs.DeploymentStatusDescriptionRunning
+	}
+
+	watcher := watch.NewItems()
+	if err := s.upsertDeploymentImpl(index, deployment, watcher, txn); err != nil {
+		return err
+	}
+
+	allocs, err := s.allocsByDeployment(txn, deployment.ID)
+	if err != nil {
+		return err
+	}
+	promoted := false
+	for _, alloc := range allocs {
+		if !alloc.DeploymentStatus.IsCanary() || alloc.TerminalStatus() ||
+			!req.PromotesGroup(alloc.TaskGroup) {
+			continue
+		}
+
+		copyAlloc := new(structs.Allocation)
+		*copyAlloc = *alloc
+		copyAlloc.DeploymentStatus = alloc.DeploymentStatus.Copy()
+		copyAlloc.DeploymentStatus.Canary = false
+		copyAlloc.ModifyIndex = index
+		copyAlloc.AllocModifyIndex = index
+		if err := txn.Insert("allocs", copyAlloc); err != nil {
+			return fmt.Errorf("alloc insert failed: %v", err)
+		}
+
+		watcher.Add(watch.Item{Alloc: alloc.ID})
+		watcher.Add(watch.Item{AllocEval: alloc.EvalID})
+		watcher.Add(watch.Item{AllocJob: alloc.JobID})
+		watcher.Add(watch.Item{AllocNode: alloc.NodeID})
+		promoted = true
+	}
+	if promoted {
+		watcher.Add(watch.Item{Table: "allocs"})
+		if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// UpdateDeploymentAllocHealth marks the given allocations of a deployment as
+// healthy.
+func (s *StateStore) UpdateDeploymentAllocHealth(index uint64, req *structs.DeploymentAllocHealthRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "allocs"})
+
+	for _, id := range req.HealthyAllocationIDs {
+		existing, err := txn.First("allocs", "id", id)
+		if err != nil {
+			return fmt.Errorf("alloc lookup failed: %v", err)
+		}
+		if existing == nil {
+			return fmt.Errorf("alloc %q not found", id)
+		}
+		exist := existing.(*structs.Allocation)
+		if exist.DeploymentID != req.DeploymentID {
+			return fmt.Errorf("alloc %q is not part of deployment %q", id, req.DeploymentID)
+		}
+
+		// Copy everything from the existing allocation
+		copyAlloc := new(structs.Allocation)
+		*copyAlloc = *exist
+
+		healthy := true
+		copyAlloc.DeploymentStatus = &structs.AllocDeploymentStatus{
+			Healthy: &healthy,
+			Canary:  exist.DeploymentStatus.IsCanary(),
+		}
+		copyAlloc.ModifyIndex = index
+
+		if err := s.updateDeploymentWithAlloc(index, copyAlloc, exist, watcher, txn); err != nil {
+			return fmt.Errorf("error updating deployment: %v", err)
+		}
+		if err := txn.Insert("allocs", copyAlloc); err != nil {
+			return fmt.Errorf("alloc insert failed: %v", err)
+		}
+
+		watcher.Add(watch.Item{Alloc: exist.ID})
+		watcher.Add(watch.Item{AllocEval: exist.EvalID})
+		watcher.Add(watch.Item{AllocJob: exist.JobID})
+		watcher.Add(watch.Item{AllocNode: exist.NodeID})
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// DeploymentByID is used to lookup a deployment by its ID
+func (s *StateStore) DeploymentByID(id string) (*structs.Deployment, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("deployment", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("deployment lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Deployment), nil
+	}
+	return nil, nil
+}
+
+// DeploymentsByIDPrefix is used to lookup deployments by prefix
+func (s *StateStore) DeploymentsByIDPrefix(id string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("deployment", "id_prefix", id)
+	if err != nil {
+		return nil, fmt.Errorf("deployment lookup failed: %v", err)
+	}
+
+	return iter, nil
+}
+
+// Deployments returns an iterator over all the deployments
+func (s *StateStore) Deployments() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("deployment", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// DeploymentsByJobID returns the deployments of the job with the given ID in
+// the given namespace
+func (s *StateStore) DeploymentsByJobID(namespace, jobID string) ([]*structs.Deployment, error) {
+	txn := s.db.Txn(false)
+	return s.deploymentsByJobID(txn, namespace, jobID)
+}
+
+// deploymentsByJobID is the implementation of DeploymentsByJobID that uses
+// the passed transaction.
+func (s *StateStore) deploymentsByJobID(txn *memdb.Txn, namespace, jobID string) ([]*structs.Deployment, error) {
+	iter, err := txn.Get("deployment", "job", namespace, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Deployment
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Deployment))
+	}
+	return out, nil
+}
+
+// LatestDeploymentByJobID returns the most recently created deployment of
+// the job with the given ID in the given namespace, or nil if there is none.
+func (s *StateStore) LatestDeploymentByJobID(namespace, jobID string) (*structs.Deployment, error) {
+	deployments, err := s.DeploymentsByJobID(namespace, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *structs.Deployment
+	for _, deployment := range deployments {
+		if latest == nil || latest.CreateIndex < deployment.CreateIndex {
+			latest = deployment
+		}
+	}
+	return latest, nil
+}
+
+// UpsertEvaluation is used to upsert an evaluation
+func (s *StateStore) UpsertEvals(index uint64, evals []*structs.Evaluation) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "evals"})
+
+	// Do a nested upsert
+	jobs := make(map[structs.NamespacedID]string, len(evals))
+	for _, eval := range evals {
+		watcher.Add(watch.Item{Eval: eval.ID})
+		watcher.Add(watch.Item{EvalJob: eval.JobID})
+		if err := s.nestedUpsertEval(txn, index, eval); err != nil {
+			return err
+		}
+
+		jobs[structs.NewNamespacedID(eval.JobID, eval.Namespace)] = ""
+	}
+
+	// Set the job's status
+	if err := s.setJobStatuses(index, watcher, txn, jobs, false); err != nil {
+		return fmt.Errorf("setting job status failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// nestedUpsertEvaluation is used to nest an evaluation upsert within a transaction
+func (s *StateStore) nestedUpsertEval(txn *memdb.Txn, index uint64, eval *structs.Evaluation) error {
+	// Lookup the evaluation
+	existing, err := txn.First("evals", "id", eval.ID)
+	if err != nil {
+		return fmt.Errorf("eval lookup failed: %v", err)
+	}
+
+	// Update the indexes
+	if existing != nil {
+		existingEval := existing.(*structs.Evaluation)
+		eval.CreateIndex = existingEval.CreateIndex
+		eval.ModifyIndex = index
+
+		// Don't lose the delivery attempts recorded since the eval was
+		// dequeued
+		if existingEval.DeliveryAttempts > eval.DeliveryAttempts {
+			eval.DeliveryAttempts = existingEval.DeliveryAttempts
+		}
+	} else {
+		eval.CreateIndex = index
+		eval.ModifyIndex = index
+	}
+
+	// Evaluations created without a namespace belong to the default namespace
+	if eval.Namespace == "" {
+		eval.Namespace = structs.DefaultNamespace
+	}
+
+	// Update the job summary
+	summaryRaw, err := txn.First("job_summary", "id", eval.Namespace, eval.JobID)
+	if err != nil {
+		return fmt.Errorf("job summary lookup failed: %v", err)
+	}
+	if summaryRaw != nil {
+		js := summaryRaw.(structs.JobSummary)
+		var hasSummaryChanged bool
+		for tg, num := range eval.QueuedAllocations {
+			if summary, ok := js.Summary[tg]; ok {
+				if summary.Queued != num {
+					summary.Queued = num
+					js.Summary[tg] = summary
+					hasSummaryChanged = true
+				}
+			} else {
+				s.logger.Printf("[ERR] state_store: unable to update queued for job %q and task group %q", eval.JobID, tg)
+			}
+		}
+
+		// Insert the job summary
+		if hasSummaryChanged {
+			js.ModifyIndex = index
+			if err := txn.Insert("job_summary", js); err != nil {
+				return fmt.Errorf("job summary insert failed: %v", err)
+			}
+			if err := txn.Insert("index", &IndexEntry{"job_summary", index}); err != nil {
+				return fmt.Errorf("index update failed: %v", err)
+			}
+		}
+	}
+
+	// Insert the eval
+	if err := txn.Insert("evals", eval); err != nil {
+		return fmt.Errorf("eval insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"evals", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	return nil
+}
+
+// UpdateEvalDeliveries records the delivery attempts of evaluations. Evals
+// that no longer exist or are terminal are skipped, as are attempts lower than
+// the recorded ones.
+func (s *StateStore) UpdateEvalDeliveries(index uint64, req *structs.EvalDeliveryUpdateRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "evals"})
+
+	for id, attempts := range req.DeliveryAttempts {
+		existing, err := txn.First("evals", "id", id)
+		if err != nil {
+			return fmt.Errorf("eval lookup failed: %v", err)
+		}
+		if existing == nil {
+			continue
+		}
+		eval := existing.(*structs.Evaluation)
+		if eval.TerminalStatus() || eval.DeliveryAttempts >= attempts {
+			continue
+		}
+
+		eval = eval.Copy()
+		eval.DeliveryAttempts = attempts
+		eval.ModifyIndex = index
+		if err := txn.Insert("evals", eval); err != nil {
+			return fmt.Errorf("eval insert failed: %v", err)
+		}
+		watcher.Add(watch.Item{Eval: id})
+		watcher.Add(watch.Item{EvalJob: eval.JobID})
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"evals", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// DeleteEval is used to delete an evaluation
+func (s *StateStore) DeleteEval(index uint64, evals []string, allocs []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "evals"})
+	watcher.Add(watch.Item{Table: "allocs"})
+
+	jobs := make(map[structs.NamespacedID]string, len(evals))
+	for _, eval := range evals {
+		existing, err := txn.First("evals", "id", eval)
+		if err != nil {
+			return fmt.Errorf("eval lookup failed: %v", err)
+		}
+		if existing == nil {
+			continue
+		}
+		if err := txn.Delete("evals", existing); err != nil {
+			return fmt.Errorf("eval delete failed: %v", err)
+		}
+		watcher.Add(watch.Item{Eval: eval})
+		watcher.Add(watch.Item{EvalJob: existing.(*structs.Evaluation).JobID})
+		existingEval := existing.(*structs.Evaluation)
+		jobs[structs.NewNamespacedID(existingEval.JobID, existingEval.Namespace)] = ""
+	}
+
+	for _, alloc := range allocs {
+		existing, err := txn.First("allocs", "id", alloc)
+		if err != nil {
+			return fmt.Errorf("alloc lookup failed: %v", err)
+		}
+		if existing == nil {
+			continue
+		}
+		if err := txn.Delete("allocs", existing); err != nil {
+			return fmt.Errorf("alloc delete failed: %v", err)
+		}
+		realAlloc := existing.(*structs.Allocation)
+		watcher.Add(watch.Item{Alloc: realAlloc.ID})
+		watcher.Add(watch.Item{AllocEval: realAlloc.EvalID})
+		watcher.Add(watch.Item{AllocJob: realAlloc.JobID})
+		watcher.Add(watch.Item{AllocNode: realAlloc.NodeID})
+	}
+
+	// Update the indexes
+	if err := txn.Insert("index", &IndexEntry{"evals", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// Set the job's status
+	if err := s.setJobStatuses(index, watcher, txn, jobs, true); err != nil {
+		return fmt.Errorf("setting job status failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// EvalByID is used to lookup an eval by its ID
+func (s *StateStore) EvalByID(id string) (*structs.Evaluation, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("evals", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("eval lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Evaluation), nil
+	}
+	return nil, nil
+}
+
+// EvalsByIDPrefix is used to lookup evaluations by prefix
+func (s *StateStore) EvalsByIDPrefix(id string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("evals", "id_prefix", id)
+	if err != nil {
+		return nil, fmt.Errorf("eval lookup failed: %v", err)
+	}
+
+	return iter, nil
+}
+
+// EvalsByJob returns all the evaluations by job id in the given namespace
+func (s *StateStore) EvalsByJob(namespace, jobID string) ([]*structs.Evaluation, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the node allocations
+	iter, err := txn.Get("evals", "job", namespace, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Evaluation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Evaluation))
+	}
+	return out, nil
+}
+
+// Evals returns an iterator over all the evaluations
+func (s *StateStore) Evals() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("evals", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// UpdateAllocsFromClient is used to update an allocation based on input
+
+// from a client. While the schedulers are the authority on the allocation for
+// most things, some updates are authoritative from the client. Specifically,
+// the desired state comes from the schedulers, while the actual state comes
+// from clients.
+func (s *StateStore) UpdateAllocsFromClient(index uint64, allocs []*structs.Allocation) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	// Setup the watcher
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "allocs"})
+
+	// Handle each of the updated allocations
+	for _, alloc := range allocs {
+		if err := s.nestedUpdateAllocFromClient(txn, watcher, index, alloc); err != nil {
+			return err
+		}
+	}
+
+	// Update the indexes
+	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// nestedUpdateAllocFromClient is used to nest an update of an allocation with client status
+func (s *StateStore) nestedUpdateAllocFromClient(txn *memdb.Txn, watcher watch.Items, index uint64, alloc *structs.Allocation) error {
+	// Look for existing alloc
+	existing, err := txn.First("allocs", "id", alloc.ID)
+	if err != nil {
+		return fmt.Errorf("alloc lookup failed: %v", err)
+	}
+
+	// Nothing to do if this does not exist
+	if existing == nil {
+		return nil
+	}
+	exist := existing.(*structs.Allocation)
+	// Trigger the watcher
+	watcher.Add(watch.Item{Alloc: alloc.ID})
+	watcher.Add(watch.Item{AllocEval: exist.EvalID})
+	watcher.Add(watch.Item{AllocJob: exist.JobID})
+	watcher.Add(watch.Item{AllocNode: exist.NodeID})
+
+	// Copy everything from the existing allocation
+	copyAlloc := new(structs.Allocation)
+	*copyAlloc = *exist
+
+	// Pull in anything the client is the authority on
+	copyAlloc.ClientStatus = alloc.ClientStatus
+	copyAlloc.ClientDescription = alloc.ClientDescription
+	copyAlloc.TaskStates = alloc.TaskStates
+	if alloc.DeploymentStatus != nil {
+		// The canary flag is owned by the scheduler
+		copyAlloc.DeploymentStatus = alloc.DeploymentStatus.Copy()
+		copyAlloc.DeploymentStatus.Canary = exist.DeploymentStatus.IsCanary()
+	}
+
+	// Update the modify index
+	copyAlloc.ModifyIndex = index
+
+	if err := s.updateSummaryWithAlloc(index, copyAlloc, exist, watcher, txn); err != nil {
+		return fmt.Errorf("error updating job summary: %v", err)
+	}
+
+	if err := s.updateDeploymentWithAlloc(index, copyAlloc, exist, watcher, txn); err != nil {
+		return fmt.Errorf("error updating deployment: %v", err)
+	}
+
+	// Update the allocation
+	if err := txn.Insert("allocs", copyAlloc); err != nil {
+		return fmt.Errorf("alloc insert failed: %v", err)
+	}
+
+	// Set the job's status
+	forceStatus := ""
+	if !copyAlloc.TerminalStatus() {
+		forceStatus = structs.JobStatusRunning
+	}
+	jobs := map[structs.NamespacedID]string{structs.NewNamespacedID(exist.JobID, exist.Namespace): forceStatus}
+	if err := s.setJobStatuses(index, watcher, txn, jobs, false); err != nil {
+		return fmt.Errorf("setting job status failed: %v", err)
+	}
+	return nil
+}
+
+// UpsertPlanResults is used to upsert the results of a plan. The
+// allocations are upserted along with the deployment created by the plan and
+// the status updates of existing deployments.
+func (s *StateStore) UpsertPlanResults(index uint64, results *structs.AllocUpdateRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "allocs"})
+
+	// Upsert the deployment before the allocations it placed
+	if results.Deployment != nil {
+		if err := s.upsertDeploymentImpl(index, results.Deployment, watcher, txn); err != nil {
+			return err
+		}
+	}
+
+	for _, update := range results.DeploymentUpdates {
+		if err := s.updateDeploymentStatusImpl(index, update, watcher, txn); err != nil {
+			return err
+		}
+	}
+
+	if err := s.upsertAllocsImpl(index, results.Alloc, watcher, txn); err != nil {
+		return err
+	}
+
+	// Upsert the evaluations of the jobs whose allocations were preempted
+	if len(results.PreemptionEvals) != 0 {
+		watcher.Add(watch.Item{Table: "evals"})
+		jobs := make(map[structs.NamespacedID]string, len(results.PreemptionEvals))
+		for _, eval := range results.PreemptionEvals {
+			watcher.Add(watch.Item{Eval: eval.ID})
+			watcher.Add(watch.Item{EvalJob: eval.JobID})
+			if err := s.nestedUpsertEval(txn, index, eval); err != nil {
+				return err
+			}
+			jobs[structs.NewNamespacedID(eval.JobID, eval.Namespace)] = ""
+		}
+		if err := s.setJobStatuses(index, watcher, txn, jobs, false); err != nil {
+			return fmt.Errorf("setting job status failed: %v", err)
+		}
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// UpsertAllocs is used to evict a set of allocations
+// and allocate new ones at the same time.
+func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "allocs"})
+
+	if err := s.upsertAllocsImpl(index, allocs, watcher, txn); err != nil {
+		return err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// upsertAllocsImpl is the implementation of UpsertAllocs that uses the
+// passed transaction.
+func (s *StateStore) upsertAllocsImpl(index uint64, allocs []*structs.Allocation,
+	watcher watch.Items, txn *memdb.Txn) error {
+
+	// Handle the allocations
+	jobs := make(map[structs.NamespacedID]string, 1)
+	for _, alloc := range allocs {
+		existing, err := txn.First("allocs", "id", alloc.ID)
+		if err != nil {
+			return fmt.Errorf("alloc lookup failed: %v", err)
+		}
+		exist, _ := existing.(*structs.Allocation)
+
+		if exist == nil {
+			alloc.CreateIndex = index
+			alloc.ModifyIndex = index
+			alloc.AllocModifyIndex = index
+		} else {
+			alloc.CreateIndex = exist.CreateIndex
+			alloc.ModifyIndex = index
+			alloc.AllocModifyIndex = index
+
+			// If the scheduler is marking this allocation as lost we do not
+			// want to reuse the status of the existing allocation.
+			if alloc.ClientStatus != structs.AllocClientStatusLost {
+				alloc.ClientStatus = exist.ClientStatus
+				alloc.ClientDescription = exist.ClientDescription
+			}
+
+			// The health of the allocation is not set by the scheduler
+			alloc.DeploymentStatus = exist.DeploymentStatus
+
+			// The job has been denormalized so re-attach the original job
+			if alloc.Job == nil {
+				alloc.Job = exist.Job
+			}
+		}
+
+		// Allocations belong to the namespace of their job
+		if alloc.Namespace == "" {
+			alloc.Namespace = structs.DefaultNamespace
+			if alloc.Job != nil && alloc.Job.Namespace != "" {
+				alloc.Namespace = alloc.Job.Namespace
+			}
+		}
+
+		if err := s.updateSummaryWithAlloc(index, alloc, exist, watcher, txn); err != nil {
+			return fmt.Errorf("error updating job summary: %v", err)
+		}
+
+		if err := s.updateDeploymentWithAlloc(index, alloc, exist, watcher, txn); err != nil {
+			return fmt.Errorf("error updating deployment: %v", err)
+		}
+
+		// Create the EphemeralDisk if it's nil by adding up DiskMB from task resources.
+		// COMPAT 0.4.1 -> 0.5
+		if alloc.Job != nil {
+			s.addEphemeralDiskToTaskGroups(alloc.Job)
+		}
+
+		if err := txn.Insert("allocs", alloc); err != nil {
+			return fmt.Errorf("alloc insert failed: %v", err)
+		}
+
+		// If the allocation is running, force the job to running status.
+		forceStatus := ""
+		if !alloc.TerminalStatus() {
+			forceStatus = structs.JobStatusRunning
+		}
+		jobs[structs.NewNamespacedID(alloc.JobID, alloc.Namespace)] = forceStatus
+
+		watcher.Add(watch.Item{Alloc: alloc.ID})
+		watcher.Add(watch.Item{AllocEval: alloc.EvalID})
+		watcher.Add(watch.Item{AllocJob: alloc.JobID})
+		watcher.Add(watch.Item{AllocNode: alloc.NodeID})
+	}
+
+	// Update the indexes
+	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// Set the job's status
+	if err := s.setJobStatuses(index, watcher, txn, jobs, false); err != nil {
+		return fmt.Errorf("setting job status failed: %v", err)
+	}
+	return nil
+}
+
+// AllocByID is used to lookup an allocation by its ID
+func (s *StateStore) AllocByID(id string) (*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("allocs", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("alloc lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Allocation), nil
+	}
+	return nil, nil
+}
+
+// AllocsByIDPrefix is used to lookup allocs by prefix
+func (s *StateStore) AllocsByIDPrefix(id string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("allocs", "id_prefix", id)
+	if err != nil {
+		return nil, fmt.Errorf("alloc lookup failed: %v", err)
+	}
+
+	return iter, nil
+}
+
+// AllocsByNode returns all the allocations by node
+func (s *StateStore) AllocsByNode(node string) ([]*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the node allocations, using only the
+	// node prefix which ignores the terminal status
+	iter, err := txn.Get("allocs", "node_prefix", node)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+	return out, nil
+}
+
+// AllocsByNode returns all the allocations by node and terminal status
+func (s *StateStore) AllocsByNodeTerminal(node string, terminal bool) ([]*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the node allocations
+	iter, err := txn.Get("allocs", "node", node, terminal)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+	return out, nil
+}
+
+// AllocsByJob returns all the allocations by job id in the given namespace
+func (s *StateStore) AllocsByJob(namespace, jobID string) ([]*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the node allocations
+	iter, err := txn.Get("allocs", "job", namespace, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+	return out, nil
+}
+
+// AllocsByEval returns all the allocations by eval id
+func (s *StateStore) AllocsByEval(evalID string) ([]*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the eval allocations
+	iter, err := txn.Get("allocs", "eval", evalID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+	return out, nil
+}
+
+// AllocsByDeployment returns all the allocations placed by a deployment
+func (s *StateStore) AllocsByDeployment(deploymentID string) ([]*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+	return s.allocsByDeployment(txn, deploymentID)
+}
+
+// allocsByDeployment is used to lookup the allocations placed by a deployment
+// using the passed transaction.
+func (s *StateStore) allocsByDeployment(txn *memdb.Txn, deploymentID string) ([]*structs.Allocation, error) {
+	// Get an iterator over the deployment allocations
+	iter, err := txn.Get("allocs", "deployment", deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+	return out, nil
+}
+
+// Allocs returns an iterator over all the evaluations
+func (s *StateStore) Allocs() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("allocs", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// UpsertVaultAccessors is used to register a set of Vault Accessors
+func (s *StateStore) UpsertVaultAccessor(index uint64, accessors []*structs.VaultAccessor) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, accessor := range accessors {
+		// Set the create index
+		accessor.CreateIndex = index
+
+		// Insert the accessor
+		if err := txn.Insert("vault_accessors", accessor); err != nil {
+			return fmt.Errorf("accessor insert failed: %v", err)
+		}
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"vault_accessors", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// DeleteVaultAccessors is used to delete a set of Vault Accessors
+func (s *StateStore) DeleteVaultAccessors(index uint64, accessors []*structs.VaultAccessor) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	// Lookup the accessor
+	for _, accessor := range accessors {
+		// Delete the accessor
+		if err := txn.Delete("vault_accessors", accessor); err != nil {
+			return fmt.Errorf("accessor delete failed: %v", err)
+		}
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"vault_accessors", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// VaultAccessor returns the given Vault accessor
+func (s *StateStore) VaultAccessor(accessor string) (*structs.VaultAccessor, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("vault_accessors", "id", accessor)
+	if err != nil {
+		return nil, fmt.Errorf("accessor lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.VaultAccessor), nil
+	}
+
+	return nil, nil
+}
+
+// VaultAccessors returns an iterator of Vault accessors.
+func (s *StateStore) VaultAccessors() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("vault_accessors", "id")
+	if err != nil {
+		return nil, err
 	}
-
 	return iter, nil
 }
 
-// EvalsByJob returns all the evaluations by job id
-func (s *StateStore) EvalsByJob(jobID string) ([]*structs.Evaluation, error) {
+// VaultAccessorsByAlloc returns all the Vault accessors by alloc id
+func (s *StateStore) VaultAccessorsByAlloc(allocID string) ([]*structs.VaultAccessor, error) {
 	txn := s.db.Txn(false)
 
-	// Get an iterator over the node allocations
-	iter, err := txn.Get("evals", "job", jobID)
+	// Get an iterator over the accessors
+	iter, err := txn.Get("vault_accessors", "alloc_id", allocID)
 	if err != nil {
 		return nil, err
 	}
 
-	var out []*structs.Evaluation
+	var out []*structs.VaultAccessor
 	for {
 		raw := iter.Next()
 		if raw == nil {
 			break
 		}
-		out = append(out, raw.(*structs.Evaluation))
+		out = append(out, raw.(*structs.VaultAccessor))
 	}
 	return out, nil
 }
 
-// Evals returns an iterator over all the evaluations
-func (s *StateStore) Evals() (memdb.ResultIterator, error) {
+// VaultAccessorsByNode returns all the Vault accessors by node id
+func (s *StateStore) VaultAccessorsByNode(nodeID string) ([]*structs.VaultAccessor, error) {
 	txn := s.db.Txn(false)
 
-	// Walk the entire table
-	iter, err := txn.Get("evals", "id")
+	// Get an iterator over the accessors
+	iter, err := txn.Get("vault_accessors", "node_id", nodeID)
 	if err != nil {
 		return nil, err
 	}
-	return iter, nil
+
+	var out []*structs.VaultAccessor
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.VaultAccessor))
+	}
+	return out, nil
 }
 
-// UpdateAllocsFromClient is used to update an allocation based on input
+// SchedulerConfig returns the scheduler configuration or nil if it was never
+// set.
+func (s *StateStore) SchedulerConfig() (*structs.SchedulerConfiguration, error) {
+	txn := s.db.Txn(false)
 
-// from a client. While the schedulers are the authority on the allocation for
-// most things, some updates are authoritative from the client. Specifically,
-// the desired state comes from the schedulers, while the actual state comes
-// from clients.
-func (s *StateStore) UpdateAllocsFromClient(index uint64, allocs []*structs.Allocation) error {
+	existing, err := txn.First("scheduler_config", "id", true)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler config lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.SchedulerConfiguration), nil
+	}
+
+	return nil, nil
+}
+
+// SchedulerSetConfig is used to set the scheduler configuration
+func (s *StateStore) SchedulerSetConfig(index uint64, config *structs.SchedulerConfiguration) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
-	// Setup the watcher
-	watcher := watch.NewItems()
-	watcher.Add(watch.Item{Table: "allocs"})
+	existing, err := txn.First("scheduler_config", "id", true)
+	if err != nil {
+		return fmt.Errorf("scheduler config lookup failed: %v", err)
+	}
 
-	// Handle each of the updated allocations
-	for _, alloc := range allocs {
-		if err := s.nestedUpdateAllocFromClient(txn, watcher, index, alloc); err != nil {
-			return err
-		}
+	// Set the indexes
+	if existing != nil {
+		config.CreateIndex = existing.(*structs.SchedulerConfiguration).CreateIndex
+	} else {
+		config.CreateIndex = index
 	}
+	config.ModifyIndex = index
 
-	// Update the indexes
-	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+	if err := txn.Insert("scheduler_config", config); err != nil {
+		return fmt.Errorf("scheduler config insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"scheduler_config", index}); err != nil {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
-	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
 }
 
-// nestedUpdateAllocFromClient is used to nest an update of an allocation with client status
-func (s *StateStore) nestedUpdateAllocFromClient(txn *memdb.Txn, watcher watch.Items, index uint64, alloc *structs.Allocation) error {
-	// Look for existing alloc
-	existing, err := txn.First("allocs", "id", alloc.ID)
+// AutopilotConfig returns the Autopilot configuration or nil if it was never
+// set.
+func (s *StateStore) AutopilotConfig() (*structs.AutopilotConfig, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("autopilot_config", "id", true)
 	if err != nil {
-		return fmt.Errorf("alloc lookup failed: %v", err)
+		return nil, fmt.Errorf("autopilot config lookup failed: %v", err)
 	}
 
-	// Nothing to do if this does not exist
-	if existing == nil {
-		return nil
+	if existing != nil {
+		return existing.(*structs.AutopilotConfig), nil
 	}
-	exist := existing.(*structs.Allocation)
-	// Trigger the watcher
-	watcher.Add(watch.Item{Alloc: alloc.ID})
-	watcher.Add(watch.Item{AllocEval: exist.EvalID})
-	watcher.Add(watch.Item{AllocJob: exist.JobID})
-	watcher.Add(watch.Item{AllocNode: exist.NodeID})
 
-	// Copy everything from the existing allocation
-	copyAlloc := new(structs.Allocation)
-	*copyAlloc = *exist
+	return nil, nil
+}
 
-	// Pull in anything the client is the authority on
-	copyAlloc.ClientStatus = alloc.ClientStatus
-	copyAlloc.ClientDescription = alloc.ClientDescription
-	copyAlloc.TaskStates = alloc.TaskStates
+// AutopilotSetConfig is used to set the Autopilot configuration
+func (s *StateStore) AutopilotSetConfig(index uint64, config *structs.AutopilotConfig) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
 
-	// Update the modify index
-	copyAlloc.ModifyIndex = index
+	if err := s.autopilotSetConfigTxn(index, config, txn); err != nil {
+		return err
+	}
 
-	if err := s.updateSummaryWithAlloc(index, copyAlloc, exist, watcher, txn); err != nil {
-		return fmt.Errorf("error updating job summary: %v", err)
+	txn.Commit()
+	return nil
+}
+
+// AutopilotCASConfig is used to set the Autopilot configuration only if the
+// index matches the ModifyIndex of the current configuration. A zero index
+// only sets the configuration if none exists yet. It returns whether the
+// configuration was set.
+func (s *StateStore) AutopilotCASConfig(index, cidx uint64, config *structs.AutopilotConfig) (bool, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	existing, err := txn.First("autopilot_config", "id", true)
+	if err != nil {
+		return false, fmt.Errorf("autopilot config lookup failed: %v", err)
 	}
 
-	// Update the allocation
-	if err := txn.Insert("allocs", copyAlloc); err != nil {
-		return fmt.Errorf("alloc insert failed: %v", err)
+	// The set is rejected if the configuration was modified since the given
+	// index
+	if existing == nil && cidx != 0 {
+		return false, nil
+	}
+	if existing != nil && existing.(*structs.AutopilotConfig).ModifyIndex != cidx {
+		return false, nil
 	}
 
-	// Set the job's status
-	forceStatus := ""
-	if !copyAlloc.TerminalStatus() {
-		forceStatus = structs.JobStatusRunning
+	if err := s.autopilotSetConfigTxn(index, config, txn); err != nil {
+		return false, err
 	}
-	jobs := map[string]string{exist.JobID: forceStatus}
-	if err := s.setJobStatuses(index, watcher, txn, jobs, false); err != nil {
-		return fmt.Errorf("setting job status failed: %v", err)
+
+	txn.Commit()
+	return true, nil
+}
+
+// autopilotSetConfigTxn sets the Autopilot configuration within a
+// transaction.
+func (s *StateStore) autopilotSetConfigTxn(index uint64, config *structs.AutopilotConfig, txn *memdb.Txn) error {
+	existing, err := txn.First("autopilot_config", "id", true)
+	if err != nil {
+		return fmt.Errorf("autopilot config lookup failed: %v", err)
+	}
+
+	// Set the indexes
+	if existing != nil {
+		config.CreateIndex = existing.(*structs.AutopilotConfig).CreateIndex
+	} else {
+		config.CreateIndex = index
+	}
+	config.ModifyIndex = index
+
+	if err := txn.Insert("autopilot_config", config); err != nil {
+		return fmt.Errorf("autopilot config insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"autopilot_config", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
 	}
 	return nil
 }
 
-// UpsertAllocs is used to evict a set of allocations
-// and allocate new ones at the same time.
-func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) error {
+// UpsertQuotaSpec is used to create or update a quota specification
+func (s *StateStore) UpsertQuotaSpec(index uint64, quota *structs.QuotaSpec) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
 	watcher := watch.NewItems()
-	watcher.Add(watch.Item{Table: "allocs"})
-
-	// Handle the allocations
-	jobs := make(map[string]string, 1)
-	for _, alloc := range allocs {
-		existing, err := txn.First("allocs", "id", alloc.ID)
-		if err != nil {
-			return fmt.Errorf("alloc lookup failed: %v", err)
-		}
-		exist, _ := existing.(*structs.Allocation)
-
-		if exist == nil {
-			alloc.CreateIndex = index
-			alloc.ModifyIndex = index
-			alloc.AllocModifyIndex = index
-		} else {
-			alloc.CreateIndex = exist.CreateIndex
-			alloc.ModifyIndex = index
-			alloc.AllocModifyIndex = index
+	watcher.Add(watch.Item{Table: "quota_specs"})
 
-			// If the scheduler is marking this allocation as lost we do not
-			// want to reuse the status of the existing allocation.
-			if alloc.ClientStatus != structs.AllocClientStatusLost {
-				alloc.ClientStatus = exist.ClientStatus
-				alloc.ClientDescription = exist.ClientDescription
-			}
+	existing, err := txn.First("quota_specs", "id", quota.Name)
+	if err != nil {
+		return fmt.Errorf("quota spec lookup failed: %v", err)
+	}
 
-			// The job has been denormalized so re-attach the original job
-			if alloc.Job == nil {
-				alloc.Job = exist.Job
-			}
-		}
+	// Set the indexes
+	if existing != nil {
+		quota.CreateIndex = existing.(*structs.QuotaSpec).CreateIndex
+	} else {
+		quota.CreateIndex = index
+	}
+	quota.ModifyIndex = index
 
-		if err := s.updateSummaryWithAlloc(index, alloc, exist, watcher, txn); err != nil {
-			return fmt.Errorf("error updating job summary: %v", err)
-		}
+	if err := txn.Insert("quota_specs", quota); err != nil {
+		return fmt.Errorf("quota spec insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"quota_specs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
 
-		// Create the EphemeralDisk if it's nil by adding up DiskMB from task resources.
-		// COMPAT 0.4.1 -> 0.5
-		if alloc.Job != nil {
-			s.addEphemeralDiskToTaskGroups(alloc.Job)
-		}
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
 
-		if err := txn.Insert("allocs", alloc); err != nil {
-			return fmt.Errorf("alloc insert failed: %v", err)
-		}
+// DeleteQuotaSpec is used to delete a quota specification. Quotas still
+// referenced by namespaces can not be deleted.
+func (s *StateStore) DeleteQuotaSpec(index uint64, name string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
 
-		// If the allocation is running, force the job to running status.
-		forceStatus := ""
-		if !alloc.TerminalStatus() {
-			forceStatus = structs.JobStatusRunning
-		}
-		jobs[alloc.JobID] = forceStatus
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "quota_specs"})
 
-		watcher.Add(watch.Item{Alloc: alloc.ID})
-		watcher.Add(watch.Item{AllocEval: alloc.EvalID})
-		watcher.Add(watch.Item{AllocJob: alloc.JobID})
-		watcher.Add(watch.Item{AllocNode: alloc.NodeID})
+	existing, err := txn.First("quota_specs", "id", name)
+	if err != nil {
+		return fmt.Errorf("quota spec lookup failed: %v", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("quota %q not found", name)
 	}
 
-	// Update the indexes
-	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
-		return fmt.Errorf("index update failed: %v", err)
+	// Ensure no namespace uses the quota
+	namespace, err := txn.First("namespaces", "quota", name)
+	if err != nil {
+		return fmt.Errorf("namespace lookup failed: %v", err)
+	}
+	if namespace != nil {
+		return fmt.Errorf("quota %q is used by namespace %q", name, namespace.(*structs.Namespace).Name)
 	}
 
-	// Set the job's status
-	if err := s.setJobStatuses(index, watcher, txn, jobs, false); err != nil {
-		return fmt.Errorf("setting job status failed: %v", err)
+	if err := txn.Delete("quota_specs", existing); err != nil {
+		return fmt.Errorf("quota spec delete failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"quota_specs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
 	}
 
 	txn.Defer(func() { s.watch.notify(watcher) })
@@ -999,241 +2149,316 @@ func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) er
 	return nil
 }
 
-// AllocByID is used to lookup an allocation by its ID
-func (s *StateStore) AllocByID(id string) (*structs.Allocation, error) {
+// QuotaSpecByName is used to lookup a quota specification by its name
+func (s *StateStore) QuotaSpecByName(name string) (*structs.QuotaSpec, error) {
 	txn := s.db.Txn(false)
 
-	existing, err := txn.First("allocs", "id", id)
+	existing, err := txn.First("quota_specs", "id", name)
 	if err != nil {
-		return nil, fmt.Errorf("alloc lookup failed: %v", err)
+		return nil, fmt.Errorf("quota spec lookup failed: %v", err)
 	}
 
 	if existing != nil {
-		return existing.(*structs.Allocation), nil
+		return existing.(*structs.QuotaSpec), nil
 	}
 	return nil, nil
 }
 
-// AllocsByIDPrefix is used to lookup allocs by prefix
-func (s *StateStore) AllocsByIDPrefix(id string) (memdb.ResultIterator, error) {
+// QuotaSpecsByNamePrefix is used to lookup quota specifications by prefix
+func (s *StateStore) QuotaSpecsByNamePrefix(prefix string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
 
-	iter, err := txn.Get("allocs", "id_prefix", id)
+	iter, err := txn.Get("quota_specs", "id_prefix", prefix)
 	if err != nil {
-		return nil, fmt.Errorf("alloc lookup failed: %v", err)
+		return nil, fmt.Errorf("quota spec lookup failed: %v", err)
 	}
-
 	return iter, nil
 }
 
-// AllocsByNode returns all the allocations by node
-func (s *StateStore) AllocsByNode(node string) ([]*structs.Allocation, error) {
+// QuotaSpecs returns an iterator over all the quota specifications
+func (s *StateStore) QuotaSpecs() (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
 
-	// Get an iterator over the node allocations, using only the
-	// node prefix which ignores the terminal status
-	iter, err := txn.Get("allocs", "node_prefix", node)
+	iter, err := txn.Get("quota_specs", "id")
 	if err != nil {
 		return nil, err
 	}
-
-	var out []*structs.Allocation
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
-		}
-		out = append(out, raw.(*structs.Allocation))
-	}
-	return out, nil
+	return iter, nil
 }
 
-// AllocsByNode returns all the allocations by node and terminal status
-func (s *StateStore) AllocsByNodeTerminal(node string, terminal bool) ([]*structs.Allocation, error) {
+// NamespacesByQuota returns an iterator over the namespaces using the given
+// quota
+func (s *StateStore) NamespacesByQuota(name string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
 
-	// Get an iterator over the node allocations
-	iter, err := txn.Get("allocs", "node", node, terminal)
+	iter, err := txn.Get("namespaces", "quota", name)
 	if err != nil {
 		return nil, err
 	}
+	return iter, nil
+}
 
-	var out []*structs.Allocation
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
-		}
-		out = append(out, raw.(*structs.Allocation))
+// namespaceQuota returns the name of the quota of the namespace using the
+// passed transaction.
+func namespaceQuota(txn *memdb.Txn, namespace string) (string, error) {
+	raw, err := txn.First("namespaces", "id", namespace)
+	if err != nil {
+		return "", fmt.Errorf("namespace lookup failed: %v", err)
 	}
-	return out, nil
+	if raw == nil {
+		return "", nil
+	}
+	return raw.(*structs.Namespace).Quota, nil
 }
 
-// AllocsByJob returns all the allocations by job id
-func (s *StateStore) AllocsByJob(jobID string) ([]*structs.Allocation, error) {
+// QuotaUsage returns the resources used by the non-terminal allocations of
+// the jobs of the namespaces using the given quota.
+func (s *StateStore) QuotaUsage(name string) (*structs.QuotaUsage, error) {
 	txn := s.db.Txn(false)
 
-	// Get an iterator over the node allocations
-	iter, err := txn.Get("allocs", "job", jobID)
+	namespaces, err := txn.Get("namespaces", "quota", name)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("namespace lookup failed: %v", err)
 	}
 
-	var out []*structs.Allocation
+	usage := &structs.QuotaUsage{
+		Name: name,
+		Used: new(structs.Resources),
+	}
 	for {
-		raw := iter.Next()
+		raw := namespaces.Next()
 		if raw == nil {
 			break
 		}
-		out = append(out, raw.(*structs.Allocation))
+
+		jobs, err := txn.Get("jobs", "namespace", raw.(*structs.Namespace).Name)
+		if err != nil {
+			return nil, fmt.Errorf("job lookup failed: %v", err)
+		}
+		for {
+			raw := jobs.Next()
+			if raw == nil {
+				break
+			}
+
+			job := raw.(*structs.Job)
+			allocs, err := txn.Get("allocs", "job", job.Namespace, job.ID)
+			if err != nil {
+				return nil, fmt.Errorf("alloc lookup failed: %v", err)
+			}
+			for {
+				raw := allocs.Next()
+				if raw == nil {
+					break
+				}
+				structs.AddQuotaUsage(usage.Used, raw.(*structs.Allocation))
+			}
+		}
 	}
-	return out, nil
+	return usage, nil
 }
 
-// AllocsByEval returns all the allocations by eval id
-func (s *StateStore) AllocsByEval(evalID string) ([]*structs.Allocation, error) {
-	txn := s.db.Txn(false)
-
-	// Get an iterator over the eval allocations
-	iter, err := txn.Get("allocs", "eval", evalID)
+// QuotaUsageAfterPlan returns the resources used by the non-terminal
+// allocations of the jobs of the namespaces using the given quota once the
+// plan is applied. The existing allocations stopped or updated by the plan are
+// replaced by the plan's allocations, which are assumed to use the quota.
+func (s *StateStore) QuotaUsageAfterPlan(name string, plan *structs.Plan) (*structs.Resources, error) {
+	usage, err := s.QuotaUsage(name)
 	if err != nil {
 		return nil, err
 	}
+	used := usage.Used
 
-	var out []*structs.Allocation
-	for {
-		raw := iter.Next()
+	txn := s.db.Txn(false)
+	remove := func(alloc *structs.Allocation) error {
+		raw, err := txn.First("allocs", "id", alloc.ID)
+		if err != nil {
+			return fmt.Errorf("alloc lookup failed: %v", err)
+		}
 		if raw == nil {
-			break
+			return nil
 		}
-		out = append(out, raw.(*structs.Allocation))
-	}
-	return out, nil
-}
+		existing := raw.(*structs.Allocation)
 
-// Allocs returns an iterator over all the evaluations
-func (s *StateStore) Allocs() (memdb.ResultIterator, error) {
-	txn := s.db.Txn(false)
+		job, err := txn.First("jobs", "id", existing.Namespace, existing.JobID)
+		if err != nil {
+			return fmt.Errorf("job lookup failed: %v", err)
+		}
+		if job == nil {
+			return nil
+		}
+		quota, err := namespaceQuota(txn, job.(*structs.Job).Namespace)
+		if err != nil {
+			return err
+		}
+		if quota != name {
+			return nil
+		}
 
-	// Walk the entire table
-	iter, err := txn.Get("allocs", "id")
-	if err != nil {
-		return nil, err
+		removed := new(structs.Resources)
+		structs.AddQuotaUsage(removed, existing)
+		used.CPU -= removed.CPU
+		used.MemoryMB -= removed.MemoryMB
+		used.DiskMB -= removed.DiskMB
+		return nil
 	}
-	return iter, nil
+
+	for _, allocs := range plan.NodeUpdate {
+		for _, alloc := range allocs {
+			if err := remove(alloc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, allocs := range plan.NodeAllocation {
+		for _, alloc := range allocs {
+			if err := remove(alloc); err != nil {
+				return nil, err
+			}
+			structs.AddQuotaUsage(used, alloc)
+		}
+	}
+	return used, nil
 }
 
-// UpsertVaultAccessors is used to register a set of Vault Accessors
-func (s *StateStore) UpsertVaultAccessor(index uint64, accessors []*structs.VaultAccessor) error {
+// UpsertNamespaces is used to create or update namespaces
+func (s *StateStore) UpsertNamespaces(index uint64, namespaces []*structs.Namespace) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
-	for _, accessor := range accessors {
-		// Set the create index
-		accessor.CreateIndex = index
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "namespaces"})
 
-		// Insert the accessor
-		if err := txn.Insert("vault_accessors", accessor); err != nil {
-			return fmt.Errorf("accessor insert failed: %v", err)
+	for _, namespace := range namespaces {
+		existing, err := txn.First("namespaces", "id", namespace.Name)
+		if err != nil {
+			return fmt.Errorf("namespace lookup failed: %v", err)
+		}
+
+		// Ensure the quota of the namespace exists
+		if namespace.Quota != "" {
+			quota, err := txn.First("quota_specs", "id", namespace.Quota)
+			if err != nil {
+				return fmt.Errorf("quota spec lookup failed: %v", err)
+			}
+			if quota == nil {
+				return fmt.Errorf("namespace quota %q does not exist", namespace.Quota)
+			}
+		}
+
+		// Set the indexes
+		if existing != nil {
+			namespace.CreateIndex = existing.(*structs.Namespace).CreateIndex
+		} else {
+			namespace.CreateIndex = index
+		}
+		namespace.ModifyIndex = index
+
+		if err := txn.Insert("namespaces", namespace); err != nil {
+			return fmt.Errorf("namespace insert failed: %v", err)
 		}
 	}
 
-	if err := txn.Insert("index", &IndexEntry{"vault_accessors", index}); err != nil {
+	if err := txn.Insert("index", &IndexEntry{"namespaces", index}); err != nil {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
 }
 
-// DeleteVaultAccessors is used to delete a set of Vault Accessors
-func (s *StateStore) DeleteVaultAccessors(index uint64, accessors []*structs.VaultAccessor) error {
+// DeleteNamespaces is used to delete namespaces. The default namespace and
+// namespaces still holding jobs can not be deleted.
+func (s *StateStore) DeleteNamespaces(index uint64, names []string) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
-	// Lookup the accessor
-	for _, accessor := range accessors {
-		// Delete the accessor
-		if err := txn.Delete("vault_accessors", accessor); err != nil {
-			return fmt.Errorf("accessor delete failed: %v", err)
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "namespaces"})
+
+	for _, name := range names {
+		if name == structs.DefaultNamespace {
+			return fmt.Errorf("default namespace can not be deleted")
+		}
+
+		existing, err := txn.First("namespaces", "id", name)
+		if err != nil {
+			return fmt.Errorf("namespace lookup failed: %v", err)
+		}
+		if existing == nil {
+			return fmt.Errorf("namespace %q not found", name)
+		}
+
+		// Ensure the namespace holds no job
+		job, err := txn.First("jobs", "namespace", name)
+		if err != nil {
+			return fmt.Errorf("job lookup failed: %v", err)
+		}
+		if job != nil {
+			return fmt.Errorf("namespace %q has job %q", name, job.(*structs.Job).ID)
+		}
+
+		if err := txn.Delete("namespaces", existing); err != nil {
+			return fmt.Errorf("namespace delete failed: %v", err)
 		}
 	}
 
-	if err := txn.Insert("index", &IndexEntry{"vault_accessors", index}); err != nil {
+	if err := txn.Insert("index", &IndexEntry{"namespaces", index}); err != nil {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
 }
 
-// VaultAccessor returns the given Vault accessor
-func (s *StateStore) VaultAccessor(accessor string) (*structs.VaultAccessor, error) {
+// NamespaceByName is used to lookup a namespace by its name
+func (s *StateStore) NamespaceByName(name string) (*structs.Namespace, error) {
 	txn := s.db.Txn(false)
 
-	existing, err := txn.First("vault_accessors", "id", accessor)
+	existing, err := txn.First("namespaces", "id", name)
 	if err != nil {
-		return nil, fmt.Errorf("accessor lookup failed: %v", err)
+		return nil, fmt.Errorf("namespace lookup failed: %v", err)
 	}
 
 	if existing != nil {
-		return existing.(*structs.VaultAccessor), nil
+		return existing.(*structs.Namespace), nil
 	}
-
 	return nil, nil
 }
 
-// VaultAccessors returns an iterator of Vault accessors.
-func (s *StateStore) VaultAccessors() (memdb.ResultIterator, error) {
+// NamespacesByNamePrefix is used to lookup namespaces by prefix
+func (s *StateStore) NamespacesByNamePrefix(prefix string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
 
-	iter, err := txn.Get("vault_accessors", "id")
+	iter, err := txn.Get("namespaces", "id_prefix", prefix)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("namespace lookup failed: %v", err)
 	}
 	return iter, nil
 }
 
-// VaultAccessorsByAlloc returns all the Vault accessors by alloc id
-func (s *StateStore) VaultAccessorsByAlloc(allocID string) ([]*structs.VaultAccessor, error) {
+// Namespaces returns an iterator over all the namespaces
+func (s *StateStore) Namespaces() (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
 
-	// Get an iterator over the accessors
-	iter, err := txn.Get("vault_accessors", "alloc_id", allocID)
+	iter, err := txn.Get("namespaces", "id")
 	if err != nil {
 		return nil, err
 	}
-
-	var out []*structs.VaultAccessor
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
-		}
-		out = append(out, raw.(*structs.VaultAccessor))
-	}
-	return out, nil
+	return iter, nil
 }
 
-// VaultAccessorsByNode returns all the Vault accessors by node id
-func (s *StateStore) VaultAccessorsByNode(nodeID string) ([]*structs.VaultAccessor, error) {
+// JobsByNamespace returns an iterator over the jobs of the given namespace
+func (s *StateStore) JobsByNamespace(namespace string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
 
-	// Get an iterator over the accessors
-	iter, err := txn.Get("vault_accessors", "node_id", nodeID)
+	iter, err := txn.Get("jobs", "namespace", namespace)
 	if err != nil {
 		return nil, err
 	}
-
-	var out []*structs.VaultAccessor
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
-		}
-		out = append(out, raw.(*structs.VaultAccessor))
-	}
-	return out, nil
+	return iter, nil
 }
 
 // LastIndex returns the greatest index value for all indexes
@@ -1322,15 +2547,16 @@ func (s *StateStore) ReconcileJobSummaries(index uint64) error {
 
 		// Create a job summary for the job
 		summary := structs.JobSummary{
-			JobID:   job.ID,
-			Summary: make(map[string]structs.TaskGroupSummary),
+			JobID:     job.ID,
+			Namespace: job.Namespace,
+			Summary:   make(map[string]structs.TaskGroupSummary),
 		}
 		for _, tg := range job.TaskGroups {
 			summary.Summary[tg.Name] = structs.TaskGroupSummary{}
 		}
 
 		// Find all the allocations for the jobs
-		iterAllocs, err := txn.Get("allocs", "job", job.ID)
+		iterAllocs, err := txn.Get("allocs", "job", job.Namespace, job.ID)
 		if err != nil {
 			return err
 		}
@@ -1387,12 +2613,12 @@ func (s *StateStore) ReconcileJobSummaries(index uint64) error {
 }
 
 // setJobStatuses is a helper for calling setJobStatus on multiple jobs by ID.
-// It takes a map of job IDs to an optional forceStatus string. It returns an
-// error if the job doesn't exist or setJobStatus fails.
+// It takes a map of namespaced job IDs to an optional forceStatus string. It
+// returns an error if the job doesn't exist or setJobStatus fails.
 func (s *StateStore) setJobStatuses(index uint64, watcher watch.Items, txn *memdb.Txn,
-	jobs map[string]string, evalDelete bool) error {
+	jobs map[structs.NamespacedID]string, evalDelete bool) error {
 	for job, forceStatus := range jobs {
-		existing, err := txn.First("jobs", "id", job)
+		existing, err := txn.First("jobs", "id", job.Namespace, job.ID)
 		if err != nil {
 			return fmt.Errorf("job lookup failed: %v", err)
 		}
@@ -1455,7 +2681,7 @@ func (s *StateStore) setJobStatus(index uint64, watcher watch.Items, txn *memdb.
 }
 
 func (s *StateStore) getJobStatus(txn *memdb.Txn, job *structs.Job, evalDelete bool) (string, error) {
-	allocs, err := txn.Get("allocs", "job", job.ID)
+	allocs, err := txn.Get("allocs", "job", job.Namespace, job.ID)
 	if err != nil {
 		return "", err
 	}
@@ -1469,7 +2695,7 @@ func (s *StateStore) getJobStatus(txn *memdb.Txn, job *structs.Job, evalDelete b
 		}
 	}
 
-	evals, err := txn.Get("evals", "job", job.ID)
+	evals, err := txn.Get("evals", "job", job.Namespace, job.ID)
 	if err != nil {
 		return "", err
 	}
@@ -1489,9 +2715,9 @@ func (s *StateStore) getJobStatus(txn *memdb.Txn, job *structs.Job, evalDelete b
 	}
 
 	// If there are no allocations or evaluations it is a new job. If the job is
-	// periodic, we mark it as running as it will never have an
-	// allocation/evaluation against it.
-	if job.IsPeriodic() {
+	// periodic or parameterized, we mark it as running as it will never have
+	// an allocation/evaluation against it.
+	if job.IsPeriodic() || job.IsParameterized() {
 		return structs.JobStatusRunning, nil
 	}
 	return structs.JobStatusPending, nil
@@ -1502,7 +2728,7 @@ func (s *StateStore) getJobStatus(txn *memdb.Txn, job *structs.Job, evalDelete b
 func (s *StateStore) updateSummaryWithJob(index uint64, job *structs.Job,
 	watcher watch.Items, txn *memdb.Txn) error {
 
-	existing, err := s.JobSummaryByID(job.ID)
+	existing, err := s.JobSummaryByID(job.Namespace, job.ID)
 	if err != nil {
 		return fmt.Errorf("unable to retrieve summary for job: %v", err)
 	}
@@ -1510,6 +2736,7 @@ func (s *StateStore) updateSummaryWithJob(index uint64, job *structs.Job,
 	if existing == nil {
 		existing = &structs.JobSummary{
 			JobID:       job.ID,
+			Namespace:   job.Namespace,
 			Summary:     make(map[string]structs.TaskGroupSummary),
 			CreateIndex: index,
 		}
@@ -1557,13 +2784,13 @@ func (s *StateStore) updateSummaryWithAlloc(index uint64, alloc *structs.Allocat
 		return nil
 	}
 
-	summaryRaw, err := txn.First("job_summary", "id", alloc.JobID)
+	summaryRaw, err := txn.First("job_summary", "id", alloc.Namespace, alloc.JobID)
 	if err != nil {
 		return fmt.Errorf("unable to lookup job summary for job id %q: %v", err)
 	}
 	if summaryRaw == nil {
 		// Check if the job is de-registered
-		rawJob, err := txn.First("jobs", "id", alloc.JobID)
+		rawJob, err := txn.First("jobs", "id", alloc.Namespace, alloc.JobID)
 		if err != nil {
 			return fmt.Errorf("unable to query job: %v", err)
 		}
@@ -1656,6 +2883,69 @@ func (s *StateStore) updateSummaryWithAlloc(index uint64, alloc *structs.Allocat
 	return nil
 }
 
+// updateDeploymentWithAlloc updates the progress of the deployment that
+// placed an allocation given the existing version of the allocation.
+func (s *StateStore) updateDeploymentWithAlloc(index uint64, alloc, existing *structs.Allocation,
+	watcher watch.Items, txn *memdb.Txn) error {
+
+	if alloc.DeploymentID == "" {
+		return nil
+	}
+
+	raw, err := txn.First("deployment", "id", alloc.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("deployment lookup failed: %v", err)
+	}
+
+	// The deployment is removed along with its job
+	if raw == nil {
+		return nil
+	}
+
+	var placed, canaries, healthy, unhealthy int
+	if existing == nil {
+		placed++
+		if alloc.DeploymentStatus.IsCanary() {
+			canaries++
+		}
+	}
+
+	var prev *structs.AllocDeploymentStatus
+	if existing != nil {
+		prev = existing.DeploymentStatus
+	}
+	if !prev.IsHealthy() && alloc.DeploymentStatus.IsHealthy() {
+		healthy++
+	} else if prev.IsHealthy() && !alloc.DeploymentStatus.IsHealthy() {
+		healthy--
+	}
+	if !prev.IsUnhealthy() && alloc.DeploymentStatus.IsUnhealthy() {
+		unhealthy++
+	} else if prev.IsUnhealthy() && !alloc.DeploymentStatus.IsUnhealthy() {
+		unhealthy--
+	}
+
+	if placed == 0 && healthy == 0 && unhealthy == 0 {
+		return nil
+	}
+
+	deployment := raw.(*structs.Deployment).Copy()
+	if deployment.TaskGroups == nil {
+		deployment.TaskGroups = make(map[string]*structs.DeploymentState)
+	}
+	state, ok := deployment.TaskGroups[alloc.TaskGroup]
+	if !ok {
+		state = &structs.DeploymentState{}
+		deployment.TaskGroups[alloc.TaskGroup] = state
+	}
+	state.PlacedAllocs += placed
+	state.PlacedCanaries += canaries
+	state.HealthyAllocs += healthy
+	state.UnhealthyAllocs += unhealthy
+
+	return s.upsertDeploymentImpl(index, deployment, watcher, txn)
+}
+
 // addEphemeralDiskToTaskGroups adds missing EphemeralDisk objects to TaskGroups
 func (s *StateStore) addEphemeralDiskToTaskGroups(job *structs.Job) {
 	for _, tg := range job.TaskGroups {
@@ -1719,16 +3009,47 @@ func (r *StateRestore) JobRestore(job *structs.Job) error {
 	// COMPAT 0.4.1 -> 0.5
 	r.addEphemeralDiskToTaskGroups(job)
 
+	// Jobs registered before namespaces belong to the default namespace
+	if job.Namespace == "" {
+		job.Namespace = structs.DefaultNamespace
+	}
+
 	if err := r.txn.Insert("jobs", job); err != nil {
 		return fmt.Errorf("job insert failed: %v", err)
 	}
 	return nil
 }
 
+// JobVersionRestore is used to restore a tracked version of a job
+func (r *StateRestore) JobVersionRestore(job *structs.Job) error {
+	r.items.Add(watch.Item{Job: job.ID})
+
+	// Create the EphemeralDisk if it's nil by adding up DiskMB from task resources.
+	// COMPAT 0.4.1 -> 0.5
+	r.addEphemeralDiskToTaskGroups(job)
+
+	// Jobs registered before namespaces belong to the default namespace
+	if job.Namespace == "" {
+		job.Namespace = structs.DefaultNamespace
+	}
+
+	if err := r.txn.Insert("job_version", job); err != nil {
+		return fmt.Errorf("job version insert failed: %v", err)
+	}
+	return nil
+}
+
 // EvalRestore is used to restore an evaluation
 func (r *StateRestore) EvalRestore(eval *structs.Evaluation) error {
 	r.items.Add(watch.Item{Table: "evals"})
 	r.items.Add(watch.Item{Eval: eval.ID})
+	r.items.Add(watch.Item{EvalJob: eval.JobID})
+
+	// Evaluations created before namespaces belong to the default namespace
+	if eval.Namespace == "" {
+		eval.Namespace = structs.DefaultNamespace
+	}
+
 	if err := r.txn.Insert("evals", eval); err != nil {
 		return fmt.Errorf("eval insert failed: %v", err)
 	}
@@ -1756,12 +3077,33 @@ func (r *StateRestore) AllocRestore(alloc *structs.Allocation) error {
 		r.addEphemeralDiskToTaskGroups(alloc.Job)
 	}
 
+	// Allocations created before namespaces belong to the default namespace
+	if alloc.Namespace == "" {
+		alloc.Namespace = structs.DefaultNamespace
+	}
+
 	if err := r.txn.Insert("allocs", alloc); err != nil {
 		return fmt.Errorf("alloc insert failed: %v", err)
 	}
 	return nil
 }
 
+// DeploymentRestore is used to restore a deployment
+func (r *StateRestore) DeploymentRestore(deployment *structs.Deployment) error {
+	r.items.Add(watch.Item{Table: "deployment"})
+	r.items.Add(watch.Item{Deployment: deployment.ID})
+
+	// Deployments created before namespaces belong to the default namespace
+	if deployment.Namespace == "" {
+		deployment.Namespace = structs.DefaultNamespace
+	}
+
+	if err := r.txn.Insert("deployment", deployment); err != nil {
+		return fmt.Errorf("deployment insert failed: %v", err)
+	}
+	return nil
+}
+
 // IndexRestore is used to restore an index
 func (r *StateRestore) IndexRestore(idx *IndexEntry) error {
 	if err := r.txn.Insert("index", idx); err != nil {
@@ -1774,6 +3116,12 @@ func (r *StateRestore) IndexRestore(idx *IndexEntry) error {
 func (r *StateRestore) PeriodicLaunchRestore(launch *structs.PeriodicLaunch) error {
 	r.items.Add(watch.Item{Table: "periodic_launch"})
 	r.items.Add(watch.Item{Job: launch.ID})
+
+	// Launches recorded before namespaces belong to the default namespace
+	if launch.Namespace == "" {
+		launch.Namespace = structs.DefaultNamespace
+	}
+
 	if err := r.txn.Insert("periodic_launch", launch); err != nil {
 		return fmt.Errorf("periodic launch insert failed: %v", err)
 	}
@@ -1782,6 +3130,11 @@ func (r *StateRestore) PeriodicLaunchRestore(launch *structs.PeriodicLaunch) err
 
 // JobSummaryRestore is used to restore a job summary
 func (r *StateRestore) JobSummaryRestore(jobSummary *structs.JobSummary) error {
+	// Summaries created before namespaces belong to the default namespace
+	if jobSummary.Namespace == "" {
+		jobSummary.Namespace = structs.DefaultNamespace
+	}
+
 	if err := r.txn.Insert("job_summary", *jobSummary); err != nil {
 		return fmt.Errorf("job summary insert failed: %v", err)
 	}
@@ -1796,6 +3149,40 @@ func (r *StateRestore) VaultAccessorRestore(accessor *structs.VaultAccessor) err
 	return nil
 }
 
+// AutopilotConfigRestore is used to restore the Autopilot configuration
+func (r *StateRestore) AutopilotConfigRestore(config *structs.AutopilotConfig) error {
+	if err := r.txn.Insert("autopilot_config", config); err != nil {
+		return fmt.Errorf("autopilot config insert failed: %v", err)
+	}
+	return nil
+}
+
+// SchedulerConfigRestore is used to restore the scheduler configuration
+func (r *StateRestore) SchedulerConfigRestore(config *structs.SchedulerConfiguration) error {
+	if err := r.txn.Insert("scheduler_config", config); err != nil {
+		return fmt.Errorf("scheduler config insert failed: %v", err)
+	}
+	return nil
+}
+
+// QuotaSpecRestore is used to restore a quota specification
+func (r *StateRestore) QuotaSpecRestore(quota *structs.QuotaSpec) error {
+	r.items.Add(watch.Item{Table: "quota_specs"})
+	if err := r.txn.Insert("quota_specs", quota); err != nil {
+		return fmt.Errorf("quota spec insert failed: %v", err)
+	}
+	return nil
+}
+
+// NamespaceRestore is used to restore a namespace
+func (r *StateRestore) NamespaceRestore(namespace *structs.Namespace) error {
+	r.items.Add(watch.Item{Table: "namespaces"})
+	if err := r.txn.Insert("namespaces", namespace); err != nil {
+		return fmt.Errorf("namespace insert failed: %v", err)
+	}
+	return nil
+}
+
 // addEphemeralDiskToTaskGroups adds missing EphemeralDisk objects to TaskGroups
 func (r *StateRestore) addEphemeralDiskToTaskGroups(job *structs.Job) {
 	for _, tg := range job.TaskGroups {
@@ -153,7 +153,8 @@ func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	err = state.UpdateNodeDrain(1001, node.ID, true)
+	strategy := &structs.DrainStrategy{Deadline: time.Hour}
+	err = state.UpdateNodeDrain(1001, node.ID, true, strategy)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -163,7 +164,7 @@ func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	if !out.Drain {
+	if !out.Drain || !reflect.DeepEqual(out.DrainStrategy, strategy) {
 		t.Fatalf("bad: %#v", out)
 	}
 	if out.ModifyIndex != 1001 {
@@ -334,7 +335,7 @@ func TestStateStore_UpsertJob_Job(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -351,7 +352,7 @@ func TestStateStore_UpsertJob_Job(t *testing.T) {
 		t.Fatalf("bad: %d", index)
 	}
 
-	summary, err := state.JobSummaryByID(job.ID)
+	summary, err := state.JobSummaryByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -389,7 +390,7 @@ func TestStateStore_UpdateUpsertJob_Job(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -415,7 +416,7 @@ func TestStateStore_UpdateUpsertJob_Job(t *testing.T) {
 
 	// Test that the job summary remains the same if the job is updated but
 	// count remains same
-	summary, err := state.JobSummaryByID(job.ID)
+	summary, err := state.JobSummaryByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -449,7 +450,7 @@ func TestStateStore_UpsertJob_NoEphemeralDisk(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -481,12 +482,12 @@ func TestStateStore_DeleteJob_Job(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	err = state.DeleteJob(1001, job.ID)
+	err = state.DeleteJob(1001, job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -503,7 +504,7 @@ func TestStateStore_DeleteJob_Job(t *testing.T) {
 		t.Fatalf("bad: %d", index)
 	}
 
-	summary, err := state.JobSummaryByID(job.ID)
+	summary, err := state.JobSummaryByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -514,6 +515,89 @@ func TestStateStore_DeleteJob_Job(t *testing.T) {
 	notify.verify(t)
 }
 
+func TestStateStore_UpsertJob_Versions(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+
+	// Register more versions of the job than are tracked
+	total := structs.JobTrackedVersions + 2
+	for i := 0; i < total; i++ {
+		next := job.Copy()
+		next.Priority = i + 1
+		if err := state.UpsertJob(uint64(1000+i), next); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if next.Version != uint64(i) {
+			t.Fatalf("bad version: got %d; want %d", next.Version, i)
+		}
+	}
+
+	// Register a job whose ID is prefixed by the first job
+	other := mock.Job()
+	other.ID = job.ID + "-other"
+	if err := state.UpsertJob(2000, other); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	versions, err := state.JobVersionsByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(versions) != structs.JobTrackedVersions {
+		t.Fatalf("got %d versions; want %d", len(versions), structs.JobTrackedVersions)
+	}
+	for i, v := range versions {
+		want := uint64(total - 1 - i)
+		if v.ID != job.ID || v.Version != want || v.Priority != int(want)+1 {
+			t.Fatalf("bad version %d: %#v", i, v)
+		}
+	}
+
+	out, err := state.JobByIDAndVersion(job.Namespace, job.ID, uint64(total-1))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(versions[0], out) {
+		t.Fatalf("bad: %#v %#v", versions[0], out)
+	}
+
+	// The oldest versions are no longer tracked
+	out, err = state.JobByIDAndVersion(job.Namespace, job.ID, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("expected untracked version, got: %#v", out)
+	}
+
+	// Deleting the job removes all its versions
+	if err := state.DeleteJob(3000, job.Namespace, job.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	versions, err = state.JobVersionsByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(versions) != 0 {
+		t.Fatalf("expected no versions, got: %#v", versions)
+	}
+	versions, err = state.JobVersionsByID(other.Namespace, other.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("bad: %#v", versions)
+	}
+
+	index, err := state.Index("job_version")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 3000 {
+		t.Fatalf("bad: %d", index)
+	}
+}
+
 func TestStateStore_Jobs(t *testing.T) {
 	state := testStateStore(t)
 	var jobs []*structs.Job
@@ -560,7 +644,7 @@ func TestStateStore_JobsByIDPrefix(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	iter, err := state.JobsByIDPrefix(job.ID)
+	iter, err := state.JobsByIDPrefix(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -582,7 +666,7 @@ func TestStateStore_JobsByIDPrefix(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	iter, err = state.JobsByIDPrefix("re")
+	iter, err = state.JobsByIDPrefix(structs.DefaultNamespace, "re")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -599,7 +683,7 @@ func TestStateStore_JobsByIDPrefix(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	iter, err = state.JobsByIDPrefix("r")
+	iter, err = state.JobsByIDPrefix(structs.DefaultNamespace, "r")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -609,7 +693,7 @@ func TestStateStore_JobsByIDPrefix(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	iter, err = state.JobsByIDPrefix("ri")
+	iter, err = state.JobsByIDPrefix(structs.DefaultNamespace, "ri")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -835,7 +919,7 @@ func TestStateStore_RestoreJob(t *testing.T) {
 	}
 	restore.Commit()
 
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -874,7 +958,7 @@ func TestStateStore_Jobs_NoEphemeralDisk(t *testing.T) {
 	}
 	restore.Commit()
 
-	out, err := state.JobByID(job.ID)
+	out, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -907,7 +991,7 @@ func TestStateStore_UpsertPeriodicLaunch(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.PeriodicLaunchByID(job.ID)
+	out, err := state.PeriodicLaunchByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -957,7 +1041,7 @@ func TestStateStore_UpdateUpsertPeriodicLaunch(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.PeriodicLaunchByID(job.ID)
+	out, err := state.PeriodicLaunchByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -998,12 +1082,12 @@ func TestStateStore_DeletePeriodicLaunch(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	err = state.DeletePeriodicLaunch(1001, job.ID)
+	err = state.DeletePeriodicLaunch(1001, job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.PeriodicLaunchByID(job.ID)
+	out, err := state.PeriodicLaunchByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -1096,7 +1180,7 @@ func TestStateStore_RestorePeriodicLaunch(t *testing.T) {
 	}
 	restore.Commit()
 
-	out, err := state.PeriodicLaunchByID(job.ID)
+	out, err := state.PeriodicLaunchByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -1108,1130 +1192,1194 @@ func TestStateStore_RestorePeriodicLaunch(t *testing.T) {
 	notify.verify(t)
 }
 
-func TestStateStore_RestoreJobSummary(t *testing.T) {
+func TestStateStore_UpsertDeployment(t *testing.T) {
 	state := testStateStore(t)
 	job := mock.Job()
-	jobSummary := &structs.JobSummary{
-		JobID: job.ID,
-		Summary: map[string]structs.TaskGroupSummary{
-			"web": structs.TaskGroupSummary{
-				Starting: 10,
-			},
-		},
+	deployment := structs.NewDeployment(job)
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: deployment.ID})
+
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
 	}
-	restore, err := state.Restore()
+
+	out, err := state.DeploymentByID(deployment.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if !reflect.DeepEqual(deployment, out) {
+		t.Fatalf("bad: %#v %#v", deployment, out)
+	}
 
-	err = restore.JobSummaryRestore(jobSummary)
+	latest, err := state.LatestDeploymentByJobID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	restore.Commit()
+	if !reflect.DeepEqual(deployment, latest) {
+		t.Fatalf("bad: %#v %#v", deployment, latest)
+	}
 
-	out, err := state.JobSummaryByID(job.ID)
+	index, err := state.Index("deployment")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if !reflect.DeepEqual(out, jobSummary) {
-		t.Fatalf("Bad: %#v %#v", out, jobSummary)
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
 	}
+
+	notify.verify(t)
 }
 
-func TestStateStore_Indexes(t *testing.T) {
+func TestStateStore_UpsertDeployment_Prune(t *testing.T) {
 	state := testStateStore(t)
-	node := mock.Node()
+	job := mock.Job()
 
-	err := state.UpsertNode(1000, node)
-	if err != nil {
-		t.Fatalf("err: %v", err)
+	// Create more completed deployments than are tracked and a running one
+	var deployments []*structs.Deployment
+	for i := 0; i < structs.JobTrackedDeployments+2; i++ {
+		deployment := structs.NewDeployment(job)
+		deployment.Status = structs.DeploymentStatusSuccessful
+		deployments = append(deployments, deployment)
 	}
+	running := structs.NewDeployment(job)
+	deployments = append(deployments, running)
 
-	iter, err := state.Indexes()
+	for i, deployment := range deployments {
+		if err := state.UpsertDeployment(uint64(1000+i), deployment); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	out, err := state.DeploymentsByJobID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if len(out) != structs.JobTrackedDeployments {
+		t.Fatalf("got %d deployments; want %d", len(out), structs.JobTrackedDeployments)
+	}
 
-	var out []*IndexEntry
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
+	// The oldest completed deployments are removed first
+	for _, deployment := range deployments[:3] {
+		d, err := state.DeploymentByID(deployment.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if d != nil {
+			t.Fatalf("deployment not pruned: %#v", d)
 		}
-		out = append(out, raw.(*IndexEntry))
 	}
-
-	expect := []*IndexEntry{
-		&IndexEntry{"nodes", 1000},
+	d, err := state.DeploymentByID(running.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-
-	if !reflect.DeepEqual(expect, out) {
-		t.Fatalf("bad: %#v %#v", expect, out)
+	if d == nil {
+		t.Fatalf("running deployment pruned")
 	}
 }
 
-func TestStateStore_LatestIndex(t *testing.T) {
+func TestStateStore_UpdateDeploymentStatus_Stable(t *testing.T) {
 	state := testStateStore(t)
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	if err := state.UpsertNode(1000, mock.Node()); err != nil {
+	deployment := structs.NewDeployment(job)
+	if err := state.UpsertDeployment(1001, deployment); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	exp := uint64(2000)
-	if err := state.UpsertJob(exp, mock.Job()); err != nil {
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: deployment.ID},
+		watch.Item{Job: job.ID})
+
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      deployment.ID,
+			Status:            structs.DeploymentStatusSuccessful,
+			StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+		},
+	}
+	if err := state.UpdateDeploymentStatus(1002, req); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	latest, err := state.LatestIndex()
+	out, err := state.DeploymentByID(deployment.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if latest != exp {
-		t.Fatalf("LatestIndex() returned %d; want %d", latest, exp)
+	if out.Status != structs.DeploymentStatusSuccessful || out.ModifyIndex != 1002 {
+		t.Fatalf("bad: %#v", out)
 	}
-}
 
-func TestStateStore_RestoreIndex(t *testing.T) {
-	state := testStateStore(t)
-
-	restore, err := state.Restore()
+	// The deployed version of the job is now stable
+	current, err := state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	index := &IndexEntry{"jobs", 1000}
-	err = restore.IndexRestore(index)
+	if !current.Stable {
+		t.Fatalf("job not stable: %#v", current)
+	}
+	version, err := state.JobByIDAndVersion(job.Namespace, job.ID, job.Version)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if !version.Stable {
+		t.Fatalf("job version not stable: %#v", version)
+	}
 
-	restore.Commit()
-
-	out, err := state.Index("jobs")
+	// Registering a new version isn't stable
+	if err := state.UpsertJob(1003, job.Copy()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	current, err = state.JobByID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if out != 1000 {
-		t.Fatalf("Bad: %#v %#v", out, 1000)
+	if current.Stable {
+		t.Fatalf("new version stable: %#v", current)
 	}
+
+	notify.verify(t)
 }
 
-func TestStateStore_UpsertEvals_Eval(t *testing.T) {
+func TestStateStore_UpsertPlanResults_Deployment(t *testing.T) {
 	state := testStateStore(t)
-	eval := mock.Eval()
+	job := mock.Job()
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	notify := setupNotifyTest(
-		state,
-		watch.Item{Table: "evals"},
-		watch.Item{Eval: eval.ID})
+	// Create a running deployment of a prior version
+	prior := structs.NewDeployment(job)
+	if err := state.UpsertDeployment(1000, prior); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	err := state.UpsertEvals(1000, []*structs.Evaluation{eval})
-	if err != nil {
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.DeploymentID = deployment.ID
+	alloc2 := mock.Alloc()
+	alloc2.Job = job
+	alloc2.JobID = job.ID
+	alloc2.DeploymentID = deployment.ID
+
+	req := &structs.AllocUpdateRequest{
+		Alloc:      []*structs.Allocation{alloc, alloc2},
+		Deployment: deployment,
+		DeploymentUpdates: []*structs.DeploymentStatusUpdate{
+			{
+				DeploymentID:      prior.ID,
+				Status:            structs.DeploymentStatusCancelled,
+				StatusDescription: structs.DeploymentStatusDescriptionNewerJob,
+			},
+		},
+	}
+	if err := state.UpsertPlanResults(1001, req); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.EvalByID(eval.ID)
+	out, err := state.DeploymentByID(prior.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if !reflect.DeepEqual(eval, out) {
-		t.Fatalf("bad: %#v %#v", eval, out)
+	if out.Status != structs.DeploymentStatusCancelled {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	index, err := state.Index("evals")
+	out, err = state.DeploymentByID(deployment.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1000 {
-		t.Fatalf("bad: %d", index)
+	if s := out.TaskGroups["web"]; s.PlacedAllocs != 2 || s.HealthyAllocs != 0 {
+		t.Fatalf("bad: %#v", s)
 	}
 
-	notify.verify(t)
-}
-
-func TestStateStore_Update_UpsertEvals_Eval(t *testing.T) {
-	state := testStateStore(t)
-	eval := mock.Eval()
-
-	err := state.UpsertEvals(1000, []*structs.Evaluation{eval})
+	allocs, err := state.AllocsByDeployment(deployment.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if len(allocs) != 2 {
+		t.Fatalf("bad: %#v", allocs)
+	}
 
-	notify := setupNotifyTest(
-		state,
-		watch.Item{Table: "evals"},
-		watch.Item{Eval: eval.ID})
-
-	eval2 := mock.Eval()
-	eval2.ID = eval.ID
-	err = state.UpsertEvals(1001, []*structs.Evaluation{eval2})
-	if err != nil {
+	// The client reports the health of the allocations
+	healthy, unhealthy := true, false
+	update := alloc.Copy()
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+	update2 := alloc2.Copy()
+	update2.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &unhealthy}
+	if err := state.UpdateAllocsFromClient(1002, []*structs.Allocation{update, update2}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.EvalByID(eval.ID)
+	out, err = state.DeploymentByID(deployment.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if !reflect.DeepEqual(eval2, out) {
-		t.Fatalf("bad: %#v %#v", eval2, out)
+	if s := out.TaskGroups["web"]; s.PlacedAllocs != 2 || s.HealthyAllocs != 1 || s.UnhealthyAllocs != 1 {
+		t.Fatalf("bad: %#v", s)
 	}
 
-	if out.CreateIndex != 1000 {
-		t.Fatalf("bad: %#v", out)
+	// Updating the allocations from a plan keeps their health
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc.Copy()}); err != nil {
+		t.Fatalf("err: %v", err)
 	}
-	if out.ModifyIndex != 1001 {
-		t.Fatalf("bad: %#v", out)
+	a, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !a.DeploymentStatus.IsHealthy() {
+		t.Fatalf("bad: %#v", a.DeploymentStatus)
 	}
 
-	index, err := state.Index("evals")
+	// Deleting the job deletes its deployments
+	if err := state.DeleteJob(1004, job.Namespace, job.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	deployments, err := state.DeploymentsByJobID(job.Namespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1001 {
-		t.Fatalf("bad: %d", index)
+	if len(deployments) != 0 {
+		t.Fatalf("bad: %#v", deployments)
 	}
-
-	notify.verify(t)
 }
 
-func TestStateStore_DeleteEval_Eval(t *testing.T) {
+func TestStateStore_UpdateDeploymentAllocHealth(t *testing.T) {
 	state := testStateStore(t)
-	eval1 := mock.Eval()
-	eval2 := mock.Eval()
-	alloc1 := mock.Alloc()
-	alloc2 := mock.Alloc()
+	job := mock.Job()
+	deployment := structs.NewDeployment(job)
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 1}
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	alloc := mock.Alloc()
+	alloc.DeploymentID = deployment.ID
+	alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	other := mock.Alloc()
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc, other}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Allocations of other deployments can't be marked healthy
+	req := &structs.DeploymentAllocHealthRequest{
+		DeploymentID:         deployment.ID,
+		HealthyAllocationIDs: []string{other.ID},
+	}
+	if err := state.UpdateDeploymentAllocHealth(1002, req); err == nil {
+		t.Fatalf("expected error")
+	}
 
 	notify := setupNotifyTest(
 		state,
-		watch.Item{Table: "evals"},
 		watch.Item{Table: "allocs"},
-		watch.Item{Eval: eval1.ID},
-		watch.Item{Eval: eval2.ID},
-		watch.Item{Alloc: alloc1.ID},
-		watch.Item{Alloc: alloc2.ID},
-		watch.Item{AllocEval: alloc1.EvalID},
-		watch.Item{AllocEval: alloc2.EvalID},
-		watch.Item{AllocJob: alloc1.JobID},
-		watch.Item{AllocJob: alloc2.JobID},
-		watch.Item{AllocNode: alloc1.NodeID},
-		watch.Item{AllocNode: alloc2.NodeID})
+		watch.Item{Alloc: alloc.ID},
+		watch.Item{Deployment: deployment.ID})
 
-	state.UpsertJobSummary(900, mock.JobSummary(eval1.JobID))
-	state.UpsertJobSummary(901, mock.JobSummary(eval2.JobID))
-	state.UpsertJobSummary(902, mock.JobSummary(alloc1.JobID))
-	state.UpsertJobSummary(903, mock.JobSummary(alloc2.JobID))
-	err := state.UpsertEvals(1000, []*structs.Evaluation{eval1, eval2})
-	if err != nil {
+	req.HealthyAllocationIDs = []string{alloc.ID}
+	if err := state.UpdateDeploymentAllocHealth(1003, req); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	err = state.UpsertAllocs(1001, []*structs.Allocation{alloc1, alloc2})
+	out, err := state.AllocByID(alloc.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	err = state.DeleteEval(1002, []string{eval1.ID, eval2.ID}, []string{alloc1.ID, alloc2.ID})
-	if err != nil {
-		t.Fatalf("err: %v", err)
+	if !out.DeploymentStatus.IsHealthy() || !out.DeploymentStatus.IsCanary() || out.ModifyIndex != 1003 {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	out, err := state.EvalByID(eval1.ID)
+	d, err := state.DeploymentByID(deployment.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if out != nil {
-		t.Fatalf("bad: %#v %#v", eval1, out)
+	if !d.Healthy() || d.TaskGroups["web"].PlacedCanaries != 1 {
+		t.Fatalf("bad: %#v", d.TaskGroups["web"])
 	}
 
-	out, err = state.EvalByID(eval2.ID)
-	if err != nil {
+	notify.verify(t)
+}
+
+func TestStateStore_UpdateDeploymentPromotion(t *testing.T) {
+	state := testStateStore(t)
+	deployment := structs.NewDeployment(mock.Job())
+	deployment.StatusDescription = structs.DeploymentStatusDescriptionNeedsPromotion
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2, DesiredCanaries: 1}
+	deployment.TaskGroups["db"] = &structs.DeploymentState{DesiredTotal: 1}
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if out != nil {
-		t.Fatalf("bad: %#v %#v", eval1, out)
+	canary := mock.Alloc()
+	canary.DeploymentID = deployment.ID
+	canary.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{canary}); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	outA, err := state.AllocByID(alloc1.ID)
-	if err != nil {
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: deployment.ID},
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: canary.ID})
+
+	req := &structs.DeploymentPromoteRequest{DeploymentID: deployment.ID}
+	if err := state.UpdateDeploymentPromotion(1001, req); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if out != nil {
-		t.Fatalf("bad: %#v %#v", alloc1, outA)
+	out, err := state.DeploymentByID(deployment.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.RequiresPromotion() || out.TaskGroups["db"].Promoted || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.StatusDescription != structs.DeploymentStatusDescriptionRunning {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	outA, err = state.AllocByID(alloc2.ID)
+	// The promoted canary is pushed to its client
+	alloc, err := state.AllocByID(canary.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if alloc.DeploymentStatus.IsCanary() || alloc.AllocModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", alloc)
+	}
 
-	if out != nil {
-		t.Fatalf("bad: %#v %#v", alloc1, outA)
+	notify.verify(t)
+
+	req.DeploymentID = structs.GenerateUUID()
+	if err := state.UpdateDeploymentPromotion(1002, req); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestStateStore_UpdateDeploymentPromotion_Groups(t *testing.T) {
+	state := testStateStore(t)
+	deployment := structs.NewDeployment(mock.Job())
+	deployment.StatusDescription = structs.DeploymentStatusDescriptionNeedsPromotion
+	deployment.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2, DesiredCanaries: 1}
+	deployment.TaskGroups["db"] = &structs.DeploymentState{DesiredTotal: 2, DesiredCanaries: 1}
+	if err := state.UpsertDeployment(1000, deployment); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	index, err := state.Index("evals")
+	web := mock.Alloc()
+	web.DeploymentID = deployment.ID
+	web.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	db := mock.Alloc()
+	db.TaskGroup = "db"
+	db.DeploymentID = deployment.ID
+	db.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{web, db}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Only promote the web task group
+	req := &structs.DeploymentPromoteRequest{
+		DeploymentID: deployment.ID,
+		Groups:       []string{"web"},
+	}
+	if err := state.UpdateDeploymentPromotion(1001, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(deployment.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1002 {
-		t.Fatalf("bad: %d", index)
+	if !out.TaskGroups["web"].Promoted || out.TaskGroups["db"].Promoted || !out.RequiresPromotion() {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.StatusDescription != structs.DeploymentStatusDescriptionNeedsPromotion {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	index, err = state.Index("allocs")
+	// Only the canary of the promoted task group is cut over
+	outWeb, err := state.AllocByID(web.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1002 {
-		t.Fatalf("bad: %d", index)
+	outDB, err := state.AllocByID(db.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outWeb.DeploymentStatus.IsCanary() || !outDB.DeploymentStatus.IsCanary() {
+		t.Fatalf("bad: %#v %#v", outWeb.DeploymentStatus, outDB.DeploymentStatus)
 	}
 
-	notify.verify(t)
+	// Promoting the remaining task group completes the promotion
+	req.Groups = []string{"db"}
+	if err := state.UpdateDeploymentPromotion(1002, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.DeploymentByID(deployment.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.RequiresPromotion() || out.StatusDescription != structs.DeploymentStatusDescriptionRunning {
+		t.Fatalf("bad: %#v", out)
+	}
 }
 
-func TestStateStore_EvalsByJob(t *testing.T) {
+func TestStateStore_RestoreDeployment(t *testing.T) {
 	state := testStateStore(t)
+	deployment := structs.NewDeployment(mock.Job())
 
-	eval1 := mock.Eval()
-	eval2 := mock.Eval()
-	eval2.JobID = eval1.JobID
-	eval3 := mock.Eval()
-	evals := []*structs.Evaluation{eval1, eval2}
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: deployment.ID})
 
-	err := state.UpsertEvals(1000, evals)
+	restore, err := state.Restore()
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	err = state.UpsertEvals(1001, []*structs.Evaluation{eval3})
+
+	err = restore.DeploymentRestore(deployment)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	restore.Commit()
 
-	out, err := state.EvalsByJob(eval1.JobID)
+	out, err := state.DeploymentByID(deployment.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	sort.Sort(EvalIDSort(evals))
-	sort.Sort(EvalIDSort(out))
-
-	if !reflect.DeepEqual(evals, out) {
-		t.Fatalf("bad: %#v %#v", evals, out)
+	if !reflect.DeepEqual(out, deployment) {
+		t.Fatalf("Bad: %#v %#v", out, deployment)
 	}
+
+	notify.verify(t)
 }
 
-func TestStateStore_Evals(t *testing.T) {
+func TestStateStore_RestoreJobSummary(t *testing.T) {
 	state := testStateStore(t)
-	var evals []*structs.Evaluation
+	job := mock.Job()
+	jobSummary := &structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     job.ID,
+		Summary: map[string]structs.TaskGroupSummary{
+			"web": structs.TaskGroupSummary{
+				Starting: 10,
+			},
+		},
+	}
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	for i := 0; i < 10; i++ {
-		eval := mock.Eval()
-		evals = append(evals, eval)
+	err = restore.JobSummaryRestore(jobSummary)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
 
-		err := state.UpsertEvals(1000+uint64(i), []*structs.Evaluation{eval})
-		if err != nil {
-			t.Fatalf("err: %v", err)
-		}
+	out, err := state.JobSummaryByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	iter, err := state.Evals()
+	if !reflect.DeepEqual(out, jobSummary) {
+		t.Fatalf("Bad: %#v %#v", out, jobSummary)
+	}
+}
+
+func TestStateStore_Indexes(t *testing.T) {
+	state := testStateStore(t)
+	node := mock.Node()
+
+	err := state.UpsertNode(1000, node)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	var out []*structs.Evaluation
+	iter, err := state.Indexes()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var out []*IndexEntry
 	for {
 		raw := iter.Next()
 		if raw == nil {
 			break
 		}
-		out = append(out, raw.(*structs.Evaluation))
+		out = append(out, raw.(*IndexEntry))
 	}
 
-	sort.Sort(EvalIDSort(evals))
-	sort.Sort(EvalIDSort(out))
+	expect := []*IndexEntry{
+		&IndexEntry{"nodes", 1000},
+	}
 
-	if !reflect.DeepEqual(evals, out) {
-		t.Fatalf("bad: %#v %#v", evals, out)
+	if !reflect.DeepEqual(expect, out) {
+		t.Fatalf("bad: %#v %#v", expect, out)
 	}
 }
 
-func TestStateStore_EvalsByIDPrefix(t *testing.T) {
+func TestStateStore_LatestIndex(t *testing.T) {
 	state := testStateStore(t)
-	var evals []*structs.Evaluation
 
-	ids := []string{
-		"aaaaaaaa-7bfb-395d-eb95-0685af2176b2",
-		"aaaaaaab-7bfb-395d-eb95-0685af2176b2",
-		"aaaaaabb-7bfb-395d-eb95-0685af2176b2",
-		"aaaaabbb-7bfb-395d-eb95-0685af2176b2",
-		"aaaabbbb-7bfb-395d-eb95-0685af2176b2",
-		"aaabbbbb-7bfb-395d-eb95-0685af2176b2",
-		"aabbbbbb-7bfb-395d-eb95-0685af2176b2",
-		"abbbbbbb-7bfb-395d-eb95-0685af2176b2",
-		"bbbbbbbb-7bfb-395d-eb95-0685af2176b2",
-	}
-	for i := 0; i < 9; i++ {
-		eval := mock.Eval()
-		eval.ID = ids[i]
-		evals = append(evals, eval)
+	if err := state.UpsertNode(1000, mock.Node()); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	err := state.UpsertEvals(1000, evals)
-	if err != nil {
+	exp := uint64(2000)
+	if err := state.UpsertJob(exp, mock.Job()); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	iter, err := state.EvalsByIDPrefix("aaaa")
+	latest, err := state.LatestIndex()
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	gatherEvals := func(iter memdb.ResultIterator) []*structs.Evaluation {
-		var evals []*structs.Evaluation
-		for {
-			raw := iter.Next()
-			if raw == nil {
-				break
-			}
-			evals = append(evals, raw.(*structs.Evaluation))
-		}
-		return evals
-	}
-
-	out := gatherEvals(iter)
-	if len(out) != 5 {
-		t.Fatalf("bad: expected five evaluations, got: %#v", out)
+	if latest != exp {
+		t.Fatalf("LatestIndex() returned %d; want %d", latest, exp)
 	}
+}
 
-	sort.Sort(EvalIDSort(evals))
+func TestStateStore_RestoreIndex(t *testing.T) {
+	state := testStateStore(t)
 
-	for index, eval := range out {
-		if ids[index] != eval.ID {
-			t.Fatalf("bad: got unexpected id: %s", eval.ID)
-		}
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	iter, err = state.EvalsByIDPrefix("b-a7bfb")
+	index := &IndexEntry{"jobs", 1000}
+	err = restore.IndexRestore(index)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out = gatherEvals(iter)
-	if len(out) != 0 {
-		t.Fatalf("bad: unexpected zero evaluations, got: %#v", out)
+	restore.Commit()
+
+	out, err := state.Index("jobs")
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
+	if out != 1000 {
+		t.Fatalf("Bad: %#v %#v", out, 1000)
+	}
 }
 
-func TestStateStore_RestoreEval(t *testing.T) {
+func TestStateStore_UpsertEvals_Eval(t *testing.T) {
 	state := testStateStore(t)
 	eval := mock.Eval()
 
 	notify := setupNotifyTest(
 		state,
 		watch.Item{Table: "evals"},
-		watch.Item{Eval: eval.ID})
+		watch.Item{Eval: eval.ID},
+		watch.Item{EvalJob: eval.JobID})
 
-	restore, err := state.Restore()
+	err := state.UpsertEvals(1000, []*structs.Evaluation{eval})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	err = restore.EvalRestore(eval)
+	out, err := state.EvalByID(eval.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	restore.Commit()
 
-	out, err := state.EvalByID(eval.ID)
+	if !reflect.DeepEqual(eval, out) {
+		t.Fatalf("bad: %#v %#v", eval, out)
+	}
+
+	index, err := state.Index("evals")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if !reflect.DeepEqual(out, eval) {
-		t.Fatalf("Bad: %#v %#v", out, eval)
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
 	}
 
 	notify.verify(t)
 }
 
-func TestStateStore_UpdateAllocsFromClient(t *testing.T) {
+func TestStateStore_Update_UpsertEvals_Eval(t *testing.T) {
 	state := testStateStore(t)
-	alloc := mock.Alloc()
-	alloc2 := mock.Alloc()
+	eval := mock.Eval()
+
+	err := state.UpsertEvals(1000, []*structs.Evaluation{eval})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
 	notify := setupNotifyTest(
 		state,
-		watch.Item{Table: "allocs"},
-		watch.Item{Alloc: alloc.ID},
-		watch.Item{AllocEval: alloc.EvalID},
-		watch.Item{AllocJob: alloc.JobID},
-		watch.Item{AllocNode: alloc.NodeID},
-		watch.Item{Alloc: alloc2.ID},
-		watch.Item{AllocEval: alloc2.EvalID},
-		watch.Item{AllocJob: alloc2.JobID},
-		watch.Item{AllocNode: alloc2.NodeID})
+		watch.Item{Table: "evals"},
+		watch.Item{Eval: eval.ID})
 
-	if err := state.UpsertJob(999, alloc.Job); err != nil {
-		t.Fatalf("err: %v", err)
-	}
-	if err := state.UpsertJob(999, alloc2.Job); err != nil {
+	eval2 := mock.Eval()
+	eval2.ID = eval.ID
+	err = state.UpsertEvals(1001, []*structs.Evaluation{eval2})
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc, alloc2})
+	out, err := state.EvalByID(eval.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Create the delta updates
-	ts := map[string]*structs.TaskState{"web": &structs.TaskState{State: structs.TaskStatePending}}
-	update := &structs.Allocation{
-		ID:           alloc.ID,
-		ClientStatus: structs.AllocClientStatusFailed,
-		TaskStates:   ts,
-		JobID:        alloc.JobID,
-		TaskGroup:    alloc.TaskGroup,
-	}
-	update2 := &structs.Allocation{
-		ID:           alloc2.ID,
-		ClientStatus: structs.AllocClientStatusRunning,
-		TaskStates:   ts,
-		JobID:        alloc2.JobID,
-		TaskGroup:    alloc2.TaskGroup,
+	if !reflect.DeepEqual(eval2, out) {
+		t.Fatalf("bad: %#v %#v", eval2, out)
 	}
 
-	err = state.UpdateAllocsFromClient(1001, []*structs.Allocation{update, update2})
-	if err != nil {
-		t.Fatalf("err: %v", err)
+	if out.CreateIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	out, err := state.AllocByID(alloc.ID)
+	index, err := state.Index("evals")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	alloc.CreateIndex = 1000
-	alloc.ModifyIndex = 1001
-	alloc.TaskStates = ts
-	alloc.ClientStatus = structs.AllocClientStatusFailed
-	if !reflect.DeepEqual(alloc, out) {
-		t.Fatalf("bad: %#v %#v", alloc, out)
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
 	}
 
-	out, err = state.AllocByID(alloc2.ID)
-	if err != nil {
+	notify.verify(t)
+}
+
+func TestStateStore_UpdateEvalDeliveries(t *testing.T) {
+	state := testStateStore(t)
+	eval := mock.Eval()
+	complete := mock.Eval()
+	complete.Status = structs.EvalStatusComplete
+	if err := state.UpsertEvals(1000, []*structs.Evaluation{eval, complete}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	alloc2.ModifyIndex = 1000
-	alloc2.ModifyIndex = 1001
-	alloc2.ClientStatus = structs.AllocClientStatusRunning
-	alloc2.TaskStates = ts
-	if !reflect.DeepEqual(alloc2, out) {
-		t.Fatalf("bad: %#v %#v", alloc2, out)
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "evals"},
+		watch.Item{Eval: eval.ID},
+		watch.Item{EvalJob: eval.JobID})
+
+	req := &structs.EvalDeliveryUpdateRequest{
+		DeliveryAttempts: map[string]int{
+			eval.ID:                2,
+			complete.ID:            2,
+			structs.GenerateUUID(): 1,
+		},
+	}
+	if err := state.UpdateEvalDeliveries(1001, req); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	index, err := state.Index("allocs")
+	out, err := state.EvalByID(eval.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1001 {
-		t.Fatalf("bad: %d", index)
+	if out.DeliveryAttempts != 2 || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	// Ensure summaries have been updated
-	summary, err := state.JobSummaryByID(alloc.JobID)
+	// Terminal evals aren't updated
+	out, err = state.EvalByID(complete.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	tgSummary := summary.Summary["web"]
-	if tgSummary.Failed != 1 {
-		t.Fatalf("expected failed: %v, actual: %v, summary: %#v", 1, tgSummary.Failed, tgSummary)
+	if out.DeliveryAttempts != 0 || out.ModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	summary2, err := state.JobSummaryByID(alloc2.JobID)
+	// Upserting the eval doesn't lose the recorded attempts
+	update := eval.Copy()
+	update.DeliveryAttempts = 1
+	if err := state.UpsertEvals(1002, []*structs.Evaluation{update}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.EvalByID(eval.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	tgSummary2 := summary2.Summary["web"]
-	if tgSummary2.Running != 1 {
-		t.Fatalf("expected running: %v, actual: %v", 1, tgSummary2.Running)
+	if out.DeliveryAttempts != 2 {
+		t.Fatalf("bad: %#v", out)
 	}
 
 	notify.verify(t)
 }
 
-func TestStateStore_UpdateMultipleAllocsFromClient(t *testing.T) {
+func TestStateStore_DeleteEval_Eval(t *testing.T) {
 	state := testStateStore(t)
-	alloc := mock.Alloc()
+	eval1 := mock.Eval()
+	eval2 := mock.Eval()
+	alloc1 := mock.Alloc()
+	alloc2 := mock.Alloc()
 
-	if err := state.UpsertJob(999, alloc.Job); err != nil {
-		t.Fatalf("err: %v", err)
-	}
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "evals"},
+		watch.Item{Table: "allocs"},
+		watch.Item{Eval: eval1.ID},
+		watch.Item{Eval: eval2.ID},
+		watch.Item{EvalJob: eval1.JobID},
+		watch.Item{EvalJob: eval2.JobID},
+		watch.Item{Alloc: alloc1.ID},
+		watch.Item{Alloc: alloc2.ID},
+		watch.Item{AllocEval: alloc1.EvalID},
+		watch.Item{AllocEval: alloc2.EvalID},
+		watch.Item{AllocJob: alloc1.JobID},
+		watch.Item{AllocJob: alloc2.JobID},
+		watch.Item{AllocNode: alloc1.NodeID},
+		watch.Item{AllocNode: alloc2.NodeID})
+
+	state.UpsertJobSummary(900, mock.JobSummary(eval1.JobID))
+	state.UpsertJobSummary(901, mock.JobSummary(eval2.JobID))
+	state.UpsertJobSummary(902, mock.JobSummary(alloc1.JobID))
+	state.UpsertJobSummary(903, mock.JobSummary(alloc2.JobID))
+	err := state.UpsertEvals(1000, []*structs.Evaluation{eval1, eval2})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Create the delta updates
-	ts := map[string]*structs.TaskState{"web": &structs.TaskState{State: structs.TaskStatePending}}
-	update := &structs.Allocation{
-		ID:           alloc.ID,
-		ClientStatus: structs.AllocClientStatusRunning,
-		TaskStates:   ts,
-		JobID:        alloc.JobID,
-		TaskGroup:    alloc.TaskGroup,
-	}
-	update2 := &structs.Allocation{
-		ID:           alloc.ID,
-		ClientStatus: structs.AllocClientStatusPending,
-		TaskStates:   ts,
-		JobID:        alloc.JobID,
-		TaskGroup:    alloc.TaskGroup,
+	err = state.UpsertAllocs(1001, []*structs.Allocation{alloc1, alloc2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	err = state.UpdateAllocsFromClient(1001, []*structs.Allocation{update, update2})
+	err = state.DeleteEval(1002, []string{eval1.ID, eval2.ID}, []string{alloc1.ID, alloc2.ID})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.AllocByID(alloc.ID)
+	out, err := state.EvalByID(eval1.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	alloc.CreateIndex = 1000
-	alloc.ModifyIndex = 1001
-	alloc.TaskStates = ts
-	alloc.ClientStatus = structs.AllocClientStatusPending
-	if !reflect.DeepEqual(alloc, out) {
-		t.Fatalf("bad: %#v , actual:%#v", alloc, out)
+	if out != nil {
+		t.Fatalf("bad: %#v %#v", eval1, out)
 	}
 
-	summary, err := state.JobSummaryByID(alloc.JobID)
-	expectedSummary := &structs.JobSummary{
-		JobID: alloc.JobID,
-		Summary: map[string]structs.TaskGroupSummary{
-			"web": structs.TaskGroupSummary{
-				Starting: 1,
-			},
-		},
-		CreateIndex: 999,
-		ModifyIndex: 1001,
-	}
+	out, err = state.EvalByID(eval2.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if !reflect.DeepEqual(summary, expectedSummary) {
-		t.Fatalf("expected: %#v, actual: %#v", expectedSummary, summary)
-	}
-}
-
-func TestStateStore_UpsertAlloc_Alloc(t *testing.T) {
-	state := testStateStore(t)
-	alloc := mock.Alloc()
-
-	notify := setupNotifyTest(
-		state,
-		watch.Item{Table: "allocs"},
-		watch.Item{Alloc: alloc.ID},
-		watch.Item{AllocEval: alloc.EvalID},
-		watch.Item{AllocJob: alloc.JobID},
-		watch.Item{AllocNode: alloc.NodeID})
 
-	if err := state.UpsertJob(999, alloc.Job); err != nil {
-		t.Fatalf("err: %v", err)
+	if out != nil {
+		t.Fatalf("bad: %#v %#v", eval1, out)
 	}
 
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	outA, err := state.AllocByID(alloc1.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.AllocByID(alloc.ID)
+	if out != nil {
+		t.Fatalf("bad: %#v %#v", alloc1, outA)
+	}
+
+	outA, err = state.AllocByID(alloc2.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if !reflect.DeepEqual(alloc, out) {
-		t.Fatalf("bad: %#v %#v", alloc, out)
+	if out != nil {
+		t.Fatalf("bad: %#v %#v", alloc1, outA)
 	}
 
-	index, err := state.Index("allocs")
+	index, err := state.Index("evals")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1000 {
+	if index != 1002 {
 		t.Fatalf("bad: %d", index)
 	}
 
-	summary, err := state.JobSummaryByID(alloc.JobID)
+	index, err = state.Index("allocs")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	tgSummary, ok := summary.Summary["web"]
-	if !ok {
-		t.Fatalf("no summary for task group web")
-	}
-	if tgSummary.Starting != 1 {
-		t.Fatalf("expected queued: %v, actual: %v", 1, tgSummary.Starting)
+	if index != 1002 {
+		t.Fatalf("bad: %d", index)
 	}
 
 	notify.verify(t)
 }
 
-func TestStateStore_UpsertAlloc_NoEphemeralDisk(t *testing.T) {
+func TestStateStore_EvalsByJob(t *testing.T) {
 	state := testStateStore(t)
-	alloc := mock.Alloc()
-	alloc.Job.TaskGroups[0].EphemeralDisk = nil
-	alloc.Job.TaskGroups[0].Tasks[0].Resources.DiskMB = 120
 
-	if err := state.UpsertJob(999, alloc.Job); err != nil {
+	eval1 := mock.Eval()
+	eval2 := mock.Eval()
+	eval2.JobID = eval1.JobID
+	eval3 := mock.Eval()
+	evals := []*structs.Evaluation{eval1, eval2}
+
+	err := state.UpsertEvals(1000, evals)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	err = state.UpsertEvals(1001, []*structs.Evaluation{eval3})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.AllocByID(alloc.ID)
+	out, err := state.EvalsByJob(eval1.Namespace, eval1.JobID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	expected := alloc.Copy()
-	expected.Job.TaskGroups[0].EphemeralDisk = &structs.EphemeralDisk{SizeMB: 120}
-	if !reflect.DeepEqual(expected, out) {
-		t.Fatalf("bad: %#v %#v", expected, out)
+	sort.Sort(EvalIDSort(evals))
+	sort.Sort(EvalIDSort(out))
+
+	if !reflect.DeepEqual(evals, out) {
+		t.Fatalf("bad: %#v %#v", evals, out)
 	}
 }
 
-func TestStateStore_UpdateAlloc_Alloc(t *testing.T) {
+func TestStateStore_Evals(t *testing.T) {
 	state := testStateStore(t)
-	alloc := mock.Alloc()
+	var evals []*structs.Evaluation
 
-	if err := state.UpsertJob(999, alloc.Job); err != nil {
-		t.Fatalf("err: %v", err)
-	}
+	for i := 0; i < 10; i++ {
+		eval := mock.Eval()
+		evals = append(evals, eval)
 
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
-	if err != nil {
-		t.Fatalf("err: %v", err)
+		err := state.UpsertEvals(1000+uint64(i), []*structs.Evaluation{eval})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
 	}
 
-	summary, err := state.JobSummaryByID(alloc.JobID)
+	iter, err := state.Evals()
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	tgSummary := summary.Summary["web"]
-	if tgSummary.Starting != 1 {
-		t.Fatalf("expected starting: %v, actual: %v", 1, tgSummary.Starting)
+
+	var out []*structs.Evaluation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Evaluation))
 	}
 
-	alloc2 := mock.Alloc()
-	alloc2.ID = alloc.ID
-	alloc2.NodeID = alloc.NodeID + ".new"
-	state.UpsertJobSummary(1001, mock.JobSummary(alloc2.JobID))
+	sort.Sort(EvalIDSort(evals))
+	sort.Sort(EvalIDSort(out))
 
-	notify := setupNotifyTest(
-		state,
-		watch.Item{Table: "allocs"},
-		watch.Item{Alloc: alloc2.ID},
-		watch.Item{AllocEval: alloc2.EvalID},
-		watch.Item{AllocJob: alloc2.JobID},
-		watch.Item{AllocNode: alloc2.NodeID})
+	if !reflect.DeepEqual(evals, out) {
+		t.Fatalf("bad: %#v %#v", evals, out)
+	}
+}
 
-	err = state.UpsertAllocs(1002, []*structs.Allocation{alloc2})
+func TestStateStore_EvalsByIDPrefix(t *testing.T) {
+	state := testStateStore(t)
+	var evals []*structs.Evaluation
+
+	ids := []string{
+		"aaaaaaaa-7bfb-395d-eb95-0685af2176b2",
+		"aaaaaaab-7bfb-395d-eb95-0685af2176b2",
+		"aaaaaabb-7bfb-395d-eb95-0685af2176b2",
+		"aaaaabbb-7bfb-395d-eb95-0685af2176b2",
+		"aaaabbbb-7bfb-395d-eb95-0685af2176b2",
+		"aaabbbbb-7bfb-395d-eb95-0685af2176b2",
+		"aabbbbbb-7bfb-395d-eb95-0685af2176b2",
+		"abbbbbbb-7bfb-395d-eb95-0685af2176b2",
+		"bbbbbbbb-7bfb-395d-eb95-0685af2176b2",
+	}
+	for i := 0; i < 9; i++ {
+		eval := mock.Eval()
+		eval.ID = ids[i]
+		evals = append(evals, eval)
+	}
+
+	err := state.UpsertEvals(1000, evals)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.AllocByID(alloc.ID)
+	iter, err := state.EvalsByIDPrefix("aaaa")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if !reflect.DeepEqual(alloc2, out) {
-		t.Fatalf("bad: %#v %#v", alloc2, out)
+	gatherEvals := func(iter memdb.ResultIterator) []*structs.Evaluation {
+		var evals []*structs.Evaluation
+		for {
+			raw := iter.Next()
+			if raw == nil {
+				break
+			}
+			evals = append(evals, raw.(*structs.Evaluation))
+		}
+		return evals
 	}
 
-	if out.CreateIndex != 1000 {
-		t.Fatalf("bad: %#v", out)
-	}
-	if out.ModifyIndex != 1002 {
-		t.Fatalf("bad: %#v", out)
+	out := gatherEvals(iter)
+	if len(out) != 5 {
+		t.Fatalf("bad: expected five evaluations, got: %#v", out)
 	}
 
-	index, err := state.Index("allocs")
-	if err != nil {
-		t.Fatalf("err: %v", err)
-	}
-	if index != 1002 {
-		t.Fatalf("bad: %d", index)
+	sort.Sort(EvalIDSort(evals))
+
+	for index, eval := range out {
+		if ids[index] != eval.ID {
+			t.Fatalf("bad: got unexpected id: %s", eval.ID)
+		}
 	}
 
-	// Ensure that summary hasb't changed
-	summary, err = state.JobSummaryByID(alloc.JobID)
+	iter, err = state.EvalsByIDPrefix("b-a7bfb")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	tgSummary = summary.Summary["web"]
-	if tgSummary.Starting != 1 {
-		t.Fatalf("expected starting: %v, actual: %v", 1, tgSummary.Starting)
+
+	out = gatherEvals(iter)
+	if len(out) != 0 {
+		t.Fatalf("bad: unexpected zero evaluations, got: %#v", out)
 	}
 
-	notify.verify(t)
 }
 
-// This test ensures that the state store will mark the clients status as lost
-// when set rather than preferring the existing status.
-func TestStateStore_UpdateAlloc_Lost(t *testing.T) {
+func TestStateStore_RestoreEval(t *testing.T) {
 	state := testStateStore(t)
-	alloc := mock.Alloc()
-	alloc.ClientStatus = "foo"
+	eval := mock.Eval()
 
-	if err := state.UpsertJob(999, alloc.Job); err != nil {
-		t.Fatalf("err: %v", err)
-	}
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "evals"},
+		watch.Item{Eval: eval.ID},
+		watch.Item{EvalJob: eval.JobID})
 
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	restore, err := state.Restore()
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	alloc2 := new(structs.Allocation)
-	*alloc2 = *alloc
-	alloc2.ClientStatus = structs.AllocClientStatusLost
-	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc2}); err != nil {
+	err = restore.EvalRestore(eval)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	restore.Commit()
 
-	out, err := state.AllocByID(alloc2.ID)
+	out, err := state.EvalByID(eval.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if out.ClientStatus != structs.AllocClientStatusLost {
-		t.Fatalf("bad: %#v", out)
+	if !reflect.DeepEqual(out, eval) {
+		t.Fatalf("Bad: %#v %#v", out, eval)
 	}
+
+	notify.verify(t)
 }
 
-// This test ensures an allocation can be updated when there is no job
-// associated with it. This will happen when a job is stopped by an user which
-// has non-terminal allocations on clients
-func TestStateStore_UpdateAlloc_NoJob(t *testing.T) {
+func TestStateStore_UpdateAllocsFromClient(t *testing.T) {
 	state := testStateStore(t)
 	alloc := mock.Alloc()
+	alloc2 := mock.Alloc()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: alloc.ID},
+		watch.Item{AllocEval: alloc.EvalID},
+		watch.Item{AllocJob: alloc.JobID},
+		watch.Item{AllocNode: alloc.NodeID},
+		watch.Item{Alloc: alloc2.ID},
+		watch.Item{AllocEval: alloc2.EvalID},
+		watch.Item{AllocJob: alloc2.JobID},
+		watch.Item{AllocNode: alloc2.NodeID})
 
-	// Upsert a job
-	state.UpsertJobSummary(998, mock.JobSummary(alloc.JobID))
 	if err := state.UpsertJob(999, alloc.Job); err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if err := state.UpsertJob(999, alloc2.Job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc, alloc2})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if err := state.DeleteJob(1001, alloc.JobID); err != nil {
-		t.Fatalf("err: %v", err)
+	// Create the delta updates
+	ts := map[string]*structs.TaskState{"web": &structs.TaskState{State: structs.TaskStatePending}}
+	update := &structs.Allocation{
+		ID:           alloc.ID,
+		ClientStatus: structs.AllocClientStatusFailed,
+		TaskStates:   ts,
+		JobID:        alloc.JobID,
+		TaskGroup:    alloc.TaskGroup,
+	}
+	update2 := &structs.Allocation{
+		ID:           alloc2.ID,
+		ClientStatus: structs.AllocClientStatusRunning,
+		TaskStates:   ts,
+		JobID:        alloc2.JobID,
+		TaskGroup:    alloc2.TaskGroup,
 	}
 
-	// Update the desired state of the allocation to stop
-	allocCopy := alloc.Copy()
-	allocCopy.DesiredStatus = structs.AllocDesiredStatusStop
-	if err := state.UpsertAllocs(1002, []*structs.Allocation{allocCopy}); err != nil {
+	err = state.UpdateAllocsFromClient(1001, []*structs.Allocation{update, update2})
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Update the client state of the allocation to complete
-	allocCopy1 := allocCopy.Copy()
-	allocCopy1.ClientStatus = structs.AllocClientStatusComplete
-	if err := state.UpdateAllocsFromClient(1003, []*structs.Allocation{allocCopy1}); err != nil {
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, _ := state.AllocByID(alloc.ID)
-	// Update the modify index of the alloc before comparing
-	allocCopy1.ModifyIndex = 1003
-	if !reflect.DeepEqual(out, allocCopy1) {
-		t.Fatalf("expected: %#v \n actual: %#v", allocCopy1, out)
+	alloc.CreateIndex = 1000
+	alloc.ModifyIndex = 1001
+	alloc.TaskStates = ts
+	alloc.ClientStatus = structs.AllocClientStatusFailed
+	if !reflect.DeepEqual(alloc, out) {
+		t.Fatalf("bad: %#v %#v", alloc, out)
 	}
-}
 
-func TestStateStore_JobSummary(t *testing.T) {
-	state := testStateStore(t)
+	out, err = state.AllocByID(alloc2.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	// Add a job
-	job := mock.Job()
-	state.UpsertJob(900, job)
+	alloc2.ModifyIndex = 1000
+	alloc2.ModifyIndex = 1001
+	alloc2.ClientStatus = structs.AllocClientStatusRunning
+	alloc2.TaskStates = ts
+	if !reflect.DeepEqual(alloc2, out) {
+		t.Fatalf("bad: %#v %#v", alloc2, out)
+	}
 
-	// Get the job back
-	outJob, _ := state.JobByID(job.ID)
-	if outJob.CreateIndex != 900 {
-		t.Fatalf("bad create index: %v", outJob.CreateIndex)
+	index, err := state.Index("allocs")
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-	summary, _ := state.JobSummaryByID(job.ID)
-	if summary.CreateIndex != 900 {
-		t.Fatalf("bad create index: %v", summary.CreateIndex)
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
 	}
 
-	// Upser an allocation
-	alloc := mock.Alloc()
-	alloc.JobID = job.ID
-	alloc.Job = job
-	state.UpsertAllocs(910, []*structs.Allocation{alloc})
-
-	// Update the alloc from client
-	alloc1 := alloc.Copy()
-	alloc1.ClientStatus = structs.AllocClientStatusPending
-	alloc1.DesiredStatus = ""
-	state.UpdateAllocsFromClient(920, []*structs.Allocation{alloc})
-
-	alloc3 := alloc.Copy()
-	alloc3.ClientStatus = structs.AllocClientStatusRunning
-	alloc3.DesiredStatus = ""
-	state.UpdateAllocsFromClient(930, []*structs.Allocation{alloc3})
-
-	// Upsert the alloc
-	alloc4 := alloc.Copy()
-	alloc4.ClientStatus = structs.AllocClientStatusPending
-	alloc4.DesiredStatus = structs.AllocDesiredStatusRun
-	state.UpsertAllocs(950, []*structs.Allocation{alloc4})
-
-	// Again upsert the alloc
-	alloc5 := alloc.Copy()
-	alloc5.ClientStatus = structs.AllocClientStatusPending
-	alloc5.DesiredStatus = structs.AllocDesiredStatusRun
-	state.UpsertAllocs(970, []*structs.Allocation{alloc5})
-
-	expectedSummary := structs.JobSummary{
-		JobID: job.ID,
-		Summary: map[string]structs.TaskGroupSummary{
-			"web": structs.TaskGroupSummary{
-				Running: 1,
-			},
-		},
-		CreateIndex: 900,
-		ModifyIndex: 930,
+	// Ensure summaries have been updated
+	summary, err := state.JobSummaryByID(alloc.Namespace, alloc.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	tgSummary := summary.Summary["web"]
+	if tgSummary.Failed != 1 {
+		t.Fatalf("expected failed: %v, actual: %v, summary: %#v", 1, tgSummary.Failed, tgSummary)
 	}
 
-	summary, _ = state.JobSummaryByID(job.ID)
-	if !reflect.DeepEqual(&expectedSummary, summary) {
-		t.Fatalf("expected: %#v, actual: %v", expectedSummary, summary)
+	summary2, err := state.JobSummaryByID(alloc2.Namespace, alloc2.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	tgSummary2 := summary2.Summary["web"]
+	if tgSummary2.Running != 1 {
+		t.Fatalf("expected running: %v, actual: %v", 1, tgSummary2.Running)
 	}
 
-	// De-register the job.
-	state.DeleteJob(980, job.ID)
+	notify.verify(t)
+}
 
-	// Shouldn't have any effect on the summary
-	alloc6 := alloc.Copy()
-	alloc6.ClientStatus = structs.AllocClientStatusRunning
-	alloc6.DesiredStatus = ""
-	state.UpdateAllocsFromClient(990, []*structs.Allocation{alloc6})
+func TestStateStore_UpdateMultipleAllocsFromClient(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
 
-	// We shouldn't have any summary at this point
-	summary, _ = state.JobSummaryByID(job.ID)
-	if summary != nil {
-		t.Fatalf("expected nil, actual: %#v", summary)
+	if err := state.UpsertJob(999, alloc.Job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	// Re-register the same job
-	job1 := mock.Job()
-	job1.ID = job.ID
-	state.UpsertJob(1000, job1)
-	outJob2, _ := state.JobByID(job1.ID)
-	if outJob2.CreateIndex != 1000 {
-		t.Fatalf("bad create index: %v", outJob2.CreateIndex)
+	// Create the delta updates
+	ts := map[string]*structs.TaskState{"web": &structs.TaskState{State: structs.TaskStatePending}}
+	update := &structs.Allocation{
+		ID:           alloc.ID,
+		ClientStatus: structs.AllocClientStatusRunning,
+		TaskStates:   ts,
+		JobID:        alloc.JobID,
+		TaskGroup:    alloc.TaskGroup,
 	}
-	summary, _ = state.JobSummaryByID(job1.ID)
-	if summary.CreateIndex != 1000 {
-		t.Fatalf("bad create index: %v", summary.CreateIndex)
+	update2 := &structs.Allocation{
+		ID:           alloc.ID,
+		ClientStatus: structs.AllocClientStatusPending,
+		TaskStates:   ts,
+		JobID:        alloc.JobID,
+		TaskGroup:    alloc.TaskGroup,
 	}
 
-	// Upsert an allocation
-	alloc7 := alloc.Copy()
-	alloc7.JobID = outJob.ID
-	alloc7.Job = outJob
-	alloc7.ClientStatus = structs.AllocClientStatusComplete
-	alloc7.DesiredStatus = structs.AllocDesiredStatusRun
-	state.UpdateAllocsFromClient(1020, []*structs.Allocation{alloc7})
-
-	expectedSummary = structs.JobSummary{
-		JobID: job.ID,
-		Summary: map[string]structs.TaskGroupSummary{
-			"web": structs.TaskGroupSummary{},
-		},
-		CreateIndex: 1000,
-		ModifyIndex: 1000,
+	err = state.UpdateAllocsFromClient(1001, []*structs.Allocation{update, update2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	summary, _ = state.JobSummaryByID(job1.ID)
-	if !reflect.DeepEqual(&expectedSummary, summary) {
-		t.Fatalf("expected: %#v, actual: %#v", expectedSummary, summary)
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-}
-
-func TestStateStore_ReconcileJobSummary(t *testing.T) {
-	state := testStateStore(t)
-
-	// Create an alloc
-	alloc := mock.Alloc()
-
-	// Add another task group to the job
-	tg2 := alloc.Job.TaskGroups[0].Copy()
-	tg2.Name = "db"
-	alloc.Job.TaskGroups = append(alloc.Job.TaskGroups, tg2)
-	state.UpsertJob(100, alloc.Job)
-
-	// Create one more alloc for the db task group
-	alloc2 := mock.Alloc()
-	alloc2.TaskGroup = "db"
-	alloc2.JobID = alloc.JobID
-	alloc2.Job = alloc.Job
-
-	// Upserts the alloc
-	state.UpsertAllocs(110, []*structs.Allocation{alloc, alloc2})
-
-	// Change the state of the first alloc to running
-	alloc3 := alloc.Copy()
-	alloc3.ClientStatus = structs.AllocClientStatusRunning
-	state.UpdateAllocsFromClient(120, []*structs.Allocation{alloc3})
-
-	//Add some more allocs to the second tg
-	alloc4 := mock.Alloc()
-	alloc4.JobID = alloc.JobID
-	alloc4.Job = alloc.Job
-	alloc4.TaskGroup = "db"
-	alloc5 := alloc4.Copy()
-	alloc5.ClientStatus = structs.AllocClientStatusRunning
-
-	alloc6 := mock.Alloc()
-	alloc6.JobID = alloc.JobID
-	alloc6.Job = alloc.Job
-	alloc6.TaskGroup = "db"
-	alloc7 := alloc6.Copy()
-	alloc7.ClientStatus = structs.AllocClientStatusComplete
-
-	alloc8 := mock.Alloc()
-	alloc8.JobID = alloc.JobID
-	alloc8.Job = alloc.Job
-	alloc8.TaskGroup = "db"
-	alloc9 := alloc8.Copy()
-	alloc9.ClientStatus = structs.AllocClientStatusFailed
-
-	alloc10 := mock.Alloc()
-	alloc10.JobID = alloc.JobID
-	alloc10.Job = alloc.Job
-	alloc10.TaskGroup = "db"
-	alloc11 := alloc10.Copy()
-	alloc11.ClientStatus = structs.AllocClientStatusLost
 
-	state.UpsertAllocs(130, []*structs.Allocation{alloc4, alloc6, alloc8, alloc10})
-
-	state.UpdateAllocsFromClient(150, []*structs.Allocation{alloc5, alloc7, alloc9, alloc11})
-
-	// DeleteJobSummary is a helper method and doesn't modify the indexes table
-	state.DeleteJobSummary(130, alloc.Job.ID)
-
-	state.ReconcileJobSummaries(120)
+	alloc.CreateIndex = 1000
+	alloc.ModifyIndex = 1001
+	alloc.TaskStates = ts
+	alloc.ClientStatus = structs.AllocClientStatusPending
+	if !reflect.DeepEqual(alloc, out) {
+		t.Fatalf("bad: %#v , actual:%#v", alloc, out)
+	}
 
-	summary, _ := state.JobSummaryByID(alloc.Job.ID)
-	expectedSummary := structs.JobSummary{
-		JobID: alloc.Job.ID,
+	summary, err := state.JobSummaryByID(alloc.Namespace, alloc.JobID)
+	expectedSummary := &structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     alloc.JobID,
 		Summary: map[string]structs.TaskGroupSummary{
 			"web": structs.TaskGroupSummary{
-				Running: 1,
-			},
-			"db": structs.TaskGroupSummary{
 				Starting: 1,
-				Running:  1,
-				Failed:   1,
-				Complete: 1,
-				Lost:     1,
 			},
 		},
-		CreateIndex: 100,
-		ModifyIndex: 120,
-	}
-	if !reflect.DeepEqual(&expectedSummary, summary) {
-		t.Fatalf("expected: %v, actual: %v", expectedSummary, summary)
-	}
-}
-
-func TestStateStore_UpdateAlloc_JobNotPresent(t *testing.T) {
-	state := testStateStore(t)
-
-	alloc := mock.Alloc()
-	state.UpsertJob(100, alloc.Job)
-	state.UpsertAllocs(200, []*structs.Allocation{alloc})
-
-	// Delete the job
-	state.DeleteJob(300, alloc.Job.ID)
-
-	// Update the alloc
-	alloc1 := alloc.Copy()
-	alloc1.ClientStatus = structs.AllocClientStatusRunning
-
-	// Updating allocation should not throw any error
-	if err := state.UpdateAllocsFromClient(400, []*structs.Allocation{alloc1}); err != nil {
-		t.Fatalf("expect err: %v", err)
-	}
-
-	// Re-Register the job
-	state.UpsertJob(500, alloc.Job)
-
-	// Update the alloc again
-	alloc2 := alloc.Copy()
-	alloc2.ClientStatus = structs.AllocClientStatusComplete
-	if err := state.UpdateAllocsFromClient(400, []*structs.Allocation{alloc1}); err != nil {
-		t.Fatalf("expect err: %v", err)
+		CreateIndex: 999,
+		ModifyIndex: 1001,
 	}
-
-	// Job Summary of the newly registered job shouldn't account for the
-	// allocation update for the older job
-	expectedSummary := structs.JobSummary{
-		JobID: alloc1.JobID,
-		Summary: map[string]structs.TaskGroupSummary{
-			"web": structs.TaskGroupSummary{},
-		},
-		CreateIndex: 500,
-		ModifyIndex: 500,
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-	summary, _ := state.JobSummaryByID(alloc.Job.ID)
-	if !reflect.DeepEqual(&expectedSummary, summary) {
-		t.Fatalf("expected: %v, actual: %v", expectedSummary, summary)
+	if !reflect.DeepEqual(summary, expectedSummary) {
+		t.Fatalf("expected: %#v, actual: %#v", expectedSummary, summary)
 	}
 }
 
-func TestStateStore_EvictAlloc_Alloc(t *testing.T) {
+func TestStateStore_UpsertAlloc_Alloc(t *testing.T) {
 	state := testStateStore(t)
 	alloc := mock.Alloc()
 
-	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
-	if err != nil {
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: alloc.ID},
+		watch.Item{AllocEval: alloc.EvalID},
+		watch.Item{AllocJob: alloc.JobID},
+		watch.Item{AllocNode: alloc.NodeID})
+
+	if err := state.UpsertJob(999, alloc.Job); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	alloc2 := new(structs.Allocation)
-	*alloc2 = *alloc
-	alloc2.DesiredStatus = structs.AllocDesiredStatusEvict
-	err = state.UpsertAllocs(1001, []*structs.Allocation{alloc2})
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -2241,7 +2389,7 @@ func TestStateStore_EvictAlloc_Alloc(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	if out.DesiredStatus != structs.AllocDesiredStatusEvict {
+	if !reflect.DeepEqual(alloc, out) {
 		t.Fatalf("bad: %#v %#v", alloc, out)
 	}
 
@@ -2249,317 +2397,798 @@ func TestStateStore_EvictAlloc_Alloc(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1001 {
+	if index != 1000 {
 		t.Fatalf("bad: %d", index)
 	}
-}
 
-func TestStateStore_AllocsByNode(t *testing.T) {
-	state := testStateStore(t)
-	var allocs []*structs.Allocation
+	summary, err := state.JobSummaryByID(alloc.Namespace, alloc.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	for i := 0; i < 10; i++ {
-		alloc := mock.Alloc()
-		alloc.NodeID = "foo"
-		allocs = append(allocs, alloc)
+	tgSummary, ok := summary.Summary["web"]
+	if !ok {
+		t.Fatalf("no summary for task group web")
+	}
+	if tgSummary.Starting != 1 {
+		t.Fatalf("expected queued: %v, actual: %v", 1, tgSummary.Starting)
 	}
 
-	for idx, alloc := range allocs {
-		state.UpsertJobSummary(uint64(900+idx), mock.JobSummary(alloc.JobID))
+	notify.verify(t)
+}
+
+func TestStateStore_UpsertAlloc_NoEphemeralDisk(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+	alloc.Job.TaskGroups[0].EphemeralDisk = nil
+	alloc.Job.TaskGroups[0].Tasks[0].Resources.DiskMB = 120
+
+	if err := state.UpsertJob(999, alloc.Job); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	err := state.UpsertAllocs(1000, allocs)
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.AllocsByNode("foo")
+	out, err := state.AllocByID(alloc.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	sort.Sort(AllocIDSort(allocs))
-	sort.Sort(AllocIDSort(out))
-
-	if !reflect.DeepEqual(allocs, out) {
-		t.Fatalf("bad: %#v %#v", allocs, out)
+	expected := alloc.Copy()
+	expected.Job.TaskGroups[0].EphemeralDisk = &structs.EphemeralDisk{SizeMB: 120}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("bad: %#v %#v", expected, out)
 	}
 }
 
-func TestStateStore_AllocsByNodeTerminal(t *testing.T) {
+func TestStateStore_UpdateAlloc_Alloc(t *testing.T) {
 	state := testStateStore(t)
-	var allocs, term, nonterm []*structs.Allocation
+	alloc := mock.Alloc()
 
-	for i := 0; i < 10; i++ {
-		alloc := mock.Alloc()
-		alloc.NodeID = "foo"
-		if i%2 == 0 {
-			alloc.DesiredStatus = structs.AllocDesiredStatusStop
-			term = append(term, alloc)
-		} else {
-			nonterm = append(nonterm, alloc)
-		}
-		allocs = append(allocs, alloc)
-	}
-
-	for idx, alloc := range allocs {
-		state.UpsertJobSummary(uint64(900+idx), mock.JobSummary(alloc.JobID))
-	}
-
-	err := state.UpsertAllocs(1000, allocs)
-	if err != nil {
+	if err := state.UpsertJob(999, alloc.Job); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Verify the terminal allocs
-	out, err := state.AllocsByNodeTerminal("foo", true)
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	sort.Sort(AllocIDSort(term))
-	sort.Sort(AllocIDSort(out))
-
-	if !reflect.DeepEqual(term, out) {
-		t.Fatalf("bad: %#v %#v", term, out)
-	}
-
-	// Verify the non-terminal allocs
-	out, err = state.AllocsByNodeTerminal("foo", false)
+	summary, err := state.JobSummaryByID(alloc.Namespace, alloc.JobID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	sort.Sort(AllocIDSort(nonterm))
-	sort.Sort(AllocIDSort(out))
-
-	if !reflect.DeepEqual(nonterm, out) {
-		t.Fatalf("bad: %#v %#v", nonterm, out)
+	tgSummary := summary.Summary["web"]
+	if tgSummary.Starting != 1 {
+		t.Fatalf("expected starting: %v, actual: %v", 1, tgSummary.Starting)
 	}
-}
-
-func TestStateStore_AllocsByJob(t *testing.T) {
-	state := testStateStore(t)
-	var allocs []*structs.Allocation
 
-	for i := 0; i < 10; i++ {
-		alloc := mock.Alloc()
-		alloc.JobID = "foo"
-		allocs = append(allocs, alloc)
-	}
+	alloc2 := mock.Alloc()
+	alloc2.ID = alloc.ID
+	alloc2.NodeID = alloc.NodeID + ".new"
+	state.UpsertJobSummary(1001, mock.JobSummary(alloc2.JobID))
 
-	for i, alloc := range allocs {
-		state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID))
-	}
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: alloc2.ID},
+		watch.Item{AllocEval: alloc2.EvalID},
+		watch.Item{AllocJob: alloc2.JobID},
+		watch.Item{AllocNode: alloc2.NodeID})
 
-	err := state.UpsertAllocs(1000, allocs)
+	err = state.UpsertAllocs(1002, []*structs.Allocation{alloc2})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.AllocsByJob("foo")
+	out, err := state.AllocByID(alloc.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	sort.Sort(AllocIDSort(allocs))
-	sort.Sort(AllocIDSort(out))
-
-	if !reflect.DeepEqual(allocs, out) {
-		t.Fatalf("bad: %#v %#v", allocs, out)
-	}
-}
-
-func TestStateStore_AllocsByIDPrefix(t *testing.T) {
-	state := testStateStore(t)
-	var allocs []*structs.Allocation
-
-	ids := []string{
-		"aaaaaaaa-7bfb-395d-eb95-0685af2176b2",
-		"aaaaaaab-7bfb-395d-eb95-0685af2176b2",
-		"aaaaaabb-7bfb-395d-eb95-0685af2176b2",
-		"aaaaabbb-7bfb-395d-eb95-0685af2176b2",
-		"aaaabbbb-7bfb-395d-eb95-0685af2176b2",
-		"aaabbbbb-7bfb-395d-eb95-0685af2176b2",
-		"aabbbbbb-7bfb-395d-eb95-0685af2176b2",
-		"abbbbbbb-7bfb-395d-eb95-0685af2176b2",
-		"bbbbbbbb-7bfb-395d-eb95-0685af2176b2",
-	}
-	for i := 0; i < 9; i++ {
-		alloc := mock.Alloc()
-		alloc.ID = ids[i]
-		allocs = append(allocs, alloc)
+	if !reflect.DeepEqual(alloc2, out) {
+		t.Fatalf("bad: %#v %#v", alloc2, out)
 	}
 
-	for i, alloc := range allocs {
-		state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID))
+	if out.CreateIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
 	}
-
-	err := state.UpsertAllocs(1000, allocs)
-	if err != nil {
-		t.Fatalf("err: %v", err)
+	if out.ModifyIndex != 1002 {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	iter, err := state.AllocsByIDPrefix("aaaa")
+	index, err := state.Index("allocs")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	gatherAllocs := func(iter memdb.ResultIterator) []*structs.Allocation {
-		var allocs []*structs.Allocation
-		for {
-			raw := iter.Next()
-			if raw == nil {
-				break
-			}
-			allocs = append(allocs, raw.(*structs.Allocation))
-		}
-		return allocs
-	}
-
-	out := gatherAllocs(iter)
-	if len(out) != 5 {
-		t.Fatalf("bad: expected five allocations, got: %#v", out)
-	}
-
-	sort.Sort(AllocIDSort(allocs))
-
-	for index, alloc := range out {
-		if ids[index] != alloc.ID {
-			t.Fatalf("bad: got unexpected id: %s", alloc.ID)
-		}
+	if index != 1002 {
+		t.Fatalf("bad: %d", index)
 	}
 
-	iter, err = state.AllocsByIDPrefix("b-a7bfb")
+	// Ensure that summary hasb't changed
+	summary, err = state.JobSummaryByID(alloc.Namespace, alloc.JobID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	out = gatherAllocs(iter)
-	if len(out) != 0 {
-		t.Fatalf("bad: unexpected zero allocations, got: %#v", out)
+	tgSummary = summary.Summary["web"]
+	if tgSummary.Starting != 1 {
+		t.Fatalf("expected starting: %v, actual: %v", 1, tgSummary.Starting)
 	}
+
+	notify.verify(t)
 }
 
-func TestStateStore_Allocs(t *testing.T) {
+// This test ensures that the state store will mark the clients status as lost
+// when set rather than preferring the existing status.
+func TestStateStore_UpdateAlloc_Lost(t *testing.T) {
 	state := testStateStore(t)
-	var allocs []*structs.Allocation
+	alloc := mock.Alloc()
+	alloc.ClientStatus = "foo"
 
-	for i := 0; i < 10; i++ {
-		alloc := mock.Alloc()
-		allocs = append(allocs, alloc)
-	}
-	for i, alloc := range allocs {
-		state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID))
+	if err := state.UpsertJob(999, alloc.Job); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	err := state.UpsertAllocs(1000, allocs)
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	iter, err := state.Allocs()
-	if err != nil {
+	alloc2 := new(structs.Allocation)
+	*alloc2 = *alloc
+	alloc2.ClientStatus = structs.AllocClientStatusLost
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc2}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	var out []*structs.Allocation
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
-		}
-		out = append(out, raw.(*structs.Allocation))
+	out, err := state.AllocByID(alloc2.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	sort.Sort(AllocIDSort(allocs))
-	sort.Sort(AllocIDSort(out))
-
-	if !reflect.DeepEqual(allocs, out) {
-		t.Fatalf("bad: %#v %#v", allocs, out)
+	if out.ClientStatus != structs.AllocClientStatusLost {
+		t.Fatalf("bad: %#v", out)
 	}
 }
 
-func TestStateStore_RestoreAlloc(t *testing.T) {
+// This test ensures an allocation can be updated when there is no job
+// associated with it. This will happen when a job is stopped by an user which
+// has non-terminal allocations on clients
+func TestStateStore_UpdateAlloc_NoJob(t *testing.T) {
 	state := testStateStore(t)
 	alloc := mock.Alloc()
 
-	notify := setupNotifyTest(
-		state,
-		watch.Item{Table: "allocs"},
-		watch.Item{Alloc: alloc.ID},
-		watch.Item{AllocEval: alloc.EvalID},
-		watch.Item{AllocJob: alloc.JobID},
-		watch.Item{AllocNode: alloc.NodeID})
-
-	restore, err := state.Restore()
-	if err != nil {
-		t.Fatalf("err: %v", err)
-	}
-
-	err = restore.AllocRestore(alloc)
-	if err != nil {
+	// Upsert a job
+	state.UpsertJobSummary(998, mock.JobSummary(alloc.JobID))
+	if err := state.UpsertJob(999, alloc.Job); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	restore.Commit()
-
-	out, err := state.AllocByID(alloc.ID)
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if !reflect.DeepEqual(out, alloc) {
-		t.Fatalf("Bad: %#v %#v", out, alloc)
-	}
-
-	notify.verify(t)
-}
-
-func TestStateStore_RestoreAlloc_NoEphemeralDisk(t *testing.T) {
-	state := testStateStore(t)
-	alloc := mock.Alloc()
-	alloc.Job.TaskGroups[0].EphemeralDisk = nil
-	alloc.Job.TaskGroups[0].Tasks[0].Resources.DiskMB = 120
-
-	restore, err := state.Restore()
-	if err != nil {
+	if err := state.DeleteJob(1001, alloc.Namespace, alloc.JobID); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	err = restore.AllocRestore(alloc)
-	if err != nil {
+	// Update the desired state of the allocation to stop
+	allocCopy := alloc.Copy()
+	allocCopy.DesiredStatus = structs.AllocDesiredStatusStop
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{allocCopy}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	restore.Commit()
-
-	out, err := state.AllocByID(alloc.ID)
-	if err != nil {
+	// Update the client state of the allocation to complete
+	allocCopy1 := allocCopy.Copy()
+	allocCopy1.ClientStatus = structs.AllocClientStatusComplete
+	if err := state.UpdateAllocsFromClient(1003, []*structs.Allocation{allocCopy1}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	expected := alloc.Copy()
-	expected.Job.TaskGroups[0].EphemeralDisk = &structs.EphemeralDisk{SizeMB: 120}
-	expected.Job.TaskGroups[0].Tasks[0].Resources.DiskMB = 0
-
-	if !reflect.DeepEqual(out, expected) {
-		t.Fatalf("Bad: %#v %#v", out, expected)
+	out, _ := state.AllocByID(alloc.ID)
+	// Update the modify index of the alloc before comparing
+	allocCopy1.ModifyIndex = 1003
+	if !reflect.DeepEqual(out, allocCopy1) {
+		t.Fatalf("expected: %#v \n actual: %#v", allocCopy1, out)
 	}
 }
 
-func TestStateStore_SetJobStatus_ForceStatus(t *testing.T) {
+func TestStateStore_JobSummary(t *testing.T) {
 	state := testStateStore(t)
-	watcher := watch.NewItems()
-	txn := state.db.Txn(true)
 
-	// Create and insert a mock job.
+	// Add a job
 	job := mock.Job()
-	job.Status = ""
-	job.ModifyIndex = 0
-	if err := txn.Insert("jobs", job); err != nil {
-		t.Fatalf("job insert failed: %v", err)
-	}
+	state.UpsertJob(900, job)
+
+	// Get the job back
+	outJob, _ := state.JobByID(job.Namespace, job.ID)
+	if outJob.CreateIndex != 900 {
+		t.Fatalf("bad create index: %v", outJob.CreateIndex)
+	}
+	summary, _ := state.JobSummaryByID(job.Namespace, job.ID)
+	if summary.CreateIndex != 900 {
+		t.Fatalf("bad create index: %v", summary.CreateIndex)
+	}
+
+	// Upser an allocation
+	alloc := mock.Alloc()
+	alloc.JobID = job.ID
+	alloc.Job = job
+	state.UpsertAllocs(910, []*structs.Allocation{alloc})
+
+	// Update the alloc from client
+	alloc1 := alloc.Copy()
+	alloc1.ClientStatus = structs.AllocClientStatusPending
+	alloc1.DesiredStatus = ""
+	state.UpdateAllocsFromClient(920, []*structs.Allocation{alloc})
+
+	alloc3 := alloc.Copy()
+	alloc3.ClientStatus = structs.AllocClientStatusRunning
+	alloc3.DesiredStatus = ""
+	state.UpdateAllocsFromClient(930, []*structs.Allocation{alloc3})
+
+	// Upsert the alloc
+	alloc4 := alloc.Copy()
+	alloc4.ClientStatus = structs.AllocClientStatusPending
+	alloc4.DesiredStatus = structs.AllocDesiredStatusRun
+	state.UpsertAllocs(950, []*structs.Allocation{alloc4})
+
+	// Again upsert the alloc
+	alloc5 := alloc.Copy()
+	alloc5.ClientStatus = structs.AllocClientStatusPending
+	alloc5.DesiredStatus = structs.AllocDesiredStatusRun
+	state.UpsertAllocs(970, []*structs.Allocation{alloc5})
+
+	expectedSummary := structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     job.ID,
+		Summary: map[string]structs.TaskGroupSummary{
+			"web": structs.TaskGroupSummary{
+				Running: 1,
+			},
+		},
+		CreateIndex: 900,
+		ModifyIndex: 930,
+	}
+
+	summary, _ = state.JobSummaryByID(job.Namespace, job.ID)
+	if !reflect.DeepEqual(&expectedSummary, summary) {
+		t.Fatalf("expected: %#v, actual: %v", expectedSummary, summary)
+	}
+
+	// De-register the job.
+	state.DeleteJob(980, job.Namespace, job.ID)
+
+	// Shouldn't have any effect on the summary
+	alloc6 := alloc.Copy()
+	alloc6.ClientStatus = structs.AllocClientStatusRunning
+	alloc6.DesiredStatus = ""
+	state.UpdateAllocsFromClient(990, []*structs.Allocation{alloc6})
+
+	// We shouldn't have any summary at this point
+	summary, _ = state.JobSummaryByID(job.Namespace, job.ID)
+	if summary != nil {
+		t.Fatalf("expected nil, actual: %#v", summary)
+	}
+
+	// Re-register the same job
+	job1 := mock.Job()
+	job1.ID = job.ID
+	state.UpsertJob(1000, job1)
+	outJob2, _ := state.JobByID(job1.Namespace, job1.ID)
+	if outJob2.CreateIndex != 1000 {
+		t.Fatalf("bad create index: %v", outJob2.CreateIndex)
+	}
+	summary, _ = state.JobSummaryByID(job1.Namespace, job1.ID)
+	if summary.CreateIndex != 1000 {
+		t.Fatalf("bad create index: %v", summary.CreateIndex)
+	}
+
+	// Upsert an allocation
+	alloc7 := alloc.Copy()
+	alloc7.JobID = outJob.ID
+	alloc7.Job = outJob
+	alloc7.ClientStatus = structs.AllocClientStatusComplete
+	alloc7.DesiredStatus = structs.AllocDesiredStatusRun
+	state.UpdateAllocsFromClient(1020, []*structs.Allocation{alloc7})
+
+	expectedSummary = structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     job.ID,
+		Summary: map[string]structs.TaskGroupSummary{
+			"web": structs.TaskGroupSummary{},
+		},
+		CreateIndex: 1000,
+		ModifyIndex: 1000,
+	}
+
+	summary, _ = state.JobSummaryByID(job1.Namespace, job1.ID)
+	if !reflect.DeepEqual(&expectedSummary, summary) {
+		t.Fatalf("expected: %#v, actual: %#v", expectedSummary, summary)
+	}
+}
+
+func TestStateStore_ReconcileJobSummary(t *testing.T) {
+	state := testStateStore(t)
+
+	// Create an alloc
+	alloc := mock.Alloc()
+
+	// Add another task group to the job
+	tg2 := alloc.Job.TaskGroups[0].Copy()
+	tg2.Name = "db"
+	alloc.Job.TaskGroups = append(alloc.Job.TaskGroups, tg2)
+	state.UpsertJob(100, alloc.Job)
+
+	// Create one more alloc for the db task group
+	alloc2 := mock.Alloc()
+	alloc2.TaskGroup = "db"
+	alloc2.JobID = alloc.JobID
+	alloc2.Job = alloc.Job
+
+	// Upserts the alloc
+	state.UpsertAllocs(110, []*structs.Allocation{alloc, alloc2})
+
+	// Change the state of the first alloc to running
+	alloc3 := alloc.Copy()
+	alloc3.ClientStatus = structs.AllocClientStatusRunning
+	state.UpdateAllocsFromClient(120, []*structs.Allocation{alloc3})
+
+	//Add some more allocs to the second tg
+	alloc4 := mock.Alloc()
+	alloc4.JobID = alloc.JobID
+	alloc4.Job = alloc.Job
+	alloc4.TaskGroup = "db"
+	alloc5 := alloc4.Copy()
+	alloc5.ClientStatus = structs.AllocClientStatusRunning
+
+	alloc6 := mock.Alloc()
+	alloc6.JobID = alloc.JobID
+	alloc6.Job = alloc.Job
+	alloc6.TaskGroup = "db"
+	alloc7 := alloc6.Copy()
+	alloc7.ClientStatus = structs.AllocClientStatusComplete
+
+	alloc8 := mock.Alloc()
+	alloc8.JobID = alloc.JobID
+	alloc8.Job = alloc.Job
+	alloc8.TaskGroup = "db"
+	alloc9 := alloc8.Copy()
+	alloc9.ClientStatus = structs.AllocClientStatusFailed
+
+	alloc10 := mock.Alloc()
+	alloc10.JobID = alloc.JobID
+	alloc10.Job = alloc.Job
+	alloc10.TaskGroup = "db"
+	alloc11 := alloc10.Copy()
+	alloc11.ClientStatus = structs.AllocClientStatusLost
+
+	state.UpsertAllocs(130, []*structs.Allocation{alloc4, alloc6, alloc8, alloc10})
+
+	state.UpdateAllocsFromClient(150, []*structs.Allocation{alloc5, alloc7, alloc9, alloc11})
+
+	// DeleteJobSummary is a helper method and doesn't modify the indexes table
+	state.DeleteJobSummary(130, alloc.Job.Namespace, alloc.Job.ID)
+
+	state.ReconcileJobSummaries(120)
+
+	summary, _ := state.JobSummaryByID(alloc.Job.Namespace, alloc.Job.ID)
+	expectedSummary := structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     alloc.Job.ID,
+		Summary: map[string]structs.TaskGroupSummary{
+			"web": structs.TaskGroupSummary{
+				Running: 1,
+			},
+			"db": structs.TaskGroupSummary{
+				Starting: 1,
+				Running:  1,
+				Failed:   1,
+				Complete: 1,
+				Lost:     1,
+			},
+		},
+		CreateIndex: 100,
+		ModifyIndex: 120,
+	}
+	if !reflect.DeepEqual(&expectedSummary, summary) {
+		t.Fatalf("expected: %v, actual: %v", expectedSummary, summary)
+	}
+}
+
+func TestStateStore_UpdateAlloc_JobNotPresent(t *testing.T) {
+	state := testStateStore(t)
+
+	alloc := mock.Alloc()
+	state.UpsertJob(100, alloc.Job)
+	state.UpsertAllocs(200, []*structs.Allocation{alloc})
+
+	// Delete the job
+	state.DeleteJob(300, alloc.Job.Namespace, alloc.Job.ID)
+
+	// Update the alloc
+	alloc1 := alloc.Copy()
+	alloc1.ClientStatus = structs.AllocClientStatusRunning
+
+	// Updating allocation should not throw any error
+	if err := state.UpdateAllocsFromClient(400, []*structs.Allocation{alloc1}); err != nil {
+		t.Fatalf("expect err: %v", err)
+	}
+
+	// Re-Register the job
+	state.UpsertJob(500, alloc.Job)
+
+	// Update the alloc again
+	alloc2 := alloc.Copy()
+	alloc2.ClientStatus = structs.AllocClientStatusComplete
+	if err := state.UpdateAllocsFromClient(400, []*structs.Allocation{alloc1}); err != nil {
+		t.Fatalf("expect err: %v", err)
+	}
+
+	// Job Summary of the newly registered job shouldn't account for the
+	// allocation update for the older job
+	expectedSummary := structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     alloc1.JobID,
+		Summary: map[string]structs.TaskGroupSummary{
+			"web": structs.TaskGroupSummary{},
+		},
+		CreateIndex: 500,
+		ModifyIndex: 500,
+	}
+	summary, _ := state.JobSummaryByID(alloc.Job.Namespace, alloc.Job.ID)
+	if !reflect.DeepEqual(&expectedSummary, summary) {
+		t.Fatalf("expected: %v, actual: %v", expectedSummary, summary)
+	}
+}
+
+func TestStateStore_EvictAlloc_Alloc(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+
+	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	alloc2 := new(structs.Allocation)
+	*alloc2 = *alloc
+	alloc2.DesiredStatus = structs.AllocDesiredStatusEvict
+	err = state.UpsertAllocs(1001, []*structs.Allocation{alloc2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if out.DesiredStatus != structs.AllocDesiredStatusEvict {
+		t.Fatalf("bad: %#v %#v", alloc, out)
+	}
+
+	index, err := state.Index("allocs")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+}
+
+func TestStateStore_AllocsByNode(t *testing.T) {
+	state := testStateStore(t)
+	var allocs []*structs.Allocation
+
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.NodeID = "foo"
+		allocs = append(allocs, alloc)
+	}
+
+	for idx, alloc := range allocs {
+		state.UpsertJobSummary(uint64(900+idx), mock.JobSummary(alloc.JobID))
+	}
+
+	err := state.UpsertAllocs(1000, allocs)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.AllocsByNode("foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sort.Sort(AllocIDSort(allocs))
+	sort.Sort(AllocIDSort(out))
+
+	if !reflect.DeepEqual(allocs, out) {
+		t.Fatalf("bad: %#v %#v", allocs, out)
+	}
+}
+
+func TestStateStore_AllocsByNodeTerminal(t *testing.T) {
+	state := testStateStore(t)
+	var allocs, term, nonterm []*structs.Allocation
+
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.NodeID = "foo"
+		if i%2 == 0 {
+			alloc.DesiredStatus = structs.AllocDesiredStatusStop
+			term = append(term, alloc)
+		} else {
+			nonterm = append(nonterm, alloc)
+		}
+		allocs = append(allocs, alloc)
+	}
+
+	for idx, alloc := range allocs {
+		state.UpsertJobSummary(uint64(900+idx), mock.JobSummary(alloc.JobID))
+	}
+
+	err := state.UpsertAllocs(1000, allocs)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Verify the terminal allocs
+	out, err := state.AllocsByNodeTerminal("foo", true)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sort.Sort(AllocIDSort(term))
+	sort.Sort(AllocIDSort(out))
+
+	if !reflect.DeepEqual(term, out) {
+		t.Fatalf("bad: %#v %#v", term, out)
+	}
+
+	// Verify the non-terminal allocs
+	out, err = state.AllocsByNodeTerminal("foo", false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sort.Sort(AllocIDSort(nonterm))
+	sort.Sort(AllocIDSort(out))
+
+	if !reflect.DeepEqual(nonterm, out) {
+		t.Fatalf("bad: %#v %#v", nonterm, out)
+	}
+}
+
+func TestStateStore_AllocsByJob(t *testing.T) {
+	state := testStateStore(t)
+	var allocs []*structs.Allocation
+
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.JobID = "foo"
+		allocs = append(allocs, alloc)
+	}
+
+	for i, alloc := range allocs {
+		state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID))
+	}
+
+	err := state.UpsertAllocs(1000, allocs)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.AllocsByJob(structs.DefaultNamespace, "foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sort.Sort(AllocIDSort(allocs))
+	sort.Sort(AllocIDSort(out))
+
+	if !reflect.DeepEqual(allocs, out) {
+		t.Fatalf("bad: %#v %#v", allocs, out)
+	}
+}
+
+func TestStateStore_AllocsByIDPrefix(t *testing.T) {
+	state := testStateStore(t)
+	var allocs []*structs.Allocation
+
+	ids := []string{
+		"aaaaaaaa-7bfb-395d-eb95-0685af2176b2",
+		"aaaaaaab-7bfb-395d-eb95-0685af2176b2",
+		"aaaaaabb-7bfb-395d-eb95-0685af2176b2",
+		"aaaaabbb-7bfb-395d-eb95-0685af2176b2",
+		"aaaabbbb-7bfb-395d-eb95-0685af2176b2",
+		"aaabbbbb-7bfb-395d-eb95-0685af2176b2",
+		"aabbbbbb-7bfb-395d-eb95-0685af2176b2",
+		"abbbbbbb-7bfb-395d-eb95-0685af2176b2",
+		"bbbbbbbb-7bfb-395d-eb95-0685af2176b2",
+	}
+	for i := 0; i < 9; i++ {
+		alloc := mock.Alloc()
+		alloc.ID = ids[i]
+		allocs = append(allocs, alloc)
+	}
+
+	for i, alloc := range allocs {
+		state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID))
+	}
+
+	err := state.UpsertAllocs(1000, allocs)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	iter, err := state.AllocsByIDPrefix("aaaa")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	gatherAllocs := func(iter memdb.ResultIterator) []*structs.Allocation {
+		var allocs []*structs.Allocation
+		for {
+			raw := iter.Next()
+			if raw == nil {
+				break
+			}
+			allocs = append(allocs, raw.(*structs.Allocation))
+		}
+		return allocs
+	}
+
+	out := gatherAllocs(iter)
+	if len(out) != 5 {
+		t.Fatalf("bad: expected five allocations, got: %#v", out)
+	}
+
+	sort.Sort(AllocIDSort(allocs))
+
+	for index, alloc := range out {
+		if ids[index] != alloc.ID {
+			t.Fatalf("bad: got unexpected id: %s", alloc.ID)
+		}
+	}
+
+	iter, err = state.AllocsByIDPrefix("b-a7bfb")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out = gatherAllocs(iter)
+	if len(out) != 0 {
+		t.Fatalf("bad: unexpected zero allocations, got: %#v", out)
+	}
+}
+
+func TestStateStore_Allocs(t *testing.T) {
+	state := testStateStore(t)
+	var allocs []*structs.Allocation
+
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		allocs = append(allocs, alloc)
+	}
+	for i, alloc := range allocs {
+		state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID))
+	}
+
+	err := state.UpsertAllocs(1000, allocs)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	iter, err := state.Allocs()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+
+	sort.Sort(AllocIDSort(allocs))
+	sort.Sort(AllocIDSort(out))
+
+	if !reflect.DeepEqual(allocs, out) {
+		t.Fatalf("bad: %#v %#v", allocs, out)
+	}
+}
+
+func TestStateStore_RestoreAlloc(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: alloc.ID},
+		watch.Item{AllocEval: alloc.EvalID},
+		watch.Item{AllocJob: alloc.JobID},
+		watch.Item{AllocNode: alloc.NodeID})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = restore.AllocRestore(alloc)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	restore.Commit()
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, alloc) {
+		t.Fatalf("Bad: %#v %#v", out, alloc)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_RestoreAlloc_NoEphemeralDisk(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+	alloc.Job.TaskGroups[0].EphemeralDisk = nil
+	alloc.Job.TaskGroups[0].Tasks[0].Resources.DiskMB = 120
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = restore.AllocRestore(alloc)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	restore.Commit()
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := alloc.Copy()
+	expected.Job.TaskGroups[0].EphemeralDisk = &structs.EphemeralDisk{SizeMB: 120}
+	expected.Job.TaskGroups[0].Tasks[0].Resources.DiskMB = 0
+
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Bad: %#v %#v", out, expected)
+	}
+}
+
+func TestStateStore_SetJobStatus_ForceStatus(t *testing.T) {
+	state := testStateStore(t)
+	watcher := watch.NewItems()
+	txn := state.db.Txn(true)
+
+	// Create and insert a mock job.
+	job := mock.Job()
+	job.Status = ""
+	job.ModifyIndex = 0
+	if err := txn.Insert("jobs", job); err != nil {
+		t.Fatalf("job insert failed: %v", err)
+	}
 
 	exp := "foobar"
 	index := uint64(1000)
@@ -2567,590 +3196,1056 @@ func TestStateStore_SetJobStatus_ForceStatus(t *testing.T) {
 		t.Fatalf("setJobStatus() failed: %v", err)
 	}
 
-	i, err := txn.First("jobs", "id", job.ID)
-	if err != nil {
-		t.Fatalf("job lookup failed: %v", err)
+	i, err := txn.First("jobs", "id", job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("job lookup failed: %v", err)
+	}
+	updated := i.(*structs.Job)
+
+	if updated.Status != exp {
+		t.Fatalf("setJobStatus() set %v; expected %v", updated.Status, exp)
+	}
+
+	if updated.ModifyIndex != index {
+		t.Fatalf("setJobStatus() set %d; expected %d", updated.ModifyIndex, index)
+	}
+}
+
+func TestStateStore_SetJobStatus_NoOp(t *testing.T) {
+	state := testStateStore(t)
+	watcher := watch.NewItems()
+	txn := state.db.Txn(true)
+
+	// Create and insert a mock job that should be pending.
+	job := mock.Job()
+	job.Status = structs.JobStatusPending
+	job.ModifyIndex = 10
+	if err := txn.Insert("jobs", job); err != nil {
+		t.Fatalf("job insert failed: %v", err)
+	}
+
+	index := uint64(1000)
+	if err := state.setJobStatus(index, watcher, txn, job, false, ""); err != nil {
+		t.Fatalf("setJobStatus() failed: %v", err)
+	}
+
+	i, err := txn.First("jobs", "id", job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("job lookup failed: %v", err)
+	}
+	updated := i.(*structs.Job)
+
+	if updated.ModifyIndex == index {
+		t.Fatalf("setJobStatus() should have been a no-op")
+	}
+}
+
+func TestStateStore_SetJobStatus(t *testing.T) {
+	state := testStateStore(t)
+	watcher := watch.NewItems()
+	txn := state.db.Txn(true)
+
+	// Create and insert a mock job that should be pending but has an incorrect
+	// status.
+	job := mock.Job()
+	job.Status = "foobar"
+	job.ModifyIndex = 10
+	if err := txn.Insert("jobs", job); err != nil {
+		t.Fatalf("job insert failed: %v", err)
+	}
+
+	index := uint64(1000)
+	if err := state.setJobStatus(index, watcher, txn, job, false, ""); err != nil {
+		t.Fatalf("setJobStatus() failed: %v", err)
+	}
+
+	i, err := txn.First("jobs", "id", job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("job lookup failed: %v", err)
+	}
+	updated := i.(*structs.Job)
+
+	if updated.Status != structs.JobStatusPending {
+		t.Fatalf("setJobStatus() set %v; expected %v", updated.Status, structs.JobStatusPending)
+	}
+
+	if updated.ModifyIndex != index {
+		t.Fatalf("setJobStatus() set %d; expected %d", updated.ModifyIndex, index)
+	}
+}
+
+func TestStateStore_GetJobStatus_NoEvalsOrAllocs(t *testing.T) {
+	job := mock.Job()
+	state := testStateStore(t)
+	txn := state.db.Txn(false)
+	status, err := state.getJobStatus(txn, job, false)
+	if err != nil {
+		t.Fatalf("getJobStatus() failed: %v", err)
+	}
+
+	if status != structs.JobStatusPending {
+		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusPending)
+	}
+}
+
+func TestStateStore_GetJobStatus_NoEvalsOrAllocs_Periodic(t *testing.T) {
+	job := mock.PeriodicJob()
+	state := testStateStore(t)
+	txn := state.db.Txn(false)
+	status, err := state.getJobStatus(txn, job, false)
+	if err != nil {
+		t.Fatalf("getJobStatus() failed: %v", err)
+	}
+
+	if status != structs.JobStatusRunning {
+		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusRunning)
+	}
+}
+
+func TestStateStore_GetJobStatus_NoEvalsOrAllocs_EvalDelete(t *testing.T) {
+	job := mock.Job()
+	state := testStateStore(t)
+	txn := state.db.Txn(false)
+	status, err := state.getJobStatus(txn, job, true)
+	if err != nil {
+		t.Fatalf("getJobStatus() failed: %v", err)
+	}
+
+	if status != structs.JobStatusDead {
+		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusDead)
+	}
+}
+
+func TestStateStore_GetJobStatus_DeadEvalsAndAllocs(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+
+	// Create a mock alloc that is dead.
+	alloc := mock.Alloc()
+	alloc.JobID = job.ID
+	alloc.DesiredStatus = structs.AllocDesiredStatusStop
+	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a mock eval that is complete
+	eval := mock.Eval()
+	eval.JobID = job.ID
+	eval.Status = structs.EvalStatusComplete
+	if err := state.UpsertEvals(1001, []*structs.Evaluation{eval}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	txn := state.db.Txn(false)
+	status, err := state.getJobStatus(txn, job, false)
+	if err != nil {
+		t.Fatalf("getJobStatus() failed: %v", err)
+	}
+
+	if status != structs.JobStatusDead {
+		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusDead)
+	}
+}
+
+func TestStateStore_GetJobStatus_RunningAlloc(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+
+	// Create a mock alloc that is running.
+	alloc := mock.Alloc()
+	alloc.JobID = job.ID
+	alloc.DesiredStatus = structs.AllocDesiredStatusRun
+	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	txn := state.db.Txn(false)
+	status, err := state.getJobStatus(txn, job, true)
+	if err != nil {
+		t.Fatalf("getJobStatus() failed: %v", err)
+	}
+
+	if status != structs.JobStatusRunning {
+		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusRunning)
+	}
+}
+
+func TestStateStore_SetJobStatus_PendingEval(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+
+	// Create a mock eval that is pending.
+	eval := mock.Eval()
+	eval.JobID = job.ID
+	eval.Status = structs.EvalStatusPending
+	if err := state.UpsertEvals(1000, []*structs.Evaluation{eval}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	txn := state.db.Txn(false)
+	status, err := state.getJobStatus(txn, job, true)
+	if err != nil {
+		t.Fatalf("getJobStatus() failed: %v", err)
+	}
+
+	if status != structs.JobStatusPending {
+		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusPending)
+	}
+}
+
+func TestStateWatch_watch(t *testing.T) {
+	sw := newStateWatch()
+	notify1 := make(chan struct{}, 1)
+	notify2 := make(chan struct{}, 1)
+	notify3 := make(chan struct{}, 1)
+
+	// Notifications trigger subscribed channels
+	sw.watch(watch.NewItems(watch.Item{Table: "foo"}), notify1)
+	sw.watch(watch.NewItems(watch.Item{Table: "bar"}), notify2)
+	sw.watch(watch.NewItems(watch.Item{Table: "baz"}), notify3)
+
+	items := watch.NewItems()
+	items.Add(watch.Item{Table: "foo"})
+	items.Add(watch.Item{Table: "bar"})
+
+	sw.notify(items)
+	if len(notify1) != 1 {
+		t.Fatalf("should notify")
 	}
-	updated := i.(*structs.Job)
+	if len(notify2) != 1 {
+		t.Fatalf("should notify")
+	}
+	if len(notify3) != 0 {
+		t.Fatalf("should not notify")
+	}
+}
 
-	if updated.Status != exp {
-		t.Fatalf("setJobStatus() set %v; expected %v", updated.Status, exp)
+func TestStateWatch_stopWatch(t *testing.T) {
+	sw := newStateWatch()
+	notify := make(chan struct{})
+
+	// First subscribe
+	sw.watch(watch.NewItems(watch.Item{Table: "foo"}), notify)
+
+	// Unsubscribe stop notifications
+	sw.stopWatch(watch.NewItems(watch.Item{Table: "foo"}), notify)
+
+	// Check that the group was removed
+	if _, ok := sw.items[watch.Item{Table: "foo"}]; ok {
+		t.Fatalf("should remove group")
 	}
 
-	if updated.ModifyIndex != index {
-		t.Fatalf("setJobStatus() set %d; expected %d", updated.ModifyIndex, index)
+	// Check that we are not notified
+	sw.notify(watch.NewItems(watch.Item{Table: "foo"}))
+	if len(notify) != 0 {
+		t.Fatalf("should not notify")
 	}
 }
 
-func TestStateStore_SetJobStatus_NoOp(t *testing.T) {
+func TestStateJobSummary_UpdateJobCount(t *testing.T) {
 	state := testStateStore(t)
-	watcher := watch.NewItems()
-	txn := state.db.Txn(true)
+	alloc := mock.Alloc()
+	job := alloc.Job
+	job.TaskGroups[0].Count = 3
+	err := state.UpsertJob(1000, job)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	// Create and insert a mock job that should be pending.
-	job := mock.Job()
-	job.Status = structs.JobStatusPending
-	job.ModifyIndex = 10
-	if err := txn.Insert("jobs", job); err != nil {
-		t.Fatalf("job insert failed: %v", err)
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	summary, _ := state.JobSummaryByID(job.Namespace, job.ID)
+	expectedSummary := structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     job.ID,
+		Summary: map[string]structs.TaskGroupSummary{
+			"web": {
+				Starting: 1,
+			},
+		},
+		CreateIndex: 1000,
+		ModifyIndex: 1001,
+	}
+	if !reflect.DeepEqual(summary, &expectedSummary) {
+		t.Fatalf("expected: %v, actual: %v", expectedSummary, summary)
 	}
 
-	index := uint64(1000)
-	if err := state.setJobStatus(index, watcher, txn, job, false, ""); err != nil {
-		t.Fatalf("setJobStatus() failed: %v", err)
+	alloc2 := mock.Alloc()
+	alloc2.Job = job
+	alloc2.JobID = job.ID
+
+	alloc3 := mock.Alloc()
+	alloc3.Job = job
+	alloc3.JobID = job.ID
+
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc2, alloc3}); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	i, err := txn.First("jobs", "id", job.ID)
-	if err != nil {
-		t.Fatalf("job lookup failed: %v", err)
+	outA, _ := state.AllocByID(alloc3.ID)
+
+	summary, _ = state.JobSummaryByID(job.Namespace, job.ID)
+	expectedSummary = structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     job.ID,
+		Summary: map[string]structs.TaskGroupSummary{
+			"web": {
+				Starting: 3,
+			},
+		},
+		CreateIndex: job.CreateIndex,
+		ModifyIndex: outA.ModifyIndex,
+	}
+	if !reflect.DeepEqual(summary, &expectedSummary) {
+		t.Fatalf("expected summary: %v, actual: %v", expectedSummary, summary)
 	}
-	updated := i.(*structs.Job)
 
-	if updated.ModifyIndex == index {
-		t.Fatalf("setJobStatus() should have been a no-op")
+	alloc4 := mock.Alloc()
+	alloc4.ID = alloc2.ID
+	alloc4.Job = alloc2.Job
+	alloc4.JobID = alloc2.JobID
+	alloc4.ClientStatus = structs.AllocClientStatusComplete
+
+	alloc5 := mock.Alloc()
+	alloc5.ID = alloc3.ID
+	alloc5.Job = alloc3.Job
+	alloc5.JobID = alloc3.JobID
+	alloc5.ClientStatus = structs.AllocClientStatusComplete
+
+	if err := state.UpdateAllocsFromClient(1004, []*structs.Allocation{alloc4, alloc5}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	outA, _ = state.AllocByID(alloc5.ID)
+	summary, _ = state.JobSummaryByID(job.Namespace, job.ID)
+	expectedSummary = structs.JobSummary{
+		Namespace: structs.DefaultNamespace,
+		JobID:     job.ID,
+		Summary: map[string]structs.TaskGroupSummary{
+			"web": {
+				Complete: 2,
+				Starting: 1,
+			},
+		},
+		CreateIndex: job.CreateIndex,
+		ModifyIndex: outA.ModifyIndex,
+	}
+	if !reflect.DeepEqual(summary, &expectedSummary) {
+		t.Fatalf("expected: %v, actual: %v", expectedSummary, summary)
 	}
 }
 
-func TestStateStore_SetJobStatus(t *testing.T) {
+func TestJobSummary_UpdateClientStatus(t *testing.T) {
 	state := testStateStore(t)
-	watcher := watch.NewItems()
-	txn := state.db.Txn(true)
+	alloc := mock.Alloc()
+	job := alloc.Job
+	job.TaskGroups[0].Count = 3
 
-	// Create and insert a mock job that should be pending but has an incorrect
-	// status.
-	job := mock.Job()
-	job.Status = "foobar"
-	job.ModifyIndex = 10
-	if err := txn.Insert("jobs", job); err != nil {
-		t.Fatalf("job insert failed: %v", err)
-	}
+	alloc2 := mock.Alloc()
+	alloc2.Job = job
+	alloc2.JobID = job.ID
 
-	index := uint64(1000)
-	if err := state.setJobStatus(index, watcher, txn, job, false, ""); err != nil {
-		t.Fatalf("setJobStatus() failed: %v", err)
-	}
+	alloc3 := mock.Alloc()
+	alloc3.Job = job
+	alloc3.JobID = job.ID
 
-	i, err := txn.First("jobs", "id", job.ID)
+	err := state.UpsertJob(1000, job)
 	if err != nil {
-		t.Fatalf("job lookup failed: %v", err)
+		t.Fatalf("err: %v", err)
 	}
-	updated := i.(*structs.Job)
 
-	if updated.Status != structs.JobStatusPending {
-		t.Fatalf("setJobStatus() set %v; expected %v", updated.Status, structs.JobStatusPending)
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc, alloc2, alloc3}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	summary, _ := state.JobSummaryByID(job.Namespace, job.ID)
+	if summary.Summary["web"].Starting != 3 {
+		t.Fatalf("bad job summary: %v", summary)
 	}
 
-	if updated.ModifyIndex != index {
-		t.Fatalf("setJobStatus() set %d; expected %d", updated.ModifyIndex, index)
+	alloc4 := mock.Alloc()
+	alloc4.ID = alloc2.ID
+	alloc4.Job = alloc2.Job
+	alloc4.JobID = alloc2.JobID
+	alloc4.ClientStatus = structs.AllocClientStatusComplete
+
+	alloc5 := mock.Alloc()
+	alloc5.ID = alloc3.ID
+	alloc5.Job = alloc3.Job
+	alloc5.JobID = alloc3.JobID
+	alloc5.ClientStatus = structs.AllocClientStatusFailed
+
+	alloc6 := mock.Alloc()
+	alloc6.ID = alloc.ID
+	alloc6.Job = alloc.Job
+	alloc6.JobID = alloc.JobID
+	alloc6.ClientStatus = structs.AllocClientStatusRunning
+
+	if err := state.UpdateAllocsFromClient(1002, []*structs.Allocation{alloc4, alloc5, alloc6}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	summary, _ = state.JobSummaryByID(job.Namespace, job.ID)
+	if summary.Summary["web"].Running != 1 || summary.Summary["web"].Failed != 1 || summary.Summary["web"].Complete != 1 {
+		t.Fatalf("bad job summary: %v", summary)
+	}
+
+	alloc7 := mock.Alloc()
+	alloc7.Job = alloc.Job
+	alloc7.JobID = alloc.JobID
+
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc7}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	summary, _ = state.JobSummaryByID(job.Namespace, job.ID)
+	if summary.Summary["web"].Starting != 1 || summary.Summary["web"].Running != 1 || summary.Summary["web"].Failed != 1 || summary.Summary["web"].Complete != 1 {
+		t.Fatalf("bad job summary: %v", summary)
 	}
 }
 
-func TestStateStore_GetJobStatus_NoEvalsOrAllocs(t *testing.T) {
-	job := mock.Job()
+func TestStateStore_UpsertVaultAccessors(t *testing.T) {
 	state := testStateStore(t)
-	txn := state.db.Txn(false)
-	status, err := state.getJobStatus(txn, job, false)
+	a := mock.VaultAccessor()
+	a2 := mock.VaultAccessor()
+
+	err := state.UpsertVaultAccessor(1000, []*structs.VaultAccessor{a, a2})
 	if err != nil {
-		t.Fatalf("getJobStatus() failed: %v", err)
+		t.Fatalf("err: %v", err)
 	}
 
-	if status != structs.JobStatusPending {
-		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusPending)
+	out, err := state.VaultAccessor(a.Accessor)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-}
 
-func TestStateStore_GetJobStatus_NoEvalsOrAllocs_Periodic(t *testing.T) {
-	job := mock.PeriodicJob()
-	state := testStateStore(t)
-	txn := state.db.Txn(false)
-	status, err := state.getJobStatus(txn, job, false)
+	if !reflect.DeepEqual(a, out) {
+		t.Fatalf("bad: %#v %#v", a, out)
+	}
+
+	out, err = state.VaultAccessor(a2.Accessor)
 	if err != nil {
-		t.Fatalf("getJobStatus() failed: %v", err)
+		t.Fatalf("err: %v", err)
 	}
 
-	if status != structs.JobStatusRunning {
-		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusRunning)
+	if !reflect.DeepEqual(a2, out) {
+		t.Fatalf("bad: %#v %#v", a2, out)
+	}
+
+	iter, err := state.VaultAccessors()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	count := 0
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+
+		count++
+		accessor := raw.(*structs.VaultAccessor)
+
+		if !reflect.DeepEqual(accessor, a) && !reflect.DeepEqual(accessor, a2) {
+			t.Fatalf("bad: %#v", accessor)
+		}
+	}
+
+	if count != 2 {
+		t.Fatalf("bad: %d", count)
 	}
-}
 
-func TestStateStore_GetJobStatus_NoEvalsOrAllocs_EvalDelete(t *testing.T) {
-	job := mock.Job()
-	state := testStateStore(t)
-	txn := state.db.Txn(false)
-	status, err := state.getJobStatus(txn, job, true)
+	index, err := state.Index("vault_accessors")
 	if err != nil {
-		t.Fatalf("getJobStatus() failed: %v", err)
+		t.Fatalf("err: %v", err)
 	}
-
-	if status != structs.JobStatusDead {
-		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusDead)
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
 	}
 }
 
-func TestStateStore_GetJobStatus_DeadEvalsAndAllocs(t *testing.T) {
+func TestStateStore_DeleteVaultAccessors(t *testing.T) {
 	state := testStateStore(t)
-	job := mock.Job()
+	a1 := mock.VaultAccessor()
+	a2 := mock.VaultAccessor()
+	accessors := []*structs.VaultAccessor{a1, a2}
 
-	// Create a mock alloc that is dead.
-	alloc := mock.Alloc()
-	alloc.JobID = job.ID
-	alloc.DesiredStatus = structs.AllocDesiredStatusStop
-	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
-	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+	err := state.UpsertVaultAccessor(1000, accessors)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Create a mock eval that is complete
-	eval := mock.Eval()
-	eval.JobID = job.ID
-	eval.Status = structs.EvalStatusComplete
-	if err := state.UpsertEvals(1001, []*structs.Evaluation{eval}); err != nil {
+	err = state.DeleteVaultAccessors(1001, accessors)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	txn := state.db.Txn(false)
-	status, err := state.getJobStatus(txn, job, false)
+	out, err := state.VaultAccessor(a1.Accessor)
 	if err != nil {
-		t.Fatalf("getJobStatus() failed: %v", err)
+		t.Fatalf("err: %v", err)
 	}
-
-	if status != structs.JobStatusDead {
-		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusDead)
+	if out != nil {
+		t.Fatalf("bad: %#v %#v", a1, out)
 	}
-}
-
-func TestStateStore_GetJobStatus_RunningAlloc(t *testing.T) {
-	state := testStateStore(t)
-	job := mock.Job()
-
-	// Create a mock alloc that is running.
-	alloc := mock.Alloc()
-	alloc.JobID = job.ID
-	alloc.DesiredStatus = structs.AllocDesiredStatusRun
-	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
-	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+	out, err = state.VaultAccessor(a2.Accessor)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if out != nil {
+		t.Fatalf("bad: %#v %#v", a2, out)
+	}
 
-	txn := state.db.Txn(false)
-	status, err := state.getJobStatus(txn, job, true)
+	index, err := state.Index("vault_accessors")
 	if err != nil {
-		t.Fatalf("getJobStatus() failed: %v", err)
+		t.Fatalf("err: %v", err)
 	}
-
-	if status != structs.JobStatusRunning {
-		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusRunning)
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
 	}
 }
 
-func TestStateStore_SetJobStatus_PendingEval(t *testing.T) {
+func TestStateStore_VaultAccessorsByAlloc(t *testing.T) {
 	state := testStateStore(t)
-	job := mock.Job()
+	alloc := mock.Alloc()
+	var accessors []*structs.VaultAccessor
+	var expected []*structs.VaultAccessor
 
-	// Create a mock eval that is pending.
-	eval := mock.Eval()
-	eval.JobID = job.ID
-	eval.Status = structs.EvalStatusPending
-	if err := state.UpsertEvals(1000, []*structs.Evaluation{eval}); err != nil {
-		t.Fatalf("err: %v", err)
+	for i := 0; i < 5; i++ {
+		accessor := mock.VaultAccessor()
+		accessor.AllocID = alloc.ID
+		expected = append(expected, accessor)
+		accessors = append(accessors, accessor)
 	}
 
-	txn := state.db.Txn(false)
-	status, err := state.getJobStatus(txn, job, true)
-	if err != nil {
-		t.Fatalf("getJobStatus() failed: %v", err)
+	for i := 0; i < 10; i++ {
+		accessor := mock.VaultAccessor()
+		accessors = append(accessors, accessor)
 	}
 
-	if status != structs.JobStatusPending {
-		t.Fatalf("getJobStatus() returned %v; expected %v", status, structs.JobStatusPending)
+	err := state.UpsertVaultAccessor(1000, accessors)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-}
-
-func TestStateWatch_watch(t *testing.T) {
-	sw := newStateWatch()
-	notify1 := make(chan struct{}, 1)
-	notify2 := make(chan struct{}, 1)
-	notify3 := make(chan struct{}, 1)
-
-	// Notifications trigger subscribed channels
-	sw.watch(watch.NewItems(watch.Item{Table: "foo"}), notify1)
-	sw.watch(watch.NewItems(watch.Item{Table: "bar"}), notify2)
-	sw.watch(watch.NewItems(watch.Item{Table: "baz"}), notify3)
 
-	items := watch.NewItems()
-	items.Add(watch.Item{Table: "foo"})
-	items.Add(watch.Item{Table: "bar"})
+	out, err := state.VaultAccessorsByAlloc(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	sw.notify(items)
-	if len(notify1) != 1 {
-		t.Fatalf("should notify")
+	if len(expected) != len(out) {
+		t.Fatalf("bad: %#v %#v", len(expected), len(out))
 	}
-	if len(notify2) != 1 {
-		t.Fatalf("should notify")
+
+	index, err := state.Index("vault_accessors")
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-	if len(notify3) != 0 {
-		t.Fatalf("should not notify")
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
 	}
 }
 
-func TestStateWatch_stopWatch(t *testing.T) {
-	sw := newStateWatch()
-	notify := make(chan struct{})
-
-	// First subscribe
-	sw.watch(watch.NewItems(watch.Item{Table: "foo"}), notify)
-
-	// Unsubscribe stop notifications
-	sw.stopWatch(watch.NewItems(watch.Item{Table: "foo"}), notify)
+func TestStateStore_VaultAccessorsByNode(t *testing.T) {
+	state := testStateStore(t)
+	node := mock.Node()
+	var accessors []*structs.VaultAccessor
+	var expected []*structs.VaultAccessor
 
-	// Check that the group was removed
-	if _, ok := sw.items[watch.Item{Table: "foo"}]; ok {
-		t.Fatalf("should remove group")
+	for i := 0; i < 5; i++ {
+		accessor := mock.VaultAccessor()
+		accessor.NodeID = node.ID
+		expected = append(expected, accessor)
+		accessors = append(accessors, accessor)
 	}
 
-	// Check that we are not notified
-	sw.notify(watch.NewItems(watch.Item{Table: "foo"}))
-	if len(notify) != 0 {
-		t.Fatalf("should not notify")
+	for i := 0; i < 10; i++ {
+		accessor := mock.VaultAccessor()
+		accessors = append(accessors, accessor)
 	}
-}
 
-func TestStateJobSummary_UpdateJobCount(t *testing.T) {
-	state := testStateStore(t)
-	alloc := mock.Alloc()
-	job := alloc.Job
-	job.TaskGroups[0].Count = 3
-	err := state.UpsertJob(1000, job)
+	err := state.UpsertVaultAccessor(1000, accessors)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+	out, err := state.VaultAccessorsByNode(node.ID)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	summary, _ := state.JobSummaryByID(job.ID)
-	expectedSummary := structs.JobSummary{
-		JobID: job.ID,
-		Summary: map[string]structs.TaskGroupSummary{
-			"web": {
-				Starting: 1,
-			},
-		},
-		CreateIndex: 1000,
-		ModifyIndex: 1001,
-	}
-	if !reflect.DeepEqual(summary, &expectedSummary) {
-		t.Fatalf("expected: %v, actual: %v", expectedSummary, summary)
-	}
-
-	alloc2 := mock.Alloc()
-	alloc2.Job = job
-	alloc2.JobID = job.ID
 
-	alloc3 := mock.Alloc()
-	alloc3.Job = job
-	alloc3.JobID = job.ID
+	if len(expected) != len(out) {
+		t.Fatalf("bad: %#v %#v", len(expected), len(out))
+	}
 
-	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc2, alloc3}); err != nil {
+	index, err := state.Index("vault_accessors")
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
+	}
+}
 
-	outA, _ := state.AllocByID(alloc3.ID)
+func TestStateStore_SchedulerConfig(t *testing.T) {
+	state := testStateStore(t)
 
-	summary, _ = state.JobSummaryByID(job.ID)
-	expectedSummary = structs.JobSummary{
-		JobID: job.ID,
-		Summary: map[string]structs.TaskGroupSummary{
-			"web": {
-				Starting: 3,
-			},
-		},
-		CreateIndex: job.CreateIndex,
-		ModifyIndex: outA.ModifyIndex,
+	// Nothing is returned if the config was never set
+	out, err := state.SchedulerConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-	if !reflect.DeepEqual(summary, &expectedSummary) {
-		t.Fatalf("expected summary: %v, actual: %v", expectedSummary, summary)
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	alloc4 := mock.Alloc()
-	alloc4.ID = alloc2.ID
-	alloc4.Job = alloc2.Job
-	alloc4.JobID = alloc2.JobID
-	alloc4.ClientStatus = structs.AllocClientStatusComplete
+	config := &structs.SchedulerConfiguration{
+		PreemptionConfig: structs.PreemptionConfig{SystemSchedulerEnabled: true},
+	}
+	if err := state.SchedulerSetConfig(1000, config); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	alloc5 := mock.Alloc()
-	alloc5.ID = alloc3.ID
-	alloc5.Job = alloc3.Job
-	alloc5.JobID = alloc3.JobID
-	alloc5.ClientStatus = structs.AllocClientStatusComplete
+	update := &structs.SchedulerConfiguration{
+		PreemptionConfig: structs.PreemptionConfig{BatchSchedulerEnabled: true},
+	}
+	if err := state.SchedulerSetConfig(1001, update); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	if err := state.UpdateAllocsFromClient(1004, []*structs.Allocation{alloc4, alloc5}); err != nil {
+	out, err = state.SchedulerConfig()
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	outA, _ = state.AllocByID(alloc5.ID)
-	summary, _ = state.JobSummaryByID(job.ID)
-	expectedSummary = structs.JobSummary{
-		JobID: job.ID,
-		Summary: map[string]structs.TaskGroupSummary{
-			"web": {
-				Complete: 2,
-				Starting: 1,
-			},
-		},
-		CreateIndex: job.CreateIndex,
-		ModifyIndex: outA.ModifyIndex,
+	if !reflect.DeepEqual(out, update) {
+		t.Fatalf("bad: %#v %#v", out, update)
 	}
-	if !reflect.DeepEqual(summary, &expectedSummary) {
-		t.Fatalf("expected: %v, actual: %v", expectedSummary, summary)
+	if out.CreateIndex != 1000 || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	index, err := state.Index("scheduler_config")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
 	}
 }
 
-func TestJobSummary_UpdateClientStatus(t *testing.T) {
+func TestStateStore_AutopilotConfig(t *testing.T) {
 	state := testStateStore(t)
-	alloc := mock.Alloc()
-	job := alloc.Job
-	job.TaskGroups[0].Count = 3
-
-	alloc2 := mock.Alloc()
-	alloc2.Job = job
-	alloc2.JobID = job.ID
-
-	alloc3 := mock.Alloc()
-	alloc3.Job = job
-	alloc3.JobID = job.ID
+	config := &structs.AutopilotConfig{
+		CleanupDeadServers:   true,
+		LastContactThreshold: 5 * time.Second,
+		MaxTrailingLogs:      500,
+	}
+	if err := state.AutopilotSetConfig(1000, config); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	err := state.UpsertJob(1000, job)
+	out, err := state.AutopilotConfig()
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if !reflect.DeepEqual(out, config) || out.CreateIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
 
-	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc, alloc2, alloc3}); err != nil {
+	// A check-and-set with a stale index is rejected
+	update := &structs.AutopilotConfig{MaxTrailingLogs: 100}
+	ok, err := state.AutopilotCASConfig(1001, 999, update)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	summary, _ := state.JobSummaryByID(job.ID)
-	if summary.Summary["web"].Starting != 3 {
-		t.Fatalf("bad job summary: %v", summary)
+	if ok {
+		t.Fatalf("expected the stale check-and-set to fail")
 	}
 
-	alloc4 := mock.Alloc()
-	alloc4.ID = alloc2.ID
-	alloc4.Job = alloc2.Job
-	alloc4.JobID = alloc2.JobID
-	alloc4.ClientStatus = structs.AllocClientStatusComplete
-
-	alloc5 := mock.Alloc()
-	alloc5.ID = alloc3.ID
-	alloc5.Job = alloc3.Job
-	alloc5.JobID = alloc3.JobID
-	alloc5.ClientStatus = structs.AllocClientStatusFailed
-
-	alloc6 := mock.Alloc()
-	alloc6.ID = alloc.ID
-	alloc6.Job = alloc.Job
-	alloc6.JobID = alloc.JobID
-	alloc6.ClientStatus = structs.AllocClientStatusRunning
-
-	if err := state.UpdateAllocsFromClient(1002, []*structs.Allocation{alloc4, alloc5, alloc6}); err != nil {
+	// A check-and-set with the current index is applied
+	ok, err = state.AutopilotCASConfig(1002, 1000, update)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	summary, _ = state.JobSummaryByID(job.ID)
-	if summary.Summary["web"].Running != 1 || summary.Summary["web"].Failed != 1 || summary.Summary["web"].Complete != 1 {
-		t.Fatalf("bad job summary: %v", summary)
+	if !ok {
+		t.Fatalf("expected the check-and-set to succeed")
 	}
 
-	alloc7 := mock.Alloc()
-	alloc7.Job = alloc.Job
-	alloc7.JobID = alloc.JobID
+	out, err = state.AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.MaxTrailingLogs != 100 || out.CleanupDeadServers {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.CreateIndex != 1000 || out.ModifyIndex != 1002 {
+		t.Fatalf("bad: %#v", out)
+	}
 
-	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc7}); err != nil {
+	index, err := state.Index("autopilot_config")
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	summary, _ = state.JobSummaryByID(job.ID)
-	if summary.Summary["web"].Starting != 1 || summary.Summary["web"].Running != 1 || summary.Summary["web"].Failed != 1 || summary.Summary["web"].Complete != 1 {
-		t.Fatalf("bad job summary: %v", summary)
+	if index != 1002 {
+		t.Fatalf("bad: %d", index)
 	}
 }
 
-func TestStateStore_UpsertVaultAccessors(t *testing.T) {
+func TestStateStore_UpsertDeleteQuotaSpec(t *testing.T) {
 	state := testStateStore(t)
-	a := mock.VaultAccessor()
-	a2 := mock.VaultAccessor()
+	quota := &structs.QuotaSpec{
+		Name:  "engineering",
+		Limit: &structs.QuotaLimit{CPU: 1000},
+	}
+	if err := state.UpsertQuotaSpec(1000, quota); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	err := state.UpsertVaultAccessor(1000, []*structs.VaultAccessor{a, a2})
+	out, err := state.QuotaSpecByName("engineering")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if !reflect.DeepEqual(out, quota) || out.CreateIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
 
-	out, err := state.VaultAccessor(a.Accessor)
-	if err != nil {
+	// A namespace can't use a missing quota
+	ns := &structs.Namespace{Name: "engineering", Quota: "marketing"}
+	if err := state.UpsertNamespaces(1001, []*structs.Namespace{ns}); err == nil {
+		t.Fatalf("expected an error using a missing quota")
+	}
+
+	// A quota used by a namespace can not be deleted
+	ns.Quota = "engineering"
+	if err := state.UpsertNamespaces(1001, []*structs.Namespace{ns}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if err := state.DeleteQuotaSpec(1002, "engineering"); err == nil {
+		t.Fatalf("expected an error deleting a quota in use")
+	}
 
-	if !reflect.DeepEqual(a, out) {
-		t.Fatalf("bad: %#v %#v", a, out)
+	if err := state.DeleteNamespaces(1003, []string{ns.Name}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.DeleteQuotaSpec(1004, "engineering"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.QuotaSpecByName("engineering")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	out, err = state.VaultAccessor(a2.Accessor)
+	index, err := state.Index("quota_specs")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if index != 1004 {
+		t.Fatalf("bad: %d", index)
+	}
+}
 
-	if !reflect.DeepEqual(a2, out) {
-		t.Fatalf("bad: %#v %#v", a2, out)
+func TestStateStore_UpsertDeleteNamespaces(t *testing.T) {
+	state := testStateStore(t)
+	ns := &structs.Namespace{
+		Name:        "engineering",
+		Description: "Engineering team",
+	}
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	iter, err := state.VaultAccessors()
+	out, err := state.NamespaceByName("engineering")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if !reflect.DeepEqual(out, ns) || out.CreateIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
 
-	count := 0
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
-		}
-
-		count++
-		accessor := raw.(*structs.VaultAccessor)
+	// A namespace with jobs can not be deleted
+	job := mock.Job()
+	job.Namespace = "engineering"
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.DeleteNamespaces(1002, []string{"engineering"}); err == nil {
+		t.Fatalf("expected an error deleting a namespace with jobs")
+	}
 
-		if !reflect.DeepEqual(accessor, a) && !reflect.DeepEqual(accessor, a2) {
-			t.Fatalf("bad: %#v", accessor)
-		}
+	// The default namespace can not be deleted
+	if err := state.DeleteNamespaces(1002, []string{structs.DefaultNamespace}); err == nil {
+		t.Fatalf("expected an error deleting the default namespace")
 	}
 
-	if count != 2 {
-		t.Fatalf("bad: %d", count)
+	if err := state.DeleteJob(1003, job.Namespace, job.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.DeleteNamespaces(1004, []string{"engineering"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NamespaceByName("engineering")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
 	}
 
-	index, err := state.Index("vault_accessors")
+	index, err := state.Index("namespaces")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1000 {
+	if index != 1004 {
 		t.Fatalf("bad: %d", index)
 	}
 }
 
-func TestStateStore_DeleteVaultAccessors(t *testing.T) {
+func TestStateStore_Namespace_SameJobID(t *testing.T) {
 	state := testStateStore(t)
-	a1 := mock.VaultAccessor()
-	a2 := mock.VaultAccessor()
-	accessors := []*structs.VaultAccessor{a1, a2}
 
-	err := state.UpsertVaultAccessor(1000, accessors)
+	// Register the same job ID in two namespaces
+	job := mock.Job()
+	other := mock.Job()
+	other.ID = job.ID
+	other.Namespace = "engineering"
+	other.Priority = 20
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJob(1001, other); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	eval.JobID = other.ID
+	eval.Namespace = other.Namespace
+	if err := state.UpsertEvals(1002, []*structs.Evaluation{eval}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	alloc := mock.Alloc()
+	alloc.Job = other
+	alloc.JobID = other.ID
+	alloc.Namespace = other.Namespace
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookups are scoped to the namespace
+	out, err := state.JobByID(structs.DefaultNamespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Priority != job.Priority {
+		t.Fatalf("bad: %#v", out)
+	}
+	out, err = state.JobByID("engineering", job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if out == nil || out.Priority != 20 {
+		t.Fatalf("bad: %#v", out)
+	}
 
-	err = state.DeleteVaultAccessors(1001, accessors)
+	evals, err := state.EvalsByJob(structs.DefaultNamespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evals) != 0 {
+		t.Fatalf("bad: %#v", evals)
+	}
+	evals, err = state.EvalsByJob("engineering", job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if len(evals) != 1 || evals[0].ID != eval.ID {
+		t.Fatalf("bad: %#v", evals)
+	}
 
-	out, err := state.VaultAccessor(a1.Accessor)
+	allocs, err := state.AllocsByJob(structs.DefaultNamespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if out != nil {
-		t.Fatalf("bad: %#v %#v", a1, out)
+	if len(allocs) != 0 {
+		t.Fatalf("bad: %#v", allocs)
 	}
-	out, err = state.VaultAccessor(a2.Accessor)
+	allocs, err = state.AllocsByJob("engineering", job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if out != nil {
-		t.Fatalf("bad: %#v %#v", a2, out)
+	if len(allocs) != 1 || allocs[0].ID != alloc.ID {
+		t.Fatalf("bad: %#v", allocs)
 	}
 
-	index, err := state.Index("vault_accessors")
+	// Deleting one job leaves the other in place
+	if err := state.DeleteJob(1004, structs.DefaultNamespace, job.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.JobByID(structs.DefaultNamespace, job.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1001 {
-		t.Fatalf("bad: %d", index)
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+	out, err = state.JobByID("engineering", job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("job of another namespace deleted")
+	}
+	summary, err := state.JobSummaryByID("engineering", job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if summary == nil {
+		t.Fatalf("summary of another namespace deleted")
 	}
 }
 
-func TestStateStore_VaultAccessorsByAlloc(t *testing.T) {
+func TestStateStore_Namespace_Defaults(t *testing.T) {
 	state := testStateStore(t)
-	alloc := mock.Alloc()
-	var accessors []*structs.VaultAccessor
-	var expected []*structs.VaultAccessor
 
-	for i := 0; i < 5; i++ {
-		accessor := mock.VaultAccessor()
-		accessor.AllocID = alloc.ID
-		expected = append(expected, accessor)
-		accessors = append(accessors, accessor)
+	// Jobs without a namespace are in the default namespace
+	job := mock.Job()
+	job.Namespace = ""
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := state.JobByID(job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Namespace != structs.DefaultNamespace {
+		t.Fatalf("bad: %q", out.Namespace)
 	}
 
-	for i := 0; i < 10; i++ {
-		accessor := mock.VaultAccessor()
-		accessors = append(accessors, accessor)
+	// Evaluations without a namespace are in the default namespace while
+	// allocations inherit the namespace of their job
+	other := mock.Job()
+	other.Namespace = "engineering"
+	if err := state.UpsertJob(1001, other); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	eval := mock.Eval()
+	eval.JobID = other.ID
+	eval.Namespace = ""
+	if err := state.UpsertEvals(1002, []*structs.Evaluation{eval}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	alloc := mock.Alloc()
+	alloc.Job = other
+	alloc.JobID = other.ID
+	alloc.Namespace = ""
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	err := state.UpsertVaultAccessor(1000, accessors)
+	outEval, err := state.EvalByID(eval.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	out, err := state.VaultAccessorsByAlloc(alloc.ID)
+	if outEval.Namespace != structs.DefaultNamespace {
+		t.Fatalf("bad: %q", outEval.Namespace)
+	}
+	outAlloc, err := state.AllocByID(alloc.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if len(expected) != len(out) {
-		t.Fatalf("bad: %#v %#v", len(expected), len(out))
+	if outAlloc.Namespace != "engineering" {
+		t.Fatalf("bad: %q", outAlloc.Namespace)
 	}
 
-	index, err := state.Index("vault_accessors")
+	// Jobs are listed by namespace
+	iter, err := state.JobsByNamespace("engineering")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1000 {
-		t.Fatalf("bad: %d", index)
+	var ids []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		ids = append(ids, raw.(*structs.Job).ID)
+	}
+	if len(ids) != 1 || ids[0] != other.ID {
+		t.Fatalf("bad: %v", ids)
 	}
 }
 
-func TestStateStore_VaultAccessorsByNode(t *testing.T) {
+func TestStateStore_QuotaUsage(t *testing.T) {
 	state := testStateStore(t)
-	node := mock.Node()
-	var accessors []*structs.VaultAccessor
-	var expected []*structs.VaultAccessor
-
-	for i := 0; i < 5; i++ {
-		accessor := mock.VaultAccessor()
-		accessor.NodeID = node.ID
-		expected = append(expected, accessor)
-		accessors = append(accessors, accessor)
+	quota := &structs.QuotaSpec{
+		Name:  "engineering",
+		Limit: &structs.QuotaLimit{CPU: 1000},
+	}
+	if err := state.UpsertQuotaSpec(1000, quota); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	for i := 0; i < 10; i++ {
-		accessor := mock.VaultAccessor()
-		accessors = append(accessors, accessor)
+	// Create a job of a namespace using the quota and one not using it
+	ns := &structs.Namespace{Name: "engineering", Quota: "engineering"}
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	job := mock.Job()
+	job.Namespace = "engineering"
+	other := mock.Job()
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJob(1002, other); err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	err := state.UpsertVaultAccessor(1000, accessors)
-	if err != nil {
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.Namespace = job.Namespace
+	stopped := mock.Alloc()
+	stopped.Job = job
+	stopped.JobID = job.ID
+	stopped.Namespace = job.Namespace
+	stopped.DesiredStatus = structs.AllocDesiredStatusStop
+	unrelated := mock.Alloc()
+	unrelated.Job = other
+	unrelated.JobID = other.ID
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc, stopped, unrelated}); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err := state.VaultAccessorsByNode(node.ID)
+	usage, err := state.QuotaUsage("engineering")
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if len(expected) != len(out) {
-		t.Fatalf("bad: %#v %#v", len(expected), len(out))
+	if usage.Name != "engineering" || usage.Used.CPU != alloc.Resources.CPU ||
+		usage.Used.MemoryMB != alloc.Resources.MemoryMB {
+		t.Fatalf("bad: %#v", usage.Used)
 	}
 
-	index, err := state.Index("vault_accessors")
+	// A plan stopping the allocation and placing a bigger one
+	placed := mock.Alloc()
+	placed.Job = job
+	placed.JobID = job.ID
+	placed.Namespace = job.Namespace
+	placed.Resources = nil
+	placed.SharedResources = &structs.Resources{DiskMB: 10}
+	placed.TaskResources = map[string]*structs.Resources{
+		"web": &structs.Resources{CPU: 800, MemoryMB: 64},
+	}
+	plan := &structs.Plan{
+		NodeUpdate: map[string][]*structs.Allocation{
+			alloc.NodeID: []*structs.Allocation{alloc},
+		},
+		NodeAllocation: map[string][]*structs.Allocation{
+			placed.NodeID: []*structs.Allocation{placed},
+		},
+	}
+	used, err := state.QuotaUsageAfterPlan("engineering", plan)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1000 {
-		t.Fatalf("bad: %d", index)
+	if used.CPU != 800 || used.MemoryMB != 64 || used.DiskMB != 10 {
+		t.Fatalf("bad: %#v", used)
 	}
 }
 
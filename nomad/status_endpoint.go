@@ -61,3 +61,13 @@ func (s *Status) Peers(args *structs.GenericRequest, reply *[]string) error {
 	*reply = peers
 	return nil
 }
+
+// RaftStats is used by Autopilot to query the Raft stats of the local server.
+func (s *Status) RaftStats(args struct{}, reply *structs.RaftStats) error {
+	stats, err := s.srv.raftStats()
+	if err != nil {
+		return err
+	}
+	*reply = *stats
+	return nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+)
+
+// TLSConfig provides TLS related configuration
+type TLSConfig struct {
+
+	// EnableHTTP enables TLS for the HTTP API of the agent
+	EnableHTTP bool `mapstructure:"http"`
+
+	// EnableRPC enables mutual TLS for the RPC between servers and between
+	// clients and servers. Plain connections are then rejected by servers.
+	EnableRPC bool `mapstructure:"rpc"`
+
+	// VerifyServerHostname is used to verify that the certificate presented
+	// by a server is valid for server.<region>.nomad. This prevents a
+	// compromised client from being restarted as a server.
+	VerifyServerHostname bool `mapstructure:"verify_server_hostname"`
+
+	// VerifyHTTPSClient requires the clients of the HTTP API to present a
+	// certificate signed by the certificate authority.
+	VerifyHTTPSClient bool `mapstructure:"verify_https_client"`
+
+	// CAFile is a path to a certificate authority file used to verify the
+	// certificates presented by the remote end of the connections.
+	CAFile string `mapstructure:"ca_file"`
+
+	// CertFile is the path to the certificate presented by the agent
+	CertFile string `mapstructure:"cert_file"`
+
+	// KeyFile is the path to the private key of the certificate
+	KeyFile string `mapstructure:"key_file"`
+
+	// KeyLoader loads the key pair of the agent and is shared by the copies
+	// of the configuration so that the certificate can be reloaded without
+	// restarting the agent.
+	KeyLoader *KeyLoader `mapstructure:"-"`
+}
+
+// GetKeyLoader returns the key loader of the configuration, creating it if
+// it doesn't exist yet.
+func (t *TLSConfig) GetKeyLoader() *KeyLoader {
+	if t.KeyLoader == nil {
+		t.KeyLoader = new(KeyLoader)
+	}
+	return t.KeyLoader
+}
+
+// Copy returns a copy of this TLS config. The key loader is shared.
+func (t *TLSConfig) Copy() *TLSConfig {
+	if t == nil {
+		return nil
+	}
+
+	nt := new(TLSConfig)
+	*nt = *t
+	return nt
+}
+
+// Merge merges two TLS configurations together.
+func (t *TLSConfig) Merge(b *TLSConfig) *TLSConfig {
+	result := *t
+
+	if b.EnableHTTP {
+		result.EnableHTTP = true
+	}
+	if b.EnableRPC {
+		result.EnableRPC = true
+	}
+	if b.VerifyServerHostname {
+		result.VerifyServerHostname = true
+	}
+	if b.VerifyHTTPSClient {
+		result.VerifyHTTPSClient = true
+	}
+	if b.CAFile != "" {
+		result.CAFile = b.CAFile
+	}
+	if b.CertFile != "" {
+		result.CertFile = b.CertFile
+	}
+	if b.KeyFile != "" {
+		result.KeyFile = b.KeyFile
+	}
+	return &result
+}
+
+// KeyLoader loads a certificate and its private key and serves them to the
+// TLS handshakes, allowing the key pair to be reloaded while connections are
+// being established.
+type KeyLoader struct {
+	cacheLock   sync.RWMutex
+	certificate *tls.Certificate
+}
+
+// LoadKeyPair loads the certificate and private key from the given files,
+// replacing the previously loaded key pair. On error, the previous key pair
+// is kept.
+func (k *KeyLoader) LoadKeyPair(certFile, keyFile string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load cert/key pair: %v", err)
+	}
+
+	k.cacheLock.Lock()
+	defer k.cacheLock.Unlock()
+	k.certificate = &cert
+	return k.certificate, nil
+}
+
+// GetCertificate returns the loaded certificate to serve incoming TLS
+// connections.
+func (k *KeyLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	k.cacheLock.RLock()
+	defer k.cacheLock.RUnlock()
+	if k.certificate == nil {
+		return nil, fmt.Errorf("no certificate loaded")
+	}
+	return k.certificate, nil
+}
+
+// GetClientCertificate returns the loaded certificate to present on outgoing
+// TLS connections.
+func (k *KeyLoader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	k.cacheLock.RLock()
+	defer k.cacheLock.RUnlock()
+	if k.certificate == nil {
+		return &tls.Certificate{}, nil
+	}
+	return k.certificate, nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTLSConfig_Merge(t *testing.T) {
+	a := &TLSConfig{
+		EnableHTTP: true,
+		CAFile:     "a",
+		CertFile:   "a",
+		KeyFile:    "a",
+	}
+
+	b := &TLSConfig{
+		EnableRPC:            true,
+		VerifyServerHostname: true,
+		VerifyHTTPSClient:    true,
+		CAFile:               "b",
+		KeyFile:              "b",
+	}
+
+	e := &TLSConfig{
+		EnableHTTP:           true,
+		EnableRPC:            true,
+		VerifyServerHostname: true,
+		VerifyHTTPSClient:    true,
+		CAFile:               "b",
+		CertFile:             "a",
+		KeyFile:              "b",
+	}
+
+	result := a.Merge(b)
+	if !reflect.DeepEqual(result, e) {
+		t.Fatalf("bad:\n%#v\n%#v", result, e)
+	}
+}
+
+func TestTLSConfig_Copy(t *testing.T) {
+	a := &TLSConfig{
+		EnableRPC: true,
+		CertFile:  "a",
+	}
+	loader := a.GetKeyLoader()
+
+	b := a.Copy()
+	b.CertFile = "b"
+	if a.CertFile != "a" {
+		t.Fatalf("copy modified the original: %#v", a)
+	}
+	if b.GetKeyLoader() != loader {
+		t.Fatalf("expected the key loader to be shared")
+	}
+}
@@ -59,7 +59,11 @@ type JobDiff struct {
 func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 	diff := &JobDiff{Type: DiffTypeNone}
 	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
-	filter := []string{"ID", "Status", "StatusDescription", "CreateIndex", "ModifyIndex", "JobModifyIndex"}
+	flatOpts := &flatmap.Options{
+		Filter:        []string{"ID"},
+		IgnoreFields:  []string{"Status", "StatusDescription", "Version", "Stable", "CreateIndex", "ModifyIndex", "JobModifyIndex"},
+		PrimitiveOnly: true,
+	}
 
 	// Have to treat this special since it is a struct literal, not a pointer
 	var jUpdate, otherUpdate *UpdateStrategy
@@ -70,13 +74,13 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 		j = &Job{}
 		otherUpdate = &other.Update
 		diff.Type = DiffTypeAdded
-		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+		newPrimitiveFlat = flatmap.FlattenWithOptions(other, flatOpts)
 		diff.ID = other.ID
 	} else if other == nil {
 		other = &Job{}
 		jUpdate = &j.Update
 		diff.Type = DiffTypeDeleted
-		oldPrimitiveFlat = flatmap.Flatten(j, filter, true)
+		oldPrimitiveFlat = flatmap.FlattenWithOptions(j, flatOpts)
 		diff.ID = j.ID
 	} else {
 		if j.ID != other.ID {
@@ -85,8 +89,8 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 
 		jUpdate = &j.Update
 		otherUpdate = &other.Update
-		oldPrimitiveFlat = flatmap.Flatten(j, filter, true)
-		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+		oldPrimitiveFlat = flatmap.FlattenWithOptions(j, flatOpts)
+		newPrimitiveFlat = flatmap.FlattenWithOptions(other, flatOpts)
 		diff.ID = other.ID
 	}
 
@@ -126,6 +130,16 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 		diff.Objects = append(diff.Objects, pDiff)
 	}
 
+	// Failover diff
+	if fDiff := primitiveObjectDiff(j.Failover, other.Failover, nil, "Failover", contextual); fDiff != nil {
+		diff.Objects = append(diff.Objects, fDiff)
+	}
+
+	// Parameterized job diff
+	if pDiff := parameterizedJobDiff(j.ParameterizedJob, other.ParameterizedJob, contextual); pDiff != nil {
+		diff.Objects = append(diff.Objects, pDiff)
+	}
+
 	// If the job is not a delete or add, determine if there are edits.
 	if diff.Type == DiffTypeNone {
 		tgEdit := false
@@ -223,6 +237,18 @@ func (tg *TaskGroup) Diff(other *TaskGroup, contextual bool) (*TaskGroupDiff, er
 		diff.Objects = append(diff.Objects, rDiff)
 	}
 
+	// Reschedule policy diff
+	reschedDiff := primitiveObjectDiff(tg.ReschedulePolicy, other.ReschedulePolicy, nil, "ReschedulePolicy", contextual)
+	if reschedDiff != nil {
+		diff.Objects = append(diff.Objects, reschedDiff)
+	}
+
+	// Migrate strategy diff
+	migrateDiff := primitiveObjectDiff(tg.Migrate, other.Migrate, nil, "Migrate", contextual)
+	if migrateDiff != nil {
+		diff.Objects = append(diff.Objects, migrateDiff)
+	}
+
 	// EphemeralDisk diff
 	diskDiff := primitiveObjectDiff(tg.EphemeralDisk, other.EphemeralDisk, nil, "EphemeralDisk", contextual)
 	if diskDiff != nil {
@@ -374,12 +400,41 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, rDiff)
 	}
 
+	// ResourceOverrides diff
+	roDiffs := primitiveObjectSetDiff(
+		interfaceSlice(t.ResourceOverrides),
+		interfaceSlice(other.ResourceOverrides),
+		nil,
+		"ResourceOverride",
+		contextual)
+	if roDiffs != nil {
+		diff.Objects = append(diff.Objects, roDiffs...)
+	}
+
 	// LogConfig diff
 	lDiff := primitiveObjectDiff(t.LogConfig, other.LogConfig, nil, "LogConfig", contextual)
 	if lDiff != nil {
 		diff.Objects = append(diff.Objects, lDiff)
 	}
 
+	// DispatchPayload diff
+	dDiff := primitiveObjectDiff(t.DispatchPayload, other.DispatchPayload, nil, "DispatchPayload", contextual)
+	if dDiff != nil {
+		diff.Objects = append(diff.Objects, dDiff)
+	}
+
+	// Lifecycle diff
+	lcDiff := primitiveObjectDiff(t.Lifecycle, other.Lifecycle, nil, "Lifecycle", contextual)
+	if lcDiff != nil {
+		diff.Objects = append(diff.Objects, lcDiff)
+	}
+
+	// EnvFromConsul diff
+	ecDiff := primitiveObjectDiff(t.EnvFromConsul, other.EnvFromConsul, nil, "EnvFromConsul", contextual)
+	if ecDiff != nil {
+		diff.Objects = append(diff.Objects, ecDiff)
+	}
+
 	// Artifacts diff
 	diffs := primitiveObjectSetDiff(
 		interfaceSlice(t.Artifacts),
@@ -391,6 +446,17 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, diffs...)
 	}
 
+	// Templates diff
+	tmplDiffs := primitiveObjectSetDiff(
+		interfaceSlice(t.Templates),
+		interfaceSlice(other.Templates),
+		nil,
+		"Template",
+		contextual)
+	if tmplDiffs != nil {
+		diff.Objects = append(diff.Objects, tmplDiffs...)
+	}
+
 	// Services diff
 	if sDiffs := serviceDiffs(t.Services, other.Services, contextual); sDiffs != nil {
 		diff.Objects = append(diff.Objects, sDiffs...)
@@ -552,6 +618,12 @@ func serviceCheckDiff(old, new *ServiceCheck, contextual bool) *ObjectDiff {
 
 	// Diff the primitive fields.
 	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// CheckRestart diff
+	crDiff := primitiveObjectDiff(old.CheckRestart, new.CheckRestart, nil, "CheckRestart", contextual)
+	if crDiff != nil {
+		diff.Objects = append(diff.Objects, crDiff)
+	}
 	return diff
 }
 
@@ -987,6 +1059,36 @@ func stringSetDiff(old, new []string, name string) *ObjectDiff {
 	return diff
 }
 
+// parameterizedJobDiff diffs the parameterized job configurations. The payload
+// requirement is diffed as a field and the meta keys as sets of strings.
+func parameterizedJobDiff(old, new *ParameterizedJobConfig, contextual bool) *ObjectDiff {
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+
+	diff := primitiveObjectDiff(old, new, nil, "ParameterizedJob", contextual)
+	if diff == nil {
+		diff = &ObjectDiff{Type: DiffTypeEdited, Name: "ParameterizedJob"}
+	}
+
+	if old == nil {
+		old = &ParameterizedJobConfig{}
+		diff.Type = DiffTypeAdded
+	} else if new == nil {
+		new = &ParameterizedJobConfig{}
+		diff.Type = DiffTypeDeleted
+	}
+
+	if setDiff := stringSetDiff(old.MetaRequired, new.MetaRequired, "MetaRequired"); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+	if setDiff := stringSetDiff(old.MetaOptional, new.MetaOptional, "MetaOptional"); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+
+	return diff
+}
+
 // primitiveObjectDiff returns a diff of the passed objects' primitive fields.
 // The filter field can be used to exclude fields from the diff. The name is the
 // name of the objects. If contextual is set, non-changed fields will also be
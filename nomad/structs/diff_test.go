@@ -186,16 +186,40 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeDeleted,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "AutoRevert",
+								Old:  "false",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Canary",
+								Old:  "0",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "HealthyDeadline",
+								Old:  "0s",
+								New:  "",
+							},
 							{
 								Type: DiffTypeDeleted,
 								Name: "MaxParallel",
 								Old:  "0",
 								New:  "",
 							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "MinHealthyTime",
+								Old:  "0s",
+								New:  "",
+							},
 							{
 								Type: DiffTypeDeleted,
 								Name: "Stagger",
-								Old:  "0",
+								Old:  "0s",
 								New:  "",
 							},
 						},
@@ -263,17 +287,41 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeAdded,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "AutoRevert",
+								Old:  "",
+								New:  "false",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Canary",
+								Old:  "",
+								New:  "0",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "HealthyDeadline",
+								Old:  "",
+								New:  "0s",
+							},
 							{
 								Type: DiffTypeAdded,
 								Name: "MaxParallel",
 								Old:  "",
 								New:  "0",
 							},
+							{
+								Type: DiffTypeAdded,
+								Name: "MinHealthyTime",
+								Old:  "",
+								New:  "0s",
+							},
 							{
 								Type: DiffTypeAdded,
 								Name: "Stagger",
 								Old:  "",
-								New:  "0",
+								New:  "0s",
 							},
 						},
 					},
@@ -426,8 +474,8 @@ func TestJobDiff(t *testing.T) {
 							{
 								Type: DiffTypeEdited,
 								Name: "Stagger",
-								Old:  "10000000000",
-								New:  "60000000000",
+								Old:  "10s",
+								New:  "1m0s",
 							},
 						},
 					},
@@ -456,17 +504,47 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeEdited,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeNone,
+								Name: "AutoRevert",
+								Old:  "false",
+								New:  "false",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "Canary",
+								Old:  "0",
+								New:  "0",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "HealthCheck",
+								Old:  "",
+								New:  "",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "HealthyDeadline",
+								Old:  "0s",
+								New:  "0s",
+							},
 							{
 								Type: DiffTypeNone,
 								Name: "MaxParallel",
 								Old:  "5",
 								New:  "5",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "MinHealthyTime",
+								Old:  "0s",
+								New:  "0s",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "Stagger",
-								Old:  "10000000000",
-								New:  "60000000000",
+								Old:  "10s",
+								New:  "1m0s",
 							},
 						},
 					},
@@ -676,6 +754,91 @@ func TestJobDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Parameterized job added
+			Old: &Job{},
+			New: &Job{
+				ParameterizedJob: &ParameterizedJobConfig{
+					Payload:      DispatchPayloadRequired,
+					MetaRequired: []string{"foo"},
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeAdded,
+						Name: "ParameterizedJob",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Payload",
+								Old:  "",
+								New:  DispatchPayloadRequired,
+							},
+						},
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "MetaRequired",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "MetaRequired",
+										Old:  "",
+										New:  "foo",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			// Parameterized job meta edited
+			Old: &Job{
+				ParameterizedJob: &ParameterizedJobConfig{
+					Payload:      DispatchPayloadOptional,
+					MetaOptional: []string{"foo"},
+				},
+			},
+			New: &Job{
+				ParameterizedJob: &ParameterizedJobConfig{
+					Payload:      DispatchPayloadOptional,
+					MetaOptional: []string{"bar"},
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "ParameterizedJob",
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "MetaOptional",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "MetaOptional",
+										Old:  "",
+										New:  "bar",
+									},
+									{
+										Type: DiffTypeDeleted,
+										Name: "MetaOptional",
+										Old:  "foo",
+										New:  "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// Constraints edited
 			Old: &Job{
@@ -1101,13 +1264,13 @@ func TestTaskGroupDiff(t *testing.T) {
 								Type: DiffTypeAdded,
 								Name: "Delay",
 								Old:  "",
-								New:  "1000000000",
+								New:  "1s",
 							},
 							{
 								Type: DiffTypeAdded,
 								Name: "Interval",
 								Old:  "",
-								New:  "1000000000",
+								New:  "1s",
 							},
 							{
 								Type: DiffTypeAdded,
@@ -1147,13 +1310,13 @@ func TestTaskGroupDiff(t *testing.T) {
 							{
 								Type: DiffTypeDeleted,
 								Name: "Delay",
-								Old:  "1000000000",
+								Old:  "1s",
 								New:  "",
 							},
 							{
 								Type: DiffTypeDeleted,
 								Name: "Interval",
-								Old:  "1000000000",
+								Old:  "1s",
 								New:  "",
 							},
 							{
@@ -1201,14 +1364,14 @@ func TestTaskGroupDiff(t *testing.T) {
 							{
 								Type: DiffTypeEdited,
 								Name: "Delay",
-								Old:  "1000000000",
-								New:  "2000000000",
+								Old:  "1s",
+								New:  "2s",
 							},
 							{
 								Type: DiffTypeEdited,
 								Name: "Interval",
-								Old:  "1000000000",
-								New:  "2000000000",
+								Old:  "1s",
+								New:  "2s",
 							},
 							{
 								Type: DiffTypeEdited,
@@ -1256,14 +1419,14 @@ func TestTaskGroupDiff(t *testing.T) {
 							{
 								Type: DiffTypeNone,
 								Name: "Delay",
-								Old:  "1000000000",
-								New:  "1000000000",
+								Old:  "1s",
+								New:  "1s",
 							},
 							{
 								Type: DiffTypeEdited,
 								Name: "Interval",
-								Old:  "1000000000",
-								New:  "2000000000",
+								Old:  "1s",
+								New:  "2s",
 							},
 							{
 								Type: DiffTypeNone,
@@ -1478,7 +1641,7 @@ func TestTaskGroupDiff(t *testing.T) {
 								Type: DiffTypeAdded,
 								Name: "KillTimeout",
 								Old:  "",
-								New:  "0",
+								New:  "0s",
 							},
 						},
 					},
@@ -1517,7 +1680,7 @@ func TestTaskGroupDiff(t *testing.T) {
 							{
 								Type: DiffTypeDeleted,
 								Name: "KillTimeout",
-								Old:  "0",
+								Old:  "0s",
 								New:  "",
 							},
 						},
@@ -1652,8 +1815,8 @@ func TestTaskDiff(t *testing.T) {
 					{
 						Type: DiffTypeEdited,
 						Name: "KillTimeout",
-						Old:  "1000000000",
-						New:  "2000000000",
+						Old:  "1s",
+						New:  "2s",
 					},
 					{
 						Type: DiffTypeEdited,
@@ -2131,12 +2294,24 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "100",
 								New:  "200",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "CPUHardLimit",
+								Old:  "false",
+								New:  "false",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "DiskMB",
 								Old:  "100",
 								New:  "200",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "GPU",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeNone,
 								Name: "IOPS",
@@ -2761,7 +2936,7 @@ func TestTaskDiff(t *testing.T) {
 										Type: DiffTypeAdded,
 										Name: "Interval",
 										Old:  "",
-										New:  "1000000000",
+										New:  "1s",
 									},
 									{
 										Type: DiffTypeAdded,
@@ -2785,7 +2960,7 @@ func TestTaskDiff(t *testing.T) {
 										Type: DiffTypeAdded,
 										Name: "Timeout",
 										Old:  "",
-										New:  "1000000000",
+										New:  "1s",
 									},
 									{
 										Type: DiffTypeAdded,
@@ -2808,7 +2983,7 @@ func TestTaskDiff(t *testing.T) {
 									{
 										Type: DiffTypeDeleted,
 										Name: "Interval",
-										Old:  "1000000000",
+										Old:  "1s",
 										New:  "",
 									},
 									{
@@ -2832,7 +3007,7 @@ func TestTaskDiff(t *testing.T) {
 									{
 										Type: DiffTypeDeleted,
 										Name: "Timeout",
-										Old:  "1000000000",
+										Old:  "1s",
 										New:  "",
 									},
 									{
@@ -2848,6 +3023,72 @@ func TestTaskDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Service Check CheckRestart added
+			Old: &Task{
+				Services: []*Service{
+					{
+						Name: "foo",
+						Checks: []*ServiceCheck{
+							{
+								Name: "foo",
+							},
+						},
+					},
+				},
+			},
+			New: &Task{
+				Services: []*Service{
+					{
+						Name: "foo",
+						Checks: []*ServiceCheck{
+							{
+								Name: "foo",
+								CheckRestart: &CheckRestart{
+									Limit: 3,
+									Grace: 10 * time.Second,
+								},
+							},
+						},
+					},
+				},
+			},
+			Expected: &TaskDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Service",
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Check",
+								Objects: []*ObjectDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "CheckRestart",
+										Fields: []*FieldDiff{
+											{
+												Type: DiffTypeAdded,
+												Name: "Grace",
+												Old:  "",
+												New:  "10s",
+											},
+											{
+												Type: DiffTypeAdded,
+												Name: "Limit",
+												Old:  "",
+												New:  "3",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// Service Checks edited with context
 			Contextual: true,
@@ -2931,8 +3172,8 @@ func TestTaskDiff(t *testing.T) {
 									{
 										Type: DiffTypeNone,
 										Name: "Interval",
-										Old:  "1000000000",
-										New:  "1000000000",
+										Old:  "1s",
+										New:  "1s",
 									},
 									{
 										Type: DiffTypeNone,
@@ -2961,8 +3202,8 @@ func TestTaskDiff(t *testing.T) {
 									{
 										Type: DiffTypeNone,
 										Name: "Timeout",
-										Old:  "1000000000",
-										New:  "1000000000",
+										Old:  "1s",
+										New:  "1s",
 									},
 									{
 										Type: DiffTypeEdited,
@@ -0,0 +1,85 @@
+package structs
+
+import "fmt"
+
+const (
+	// The topics of the events
+	EventTopicJob        = "Job"
+	EventTopicAllocation = "Allocation"
+	EventTopicNode       = "Node"
+	EventTopicEvaluation = "Evaluation"
+)
+
+const (
+	// The types of the events
+	EventTypeJobRegistered       = "JobRegistered"
+	EventTypeJobDeregistered     = "JobDeregistered"
+	EventTypeAllocationUpdated   = "AllocationUpdated"
+	EventTypeNodeRegistered      = "NodeRegistered"
+	EventTypeNodeDeregistered    = "NodeDeregistered"
+	EventTypeNodeStatusUpdated   = "NodeStatusUpdated"
+	EventTypeNodeDrainUpdated    = "NodeDrainUpdated"
+	EventTypeEvaluationUpdated   = "EvaluationUpdated"
+	EventTypeEvaluationCompleted = "EvaluationCompleted"
+)
+
+// EventTopics are the valid topics of the events.
+var EventTopics = []string{
+	EventTopicJob,
+	EventTopicAllocation,
+	EventTopicNode,
+	EventTopicEvaluation,
+}
+
+// Event is a change of the state of the cluster. Only the object of the topic
+// of the event is set, as it was after the change.
+type Event struct {
+	// Topic is the kind of object that changed
+	Topic string
+
+	// Type is the kind of change
+	Type string
+
+	// Key is the ID of the object that changed
+	Key string
+
+	// Namespace is the namespace of the object, empty for the nodes
+	Namespace string
+
+	// Index is the Raft index of the change
+	Index uint64
+
+	Job        *Job        `json:",omitempty"`
+	Allocation *Allocation `json:",omitempty"`
+	Node       *Node       `json:",omitempty"`
+	Evaluation *Evaluation `json:",omitempty"`
+}
+
+// EventListRequest is used to list the events following an index
+type EventListRequest struct {
+	// Topics restricts the events to the given topics. All the topics are
+	// returned if empty.
+	Topics []string
+
+	QueryOptions
+}
+
+// Validate checks that the topics of the request are known.
+func (r *EventListRequest) Validate() error {
+OUTER:
+	for _, topic := range r.Topics {
+		for _, valid := range EventTopics {
+			if topic == valid {
+				continue OUTER
+			}
+		}
+		return fmt.Errorf("invalid event topic %q", topic)
+	}
+	return nil
+}
+
+// EventListResponse is used to return the events following an index
+type EventListResponse struct {
+	Events []*Event
+	QueryMeta
+}
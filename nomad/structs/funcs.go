@@ -99,6 +99,15 @@ func AllocsFit(node *Node, allocs []*Allocation, netIdx *NetworkIndex) (bool, st
 		return false, dimension, used, nil
 	}
 
+	// Check that no GPU is assigned twice
+	gpus := make(map[int]struct{}, len(used.GPUDevices))
+	for _, device := range used.GPUDevices {
+		if _, ok := gpus[device]; ok {
+			return false, "gpu device collision", used, nil
+		}
+		gpus[device] = struct{}{}
+	}
+
 	// Create the network index if missing
 	if netIdx == nil {
 		netIdx = NewNetworkIndex()
@@ -117,6 +126,39 @@ func AllocsFit(node *Node, allocs []*Allocation, netIdx *NetworkIndex) (bool, st
 	return true, "", used, nil
 }
 
+// FreeGPUDevices returns the GPUs of the node that are neither reserved nor
+// assigned to the allocations.
+func FreeGPUDevices(node *Node, allocs []*Allocation) []int {
+	used := make(map[int]struct{})
+	markUsed := func(r *Resources) {
+		if r == nil {
+			return
+		}
+		for _, device := range r.GPUDevices {
+			used[device] = struct{}{}
+		}
+	}
+
+	markUsed(node.Reserved)
+	for _, alloc := range allocs {
+		if alloc.TaskResources == nil {
+			markUsed(alloc.Resources)
+			continue
+		}
+		for _, taskResource := range alloc.TaskResources {
+			markUsed(taskResource)
+		}
+	}
+
+	var free []int
+	for _, device := range node.Resources.GPUDevices {
+		if _, ok := used[device]; !ok {
+			free = append(free, device)
+		}
+	}
+	return free
+}
+
 // ScoreFit is used to score the fit based on the Google work published here:
 // http://www.columbia.edu/~cs2035/courses/ieor4405.S13/datacenter_scheduling.ppt
 // This is equivalent to their BestFit v3
@@ -2,6 +2,7 @@ package structs
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
 	"testing"
 )
@@ -284,3 +285,71 @@ func TestSliceStringIsSubset(t *testing.T) {
 		t.Fatalf("bad %v %v", sub, offending)
 	}
 }
+
+func TestAllocsFit_GPU(t *testing.T) {
+	n := &Node{
+		Resources: &Resources{
+			CPU:        2000,
+			MemoryMB:   2048,
+			GPU:        2,
+			GPUDevices: []int{0, 1},
+		},
+	}
+
+	a1 := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": &Resources{
+				CPU:        500,
+				MemoryMB:   512,
+				GPU:        1,
+				GPUDevices: []int{0},
+			},
+		},
+	}
+	a2 := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": &Resources{
+				CPU:        500,
+				MemoryMB:   512,
+				GPU:        1,
+				GPUDevices: []int{1},
+			},
+		},
+	}
+
+	if free := FreeGPUDevices(n, []*Allocation{a1}); !reflect.DeepEqual(free, []int{1}) {
+		t.Fatalf("got free GPUs %v", free)
+	}
+
+	fit, _, used, err := AllocsFit(n, []*Allocation{a1, a2}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !fit {
+		t.Fatalf("Bad")
+	}
+	if used.GPU != 2 {
+		t.Fatalf("bad: %#v", used)
+	}
+	if free := FreeGPUDevices(n, []*Allocation{a1, a2}); len(free) != 0 {
+		t.Fatalf("got free GPUs %v", free)
+	}
+
+	// A third GPU doesn't fit
+	fit, dim, _, err := AllocsFit(n, []*Allocation{a1, a2, a2}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit || dim != "gpu exhausted" {
+		t.Fatalf("got fit %v, dimension %q", fit, dim)
+	}
+
+	// The same GPU can't be assigned twice
+	fit, dim, _, err = AllocsFit(n, []*Allocation{a1, a1}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit || dim != "gpu device collision" {
+		t.Fatalf("got fit %v, dimension %q", fit, dim)
+	}
+}
@@ -0,0 +1,84 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// DefaultNamespace is the namespace of the jobs, evaluations and
+	// allocations created without a namespace. It always exists.
+	DefaultNamespace = "default"
+
+	// maxNamespaceDescriptionLength limits the length of the description
+	// of a namespace.
+	maxNamespaceDescriptionLength = 256
+)
+
+var (
+	// validNamespaceName matches the allowed namespace names
+	validNamespaceName = regexp.MustCompile("^[a-zA-Z0-9-]{1,128}$")
+)
+
+// Namespace isolates jobs and their evaluations and allocations so that
+// teams sharing a cluster only list and read their own.
+type Namespace struct {
+	// Name is the unique name of the namespace
+	Name string
+
+	// Description describes the namespace
+	Description string
+
+	// Quota is the name of the quota specification limiting the resources
+	// used by the allocations of the jobs of the namespace
+	Quota string
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Copy returns a copy of the namespace
+func (n *Namespace) Copy() *Namespace {
+	if n == nil {
+		return nil
+	}
+	nn := new(Namespace)
+	*nn = *n
+	return nn
+}
+
+// Validate checks if the namespace is valid
+func (n *Namespace) Validate() error {
+	var mErr multierror.Error
+	if !validNamespaceName.MatchString(n.Name) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid namespace name %q; must match %s", n.Name, validNamespaceName))
+	}
+	if len(n.Description) > maxNamespaceDescriptionLength {
+		mErr.Errors = append(mErr.Errors, errors.New("Namespace description longer than 256 characters"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// NamespacedID is the identity of a job. Job IDs are only unique within a
+// namespace so both are needed to track a job.
+type NamespacedID struct {
+	ID        string
+	Namespace string
+}
+
+// NewNamespacedID returns the identity of the job with the given ID in the
+// given namespace. The default namespace is used if none is given.
+func NewNamespacedID(id, namespace string) NamespacedID {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return NamespacedID{ID: id, Namespace: namespace}
+}
+
+func (n NamespacedID) String() string {
+	return fmt.Sprintf("<ns: %q, id: %q>", n.Namespace, n.ID)
+}
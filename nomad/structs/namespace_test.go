@@ -0,0 +1,39 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamespace_Validate(t *testing.T) {
+	n := &Namespace{Name: "team a"}
+	err := n.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Invalid namespace name") {
+		t.Fatalf("err: %v", err)
+	}
+
+	n = &Namespace{Name: "team-a", Description: strings.Repeat("a", 257)}
+	err = n.Validate()
+	if err == nil || !strings.Contains(err.Error(), "description") {
+		t.Fatalf("err: %v", err)
+	}
+
+	n = &Namespace{Name: "team-a", Description: "Team A"}
+	if err := n.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestJob_Canonicalize_Namespace(t *testing.T) {
+	j := &Job{}
+	j.Canonicalize()
+	if j.Namespace != DefaultNamespace {
+		t.Fatalf("bad: %q", j.Namespace)
+	}
+
+	j = &Job{Namespace: "team-a"}
+	j.Canonicalize()
+	if j.Namespace != "team-a" {
+		t.Fatalf("bad: %q", j.Namespace)
+	}
+}
@@ -8,10 +8,12 @@ import (
 )
 
 const (
-	// MinDynamicPort is the smallest dynamic port generated
+	// MinDynamicPort is the smallest dynamic port generated on the nodes
+	// that don't configure their dynamic port range
 	MinDynamicPort = 20000
 
-	// MaxDynamicPort is the largest dynamic port generated
+	// MaxDynamicPort is the largest dynamic port generated on the nodes
+	// that don't configure their dynamic port range
 	MaxDynamicPort = 60000
 
 	// maxRandPortAttempts is the maximum number of attempt
@@ -37,6 +39,8 @@ type NetworkIndex struct {
 	AvailBandwidth map[string]int     // Bandwidth by device
 	UsedPorts      map[string]Bitmap  // Ports by IP
 	UsedBandwidth  map[string]int     // Bandwidth by device
+	MinDynamicPort int                // Smallest dynamic port to assign
+	MaxDynamicPort int                // Largest dynamic port to assign
 }
 
 // NewNetworkIndex is used to construct a new network index
@@ -45,6 +49,8 @@ func NewNetworkIndex() *NetworkIndex {
 		AvailBandwidth: make(map[string]int),
 		UsedPorts:      make(map[string]Bitmap),
 		UsedBandwidth:  make(map[string]int),
+		MinDynamicPort: MinDynamicPort,
+		MaxDynamicPort: MaxDynamicPort,
 	}
 }
 
@@ -70,6 +76,9 @@ func (idx *NetworkIndex) Overcommitted() bool {
 // SetNode is used to setup the available network resources. Returns
 // true if there is a collision
 func (idx *NetworkIndex) SetNode(node *Node) (collide bool) {
+	// Assign the dynamic ports in the range of the node
+	idx.MinDynamicPort, idx.MaxDynamicPort = node.DynamicPortRange()
+
 	// Add the available CIDR blocks
 	for _, n := range node.Resources.Networks {
 		if n.Device != "" {
@@ -213,13 +222,13 @@ func (idx *NetworkIndex) AssignNetwork(ask *NetworkResource) (out *NetworkResour
 		// lower memory usage.
 		var dynPorts []int
 		var dynErr error
-		dynPorts, dynErr = getDynamicPortsStochastic(used, ask)
+		dynPorts, dynErr = getDynamicPortsStochastic(used, ask, idx.MinDynamicPort, idx.MaxDynamicPort)
 		if dynErr == nil {
 			goto BUILD_OFFER
 		}
 
 		// Fall back to the precise method if the random sampling failed.
-		dynPorts, dynErr = getDynamicPortsPrecise(used, ask)
+		dynPorts, dynErr = getDynamicPortsPrecise(used, ask, idx.MinDynamicPort, idx.MaxDynamicPort)
 		if dynErr != nil {
 			err = dynErr
 			return
@@ -239,10 +248,11 @@ func (idx *NetworkIndex) AssignNetwork(ask *NetworkResource) (out *NetworkResour
 }
 
 // getDynamicPortsPrecise takes the nodes used port bitmap which may be nil if
-// no ports have been allocated yet, the network ask and returns a set of unused
-// ports to fullfil the ask's DynamicPorts or an error if it failed. An error
-// means the ask can not be satisfied as the method does a precise search.
-func getDynamicPortsPrecise(nodeUsed Bitmap, ask *NetworkResource) ([]int, error) {
+// no ports have been allocated yet, the network ask and the inclusive range of
+// dynamic ports and returns a set of unused ports to fullfil the ask's
+// DynamicPorts or an error if it failed. An error means the ask can not be
+// satisfied as the method does a precise search.
+func getDynamicPortsPrecise(nodeUsed Bitmap, ask *NetworkResource, minPort, maxPort int) ([]int, error) {
 	// Create a copy of the used ports and apply the new reserves
 	var usedSet Bitmap
 	var err error
@@ -263,7 +273,7 @@ func getDynamicPortsPrecise(nodeUsed Bitmap, ask *NetworkResource) ([]int, error
 	}
 
 	// Get the indexes of the unset
-	availablePorts := usedSet.IndexesInRange(false, MinDynamicPort, MaxDynamicPort)
+	availablePorts := usedSet.IndexesInRange(false, uint(minPort), uint(maxPort))
 
 	// Randomize the amount we need
 	numDyn := len(ask.DynamicPorts)
@@ -281,11 +291,12 @@ func getDynamicPortsPrecise(nodeUsed Bitmap, ask *NetworkResource) ([]int, error
 }
 
 // getDynamicPortsStochastic takes the nodes used port bitmap which may be nil if
-// no ports have been allocated yet, the network ask and returns a set of unused
-// ports to fullfil the ask's DynamicPorts or an error if it failed. An error
-// does not mean the ask can not be satisfied as the method has a fixed amount
-// of random probes and if these fail, the search is aborted.
-func getDynamicPortsStochastic(nodeUsed Bitmap, ask *NetworkResource) ([]int, error) {
+// no ports have been allocated yet, the network ask and the inclusive range of
+// dynamic ports and returns a set of unused ports to fullfil the ask's
+// DynamicPorts or an error if it failed. An error does not mean the ask can not
+// be satisfied as the method has a fixed amount of random probes and if these
+// fail, the search is aborted.
+func getDynamicPortsStochastic(nodeUsed Bitmap, ask *NetworkResource, minPort, maxPort int) ([]int, error) {
 	var reserved, dynamic []int
 	for _, port := range ask.ReservedPorts {
 		reserved = append(reserved, port.Value)
@@ -299,7 +310,7 @@ func getDynamicPortsStochastic(nodeUsed Bitmap, ask *NetworkResource) ([]int, er
 			return nil, fmt.Errorf("stochastic dynamic port selection failed")
 		}
 
-		randPort := MinDynamicPort + rand.Intn(MaxDynamicPort-MinDynamicPort)
+		randPort := minPort + rand.Intn(maxPort-minPort+1)
 		if nodeUsed != nil && nodeUsed.Check(uint(randPort)) {
 			goto PICK
 		}
@@ -369,7 +369,7 @@ func TestNetworkIndex_AssignNetwork_Dynamic_Contention(t *testing.T) {
 			},
 		},
 	}
-	for i := MinDynamicPort; i < MaxDynamicPort-1; i++ {
+	for i := MinDynamicPort; i < MaxDynamicPort; i++ {
 		n.Reserved.Networks[0].ReservedPorts = append(n.Reserved.Networks[0].ReservedPorts, Port{Value: i})
 	}
 
@@ -397,6 +397,47 @@ func TestNetworkIndex_AssignNetwork_Dynamic_Contention(t *testing.T) {
 	}
 }
 
+func TestNetworkIndex_AssignNetwork_DynamicRange(t *testing.T) {
+	idx := NewNetworkIndex()
+	n := &Node{
+		Resources: &Resources{
+			Networks: []*NetworkResource{
+				&NetworkResource{
+					Device: "eth0",
+					CIDR:   "192.168.0.100/32",
+					MBits:  1000,
+				},
+			},
+		},
+		MinDynamicPort: 30000,
+		MaxDynamicPort: 30001,
+	}
+	idx.SetNode(n)
+
+	// Ask for as many dynamic ports as the range holds
+	ask := &NetworkResource{
+		DynamicPorts: []Port{{"http", 0}, {"https", 0}},
+	}
+	offer, err := idx.AssignNetwork(ask)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, port := range offer.DynamicPorts {
+		if port.Value < 30000 || port.Value > 30001 {
+			t.Fatalf("port %d out of the dynamic range", port.Value)
+		}
+	}
+	idx.AddReserved(offer)
+
+	// The range is exhausted
+	ask = &NetworkResource{
+		DynamicPorts: []Port{{"admin", 0}},
+	}
+	if _, err := idx.AssignNetwork(ask); err == nil {
+		t.Fatalf("expected the dynamic port range to be exhausted")
+	}
+}
+
 func TestIntContains(t *testing.T) {
 	l := []int{1, 2, 10, 20}
 	if isPortReserved(l, 50) {
@@ -0,0 +1,150 @@
+package structs
+
+import "time"
+
+// RaftServer has information about a server in the Raft configuration.
+type RaftServer struct {
+	// Node is the name of the server, as known by the gossip pool. It is
+	// empty if the server isn't a known member of the region.
+	Node string
+
+	// Address is the IP:port of the server, used as its identity by Raft
+	Address string
+
+	// Leader is true if this server is the current cluster leader.
+	Leader bool
+
+	// Voter is true if this server has a vote in the cluster.
+	Voter bool
+}
+
+// RaftConfigurationResponse is returned when querying for the current Raft
+// configuration.
+type RaftConfigurationResponse struct {
+	// Servers has the list of servers in the Raft configuration.
+	Servers []*RaftServer
+
+	// Index has the Raft index of this configuration.
+	Index uint64
+}
+
+// RaftPeerByAddressRequest is used by the Operator endpoint to apply a Raft
+// operation on a specific Raft peer by address in the form of "IP:port".
+type RaftPeerByAddressRequest struct {
+	// Address is the peer to remove, in the form "IP:port".
+	Address string
+
+	WriteRequest
+}
+
+// AutopilotConfig is the cluster wide configuration of Autopilot, which
+// manages the Raft peers of the servers.
+type AutopilotConfig struct {
+	// CleanupDeadServers controls whether to remove dead servers from the Raft
+	// peer set once a replacement joins the cluster.
+	CleanupDeadServers bool `mapstructure:"cleanup_dead_servers"`
+
+	// LastContactThreshold is the limit on the amount of time a server can go
+	// without leader contact before being considered unhealthy.
+	LastContactThreshold time.Duration `mapstructure:"last_contact_threshold"`
+
+	// MaxTrailingLogs is the amount of entries in the Raft log that a server
+	// can be behind before being considered unhealthy.
+	MaxTrailingLogs uint64 `mapstructure:"max_trailing_logs"`
+
+	// ServerStabilizationTime is the minimum amount of time a new server must
+	// be healthy before it is added to the Raft peer set.
+	ServerStabilizationTime time.Duration `mapstructure:"server_stabilization_time"`
+
+	// Raft Indexes
+	CreateIndex uint64 `mapstructure:"-"`
+	ModifyIndex uint64 `mapstructure:"-"`
+}
+
+// DefaultAutopilotConfig returns the default Autopilot configuration.
+func DefaultAutopilotConfig() AutopilotConfig {
+	return AutopilotConfig{
+		CleanupDeadServers:      true,
+		LastContactThreshold:    200 * time.Millisecond,
+		MaxTrailingLogs:         250,
+		ServerStabilizationTime: 10 * time.Second,
+	}
+}
+
+// AutopilotSetConfigRequest is used to set the Autopilot configuration.
+type AutopilotSetConfigRequest struct {
+	// Config is the new Autopilot configuration.
+	Config AutopilotConfig
+
+	// CAS controls whether to use check-and-set semantics for this request,
+	// in which case the configuration is only set if its ModifyIndex matches
+	// the one of Config.
+	CAS bool
+
+	WriteRequest
+}
+
+// RaftStats holds the Raft statistics of a server used by Autopilot to
+// determine its health.
+type RaftStats struct {
+	// LastContact is the time since the last contact of the server with the
+	// leader, "never" if there was none.
+	LastContact string
+
+	// LastTerm is the term of the last log entry of the server.
+	LastTerm uint64
+
+	// LastIndex is the index of the last log entry of the server.
+	LastIndex uint64
+}
+
+// ServerHealth is the health of a server as determined by Autopilot.
+type ServerHealth struct {
+	// Name is the name of the server.
+	Name string
+
+	// Address is the IP:port of the server.
+	Address string
+
+	// Version is the Nomad version of the server.
+	Version string
+
+	// Leader is true if this server is the current cluster leader.
+	Leader bool
+
+	// SerfStatus is the status of the server in the gossip pool.
+	SerfStatus string
+
+	// LastContact is the time since the last contact of the server with the
+	// leader.
+	LastContact time.Duration
+
+	// LastTerm is the term of the last log entry of the server.
+	LastTerm uint64
+
+	// LastIndex is the index of the last log entry of the server.
+	LastIndex uint64
+
+	// Healthy is whether the server is healthy according to the Autopilot
+	// configuration.
+	Healthy bool
+
+	// Voter is whether the server is a Raft peer with a vote in the cluster.
+	Voter bool
+
+	// StableSince is the time since the server has been healthy.
+	StableSince time.Time
+}
+
+// OperatorHealthReply is the health of the servers of the region.
+type OperatorHealthReply struct {
+	// Healthy is true if all the voting servers are healthy.
+	Healthy bool
+
+	// FailureTolerance is the number of healthy voting servers that could be
+	// lost without the cluster losing its quorum.
+	FailureTolerance int
+
+	// Servers is the health of each server.
+	Servers []ServerHealth
+}
@@ -0,0 +1,126 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// QuotaSpec caps the combined resources of the allocations of the jobs of the
+// namespaces that reference it, so that a team sharing the cluster can not
+// monopolize it.
+type QuotaSpec struct {
+	// Name is the unique name of the quota referenced by namespaces
+	Name string
+
+	// Description describes the quota
+	Description string
+
+	// Limit is the combined resources the allocations of the jobs of the
+	// namespaces using the quota may use.
+	Limit *QuotaLimit
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// QuotaLimit is the resources limited by a quota. A zero value leaves the
+// resource unlimited.
+type QuotaLimit struct {
+	CPU      int
+	MemoryMB int `mapstructure:"memory"`
+	DiskMB   int `mapstructure:"disk"`
+}
+
+// QuotaUsage is the resources used by the allocations of the jobs of the
+// namespaces using a quota.
+type QuotaUsage struct {
+	Name string
+	Used *Resources
+}
+
+// Copy returns a copy of the quota specification
+func (q *QuotaSpec) Copy() *QuotaSpec {
+	if q == nil {
+		return nil
+	}
+	nq := new(QuotaSpec)
+	*nq = *q
+	if q.Limit != nil {
+		l := *q.Limit
+		nq.Limit = &l
+	}
+	return nq
+}
+
+// Validate checks if the quota specification is valid
+func (q *QuotaSpec) Validate() error {
+	var mErr multierror.Error
+	if q.Name == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing quota name"))
+	} else if strings.ContainsAny(q.Name, " /") {
+		mErr.Errors = append(mErr.Errors, errors.New("Quota name can not include spaces or slashes"))
+	}
+
+	if q.Limit == nil {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing quota limit"))
+	} else {
+		if q.Limit.CPU < 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("CPU limit must be positive; got %d", q.Limit.CPU))
+		}
+		if q.Limit.MemoryMB < 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("MemoryMB limit must be positive; got %d", q.Limit.MemoryMB))
+		}
+		if q.Limit.DiskMB < 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("DiskMB limit must be positive; got %d", q.Limit.DiskMB))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Superset checks if the limit is a superset of the used resources. If not,
+// the exhausted dimension is returned.
+func (l *QuotaLimit) Superset(used *Resources) (bool, string) {
+	if l.CPU > 0 && used.CPU > l.CPU {
+		return false, "quota cpu exhausted"
+	}
+	if l.MemoryMB > 0 && used.MemoryMB > l.MemoryMB {
+		return false, "quota memory exhausted"
+	}
+	if l.DiskMB > 0 && used.DiskMB > l.DiskMB {
+		return false, "quota disk exhausted"
+	}
+	return true, ""
+}
+
+// AddQuotaUsage adds the CPU, memory and disk of the allocation to the used
+// resources of a quota. Terminal allocations don't use any resources.
+func AddQuotaUsage(used *Resources, alloc *Allocation) {
+	if alloc.TerminalStatus() {
+		return
+	}
+
+	add := func(r *Resources) {
+		if r == nil {
+			return
+		}
+		used.CPU += r.CPU
+		used.MemoryMB += r.MemoryMB
+		used.DiskMB += r.DiskMB
+	}
+
+	if alloc.Resources != nil {
+		add(alloc.Resources)
+		return
+	}
+
+	// Allocations within a plan have the combined resources stripped, so sum
+	// up the individual task resources.
+	add(alloc.SharedResources)
+	for _, r := range alloc.TaskResources {
+		add(r)
+	}
+}
@@ -0,0 +1,79 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuotaSpec_Validate(t *testing.T) {
+	q := &QuotaSpec{}
+	err := q.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Missing quota name") ||
+		!strings.Contains(err.Error(), "Missing quota limit") {
+		t.Fatalf("err: %v", err)
+	}
+
+	q = &QuotaSpec{
+		Name:  "eng team",
+		Limit: &QuotaLimit{CPU: -1},
+	}
+	err = q.Validate()
+	if err == nil || !strings.Contains(err.Error(), "spaces or slashes") ||
+		!strings.Contains(err.Error(), "CPU limit") {
+		t.Fatalf("err: %v", err)
+	}
+
+	q = &QuotaSpec{
+		Name:  "engineering",
+		Limit: &QuotaLimit{MemoryMB: 1024},
+	}
+	if err := q.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestQuotaLimit_Superset(t *testing.T) {
+	l := &QuotaLimit{CPU: 1000, MemoryMB: 512}
+
+	if fit, dim := l.Superset(&Resources{CPU: 1000, MemoryMB: 512, DiskMB: 100000}); !fit {
+		t.Fatalf("expected fit, exhausted %q", dim)
+	}
+	if fit, dim := l.Superset(&Resources{CPU: 1001}); fit || dim != "quota cpu exhausted" {
+		t.Fatalf("bad: %v %q", fit, dim)
+	}
+	if fit, dim := l.Superset(&Resources{MemoryMB: 513}); fit || dim != "quota memory exhausted" {
+		t.Fatalf("bad: %v %q", fit, dim)
+	}
+}
+
+func TestAddQuotaUsage(t *testing.T) {
+	used := &Resources{}
+
+	// The combined resources are used when set
+	AddQuotaUsage(used, &Allocation{
+		DesiredStatus: AllocDesiredStatusRun,
+		ClientStatus:  AllocClientStatusRunning,
+		Resources:     &Resources{CPU: 100, MemoryMB: 256, DiskMB: 150, IOPS: 10},
+	})
+
+	// Otherwise the task and shared resources are summed
+	AddQuotaUsage(used, &Allocation{
+		DesiredStatus:   AllocDesiredStatusRun,
+		ClientStatus:    AllocClientStatusPending,
+		SharedResources: &Resources{DiskMB: 50},
+		TaskResources: map[string]*Resources{
+			"web": &Resources{CPU: 200, MemoryMB: 128},
+		},
+	})
+
+	// Terminal allocations are skipped
+	AddQuotaUsage(used, &Allocation{
+		DesiredStatus: AllocDesiredStatusStop,
+		ClientStatus:  AllocClientStatusComplete,
+		Resources:     &Resources{CPU: 1000},
+	})
+
+	if used.CPU != 300 || used.MemoryMB != 384 || used.DiskMB != 200 || used.IOPS != 0 {
+		t.Fatalf("bad: %#v", used)
+	}
+}
@@ -10,9 +10,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -49,6 +51,17 @@ const (
 	ReconcileJobSummariesRequestType
 	VaultAccessorRegisterRequestType
 	VaultAccessorDegisterRequestType
+	PeriodicLaunchSkipRequestType
+	DeploymentStatusUpdateRequestType
+	DeploymentAllocHealthRequestType
+	DeploymentPromoteRequestType
+	EvalDeliveryUpdateRequestType
+	SchedulerConfigRequestType
+	QuotaSpecUpsertRequestType
+	QuotaSpecDeleteRequestType
+	NamespaceUpsertRequestType
+	NamespaceDeleteRequestType
+	AutopilotRequestType
 )
 
 const (
@@ -100,12 +113,32 @@ type QueryOptions struct {
 
 	// If set, used as prefix for resource list searches
 	Prefix string
+
+	// Namespace is the namespace of the queried objects. The default
+	// namespace is used if it isn't set.
+	Namespace string
+
+	// PerPage is the maximum number of objects returned by list queries. All
+	// of them are returned if zero.
+	PerPage int32
+
+	// NextToken resumes a paginated list query from the NextToken returned
+	// with the previous page.
+	NextToken string
 }
 
 func (q QueryOptions) RequestRegion() string {
 	return q.Region
 }
 
+// RequestNamespace returns the namespace of the query
+func (q QueryOptions) RequestNamespace() string {
+	if q.Namespace == "" {
+		return DefaultNamespace
+	}
+	return q.Namespace
+}
+
 // QueryOption only applies to reads, so always true
 func (q QueryOptions) IsRead() bool {
 	return true
@@ -118,6 +151,10 @@ func (q QueryOptions) AllowStaleRead() bool {
 type WriteRequest struct {
 	// The target region for this write
 	Region string
+
+	// Namespace is the namespace of the written objects. The default
+	// namespace is used if it isn't set.
+	Namespace string
 }
 
 func (w WriteRequest) RequestRegion() string {
@@ -125,6 +162,14 @@ func (w WriteRequest) RequestRegion() string {
 	return w.Region
 }
 
+// RequestNamespace returns the namespace of the write
+func (w WriteRequest) RequestNamespace() string {
+	if w.Namespace == "" {
+		return DefaultNamespace
+	}
+	return w.Namespace
+}
+
 // WriteRequest only applies to writes, always false
 func (w WriteRequest) IsRead() bool {
 	return false
@@ -147,6 +192,10 @@ type QueryMeta struct {
 
 	// Used to indicate if there is a known leader node
 	KnownLeader bool
+
+	// NextToken is the token to list the next page of a paginated list
+	// query. It is empty on the last page.
+	NextToken string
 }
 
 // WriteMeta allows a write response to include potentially
@@ -201,6 +250,10 @@ type NodeUpdateStatusRequest struct {
 type NodeUpdateDrainRequest struct {
 	NodeID string
 	Drain  bool
+
+	// DrainStrategy controls how the allocations of the node are migrated
+	// when drain mode is enabled.
+	DrainStrategy *DrainStrategy
 	WriteRequest
 }
 
@@ -255,6 +308,16 @@ type JobListRequest struct {
 	QueryOptions
 }
 
+// JobEvaluationsRequest is used to list the evaluations of a job
+type JobEvaluationsRequest struct {
+	JobID string
+
+	// Status filters the listed evaluations when set
+	Status string
+
+	QueryOptions
+}
+
 // JobPlanRequest is used for the Job.Plan endpoint to trigger a dry-run
 // evaluation of the Job.
 type JobPlanRequest struct {
@@ -263,6 +326,67 @@ type JobPlanRequest struct {
 	WriteRequest
 }
 
+// JobDiffRequest is used for the Job.Diff endpoint to compare a submitted Job
+// against the currently registered version without evaluating it.
+type JobDiffRequest struct {
+	Job        *Job
+	Contextual bool // Toggles including unchanged fields in the diff
+	WriteRequest
+}
+
+// JobDispatchRequest is used to dispatch a job based on a parameterized job
+type JobDispatchRequest struct {
+	JobID   string
+	Payload []byte
+	Meta    map[string]string
+	WriteRequest
+}
+
+// JobPriorityRequest is used to change the priority of a registered job
+// without resubmitting its specification
+type JobPriorityRequest struct {
+	JobID    string
+	Priority int
+	WriteRequest
+}
+
+// JobVersionsRequest is used to get the tracked versions of a job
+type JobVersionsRequest struct {
+	JobID string
+	QueryOptions
+}
+
+// JobVersionDiffRequest is used to compare two tracked versions of a job
+type JobVersionDiffRequest struct {
+	JobID string
+
+	// To is the version compared against From. It defaults to the most
+	// recent version of the job.
+	To *uint64
+
+	// From is the version To is compared against. It defaults to the tracked
+	// version preceding To.
+	From *uint64
+
+	Contextual bool // Toggles including unchanged fields in the diff
+	QueryOptions
+}
+
+// JobRevertRequest is used to revert a job to a prior version
+type JobRevertRequest struct {
+	// JobID is the ID of the job being reverted
+	JobID string
+
+	// JobVersion is the version to revert to
+	JobVersion uint64
+
+	// EnforcePriorVersion if set will enforce that the job is at the given
+	// version before reverting.
+	EnforcePriorVersion *uint64
+
+	WriteRequest
+}
+
 // JobSummaryRequest is used when we just need to get a specific job summary
 type JobSummaryRequest struct {
 	JobID string
@@ -271,9 +395,34 @@ type JobSummaryRequest struct {
 
 // NodeListRequest is used to parameterize a list request
 type NodeListRequest struct {
+	// Status, Datacenter, NodeClass and NamePrefix filter the listed nodes
+	// when set
+	Status     string
+	Datacenter string
+	NodeClass  string
+	NamePrefix string
+
 	QueryOptions
 }
 
+// Matches returns whether the node passes the filters of the request,
+// including the ID prefix of its query options.
+func (r *NodeListRequest) Matches(node *Node) bool {
+	switch {
+	case !strings.HasPrefix(node.ID, r.Prefix):
+		return false
+	case r.Status != "" && node.Status != r.Status:
+		return false
+	case r.Datacenter != "" && node.Datacenter != r.Datacenter:
+		return false
+	case r.NodeClass != "" && node.NodeClass != r.NodeClass:
+		return false
+	case !strings.HasPrefix(node.Name, r.NamePrefix):
+		return false
+	}
+	return true
+}
+
 // EvalUpdateRequest is used for upserting evaluations.
 type EvalUpdateRequest struct {
 	Evals     []*Evaluation
@@ -281,6 +430,13 @@ type EvalUpdateRequest struct {
 	WriteRequest
 }
 
+// EvalDeliveryUpdateRequest is used to persist the number of times evaluations
+// have been delivered to a scheduler, keyed by evaluation ID.
+type EvalDeliveryUpdateRequest struct {
+	DeliveryAttempts map[string]int
+	WriteRequest
+}
+
 // EvalDeleteRequest is used for deleting an evaluation.
 type EvalDeleteRequest struct {
 	Evals  []string
@@ -310,9 +466,27 @@ type EvalDequeueRequest struct {
 
 // EvalListRequest is used to list the evaluations
 type EvalListRequest struct {
+	// JobID and Status filter the listed evaluations when set
+	JobID  string
+	Status string
+
 	QueryOptions
 }
 
+// Matches returns whether the evaluation passes the filters of the request,
+// including the ID prefix of its query options.
+func (r *EvalListRequest) Matches(eval *Evaluation) bool {
+	switch {
+	case !strings.HasPrefix(eval.ID, r.Prefix):
+		return false
+	case r.JobID != "" && eval.JobID != r.JobID:
+		return false
+	case r.Status != "" && eval.Status != r.Status:
+		return false
+	}
+	return true
+}
+
 // PlanRequest is used to submit an allocation plan to the leader
 type PlanRequest struct {
 	Plan *Plan
@@ -330,14 +504,52 @@ type AllocUpdateRequest struct {
 	// It is pulled out since it is common to reduce payload size.
 	Job *Job
 
+	// Deployment is the deployment created or updated with the allocations
+	Deployment *Deployment
+
+	// DeploymentUpdates is a set of status updates to apply to deployments
+	DeploymentUpdates []*DeploymentStatusUpdate
+
+	// PreemptionEvals is the set of evaluations created for the jobs whose
+	// allocations were preempted by the plan.
+	PreemptionEvals []*Evaluation
+
 	WriteRequest
 }
 
 // AllocListRequest is used to request a list of allocations
 type AllocListRequest struct {
+	// JobID, NodeID, TaskGroup, ClientStatus and DesiredStatus filter the
+	// listed allocations when set
+	JobID         string
+	NodeID        string
+	TaskGroup     string
+	ClientStatus  string
+	DesiredStatus string
+
 	QueryOptions
 }
 
+// Matches returns whether the allocation passes the filters of the request,
+// including the ID prefix of its query options.
+func (r *AllocListRequest) Matches(alloc *Allocation) bool {
+	switch {
+	case !strings.HasPrefix(alloc.ID, r.Prefix):
+		return false
+	case r.JobID != "" && alloc.JobID != r.JobID:
+		return false
+	case r.NodeID != "" && alloc.NodeID != r.NodeID:
+		return false
+	case r.TaskGroup != "" && alloc.TaskGroup != r.TaskGroup:
+		return false
+	case r.ClientStatus != "" && alloc.ClientStatus != r.ClientStatus:
+		return false
+	case r.DesiredStatus != "" && alloc.DesiredStatus != r.DesiredStatus:
+		return false
+	}
+	return true
+}
+
 // AllocSpecificRequest is used to query a specific allocation
 type AllocSpecificRequest struct {
 	AllocID string
@@ -353,6 +565,130 @@ type AllocsGetRequest struct {
 // PeriodicForceReqeuest is used to force a specific periodic job.
 type PeriodicForceRequest struct {
 	JobID string
+
+	// Missed launches the earliest skipped launch of the job instead of a
+	// new instance.
+	Missed bool
+
+	WriteRequest
+}
+
+// PeriodicLaunchRequest is used to query the launch state of a periodic job
+type PeriodicLaunchRequest struct {
+	JobID string
+	QueryOptions
+}
+
+// PeriodicLaunchSkipRequest is used to record the launches of a periodic job
+// that were skipped.
+type PeriodicLaunchSkipRequest struct {
+	JobID    string
+	Launches []time.Time
+	WriteRequest
+}
+
+// DeploymentListRequest is used to list the deployments
+type DeploymentListRequest struct {
+	QueryOptions
+}
+
+// DeploymentSpecificRequest is used to query a specific deployment
+type DeploymentSpecificRequest struct {
+	DeploymentID string
+	QueryOptions
+}
+
+// DeploymentFailRequest is used to fail a running deployment
+type DeploymentFailRequest struct {
+	DeploymentID string
+	WriteRequest
+}
+
+// DeploymentPromoteRequest is used to promote the canaries of a running
+// deployment and to mark its allocations whose health is not yet known as
+// healthy. If Groups is set, only the task groups it names are promoted.
+type DeploymentPromoteRequest struct {
+	DeploymentID string
+	Groups       []string
+	WriteRequest
+}
+
+// PromotesGroup returns whether the request promotes the given task group.
+func (r *DeploymentPromoteRequest) PromotesGroup(name string) bool {
+	if len(r.Groups) == 0 {
+		return true
+	}
+	for _, group := range r.Groups {
+		if group == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DeploymentStatusUpdateRequest is used to update the status of a deployment
+type DeploymentStatusUpdateRequest struct {
+	DeploymentUpdate *DeploymentStatusUpdate
+	WriteRequest
+}
+
+// DeploymentAllocHealthRequest is used to mark allocations of a deployment
+// as healthy.
+type DeploymentAllocHealthRequest struct {
+	DeploymentID         string
+	HealthyAllocationIDs []string
+	WriteRequest
+}
+
+// SchedulerSetConfigRequest is used to set the scheduler configuration
+type SchedulerSetConfigRequest struct {
+	Config SchedulerConfiguration
+	WriteRequest
+}
+
+// QuotaSpecListRequest is used to list the quota specifications
+type QuotaSpecListRequest struct {
+	QueryOptions
+}
+
+// QuotaSpecSpecificRequest is used to query a specific quota specification
+type QuotaSpecSpecificRequest struct {
+	Name string
+	QueryOptions
+}
+
+// QuotaSpecUpsertRequest is used to create or update a quota specification
+type QuotaSpecUpsertRequest struct {
+	Quota *QuotaSpec
+	WriteRequest
+}
+
+// QuotaSpecDeleteRequest is used to delete a quota specification
+type QuotaSpecDeleteRequest struct {
+	Name string
+	WriteRequest
+}
+
+// NamespaceListRequest is used to list the namespaces
+type NamespaceListRequest struct {
+	QueryOptions
+}
+
+// NamespaceSpecificRequest is used to query a specific namespace
+type NamespaceSpecificRequest struct {
+	Name string
+	QueryOptions
+}
+
+// NamespaceUpsertRequest is used to create or update namespaces
+type NamespaceUpsertRequest struct {
+	Namespaces []*Namespace
+	WriteRequest
+}
+
+// NamespaceDeleteRequest is used to delete namespaces
+type NamespaceDeleteRequest struct {
+	Namespaces []string
 	WriteRequest
 }
 
@@ -487,6 +823,20 @@ type SingleJobResponse struct {
 	QueryMeta
 }
 
+// JobVersionsResponse is used to return the tracked versions of a job,
+// ordered from the most recent version
+type JobVersionsResponse struct {
+	Versions []*Job
+	QueryMeta
+}
+
+// JobVersionDiffResponse is used to return the diff between two versions of
+// a job
+type JobVersionDiffResponse struct {
+	Diff *JobDiff
+	QueryMeta
+}
+
 // JobSummaryResponse is used to return a single job summary
 type JobSummaryResponse struct {
 	JobSummary *JobSummary
@@ -527,6 +877,39 @@ type JobPlanResponse struct {
 	WriteMeta
 }
 
+// JobDiffResponse is used to respond to a job diff request
+type JobDiffResponse struct {
+	// Diff contains the diff of the job and annotations on whether a task
+	// change causes an in-place update or create/destroy
+	Diff *JobDiff
+
+	// JobModifyIndex is the modification index of the registered job the
+	// diff was computed against. If the job is not registered the value is
+	// zero.
+	JobModifyIndex uint64
+
+	WriteMeta
+}
+
+// JobPriorityResponse is used to respond to a job priority change
+type JobPriorityResponse struct {
+	JobModifyIndex uint64
+
+	// UpdatedEvals is the number of queued evaluations whose priority was
+	// updated
+	UpdatedEvals int
+	WriteMeta
+}
+
+// JobDispatchResponse is used to respond to a job dispatch
+type JobDispatchResponse struct {
+	DispatchedJobID string
+	EvalID          string
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+	WriteMeta
+}
+
 // SingleAllocResponse is used to return a single allocation
 type SingleAllocResponse struct {
 	Alloc *Allocation
@@ -595,12 +978,120 @@ type PeriodicForceResponse struct {
 	WriteMeta
 }
 
+// PeriodicLaunchResponse is used to return the launch state of a periodic job
+type PeriodicLaunchResponse struct {
+	Launch *PeriodicLaunch
+	QueryMeta
+}
+
+// DeploymentListResponse is used for a list request
+type DeploymentListResponse struct {
+	Deployments []*Deployment
+	QueryMeta
+}
+
+// SingleDeploymentResponse is used to return a single deployment
+type SingleDeploymentResponse struct {
+	Deployment *Deployment
+	QueryMeta
+}
+
+// QuotaSpecListResponse is used for a list request
+type QuotaSpecListResponse struct {
+	Quotas []*QuotaSpec
+	QueryMeta
+}
+
+// SingleQuotaSpecResponse is used to return a single quota specification
+type SingleQuotaSpecResponse struct {
+	Quota *QuotaSpec
+	QueryMeta
+}
+
+// QuotaUsageResponse is used to return the usage of a quota
+type QuotaUsageResponse struct {
+	Usage *QuotaUsage
+	QueryMeta
+}
+
+// NamespaceListResponse is used for a list request
+type NamespaceListResponse struct {
+	Namespaces []*Namespace
+	QueryMeta
+}
+
+// SingleNamespaceResponse is used to return a single namespace
+type SingleNamespaceResponse struct {
+	Namespace *Namespace
+	QueryMeta
+}
+
+// DeploymentUpdateResponse is used to respond to a change of a deployment
+type DeploymentUpdateResponse struct {
+	DeploymentModifyIndex uint64
+
+	// RevertedJobVersion is the version the job was reverted to if the
+	// deployment failed and the job auto reverts.
+	RevertedJobVersion *uint64
+
+	EvalID          string
+	EvalCreateIndex uint64
+	WriteMeta
+}
+
+const (
+	// NodeDriverJobWhitelistAttr and NodeDriverJobBlacklistAttr are the
+	// format of the node attributes restricting the jobs that may use a
+	// driver.
+	NodeDriverJobWhitelistAttr = "driver.%s.job_whitelist"
+	NodeDriverJobBlacklistAttr = "driver.%s.job_blacklist"
+
+	// NodeDriverNamespaceWhitelistAttr and NodeDriverNamespaceBlacklistAttr
+	// are the format of the node attributes restricting the namespaces whose
+	// jobs may use a driver.
+	NodeDriverNamespaceWhitelistAttr = "driver.%s.namespace_whitelist"
+	NodeDriverNamespaceBlacklistAttr = "driver.%s.namespace_blacklist"
+)
+
 const (
 	NodeStatusInit  = "initializing"
 	NodeStatusReady = "ready"
 	NodeStatusDown  = "down"
 )
 
+// DrainStrategy controls how the allocations of a draining node are migrated.
+// Until the deadline is reached, the migrations of each task group are
+// throttled by its migrate strategy.
+type DrainStrategy struct {
+	// Deadline is the time after which the remaining allocations are
+	// migrated at once. Zero means no deadline and a negative deadline
+	// migrates all the allocations immediately.
+	Deadline time.Duration
+
+	// IgnoreSystemJobs leaves the allocations of system jobs running on the
+	// node.
+	IgnoreSystemJobs bool
+
+	// ForceDeadline is the time at which the deadline is reached. It is set
+	// by the servers when drain mode is enabled.
+	ForceDeadline time.Time
+}
+
+func (d *DrainStrategy) Copy() *DrainStrategy {
+	if d == nil {
+		return nil
+	}
+	nd := new(DrainStrategy)
+	*nd = *d
+	return nd
+}
+
+// DeadlineReached returns whether the deadline of the drain is reached at the
+// given time.
+func (d *DrainStrategy) DeadlineReached(now time.Time) bool {
+	return d != nil && !d.ForceDeadline.IsZero() && !now.Before(d.ForceDeadline)
+}
+
 // ShouldDrainNode checks if a given node status should trigger an
 // evaluation. Some states don't require any further action.
 func ShouldDrainNode(status string) bool {
@@ -646,6 +1137,13 @@ type Node struct {
 	// requests
 	HTTPAddr string
 
+	// TLSEnabled indicates if the Agent has TLS enabled for the HTTP API
+	TLSEnabled bool
+
+	// StreamAddr is the address advertised for the client's file system and
+	// log streaming endpoints. If empty, HTTPAddr is used.
+	StreamAddr string
+
 	// Attributes is an arbitrary set of key/value
 	// data that can be used for constraints. Examples
 	// include "kernel.name=linux", "arch=386", "driver.docker=1",
@@ -663,6 +1161,11 @@ type Node struct {
 	// consuming resources.
 	Reserved *Resources
 
+	// MinDynamicPort and MaxDynamicPort are the inclusive range of the ports
+	// assigned as dynamic ports on the node. The defaults are used if unset.
+	MinDynamicPort int
+	MaxDynamicPort int
+
 	// Links are used to 'link' this client to external
 	// systems. For example 'consul=foo.dc1' 'aws=i-83212'
 	// 'ami=ami-123'
@@ -685,6 +1188,10 @@ type Node struct {
 	// allocations will be drained.
 	Drain bool
 
+	// DrainStrategy controls how the allocations of a draining node are
+	// migrated. It is set by the servers along with Drain.
+	DrainStrategy *DrainStrategy
+
 	// Status of this node
 	Status string
 
@@ -695,6 +1202,10 @@ type Node struct {
 	// updated
 	StatusUpdatedAt int64
 
+	// HostVolumes are the directories of the node that can be mounted into
+	// tasks, by name.
+	HostVolumes map[string]*ClientHostVolumeConfig
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -711,9 +1222,24 @@ func (n *Node) Copy() *Node {
 	nn.Reserved = nn.Reserved.Copy()
 	nn.Links = CopyMapStringString(nn.Links)
 	nn.Meta = CopyMapStringString(nn.Meta)
+	nn.HostVolumes = CopyMapStringClientHostVolumeConfig(nn.HostVolumes)
+	nn.DrainStrategy = nn.DrainStrategy.Copy()
 	return nn
 }
 
+// DynamicPortRange returns the inclusive range of the ports assigned as
+// dynamic ports on the node.
+func (n *Node) DynamicPortRange() (int, int) {
+	min, max := n.MinDynamicPort, n.MaxDynamicPort
+	if min == 0 {
+		min = MinDynamicPort
+	}
+	if max == 0 {
+		max = MaxDynamicPort
+	}
+	return min, max
+}
+
 // TerminalStatus returns if the current status is terminal and
 // will no longer transition.
 func (n *Node) TerminalStatus() bool {
@@ -725,6 +1251,59 @@ func (n *Node) TerminalStatus() bool {
 	}
 }
 
+// DriverJobAllowed returns whether the job may use the given driver on the
+// node. A client restricts the jobs that may use a driver by advertising a
+// comma separated list of job IDs in the "driver.<name>.job_whitelist" or
+// "driver.<name>.job_blacklist" attribute, and a comma separated list of
+// namespaces in the "driver.<name>.namespace_whitelist" or
+// "driver.<name>.namespace_blacklist" attribute. Jobs are matched by their ID
+// or the ID of their parent job, and by their namespace.
+func (n *Node) DriverJobAllowed(driver string, job *Job) bool {
+	namespace := job.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	if whitelist, ok := n.Attributes[fmt.Sprintf(NodeDriverJobWhitelistAttr, driver)]; ok {
+		if !inList(whitelist, job.ID, job.ParentID) {
+			return false
+		}
+	}
+	if blacklist, ok := n.Attributes[fmt.Sprintf(NodeDriverJobBlacklistAttr, driver)]; ok {
+		if inList(blacklist, job.ID, job.ParentID) {
+			return false
+		}
+	}
+	if whitelist, ok := n.Attributes[fmt.Sprintf(NodeDriverNamespaceWhitelistAttr, driver)]; ok {
+		if !inList(whitelist, namespace) {
+			return false
+		}
+	}
+	if blacklist, ok := n.Attributes[fmt.Sprintf(NodeDriverNamespaceBlacklistAttr, driver)]; ok {
+		if inList(blacklist, namespace) {
+			return false
+		}
+	}
+	return true
+}
+
+// inList returns whether any of the non-empty values is in the comma
+// separated list.
+func inList(list string, values ...string) bool {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		for _, value := range values {
+			if value != "" && item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Stub returns a summarized version of the node
 func (n *Node) Stub() *NodeListStub {
 	return &NodeListStub{
@@ -762,6 +1341,15 @@ type Resources struct {
 	DiskMB   int `mapstructure:"disk"`
 	IOPS     int
 	Networks []*NetworkResource
+
+	// GPU is the number of GPUs. GPUDevices are the indexes of the devices:
+	// those of the node, or those assigned to a task by the scheduler.
+	GPU        int
+	GPUDevices []int `mapstructure:"-"`
+
+	// CPUHardLimit bounds the CPU usage of the task to its CPU resources
+	// rather than letting it use the idle CPU of the node.
+	CPUHardLimit bool `mapstructure:"cpu_hard_limit"`
 }
 
 const (
@@ -799,6 +1387,12 @@ func (r *Resources) Merge(other *Resources) {
 	if len(other.Networks) != 0 {
 		r.Networks = other.Networks
 	}
+	if other.GPU != 0 {
+		r.GPU = other.GPU
+	}
+	if other.CPUHardLimit {
+		r.CPUHardLimit = true
+	}
 }
 
 func (r *Resources) Canonicalize() {
@@ -826,6 +1420,9 @@ func (r *Resources) MeetsMinResources() error {
 	if r.IOPS < 0 {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum IOPS value is 0; got %d", r.IOPS))
 	}
+	if r.GPU < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum GPU value is 0; got %d", r.GPU))
+	}
 	for i, n := range r.Networks {
 		if err := n.MeetsMinResources(); err != nil {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("network resource at index %d failed: %v", i, err))
@@ -849,6 +1446,10 @@ func (r *Resources) Copy() *Resources {
 			newR.Networks[i] = r.Networks[i].Copy()
 		}
 	}
+	if r.GPUDevices != nil {
+		newR.GPUDevices = make([]int, len(r.GPUDevices))
+		copy(newR.GPUDevices, r.GPUDevices)
+	}
 	return newR
 }
 
@@ -878,6 +1479,9 @@ func (r *Resources) Superset(other *Resources) (bool, string) {
 	if r.IOPS < other.IOPS {
 		return false, "iops exhausted"
 	}
+	if r.GPU < other.GPU {
+		return false, "gpu exhausted"
+	}
 	return true, ""
 }
 
@@ -891,6 +1495,8 @@ func (r *Resources) Add(delta *Resources) error {
 	r.MemoryMB += delta.MemoryMB
 	r.DiskMB += delta.DiskMB
 	r.IOPS += delta.IOPS
+	r.GPU += delta.GPU
+	r.GPUDevices = append(r.GPUDevices, delta.GPUDevices...)
 
 	for _, n := range delta.Networks {
 		// Find the matching interface by IP or CIDR
@@ -913,6 +1519,43 @@ type Port struct {
 	Value int `mapstructure:"static"`
 }
 
+// ResourceOverride overrides the resources of a task when it is placed on a
+// node of the given node class. Only the non-zero values are overridden.
+type ResourceOverride struct {
+	NodeClass string
+	CPU       int
+	MemoryMB  int `mapstructure:"memory"`
+	IOPS      int
+}
+
+// Copy returns a copy of the resource override
+func (o *ResourceOverride) Copy() *ResourceOverride {
+	if o == nil {
+		return nil
+	}
+	no := new(ResourceOverride)
+	*no = *o
+	return no
+}
+
+// Validate checks if the resource override is valid
+func (o *ResourceOverride) Validate() error {
+	var mErr multierror.Error
+	if o.NodeClass == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing node class"))
+	}
+	if o.CPU != 0 && o.CPU < 20 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum CPU value is 20; got %d", o.CPU))
+	}
+	if o.MemoryMB != 0 && o.MemoryMB < 10 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum MemoryMB value is 10; got %d", o.MemoryMB))
+	}
+	if o.IOPS < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum IOPS value is 0; got %d", o.IOPS))
+	}
+	return mErr.ErrorOrNil()
+}
+
 // NetworkResource is used to represent available network
 // resources
 type NetworkResource struct {
@@ -999,6 +1642,14 @@ const (
 	JobTypeSystem  = "system"
 )
 
+const (
+	// JobStopStrategyAll stops all the allocations of a deregistered job at
+	// once. JobStopStrategyRolling stops them in batches honoring the job's
+	// update strategy.
+	JobStopStrategyAll     = "all"
+	JobStopStrategyRolling = "rolling"
+)
+
 const (
 	JobStatusPending = "pending" // Pending means the job is waiting on scheduling
 	JobStatusRunning = "running" // Running means the job has non-terminal allocations
@@ -1013,18 +1664,32 @@ const (
 	// not specified.
 	JobDefaultPriority = 50
 
-	// JobMaxPriority is the maximum allowed priority
-	JobMaxPriority = 100
+	// JobMaxPriority is the maximum allowed priority. The range is wider
+	// than the default priority calls for so that clusters shared by many
+	// teams can order their jobs finely.
+	JobMaxPriority = 1000
 
 	// Ensure CoreJobPriority is higher than any user
 	// specified job so that it gets priority. This is important
 	// for the system to remain healthy.
 	CoreJobPriority = JobMaxPriority * 2
+
+	// JobTrackedVersions is the number of historic job versions that are
+	// kept.
+	JobTrackedVersions = 6
+
+	// JobTrackedDeployments is the number of deployments of a job that are
+	// kept.
+	JobTrackedDeployments = 6
 )
 
 // JobSummary summarizes the state of the allocations of a job
 type JobSummary struct {
-	JobID   string
+	JobID string
+
+	// Namespace is the namespace of the job
+	Namespace string
+
 	Summary map[string]TaskGroupSummary
 
 	// Raft Indexes
@@ -1074,6 +1739,10 @@ type Job struct {
 	// per region, but not unique globally.
 	Name string
 
+	// Namespace is the namespace the job and its evaluations and allocations
+	// are isolated in. Job IDs are unique within a namespace.
+	Namespace string
+
 	// Type is used to control various behaviors about the job. Most jobs
 	// are service jobs, meaning they are expected to be long lived.
 	// Some jobs are batch oriented meaning they run and then terminate.
@@ -1103,9 +1772,32 @@ type Job struct {
 	// Update is used to control the update strategy
 	Update UpdateStrategy
 
+	// StopStrategy controls whether deregistering the job stops all of its
+	// allocations at once or in batches using the update strategy. An empty
+	// value is treated as JobStopStrategyAll.
+	StopStrategy string `mapstructure:"stop_strategy"`
+
 	// Periodic is used to define the interval the job is run at.
 	Periodic *PeriodicConfig
 
+	// Failover is used to move the job to a fallback region if it can not
+	// be placed in its region.
+	Failover *FailoverConfig
+
+	// FailoverFrom is the region the job was moved from by a failover.
+	FailoverFrom string
+
+	// FailoverTo is the region the job was moved to by a failover. The job
+	// is kept in its region as a record of the move but is no longer placed.
+	FailoverTo string
+
+	// ParameterizedJob is used to declare the job as a parameterized job
+	// which is not run itself but dispatched as child jobs.
+	ParameterizedJob *ParameterizedJobConfig `mapstructure:"parameterized"`
+
+	// Payload is the input of a job dispatched from a parameterized job.
+	Payload []byte
+
 	// Meta is used to associate arbitrary metadata with this
 	// job. This is opaque to Nomad.
 	Meta map[string]string
@@ -1121,6 +1813,15 @@ type Job struct {
 	// StatusDescription is meant to provide more human useful information
 	StatusDescription string
 
+	// Version is a monotonically increasing version number that is
+	// incremented on each job register.
+	Version uint64
+
+	// Stable marks a version of the job whose deployment completed
+	// successfully. Deployments that fail may revert to the latest stable
+	// version.
+	Stable bool
+
 	// Raft Indexes
 	CreateIndex    uint64
 	ModifyIndex    uint64
@@ -1136,9 +1837,17 @@ func (j *Job) Canonicalize() {
 		j.Meta = nil
 	}
 
+	if j.Namespace == "" {
+		j.Namespace = DefaultNamespace
+	}
+
 	for _, tg := range j.TaskGroups {
 		tg.Canonicalize(j)
 	}
+
+	if j.ParameterizedJob != nil {
+		j.ParameterizedJob.Canonicalize()
+	}
 }
 
 // Copy returns a deep copy of the Job. It is expected that callers use recover.
@@ -1161,6 +1870,8 @@ func (j *Job) Copy() *Job {
 	}
 
 	nj.Periodic = nj.Periodic.Copy()
+	nj.Failover = nj.Failover.Copy()
+	nj.ParameterizedJob = nj.ParameterizedJob.Copy()
 	nj.Meta = CopyMapStringString(nj.Meta)
 	return nj
 }
@@ -1214,6 +1925,16 @@ func (j *Job) Validate() error {
 				fmt.Errorf("Job task group %s has count %d. Count cannot exceed 1 with system scheduler",
 					tg.Name, tg.Count))
 		}
+
+		if j.Type != JobTypeSystem {
+			for _, task := range tg.Tasks {
+				if len(task.ResourceOverrides) != 0 {
+					mErr.Errors = append(mErr.Errors,
+						fmt.Errorf("Task %s in task group %s has resource overrides. Resource overrides can only be used with %q scheduler",
+							task.Name, tg.Name, JobTypeSystem))
+				}
+			}
+		}
 	}
 
 	// Validate the task group
@@ -1224,6 +1945,26 @@ func (j *Job) Validate() error {
 		}
 	}
 
+	// Validate the stop strategy
+	switch j.StopStrategy {
+	case "", JobStopStrategyAll:
+	case JobStopStrategyRolling:
+		if !j.Update.Rolling() {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Stop strategy %q requires an update strategy with a positive stagger and max_parallel", JobStopStrategyRolling))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid stop strategy %q", j.StopStrategy))
+	}
+
+	// Validate the update strategy
+	if err := j.Update.Validate(); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	} else if j.Update.HealthGated() && j.Type != JobTypeService {
+		mErr.Errors = append(mErr.Errors,
+			fmt.Errorf("Update health checks can only be used with %q scheduler", JobTypeService))
+	}
+
 	// Validate periodic is only used with batch jobs.
 	if j.IsPeriodic() && j.Periodic.Enabled {
 		if j.Type != JobTypeBatch {
@@ -1236,6 +1977,26 @@ func (j *Job) Validate() error {
 		}
 	}
 
+	if j.Failover != nil {
+		if err := j.Failover.Validate(j.Region); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	if j.IsParameterized() {
+		if j.Type != JobTypeBatch {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Parameterized job can only be used with %q scheduler", JobTypeBatch))
+		}
+		if j.IsPeriodic() {
+			mErr.Errors = append(mErr.Errors, errors.New("Parameterized job can not be periodic"))
+		}
+
+		if err := j.ParameterizedJob.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
 	return mErr.ErrorOrNil()
 }
 
@@ -1271,6 +2032,22 @@ func (j *Job) IsPeriodic() bool {
 	return j.Periodic != nil
 }
 
+// IsParameterized returns whether a job is a parameterized job.
+func (j *Job) IsParameterized() bool {
+	return j.ParameterizedJob != nil
+}
+
+// NamespacedID returns the identity of the job in its namespace
+func (j *Job) NamespacedID() NamespacedID {
+	return NewNamespacedID(j.ID, j.Namespace)
+}
+
+// FailedOver returns whether the job was moved to its failover region, in
+// which case it is treated as stopped in its own region.
+func (j *Job) FailedOver() bool {
+	return j != nil && j.FailoverTo != ""
+}
+
 // VaultPolicies returns the set of Vault policies per task group, per task
 func (j *Job) VaultPolicies() map[string]map[string]*Vault {
 	policies := make(map[string]map[string]*Vault, len(j.TaskGroups))
@@ -1314,13 +2091,102 @@ type UpdateStrategy struct {
 
 	// MaxParallel is how many updates can be done in parallel
 	MaxParallel int `mapstructure:"max_parallel"`
+
+	// HealthCheck specifies how the health of updated allocations is
+	// determined. If set, the update is rolled out as a deployment that only
+	// replaces the next MaxParallel allocations once the previous ones are
+	// healthy.
+	HealthCheck string `mapstructure:"health_check"`
+
+	// MinHealthyTime is the minimum time an allocation must be healthy
+	// before it is marked as healthy.
+	MinHealthyTime time.Duration `mapstructure:"min_healthy_time"`
+
+	// HealthyDeadline is the time within which an allocation must become
+	// healthy before it is marked as unhealthy.
+	HealthyDeadline time.Duration `mapstructure:"healthy_deadline"`
+
+	// AutoRevert reverts the job to its latest stable version if its
+	// deployment fails.
+	AutoRevert bool `mapstructure:"auto_revert"`
+
+	// Canary is the number of allocations of a new version of the job that
+	// are placed alongside the existing allocations of each task group. The
+	// rollout only proceeds once the deployment has been promoted.
+	Canary int `mapstructure:"canary"`
 }
 
+const (
+	// UpdateHealthCheckChecks marks an allocation healthy once its tasks are
+	// running and their Consul checks are passing.
+	UpdateHealthCheckChecks = "checks"
+
+	// UpdateHealthCheckTaskStates marks an allocation healthy once its tasks
+	// are running.
+	UpdateHealthCheckTaskStates = "task_states"
+
+	// UpdateHealthCheckManual requires the health of allocations to be set
+	// by promoting the deployment.
+	UpdateHealthCheckManual = "manual"
+)
+
+const (
+	// DefaultHealthyDeadline is the healthy deadline used if a health gated
+	// update strategy doesn't set one.
+	DefaultHealthyDeadline = 5 * time.Minute
+)
+
 // Rolling returns if a rolling strategy should be used
 func (u *UpdateStrategy) Rolling() bool {
 	return u.Stagger > 0 && u.MaxParallel > 0
 }
 
+// HealthGated returns if updates are rolled out as a deployment that waits
+// for updated allocations to be healthy.
+func (u *UpdateStrategy) HealthGated() bool {
+	return u.MaxParallel > 0 && u.HealthCheck != ""
+}
+
+// Deadline returns the time within which an allocation must become healthy.
+func (u *UpdateStrategy) Deadline() time.Duration {
+	if u.HealthyDeadline == 0 {
+		return DefaultHealthyDeadline
+	}
+	return u.HealthyDeadline
+}
+
+// Validate returns an error if the update strategy is invalid
+func (u *UpdateStrategy) Validate() error {
+	var mErr multierror.Error
+	switch u.HealthCheck {
+	case "", UpdateHealthCheckChecks, UpdateHealthCheckTaskStates, UpdateHealthCheckManual:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid update health check %q", u.HealthCheck))
+	}
+	if u.HealthCheck != "" && u.MaxParallel <= 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Update health check requires a positive max_parallel"))
+	}
+	if u.MinHealthyTime < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Update min_healthy_time must not be negative"))
+	}
+	if u.HealthyDeadline < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Update healthy_deadline must not be negative"))
+	}
+	if u.HealthyDeadline != 0 && u.MinHealthyTime >= u.HealthyDeadline {
+		mErr.Errors = append(mErr.Errors, errors.New("Update min_healthy_time must be less than healthy_deadline"))
+	}
+	if u.AutoRevert && u.HealthCheck == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Update auto_revert requires a health check"))
+	}
+	if u.Canary < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Update canary must not be negative"))
+	}
+	if u.Canary > 0 && u.HealthCheck == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Update canary requires a health check"))
+	}
+	return mErr.ErrorOrNil()
+}
+
 const (
 	// PeriodicSpecCron is used for a cron spec.
 	PeriodicSpecCron = "cron"
@@ -1421,18 +2287,298 @@ const (
 	// PeriodicLaunchSuffix is the string appended to the periodic jobs ID
 	// when launching derived instances of it.
 	PeriodicLaunchSuffix = "/periodic-"
+
+	// PeriodicMaxSkippedLaunches is the maximum number of skipped launches
+	// that are tracked per periodic job.
+	PeriodicMaxSkippedLaunches = 25
+)
+
+// FailoverConfig is used to move a job to a fallback region once it has been
+// blocked in its region for a period of time.
+type FailoverConfig struct {
+	// Region is the region the job is moved to.
+	Region string
+
+	// After is how long the job has to be blocked before it is moved.
+	After time.Duration
+}
+
+func (f *FailoverConfig) Copy() *FailoverConfig {
+	if f == nil {
+		return nil
+	}
+	nf := new(FailoverConfig)
+	*nf = *f
+	return nf
+}
+
+// Validate validates the failover config of a job in the given region.
+func (f *FailoverConfig) Validate(region string) error {
+	var mErr multierror.Error
+	if f.Region == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing failover region"))
+	} else if f.Region == region {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Failover region must differ from the job region %q", region))
+	}
+	if f.After <= 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Failover after must be positive"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+const (
+	// DispatchPayloadForbidden, DispatchPayloadOptional and
+	// DispatchPayloadRequired control whether a payload must be supplied when
+	// dispatching a parameterized job.
+	DispatchPayloadForbidden = "forbidden"
+	DispatchPayloadOptional  = "optional"
+	DispatchPayloadRequired  = "required"
+
+	// DispatchLaunchSuffix is the string appended to the parameterized job's
+	// ID when dispatching instances of it.
+	DispatchLaunchSuffix = "/dispatch-"
+
+	// DispatchPayloadSizeLimit is the maximum size of a dispatch payload.
+	DispatchPayloadSizeLimit = 16 * 1024
 )
 
+// ParameterizedJobConfig is used to configure the parameters a job can be
+// dispatched with.
+type ParameterizedJobConfig struct {
+	// Payload configures whether a payload is forbidden, optional or required
+	// when dispatching.
+	Payload string
+
+	// MetaRequired is the set of meta keys that must be set when dispatching.
+	MetaRequired []string `mapstructure:"meta_required"`
+
+	// MetaOptional is the set of meta keys that may be set when dispatching.
+	MetaOptional []string `mapstructure:"meta_optional"`
+}
+
+// Validate checks the payload setting and that no meta key is both required
+// and optional.
+func (d *ParameterizedJobConfig) Validate() error {
+	var mErr multierror.Error
+	switch d.Payload {
+	case DispatchPayloadOptional, DispatchPayloadRequired, DispatchPayloadForbidden:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unknown payload requirement: %q", d.Payload))
+	}
+
+	optional := make(map[string]struct{}, len(d.MetaOptional))
+	for _, k := range d.MetaOptional {
+		optional[k] = struct{}{}
+	}
+	for _, k := range d.MetaRequired {
+		if _, ok := optional[k]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Meta key %q can not be both required and optional", k))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Canonicalize defaults the payload to being optional.
+func (d *ParameterizedJobConfig) Canonicalize() {
+	if d.Payload == "" {
+		d.Payload = DispatchPayloadOptional
+	}
+}
+
+func (d *ParameterizedJobConfig) Copy() *ParameterizedJobConfig {
+	if d == nil {
+		return nil
+	}
+	nd := new(ParameterizedJobConfig)
+	*nd = *d
+	nd.MetaOptional = CopySliceString(nd.MetaOptional)
+	nd.MetaRequired = CopySliceString(nd.MetaRequired)
+	return nd
+}
+
+// DispatchedID returns the ID of a job dispatched from the parameterized job
+// with the given ID at the given time.
+func DispatchedID(templateID string, t time.Time) string {
+	u := GenerateUUID()[:8]
+	return fmt.Sprintf("%s%s%d-%s", templateID, DispatchLaunchSuffix, t.Unix(), u)
+}
+
+// DispatchPayloadConfig configures how a task gets its input from a job
+// dispatch.
+type DispatchPayloadConfig struct {
+	// File is the name of the file, relative to the task's local directory,
+	// the payload is written to.
+	File string
+}
+
+func (d *DispatchPayloadConfig) Copy() *DispatchPayloadConfig {
+	if d == nil {
+		return nil
+	}
+	nd := new(DispatchPayloadConfig)
+	*nd = *d
+	return nd
+}
+
+// Validate checks that the file doesn't escape the task's local directory.
+func (d *DispatchPayloadConfig) Validate() error {
+	if d.File == "" {
+		return errors.New("Missing payload file")
+	}
+
+	local := filepath.Join("/", "local")
+	rel, err := filepath.Rel(local, filepath.Join(local, d.File))
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(rel, "..") {
+		return errors.New("Payload file escapes the task's local directory")
+	}
+	return nil
+}
+
+const (
+	// TaskLifecycleHookPrestart runs the task before the main tasks of the
+	// task group are started.
+	TaskLifecycleHookPrestart = "prestart"
+
+	// TaskLifecycleHookPoststop runs the task once the main tasks of the task
+	// group have exited.
+	TaskLifecycleHookPoststop = "poststop"
+)
+
+// TaskLifecycleConfig configures when a task is run relative to the main tasks
+// of its task group.
+type TaskLifecycleConfig struct {
+	// Hook is the point in the lifecycle of the task group the task is run
+	// at.
+	Hook string
+
+	// Sidecar marks that a prestart task keeps running alongside the main
+	// tasks instead of running to completion before they are started.
+	Sidecar bool
+}
+
+func (l *TaskLifecycleConfig) Copy() *TaskLifecycleConfig {
+	if l == nil {
+		return nil
+	}
+	nl := new(TaskLifecycleConfig)
+	*nl = *l
+	return nl
+}
+
+// Validate checks that the hook is known and that only prestart tasks are
+// sidecars.
+func (l *TaskLifecycleConfig) Validate() error {
+	switch l.Hook {
+	case TaskLifecycleHookPrestart:
+	case TaskLifecycleHookPoststop:
+		if l.Sidecar {
+			return errors.New("Poststop tasks can't be sidecars")
+		}
+	case "":
+		return errors.New("Missing lifecycle hook")
+	default:
+		return fmt.Errorf("Unsupported lifecycle hook %q", l.Hook)
+	}
+	return nil
+}
+
+// EnvFromConsulConfig configures the environment variables of a task read
+// from Consul's key/value store.
+type EnvFromConsulConfig struct {
+	// Prefix is the key prefix under which each key sets the environment
+	// variable named after the key relative to the prefix.
+	Prefix string
+}
+
+func (e *EnvFromConsulConfig) Copy() *EnvFromConsulConfig {
+	if e == nil {
+		return nil
+	}
+	ne := new(EnvFromConsulConfig)
+	*ne = *e
+	return ne
+}
+
+// Validate checks that a prefix is set.
+func (e *EnvFromConsulConfig) Validate() error {
+	if e.Prefix == "" {
+		return errors.New("Missing key prefix")
+	}
+	return nil
+}
+
 // PeriodicLaunch tracks the last launch time of a periodic job.
 type PeriodicLaunch struct {
-	ID     string    // ID of the periodic job.
-	Launch time.Time // The last launch time.
+	ID        string    // ID of the periodic job.
+	Namespace string    // Namespace of the periodic job.
+	Launch    time.Time // The last launch time.
+
+	// Skipped is the set of launch times, in UTC and ordered from the
+	// earliest, that were not run either because the job prohibits overlap
+	// or because no leader was available to launch them. At most
+	// PeriodicMaxSkippedLaunches of the most recent skips are kept.
+	Skipped []time.Time
 
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
 }
 
+// Copy returns a copy of the periodic launch
+func (p *PeriodicLaunch) Copy() *PeriodicLaunch {
+	if p == nil {
+		return nil
+	}
+	np := new(PeriodicLaunch)
+	*np = *p
+	if p.Skipped != nil {
+		np.Skipped = make([]time.Time, len(p.Skipped))
+		copy(np.Skipped, p.Skipped)
+	}
+	return np
+}
+
+// AddSkipped records the passed launch times as skipped. Launch times that
+// are already recorded are ignored and only the most recent
+// PeriodicMaxSkippedLaunches are kept.
+func (p *PeriodicLaunch) AddSkipped(launches ...time.Time) {
+	for _, launch := range launches {
+		launch = launch.UTC()
+		i := sort.Search(len(p.Skipped), func(i int) bool {
+			return !p.Skipped[i].Before(launch)
+		})
+		if i < len(p.Skipped) && p.Skipped[i].Equal(launch) {
+			continue
+		}
+
+		p.Skipped = append(p.Skipped, time.Time{})
+		copy(p.Skipped[i+1:], p.Skipped[i:])
+		p.Skipped[i] = launch
+	}
+
+	if n := len(p.Skipped); n > PeriodicMaxSkippedLaunches {
+		p.Skipped = p.Skipped[n-PeriodicMaxSkippedLaunches:]
+	}
+}
+
+// RemoveSkipped removes the passed launch time from the skipped launches. It
+// returns whether the launch was recorded as skipped.
+func (p *PeriodicLaunch) RemoveSkipped(launch time.Time) bool {
+	for i, skipped := range p.Skipped {
+		if skipped.Equal(launch) {
+			p.Skipped = append(p.Skipped[:i], p.Skipped[i+1:]...)
+			if len(p.Skipped) == 0 {
+				p.Skipped = nil
+			}
+			return true
+		}
+	}
+	return false
+}
+
 var (
 	defaultServiceJobRestartPolicy = RestartPolicy{
 		Delay:    15 * time.Second,
@@ -1453,65 +2599,230 @@ const (
 	// reached when the specified attempts have been reached in the interval.
 	RestartPolicyModeDelay = "delay"
 
-	// RestartPolicyModeFail causes a job to fail if the specified number of
-	// attempts are reached within an interval.
-	RestartPolicyModeFail = "fail"
+	// RestartPolicyModeFail causes a job to fail if the specified number of
+	// attempts are reached within an interval.
+	RestartPolicyModeFail = "fail"
+)
+
+// RestartPolicy configures how Tasks are restarted when they crash or fail.
+type RestartPolicy struct {
+	// Attempts is the number of restart that will occur in an interval.
+	Attempts int
+
+	// Interval is a duration in which we can limit the number of restarts
+	// within.
+	Interval time.Duration
+
+	// Delay is the time between a failure and a restart.
+	Delay time.Duration
+
+	// Mode controls what happens when the task restarts more than attempt times
+	// in an interval.
+	Mode string
+}
+
+func (r *RestartPolicy) Copy() *RestartPolicy {
+	if r == nil {
+		return nil
+	}
+	nrp := new(RestartPolicy)
+	*nrp = *r
+	return nrp
+}
+
+func (r *RestartPolicy) Validate() error {
+	switch r.Mode {
+	case RestartPolicyModeDelay, RestartPolicyModeFail:
+	default:
+		return fmt.Errorf("Unsupported restart mode: %q", r.Mode)
+	}
+
+	// Check for ambiguous/confusing settings
+	if r.Attempts == 0 && r.Mode != RestartPolicyModeFail {
+		return fmt.Errorf("Restart policy %q with %d attempts is ambiguous", r.Mode, r.Attempts)
+	}
+
+	if r.Interval == 0 {
+		return nil
+	}
+	if time.Duration(r.Attempts)*r.Delay > r.Interval {
+		return fmt.Errorf("Nomad can't restart the TaskGroup %v times in an interval of %v with a delay of %v", r.Attempts, r.Interval, r.Delay)
+	}
+	return nil
+}
+
+func NewRestartPolicy(jobType string) *RestartPolicy {
+	switch jobType {
+	case JobTypeService, JobTypeSystem:
+		rp := defaultServiceJobRestartPolicy
+		return &rp
+	case JobTypeBatch:
+		rp := defaultBatchJobRestartPolicy
+		return &rp
+	}
+	return nil
+}
+
+var (
+	defaultServiceJobReschedulePolicy = ReschedulePolicy{
+		Delay:         30 * time.Second,
+		DelayFunction: ReschedulePolicyDelayExponential,
+		MaxDelay:      1 * time.Hour,
+		Unlimited:     true,
+	}
+	defaultBatchJobReschedulePolicy = ReschedulePolicy{
+		Attempts:      1,
+		Interval:      24 * time.Hour,
+		Delay:         5 * time.Second,
+		DelayFunction: ReschedulePolicyDelayConstant,
+	}
+)
+
+const (
+	// ReschedulePolicyDelayConstant waits the same delay before each
+	// rescheduling attempt.
+	ReschedulePolicyDelayConstant = "constant"
+
+	// ReschedulePolicyDelayExponential doubles the delay of each rescheduling
+	// attempt, up to the maximum delay.
+	ReschedulePolicyDelayExponential = "exponential"
 )
 
-// RestartPolicy configures how Tasks are restarted when they crash or fail.
-type RestartPolicy struct {
-	// Attempts is the number of restart that will occur in an interval.
+// ReschedulePolicy configures how the scheduler replaces the allocations of a
+// TaskGroup that failed.
+type ReschedulePolicy struct {
+	// Attempts is the number of reschedules that may occur in an interval.
 	Attempts int
 
-	// Interval is a duration in which we can limit the number of restarts
+	// Interval is a duration in which we can limit the number of reschedules
 	// within.
 	Interval time.Duration
 
-	// Delay is the time between a failure and a restart.
+	// Delay is the time between a failure and the placement of its
+	// replacement.
 	Delay time.Duration
 
-	// Mode controls what happens when the task restarts more than attempt times
-	// in an interval.
-	Mode string
+	// DelayFunction determines how the delay progresses on successive
+	// reschedules.
+	DelayFunction string `mapstructure:"delay_function"`
+
+	// MaxDelay is the upper bound of an exponentially increasing delay.
+	MaxDelay time.Duration `mapstructure:"max_delay"`
+
+	// Unlimited allows an unlimited number of reschedules.
+	Unlimited bool
 }
 
-func (r *RestartPolicy) Copy() *RestartPolicy {
+func (r *ReschedulePolicy) Copy() *ReschedulePolicy {
 	if r == nil {
 		return nil
 	}
-	nrp := new(RestartPolicy)
+	nrp := new(ReschedulePolicy)
 	*nrp = *r
 	return nrp
 }
 
-func (r *RestartPolicy) Validate() error {
-	switch r.Mode {
-	case RestartPolicyModeDelay, RestartPolicyModeFail:
+func (r *ReschedulePolicy) Validate() error {
+	var mErr multierror.Error
+	switch r.DelayFunction {
+	case ReschedulePolicyDelayConstant, ReschedulePolicyDelayExponential:
 	default:
-		return fmt.Errorf("Unsupported restart mode: %q", r.Mode)
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unsupported reschedule delay function: %q", r.DelayFunction))
+	}
+
+	if r.Attempts < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Reschedule attempts must not be negative"))
+	}
+	if r.Delay < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Reschedule delay must not be negative"))
 	}
 
 	// Check for ambiguous/confusing settings
-	if r.Attempts == 0 && r.Mode != RestartPolicyModeFail {
-		return fmt.Errorf("Restart policy %q with %d attempts is ambiguous", r.Mode, r.Attempts)
+	if r.Unlimited && r.Attempts != 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unlimited reschedule policy with %d attempts is ambiguous", r.Attempts))
+	}
+	if !r.Unlimited && r.Attempts > 0 && r.Interval <= 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Reschedule policy with %d attempts requires an interval", r.Attempts))
 	}
+	if r.DelayFunction == ReschedulePolicyDelayExponential && r.MaxDelay < r.Delay {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Reschedule max delay %v must not be less than the delay %v", r.MaxDelay, r.Delay))
+	}
+	return mErr.ErrorOrNil()
+}
 
-	if r.Interval == 0 {
+// Enabled returns whether the policy allows failed allocations to be
+// rescheduled at all.
+func (r *ReschedulePolicy) Enabled() bool {
+	return r.Unlimited || r.Attempts > 0
+}
+
+// NextDelay returns the delay of the rescheduling attempt following the one
+// that was delayed by the given delay, or of the first attempt if it is zero.
+func (r *ReschedulePolicy) NextDelay(prev time.Duration) time.Duration {
+	if prev == 0 || r.DelayFunction != ReschedulePolicyDelayExponential {
+		return r.Delay
+	}
+	if next := 2 * prev; next < r.MaxDelay {
+		return next
+	}
+	return r.MaxDelay
+}
+
+const (
+	// DefaultMigrateHealthDeadline is the health deadline used if a migrate
+	// strategy doesn't set one.
+	DefaultMigrateHealthDeadline = 5 * time.Minute
+)
+
+// MigrateStrategy throttles the migration of the allocations of a TaskGroup
+// off draining nodes.
+type MigrateStrategy struct {
+	// MaxParallel is the number of allocations that may be migrated at once.
+	// An allocation is being migrated until its replacement is running or
+	// the health deadline passed.
+	MaxParallel int `mapstructure:"max_parallel"`
+
+	// HealthDeadline is the time within which a replacement must be running
+	// before the next allocation is migrated regardless.
+	HealthDeadline time.Duration `mapstructure:"health_deadline"`
+}
+
+func (m *MigrateStrategy) Copy() *MigrateStrategy {
+	if m == nil {
 		return nil
 	}
-	if time.Duration(r.Attempts)*r.Delay > r.Interval {
-		return fmt.Errorf("Nomad can't restart the TaskGroup %v times in an interval of %v with a delay of %v", r.Attempts, r.Interval, r.Delay)
+	nm := new(MigrateStrategy)
+	*nm = *m
+	return nm
+}
+
+// Deadline returns the time within which a replacement must be running.
+func (m *MigrateStrategy) Deadline() time.Duration {
+	if m.HealthDeadline == 0 {
+		return DefaultMigrateHealthDeadline
 	}
-	return nil
+	return m.HealthDeadline
 }
 
-func NewRestartPolicy(jobType string) *RestartPolicy {
+// Validate returns an error if the migrate strategy is invalid
+func (m *MigrateStrategy) Validate() error {
+	var mErr multierror.Error
+	if m.MaxParallel <= 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Migrate max_parallel must be positive"))
+	}
+	if m.HealthDeadline < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Migrate health_deadline must not be negative"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+func NewReschedulePolicy(jobType string) *ReschedulePolicy {
 	switch jobType {
-	case JobTypeService, JobTypeSystem:
-		rp := defaultServiceJobRestartPolicy
+	case JobTypeService:
+		rp := defaultServiceJobReschedulePolicy
 		return &rp
 	case JobTypeBatch:
-		rp := defaultBatchJobRestartPolicy
+		rp := defaultBatchJobReschedulePolicy
 		return &rp
 	}
 	return nil
@@ -1535,6 +2846,14 @@ type TaskGroup struct {
 	//RestartPolicy of a TaskGroup
 	RestartPolicy *RestartPolicy
 
+	// ReschedulePolicy controls how failed allocations of the TaskGroup are
+	// replaced by the scheduler
+	ReschedulePolicy *ReschedulePolicy
+
+	// Migrate throttles the migration of the allocations of the TaskGroup off
+	// draining nodes. If nil, they are all migrated at once.
+	Migrate *MigrateStrategy
+
 	// Tasks are the collection of tasks that this task group needs to run
 	Tasks []*Task
 
@@ -1544,6 +2863,10 @@ type TaskGroup struct {
 	// Meta is used to associate arbitrary metadata with this
 	// task group. This is opaque to Nomad.
 	Meta map[string]string
+
+	// Volumes are the volumes requested by the task group, by name. Tasks
+	// mount them with their VolumeMounts.
+	Volumes map[string]*VolumeRequest
 }
 
 func (tg *TaskGroup) Copy() *TaskGroup {
@@ -1555,6 +2878,8 @@ func (tg *TaskGroup) Copy() *TaskGroup {
 	ntg.Constraints = CopySliceConstraints(ntg.Constraints)
 
 	ntg.RestartPolicy = ntg.RestartPolicy.Copy()
+	ntg.ReschedulePolicy = ntg.ReschedulePolicy.Copy()
+	ntg.Migrate = ntg.Migrate.Copy()
 
 	if tg.Tasks != nil {
 		tasks := make([]*Task, len(ntg.Tasks))
@@ -1565,6 +2890,7 @@ func (tg *TaskGroup) Copy() *TaskGroup {
 	}
 
 	ntg.Meta = CopyMapStringString(ntg.Meta)
+	ntg.Volumes = CopyMapVolumeRequest(ntg.Volumes)
 
 	if tg.EphemeralDisk != nil {
 		ntg.EphemeralDisk = tg.EphemeralDisk.Copy()
@@ -1579,12 +2905,22 @@ func (tg *TaskGroup) Canonicalize(job *Job) {
 	if len(tg.Meta) == 0 {
 		tg.Meta = nil
 	}
+	if len(tg.Volumes) == 0 {
+		tg.Volumes = nil
+	}
 
 	// Set the default restart policy.
 	if tg.RestartPolicy == nil {
 		tg.RestartPolicy = NewRestartPolicy(job.Type)
 	}
 
+	// Set the default reschedule policy.
+	if tg.ReschedulePolicy == nil {
+		tg.ReschedulePolicy = NewReschedulePolicy(job.Type)
+	} else if tg.ReschedulePolicy.DelayFunction == "" {
+		tg.ReschedulePolicy.DelayFunction = ReschedulePolicyDelayConstant
+	}
+
 	for _, task := range tg.Tasks {
 		task.Canonicalize(job, tg)
 	}
@@ -1617,6 +2953,18 @@ func (tg *TaskGroup) Validate() error {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("Task Group %v should have a restart policy", tg.Name))
 	}
 
+	if tg.ReschedulePolicy != nil {
+		if err := tg.ReschedulePolicy.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	if tg.Migrate != nil {
+		if err := tg.Migrate.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
 	if tg.EphemeralDisk != nil {
 		if err := tg.EphemeralDisk.Validate(); err != nil {
 			mErr.Errors = append(mErr.Errors, err)
@@ -1637,12 +2985,37 @@ func (tg *TaskGroup) Validate() error {
 		}
 	}
 
+	// Validate the volumes
+	for name, volume := range tg.Volumes {
+		if volume.Name != name {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume %q has mismatched name %q", name, volume.Name))
+		}
+		if err := volume.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
 	// Validate the tasks
+	mainTasks := 0
 	for _, task := range tg.Tasks {
 		if err := task.Validate(tg.EphemeralDisk); err != nil {
 			outer := fmt.Errorf("Task %s validation failed: %s", task.Name, err)
 			mErr.Errors = append(mErr.Errors, outer)
 		}
+		for idx, mount := range task.VolumeMounts {
+			if err := mount.Validate(tg.Volumes); err != nil {
+				outer := fmt.Errorf("Task %s volume mount %d validation failed: %s", task.Name, idx+1, err)
+				mErr.Errors = append(mErr.Errors, outer)
+			}
+		}
+		if task.Lifecycle == nil {
+			mainTasks++
+		}
+	}
+
+	// Lifecycle tasks are run around the main tasks, so there must be one
+	if len(tg.Tasks) != 0 && mainTasks == 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Task group must have a task without a lifecycle"))
 	}
 	return mErr.ErrorOrNil()
 }
@@ -1690,6 +3063,7 @@ type ServiceCheck struct {
 	Interval      time.Duration // Interval of the check
 	Timeout       time.Duration // Timeout of the response from the check before consul fails the check
 	InitialStatus string        `mapstructure:"initial_status"` // Initial status of the check
+	CheckRestart  *CheckRestart `mapstructure:"check_restart"`  // Restarts the task when the check keeps failing
 }
 
 func (sc *ServiceCheck) Copy() *ServiceCheck {
@@ -1698,6 +3072,7 @@ func (sc *ServiceCheck) Copy() *ServiceCheck {
 	}
 	nsc := new(ServiceCheck)
 	*nsc = *sc
+	nsc.CheckRestart = sc.CheckRestart.Copy()
 	return nsc
 }
 
@@ -1754,6 +3129,12 @@ func (sc *ServiceCheck) validate() error {
 
 	}
 
+	if sc.CheckRestart != nil {
+		if err := sc.CheckRestart.Validate(); err != nil {
+			return fmt.Errorf("check_restart: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -1782,6 +3163,39 @@ func (sc *ServiceCheck) Hash(serviceID string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// CheckRestart configures the restart of a task whose service check keeps
+// failing. The restart counts against the restart policy of the task group.
+type CheckRestart struct {
+	// Limit is the number of consecutive failures of the check after which
+	// the task is restarted. Zero disables restarts.
+	Limit int
+
+	// Grace is the time to wait after the task was started before the
+	// failures of the check are counted.
+	Grace time.Duration
+}
+
+func (c *CheckRestart) Copy() *CheckRestart {
+	if c == nil {
+		return nil
+	}
+	nc := new(CheckRestart)
+	*nc = *c
+	return nc
+}
+
+// Validate checks that the limit and grace period aren't negative.
+func (c *CheckRestart) Validate() error {
+	var mErr multierror.Error
+	if c.Limit < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("limit (%d) can not be negative", c.Limit))
+	}
+	if c.Grace < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("grace (%v) can not be negative", c.Grace))
+	}
+	return mErr.ErrorOrNil()
+}
+
 // Service represents a Consul service definition in Nomad
 type Service struct {
 	// Name of the service registered with Consul. Consul defaults the
@@ -1795,6 +3209,10 @@ type Service struct {
 	PortLabel string          `mapstructure:"port"`
 	Tags      []string        // List of tags for the service
 	Checks    []*ServiceCheck // List of checks associated with the service
+
+	// CanaryTags replaces Tags while the allocation is a canary that has not
+	// been promoted, allowing traffic to be cut over on promotion.
+	CanaryTags []string `mapstructure:"canary_tags"`
 }
 
 func (s *Service) Copy() *Service {
@@ -1804,6 +3222,7 @@ func (s *Service) Copy() *Service {
 	ns := new(Service)
 	*ns = *s
 	ns.Tags = CopySliceString(ns.Tags)
+	ns.CanaryTags = CopySliceString(ns.CanaryTags)
 
 	if s.Checks != nil {
 		checks := make([]*ServiceCheck, len(ns.Checks))
@@ -1824,6 +3243,9 @@ func (s *Service) Canonicalize(job string, taskGroup string, task string) {
 	if len(s.Tags) == 0 {
 		s.Tags = nil
 	}
+	if len(s.CanaryTags) == 0 {
+		s.CanaryTags = nil
+	}
 	if len(s.Checks) == 0 {
 		s.Checks = nil
 	}
@@ -1911,6 +3333,7 @@ type LogShuttleConfig struct {
 	BatchSize     int
 	BackBuff      int
 	MaxLineLength int
+	MaxLineSplits int
 	KinesisShards int
 }
 
@@ -1984,6 +3407,29 @@ type Task struct {
 	// Artifacts is a list of artifacts to download and extract before running
 	// the task.
 	Artifacts []*TaskArtifact
+
+	// DispatchPayload configures how the task retrieves its input from a
+	// dispatched job's payload.
+	DispatchPayload *DispatchPayloadConfig `mapstructure:"dispatch_payload"`
+
+	// Lifecycle configures when the task is run relative to the main tasks
+	// of the task group. Tasks without it are main tasks.
+	Lifecycle *TaskLifecycleConfig
+
+	// Templates are the set of templates rendered into the task's directory
+	// before the task is started and whenever their data changes.
+	Templates []*Template
+
+	// EnvFromConsul configures environment variables read from Consul each
+	// time the task is started.
+	EnvFromConsul *EnvFromConsulConfig `mapstructure:"env_from_consul"`
+
+	// VolumeMounts mount volumes of the task group into the task.
+	VolumeMounts []*VolumeMount
+
+	// ResourceOverrides override the resources of the task on the nodes of
+	// specific node classes. They are only supported by system jobs.
+	ResourceOverrides []*ResourceOverride
 }
 
 func (t *Task) Copy() *Task {
@@ -2005,8 +3451,20 @@ func (t *Task) Copy() *Task {
 	nt.Constraints = CopySliceConstraints(nt.Constraints)
 
 	nt.Vault = nt.Vault.Copy()
+	nt.DispatchPayload = nt.DispatchPayload.Copy()
+	nt.Lifecycle = nt.Lifecycle.Copy()
+	nt.EnvFromConsul = nt.EnvFromConsul.Copy()
 	nt.Resources = nt.Resources.Copy()
 	nt.Meta = CopyMapStringString(nt.Meta)
+	nt.VolumeMounts = CopySliceVolumeMount(nt.VolumeMounts)
+
+	if t.ResourceOverrides != nil {
+		overrides := make([]*ResourceOverride, len(t.ResourceOverrides))
+		for i, o := range nt.ResourceOverrides {
+			overrides[i] = o.Copy()
+		}
+		nt.ResourceOverrides = overrides
+	}
 
 	if t.Artifacts != nil {
 		artifacts := make([]*TaskArtifact, 0, len(t.Artifacts))
@@ -2016,6 +3474,14 @@ func (t *Task) Copy() *Task {
 		nt.Artifacts = artifacts
 	}
 
+	if t.Templates != nil {
+		templates := make([]*Template, len(t.Templates))
+		for i, tmpl := range nt.Templates {
+			templates[i] = tmpl.Copy()
+		}
+		nt.Templates = templates
+	}
+
 	if i, err := copystructure.Copy(nt.Config); err != nil {
 		nt.Config = i.(map[string]interface{})
 	}
@@ -2036,6 +3502,9 @@ func (t *Task) Canonicalize(job *Job, tg *TaskGroup) {
 	if len(t.Env) == 0 {
 		t.Env = nil
 	}
+	if len(t.VolumeMounts) == 0 {
+		t.VolumeMounts = nil
+	}
 
 	for _, service := range t.Services {
 		service.Canonicalize(job.Name, tg.Name, t.Name)
@@ -2045,6 +3514,10 @@ func (t *Task) Canonicalize(job *Job, tg *TaskGroup) {
 		t.Resources.Canonicalize()
 	}
 
+	for _, template := range t.Templates {
+		template.Canonicalize()
+	}
+
 	// Set the default timeout if it is not specified.
 	if t.KillTimeout == 0 {
 		t.KillTimeout = DefaultKillTimeout
@@ -2064,6 +3537,25 @@ func (t *Task) FindHostAndPortFor(portLabel string) (string, int) {
 	return "", 0
 }
 
+// ResourcesForNodeClass returns the resources of the task when placed on a
+// node of the given node class, applying the matching resource override.
+func (t *Task) ResourcesForNodeClass(class string) *Resources {
+	for _, o := range t.ResourceOverrides {
+		if o.NodeClass != class {
+			continue
+		}
+
+		r := t.Resources.Copy()
+		r.Merge(&Resources{
+			CPU:      o.CPU,
+			MemoryMB: o.MemoryMB,
+			IOPS:     o.IOPS,
+		})
+		return r
+	}
+	return t.Resources
+}
+
 // Validate is used to sanity check a task
 func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 	var mErr multierror.Error
@@ -2096,6 +3588,21 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		}
 	}
 
+	// Validate the resource overrides
+	classes := make(map[string]int, len(t.ResourceOverrides))
+	for idx, o := range t.ResourceOverrides {
+		if err := o.Validate(); err != nil {
+			outer := fmt.Errorf("Resource override %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+		if other, ok := classes[o.NodeClass]; ok {
+			outer := fmt.Errorf("Resource override %d has same node class as %d", idx+1, other)
+			mErr.Errors = append(mErr.Errors, outer)
+		} else {
+			classes[o.NodeClass] = idx + 1
+		}
+	}
+
 	// Validate the log config
 	if t.LogConfig == nil {
 		mErr.Errors = append(mErr.Errors, errors.New("Missing Log Config"))
@@ -2131,12 +3638,45 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		}
 	}
 
+	destinations := make(map[string]int, len(t.Templates))
+	for idx, template := range t.Templates {
+		if err := template.Validate(); err != nil {
+			outer := fmt.Errorf("Template %d validation failed: %v", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+
+		if other, ok := destinations[template.DestPath]; ok {
+			outer := fmt.Errorf("Template %d has same destination as %d", idx+1, other)
+			mErr.Errors = append(mErr.Errors, outer)
+		} else {
+			destinations[template.DestPath] = idx + 1
+		}
+	}
+
 	if t.Vault != nil {
 		if err := t.Vault.Validate(); err != nil {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("Vault validation failed: %v", err))
 		}
 	}
 
+	if t.DispatchPayload != nil {
+		if err := t.DispatchPayload.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Dispatch payload validation failed: %v", err))
+		}
+	}
+
+	if t.Lifecycle != nil {
+		if err := t.Lifecycle.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Lifecycle validation failed: %v", err))
+		}
+	}
+
+	if t.EnvFromConsul != nil {
+		if err := t.EnvFromConsul.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Consul environment validation failed: %v", err))
+		}
+	}
+
 	return mErr.ErrorOrNil()
 }
 
@@ -2211,6 +3751,12 @@ type TaskState struct {
 
 	// Series of task events that transition the state of the task.
 	Events []*TaskEvent
+
+	// FailedSetup marks that the task last failed while being set up, such
+	// as while being validated, downloading its artifacts or being started
+	// by the driver, rather than after it was started. This allows failures
+	// of the infrastructure to be told apart from the application crashing.
+	FailedSetup bool
 }
 
 func (ts *TaskState) Copy() *TaskState {
@@ -2219,6 +3765,7 @@ func (ts *TaskState) Copy() *TaskState {
 	}
 	copy := new(TaskState)
 	copy.State = ts.State
+	copy.FailedSetup = ts.FailedSetup
 
 	if ts.Events != nil {
 		copy.Events = make([]*TaskEvent, len(ts.Events))
@@ -2244,6 +3791,20 @@ func (ts *TaskState) Failed() bool {
 	}
 }
 
+// OOMKilled returns whether the last run of the task ended with the task
+// being killed for exceeding its memory limit.
+func (ts *TaskState) OOMKilled() bool {
+	for i := len(ts.Events) - 1; i >= 0; i-- {
+		switch ts.Events[i].Type {
+		case TaskOOMKilled:
+			return true
+		case TaskStarted:
+			return false
+		}
+	}
+	return false
+}
+
 // Successful returns whether a task finished successfully.
 func (ts *TaskState) Successful() bool {
 	l := len(ts.Events)
@@ -2307,6 +3868,33 @@ const (
 	// TaskSiblingFailed indicates that a sibling task in the task group has
 	// failed.
 	TaskSiblingFailed = "Sibling task failed"
+
+	// TaskRestartSignal indicates that the task has been signalled to be
+	// restarted by an operator.
+	TaskRestartSignal = "Restart Signaled"
+
+	// TaskSignaling indicates that the task is being sent a signal.
+	TaskSignaling = "Signaling"
+
+	// TaskTemplateRenderFailed indicates that rendering the templates of the
+	// task failed.
+	TaskTemplateRenderFailed = "Failed Template Rendering"
+
+	// TaskEnvFromConsulFailed indicates that reading the environment
+	// variables of the task from Consul failed.
+	TaskEnvFromConsulFailed = "Failed Consul Environment"
+
+	// TaskHealthChanged indicates that the health of the task reported by
+	// its driver changed.
+	TaskHealthChanged = "Health Changed"
+
+	// TaskDriverMessage is an informational event reported by the driver of
+	// the task, such as the progress of downloading an image.
+	TaskDriverMessage = "Driver"
+
+	// TaskOOMKilled indicates that the task was killed by the kernel for
+	// exceeding its memory limit.
+	TaskOOMKilled = "OOM Killed"
 )
 
 // TaskEvent is an event that effects the state of a task and contains meta-data
@@ -2315,6 +3903,9 @@ type TaskEvent struct {
 	Type string
 	Time int64 // Unix Nanosecond timestamp
 
+	// DisplayMessage is a human readable description of the event
+	DisplayMessage string
+
 	// Restart fields.
 	RestartReason string
 
@@ -2322,9 +3913,10 @@ type TaskEvent struct {
 	DriverError string // A driver error occurred while starting the task.
 
 	// Task Terminated Fields.
-	ExitCode int    // The exit code of the task.
-	Signal   int    // The signal that terminated the task.
-	Message  string // A possible message explaining the termination of the task.
+	ExitCode  int    // The exit code of the task.
+	Signal    int    // The signal that terminated the task.
+	Message   string // A possible message explaining the termination of the task.
+	OOMKilled bool   // Whether the task was killed for running out of memory.
 
 	// Killing fields
 	KillTimeout time.Duration
@@ -2350,6 +3942,22 @@ type TaskEvent struct {
 	// Name of the sibling task that caused termination of the task that
 	// the TaskEvent refers to.
 	FailedSibling string
+
+	// Template fields
+	TemplateError string // Error rendering the templates
+
+	// Consul environment fields
+	EnvFromConsulError string // Error reading the environment from Consul
+
+	// Health fields
+	HealthStatus string // The health status of the task reported by its driver
+
+	// TaskSignal fields
+	TaskSignalReason string // The reason the task was signaled
+	TaskSignal       string // The signal that was sent to the task
+
+	// Driver fields
+	DriverMessage string // An informational message of the driver
 }
 
 func (te *TaskEvent) GoString() string {
@@ -2372,6 +3980,18 @@ func NewTaskEvent(event string) *TaskEvent {
 	}
 }
 
+// SetupFailure returns whether the event records a failure to set up the task
+// before it could be started.
+func (e *TaskEvent) SetupFailure() bool {
+	switch e.Type {
+	case TaskFailedValidation, TaskDriverFailure, TaskArtifactDownloadFailed, TaskTemplateRenderFailed,
+		TaskEnvFromConsulFailed:
+		return true
+	default:
+		return false
+	}
+}
+
 func (e *TaskEvent) SetDriverError(err error) *TaskEvent {
 	if err != nil {
 		e.DriverError = err.Error()
@@ -2447,6 +4067,45 @@ func (e *TaskEvent) SetFailedSibling(sibling string) *TaskEvent {
 	return e
 }
 
+func (e *TaskEvent) SetTemplateError(err error) *TaskEvent {
+	if err != nil {
+		e.TemplateError = err.Error()
+	}
+	return e
+}
+
+func (e *TaskEvent) SetEnvFromConsulError(err error) *TaskEvent {
+	if err != nil {
+		e.EnvFromConsulError = err.Error()
+	}
+	return e
+}
+
+func (e *TaskEvent) SetHealthStatus(status string) *TaskEvent {
+	e.HealthStatus = status
+	return e
+}
+
+func (e *TaskEvent) SetTaskSignalReason(reason string) *TaskEvent {
+	e.TaskSignalReason = reason
+	return e
+}
+
+func (e *TaskEvent) SetTaskSignal(s os.Signal) *TaskEvent {
+	e.TaskSignal = s.String()
+	return e
+}
+
+func (e *TaskEvent) SetOOMKilled(oom bool) *TaskEvent {
+	e.OOMKilled = oom
+	return e
+}
+
+func (e *TaskEvent) SetDriverMessage(msg string) *TaskEvent {
+	e.DriverMessage = msg
+	return e
+}
+
 // TaskArtifact is an artifact to download before running the task.
 type TaskArtifact struct {
 	// GetterSource is the source to download an artifact using go-getter
@@ -2483,22 +4142,12 @@ func (ta *TaskArtifact) Validate() error {
 	}
 
 	// Verify the destination doesn't escape the tasks directory
-	alloc, err := filepath.Abs(filepath.Join("/", "foo/", "bar/"))
-	if err != nil {
-		mErr.Errors = append(mErr.Errors, err)
-		return mErr.ErrorOrNil()
-	}
-	abs, err := filepath.Abs(filepath.Join(alloc, ta.RelativeDest))
-	if err != nil {
-		mErr.Errors = append(mErr.Errors, err)
-		return mErr.ErrorOrNil()
-	}
-	rel, err := filepath.Rel(alloc, abs)
+	escaped, err := pathEscapesTaskDir(ta.RelativeDest)
 	if err != nil {
 		mErr.Errors = append(mErr.Errors, err)
 		return mErr.ErrorOrNil()
 	}
-	if strings.HasPrefix(rel, "..") {
+	if escaped {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("destination escapes task's directory"))
 	}
 
@@ -2539,19 +4188,146 @@ func (ta *TaskArtifact) Validate() error {
 			return mErr.ErrorOrNil()
 		}
 
-		if len(checksumBytes) != expectedLength {
-			mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid %s checksum: %v", checksumType, checksumVal))
-			return mErr.ErrorOrNil()
+		if len(checksumBytes) != expectedLength {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid %s checksum: %v", checksumType, checksumVal))
+			return mErr.ErrorOrNil()
+		}
+	}
+
+	return mErr.ErrorOrNil()
+}
+
+// pathEscapesTaskDir returns whether the given path, relative to the task's
+// directory, escapes it.
+func pathEscapesTaskDir(path string) (bool, error) {
+	alloc, err := filepath.Abs(filepath.Join("/", "foo/", "bar/"))
+	if err != nil {
+		return false, err
+	}
+	abs, err := filepath.Abs(filepath.Join(alloc, path))
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(alloc, abs)
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(rel, ".."), nil
+}
+
+const (
+	// TemplateChangeModeNoop marks that no action is taken when a template
+	// is re-rendered.
+	TemplateChangeModeNoop = "noop"
+
+	// TemplateChangeModeSignal marks that the task is sent the change signal
+	// when a template is re-rendered.
+	TemplateChangeModeSignal = "signal"
+
+	// TemplateChangeModeRestart marks that the task is restarted when a
+	// template is re-rendered.
+	TemplateChangeModeRestart = "restart"
+)
+
+// Template represents a template rendered into the task's directory from the
+// data of Consul. The template is watched for changes of its data while the
+// task is running.
+type Template struct {
+	// SourcePath is the path of the template, relative to the task's
+	// directory, such as an artifact downloaded into it.
+	SourcePath string `mapstructure:"source"`
+
+	// DestPath is the path the template is rendered to, relative to the
+	// task's directory.
+	DestPath string `mapstructure:"destination"`
+
+	// EmbeddedTmpl is the template itself, used instead of SourcePath.
+	EmbeddedTmpl string `mapstructure:"data"`
+
+	// ChangeMode is the action taken when the template is re-rendered.
+	ChangeMode string `mapstructure:"change_mode"`
+
+	// ChangeSignal is the signal sent to the task when the template is
+	// re-rendered and the change mode is signal.
+	ChangeSignal string `mapstructure:"change_signal"`
+
+	// Splay is the maximum random delay before the change mode is triggered,
+	// spreading the restarts of the tasks reading the same data.
+	Splay time.Duration `mapstructure:"splay"`
+}
+
+func (t *Template) Copy() *Template {
+	if t == nil {
+		return nil
+	}
+	nt := new(Template)
+	*nt = *t
+	return nt
+}
+
+// Canonicalize sets the default change mode.
+func (t *Template) Canonicalize() {
+	if t.ChangeMode == "" {
+		t.ChangeMode = TemplateChangeModeRestart
+	}
+	if t.ChangeSignal != "" {
+		t.ChangeSignal = strings.ToUpper(t.ChangeSignal)
+	}
+}
+
+// Validate checks that the template has a source, a destination within the
+// task's directory and a known change mode.
+func (t *Template) Validate() error {
+	var mErr multierror.Error
+
+	// Verify we have something to render
+	if t.SourcePath == "" && t.EmbeddedTmpl == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Must specify a source path or have an embedded template"))
+	} else if t.SourcePath != "" && t.EmbeddedTmpl != "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Can't specify both a source path and an embedded template"))
+	}
+
+	// Verify the source doesn't escape the tasks directory
+	if t.SourcePath != "" {
+		if escaped, err := pathEscapesTaskDir(t.SourcePath); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		} else if escaped {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("source escapes task's directory"))
+		}
+	}
+
+	// Verify we can render somewhere
+	if t.DestPath == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Must specify a destination for the template"))
+	} else if escaped, err := pathEscapesTaskDir(t.DestPath); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	} else if escaped {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("destination escapes task's directory"))
+	}
+
+	// Verify the change mode
+	switch t.ChangeMode {
+	case TemplateChangeModeNoop, TemplateChangeModeRestart:
+	case TemplateChangeModeSignal:
+		if t.ChangeSignal == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Must specify signal value when change mode is signal"))
 		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid change mode %q", t.ChangeMode))
+	}
+
+	if t.Splay < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Must specify positive splay value"))
 	}
 
 	return mErr.ErrorOrNil()
 }
 
 const (
-	ConstraintDistinctHosts = "distinct_hosts"
-	ConstraintRegex         = "regexp"
-	ConstraintVersion       = "version"
+	ConstraintDistinctHosts    = "distinct_hosts"
+	ConstraintDistinctProperty = "distinct_property"
+	ConstraintRegex            = "regexp"
+	ConstraintVersion          = "version"
 )
 
 // Constraints are used to restrict placement options.
@@ -2595,6 +4371,10 @@ func (c *Constraint) Validate() error {
 		if _, err := version.NewConstraint(c.RTarget); err != nil {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("Version constraint is invalid: %v", err))
 		}
+	case ConstraintDistinctProperty:
+		if c.LTarget == "" {
+			mErr.Errors = append(mErr.Errors, errors.New("Distinct property constraint must specify a property"))
+		}
 	}
 	return mErr.ErrorOrNil()
 }
@@ -2660,6 +4440,210 @@ func (v *Vault) Validate() error {
 	return nil
 }
 
+const (
+	DeploymentStatusRunning    = "running"
+	DeploymentStatusFailed     = "failed"
+	DeploymentStatusSuccessful = "successful"
+	DeploymentStatusCancelled  = "cancelled"
+)
+
+const (
+	DeploymentStatusDescriptionRunning           = "Deployment is running"
+	DeploymentStatusDescriptionSuccessful        = "Deployment completed successfully"
+	DeploymentStatusDescriptionNewerJob          = "Cancelled due to newer version of job"
+	DeploymentStatusDescriptionFailedAllocations = "Failed due to unhealthy allocations"
+	DeploymentStatusDescriptionFailedByUser      = "Deployment marked as failed"
+	DeploymentStatusDescriptionNeedsPromotion    = "Deployment is running but requires promotion"
+)
+
+// DeploymentStatusDescriptionRollback is used to describe a failed deployment
+// whose job was reverted to the given version.
+func DeploymentStatusDescriptionRollback(desc string, version uint64) string {
+	return fmt.Sprintf("%s - rolling back to job version %d", desc, version)
+}
+
+// Deployment tracks the rollout of a version of a job whose update strategy
+// is health gated.
+type Deployment struct {
+	// ID is a generated UUID for the deployment
+	ID string
+
+	// JobID is the job the deployment is created for
+	JobID string
+
+	// Namespace is the namespace of the deployment's job
+	Namespace string
+
+	// JobVersion is the version of the job the deployment is rolling out
+	JobVersion uint64
+
+	// JobModifyIndex is the modify index of the job version
+	JobModifyIndex uint64
+
+	// TaskGroups is the progress of the deployment per task group
+	TaskGroups map[string]*DeploymentState
+
+	// Status is the status of the deployment
+	Status string
+
+	// StatusDescription allows a human readable description of the
+	// deployment status
+	StatusDescription string
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// NewDeployment creates a running deployment for the given job
+func NewDeployment(job *Job) *Deployment {
+	return &Deployment{
+		ID:                GenerateUUID(),
+		JobID:             job.ID,
+		Namespace:         job.Namespace,
+		JobVersion:        job.Version,
+		JobModifyIndex:    job.JobModifyIndex,
+		TaskGroups:        make(map[string]*DeploymentState, len(job.TaskGroups)),
+		Status:            DeploymentStatusRunning,
+		StatusDescription: DeploymentStatusDescriptionRunning,
+	}
+}
+
+func (d *Deployment) Copy() *Deployment {
+	if d == nil {
+		return nil
+	}
+
+	c := new(Deployment)
+	*c = *d
+
+	c.TaskGroups = nil
+	if d.TaskGroups != nil {
+		c.TaskGroups = make(map[string]*DeploymentState, len(d.TaskGroups))
+		for tg, state := range d.TaskGroups {
+			c.TaskGroups[tg] = state.Copy()
+		}
+	}
+	return c
+}
+
+// Active returns whether the deployment is still rolling out its job
+func (d *Deployment) Active() bool {
+	return d.Status == DeploymentStatusRunning
+}
+
+// HasUnhealthy returns whether any allocation of the deployment is unhealthy
+func (d *Deployment) HasUnhealthy() bool {
+	for _, state := range d.TaskGroups {
+		if state.UnhealthyAllocs > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Healthy returns whether all the allocations the deployment desires to
+// place are healthy.
+func (d *Deployment) Healthy() bool {
+	if d.RequiresPromotion() {
+		return false
+	}
+	for _, state := range d.TaskGroups {
+		if state.HealthyAllocs < state.DesiredTotal {
+			return false
+		}
+	}
+	return true
+}
+
+// HealthyAllocs returns the number of healthy allocations of the deployment
+func (d *Deployment) HealthyAllocs() int {
+	healthy := 0
+	for _, state := range d.TaskGroups {
+		healthy += state.HealthyAllocs
+	}
+	return healthy
+}
+
+// RequiresPromotion returns whether the deployment placed canaries that have
+// not been promoted yet in any of its task groups.
+func (d *Deployment) RequiresPromotion() bool {
+	for _, state := range d.TaskGroups {
+		if state.RequiresPromotion() {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoRevert returns whether the job should be reverted if the deployment
+// fails.
+func (d *Deployment) AutoRevert() bool {
+	for _, state := range d.TaskGroups {
+		if state.AutoRevert {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Deployment) GoString() string {
+	return fmt.Sprintf("<Deployment '%s' JobID: '%s' JobVersion: %d Status: '%s'>",
+		d.ID, d.JobID, d.JobVersion, d.Status)
+}
+
+// DeploymentState tracks the progress of a deployment for a task group
+type DeploymentState struct {
+	// AutoRevert marks whether the job is reverted if the deployment fails
+	AutoRevert bool
+
+	// DesiredTotal is the number of allocations the deployment places
+	DesiredTotal int
+
+	// PlacedAllocs is the number of allocations placed by the deployment
+	PlacedAllocs int
+
+	// HealthyAllocs is the number of placed allocations that are healthy
+	HealthyAllocs int
+
+	// UnhealthyAllocs is the number of placed allocations that are unhealthy
+	UnhealthyAllocs int
+
+	// DesiredCanaries is the number of canaries the deployment places
+	DesiredCanaries int
+
+	// PlacedCanaries is the number of canaries placed by the deployment
+	PlacedCanaries int
+
+	// Promoted marks whether the canaries have been promoted, allowing the
+	// rollout to proceed.
+	Promoted bool
+}
+
+func (d *DeploymentState) Copy() *DeploymentState {
+	c := new(DeploymentState)
+	*c = *d
+	return c
+}
+
+// RequiresPromotion returns whether the task group placed canaries that have
+// not been promoted yet. The rollout of the task group waits for them to be.
+func (d *DeploymentState) RequiresPromotion() bool {
+	return d.DesiredCanaries > 0 && !d.Promoted
+}
+
+// DeploymentStatusUpdate is used to update the status of a deployment
+type DeploymentStatusUpdate struct {
+	// DeploymentID is the ID of the deployment to update
+	DeploymentID string
+
+	// Status is the new status of the deployment
+	Status string
+
+	// StatusDescription is the new status description of the deployment
+	StatusDescription string
+}
+
 const (
 	AllocDesiredStatusRun   = "run"   // Allocation should run
 	AllocDesiredStatusStop  = "stop"  // Allocation should stop
@@ -2685,6 +4669,9 @@ type Allocation struct {
 	// Name is a logical name of the allocation.
 	Name string
 
+	// Namespace is the namespace of the allocation's job
+	Namespace string
+
 	// NodeID is the node this is being placed on
 	NodeID string
 
@@ -2730,6 +4717,24 @@ type Allocation struct {
 	// PreviousAllocation is the allocation that this allocation is replacing
 	PreviousAllocation string
 
+	// PreemptedAllocations is the set of allocations evicted to make room
+	// for this allocation.
+	PreemptedAllocations []string
+
+	// PreemptedByAllocation is the allocation that preempted this allocation.
+	PreemptedByAllocation string
+
+	// DeploymentID identifies the deployment that placed the allocation
+	DeploymentID string
+
+	// DeploymentStatus captures the health of the allocation as reported by
+	// the client or set when promoting its deployment.
+	DeploymentStatus *AllocDeploymentStatus
+
+	// RescheduleTracker tracks the rescheduling attempts that led to this
+	// allocation replacing failed allocations.
+	RescheduleTracker *RescheduleTracker
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -2771,6 +4776,14 @@ func (a *Allocation) Copy() *Allocation {
 		}
 		na.TaskStates = ts
 	}
+
+	na.DeploymentStatus = na.DeploymentStatus.Copy()
+	na.RescheduleTracker = na.RescheduleTracker.Copy()
+
+	if a.PreemptedAllocations != nil {
+		na.PreemptedAllocations = make([]string, len(a.PreemptedAllocations))
+		copy(na.PreemptedAllocations, a.PreemptedAllocations)
+	}
 	return na
 }
 
@@ -2820,6 +4833,134 @@ func (a *Allocation) RanSuccessfully() bool {
 	return allSuccess
 }
 
+// FinishTime returns the time of the last event of the tasks of the
+// allocation, falling back to its creation time if there is none.
+func (a *Allocation) FinishTime() time.Time {
+	var last int64
+	for _, state := range a.TaskStates {
+		for _, e := range state.Events {
+			if e.Time > last {
+				last = e.Time
+			}
+		}
+	}
+	if last == 0 {
+		last = a.CreateTime
+	}
+	return time.Unix(0, last).UTC()
+}
+
+// FailedSetup returns whether one of the allocation's tasks failed while being
+// set up rather than after it was started.
+func (a *Allocation) FailedSetup() bool {
+	for _, state := range a.TaskStates {
+		if state.FailedSetup && state.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// AllocTaskEvent is an event of a task of an allocation.
+type AllocTaskEvent struct {
+	Task  string
+	Event *TaskEvent
+}
+
+// TaskEvents returns the events of the tasks of the allocation ordered by
+// time.
+func (a *Allocation) TaskEvents() []*AllocTaskEvent {
+	var events []*AllocTaskEvent
+	for task, state := range a.TaskStates {
+		for _, e := range state.Events {
+			events = append(events, &AllocTaskEvent{Task: task, Event: e})
+		}
+	}
+	sort.Stable(AllocTaskEvents(events))
+	return events
+}
+
+// AllocTaskEvents sorts the events of tasks by time.
+type AllocTaskEvents []*AllocTaskEvent
+
+func (e AllocTaskEvents) Len() int      { return len(e) }
+func (e AllocTaskEvents) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e AllocTaskEvents) Less(i, j int) bool {
+	if e[i].Event.Time != e[j].Event.Time {
+		return e[i].Event.Time < e[j].Event.Time
+	}
+	return e[i].Task < e[j].Task
+}
+
+// RescheduleEligible returns whether the failed allocation may be rescheduled
+// at the given time according to the policy, taking the attempts made within
+// the interval of the policy into account.
+func (a *Allocation) RescheduleEligible(policy *ReschedulePolicy, now time.Time) bool {
+	if policy == nil || !policy.Enabled() {
+		return false
+	}
+	if policy.Unlimited {
+		return true
+	}
+
+	attempts := 0
+	if a.RescheduleTracker != nil {
+		window := now.Add(-policy.Interval).UnixNano()
+		for _, e := range a.RescheduleTracker.Events {
+			if e.RescheduleTime > window {
+				attempts++
+			}
+		}
+	}
+	return attempts < policy.Attempts
+}
+
+// NextRescheduleDelay returns the delay to wait after the allocation failed
+// before rescheduling it according to the policy. An allocation that failed
+// while setting up one of its tasks is rescheduled right away, as the failure
+// is attributed to the infrastructure rather than to the application, and
+// such failures do not grow the delay of the following reschedules.
+func (a *Allocation) NextRescheduleDelay(policy *ReschedulePolicy) time.Duration {
+	if a.FailedSetup() {
+		return 0
+	}
+
+	var prev time.Duration
+	if a.RescheduleTracker != nil {
+		for i := len(a.RescheduleTracker.Events) - 1; i >= 0; i-- {
+			if e := a.RescheduleTracker.Events[i]; !e.SetupFailure {
+				prev = e.Delay
+				break
+			}
+		}
+	}
+	return policy.NextDelay(prev)
+}
+
+// NextRescheduleTracker returns the tracker of the allocation rescheduled at
+// the given time to replace this failed allocation. Only the attempts that
+// still count towards a limited policy are retained.
+func (a *Allocation) NextRescheduleTracker(policy *ReschedulePolicy, now time.Time) *RescheduleTracker {
+	var events []*RescheduleEvent
+	if a.RescheduleTracker != nil && !policy.Unlimited {
+		window := now.Add(-policy.Interval).UnixNano()
+		for _, e := range a.RescheduleTracker.Events {
+			if e.RescheduleTime > window {
+				events = append(events, e.Copy())
+			}
+		}
+	}
+
+	events = append(events, &RescheduleEvent{
+		RescheduleTime: now.UnixNano(),
+		PrevAllocID:    a.ID,
+		PrevNodeID:     a.NodeID,
+		Delay:          a.NextRescheduleDelay(policy),
+		SetupFailure:   a.FailedSetup(),
+	})
+	return &RescheduleTracker{Events: events}
+}
+
 // Stub returns a list stub for the allocation
 func (a *Allocation) Stub() *AllocListStub {
 	return &AllocListStub{
@@ -2834,6 +4975,7 @@ func (a *Allocation) Stub() *AllocListStub {
 		ClientStatus:       a.ClientStatus,
 		ClientDescription:  a.ClientDescription,
 		TaskStates:         a.TaskStates,
+		DeploymentStatus:   a.DeploymentStatus,
 		CreateIndex:        a.CreateIndex,
 		ModifyIndex:        a.ModifyIndex,
 		CreateTime:         a.CreateTime,
@@ -2874,11 +5016,107 @@ type AllocListStub struct {
 	ClientStatus       string
 	ClientDescription  string
 	TaskStates         map[string]*TaskState
+	DeploymentStatus   *AllocDeploymentStatus
 	CreateIndex        uint64
 	ModifyIndex        uint64
 	CreateTime         int64
 }
 
+// RescheduleTracker tracks the rescheduling attempts of the allocations that
+// replaced one another after failing.
+type RescheduleTracker struct {
+	Events []*RescheduleEvent
+}
+
+func (r *RescheduleTracker) Copy() *RescheduleTracker {
+	if r == nil {
+		return nil
+	}
+	nt := new(RescheduleTracker)
+	if r.Events != nil {
+		nt.Events = make([]*RescheduleEvent, len(r.Events))
+		for i, e := range r.Events {
+			nt.Events[i] = e.Copy()
+		}
+	}
+	return nt
+}
+
+// RescheduleEvent records the rescheduling of a failed allocation.
+type RescheduleEvent struct {
+	// RescheduleTime is the time the replacement was placed, in nanoseconds
+	// since the epoch.
+	RescheduleTime int64
+
+	// PrevAllocID is the ID of the failed allocation
+	PrevAllocID string
+
+	// PrevNodeID is the node the failed allocation ran on
+	PrevNodeID string
+
+	// Delay is the time that was waited after the failure
+	Delay time.Duration
+
+	// SetupFailure marks that the failed allocation failed while setting up
+	// one of its tasks rather than while running it.
+	SetupFailure bool
+}
+
+func (r *RescheduleEvent) Copy() *RescheduleEvent {
+	if r == nil {
+		return nil
+	}
+	ne := new(RescheduleEvent)
+	*ne = *r
+	return ne
+}
+
+// AllocDeploymentStatus captures the health of an allocation placed by a
+// deployment.
+type AllocDeploymentStatus struct {
+	// Healthy marks whether the allocation is healthy. It is unset as long as
+	// the health is not known.
+	Healthy *bool
+
+	// Canary marks whether the allocation is a canary placed alongside the
+	// allocation of the same name it replaces once the deployment is
+	// promoted.
+	Canary bool
+}
+
+// IsHealthy returns if the allocation is marked as healthy
+func (a *AllocDeploymentStatus) IsHealthy() bool {
+	return a != nil && a.Healthy != nil && *a.Healthy
+}
+
+// IsUnhealthy returns if the allocation is marked as unhealthy
+func (a *AllocDeploymentStatus) IsUnhealthy() bool {
+	return a != nil && a.Healthy != nil && !*a.Healthy
+}
+
+// HasHealth returns if the health of the allocation is known
+func (a *AllocDeploymentStatus) HasHealth() bool {
+	return a != nil && a.Healthy != nil
+}
+
+// IsCanary returns if the allocation is a canary
+func (a *AllocDeploymentStatus) IsCanary() bool {
+	return a != nil && a.Canary
+}
+
+func (a *AllocDeploymentStatus) Copy() *AllocDeploymentStatus {
+	if a == nil {
+		return nil
+	}
+	c := new(AllocDeploymentStatus)
+	c.Canary = a.Canary
+	if a.Healthy != nil {
+		healthy := *a.Healthy
+		c.Healthy = &healthy
+	}
+	return c
+}
+
 // AllocMetric is used to track various metrics while attempting
 // to make an allocation. These are used to debug a job, or to better
 // understand the pressure within the system.
@@ -2991,13 +5229,17 @@ const (
 )
 
 const (
-	EvalTriggerJobRegister   = "job-register"
-	EvalTriggerJobDeregister = "job-deregister"
-	EvalTriggerPeriodicJob   = "periodic-job"
-	EvalTriggerNodeUpdate    = "node-update"
-	EvalTriggerScheduled     = "scheduled"
-	EvalTriggerRollingUpdate = "rolling-update"
-	EvalTriggerMaxPlans      = "max-plan-attempts"
+	EvalTriggerJobRegister       = "job-register"
+	EvalTriggerJobDeregister     = "job-deregister"
+	EvalTriggerPeriodicJob       = "periodic-job"
+	EvalTriggerNodeUpdate        = "node-update"
+	EvalTriggerScheduled         = "scheduled"
+	EvalTriggerRollingUpdate     = "rolling-update"
+	EvalTriggerDeploymentWatcher = "deployment-watcher"
+	EvalTriggerMaxPlans          = "max-plan-attempts"
+	EvalTriggerRetryFailedAlloc  = "alloc-failure"
+	EvalTriggerNodeDrain         = "node-drain"
+	EvalTriggerPreemption        = "preemption"
 )
 
 const (
@@ -3048,6 +5290,9 @@ type Evaluation struct {
 	// be run in parallel for a given JobID, so we serialize on this.
 	JobID string
 
+	// Namespace is the namespace of the evaluation's job
+	Namespace string
+
 	// JobModifyIndex is the modify index of the job at the time
 	// the evaluation was created
 	JobModifyIndex uint64
@@ -3108,6 +5353,11 @@ type Evaluation struct {
 	// evaluation was processed. The map is keyed by Task Group names.
 	QueuedAllocations map[string]int
 
+	// DeliveryAttempts is the number of times the evaluation was delivered to
+	// a scheduler without being acknowledged. It is persisted by the leader
+	// such that the delivery limit holds across leader elections.
+	DeliveryAttempts int
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -3215,6 +5465,41 @@ func (e *Evaluation) NextRollingEval(wait time.Duration) *Evaluation {
 		Type:           e.Type,
 		TriggeredBy:    EvalTriggerRollingUpdate,
 		JobID:          e.JobID,
+		Namespace:      e.Namespace,
+		JobModifyIndex: e.JobModifyIndex,
+		Status:         EvalStatusPending,
+		Wait:           wait,
+		PreviousEval:   e.ID,
+	}
+}
+
+// NextRescheduleEval creates an evaluation to followup this eval once the
+// delay before rescheduling failed allocations has elapsed.
+func (e *Evaluation) NextRescheduleEval(wait time.Duration) *Evaluation {
+	return &Evaluation{
+		ID:             GenerateUUID(),
+		Priority:       e.Priority,
+		Type:           e.Type,
+		TriggeredBy:    EvalTriggerRetryFailedAlloc,
+		JobID:          e.JobID,
+		Namespace:      e.Namespace,
+		JobModifyIndex: e.JobModifyIndex,
+		Status:         EvalStatusPending,
+		Wait:           wait,
+		PreviousEval:   e.ID,
+	}
+}
+
+// NextMigrationEval creates an evaluation to followup this eval once the
+// throttled migrations of allocations off draining nodes may continue.
+func (e *Evaluation) NextMigrationEval(wait time.Duration) *Evaluation {
+	return &Evaluation{
+		ID:             GenerateUUID(),
+		Priority:       e.Priority,
+		Type:           e.Type,
+		TriggeredBy:    EvalTriggerNodeDrain,
+		JobID:          e.JobID,
+		Namespace:      e.Namespace,
 		JobModifyIndex: e.JobModifyIndex,
 		Status:         EvalStatusPending,
 		Wait:           wait,
@@ -3232,6 +5517,7 @@ func (e *Evaluation) CreateBlockedEval(classEligibility map[string]bool, escaped
 		Type:                 e.Type,
 		TriggeredBy:          e.TriggeredBy,
 		JobID:                e.JobID,
+		Namespace:            e.Namespace,
 		JobModifyIndex:       e.JobModifyIndex,
 		Status:               EvalStatusBlocked,
 		PreviousEval:         e.ID,
@@ -3240,6 +5526,47 @@ func (e *Evaluation) CreateBlockedEval(classEligibility map[string]bool, escaped
 	}
 }
 
+// PreemptionConfig specifies for which scheduler types the allocations of
+// lower priority jobs may be preempted to place the allocations of higher
+// priority jobs.
+type PreemptionConfig struct {
+	SystemSchedulerEnabled  bool `mapstructure:"system_scheduler_enabled"`
+	ServiceSchedulerEnabled bool `mapstructure:"service_scheduler_enabled"`
+	BatchSchedulerEnabled   bool `mapstructure:"batch_scheduler_enabled"`
+}
+
+// DefaultPreemptionConfig returns the default preemption configuration which
+// only allows the system scheduler to preempt allocations.
+func DefaultPreemptionConfig() PreemptionConfig {
+	return PreemptionConfig{
+		SystemSchedulerEnabled: true,
+	}
+}
+
+// Enabled returns whether preemption is enabled for the scheduler type.
+func (p *PreemptionConfig) Enabled(schedulerType string) bool {
+	switch schedulerType {
+	case JobTypeSystem:
+		return p.SystemSchedulerEnabled
+	case JobTypeService:
+		return p.ServiceSchedulerEnabled
+	case JobTypeBatch:
+		return p.BatchSchedulerEnabled
+	default:
+		return false
+	}
+}
+
+// SchedulerConfiguration is the cluster wide configuration of the schedulers.
+type SchedulerConfiguration struct {
+	// PreemptionConfig specifies the scheduler types that preempt allocations.
+	PreemptionConfig PreemptionConfig
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
 // Plan is used to submit a commit plan for task allocations. These
 // are submitted to the leader which verifies that resources have
 // not been overcommitted before admiting the plan.
@@ -3279,6 +5606,14 @@ type Plan struct {
 	// Annotations contains annotations by the scheduler to be used by operators
 	// to understand the decisions made by the scheduler.
 	Annotations *PlanAnnotations
+
+	// Deployment is the deployment created by the scheduler to roll out the
+	// job's health gated update.
+	Deployment *Deployment
+
+	// DeploymentUpdates is a set of status updates to apply to existing
+	// deployments.
+	DeploymentUpdates []*DeploymentStatusUpdate
 }
 
 // AppendUpdate marks the allocation for eviction. The clientStatus of the
@@ -3311,6 +5646,25 @@ func (p *Plan) AppendUpdate(alloc *Allocation, desiredStatus, desiredDesc, clien
 	p.NodeUpdate[node] = append(existing, newAlloc)
 }
 
+// AppendPreemptedAlloc marks the allocation of a lower priority job for
+// eviction to make room for the allocation with the given ID.
+func (p *Plan) AppendPreemptedAlloc(alloc *Allocation, preemptingAllocID string) {
+	newAlloc := new(Allocation)
+	*newAlloc = *alloc
+
+	// The allocation belongs to another job so only strip it
+	newAlloc.Job = nil
+	newAlloc.Resources = nil
+
+	newAlloc.DesiredStatus = AllocDesiredStatusEvict
+	newAlloc.DesiredDescription = fmt.Sprintf("Preempted by alloc ID %v", preemptingAllocID)
+	newAlloc.PreemptedByAllocation = preemptingAllocID
+
+	node := alloc.NodeID
+	existing := p.NodeUpdate[node]
+	p.NodeUpdate[node] = append(existing, newAlloc)
+}
+
 func (p *Plan) PopUpdate(alloc *Allocation) {
 	existing := p.NodeUpdate[alloc.NodeID]
 	n := len(existing)
@@ -3330,9 +5684,15 @@ func (p *Plan) AppendAlloc(alloc *Allocation) {
 	p.NodeAllocation[node] = append(existing, alloc)
 }
 
+// AppendDeploymentUpdate adds a status update for an existing deployment
+func (p *Plan) AppendDeploymentUpdate(update *DeploymentStatusUpdate) {
+	p.DeploymentUpdates = append(p.DeploymentUpdates, update)
+}
+
 // IsNoOp checks if this plan would do nothing
 func (p *Plan) IsNoOp() bool {
-	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0
+	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 &&
+		p.Deployment == nil && len(p.DeploymentUpdates) == 0
 }
 
 // PlanResult is the result of a plan submitted to the leader.
@@ -3343,6 +5703,12 @@ type PlanResult struct {
 	// NodeAllocation contains all the allocations that were committed.
 	NodeAllocation map[string][]*Allocation
 
+	// Deployment is the deployment that was committed.
+	Deployment *Deployment
+
+	// DeploymentUpdates is the set of deployment updates that were committed.
+	DeploymentUpdates []*DeploymentStatusUpdate
+
 	// RefreshIndex is the index the worker should refresh state up to.
 	// This allows all evictions and allocations to be materialized.
 	// If any allocations were rejected due to stale data (node state,
@@ -3356,7 +5722,8 @@ type PlanResult struct {
 
 // IsNoOp checks if this plan result would do nothing
 func (p *PlanResult) IsNoOp() bool {
-	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0
+	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 &&
+		p.Deployment == nil && len(p.DeploymentUpdates) == 0
 }
 
 // FullCommit is used to check if all the allocations in a plan
@@ -3378,6 +5745,10 @@ func (p *PlanResult) FullCommit(plan *Plan) (bool, int, int) {
 type PlanAnnotations struct {
 	// DesiredTGUpdates is the set of desired updates per task group.
 	DesiredTGUpdates map[string]*DesiredUpdates
+
+	// PreemptedAllocs is the set of allocations of lower priority jobs that
+	// would be evicted to place the job.
+	PreemptedAllocs []*AllocListStub
 }
 
 // DesiredUpdates is the set of changes the scheduler would like to make given
@@ -1,6 +1,7 @@
 package structs
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
@@ -48,6 +49,26 @@ func TestJob_Validate(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
+	j = &Job{
+		Type:         JobTypeService,
+		StopStrategy: JobStopStrategyRolling,
+	}
+	err = j.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Error(), "Stop strategy") {
+		t.Fatalf("err: %s", err)
+	}
+
+	j = &Job{
+		Type:         JobTypeService,
+		StopStrategy: "foo",
+	}
+	err = j.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Error(), "Invalid stop strategy") {
+		t.Fatalf("err: %s", err)
+	}
+
 	j = &Job{
 		Region:      "global",
 		ID:          GenerateUUID(),
@@ -175,6 +196,23 @@ func testJob() *Job {
 	}
 }
 
+func TestJob_Validate_Priority(t *testing.T) {
+	j := testJob()
+	for _, p := range []int{JobMinPriority, 100, 500, JobMaxPriority} {
+		j.Priority = p
+		if err := j.Validate(); err != nil && strings.Contains(err.Error(), "priority") {
+			t.Fatalf("priority %d: %v", p, err)
+		}
+	}
+
+	for _, p := range []int{JobMinPriority - 1, JobMaxPriority + 1} {
+		j.Priority = p
+		if err := j.Validate(); err == nil || !strings.Contains(err.Error(), "priority") {
+			t.Fatalf("priority %d: expected a priority error, got %v", p, err)
+		}
+	}
+}
+
 func TestJob_Copy(t *testing.T) {
 	j := testJob()
 	c := j.Copy()
@@ -399,6 +437,206 @@ func TestTask_Validate(t *testing.T) {
 	}
 }
 
+func TestTask_Validate_ResourceOverrides(t *testing.T) {
+	task := &Task{
+		Name:   "web",
+		Driver: "docker",
+		Resources: &Resources{
+			CPU:      100,
+			MemoryMB: 100,
+		},
+		ResourceOverrides: []*ResourceOverride{
+			{NodeClass: "large", MemoryMB: 1024},
+			{NodeClass: "large", CPU: 200},
+			{MemoryMB: 5},
+		},
+		LogConfig: DefaultLogConfig(),
+	}
+	err := task.Validate(DefaultEphemeralDisk())
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "same node class") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "Missing node class") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "minimum MemoryMB") {
+		t.Fatalf("err: %s", err)
+	}
+
+	task.ResourceOverrides = task.ResourceOverrides[:1]
+	if err := task.Validate(DefaultEphemeralDisk()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestTask_ResourcesForNodeClass(t *testing.T) {
+	task := &Task{
+		Resources: &Resources{
+			CPU:      100,
+			MemoryMB: 100,
+			IOPS:     10,
+		},
+		ResourceOverrides: []*ResourceOverride{
+			{NodeClass: "large", MemoryMB: 1024},
+		},
+	}
+
+	if r := task.ResourcesForNodeClass("small"); r != task.Resources {
+		t.Fatalf("expected the task resources, got %#v", r)
+	}
+
+	expected := &Resources{
+		CPU:      100,
+		MemoryMB: 1024,
+		IOPS:     10,
+	}
+	if r := task.ResourcesForNodeClass("large"); !reflect.DeepEqual(r, expected) {
+		t.Fatalf("got %#v; want %#v", r, expected)
+	}
+	if task.Resources.MemoryMB != 100 {
+		t.Fatalf("task resources modified: %#v", task.Resources)
+	}
+}
+
+func TestJob_Validate_ResourceOverrides(t *testing.T) {
+	j := testJob()
+	j.TaskGroups[0].Tasks[0].ResourceOverrides = []*ResourceOverride{
+		{NodeClass: "large", MemoryMB: 1024},
+	}
+	err := j.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Resource overrides can only be used") {
+		t.Fatalf("expected resource override error, got %v", err)
+	}
+
+	j.Type = JobTypeSystem
+	if err := j.Validate(); err != nil && strings.Contains(err.Error(), "Resource overrides") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestTaskLifecycleConfig_Validate(t *testing.T) {
+	valid := []*TaskLifecycleConfig{
+		{Hook: TaskLifecycleHookPrestart},
+		{Hook: TaskLifecycleHookPrestart, Sidecar: true},
+		{Hook: TaskLifecycleHookPoststop},
+	}
+	for _, l := range valid {
+		if err := l.Validate(); err != nil {
+			t.Fatalf("%#v: unexpected error: %v", l, err)
+		}
+	}
+
+	invalid := []*TaskLifecycleConfig{
+		{},
+		{Hook: "poststart"},
+		{Hook: TaskLifecycleHookPoststop, Sidecar: true},
+	}
+	for _, l := range invalid {
+		if err := l.Validate(); err == nil {
+			t.Fatalf("%#v: expected error", l)
+		}
+	}
+}
+
+func TestTaskGroup_Validate_Lifecycle(t *testing.T) {
+	j := testJob()
+	j.Canonicalize()
+	tg := j.TaskGroups[0]
+	tg.Tasks[0].Lifecycle = &TaskLifecycleConfig{Hook: TaskLifecycleHookPrestart}
+	err := tg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "without a lifecycle") {
+		t.Fatalf("expected missing main task error, got %v", err)
+	}
+
+	main := tg.Tasks[0].Copy()
+	main.Name = "main"
+	main.Lifecycle = nil
+	tg.Tasks = append(tg.Tasks, main)
+	if err := tg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestMigrateStrategy_Validate(t *testing.T) {
+	m := &MigrateStrategy{MaxParallel: 1}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if m.Deadline() != DefaultMigrateHealthDeadline {
+		t.Fatalf("bad deadline: %v", m.Deadline())
+	}
+
+	m = &MigrateStrategy{HealthDeadline: -time.Minute}
+	err := m.Validate()
+	mErr := err.(*multierror.Error)
+	if len(mErr.Errors) != 2 {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[0].Error(), "max_parallel") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "health_deadline") {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestDrainStrategy_DeadlineReached(t *testing.T) {
+	now := time.Now()
+	var d *DrainStrategy
+	if d.DeadlineReached(now) {
+		t.Fatalf("nil strategy reached its deadline")
+	}
+	d = &DrainStrategy{}
+	if d.DeadlineReached(now) {
+		t.Fatalf("strategy without deadline reached its deadline")
+	}
+	d.ForceDeadline = now.Add(time.Minute)
+	if d.DeadlineReached(now) {
+		t.Fatalf("deadline reached early")
+	}
+	if !d.DeadlineReached(now.Add(time.Minute)) {
+		t.Fatalf("deadline not reached")
+	}
+}
+
+func TestTaskGroup_Validate_Volumes(t *testing.T) {
+	j := testJob()
+	j.Canonicalize()
+	tg := j.TaskGroups[0]
+	tg.Volumes = map[string]*VolumeRequest{
+		"data": &VolumeRequest{Name: "data", Type: VolumeTypeHost, Source: "shared_data"},
+	}
+	tg.Tasks[0].VolumeMounts = []*VolumeMount{
+		&VolumeMount{Volume: "data", Destination: "/srv/data"},
+	}
+	if err := tg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tg.Volumes["cache"] = &VolumeRequest{Name: "cache", Type: "csi", Source: "cache"}
+	tg.Volumes["logs"] = &VolumeRequest{Name: "logs", Type: VolumeTypeHost}
+	tg.Tasks[0].VolumeMounts = append(tg.Tasks[0].VolumeMounts,
+		&VolumeMount{Volume: "missing", Destination: "relative"})
+	err := tg.Validate()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, expected := range []string{
+		`unsupported type "csi"`,
+		`Volume "logs" must have a source`,
+		`unknown volume "missing"`,
+		`"relative" must be an absolute path`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q in error: %v", expected, err)
+		}
+	}
+}
+
 func TestTask_Validate_Services(t *testing.T) {
 	s1 := &Service{
 		Name:      "service-name",
@@ -498,6 +736,39 @@ func TestTask_Validate_Service_Check(t *testing.T) {
 	}
 }
 
+func TestServiceCheck_Validate_CheckRestart(t *testing.T) {
+	check := ServiceCheck{
+		Name:     "check-name",
+		Type:     ServiceCheckTCP,
+		Interval: 10 * time.Second,
+		Timeout:  2 * time.Second,
+		CheckRestart: &CheckRestart{
+			Limit: 3,
+			Grace: 10 * time.Second,
+		},
+	}
+	if err := check.validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	check.CheckRestart.Limit = -1
+	check.CheckRestart.Grace = -1 * time.Second
+	err := check.validate()
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if !strings.Contains(err.Error(), "limit (-1)") || !strings.Contains(err.Error(), "grace (-1s)") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The copy must not share the restart config
+	copied := check.Copy()
+	copied.CheckRestart.Limit = 5
+	if check.CheckRestart.Limit != -1 {
+		t.Fatalf("copy shares the check restart config")
+	}
+}
+
 func TestTask_Validate_LogConfig(t *testing.T) {
 	task := &Task{
 		LogConfig: DefaultLogConfig(),
@@ -548,6 +819,14 @@ func TestConstraint_Validate(t *testing.T) {
 	if !strings.Contains(mErr.Errors[0].Error(), "Malformed constraint") {
 		t.Fatalf("err: %s", err)
 	}
+
+	// Perform distinct_property validation
+	c = &Constraint{Operand: ConstraintDistinctProperty}
+	err = c.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "must specify a property") {
+		t.Fatalf("err: %s", err)
+	}
 }
 
 func TestResource_NetIndex(t *testing.T) {
@@ -926,6 +1205,198 @@ func TestJob_ExpandServiceNames(t *testing.T) {
 
 }
 
+func TestFailoverConfig_Validate(t *testing.T) {
+	f := &FailoverConfig{}
+	err := f.Validate("global")
+	mErr := err.(*multierror.Error)
+	if len(mErr.Errors) != 2 {
+		t.Fatalf("err: %s", err)
+	}
+
+	f = &FailoverConfig{Region: "global", After: time.Minute}
+	if err := f.Validate("global"); err == nil || !strings.Contains(err.Error(), "differ") {
+		t.Fatalf("err: %v", err)
+	}
+
+	f = &FailoverConfig{Region: "eu", After: time.Minute}
+	if err := f.Validate("global"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestParameterizedJobConfig_Validate(t *testing.T) {
+	d := &ParameterizedJobConfig{
+		Payload: "foo",
+	}
+
+	if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "payload") {
+		t.Fatalf("Expected unknown payload requirement: %v", err)
+	}
+
+	d.Payload = DispatchPayloadOptional
+	d.MetaOptional = []string{"foo", "bar"}
+	d.MetaRequired = []string{"bar", "baz"}
+
+	if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "both required and optional") {
+		t.Fatalf("Expected meta not being mutually exclusive: %v", err)
+	}
+}
+
+func TestParameterizedJobConfig_Validate_NonBatch(t *testing.T) {
+	job := testJob()
+	job.ParameterizedJob = &ParameterizedJobConfig{
+		Payload: DispatchPayloadOptional,
+	}
+	job.Type = JobTypeSystem
+
+	if err := job.Validate(); err == nil || !strings.Contains(err.Error(), "only be used with") {
+		t.Fatalf("Expected bad scheduler type: %v", err)
+	}
+}
+
+func TestDispatchPayloadConfig_Validate(t *testing.T) {
+	d := &DispatchPayloadConfig{
+		File: "foo",
+	}
+
+	// task/local/haha
+	if err := d.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// task/haha
+	d.File = "../haha"
+	if err := d.Validate(); err == nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// ../haha
+	d.File = "../../../haha"
+	if err := d.Validate(); err == nil {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestEnvFromConsulConfig_Validate(t *testing.T) {
+	e := &EnvFromConsulConfig{
+		Prefix: "config/web/",
+	}
+	if err := e.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	e.Prefix = ""
+	if err := e.Validate(); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestUpdateStrategy_Validate(t *testing.T) {
+	u := &UpdateStrategy{
+		HealthCheck:     "foo",
+		MinHealthyTime:  2 * time.Minute,
+		HealthyDeadline: time.Minute,
+	}
+	err := u.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "Invalid update health check") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "max_parallel") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[2].Error(), "less than healthy_deadline") {
+		t.Fatalf("err: %s", err)
+	}
+
+	u = &UpdateStrategy{AutoRevert: true}
+	if err := u.Validate(); err == nil || !strings.Contains(err.Error(), "auto_revert") {
+		t.Fatalf("err: %v", err)
+	}
+
+	u = &UpdateStrategy{Canary: 1}
+	if err := u.Validate(); err == nil || !strings.Contains(err.Error(), "canary requires a health check") {
+		t.Fatalf("err: %v", err)
+	}
+
+	u = &UpdateStrategy{Canary: -1}
+	if err := u.Validate(); err == nil || !strings.Contains(err.Error(), "canary must not be negative") {
+		t.Fatalf("err: %v", err)
+	}
+
+	u = &UpdateStrategy{
+		MaxParallel:    2,
+		HealthCheck:    UpdateHealthCheckChecks,
+		MinHealthyTime: 10 * time.Second,
+		AutoRevert:     true,
+		Canary:         1,
+	}
+	if err := u.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !u.HealthGated() || u.Deadline() != DefaultHealthyDeadline {
+		t.Fatalf("bad: %#v", u)
+	}
+
+	// Only service jobs can be health gated
+	j := testJob()
+	j.Type = JobTypeBatch
+	j.Update = *u
+	if err := j.Validate(); err == nil || !strings.Contains(err.Error(), "service") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestDeployment_Health(t *testing.T) {
+	d := NewDeployment(testJob())
+	d.TaskGroups["web"] = &DeploymentState{DesiredTotal: 2, PlacedAllocs: 2, HealthyAllocs: 1}
+	d.TaskGroups["db"] = &DeploymentState{DesiredTotal: 1, PlacedAllocs: 1, HealthyAllocs: 1, AutoRevert: true}
+
+	if !d.Active() || d.Healthy() || d.HasUnhealthy() || d.HealthyAllocs() != 2 || !d.AutoRevert() {
+		t.Fatalf("bad: %#v", d)
+	}
+
+	c := d.Copy()
+	c.TaskGroups["web"].HealthyAllocs++
+	if !c.Healthy() || d.Healthy() {
+		t.Fatalf("bad: %#v %#v", c.TaskGroups["web"], d.TaskGroups["web"])
+	}
+
+	c.TaskGroups["db"].UnhealthyAllocs++
+	if !c.HasUnhealthy() || d.HasUnhealthy() {
+		t.Fatalf("bad: %#v %#v", c.TaskGroups["db"], d.TaskGroups["db"])
+	}
+}
+
+func TestDeployment_RequiresPromotion(t *testing.T) {
+	d := NewDeployment(testJob())
+	d.TaskGroups["web"] = &DeploymentState{DesiredTotal: 2, DesiredCanaries: 1, PlacedAllocs: 2, HealthyAllocs: 2}
+	d.TaskGroups["db"] = &DeploymentState{DesiredTotal: 1, PlacedAllocs: 1, HealthyAllocs: 1}
+
+	if !d.RequiresPromotion() || d.Healthy() {
+		t.Fatalf("bad: %#v", d)
+	}
+
+	d.TaskGroups["web"].Promoted = true
+	if d.RequiresPromotion() || !d.Healthy() {
+		t.Fatalf("bad: %#v", d)
+	}
+}
+
+func TestAllocDeploymentStatus_Copy(t *testing.T) {
+	healthy := true
+	a := &AllocDeploymentStatus{Healthy: &healthy, Canary: true}
+	c := a.Copy()
+	if !c.IsCanary() || !c.IsHealthy() || c.Healthy == a.Healthy {
+		t.Fatalf("bad: %#v", c)
+	}
+
+	var n *AllocDeploymentStatus
+	if n.IsCanary() || n.Copy() != nil {
+		t.Fatalf("bad")
+	}
+}
+
 func TestPeriodicConfig_EnabledInvalid(t *testing.T) {
 	// Create a config that is enabled but with no interval specified.
 	p := &PeriodicConfig{Enabled: true}
@@ -979,6 +1450,45 @@ func TestPeriodicConfig_NextCron(t *testing.T) {
 	}
 }
 
+func TestPeriodicLaunch_Skipped(t *testing.T) {
+	launch := &PeriodicLaunch{ID: "foo"}
+
+	// Skipped launches are sorted, deduplicated and stored in UTC
+	base := time.Unix(1000, 0)
+	launch.AddSkipped(base.Add(2*time.Second), base, base.Add(time.Second), base)
+	if len(launch.Skipped) != 3 {
+		t.Fatalf("bad: %v", launch.Skipped)
+	}
+	for i, skipped := range launch.Skipped {
+		if !skipped.Equal(base.Add(time.Duration(i)*time.Second)) || skipped.Location() != time.UTC {
+			t.Fatalf("bad skipped launch %d: %v", i, skipped)
+		}
+	}
+
+	// Copies don't share the skipped launches
+	c := launch.Copy()
+	if !c.RemoveSkipped(base) {
+		t.Fatalf("expected launch to be removed")
+	}
+	if c.RemoveSkipped(base) {
+		t.Fatalf("expected launch to already be removed")
+	}
+	if len(c.Skipped) != 2 || len(launch.Skipped) != 3 || !launch.Skipped[0].Equal(base) {
+		t.Fatalf("bad: %v %v", c.Skipped, launch.Skipped)
+	}
+
+	// Only the most recent skipped launches are kept
+	for i := 0; i < PeriodicMaxSkippedLaunches; i++ {
+		launch.AddSkipped(base.Add(time.Duration(10+i) * time.Second))
+	}
+	if l := len(launch.Skipped); l != PeriodicMaxSkippedLaunches {
+		t.Fatalf("got %d skipped launches; want %d", l, PeriodicMaxSkippedLaunches)
+	}
+	if !launch.Skipped[0].Equal(base.Add(10 * time.Second)) {
+		t.Fatalf("bad earliest skipped launch: %v", launch.Skipped[0])
+	}
+}
+
 func TestRestartPolicy_Validate(t *testing.T) {
 	// Policy with acceptable restart options passes
 	p := &RestartPolicy{
@@ -1019,6 +1529,252 @@ func TestRestartPolicy_Validate(t *testing.T) {
 	}
 }
 
+func TestReschedulePolicy_Validate(t *testing.T) {
+	// The default policies pass
+	for _, jobType := range []string{JobTypeService, JobTypeBatch} {
+		if err := NewReschedulePolicy(jobType).Validate(); err != nil {
+			t.Fatalf("%s: err: %v", jobType, err)
+		}
+	}
+
+	// Bad delay function fails
+	p := &ReschedulePolicy{
+		DelayFunction: "nope",
+		Unlimited:     true,
+	}
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "delay function") {
+		t.Fatalf("expect delay function error, got: %v", err)
+	}
+
+	// Policy with ambiguous attempts fails
+	p = &ReschedulePolicy{
+		DelayFunction: ReschedulePolicyDelayConstant,
+		Attempts:      2,
+		Interval:      time.Hour,
+		Unlimited:     true,
+	}
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expect ambiguity error, got: %v", err)
+	}
+
+	// Limited attempts require an interval
+	p = &ReschedulePolicy{
+		DelayFunction: ReschedulePolicyDelayConstant,
+		Attempts:      2,
+	}
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "interval") {
+		t.Fatalf("expect interval error, got: %v", err)
+	}
+
+	// Exponential delay requires a max delay
+	p = &ReschedulePolicy{
+		DelayFunction: ReschedulePolicyDelayExponential,
+		Delay:         time.Minute,
+		Unlimited:     true,
+	}
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "max delay") {
+		t.Fatalf("expect max delay error, got: %v", err)
+	}
+}
+
+func TestReschedulePolicy_NextDelay(t *testing.T) {
+	p := &ReschedulePolicy{
+		DelayFunction: ReschedulePolicyDelayConstant,
+		Delay:         time.Minute,
+	}
+	if d := p.NextDelay(0); d != time.Minute {
+		t.Fatalf("bad: %v", d)
+	}
+	if d := p.NextDelay(time.Minute); d != time.Minute {
+		t.Fatalf("bad: %v", d)
+	}
+
+	p.DelayFunction = ReschedulePolicyDelayExponential
+	p.MaxDelay = 3 * time.Minute
+	if d := p.NextDelay(0); d != time.Minute {
+		t.Fatalf("bad: %v", d)
+	}
+	if d := p.NextDelay(time.Minute); d != 2*time.Minute {
+		t.Fatalf("bad: %v", d)
+	}
+	if d := p.NextDelay(2 * time.Minute); d != 3*time.Minute {
+		t.Fatalf("bad: %v", d)
+	}
+}
+
+func TestAllocation_Reschedule(t *testing.T) {
+	now := time.Now()
+	policy := &ReschedulePolicy{
+		Attempts:      2,
+		Interval:      time.Hour,
+		Delay:         time.Minute,
+		DelayFunction: ReschedulePolicyDelayConstant,
+	}
+
+	alloc := &Allocation{ID: GenerateUUID(), NodeID: GenerateUUID()}
+	if !alloc.RescheduleEligible(policy, now) {
+		t.Fatalf("alloc should be eligible")
+	}
+
+	// An attempt older than the interval does not count
+	alloc.RescheduleTracker = &RescheduleTracker{
+		Events: []*RescheduleEvent{
+			{RescheduleTime: now.Add(-2 * time.Hour).UnixNano(), Delay: time.Minute},
+			{RescheduleTime: now.Add(-30 * time.Minute).UnixNano(), Delay: time.Minute},
+		},
+	}
+	if !alloc.RescheduleEligible(policy, now) {
+		t.Fatalf("alloc should be eligible")
+	}
+
+	// The next tracker only retains the attempts within the interval
+	tracker := alloc.NextRescheduleTracker(policy, now)
+	if len(tracker.Events) != 2 {
+		t.Fatalf("bad: %#v", tracker.Events)
+	}
+	last := tracker.Events[1]
+	if last.PrevAllocID != alloc.ID || last.PrevNodeID != alloc.NodeID ||
+		last.RescheduleTime != now.UnixNano() || last.Delay != time.Minute {
+		t.Fatalf("bad: %#v", last)
+	}
+
+	// The attempts are exhausted once the limit is reached
+	alloc.RescheduleTracker = tracker
+	if alloc.RescheduleEligible(policy, now) {
+		t.Fatalf("alloc should not be eligible")
+	}
+
+	// Policies without attempts never reschedule
+	if alloc.RescheduleEligible(&ReschedulePolicy{}, now) || alloc.RescheduleEligible(nil, now) {
+		t.Fatalf("alloc should not be eligible")
+	}
+}
+
+func TestAllocation_FailedSetup(t *testing.T) {
+	alloc := &Allocation{
+		ID: GenerateUUID(),
+		TaskStates: map[string]*TaskState{
+			"web": &TaskState{
+				State: TaskStateDead,
+				Events: []*TaskEvent{
+					NewTaskEvent(TaskArtifactDownloadFailed),
+					NewTaskEvent(TaskNotRestarting),
+				},
+				FailedSetup: true,
+			},
+		},
+	}
+	if !alloc.FailedSetup() {
+		t.Fatalf("alloc should have failed setup")
+	}
+
+	// Setup failures are rescheduled right away
+	policy := &ReschedulePolicy{
+		Attempts:      3,
+		Interval:      time.Hour,
+		Delay:         time.Minute,
+		DelayFunction: ReschedulePolicyDelayExponential,
+		MaxDelay:      time.Hour,
+	}
+	if delay := alloc.NextRescheduleDelay(policy); delay != 0 {
+		t.Fatalf("bad delay: %v", delay)
+	}
+	tracker := alloc.NextRescheduleTracker(policy, time.Now())
+	if !tracker.Events[0].SetupFailure || tracker.Events[0].Delay != 0 {
+		t.Fatalf("bad: %#v", tracker.Events[0])
+	}
+
+	// A task that failed after being started did not fail setup, and the
+	// setup failures do not grow its delay
+	alloc.TaskStates["web"].FailedSetup = false
+	if alloc.FailedSetup() {
+		t.Fatalf("alloc should not have failed setup")
+	}
+	alloc.RescheduleTracker = &RescheduleTracker{
+		Events: []*RescheduleEvent{
+			{Delay: 2 * time.Minute},
+			{SetupFailure: true},
+		},
+	}
+	if delay := alloc.NextRescheduleDelay(policy); delay != 4*time.Minute {
+		t.Fatalf("bad delay: %v", delay)
+	}
+
+	if !NewTaskEvent(TaskDriverFailure).SetupFailure() || NewTaskEvent(TaskTerminated).SetupFailure() {
+		t.Fatalf("bad setup failure classification")
+	}
+}
+
+func TestAllocation_FinishTime(t *testing.T) {
+	alloc := &Allocation{CreateTime: 10}
+	if ft := alloc.FinishTime(); ft.UnixNano() != 10 {
+		t.Fatalf("bad: %v", ft)
+	}
+
+	alloc.TaskStates = map[string]*TaskState{
+		"web": &TaskState{
+			Events: []*TaskEvent{{Time: 20}, {Time: 40}},
+		},
+		"sidecar": &TaskState{
+			Events: []*TaskEvent{{Time: 30}},
+		},
+	}
+	if ft := alloc.FinishTime(); ft.UnixNano() != 40 {
+		t.Fatalf("bad: %v", ft)
+	}
+}
+
+func TestAllocation_TaskEvents(t *testing.T) {
+	alloc := &Allocation{}
+	if events := alloc.TaskEvents(); len(events) != 0 {
+		t.Fatalf("bad: %v", events)
+	}
+
+	alloc.TaskStates = map[string]*TaskState{
+		"web": &TaskState{
+			Events: []*TaskEvent{{Type: TaskReceived, Time: 20}, {Type: TaskStarted, Time: 40}},
+		},
+		"sidecar": &TaskState{
+			Events: []*TaskEvent{{Type: TaskReceived, Time: 20}, {Type: TaskStarted, Time: 30}},
+		},
+	}
+
+	var actual []string
+	for _, e := range alloc.TaskEvents() {
+		actual = append(actual, fmt.Sprintf("%s %s %d", e.Task, e.Event.Type, e.Event.Time))
+	}
+	expected := []string{
+		"sidecar Received 20",
+		"web Received 20",
+		"sidecar Started 30",
+		"web Started 40",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %v", actual)
+	}
+}
+
+func TestTaskState_OOMKilled(t *testing.T) {
+	state := &TaskState{
+		State: TaskStateDead,
+		Events: []*TaskEvent{
+			{Type: TaskStarted},
+			{Type: TaskTerminated, OOMKilled: true},
+			{Type: TaskOOMKilled},
+			{Type: TaskRestarting},
+		},
+	}
+	if !state.OOMKilled() {
+		t.Fatalf("expected the task to be OOM killed")
+	}
+
+	// Only the last run of the task is considered
+	state.Events = append(state.Events, &TaskEvent{Type: TaskStarted}, &TaskEvent{Type: TaskTerminated})
+	if state.OOMKilled() {
+		t.Fatalf("expected the task not to be OOM killed")
+	}
+}
+
 func TestAllocation_Index(t *testing.T) {
 	a1 := Allocation{Name: "example.cache[0]"}
 	e1 := 0
@@ -1059,6 +1815,103 @@ func TestTaskArtifact_Validate_Dest(t *testing.T) {
 	}
 }
 
+func TestTemplate_Validate(t *testing.T) {
+	cases := []struct {
+		Tmpl         *Template
+		Fail         bool
+		ContainsErrs []string
+	}{
+		{
+			Tmpl: &Template{},
+			Fail: true,
+			ContainsErrs: []string{
+				"specify a source path",
+				"specify a destination",
+				"Invalid change mode",
+			},
+		},
+		{
+			Tmpl: &Template{
+				SourcePath:   "local/foo.tmpl",
+				EmbeddedTmpl: "foo",
+				DestPath:     "local/foo",
+				ChangeMode:   TemplateChangeModeNoop,
+			},
+			Fail:         true,
+			ContainsErrs: []string{"both a source path and an embedded template"},
+		},
+		{
+			Tmpl: &Template{
+				SourcePath: "../../foo.tmpl",
+				DestPath:   "../../foo",
+				ChangeMode: TemplateChangeModeNoop,
+			},
+			Fail: true,
+			ContainsErrs: []string{
+				"source escapes",
+				"destination escapes",
+			},
+		},
+		{
+			Tmpl: &Template{
+				EmbeddedTmpl: "foo",
+				DestPath:     "local/foo",
+				ChangeMode:   TemplateChangeModeSignal,
+				Splay:        -1,
+			},
+			Fail: true,
+			ContainsErrs: []string{
+				"specify signal value",
+				"positive splay",
+			},
+		},
+		{
+			Tmpl: &Template{
+				EmbeddedTmpl: "foo",
+				DestPath:     "local/foo",
+				ChangeMode:   TemplateChangeModeSignal,
+				ChangeSignal: "SIGHUP",
+				Splay:        5 * time.Second,
+			},
+			Fail: false,
+		},
+	}
+
+	for i, c := range cases {
+		err := c.Tmpl.Validate()
+		if err != nil {
+			if !c.Fail {
+				t.Fatalf("Case %d: shouldn't have failed: %v", i+1, err)
+			}
+
+			e := err.Error()
+			for _, exp := range c.ContainsErrs {
+				if !strings.Contains(e, exp) {
+					t.Fatalf("Cased %d: should have contained error %q: %q", i+1, exp, e)
+				}
+			}
+		} else if c.Fail {
+			t.Fatalf("Case %d: should have failed: %v", i+1, err)
+		}
+	}
+}
+
+func TestTask_Validate_Template_Destinations(t *testing.T) {
+	tmpl := &Template{
+		EmbeddedTmpl: "foo",
+		DestPath:     "local/foo",
+		ChangeMode:   TemplateChangeModeRestart,
+	}
+	task := &Task{
+		Templates: []*Template{tmpl, tmpl.Copy()},
+	}
+
+	err := task.Validate(DefaultEphemeralDisk())
+	if err == nil || !strings.Contains(err.Error(), "Template 2 has same destination as 1") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestTaskArtifact_Validate_Checksum(t *testing.T) {
 	cases := []struct {
 		Input *TaskArtifact
@@ -1141,3 +1994,52 @@ func TestAllocation_Terminated(t *testing.T) {
 		}
 	}
 }
+
+func TestNode_DriverJobAllowed(t *testing.T) {
+	n := &Node{
+		Attributes: map[string]string{
+			"driver.raw_exec.job_whitelist": "infra, ops",
+			"driver.exec.job_blacklist":     "web",
+		},
+	}
+
+	job := testJob()
+	job.ID = "infra/periodic-1490000000"
+	job.ParentID = "infra"
+	if !n.DriverJobAllowed("raw_exec", job) || !n.DriverJobAllowed("exec", job) || !n.DriverJobAllowed("docker", job) {
+		t.Fatalf("expected job %q to be allowed", job.ID)
+	}
+
+	job.ID = "web"
+	job.ParentID = ""
+	if n.DriverJobAllowed("raw_exec", job) || n.DriverJobAllowed("exec", job) || !n.DriverJobAllowed("docker", job) {
+		t.Fatalf("expected job %q to be disallowed", job.ID)
+	}
+}
+
+func TestNode_DriverJobAllowed_Namespace(t *testing.T) {
+	n := &Node{
+		Attributes: map[string]string{
+			"driver.raw_exec.namespace_whitelist": "infra, ops",
+			"driver.exec.namespace_blacklist":     "web",
+		},
+	}
+
+	job := testJob()
+	job.Namespace = "infra"
+	if !n.DriverJobAllowed("raw_exec", job) || !n.DriverJobAllowed("exec", job) || !n.DriverJobAllowed("docker", job) {
+		t.Fatalf("expected namespace %q to be allowed", job.Namespace)
+	}
+
+	job.Namespace = "web"
+	if n.DriverJobAllowed("raw_exec", job) || n.DriverJobAllowed("exec", job) || !n.DriverJobAllowed("docker", job) {
+		t.Fatalf("expected namespace %q to be disallowed", job.Namespace)
+	}
+
+	// Jobs without a namespace are in the default namespace
+	n.Attributes["driver.docker.namespace_whitelist"] = DefaultNamespace
+	job.Namespace = ""
+	if !n.DriverJobAllowed("docker", job) || n.DriverJobAllowed("raw_exec", job) {
+		t.Fatalf("expected the default namespace to be matched")
+	}
+}
@@ -0,0 +1,128 @@
+package structs
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// VolumeTypeHost is the type of the volumes mounting a host volume
+	// declared in the client's configuration.
+	VolumeTypeHost = "host"
+)
+
+// ClientHostVolumeConfig is a directory of the client's host that can be
+// mounted into tasks.
+type ClientHostVolumeConfig struct {
+	Name     string `mapstructure:"-"`
+	Path     string `mapstructure:"path"`
+	ReadOnly bool   `mapstructure:"read_only"`
+}
+
+func (p *ClientHostVolumeConfig) Copy() *ClientHostVolumeConfig {
+	if p == nil {
+		return nil
+	}
+	c := new(ClientHostVolumeConfig)
+	*c = *p
+	return c
+}
+
+// CopyMapStringClientHostVolumeConfig returns a copy of the host volumes.
+func CopyMapStringClientHostVolumeConfig(m map[string]*ClientHostVolumeConfig) map[string]*ClientHostVolumeConfig {
+	if m == nil {
+		return nil
+	}
+	nm := make(map[string]*ClientHostVolumeConfig, len(m))
+	for k, v := range m {
+		nm[k] = v.Copy()
+	}
+	return nm
+}
+
+// VolumeRequest is a volume requested by a task group. Tasks mount it with a
+// VolumeMount.
+type VolumeRequest struct {
+	Name     string
+	Type     string
+	Source   string
+	ReadOnly bool `mapstructure:"read_only"`
+}
+
+func (v *VolumeRequest) Copy() *VolumeRequest {
+	if v == nil {
+		return nil
+	}
+	nv := new(VolumeRequest)
+	*nv = *v
+	return nv
+}
+
+// Validate returns an error if the volume request is invalid.
+func (v *VolumeRequest) Validate() error {
+	var mErr multierror.Error
+	switch v.Type {
+	case VolumeTypeHost:
+		if v.Source == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume %q must have a source", v.Name))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume %q has unsupported type %q", v.Name, v.Type))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopyMapVolumeRequest returns a copy of the volume requests.
+func CopyMapVolumeRequest(m map[string]*VolumeRequest) map[string]*VolumeRequest {
+	if m == nil {
+		return nil
+	}
+	nm := make(map[string]*VolumeRequest, len(m))
+	for k, v := range m {
+		nm[k] = v.Copy()
+	}
+	return nm
+}
+
+// VolumeMount mounts a volume of the task group into a task.
+type VolumeMount struct {
+	Volume      string
+	Destination string
+	ReadOnly    bool `mapstructure:"read_only"`
+}
+
+func (v *VolumeMount) Copy() *VolumeMount {
+	if v == nil {
+		return nil
+	}
+	nv := new(VolumeMount)
+	*nv = *v
+	return nv
+}
+
+// Validate returns an error if the volume mount doesn't mount one of the
+// volumes to an absolute destination.
+func (v *VolumeMount) Validate(volumes map[string]*VolumeRequest) error {
+	var mErr multierror.Error
+	if _, ok := volumes[v.Volume]; !ok {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume mount references unknown volume %q", v.Volume))
+	}
+	if !path.IsAbs(v.Destination) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume mount destination %q must be an absolute path", v.Destination))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopySliceVolumeMount returns a copy of the volume mounts.
+func CopySliceVolumeMount(s []*VolumeMount) []*VolumeMount {
+	if s == nil {
+		return nil
+	}
+	ns := make([]*VolumeMount, len(s))
+	for i, v := range s {
+		ns[i] = v.Copy()
+	}
+	return ns
+}
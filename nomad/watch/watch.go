@@ -13,7 +13,9 @@ type Item struct {
 	AllocEval  string
 	AllocJob   string
 	AllocNode  string
+	Deployment string
 	Eval       string
+	EvalJob    string
 	Job        string
 	JobSummary string
 	Node       string
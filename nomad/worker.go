@@ -118,6 +118,13 @@ func (w *Worker) run() {
 			continue
 		}
 
+		// Skip the evaluations deleted by operators while they were enqueued
+		if w.evalDeleted(eval) {
+			w.logger.Printf("[DEBUG] worker: skipping deleted evaluation %s", eval.ID)
+			w.sendAck(eval.ID, token, true)
+			continue
+		}
+
 		// Invoke the scheduler to determine placements
 		if err := w.invokeScheduler(eval, token); err != nil {
 			w.sendAck(eval.ID, token, false)
@@ -206,6 +213,16 @@ func (w *Worker) sendAck(evalID, token string, ack bool) {
 	}
 }
 
+// evalDeleted returns whether the evaluation was deleted from the state. Core
+// evaluations are never stored and so are never deleted.
+func (w *Worker) evalDeleted(eval *structs.Evaluation) bool {
+	if eval.Type == structs.JobTypeCore {
+		return false
+	}
+	existing, err := w.srv.fsm.State().EvalByID(eval.ID)
+	return err == nil && existing == nil
+}
+
 // waitForIndex ensures that the local state is at least as fresh
 // as the given index. This is used before starting an evaluation,
 // but also potentially mid-stream. If a Plan fails because of stale
@@ -432,7 +449,7 @@ func (w *Worker) ReblockEval(eval *structs.Evaluation) error {
 
 	// Update the evaluation if the queued jobs is not same as what is
 	// recorded in the job summary
-	summary, err := w.srv.fsm.state.JobSummaryByID(eval.JobID)
+	summary, err := w.srv.fsm.state.JobSummaryByID(eval.Namespace, eval.JobID)
 	if err != nil {
 		return fmt.Errorf("couldn't retreive job summary: %v", err)
 	}
@@ -223,6 +223,33 @@ func TestWorker_waitForIndex(t *testing.T) {
 	}
 }
 
+func TestWorker_evalDeleted(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+		c.EnabledSchedulers = []string{structs.JobTypeService}
+	})
+	defer s1.Shutdown()
+
+	w := &Worker{srv: s1, logger: s1.logger}
+	eval := mock.Eval()
+	if !w.evalDeleted(eval) {
+		t.Fatalf("eval missing from the state should be deleted")
+	}
+
+	if err := s1.fsm.State().UpsertEvals(1000, []*structs.Evaluation{eval}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if w.evalDeleted(eval) {
+		t.Fatalf("eval in the state should not be deleted")
+	}
+
+	// Core evals are never stored
+	core := s1.coreJobEval(structs.CoreJobEvalGC, 1000)
+	if w.evalDeleted(core) {
+		t.Fatalf("core eval should not be deleted")
+	}
+}
+
 func TestWorker_invokeScheduler(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0
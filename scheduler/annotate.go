@@ -159,30 +159,23 @@ func annotateTask(diff *structs.TaskDiff, parent *structs.TaskGroupDiff) {
 		}
 	}
 
-	// All changes to primitive fields result in a destructive update except
-	// KillTimeout
+	// Annotate each changed field with the update it forces. Fields of added
+	// or deleted tasks are not annotated as the task annotation covers them.
+	annotateFields := diff.Type == structs.DiffTypeEdited
 	destructive := false
 	for _, fDiff := range diff.Fields {
-		switch fDiff.Name {
-		case "KillTimeout":
-			continue
-		default:
-			destructive = true
-			break
+		inplace := inplaceTaskField(fDiff.Name)
+		destructive = destructive || !inplace
+		if annotateFields {
+			annotateField(fDiff, inplace)
 		}
 	}
 
-	// Object changes that can be done in-place are log configs, services,
-	// constraints.
-	if !destructive {
-		for _, oDiff := range diff.Objects {
-			switch oDiff.Name {
-			case "LogConfig", "Service", "Constraint":
-				continue
-			default:
-				destructive = true
-				break
-			}
+	for _, oDiff := range diff.Objects {
+		inplace := inplaceTaskObject(oDiff.Name)
+		destructive = destructive || !inplace
+		if annotateFields {
+			annotateObjectFields(oDiff, inplace)
 		}
 	}
 
@@ -192,3 +185,46 @@ func annotateTask(diff *structs.TaskDiff, parent *structs.TaskGroupDiff) {
 		diff.Annotations = append(diff.Annotations, AnnotationForcesInplaceUpdate)
 	}
 }
+
+// inplaceTaskField returns whether a change to the primitive task field can be
+// done in-place. All changes result in a destructive update except KillTimeout.
+func inplaceTaskField(name string) bool {
+	return name == "KillTimeout"
+}
+
+// inplaceTaskObject returns whether a change to the task object can be done
+// in-place. Object changes that can be done in-place are log configs,
+// services and constraints.
+func inplaceTaskObject(name string) bool {
+	switch name {
+	case "LogConfig", "Service", "Constraint":
+		return true
+	default:
+		return false
+	}
+}
+
+// annotateField annotates a changed field with whether it forces an in-place
+// or destructive update.
+func annotateField(diff *structs.FieldDiff, inplace bool) {
+	if diff.Type == structs.DiffTypeNone {
+		return
+	}
+
+	if inplace {
+		diff.Annotations = append(diff.Annotations, AnnotationForcesInplaceUpdate)
+	} else {
+		diff.Annotations = append(diff.Annotations, AnnotationForcesDestructiveUpdate)
+	}
+}
+
+// annotateObjectFields annotates the changed fields of an object diff and its
+// nested objects.
+func annotateObjectFields(diff *structs.ObjectDiff, inplace bool) {
+	for _, fDiff := range diff.Fields {
+		annotateField(fDiff, inplace)
+	}
+	for _, oDiff := range diff.Objects {
+		annotateObjectFields(oDiff, inplace)
+	}
+}
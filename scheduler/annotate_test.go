@@ -435,3 +435,63 @@ func TestAnnotateTask(t *testing.T) {
 		}
 	}
 }
+
+func TestAnnotateTask_Fields(t *testing.T) {
+	driver := &structs.FieldDiff{
+		Type: structs.DiffTypeEdited,
+		Name: "Driver",
+		Old:  "docker",
+		New:  "exec",
+	}
+	killTimeout := &structs.FieldDiff{
+		Type: structs.DiffTypeEdited,
+		Name: "KillTimeout",
+		Old:  "1s",
+		New:  "2s",
+	}
+	unchanged := &structs.FieldDiff{
+		Type: structs.DiffTypeNone,
+		Name: "User",
+		Old:  "foo",
+		New:  "foo",
+	}
+	checkName := &structs.FieldDiff{
+		Type: structs.DiffTypeEdited,
+		Name: "Name",
+		Old:  "foo",
+		New:  "bar",
+	}
+	diff := &structs.TaskDiff{
+		Type:   structs.DiffTypeEdited,
+		Fields: []*structs.FieldDiff{driver, killTimeout, unchanged},
+		Objects: []*structs.ObjectDiff{
+			{
+				Type: structs.DiffTypeEdited,
+				Name: "Service",
+				Objects: []*structs.ObjectDiff{
+					{
+						Type:   structs.DiffTypeEdited,
+						Name:   "Check",
+						Fields: []*structs.FieldDiff{checkName},
+					},
+				},
+			},
+		},
+	}
+
+	annotateTask(diff, &structs.TaskGroupDiff{Type: structs.DiffTypeEdited})
+	cases := []struct {
+		Diff    *structs.FieldDiff
+		Desired []string
+	}{
+		{driver, []string{AnnotationForcesDestructiveUpdate}},
+		{killTimeout, []string{AnnotationForcesInplaceUpdate}},
+		{unchanged, nil},
+		{checkName, []string{AnnotationForcesInplaceUpdate}},
+	}
+	for _, c := range cases {
+		if !reflect.DeepEqual(c.Diff.Annotations, c.Desired) {
+			t.Fatalf("field %q not properly annotated; got %v, want %v", c.Diff.Name, c.Diff.Annotations, c.Desired)
+		}
+	}
+}
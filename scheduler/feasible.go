@@ -92,6 +92,7 @@ func NewRandomIterator(ctx Context, nodes []*structs.Node) *StaticIterator {
 // drivers necessary to scheduler a task group.
 type DriverChecker struct {
 	ctx     Context
+	job     *structs.Job
 	drivers map[string]struct{}
 }
 
@@ -107,13 +108,35 @@ func (c *DriverChecker) SetDrivers(d map[string]struct{}) {
 	c.drivers = d
 }
 
+// SetJob sets the job whose access to the drivers of a node is checked
+func (c *DriverChecker) SetJob(job *structs.Job) {
+	c.job = job
+}
+
 func (c *DriverChecker) Feasible(option *structs.Node) bool {
-	// Use this node if possible
-	if c.hasDrivers(option) {
+	if !c.hasDrivers(option) {
+		c.ctx.Metrics().FilterNode(option, "missing drivers")
+		return false
+	}
+	if !c.allowsJob(option) {
+		c.ctx.Metrics().FilterNode(option, "drivers not allowed for job")
+		return false
+	}
+	return true
+}
+
+// allowsJob is used to check if the node allows the job to use the drivers
+// of the task group.
+func (c *DriverChecker) allowsJob(option *structs.Node) bool {
+	if c.job == nil {
 		return true
 	}
-	c.ctx.Metrics().FilterNode(option, "missing drivers")
-	return false
+	for driver := range c.drivers {
+		if !option.DriverJobAllowed(driver, c.job) {
+			return false
+		}
+	}
+	return true
 }
 
 // hasDrivers is used to check if the node has all the appropriate
@@ -142,6 +165,50 @@ func (c *DriverChecker) hasDrivers(option *structs.Node) bool {
 	return true
 }
 
+// HostVolumeChecker is a FeasibilityChecker which returns whether a node has
+// the host volumes requested by a task group.
+type HostVolumeChecker struct {
+	ctx     Context
+	volumes map[string]*structs.VolumeRequest
+}
+
+// NewHostVolumeChecker creates a HostVolumeChecker
+func NewHostVolumeChecker(ctx Context) *HostVolumeChecker {
+	return &HostVolumeChecker{
+		ctx: ctx,
+	}
+}
+
+func (c *HostVolumeChecker) SetVolumes(volumes map[string]*structs.VolumeRequest) {
+	c.volumes = volumes
+}
+
+func (c *HostVolumeChecker) Feasible(option *structs.Node) bool {
+	if c.hasVolumes(option) {
+		return true
+	}
+	c.ctx.Metrics().FilterNode(option, "missing compatible host volumes")
+	return false
+}
+
+// hasVolumes is used to check if the node exposes the host volumes of the task
+// group, writable unless requested read only.
+func (c *HostVolumeChecker) hasVolumes(option *structs.Node) bool {
+	for _, req := range c.volumes {
+		if req.Type != structs.VolumeTypeHost {
+			continue
+		}
+		volume, ok := option.HostVolumes[req.Source]
+		if !ok {
+			return false
+		}
+		if volume.ReadOnly && !req.ReadOnly {
+			return false
+		}
+	}
+	return true
+}
+
 // ProposedAllocConstraintIterator is a FeasibleIterator which returns nodes that
 // match constraints that are not static such as Node attributes but are
 // effected by proposed alloc placements. Examples are distinct_hosts and
@@ -157,6 +224,10 @@ type ProposedAllocConstraintIterator struct {
 	// they don't have to be calculated every time Next() is called.
 	tgDistinctHosts  bool
 	jobDistinctHosts bool
+
+	// Store the distinct_property constraints of the Job and TaskGroup.
+	tgDistinctProperties  []*structs.Constraint
+	jobDistinctProperties []*structs.Constraint
 }
 
 // NewProposedAllocConstraintIterator creates a ProposedAllocConstraintIterator
@@ -171,11 +242,13 @@ func NewProposedAllocConstraintIterator(ctx Context, source FeasibleIterator) *P
 func (iter *ProposedAllocConstraintIterator) SetTaskGroup(tg *structs.TaskGroup) {
 	iter.tg = tg
 	iter.tgDistinctHosts = iter.hasDistinctHostsConstraint(tg.Constraints)
+	iter.tgDistinctProperties = distinctPropertyConstraints(tg.Constraints)
 }
 
 func (iter *ProposedAllocConstraintIterator) SetJob(job *structs.Job) {
 	iter.job = job
 	iter.jobDistinctHosts = iter.hasDistinctHostsConstraint(job.Constraints)
+	iter.jobDistinctProperties = distinctPropertyConstraints(job.Constraints)
 }
 
 func (iter *ProposedAllocConstraintIterator) hasDistinctHostsConstraint(constraints []*structs.Constraint) bool {
@@ -187,13 +260,26 @@ func (iter *ProposedAllocConstraintIterator) hasDistinctHostsConstraint(constrai
 	return false
 }
 
+// distinctPropertyConstraints returns the distinct_property constraints.
+func distinctPropertyConstraints(constraints []*structs.Constraint) []*structs.Constraint {
+	var distinct []*structs.Constraint
+	for _, con := range constraints {
+		if con.Operand == structs.ConstraintDistinctProperty {
+			distinct = append(distinct, con)
+		}
+	}
+	return distinct
+}
+
 func (iter *ProposedAllocConstraintIterator) Next() *structs.Node {
 	for {
 		// Get the next option from the source
 		option := iter.source.Next()
 
-		// Hot-path if the option is nil or there are no distinct_hosts constraints.
-		if option == nil || !(iter.jobDistinctHosts || iter.tgDistinctHosts) {
+		// Hot-path if the option is nil or there are no distinct_hosts or
+		// distinct_property constraints.
+		hasDistinctProperties := len(iter.jobDistinctProperties) != 0 || len(iter.tgDistinctProperties) != 0
+		if option == nil || !(iter.jobDistinctHosts || iter.tgDistinctHosts || hasDistinctProperties) {
 			return option
 		}
 
@@ -202,6 +288,11 @@ func (iter *ProposedAllocConstraintIterator) Next() *structs.Node {
 			continue
 		}
 
+		if !iter.satisfiesDistinctProperties(option) {
+			iter.ctx.Metrics().FilterNode(option, structs.ConstraintDistinctProperty)
+			continue
+		}
+
 		return option
 	}
 }
@@ -237,6 +328,105 @@ func (iter *ProposedAllocConstraintIterator) satisfiesDistinctHosts(option *stru
 	return true
 }
 
+// satisfiesDistinctProperties checks if the node satisfies the
+// distinct_property constraints specified at the job level or the TaskGroup
+// level: no other allocation of the job, or of the TaskGroup, may be placed on
+// a node with the same value of the property.
+func (iter *ProposedAllocConstraintIterator) satisfiesDistinctProperties(option *structs.Node) bool {
+	if len(iter.jobDistinctProperties) == 0 && len(iter.tgDistinctProperties) == 0 {
+		return true
+	}
+
+	// Get the allocations of the job once placed
+	allocs, err := iter.proposedJobAllocs()
+	if err != nil {
+		iter.ctx.Logger().Printf(
+			"[ERR] scheduler.dynamic-constraint: failed to get proposed allocations of the job: %v", err)
+		return false
+	}
+
+	nodes := make(map[string]*structs.Node)
+	check := func(con *structs.Constraint, tg string) bool {
+		value, ok := resolveConstraintTarget(con.LTarget, option)
+		if !ok {
+			return false
+		}
+
+		for _, alloc := range allocs {
+			if tg != "" && alloc.TaskGroup != tg {
+				continue
+			}
+			node, ok := nodes[alloc.NodeID]
+			if !ok {
+				if node, err = iter.ctx.State().NodeByID(alloc.NodeID); err != nil {
+					iter.ctx.Logger().Printf(
+						"[ERR] scheduler.dynamic-constraint: failed to lookup node %q: %v", alloc.NodeID, err)
+					return false
+				}
+				nodes[alloc.NodeID] = node
+			}
+			if node == nil {
+				continue
+			}
+			if used, ok := resolveConstraintTarget(con.LTarget, node); ok && reflect.DeepEqual(used, value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, con := range iter.jobDistinctProperties {
+		if !check(con, "") {
+			return false
+		}
+	}
+	for _, con := range iter.tgDistinctProperties {
+		if !check(con, iter.tg.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// proposedJobAllocs returns the non-terminal allocations of the job once the
+// plan is applied.
+func (iter *ProposedAllocConstraintIterator) proposedJobAllocs() ([]*structs.Allocation, error) {
+	existing, err := iter.ctx.State().AllocsByJob(iter.job.Namespace, iter.job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove the planned evictions and override the existing allocations with
+	// the planned ones
+	plan := iter.ctx.Plan()
+	evicted := make(map[string]struct{})
+	for _, updates := range plan.NodeUpdate {
+		for _, alloc := range updates {
+			evicted[alloc.ID] = struct{}{}
+		}
+	}
+	proposed := make(map[string]*structs.Allocation)
+	for _, alloc := range existing {
+		if _, ok := evicted[alloc.ID]; ok || alloc.TerminalStatus() {
+			continue
+		}
+		proposed[alloc.ID] = alloc
+	}
+	for _, placements := range plan.NodeAllocation {
+		for _, alloc := range placements {
+			if alloc.JobID == iter.job.ID {
+				proposed[alloc.ID] = alloc
+			}
+		}
+	}
+
+	allocs := make([]*structs.Allocation, 0, len(proposed))
+	for _, alloc := range proposed {
+		allocs = append(allocs, alloc)
+	}
+	return allocs, nil
+}
+
 func (iter *ProposedAllocConstraintIterator) Reset() {
 	iter.source.Reset()
 }
@@ -327,7 +517,7 @@ func resolveConstraintTarget(target string, node *structs.Node) (interface{}, bo
 func checkConstraint(ctx Context, operand string, lVal, rVal interface{}) bool {
 	// Check for constraints not handled by this checker.
 	switch operand {
-	case structs.ConstraintDistinctHosts:
+	case structs.ConstraintDistinctHosts, structs.ConstraintDistinctProperty:
 		return true
 	default:
 		break
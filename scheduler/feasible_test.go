@@ -123,6 +123,85 @@ func TestDriverChecker(t *testing.T) {
 	}
 }
 
+func TestHostVolumeChecker(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+	}
+	nodes[1].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"shared_data": {Name: "shared_data", Path: "/mnt/data"},
+	}
+	nodes[2].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"shared_data": {Name: "shared_data", Path: "/mnt/data", ReadOnly: true},
+	}
+
+	checker := NewHostVolumeChecker(ctx)
+	checker.SetVolumes(map[string]*structs.VolumeRequest{
+		"data": {Name: "data", Type: structs.VolumeTypeHost, Source: "shared_data"},
+	})
+	for i, expected := range []bool{false, true, false} {
+		if act := checker.Feasible(nodes[i]); act != expected {
+			t.Fatalf("case(%d) failed: got %v; want %v", i, act, expected)
+		}
+	}
+
+	// Read only volumes can be requested read only
+	checker.SetVolumes(map[string]*structs.VolumeRequest{
+		"data": {Name: "data", Type: structs.VolumeTypeHost, Source: "shared_data", ReadOnly: true},
+	})
+	for i, expected := range []bool{false, true, true} {
+		if act := checker.Feasible(nodes[i]); act != expected {
+			t.Fatalf("case(%d) failed: got %v; want %v", i, act, expected)
+		}
+	}
+}
+
+func TestDriverChecker_JobLists(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+	}
+	nodes[0].Attributes["driver.exec.job_whitelist"] = "foo, bar"
+	nodes[1].Attributes["driver.exec.job_whitelist"] = "baz"
+	nodes[2].Attributes["driver.exec.job_blacklist"] = "bar"
+
+	drivers := map[string]struct{}{
+		"exec": struct{}{},
+	}
+	checker := NewDriverChecker(ctx, drivers)
+	job := mock.Job()
+	job.ID = "bar"
+	checker.SetJob(job)
+
+	cases := []struct {
+		Node   *structs.Node
+		Result bool
+	}{
+		{
+			Node:   nodes[0],
+			Result: true,
+		},
+		{
+			Node:   nodes[1],
+			Result: false,
+		},
+		{
+			Node:   nodes[2],
+			Result: false,
+		},
+	}
+
+	for i, c := range cases {
+		if act := checker.Feasible(c.Node); act != c.Result {
+			t.Fatalf("case(%d) failed: got %v; want %v", i, act, c.Result)
+		}
+	}
+}
+
 func TestConstraintChecker(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*structs.Node{
@@ -602,6 +681,143 @@ func TestProposedAllocConstraint_TaskGroupDistinctHosts(t *testing.T) {
 	}
 }
 
+func TestProposedAllocConstraint_JobDistinctProperty(t *testing.T) {
+	state, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+	}
+	for i, rack := range []string{"r1", "r2", "r3"} {
+		nodes[i].Meta["rack"] = rack
+	}
+	for i, node := range nodes {
+		if err := state.UpsertNode(uint64(100+i), node); err != nil {
+			t.Fatalf("failed to upsert node: %v", err)
+		}
+	}
+	static := NewStaticIterator(ctx, nodes)
+
+	// Create a job with a distinct_property constraint and two task groups.
+	tg1 := &structs.TaskGroup{Name: "bar"}
+	tg2 := &structs.TaskGroup{Name: "baz"}
+
+	job := &structs.Job{
+		ID: "foo",
+		Constraints: []*structs.Constraint{
+			{
+				Operand: structs.ConstraintDistinctProperty,
+				LTarget: "${meta.rack}",
+			},
+		},
+		TaskGroups: []*structs.TaskGroup{tg1, tg2},
+	}
+
+	// Add an existing alloc on node2 that the plan evicts.
+	existing := mock.Alloc()
+	existing.NodeID = nodes[1].ID
+	existing.JobID = job.ID
+	existing.TaskGroup = tg2.Name
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{existing}); err != nil {
+		t.Fatalf("failed to upsert alloc: %v", err)
+	}
+	plan := ctx.Plan()
+	plan.NodeUpdate[nodes[1].ID] = []*structs.Allocation{existing}
+
+	// Add a planned alloc of the other task group to node1.
+	plan.NodeAllocation[nodes[0].ID] = []*structs.Allocation{
+		&structs.Allocation{
+			TaskGroup: tg2.Name,
+			JobID:     job.ID,
+			NodeID:    nodes[0].ID,
+			ID:        structs.GenerateUUID(),
+		},
+	}
+
+	// Add a planned alloc of a different job to node3.
+	plan.NodeAllocation[nodes[2].ID] = []*structs.Allocation{
+		&structs.Allocation{
+			TaskGroup: tg1.Name,
+			JobID:     "ignore",
+			NodeID:    nodes[2].ID,
+			ID:        structs.GenerateUUID(),
+		},
+	}
+
+	propsed := NewProposedAllocConstraintIterator(ctx, static)
+	propsed.SetTaskGroup(tg1)
+	propsed.SetJob(job)
+
+	// Expect node1 to be skipped because its rack is used and node4 because it
+	// has no rack.
+	out := collectFeasible(propsed)
+	if len(out) != 2 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if out[0] != nodes[1] || out[1] != nodes[2] {
+		t.Fatalf("Bad: %v", out)
+	}
+}
+
+func TestProposedAllocConstraint_TaskGroupDistinctProperty(t *testing.T) {
+	state, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+	}
+	for i, node := range nodes {
+		if err := state.UpsertNode(uint64(100+i), node); err != nil {
+			t.Fatalf("failed to upsert node: %v", err)
+		}
+	}
+	static := NewStaticIterator(ctx, nodes)
+
+	// Create a task group with a distinct_property constraint.
+	taskGroup := &structs.TaskGroup{
+		Name: "example",
+		Constraints: []*structs.Constraint{
+			{
+				Operand: structs.ConstraintDistinctProperty,
+				LTarget: "${node.unique.id}",
+			},
+		},
+	}
+	job := &structs.Job{ID: "foo"}
+
+	// Add a planned alloc of the task group to node1 and one of a different
+	// task group to node2.
+	plan := ctx.Plan()
+	plan.NodeAllocation[nodes[0].ID] = []*structs.Allocation{
+		&structs.Allocation{
+			TaskGroup: taskGroup.Name,
+			JobID:     job.ID,
+			NodeID:    nodes[0].ID,
+			ID:        structs.GenerateUUID(),
+		},
+	}
+	plan.NodeAllocation[nodes[1].ID] = []*structs.Allocation{
+		&structs.Allocation{
+			TaskGroup: "other",
+			JobID:     job.ID,
+			NodeID:    nodes[1].ID,
+			ID:        structs.GenerateUUID(),
+		},
+	}
+
+	propsed := NewProposedAllocConstraintIterator(ctx, static)
+	propsed.SetTaskGroup(taskGroup)
+	propsed.SetJob(job)
+
+	out := collectFeasible(propsed)
+	if len(out) != 1 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if out[0] != nodes[1] {
+		t.Fatalf("Bad: %v", out)
+	}
+}
+
 func collectFeasible(iter FeasibleIterator) (out []*structs.Node) {
 	for {
 		next := iter.Next()
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -26,6 +27,10 @@ const (
 	// allocUpdating is the status used when a job requires an update
 	allocUpdating = "alloc is being updated due to job update"
 
+	// allocCanaryNotNeeded is the status used when a canary of an older
+	// version of the job is no longer required
+	allocCanaryNotNeeded = "canary not needed due to job update"
+
 	// allocLost is the status used when an allocation is lost
 	allocLost = "alloc is lost since its node is down"
 
@@ -72,6 +77,26 @@ type GenericScheduler struct {
 	limitReached bool
 	nextEval     *structs.Evaluation
 
+	// rescheduleWait is the time until the earliest failed allocation whose
+	// reschedule is delayed may be replaced. rescheduleEval is the
+	// evaluation created to replace it then.
+	rescheduleWait time.Duration
+	rescheduleEval *structs.Evaluation
+
+	// migrateWait is the time until the throttled migrations off draining
+	// nodes may continue even if no replacement becomes running.
+	// migrateEval is the evaluation created to continue them then.
+	migrateWait time.Duration
+	migrateEval *structs.Evaluation
+
+	// deployment is the running deployment of a health gated job that
+	// new placements are made for.
+	deployment *structs.Deployment
+
+	// stoppedJob is the last version of a deregistered job whose allocations
+	// are being stopped. It is used to honor the job's stop strategy.
+	stoppedJob *structs.Job
+
 	blocked        *structs.Evaluation
 	failedTGAllocs map[string]*structs.AllocMetric
 	queuedAllocs   map[string]int
@@ -108,7 +133,9 @@ func (s *GenericScheduler) Process(eval *structs.Evaluation) error {
 	switch eval.TriggeredBy {
 	case structs.EvalTriggerJobRegister, structs.EvalTriggerNodeUpdate,
 		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate,
-		structs.EvalTriggerPeriodicJob, structs.EvalTriggerMaxPlans:
+		structs.EvalTriggerPeriodicJob, structs.EvalTriggerMaxPlans,
+		structs.EvalTriggerDeploymentWatcher, structs.EvalTriggerRetryFailedAlloc,
+		structs.EvalTriggerNodeDrain:
 	default:
 		desc := fmt.Sprintf("scheduler cannot handle '%s' evaluation reason",
 			eval.TriggeredBy)
@@ -168,6 +195,7 @@ func (s *GenericScheduler) createBlockedEval(planFailure bool) error {
 	}
 
 	s.blocked = s.eval.CreateBlockedEval(classEligibility, escaped)
+	s.blocked.FailedTGAllocs = s.failedTGAllocs
 	if planFailure {
 		s.blocked.TriggeredBy = structs.EvalTriggerMaxPlans
 		s.blocked.StatusDescription = blockedEvalMaxPlanDesc
@@ -183,11 +211,17 @@ func (s *GenericScheduler) createBlockedEval(planFailure bool) error {
 func (s *GenericScheduler) process() (bool, error) {
 	// Lookup the Job by ID
 	var err error
-	s.job, err = s.state.JobByID(s.eval.JobID)
+	s.job, err = s.state.JobByID(s.eval.Namespace, s.eval.JobID)
 	if err != nil {
 		return false, fmt.Errorf("failed to get job '%s': %v",
 			s.eval.JobID, err)
 	}
+
+	// A job moved to its failover region is stopped in this one
+	if s.job.FailedOver() {
+		s.job = nil
+	}
+
 	numTaskGroups := 0
 	if s.job != nil {
 		numTaskGroups = len(s.job.TaskGroups)
@@ -197,8 +231,12 @@ func (s *GenericScheduler) process() (bool, error) {
 	// Create a plan
 	s.plan = s.eval.MakePlan(s.job)
 
-	// Reset the failed allocations
+	// Reset the failed allocations, the deployment and the reschedule and
+	// migration delays
 	s.failedTGAllocs = nil
+	s.deployment = nil
+	s.rescheduleWait = 0
+	s.migrateWait = 0
 
 	// Create an evaluation context
 	s.ctx = NewEvalContext(s.state, s.plan, s.logger)
@@ -226,6 +264,28 @@ func (s *GenericScheduler) process() (bool, error) {
 		s.logger.Printf("[DEBUG] sched: %#v: failed to place all allocations, blocked eval '%s' created", s.eval, s.blocked.ID)
 	}
 
+	// If the rescheduling of failed allocations is delayed, create an
+	// evaluation to replace them once the delay elapsed.
+	if s.rescheduleWait > 0 && s.rescheduleEval == nil {
+		s.rescheduleEval = s.eval.NextRescheduleEval(s.rescheduleWait)
+		if err := s.planner.CreateEval(s.rescheduleEval); err != nil {
+			s.logger.Printf("[ERR] sched: %#v failed to make next eval for rescheduling: %v", s.eval, err)
+			return false, err
+		}
+		s.logger.Printf("[DEBUG] sched: %#v: rescheduling delayed, next eval '%s' created", s.eval, s.rescheduleEval.ID)
+	}
+
+	// If migrations off draining nodes are throttled, create an evaluation to
+	// continue them once the replacements had time to become healthy.
+	if s.migrateWait > 0 && s.migrateEval == nil {
+		s.migrateEval = s.eval.NextMigrationEval(s.migrateWait)
+		if err := s.planner.CreateEval(s.migrateEval); err != nil {
+			s.logger.Printf("[ERR] sched: %#v failed to make next eval for migrations: %v", s.eval, err)
+			return false, err
+		}
+		s.logger.Printf("[DEBUG] sched: %#v: migrations throttled, next eval '%s' created", s.eval, s.migrateEval.ID)
+	}
+
 	// If the plan is a no-op, we can bail. If AnnotatePlan is set submit the plan
 	// anyways to get the annotations.
 	if s.plan.IsNoOp() && !s.eval.AnnotatePlan {
@@ -235,7 +295,11 @@ func (s *GenericScheduler) process() (bool, error) {
 	// If the limit of placements was reached we need to create an evaluation
 	// to pickup from here after the stagger period.
 	if s.limitReached && s.nextEval == nil {
-		s.nextEval = s.eval.NextRollingEval(s.job.Update.Stagger)
+		job := s.job
+		if job == nil {
+			job = s.stoppedJob
+		}
+		s.nextEval = s.eval.NextRollingEval(job.Update.Stagger)
 		if err := s.planner.CreateEval(s.nextEval); err != nil {
 			s.logger.Printf("[ERR] sched: %#v failed to make next eval for rolling update: %v", s.eval, err)
 			return false, err
@@ -354,7 +418,7 @@ func (s *GenericScheduler) computeJobAllocs() error {
 	}
 
 	// Lookup the allocations by JobID
-	allocs, err := s.state.AllocsByJob(s.eval.JobID)
+	allocs, err := s.state.AllocsByJob(s.eval.Namespace, s.eval.JobID)
 	if err != nil {
 		return fmt.Errorf("failed to get allocs for job '%s': %v",
 			s.eval.JobID, err)
@@ -371,15 +435,32 @@ func (s *GenericScheduler) computeJobAllocs() error {
 	// nodes to lost
 	updateNonTerminalAllocsToLost(s.plan, tainted, allocs)
 
-	// Filter out the allocations in a terminal state
+	// Filter out the allocations in a terminal state. The filter reorders the
+	// allocations in place, so the migrations are computed from a copy.
+	allAllocs := make([]*structs.Allocation, len(allocs))
+	copy(allAllocs, allocs)
 	allocs, terminalAllocs := s.filterCompleteAllocs(allocs)
 
 	// Diff the required and existing allocations
 	diff := diffAllocs(s.job, tainted, groups, allocs, terminalAllocs)
 	s.logger.Printf("[DEBUG] sched: %#v: %#v", s.eval, diff)
 
+	// Failed allocations are replaced according to their reschedule policy
+	now := time.Now().UTC()
+	s.computeReschedules(diff, now)
+
+	// Migrations off draining nodes are throttled by their migrate strategy
+	s.computeMigrations(diff, tainted, allAllocs, now)
+
 	// Add all the allocs to stop
-	for _, e := range diff.stop {
+	// If the job has been deregistered, its stop strategy controls how many
+	// allocations are stopped at once.
+	stop, stopLimitReached := diff.stop, false
+	if s.job == nil {
+		s.stoppedJob = deregisteredJob(diff.stop)
+		stop, stopLimitReached = limitStops(s.stoppedJob, diff.stop)
+	}
+	for _, e := range stop {
 		s.plan.AppendUpdate(e.Alloc, structs.AllocDesiredStatusStop, allocNotNeeded, "")
 	}
 
@@ -399,11 +480,28 @@ func (s *GenericScheduler) computeJobAllocs() error {
 		limit = s.job.Update.MaxParallel
 	}
 
+	// Health gated updates are limited by their deployment instead
+	var updateLimit int
+	var gated bool
+	if s.job != nil && !s.batch {
+		updateLimit, gated, err = s.computeDeployment(allocs, diff)
+		if err != nil {
+			return err
+		}
+		s.computeCanaries(allocs, diff)
+	}
+
 	// Treat migrations as an eviction and a new placement.
-	s.limitReached = evictAndPlace(s.ctx, diff, diff.migrate, allocMigrating, &limit)
+	s.limitReached = stopLimitReached || evictAndPlace(s.ctx, diff, diff.migrate, allocMigrating, &limit)
 
-	// Treat non in-place updates as an eviction and new placement.
-	s.limitReached = s.limitReached || evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &limit)
+	// Treat non in-place updates as an eviction and new placement. The
+	// deployment of a health gated update creates the evaluation for the
+	// next batch once the placed allocations are healthy.
+	if gated {
+		evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &updateLimit)
+	} else {
+		s.limitReached = s.limitReached || evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &limit)
+	}
 
 	// Lost allocations should be transistioned to desired status stop and client
 	// status lost and a new placement should be made
@@ -428,6 +526,289 @@ func (s *GenericScheduler) computeJobAllocs() error {
 	return s.computePlacements(diff.place)
 }
 
+// computeDeployment cancels the running deployment of an older version of the
+// job and, if the job's update strategy is health gated, determines the
+// deployment rolling out its current version. It returns how many destructive
+// updates may be made and whether they are gated by the deployment. Updates
+// are made in batches of MaxParallel and a batch may only be started once the
+// allocations placed by the deployment are healthy.
+func (s *GenericScheduler) computeDeployment(allocs []*structs.Allocation, diff *diffResult) (int, bool, error) {
+	d, err := s.state.LatestDeploymentByJobID(s.job.Namespace, s.job.ID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get deployment for job '%s': %v",
+			s.job.ID, err)
+	}
+
+	if d != nil && d.JobVersion != s.job.Version {
+		if d.Active() {
+			s.plan.AppendDeploymentUpdate(&structs.DeploymentStatusUpdate{
+				DeploymentID:      d.ID,
+				Status:            structs.DeploymentStatusCancelled,
+				StatusDescription: structs.DeploymentStatusDescriptionNewerJob,
+			})
+		}
+		d = nil
+	}
+
+	if !s.job.Update.HealthGated() {
+		return 0, false, nil
+	}
+
+	// Create a deployment if this version of the job has not been rolled out
+	if d == nil {
+		if len(diff.place) == 0 && len(diff.update) == 0 {
+			return 0, true, nil
+		}
+
+		d = structs.NewDeployment(s.job)
+		for _, tuples := range [][]allocTuple{diff.place, diff.update} {
+			for _, tuple := range tuples {
+				state, ok := d.TaskGroups[tuple.TaskGroup.Name]
+				if !ok {
+					state = &structs.DeploymentState{AutoRevert: s.job.Update.AutoRevert}
+					d.TaskGroups[tuple.TaskGroup.Name] = state
+				}
+				state.DesiredTotal++
+			}
+		}
+
+		// Destructive updates are preceded by canaries
+		for _, tuple := range diff.update {
+			state := d.TaskGroups[tuple.TaskGroup.Name]
+			if state.DesiredCanaries < s.job.Update.Canary {
+				state.DesiredCanaries++
+			}
+		}
+		if d.RequiresPromotion() {
+			d.StatusDescription = structs.DeploymentStatusDescriptionNeedsPromotion
+		}
+		s.plan.Deployment = d
+	}
+
+	switch d.Status {
+	case structs.DeploymentStatusRunning:
+		s.deployment = d
+	case structs.DeploymentStatusFailed:
+		// Halt the rollout of a version of the job whose deployment failed
+		return 0, true, nil
+	default:
+		return len(diff.update), true, nil
+	}
+
+	// Count the placed allocations whose health is not known yet
+	pending := 0
+	for _, alloc := range allocs {
+		if alloc.DeploymentID == d.ID && !alloc.DeploymentStatus.IsHealthy() {
+			pending++
+		}
+	}
+
+	limit := s.job.Update.MaxParallel - pending
+	if limit < 0 {
+		limit = 0
+	}
+	return limit, true, nil
+}
+
+// computeCanaries handles the canaries placed alongside the allocations they
+// replace. Canaries of an older version of the job that still run alongside
+// the allocation of the same name are stopped. The allocations replaced by an
+// allocation of the running deployment are left untouched until their task
+// group is promoted and are stopped afterwards. While a task group requires
+// promotion, its missing canaries are placed and no other destructive update
+// is made to it; the task groups already promoted keep rolling out.
+func (s *GenericScheduler) computeCanaries(allocs []*structs.Allocation, diff *diffResult) {
+	// Index the allocations of the running deployment, which are canaries
+	// until it is promoted, and count the remaining allocations by name
+	placed := make(map[string]struct{})
+	groupCanaries := make(map[string]int)
+	names := make(map[string]int, len(allocs))
+	for _, alloc := range allocs {
+		if s.deployment != nil && alloc.DeploymentID == s.deployment.ID {
+			placed[alloc.Name] = struct{}{}
+			if alloc.DeploymentStatus.IsCanary() {
+				groupCanaries[alloc.TaskGroup]++
+			}
+			continue
+		}
+		names[alloc.Name]++
+	}
+
+	// awaiting returns the deployment state of the task group if it requires
+	// promotion
+	awaiting := func(group string) *structs.DeploymentState {
+		if s.deployment == nil {
+			return nil
+		}
+		state, ok := s.deployment.TaskGroups[group]
+		if !ok || !state.RequiresPromotion() {
+			return nil
+		}
+		return state
+	}
+
+	update := make([]allocTuple, 0, len(diff.update))
+	for _, tuple := range diff.update {
+		if tuple.Alloc.DeploymentStatus.IsCanary() && names[tuple.Name] > 1 {
+			s.plan.AppendUpdate(tuple.Alloc, structs.AllocDesiredStatusStop, allocCanaryNotNeeded, "")
+			continue
+		}
+
+		name := tuple.TaskGroup.Name
+		state := awaiting(name)
+		if _, ok := placed[tuple.Name]; ok {
+			if state == nil {
+				s.plan.AppendUpdate(tuple.Alloc, structs.AllocDesiredStatusStop, allocUpdating, "")
+			}
+			continue
+		}
+		if state == nil {
+			update = append(update, tuple)
+			continue
+		}
+
+		// Place the missing canaries of the task group awaiting promotion
+		if groupCanaries[name] >= state.DesiredCanaries {
+			continue
+		}
+		groupCanaries[name]++
+		diff.place = append(diff.place, allocTuple{
+			Name:      tuple.Name,
+			TaskGroup: tuple.TaskGroup,
+			Canary:    true,
+		})
+	}
+	diff.update = update
+}
+
+// computeReschedules applies the reschedule policy of the task groups to the
+// placements replacing failed allocations. Failed allocations that exhausted
+// their reschedule attempts are not replaced and those whose delay has not
+// elapsed yet are replaced by a later evaluation. Task groups without a
+// reschedule policy have their failed allocations replaced right away.
+func (s *GenericScheduler) computeReschedules(diff *diffResult, now time.Time) {
+	place := make([]allocTuple, 0, len(diff.place))
+	for _, tuple := range diff.place {
+		alloc := tuple.Alloc
+		policy := tuple.TaskGroup.ReschedulePolicy
+		if alloc == nil || policy == nil ||
+			alloc.ClientStatus != structs.AllocClientStatusFailed ||
+			alloc.DesiredStatus != structs.AllocDesiredStatusRun {
+			place = append(place, tuple)
+			continue
+		}
+
+		if !alloc.RescheduleEligible(policy, now) {
+			s.logger.Printf("[DEBUG] sched: %#v: not rescheduling failed alloc '%s', no attempts left", s.eval, alloc.ID)
+			continue
+		}
+
+		rescheduleTime := alloc.FinishTime().Add(alloc.NextRescheduleDelay(policy))
+		if wait := rescheduleTime.Sub(now); wait > 0 {
+			if s.rescheduleWait == 0 || wait < s.rescheduleWait {
+				s.rescheduleWait = wait
+			}
+			continue
+		}
+
+		tuple.Reschedule = true
+		place = append(place, tuple)
+	}
+	diff.place = place
+}
+
+// computeMigrations applies the migrate strategy of the task groups to the
+// allocations migrated off draining nodes. At most MaxParallel allocations of
+// a task group are being migrated at once: an allocation is being migrated
+// until its replacement is running or the health deadline of the replacement
+// passed. Once the drain deadline of a node is reached, its allocations are
+// migrated regardless. Task groups without a migrate strategy and
+// allocations of down nodes are migrated right away.
+func (s *GenericScheduler) computeMigrations(diff *diffResult, tainted map[string]*structs.Node,
+	allocs []*structs.Allocation, now time.Time) {
+	if s.batch || len(diff.migrate) == 0 {
+		return
+	}
+
+	var wait time.Duration
+	setWait := func(d time.Duration) {
+		if d > 0 && (wait == 0 || d < wait) {
+			wait = d
+		}
+	}
+
+	migrating := make(map[string]int)
+	deferred := false
+	migrate := make([]allocTuple, 0, len(diff.migrate))
+	for _, tuple := range diff.migrate {
+		node := tainted[tuple.Alloc.NodeID]
+		strategy := tuple.TaskGroup.Migrate
+		if strategy == nil || node == nil || !node.Drain ||
+			structs.ShouldDrainNode(node.Status) || node.DrainStrategy.DeadlineReached(now) {
+			migrate = append(migrate, tuple)
+			continue
+		}
+
+		// Continue the migrations at the deadline of the drain
+		if node.DrainStrategy != nil && !node.DrainStrategy.ForceDeadline.IsZero() {
+			setWait(node.DrainStrategy.ForceDeadline.Sub(now))
+		}
+
+		name := tuple.TaskGroup.Name
+		if _, ok := migrating[name]; !ok {
+			n, next := migratingAllocs(name, strategy, allocs, now)
+			migrating[name] = n
+			setWait(next)
+		}
+		if migrating[name] >= strategy.MaxParallel {
+			deferred = true
+			continue
+		}
+		migrating[name]++
+		migrate = append(migrate, tuple)
+	}
+	diff.migrate = migrate
+
+	if deferred {
+		s.migrateWait = wait
+	}
+}
+
+// migratingAllocs returns the number of allocations of the task group that
+// are being migrated and the time until the earliest health deadline of their
+// replacements passes.
+func migratingAllocs(tg string, strategy *structs.MigrateStrategy, allocs []*structs.Allocation,
+	now time.Time) (int, time.Duration) {
+	byID := make(map[string]*structs.Allocation, len(allocs))
+	for _, alloc := range allocs {
+		byID[alloc.ID] = alloc
+	}
+
+	n := 0
+	var next time.Duration
+	for _, alloc := range allocs {
+		if alloc.TaskGroup != tg || alloc.TerminalStatus() ||
+			alloc.ClientStatus != structs.AllocClientStatusPending {
+			continue
+		}
+		prev, ok := byID[alloc.PreviousAllocation]
+		if !ok || prev.DesiredDescription != allocMigrating {
+			continue
+		}
+
+		deadline := time.Unix(0, alloc.CreateTime).Add(strategy.Deadline())
+		remaining := deadline.Sub(now)
+		if remaining <= 0 {
+			continue
+		}
+		n++
+		if next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+	return n, next
+}
+
 // computePlacements computes placements for allocations
 func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 	// Get the base nodes
@@ -439,6 +820,12 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 	// Update the set of placement ndoes
 	s.stack.SetNodes(nodes)
 
+	// Track the quota of the job as allocations are placed
+	quota, err := newQuotaTracker(s.state, s.plan, s.job)
+	if err != nil {
+		return err
+	}
+
 	for _, missing := range place {
 		// Check if this task group has already failed
 		if metric, ok := s.failedTGAllocs[missing.TaskGroup.Name]; ok {
@@ -471,6 +858,7 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 				EvalID:        s.eval.ID,
 				Name:          missing.Name,
 				JobID:         s.job.ID,
+				Namespace:     s.job.Namespace,
 				TaskGroup:     missing.TaskGroup.Name,
 				Metrics:       s.ctx.Metrics(),
 				NodeID:        option.Node.ID,
@@ -489,8 +877,33 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 				alloc.PreviousAllocation = missing.Alloc.ID
 			}
 
-			s.plan.AppendAlloc(alloc)
-		} else {
+			// Allocations placed during a deployment are tracked by it
+			if s.deployment != nil {
+				alloc.DeploymentID = s.deployment.ID
+			}
+			if missing.Canary {
+				alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+			}
+
+			// Track the attempts to reschedule a failed allocation
+			if missing.Reschedule {
+				alloc.RescheduleTracker = missing.Alloc.NextRescheduleTracker(
+					missing.TaskGroup.ReschedulePolicy, time.Now().UTC())
+			}
+
+			// Fail the placement if it exceeds the quota of the job
+			if fit, dimension := quota.Place(alloc); !fit {
+				s.ctx.Metrics().ExhaustedNode(option.Node, dimension)
+				option = nil
+			} else {
+				// Evict the allocations preempted to make room
+				appendPreemptions(s.plan, alloc, option.PreemptedAllocs)
+
+				s.plan.AppendAlloc(alloc)
+			}
+		}
+
+		if option == nil {
 			// Lazy initialize the failed map
 			if s.failedTGAllocs == nil {
 				s.failedTGAllocs = make(map[string]*structs.AllocMetric)
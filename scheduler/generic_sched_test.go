@@ -26,6 +26,7 @@ func TestServiceSched_JobRegister(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -67,7 +68,7 @@ func TestServiceSched_JobRegister(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -107,6 +108,7 @@ func TestServiceSched_JobRegister_StickyAllocs(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -135,6 +137,7 @@ func TestServiceSched_JobRegister_StickyAllocs(t *testing.T) {
 
 	// Create a mock evaluation to handle the update
 	eval = &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -177,6 +180,7 @@ func TestServiceSched_JobRegister_DiskConstraints(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -215,7 +219,7 @@ func TestServiceSched_JobRegister_DiskConstraints(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure only one allocation was placed
@@ -241,6 +245,7 @@ func TestServiceSched_JobRegister_Annotate(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:    structs.DefaultNamespace,
 		ID:           structs.GenerateUUID(),
 		Priority:     job.Priority,
 		TriggeredBy:  structs.EvalTriggerJobRegister,
@@ -270,7 +275,7 @@ func TestServiceSched_JobRegister_Annotate(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -317,6 +322,7 @@ func TestServiceSched_JobRegister_CountZero(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -335,7 +341,7 @@ func TestServiceSched_JobRegister_CountZero(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure no allocations placed
@@ -356,6 +362,7 @@ func TestServiceSched_JobRegister_AllocFail(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -416,6 +423,151 @@ func TestServiceSched_JobRegister_AllocFail(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestServiceSched_JobRegister_Quota(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Create a quota allowing only four of the job's allocations
+	quota := &structs.QuotaSpec{
+		Name:  "engineering",
+		Limit: &structs.QuotaLimit{CPU: 2000},
+	}
+	noErr(t, h.State.UpsertQuotaSpec(h.NextIndex(), quota))
+
+	// Create a job of a namespace using the quota
+	ns := &structs.Namespace{Name: "engineering", Quota: "engineering"}
+	noErr(t, h.State.UpsertNamespaces(h.NextIndex(), []*structs.Namespace{ns}))
+	job := mock.Job()
+	job.Namespace = "engineering"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:   job.Namespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure only the allocations within the quota are planned
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	var planned []*structs.Allocation
+	for _, allocList := range h.Plans[0].NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 4 {
+		t.Fatalf("bad: %#v", planned)
+	}
+
+	// Ensure the remaining placements failed on the quota
+	outEval := h.Evals[0]
+	metrics, ok := outEval.FailedTGAllocs[job.TaskGroups[0].Name]
+	if !ok {
+		t.Fatalf("no failed metrics: %#v", outEval.FailedTGAllocs)
+	}
+	if metrics.CoalescedFailures != 5 {
+		t.Fatalf("bad: %#v", metrics)
+	}
+	if metrics.DimensionExhausted["quota cpu exhausted"] != 1 {
+		t.Fatalf("bad: %#v", metrics.DimensionExhausted)
+	}
+
+	// Ensure there is a follow up eval
+	if len(h.CreateEvals) != 1 || h.CreateEvals[0].Status != structs.EvalStatusBlocked {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobRegister_Preemption(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node filled by an allocation of a low priority job
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	lowJob := mock.Job()
+	lowJob.Priority = 20
+	noErr(t, h.State.UpsertJob(h.NextIndex(), lowJob))
+
+	low := mock.Alloc()
+	low.Job = lowJob
+	low.JobID = lowJob.ID
+	low.NodeID = node.ID
+	low.Resources = &structs.Resources{
+		CPU:      3900,
+		MemoryMB: 7936,
+	}
+	low.TaskResources = map[string]*structs.Resources{"web": low.Resources}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{low}))
+
+	// Create a higher priority job
+	job := mock.Job()
+	job.Priority = 80
+	job.TaskGroups[0].Count = 1
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Preemption is disabled for the service scheduler by default
+	noErr(t, h.Process(NewServiceScheduler, eval))
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+
+	// Enable preemption and process the evaluation again
+	config := &structs.SchedulerConfiguration{
+		PreemptionConfig: structs.PreemptionConfig{ServiceSchedulerEnabled: true},
+	}
+	noErr(t, h.State.SchedulerSetConfig(h.NextIndex(), config))
+	noErr(t, h.Process(NewServiceScheduler, eval))
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan allocated and preempted the low priority allocation
+	planned := plan.NodeAllocation[node.ID]
+	if len(planned) != 1 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	update := plan.NodeUpdate[node.ID]
+	if len(update) != 1 || update[0].ID != low.ID ||
+		update[0].PreemptedByAllocation != planned[0].ID {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the allocation of the low priority job is evicted
+	out, err := h.State.AllocByID(low.ID)
+	noErr(t, err)
+	if out.DesiredStatus != structs.AllocDesiredStatusEvict {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestServiceSched_JobRegister_CreateBlockedEval(t *testing.T) {
 	h := NewHarness(t)
 
@@ -437,6 +589,7 @@ func TestServiceSched_JobRegister_CreateBlockedEval(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -533,6 +686,7 @@ func TestServiceSched_JobRegister_FeasibleAndInfeasibleTG(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -561,7 +715,7 @@ func TestServiceSched_JobRegister_FeasibleAndInfeasibleTG(t *testing.T) {
 	}
 
 	// Ensure two allocations placed
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 	if len(out) != 2 {
 		t.Fatalf("bad: %#v", out)
@@ -607,6 +761,7 @@ func TestServiceSched_EvaluateMaxPlanEval(t *testing.T) {
 
 	// Create a mock blocked evaluation
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Status:      structs.EvalStatusBlocked,
 		Priority:    job.Priority,
@@ -647,6 +802,7 @@ func TestServiceSched_Plan_Partial_Progress(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -680,7 +836,7 @@ func TestServiceSched_Plan_Partial_Progress(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure only one allocations placed
@@ -705,6 +861,7 @@ func TestServiceSched_EvaluateBlockedEval(t *testing.T) {
 
 	// Create a mock blocked evaluation
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Status:      structs.EvalStatusBlocked,
 		Priority:    job.Priority,
@@ -755,6 +912,7 @@ func TestServiceSched_EvaluateBlockedEval_Finished(t *testing.T) {
 
 	// Create a mock blocked evaluation
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Status:      structs.EvalStatusBlocked,
 		Priority:    job.Priority,
@@ -800,7 +958,7 @@ func TestServiceSched_EvaluateBlockedEval_Finished(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -871,6 +1029,7 @@ func TestServiceSched_JobModify(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -908,7 +1067,7 @@ func TestServiceSched_JobModify(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -953,6 +1112,7 @@ func TestServiceSched_JobModify_IncrCount_NodeLimit(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -999,7 +1159,7 @@ func TestServiceSched_JobModify_IncrCount_NodeLimit(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -1058,6 +1218,7 @@ func TestServiceSched_JobModify_CountZero(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1095,7 +1256,7 @@ func TestServiceSched_JobModify_CountZero(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -1147,6 +1308,7 @@ func TestServiceSched_JobModify_Rolling(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1208,7 +1370,7 @@ func TestServiceSched_JobModify_Rolling(t *testing.T) {
 	}
 }
 
-func TestServiceSched_JobModify_InPlace(t *testing.T) {
+func TestServiceSched_JobModify_HealthGated(t *testing.T) {
 	h := NewHarness(t)
 
 	// Create some nodes
@@ -1234,13 +1396,21 @@ func TestServiceSched_JobModify_InPlace(t *testing.T) {
 	}
 	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
 
-	// Update the job
+	// Update the job with a health gated update strategy
 	job2 := mock.Job()
 	job2.ID = job.ID
+	job2.Update = structs.UpdateStrategy{
+		MaxParallel: 3,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+		AutoRevert:  true,
+	}
+
+	// Update the task, such that it cannot be done in-place
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
 	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
 
-	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1259,124 +1429,100 @@ func TestServiceSched_JobModify_InPlace(t *testing.T) {
 	}
 	plan := h.Plans[0]
 
-	// Ensure the plan did not evict any allocs
+	// Ensure the plan created a deployment for all the updates
+	d := plan.Deployment
+	if d == nil {
+		t.Fatalf("expected a deployment: %#v", plan)
+	}
+	state := d.TaskGroups["web"]
+	if state == nil || state.DesiredTotal != 10 || !state.AutoRevert {
+		t.Fatalf("bad: %#v", state)
+	}
+
+	// Ensure the plan evicted and placed only MaxParallel
 	var update []*structs.Allocation
 	for _, updateList := range plan.NodeUpdate {
 		update = append(update, updateList...)
 	}
-	if len(update) != 0 {
+	if len(update) != job2.Update.MaxParallel {
 		t.Fatalf("bad: %#v", plan)
 	}
-
-	// Ensure the plan updated the existing allocs
 	var planned []*structs.Allocation
 	for _, allocList := range plan.NodeAllocation {
 		planned = append(planned, allocList...)
 	}
-	if len(planned) != 10 {
+	if len(planned) != job2.Update.MaxParallel {
 		t.Fatalf("bad: %#v", plan)
 	}
-	for _, p := range planned {
-		if p.Job != job2 {
-			t.Fatalf("should update job")
+	for _, alloc := range planned {
+		if alloc.DeploymentID != d.ID {
+			t.Fatalf("bad: %#v", alloc)
 		}
 	}
 
-	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
-	noErr(t, err)
-
-	// Ensure all allocations placed
-	if len(out) != 10 {
-		for _, alloc := range out {
-			t.Logf("%#v", alloc)
-		}
-		t.Fatalf("bad: %#v", out)
-	}
+	// The deployment continues the rollout instead of a follow up eval
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
-
-	// Verify the network did not change
-	rp := structs.Port{Label: "main", Value: 5000}
-	for _, alloc := range out {
-		for _, resources := range alloc.TaskResources {
-			if resources.Networks[0].ReservedPorts[0] != rp {
-				t.Fatalf("bad: %#v", alloc)
-			}
-		}
+	if h.Evals[0].NextEval != "" || len(h.CreateEvals) != 0 {
+		t.Fatalf("unexpected follow up eval: %#v", h.CreateEvals)
 	}
-}
 
-func TestServiceSched_JobDeregister(t *testing.T) {
-	h := NewHarness(t)
-
-	// Generate a fake job with allocations
-	job := mock.Job()
-
-	var allocs []*structs.Allocation
-	for i := 0; i < 10; i++ {
-		alloc := mock.Alloc()
-		alloc.Job = job
-		alloc.JobID = job.ID
-		allocs = append(allocs, alloc)
-	}
-	for _, alloc := range allocs {
-		h.State.UpsertJobSummary(h.NextIndex(), mock.JobSummary(alloc.JobID))
+	// The deployment tracks the placed allocations
+	out, err := h.State.LatestDeploymentByJobID(job.Namespace, job.ID)
+	noErr(t, err)
+	if out == nil || out.ID != d.ID || out.TaskGroups["web"].PlacedAllocs != job2.Update.MaxParallel {
+		t.Fatalf("bad: %#v", out)
 	}
-	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
 
-	// Create a mock evaluation to deregister the job
-	eval := &structs.Evaluation{
+	// Nothing is updated while the placed allocations are not healthy
+	h2 := NewHarnessWithState(t, h.State)
+	eval2 := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
-		TriggeredBy: structs.EvalTriggerJobDeregister,
+		TriggeredBy: structs.EvalTriggerDeploymentWatcher,
 		JobID:       job.ID,
 	}
-
-	// Process the evaluation
-	err := h.Process(NewServiceScheduler, eval)
-	if err != nil {
+	if err := h2.Process(NewServiceScheduler, eval2); err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	// Ensure a single plan
-	if len(h.Plans) != 1 {
-		t.Fatalf("bad: %#v", h.Plans)
-	}
-	plan := h.Plans[0]
-
-	// Ensure the plan evicted all nodes
-	if len(plan.NodeUpdate["12345678-abcd-efab-cdef-123456789abc"]) != len(allocs) {
-		t.Fatalf("bad: %#v", plan)
+	if len(h2.Plans) != 0 {
+		t.Fatalf("bad: %#v", h2.Plans)
 	}
 
-	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
-	noErr(t, err)
+	// Once a placed allocation is healthy the next one is updated
+	isHealthy := true
+	healthy := planned[0].Copy()
+	healthy.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &isHealthy}
+	noErr(t, h.State.UpdateAllocsFromClient(h.NextIndex(), []*structs.Allocation{healthy}))
 
-	// Ensure that the job field on the allocation is still populated
-	for _, alloc := range out {
-		if alloc.Job == nil {
-			t.Fatalf("bad: %#v", alloc)
-		}
+	h3 := NewHarnessWithState(t, h.State)
+	if err := h3.Process(NewServiceScheduler, eval2); err != nil {
+		t.Fatalf("err: %v", err)
 	}
-
-	// Ensure no remaining allocations
-	out, _ = structs.FilterTerminalAllocs(out)
-	if len(out) != 0 {
-		t.Fatalf("bad: %#v", out)
+	if len(h3.Plans) != 1 {
+		t.Fatalf("bad: %#v", h3.Plans)
+	}
+	planned = nil
+	for _, allocList := range h3.Plans[0].NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 1 || planned[0].DeploymentID != d.ID || h3.Plans[0].Deployment != nil {
+		t.Fatalf("bad: %#v", h3.Plans[0])
 	}
-
-	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
-func TestServiceSched_NodeDown(t *testing.T) {
+func TestServiceSched_JobModify_Canary(t *testing.T) {
 	h := NewHarness(t)
 
-	// Register a node
-	node := mock.Node()
-	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
 
-	// Generate a fake job with allocations and an update policy.
+	// Generate a fake job with allocations
 	job := mock.Job()
 	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
 
@@ -1385,43 +1531,33 @@ func TestServiceSched_NodeDown(t *testing.T) {
 		alloc := mock.Alloc()
 		alloc.Job = job
 		alloc.JobID = job.ID
-		alloc.NodeID = node.ID
+		alloc.NodeID = nodes[i].ID
 		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
 		allocs = append(allocs, alloc)
 	}
-
-	// Cover each terminal case and ensure it doesn't change to lost
-	allocs[7].DesiredStatus = structs.AllocDesiredStatusRun
-	allocs[7].ClientStatus = structs.AllocClientStatusLost
-	allocs[8].DesiredStatus = structs.AllocDesiredStatusRun
-	allocs[8].ClientStatus = structs.AllocClientStatusFailed
-	allocs[9].DesiredStatus = structs.AllocDesiredStatusRun
-	allocs[9].ClientStatus = structs.AllocClientStatusComplete
-
 	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
 
-	// Mark some allocs as running
-	for i := 0; i < 4; i++ {
-		out, _ := h.State.AllocByID(allocs[i].ID)
-		out.ClientStatus = structs.AllocClientStatusRunning
-		noErr(t, h.State.UpdateAllocsFromClient(h.NextIndex(), []*structs.Allocation{out}))
+	// Update the job with canaries, such that it cannot be done in-place
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = structs.UpdateStrategy{
+		MaxParallel: 3,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+		Canary:      2,
 	}
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
 
-	// Mark the node as down
-	noErr(t, h.State.UpdateNodeStatus(h.NextIndex(), node.ID, structs.NodeStatusDown))
-
-	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
-		TriggeredBy: structs.EvalTriggerNodeUpdate,
+		TriggeredBy: structs.EvalTriggerJobRegister,
 		JobID:       job.ID,
-		NodeID:      node.ID,
 	}
 
 	// Process the evaluation
-	err := h.Process(NewServiceScheduler, eval)
-	if err != nil {
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
@@ -1431,26 +1567,810 @@ func TestServiceSched_NodeDown(t *testing.T) {
 	}
 	plan := h.Plans[0]
 
-	// Test the scheduler marked all non-terminal allocations as lost
-	if len(plan.NodeUpdate[node.ID]) != 7 {
+	// Ensure the deployment requires promotion
+	d := plan.Deployment
+	if d == nil || !d.RequiresPromotion() {
 		t.Fatalf("bad: %#v", plan)
 	}
+	if state := d.TaskGroups["web"]; state.DesiredTotal != 10 || state.DesiredCanaries != 2 {
+		t.Fatalf("bad: %#v", state)
+	}
 
-	for _, out := range plan.NodeUpdate[node.ID] {
-		if out.ClientStatus != structs.AllocClientStatusLost && out.DesiredStatus != structs.AllocDesiredStatusStop {
-			t.Fatalf("bad alloc: %#v", out)
+	// Ensure only the canaries were placed alongside the existing allocations
+	if len(plan.NodeUpdate) != 0 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	var canaries []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		canaries = append(canaries, allocList...)
+	}
+	if len(canaries) != 2 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	for _, alloc := range canaries {
+		if alloc.DeploymentID != d.ID || !alloc.DeploymentStatus.IsCanary() || alloc.PreviousAllocation != "" {
+			t.Fatalf("bad: %#v", alloc)
 		}
 	}
 
-	h.AssertEvalStatus(t, structs.EvalStatusComplete)
-}
+	out, err := h.State.DeploymentByID(d.ID)
+	noErr(t, err)
+	if out.TaskGroups["web"].PlacedCanaries != 2 {
+		t.Fatalf("bad: %#v", out.TaskGroups["web"])
+	}
 
-func TestServiceSched_NodeUpdate(t *testing.T) {
-	h := NewHarness(t)
+	// Nothing is updated while the deployment is not promoted, even once
+	// the canaries are healthy
+	isHealthy := true
+	var updates []*structs.Allocation
+	for _, alloc := range canaries {
+		healthy := alloc.Copy()
+		healthy.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &isHealthy}
+		updates = append(updates, healthy)
+	}
+	noErr(t, h.State.UpdateAllocsFromClient(h.NextIndex(), updates))
 
-	// Register a node
-	node := mock.Node()
-	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	h2 := NewHarnessWithState(t, h.State)
+	eval2 := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerDeploymentWatcher,
+		JobID:       job.ID,
+	}
+	if err := h2.Process(NewServiceScheduler, eval2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(h2.Plans) != 0 {
+		t.Fatalf("bad: %#v", h2.Plans)
+	}
+
+	// The client keeps the canary flag when reporting health
+	out2, err := h.State.AllocByID(canaries[0].ID)
+	noErr(t, err)
+	if !out2.DeploymentStatus.IsCanary() || !out2.DeploymentStatus.IsHealthy() {
+		t.Fatalf("bad: %#v", out2.DeploymentStatus)
+	}
+
+	// Once promoted, the replaced allocations are stopped and the rollout
+	// proceeds
+	noErr(t, h.State.UpdateDeploymentPromotion(h.NextIndex(), &structs.DeploymentPromoteRequest{DeploymentID: d.ID}))
+
+	h3 := NewHarnessWithState(t, h.State)
+	if err := h3.Process(NewServiceScheduler, eval2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(h3.Plans) != 1 {
+		t.Fatalf("bad: %#v", h3.Plans)
+	}
+	var stopped []*structs.Allocation
+	for _, updateList := range h3.Plans[0].NodeUpdate {
+		stopped = append(stopped, updateList...)
+	}
+	if len(stopped) != 2+job2.Update.MaxParallel {
+		t.Fatalf("bad: %#v", h3.Plans[0])
+	}
+	var planned []*structs.Allocation
+	for _, allocList := range h3.Plans[0].NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != job2.Update.MaxParallel {
+		t.Fatalf("bad: %#v", h3.Plans[0])
+	}
+	for _, alloc := range planned {
+		if alloc.DeploymentStatus.IsCanary() {
+			t.Fatalf("bad: %#v", alloc)
+		}
+	}
+}
+
+func TestServiceSched_JobModify_Canaries_PromoteGroup(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with two task groups and allocations
+	job := mock.Job()
+	job.TaskGroups[0].Count = 3
+	db := job.TaskGroups[0].Copy()
+	db.Name = "db"
+	job.TaskGroups = append(job.TaskGroups, db)
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for _, tg := range job.TaskGroups {
+		for i := 0; i < 3; i++ {
+			alloc := mock.Alloc()
+			alloc.Job = job
+			alloc.JobID = job.ID
+			alloc.NodeID = nodes[len(allocs)].ID
+			alloc.TaskGroup = tg.Name
+			alloc.Name = fmt.Sprintf("my-job.%s[%d]", tg.Name, i)
+			allocs = append(allocs, alloc)
+		}
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update both task groups with a canary
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.TaskGroups[0].Count = 3
+	db2 := job2.TaskGroups[0].Copy()
+	db2.Name = "db"
+	db2.Tasks[0].Config = map[string]interface{}{"command": "/bin/other"}
+	job2.TaskGroups = append(job2.TaskGroups, db2)
+	job2.Update = structs.UpdateStrategy{
+		MaxParallel: 1,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+		Canary:      1,
+	}
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a canary is placed for each task group
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+	var canaries []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		canaries = append(canaries, allocList...)
+	}
+	if len(canaries) != 2 || len(plan.NodeUpdate) != 0 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Mark the canaries healthy and only promote the web task group
+	isHealthy := true
+	var updates []*structs.Allocation
+	for _, alloc := range canaries {
+		healthy := alloc.Copy()
+		healthy.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &isHealthy, Canary: true}
+		updates = append(updates, healthy)
+	}
+	noErr(t, h.State.UpdateAllocsFromClient(h.NextIndex(), updates))
+	noErr(t, h.State.UpdateDeploymentPromotion(h.NextIndex(), &structs.DeploymentPromoteRequest{
+		DeploymentID: plan.Deployment.ID,
+		Groups:       []string{"web"},
+	}))
+
+	h2 := NewHarnessWithState(t, h.State)
+	eval2 := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerDeploymentWatcher,
+		JobID:       job.ID,
+	}
+	if err := h2.Process(NewServiceScheduler, eval2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(h2.Plans) != 1 {
+		t.Fatalf("bad: %#v", h2.Plans)
+	}
+
+	// Only the web task group rolls out: the allocation replaced by its
+	// canary and the next batch are stopped, the db task group is untouched
+	for _, updateList := range h2.Plans[0].NodeUpdate {
+		for _, alloc := range updateList {
+			if alloc.TaskGroup != "web" {
+				t.Fatalf("bad: %#v", alloc)
+			}
+		}
+	}
+	for _, allocList := range h2.Plans[0].NodeAllocation {
+		for _, alloc := range allocList {
+			if alloc.TaskGroup != "web" || alloc.DeploymentStatus.IsCanary() {
+				t.Fatalf("bad: %#v", alloc)
+			}
+		}
+	}
+	var stopped []*structs.Allocation
+	for _, updateList := range h2.Plans[0].NodeUpdate {
+		stopped = append(stopped, updateList...)
+	}
+	if len(stopped) != 1+job2.Update.MaxParallel {
+		t.Fatalf("bad: %#v", h2.Plans[0])
+	}
+}
+
+func TestServiceSched_JobModify_BlueGreen(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	job.TaskGroups[0].Count = 5
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 5; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job with as many canaries as allocations
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.TaskGroups[0].Count = 5
+	job2.Update = structs.UpdateStrategy{
+		MaxParallel: 1,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+		Canary:      5,
+	}
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the full new set is placed alongside the old one
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+	if len(plan.NodeUpdate) != 0 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	var green []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		green = append(green, allocList...)
+	}
+	if len(green) != 5 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Once promoted, the old set is torn down without further placements
+	noErr(t, h.State.UpdateDeploymentPromotion(h.NextIndex(), &structs.DeploymentPromoteRequest{DeploymentID: plan.Deployment.ID}))
+
+	h2 := NewHarnessWithState(t, h.State)
+	eval2 := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerDeploymentWatcher,
+		JobID:       job.ID,
+	}
+	if err := h2.Process(NewServiceScheduler, eval2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(h2.Plans) != 1 {
+		t.Fatalf("bad: %#v", h2.Plans)
+	}
+	var stopped []*structs.Allocation
+	for _, updateList := range h2.Plans[0].NodeUpdate {
+		stopped = append(stopped, updateList...)
+	}
+	if len(stopped) != 5 || len(h2.Plans[0].NodeAllocation) != 0 {
+		t.Fatalf("bad: %#v", h2.Plans[0])
+	}
+	blue := make(map[string]struct{}, len(allocs))
+	for _, alloc := range allocs {
+		blue[alloc.ID] = struct{}{}
+	}
+	for _, alloc := range stopped {
+		if _, ok := blue[alloc.ID]; !ok {
+			t.Fatalf("bad: %#v", alloc)
+		}
+	}
+}
+
+func TestServiceSched_JobModify_HealthGated_Failed(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 5; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	job.TaskGroups[0].Count = 5
+	job.Update = structs.UpdateStrategy{
+		MaxParallel: 2,
+		HealthCheck: structs.UpdateHealthCheckTaskStates,
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 5; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job, such that it cannot be done in-place
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.TaskGroups[0].Count = 5
+	job2.Update = job.Update
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+	job2, err := h.State.JobByID(job.Namespace, job.ID)
+	noErr(t, err)
+
+	// Mark the deployment of the new version as failed
+	d := structs.NewDeployment(job2)
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 5}
+	d.Status = structs.DeploymentStatusFailed
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d))
+
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure no allocation was updated
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobModify_CancelDeployment(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Generate a fake job with a running deployment
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+	d := structs.NewDeployment(job)
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 10}
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d))
+
+	// Register a new version of the job
+	job2 := job.Copy()
+	job2.TaskGroups[0].Count = 1
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the deployment of the older version was cancelled
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+	if len(plan.DeploymentUpdates) != 1 || plan.Deployment != nil {
+		t.Fatalf("bad: %#v", plan)
+	}
+	update := plan.DeploymentUpdates[0]
+	if update.DeploymentID != d.ID || update.Status != structs.DeploymentStatusCancelled {
+		t.Fatalf("bad: %#v", update)
+	}
+
+	out, err := h.State.DeploymentByID(d.ID)
+	noErr(t, err)
+	if out.Status != structs.DeploymentStatusCancelled {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestServiceSched_JobModify_InPlace(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job
+	job2 := mock.Job()
+	job2.ID = job.ID
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a mock evaluation to deal with drain
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan did not evict any allocs
+	var update []*structs.Allocation
+	for _, updateList := range plan.NodeUpdate {
+		update = append(update, updateList...)
+	}
+	if len(update) != 0 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the plan updated the existing allocs
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 10 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	for _, p := range planned {
+		if p.Job != job2 {
+			t.Fatalf("should update job")
+		}
+	}
+
+	// Lookup the allocations by JobID
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
+	noErr(t, err)
+
+	// Ensure all allocations placed
+	if len(out) != 10 {
+		for _, alloc := range out {
+			t.Logf("%#v", alloc)
+		}
+		t.Fatalf("bad: %#v", out)
+	}
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+
+	// Verify the network did not change
+	rp := structs.Port{Label: "main", Value: 5000}
+	for _, alloc := range out {
+		for _, resources := range alloc.TaskResources {
+			if resources.Networks[0].ReservedPorts[0] != rp {
+				t.Fatalf("bad: %#v", alloc)
+			}
+		}
+	}
+}
+
+func TestServiceSched_JobDeregister(t *testing.T) {
+	h := NewHarness(t)
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		allocs = append(allocs, alloc)
+	}
+	for _, alloc := range allocs {
+		h.State.UpsertJobSummary(h.NextIndex(), mock.JobSummary(alloc.JobID))
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Create a mock evaluation to deregister the job
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobDeregister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan evicted all nodes
+	if len(plan.NodeUpdate["12345678-abcd-efab-cdef-123456789abc"]) != len(allocs) {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Lookup the allocations by JobID
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
+	noErr(t, err)
+
+	// Ensure that the job field on the allocation is still populated
+	for _, alloc := range out {
+		if alloc.Job == nil {
+			t.Fatalf("bad: %#v", alloc)
+		}
+	}
+
+	// Ensure no remaining allocations
+	out, _ = structs.FilterTerminalAllocs(out)
+	if len(out) != 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobFailedOver(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a job that was moved to its failover region
+	job := mock.Job()
+	job.Failover = &structs.FailoverConfig{
+		Region: "region2",
+		After:  10 * time.Minute,
+	}
+	job.FailoverTo = "region2"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure nothing was placed nor blocked
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	if len(h.CreateEvals) != 0 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobDeregister_RollingStop(t *testing.T) {
+	h := NewHarness(t)
+
+	// Generate a fake job with allocations and a rolling stop strategy
+	job := mock.Job()
+	job.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 5,
+	}
+	job.StopStrategy = structs.JobStopStrategyRolling
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		allocs = append(allocs, alloc)
+	}
+	for _, alloc := range allocs {
+		h.State.UpsertJobSummary(h.NextIndex(), mock.JobSummary(alloc.JobID))
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Create a mock evaluation to deregister the job
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobDeregister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan only evicted MaxParallel allocations
+	if len(plan.NodeUpdate["12345678-abcd-efab-cdef-123456789abc"]) != job.Update.MaxParallel {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure a follow up eval was created
+	eval = h.Evals[0]
+	if eval.NextEval == "" {
+		t.Fatalf("missing next eval")
+	}
+
+	// Check for create
+	if len(h.CreateEvals) == 0 {
+		t.Fatalf("missing created eval")
+	}
+	create := h.CreateEvals[0]
+	if eval.NextEval != create.ID {
+		t.Fatalf("ID mismatch")
+	}
+	if create.PreviousEval != eval.ID {
+		t.Fatalf("missing previous eval")
+	}
+	if create.TriggeredBy != structs.EvalTriggerRollingUpdate {
+		t.Fatalf("bad: %#v", create)
+	}
+	if create.Wait != job.Update.Stagger {
+		t.Fatalf("bad: %#v", create)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_NodeDown(t *testing.T) {
+	h := NewHarness(t)
+
+	// Register a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Generate a fake job with allocations and an update policy.
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = node.ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+
+	// Cover each terminal case and ensure it doesn't change to lost
+	allocs[7].DesiredStatus = structs.AllocDesiredStatusRun
+	allocs[7].ClientStatus = structs.AllocClientStatusLost
+	allocs[8].DesiredStatus = structs.AllocDesiredStatusRun
+	allocs[8].ClientStatus = structs.AllocClientStatusFailed
+	allocs[9].DesiredStatus = structs.AllocDesiredStatusRun
+	allocs[9].ClientStatus = structs.AllocClientStatusComplete
+
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Mark some allocs as running
+	for i := 0; i < 4; i++ {
+		out, _ := h.State.AllocByID(allocs[i].ID)
+		out.ClientStatus = structs.AllocClientStatusRunning
+		noErr(t, h.State.UpdateAllocsFromClient(h.NextIndex(), []*structs.Allocation{out}))
+	}
+
+	// Mark the node as down
+	noErr(t, h.State.UpdateNodeStatus(h.NextIndex(), node.ID, structs.NodeStatusDown))
+
+	// Create a mock evaluation to deal with drain
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerNodeUpdate,
+		JobID:       job.ID,
+		NodeID:      node.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Test the scheduler marked all non-terminal allocations as lost
+	if len(plan.NodeUpdate[node.ID]) != 7 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	for _, out := range plan.NodeUpdate[node.ID] {
+		if out.ClientStatus != structs.AllocClientStatusLost && out.DesiredStatus != structs.AllocDesiredStatusStop {
+			t.Fatalf("bad alloc: %#v", out)
+		}
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_NodeUpdate(t *testing.T) {
+	h := NewHarness(t)
+
+	// Register a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
 
 	// Generate a fake job with allocations and an update policy.
 	job := mock.Job()
@@ -1476,6 +2396,7 @@ func TestServiceSched_NodeUpdate(t *testing.T) {
 
 	// Create a mock evaluation which won't trigger any new placements
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -1526,6 +2447,7 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -1560,7 +2482,7 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -1572,6 +2494,130 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+// testMigrateThrottled processes a drain evaluation of a job whose task group
+// migrates at most two allocations at once and that already has one
+// allocation being migrated. It returns the harness and the draining node.
+func testMigrateThrottled(t *testing.T, deadline time.Time) (*Harness, *structs.Node) {
+	h := NewHarness(t)
+
+	// Register a draining node
+	node := mock.Node()
+	node.Drain = true
+	node.DrainStrategy = &structs.DrainStrategy{
+		Deadline:      time.Hour,
+		ForceDeadline: deadline,
+	}
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with a migrate strategy
+	job := mock.Job()
+	job.TaskGroups[0].Count = 6
+	job.TaskGroups[0].Migrate = &structs.MigrateStrategy{MaxParallel: 2}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 5; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = node.ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+
+	// Add an allocation that is being migrated: its replacement is pending
+	migrated := mock.Alloc()
+	migrated.Job = job
+	migrated.JobID = job.ID
+	migrated.NodeID = node.ID
+	migrated.Name = "my-job.web[5]"
+	migrated.DesiredStatus = structs.AllocDesiredStatusStop
+	migrated.DesiredDescription = allocMigrating
+	migrated.ClientStatus = structs.AllocClientStatusComplete
+	replacement := mock.Alloc()
+	replacement.Job = job
+	replacement.JobID = job.ID
+	replacement.NodeID = nodes[0].ID
+	replacement.Name = migrated.Name
+	replacement.PreviousAllocation = migrated.ID
+	replacement.ClientStatus = structs.AllocClientStatusPending
+	replacement.CreateTime = time.Now().UnixNano()
+	allocs = append(allocs, migrated, replacement)
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Create a mock evaluation to deal with drain
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerNodeDrain,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+	return h, node
+}
+
+func TestServiceSched_NodeDrain_MigrateThrottled(t *testing.T) {
+	h, node := testMigrateThrottled(t, time.Now().Add(time.Hour))
+	plan := h.Plans[0]
+
+	// Ensure a single allocation is migrated as the other one is in flight
+	if len(plan.NodeUpdate[node.ID]) != 1 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 1 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the migrations continue once the replacement is due healthy
+	if len(h.CreateEvals) != 1 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+	next := h.CreateEvals[0]
+	if next.TriggeredBy != structs.EvalTriggerNodeDrain {
+		t.Fatalf("bad: %#v", next)
+	}
+	if next.Wait <= 0 || next.Wait > structs.DefaultMigrateHealthDeadline {
+		t.Fatalf("bad wait: %v", next.Wait)
+	}
+}
+
+func TestServiceSched_NodeDrain_MigrateDeadline(t *testing.T) {
+	h, node := testMigrateThrottled(t, time.Now().Add(-time.Minute))
+	plan := h.Plans[0]
+
+	// Ensure all the allocations are migrated once the deadline is reached
+	if len(plan.NodeUpdate[node.ID]) != 5 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	if len(h.CreateEvals) != 0 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+}
+
 func TestServiceSched_NodeDrain_Down(t *testing.T) {
 	h := NewHarness(t)
 
@@ -1625,6 +2671,7 @@ func TestServiceSched_NodeDrain_Down(t *testing.T) {
 
 	// Create a mock evaluation to deal with the node update
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -1698,6 +2745,7 @@ func TestServiceSched_NodeDrain_Queued_Allocations(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -1753,6 +2801,7 @@ func TestServiceSched_NodeDrain_UpdateStrategy(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -1811,6 +2860,7 @@ func TestServiceSched_RetryLimit(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1829,7 +2879,7 @@ func TestServiceSched_RetryLimit(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure no allocations placed
@@ -1864,6 +2914,7 @@ func TestBatchSched_Run_CompleteAlloc(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1882,7 +2933,7 @@ func TestBatchSched_Run_CompleteAlloc(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure no allocations placed
@@ -1917,6 +2968,7 @@ func TestBatchSched_Run_DrainedAlloc(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1935,7 +2987,7 @@ func TestBatchSched_Run_DrainedAlloc(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure a replacement alloc was placed.
@@ -1969,6 +3021,7 @@ func TestBatchSched_Run_FailedAlloc(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1987,7 +3040,7 @@ func TestBatchSched_Run_FailedAlloc(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure a replacement alloc was placed.
@@ -2005,6 +3058,219 @@ func TestBatchSched_Run_FailedAlloc(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestServiceSched_Reschedule_Delayed(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a job rescheduling failed allocs after an hour
+	job := mock.Job()
+	job.TaskGroups[0].Count = 1
+	job.TaskGroups[0].ReschedulePolicy = &structs.ReschedulePolicy{
+		Delay:         time.Hour,
+		DelayFunction: structs.ReschedulePolicyDelayConstant,
+		Unlimited:     true,
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create an alloc that just failed
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	alloc.Name = "my-job.web[0]"
+	alloc.ClientStatus = structs.AllocClientStatusFailed
+	alloc.TaskStates = map[string]*structs.TaskState{
+		"web": &structs.TaskState{
+			State: structs.TaskStateDead,
+			Events: []*structs.TaskEvent{
+				structs.NewTaskEvent(structs.TaskTerminated).SetExitCode(1),
+			},
+		},
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
+
+	// Create a mock evaluation
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerNodeUpdate,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure no plan as the replacement is delayed
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+
+	// Ensure an eval was created to reschedule once the delay elapsed
+	if len(h.CreateEvals) != 1 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+	next := h.CreateEvals[0]
+	if next.TriggeredBy != structs.EvalTriggerRetryFailedAlloc {
+		t.Fatalf("bad: %#v", next)
+	}
+	if next.Wait <= 59*time.Minute || next.Wait > time.Hour {
+		t.Fatalf("bad wait: %v", next.Wait)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_Reschedule_Tracked(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a job rescheduling failed allocs with an exponential delay
+	job := mock.Job()
+	job.TaskGroups[0].Count = 1
+	job.TaskGroups[0].ReschedulePolicy = &structs.ReschedulePolicy{
+		Delay:         time.Minute,
+		DelayFunction: structs.ReschedulePolicyDelayExponential,
+		MaxDelay:      time.Hour,
+		Unlimited:     true,
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create an alloc that was rescheduled once and failed long ago
+	failed := time.Now().Add(-2 * time.Hour)
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	alloc.Name = "my-job.web[0]"
+	alloc.ClientStatus = structs.AllocClientStatusFailed
+	alloc.CreateTime = failed.UnixNano()
+	alloc.RescheduleTracker = &structs.RescheduleTracker{
+		Events: []*structs.RescheduleEvent{
+			{
+				RescheduleTime: failed.Add(-time.Hour).UnixNano(),
+				PrevAllocID:    structs.GenerateUUID(),
+				PrevNodeID:     node.ID,
+				Delay:          time.Minute,
+			},
+		},
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
+
+	// Create a mock evaluation
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerRetryFailedAlloc,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	if len(h.CreateEvals) != 0 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+
+	// Ensure the replacement tracks the attempt with a doubled delay
+	planned := h.Plans[0].NodeAllocation[node.ID]
+	if len(planned) != 1 {
+		t.Fatalf("bad: %#v", h.Plans[0])
+	}
+	tracker := planned[0].RescheduleTracker
+	if tracker == nil || len(tracker.Events) != 1 {
+		t.Fatalf("bad tracker: %#v", tracker)
+	}
+	event := tracker.Events[0]
+	if event.PrevAllocID != alloc.ID || event.PrevNodeID != node.ID || event.Delay != 2*time.Minute {
+		t.Fatalf("bad event: %#v", event)
+	}
+	if planned[0].PreviousAllocation != alloc.ID {
+		t.Fatalf("bad previous alloc: %v", planned[0].PreviousAllocation)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestBatchSched_Reschedule_Exhausted(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a job rescheduling failed allocs once a day
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	job.TaskGroups[0].Count = 1
+	job.TaskGroups[0].ReschedulePolicy = &structs.ReschedulePolicy{
+		Attempts:      1,
+		Interval:      24 * time.Hour,
+		DelayFunction: structs.ReschedulePolicyDelayConstant,
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a failed alloc that was already rescheduled an hour ago
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	alloc.Name = "my-job.web[0]"
+	alloc.ClientStatus = structs.AllocClientStatusFailed
+	alloc.RescheduleTracker = &structs.RescheduleTracker{
+		Events: []*structs.RescheduleEvent{
+			{
+				RescheduleTime: time.Now().Add(-time.Hour).UnixNano(),
+				PrevAllocID:    structs.GenerateUUID(),
+				PrevNodeID:     node.ID,
+			},
+		},
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
+
+	// Create a mock evaluation
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerNodeUpdate,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewBatchScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the failed alloc is not replaced
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	if len(h.CreateEvals) != 0 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestBatchSched_Run_FailedAllocQueuedAllocations(t *testing.T) {
 	h := NewHarness(t)
 
@@ -2028,6 +3294,7 @@ func TestBatchSched_Run_FailedAllocQueuedAllocations(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -2087,6 +3354,7 @@ func TestBatchSched_ReRun_SuccessfullyFinishedAlloc(t *testing.T) {
 
 	// Create a mock evaluation to rerun the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -2105,7 +3373,7 @@ func TestBatchSched_ReRun_SuccessfullyFinishedAlloc(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure no replacement alloc was placed.
@@ -2228,6 +3496,7 @@ func TestGenericSched_ChainedAlloc(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -2256,6 +3525,7 @@ func TestGenericSched_ChainedAlloc(t *testing.T) {
 
 	// Create a mock evaluation to update the job
 	eval1 := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job1.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
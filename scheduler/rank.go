@@ -2,10 +2,22 @@ package scheduler
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
+const (
+	// preemptionPriorityDelta is the minimum difference between the priority
+	// of the job being placed and the priority of the jobs whose allocations
+	// may be preempted to make room for it.
+	preemptionPriorityDelta = 10
+
+	// preemptionPenalty is the penalty applied to the score of a node for
+	// each allocation preempted on it.
+	preemptionPenalty = 10.0
+)
+
 // Rank is used to provide a score and various ranking metadata
 // along with a node when iterating. This state can be modified as
 // various rank methods are applied.
@@ -17,6 +29,10 @@ type RankedNode struct {
 	// Allocs is used to cache the proposed allocations on the
 	// node. This can be shared between iterators that require it.
 	Proposed []*structs.Allocation
+
+	// PreemptedAllocs is the set of allocations of lower priority jobs
+	// to evict to place the task group on the node.
+	PreemptedAllocs []*structs.Allocation
 }
 
 func (r *RankedNode) GoString() string {
@@ -158,8 +174,11 @@ func (iter *BinPackIterator) SetTaskGroup(taskGroup *structs.TaskGroup) {
 	iter.taskGroup = taskGroup
 }
 
+func (iter *BinPackIterator) SetEvict(evict bool) {
+	iter.evict = evict
+}
+
 func (iter *BinPackIterator) Next() *RankedNode {
-OUTER:
 	for {
 		// Get the next potential option
 		option := iter.source.Next()
@@ -176,71 +195,174 @@ OUTER:
 			continue
 		}
 
-		// Index the existing network usage
-		netIdx := structs.NewNetworkIndex()
-		netIdx.SetNode(option.Node)
-		netIdx.AddAllocs(proposed)
-
-		// Assign the resources for each task
-		total := &structs.Resources{
-			DiskMB: iter.taskGroup.EphemeralDisk.SizeMB,
+		// Check if the task group fits, if it does not try to make room by
+		// evicting lower priority allocations or simply skip this node
+		fit, dim, util := iter.fit(option, proposed)
+		if !fit && iter.evict {
+			option.PreemptedAllocs, util = iter.preempt(option, proposed)
+			fit = option.PreemptedAllocs != nil
 		}
-		for _, task := range iter.taskGroup.Tasks {
-			taskResources := task.Resources.Copy()
-
-			// Check if we need a network resource
-			if len(taskResources.Networks) > 0 {
-				ask := taskResources.Networks[0]
-				offer, err := netIdx.AssignNetwork(ask)
-				if offer == nil {
-					iter.ctx.Metrics().ExhaustedNode(option.Node,
-						fmt.Sprintf("network: %s", err))
-					netIdx.Release()
-					continue OUTER
-				}
-
-				// Reserve this to prevent another task from colliding
-				netIdx.AddReserved(offer)
-
-				// Update the network ask to the offer
-				taskResources.Networks = []*structs.NetworkResource{offer}
-			}
-
-			// Store the task resource
-			option.SetTaskResources(task, taskResources)
-
-			// Accumulate the total resource requirement
-			total.Add(taskResources)
-		}
-
-		// Add the resources we are trying to fit
-		proposed = append(proposed, &structs.Allocation{Resources: total})
-
-		// Check if these allocations fit, if they do not, simply skip this node
-		fit, dim, util, _ := structs.AllocsFit(option.Node, proposed, netIdx)
-		netIdx.Release()
 		if !fit {
 			iter.ctx.Metrics().ExhaustedNode(option.Node, dim)
 			continue
 		}
 
-		// XXX: For now we completely ignore evictions. We should use that flag
-		// to determine if its possible to evict other lower priority allocations
-		// to make room. This explodes the search space, so it must be done
-		// carefully.
-
 		// Score the fit normally otherwise
 		fitness := structs.ScoreFit(option.Node, util)
 		option.Score += fitness
 		iter.ctx.Metrics().ScoreNode(option.Node, "binpack", fitness)
+
+		// Prefer the nodes on which fewer allocations are preempted
+		if n := len(option.PreemptedAllocs); n > 0 {
+			scorePenalty := -1 * float64(n) * preemptionPenalty
+			option.Score += scorePenalty
+			iter.ctx.Metrics().ScoreNode(option.Node, "preemption", scorePenalty)
+		}
 		return option
 	}
 }
 
+// fit assigns the resources of the task group's tasks on the option and checks
+// if they fit along with the proposed allocations. If they do not, the
+// exhausted dimension is returned, otherwise the utilization of the node.
+func (iter *BinPackIterator) fit(option *RankedNode, proposed []*structs.Allocation) (bool, string, *structs.Resources) {
+	// Index the existing network usage
+	netIdx := structs.NewNetworkIndex()
+	netIdx.SetNode(option.Node)
+	netIdx.AddAllocs(proposed)
+	defer netIdx.Release()
+
+	// Find the GPUs that aren't assigned yet
+	freeGPUs := structs.FreeGPUDevices(option.Node, proposed)
+
+	// Assign the resources for each task
+	total := &structs.Resources{
+		DiskMB: iter.taskGroup.EphemeralDisk.SizeMB,
+	}
+	for _, task := range iter.taskGroup.Tasks {
+		taskResources := task.ResourcesForNodeClass(option.Node.NodeClass).Copy()
+
+		// Check if we need a network resource
+		if len(taskResources.Networks) > 0 {
+			ask := taskResources.Networks[0]
+			offer, err := netIdx.AssignNetwork(ask)
+			if offer == nil {
+				return false, fmt.Sprintf("network: %s", err), nil
+			}
+
+			// Reserve this to prevent another task from colliding
+			netIdx.AddReserved(offer)
+
+			// Update the network ask to the offer
+			taskResources.Networks = []*structs.NetworkResource{offer}
+		}
+
+		// Assign the GPUs to the task
+		if taskResources.GPU > 0 {
+			if len(freeGPUs) < taskResources.GPU {
+				return false, "gpu exhausted", nil
+			}
+			taskResources.GPUDevices = make([]int, taskResources.GPU)
+			copy(taskResources.GPUDevices, freeGPUs)
+			freeGPUs = freeGPUs[taskResources.GPU:]
+		}
+
+		// Store the task resource
+		option.SetTaskResources(task, taskResources)
+
+		// Accumulate the total resource requirement
+		total.Add(taskResources)
+	}
+
+	// Add the resources we are trying to fit
+	proposed = append(proposed, &structs.Allocation{Resources: total})
+
+	// Check if these allocations fit
+	fit, dim, util, _ := structs.AllocsFit(option.Node, proposed, netIdx)
+	return fit, dim, util
+}
+
+// preempt returns the allocations of lower priority jobs to evict from the
+// node to make room for the task group, along with the resulting utilization
+// of the node. The allocations of the lowest priority jobs are evicted first
+// and no more allocations than needed are evicted. Nil is returned if the
+// task group does not fit even after evicting all the candidates.
+func (iter *BinPackIterator) preempt(option *RankedNode, proposed []*structs.Allocation) ([]*structs.Allocation, *structs.Resources) {
+	// Collect the allocations that can be preempted
+	var candidates preemptionCandidates
+	for _, alloc := range proposed {
+		if alloc.Job != nil && iter.priority-alloc.Job.Priority >= preemptionPriorityDelta {
+			candidates = append(candidates, alloc)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.Sort(candidates)
+
+	// Evict candidates until the task group fits
+	evicted := make(map[string]struct{}, len(candidates))
+	remaining := func() []*structs.Allocation {
+		out := make([]*structs.Allocation, 0, len(proposed))
+		for _, alloc := range proposed {
+			if _, ok := evicted[alloc.ID]; !ok {
+				out = append(out, alloc)
+			}
+		}
+		return out
+	}
+	fits := false
+	for _, alloc := range candidates {
+		evicted[alloc.ID] = struct{}{}
+		if fits, _, _ = iter.fit(option, remaining()); fits {
+			break
+		}
+	}
+	if !fits {
+		return nil, nil
+	}
+
+	// Keep the evicted allocations that are not needed for the fit
+	var preempted []*structs.Allocation
+	for _, alloc := range candidates {
+		if _, ok := evicted[alloc.ID]; !ok {
+			continue
+		}
+		delete(evicted, alloc.ID)
+		if fit, _, _ := iter.fit(option, remaining()); !fit {
+			evicted[alloc.ID] = struct{}{}
+			preempted = append(preempted, alloc)
+		}
+	}
+
+	// Assign the resources given the final set of evictions
+	_, _, util := iter.fit(option, remaining())
+	return preempted, util
+}
+
 func (iter *BinPackIterator) Reset() {
 	iter.source.Reset()
 }
 
+// preemptionCandidates sorts the allocations that may be preempted by
+// ascending job priority, preferring to preempt the newest allocations.
+type preemptionCandidates []*structs.Allocation
+
+func (p preemptionCandidates) Len() int {
+	return len(p)
+}
+
+func (p preemptionCandidates) Less(i, j int) bool {
+	if pi, pj := p[i].Job.Priority, p[j].Job.Priority; pi != pj {
+		return pi < pj
+	}
+	return p[i].CreateIndex > p[j].CreateIndex
+}
+
+func (p preemptionCandidates) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
 // JobAntiAffinityIterator is used to apply an anti-affinity to allocating
 // along side other allocations from this job. This is used to help distribute
 // load across the cluster.
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/nomad/nomad/mock"
@@ -175,6 +176,92 @@ func TestBinPackIterator_PlannedAlloc(t *testing.T) {
 	}
 }
 
+func TestBinPackIterator_GPU(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				// No GPUs
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+				},
+			},
+		},
+		&RankedNode{
+			Node: &structs.Node{
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:        2048,
+					MemoryMB:   2048,
+					GPU:        2,
+					GPUDevices: []int{0, 1},
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	// Add a planned alloc to node2 that uses its first GPU
+	plan := ctx.Plan()
+	plan.NodeAllocation[nodes[1].Node.ID] = []*structs.Allocation{
+		&structs.Allocation{
+			TaskResources: map[string]*structs.Resources{
+				"train": &structs.Resources{
+					CPU:        512,
+					MemoryMB:   512,
+					GPU:        1,
+					GPUDevices: []int{0},
+				},
+			},
+		},
+	}
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      512,
+					MemoryMB: 512,
+					GPU:      1,
+				},
+			},
+		},
+	}
+
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+
+	out := collectRanked(binp)
+	if len(out) != 1 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if out[0] != nodes[1] {
+		t.Fatalf("Bad: %v", out)
+	}
+	if devices := out[0].TaskResources["web"].GPUDevices; !reflect.DeepEqual(devices, []int{1}) {
+		t.Fatalf("got GPU devices %v; want [1]", devices)
+	}
+
+	// Two tasks asking for a GPU don't fit
+	taskGroup.Tasks = append(taskGroup.Tasks, &structs.Task{
+		Name: "sidecar",
+		Resources: &structs.Resources{
+			CPU:      512,
+			MemoryMB: 512,
+			GPU:      1,
+		},
+	})
+	static.Reset()
+	binp.SetTaskGroup(taskGroup)
+	if out := collectRanked(binp); len(out) != 0 {
+		t.Fatalf("Bad: %#v", out)
+	}
+}
+
 func TestBinPackIterator_ExistingAlloc(t *testing.T) {
 	state, ctx := testContext(t)
 	nodes := []*RankedNode{
@@ -351,6 +438,90 @@ func TestBinPackIterator_ExistingAlloc_PlannedEvict(t *testing.T) {
 	}
 }
 
+func TestBinPackIterator_Preemption(t *testing.T) {
+	state, ctx := testContext(t)
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	// Fill the node with allocations of jobs of various priorities
+	var allocs []*structs.Allocation
+	for _, c := range []struct {
+		priority int
+		size     int
+	}{{20, 512}, {30, 1024}, {70, 512}} {
+		job := mock.Job()
+		job.Priority = c.priority
+		allocs = append(allocs, &structs.Allocation{
+			ID:     structs.GenerateUUID(),
+			EvalID: structs.GenerateUUID(),
+			NodeID: nodes[0].Node.ID,
+			JobID:  job.ID,
+			Job:    job,
+			Resources: &structs.Resources{
+				CPU:      c.size,
+				MemoryMB: c.size,
+			},
+			DesiredStatus: structs.AllocDesiredStatusRun,
+			ClientStatus:  structs.AllocClientStatusPending,
+			TaskGroup:     "web",
+		})
+		noErr(t, state.UpsertJobSummary(998, mock.JobSummary(job.ID)))
+	}
+	noErr(t, state.UpsertAllocs(1000, allocs))
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+
+	// Without eviction the node is exhausted
+	binp := NewBinPackIterator(ctx, static, false, 70)
+	binp.SetTaskGroup(taskGroup)
+	if out := collectRanked(binp); len(out) != 0 {
+		t.Fatalf("Bad: %#v", out)
+	}
+
+	// Only the allocation needed to make room is preempted
+	static.Reset()
+	binp.SetEvict(true)
+	out := collectRanked(binp)
+	if len(out) != 1 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	preempted := out[0].PreemptedAllocs
+	if len(preempted) != 1 || preempted[0].ID != allocs[1].ID {
+		t.Fatalf("Bad: %#v", preempted)
+	}
+	if out[0].Score != 18-preemptionPenalty {
+		t.Fatalf("Bad: %v", out[0])
+	}
+
+	// Allocations of jobs whose priority is not low enough are not preempted
+	static.Reset()
+	binp.SetPriority(35)
+	if out := collectRanked(binp); len(out) != 0 {
+		t.Fatalf("Bad: %#v", out)
+	}
+}
+
 func TestJobAntiAffinity_PlannedAlloc(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*RankedNode{
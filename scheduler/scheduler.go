@@ -57,8 +57,8 @@ type State interface {
 	// The type of each result is *structs.Node
 	Nodes() (memdb.ResultIterator, error)
 
-	// AllocsByJob returns the allocations by JobID
-	AllocsByJob(jobID string) ([]*structs.Allocation, error)
+	// AllocsByJob returns the allocations by JobID in the given namespace
+	AllocsByJob(namespace, jobID string) ([]*structs.Allocation, error)
 
 	// AllocsByNode returns all the allocations by node
 	AllocsByNode(node string) ([]*structs.Allocation, error)
@@ -69,8 +69,24 @@ type State interface {
 	// GetNodeByID is used to lookup a node by ID
 	NodeByID(nodeID string) (*structs.Node, error)
 
-	// GetJobByID is used to lookup a job by ID
-	JobByID(id string) (*structs.Job, error)
+	// GetJobByID is used to lookup a job by ID in the given namespace
+	JobByID(namespace, id string) (*structs.Job, error)
+
+	// LatestDeploymentByJobID returns the latest deployment of the job
+	LatestDeploymentByJobID(namespace, jobID string) (*structs.Deployment, error)
+
+	// SchedulerConfig returns the scheduler configuration or nil if unset
+	SchedulerConfig() (*structs.SchedulerConfiguration, error)
+
+	// NamespaceByName is used to lookup a namespace by its name
+	NamespaceByName(name string) (*structs.Namespace, error)
+
+	// QuotaSpecByName is used to lookup a quota specification by its name
+	QuotaSpecByName(name string) (*structs.QuotaSpec, error)
+
+	// QuotaUsageAfterPlan returns the resources used by the allocations of
+	// the jobs of the namespaces using the quota once the plan is applied
+	QuotaUsageAfterPlan(name string, plan *structs.Plan) (*structs.Resources, error)
 }
 
 // Planner interface is used to submit a task allocation plan.
@@ -43,6 +43,7 @@ type GenericStack struct {
 	jobConstraint       *ConstraintChecker
 	taskGroupDrivers    *DriverChecker
 	taskGroupConstraint *ConstraintChecker
+	taskGroupVolumes    *HostVolumeChecker
 
 	proposedAllocConstraint *ProposedAllocConstraintIterator
 	binPack                 *BinPackIterator
@@ -73,12 +74,15 @@ func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	// Filter on task group constraints second
 	s.taskGroupConstraint = NewConstraintChecker(ctx, nil)
 
+	// Filter on the host volumes requested by the task group
+	s.taskGroupVolumes = NewHostVolumeChecker(ctx)
+
 	// Create the feasibility wrapper which wraps all feasibility checks in
 	// which feasibility checking can be skipped if the computed node class has
 	// previously been marked as eligible or ineligible. Generally this will be
 	// checks that only needs to examine the single node to determine feasibility.
 	jobs := []FeasibilityChecker{s.jobConstraint}
-	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint}
+	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint, s.taskGroupVolumes}
 	s.wrappedChecks = NewFeasibilityWrapper(ctx, s.source, jobs, tgs)
 
 	// Filter on constraints that are affected by propsed allocations.
@@ -88,10 +92,9 @@ func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	rankSource := NewFeasibleRankIterator(ctx, s.proposedAllocConstraint)
 
 	// Apply the bin packing, this depends on the resources needed
-	// by a particular task group. Only enable eviction for the service
-	// scheduler as that logic is expensive.
-	evict := !batch
-	s.binPack = NewBinPackIterator(ctx, rankSource, evict, 0)
+	// by a particular task group. Eviction is enabled when setting the job
+	// if preemption is configured for its type.
+	s.binPack = NewBinPackIterator(ctx, rankSource, false, 0)
 
 	// Apply the job anti-affinity iterator. This is to avoid placing
 	// multiple allocations on the same node for this job. The penalty
@@ -134,8 +137,10 @@ func (s *GenericStack) SetNodes(baseNodes []*structs.Node) {
 
 func (s *GenericStack) SetJob(job *structs.Job) {
 	s.jobConstraint.SetConstraints(job.Constraints)
+	s.taskGroupDrivers.SetJob(job)
 	s.proposedAllocConstraint.SetJob(job)
 	s.binPack.SetPriority(job.Priority)
+	s.binPack.SetEvict(preemptionEnabled(s.ctx, job.Type))
 	s.jobAntiAff.SetJob(job.ID)
 	s.ctx.Eligibility().SetJob(job)
 }
@@ -152,6 +157,7 @@ func (s *GenericStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Reso
 	// Update the parameters of iterators
 	s.taskGroupDrivers.SetDrivers(tgConstr.drivers)
 	s.taskGroupConstraint.SetConstraints(tgConstr.constraints)
+	s.taskGroupVolumes.SetVolumes(tg.Volumes)
 	s.proposedAllocConstraint.SetTaskGroup(tg)
 	s.wrappedChecks.SetTaskGroup(tg.Name)
 	s.binPack.SetTaskGroup(tg)
@@ -162,7 +168,7 @@ func (s *GenericStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Reso
 	// Ensure that the task resources were specified
 	if option != nil && len(option.TaskResources) != len(tg.Tasks) {
 		for _, task := range tg.Tasks {
-			option.SetTaskResources(task, task.Resources)
+			option.SetTaskResources(task, task.ResourcesForNodeClass(option.Node.NodeClass))
 		}
 	}
 
@@ -193,6 +199,7 @@ type SystemStack struct {
 	jobConstraint       *ConstraintChecker
 	taskGroupDrivers    *DriverChecker
 	taskGroupConstraint *ConstraintChecker
+	taskGroupVolumes    *HostVolumeChecker
 	binPack             *BinPackIterator
 }
 
@@ -214,21 +221,24 @@ func NewSystemStack(ctx Context) *SystemStack {
 	// Filter on task group constraints second
 	s.taskGroupConstraint = NewConstraintChecker(ctx, nil)
 
+	// Filter on the host volumes requested by the task group
+	s.taskGroupVolumes = NewHostVolumeChecker(ctx)
+
 	// Create the feasibility wrapper which wraps all feasibility checks in
 	// which feasibility checking can be skipped if the computed node class has
 	// previously been marked as eligible or ineligible. Generally this will be
 	// checks that only needs to examine the single node to determine feasibility.
 	jobs := []FeasibilityChecker{s.jobConstraint}
-	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint}
+	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint, s.taskGroupVolumes}
 	s.wrappedChecks = NewFeasibilityWrapper(ctx, s.source, jobs, tgs)
 
 	// Upgrade from feasible to rank iterator
 	rankSource := NewFeasibleRankIterator(ctx, s.wrappedChecks)
 
 	// Apply the bin packing, this depends on the resources needed
-	// by a particular task group. Enable eviction as system jobs are high
-	// priority.
-	s.binPack = NewBinPackIterator(ctx, rankSource, true, 0)
+	// by a particular task group. Eviction is enabled when setting the job
+	// if preemption is configured for system jobs.
+	s.binPack = NewBinPackIterator(ctx, rankSource, false, 0)
 	return s
 }
 
@@ -239,7 +249,9 @@ func (s *SystemStack) SetNodes(baseNodes []*structs.Node) {
 
 func (s *SystemStack) SetJob(job *structs.Job) {
 	s.jobConstraint.SetConstraints(job.Constraints)
+	s.taskGroupDrivers.SetJob(job)
 	s.binPack.SetPriority(job.Priority)
+	s.binPack.SetEvict(preemptionEnabled(s.ctx, job.Type))
 	s.ctx.Eligibility().SetJob(job)
 }
 
@@ -255,6 +267,7 @@ func (s *SystemStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Resou
 	// Update the parameters of iterators
 	s.taskGroupDrivers.SetDrivers(tgConstr.drivers)
 	s.taskGroupConstraint.SetConstraints(tgConstr.constraints)
+	s.taskGroupVolumes.SetVolumes(tg.Volumes)
 	s.binPack.SetTaskGroup(tg)
 	s.wrappedChecks.SetTaskGroup(tg.Name)
 
@@ -264,7 +277,7 @@ func (s *SystemStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Resou
 	// Ensure that the task resources were specified
 	if option != nil && len(option.TaskResources) != len(tg.Tasks) {
 		for _, task := range tg.Tasks {
-			option.SetTaskResources(task, task.Resources)
+			option.SetTaskResources(task, task.ResourcesForNodeClass(option.Node.NodeClass))
 		}
 	}
 
@@ -37,6 +37,10 @@ type SystemScheduler struct {
 	limitReached bool
 	nextEval     *structs.Evaluation
 
+	// stoppedJob is the last version of a deregistered job whose allocations
+	// are being stopped. It is used to honor the job's stop strategy.
+	stoppedJob *structs.Job
+
 	failedTGAllocs map[string]*structs.AllocMetric
 	queuedAllocs   map[string]int
 }
@@ -87,11 +91,17 @@ func (s *SystemScheduler) Process(eval *structs.Evaluation) error {
 func (s *SystemScheduler) process() (bool, error) {
 	// Lookup the Job by ID
 	var err error
-	s.job, err = s.state.JobByID(s.eval.JobID)
+	s.job, err = s.state.JobByID(s.eval.Namespace, s.eval.JobID)
 	if err != nil {
 		return false, fmt.Errorf("failed to get job '%s': %v",
 			s.eval.JobID, err)
 	}
+
+	// A job moved to its failover region is stopped in this one
+	if s.job.FailedOver() {
+		s.job = nil
+	}
+
 	numTaskGroups := 0
 	if s.job != nil {
 		numTaskGroups = len(s.job.TaskGroups)
@@ -136,7 +146,11 @@ func (s *SystemScheduler) process() (bool, error) {
 	// If the limit of placements was reached we need to create an evaluation
 	// to pickup from here after the stagger period.
 	if s.limitReached && s.nextEval == nil {
-		s.nextEval = s.eval.NextRollingEval(s.job.Update.Stagger)
+		job := s.job
+		if job == nil {
+			job = s.stoppedJob
+		}
+		s.nextEval = s.eval.NextRollingEval(job.Update.Stagger)
 		if err := s.planner.CreateEval(s.nextEval); err != nil {
 			s.logger.Printf("[ERR] sched: %#v failed to make next eval for rolling update: %v", s.eval, err)
 			return false, err
@@ -178,7 +192,7 @@ func (s *SystemScheduler) process() (bool, error) {
 // existing allocations and node status to update the allocations.
 func (s *SystemScheduler) computeJobAllocs() error {
 	// Lookup the allocations by JobID
-	allocs, err := s.state.AllocsByJob(s.eval.JobID)
+	allocs, err := s.state.AllocsByJob(s.eval.Namespace, s.eval.JobID)
 	if err != nil {
 		return fmt.Errorf("failed to get allocs for job '%s': %v",
 			s.eval.JobID, err)
@@ -191,6 +205,9 @@ func (s *SystemScheduler) computeJobAllocs() error {
 			s.eval.JobID, err)
 	}
 
+	// Nodes drained without their system jobs keep running their allocations
+	drained := untaintSystemDrains(tainted)
+
 	// Update the allocations which are in pending/running state on tainted
 	// nodes to lost
 	updateNonTerminalAllocsToLost(s.plan, tainted, allocs)
@@ -203,7 +220,14 @@ func (s *SystemScheduler) computeJobAllocs() error {
 	s.logger.Printf("[DEBUG] sched: %#v: %#v", s.eval, diff)
 
 	// Add all the allocs to stop
-	for _, e := range diff.stop {
+	// If the job has been deregistered, its stop strategy controls how many
+	// allocations are stopped at once.
+	stop, stopLimitReached := diff.stop, false
+	if s.job == nil {
+		s.stoppedJob = deregisteredJob(diff.stop)
+		stop, stopLimitReached = limitStops(s.stoppedJob, diff.stop)
+	}
+	for _, e := range stop {
 		s.plan.AppendUpdate(e.Alloc, structs.AllocDesiredStatusStop, allocNotNeeded, "")
 	}
 
@@ -215,7 +239,18 @@ func (s *SystemScheduler) computeJobAllocs() error {
 
 	// Attempt to do the upgrades in place
 	destructiveUpdates, inplaceUpdates := inplaceUpdate(s.ctx, s.eval, s.job, s.stack, diff.update)
-	diff.update = destructiveUpdates
+
+	// Nothing can be placed on draining nodes, so their allocations keep
+	// running the current version of the job instead of being replaced.
+	diff.update = destructiveUpdates[:0]
+	for _, tuple := range destructiveUpdates {
+		if _, ok := drained[tuple.Alloc.NodeID]; ok {
+			diff.ignore = append(diff.ignore, tuple)
+			continue
+		}
+		diff.update = append(diff.update, tuple)
+	}
+	destructiveUpdates = diff.update
 
 	if s.eval.AnnotatePlan {
 		s.plan.Annotations = &structs.PlanAnnotations{
@@ -230,7 +265,7 @@ func (s *SystemScheduler) computeJobAllocs() error {
 	}
 
 	// Treat non in-place updates as an eviction and new placement.
-	s.limitReached = evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &limit)
+	s.limitReached = stopLimitReached || evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &limit)
 
 	// Nothing remaining to do if placement is not required
 	if len(diff.place) == 0 {
@@ -260,6 +295,12 @@ func (s *SystemScheduler) computePlacements(place []allocTuple) error {
 
 	nodes := make([]*structs.Node, 1)
 
+	// Track the quota of the job as allocations are placed
+	quota, err := newQuotaTracker(s.state, s.plan, s.job)
+	if err != nil {
+		return err
+	}
+
 	// nodesFiltered holds the number of nodes filtered by the stack due to
 	// constrain mismatches while we are trying to place allocations on node
 	var nodesFiltered int
@@ -313,6 +354,7 @@ func (s *SystemScheduler) computePlacements(place []allocTuple) error {
 				EvalID:        s.eval.ID,
 				Name:          missing.Name,
 				JobID:         s.job.ID,
+				Namespace:     s.job.Namespace,
 				TaskGroup:     missing.TaskGroup.Name,
 				Metrics:       s.ctx.Metrics(),
 				NodeID:        option.Node.ID,
@@ -331,8 +373,19 @@ func (s *SystemScheduler) computePlacements(place []allocTuple) error {
 				alloc.PreviousAllocation = missing.Alloc.ID
 			}
 
-			s.plan.AppendAlloc(alloc)
-		} else {
+			// Fail the placement if it exceeds the quota of the job
+			if fit, dimension := quota.Place(alloc); !fit {
+				s.ctx.Metrics().ExhaustedNode(option.Node, dimension)
+				option = nil
+			} else {
+				// Evict the allocations preempted to make room
+				appendPreemptions(s.plan, alloc, option.PreemptedAllocs)
+
+				s.plan.AppendAlloc(alloc)
+			}
+		}
+
+		if option == nil {
 			// Lazy initialize the failed map
 			if s.failedTGAllocs == nil {
 				s.failedTGAllocs = make(map[string]*structs.AllocMetric)
@@ -25,6 +25,7 @@ func TestSystemSched_JobRegister(t *testing.T) {
 
 	// Create a mock evaluation to deregister the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -58,7 +59,7 @@ func TestSystemSched_JobRegister(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -80,6 +81,71 @@ func TestSystemSched_JobRegister(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestSystemSched_JobRegister_ResourceOverride(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node of the default class and a large one
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	large := mock.Node()
+	large.NodeClass = "large"
+	large.ComputeClass()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), large))
+
+	// Create a job overriding the memory on large nodes
+	job := mock.SystemJob()
+	job.TaskGroups[0].Tasks[0].ResourceOverrides = []*structs.ResourceOverride{
+		&structs.ResourceOverride{
+			NodeClass: "large",
+			MemoryMB:  1024,
+		},
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewSystemScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the memory of the task is overridden on the large node only
+	task := job.TaskGroups[0].Tasks[0]
+	expected := map[string]int{
+		node.ID:  task.Resources.MemoryMB,
+		large.ID: 1024,
+	}
+	for nodeID, memory := range expected {
+		allocs := plan.NodeAllocation[nodeID]
+		if len(allocs) != 1 {
+			t.Fatalf("bad: %#v", plan)
+		}
+		if mem := allocs[0].TaskResources[task.Name].MemoryMB; mem != memory {
+			t.Fatalf("node %q: got memory %d; want %d", nodeID, mem, memory)
+		}
+		if cpu := allocs[0].TaskResources[task.Name].CPU; cpu != task.Resources.CPU {
+			t.Fatalf("node %q: got cpu %d; want %d", nodeID, cpu, task.Resources.CPU)
+		}
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestSystemeSched_JobRegister_StickyAllocs(t *testing.T) {
 	h := NewHarness(t)
 
@@ -96,6 +162,7 @@ func TestSystemeSched_JobRegister_StickyAllocs(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -124,6 +191,7 @@ func TestSystemeSched_JobRegister_StickyAllocs(t *testing.T) {
 
 	// Create a mock evaluation to handle the update
 	eval = &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -170,6 +238,7 @@ func TestSystemSched_JobRegister_EphemeralDiskConstraint(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -182,7 +251,7 @@ func TestSystemSched_JobRegister_EphemeralDiskConstraint(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -194,6 +263,7 @@ func TestSystemSched_JobRegister_EphemeralDiskConstraint(t *testing.T) {
 	h1 := NewHarnessWithState(t, h.State)
 	// Create a mock evaluation to register the job
 	eval1 := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job1.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -205,7 +275,7 @@ func TestSystemSched_JobRegister_EphemeralDiskConstraint(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	out, err = h1.State.AllocsByJob(job1.ID)
+	out, err = h1.State.AllocsByJob(job1.Namespace, job1.ID)
 	noErr(t, err)
 	if len(out) != 0 {
 		t.Fatalf("bad: %#v", out)
@@ -227,6 +297,7 @@ func TestSystemSched_ExhaustResources(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    svcJob.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -239,12 +310,17 @@ func TestSystemSched_ExhaustResources(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
+	// Disable preemption so the service job is not evicted
+	config := &structs.SchedulerConfiguration{}
+	noErr(t, h.State.SchedulerSetConfig(h.NextIndex(), config))
+
 	// Create a system job
 	job := mock.SystemJob()
 	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
 
 	// Create a mock evaluation to register the job
 	eval1 := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -290,6 +366,7 @@ func TestSystemSched_JobRegister_Annotate(t *testing.T) {
 
 	// Create a mock evaluation to deregister the job
 	eval := &structs.Evaluation{
+		Namespace:    structs.DefaultNamespace,
 		ID:           structs.GenerateUUID(),
 		Priority:     job.Priority,
 		TriggeredBy:  structs.EvalTriggerJobRegister,
@@ -319,7 +396,7 @@ func TestSystemSched_JobRegister_Annotate(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -387,6 +464,7 @@ func TestSystemSched_JobRegister_AddNode(t *testing.T) {
 
 	// Create a mock evaluation to deal with the node update
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -430,7 +508,7 @@ func TestSystemSched_JobRegister_AddNode(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -452,6 +530,7 @@ func TestSystemSched_JobRegister_AllocFail(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -472,6 +551,81 @@ func TestSystemSched_JobRegister_AllocFail(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestSystemSched_JobRegister_Preemption(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node filled by an allocation of a low priority job
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	lowJob := mock.Job()
+	lowJob.Priority = 20
+	noErr(t, h.State.UpsertJob(h.NextIndex(), lowJob))
+
+	low := mock.Alloc()
+	low.Job = lowJob
+	low.JobID = lowJob.ID
+	low.NodeID = node.ID
+	low.Resources = &structs.Resources{
+		CPU:      3900,
+		MemoryMB: 7936,
+	}
+	low.TaskResources = map[string]*structs.Resources{"web": low.Resources}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{low}))
+
+	// Create a job
+	job := mock.SystemJob()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:    structs.DefaultNamespace,
+		ID:           structs.GenerateUUID(),
+		Priority:     job.Priority,
+		TriggeredBy:  structs.EvalTriggerJobRegister,
+		JobID:        job.ID,
+		AnnotatePlan: true,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewSystemScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan allocated
+	planned := plan.NodeAllocation[node.ID]
+	if len(planned) != 1 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the low priority allocation was preempted
+	update := plan.NodeUpdate[node.ID]
+	if len(update) != 1 || update[0].ID != low.ID {
+		t.Fatalf("bad: %#v", plan)
+	}
+	if update[0].DesiredStatus != structs.AllocDesiredStatusEvict ||
+		update[0].PreemptedByAllocation != planned[0].ID {
+		t.Fatalf("bad: %#v", update[0])
+	}
+	if p := planned[0].PreemptedAllocations; len(p) != 1 || p[0] != low.ID {
+		t.Fatalf("bad: %#v", planned[0])
+	}
+
+	// Ensure the preemption is annotated
+	if a := plan.Annotations; a == nil || len(a.PreemptedAllocs) != 1 || a.PreemptedAllocs[0].ID != low.ID {
+		t.Fatalf("bad: %#v", plan.Annotations)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestSystemSched_JobModify(t *testing.T) {
 	h := NewHarness(t)
 
@@ -521,6 +675,7 @@ func TestSystemSched_JobModify(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -558,7 +713,7 @@ func TestSystemSched_JobModify(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -610,6 +765,7 @@ func TestSystemSched_JobModify_Rolling(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -704,6 +860,7 @@ func TestSystemSched_JobModify_InPlace(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -746,7 +903,7 @@ func TestSystemSched_JobModify_InPlace(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure all allocations placed
@@ -796,6 +953,7 @@ func TestSystemSched_JobDeregister(t *testing.T) {
 
 	// Create a mock evaluation to deregister the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerJobDeregister,
@@ -822,7 +980,7 @@ func TestSystemSched_JobDeregister(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure no remaining allocations
@@ -855,6 +1013,7 @@ func TestSystemSched_NodeDown(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -919,6 +1078,7 @@ func TestSystemSched_NodeDrain_Down(t *testing.T) {
 
 	// Create a mock evaluation to deal with the node update
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -977,6 +1137,7 @@ func TestSystemSched_NodeDrain(t *testing.T) {
 
 	// Create a mock evaluation to deal with drain
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -1019,6 +1180,50 @@ func TestSystemSched_NodeDrain(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestSystemSched_NodeDrain_IgnoreSystemJobs(t *testing.T) {
+	h := NewHarness(t)
+
+	// Register a node draining everything but system jobs
+	node := mock.Node()
+	node.Drain = true
+	node.DrainStrategy = &structs.DrainStrategy{IgnoreSystemJobs: true}
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Generate a fake job allocated on that node.
+	job := mock.SystemJob()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	alloc.Name = "my-job.web[0]"
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
+
+	// Create a mock evaluation to deal with drain
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerNodeUpdate,
+		JobID:       job.ID,
+		NodeID:      node.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewSystemScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the allocation is left running
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans[0])
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestSystemSched_NodeUpdate(t *testing.T) {
 	h := NewHarness(t)
 
@@ -1039,6 +1244,7 @@ func TestSystemSched_NodeUpdate(t *testing.T) {
 
 	// Create a mock evaluation to deal
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -1076,6 +1282,7 @@ func TestSystemSched_RetryLimit(t *testing.T) {
 
 	// Create a mock evaluation to deregister the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1094,7 +1301,7 @@ func TestSystemSched_RetryLimit(t *testing.T) {
 	}
 
 	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
+	out, err := h.State.AllocsByJob(job.Namespace, job.ID)
 	noErr(t, err)
 
 	// Ensure no allocations placed
@@ -1123,6 +1330,7 @@ func TestSystemSched_Queued_With_Constraints(t *testing.T) {
 
 	// Create a mock evaluation to deal
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
 		TriggeredBy: structs.EvalTriggerNodeUpdate,
@@ -1157,6 +1365,7 @@ func TestSystemSched_ChainedAlloc(t *testing.T) {
 
 	// Create a mock evaluation to register the job
 	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
@@ -1190,6 +1399,7 @@ func TestSystemSched_ChainedAlloc(t *testing.T) {
 
 	// Create a mock evaluation to update the job
 	eval1 := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
 		ID:          structs.GenerateUUID(),
 		Priority:    job1.Priority,
 		TriggeredBy: structs.EvalTriggerJobRegister,
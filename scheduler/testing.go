@@ -96,6 +96,8 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 	result := new(structs.PlanResult)
 	result.NodeUpdate = plan.NodeUpdate
 	result.NodeAllocation = plan.NodeAllocation
+	result.Deployment = plan.Deployment
+	result.DeploymentUpdates = plan.DeploymentUpdates
 	result.AllocIndex = index
 
 	// Flatten evicts and allocs
@@ -119,7 +121,12 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 	}
 
 	// Apply the full plan
-	err := h.State.UpsertAllocs(index, allocs)
+	req := structs.AllocUpdateRequest{
+		Alloc:             allocs,
+		Deployment:        plan.Deployment,
+		DeploymentUpdates: plan.DeploymentUpdates,
+	}
+	err := h.State.UpsertPlanResults(index, &req)
 	return result, nil, err
 }
 
@@ -14,6 +14,14 @@ type allocTuple struct {
 	Name      string
 	TaskGroup *structs.TaskGroup
 	Alloc     *structs.Allocation
+
+	// Canary marks a placement that runs alongside the allocation of the
+	// same name until the deployment is promoted
+	Canary bool
+
+	// Reschedule marks a placement that replaces a failed allocation
+	// according to the reschedule policy of the task group
+	Reschedule bool
 }
 
 // materializeTaskGroups is used to materialize all the task groups
@@ -313,6 +321,21 @@ func taintedNodes(state State, allocs []*structs.Allocation) (map[string]*struct
 	return out, nil
 }
 
+// untaintSystemDrains removes the nodes whose drain leaves the allocations of
+// system jobs running from the tainted nodes and returns their IDs.
+func untaintSystemDrains(tainted map[string]*structs.Node) map[string]struct{} {
+	drained := make(map[string]struct{})
+	for id, node := range tainted {
+		if node == nil || structs.ShouldDrainNode(node.Status) ||
+			node.DrainStrategy == nil || !node.DrainStrategy.IgnoreSystemJobs {
+			continue
+		}
+		delete(tainted, id)
+		drained[id] = struct{}{}
+	}
+	return drained
+}
+
 // shuffleNodes randomizes the slice order with the Fisher-Yates algorithm
 func shuffleNodes(nodes []*structs.Node) {
 	n := len(nodes)
@@ -330,6 +353,11 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 		return true
 	}
 
+	// Changing the volumes requires placing the allocations again
+	if !reflect.DeepEqual(a.Volumes, b.Volumes) {
+		return true
+	}
+
 	// Check each task
 	for _, at := range a.Tasks {
 		bt := b.LookupTask(at.Name)
@@ -354,6 +382,21 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 		if !reflect.DeepEqual(at.Artifacts, bt.Artifacts) {
 			return true
 		}
+		if !reflect.DeepEqual(at.DispatchPayload, bt.DispatchPayload) {
+			return true
+		}
+		if !reflect.DeepEqual(at.Lifecycle, bt.Lifecycle) {
+			return true
+		}
+		if !reflect.DeepEqual(at.Templates, bt.Templates) {
+			return true
+		}
+		if !reflect.DeepEqual(at.EnvFromConsul, bt.EnvFromConsul) {
+			return true
+		}
+		if !reflect.DeepEqual(at.VolumeMounts, bt.VolumeMounts) {
+			return true
+		}
 
 		// Inspect the network to see if the dynamic ports are different
 		if len(at.Resources.Networks) != len(bt.Resources.Networks) {
@@ -382,6 +425,8 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 			return true
 		} else if ar.IOPS != br.IOPS {
 			return true
+		} else if ar.GPU != br.GPU {
+			return true
 		}
 	}
 	return false
@@ -470,18 +515,20 @@ func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
 		// Pop the allocation
 		ctx.Plan().PopUpdate(update.Alloc)
 
-		// Skip if we could not do an in-place update
-		if option == nil {
+		// Skip if we could not do an in-place update without preempting
+		// other allocations
+		if option == nil || option.PreemptedAllocs != nil {
 			continue
 		}
 
-		// Restore the network offers from the existing allocation.
-		// We do not allow network resources (reserved/dynamic ports)
-		// to be updated. This is guarded in taskUpdated, so we can
+		// Restore the network offers and GPUs from the existing allocation.
+		// We do not allow network resources (reserved/dynamic ports) or the
+		// GPUs to be updated. This is guarded in taskUpdated, so we can
 		// safely restore those here.
 		for task, resources := range option.TaskResources {
 			existing := update.Alloc.TaskResources[task]
 			resources.Networks = existing.Networks
+			resources.GPUDevices = existing.GPUDevices
 		}
 
 		// Create a shallow copy
@@ -544,6 +591,36 @@ func markLostAndPlace(ctx Context, diff *diffResult, allocs []allocTuple, desc s
 	return true
 }
 
+// deregisteredJob returns the most recent version of the job that the given
+// allocations belong to. It is used to retrieve the stop strategy of a job
+// that has been deregistered and is no longer in the state store.
+func deregisteredJob(allocs []allocTuple) *structs.Job {
+	var job *structs.Job
+	for _, a := range allocs {
+		if a.Alloc == nil || a.Alloc.Job == nil {
+			continue
+		}
+		if job == nil || a.Alloc.Job.JobModifyIndex > job.JobModifyIndex {
+			job = a.Alloc.Job
+		}
+	}
+	return job
+}
+
+// limitStops applies the stop strategy of a deregistered job to the
+// allocations that need to be stopped. If the job uses a rolling stop
+// strategy, at most MaxParallel allocations are returned. It returns the
+// allocations to stop and true if the limit has been reached.
+func limitStops(job *structs.Job, allocs []allocTuple) ([]allocTuple, bool) {
+	if job == nil || job.StopStrategy != structs.JobStopStrategyRolling || !job.Update.Rolling() {
+		return allocs, false
+	}
+	if len(allocs) <= job.Update.MaxParallel {
+		return allocs, false
+	}
+	return allocs[:job.Update.MaxParallel], true
+}
+
 // tgConstrainTuple is used to store the total constraints of a task group.
 type tgConstrainTuple struct {
 	// Holds the combined constraints of the task group and all it's sub-tasks.
@@ -684,3 +761,83 @@ func updateNonTerminalAllocsToLost(plan *structs.Plan, tainted map[string]*struc
 		}
 	}
 }
+
+// preemptionEnabled returns whether the allocations of lower priority jobs may
+// be preempted to place the jobs of the given type. The default preemption
+// configuration is used if the scheduler configuration is not set.
+func preemptionEnabled(ctx Context, jobType string) bool {
+	config, err := ctx.State().SchedulerConfig()
+	if err != nil {
+		ctx.Logger().Printf("[ERR] sched: failed to get scheduler config: %v", err)
+		return false
+	}
+
+	preemption := structs.DefaultPreemptionConfig()
+	if config != nil {
+		preemption = config.PreemptionConfig
+	}
+	return preemption.Enabled(jobType)
+}
+
+// appendPreemptions marks the allocations preempted to place the allocation
+// for eviction and records them on the allocation. The preempted allocations
+// are added to the plan annotations if they are set.
+func appendPreemptions(plan *structs.Plan, alloc *structs.Allocation, preempted []*structs.Allocation) {
+	for _, stop := range preempted {
+		plan.AppendPreemptedAlloc(stop, alloc.ID)
+		alloc.PreemptedAllocations = append(alloc.PreemptedAllocations, stop.ID)
+		if plan.Annotations != nil {
+			plan.Annotations.PreemptedAllocs = append(plan.Annotations.PreemptedAllocs, stop.Stub())
+		}
+	}
+}
+
+// quotaTracker tracks the resources used by the jobs sharing the quota of the
+// namespace of the job being scheduled as allocations are placed.
+type quotaTracker struct {
+	limit *structs.QuotaLimit
+	used  *structs.Resources
+}
+
+// newQuotaTracker returns a tracker of the quota of the job's namespace,
+// starting from its usage once the plan is applied. A nil tracker is returned
+// if the namespace doesn't use a quota.
+func newQuotaTracker(state State, plan *structs.Plan, job *structs.Job) (*quotaTracker, error) {
+	if job == nil {
+		return nil, nil
+	}
+	namespace, err := state.NamespaceByName(job.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup namespace %q: %v", job.Namespace, err)
+	}
+	if namespace == nil || namespace.Quota == "" {
+		return nil, nil
+	}
+	quota, err := state.QuotaSpecByName(namespace.Quota)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup quota %q: %v", namespace.Quota, err)
+	}
+	if quota == nil || quota.Limit == nil {
+		return nil, nil
+	}
+	used, err := state.QuotaUsageAfterPlan(quota.Name, plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute usage of quota %q: %v", quota.Name, err)
+	}
+	return &quotaTracker{limit: quota.Limit, used: used}, nil
+}
+
+// Place adds the resources of the allocation to the quota usage if they fit
+// within the limit. Otherwise the exhausted dimension is returned.
+func (q *quotaTracker) Place(alloc *structs.Allocation) (bool, string) {
+	if q == nil {
+		return true, ""
+	}
+	used := q.used.Copy()
+	structs.AddQuotaUsage(used, alloc)
+	if fit, dimension := q.limit.Superset(used); !fit {
+		return false, dimension
+	}
+	q.used = used
+	return true, ""
+}
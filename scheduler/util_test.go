@@ -540,6 +540,18 @@ func TestTasksUpdated(t *testing.T) {
 	if !tasksUpdated(j1.TaskGroups[0], j14.TaskGroups[0]) {
 		t.Fatalf("bad")
 	}
+
+	j15 := mock.Job()
+	j15.TaskGroups[0].Tasks[0].Templates = []*structs.Template{
+		{
+			EmbeddedTmpl: "{{ key \"foo\" }}",
+			DestPath:     "local/foo.conf",
+			ChangeMode:   structs.TemplateChangeModeRestart,
+		},
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j15.TaskGroups[0]) {
+		t.Fatalf("bad")
+	}
 }
 
 func TestEvictAndPlace_LimitLessThanAllocs(t *testing.T) {
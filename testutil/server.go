@@ -36,6 +36,7 @@ type TestServerConfig struct {
 	Region            string        `json:"region,omitempty"`
 	DisableCheckpoint bool          `json:"disable_update_check"`
 	LogLevel          string        `json:"log_level,omitempty"`
+	EnableDebug       bool          `json:"enable_debug,omitempty"`
 	Ports             *PortsConfig  `json:"ports,omitempty"`
 	Server            *ServerConfig `json:"server,omitempty"`
 	Client            *ClientConfig `json:"client,omitempty"`
@@ -53,8 +54,9 @@ type PortsConfig struct {
 
 // ServerConfig is used to configure the nomad server.
 type ServerConfig struct {
-	Enabled         bool `json:"enabled"`
-	BootstrapExpect int  `json:"bootstrap_expect"`
+	Enabled         bool   `json:"enabled"`
+	BootstrapExpect int    `json:"bootstrap_expect"`
+	EncryptKey      string `json:"encrypt,omitempty"`
 }
 
 // ClientConfig is used to configure the client
@@ -3,13 +3,40 @@ module CommandHelpers
   def general_options_usage()
     <<EOF
 * `-address=<addr>`: The address of the Nomad server. Overrides the `NOMAD_ADDR`
-  environment variable if set. Defaults to `http://127.0.0.1:4646`.
+  environment variable if set. Defaults to `http://127.0.0.1:4646`. A
+  `unix://` address, such as `unix:///var/run/nomad.sock`, connects to the
+  unix domain socket of a local agent.
 
 * `-region=<region>`: The region of the Nomad server to forward commands to.
   Overrides the `NOMAD_REGION` environment variable if set. Defaults to the
   Agent's local region.
 
+* `-namespace=<namespace>`: The namespace of the jobs, evaluations and
+  allocations to operate on. Overrides the `NOMAD_NAMESPACE` environment
+  variable if set. Defaults to the `default` namespace.
+
 * `-no-color`: Disables colored command output.
+
+* `-ca-cert=<path>`: Path to a PEM encoded CA cert file to use to verify the
+  Nomad server SSL certificate. Overrides the `NOMAD_CACERT` environment
+  variable if set.
+
+* `-ca-path=<path>`: Path to a directory of PEM encoded CA cert files to verify
+  the Nomad server SSL certificate. If both `-ca-cert` and `-ca-path` are
+  specified, `-ca-cert` is used. Overrides the `NOMAD_CAPATH` environment
+  variable if set.
+
+* `-client-cert=<path>`: Path to a PEM encoded client certificate for TLS
+  authentication to the Nomad server. Must also specify `-client-key`. Overrides
+  the `NOMAD_CLIENT_CERT` environment variable if set.
+
+* `-client-key=<path>`: Path to an unencrypted PEM encoded private key matching
+  the client certificate from `-client-cert`. Overrides the `NOMAD_CLIENT_KEY`
+  environment variable if set.
+
+* `-tls-skip-verify`: Do not verify TLS certificate. This is highly not
+  recommended. Verification will be skipped if set. Overrides the
+  `NOMAD_SKIP_VERIFY` environment variable if set.
 EOF
   end
 end